@@ -43,7 +43,8 @@ type NetworkControllers interface {
 type Severity string
 
 const (
-	SeverityError = "error"
+	SeverityError   = "error"
+	SeverityWarning = "warning"
 )
 
 type Reporter struct {
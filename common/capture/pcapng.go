@@ -0,0 +1,156 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package capture contains helpers for recording circuit traffic in pcap-ng format, so it can be inspected
+// with standard tools such as wireshark or tshark.
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	blockTypeSectionHeader  = 0x0A0D0D0A
+	blockTypeInterfaceDesc  = 0x00000001
+	blockTypeEnhancedPacket = 0x00000006
+	byteOrderMagic          = 0x1A2B3C4D
+	optEndOfOpt             = 0
+	optComment              = 1
+	optShbUserAppl          = 4
+	optIfName               = 2
+	optIfTsResol            = 9
+	tsResolNanos            = 9
+)
+
+// LinkTypeUser0 is the first of the link types reserved for private use. Xgress payloads aren't IP packets,
+// so this lets tools show the raw bytes without trying to decode them.
+const LinkTypeUser0 uint16 = 147
+
+// PcapngWriter writes a single pcap-ng section. Interfaces must be added before packets referring to them
+// are written.
+type PcapngWriter struct {
+	w             io.Writer
+	interfaceIds  map[string]uint32
+	nextInterface uint32
+}
+
+// NewPcapngWriter writes the section header to w and returns a writer for the section
+func NewPcapngWriter(w io.Writer, application string) (*PcapngWriter, error) {
+	result := &PcapngWriter{
+		w:            w,
+		interfaceIds: map[string]uint32{},
+	}
+
+	body := &bytes.Buffer{}
+	writeUint32(body, byteOrderMagic)
+	writeUint16(body, 1)                  // major version
+	writeUint16(body, 0)                  // minor version
+	writeUint64(body, 0xFFFFFFFFFFFFFFFF) // section length not specified
+	writeOption(body, optShbUserAppl, []byte(application))
+	writeOption(body, optEndOfOpt, nil)
+
+	if err := result.writeBlock(blockTypeSectionHeader, body.Bytes()); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AddInterface adds an interface description with nanosecond timestamp resolution. If an interface with
+// the given name has already been added, its id is returned.
+func (self *PcapngWriter) AddInterface(name string, linkType uint16, snapLength uint32) (uint32, error) {
+	if id, found := self.interfaceIds[name]; found {
+		return id, nil
+	}
+
+	body := &bytes.Buffer{}
+	writeUint16(body, linkType)
+	writeUint16(body, 0) // reserved
+	writeUint32(body, snapLength)
+	writeOption(body, optIfName, []byte(name))
+	writeOption(body, optIfTsResol, []byte{tsResolNanos})
+	writeOption(body, optEndOfOpt, nil)
+
+	if err := self.writeBlock(blockTypeInterfaceDesc, body.Bytes()); err != nil {
+		return 0, err
+	}
+
+	id := self.nextInterface
+	self.interfaceIds[name] = id
+	self.nextInterface++
+	return id, nil
+}
+
+// WritePacket writes an enhanced packet block. data may be shorter than originalLength if the packet was
+// truncated, or empty if only metadata was captured. If comment is not empty, it's attached to the packet.
+func (self *PcapngWriter) WritePacket(interfaceId uint32, timestamp time.Time, data []byte, originalLength uint32, comment string) error {
+	ts := uint64(timestamp.UnixNano())
+
+	body := &bytes.Buffer{}
+	writeUint32(body, interfaceId)
+	writeUint32(body, uint32(ts>>32))
+	writeUint32(body, uint32(ts))
+	writeUint32(body, uint32(len(data)))
+	writeUint32(body, originalLength)
+	body.Write(data)
+	writePadding(body, len(data))
+	if comment != "" {
+		writeOption(body, optComment, []byte(comment))
+		writeOption(body, optEndOfOpt, nil)
+	}
+
+	return self.writeBlock(blockTypeEnhancedPacket, body.Bytes())
+}
+
+func (self *PcapngWriter) writeBlock(blockType uint32, body []byte) error {
+	totalLength := uint32(12 + len(body))
+
+	block := &bytes.Buffer{}
+	writeUint32(block, blockType)
+	writeUint32(block, totalLength)
+	block.Write(body)
+	writeUint32(block, totalLength)
+
+	_, err := self.w.Write(block.Bytes())
+	return err
+}
+
+func writeOption(buf *bytes.Buffer, code uint16, value []byte) {
+	writeUint16(buf, code)
+	writeUint16(buf, uint16(len(value)))
+	buf.Write(value)
+	writePadding(buf, len(value))
+}
+
+func writePadding(buf *bytes.Buffer, length int) {
+	if pad := (4 - length%4) % 4; pad > 0 {
+		buf.Write(make([]byte, pad))
+	}
+}
+
+func writeUint16(buf *bytes.Buffer, val uint16) {
+	_ = binary.Write(buf, binary.LittleEndian, val)
+}
+
+func writeUint32(buf *bytes.Buffer, val uint32) {
+	_ = binary.Write(buf, binary.LittleEndian, val)
+}
+
+func writeUint64(buf *bytes.Buffer, val uint64) {
+	_ = binary.Write(buf, binary.LittleEndian, val)
+}
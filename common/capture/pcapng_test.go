@@ -0,0 +1,80 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPcapngWriter(t *testing.T) {
+	req := require.New(t)
+
+	buf := &bytes.Buffer{}
+	writer, err := NewPcapngWriter(buf, "test")
+	req.NoError(err)
+
+	routerA, err := writer.AddInterface("router-a", LinkTypeUser0, 65535)
+	req.NoError(err)
+	routerB, err := writer.AddInterface("router-b", LinkTypeUser0, 65535)
+	req.NoError(err)
+	req.Equal(uint32(0), routerA)
+	req.Equal(uint32(1), routerB)
+
+	id, err := writer.AddInterface("router-a", LinkTypeUser0, 65535)
+	req.NoError(err)
+	req.Equal(routerA, id)
+
+	ts := time.Unix(1700000000, 123456789)
+	req.NoError(writer.WritePacket(routerB, ts, []byte("hello"), 10, "seq=1"))
+	req.NoError(writer.WritePacket(routerA, ts, nil, 42, ""))
+
+	data := buf.Bytes()
+	var blockTypes []uint32
+	for len(data) > 0 {
+		req.GreaterOrEqual(len(data), 12)
+		blockType := binary.LittleEndian.Uint32(data)
+		length := binary.LittleEndian.Uint32(data[4:])
+		req.Equal(uint32(0), length%4, "block length must be a multiple of 4")
+		req.GreaterOrEqual(uint32(len(data)), length)
+		req.Equal(length, binary.LittleEndian.Uint32(data[length-4:]), "trailing block length must match")
+
+		if blockType == blockTypeEnhancedPacket && len(blockTypes) == 3 {
+			req.Equal(routerB, binary.LittleEndian.Uint32(data[8:]))
+			tsVal := uint64(binary.LittleEndian.Uint32(data[12:]))<<32 | uint64(binary.LittleEndian.Uint32(data[16:]))
+			req.Equal(uint64(ts.UnixNano()), tsVal)
+			req.Equal(uint32(5), binary.LittleEndian.Uint32(data[20:]))
+			req.Equal(uint32(10), binary.LittleEndian.Uint32(data[24:]))
+			req.Equal([]byte("hello"), data[28:33])
+		}
+
+		blockTypes = append(blockTypes, blockType)
+		data = data[length:]
+	}
+
+	req.Equal([]uint32{
+		blockTypeSectionHeader,
+		blockTypeInterfaceDesc,
+		blockTypeInterfaceDesc,
+		blockTypeEnhancedPacket,
+		blockTypeEnhancedPacket,
+	}, blockTypes)
+}
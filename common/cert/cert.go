@@ -78,11 +78,14 @@ type SerialGenerator interface {
 
 type DefaultSerialGenerator struct{}
 
+// maxSerial limits serials to 128 bits, so they are unique enough to identify a certificate in OCSP requests
+var maxSerial = new(big.Int).Lsh(big.NewInt(1), 128)
+
 func (DefaultSerialGenerator) Generate() *big.Int {
-	//@todo this need to be better, this does not include negative numbers for 20bit values, nor is this managed
-	r, _ := rand.Int(rand.Reader, big.NewInt(524287))
+	r, _ := rand.Int(rand.Reader, maxSerial)
 
-	return r
+	// serials must be positive
+	return r.Add(r, big.NewInt(1))
 }
 
 var _ Signer = &ServerSigner{}
@@ -91,6 +94,9 @@ type ServerSigner struct {
 	caCert          *x509.Certificate
 	caKey           crypto.PrivateKey
 	SerialGenerator SerialGenerator
+
+	// OcspServers, if set, are included in signed certificates as the locations to check revocation status
+	OcspServers []string
 }
 
 func (s *ServerSigner) Cert() *x509.Certificate {
@@ -132,6 +138,7 @@ func (s *ServerSigner) SignCsr(csr *x509.CertificateRequest, opts *SigningOpts)
 		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment,
 		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		IsCA:         false,
+		OCSPServer:   s.OcspServers,
 	}
 
 	if opts != nil {
@@ -153,6 +160,9 @@ type ClientSigner struct {
 	caCert          *x509.Certificate
 	caKey           crypto.PrivateKey
 	SerialGenerator SerialGenerator
+
+	// OcspServers, if set, are included in signed certificates as the locations to check revocation status
+	OcspServers []string
 }
 
 func (s *ClientSigner) Cert() *x509.Certificate {
@@ -195,6 +205,7 @@ func (s *ClientSigner) SignCsr(csr *x509.CertificateRequest, opts *SigningOpts)
 		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment,
 		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 		IsCA:         false,
+		OCSPServer:   s.OcspServers,
 	}
 
 	if opts != nil {
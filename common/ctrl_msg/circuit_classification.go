@@ -0,0 +1,84 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package ctrl_msg
+
+import (
+	"errors"
+
+	"github.com/openziti/channel/v4"
+)
+
+const (
+	CircuitClassificationType = 1072
+
+	CircuitClassificationCircuitIdHeader  = 10
+	CircuitClassificationProtocolHeader   = 11
+	CircuitClassificationServerNameHeader = 12
+	CircuitClassificationHostHeader       = 13
+	CircuitClassificationGrpcMethodHeader = 14
+
+	// CircuitProtocolTls is reported for circuits whose client started with a TLS ClientHello
+	CircuitProtocolTls = "tls"
+	// CircuitProtocolHttp is reported for circuits whose client started with an HTTP/1.x request
+	CircuitProtocolHttp = "http"
+	// CircuitProtocolHttp2 is reported for circuits whose client started with a cleartext HTTP/2 connection preface
+	CircuitProtocolHttp2 = "http2"
+	// CircuitProtocolGrpc is reported for cleartext HTTP/2 circuits whose first request is a gRPC call
+	CircuitProtocolGrpc = "grpc"
+)
+
+// CircuitClassification is sent by an ingress router to report what it recognized in the first bytes a client sent
+// on a circuit. Only the fields relevant to the protocol are set: ServerName for TLS, Host for HTTP and HTTP/2 and
+// GrpcMethod for gRPC.
+type CircuitClassification struct {
+	CircuitId  string
+	Protocol   string
+	ServerName string
+	Host       string
+	GrpcMethod string
+}
+
+func (self *CircuitClassification) ToMessage() *channel.Message {
+	msg := channel.NewMessage(CircuitClassificationType, nil)
+	msg.PutStringHeader(CircuitClassificationCircuitIdHeader, self.CircuitId)
+	msg.PutStringHeader(CircuitClassificationProtocolHeader, self.Protocol)
+	if self.ServerName != "" {
+		msg.PutStringHeader(CircuitClassificationServerNameHeader, self.ServerName)
+	}
+	if self.Host != "" {
+		msg.PutStringHeader(CircuitClassificationHostHeader, self.Host)
+	}
+	if self.GrpcMethod != "" {
+		msg.PutStringHeader(CircuitClassificationGrpcMethodHeader, self.GrpcMethod)
+	}
+	return msg
+}
+
+func DecodeCircuitClassification(m *channel.Message) (*CircuitClassification, error) {
+	result := &CircuitClassification{}
+	result.CircuitId, _ = m.GetStringHeader(CircuitClassificationCircuitIdHeader)
+	result.Protocol, _ = m.GetStringHeader(CircuitClassificationProtocolHeader)
+	result.ServerName, _ = m.GetStringHeader(CircuitClassificationServerNameHeader)
+	result.Host, _ = m.GetStringHeader(CircuitClassificationHostHeader)
+	result.GrpcMethod, _ = m.GetStringHeader(CircuitClassificationGrpcMethodHeader)
+
+	if result.CircuitId == "" || result.Protocol == "" {
+		return nil, errors.New("circuit classification requires a circuit id and protocol")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,72 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package ctrl_msg
+
+import (
+	"errors"
+	"time"
+
+	"github.com/openziti/channel/v4"
+)
+
+const (
+	CircuitLimitExceededType = 1075
+
+	CircuitLimitExceededCircuitIdHeader = 10
+	CircuitLimitExceededLimitHeader     = 11
+	CircuitLimitExceededElapsedHeader   = 12
+
+	// CircuitLimitMaxLifetime is reported when a circuit has been up for longer than its service allows
+	CircuitLimitMaxLifetime = "maxLifetime"
+	// CircuitLimitMaxIdleTime is reported when a circuit has been idle for longer than its service allows
+	CircuitLimitMaxIdleTime = "maxIdleTime"
+)
+
+// CircuitLimitExceeded is sent by an endpoint router when a circuit exceeds the maximum lifetime or idle time set
+// by its service. Elapsed is how long the circuit had been up or idle, depending on the limit.
+type CircuitLimitExceeded struct {
+	CircuitId string
+	Limit     string
+	Elapsed   time.Duration
+}
+
+func (self *CircuitLimitExceeded) ToMessage() *channel.Message {
+	msg := channel.NewMessage(CircuitLimitExceededType, nil)
+	msg.PutStringHeader(CircuitLimitExceededCircuitIdHeader, self.CircuitId)
+	msg.PutStringHeader(CircuitLimitExceededLimitHeader, self.Limit)
+	msg.PutUint64Header(CircuitLimitExceededElapsedHeader, uint64(self.Elapsed))
+	return msg
+}
+
+func DecodeCircuitLimitExceeded(m *channel.Message) (*CircuitLimitExceeded, error) {
+	result := &CircuitLimitExceeded{}
+	result.CircuitId, _ = m.GetStringHeader(CircuitLimitExceededCircuitIdHeader)
+	result.Limit, _ = m.GetStringHeader(CircuitLimitExceededLimitHeader)
+	if elapsed, found := m.GetUint64Header(CircuitLimitExceededElapsedHeader); found {
+		result.Elapsed = time.Duration(elapsed)
+	}
+
+	if result.CircuitId == "" {
+		return nil, errors.New("circuit limit exceeded requires a circuit id")
+	}
+
+	if result.Limit != CircuitLimitMaxLifetime && result.Limit != CircuitLimitMaxIdleTime {
+		return nil, errors.New("circuit limit exceeded has unknown limit")
+	}
+
+	return result, nil
+}
@@ -54,6 +54,7 @@ const (
 	CreateCircuitReqFingerprintsHeader         = 12
 	CreateCircuitReqTerminatorInstanceIdHeader = 13
 	CreateCircuitReqApiSessionTokenHeader      = 14
+	CreateCircuitReqTagsHeader                 = 15
 
 	CreateCircuitRespCircuitId  = 11
 	CreateCircuitRespAddress    = 12
@@ -95,6 +96,7 @@ type CreateCircuitRequest struct {
 	Fingerprints         []string
 	TerminatorInstanceId string
 	PeerData             map[uint32][]byte
+	Tags                 map[string]string
 }
 
 func (self *CreateCircuitRequest) GetApiSessionToken() string {
@@ -117,6 +119,10 @@ func (self *CreateCircuitRequest) GetPeerData() map[uint32][]byte {
 	return self.PeerData
 }
 
+func (self *CreateCircuitRequest) GetTags() map[string]string {
+	return self.Tags
+}
+
 func (self *CreateCircuitRequest) ToMessage() *channel.Message {
 	msg := channel.NewMessage(int32(edge_ctrl_pb.ContentType_CreateCircuitV2RequestType), nil)
 	msg.PutStringHeader(CreateCircuitReqSessionTokenHeader, self.SessionToken)
@@ -124,6 +130,9 @@ func (self *CreateCircuitRequest) ToMessage() *channel.Message {
 	msg.PutStringSliceHeader(CreateCircuitReqFingerprintsHeader, self.Fingerprints)
 	msg.PutStringHeader(CreateCircuitReqTerminatorInstanceIdHeader, self.TerminatorInstanceId)
 	msg.PutU32ToBytesMapHeader(CreateCircuitPeerDataHeader, self.PeerData)
+	if len(self.Tags) > 0 {
+		msg.PutStringToStringMapHeader(CreateCircuitReqTagsHeader, self.Tags)
+	}
 	return msg
 }
 
@@ -146,12 +155,18 @@ func DecodeCreateCircuitRequest(m *channel.Message) (*CreateCircuitRequest, erro
 		return nil, fmt.Errorf("unable to get create circuit request peer data (%w)", err)
 	}
 
+	tags, _, err := m.GetStringToStringMapHeader(CreateCircuitReqTagsHeader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get create circuit request tags (%w)", err)
+	}
+
 	return &CreateCircuitRequest{
 		ApiSessionToken:      apiSessionToken,
 		SessionToken:         sessionToken,
 		Fingerprints:         fingerprints,
 		TerminatorInstanceId: terminatorInstanceId,
 		PeerData:             peerData,
+		Tags:                 tags,
 	}, nil
 }
 
@@ -0,0 +1,134 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package ctrl_msg
+
+import (
+	"errors"
+
+	"github.com/openziti/channel/v4"
+)
+
+const (
+	RouterUpdateRequestType = 1070
+	RouterUpdateStatusType  = 1071
+
+	RouterUpdateRolloutIdHeader = 10
+	RouterUpdatePhaseHeader     = 11
+	RouterUpdateVersionHeader   = 12
+	RouterUpdateUrlHeader       = 13
+	RouterUpdateSha256Header    = 14
+	RouterUpdateStateHeader     = 15
+	RouterUpdateErrorHeader     = 16
+
+	// RouterUpdatePhaseStage asks the router to download and verify a binary, without installing it
+	RouterUpdatePhaseStage = "stage"
+	// RouterUpdatePhaseApply asks the router to install the staged binary and restart
+	RouterUpdatePhaseApply = "apply"
+	// RouterUpdatePhaseDiscard asks the router to remove the staged binary
+	RouterUpdatePhaseDiscard = "discard"
+
+	RouterUpdateStateDownloading = "downloading"
+	RouterUpdateStateStaged      = "staged"
+	RouterUpdateStateRestarting  = "restarting"
+	RouterUpdateStateDiscarded   = "discarded"
+	RouterUpdateStateFailed      = "failed"
+)
+
+// RouterUpdateRequest is sent by the controller to have a router stage, apply or discard a new router binary
+type RouterUpdateRequest struct {
+	RolloutId string
+	Phase     string
+	Version   string
+	Url       string
+	Sha256    string
+}
+
+func (self *RouterUpdateRequest) ToMessage() *channel.Message {
+	msg := channel.NewMessage(RouterUpdateRequestType, nil)
+	msg.PutStringHeader(RouterUpdateRolloutIdHeader, self.RolloutId)
+	msg.PutStringHeader(RouterUpdatePhaseHeader, self.Phase)
+	msg.PutStringHeader(RouterUpdateVersionHeader, self.Version)
+	if self.Url != "" {
+		msg.PutStringHeader(RouterUpdateUrlHeader, self.Url)
+	}
+	if self.Sha256 != "" {
+		msg.PutStringHeader(RouterUpdateSha256Header, self.Sha256)
+	}
+	return msg
+}
+
+func DecodeRouterUpdateRequest(m *channel.Message) (*RouterUpdateRequest, error) {
+	result := &RouterUpdateRequest{}
+	result.RolloutId, _ = m.GetStringHeader(RouterUpdateRolloutIdHeader)
+	result.Phase, _ = m.GetStringHeader(RouterUpdatePhaseHeader)
+	result.Version, _ = m.GetStringHeader(RouterUpdateVersionHeader)
+	result.Url, _ = m.GetStringHeader(RouterUpdateUrlHeader)
+	result.Sha256, _ = m.GetStringHeader(RouterUpdateSha256Header)
+
+	if result.RolloutId == "" {
+		return nil, errors.New("no rollout id provided in router update request")
+	}
+
+	switch result.Phase {
+	case RouterUpdatePhaseStage:
+		if result.Version == "" || result.Url == "" || result.Sha256 == "" {
+			return nil, errors.New("router update stage request requires a version, url and sha256")
+		}
+	case RouterUpdatePhaseApply:
+		if result.Version == "" {
+			return nil, errors.New("router update apply request requires a version")
+		}
+	case RouterUpdatePhaseDiscard:
+	default:
+		return nil, errors.New("invalid router update phase: " + result.Phase)
+	}
+
+	return result, nil
+}
+
+// RouterUpdateStatus is sent by a router to report the progress of a router update request
+type RouterUpdateStatus struct {
+	RolloutId string
+	Version   string
+	State     string
+	Error     string
+}
+
+func (self *RouterUpdateStatus) ToMessage() *channel.Message {
+	msg := channel.NewMessage(RouterUpdateStatusType, nil)
+	msg.PutStringHeader(RouterUpdateRolloutIdHeader, self.RolloutId)
+	msg.PutStringHeader(RouterUpdateVersionHeader, self.Version)
+	msg.PutStringHeader(RouterUpdateStateHeader, self.State)
+	if self.Error != "" {
+		msg.PutStringHeader(RouterUpdateErrorHeader, self.Error)
+	}
+	return msg
+}
+
+func DecodeRouterUpdateStatus(m *channel.Message) (*RouterUpdateStatus, error) {
+	result := &RouterUpdateStatus{}
+	result.RolloutId, _ = m.GetStringHeader(RouterUpdateRolloutIdHeader)
+	result.Version, _ = m.GetStringHeader(RouterUpdateVersionHeader)
+	result.State, _ = m.GetStringHeader(RouterUpdateStateHeader)
+	result.Error, _ = m.GetStringHeader(RouterUpdateErrorHeader)
+
+	if result.RolloutId == "" || result.State == "" {
+		return nil, errors.New("router update status requires a rollout id and state")
+	}
+
+	return result, nil
+}
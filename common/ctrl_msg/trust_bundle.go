@@ -0,0 +1,67 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package ctrl_msg
+
+import (
+	"encoding/json"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/common/trustbundle"
+	"github.com/pkg/errors"
+)
+
+const (
+	TrustBundleRequestType  = 1073
+	TrustBundleResponseType = 1074
+
+	TrustBundleETagHeader = 10
+)
+
+// NewTrustBundleRequest asks the controller for the changes to the trust bundle since the version with the given
+// ETag. An empty ETag requests the full bundle.
+func NewTrustBundleRequest(etag string) *channel.Message {
+	msg := channel.NewMessage(TrustBundleRequestType, nil)
+	if etag != "" {
+		msg.PutStringHeader(TrustBundleETagHeader, etag)
+	}
+	return msg
+}
+
+func NewTrustBundleResponse(delta *trustbundle.Delta) (*channel.Message, error) {
+	body, err := json.Marshal(delta)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal trust bundle delta")
+	}
+	return channel.NewMessage(TrustBundleResponseType, body), nil
+}
+
+func DecodeTrustBundleResponse(m *channel.Message) (*trustbundle.Delta, error) {
+	if m.ContentType != TrustBundleResponseType {
+		return nil, errors.Errorf("unexpected response type %d to trust bundle request", m.ContentType)
+	}
+
+	result := &trustbundle.Delta{}
+	if err := json.Unmarshal(m.Body, result); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal trust bundle delta")
+	}
+
+	if result.ETag == "" {
+		return nil, errors.New("trust bundle delta is missing its etag")
+	}
+
+	return result, nil
+}
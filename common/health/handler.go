@@ -23,24 +23,42 @@ import (
 	"github.com/openziti/xweb/v2"
 	"github.com/sirupsen/logrus"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 )
 
 const (
 	Binding = "health-checks"
+
+	LivenessPath  = "/live"
+	ReadinessPath = "/ready"
+
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
 )
 
+// Warning may be implemented by check details. A passing check whose details report a warning marks the overall
+// status as degraded, without failing readiness.
+type Warning interface {
+	IsHealthWarning() bool
+}
+
 var _ xweb.ApiHandlerFactory = &HealthCheckApiFactory{}
 
-func NewHealthCheckApiFactory(healthChecker gosundheit.Health) *HealthCheckApiFactory {
+// NewHealthCheckApiFactory returns a factory for the health check API. The liveness checks are the ids of the checks
+// which are reported by the liveness endpoint. All checks are reported by the readiness endpoint.
+func NewHealthCheckApiFactory(healthChecker gosundheit.Health, livenessChecks ...string) *HealthCheckApiFactory {
 	return &HealthCheckApiFactory{
-		healthChecker: healthChecker,
+		healthChecker:  healthChecker,
+		livenessChecks: livenessChecks,
 	}
 }
 
 type HealthCheckApiFactory struct {
-	healthChecker gosundheit.Health
+	healthChecker  gosundheit.Health
+	livenessChecks []string
 }
 
 func (factory HealthCheckApiFactory) Validate(*xweb.InstanceConfig) error {
@@ -53,14 +71,16 @@ func (factory HealthCheckApiFactory) Binding() string {
 
 func (factory HealthCheckApiFactory) New(_ *xweb.ServerConfig, options map[interface{}]interface{}) (xweb.ApiHandler, error) {
 	return &HealthCheckApiHandler{
-		healthChecker: factory.healthChecker,
-		options:       options,
+		healthChecker:  factory.healthChecker,
+		livenessChecks: factory.livenessChecks,
+		options:        options,
 	}, nil
 }
 
 type HealthCheckApiHandler struct {
-	options       map[interface{}]interface{}
-	healthChecker gosundheit.Health
+	options        map[interface{}]interface{}
+	healthChecker  gosundheit.Health
+	livenessChecks []string
 }
 
 func (self *HealthCheckApiHandler) Binding() string {
@@ -79,6 +99,9 @@ func (self *HealthCheckApiHandler) IsHandler(r *http.Request) bool {
 	return strings.HasPrefix(r.URL.Path, self.RootPath())
 }
 
+// ServeHTTP reports the health check results. The root path reports all checks and always responds with a 200, so
+// existing consumers can inspect the results. The liveness and readiness paths respond with a 503 when any of the
+// checks they report is failing, so they can be used directly as Kubernetes probes.
 func (self *HealthCheckApiHandler) ServeHTTP(w http.ResponseWriter, request *http.Request) {
 	output := map[string]interface{}{}
 	output["meta"] = map[string]interface{}{}
@@ -90,17 +113,45 @@ func (self *HealthCheckApiHandler) ServeHTTP(w http.ResponseWriter, request *htt
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "    ")
 
-	results, healthy := self.healthChecker.Results()
-	data["healthy"] = healthy
+	probe := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, self.RootPath()), "/")
+
+	results, _ := self.healthChecker.Results()
+	if probe == LivenessPath {
+		filtered := map[string]gosundheit.Result{}
+		for _, id := range self.livenessChecks {
+			if result, found := results[id]; found {
+				filtered[id] = result
+			}
+		}
+		results = filtered
+	}
+
+	healthy := true
+	warning := false
 	var checks []map[string]interface{}
 
 	shortFormat := request.URL.Query().Get("type") == "short"
 
-	for id, result := range results {
+	ids := make([]string, 0, len(results))
+	for id := range results {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		result := results[id]
+		healthy = healthy && result.IsHealthy()
+
 		check := map[string]interface{}{}
 		checks = append(checks, check)
 		check["id"] = id
 		check["healthy"] = result.IsHealthy()
+
+		if warner, ok := result.Details.(Warning); ok && result.IsHealthy() && warner.IsHealthWarning() {
+			check["warning"] = true
+			warning = true
+		}
+
 		if !shortFormat {
 			check["lastCheckDuration"] = fmt.Sprintf("%v", result.Duration)
 			check["lastCheckTime"] = result.Timestamp.UTC().Format(time.RFC3339)
@@ -119,8 +170,25 @@ func (self *HealthCheckApiHandler) ServeHTTP(w http.ResponseWriter, request *htt
 		}
 	}
 
+	data["healthy"] = healthy
+	data["status"] = getStatus(healthy, warning)
 	data["checks"] = checks
+
+	if (probe == LivenessPath || probe == ReadinessPath) && !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
 	if err := encoder.Encode(output); err != nil {
 		logrus.WithError(err).Error("failure encoding health check results")
 	}
 }
+
+func getStatus(healthy, warning bool) string {
+	if !healthy {
+		return StatusUnhealthy
+	}
+	if warning {
+		return StatusDegraded
+	}
+	return StatusHealthy
+}
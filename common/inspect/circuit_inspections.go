@@ -46,3 +46,15 @@ type SdkCircuitDetail struct {
 	ChannelConnId string `json:"channelConnId"`
 	*xgress.CircuitDetail
 }
+
+// CircuitHopDetail summarizes how a single router on a circuit's path is handling the circuit's traffic
+type CircuitHopDetail struct {
+	ForwardedPayloads     uint64            `json:"forwardedPayloads"`
+	RetransmittedPayloads uint64            `json:"retransmittedPayloads"`
+	AvgForwardLatency     string            `json:"avgForwardLatency"`
+	MaxForwardLatency     string            `json:"maxForwardLatency"`
+	QueuedPayloads        int               `json:"queuedPayloads"`
+	Retransmits           uint32            `json:"retransmits"`
+	DuplicateAcks         uint32            `json:"duplicateAcks"`
+	LinkLatency           map[string]string `json:"linkLatency,omitempty"`
+}
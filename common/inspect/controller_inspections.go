@@ -17,7 +17,8 @@
 package inspect
 
 type ControllerInspectDetails struct {
-	Controllers map[string]*ControllerInspectDetail `json:"controllers"`
+	Controllers  map[string]*ControllerInspectDetail `json:"controllers"`
+	ActiveActive bool                                `json:"activeActive"`
 }
 
 type ControllerInspectDetail struct {
@@ -33,6 +33,7 @@ type LinkInspectDetail struct {
 	Underlays          map[string]int    `json:"underlays"`
 	Connections        []*LinkConnection `json:"connections"`
 	ConnStateIteration uint32            `json:"connStateIteration"`
+	Compression        string            `json:"compression,omitempty"`
 }
 
 type LinksInspectResult struct {
@@ -60,6 +61,7 @@ type LinkState struct {
 	Key               string   `json:"key"`
 	Status            string   `json:"status"`
 	DialAttempts      uint64   `json:"dialAttempts"`
+	DialFailures      uint32   `json:"dialFailures"`
 	ConnectedCount    uint64   `json:"connectedCount"`
 	RetryDelay        string   `json:"retryDelay"`
 	NextDial          string   `json:"nextDial"`
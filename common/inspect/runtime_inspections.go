@@ -0,0 +1,175 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	GoroutinesKey   = "goroutines"
+	MutexProfileKey = "mutex-profile"
+	BlockProfileKey = "block-profile"
+	BoltStatsKey    = "bolt-stats"
+
+	// DefaultMutexProfileFraction is the mutex profile fraction used when mutex profiling is first requested
+	DefaultMutexProfileFraction = 10
+
+	// DefaultBlockProfileRate is the block profile rate used when block profiling is first requested
+	DefaultBlockProfileRate = time.Millisecond
+)
+
+var blockProfileRate atomic.Int64
+
+// InspectRuntime handles the runtime inspections which are available on both controllers and routers.
+//
+//   - goroutines returns the goroutine stacks, with identical stacks grouped together
+//   - mutex-profile returns the mutex contention profile. Profiling is enabled at the default fraction the first time
+//     it's requested. mutex-profile:<fraction> sets the fraction first, with 0 disabling profiling
+//   - block-profile returns the blocking profile. Profiling is enabled at the default rate the first time it's
+//     requested. block-profile:<rate> sets the rate first, as a duration, with 0 disabling profiling
+func InspectRuntime(key string) (bool, *string, error) {
+	lc := strings.ToLower(key)
+	name, arg, hasArg := strings.Cut(lc, ":")
+
+	switch name {
+	case GoroutinesKey:
+		result, err := writeProfile(GoroutinesKey, "goroutine")
+		return true, result, err
+	case MutexProfileKey:
+		result, err := inspectMutexProfile(arg, hasArg)
+		return true, result, err
+	case BlockProfileKey:
+		result, err := inspectBlockProfile(arg, hasArg)
+		return true, result, err
+	}
+
+	return false, nil, nil
+}
+
+func inspectMutexProfile(arg string, hasArg bool) (*string, error) {
+	var note string
+
+	if hasArg {
+		fraction, err := strconv.Atoi(arg)
+		if err != nil || fraction < 0 {
+			return nil, errors.Errorf("invalid mutex profile fraction '%s', must be a non-negative integer", arg)
+		}
+		runtime.SetMutexProfileFraction(fraction)
+		note = fmt.Sprintf("# mutex profile fraction set to %d\n", fraction)
+	} else if runtime.SetMutexProfileFraction(-1) == 0 {
+		runtime.SetMutexProfileFraction(DefaultMutexProfileFraction)
+		note = fmt.Sprintf("# mutex profiling was disabled, enabled with fraction %d. Inspect again to see contention\n",
+			DefaultMutexProfileFraction)
+	}
+
+	return writeProfileWithNote(MutexProfileKey, "mutex", note)
+}
+
+func inspectBlockProfile(arg string, hasArg bool) (*string, error) {
+	var note string
+
+	if hasArg {
+		rate, err := parseBlockProfileRate(arg)
+		if err != nil {
+			return nil, err
+		}
+		setBlockProfileRate(rate)
+		note = fmt.Sprintf("# block profile rate set to %v\n", rate)
+	} else if blockProfileRate.Load() == 0 {
+		setBlockProfileRate(DefaultBlockProfileRate)
+		note = fmt.Sprintf("# block profiling was disabled, enabled with rate %v. Inspect again to see blocking\n",
+			DefaultBlockProfileRate)
+	}
+
+	return writeProfileWithNote(BlockProfileKey, "block", note)
+}
+
+func parseBlockProfileRate(arg string) (time.Duration, error) {
+	rate, err := time.ParseDuration(arg)
+	if err != nil || rate < 0 {
+		return 0, errors.Errorf("invalid block profile rate '%s', must be a non-negative duration, such as 1ms", arg)
+	}
+	return rate, nil
+}
+
+func setBlockProfileRate(rate time.Duration) {
+	blockProfileRate.Store(int64(rate))
+	runtime.SetBlockProfileRate(int(rate.Nanoseconds()))
+}
+
+func writeProfileWithNote(key, profileName, note string) (*string, error) {
+	result, err := writeProfile(key, profileName)
+	if err != nil || note == "" {
+		return result, err
+	}
+	withNote := note + *result
+	return &withNote, nil
+}
+
+func writeProfile(key, profileName string) (*string, error) {
+	profile := pprof.Lookup(profileName)
+	if profile == nil {
+		return nil, errors.Errorf("%s: no %s profile available", key, profileName)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := profile.WriteTo(buf, 1); err != nil {
+		return nil, errors.Wrapf(err, "%s: unable to write %s profile", key, profileName)
+	}
+
+	result := buf.String()
+	return &result, nil
+}
+
+// BoltStats holds the statistics of a bbolt database
+type BoltStats struct {
+	Path               string       `json:"path"`
+	SizeBytes          int64        `json:"sizeBytes"`
+	FreePages          int          `json:"freePages"`
+	PendingPages       int          `json:"pendingPages"`
+	FreeAllocBytes     int          `json:"freeAllocBytes"`
+	FreelistInuseBytes int          `json:"freelistInuseBytes"`
+	ReadTxTotal        int          `json:"readTxTotal"`
+	ReadTxOpen         int          `json:"readTxOpen"`
+	Tx                 *BoltTxStats `json:"tx"`
+}
+
+// BoltTxStats holds the totals of the bbolt transaction statistics, since the database was opened
+type BoltTxStats struct {
+	PageCount     int64  `json:"pageCount"`
+	PageAlloc     int64  `json:"pageAllocBytes"`
+	CursorCount   int64  `json:"cursorCount"`
+	NodeCount     int64  `json:"nodeCount"`
+	NodeDeref     int64  `json:"nodeDeref"`
+	Rebalance     int64  `json:"rebalance"`
+	RebalanceTime string `json:"rebalanceTime"`
+	Split         int64  `json:"split"`
+	Spill         int64  `json:"spill"`
+	SpillTime     string `json:"spillTime"`
+	Write         int64  `json:"write"`
+	WriteTime     string `json:"writeTime"`
+}
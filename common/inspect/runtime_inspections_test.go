@@ -0,0 +1,78 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package inspect
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectRuntime(t *testing.T) {
+	req := require.New(t)
+
+	handled, _, _ := InspectRuntime("links")
+	req.False(handled)
+
+	handled, val, err := InspectRuntime("Goroutines")
+	req.True(handled)
+	req.NoError(err)
+	req.Contains(*val, "goroutine profile:")
+	req.Contains(*val, "TestInspectRuntime")
+}
+
+func TestInspectMutexProfile(t *testing.T) {
+	req := require.New(t)
+	defer runtime.SetMutexProfileFraction(runtime.SetMutexProfileFraction(0))
+
+	handled, val, err := InspectRuntime(MutexProfileKey)
+	req.True(handled)
+	req.NoError(err)
+	req.Contains(*val, "mutex profiling was disabled")
+	req.Equal(DefaultMutexProfileFraction, runtime.SetMutexProfileFraction(-1))
+
+	_, val, err = InspectRuntime(MutexProfileKey)
+	req.NoError(err)
+	req.NotContains(*val, "mutex profiling was disabled")
+
+	_, _, err = InspectRuntime(MutexProfileKey + ":0")
+	req.NoError(err)
+	req.Equal(0, runtime.SetMutexProfileFraction(-1))
+
+	_, _, err = InspectRuntime(MutexProfileKey + ":abc")
+	req.Error(err)
+}
+
+func TestInspectBlockProfile(t *testing.T) {
+	req := require.New(t)
+	defer setBlockProfileRate(0)
+
+	handled, val, err := InspectRuntime(BlockProfileKey)
+	req.True(handled)
+	req.NoError(err)
+	req.Contains(*val, "block profiling was disabled")
+	req.Equal(int64(DefaultBlockProfileRate), blockProfileRate.Load())
+
+	_, val, err = InspectRuntime(BlockProfileKey + ":0")
+	req.NoError(err)
+	req.Contains(*val, "block profile rate set to 0s")
+	req.Equal(int64(0), blockProfileRate.Load())
+
+	_, _, err = InspectRuntime(BlockProfileKey + ":-1s")
+	req.Error(err)
+}
@@ -30,6 +30,8 @@ type TerminatorCostDetail struct {
 	CircuitCount uint32 `json:"circuitCount"`
 	FailureCost  uint32 `json:"failureCost"`
 	CurrentCost  uint32 `json:"currentCost"`
+	LastFailure  string `json:"lastFailure,omitempty"`
+	CostDecay    string `json:"costDecay,omitempty"`
 }
 
 type SdkTerminatorInspectResult struct {
@@ -0,0 +1,88 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package linkgroups handles link groups assigned to routers through role attributes. Role attributes prefixed
+// with RoleAttributePrefix name the link groups a router belongs to. If a router has any such groups, they take
+// the place of the default link group on its link listeners and dialers, so that links only form between routers
+// sharing a group.
+package linkgroups
+
+import "strings"
+
+const (
+	RoleAttributePrefix = "link-group:"
+	GroupDefault        = "default"
+)
+
+// FromRoleAttributes returns the link groups named by the given role attributes, in order, without duplicates
+func FromRoleAttributes(roleAttributes []string) []string {
+	var result []string
+	for _, attr := range roleAttributes {
+		if group, ok := strings.CutPrefix(attr, RoleAttributePrefix); ok {
+			group = strings.TrimSpace(group)
+			if group != "" && !contains(result, group) {
+				result = append(result, group)
+			}
+		}
+	}
+	return result
+}
+
+// Apply returns the effective groups for a listener or dialer configured with the given groups. If no groups are
+// assigned, the configured groups are returned unchanged. Otherwise, the default group is replaced by the
+// assigned groups, while any other explicitly configured groups are kept.
+func Apply(configured []string, assigned []string) []string {
+	if len(assigned) == 0 {
+		return configured
+	}
+
+	var result []string
+	for _, group := range configured {
+		if group != GroupDefault && !contains(result, group) {
+			result = append(result, group)
+		}
+	}
+
+	for _, group := range assigned {
+		if !contains(result, group) {
+			result = append(result, group)
+		}
+	}
+
+	return result
+}
+
+// Equal returns true if the two group lists contain the same groups in the same order
+func Equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(groups []string, group string) bool {
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
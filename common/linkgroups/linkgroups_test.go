@@ -0,0 +1,38 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package linkgroups
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromRoleAttributes(t *testing.T) {
+	req := require.New(t)
+	req.Nil(FromRoleAttributes(nil))
+	req.Nil(FromRoleAttributes([]string{"public", "dmz"}))
+	req.Equal([]string{"dmz", "core"}, FromRoleAttributes([]string{"public", "link-group:dmz", "link-group:core", "link-group:dmz", "link-group:"}))
+}
+
+func TestApply(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal([]string{GroupDefault}, Apply([]string{GroupDefault}, nil))
+	req.Equal([]string{"dmz"}, Apply([]string{GroupDefault}, []string{"dmz"}))
+	req.Equal([]string{"mgmt", "dmz", "core"}, Apply([]string{GroupDefault, "mgmt"}, []string{"dmz", "core", "mgmt"}))
+}
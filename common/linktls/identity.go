@@ -0,0 +1,79 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package linktls
+
+import (
+	"crypto/tls"
+
+	"github.com/openziti/identity"
+)
+
+// PolicyProvider returns the link TLS policy currently in effect. It may return nil if no policy is in effect.
+type PolicyProvider func() *Policy
+
+// NewTokenId returns a copy of the given token id whose TLS configs are constrained by the policy returned
+// from the given provider. The provider is consulted each time a client config is requested and on each
+// incoming handshake, so policy changes apply to new links without restarting listeners.
+func NewTokenId(id *identity.TokenId, provider PolicyProvider) *identity.TokenId {
+	return &identity.TokenId{
+		Identity: &policyIdentity{
+			Identity: id.Identity,
+			provider: provider,
+		},
+		Token: id.Token,
+		Data:  id.Data,
+	}
+}
+
+type policyIdentity struct {
+	identity.Identity
+	provider PolicyProvider
+}
+
+func (self *policyIdentity) ServerTLSConfig() *tls.Config {
+	cfg := self.Identity.ServerTLSConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	result := cfg.Clone()
+	self.provider().applyTo(result)
+
+	getConfigForClient := cfg.GetConfigForClient
+	result.GetConfigForClient = func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+		c := cfg
+		if getConfigForClient != nil {
+			var err error
+			if c, err = getConfigForClient(info); err != nil {
+				return nil, err
+			}
+			if c == nil {
+				c = cfg
+			}
+		}
+		c = c.Clone()
+		c.GetConfigForClient = nil
+		self.provider().applyTo(c)
+		return c, nil
+	}
+
+	return result
+}
+
+func (self *policyIdentity) ClientTLSConfig() *tls.Config {
+	return self.provider().Apply(self.Identity.ClientTLSConfig())
+}
@@ -0,0 +1,311 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package linktls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/pkg/errors"
+)
+
+// Policy constrains the TLS parameters used for router-to-router links. Zero values leave the values from the
+// router identity's TLS configuration in place.
+//
+// Note that Go does not allow TLS 1.3 cipher suites to be configured, so CipherSuites only applies to TLS 1.2
+// handshakes. To restrict links to the TLS 1.2 suites given, set MaxVersion to TLS 1.2.
+type Policy struct {
+	MinVersion       uint16
+	MaxVersion       uint16
+	CipherSuites     []uint16
+	CurvePreferences []tls.CurveID
+}
+
+// IsEmpty returns true if the policy doesn't constrain anything
+func (self *Policy) IsEmpty() bool {
+	return self == nil || (self.MinVersion == 0 && self.MaxVersion == 0 && len(self.CipherSuites) == 0 && len(self.CurvePreferences) == 0)
+}
+
+func (self *Policy) Validate() error {
+	if self.MinVersion != 0 && self.MaxVersion != 0 && self.MinVersion > self.MaxVersion {
+		return errors.Errorf("minVersion %s is greater than maxVersion %s", VersionName(self.MinVersion), VersionName(self.MaxVersion))
+	}
+	return nil
+}
+
+// Apply returns a copy of the given tls.Config, constrained by the policy. If the config has a GetConfigForClient
+// callback, the configs it returns are constrained as well.
+func (self *Policy) Apply(cfg *tls.Config) *tls.Config {
+	if cfg == nil {
+		return nil
+	}
+
+	result := cfg.Clone()
+	self.applyTo(result)
+
+	if getConfigForClient := cfg.GetConfigForClient; getConfigForClient != nil {
+		result.GetConfigForClient = func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+			c, err := getConfigForClient(info)
+			if err != nil || c == nil {
+				return c, err
+			}
+			c = c.Clone()
+			self.applyTo(c)
+			return c, nil
+		}
+	}
+
+	return result
+}
+
+func (self *Policy) applyTo(cfg *tls.Config) {
+	if self == nil {
+		return
+	}
+
+	if self.MinVersion > cfg.MinVersion {
+		cfg.MinVersion = self.MinVersion
+	}
+
+	if self.MaxVersion != 0 && (cfg.MaxVersion == 0 || self.MaxVersion < cfg.MaxVersion) {
+		cfg.MaxVersion = self.MaxVersion
+	}
+
+	if len(self.CipherSuites) > 0 {
+		cfg.CipherSuites = slices.Clone(self.CipherSuites)
+	}
+
+	if len(self.CurvePreferences) > 0 {
+		cfg.CurvePreferences = slices.Clone(self.CurvePreferences)
+	}
+}
+
+// ToSettings converts the policy to the form sent from the controller to routers
+func (self *Policy) ToSettings() *ctrl_pb.LinkTlsPolicySettings {
+	result := &ctrl_pb.LinkTlsPolicySettings{}
+	if self == nil {
+		return result
+	}
+	if self.MinVersion != 0 {
+		result.MinVersion = VersionName(self.MinVersion)
+	}
+	if self.MaxVersion != 0 {
+		result.MaxVersion = VersionName(self.MaxVersion)
+	}
+	for _, suite := range self.CipherSuites {
+		result.CipherSuites = append(result.CipherSuites, tls.CipherSuiteName(suite))
+	}
+	for _, curve := range self.CurvePreferences {
+		result.CurvePreferences = append(result.CurvePreferences, curve.String())
+	}
+	return result
+}
+
+// FromSettings converts a controller provided link TLS policy
+func FromSettings(settings *ctrl_pb.LinkTlsPolicySettings) (*Policy, error) {
+	result := &Policy{}
+	if settings == nil {
+		return result, nil
+	}
+
+	var err error
+	if settings.MinVersion != "" {
+		if result.MinVersion, err = ParseVersion(settings.MinVersion); err != nil {
+			return nil, errors.Wrap(err, "invalid minVersion")
+		}
+	}
+
+	if settings.MaxVersion != "" {
+		if result.MaxVersion, err = ParseVersion(settings.MaxVersion); err != nil {
+			return nil, errors.Wrap(err, "invalid maxVersion")
+		}
+	}
+
+	for _, name := range settings.CipherSuites {
+		suite, err := ParseCipherSuite(name)
+		if err != nil {
+			return nil, err
+		}
+		result.CipherSuites = append(result.CipherSuites, suite)
+	}
+
+	for _, name := range settings.CurvePreferences {
+		curve, err := ParseCurve(name)
+		if err != nil {
+			return nil, err
+		}
+		result.CurvePreferences = append(result.CurvePreferences, curve)
+	}
+
+	if err = result.Validate(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// LoadPolicy loads a policy from a config file stanza, which may contain minVersion, maxVersion, cipherSuites
+// and curvePreferences
+func LoadPolicy(data map[interface{}]interface{}) (*Policy, error) {
+	settings := &ctrl_pb.LinkTlsPolicySettings{}
+
+	if value, found := data["minVersion"]; found {
+		settings.MinVersion = fmt.Sprint(value)
+	}
+
+	if value, found := data["maxVersion"]; found {
+		settings.MaxVersion = fmt.Sprint(value)
+	}
+
+	if value, found := data["cipherSuites"]; found {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid 'cipherSuites' value, must be a list (%s)", reflect.TypeOf(value))
+		}
+		for _, v := range list {
+			settings.CipherSuites = append(settings.CipherSuites, fmt.Sprint(v))
+		}
+	}
+
+	if value, found := data["curvePreferences"]; found {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid 'curvePreferences' value, must be a list (%s)", reflect.TypeOf(value))
+		}
+		for _, v := range list {
+			settings.CurvePreferences = append(settings.CurvePreferences, fmt.Sprint(v))
+		}
+	}
+
+	return FromSettings(settings)
+}
+
+// Merge combines a locally configured policy with one provided by the controller, returning the stricter of the two.
+// The higher minimum version and lower maximum version are used. Cipher suites and curves are limited to those in
+// both lists, in local preference order. If the lists have nothing in common, the local list is used.
+func Merge(local, remote *Policy) *Policy {
+	if remote.IsEmpty() {
+		return local
+	}
+
+	if local.IsEmpty() {
+		return remote
+	}
+
+	result := &Policy{
+		MinVersion:       max(local.MinVersion, remote.MinVersion),
+		MaxVersion:       local.MaxVersion,
+		CipherSuites:     intersect(local.CipherSuites, remote.CipherSuites),
+		CurvePreferences: intersect(local.CurvePreferences, remote.CurvePreferences),
+	}
+
+	if remote.MaxVersion != 0 && (result.MaxVersion == 0 || remote.MaxVersion < result.MaxVersion) {
+		result.MaxVersion = remote.MaxVersion
+	}
+
+	// never produce a policy which can't negotiate any version
+	if result.MaxVersion != 0 && result.MinVersion > result.MaxVersion {
+		result.MinVersion, result.MaxVersion = local.MinVersion, local.MaxVersion
+	}
+
+	return result
+}
+
+func intersect[T comparable](local, remote []T) []T {
+	if len(remote) == 0 {
+		return local
+	}
+	if len(local) == 0 {
+		return remote
+	}
+	var result []T
+	for _, v := range local {
+		if slices.Contains(remote, v) {
+			result = append(result, v)
+		}
+	}
+	if len(result) == 0 {
+		return local
+	}
+	return result
+}
+
+// ParseVersion parses a TLS version such as 1.2, TLS1.3 or TLS 1.2
+func ParseVersion(s string) (uint16, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimPrefix(v, "TLS")
+	v = strings.TrimPrefix(strings.TrimSpace(v), "V")
+	switch strings.ReplaceAll(v, "_", ".") {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, errors.Errorf("unsupported TLS version '%s', must be 1.2 or 1.3", s)
+}
+
+// VersionName returns the name of a TLS version, in the form accepted by ParseVersion
+func VersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS12:
+		return "1.2"
+	case tls.VersionTLS13:
+		return "1.3"
+	}
+	return tls.VersionName(version)
+}
+
+// ParseCipherSuite parses a TLS 1.2 cipher suite name such as TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384. Insecure
+// suites aren't accepted. TLS 1.3 suites aren't accepted either, since Go doesn't allow them to be configured.
+func ParseCipherSuite(name string) (uint16, error) {
+	n := strings.ToUpper(strings.TrimSpace(name))
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == n {
+			if slices.Equal(suite.SupportedVersions, []uint16{tls.VersionTLS13}) {
+				return 0, errors.Errorf("cipher suite %s is a TLS 1.3 suite, which can't be configured", name)
+			}
+			return suite.ID, nil
+		}
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == n {
+			return 0, errors.Errorf("cipher suite %s is insecure and may not be used for links", name)
+		}
+	}
+	return 0, errors.Errorf("unknown cipher suite %s", name)
+}
+
+// ParseCurve parses a key exchange group name such as X25519, P-256 or secp384r1
+func ParseCurve(name string) (tls.CurveID, error) {
+	switch strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), "-", "")) {
+	case "X25519":
+		return tls.X25519, nil
+	case "P256", "SECP256R1", "CURVEP256":
+		return tls.CurveP256, nil
+	case "P384", "SECP384R1", "CURVEP384":
+		return tls.CurveP384, nil
+	case "P521", "SECP521R1", "CURVEP521":
+		return tls.CurveP521, nil
+	case "X25519MLKEM768":
+		return tls.X25519MLKEM768, nil
+	}
+	return 0, errors.Errorf("unknown curve %s", name)
+}
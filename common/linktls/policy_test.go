@@ -0,0 +1,134 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package linktls
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	req := require.New(t)
+
+	policy, err := LoadPolicy(map[interface{}]interface{}{
+		"minVersion":       1.2,
+		"maxVersion":       "TLS1.3",
+		"cipherSuites":     []interface{}{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", "tls_ecdhe_rsa_with_aes_256_gcm_sha384"},
+		"curvePreferences": []interface{}{"P-384", "secp256r1"},
+	})
+	req.NoError(err)
+	req.Equal(uint16(tls.VersionTLS12), policy.MinVersion)
+	req.Equal(uint16(tls.VersionTLS13), policy.MaxVersion)
+	req.Equal([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}, policy.CipherSuites)
+	req.Equal([]tls.CurveID{tls.CurveP384, tls.CurveP256}, policy.CurvePreferences)
+
+	roundTrip, err := FromSettings(policy.ToSettings())
+	req.NoError(err)
+	req.Equal(policy, roundTrip)
+}
+
+func TestLoadPolicyErrors(t *testing.T) {
+	req := require.New(t)
+
+	_, err := LoadPolicy(map[interface{}]interface{}{"minVersion": "1.1"})
+	req.Error(err)
+
+	_, err = LoadPolicy(map[interface{}]interface{}{"minVersion": "1.3", "maxVersion": "1.2"})
+	req.Error(err)
+
+	_, err = LoadPolicy(map[interface{}]interface{}{"cipherSuites": []interface{}{"TLS_RSA_WITH_RC4_128_SHA"}})
+	req.ErrorContains(err, "insecure")
+
+	_, err = LoadPolicy(map[interface{}]interface{}{"cipherSuites": []interface{}{"TLS_AES_128_GCM_SHA256"}})
+	req.ErrorContains(err, "TLS 1.3")
+
+	_, err = LoadPolicy(map[interface{}]interface{}{"curvePreferences": []interface{}{"P-224"}})
+	req.Error(err)
+
+	_, err = LoadPolicy(map[interface{}]interface{}{"cipherSuites": "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"})
+	req.Error(err)
+}
+
+func TestMerge(t *testing.T) {
+	req := require.New(t)
+
+	local := &Policy{
+		MinVersion:       tls.VersionTLS12,
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		CurvePreferences: []tls.CurveID{tls.CurveP384},
+	}
+
+	req.Equal(local, Merge(local, nil))
+	req.Equal(local, Merge(nil, local))
+	req.Nil(Merge(nil, nil))
+
+	remote := &Policy{
+		MinVersion:       tls.VersionTLS13,
+		MaxVersion:       tls.VersionTLS13,
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		CurvePreferences: []tls.CurveID{tls.X25519},
+	}
+
+	merged := Merge(local, remote)
+	req.Equal(uint16(tls.VersionTLS13), merged.MinVersion)
+	req.Equal(uint16(tls.VersionTLS13), merged.MaxVersion)
+	req.Equal([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, merged.CipherSuites)
+	// no overlap, so the local list is kept
+	req.Equal([]tls.CurveID{tls.CurveP384}, merged.CurvePreferences)
+
+	// a merge which would leave no usable version falls back to the local versions
+	merged = Merge(&Policy{MinVersion: tls.VersionTLS13}, &Policy{MaxVersion: tls.VersionTLS12})
+	req.Equal(uint16(tls.VersionTLS13), merged.MinVersion)
+	req.Equal(uint16(0), merged.MaxVersion)
+}
+
+func TestApply(t *testing.T) {
+	req := require.New(t)
+
+	inner := &tls.Config{MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS13}
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+			return inner, nil
+		},
+	}
+
+	policy := &Policy{
+		MinVersion:       tls.VersionTLS12,
+		MaxVersion:       tls.VersionTLS12,
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+		CurvePreferences: []tls.CurveID{tls.CurveP384},
+	}
+
+	result := policy.Apply(cfg)
+	req.Equal(uint16(tls.VersionTLS12), result.MaxVersion)
+	req.Equal(policy.CipherSuites, result.CipherSuites)
+	req.Equal(policy.CurvePreferences, result.CurvePreferences)
+	req.Equal(uint16(0), cfg.MaxVersion, "source config must not be modified")
+
+	forClient, err := result.GetConfigForClient(&tls.ClientHelloInfo{})
+	req.NoError(err)
+	req.Equal(uint16(tls.VersionTLS12), forClient.MaxVersion)
+	req.Equal(policy.CurvePreferences, forClient.CurvePreferences)
+	req.Equal(uint16(tls.VersionTLS13), inner.MaxVersion, "source config must not be modified")
+
+	// a nil policy leaves the config unchanged
+	var nilPolicy *Policy
+	req.Equal(uint16(0), nilPolicy.Apply(cfg).MaxVersion)
+}
@@ -0,0 +1,144 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package sampling
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+const (
+	ReservoirExpDecay          = "exp-decay"
+	ReservoirUniform           = "uniform"
+	ReservoirSlidingTimeWindow = "sliding-time-window"
+
+	DefaultReservoirSize       = 128
+	DefaultAlpha               = 0.015
+	DefaultWindow              = time.Minute
+	DefaultWindowReservoirSize = 1028
+)
+
+// HistogramConfig defines how histograms, and the histograms backing timers, sample the values they record.
+type HistogramConfig struct {
+	// Reservoir is the sampling strategy. One of exp-decay, uniform or sliding-time-window
+	Reservoir string
+
+	// Size is the maximum number of values kept in the reservoir
+	Size int
+
+	// Alpha is the decay factor used by exp-decay reservoirs. Larger values favor recent samples more heavily
+	Alpha float64
+
+	// Window is how long values are kept in sliding-time-window reservoirs
+	Window time.Duration
+}
+
+// NewSample returns a new sample matching the configuration
+func (self *HistogramConfig) NewSample() gometrics.Sample {
+	switch self.Reservoir {
+	case ReservoirUniform:
+		return gometrics.NewUniformSample(self.Size)
+	case ReservoirSlidingTimeWindow:
+		return NewSlidingTimeWindowSample(self.Window, self.Size)
+	default:
+		return gometrics.NewExpDecaySample(self.Size, self.Alpha)
+	}
+}
+
+func (self *HistogramConfig) String() string {
+	switch self.Reservoir {
+	case ReservoirUniform:
+		return fmt.Sprintf("%s(size=%d)", self.Reservoir, self.Size)
+	case ReservoirSlidingTimeWindow:
+		return fmt.Sprintf("%s(window=%s, size=%d)", self.Reservoir, self.Window, self.Size)
+	default:
+		return fmt.Sprintf("%s(size=%d, alpha=%v)", self.Reservoir, self.Size, self.Alpha)
+	}
+}
+
+// LoadHistogramConfig parses a histograms config section, found at the given path. Example:
+//
+//	histograms:
+//	  reservoir: sliding-time-window
+//	  window: 1m
+//	  size: 1028
+func LoadHistogramConfig(value interface{}, path string) (*HistogramConfig, error) {
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid %s configuration, expected map, got %T", path, value)
+	}
+
+	result := &HistogramConfig{
+		Reservoir: ReservoirExpDecay,
+		Alpha:     DefaultAlpha,
+		Window:    DefaultWindow,
+	}
+
+	if value, found := submap["reservoir"]; found {
+		result.Reservoir = fmt.Sprintf("%v", value)
+		switch result.Reservoir {
+		case ReservoirExpDecay, ReservoirUniform, ReservoirSlidingTimeWindow:
+		default:
+			return nil, errors.Errorf("invalid %s.reservoir [%v], must be one of %s, %s or %s", path, value,
+				ReservoirExpDecay, ReservoirUniform, ReservoirSlidingTimeWindow)
+		}
+	}
+
+	if value, found := submap["size"]; found {
+		size, ok := value.(int)
+		if !ok || size < 1 {
+			return nil, errors.Errorf("invalid %s.size [%v], must be a positive integer", path, value)
+		}
+		result.Size = size
+	}
+
+	if value, found := submap["alpha"]; found {
+		alpha, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s.alpha [%v]", path, value)
+		}
+		if alpha <= 0 {
+			return nil, errors.Errorf("invalid %s.alpha [%v], must be greater than 0", path, value)
+		}
+		result.Alpha = alpha
+	}
+
+	if value, found := submap["window"]; found {
+		window, err := time.ParseDuration(fmt.Sprintf("%v", value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s.window [%v]", path, value)
+		}
+		if window <= 0 {
+			return nil, errors.Errorf("invalid %s.window [%v], must be greater than 0", path, value)
+		}
+		result.Window = window
+	}
+
+	if result.Size == 0 {
+		if result.Reservoir == ReservoirSlidingTimeWindow {
+			result.Size = DefaultWindowReservoirSize
+		} else {
+			result.Size = DefaultReservoirSize
+		}
+	}
+
+	return result, nil
+}
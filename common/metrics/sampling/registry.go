@@ -0,0 +1,366 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package sampling
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openziti/metrics"
+	"github.com/openziti/metrics/metrics_pb"
+	gometrics "github.com/rcrowley/go-metrics"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var reportedPercentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+
+// NewRegistry wraps the given registry so that histograms and timers are created using samples built from the
+// given config. All other metrics are delegated to the wrapped registry.
+func NewRegistry(registry metrics.Registry, config *HistogramConfig) metrics.Registry {
+	return newSampledRegistry(registry, config)
+}
+
+// NewUsageRegistry wraps the given usage registry so that histograms and timers are created using samples built
+// from the given config. Metrics messages reported by the wrapped registry include the sampled metrics.
+func NewUsageRegistry(registry metrics.UsageRegistry, config *HistogramConfig) metrics.UsageRegistry {
+	return &sampledUsageRegistry{
+		sampledRegistry: newSampledRegistry(registry, config),
+		usageRegistry:   registry,
+	}
+}
+
+func newSampledRegistry(registry metrics.Registry, config *HistogramConfig) *sampledRegistry {
+	return &sampledRegistry{
+		Registry: registry,
+		config:   config,
+		metrics:  map[string]metrics.Metric{},
+	}
+}
+
+type sampledRegistry struct {
+	metrics.Registry
+	config  *HistogramConfig
+	lock    sync.Mutex
+	metrics map[string]metrics.Metric
+}
+
+func (self *sampledRegistry) Histogram(name string) metrics.Histogram {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if metric, found := self.metrics[name]; found {
+		histogram, ok := metric.(*sampledHistogram)
+		if !ok {
+			panic(fmt.Errorf("metric '%v' already exists and is not a histogram. It is a %T", name, metric))
+		}
+		histogram.refCount++
+		return histogram
+	}
+
+	histogram := &sampledHistogram{
+		Histogram: gometrics.NewHistogram(self.config.NewSample()),
+		name:      name,
+		registry:  self,
+		refCount:  1,
+	}
+	self.metrics[name] = histogram
+	return histogram
+}
+
+func (self *sampledRegistry) Timer(name string) metrics.Timer {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if metric, found := self.metrics[name]; found {
+		timer, ok := metric.(*sampledTimer)
+		if !ok {
+			panic(fmt.Errorf("metric '%v' already exists and is not a timer. It is a %T", name, metric))
+		}
+		return timer
+	}
+
+	timer := &sampledTimer{
+		Timer:    gometrics.NewCustomTimer(gometrics.NewHistogram(self.config.NewSample()), gometrics.NewMeter()),
+		name:     name,
+		registry: self,
+	}
+	self.metrics[name] = timer
+	return timer
+}
+
+func (self *sampledRegistry) GetHistogram(name string) metrics.Histogram {
+	self.lock.Lock()
+	metric, found := self.metrics[name]
+	self.lock.Unlock()
+
+	if found {
+		if histogram, ok := metric.(*sampledHistogram); ok {
+			return histogram
+		}
+		return nil
+	}
+	return self.Registry.GetHistogram(name)
+}
+
+func (self *sampledRegistry) GetTimer(name string) metrics.Timer {
+	self.lock.Lock()
+	metric, found := self.metrics[name]
+	self.lock.Unlock()
+
+	if found {
+		if timer, ok := metric.(*sampledTimer); ok {
+			return timer
+		}
+		return nil
+	}
+	return self.Registry.GetTimer(name)
+}
+
+func (self *sampledRegistry) IsValidMetric(name string) bool {
+	self.lock.Lock()
+	_, found := self.metrics[name]
+	self.lock.Unlock()
+	return found || self.Registry.IsValidMetric(name)
+}
+
+func (self *sampledRegistry) EachMetric(visitor func(name string, metric metrics.Metric)) {
+	self.Registry.EachMetric(visitor)
+	for name, metric := range self.copyMetrics() {
+		visitor(name, metric)
+	}
+}
+
+func (self *sampledRegistry) Poll() *metrics_pb.MetricsMessage {
+	return self.appendTo(self.Registry.Poll())
+}
+
+func (self *sampledRegistry) AcceptVisitor(visitor metrics.Visitor) {
+	self.Registry.AcceptVisitor(visitor)
+	for name, metric := range self.copyMetrics() {
+		switch m := metric.(type) {
+		case *sampledHistogram:
+			visitor.VisitHistogram(name, m.CreateSnapshot())
+		case *sampledTimer:
+			visitor.VisitTimer(name, m.CreateSnapshot())
+		}
+	}
+}
+
+func (self *sampledRegistry) DisposeAll() {
+	self.Registry.DisposeAll()
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for _, metric := range self.metrics {
+		if timer, ok := metric.(*sampledTimer); ok {
+			timer.Stop()
+		}
+	}
+	self.metrics = map[string]metrics.Metric{}
+}
+
+func (self *sampledRegistry) copyMetrics() map[string]metrics.Metric {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	result := make(map[string]metrics.Metric, len(self.metrics))
+	for k, v := range self.metrics {
+		result[k] = v
+	}
+	return result
+}
+
+// appendTo adds the sampled histograms and timers to the given message, creating a message if needed
+func (self *sampledRegistry) appendTo(msg *metrics_pb.MetricsMessage) *metrics_pb.MetricsMessage {
+	sampled := self.copyMetrics()
+	if len(sampled) == 0 {
+		return msg
+	}
+
+	if msg == nil {
+		msg = &metrics_pb.MetricsMessage{
+			EventId:   uuid.NewString(),
+			Timestamp: timestamppb.New(time.Now()),
+			SourceId:  self.SourceId(),
+		}
+	}
+
+	for name, metric := range sampled {
+		switch m := metric.(type) {
+		case *sampledHistogram:
+			if msg.Histograms == nil {
+				msg.Histograms = map[string]*metrics_pb.MetricsMessage_Histogram{}
+			}
+			msg.Histograms[name] = toHistogramMsg(m.Snapshot())
+		case *sampledTimer:
+			if msg.Timers == nil {
+				msg.Timers = map[string]*metrics_pb.MetricsMessage_Timer{}
+			}
+			msg.Timers[name] = toTimerMsg(m.Snapshot())
+		}
+	}
+
+	return msg
+}
+
+func (self *sampledRegistry) releaseHistogram(histogram *sampledHistogram) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	histogram.refCount--
+	if histogram.refCount <= 0 && self.metrics[histogram.name] == histogram {
+		delete(self.metrics, histogram.name)
+	}
+}
+
+func (self *sampledRegistry) releaseTimer(timer *sampledTimer) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.metrics[timer.name] == timer {
+		delete(self.metrics, timer.name)
+	}
+}
+
+type sampledUsageRegistry struct {
+	*sampledRegistry
+	usageRegistry metrics.UsageRegistry
+}
+
+func (self *sampledUsageRegistry) PollWithoutUsageMetrics() *metrics_pb.MetricsMessage {
+	return self.appendTo(self.usageRegistry.PollWithoutUsageMetrics())
+}
+
+func (self *sampledUsageRegistry) IntervalCounter(name string, intervalSize time.Duration) metrics.IntervalCounter {
+	return self.usageRegistry.IntervalCounter(name, intervalSize)
+}
+
+func (self *sampledUsageRegistry) UsageCounter(name string, intervalSize time.Duration) metrics.UsageCounter {
+	return self.usageRegistry.UsageCounter(name, intervalSize)
+}
+
+func (self *sampledUsageRegistry) FlushToHandler(handler metrics.Handler) {
+	self.usageRegistry.FlushToHandler(self.wrapHandler(handler))
+}
+
+func (self *sampledUsageRegistry) StartReporting(eventSink metrics.Handler, reportInterval time.Duration, msgQueueSize int) {
+	self.usageRegistry.StartReporting(self.wrapHandler(eventSink), reportInterval, msgQueueSize)
+}
+
+func (self *sampledUsageRegistry) wrapHandler(handler metrics.Handler) metrics.Handler {
+	return handlerF(func(msg *metrics_pb.MetricsMessage) {
+		handler.AcceptMetrics(self.appendTo(msg))
+	})
+}
+
+type handlerF func(msg *metrics_pb.MetricsMessage)
+
+func (self handlerF) AcceptMetrics(msg *metrics_pb.MetricsMessage) {
+	self(msg)
+}
+
+type sampledHistogram struct {
+	gometrics.Histogram
+	name     string
+	registry *sampledRegistry
+	refCount int
+}
+
+func (self *sampledHistogram) Dispose() {
+	self.registry.releaseHistogram(self)
+}
+
+func (self *sampledHistogram) CreateSnapshot() metrics.Histogram {
+	return &histogramSnapshot{Histogram: self.Snapshot()}
+}
+
+type histogramSnapshot struct {
+	gometrics.Histogram
+}
+
+func (self *histogramSnapshot) Dispose() {}
+
+func (self *histogramSnapshot) CreateSnapshot() metrics.Histogram {
+	return self
+}
+
+type sampledTimer struct {
+	gometrics.Timer
+	name     string
+	registry *sampledRegistry
+}
+
+func (self *sampledTimer) Dispose() {
+	self.Stop()
+	self.registry.releaseTimer(self)
+}
+
+func (self *sampledTimer) CreateSnapshot() metrics.Timer {
+	return &timerSnapshot{Timer: self.Snapshot()}
+}
+
+type timerSnapshot struct {
+	gometrics.Timer
+}
+
+func (self *timerSnapshot) Dispose() {}
+
+func (self *timerSnapshot) CreateSnapshot() metrics.Timer {
+	return self
+}
+
+func toHistogramMsg(h gometrics.Histogram) *metrics_pb.MetricsMessage_Histogram {
+	ps := h.Percentiles(reportedPercentiles)
+	return &metrics_pb.MetricsMessage_Histogram{
+		Count:    h.Count(),
+		Max:      h.Max(),
+		Mean:     h.Mean(),
+		Min:      h.Min(),
+		StdDev:   h.StdDev(),
+		Variance: h.Variance(),
+		P50:      ps[0],
+		P75:      ps[1],
+		P95:      ps[2],
+		P99:      ps[3],
+		P999:     ps[4],
+		P9999:    ps[5],
+	}
+}
+
+func toTimerMsg(t gometrics.Timer) *metrics_pb.MetricsMessage_Timer {
+	ps := t.Percentiles(reportedPercentiles)
+	return &metrics_pb.MetricsMessage_Timer{
+		Count:    t.Count(),
+		Max:      t.Max(),
+		Mean:     t.Mean(),
+		Min:      t.Min(),
+		StdDev:   t.StdDev(),
+		Variance: t.Variance(),
+		P50:      ps[0],
+		P75:      ps[1],
+		P95:      ps[2],
+		P99:      ps[3],
+		P999:     ps[4],
+		P9999:    ps[5],
+		M1Rate:   t.Rate1(),
+		M5Rate:   t.Rate5(),
+		M15Rate:  t.Rate15(),
+		MeanRate: t.RateMean(),
+	}
+}
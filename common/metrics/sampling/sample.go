@@ -0,0 +1,146 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package sampling
+
+import (
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+type timedValue struct {
+	timestamp time.Time
+	value     int64
+}
+
+// SlidingTimeWindowSample keeps the values recorded during the most recent time window, so that statistics reflect
+// recent behavior only. If more than size values are recorded in the window, the oldest values are discarded.
+type SlidingTimeWindowSample struct {
+	lock   sync.Mutex
+	window time.Duration
+	size   int
+	count  int64
+	values []timedValue
+	now    func() time.Time
+}
+
+func NewSlidingTimeWindowSample(window time.Duration, size int) *SlidingTimeWindowSample {
+	if size < 1 {
+		size = DefaultWindowReservoirSize
+	}
+	return &SlidingTimeWindowSample{
+		window: window,
+		size:   size,
+		now:    time.Now,
+	}
+}
+
+// prune removes values which have aged out of the window. Must be called with the lock held
+func (self *SlidingTimeWindowSample) prune(now time.Time) {
+	cutoff := now.Add(-self.window)
+	idx := 0
+	for idx < len(self.values) && self.values[idx].timestamp.Before(cutoff) {
+		idx++
+	}
+	if idx > 0 {
+		self.values = append(self.values[:0], self.values[idx:]...)
+	}
+}
+
+func (self *SlidingTimeWindowSample) Clear() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.count = 0
+	self.values = nil
+}
+
+// Count returns the number of values recorded since the sample was created or cleared, which may exceed Size
+func (self *SlidingTimeWindowSample) Count() int64 {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.count
+}
+
+func (self *SlidingTimeWindowSample) Max() int64 {
+	return self.Snapshot().Max()
+}
+
+func (self *SlidingTimeWindowSample) Mean() float64 {
+	return self.Snapshot().Mean()
+}
+
+func (self *SlidingTimeWindowSample) Min() int64 {
+	return self.Snapshot().Min()
+}
+
+func (self *SlidingTimeWindowSample) Percentile(p float64) float64 {
+	return self.Snapshot().Percentile(p)
+}
+
+func (self *SlidingTimeWindowSample) Percentiles(ps []float64) []float64 {
+	return self.Snapshot().Percentiles(ps)
+}
+
+// Size returns the number of values currently in the window
+func (self *SlidingTimeWindowSample) Size() int {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.prune(self.now())
+	return len(self.values)
+}
+
+func (self *SlidingTimeWindowSample) Snapshot() gometrics.Sample {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.prune(self.now())
+
+	values := make([]int64, len(self.values))
+	for i, v := range self.values {
+		values[i] = v.value
+	}
+	return gometrics.NewSampleSnapshot(self.count, values)
+}
+
+func (self *SlidingTimeWindowSample) StdDev() float64 {
+	return self.Snapshot().StdDev()
+}
+
+func (self *SlidingTimeWindowSample) Sum() int64 {
+	return self.Snapshot().Sum()
+}
+
+func (self *SlidingTimeWindowSample) Update(v int64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := self.now()
+	self.count++
+	self.prune(now)
+	if len(self.values) >= self.size {
+		self.values = append(self.values[:0], self.values[len(self.values)-self.size+1:]...)
+	}
+	self.values = append(self.values, timedValue{timestamp: now, value: v})
+}
+
+func (self *SlidingTimeWindowSample) Values() []int64 {
+	return self.Snapshot().Values()
+}
+
+func (self *SlidingTimeWindowSample) Variance() float64 {
+	return self.Snapshot().Variance()
+}
@@ -0,0 +1,101 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package sampling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingTimeWindowSample(t *testing.T) {
+	req := require.New(t)
+
+	now := time.Now()
+	sample := NewSlidingTimeWindowSample(time.Minute, 3)
+	sample.now = func() time.Time {
+		return now
+	}
+
+	sample.Update(100)
+	sample.Update(200)
+	now = now.Add(45 * time.Second)
+	sample.Update(300)
+	req.Equal(3, sample.Size())
+	req.Equal(int64(100), sample.Min())
+
+	now = now.Add(30 * time.Second)
+	req.Equal(1, sample.Size())
+	req.Equal(int64(300), sample.Min())
+	req.Equal(int64(3), sample.Count())
+
+	sample.Update(400)
+	sample.Update(500)
+	sample.Update(600)
+	req.Equal([]int64{400, 500, 600}, sample.Values())
+}
+
+func TestLoadHistogramConfig(t *testing.T) {
+	req := require.New(t)
+
+	config, err := LoadHistogramConfig(map[interface{}]interface{}{
+		"reservoir": "sliding-time-window",
+		"window":    "30s",
+	}, "metrics.histograms")
+	req.NoError(err)
+	req.Equal(ReservoirSlidingTimeWindow, config.Reservoir)
+	req.Equal(30*time.Second, config.Window)
+	req.Equal(DefaultWindowReservoirSize, config.Size)
+
+	_, err = LoadHistogramConfig(map[interface{}]interface{}{"reservoir": "other"}, "metrics.histograms")
+	req.Error(err)
+
+	_, err = LoadHistogramConfig(map[interface{}]interface{}{"size": 0}, "metrics.histograms")
+	req.Error(err)
+}
+
+func TestRegistryPoll(t *testing.T) {
+	req := require.New(t)
+
+	config := &HistogramConfig{Reservoir: ReservoirUniform, Size: 10}
+	registry := NewRegistry(metrics.NewRegistry("test", nil), config)
+
+	histogram := registry.Histogram("latency")
+	req.Same(histogram, registry.Histogram("latency"))
+	histogram.Update(10)
+	histogram.Update(20)
+
+	timer := registry.Timer("work")
+	timer.Update(time.Millisecond)
+	defer timer.Dispose()
+
+	registry.Meter("rate").Mark(1)
+
+	msg := registry.Poll()
+	req.NotNil(msg)
+	req.Equal("test", msg.SourceId)
+	req.Equal(int64(2), msg.Histograms["latency"].Count)
+	req.Equal(int64(1), msg.Timers["work"].Count)
+	req.Contains(msg.Meters, "rate")
+
+	histogram.Dispose()
+	req.NotNil(registry.GetHistogram("latency"))
+	histogram.Dispose()
+	req.Nil(registry.GetHistogram("latency"))
+}
@@ -47,6 +47,10 @@ func (x *DeleteEntityCommand) GetCommandType() int32 {
 	return int32(CommandType_DeleteEntityType)
 }
 
+func (x *BatchCommand) GetCommandType() int32 {
+	return int32(CommandType_BatchType)
+}
+
 func (x *DeleteTerminatorsBatchCommand) GetCommandType() int32 {
 	return int32(CommandType_DeleteTerminatorsBatchType)
 }
@@ -125,3 +125,15 @@ func (request *LinkStateUpdate) GetContentType() int32 {
 func (request *Alerts) GetContentType() int32 {
 	return int32(ContentType_AlertsType)
 }
+
+func (request *Settings) GetContentType() int32 {
+	return int32(ContentType_SettingsType)
+}
+
+func (request *CaptureCircuitRequest) GetContentType() int32 {
+	return int32(ContentType_CaptureCircuitRequestType)
+}
+
+func (request *CaptureCircuitResponse) GetContentType() int32 {
+	return int32(ContentType_CaptureCircuitResponseType)
+}
@@ -0,0 +1,5 @@
+//go:generate protoc -I ./ ./mgmt_grpc.proto --go_out=paths=source_relative:./ --go-grpc_out=paths=source_relative:./
+
+package mgmt_grpc_pb
+
+// Here to provide the go:generate line above
@@ -0,0 +1,2097 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v3.21.12
+// source: mgmt_grpc.proto
+
+package mgmt_grpc_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A filter in the ziti query language, ex: 'name contains "foo" sort by name limit 100'. If no
+	// limit is given, the first 10 results are returned.
+	Filter        string `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_mgmt_grpc_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+type ListMeta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Limit         int64                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMeta) Reset() {
+	*x = ListMeta{}
+	mi := &file_mgmt_grpc_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMeta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMeta) ProtoMessage() {}
+
+func (x *ListMeta) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMeta.ProtoReflect.Descriptor instead.
+func (*ListMeta) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListMeta) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ListMeta) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListMeta) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_mgmt_grpc_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type EntityRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntityRef) Reset() {
+	*x = EntityRef{}
+	mi := &file_mgmt_grpc_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntityRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntityRef) ProtoMessage() {}
+
+func (x *EntityRef) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntityRef.ProtoReflect.Descriptor instead.
+func (*EntityRef) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EntityRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *EntityRef) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Service struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Id                  string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	TerminatorStrategy  string                 `protobuf:"bytes,3,opt,name=terminatorStrategy,proto3" json:"terminatorStrategy,omitempty"`
+	MaxIdleTime         *durationpb.Duration   `protobuf:"bytes,4,opt,name=maxIdleTime,proto3" json:"maxIdleTime,omitempty"`
+	CircuitBreakerState string                 `protobuf:"bytes,5,opt,name=circuitBreakerState,proto3" json:"circuitBreakerState,omitempty"`
+	Tags                *structpb.Struct       `protobuf:"bytes,6,opt,name=tags,proto3" json:"tags,omitempty"`
+	CreatedAt           *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt           *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Service) Reset() {
+	*x = Service{}
+	mi := &file_mgmt_grpc_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Service) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Service) ProtoMessage() {}
+
+func (x *Service) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Service.ProtoReflect.Descriptor instead.
+func (*Service) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Service) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Service) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Service) GetTerminatorStrategy() string {
+	if x != nil {
+		return x.TerminatorStrategy
+	}
+	return ""
+}
+
+func (x *Service) GetMaxIdleTime() *durationpb.Duration {
+	if x != nil {
+		return x.MaxIdleTime
+	}
+	return nil
+}
+
+func (x *Service) GetCircuitBreakerState() string {
+	if x != nil {
+		return x.CircuitBreakerState
+	}
+	return ""
+}
+
+func (x *Service) GetTags() *structpb.Struct {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Service) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Service) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListServicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Services      []*Service             `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+	Meta          *ListMeta              `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServicesResponse) Reset() {
+	*x = ListServicesResponse{}
+	mi := &file_mgmt_grpc_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServicesResponse) ProtoMessage() {}
+
+func (x *ListServicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServicesResponse.ProtoReflect.Descriptor instead.
+func (*ListServicesResponse) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListServicesResponse) GetServices() []*Service {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+func (x *ListServicesResponse) GetMeta() *ListMeta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type RouterListener struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Protocol      string                 `protobuf:"bytes,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouterListener) Reset() {
+	*x = RouterListener{}
+	mi := &file_mgmt_grpc_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouterListener) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouterListener) ProtoMessage() {}
+
+func (x *RouterListener) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouterListener.ProtoReflect.Descriptor instead.
+func (*RouterListener) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RouterListener) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *RouterListener) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+type Router struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Fingerprint string                 `protobuf:"bytes,3,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	Cost        uint32                 `protobuf:"varint,4,opt,name=cost,proto3" json:"cost,omitempty"`
+	NoTraversal bool                   `protobuf:"varint,5,opt,name=noTraversal,proto3" json:"noTraversal,omitempty"`
+	Disabled    bool                   `protobuf:"varint,6,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	Connected   bool                   `protobuf:"varint,7,opt,name=connected,proto3" json:"connected,omitempty"`
+	// The version of the router, if it's connected
+	Version       string                 `protobuf:"bytes,8,opt,name=version,proto3" json:"version,omitempty"`
+	Listeners     []*RouterListener      `protobuf:"bytes,9,rep,name=listeners,proto3" json:"listeners,omitempty"`
+	Tags          *structpb.Struct       `protobuf:"bytes,10,opt,name=tags,proto3" json:"tags,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Router) Reset() {
+	*x = Router{}
+	mi := &file_mgmt_grpc_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Router) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Router) ProtoMessage() {}
+
+func (x *Router) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Router.ProtoReflect.Descriptor instead.
+func (*Router) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Router) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Router) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Router) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *Router) GetCost() uint32 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *Router) GetNoTraversal() bool {
+	if x != nil {
+		return x.NoTraversal
+	}
+	return false
+}
+
+func (x *Router) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *Router) GetConnected() bool {
+	if x != nil {
+		return x.Connected
+	}
+	return false
+}
+
+func (x *Router) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Router) GetListeners() []*RouterListener {
+	if x != nil {
+		return x.Listeners
+	}
+	return nil
+}
+
+func (x *Router) GetTags() *structpb.Struct {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Router) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Router) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListRoutersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Routers       []*Router              `protobuf:"bytes,1,rep,name=routers,proto3" json:"routers,omitempty"`
+	Meta          *ListMeta              `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRoutersResponse) Reset() {
+	*x = ListRoutersResponse{}
+	mi := &file_mgmt_grpc_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRoutersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRoutersResponse) ProtoMessage() {}
+
+func (x *ListRoutersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRoutersResponse.ProtoReflect.Descriptor instead.
+func (*ListRoutersResponse) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListRoutersResponse) GetRouters() []*Router {
+	if x != nil {
+		return x.Routers
+	}
+	return nil
+}
+
+func (x *ListRoutersResponse) GetMeta() *ListMeta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type Terminator struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Service     *EntityRef             `protobuf:"bytes,2,opt,name=service,proto3" json:"service,omitempty"`
+	Router      *EntityRef             `protobuf:"bytes,3,opt,name=router,proto3" json:"router,omitempty"`
+	Binding     string                 `protobuf:"bytes,4,opt,name=binding,proto3" json:"binding,omitempty"`
+	Address     string                 `protobuf:"bytes,5,opt,name=address,proto3" json:"address,omitempty"`
+	InstanceId  string                 `protobuf:"bytes,6,opt,name=instanceId,proto3" json:"instanceId,omitempty"`
+	HostId      string                 `protobuf:"bytes,7,opt,name=hostId,proto3" json:"hostId,omitempty"`
+	Cost        uint32                 `protobuf:"varint,8,opt,name=cost,proto3" json:"cost,omitempty"`
+	DynamicCost uint32                 `protobuf:"varint,9,opt,name=dynamicCost,proto3" json:"dynamicCost,omitempty"`
+	// One of default, required or failed
+	Precedence    string                 `protobuf:"bytes,10,opt,name=precedence,proto3" json:"precedence,omitempty"`
+	Tags          *structpb.Struct       `protobuf:"bytes,11,opt,name=tags,proto3" json:"tags,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Terminator) Reset() {
+	*x = Terminator{}
+	mi := &file_mgmt_grpc_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Terminator) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Terminator) ProtoMessage() {}
+
+func (x *Terminator) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Terminator.ProtoReflect.Descriptor instead.
+func (*Terminator) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Terminator) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Terminator) GetService() *EntityRef {
+	if x != nil {
+		return x.Service
+	}
+	return nil
+}
+
+func (x *Terminator) GetRouter() *EntityRef {
+	if x != nil {
+		return x.Router
+	}
+	return nil
+}
+
+func (x *Terminator) GetBinding() string {
+	if x != nil {
+		return x.Binding
+	}
+	return ""
+}
+
+func (x *Terminator) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Terminator) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *Terminator) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
+func (x *Terminator) GetCost() uint32 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *Terminator) GetDynamicCost() uint32 {
+	if x != nil {
+		return x.DynamicCost
+	}
+	return 0
+}
+
+func (x *Terminator) GetPrecedence() string {
+	if x != nil {
+		return x.Precedence
+	}
+	return ""
+}
+
+func (x *Terminator) GetTags() *structpb.Struct {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Terminator) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Terminator) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListTerminatorsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Terminators   []*Terminator          `protobuf:"bytes,1,rep,name=terminators,proto3" json:"terminators,omitempty"`
+	Meta          *ListMeta              `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTerminatorsResponse) Reset() {
+	*x = ListTerminatorsResponse{}
+	mi := &file_mgmt_grpc_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTerminatorsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTerminatorsResponse) ProtoMessage() {}
+
+func (x *ListTerminatorsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTerminatorsResponse.ProtoReflect.Descriptor instead.
+func (*ListTerminatorsResponse) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListTerminatorsResponse) GetTerminators() []*Terminator {
+	if x != nil {
+		return x.Terminators
+	}
+	return nil
+}
+
+func (x *ListTerminatorsResponse) GetMeta() *ListMeta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type LinkConnection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	LocalAddr     string                 `protobuf:"bytes,2,opt,name=localAddr,proto3" json:"localAddr,omitempty"`
+	RemoteAddr    string                 `protobuf:"bytes,3,opt,name=remoteAddr,proto3" json:"remoteAddr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkConnection) Reset() {
+	*x = LinkConnection{}
+	mi := &file_mgmt_grpc_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkConnection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkConnection) ProtoMessage() {}
+
+func (x *LinkConnection) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkConnection.ProtoReflect.Descriptor instead.
+func (*LinkConnection) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *LinkConnection) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *LinkConnection) GetLocalAddr() string {
+	if x != nil {
+		return x.LocalAddr
+	}
+	return ""
+}
+
+func (x *LinkConnection) GetRemoteAddr() string {
+	if x != nil {
+		return x.RemoteAddr
+	}
+	return ""
+}
+
+type Link struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	SourceRouter  *EntityRef             `protobuf:"bytes,2,opt,name=sourceRouter,proto3" json:"sourceRouter,omitempty"`
+	DestRouter    *EntityRef             `protobuf:"bytes,3,opt,name=destRouter,proto3" json:"destRouter,omitempty"`
+	Protocol      string                 `protobuf:"bytes,4,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	State         string                 `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	Down          bool                   `protobuf:"varint,6,opt,name=down,proto3" json:"down,omitempty"`
+	Cost          int64                  `protobuf:"varint,7,opt,name=cost,proto3" json:"cost,omitempty"`
+	StaticCost    int32                  `protobuf:"varint,8,opt,name=staticCost,proto3" json:"staticCost,omitempty"`
+	SourceLatency int64                  `protobuf:"varint,9,opt,name=sourceLatency,proto3" json:"sourceLatency,omitempty"`
+	DestLatency   int64                  `protobuf:"varint,10,opt,name=destLatency,proto3" json:"destLatency,omitempty"`
+	Iteration     uint32                 `protobuf:"varint,11,opt,name=iteration,proto3" json:"iteration,omitempty"`
+	// The discovered path MTU, or 0 if it isn't known
+	PathMtu       uint32            `protobuf:"varint,12,opt,name=pathMtu,proto3" json:"pathMtu,omitempty"`
+	Connections   []*LinkConnection `protobuf:"bytes,13,rep,name=connections,proto3" json:"connections,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Link) Reset() {
+	*x = Link{}
+	mi := &file_mgmt_grpc_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Link) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Link) ProtoMessage() {}
+
+func (x *Link) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Link.ProtoReflect.Descriptor instead.
+func (*Link) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Link) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Link) GetSourceRouter() *EntityRef {
+	if x != nil {
+		return x.SourceRouter
+	}
+	return nil
+}
+
+func (x *Link) GetDestRouter() *EntityRef {
+	if x != nil {
+		return x.DestRouter
+	}
+	return nil
+}
+
+func (x *Link) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *Link) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Link) GetDown() bool {
+	if x != nil {
+		return x.Down
+	}
+	return false
+}
+
+func (x *Link) GetCost() int64 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *Link) GetStaticCost() int32 {
+	if x != nil {
+		return x.StaticCost
+	}
+	return 0
+}
+
+func (x *Link) GetSourceLatency() int64 {
+	if x != nil {
+		return x.SourceLatency
+	}
+	return 0
+}
+
+func (x *Link) GetDestLatency() int64 {
+	if x != nil {
+		return x.DestLatency
+	}
+	return 0
+}
+
+func (x *Link) GetIteration() uint32 {
+	if x != nil {
+		return x.Iteration
+	}
+	return 0
+}
+
+func (x *Link) GetPathMtu() uint32 {
+	if x != nil {
+		return x.PathMtu
+	}
+	return 0
+}
+
+func (x *Link) GetConnections() []*LinkConnection {
+	if x != nil {
+		return x.Connections
+	}
+	return nil
+}
+
+type ListLinksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Links         []*Link                `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	Meta          *ListMeta              `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLinksResponse) Reset() {
+	*x = ListLinksResponse{}
+	mi := &file_mgmt_grpc_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLinksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLinksResponse) ProtoMessage() {}
+
+func (x *ListLinksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLinksResponse.ProtoReflect.Descriptor instead.
+func (*ListLinksResponse) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListLinksResponse) GetLinks() []*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *ListLinksResponse) GetMeta() *ListMeta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type CircuitPath struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The ids of the routers traversed by the circuit, from initiating to terminating router
+	Nodes []string `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	// The ids of the links traversed by the circuit, from initiating to terminating router
+	Links                []string `protobuf:"bytes,2,rep,name=links,proto3" json:"links,omitempty"`
+	IngressId            string   `protobuf:"bytes,3,opt,name=ingressId,proto3" json:"ingressId,omitempty"`
+	EgressId             string   `protobuf:"bytes,4,opt,name=egressId,proto3" json:"egressId,omitempty"`
+	InitiatorLocalAddr   string   `protobuf:"bytes,5,opt,name=initiatorLocalAddr,proto3" json:"initiatorLocalAddr,omitempty"`
+	InitiatorRemoteAddr  string   `protobuf:"bytes,6,opt,name=initiatorRemoteAddr,proto3" json:"initiatorRemoteAddr,omitempty"`
+	TerminatorLocalAddr  string   `protobuf:"bytes,7,opt,name=terminatorLocalAddr,proto3" json:"terminatorLocalAddr,omitempty"`
+	TerminatorRemoteAddr string   `protobuf:"bytes,8,opt,name=terminatorRemoteAddr,proto3" json:"terminatorRemoteAddr,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *CircuitPath) Reset() {
+	*x = CircuitPath{}
+	mi := &file_mgmt_grpc_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CircuitPath) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CircuitPath) ProtoMessage() {}
+
+func (x *CircuitPath) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CircuitPath.ProtoReflect.Descriptor instead.
+func (*CircuitPath) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CircuitPath) GetNodes() []string {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *CircuitPath) GetLinks() []string {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *CircuitPath) GetIngressId() string {
+	if x != nil {
+		return x.IngressId
+	}
+	return ""
+}
+
+func (x *CircuitPath) GetEgressId() string {
+	if x != nil {
+		return x.EgressId
+	}
+	return ""
+}
+
+func (x *CircuitPath) GetInitiatorLocalAddr() string {
+	if x != nil {
+		return x.InitiatorLocalAddr
+	}
+	return ""
+}
+
+func (x *CircuitPath) GetInitiatorRemoteAddr() string {
+	if x != nil {
+		return x.InitiatorRemoteAddr
+	}
+	return ""
+}
+
+func (x *CircuitPath) GetTerminatorLocalAddr() string {
+	if x != nil {
+		return x.TerminatorLocalAddr
+	}
+	return ""
+}
+
+func (x *CircuitPath) GetTerminatorRemoteAddr() string {
+	if x != nil {
+		return x.TerminatorRemoteAddr
+	}
+	return ""
+}
+
+type Circuit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ClientId      string                 `protobuf:"bytes,2,opt,name=clientId,proto3" json:"clientId,omitempty"`
+	Service       *EntityRef             `protobuf:"bytes,3,opt,name=service,proto3" json:"service,omitempty"`
+	TerminatorId  string                 `protobuf:"bytes,4,opt,name=terminatorId,proto3" json:"terminatorId,omitempty"`
+	Path          *CircuitPath           `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	Tags          map[string]string      `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Circuit) Reset() {
+	*x = Circuit{}
+	mi := &file_mgmt_grpc_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Circuit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Circuit) ProtoMessage() {}
+
+func (x *Circuit) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Circuit.ProtoReflect.Descriptor instead.
+func (*Circuit) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Circuit) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Circuit) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *Circuit) GetService() *EntityRef {
+	if x != nil {
+		return x.Service
+	}
+	return nil
+}
+
+func (x *Circuit) GetTerminatorId() string {
+	if x != nil {
+		return x.TerminatorId
+	}
+	return ""
+}
+
+func (x *Circuit) GetPath() *CircuitPath {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+func (x *Circuit) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Circuit) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Circuit) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListCircuitsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Circuits      []*Circuit             `protobuf:"bytes,1,rep,name=circuits,proto3" json:"circuits,omitempty"`
+	Meta          *ListMeta              `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCircuitsResponse) Reset() {
+	*x = ListCircuitsResponse{}
+	mi := &file_mgmt_grpc_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCircuitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCircuitsResponse) ProtoMessage() {}
+
+func (x *ListCircuitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCircuitsResponse.ProtoReflect.Descriptor instead.
+func (*ListCircuitsResponse) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListCircuitsResponse) GetCircuits() []*Circuit {
+	if x != nil {
+		return x.Circuits
+	}
+	return nil
+}
+
+func (x *ListCircuitsResponse) GetMeta() *ListMeta {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type DeleteCircuitRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// If set, the circuit is removed without waiting for the routers to confirm that it's been torn down
+	Immediate     bool `protobuf:"varint,2,opt,name=immediate,proto3" json:"immediate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCircuitRequest) Reset() {
+	*x = DeleteCircuitRequest{}
+	mi := &file_mgmt_grpc_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCircuitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCircuitRequest) ProtoMessage() {}
+
+func (x *DeleteCircuitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCircuitRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCircuitRequest) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DeleteCircuitRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteCircuitRequest) GetImmediate() bool {
+	if x != nil {
+		return x.Immediate
+	}
+	return false
+}
+
+type DeleteCircuitResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCircuitResponse) Reset() {
+	*x = DeleteCircuitResponse{}
+	mi := &file_mgmt_grpc_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCircuitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCircuitResponse) ProtoMessage() {}
+
+func (x *DeleteCircuitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCircuitResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCircuitResponse) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{18}
+}
+
+type Subscription struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The event type, ex: circuit, link, router or fabric.usage
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Event type specific options, the same as those used when configuring event subscriptions
+	Options       *structpb.Struct `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_mgmt_grpc_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Subscription) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Subscription) GetOptions() *structpb.Struct {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type StreamEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*Subscription        `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_mgmt_grpc_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *StreamEventsRequest) GetSubscriptions() []*Subscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Json          string                 `protobuf:"bytes,2,opt,name=json,proto3" json:"json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_mgmt_grpc_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetJson() string {
+	if x != nil {
+		return x.Json
+	}
+	return ""
+}
+
+// Empty filter lists match everything
+type StreamCircuitsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceIds    []string               `protobuf:"bytes,1,rep,name=serviceIds,proto3" json:"serviceIds,omitempty"`
+	RouterIds     []string               `protobuf:"bytes,2,rep,name=routerIds,proto3" json:"routerIds,omitempty"`
+	EventTypes    []string               `protobuf:"bytes,3,rep,name=eventTypes,proto3" json:"eventTypes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamCircuitsRequest) Reset() {
+	*x = StreamCircuitsRequest{}
+	mi := &file_mgmt_grpc_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamCircuitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamCircuitsRequest) ProtoMessage() {}
+
+func (x *StreamCircuitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamCircuitsRequest.ProtoReflect.Descriptor instead.
+func (*StreamCircuitsRequest) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StreamCircuitsRequest) GetServiceIds() []string {
+	if x != nil {
+		return x.ServiceIds
+	}
+	return nil
+}
+
+func (x *StreamCircuitsRequest) GetRouterIds() []string {
+	if x != nil {
+		return x.RouterIds
+	}
+	return nil
+}
+
+func (x *StreamCircuitsRequest) GetEventTypes() []string {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+type CircuitEvent struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	EventType        string                 `protobuf:"bytes,1,opt,name=eventType,proto3" json:"eventType,omitempty"`
+	EventSrcId       string                 `protobuf:"bytes,2,opt,name=eventSrcId,proto3" json:"eventSrcId,omitempty"`
+	Timestamp        *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	CircuitId        string                 `protobuf:"bytes,4,opt,name=circuitId,proto3" json:"circuitId,omitempty"`
+	ClientId         string                 `protobuf:"bytes,5,opt,name=clientId,proto3" json:"clientId,omitempty"`
+	ServiceId        string                 `protobuf:"bytes,6,opt,name=serviceId,proto3" json:"serviceId,omitempty"`
+	TerminatorId     string                 `protobuf:"bytes,7,opt,name=terminatorId,proto3" json:"terminatorId,omitempty"`
+	InstanceId       string                 `protobuf:"bytes,8,opt,name=instanceId,proto3" json:"instanceId,omitempty"`
+	Path             *CircuitPath           `protobuf:"bytes,9,opt,name=path,proto3" json:"path,omitempty"`
+	LinkCount        uint32                 `protobuf:"varint,10,opt,name=linkCount,proto3" json:"linkCount,omitempty"`
+	Cost             uint32                 `protobuf:"varint,11,opt,name=cost,proto3" json:"cost,omitempty"`
+	FailureCause     string                 `protobuf:"bytes,12,opt,name=failureCause,proto3" json:"failureCause,omitempty"`
+	CreationTimespan *durationpb.Duration   `protobuf:"bytes,13,opt,name=creationTimespan,proto3" json:"creationTimespan,omitempty"`
+	Duration         *durationpb.Duration   `protobuf:"bytes,14,opt,name=duration,proto3" json:"duration,omitempty"`
+	Tags             map[string]string      `protobuf:"bytes,15,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CircuitEvent) Reset() {
+	*x = CircuitEvent{}
+	mi := &file_mgmt_grpc_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CircuitEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CircuitEvent) ProtoMessage() {}
+
+func (x *CircuitEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_mgmt_grpc_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CircuitEvent.ProtoReflect.Descriptor instead.
+func (*CircuitEvent) Descriptor() ([]byte, []int) {
+	return file_mgmt_grpc_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CircuitEvent) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *CircuitEvent) GetEventSrcId() string {
+	if x != nil {
+		return x.EventSrcId
+	}
+	return ""
+}
+
+func (x *CircuitEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *CircuitEvent) GetCircuitId() string {
+	if x != nil {
+		return x.CircuitId
+	}
+	return ""
+}
+
+func (x *CircuitEvent) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *CircuitEvent) GetServiceId() string {
+	if x != nil {
+		return x.ServiceId
+	}
+	return ""
+}
+
+func (x *CircuitEvent) GetTerminatorId() string {
+	if x != nil {
+		return x.TerminatorId
+	}
+	return ""
+}
+
+func (x *CircuitEvent) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *CircuitEvent) GetPath() *CircuitPath {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+func (x *CircuitEvent) GetLinkCount() uint32 {
+	if x != nil {
+		return x.LinkCount
+	}
+	return 0
+}
+
+func (x *CircuitEvent) GetCost() uint32 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *CircuitEvent) GetFailureCause() string {
+	if x != nil {
+		return x.FailureCause
+	}
+	return ""
+}
+
+func (x *CircuitEvent) GetCreationTimespan() *durationpb.Duration {
+	if x != nil {
+		return x.CreationTimespan
+	}
+	return nil
+}
+
+func (x *CircuitEvent) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *CircuitEvent) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+var File_mgmt_grpc_proto protoreflect.FileDescriptor
+
+const file_mgmt_grpc_proto_rawDesc = "" +
+	"\n" +
+	"\x0fmgmt_grpc.proto\x12\x11ziti.mgmt_grpc_pb\x1a\x1egoogle/protobuf/duration.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"%\n" +
+	"\vListRequest\x12\x16\n" +
+	"\x06filter\x18\x01 \x01(\tR\x06filter\"N\n" +
+	"\bListMeta\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x03R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"/\n" +
+	"\tEntityRef\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"\xed\x02\n" +
+	"\aService\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12.\n" +
+	"\x12terminatorStrategy\x18\x03 \x01(\tR\x12terminatorStrategy\x12;\n" +
+	"\vmaxIdleTime\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\vmaxIdleTime\x120\n" +
+	"\x13circuitBreakerState\x18\x05 \x01(\tR\x13circuitBreakerState\x12+\n" +
+	"\x04tags\x18\x06 \x01(\v2\x17.google.protobuf.StructR\x04tags\x128\n" +
+	"\tcreatedAt\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x7f\n" +
+	"\x14ListServicesResponse\x126\n" +
+	"\bservices\x18\x01 \x03(\v2\x1a.ziti.mgmt_grpc_pb.ServiceR\bservices\x12/\n" +
+	"\x04meta\x18\x02 \x01(\v2\x1b.ziti.mgmt_grpc_pb.ListMetaR\x04meta\"F\n" +
+	"\x0eRouterListener\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x1a\n" +
+	"\bprotocol\x18\x02 \x01(\tR\bprotocol\"\xba\x03\n" +
+	"\x06Router\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vfingerprint\x18\x03 \x01(\tR\vfingerprint\x12\x12\n" +
+	"\x04cost\x18\x04 \x01(\rR\x04cost\x12 \n" +
+	"\vnoTraversal\x18\x05 \x01(\bR\vnoTraversal\x12\x1a\n" +
+	"\bdisabled\x18\x06 \x01(\bR\bdisabled\x12\x1c\n" +
+	"\tconnected\x18\a \x01(\bR\tconnected\x12\x18\n" +
+	"\aversion\x18\b \x01(\tR\aversion\x12?\n" +
+	"\tlisteners\x18\t \x03(\v2!.ziti.mgmt_grpc_pb.RouterListenerR\tlisteners\x12+\n" +
+	"\x04tags\x18\n" +
+	" \x01(\v2\x17.google.protobuf.StructR\x04tags\x128\n" +
+	"\tcreatedAt\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"{\n" +
+	"\x13ListRoutersResponse\x123\n" +
+	"\arouters\x18\x01 \x03(\v2\x19.ziti.mgmt_grpc_pb.RouterR\arouters\x12/\n" +
+	"\x04meta\x18\x02 \x01(\v2\x1b.ziti.mgmt_grpc_pb.ListMetaR\x04meta\"\xed\x03\n" +
+	"\n" +
+	"Terminator\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x126\n" +
+	"\aservice\x18\x02 \x01(\v2\x1c.ziti.mgmt_grpc_pb.EntityRefR\aservice\x124\n" +
+	"\x06router\x18\x03 \x01(\v2\x1c.ziti.mgmt_grpc_pb.EntityRefR\x06router\x12\x18\n" +
+	"\abinding\x18\x04 \x01(\tR\abinding\x12\x18\n" +
+	"\aaddress\x18\x05 \x01(\tR\aaddress\x12\x1e\n" +
+	"\n" +
+	"instanceId\x18\x06 \x01(\tR\n" +
+	"instanceId\x12\x16\n" +
+	"\x06hostId\x18\a \x01(\tR\x06hostId\x12\x12\n" +
+	"\x04cost\x18\b \x01(\rR\x04cost\x12 \n" +
+	"\vdynamicCost\x18\t \x01(\rR\vdynamicCost\x12\x1e\n" +
+	"\n" +
+	"precedence\x18\n" +
+	" \x01(\tR\n" +
+	"precedence\x12+\n" +
+	"\x04tags\x18\v \x01(\v2\x17.google.protobuf.StructR\x04tags\x128\n" +
+	"\tcreatedAt\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x8b\x01\n" +
+	"\x17ListTerminatorsResponse\x12?\n" +
+	"\vterminators\x18\x01 \x03(\v2\x1d.ziti.mgmt_grpc_pb.TerminatorR\vterminators\x12/\n" +
+	"\x04meta\x18\x02 \x01(\v2\x1b.ziti.mgmt_grpc_pb.ListMetaR\x04meta\"b\n" +
+	"\x0eLinkConnection\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1c\n" +
+	"\tlocalAddr\x18\x02 \x01(\tR\tlocalAddr\x12\x1e\n" +
+	"\n" +
+	"remoteAddr\x18\x03 \x01(\tR\n" +
+	"remoteAddr\"\xd5\x03\n" +
+	"\x04Link\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12@\n" +
+	"\fsourceRouter\x18\x02 \x01(\v2\x1c.ziti.mgmt_grpc_pb.EntityRefR\fsourceRouter\x12<\n" +
+	"\n" +
+	"destRouter\x18\x03 \x01(\v2\x1c.ziti.mgmt_grpc_pb.EntityRefR\n" +
+	"destRouter\x12\x1a\n" +
+	"\bprotocol\x18\x04 \x01(\tR\bprotocol\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\tR\x05state\x12\x12\n" +
+	"\x04down\x18\x06 \x01(\bR\x04down\x12\x12\n" +
+	"\x04cost\x18\a \x01(\x03R\x04cost\x12\x1e\n" +
+	"\n" +
+	"staticCost\x18\b \x01(\x05R\n" +
+	"staticCost\x12$\n" +
+	"\rsourceLatency\x18\t \x01(\x03R\rsourceLatency\x12 \n" +
+	"\vdestLatency\x18\n" +
+	" \x01(\x03R\vdestLatency\x12\x1c\n" +
+	"\titeration\x18\v \x01(\rR\titeration\x12\x18\n" +
+	"\apathMtu\x18\f \x01(\rR\apathMtu\x12C\n" +
+	"\vconnections\x18\r \x03(\v2!.ziti.mgmt_grpc_pb.LinkConnectionR\vconnections\"s\n" +
+	"\x11ListLinksResponse\x12-\n" +
+	"\x05links\x18\x01 \x03(\v2\x17.ziti.mgmt_grpc_pb.LinkR\x05links\x12/\n" +
+	"\x04meta\x18\x02 \x01(\v2\x1b.ziti.mgmt_grpc_pb.ListMetaR\x04meta\"\xbb\x02\n" +
+	"\vCircuitPath\x12\x14\n" +
+	"\x05nodes\x18\x01 \x03(\tR\x05nodes\x12\x14\n" +
+	"\x05links\x18\x02 \x03(\tR\x05links\x12\x1c\n" +
+	"\tingressId\x18\x03 \x01(\tR\tingressId\x12\x1a\n" +
+	"\begressId\x18\x04 \x01(\tR\begressId\x12.\n" +
+	"\x12initiatorLocalAddr\x18\x05 \x01(\tR\x12initiatorLocalAddr\x120\n" +
+	"\x13initiatorRemoteAddr\x18\x06 \x01(\tR\x13initiatorRemoteAddr\x120\n" +
+	"\x13terminatorLocalAddr\x18\a \x01(\tR\x13terminatorLocalAddr\x122\n" +
+	"\x14terminatorRemoteAddr\x18\b \x01(\tR\x14terminatorRemoteAddr\"\xac\x03\n" +
+	"\aCircuit\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bclientId\x18\x02 \x01(\tR\bclientId\x126\n" +
+	"\aservice\x18\x03 \x01(\v2\x1c.ziti.mgmt_grpc_pb.EntityRefR\aservice\x12\"\n" +
+	"\fterminatorId\x18\x04 \x01(\tR\fterminatorId\x122\n" +
+	"\x04path\x18\x05 \x01(\v2\x1e.ziti.mgmt_grpc_pb.CircuitPathR\x04path\x128\n" +
+	"\x04tags\x18\x06 \x03(\v2$.ziti.mgmt_grpc_pb.Circuit.TagsEntryR\x04tags\x128\n" +
+	"\tcreatedAt\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x7f\n" +
+	"\x14ListCircuitsResponse\x126\n" +
+	"\bcircuits\x18\x01 \x03(\v2\x1a.ziti.mgmt_grpc_pb.CircuitR\bcircuits\x12/\n" +
+	"\x04meta\x18\x02 \x01(\v2\x1b.ziti.mgmt_grpc_pb.ListMetaR\x04meta\"D\n" +
+	"\x14DeleteCircuitRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\timmediate\x18\x02 \x01(\bR\timmediate\"\x17\n" +
+	"\x15DeleteCircuitResponse\"U\n" +
+	"\fSubscription\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x121\n" +
+	"\aoptions\x18\x02 \x01(\v2\x17.google.protobuf.StructR\aoptions\"\\\n" +
+	"\x13StreamEventsRequest\x12E\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2\x1f.ziti.mgmt_grpc_pb.SubscriptionR\rsubscriptions\"/\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04json\x18\x02 \x01(\tR\x04json\"u\n" +
+	"\x15StreamCircuitsRequest\x12\x1e\n" +
+	"\n" +
+	"serviceIds\x18\x01 \x03(\tR\n" +
+	"serviceIds\x12\x1c\n" +
+	"\trouterIds\x18\x02 \x03(\tR\trouterIds\x12\x1e\n" +
+	"\n" +
+	"eventTypes\x18\x03 \x03(\tR\n" +
+	"eventTypes\"\xa2\x05\n" +
+	"\fCircuitEvent\x12\x1c\n" +
+	"\teventType\x18\x01 \x01(\tR\teventType\x12\x1e\n" +
+	"\n" +
+	"eventSrcId\x18\x02 \x01(\tR\n" +
+	"eventSrcId\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1c\n" +
+	"\tcircuitId\x18\x04 \x01(\tR\tcircuitId\x12\x1a\n" +
+	"\bclientId\x18\x05 \x01(\tR\bclientId\x12\x1c\n" +
+	"\tserviceId\x18\x06 \x01(\tR\tserviceId\x12\"\n" +
+	"\fterminatorId\x18\a \x01(\tR\fterminatorId\x12\x1e\n" +
+	"\n" +
+	"instanceId\x18\b \x01(\tR\n" +
+	"instanceId\x122\n" +
+	"\x04path\x18\t \x01(\v2\x1e.ziti.mgmt_grpc_pb.CircuitPathR\x04path\x12\x1c\n" +
+	"\tlinkCount\x18\n" +
+	" \x01(\rR\tlinkCount\x12\x12\n" +
+	"\x04cost\x18\v \x01(\rR\x04cost\x12\"\n" +
+	"\ffailureCause\x18\f \x01(\tR\ffailureCause\x12E\n" +
+	"\x10creationTimespan\x18\r \x01(\v2\x19.google.protobuf.DurationR\x10creationTimespan\x125\n" +
+	"\bduration\x18\x0e \x01(\v2\x19.google.protobuf.DurationR\bduration\x12=\n" +
+	"\x04tags\x18\x0f \x03(\v2).ziti.mgmt_grpc_pb.CircuitEvent.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xcf\b\n" +
+	"\x10FabricManagement\x12W\n" +
+	"\fListServices\x12\x1e.ziti.mgmt_grpc_pb.ListRequest\x1a'.ziti.mgmt_grpc_pb.ListServicesResponse\x12G\n" +
+	"\n" +
+	"GetService\x12\x1d.ziti.mgmt_grpc_pb.GetRequest\x1a\x1a.ziti.mgmt_grpc_pb.Service\x12U\n" +
+	"\vListRouters\x12\x1e.ziti.mgmt_grpc_pb.ListRequest\x1a&.ziti.mgmt_grpc_pb.ListRoutersResponse\x12E\n" +
+	"\tGetRouter\x12\x1d.ziti.mgmt_grpc_pb.GetRequest\x1a\x19.ziti.mgmt_grpc_pb.Router\x12]\n" +
+	"\x0fListTerminators\x12\x1e.ziti.mgmt_grpc_pb.ListRequest\x1a*.ziti.mgmt_grpc_pb.ListTerminatorsResponse\x12M\n" +
+	"\rGetTerminator\x12\x1d.ziti.mgmt_grpc_pb.GetRequest\x1a\x1d.ziti.mgmt_grpc_pb.Terminator\x12Q\n" +
+	"\tListLinks\x12\x1e.ziti.mgmt_grpc_pb.ListRequest\x1a$.ziti.mgmt_grpc_pb.ListLinksResponse\x12A\n" +
+	"\aGetLink\x12\x1d.ziti.mgmt_grpc_pb.GetRequest\x1a\x17.ziti.mgmt_grpc_pb.Link\x12W\n" +
+	"\fListCircuits\x12\x1e.ziti.mgmt_grpc_pb.ListRequest\x1a'.ziti.mgmt_grpc_pb.ListCircuitsResponse\x12G\n" +
+	"\n" +
+	"GetCircuit\x12\x1d.ziti.mgmt_grpc_pb.GetRequest\x1a\x1a.ziti.mgmt_grpc_pb.Circuit\x12b\n" +
+	"\rDeleteCircuit\x12'.ziti.mgmt_grpc_pb.DeleteCircuitRequest\x1a(.ziti.mgmt_grpc_pb.DeleteCircuitResponse\x12R\n" +
+	"\fStreamEvents\x12&.ziti.mgmt_grpc_pb.StreamEventsRequest\x1a\x18.ziti.mgmt_grpc_pb.Event0\x01\x12]\n" +
+	"\x0eStreamCircuits\x12(.ziti.mgmt_grpc_pb.StreamCircuitsRequest\x1a\x1f.ziti.mgmt_grpc_pb.CircuitEvent0\x01B1Z/github.com/openziti/ziti/common/pb/mgmt_grpc_pbb\x06proto3"
+
+var (
+	file_mgmt_grpc_proto_rawDescOnce sync.Once
+	file_mgmt_grpc_proto_rawDescData []byte
+)
+
+func file_mgmt_grpc_proto_rawDescGZIP() []byte {
+	file_mgmt_grpc_proto_rawDescOnce.Do(func() {
+		file_mgmt_grpc_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mgmt_grpc_proto_rawDesc), len(file_mgmt_grpc_proto_rawDesc)))
+	})
+	return file_mgmt_grpc_proto_rawDescData
+}
+
+var file_mgmt_grpc_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_mgmt_grpc_proto_goTypes = []any{
+	(*ListRequest)(nil),             // 0: ziti.mgmt_grpc_pb.ListRequest
+	(*ListMeta)(nil),                // 1: ziti.mgmt_grpc_pb.ListMeta
+	(*GetRequest)(nil),              // 2: ziti.mgmt_grpc_pb.GetRequest
+	(*EntityRef)(nil),               // 3: ziti.mgmt_grpc_pb.EntityRef
+	(*Service)(nil),                 // 4: ziti.mgmt_grpc_pb.Service
+	(*ListServicesResponse)(nil),    // 5: ziti.mgmt_grpc_pb.ListServicesResponse
+	(*RouterListener)(nil),          // 6: ziti.mgmt_grpc_pb.RouterListener
+	(*Router)(nil),                  // 7: ziti.mgmt_grpc_pb.Router
+	(*ListRoutersResponse)(nil),     // 8: ziti.mgmt_grpc_pb.ListRoutersResponse
+	(*Terminator)(nil),              // 9: ziti.mgmt_grpc_pb.Terminator
+	(*ListTerminatorsResponse)(nil), // 10: ziti.mgmt_grpc_pb.ListTerminatorsResponse
+	(*LinkConnection)(nil),          // 11: ziti.mgmt_grpc_pb.LinkConnection
+	(*Link)(nil),                    // 12: ziti.mgmt_grpc_pb.Link
+	(*ListLinksResponse)(nil),       // 13: ziti.mgmt_grpc_pb.ListLinksResponse
+	(*CircuitPath)(nil),             // 14: ziti.mgmt_grpc_pb.CircuitPath
+	(*Circuit)(nil),                 // 15: ziti.mgmt_grpc_pb.Circuit
+	(*ListCircuitsResponse)(nil),    // 16: ziti.mgmt_grpc_pb.ListCircuitsResponse
+	(*DeleteCircuitRequest)(nil),    // 17: ziti.mgmt_grpc_pb.DeleteCircuitRequest
+	(*DeleteCircuitResponse)(nil),   // 18: ziti.mgmt_grpc_pb.DeleteCircuitResponse
+	(*Subscription)(nil),            // 19: ziti.mgmt_grpc_pb.Subscription
+	(*StreamEventsRequest)(nil),     // 20: ziti.mgmt_grpc_pb.StreamEventsRequest
+	(*Event)(nil),                   // 21: ziti.mgmt_grpc_pb.Event
+	(*StreamCircuitsRequest)(nil),   // 22: ziti.mgmt_grpc_pb.StreamCircuitsRequest
+	(*CircuitEvent)(nil),            // 23: ziti.mgmt_grpc_pb.CircuitEvent
+	nil,                             // 24: ziti.mgmt_grpc_pb.Circuit.TagsEntry
+	nil,                             // 25: ziti.mgmt_grpc_pb.CircuitEvent.TagsEntry
+	(*durationpb.Duration)(nil),     // 26: google.protobuf.Duration
+	(*structpb.Struct)(nil),         // 27: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),   // 28: google.protobuf.Timestamp
+}
+var file_mgmt_grpc_proto_depIdxs = []int32{
+	26, // 0: ziti.mgmt_grpc_pb.Service.maxIdleTime:type_name -> google.protobuf.Duration
+	27, // 1: ziti.mgmt_grpc_pb.Service.tags:type_name -> google.protobuf.Struct
+	28, // 2: ziti.mgmt_grpc_pb.Service.createdAt:type_name -> google.protobuf.Timestamp
+	28, // 3: ziti.mgmt_grpc_pb.Service.updatedAt:type_name -> google.protobuf.Timestamp
+	4,  // 4: ziti.mgmt_grpc_pb.ListServicesResponse.services:type_name -> ziti.mgmt_grpc_pb.Service
+	1,  // 5: ziti.mgmt_grpc_pb.ListServicesResponse.meta:type_name -> ziti.mgmt_grpc_pb.ListMeta
+	6,  // 6: ziti.mgmt_grpc_pb.Router.listeners:type_name -> ziti.mgmt_grpc_pb.RouterListener
+	27, // 7: ziti.mgmt_grpc_pb.Router.tags:type_name -> google.protobuf.Struct
+	28, // 8: ziti.mgmt_grpc_pb.Router.createdAt:type_name -> google.protobuf.Timestamp
+	28, // 9: ziti.mgmt_grpc_pb.Router.updatedAt:type_name -> google.protobuf.Timestamp
+	7,  // 10: ziti.mgmt_grpc_pb.ListRoutersResponse.routers:type_name -> ziti.mgmt_grpc_pb.Router
+	1,  // 11: ziti.mgmt_grpc_pb.ListRoutersResponse.meta:type_name -> ziti.mgmt_grpc_pb.ListMeta
+	3,  // 12: ziti.mgmt_grpc_pb.Terminator.service:type_name -> ziti.mgmt_grpc_pb.EntityRef
+	3,  // 13: ziti.mgmt_grpc_pb.Terminator.router:type_name -> ziti.mgmt_grpc_pb.EntityRef
+	27, // 14: ziti.mgmt_grpc_pb.Terminator.tags:type_name -> google.protobuf.Struct
+	28, // 15: ziti.mgmt_grpc_pb.Terminator.createdAt:type_name -> google.protobuf.Timestamp
+	28, // 16: ziti.mgmt_grpc_pb.Terminator.updatedAt:type_name -> google.protobuf.Timestamp
+	9,  // 17: ziti.mgmt_grpc_pb.ListTerminatorsResponse.terminators:type_name -> ziti.mgmt_grpc_pb.Terminator
+	1,  // 18: ziti.mgmt_grpc_pb.ListTerminatorsResponse.meta:type_name -> ziti.mgmt_grpc_pb.ListMeta
+	3,  // 19: ziti.mgmt_grpc_pb.Link.sourceRouter:type_name -> ziti.mgmt_grpc_pb.EntityRef
+	3,  // 20: ziti.mgmt_grpc_pb.Link.destRouter:type_name -> ziti.mgmt_grpc_pb.EntityRef
+	11, // 21: ziti.mgmt_grpc_pb.Link.connections:type_name -> ziti.mgmt_grpc_pb.LinkConnection
+	12, // 22: ziti.mgmt_grpc_pb.ListLinksResponse.links:type_name -> ziti.mgmt_grpc_pb.Link
+	1,  // 23: ziti.mgmt_grpc_pb.ListLinksResponse.meta:type_name -> ziti.mgmt_grpc_pb.ListMeta
+	3,  // 24: ziti.mgmt_grpc_pb.Circuit.service:type_name -> ziti.mgmt_grpc_pb.EntityRef
+	14, // 25: ziti.mgmt_grpc_pb.Circuit.path:type_name -> ziti.mgmt_grpc_pb.CircuitPath
+	24, // 26: ziti.mgmt_grpc_pb.Circuit.tags:type_name -> ziti.mgmt_grpc_pb.Circuit.TagsEntry
+	28, // 27: ziti.mgmt_grpc_pb.Circuit.createdAt:type_name -> google.protobuf.Timestamp
+	28, // 28: ziti.mgmt_grpc_pb.Circuit.updatedAt:type_name -> google.protobuf.Timestamp
+	15, // 29: ziti.mgmt_grpc_pb.ListCircuitsResponse.circuits:type_name -> ziti.mgmt_grpc_pb.Circuit
+	1,  // 30: ziti.mgmt_grpc_pb.ListCircuitsResponse.meta:type_name -> ziti.mgmt_grpc_pb.ListMeta
+	27, // 31: ziti.mgmt_grpc_pb.Subscription.options:type_name -> google.protobuf.Struct
+	19, // 32: ziti.mgmt_grpc_pb.StreamEventsRequest.subscriptions:type_name -> ziti.mgmt_grpc_pb.Subscription
+	28, // 33: ziti.mgmt_grpc_pb.CircuitEvent.timestamp:type_name -> google.protobuf.Timestamp
+	14, // 34: ziti.mgmt_grpc_pb.CircuitEvent.path:type_name -> ziti.mgmt_grpc_pb.CircuitPath
+	26, // 35: ziti.mgmt_grpc_pb.CircuitEvent.creationTimespan:type_name -> google.protobuf.Duration
+	26, // 36: ziti.mgmt_grpc_pb.CircuitEvent.duration:type_name -> google.protobuf.Duration
+	25, // 37: ziti.mgmt_grpc_pb.CircuitEvent.tags:type_name -> ziti.mgmt_grpc_pb.CircuitEvent.TagsEntry
+	0,  // 38: ziti.mgmt_grpc_pb.FabricManagement.ListServices:input_type -> ziti.mgmt_grpc_pb.ListRequest
+	2,  // 39: ziti.mgmt_grpc_pb.FabricManagement.GetService:input_type -> ziti.mgmt_grpc_pb.GetRequest
+	0,  // 40: ziti.mgmt_grpc_pb.FabricManagement.ListRouters:input_type -> ziti.mgmt_grpc_pb.ListRequest
+	2,  // 41: ziti.mgmt_grpc_pb.FabricManagement.GetRouter:input_type -> ziti.mgmt_grpc_pb.GetRequest
+	0,  // 42: ziti.mgmt_grpc_pb.FabricManagement.ListTerminators:input_type -> ziti.mgmt_grpc_pb.ListRequest
+	2,  // 43: ziti.mgmt_grpc_pb.FabricManagement.GetTerminator:input_type -> ziti.mgmt_grpc_pb.GetRequest
+	0,  // 44: ziti.mgmt_grpc_pb.FabricManagement.ListLinks:input_type -> ziti.mgmt_grpc_pb.ListRequest
+	2,  // 45: ziti.mgmt_grpc_pb.FabricManagement.GetLink:input_type -> ziti.mgmt_grpc_pb.GetRequest
+	0,  // 46: ziti.mgmt_grpc_pb.FabricManagement.ListCircuits:input_type -> ziti.mgmt_grpc_pb.ListRequest
+	2,  // 47: ziti.mgmt_grpc_pb.FabricManagement.GetCircuit:input_type -> ziti.mgmt_grpc_pb.GetRequest
+	17, // 48: ziti.mgmt_grpc_pb.FabricManagement.DeleteCircuit:input_type -> ziti.mgmt_grpc_pb.DeleteCircuitRequest
+	20, // 49: ziti.mgmt_grpc_pb.FabricManagement.StreamEvents:input_type -> ziti.mgmt_grpc_pb.StreamEventsRequest
+	22, // 50: ziti.mgmt_grpc_pb.FabricManagement.StreamCircuits:input_type -> ziti.mgmt_grpc_pb.StreamCircuitsRequest
+	5,  // 51: ziti.mgmt_grpc_pb.FabricManagement.ListServices:output_type -> ziti.mgmt_grpc_pb.ListServicesResponse
+	4,  // 52: ziti.mgmt_grpc_pb.FabricManagement.GetService:output_type -> ziti.mgmt_grpc_pb.Service
+	8,  // 53: ziti.mgmt_grpc_pb.FabricManagement.ListRouters:output_type -> ziti.mgmt_grpc_pb.ListRoutersResponse
+	7,  // 54: ziti.mgmt_grpc_pb.FabricManagement.GetRouter:output_type -> ziti.mgmt_grpc_pb.Router
+	10, // 55: ziti.mgmt_grpc_pb.FabricManagement.ListTerminators:output_type -> ziti.mgmt_grpc_pb.ListTerminatorsResponse
+	9,  // 56: ziti.mgmt_grpc_pb.FabricManagement.GetTerminator:output_type -> ziti.mgmt_grpc_pb.Terminator
+	13, // 57: ziti.mgmt_grpc_pb.FabricManagement.ListLinks:output_type -> ziti.mgmt_grpc_pb.ListLinksResponse
+	12, // 58: ziti.mgmt_grpc_pb.FabricManagement.GetLink:output_type -> ziti.mgmt_grpc_pb.Link
+	16, // 59: ziti.mgmt_grpc_pb.FabricManagement.ListCircuits:output_type -> ziti.mgmt_grpc_pb.ListCircuitsResponse
+	15, // 60: ziti.mgmt_grpc_pb.FabricManagement.GetCircuit:output_type -> ziti.mgmt_grpc_pb.Circuit
+	18, // 61: ziti.mgmt_grpc_pb.FabricManagement.DeleteCircuit:output_type -> ziti.mgmt_grpc_pb.DeleteCircuitResponse
+	21, // 62: ziti.mgmt_grpc_pb.FabricManagement.StreamEvents:output_type -> ziti.mgmt_grpc_pb.Event
+	23, // 63: ziti.mgmt_grpc_pb.FabricManagement.StreamCircuits:output_type -> ziti.mgmt_grpc_pb.CircuitEvent
+	51, // [51:64] is the sub-list for method output_type
+	38, // [38:51] is the sub-list for method input_type
+	38, // [38:38] is the sub-list for extension type_name
+	38, // [38:38] is the sub-list for extension extendee
+	0,  // [0:38] is the sub-list for field type_name
+}
+
+func init() { file_mgmt_grpc_proto_init() }
+func file_mgmt_grpc_proto_init() {
+	if File_mgmt_grpc_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mgmt_grpc_proto_rawDesc), len(file_mgmt_grpc_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   26,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mgmt_grpc_proto_goTypes,
+		DependencyIndexes: file_mgmt_grpc_proto_depIdxs,
+		MessageInfos:      file_mgmt_grpc_proto_msgTypes,
+	}.Build()
+	File_mgmt_grpc_proto = out.File
+	file_mgmt_grpc_proto_goTypes = nil
+	file_mgmt_grpc_proto_depIdxs = nil
+}
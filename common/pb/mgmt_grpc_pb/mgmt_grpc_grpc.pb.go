@@ -0,0 +1,598 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.21.12
+// source: mgmt_grpc.proto
+
+package mgmt_grpc_pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FabricManagement_ListServices_FullMethodName    = "/ziti.mgmt_grpc_pb.FabricManagement/ListServices"
+	FabricManagement_GetService_FullMethodName      = "/ziti.mgmt_grpc_pb.FabricManagement/GetService"
+	FabricManagement_ListRouters_FullMethodName     = "/ziti.mgmt_grpc_pb.FabricManagement/ListRouters"
+	FabricManagement_GetRouter_FullMethodName       = "/ziti.mgmt_grpc_pb.FabricManagement/GetRouter"
+	FabricManagement_ListTerminators_FullMethodName = "/ziti.mgmt_grpc_pb.FabricManagement/ListTerminators"
+	FabricManagement_GetTerminator_FullMethodName   = "/ziti.mgmt_grpc_pb.FabricManagement/GetTerminator"
+	FabricManagement_ListLinks_FullMethodName       = "/ziti.mgmt_grpc_pb.FabricManagement/ListLinks"
+	FabricManagement_GetLink_FullMethodName         = "/ziti.mgmt_grpc_pb.FabricManagement/GetLink"
+	FabricManagement_ListCircuits_FullMethodName    = "/ziti.mgmt_grpc_pb.FabricManagement/ListCircuits"
+	FabricManagement_GetCircuit_FullMethodName      = "/ziti.mgmt_grpc_pb.FabricManagement/GetCircuit"
+	FabricManagement_DeleteCircuit_FullMethodName   = "/ziti.mgmt_grpc_pb.FabricManagement/DeleteCircuit"
+	FabricManagement_StreamEvents_FullMethodName    = "/ziti.mgmt_grpc_pb.FabricManagement/StreamEvents"
+	FabricManagement_StreamCircuits_FullMethodName  = "/ziti.mgmt_grpc_pb.FabricManagement/StreamCircuits"
+)
+
+// FabricManagementClient is the client API for FabricManagement service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FabricManagement is the gRPC equivalent of the fabric management REST API. It's served by the fabric api
+// binding, over HTTP/2, and uses the same authentication as the REST API.
+type FabricManagementClient interface {
+	ListServices(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListServicesResponse, error)
+	GetService(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Service, error)
+	ListRouters(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListRoutersResponse, error)
+	GetRouter(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Router, error)
+	ListTerminators(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListTerminatorsResponse, error)
+	GetTerminator(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Terminator, error)
+	ListLinks(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListLinksResponse, error)
+	GetLink(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Link, error)
+	ListCircuits(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListCircuitsResponse, error)
+	GetCircuit(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Circuit, error)
+	DeleteCircuit(ctx context.Context, in *DeleteCircuitRequest, opts ...grpc.CallOption) (*DeleteCircuitResponse, error)
+	// StreamEvents streams controller events, formatted as JSON, the same way they're written by the event
+	// file logger. The stream ends if the client can't keep up.
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// StreamCircuits streams typed circuit events. The stream ends if the client can't keep up, so clients
+	// should re-subscribe, knowing that there's a gap in the stream.
+	StreamCircuits(ctx context.Context, in *StreamCircuitsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CircuitEvent], error)
+}
+
+type fabricManagementClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFabricManagementClient(cc grpc.ClientConnInterface) FabricManagementClient {
+	return &fabricManagementClient{cc}
+}
+
+func (c *fabricManagementClient) ListServices(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListServicesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListServicesResponse)
+	err := c.cc.Invoke(ctx, FabricManagement_ListServices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) GetService(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Service, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Service)
+	err := c.cc.Invoke(ctx, FabricManagement_GetService_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) ListRouters(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListRoutersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRoutersResponse)
+	err := c.cc.Invoke(ctx, FabricManagement_ListRouters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) GetRouter(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Router, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Router)
+	err := c.cc.Invoke(ctx, FabricManagement_GetRouter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) ListTerminators(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListTerminatorsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTerminatorsResponse)
+	err := c.cc.Invoke(ctx, FabricManagement_ListTerminators_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) GetTerminator(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Terminator, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Terminator)
+	err := c.cc.Invoke(ctx, FabricManagement_GetTerminator_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) ListLinks(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListLinksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLinksResponse)
+	err := c.cc.Invoke(ctx, FabricManagement_ListLinks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) GetLink(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Link, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Link)
+	err := c.cc.Invoke(ctx, FabricManagement_GetLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) ListCircuits(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListCircuitsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCircuitsResponse)
+	err := c.cc.Invoke(ctx, FabricManagement_ListCircuits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) GetCircuit(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Circuit, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Circuit)
+	err := c.cc.Invoke(ctx, FabricManagement_GetCircuit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) DeleteCircuit(ctx context.Context, in *DeleteCircuitRequest, opts ...grpc.CallOption) (*DeleteCircuitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCircuitResponse)
+	err := c.cc.Invoke(ctx, FabricManagement_DeleteCircuit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fabricManagementClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FabricManagement_ServiceDesc.Streams[0], FabricManagement_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FabricManagement_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *fabricManagementClient) StreamCircuits(ctx context.Context, in *StreamCircuitsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CircuitEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FabricManagement_ServiceDesc.Streams[1], FabricManagement_StreamCircuits_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamCircuitsRequest, CircuitEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FabricManagement_StreamCircuitsClient = grpc.ServerStreamingClient[CircuitEvent]
+
+// FabricManagementServer is the server API for FabricManagement service.
+// All implementations must embed UnimplementedFabricManagementServer
+// for forward compatibility.
+//
+// FabricManagement is the gRPC equivalent of the fabric management REST API. It's served by the fabric api
+// binding, over HTTP/2, and uses the same authentication as the REST API.
+type FabricManagementServer interface {
+	ListServices(context.Context, *ListRequest) (*ListServicesResponse, error)
+	GetService(context.Context, *GetRequest) (*Service, error)
+	ListRouters(context.Context, *ListRequest) (*ListRoutersResponse, error)
+	GetRouter(context.Context, *GetRequest) (*Router, error)
+	ListTerminators(context.Context, *ListRequest) (*ListTerminatorsResponse, error)
+	GetTerminator(context.Context, *GetRequest) (*Terminator, error)
+	ListLinks(context.Context, *ListRequest) (*ListLinksResponse, error)
+	GetLink(context.Context, *GetRequest) (*Link, error)
+	ListCircuits(context.Context, *ListRequest) (*ListCircuitsResponse, error)
+	GetCircuit(context.Context, *GetRequest) (*Circuit, error)
+	DeleteCircuit(context.Context, *DeleteCircuitRequest) (*DeleteCircuitResponse, error)
+	// StreamEvents streams controller events, formatted as JSON, the same way they're written by the event
+	// file logger. The stream ends if the client can't keep up.
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error
+	// StreamCircuits streams typed circuit events. The stream ends if the client can't keep up, so clients
+	// should re-subscribe, knowing that there's a gap in the stream.
+	StreamCircuits(*StreamCircuitsRequest, grpc.ServerStreamingServer[CircuitEvent]) error
+	mustEmbedUnimplementedFabricManagementServer()
+}
+
+// UnimplementedFabricManagementServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFabricManagementServer struct{}
+
+func (UnimplementedFabricManagementServer) ListServices(context.Context, *ListRequest) (*ListServicesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListServices not implemented")
+}
+func (UnimplementedFabricManagementServer) GetService(context.Context, *GetRequest) (*Service, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetService not implemented")
+}
+func (UnimplementedFabricManagementServer) ListRouters(context.Context, *ListRequest) (*ListRoutersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRouters not implemented")
+}
+func (UnimplementedFabricManagementServer) GetRouter(context.Context, *GetRequest) (*Router, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRouter not implemented")
+}
+func (UnimplementedFabricManagementServer) ListTerminators(context.Context, *ListRequest) (*ListTerminatorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTerminators not implemented")
+}
+func (UnimplementedFabricManagementServer) GetTerminator(context.Context, *GetRequest) (*Terminator, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTerminator not implemented")
+}
+func (UnimplementedFabricManagementServer) ListLinks(context.Context, *ListRequest) (*ListLinksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLinks not implemented")
+}
+func (UnimplementedFabricManagementServer) GetLink(context.Context, *GetRequest) (*Link, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLink not implemented")
+}
+func (UnimplementedFabricManagementServer) ListCircuits(context.Context, *ListRequest) (*ListCircuitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCircuits not implemented")
+}
+func (UnimplementedFabricManagementServer) GetCircuit(context.Context, *GetRequest) (*Circuit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCircuit not implemented")
+}
+func (UnimplementedFabricManagementServer) DeleteCircuit(context.Context, *DeleteCircuitRequest) (*DeleteCircuitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCircuit not implemented")
+}
+func (UnimplementedFabricManagementServer) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedFabricManagementServer) StreamCircuits(*StreamCircuitsRequest, grpc.ServerStreamingServer[CircuitEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamCircuits not implemented")
+}
+func (UnimplementedFabricManagementServer) mustEmbedUnimplementedFabricManagementServer() {}
+func (UnimplementedFabricManagementServer) testEmbeddedByValue()                          {}
+
+// UnsafeFabricManagementServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FabricManagementServer will
+// result in compilation errors.
+type UnsafeFabricManagementServer interface {
+	mustEmbedUnimplementedFabricManagementServer()
+}
+
+func RegisterFabricManagementServer(s grpc.ServiceRegistrar, srv FabricManagementServer) {
+	// If the following call pancis, it indicates UnimplementedFabricManagementServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FabricManagement_ServiceDesc, srv)
+}
+
+func _FabricManagement_ListServices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).ListServices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_ListServices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).ListServices(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_GetService_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).GetService(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_GetService_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).GetService(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_ListRouters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).ListRouters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_ListRouters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).ListRouters(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_GetRouter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).GetRouter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_GetRouter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).GetRouter(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_ListTerminators_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).ListTerminators(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_ListTerminators_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).ListTerminators(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_GetTerminator_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).GetTerminator(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_GetTerminator_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).GetTerminator(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_ListLinks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).ListLinks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_ListLinks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).ListLinks(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_GetLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).GetLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_GetLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).GetLink(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_ListCircuits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).ListCircuits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_ListCircuits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).ListCircuits(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_GetCircuit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).GetCircuit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_GetCircuit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).GetCircuit(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_DeleteCircuit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCircuitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FabricManagementServer).DeleteCircuit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FabricManagement_DeleteCircuit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FabricManagementServer).DeleteCircuit(ctx, req.(*DeleteCircuitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FabricManagement_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FabricManagementServer).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FabricManagement_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+func _FabricManagement_StreamCircuits_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamCircuitsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FabricManagementServer).StreamCircuits(m, &grpc.GenericServerStream[StreamCircuitsRequest, CircuitEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FabricManagement_StreamCircuitsServer = grpc.ServerStreamingServer[CircuitEvent]
+
+// FabricManagement_ServiceDesc is the grpc.ServiceDesc for FabricManagement service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FabricManagement_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ziti.mgmt_grpc_pb.FabricManagement",
+	HandlerType: (*FabricManagementServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListServices",
+			Handler:    _FabricManagement_ListServices_Handler,
+		},
+		{
+			MethodName: "GetService",
+			Handler:    _FabricManagement_GetService_Handler,
+		},
+		{
+			MethodName: "ListRouters",
+			Handler:    _FabricManagement_ListRouters_Handler,
+		},
+		{
+			MethodName: "GetRouter",
+			Handler:    _FabricManagement_GetRouter_Handler,
+		},
+		{
+			MethodName: "ListTerminators",
+			Handler:    _FabricManagement_ListTerminators_Handler,
+		},
+		{
+			MethodName: "GetTerminator",
+			Handler:    _FabricManagement_GetTerminator_Handler,
+		},
+		{
+			MethodName: "ListLinks",
+			Handler:    _FabricManagement_ListLinks_Handler,
+		},
+		{
+			MethodName: "GetLink",
+			Handler:    _FabricManagement_GetLink_Handler,
+		},
+		{
+			MethodName: "ListCircuits",
+			Handler:    _FabricManagement_ListCircuits_Handler,
+		},
+		{
+			MethodName: "GetCircuit",
+			Handler:    _FabricManagement_GetCircuit_Handler,
+		},
+		{
+			MethodName: "DeleteCircuit",
+			Handler:    _FabricManagement_DeleteCircuit_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _FabricManagement_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamCircuits",
+			Handler:       _FabricManagement_StreamCircuits_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mgmt_grpc.proto",
+}
@@ -100,6 +100,22 @@ func (request *RouterCircuitDetails) GetContentType() int32 {
 	return int32(ContentType_ValidateCircuitsResultType)
 }
 
+func (request *CaptureCircuitRequest) GetContentType() int32 {
+	return int32(ContentType_CaptureCircuitRequestType)
+}
+
+func (request *CaptureCircuitResponse) GetContentType() int32 {
+	return int32(ContentType_CaptureCircuitResponseType)
+}
+
+func (request *UpdateLinkDialBackoffRequest) GetContentType() int32 {
+	return int32(ContentType_UpdateLinkDialBackoffRequestType)
+}
+
+func (request *UpdateLinkDialBackoffResponse) GetContentType() int32 {
+	return int32(ContentType_UpdateLinkDialBackoffResponseType)
+}
+
 func (msg *RouterCircuitDetail) IsInErrorState() bool {
 	return msg.MissingInCtrl || msg.MissingInForwarder || msg.MissingInEdge || msg.MissingInSdk
 }
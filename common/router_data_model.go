@@ -26,15 +26,18 @@ import (
 	"os"
 	"sort"
 	"sync/atomic"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/michaelquigley/pfxlog"
 	"github.com/openziti/foundation/v2/concurrenz"
 	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	"github.com/openziti/ziti/common/schedule"
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // RouterDataModelConfig contains the configuration values for a RouterDataModel
@@ -175,6 +178,30 @@ type ServicePolicy struct {
 	PostureChecks cmap.ConcurrentMap[string, struct{}] `json:"postureChecks"`
 }
 
+// IsScheduleActive returns true if the policy has no schedule, or its schedule grants access at the given time
+func (self *ServicePolicy) IsScheduleActive(t time.Time) bool {
+	if self.ScheduleTimezone == "" && len(self.ScheduleWindows) == 0 && self.ScheduleNotBefore == nil && self.ScheduleNotAfter == nil {
+		return true
+	}
+
+	policySchedule := &schedule.Schedule{
+		Timezone: self.ScheduleTimezone,
+		Windows:  self.ScheduleWindows,
+	}
+
+	if self.ScheduleNotBefore != nil {
+		notBefore := self.ScheduleNotBefore.AsTime()
+		policySchedule.NotBefore = &notBefore
+	}
+
+	if self.ScheduleNotAfter != nil {
+		notAfter := self.ScheduleNotAfter.AsTime()
+		policySchedule.NotAfter = &notAfter
+	}
+
+	return policySchedule.IsActive(t)
+}
+
 // RouterDataModel represents a sub-set of a controller's data model. Enough to validate an identities access to dial/bind
 // a service through policies and posture checks. RouterDataModel can operate in two modes: sender (controller) and
 // receiver (router). Sender mode allows a controller support an event cache that supports replays for routers connecting
@@ -990,6 +1017,26 @@ func (rdm *RouterDataModel) GetServiceAccessPolicies(identityId string, serviceI
 	}, nil
 }
 
+// GetServiceConfigs returns the configs for the given service, keyed by config type name, including any service
+// config overrides defined on the given identity
+func (rdm *RouterDataModel) GetServiceConfigs(identityId string, serviceId string) (map[string]*IdentityConfig, error) {
+	identity, ok := rdm.Identities.Get(identityId)
+	if !ok {
+		return nil, fmt.Errorf("identity not found by id")
+	}
+
+	service, ok := rdm.Services.Get(serviceId)
+	if !ok {
+		return nil, fmt.Errorf("service not found by id")
+	}
+
+	svc := &IdentityService{
+		Service: service,
+	}
+	rdm.loadServiceConfigs(identity, svc)
+	return svc.Configs, nil
+}
+
 func CloneMap[V any](m cmap.ConcurrentMap[string, V]) cmap.ConcurrentMap[string, V] {
 	result := cmap.New[V]()
 	m.IterCb(func(key string, v V) {
@@ -1025,6 +1072,19 @@ func (rdm *RouterDataModel) SubscribeToIdentityChanges(identityId string, subscr
 	return nil
 }
 
+// UnsubscribeFromIdentityChanges removes a subscriber added with SubscribeToIdentityChanges. Once an identity has no
+// subscribers left, its subscription is removed
+func (rdm *RouterDataModel) UnsubscribeFromIdentityChanges(identityId string, subscriber IdentityEventSubscriber) {
+	pfxlog.Logger().WithField("identityId", identityId).Debug("unsubscribing from changes for identity")
+	rdm.subscriptions.RemoveCb(identityId, func(key string, v *IdentitySubscription, exists bool) bool {
+		if !exists {
+			return false
+		}
+		v.listeners.Delete(subscriber)
+		return len(v.listeners.Value()) == 0
+	})
+}
+
 func (rdm *RouterDataModel) InheritLocalData(other *RouterDataModel) {
 	other.subscriptions.IterCb(func(key string, v *IdentitySubscription) {
 		rdm.subscriptions.Set(key, v)
@@ -1201,7 +1261,7 @@ func (rdm *RouterDataModel) Diff(o *RouterDataModel, sink DiffSink) {
 	diffType("config", rdm.Configs, o.Configs, sink, Config{}, DataStateConfig{})
 	diffType("identity", rdm.Identities, o.Identities, sink, Identity{}, DataStateIdentity{})
 	diffType("service", rdm.Services, o.Services, sink, Service{}, DataStateService{})
-	diffType("service-policy", rdm.ServicePolicies, o.ServicePolicies, sink, ServicePolicy{}, DataStateServicePolicy{})
+	diffType("service-policy", rdm.ServicePolicies, o.ServicePolicies, sink, ServicePolicy{}, DataStateServicePolicy{}, timestamppb.Timestamp{})
 	diffType("posture-check", rdm.PostureChecks, o.PostureChecks, sink,
 		PostureCheck{}, DataStatePostureCheck{},
 		edge_ctrl_pb.DataState_PostureCheck_Domains_{}, edge_ctrl_pb.DataState_PostureCheck_Domains{},
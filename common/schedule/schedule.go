@@ -0,0 +1,227 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var scheduleDays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Schedule limits when access is allowed. Access is only allowed between NotBefore and NotAfter, if set, and
+// during one of the windows, if any are given.
+//
+// Windows use a cron-like format of days followed by a time range, evaluated in Timezone (UTC if not set):
+//
+//	mon-fri 09:00-17:00
+//	sat,sun 10:00-14:00
+//	* 22:00-06:00
+//
+// Days may be omitted, meaning every day, as may the time range, meaning the whole day. A time range which ends
+// before it starts extends past midnight into the following day.
+type Schedule struct {
+	Timezone  string
+	Windows   []string
+	NotBefore *time.Time
+	NotAfter  *time.Time
+}
+
+// IsActive returns true if the schedule allows access at the given time. Invalid schedules never allow access.
+func (self *Schedule) IsActive(t time.Time) bool {
+	if self.NotBefore != nil && t.Before(*self.NotBefore) {
+		return false
+	}
+
+	if self.NotAfter != nil && !t.Before(*self.NotAfter) {
+		return false
+	}
+
+	if len(self.Windows) == 0 {
+		return true
+	}
+
+	loc, err := LoadLocation(self.Timezone)
+	if err != nil {
+		return false
+	}
+
+	t = t.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+
+	for _, val := range self.Windows {
+		window, err := parseWindow(val)
+		if err != nil {
+			return false
+		}
+
+		if window.end > window.start {
+			if window.days[today] && minute >= window.start && minute < window.end {
+				return true
+			}
+		} else {
+			if window.days[today] && minute >= window.start {
+				return true
+			}
+			if window.days[yesterday] && minute < window.end {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// LoadLocation returns the location for the given timezone, or UTC if the timezone is empty
+func LoadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(timezone)
+}
+
+// ValidateWindow returns an error if the given window isn't in the expected format
+func ValidateWindow(val string) error {
+	_, err := parseWindow(val)
+	return err
+}
+
+type scheduleWindow struct {
+	days  [7]bool
+	start int
+	end   int
+}
+
+func parseWindow(val string) (*scheduleWindow, error) {
+	fields := strings.Fields(strings.ToLower(val))
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, errors.Errorf("invalid schedule window '%s', expected '<days> <start>-<end>'", val)
+	}
+
+	result := &scheduleWindow{
+		start: 0,
+		end:   24 * 60,
+	}
+
+	daySpec := "*"
+	timeSpec := ""
+	if len(fields) == 2 {
+		daySpec, timeSpec = fields[0], fields[1]
+	} else if strings.Contains(fields[0], ":") {
+		timeSpec = fields[0]
+	} else {
+		daySpec = fields[0]
+	}
+
+	if err := result.parseDays(daySpec); err != nil {
+		return nil, errors.Wrapf(err, "invalid schedule window '%s'", val)
+	}
+
+	if timeSpec != "" {
+		startSpec, endSpec, found := strings.Cut(timeSpec, "-")
+		if !found {
+			return nil, errors.Errorf("invalid schedule window '%s', time range must be in the form HH:MM-HH:MM", val)
+		}
+
+		var err error
+		if result.start, err = parseMinuteOfDay(startSpec); err != nil {
+			return nil, errors.Wrapf(err, "invalid schedule window '%s'", val)
+		}
+
+		if result.end, err = parseMinuteOfDay(endSpec); err != nil {
+			return nil, errors.Wrapf(err, "invalid schedule window '%s'", val)
+		}
+
+		if result.start == result.end {
+			return nil, errors.Errorf("invalid schedule window '%s', start and end times must differ", val)
+		}
+
+		if result.start == 24*60 {
+			return nil, errors.Errorf("invalid schedule window '%s', start time must be before 24:00", val)
+		}
+	}
+
+	return result, nil
+}
+
+func (self *scheduleWindow) parseDays(spec string) error {
+	if spec == "*" {
+		for i := range self.days {
+			self.days[i] = true
+		}
+		return nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		firstDay, ok := scheduleDays[first]
+		if !ok {
+			return errors.Errorf("unknown day '%s'", first)
+		}
+
+		if !isRange {
+			self.days[firstDay] = true
+			continue
+		}
+
+		lastDay, ok := scheduleDays[last]
+		if !ok {
+			return errors.Errorf("unknown day '%s'", last)
+		}
+
+		for day := firstDay; ; day = (day + 1) % 7 {
+			self.days[day] = true
+			if day == lastDay {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseMinuteOfDay(val string) (int, error) {
+	hourStr, minuteStr, found := strings.Cut(val, ":")
+	if !found {
+		return 0, errors.Errorf("invalid time '%s', expected HH:MM", val)
+	}
+
+	hour, err := strconv.Atoi(hourStr)
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, errors.Errorf("invalid hour in '%s'", val)
+	}
+
+	minute, err := strconv.Atoi(minuteStr)
+	if err != nil || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, errors.Errorf("invalid minute in '%s'", val)
+	}
+
+	return hour*60 + minute, nil
+}
@@ -88,3 +88,50 @@ func GetSpiffeIdFromCert(cert *x509.Certificate) (*url.URL, error) {
 
 	return spiffeId, nil
 }
+
+// VerifyX509Svid verifies that the given certificate chain is an X.509 SVID issued by one of the CAs in the given
+// trust bundle and returns the SPIFFE id along with the leaf certificate. The chain must contain exactly one non-CA
+// certificate, which must have a spiffe:// URI SAN. Any CA certificates in the chain are used as intermediates.
+func VerifyX509Svid(certs []*x509.Certificate, trustBundle *x509.CertPool) (*url.URL, *x509.Certificate, error) {
+	if trustBundle == nil {
+		return nil, nil, errors.New("no SPIFFE trust bundle available")
+	}
+
+	var leaf *x509.Certificate
+	intermediates := x509.NewCertPool()
+
+	for _, cert := range certs {
+		if cert.IsCA {
+			intermediates.AddCert(cert)
+		} else if leaf != nil {
+			return nil, nil, errors.New("multiple leaf certificates found, an X.509 SVID must have exactly one")
+		} else {
+			leaf = cert
+		}
+	}
+
+	if leaf == nil {
+		return nil, nil, errors.New("no leaf certificate found")
+	}
+
+	spiffeId, err := GetSpiffeIdFromCert(leaf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if spiffeId == nil {
+		return nil, nil, errors.New("leaf certificate has no spiffe:// URI SAN")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         trustBundle,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	if _, err = leaf.Verify(opts); err != nil {
+		return nil, nil, fmt.Errorf("X.509 SVID [%s] could not be verified against the SPIFFE trust bundle: %w", spiffeId.String(), err)
+	}
+
+	return spiffeId, leaf, nil
+}
@@ -0,0 +1,105 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package spiffehlp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCert(t *testing.T, name string, isCA bool, spiffeId string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	req := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req.NoError(err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if spiffeId != "" {
+		u, err := url.Parse(spiffeId)
+		req.NoError(err)
+		template.URIs = []*url.URL{u}
+	}
+
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	req.NoError(err)
+
+	cert, err := x509.ParseCertificate(raw)
+	req.NoError(err)
+
+	return cert, key
+}
+
+func TestVerifyX509Svid(t *testing.T) {
+	req := require.New(t)
+
+	root, rootKey := newTestCert(t, "root", true, "spiffe://example.org", nil, nil)
+	intermediate, intermediateKey := newTestCert(t, "intermediate", true, "", root, rootKey)
+	leaf, _ := newTestCert(t, "router", false, "spiffe://example.org/ziti/router/r1", intermediate, intermediateKey)
+	noSpiffeLeaf, _ := newTestCert(t, "other", false, "", intermediate, intermediateKey)
+
+	otherRoot, _ := newTestCert(t, "other-root", true, "", nil, nil)
+
+	bundle := x509.NewCertPool()
+	bundle.AddCert(root)
+
+	spiffeId, verifiedLeaf, err := VerifyX509Svid([]*x509.Certificate{leaf, intermediate}, bundle)
+	req.NoError(err)
+	req.Equal("spiffe://example.org/ziti/router/r1", spiffeId.String())
+	req.Same(leaf, verifiedLeaf)
+
+	// missing intermediate
+	_, _, err = VerifyX509Svid([]*x509.Certificate{leaf}, bundle)
+	req.Error(err)
+
+	// no spiffe id
+	_, _, err = VerifyX509Svid([]*x509.Certificate{noSpiffeLeaf, intermediate}, bundle)
+	req.Error(err)
+
+	// not issued from bundle
+	otherBundle := x509.NewCertPool()
+	otherBundle.AddCert(otherRoot)
+	_, _, err = VerifyX509Svid([]*x509.Certificate{leaf, intermediate}, otherBundle)
+	req.Error(err)
+
+	// multiple leaves
+	_, _, err = VerifyX509Svid([]*x509.Certificate{leaf, noSpiffeLeaf, intermediate}, bundle)
+	req.Error(err)
+}
@@ -0,0 +1,322 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package trustbundle holds the network trust bundle, the set of CA certificates which clients and routers need to
+// trust, along with the delta format used to distribute changes to it.
+package trustbundle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SourceController marks certificates from the controller's edge CA bundle, the roots and intermediates which
+	// issue router and identity certificates
+	SourceController = "controller"
+
+	// SourceThirdParty marks verified, auth enabled third party CAs
+	SourceThirdParty = "third-party"
+
+	// DefaultHistoryLength is how many previous bundle versions are kept, to calculate deltas from
+	DefaultHistoryLength = 32
+
+	pemTypeCertificate = "CERTIFICATE"
+)
+
+// Certificate is a single CA certificate in the trust bundle. Fingerprints are the hex encoded SHA-256 of the DER
+// encoded certificate.
+type Certificate struct {
+	Fingerprint string    `json:"fingerprint"`
+	Source      string    `json:"source"`
+	Subject     string    `json:"subject"`
+	NotAfter    time.Time `json:"notAfter"`
+	Pem         string    `json:"pem"`
+}
+
+func NewCertificate(cert *x509.Certificate, source string) *Certificate {
+	return &Certificate{
+		Fingerprint: Fingerprint(cert),
+		Source:      source,
+		Subject:     cert.Subject.String(),
+		NotAfter:    cert.NotAfter,
+		Pem:         string(pem.EncodeToMemory(&pem.Block{Type: pemTypeCertificate, Bytes: cert.Raw})),
+	}
+}
+
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// Bundle is a version of the trust bundle. The ETag identifies the set of certificates in the bundle, regardless of
+// their order.
+type Bundle struct {
+	ETag         string
+	Certificates []*Certificate
+}
+
+// NewBundle creates a bundle from the given certificates. If a certificate is given more than once, the first one
+// is kept.
+func NewBundle(certs []*Certificate) *Bundle {
+	result := &Bundle{}
+	seen := map[string]struct{}{}
+	for _, cert := range certs {
+		if _, found := seen[cert.Fingerprint]; !found {
+			seen[cert.Fingerprint] = struct{}{}
+			result.Certificates = append(result.Certificates, cert)
+		}
+	}
+	result.ETag = calculateETag(result.fingerprints())
+	return result
+}
+
+func calculateETag(fingerprints []string) string {
+	sorted := slices.Clone(fingerprints)
+	slices.Sort(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, ",")))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (self *Bundle) fingerprints() []string {
+	result := make([]string, 0, len(self.Certificates))
+	for _, cert := range self.Certificates {
+		result = append(result, cert.Fingerprint)
+	}
+	return result
+}
+
+// FilterSource returns a bundle holding only the certificates from the given source
+func (self *Bundle) FilterSource(source string) *Bundle {
+	var certs []*Certificate
+	for _, cert := range self.Certificates {
+		if cert.Source == source {
+			certs = append(certs, cert)
+		}
+	}
+	return NewBundle(certs)
+}
+
+// Pem returns the bundle as concatenated PEM encoded certificates
+func (self *Bundle) Pem() []byte {
+	buf := &bytes.Buffer{}
+	for _, cert := range self.Certificates {
+		buf.WriteString(cert.Pem)
+	}
+	return buf.Bytes()
+}
+
+// Delta describes how to get from a previous version of the bundle, identified by its ETag, to the current version.
+// If the previous version isn't known, Full is set and Added holds every certificate in the bundle.
+type Delta struct {
+	ETag    string         `json:"etag"`
+	Full    bool           `json:"full"`
+	Added   []*Certificate `json:"added"`
+	Removed []string       `json:"removed"`
+}
+
+// IsEmpty returns true if applying the delta wouldn't change anything
+func (self *Delta) IsEmpty() bool {
+	return !self.Full && len(self.Added) == 0 && len(self.Removed) == 0
+}
+
+// FilterSource drops added certificates which aren't from the given source. Removals are kept, as they only drop
+// certificates which are present.
+func (self *Delta) FilterSource(source string) *Delta {
+	result := &Delta{
+		ETag:    self.ETag,
+		Full:    self.Full,
+		Removed: self.Removed,
+	}
+	for _, cert := range self.Added {
+		if cert.Source == source {
+			result.Added = append(result.Added, cert)
+		}
+	}
+	return result
+}
+
+// History tracks the current bundle along with the fingerprints of recent versions, so deltas can be calculated for
+// clients which hold one of those versions.
+type History struct {
+	lock     sync.Mutex
+	limit    int
+	current  *Bundle
+	versions map[string][]string
+	order    []string
+}
+
+func NewHistory(limit int) *History {
+	return &History{
+		limit:    max(limit, 1),
+		current:  NewBundle(nil),
+		versions: map[string][]string{},
+	}
+}
+
+// Update makes the given bundle the current version. It returns true if the bundle differs from the previous one.
+func (self *History) Update(bundle *Bundle) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if bundle.ETag == self.current.ETag {
+		self.current = bundle
+		return false
+	}
+
+	self.current = bundle
+	if _, found := self.versions[bundle.ETag]; !found {
+		self.order = append(self.order, bundle.ETag)
+	}
+	self.versions[bundle.ETag] = bundle.fingerprints()
+
+	for len(self.order) > self.limit {
+		delete(self.versions, self.order[0])
+		self.order = self.order[1:]
+	}
+
+	return true
+}
+
+func (self *History) Current() *Bundle {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.current
+}
+
+// Delta returns the changes between the version identified by since and the current version
+func (self *History) Delta(since string) *Delta {
+	self.lock.Lock()
+	current := self.current
+	previous, found := self.versions[since]
+	self.lock.Unlock()
+
+	result := &Delta{
+		ETag: current.ETag,
+	}
+
+	if since == current.ETag {
+		return result
+	}
+
+	if !found {
+		result.Full = true
+		result.Added = current.Certificates
+		return result
+	}
+
+	previousSet := map[string]struct{}{}
+	for _, fingerprint := range previous {
+		previousSet[fingerprint] = struct{}{}
+	}
+
+	for _, cert := range current.Certificates {
+		if _, found = previousSet[cert.Fingerprint]; found {
+			delete(previousSet, cert.Fingerprint)
+		} else {
+			result.Added = append(result.Added, cert)
+		}
+	}
+
+	for _, fingerprint := range previous {
+		if _, found = previousSet[fingerprint]; found {
+			result.Removed = append(result.Removed, fingerprint)
+		}
+	}
+
+	return result
+}
+
+// Apply updates a PEM encoded CA bundle with the given delta. Certificates which are added are appended if they
+// aren't already present and certificates which are removed are dropped. Other certificates in the bundle are left
+// alone, so CAs which didn't come from the controller are kept. Returns the updated bundle and whether it changed.
+func Apply(pemBundle []byte, delta *Delta) ([]byte, bool, error) {
+	var certs []*x509.Certificate
+	rest := pemBundle
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != pemTypeCertificate {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "unable to parse certificate in CA bundle")
+		}
+		certs = append(certs, cert)
+	}
+
+	removed := map[string]struct{}{}
+	for _, fingerprint := range delta.Removed {
+		removed[fingerprint] = struct{}{}
+	}
+
+	changed := false
+	present := map[string]struct{}{}
+	buf := &bytes.Buffer{}
+
+	for _, cert := range certs {
+		fingerprint := Fingerprint(cert)
+		if _, found := removed[fingerprint]; found {
+			changed = true
+			continue
+		}
+		present[fingerprint] = struct{}{}
+		if err := pem.Encode(buf, &pem.Block{Type: pemTypeCertificate, Bytes: cert.Raw}); err != nil {
+			return nil, false, err
+		}
+	}
+
+	for _, added := range delta.Added {
+		if _, found := present[added.Fingerprint]; found {
+			continue
+		}
+		block, _ := pem.Decode([]byte(added.Pem))
+		if block == nil || block.Type != pemTypeCertificate {
+			return nil, false, errors.Errorf("invalid pem for trust bundle certificate %s", added.Fingerprint)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "unable to parse trust bundle certificate %s", added.Fingerprint)
+		}
+		if fingerprint := Fingerprint(cert); fingerprint != added.Fingerprint {
+			return nil, false, errors.Errorf("trust bundle certificate fingerprint mismatch, expected %s, got %s", added.Fingerprint, fingerprint)
+		}
+		present[added.Fingerprint] = struct{}{}
+		if err = pem.Encode(buf, block); err != nil {
+			return nil, false, err
+		}
+		changed = true
+	}
+
+	if !changed {
+		return pemBundle, false, nil
+	}
+
+	return buf.Bytes(), true, nil
+}
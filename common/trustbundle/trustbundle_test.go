@@ -0,0 +1,207 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package trustbundle
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCa(t *testing.T, name string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func toPem(certs ...*x509.Certificate) []byte {
+	var result []byte
+	for _, cert := range certs {
+		result = append(result, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return result
+}
+
+func TestBundle(t *testing.T) {
+	req := require.New(t)
+	root := newTestCa(t, "root")
+	third := newTestCa(t, "third")
+
+	bundle := NewBundle([]*Certificate{
+		NewCertificate(root, SourceController),
+		NewCertificate(third, SourceThirdParty),
+		NewCertificate(root, SourceController),
+	})
+	req.Len(bundle.Certificates, 2)
+	req.Equal(toPem(root, third), bundle.Pem())
+
+	reordered := NewBundle([]*Certificate{
+		NewCertificate(third, SourceThirdParty),
+		NewCertificate(root, SourceController),
+	})
+	req.Equal(bundle.ETag, reordered.ETag)
+
+	rootOnly := NewBundle([]*Certificate{NewCertificate(root, SourceController)})
+	req.NotEqual(bundle.ETag, rootOnly.ETag)
+
+	filtered := bundle.FilterSource(SourceController)
+	req.Equal(rootOnly.ETag, filtered.ETag)
+	req.Equal(toPem(root), filtered.Pem())
+}
+
+func TestDeltaFilterSource(t *testing.T) {
+	req := require.New(t)
+	root := newTestCa(t, "root")
+	third := newTestCa(t, "third")
+
+	delta := &Delta{
+		ETag:    "v2",
+		Full:    true,
+		Added:   []*Certificate{NewCertificate(root, SourceController), NewCertificate(third, SourceThirdParty)},
+		Removed: []string{"old"},
+	}
+
+	filtered := delta.FilterSource(SourceController)
+	req.Equal("v2", filtered.ETag)
+	req.True(filtered.Full)
+	req.Len(filtered.Added, 1)
+	req.Equal(Fingerprint(root), filtered.Added[0].Fingerprint)
+	req.Equal([]string{"old"}, filtered.Removed)
+	req.Len(delta.Added, 2)
+}
+
+func TestHistoryDelta(t *testing.T) {
+	root := newTestCa(t, "root")
+	intermediate := newTestCa(t, "intermediate")
+	third := newTestCa(t, "third")
+
+	v1 := NewBundle([]*Certificate{NewCertificate(root, SourceController), NewCertificate(intermediate, SourceController)})
+	v2 := NewBundle([]*Certificate{NewCertificate(root, SourceController), NewCertificate(third, SourceThirdParty)})
+
+	history := NewHistory(DefaultHistoryLength)
+	history.Update(v1)
+
+	t.Run("update reports changes", func(t *testing.T) {
+		req := require.New(t)
+		req.False(history.Update(v1))
+		req.True(history.Update(v2))
+		req.Equal(v2.ETag, history.Current().ETag)
+	})
+
+	t.Run("delta from current version is empty", func(t *testing.T) {
+		req := require.New(t)
+		delta := history.Delta(v2.ETag)
+		req.Equal(v2.ETag, delta.ETag)
+		req.True(delta.IsEmpty())
+	})
+
+	t.Run("delta from previous version", func(t *testing.T) {
+		req := require.New(t)
+		delta := history.Delta(v1.ETag)
+		req.Equal(v2.ETag, delta.ETag)
+		req.False(delta.Full)
+		req.Len(delta.Added, 1)
+		req.Equal(Fingerprint(third), delta.Added[0].Fingerprint)
+		req.Equal([]string{Fingerprint(intermediate)}, delta.Removed)
+	})
+
+	t.Run("delta from unknown version is full", func(t *testing.T) {
+		req := require.New(t)
+		delta := history.Delta("unknown")
+		req.True(delta.Full)
+		req.Len(delta.Added, 2)
+		req.Empty(delta.Removed)
+
+		delta = history.Delta("")
+		req.True(delta.Full)
+	})
+
+	t.Run("old versions are dropped", func(t *testing.T) {
+		req := require.New(t)
+		limited := NewHistory(1)
+		limited.Update(v1)
+		limited.Update(v2)
+		req.True(limited.Delta(v1.ETag).Full)
+	})
+}
+
+func TestApply(t *testing.T) {
+	root := newTestCa(t, "root")
+	intermediate := newTestCa(t, "intermediate")
+	third := newTestCa(t, "third")
+	local := newTestCa(t, "local")
+
+	t.Run("adds and removes certificates", func(t *testing.T) {
+		req := require.New(t)
+		delta := &Delta{
+			Added:   []*Certificate{NewCertificate(root, SourceController), NewCertificate(third, SourceThirdParty)},
+			Removed: []string{Fingerprint(intermediate)},
+		}
+
+		result, changed, err := Apply(toPem(local, root, intermediate), delta)
+		req.NoError(err)
+		req.True(changed)
+		req.Equal(toPem(local, root, third), result)
+	})
+
+	t.Run("no change", func(t *testing.T) {
+		req := require.New(t)
+		delta := &Delta{
+			Full:  true,
+			Added: []*Certificate{NewCertificate(root, SourceController)},
+		}
+
+		original := toPem(local, root)
+		result, changed, err := Apply(original, delta)
+		req.NoError(err)
+		req.False(changed)
+		req.Equal(original, result)
+	})
+
+	t.Run("rejects mismatched fingerprint", func(t *testing.T) {
+		req := require.New(t)
+		cert := NewCertificate(root, SourceController)
+		cert.Fingerprint = Fingerprint(third)
+
+		_, _, err := Apply(toPem(local), &Delta{Added: []*Certificate{cert}})
+		req.Error(err)
+	})
+}
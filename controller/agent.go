@@ -20,10 +20,11 @@ import (
 const (
 	AgentAppId byte = 1
 
-	AgentIdHeader         = 10
-	AgentAddrHeader       = 11
-	AgentIsVoterHeader    = 12
-	AgentSnapshotFileName = 13
+	AgentIdHeader          = 10
+	AgentAddrHeader        = 11
+	AgentIsVoterHeader     = 12
+	AgentSnapshotFileName  = 13
+	AgentWaitTimeoutHeader = 14
 )
 
 func (self *Controller) RegisterAgentBindHandler(bindHandler channel.BindHandler) {
@@ -142,6 +143,13 @@ func (self *Controller) agentOpRaftAddPeer(m *channel.Message, ch channel.Channe
 		return
 	}
 
+	if timeout, wait := getAgentWaitTimeout(m); wait {
+		if err := self.raftController.WaitForPeerAdded(addr, isVoter, timeout); err != nil {
+			handler_common.SendOpResult(m, ch, "cluster.add-peer", err.Error(), false)
+			return
+		}
+	}
+
 	handler_common.SendOpResult(m, ch, "cluster.add-peer", fmt.Sprintf("success, added peer at %v to cluster", addr), true)
 }
 
@@ -203,6 +211,14 @@ func (self *Controller) agentOpRaftRemovePeer(m *channel.Message, ch channel.Cha
 		handler_common.SendOpResult(m, ch, "cluster.remove-peer", err.Error(), false)
 		return
 	}
+
+	if timeout, wait := getAgentWaitTimeout(m); wait {
+		if err := self.raftController.WaitForPeerRemoved(id, timeout); err != nil {
+			handler_common.SendOpResult(m, ch, "cluster.remove-peer", err.Error(), false)
+			return
+		}
+	}
+
 	handler_common.SendOpResult(m, ch, "cluster.remove-peer", fmt.Sprintf("success, removed %v from cluster", id), true)
 }
 
@@ -217,13 +233,33 @@ func (self *Controller) agentOpRaftTransferLeadership(m *channel.Message, ch cha
 		Id: id,
 	}
 
+	_, previousLeaderId := self.raftController.GetRaft().LeaderWithID()
+
 	if err := self.raftController.HandleTransferLeadership(req); err != nil {
 		handler_common.SendOpResult(m, ch, "cluster.transfer-leadership", err.Error(), false)
 		return
 	}
+
+	if timeout, wait := getAgentWaitTimeout(m); wait {
+		if err := self.raftController.WaitForLeaderChange(string(previousLeaderId), id, timeout); err != nil {
+			handler_common.SendOpResult(m, ch, "cluster.transfer-leadership", err.Error(), false)
+			return
+		}
+	}
 	handler_common.SendOpResult(m, ch, "cluster.transfer-leadership", "success", true)
 }
 
+// getAgentWaitTimeout returns how long a cluster membership operation should wait for the change to be applied
+// locally. Membership changes are forwarded to the leader, so without waiting the change may not yet be visible on
+// this node when the operation returns.
+func getAgentWaitTimeout(m *channel.Message) (time.Duration, bool) {
+	timeoutMillis, found := m.GetUint64Header(AgentWaitTimeoutHeader)
+	if !found || timeoutMillis == 0 {
+		return 0, false
+	}
+	return time.Duration(timeoutMillis) * time.Millisecond, true
+}
+
 func (self *Controller) agentOpInitFromDb(m *channel.Message, ch channel.Channel) {
 	if self.raftController == nil {
 		handler_common.SendOpResult(m, ch, "cluster.init-from-db", "controller not running in clustered mode", false)
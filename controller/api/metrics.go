@@ -0,0 +1,156 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package api
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/openziti/metrics"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MetricApiRequestLatency is a timer, which provides both the request count and the latency histogram
+	MetricApiRequestLatency = "api.request.latency"
+
+	// MetricApiRequestClientErrors is a meter of requests which were answered with a 4xx status
+	MetricApiRequestClientErrors = "api.request.client_errors"
+
+	// MetricApiRequestServerErrors is a meter of requests which were answered with a 5xx status
+	MetricApiRequestServerErrors = "api.request.server_errors"
+
+	// UnmatchedRoute is the route reported for requests which were rejected before being routed to an operation, for
+	// example because they were rate limited, or because no operation exists for the path
+	UnmatchedRoute = "unmatched"
+
+	apiMetricPrefix    = "api.request."
+	apiMetricSeparator = ":"
+)
+
+type metricsRouteKeyType string
+
+const metricsRouteKey = metricsRouteKeyType("ziti-metrics-route")
+
+// ApiMetricName returns the name of an API request metric. The metrics registry doesn't support per-metric tags, so
+// the api, method and route are encoded in the name. ParseApiMetricName recovers them, so they can be reported as
+// tags in metrics events.
+func ApiMetricName(metric, apiName, method, route string) string {
+	return strings.Join([]string{metric, apiName, method, route}, apiMetricSeparator)
+}
+
+// ParseApiMetricName splits a name created by ApiMetricName back into the metric, api, method and route
+func ParseApiMetricName(name string) (metric, apiName, method, route string, ok bool) {
+	if !strings.HasPrefix(name, apiMetricPrefix) {
+		return "", "", "", "", false
+	}
+	parts := strings.SplitN(name, apiMetricSeparator, 4)
+	if len(parts) != 4 {
+		return "", "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], parts[3], true
+}
+
+// SetMetricsRoute records the route a request was handled by, for requests which are served outside the generated
+// OpenAPI router. It does nothing if the request isn't being measured.
+func SetMetricsRoute(r *http.Request, route string) {
+	if holder, ok := r.Context().Value(metricsRouteKey).(*atomic.Pointer[string]); ok {
+		holder.Store(&route)
+	}
+}
+
+// MetricsRouteBuilder is an OpenAPI middleware.Builder which records the route pattern matched by the OpenAPI router,
+// such as /services/{id}, so that requests measured by WrapMetricsHandler can be reported by route rather than by
+// path. It should be passed to the Serve method of the generated API.
+func MetricsRouteBuilder(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if route := middleware.MatchedRouteFrom(r); route != nil {
+			SetMetricsRoute(r, route.PathPattern)
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// WrapMetricsHandler returns a handler which records the latency of every request to the given API, along with the
+// number of requests which failed with client and server errors. Metrics are reported per api, method and route.
+func WrapMetricsHandler(next http.Handler, registry metrics.Registry, apiName string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		route := &atomic.Pointer[string]{}
+		r = r.WithContext(context.WithValue(r.Context(), metricsRouteKey, route))
+
+		sw := &statusWriter{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		routeName := UnmatchedRoute
+		if matched := route.Load(); matched != nil {
+			routeName = *matched
+		}
+
+		registry.Timer(ApiMetricName(MetricApiRequestLatency, apiName, r.Method, routeName)).UpdateSince(start)
+
+		if sw.status >= 500 {
+			registry.Meter(ApiMetricName(MetricApiRequestServerErrors, apiName, r.Method, routeName)).Mark(1)
+		} else if sw.status >= 400 {
+			registry.Meter(ApiMetricName(MetricApiRequestClientErrors, apiName, r.Method, routeName)).Mark(1)
+		}
+	})
+}
+
+// statusWriter captures the status code written to the wrapped ResponseWriter
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (self *statusWriter) WriteHeader(status int) {
+	if !self.wroteHeader {
+		self.status = status
+		self.wroteHeader = true
+	}
+	self.ResponseWriter.WriteHeader(status)
+}
+
+func (self *statusWriter) Write(b []byte) (int, error) {
+	self.wroteHeader = true
+	return self.ResponseWriter.Write(b)
+}
+
+func (self *statusWriter) Flush() {
+	if flusher, ok := self.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (self *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := self.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("underlying response writer does not support hijacking")
+}
+
+func (self *statusWriter) Unwrap() http.ResponseWriter {
+	return self.ResponseWriter
+}
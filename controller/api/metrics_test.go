@@ -0,0 +1,78 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openziti/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapMetricsHandler(t *testing.T) {
+	req := require.New(t)
+
+	registry := metrics.NewRegistry("test", nil)
+	defer registry.DisposeAll()
+
+	handler := WrapMetricsHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/services/1234", "/services/5678":
+			SetMetricsRoute(r, "/services/{id}")
+			rw.WriteHeader(http.StatusOK)
+		case "/services/broken":
+			SetMetricsRoute(r, "/services/{id}")
+			rw.WriteHeader(http.StatusInternalServerError)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}), registry, "edge-management")
+
+	for _, path := range []string{"/services/1234", "/services/5678", "/services/broken", "/unknown"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	latency := registry.GetTimer(ApiMetricName(MetricApiRequestLatency, "edge-management", http.MethodGet, "/services/{id}"))
+	req.NotNil(latency)
+	req.Equal(int64(3), latency.Count())
+
+	serverErrors := registry.GetMeter(ApiMetricName(MetricApiRequestServerErrors, "edge-management", http.MethodGet, "/services/{id}"))
+	req.NotNil(serverErrors)
+	req.Equal(int64(1), serverErrors.Count())
+
+	clientErrors := registry.GetMeter(ApiMetricName(MetricApiRequestClientErrors, "edge-management", http.MethodGet, UnmatchedRoute))
+	req.NotNil(clientErrors)
+	req.Equal(int64(1), clientErrors.Count())
+
+	req.Nil(registry.GetMeter(ApiMetricName(MetricApiRequestClientErrors, "edge-management", http.MethodGet, "/services/{id}")))
+}
+
+func TestParseApiMetricName(t *testing.T) {
+	req := require.New(t)
+
+	metric, apiName, method, route, ok := ParseApiMetricName(ApiMetricName(MetricApiRequestLatency, "fabric", http.MethodPut, "/routers/{id}"))
+	req.True(ok)
+	req.Equal(MetricApiRequestLatency, metric)
+	req.Equal("fabric", apiName)
+	req.Equal(http.MethodPut, method)
+	req.Equal("/routers/{id}", route)
+
+	_, _, _, _, ok = ParseApiMetricName("ctrl.latency:ctrl1")
+	req.False(ok)
+}
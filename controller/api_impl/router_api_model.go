@@ -17,6 +17,9 @@
 package api_impl
 
 import (
+	"time"
+
+	"github.com/go-openapi/strfmt"
 	"github.com/openziti/foundation/v2/util"
 	"github.com/openziti/ziti/controller/api"
 	"github.com/openziti/ziti/controller/model"
@@ -86,11 +89,18 @@ func MapPatchRouterToModel(id string, router *rest_model.RouterPatch) *model.Rou
 			Tags: TagsOrDefault(router.Tags),
 			Id:   id,
 		},
-		Name:        router.Name,
-		Fingerprint: router.Fingerprint,
-		Cost:        uint16(Int64OrDefault(router.Cost)),
-		NoTraversal: BoolOrDefault(router.NoTraversal),
-		Disabled:    BoolOrDefault(router.Disabled),
+		Name:          router.Name,
+		Fingerprint:   router.Fingerprint,
+		Cost:          uint16(Int64OrDefault(router.Cost)),
+		NoTraversal:   BoolOrDefault(router.NoTraversal),
+		Disabled:      BoolOrDefault(router.Disabled),
+		Draining:      BoolOrDefault(router.Draining),
+		DrainDeadline: (*time.Time)(router.DrainDeadline),
+	}
+
+	if router.DrainTimeoutSeconds != nil {
+		drainDeadline := time.Now().Add(time.Duration(*router.DrainTimeoutSeconds) * time.Second)
+		ret.DrainDeadline = &drainDeadline
 	}
 
 	return ret
@@ -115,14 +125,16 @@ func (RouterModelMapper) ToApi(n *network.Network, _ api.RequestContext, router
 	isConnected := connected != nil
 	cost := int64(router.Cost)
 	ret := &rest_model.RouterDetail{
-		BaseEntity:  BaseEntityToRestModel(router, RouterLinkFactory),
-		Fingerprint: router.Fingerprint,
-		Name:        &router.Name,
-		Connected:   &isConnected,
-		VersionInfo: restVersionInfo,
-		Cost:        &cost,
-		NoTraversal: &router.NoTraversal,
-		Disabled:    &router.Disabled,
+		BaseEntity:    BaseEntityToRestModel(router, RouterLinkFactory),
+		Fingerprint:   router.Fingerprint,
+		Name:          &router.Name,
+		Connected:     &isConnected,
+		VersionInfo:   restVersionInfo,
+		Cost:          &cost,
+		NoTraversal:   &router.NoTraversal,
+		Disabled:      &router.Disabled,
+		Draining:      router.Draining,
+		DrainDeadline: (*strfmt.DateTime)(router.DrainDeadline),
 	}
 
 	if connected != nil {
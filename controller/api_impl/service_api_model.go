@@ -26,6 +26,7 @@ import (
 
 	"github.com/openziti/foundation/v2/stringz"
 	"github.com/openziti/ziti/controller/models"
+	"time"
 )
 
 const EntityNameService = "services"
@@ -55,6 +56,8 @@ func MapCreateServiceToModel(service *rest_model.ServiceCreate) *model.Service {
 		},
 		Name:               stringz.OrEmpty(service.Name),
 		TerminatorStrategy: service.TerminatorStrategy,
+		MaxIdleTime:        time.Duration(service.MaxIdleTimeMillis) * time.Millisecond,
+		MaxCircuitLifetime: time.Duration(service.MaxCircuitLifetimeMillis) * time.Millisecond,
 	}
 
 	if ret.Id == "" {
@@ -76,6 +79,8 @@ func MapUpdateServiceToModel(id string, service *rest_model.ServiceUpdate) *mode
 		},
 		Name:               stringz.OrEmpty(service.Name),
 		TerminatorStrategy: service.TerminatorStrategy,
+		MaxIdleTime:        time.Duration(service.MaxIdleTimeMillis) * time.Millisecond,
+		MaxCircuitLifetime: time.Duration(service.MaxCircuitLifetimeMillis) * time.Millisecond,
 	}
 
 	return ret
@@ -89,6 +94,8 @@ func MapPatchServiceToModel(id string, service *rest_model.ServicePatch) *model.
 		},
 		Name:               service.Name,
 		TerminatorStrategy: service.TerminatorStrategy,
+		MaxIdleTime:        time.Duration(service.MaxIdleTimeMillis) * time.Millisecond,
+		MaxCircuitLifetime: time.Duration(service.MaxCircuitLifetimeMillis) * time.Millisecond,
 	}
 
 	return ret
@@ -96,10 +103,13 @@ func MapPatchServiceToModel(id string, service *rest_model.ServicePatch) *model.
 
 type ServiceModelMapper struct{}
 
-func (ServiceModelMapper) ToApi(_ *network.Network, _ api.RequestContext, service *model.Service) (interface{}, error) {
+func (ServiceModelMapper) ToApi(n *network.Network, _ api.RequestContext, service *model.Service) (interface{}, error) {
 	return &rest_model.ServiceDetail{
-		BaseEntity:         BaseEntityToRestModel(service, ServiceLinkFactory),
-		Name:               &service.Name,
-		TerminatorStrategy: &service.TerminatorStrategy,
+		BaseEntity:               BaseEntityToRestModel(service, ServiceLinkFactory),
+		CircuitBreakerState:      n.GetServiceCircuitBreakerState(service.Id),
+		MaxCircuitLifetimeMillis: service.MaxCircuitLifetime.Milliseconds(),
+		MaxIdleTimeMillis:        service.MaxIdleTime.Milliseconds(),
+		Name:                     &service.Name,
+		TerminatorStrategy:       &service.TerminatorStrategy,
 	}, nil
 }
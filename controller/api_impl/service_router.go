@@ -102,7 +102,9 @@ func (r *ServiceRouter) Update(n *network.Network, rc api.RequestContext, params
 
 func (r *ServiceRouter) Patch(n *network.Network, rc api.RequestContext, params service.PatchServiceParams) {
 	Patch(rc, func(id string, fields fields.UpdatedFields) error {
-		return n.Managers.Service.Update(MapPatchServiceToModel(params.ID, params.Service), fields.FilterMaps("tags"), rc.NewChangeContext())
+		return n.Managers.Service.Update(MapPatchServiceToModel(params.ID, params.Service), fields.FilterMaps("tags").
+			MapField("maxIdleTimeMillis", "maxIdleTime").
+			MapField("maxCircuitLifetimeMillis", "maxCircuitLifetime"), rc.NewChangeContext())
 	})
 }
 
@@ -297,6 +297,15 @@ func NewInvalidPosture(cause error) *errorz.ApiError {
 	}
 }
 
+func NewOutsideSchedule(cause error) *errorz.ApiError {
+	return &errorz.ApiError{
+		Cause:   cause,
+		Code:    OutsideScheduleCode,
+		Message: OutsideScheduleMessage,
+		Status:  OutsideScheduleStatus,
+	}
+}
+
 func NewMfaExistsError() *errorz.ApiError {
 	return &errorz.ApiError{
 		Code:    MfaExistsCode,
@@ -329,6 +338,31 @@ func NewInvalidMfaTokenError() *errorz.ApiError {
 	}
 }
 
+func NewTpmAttestationDisabled() *errorz.ApiError {
+	return &errorz.ApiError{
+		Code:    TpmAttestationDisabledCode,
+		Message: TpmAttestationDisabledMessage,
+		Status:  TpmAttestationDisabledStatus,
+	}
+}
+
+func NewTpmChallengeNotFound() *errorz.ApiError {
+	return &errorz.ApiError{
+		Code:    TpmChallengeNotFoundCode,
+		Message: TpmChallengeNotFoundMessage,
+		Status:  TpmChallengeNotFoundStatus,
+	}
+}
+
+func NewTpmAttestationFailed(cause error) *errorz.ApiError {
+	return &errorz.ApiError{
+		Cause:   cause,
+		Code:    TpmAttestationFailedCode,
+		Message: TpmAttestationFailedMessage,
+		Status:  TpmAttestationFailedStatus,
+	}
+}
+
 func NewInvalidBackingTokenTypeError() *errorz.ApiError {
 	return &errorz.ApiError{
 		Code:    InvalidBackingTokenTypeCode,
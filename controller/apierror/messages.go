@@ -150,6 +150,10 @@ const (
 	InvalidPostureMessage string = "Posture response data is missing or wrong"
 	InvalidPostureStatus  int    = http.StatusConflict
 
+	OutsideScheduleCode    string = "OUTSIDE_SCHEDULE"
+	OutsideScheduleMessage string = "Access to the service is not allowed at this time by the schedules of the granting service policies"
+	OutsideScheduleStatus  int    = http.StatusConflict
+
 	MfaExistsCode    string = "MFA_EXISTS"
 	MfaExistsMessage string = "An MFA record already exists, try removing it"
 	MfaExistsStatus  int    = http.StatusConflict
@@ -171,6 +175,18 @@ const (
 	MfaNotEnrolledMessage string = "The current identity is not enrolled in MFA"
 	MfaNotEnrolledStatus  int    = http.StatusConflict
 
+	TpmAttestationDisabledCode    string = "TPM_ATTESTATION_DISABLED"
+	TpmAttestationDisabledMessage string = "TPM attestation is not enabled on this controller"
+	TpmAttestationDisabledStatus  int    = http.StatusNotFound
+
+	TpmChallengeNotFoundCode    string = "TPM_CHALLENGE_NOT_FOUND"
+	TpmChallengeNotFoundMessage string = "No TPM attestation challenge is outstanding for the current API session, or it has expired"
+	TpmChallengeNotFoundStatus  int    = http.StatusConflict
+
+	TpmAttestationFailedCode    string = "TPM_ATTESTATION_FAILED"
+	TpmAttestationFailedMessage string = "The TPM attestation could not be verified, see cause"
+	TpmAttestationFailedStatus  int    = http.StatusBadRequest
+
 	CouldNotDecodeProxiedCertCode    string = "COULD_NOT_PARSE_PROXY_CERT"
 	CouldNotDecodeProxiedCertMessage string = "could not decode proxy client cert"
 	CouldNotDecodeProxiedCertStatus  int    = http.StatusInternalServerError
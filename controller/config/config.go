@@ -32,6 +32,7 @@ import (
 	transporttls "github.com/openziti/transport/v2/tls"
 	"github.com/openziti/ziti/common"
 	"github.com/openziti/ziti/common/config"
+	"github.com/openziti/ziti/common/metrics/sampling"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
 	"github.com/openziti/ziti/common/pb/mgmt_pb"
 	"github.com/openziti/ziti/controller/command"
@@ -51,6 +52,7 @@ const (
 	DefaultHealthChecksBoltCheckInterval     = 30 * time.Second
 	DefaultHealthChecksBoltCheckTimeout      = 20 * time.Second
 	DefaultHealthChecksBoltCheckInitialDelay = 30 * time.Second
+	DefaultIntegrityCheckOnStartup           = true
 
 	DefaultRaftCommandHandlerMaxQueueSize = 250
 
@@ -96,6 +98,7 @@ type Config struct {
 	Edge    *EdgeConfig
 	Db      boltz.Db
 	Trace   struct {
+		Path    string
 		Handler *channel.TraceHandler
 	}
 	Profile struct {
@@ -111,17 +114,35 @@ type Config struct {
 		Listener transport.Address
 		Options  *CtrlOptions
 	}
-	HealthChecks struct {
-		BoltCheck struct {
-			Interval     time.Duration
-			Timeout      time.Duration
-			InitialDelay time.Duration
-		}
-	}
+	HealthChecks            HealthChecksConfig
+	Metrics                 MetricsConfig
 	RouterDataModel         common.RouterDataModelConfig
 	CommandRateLimiter      command.RateLimiterConfig
+	TlsHandshakeTimeout     time.Duration
 	TlsHandshakeRateLimiter command.AdaptiveRateLimiterConfig
+	SpiffeEnrollment        *SpiffeEnrollmentConfig
+	Tracing                 *TracingConfig
+	Webhooks                []*WebhookConfig
+	EventStore              *EventStoreConfig
 	Src                     map[interface{}]interface{}
+	path                    string
+}
+
+type MetricsConfig struct {
+	// Histograms configures how histograms and timers sample values. If nil, the metrics library defaults are used
+	Histograms *sampling.HistogramConfig
+}
+
+type HealthChecksConfig struct {
+	BoltCheck struct {
+		Interval     time.Duration
+		Timeout      time.Duration
+		InitialDelay time.Duration
+	}
+	IntegrityCheck struct {
+		// OnStartup enables a quick, read-only check of the database's referential integrity at startup
+		OnStartup bool
+	}
 }
 
 func (self *Config) ToJson() (string, error) {
@@ -191,6 +212,7 @@ func LoadConfig(path string) (*Config, error) {
 	controllerConfig := &Config{
 		Network: DefaultNetworkConfig(),
 		Src:     cfgmap,
+		path:    path,
 	}
 
 	if id, err := identity.LoadIdentity(*identityConfig); err != nil {
@@ -453,19 +475,9 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
-	if value, found := cfgmap["trace"]; found {
-		if submap, ok := value.(map[interface{}]interface{}); ok {
-			if value, found := submap["path"]; found {
-				handler, err := channel.NewTraceHandler(value.(string), controllerConfig.Id.Token)
-				if err != nil {
-					return nil, err
-				}
-				handler.AddDecoder(&channel.Decoder{})
-				handler.AddDecoder(&ctrl_pb.Decoder{})
-				handler.AddDecoder(&xgress.Decoder{})
-				handler.AddDecoder(&mgmt_pb.Decoder{})
-				controllerConfig.Trace.Handler = handler
-			}
+	if controllerConfig.Trace.Path = loadTracePath(cfgmap); controllerConfig.Trace.Path != "" {
+		if controllerConfig.Trace.Handler, err = NewTraceHandler(controllerConfig.Trace.Path, controllerConfig.Id.Token); err != nil {
+			return nil, err
 		}
 	}
 
@@ -599,44 +611,8 @@ func LoadConfig(path string) (*Config, error) {
 		panic("controllerConfig must provide [ctrl]")
 	}
 
-	controllerConfig.HealthChecks.BoltCheck.Interval = DefaultHealthChecksBoltCheckInterval
-	controllerConfig.HealthChecks.BoltCheck.Timeout = DefaultHealthChecksBoltCheckTimeout
-	controllerConfig.HealthChecks.BoltCheck.InitialDelay = DefaultHealthChecksBoltCheckInitialDelay
-
-	if value, found := cfgmap["healthChecks"]; found {
-		if healthChecksMap, ok := value.(map[interface{}]interface{}); ok {
-			if value, found := healthChecksMap["boltCheck"]; found {
-				if boltMap, ok := value.(map[interface{}]interface{}); ok {
-					if value, found := boltMap["interval"]; found {
-						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
-							controllerConfig.HealthChecks.BoltCheck.Interval = val
-						} else {
-							return nil, errors.Wrapf(err, "failed to parse healthChecks.bolt.interval value '%v", value)
-						}
-					}
-
-					if value, found := boltMap["timeout"]; found {
-						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
-							controllerConfig.HealthChecks.BoltCheck.Timeout = val
-						} else {
-							return nil, errors.Wrapf(err, "failed to parse healthChecks.bolt.timeout value '%v", value)
-						}
-					}
-
-					if value, found := boltMap["initialDelay"]; found {
-						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
-							controllerConfig.HealthChecks.BoltCheck.InitialDelay = val
-						} else {
-							return nil, errors.Wrapf(err, "failed to parse healthChecks.bolt.initialDelay value '%v", value)
-						}
-					}
-				} else {
-					pfxlog.Logger().Warn("invalid [healthChecks.bolt] stanza")
-				}
-			}
-		} else {
-			pfxlog.Logger().Warn("invalid [healthChecks] stanza")
-		}
+	if err = loadHealthChecksConfig(&controllerConfig.HealthChecks, cfgmap); err != nil {
+		return nil, err
 	}
 
 	controllerConfig.CommandRateLimiter.Enabled = true
@@ -665,26 +641,12 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
-	controllerConfig.TlsHandshakeRateLimiter.SetDefaults()
-	controllerConfig.TlsHandshakeRateLimiter.Enabled = DefaultTlsHandshakeRateLimiterEnabled
-	controllerConfig.TlsHandshakeRateLimiter.MaxSize = DefaultTlsHandshakeRateLimiterMaxWindow
-	controllerConfig.TlsHandshakeRateLimiter.QueueSizeMetric = TlsHandshakeRateLimiterMetricOutstandingCount
-	controllerConfig.TlsHandshakeRateLimiter.WindowSizeMetric = TlsHandshakeRateLimiterMetricCurrentWindowSize
-	controllerConfig.TlsHandshakeRateLimiter.WorkTimerMetric = TlsHandshakeRateLimiterMetricWorkTimer
+	if controllerConfig.TlsHandshakeTimeout, err = loadTlsConfig(&controllerConfig.TlsHandshakeRateLimiter, cfgmap); err != nil {
+		return nil, err
+	}
 
-	if value, found := cfgmap["tls"]; found {
-		if tlsMap, ok := value.(map[interface{}]interface{}); ok {
-			if value, found := tlsMap["handshakeTimeout"]; found {
-				if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
-					transporttls.SetSharedListenerHandshakeTimeout(val)
-				} else {
-					return nil, errors.Wrapf(err, "failed to parse tls.handshakeTimeout value '%v", value)
-				}
-			}
-			if err = loadTlsHandshakeRateLimiterConfig(&controllerConfig.TlsHandshakeRateLimiter, tlsMap); err != nil {
-				return nil, err
-			}
-		}
+	if controllerConfig.TlsHandshakeTimeout > 0 {
+		transporttls.SetSharedListenerHandshakeTimeout(controllerConfig.TlsHandshakeTimeout)
 	}
 
 	controllerConfig.RouterDataModel.Enabled = DefaultRouterDataModelEnabled
@@ -723,6 +685,34 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
+	if controllerConfig.SpiffeEnrollment, err = loadSpiffeEnrollmentConfig(cfgmap); err != nil {
+		return nil, err
+	}
+
+	if value, found := cfgmap["metrics"]; found {
+		if submap, ok := value.(map[interface{}]interface{}); ok {
+			if value, found := submap["histograms"]; found {
+				if controllerConfig.Metrics.Histograms, err = sampling.LoadHistogramConfig(value, "metrics.histograms"); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			return nil, errors.Errorf("invalid metrics configuration, expected map, got %T", value)
+		}
+	}
+
+	if controllerConfig.Tracing, err = loadTracingConfig(cfgmap); err != nil {
+		return nil, err
+	}
+
+	if controllerConfig.Webhooks, err = loadWebhooksConfig(cfgmap); err != nil {
+		return nil, err
+	}
+
+	if controllerConfig.EventStore, err = loadEventStoreConfig(cfgmap); err != nil {
+		return nil, err
+	}
+
 	edgeConfig, err := LoadEdgeConfigFromMap(cfgmap)
 	if err != nil {
 		return nil, err
@@ -903,6 +893,120 @@ func GetSpiffeIdFromCert(cert *x509.Certificate) (*url.URL, error) {
 	return spiffeId, nil
 }
 
+func loadTracePath(cfgmap map[interface{}]interface{}) string {
+	if value, found := cfgmap["trace"]; found {
+		if submap, ok := value.(map[interface{}]interface{}); ok {
+			if value, found := submap["path"]; found {
+				return fmt.Sprintf("%v", value)
+			}
+		}
+	}
+	return ""
+}
+
+// NewTraceHandler creates a channel trace handler writing to the given path, with the decoders for the
+// controller's message types registered. Note that an existing trace file at the path will be truncated.
+func NewTraceHandler(path string, id string) (*channel.TraceHandler, error) {
+	handler, err := channel.NewTraceHandler(path, id)
+	if err != nil {
+		return nil, err
+	}
+	handler.AddDecoder(&channel.Decoder{})
+	handler.AddDecoder(&ctrl_pb.Decoder{})
+	handler.AddDecoder(&xgress.Decoder{})
+	handler.AddDecoder(&mgmt_pb.Decoder{})
+	return handler, nil
+}
+
+func loadHealthChecksConfig(cfg *HealthChecksConfig, cfgmap map[interface{}]interface{}) error {
+	cfg.BoltCheck.Interval = DefaultHealthChecksBoltCheckInterval
+	cfg.BoltCheck.Timeout = DefaultHealthChecksBoltCheckTimeout
+	cfg.BoltCheck.InitialDelay = DefaultHealthChecksBoltCheckInitialDelay
+	cfg.IntegrityCheck.OnStartup = DefaultIntegrityCheckOnStartup
+
+	if value, found := cfgmap["healthChecks"]; found {
+		if healthChecksMap, ok := value.(map[interface{}]interface{}); ok {
+			if value, found := healthChecksMap["boltCheck"]; found {
+				if boltMap, ok := value.(map[interface{}]interface{}); ok {
+					if value, found := boltMap["interval"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.BoltCheck.Interval = val
+						} else {
+							return errors.Wrapf(err, "failed to parse healthChecks.bolt.interval value '%v", value)
+						}
+					}
+
+					if value, found := boltMap["timeout"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.BoltCheck.Timeout = val
+						} else {
+							return errors.Wrapf(err, "failed to parse healthChecks.bolt.timeout value '%v", value)
+						}
+					}
+
+					if value, found := boltMap["initialDelay"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.BoltCheck.InitialDelay = val
+						} else {
+							return errors.Wrapf(err, "failed to parse healthChecks.bolt.initialDelay value '%v", value)
+						}
+					}
+				} else {
+					pfxlog.Logger().Warn("invalid [healthChecks.bolt] stanza")
+				}
+			}
+
+			if value, found := healthChecksMap["integrityCheck"]; found {
+				if integrityMap, ok := value.(map[interface{}]interface{}); ok {
+					if value, found := integrityMap["onStartup"]; found {
+						if val, ok := value.(bool); ok {
+							cfg.IntegrityCheck.OnStartup = val
+						} else {
+							return errors.Errorf("invalid value for healthChecks.integrityCheck.onStartup '%v', must be true or false", value)
+						}
+					}
+				} else {
+					pfxlog.Logger().Warn("invalid [healthChecks.integrityCheck] stanza")
+				}
+			}
+		} else {
+			pfxlog.Logger().Warn("invalid [healthChecks] stanza")
+		}
+	}
+
+	return nil
+}
+
+// loadTlsConfig loads the tls handshake rate limiter config and returns the configured tls handshake timeout,
+// or zero, if no timeout was configured
+func loadTlsConfig(rateLimitConfig *command.AdaptiveRateLimiterConfig, cfgmap map[interface{}]interface{}) (time.Duration, error) {
+	rateLimitConfig.SetDefaults()
+	rateLimitConfig.Enabled = DefaultTlsHandshakeRateLimiterEnabled
+	rateLimitConfig.MaxSize = DefaultTlsHandshakeRateLimiterMaxWindow
+	rateLimitConfig.QueueSizeMetric = TlsHandshakeRateLimiterMetricOutstandingCount
+	rateLimitConfig.WindowSizeMetric = TlsHandshakeRateLimiterMetricCurrentWindowSize
+	rateLimitConfig.WorkTimerMetric = TlsHandshakeRateLimiterMetricWorkTimer
+
+	var handshakeTimeout time.Duration
+
+	if value, found := cfgmap["tls"]; found {
+		if tlsMap, ok := value.(map[interface{}]interface{}); ok {
+			if value, found := tlsMap["handshakeTimeout"]; found {
+				if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+					handshakeTimeout = val
+				} else {
+					return 0, errors.Wrapf(err, "failed to parse tls.handshakeTimeout value '%v", value)
+				}
+			}
+			if err := loadTlsHandshakeRateLimiterConfig(rateLimitConfig, tlsMap); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return handshakeTimeout, nil
+}
+
 func loadTlsHandshakeRateLimiterConfig(rateLimitConfig *command.AdaptiveRateLimiterConfig, cfgmap map[interface{}]interface{}) error {
 	if value, found := cfgmap["rateLimiter"]; found {
 		if submap, ok := value.(map[interface{}]interface{}); ok {
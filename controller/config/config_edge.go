@@ -65,11 +65,22 @@ const (
 	AuthRateLimiterMinSizeValue = 5
 	AuthRateLimiterMaxSizeValue = 1000
 
+	DefaultApiRateLimiterIdentityRate  = 25
+	DefaultApiRateLimiterIdentityBurst = 50
+	DefaultApiRateLimiterIpRate        = 50
+	DefaultApiRateLimiterIpBurst       = 100
+	DefaultApiRateLimiterIdleTimeout   = 10 * time.Minute
+	MinApiRateLimiterIdleTimeout       = time.Minute
+
 	DefaultIdentityOnlineStatusScanInterval = time.Minute
 	MinIdentityOnlineStatusScanInterval     = time.Second
 
 	DefaultIdentityOnlineStatusUnknownTimeout = 5 * time.Minute
 	DefaultIdentityOnlineStatusSource         = IdentityStatusSourceHybrid
+
+	DefaultTpmAttestationChallengeTimeout = time.Minute
+	MinTpmAttestationChallengeTimeout     = 5 * time.Second
+	MaxTpmAttestationChallengeTimeout     = 10 * time.Minute
 )
 
 type Enrollment struct {
@@ -78,6 +89,7 @@ type Enrollment struct {
 	SigningCertCaPem  []byte
 	EdgeIdentity      EnrollmentOption
 	EdgeRouter        EnrollmentOption
+	OcspUrl           string
 }
 
 type EnrollmentOption struct {
@@ -99,6 +111,34 @@ type Api struct {
 	HttpTimeouts  HttpTimeouts
 }
 
+// ApiRateLimiterConfig configures the request rate limits applied to the edge client and management APIs. Each
+// identity and each source IP gets its own token bucket. A rate of 0 disables that kind of limit.
+type ApiRateLimiterConfig struct {
+	Enabled     bool
+	PerIdentity RateLimit
+	PerIp       RateLimit
+	IdleTimeout time.Duration
+}
+
+// RateLimit is a token bucket refilled at Rate tokens per second, holding at most Burst tokens
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// TpmAttestation configures TPM attestation. Clients prove they hold a TPM by presenting an endorsement key
+// certificate which chains to one of the EkRoots, usually the TPM manufacturer CAs. Attestation is disabled unless
+// at least one root is configured.
+type TpmAttestation struct {
+	EkRoots          *x509.CertPool
+	EkIntermediates  *x509.CertPool
+	ChallengeTimeout time.Duration
+}
+
+func (self *TpmAttestation) Enabled() bool {
+	return self.EkRoots != nil
+}
+
 type Oidc struct {
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
@@ -115,9 +155,11 @@ type EdgeConfig struct {
 	caPemsOnce           sync.Once
 	Totp                 Totp
 	AuthRateLimiter      command.AdaptiveRateLimiterConfig
+	ApiRateLimiter       ApiRateLimiterConfig
 	caCerts              []*x509.Certificate
 	caCertPool           *x509.CertPool
 	DisablePostureChecks bool
+	TpmAttestation       TpmAttestation
 }
 
 type HttpTimeouts struct {
@@ -463,6 +505,15 @@ func (c *EdgeConfig) loadEnrollmentSection(edgeConfigMap map[interface{}]interfa
 			return errors.New("required configuration section [edge.enrollment.signingCert] missing")
 		}
 
+		if value, found := enrollmentSubMap["ocspUrl"]; found {
+			if c.Enrollment.OcspUrl, _ = value.(string); c.Enrollment.OcspUrl == "" {
+				return errors.New("value [edge.enrollment.ocspUrl] must be a non-empty string")
+			}
+			if _, err = url.Parse(c.Enrollment.OcspUrl); err != nil {
+				return errors.Errorf("invalid value [edge.enrollment.ocspUrl]: %v", err)
+			}
+		}
+
 		if value, found := enrollmentSubMap["edgeIdentity"]; found {
 			edgeIdentitySubMap := value.(map[interface{}]interface{})
 
@@ -555,6 +606,99 @@ func (c *EdgeConfig) loadAuthRateLimiterConfig(cfgmap map[interface{}]interface{
 	return nil
 }
 
+func (c *EdgeConfig) loadApiRateLimiterConfig(cfgmap map[interface{}]interface{}) error {
+	c.ApiRateLimiter = ApiRateLimiterConfig{
+		PerIdentity: RateLimit{
+			Rate:  DefaultApiRateLimiterIdentityRate,
+			Burst: DefaultApiRateLimiterIdentityBurst,
+		},
+		PerIp: RateLimit{
+			Rate:  DefaultApiRateLimiterIpRate,
+			Burst: DefaultApiRateLimiterIpBurst,
+		},
+		IdleTimeout: DefaultApiRateLimiterIdleTimeout,
+	}
+
+	value, found := cfgmap["apiRateLimiter"]
+	if !found {
+		return nil
+	}
+
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return errors.Errorf("invalid type for apiRateLimiter, should be map instead of %T", value)
+	}
+
+	if value, found := submap["enabled"]; found {
+		if c.ApiRateLimiter.Enabled, ok = value.(bool); !ok {
+			return errors.Errorf("invalid type for apiRateLimiter.enabled, should be bool instead of %T", value)
+		}
+	}
+
+	if err := loadRateLimit(submap, "perIdentity", &c.ApiRateLimiter.PerIdentity); err != nil {
+		return err
+	}
+
+	if err := loadRateLimit(submap, "perIp", &c.ApiRateLimiter.PerIp); err != nil {
+		return err
+	}
+
+	if value, found := submap["idleTimeout"]; found {
+		strVal, ok := value.(string)
+		if !ok {
+			return errors.Errorf("invalid type for apiRateLimiter.idleTimeout, should be duration string instead of %T", value)
+		}
+		idleTimeout, err := time.ParseDuration(strVal)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value %v for apiRateLimiter.idleTimeout", value)
+		}
+		if idleTimeout < MinApiRateLimiterIdleTimeout {
+			return errors.Errorf("invalid value %v for apiRateLimiter.idleTimeout, must be at least %v", idleTimeout, MinApiRateLimiterIdleTimeout)
+		}
+		c.ApiRateLimiter.IdleTimeout = idleTimeout
+	}
+
+	return nil
+}
+
+func loadRateLimit(cfgmap map[interface{}]interface{}, name string, limit *RateLimit) error {
+	value, found := cfgmap[name]
+	if !found {
+		return nil
+	}
+
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return errors.Errorf("invalid type for apiRateLimiter.%s, should be map instead of %T", name, value)
+	}
+
+	if value, found := submap["rate"]; found {
+		switch v := value.(type) {
+		case int:
+			limit.Rate = float64(v)
+		case float64:
+			limit.Rate = v
+		default:
+			return errors.Errorf("invalid type for apiRateLimiter.%s.rate, should be number instead of %T", name, value)
+		}
+		if limit.Rate < 0 {
+			return errors.Errorf("invalid value %v for apiRateLimiter.%s.rate, must not be negative", limit.Rate, name)
+		}
+	}
+
+	if value, found := submap["burst"]; found {
+		if limit.Burst, ok = value.(int); !ok {
+			return errors.Errorf("invalid type for apiRateLimiter.%s.burst, should be int instead of %T", name, value)
+		}
+	}
+
+	if limit.Rate > 0 && limit.Burst < 1 {
+		return errors.Errorf("invalid value %v for apiRateLimiter.%s.burst, must be at least 1", limit.Burst, name)
+	}
+
+	return nil
+}
+
 func (c *EdgeConfig) loadIdentityStatusConfig(cfgmap map[interface{}]interface{}) error {
 	c.IdentityStatusConfig.ScanInterval = DefaultIdentityOnlineStatusScanInterval
 	c.IdentityStatusConfig.UnknownTimeout = DefaultIdentityOnlineStatusUnknownTimeout
@@ -606,6 +750,83 @@ func (c *EdgeConfig) loadIdentityStatusConfig(cfgmap map[interface{}]interface{}
 	return nil
 }
 
+func (c *EdgeConfig) loadTpmAttestationConfig(cfgmap map[interface{}]interface{}) error {
+	c.TpmAttestation.ChallengeTimeout = DefaultTpmAttestationChallengeTimeout
+
+	value, found := cfgmap["tpmAttestation"]
+	if !found {
+		return nil
+	}
+
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return errors.Errorf("invalid type for tpmAttestation, should be map instead of %T", value)
+	}
+
+	if value, found := submap["ekRoots"]; found {
+		files, ok := value.([]interface{})
+		if !ok {
+			return errors.Errorf("invalid type for tpmAttestation.ekRoots, should be list instead of %T", value)
+		}
+
+		// manufacturers publish intermediate CAs alongside their roots, so self-signed certificates are trusted as
+		// roots and the rest are only used to build chains
+		roots := x509.NewCertPool()
+		intermediates := x509.NewCertPool()
+		rootCount := 0
+
+		for _, file := range files {
+			path := fmt.Sprintf("%v", file)
+			pemBytes, err := os.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "unable to read tpmAttestation.ekRoots file %s", path)
+			}
+
+			certs := nfpem.PemBytesToCertificates(pemBytes)
+			if len(certs) == 0 {
+				return errors.Errorf("tpmAttestation.ekRoots file %s contains no certificates", path)
+			}
+
+			for _, cert := range certs {
+				if bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil {
+					roots.AddCert(cert)
+					rootCount++
+				} else {
+					intermediates.AddCert(cert)
+				}
+			}
+		}
+
+		if rootCount == 0 {
+			return errors.New("tpmAttestation.ekRoots must include at least one self-signed root certificate")
+		}
+
+		c.TpmAttestation.EkRoots = roots
+		c.TpmAttestation.EkIntermediates = intermediates
+	}
+
+	if value, found := submap["challengeTimeout"]; found {
+		strVal, ok := value.(string)
+		if !ok {
+			return errors.Errorf("invalid type for tpmAttestation.challengeTimeout, should be string instead of %T", value)
+		}
+
+		timeout, err := time.ParseDuration(strVal)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for tpmAttestation.challengeTimeout: %s", strVal)
+		}
+
+		if timeout < MinTpmAttestationChallengeTimeout || timeout > MaxTpmAttestationChallengeTimeout {
+			return errors.Errorf("invalid value for tpmAttestation.challengeTimeout: %s, must be between %s and %s",
+				timeout, MinTpmAttestationChallengeTimeout, MaxTpmAttestationChallengeTimeout)
+		}
+
+		c.TpmAttestation.ChallengeTimeout = timeout
+	}
+
+	return nil
+}
+
 func LoadEdgeConfigFromMap(configMap map[interface{}]interface{}) (*EdgeConfig, error) {
 	edgeConfig := NewEdgeConfig()
 
@@ -647,10 +868,18 @@ func LoadEdgeConfigFromMap(configMap map[interface{}]interface{}) (*EdgeConfig,
 		return nil, err
 	}
 
+	if err = edgeConfig.loadApiRateLimiterConfig(edgeConfigMap); err != nil {
+		return nil, err
+	}
+
 	if err = edgeConfig.loadIdentityStatusConfig(edgeConfigMap); err != nil {
 		return nil, err
 	}
 
+	if err = edgeConfig.loadTpmAttestationConfig(edgeConfigMap); err != nil {
+		return nil, err
+	}
+
 	if v, ok := edgeConfigMap["disablePostureChecks"]; ok {
 		if boolVal, ok := v.(bool); ok {
 			edgeConfig.DisablePostureChecks = boolVal
@@ -8,10 +8,13 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
 	"github.com/google/uuid"
 	nfpem "github.com/openziti/foundation/v2/pem"
 	"github.com/stretchr/testify/require"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -381,6 +384,7 @@ func newSelfSignedCert(commonName string, isCas bool) (*x509.Certificate, crypto
 
 	if isCas {
 		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
 	}
 
 	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
@@ -395,3 +399,109 @@ func newSelfSignedCert(commonName string, isCas bool) (*x509.Certificate, crypto
 
 	return cert, priv
 }
+
+func Test_loadApiRateLimiterConfig(t *testing.T) {
+	t.Run("defaults are used when the section is missing", func(t *testing.T) {
+		req := require.New(t)
+		edgeConfig := NewEdgeConfig()
+
+		req.NoError(edgeConfig.loadApiRateLimiterConfig(map[interface{}]interface{}{}))
+		req.False(edgeConfig.ApiRateLimiter.Enabled)
+		req.Equal(float64(DefaultApiRateLimiterIdentityRate), edgeConfig.ApiRateLimiter.PerIdentity.Rate)
+		req.Equal(DefaultApiRateLimiterIpBurst, edgeConfig.ApiRateLimiter.PerIp.Burst)
+		req.Equal(DefaultApiRateLimiterIdleTimeout, edgeConfig.ApiRateLimiter.IdleTimeout)
+	})
+
+	t.Run("configured values are loaded", func(t *testing.T) {
+		req := require.New(t)
+		edgeConfig := NewEdgeConfig()
+
+		err := edgeConfig.loadApiRateLimiterConfig(map[interface{}]interface{}{
+			"apiRateLimiter": map[interface{}]interface{}{
+				"enabled": true,
+				"perIdentity": map[interface{}]interface{}{
+					"rate":  2.5,
+					"burst": 5,
+				},
+				"perIp": map[interface{}]interface{}{
+					"rate": 0,
+				},
+				"idleTimeout": "5m",
+			},
+		})
+
+		req.NoError(err)
+		req.True(edgeConfig.ApiRateLimiter.Enabled)
+		req.Equal(2.5, edgeConfig.ApiRateLimiter.PerIdentity.Rate)
+		req.Equal(5, edgeConfig.ApiRateLimiter.PerIdentity.Burst)
+		req.Equal(float64(0), edgeConfig.ApiRateLimiter.PerIp.Rate)
+		req.Equal(5*time.Minute, edgeConfig.ApiRateLimiter.IdleTimeout)
+	})
+
+	t.Run("invalid values fail", func(t *testing.T) {
+		req := require.New(t)
+
+		invalid := []map[interface{}]interface{}{
+			{"enabled": "yes"},
+			{"perIdentity": map[interface{}]interface{}{"rate": -1}},
+			{"perIp": map[interface{}]interface{}{"rate": 10, "burst": 0}},
+			{"perIp": "fast"},
+			{"idleTimeout": "1s"},
+		}
+
+		for _, cfg := range invalid {
+			edgeConfig := NewEdgeConfig()
+			err := edgeConfig.loadApiRateLimiterConfig(map[interface{}]interface{}{"apiRateLimiter": cfg})
+			req.Error(err, "%v", cfg)
+		}
+	})
+}
+
+func Test_loadTpmAttestationConfig(t *testing.T) {
+	t.Run("attestation is disabled when the section is missing", func(t *testing.T) {
+		req := require.New(t)
+		edgeConfig := NewEdgeConfig()
+
+		req.NoError(edgeConfig.loadTpmAttestationConfig(map[interface{}]interface{}{}))
+		req.False(edgeConfig.TpmAttestation.Enabled())
+		req.Equal(DefaultTpmAttestationChallengeTimeout, edgeConfig.TpmAttestation.ChallengeTimeout)
+	})
+
+	t.Run("configured values are loaded", func(t *testing.T) {
+		req := require.New(t)
+
+		root, _ := newSelfSignedCert("TPM Root", true)
+		rootFile := filepath.Join(t.TempDir(), "ek-roots.pem")
+		req.NoError(os.WriteFile(rootFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0600))
+
+		edgeConfig := NewEdgeConfig()
+		err := edgeConfig.loadTpmAttestationConfig(map[interface{}]interface{}{
+			"tpmAttestation": map[interface{}]interface{}{
+				"ekRoots":          []interface{}{rootFile},
+				"challengeTimeout": "30s",
+			},
+		})
+
+		req.NoError(err)
+		req.True(edgeConfig.TpmAttestation.Enabled())
+		req.Equal(30*time.Second, edgeConfig.TpmAttestation.ChallengeTimeout)
+	})
+
+	t.Run("invalid values are rejected", func(t *testing.T) {
+		req := require.New(t)
+
+		invalidConfigs := []map[interface{}]interface{}{
+			{"ekRoots": "roots.pem"},
+			{"ekRoots": []interface{}{filepath.Join(t.TempDir(), "missing.pem")}},
+			{"challengeTimeout": 30},
+			{"challengeTimeout": "1s"},
+			{"challengeTimeout": "1h"},
+		}
+
+		for _, cfg := range invalidConfigs {
+			edgeConfig := NewEdgeConfig()
+			err := edgeConfig.loadTpmAttestationConfig(map[interface{}]interface{}{"tpmAttestation": cfg})
+			req.Error(err, "%v", cfg)
+		}
+	})
+}
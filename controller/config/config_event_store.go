@@ -0,0 +1,141 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openziti/ziti/controller/event"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultEventStoreMaxSizeMb = 256
+	DefaultEventStoreMaxAge    = 7 * 24 * time.Hour
+	DefaultEventStoreQueueSize = 10_000
+)
+
+// EventStoreNamespaces are the event namespaces which may be retained in the event store
+var EventStoreNamespaces = []string{
+	event.CircuitEventNS,
+	event.LinkEventNS,
+	event.SessionEventNS,
+}
+
+// EventStoreConfig configures the embedded event store, which retains recent events in a local bbolt file so they
+// can be queried through the management API. When not configured, no event history is kept.
+type EventStoreConfig struct {
+	Path       string
+	MaxSizeMb  int64
+	MaxAge     time.Duration
+	QueueSize  int
+	Namespaces map[string]struct{}
+}
+
+// IsStored returns true if events from the given namespace should be retained
+func (self *EventStoreConfig) IsStored(namespace string) bool {
+	_, found := self.Namespaces[namespace]
+	return found
+}
+
+func loadEventStoreConfig(cfgmap map[interface{}]interface{}) (*EventStoreConfig, error) {
+	value, found := cfgmap["eventStore"]
+	if !found {
+		return nil, nil
+	}
+
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid eventStore configuration, expected map, got %T", value)
+	}
+
+	result := &EventStoreConfig{
+		MaxSizeMb:  DefaultEventStoreMaxSizeMb,
+		MaxAge:     DefaultEventStoreMaxAge,
+		QueueSize:  DefaultEventStoreQueueSize,
+		Namespaces: map[string]struct{}{},
+	}
+
+	if value, found := submap["path"]; found {
+		result.Path = fmt.Sprintf("%v", value)
+	}
+
+	if result.Path == "" {
+		return nil, errors.New("eventStore.path is required")
+	}
+
+	if value, found := submap["maxSizeMb"]; found {
+		maxSize, ok := value.(int)
+		if !ok || maxSize < 1 {
+			return nil, errors.Errorf("invalid eventStore.maxSizeMb [%v], must be a positive integer", value)
+		}
+		result.MaxSizeMb = int64(maxSize)
+	}
+
+	if value, found := submap["maxAge"]; found {
+		maxAge, err := time.ParseDuration(fmt.Sprintf("%v", value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid eventStore.maxAge [%v]", value)
+		}
+		if maxAge < time.Minute {
+			return nil, errors.Errorf("invalid eventStore.maxAge [%v], must be at least 1m", value)
+		}
+		result.MaxAge = maxAge
+	}
+
+	if value, found := submap["queueSize"]; found {
+		queueSize, ok := value.(int)
+		if !ok || queueSize < 1 {
+			return nil, errors.Errorf("invalid eventStore.queueSize [%v], must be a positive integer", value)
+		}
+		result.QueueSize = queueSize
+	}
+
+	if value, found := submap["namespaces"]; found {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid eventStore.namespaces configuration, expected list, got %T", value)
+		}
+		for _, entry := range list {
+			namespace := fmt.Sprintf("%v", entry)
+			if !isEventStoreNamespace(namespace) {
+				return nil, errors.Errorf("invalid eventStore.namespaces entry [%v], valid values are %v", namespace, EventStoreNamespaces)
+			}
+			result.Namespaces[namespace] = struct{}{}
+		}
+	} else {
+		for _, namespace := range EventStoreNamespaces {
+			result.Namespaces[namespace] = struct{}{}
+		}
+	}
+
+	if len(result.Namespaces) == 0 {
+		return nil, errors.New("eventStore.namespaces may not be empty")
+	}
+
+	return result, nil
+}
+
+func isEventStoreNamespace(namespace string) bool {
+	for _, candidate := range EventStoreNamespaces {
+		if candidate == namespace {
+			return true
+		}
+	}
+	return false
+}
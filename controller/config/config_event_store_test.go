@@ -0,0 +1,82 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/controller/event"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loadEventStoreConfig(t *testing.T) {
+	t.Run("event store is disabled when not configured", func(t *testing.T) {
+		req := require.New(t)
+		cfg, err := loadEventStoreConfig(map[interface{}]interface{}{})
+		req.NoError(err)
+		req.Nil(cfg)
+	})
+
+	t.Run("defaults are applied", func(t *testing.T) {
+		req := require.New(t)
+		cfg, err := loadEventStoreConfig(map[interface{}]interface{}{
+			"eventStore": map[interface{}]interface{}{
+				"path": "/var/lib/ziti/events.db",
+			},
+		})
+		req.NoError(err)
+		req.Equal("/var/lib/ziti/events.db", cfg.Path)
+		req.Equal(int64(DefaultEventStoreMaxSizeMb), cfg.MaxSizeMb)
+		req.Equal(DefaultEventStoreMaxAge, cfg.MaxAge)
+		req.Equal(DefaultEventStoreQueueSize, cfg.QueueSize)
+		for _, namespace := range EventStoreNamespaces {
+			req.True(cfg.IsStored(namespace))
+		}
+	})
+
+	t.Run("settings are loaded", func(t *testing.T) {
+		req := require.New(t)
+		cfg, err := loadEventStoreConfig(map[interface{}]interface{}{
+			"eventStore": map[interface{}]interface{}{
+				"path":       "events.db",
+				"maxSizeMb":  64,
+				"maxAge":     "48h",
+				"namespaces": []interface{}{"circuit", "link"},
+			},
+		})
+		req.NoError(err)
+		req.Equal(int64(64), cfg.MaxSizeMb)
+		req.Equal(48*time.Hour, cfg.MaxAge)
+		req.True(cfg.IsStored(event.CircuitEventNS))
+		req.True(cfg.IsStored(event.LinkEventNS))
+		req.False(cfg.IsStored(event.SessionEventNS))
+	})
+
+	t.Run("invalid settings are rejected", func(t *testing.T) {
+		for _, submap := range []map[interface{}]interface{}{
+			{},
+			{"path": "events.db", "maxSizeMb": 0},
+			{"path": "events.db", "maxAge": "10s"},
+			{"path": "events.db", "namespaces": []interface{}{"metrics"}},
+			{"path": "events.db", "namespaces": []interface{}{}},
+		} {
+			_, err := loadEventStoreConfig(map[interface{}]interface{}{"eventStore": submap})
+			require.Error(t, err, "%v", submap)
+		}
+	})
+}
@@ -17,10 +17,13 @@
 package config
 
 import (
+	"fmt"
 	"github.com/pkg/errors"
 	"math"
 	"time"
 
+	"github.com/openziti/ziti/common/linktls"
+	"github.com/openziti/ziti/controller/xt"
 	"github.com/sirupsen/logrus"
 )
 
@@ -29,6 +32,7 @@ const (
 	DefaultOptionsCycleSeconds              = 60
 	DefaultOptionsEnableLegacyLinkMgmt      = false
 	DefaultOptionsInitialLinkLatency        = 65 * time.Second
+	DefaultOptionsLinkCostFunction          = "latency"
 	DefaultOptionsPendingLinkTimeout        = 10 * time.Second
 	DefaultOptionsMetricsReportInterval     = time.Minute
 	DefaultOptionsMinRouterCost             = 10
@@ -37,6 +41,15 @@ const (
 	DefaultOptionsRouterMessagingQueueSize  = 100
 	DefaultOptionsRouteTimeout              = 10 * time.Second
 
+	DefaultOptionsRouterScalingScaleUpThreshold   = 0.8
+	DefaultOptionsRouterScalingScaleDownThreshold = 0.3
+	DefaultOptionsRouterScalingConnectionCapacity = 10000
+	DefaultOptionsRouterScalingCircuitCapacity    = 10000
+	DefaultOptionsRouterScalingStaleAfter         = 3 * time.Minute
+
+	DefaultOptionsServiceCircuitBreakerWindow       = time.Minute
+	DefaultOptionsServiceCircuitBreakerOpenDuration = 30 * time.Second
+
 	DefaultOptionsSmartRerouteCap          = 4
 	DefaultOptionsSmartRerouteFraction     = 0.02
 	DefaultOptionsSmartRerouteMinCostDelta = 15
@@ -46,11 +59,15 @@ const (
 )
 
 type NetworkConfig struct {
-	CreateCircuitRetries    uint32
-	CycleSeconds            uint32
-	EnableLegacyLinkMgmt    bool
-	InitialLinkLatency      time.Duration
-	IntervalAgeThreshold    time.Duration
+	CreateCircuitRetries uint32
+	CycleSeconds         uint32
+	EnableLegacyLinkMgmt bool
+	InitialLinkLatency   time.Duration
+	IntervalAgeThreshold time.Duration
+	LinkCost             struct {
+		Function         string
+		ServiceOverrides map[string]string
+	}
 	MetricsReportInterval   time.Duration
 	MinRouterCost           uint16
 	PendingLinkTimeout      time.Duration
@@ -60,11 +77,38 @@ type NetworkConfig struct {
 		QueueSize  uint32
 		MaxWorkers uint32
 	}
+	// RouterScaling configures how the saturation signals reported by edge routers are normalized into autoscaling
+	// hints, and the saturation levels at which routers are reported as needing to scale up or down
+	RouterScaling struct {
+		ScaleUpThreshold   float64
+		ScaleDownThreshold float64
+		ConnectionCapacity uint32
+		CircuitCapacity    uint32
+		StaleAfter         time.Duration
+	}
 	Smart struct {
 		RerouteFraction float32
 		RerouteCap      uint32
 		MinCostDelta    uint32
+		MinImprovement  float32
+		Interval        time.Duration
+		CircuitCooldown time.Duration
 	}
+	// ServiceCircuitBreaker configures per-service circuit breakers, which fast-fail dials to a service after a run
+	// of terminator failures. Disabled if FailureThreshold is zero
+	ServiceCircuitBreaker struct {
+		FailureThreshold uint32
+		Window           time.Duration
+		OpenDuration     time.Duration
+	}
+	// TerminatorCostDecay controls how the failure cost added to terminators by dial failures decays over time. If
+	// neither a default nor a service override applies, strategies use their built-in decay
+	TerminatorCostDecay struct {
+		Default          *xt.CostDecay
+		ServiceOverrides map[string]*xt.CostDecay
+	}
+	// LinkTls, if set, is sent to routers as they connect, to constrain the TLS parameters used for links
+	LinkTls *linktls.Policy
 }
 
 func DefaultNetworkConfig() *NetworkConfig {
@@ -89,12 +133,23 @@ func DefaultNetworkConfig() *NetworkConfig {
 			RerouteFraction float32
 			RerouteCap      uint32
 			MinCostDelta    uint32
+			MinImprovement  float32
+			Interval        time.Duration
+			CircuitCooldown time.Duration
 		}{
 			RerouteFraction: DefaultOptionsSmartRerouteFraction,
 			RerouteCap:      DefaultOptionsSmartRerouteCap,
 			MinCostDelta:    DefaultOptionsSmartRerouteMinCostDelta,
 		},
 	}
+	options.LinkCost.Function = DefaultOptionsLinkCostFunction
+	options.RouterScaling.ScaleUpThreshold = DefaultOptionsRouterScalingScaleUpThreshold
+	options.RouterScaling.ScaleDownThreshold = DefaultOptionsRouterScalingScaleDownThreshold
+	options.RouterScaling.ConnectionCapacity = DefaultOptionsRouterScalingConnectionCapacity
+	options.RouterScaling.CircuitCapacity = DefaultOptionsRouterScalingCircuitCapacity
+	options.RouterScaling.StaleAfter = DefaultOptionsRouterScalingStaleAfter
+	options.ServiceCircuitBreaker.Window = DefaultOptionsServiceCircuitBreakerWindow
+	options.ServiceCircuitBreaker.OpenDuration = DefaultOptionsServiceCircuitBreakerOpenDuration
 	return options
 }
 
@@ -153,11 +208,216 @@ func LoadNetworkConfig(src map[interface{}]interface{}) (*NetworkConfig, error)
 					return nil, errors.New("invalid value for 'minCostDelta'")
 				}
 			}
+
+			if value, found := submap["minImprovement"]; found {
+				var minImprovement float64
+				switch v := value.(type) {
+				case float64:
+					minImprovement = v
+				case int:
+					minImprovement = float64(v)
+				default:
+					return nil, errors.New("invalid value for 'minImprovement'")
+				}
+				if minImprovement < 0 || minImprovement >= 1 {
+					return nil, errors.New("invalid value for 'minImprovement', must be greater than or equal to 0 and less than 1")
+				}
+				options.Smart.MinImprovement = float32(minImprovement)
+			}
+
+			if value, found := submap["interval"]; found {
+				if sval, ok := value.(string); ok {
+					val, err := time.ParseDuration(sval)
+					if err != nil {
+						return nil, errors.Wrap(err, "invalid value for 'interval'")
+					}
+					options.Smart.Interval = val
+				} else {
+					return nil, errors.New("invalid value for 'interval'")
+				}
+			}
+
+			if value, found := submap["circuitCooldown"]; found {
+				if sval, ok := value.(string); ok {
+					val, err := time.ParseDuration(sval)
+					if err != nil {
+						return nil, errors.Wrap(err, "invalid value for 'circuitCooldown'")
+					}
+					options.Smart.CircuitCooldown = val
+				} else {
+					return nil, errors.New("invalid value for 'circuitCooldown'")
+				}
+			}
 		} else {
 			logrus.Errorf("invalid or empty 'smart' stanza")
 		}
 	}
 
+	if value, found := src["linkCost"]; found {
+		if submap, ok := value.(map[interface{}]interface{}); ok {
+			if value, found := submap["function"]; found {
+				if function, ok := value.(string); ok && function != "" {
+					options.LinkCost.Function = function
+				} else {
+					return nil, errors.New("invalid value for 'linkCost.function'")
+				}
+			}
+
+			if value, found := submap["serviceOverrides"]; found {
+				if overridesMap, ok := value.(map[interface{}]interface{}); ok {
+					options.LinkCost.ServiceOverrides = map[string]string{}
+					for k, v := range overridesMap {
+						function, ok := v.(string)
+						if !ok {
+							return nil, errors.Errorf("invalid value for 'linkCost.serviceOverrides.%v'", k)
+						}
+						options.LinkCost.ServiceOverrides[fmt.Sprintf("%v", k)] = function
+					}
+				} else {
+					return nil, errors.New("invalid value for 'linkCost.serviceOverrides'")
+				}
+			}
+		} else {
+			return nil, errors.New("invalid value for 'linkCost'")
+		}
+	}
+
+	if value, found := src["serviceCircuitBreaker"]; found {
+		if submap, ok := value.(map[interface{}]interface{}); ok {
+			if value, found := submap["failureThreshold"]; found {
+				if failureThreshold, ok := value.(int); ok && failureThreshold >= 0 {
+					options.ServiceCircuitBreaker.FailureThreshold = uint32(failureThreshold)
+				} else {
+					return nil, errors.New("invalid value for 'serviceCircuitBreaker.failureThreshold', must be greater than or equal to 0")
+				}
+			}
+
+			for _, field := range []struct {
+				name  string
+				value *time.Duration
+			}{
+				{name: "window", value: &options.ServiceCircuitBreaker.Window},
+				{name: "openDuration", value: &options.ServiceCircuitBreaker.OpenDuration},
+			} {
+				if value, found := submap[field.name]; found {
+					sval, ok := value.(string)
+					if !ok {
+						return nil, errors.Errorf("invalid value for 'serviceCircuitBreaker.%s'", field.name)
+					}
+					val, err := time.ParseDuration(sval)
+					if err != nil {
+						return nil, errors.Wrapf(err, "invalid value for 'serviceCircuitBreaker.%s'", field.name)
+					}
+					if val <= 0 {
+						return nil, errors.Errorf("invalid value for 'serviceCircuitBreaker.%s', must be greater than 0", field.name)
+					}
+					*field.value = val
+				}
+			}
+		} else {
+			return nil, errors.New("invalid value for 'serviceCircuitBreaker'")
+		}
+	}
+
+	if value, found := src["routerScaling"]; found {
+		if submap, ok := value.(map[interface{}]interface{}); ok {
+			for _, field := range []struct {
+				name  string
+				value *float64
+			}{
+				{name: "scaleUpThreshold", value: &options.RouterScaling.ScaleUpThreshold},
+				{name: "scaleDownThreshold", value: &options.RouterScaling.ScaleDownThreshold},
+			} {
+				if value, found := submap[field.name]; found {
+					var threshold float64
+					switch v := value.(type) {
+					case float64:
+						threshold = v
+					case int:
+						threshold = float64(v)
+					default:
+						return nil, errors.Errorf("invalid value for 'routerScaling.%s'", field.name)
+					}
+					if threshold < 0 || threshold > 1 {
+						return nil, errors.Errorf("invalid value for 'routerScaling.%s', must be between 0 and 1", field.name)
+					}
+					*field.value = threshold
+				}
+			}
+
+			if options.RouterScaling.ScaleDownThreshold >= options.RouterScaling.ScaleUpThreshold {
+				return nil, errors.New("invalid value for 'routerScaling.scaleDownThreshold', must be less than 'routerScaling.scaleUpThreshold'")
+			}
+
+			for _, field := range []struct {
+				name  string
+				value *uint32
+			}{
+				{name: "connectionCapacity", value: &options.RouterScaling.ConnectionCapacity},
+				{name: "circuitCapacity", value: &options.RouterScaling.CircuitCapacity},
+			} {
+				if value, found := submap[field.name]; found {
+					if capacity, ok := value.(int); ok && capacity > 0 && capacity <= math.MaxUint32 {
+						*field.value = uint32(capacity)
+					} else {
+						return nil, errors.Errorf("invalid value for 'routerScaling.%s', must be greater than 0", field.name)
+					}
+				}
+			}
+
+			if value, found := submap["staleAfter"]; found {
+				sval, ok := value.(string)
+				if !ok {
+					return nil, errors.New("invalid value for 'routerScaling.staleAfter'")
+				}
+				val, err := time.ParseDuration(sval)
+				if err != nil {
+					return nil, errors.Wrap(err, "invalid value for 'routerScaling.staleAfter'")
+				}
+				if val <= 0 {
+					return nil, errors.New("invalid value for 'routerScaling.staleAfter', must be greater than 0")
+				}
+				options.RouterScaling.StaleAfter = val
+			}
+		} else {
+			return nil, errors.New("invalid value for 'routerScaling'")
+		}
+	}
+
+	if value, found := src["terminatorCostDecay"]; found {
+		if submap, ok := value.(map[interface{}]interface{}); ok {
+			if _, found := submap["curve"]; found {
+				decay, err := loadCostDecay(submap, "terminatorCostDecay")
+				if err != nil {
+					return nil, err
+				}
+				options.TerminatorCostDecay.Default = decay
+			}
+
+			if value, found := submap["serviceOverrides"]; found {
+				if overridesMap, ok := value.(map[interface{}]interface{}); ok {
+					options.TerminatorCostDecay.ServiceOverrides = map[string]*xt.CostDecay{}
+					for k, v := range overridesMap {
+						path := fmt.Sprintf("terminatorCostDecay.serviceOverrides.%v", k)
+						overrideMap, ok := v.(map[interface{}]interface{})
+						if !ok {
+							return nil, errors.Errorf("invalid value for '%s'", path)
+						}
+						decay, err := loadCostDecay(overrideMap, path)
+						if err != nil {
+							return nil, err
+						}
+						options.TerminatorCostDecay.ServiceOverrides[fmt.Sprintf("%v", k)] = decay
+					}
+				} else {
+					return nil, errors.New("invalid value for 'terminatorCostDecay.serviceOverrides'")
+				}
+			}
+		} else {
+			return nil, errors.New("invalid value for 'terminatorCostDecay'")
+		}
+	}
+
 	if value, found := src["routerMessaging"]; found {
 		if submap, ok := value.(map[interface{}]interface{}); ok {
 			if value, found := submap["queueSize"]; found {
@@ -260,6 +520,20 @@ func LoadNetworkConfig(src map[interface{}]interface{}) (*NetworkConfig, error)
 		}
 	}
 
+	if value, found := src["linkTls"]; found {
+		if submap, ok := value.(map[interface{}]interface{}); ok {
+			policy, err := linktls.LoadPolicy(submap)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid value for 'linkTls'")
+			}
+			if !policy.IsEmpty() {
+				options.LinkTls = policy
+			}
+		} else {
+			return nil, errors.New("invalid value for 'linkTls'")
+		}
+	}
+
 	if value, found := src["enableLegacyLinkMgmt"]; found {
 		if bval, ok := value.(bool); ok {
 			options.EnableLegacyLinkMgmt = bval
@@ -270,3 +544,35 @@ func LoadNetworkConfig(src map[interface{}]interface{}) (*NetworkConfig, error)
 
 	return options, nil
 }
+
+func loadCostDecay(src map[interface{}]interface{}, path string) (*xt.CostDecay, error) {
+	result := &xt.CostDecay{}
+
+	if value, found := src["curve"]; found {
+		if curve, ok := value.(string); ok {
+			result.Curve = curve
+		} else {
+			return nil, errors.Errorf("invalid value for '%s.curve'", path)
+		}
+	} else {
+		return nil, errors.Errorf("'%s.curve' is required", path)
+	}
+
+	if value, found := src["period"]; found {
+		if sval, ok := value.(string); ok {
+			val, err := time.ParseDuration(sval)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid value for '%s.period'", path)
+			}
+			result.Period = val
+		} else {
+			return nil, errors.Errorf("invalid value for '%s.period'", path)
+		}
+	}
+
+	if err := result.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid value for '%s'", path)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,98 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"github.com/openziti/ziti/common/config"
+	"github.com/openziti/ziti/controller/command"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ReloadableConfig contains the sections of the controller configuration which can be changed while the
+// controller is running. Changes to any other section require a restart.
+type ReloadableConfig struct {
+	Events                  map[interface{}]interface{}
+	TracePath               string
+	HealthChecks            HealthChecksConfig
+	TlsHandshakeTimeout     time.Duration
+	TlsHandshakeRateLimiter command.AdaptiveRateLimiterConfig
+
+	// ApiRateLimiter is nil if the config has no edge section
+	ApiRateLimiter *ApiRateLimiterConfig
+}
+
+// LoadReloadableConfig re-reads the file the config was loaded from and parses the sections which can be
+// changed at runtime. The config itself is not modified.
+func (self *Config) LoadReloadableConfig() (*ReloadableConfig, error) {
+	if self.path == "" {
+		return nil, errors.New("config was not loaded from a file, unable to reload")
+	}
+
+	cfgBytes, err := os.ReadFile(self.path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgmap := make(map[interface{}]interface{})
+	if err = yaml.NewDecoder(bytes.NewReader(cfgBytes)).Decode(&cfgmap); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse config file %s", self.path)
+	}
+	config.InjectEnv(cfgmap)
+
+	if value, found := cfgmap["v"]; !found || value != 3 {
+		return nil, errors.Errorf("config file %s is missing or has an invalid config version", self.path)
+	}
+
+	result := &ReloadableConfig{
+		TracePath: loadTracePath(cfgmap),
+	}
+
+	if value, found := cfgmap["events"]; found {
+		if events, ok := value.(map[interface{}]interface{}); ok {
+			result.Events = events
+		} else {
+			return nil, errors.New("invalid 'events' stanza")
+		}
+	}
+
+	if err = loadHealthChecksConfig(&result.HealthChecks, cfgmap); err != nil {
+		return nil, err
+	}
+
+	if result.TlsHandshakeTimeout, err = loadTlsConfig(&result.TlsHandshakeRateLimiter, cfgmap); err != nil {
+		return nil, err
+	}
+
+	if value, found := cfgmap["edge"]; found {
+		edgeMap, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("invalid 'edge' stanza")
+		}
+		edgeConfig := NewEdgeConfig()
+		if err = edgeConfig.loadApiRateLimiterConfig(edgeMap); err != nil {
+			return nil, err
+		}
+		result.ApiRateLimiter = &edgeConfig.ApiRateLimiter
+	}
+
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadReloadableConfigApiRateLimiter(t *testing.T) {
+	writeConfig := func(t *testing.T, contents string) *Config {
+		path := filepath.Join(t.TempDir(), "ctrl.yml")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+		return &Config{path: path}
+	}
+
+	t.Run("api rate limiter is nil without an edge section", func(t *testing.T) {
+		req := require.New(t)
+		cfg, err := writeConfig(t, "v: 3\n").LoadReloadableConfig()
+		req.NoError(err)
+		req.Nil(cfg.ApiRateLimiter)
+	})
+
+	t.Run("api rate limiter is loaded from the edge section", func(t *testing.T) {
+		req := require.New(t)
+		cfg, err := writeConfig(t, `
+v: 3
+edge:
+  apiRateLimiter:
+    enabled: true
+    perIp:
+      rate: 10
+      burst: 20
+`).LoadReloadableConfig()
+		req.NoError(err)
+		req.NotNil(cfg.ApiRateLimiter)
+		req.True(cfg.ApiRateLimiter.Enabled)
+		req.Equal(float64(10), cfg.ApiRateLimiter.PerIp.Rate)
+		req.Equal(20, cfg.ApiRateLimiter.PerIp.Burst)
+		req.Equal(float64(DefaultApiRateLimiterIdentityRate), cfg.ApiRateLimiter.PerIdentity.Rate)
+	})
+
+	t.Run("invalid api rate limiter settings fail the reload", func(t *testing.T) {
+		req := require.New(t)
+		_, err := writeConfig(t, `
+v: 3
+edge:
+  apiRateLimiter:
+    idleTimeout: 1s
+`).LoadReloadableConfig()
+		req.Error(err)
+	})
+}
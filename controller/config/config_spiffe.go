@@ -0,0 +1,146 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package config
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	nfpem "github.com/openziti/foundation/v2/pem"
+	"github.com/pkg/errors"
+)
+
+const DefaultSpiffeRouterPathPrefix = "/ziti/router/"
+
+// SpiffeEnrollmentConfig allows routers to authenticate using X.509 SVIDs issued by an external SPIFFE implementation,
+// such as SPIRE, instead of certificates issued during JWT enrollment. A router's SVID must have the SPIFFE id
+// spiffe://<trustDomain><routerPathPrefix><routerId> and must verify against the trust bundle. The trust bundle file is
+// re-read when it changes, so that CA rotations in the SPIFFE trust domain are picked up.
+type SpiffeEnrollmentConfig struct {
+	TrustDomain      string
+	TrustBundlePath  string
+	RouterPathPrefix string
+
+	lock             sync.Mutex
+	trustBundle      *x509.CertPool
+	trustBundleMTime time.Time
+}
+
+// RouterSpiffeId returns the SPIFFE id that a router with the given id must present
+func (self *SpiffeEnrollmentConfig) RouterSpiffeId(routerId string) string {
+	return "spiffe://" + self.TrustDomain + self.RouterPathPrefix + routerId
+}
+
+// GetTrustBundle returns the SPIFFE trust bundle, reloading it if the file has been modified
+func (self *SpiffeEnrollmentConfig) GetTrustBundle() (*x509.CertPool, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	info, err := os.Stat(self.TrustBundlePath)
+	if err != nil {
+		if self.trustBundle != nil {
+			return self.trustBundle, nil
+		}
+		return nil, errors.Wrapf(err, "unable to read SPIFFE trust bundle [%s]", self.TrustBundlePath)
+	}
+
+	if self.trustBundle != nil && info.ModTime().Equal(self.trustBundleMTime) {
+		return self.trustBundle, nil
+	}
+
+	pemBytes, err := os.ReadFile(self.TrustBundlePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read SPIFFE trust bundle [%s]", self.TrustBundlePath)
+	}
+
+	certs := nfpem.PemBytesToCertificates(pemBytes)
+	if len(certs) == 0 {
+		return nil, errors.Errorf("no certificates found in SPIFFE trust bundle [%s]", self.TrustBundlePath)
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+
+	self.trustBundle = pool
+	self.trustBundleMTime = info.ModTime()
+
+	return pool, nil
+}
+
+func loadSpiffeEnrollmentConfig(cfgmap map[interface{}]interface{}) (*SpiffeEnrollmentConfig, error) {
+	value, found := cfgmap["spiffeEnrollment"]
+	if !found {
+		return nil, nil
+	}
+
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid spiffeEnrollment configuration, expected map, got %T", value)
+	}
+
+	result := &SpiffeEnrollmentConfig{
+		RouterPathPrefix: DefaultSpiffeRouterPathPrefix,
+	}
+
+	if value, found := submap["trustDomain"]; found {
+		trustDomain := fmt.Sprintf("%v", value)
+		if strings.HasPrefix(trustDomain, "spiffe://") {
+			u, err := url.Parse(trustDomain)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid spiffeEnrollment.trustDomain [%s]", trustDomain)
+			}
+			trustDomain = u.Host
+		}
+		result.TrustDomain = trustDomain
+	}
+
+	if result.TrustDomain == "" {
+		return nil, errors.New("spiffeEnrollment.trustDomain is required")
+	}
+
+	if value, found := submap["trustBundle"]; found {
+		result.TrustBundlePath = fmt.Sprintf("%v", value)
+	}
+
+	if result.TrustBundlePath == "" {
+		return nil, errors.New("spiffeEnrollment.trustBundle is required")
+	}
+
+	if value, found := submap["routerPathPrefix"]; found {
+		prefix := fmt.Sprintf("%v", value)
+		if !strings.HasPrefix(prefix, "/") {
+			prefix = "/" + prefix
+		}
+		if !strings.HasSuffix(prefix, "/") {
+			prefix = prefix + "/"
+		}
+		result.RouterPathPrefix = prefix
+	}
+
+	if _, err := result.GetTrustBundle(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
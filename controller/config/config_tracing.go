@@ -0,0 +1,111 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const DefaultTracingServiceName = "ziti-controller"
+
+// TracingConfig configures export of OpenTelemetry traces over OTLP/HTTP. When not configured, spans are not recorded.
+type TracingConfig struct {
+	Endpoint    string
+	UrlPath     string
+	Insecure    bool
+	Headers     map[string]string
+	SampleRatio float64
+	ServiceName string
+}
+
+func loadTracingConfig(cfgmap map[interface{}]interface{}) (*TracingConfig, error) {
+	value, found := cfgmap["tracing"]
+	if !found {
+		return nil, nil
+	}
+
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid tracing configuration, expected map, got %T", value)
+	}
+
+	result := &TracingConfig{
+		SampleRatio: 1,
+		ServiceName: DefaultTracingServiceName,
+		Headers:     map[string]string{},
+	}
+
+	if value, found := submap["serviceName"]; found {
+		result.ServiceName = fmt.Sprintf("%v", value)
+	}
+
+	if value, found := submap["sampleRatio"]; found {
+		ratio, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid tracing.sampleRatio [%v]", value)
+		}
+		if ratio < 0 || ratio > 1 {
+			return nil, errors.Errorf("invalid tracing.sampleRatio [%v], must be between 0 and 1", value)
+		}
+		result.SampleRatio = ratio
+	}
+
+	value, found = submap["otlp"]
+	if !found {
+		return nil, errors.New("tracing.otlp is required")
+	}
+
+	otlpMap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid tracing.otlp configuration, expected map, got %T", value)
+	}
+
+	if value, found := otlpMap["endpoint"]; found {
+		result.Endpoint = fmt.Sprintf("%v", value)
+	}
+
+	if result.Endpoint == "" {
+		return nil, errors.New("tracing.otlp.endpoint is required")
+	}
+
+	if value, found := otlpMap["urlPath"]; found {
+		result.UrlPath = fmt.Sprintf("%v", value)
+	}
+
+	if value, found := otlpMap["insecure"]; found {
+		insecure, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid tracing.otlp.insecure value [%v], expected bool", value)
+		}
+		result.Insecure = insecure
+	}
+
+	if value, found := otlpMap["headers"]; found {
+		headers, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid tracing.otlp.headers configuration, expected map, got %T", value)
+		}
+		for k, v := range headers {
+			result.Headers[fmt.Sprintf("%v", k)] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	return result, nil
+}
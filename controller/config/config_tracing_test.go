@@ -0,0 +1,77 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loadTracingConfig(t *testing.T) {
+	t.Run("tracing is disabled when not configured", func(t *testing.T) {
+		req := require.New(t)
+		cfg, err := loadTracingConfig(map[interface{}]interface{}{})
+		req.NoError(err)
+		req.Nil(cfg)
+	})
+
+	t.Run("otlp settings are loaded", func(t *testing.T) {
+		req := require.New(t)
+		cfg, err := loadTracingConfig(map[interface{}]interface{}{
+			"tracing": map[interface{}]interface{}{
+				"sampleRatio": 0.25,
+				"otlp": map[interface{}]interface{}{
+					"endpoint": "collector:4318",
+					"insecure": true,
+					"headers": map[interface{}]interface{}{
+						"Authorization": "Bearer abc",
+					},
+				},
+			},
+		})
+		req.NoError(err)
+		req.Equal("collector:4318", cfg.Endpoint)
+		req.True(cfg.Insecure)
+		req.Equal(0.25, cfg.SampleRatio)
+		req.Equal(DefaultTracingServiceName, cfg.ServiceName)
+		req.Equal("Bearer abc", cfg.Headers["Authorization"])
+	})
+
+	t.Run("endpoint is required", func(t *testing.T) {
+		req := require.New(t)
+		_, err := loadTracingConfig(map[interface{}]interface{}{
+			"tracing": map[interface{}]interface{}{
+				"otlp": map[interface{}]interface{}{},
+			},
+		})
+		req.Error(err)
+	})
+
+	t.Run("sample ratio must be between 0 and 1", func(t *testing.T) {
+		req := require.New(t)
+		_, err := loadTracingConfig(map[interface{}]interface{}{
+			"tracing": map[interface{}]interface{}{
+				"sampleRatio": 2,
+				"otlp": map[interface{}]interface{}{
+					"endpoint": "collector:4318",
+				},
+			},
+		})
+		req.Error(err)
+	})
+}
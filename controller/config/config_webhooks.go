@@ -0,0 +1,187 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	WebhookEventIdentityCreated    = "identity.created"
+	WebhookEventIdentityEnrolled   = "identity.enrolled"
+	WebhookEventIdentityDeleted    = "identity.deleted"
+	WebhookEventIdentityAuthFailed = "identity.authFailed"
+
+	DefaultWebhookTimeout       = 10 * time.Second
+	DefaultWebhookMaxRetries    = 5
+	DefaultWebhookRetryInterval = time.Second
+	DefaultWebhookQueueSize     = 1000
+)
+
+var WebhookEventTypes = []string{
+	WebhookEventIdentityCreated,
+	WebhookEventIdentityEnrolled,
+	WebhookEventIdentityDeleted,
+	WebhookEventIdentityAuthFailed,
+}
+
+// WebhookConfig configures an endpoint which is sent identity lifecycle events
+type WebhookConfig struct {
+	Name          string
+	Url           string
+	Secret        string
+	Events        map[string]struct{}
+	Headers       map[string]string
+	Timeout       time.Duration
+	MaxRetries    int
+	RetryInterval time.Duration
+	QueueSize     int
+}
+
+// IsSubscribed returns true if the webhook should be sent events of the given type
+func (self *WebhookConfig) IsSubscribed(eventType string) bool {
+	_, found := self.Events[eventType]
+	return found
+}
+
+func loadWebhooksConfig(cfgmap map[interface{}]interface{}) ([]*WebhookConfig, error) {
+	value, found := cfgmap["webhooks"]
+	if !found {
+		return nil, nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid webhooks configuration, expected list, got %T", value)
+	}
+
+	var result []*WebhookConfig
+	for idx, entry := range list {
+		submap, ok := entry.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid webhooks[%d] configuration, expected map, got %T", idx, entry)
+		}
+		webhookConfig, err := loadWebhookConfig(submap)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid webhooks[%d] configuration", idx)
+		}
+		if webhookConfig.Name == "" {
+			webhookConfig.Name = fmt.Sprintf("webhook-%d", idx)
+		}
+		result = append(result, webhookConfig)
+	}
+
+	return result, nil
+}
+
+func loadWebhookConfig(submap map[interface{}]interface{}) (*WebhookConfig, error) {
+	result := &WebhookConfig{
+		Events:        map[string]struct{}{},
+		Headers:       map[string]string{},
+		Timeout:       DefaultWebhookTimeout,
+		MaxRetries:    DefaultWebhookMaxRetries,
+		RetryInterval: DefaultWebhookRetryInterval,
+		QueueSize:     DefaultWebhookQueueSize,
+	}
+
+	if value, found := submap["name"]; found {
+		result.Name = fmt.Sprintf("%v", value)
+	}
+
+	if value, found := submap["url"]; found {
+		result.Url = fmt.Sprintf("%v", value)
+	}
+
+	if result.Url == "" {
+		return nil, errors.New("url is required")
+	}
+
+	parsedUrl, err := url.Parse(result.Url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid url [%v]", result.Url)
+	}
+	if parsedUrl.Scheme != "https" && parsedUrl.Scheme != "http" {
+		return nil, errors.Errorf("invalid url [%v], scheme must be http or https", result.Url)
+	}
+
+	if value, found := submap["secret"]; found {
+		result.Secret = fmt.Sprintf("%v", value)
+	}
+
+	if value, found := submap["events"]; found {
+		events, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid events, expected list, got %T", value)
+		}
+		for _, v := range events {
+			eventType := fmt.Sprintf("%v", v)
+			valid := false
+			for _, validType := range WebhookEventTypes {
+				valid = valid || validType == eventType
+			}
+			if !valid {
+				return nil, errors.Errorf("invalid event type [%v], valid values are %v", eventType, WebhookEventTypes)
+			}
+			result.Events[eventType] = struct{}{}
+		}
+	} else {
+		for _, eventType := range WebhookEventTypes {
+			result.Events[eventType] = struct{}{}
+		}
+	}
+
+	if value, found := submap["headers"]; found {
+		headers, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.Errorf("invalid headers, expected map, got %T", value)
+		}
+		for k, v := range headers {
+			result.Headers[fmt.Sprintf("%v", k)] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	if value, found := submap["timeout"]; found {
+		if result.Timeout, err = time.ParseDuration(fmt.Sprintf("%v", value)); err != nil || result.Timeout <= 0 {
+			return nil, errors.Errorf("invalid timeout [%v], must be a positive duration", value)
+		}
+	}
+
+	var ok bool
+	if value, found := submap["maxRetries"]; found {
+		if result.MaxRetries, ok = value.(int); !ok || result.MaxRetries < 0 {
+			return nil, errors.Errorf("invalid maxRetries [%v], must be a non-negative integer", value)
+		}
+	}
+
+	if value, found := submap["retryInterval"]; found {
+		if result.RetryInterval, err = time.ParseDuration(fmt.Sprintf("%v", value)); err != nil || result.RetryInterval <= 0 {
+			return nil, errors.Errorf("invalid retryInterval [%v], must be a positive duration", value)
+		}
+	}
+
+	if value, found := submap["queueSize"]; found {
+		if result.QueueSize, ok = value.(int); !ok || result.QueueSize < 1 {
+			return nil, errors.Errorf("invalid queueSize [%v], must be a positive integer", value)
+		}
+	}
+
+	return result, nil
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/openziti/ziti/common/capabilities"
 	"github.com/openziti/ziti/common/concurrency"
 	fabricMetrics "github.com/openziti/ziti/common/metrics"
+	"github.com/openziti/ziti/common/metrics/sampling"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
 	"github.com/openziti/ziti/common/profiler"
 	"github.com/openziti/ziti/controller/command"
@@ -53,6 +54,7 @@ import (
 	"github.com/openziti/ziti/controller/env"
 	"github.com/openziti/ziti/controller/event"
 	"github.com/openziti/ziti/controller/events"
+	"github.com/openziti/ziti/controller/eventstore"
 	"github.com/openziti/ziti/controller/handler_ctrl"
 	"github.com/openziti/ziti/controller/handler_peer_ctrl"
 	"github.com/openziti/ziti/controller/network"
@@ -62,6 +64,8 @@ import (
 	"github.com/openziti/ziti/controller/xctrl"
 	"github.com/openziti/ziti/controller/xmgmt"
 	"github.com/openziti/ziti/controller/xt"
+	"github.com/openziti/ziti/controller/xt_consistent_hash"
+	"github.com/openziti/ziti/controller/xt_proximity"
 	"github.com/openziti/ziti/controller/xt_random"
 	"github.com/openziti/ziti/controller/xt_smartrouting"
 	"github.com/openziti/ziti/controller/xt_sticky"
@@ -69,6 +73,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/teris-io/shortid"
+	"google.golang.org/protobuf/proto"
 )
 
 type Controller struct {
@@ -93,6 +98,7 @@ type Controller struct {
 	metricsRegistry   metrics.Registry
 	versionProvider   versions.VersionProvider
 	eventDispatcher   *events.Dispatcher
+	eventStore        *eventstore.Store
 
 	apiData      map[string][]event.ApiAddress
 	apiDataBytes []byte
@@ -100,6 +106,11 @@ type Controller struct {
 
 	xwebInitialized concurrency.InitState
 	healthChecker   gosundheit.Health
+
+	ctrlAccepter  *handler_ctrl.CtrlAccepter
+	reloadLock    sync.Mutex
+	reloadEnabled atomic.Bool
+	eventsConfig  map[interface{}]interface{}
 }
 
 func (c *Controller) GetPeerSigners() []*x509.Certificate {
@@ -217,6 +228,10 @@ func (c *Controller) GetEnv() *env.AppEnv {
 
 func NewController(cfg *config.Config, versionProvider versions.VersionProvider) (*Controller, error) {
 	metricRegistry := metrics.NewRegistry(cfg.Id.Token, nil)
+	if cfg.Metrics.Histograms != nil {
+		pfxlog.Logger().Infof("using histogram sampling: %v", cfg.Metrics.Histograms)
+		metricRegistry = sampling.NewRegistry(metricRegistry, cfg.Metrics.Histograms)
+	}
 
 	shutdownC := make(chan struct{})
 
@@ -310,6 +325,10 @@ func NewController(cfg *config.Config, versionProvider versions.VersionProvider)
 		}
 	}
 
+	if err = c.initEventStore(); err != nil {
+		return nil, err
+	}
+
 	c.initWeb() // need to init web before bootstrapping, so we can provide our endpoints to peers
 
 	if c.raftController != nil && !c.raftController.IsBootstrapped() {
@@ -320,12 +339,24 @@ func NewController(cfg *config.Config, versionProvider versions.VersionProvider)
 
 	c.eventDispatcher.InitializeNetworkEvents(c.network)
 
+	onConnectSettings := map[int32][]byte{}
+
 	if cfg.Ctrl.Options.NewListener != nil {
+		onConnectSettings[int32(ctrl_pb.SettingTypes_NewCtrlAddress)] = []byte((*cfg.Ctrl.Options.NewListener).String())
+	}
+
+	if cfg.Network.LinkTls != nil {
+		linkTlsPolicy, err := proto.Marshal(cfg.Network.LinkTls.ToSettings())
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to marshal link tls policy")
+		}
+		onConnectSettings[int32(ctrl_pb.SettingTypes_LinkTlsPolicy)] = linkTlsPolicy
+	}
+
+	if len(onConnectSettings) > 0 {
 		c.network.AddRouterPresenceHandler(&OnConnectSettingsHandler{
-			config: cfg,
-			settings: map[int32][]byte{
-				int32(ctrl_pb.SettingTypes_NewCtrlAddress): []byte((*cfg.Ctrl.Options.NewListener).String()),
-			},
+			config:   cfg,
+			settings: onConnectSettings,
 		})
 	}
 
@@ -354,7 +385,10 @@ func (c *Controller) initWeb() {
 		logrus.WithError(err).Fatalf("failed to create health checks api factory")
 	}
 
-	fabricManagementFactory := webapis.NewFabricManagementApiFactory(c.config.Id, c.env, c.network, &c.xmgmts)
+	fabricManagementFactory := webapis.NewFabricManagementApiFactory(c.config.Id, c.env, c.network, &c.xmgmts, c)
+	if c.eventStore != nil {
+		fabricManagementFactory.EventHistory = c.eventStore
+	}
 	if err = c.xweb.GetRegistry().Add(fabricManagementFactory); err != nil {
 		logrus.WithError(err).Fatalf("failed to create management api factory")
 	}
@@ -384,6 +418,14 @@ func (c *Controller) initWeb() {
 			pfxlog.Logger().Fatalf("failed to create OIDC API factory: %v", err)
 		}
 
+		if err = c.xweb.GetRegistry().Add(webapis.NewScimApiFactory(c.env)); err != nil {
+			pfxlog.Logger().Fatalf("failed to create SCIM API factory: %v", err)
+		}
+
+		if err = c.xweb.GetRegistry().Add(webapis.NewOcspApiFactory(c.env)); err != nil {
+			pfxlog.Logger().Fatalf("failed to create OCSP API factory: %v", err)
+		}
+
 		webapis.OverrideRequestWrapper(webapis.NewFabricApiWrapper(c.env))
 	} else {
 		// if no edge  we need 1 default API, make the fabric api the default
@@ -399,6 +441,16 @@ func (c *Controller) IsEdgeEnabled() bool {
 func (c *Controller) Run() error {
 	c.startProfiling()
 
+	if err := c.startTracing(); err != nil {
+		return fmt.Errorf("error starting tracing: %w", err)
+	}
+
+	c.startWebhooks()
+
+	if c.config.HealthChecks.IntegrityCheck.OnStartup {
+		go c.runStartupIntegrityCheck()
+	}
+
 	if err := c.registerComponents(); err != nil {
 		return fmt.Errorf("error registering component: %s", err)
 	}
@@ -444,6 +496,7 @@ func (c *Controller) Run() error {
 	}
 
 	ctrlAccepter := handler_ctrl.NewCtrlAccepter(c.network, c.xctrls, c.config.Ctrl.Options.Options, c.config.Ctrl.Options.RouterHeartbeatOptions, c.config.Trace.Handler)
+	c.ctrlAccepter = ctrlAccepter
 
 	ctrlAcceptors := map[string]channel.UnderlayAcceptor{}
 	if c.raftController != nil {
@@ -471,9 +524,14 @@ func (c *Controller) Run() error {
 	}
 
 	// event handlers
-	if err := c.eventDispatcher.WireEventHandlers(c.getEventHandlerConfigs()); err != nil {
+	if eventsConfig, ok := c.config.Src["events"].(map[interface{}]interface{}); ok {
+		c.eventsConfig = eventsConfig
+	}
+
+	if err := c.eventDispatcher.WireEventHandlers(getEventHandlerConfigs(c.eventsConfig)); err != nil {
 		panic(err)
 	}
+	c.reloadEnabled.Store(true)
 
 	if c.raftController != nil {
 		c.raftController.StartEventGeneration()
@@ -484,19 +542,15 @@ func (c *Controller) Run() error {
 	return nil
 }
 
-func (c *Controller) getEventHandlerConfigs() []*events.EventHandlerConfig {
+func getEventHandlerConfigs(eventsConfig map[interface{}]interface{}) []*events.EventHandlerConfig {
 	var result []*events.EventHandlerConfig
 
-	if e, ok := c.config.Src["events"]; ok {
-		if em, ok := e.(map[interface{}]interface{}); ok {
-			for id, v := range em {
-				if config, ok := v.(map[interface{}]interface{}); ok {
-					result = append(result, &events.EventHandlerConfig{
-						Id:     id,
-						Config: config,
-					})
-				}
-			}
+	for id, v := range eventsConfig {
+		if config, ok := v.(map[interface{}]interface{}); ok {
+			result = append(result, &events.EventHandlerConfig{
+				Id:     id,
+				Config: config,
+			})
 		}
 	}
 	return result
@@ -574,6 +628,9 @@ func (c *Controller) registerXts() {
 	xt.GlobalRegistry().RegisterFactory(xt_random.NewFactory())
 	xt.GlobalRegistry().RegisterFactory(xt_weighted.NewFactory())
 	xt.GlobalRegistry().RegisterFactory(xt_sticky.NewFactory())
+	xt.GlobalRegistry().RegisterFactory(xt_proximity.NewFactory())
+	xt.GlobalRegistry().RegisterFactory(xt_weighted.NewRandomFactory())
+	xt.GlobalRegistry().RegisterFactory(xt_consistent_hash.NewFactory())
 }
 
 func (c *Controller) registerComponents() error {
@@ -589,14 +646,18 @@ func (c *Controller) RegisterXctrl(x xctrl.Xctrl) error {
 	if x.Enabled() {
 		c.xctrls = append(c.xctrls, x)
 		if c.config.Trace.Handler != nil {
-			for _, decoder := range x.GetTraceDecoders() {
-				c.config.Trace.Handler.AddDecoder(decoder)
-			}
+			c.addXctrlTraceDecoders(c.config.Trace.Handler, x)
 		}
 	}
 	return nil
 }
 
+func (c *Controller) addXctrlTraceDecoders(handler *channel.TraceHandler, x xctrl.Xctrl) {
+	for _, decoder := range x.GetTraceDecoders() {
+		handler.AddDecoder(decoder)
+	}
+}
+
 func (c *Controller) RegisterXmgmt(x xmgmt.Xmgmt) error {
 	if err := c.config.Configure(x); err != nil {
 		return err
@@ -98,7 +98,7 @@ func (store *baseStore[E]) getRoleAttributesCursorProvider(index boltz.SetReadIn
 		return nil, errorz.NewFieldError("invalid semantic", FieldSemantic, semantic)
 	}
 
-	roles, ids, err := splitRolesAndIds(values)
+	roles, ids, expressions, err := splitRoleEntries(values)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +112,17 @@ func (store *baseStore[E]) getRoleAttributesCursorProvider(index boltz.SetReadIn
 		}
 
 		var rolesCursor ast.SetCursor
-		if strings.EqualFold(semantic, SemanticAllOf) {
+		if len(expressions) > 0 {
+			// expressions can't be answered from the index, as they may select entities which lack an attribute
+			matches := ast.NewTreeSet(forward)
+			for cursor := store.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
+				roleAttributes := index.GetSymbol().EvalStringList(tx, cursor.Current())
+				if rolesMatch(semantic, roles, expressions, roleAttributes) {
+					matches.Add(cursor.Current())
+				}
+			}
+			rolesCursor = matches.ToCursor()
+		} else if strings.EqualFold(semantic, SemanticAllOf) {
 			rolesCursor = store.IteratorMatchingAllOf(index, roles)(tx, forward)
 		} else {
 			rolesCursor = store.IteratorMatchingAnyOf(index, roles)(tx, forward)
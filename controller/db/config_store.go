@@ -30,6 +30,7 @@ const (
 	FieldConfigData            = "data"
 	FieldConfigType            = "type"
 	FieldConfigIdentityService = "identityServices"
+	FieldConfigSchemaVersion   = "schemaVersion"
 )
 
 func newConfig(name string, configType string, data map[string]interface{}) *Config {
@@ -43,9 +44,10 @@ func newConfig(name string, configType string, data map[string]interface{}) *Con
 
 type Config struct {
 	boltz.BaseExtEntity
-	Name string                 `json:"name"`
-	Type string                 `json:"type"`
-	Data map[string]interface{} `json:"data"`
+	Name          string                 `json:"name"`
+	Type          string                 `json:"type"`
+	Data          map[string]interface{} `json:"data"`
+	SchemaVersion uint32                 `json:"schemaVersion"`
 }
 
 func (entity *Config) GetName() string {
@@ -89,6 +91,7 @@ func (store *configStoreImpl) initializeLocal() {
 	store.indexName = store.addUniqueNameField()
 	store.symbolType = store.AddFkSymbol(FieldConfigType, store.stores.configType)
 	store.AddMapSymbol(FieldConfigData, ast.NodeTypeAnyType, FieldConfigData)
+	store.AddSymbol(FieldConfigSchemaVersion, ast.NodeTypeInt64)
 	store.symbolServices = store.AddFkSetSymbol(EntityTypeServices, store.stores.edgeService)
 	store.symbolIdentityServices = store.AddSetSymbol(FieldConfigIdentityService, ast.NodeTypeOther)
 	store.identityServicesLinks = &boltz.LinkedSetSymbol{EntitySymbol: store.symbolIdentityServices}
@@ -108,6 +111,7 @@ func (store *configStoreImpl) FillEntity(entity *Config, bucket *boltz.TypedBuck
 	entity.Name = bucket.GetStringOrError(FieldName)
 	entity.Type = bucket.GetStringOrError(FieldConfigType)
 	entity.Data = bucket.GetMap(FieldConfigData)
+	entity.SchemaVersion = uint32(bucket.GetInt32WithDefault(FieldConfigSchemaVersion, 0))
 }
 
 func (store *configStoreImpl) PersistEntity(entity *Config, ctx *boltz.PersistContext) {
@@ -115,6 +119,7 @@ func (store *configStoreImpl) PersistEntity(entity *Config, ctx *boltz.PersistCo
 	ctx.SetString(FieldName, entity.Name)
 	ctx.SetString(FieldConfigType, entity.Type)
 	ctx.SetMap(FieldConfigData, entity.Data)
+	ctx.SetInt32(FieldConfigSchemaVersion, int32(entity.SchemaVersion))
 
 	if ctx.ProceedWithSet(FieldConfigData) && entity.Data == nil {
 		ctx.Bucket.SetError(errorz.NewFieldError("data is required", "data", nil))
@@ -26,7 +26,9 @@ import (
 )
 
 const (
-	FieldConfigTypeSchema = "schema"
+	FieldConfigTypeSchema        = "schema"
+	FieldConfigTypeSchemaVersion = "schemaVersion"
+	FieldConfigTypeSchemaHistory = "schemaHistory"
 )
 
 func newConfigType(name string) *ConfigType {
@@ -38,8 +40,10 @@ func newConfigType(name string) *ConfigType {
 
 type ConfigType struct {
 	boltz.BaseExtEntity
-	Name   string                 `json:"name"`
-	Schema map[string]interface{} `json:"schema"`
+	Name          string                            `json:"name"`
+	Schema        map[string]interface{}            `json:"schema"`
+	SchemaVersion uint32                            `json:"schemaVersion"`
+	SchemaHistory map[uint32]map[string]interface{} `json:"schemaHistory"`
 }
 
 func (entity *ConfigType) GetName() string {
@@ -82,6 +86,7 @@ func (store *configTypeStoreImpl) initializeLocal() {
 	store.indexName = store.addUniqueNameField()
 	store.symbolConfigs = store.AddFkSetSymbol(EntityTypeConfigs, store.stores.config)
 	store.AddSymbol(FieldConfigTypeSchema, ast.NodeTypeString)
+	store.AddSymbol(FieldConfigTypeSchemaVersion, ast.NodeTypeInt64)
 }
 
 func (store *configTypeStoreImpl) initializeLinked() {
@@ -99,6 +104,12 @@ func (store *configTypeStoreImpl) FillEntity(entity *ConfigType, bucket *boltz.T
 		entity.Schema = map[string]interface{}{}
 		bucket.SetError(json.Unmarshal([]byte(*marshalledSchema), &entity.Schema))
 	}
+	entity.SchemaVersion = uint32(bucket.GetInt32WithDefault(FieldConfigTypeSchemaVersion, 0))
+	marshalledHistory := bucket.GetString(FieldConfigTypeSchemaHistory)
+	if marshalledHistory != nil {
+		entity.SchemaHistory = map[uint32]map[string]interface{}{}
+		bucket.SetError(json.Unmarshal([]byte(*marshalledHistory), &entity.SchemaHistory))
+	}
 }
 
 func (store *configTypeStoreImpl) PersistEntity(entity *ConfigType, ctx *boltz.PersistContext) {
@@ -115,6 +126,19 @@ func (store *configTypeStoreImpl) PersistEntity(entity *ConfigType, ctx *boltz.P
 	} else {
 		ctx.SetStringP(FieldConfigTypeSchema, nil)
 	}
+
+	ctx.SetInt32(FieldConfigTypeSchemaVersion, int32(entity.SchemaVersion))
+
+	if len(entity.SchemaHistory) > 0 {
+		marshalled, err := json.Marshal(entity.SchemaHistory)
+		if err != nil {
+			ctx.Bucket.SetError(err)
+			return
+		}
+		ctx.SetString(FieldConfigTypeSchemaHistory, string(marshalled))
+	} else {
+		ctx.SetStringP(FieldConfigTypeSchemaHistory, nil)
+	}
 }
 
 func (store *configTypeStoreImpl) LoadOneByName(tx *bbolt.Tx, name string) (*ConfigType, error) {
@@ -22,6 +22,7 @@ import (
 	"github.com/openziti/storage/ast"
 	"github.com/openziti/storage/boltz"
 	"github.com/openziti/ziti/controller/apierror"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -210,8 +211,9 @@ func (store *externalJwtSignerStoreImpl) PersistEntity(entity *ExternalJwtSigner
 	jwksEndpoint := ctx.Bucket.GetString(FieldExternalJwtSignerJwksEndpoint)
 	certPem := ctx.Bucket.GetString(FieldExternalJwtSignerCertPem)
 
-	if (jwksEndpoint == nil || *jwksEndpoint == "") && (certPem == nil || *certPem == "") {
-		ctx.Bucket.SetError(apierror.NewBadRequestFieldError(*errorz.NewFieldError("jwksEndpoint or certPem is required", "certPem", certPem)))
+	// with neither jwksEndpoint nor certPem, keys are located using the issuer's OIDC discovery document
+	if (jwksEndpoint == nil || *jwksEndpoint == "") && (certPem == nil || *certPem == "") && !isDiscoverableIssuer(entity.Issuer) {
+		ctx.Bucket.SetError(apierror.NewBadRequestFieldError(*errorz.NewFieldError("jwksEndpoint or certPem is required unless issuer is an http(s) url supporting oidc discovery", "certPem", certPem)))
 	}
 
 	if jwksEndpoint != nil && certPem != nil {
@@ -220,6 +222,14 @@ func (store *externalJwtSignerStoreImpl) PersistEntity(entity *ExternalJwtSigner
 	}
 }
 
+func isDiscoverableIssuer(issuer *string) bool {
+	if issuer == nil {
+		return false
+	}
+	issuerUrl, err := url.Parse(*issuer)
+	return err == nil && (issuerUrl.Scheme == "https" || issuerUrl.Scheme == "http") && issuerUrl.Host != ""
+}
+
 func (store *externalJwtSignerStoreImpl) DeleteById(ctx boltz.MutateContext, id string) error {
 	ids, _, err := store.stores.authPolicy.QueryIds(ctx.Tx(), fmt.Sprintf(`anyOf(%s) = "%s"`, FieldAuthPolicyPrimaryExtJwtAllowedSigners, id))
 
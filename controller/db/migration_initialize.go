@@ -41,10 +41,14 @@ func (m *Migrations) initialize(step *boltz.MigrationStep) int {
 	m.createInterceptV1ConfigType(step)
 	m.createHostV1ConfigType(step)
 	m.addProcessMultiPostureCheck(step)
+	m.addProcessHashPostureCheck(step)
+	m.addTpmAttestationPostureCheck(step)
 	m.createConfigType(step, hostV2ConfigType)
 	m.addSystemAuthPolicies(step)
 	m.createConfigType(step, interfacesConfigTypeV1)
 	m.createConfigType(step, proxyConfigTypeV1)
+	m.createConfigType(step, qosConfigTypeV1)
+	m.createConfigType(step, e2eEncryptionConfigTypeV1)
 
 	return CurrentDbVersion
 }
@@ -159,7 +163,7 @@ var healthCheckSchema = map[string]interface{}{
 				},
 				"action": map[string]interface{}{
 					"type":    "string",
-					"pattern": "(mark (un)?healthy|increase cost [0-9]+|decrease cost [0-9]+|send event)",
+					"pattern": "(mark (un)?healthy|demote|increase cost [0-9]+|decrease cost [0-9]+|send event)",
 				},
 			},
 		},
@@ -180,10 +184,11 @@ var healthCheckSchema = map[string]interface{}{
 				"address",
 			},
 			"properties": map[string]interface{}{
-				"interval": map[string]interface{}{"$ref": "#/definitions/duration"},
-				"timeout":  map[string]interface{}{"$ref": "#/definitions/duration"},
-				"address":  map[string]interface{}{"type": "string"},
-				"actions":  map[string]interface{}{"$ref": "#/definitions/actionList"},
+				"interval":          map[string]interface{}{"$ref": "#/definitions/duration"},
+				"timeout":           map[string]interface{}{"$ref": "#/definitions/duration"},
+				"address":           map[string]interface{}{"type": "string"},
+				"actions":           map[string]interface{}{"$ref": "#/definitions/actionList"},
+				"useDefaultActions": map[string]interface{}{"type": "boolean"},
 			},
 		},
 		"httpCheck": map[string]interface{}{
@@ -195,12 +200,13 @@ var healthCheckSchema = map[string]interface{}{
 				"url",
 			},
 			"properties": map[string]interface{}{
-				"url":      map[string]interface{}{"type": "string"},
-				"method":   map[string]interface{}{"$ref": "#/definitions/method"},
-				"body":     map[string]interface{}{"type": "string"},
-				"interval": map[string]interface{}{"$ref": "#/definitions/duration"},
-				"timeout":  map[string]interface{}{"$ref": "#/definitions/duration"},
-				"actions":  map[string]interface{}{"$ref": "#/definitions/actionList"},
+				"url":               map[string]interface{}{"type": "string"},
+				"method":            map[string]interface{}{"$ref": "#/definitions/method"},
+				"body":              map[string]interface{}{"type": "string"},
+				"interval":          map[string]interface{}{"$ref": "#/definitions/duration"},
+				"timeout":           map[string]interface{}{"$ref": "#/definitions/duration"},
+				"actions":           map[string]interface{}{"$ref": "#/definitions/actionList"},
+				"useDefaultActions": map[string]interface{}{"type": "boolean"},
 				"expectStatus": map[string]interface{}{
 					"type":    "integer",
 					"minimum": float64(100),
@@ -209,6 +215,26 @@ var healthCheckSchema = map[string]interface{}{
 				"expectInBody": map[string]interface{}{"type": "string"},
 			},
 		},
+		"scriptCheck": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required": []interface{}{
+				"interval",
+				"timeout",
+				"script",
+			},
+			"properties": map[string]interface{}{
+				"script": map[string]interface{}{"type": "string"},
+				"args": map[string]interface{}{
+					"type":  "array",
+					"items": map[string]interface{}{"type": "string"},
+				},
+				"interval":          map[string]interface{}{"$ref": "#/definitions/duration"},
+				"timeout":           map[string]interface{}{"$ref": "#/definitions/duration"},
+				"actions":           map[string]interface{}{"$ref": "#/definitions/actionList"},
+				"useDefaultActions": map[string]interface{}{"type": "boolean"},
+			},
+		},
 		"portCheckList": map[string]interface{}{
 			"type": "array",
 			"items": map[string]interface{}{
@@ -221,6 +247,12 @@ var healthCheckSchema = map[string]interface{}{
 				"$ref": "#/definitions/httpCheck",
 			},
 		},
+		"scriptCheckList": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"$ref": "#/definitions/scriptCheck",
+			},
+		},
 	},
 	"properties": map[string]interface{}{
 		"portChecks": map[string]interface{}{
@@ -229,6 +261,9 @@ var healthCheckSchema = map[string]interface{}{
 		"httpChecks": map[string]interface{}{
 			"$ref": "#/definitions/httpCheckList",
 		},
+		"scriptChecks": map[string]interface{}{
+			"$ref": "#/definitions/scriptCheckList",
+		},
 	},
 }
 
@@ -245,6 +280,12 @@ var tunnelDefinitions = map[string]interface{}{
 		"not":         map[string]interface{}{"pattern": "^$"},
 		"description": "idn-hostname allows ipv4 and ipv6 addresses, as well as hostnames that might happen to contain '*' and/or '/'. so idn-hostname allows every supported intercept address, although ip addresses, wildcards and cidrs are only being validated as hostnames by this format. client applications will need to look for _valid_ ips, cidrs, and wildcards when parsing intercept addresses and treat them accordingly. anything else should be interpreted as a dns label. this means e.g. that '1.2.3.4/56' should be treated as a dns label, since it is not a valid cidr",
 	},
+	"addressPattern": map[string]interface{}{
+		"type":        "string",
+		"format":      "regex",
+		"not":         map[string]interface{}{"pattern": "^$"},
+		"description": "a regular expression which must match the whole hostname being resolved. matching is case-insensitive",
+	},
 	"inhabitedSet": map[string]interface{}{
 		"type":        "array",
 		"minItems":    1,
@@ -268,11 +309,54 @@ var tunnelDefinitions = map[string]interface{}{
 		"type": "string",
 		"enum": []interface{}{"tcp", "udp"},
 	},
+	"hostProtocolName": map[string]interface{}{
+		"type": "string",
+		"enum": []interface{}{"tcp", "udp", "unix"},
+	},
 	"timeoutSeconds": map[string]interface{}{
 		"type":    "integer",
 		"minimum": float64(0),
 		"maximum": float64(math.MaxInt32),
 	},
+	"udpOptions": map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"idleTimeout": map[string]interface{}{
+				"type":        "string",
+				"pattern":     "[0-9]+(h|m|s|ms)",
+				"description": "how long a udp flow may be idle before it is closed. defaults to 30s for intercepted flows, and no timeout for hosted flows.",
+			},
+			"maxDatagramSize": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     float64(1),
+				"maximum":     float64(65507),
+				"description": "datagrams larger than this size are dropped. defaults to 65507.",
+			},
+			"maxConnections": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     float64(1),
+				"description": "maximum number of concurrent udp flows tracked for the service. when the limit is reached, the least recently used flow is closed. unlimited by default.",
+			},
+		},
+	},
+	"connectionPool": map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"maxIdle": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     float64(1),
+				"maximum":     float64(1000),
+				"description": "maximum number of idle connections kept open for each dialed address. defaults to 10.",
+			},
+			"idleTimeout": map[string]interface{}{
+				"type":        "string",
+				"pattern":     "[0-9]+(h|m|s|ms)",
+				"description": "how long a pooled connection may be idle before it is closed. defaults to 30s.",
+			},
+		},
+	},
 	"proxyType": map[string]interface{}{
 		"type":        "string",
 		"enum":        []interface{}{"http"},
@@ -327,8 +411,8 @@ var hostV1SchemaSansDefs = map[string]interface{}{
 	"properties": combine(healthCheckSchema["properties"].(map[string]interface{}),
 		map[string]interface{}{
 			"protocol": map[string]interface{}{
-				"$ref":        "#/definitions/protocolName",
-				"description": "Dial the specified protocol when a ziti client connects to the service.",
+				"$ref":        "#/definitions/hostProtocolName",
+				"description": "Dial the specified protocol when a ziti client connects to the service. When 'unix', the address is the path of a Unix domain socket and no port is used.",
 			},
 			"forwardProtocol": map[string]interface{}{
 				"type":        "boolean",
@@ -344,7 +428,7 @@ var hostV1SchemaSansDefs = map[string]interface{}{
 			},
 			"address": map[string]interface{}{
 				"$ref":        "#/definitions/dialAddress",
-				"description": "Dial the specified ip address or hostname when a ziti client connects to the service.",
+				"description": "Dial the specified ip address or hostname, or Unix domain socket path, when a ziti client connects to the service.",
 			},
 			"forwardAddress": map[string]interface{}{
 				"type":        "boolean",
@@ -431,6 +515,19 @@ var hostV1SchemaSansDefs = map[string]interface{}{
 				"$ref":        "#/definitions/proxyConfiguration",
 				"description": "If defined, outgoing connections will be send through this proxy server",
 			},
+			"proxyProtocol": map[string]interface{}{
+				"type":        "string",
+				"enum":        []interface{}{"v2"},
+				"description": "If set to 'v2', a PROXY protocol v2 header carrying the original client address and identity is sent to the hosted application when a tcp connection is established. Ignored for udp and unix connections.",
+			},
+			"udpOptions": map[string]interface{}{
+				"$ref":        "#/definitions/udpOptions",
+				"description": "udp flow settings used by hosting tunnelers when dialing udp addresses",
+			},
+			"connectionPool": map[string]interface{}{
+				"$ref":        "#/definitions/connectionPool",
+				"description": "If defined, tcp connections to the hosted server are kept open when a circuit ends and reused by later circuits. Only suitable for request/response protocols, such as http with keep-alive, where each request is completed before the client closes its connection. Not used with proxyProtocol or when a source address is spoofed.",
+			},
 		},
 	),
 	"additionalProperties": false,
@@ -474,7 +571,29 @@ var hostV1SchemaSansDefs = map[string]interface{}{
 				"required": []interface{}{"allowedPortRanges"},
 			},
 			"else": map[string]interface{}{
-				"required": []interface{}{"port"},
+				"if": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"protocol": map[string]interface{}{"const": "unix"},
+					},
+					"required": []interface{}{"protocol"},
+				},
+				"else": map[string]interface{}{
+					"required": []interface{}{"port"},
+				},
+			},
+		},
+		map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"protocol": map[string]interface{}{"const": "unix"},
+				},
+				"required": []interface{}{"protocol"},
+			},
+			"then": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"forwardAddress": map[string]interface{}{"const": false},
+					"forwardPort":    map[string]interface{}{"const": false},
+				},
 			},
 		},
 	},
@@ -545,6 +664,20 @@ var interceptV1ConfigType = &ConfigType{
 					map[string]interface{}{"items": map[string]interface{}{"$ref": "#/definitions/listenAddress"}},
 				},
 			},
+			"addressPatterns": map[string]interface{}{
+				"allOf": []interface{}{
+					map[string]interface{}{"$ref": "#/definitions/inhabitedSet"},
+					map[string]interface{}{"items": map[string]interface{}{"$ref": "#/definitions/addressPattern"}},
+				},
+				"description": "regular expressions matched against hostnames. ips are assigned to matching hostnames as they are resolved, as with wildcard addresses.",
+			},
+			"excludedAddresses": map[string]interface{}{
+				"allOf": []interface{}{
+					map[string]interface{}{"$ref": "#/definitions/inhabitedSet"},
+					map[string]interface{}{"items": map[string]interface{}{"$ref": "#/definitions/listenAddress"}},
+				},
+				"description": "ips/cidrs which will not be intercepted, even if they fall inside an intercepted cidr.",
+			},
 			"portRanges": map[string]interface{}{
 				"allOf": []interface{}{
 					map[string]interface{}{"$ref": "#/definitions/inhabitedSet"},
@@ -576,12 +709,25 @@ var interceptV1ConfigType = &ConfigType{
 				},
 				"description": "white list of source ips/cidrs that can be intercepted. all ips can be intercepted if this is not set.",
 			},
+			"udpOptions": map[string]interface{}{
+				"$ref":        "#/definitions/udpOptions",
+				"description": "udp flow tracking settings used by intercepting tunnelers",
+			},
+			"priority": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     float64(-1000),
+				"maximum":     float64(1000),
+				"description": "when intercepted addresses of different services overlap, the service with the highest priority is used. if the priorities are equal, the service with the most specific address is used. defaults to 0.",
+			},
 		},
 		"required": []interface{}{
 			"protocols",
-			"addresses",
 			"portRanges",
 		},
+		"anyOf": []interface{}{
+			map[string]interface{}{"required": []interface{}{"addresses"}},
+			map[string]interface{}{"required": []interface{}{"addressPatterns"}},
+		},
 	},
 }
 
@@ -687,6 +833,73 @@ var proxyConfigTypeV1 = &ConfigType{
 	},
 }
 
+var QosV1TypeId = "qos.v1"
+
+var qosConfigTypeV1 = &ConfigType{
+	BaseExtEntity: boltz.BaseExtEntity{
+		Id: QosV1TypeId,
+	},
+	Name: QosV1TypeId,
+	Schema: map[string]interface{}{
+		"$id":                  "https://netfoundry.io/schemas/qos.v1.config.json",
+		"type":                 "object",
+		"additionalProperties": false,
+		"definitions": map[string]interface{}{
+			"bandwidthLimit": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"ingressBytesPerSecond": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     0,
+						"description": "Maximum rate of traffic from edge clients into the network, in bytes per second. 0 means unlimited",
+					},
+					"egressBytesPerSecond": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     0,
+						"description": "Maximum rate of traffic from the network to edge clients, in bytes per second. 0 means unlimited",
+					},
+					"burstBytes": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     0,
+						"description": "Number of bytes which may be sent at once, above the rate limit. Defaults to one second of traffic",
+					},
+				},
+			},
+		},
+		"properties": map[string]interface{}{
+			"service": map[string]interface{}{
+				"$ref": "#/definitions/bandwidthLimit",
+			},
+			"identity": map[string]interface{}{
+				"$ref": "#/definitions/bandwidthLimit",
+			},
+		},
+	},
+}
+
+var E2eEncryptionV1TypeId = "e2e-encryption.v1"
+
+var e2eEncryptionConfigTypeV1 = &ConfigType{
+	BaseExtEntity: boltz.BaseExtEntity{
+		Id: E2eEncryptionV1TypeId,
+	},
+	Name: E2eEncryptionV1TypeId,
+	Schema: map[string]interface{}{
+		"$id":                  "https://netfoundry.io/schemas/e2e-encryption.v1.config.json",
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []interface{}{"policy"},
+		"properties": map[string]interface{}{
+			"policy": map[string]interface{}{
+				"type":        "string",
+				"enum":        []interface{}{"required", "allowed", "disabled"},
+				"description": "Whether edge routers require, allow or disable end-to-end encryption between the dialing SDK and the hosting application",
+			},
+		},
+	},
+}
+
 func (m *Migrations) createInitialTunnelerConfigTypes(step *boltz.MigrationStep) {
 	clientConfigTypeV1 := &ConfigType{
 		BaseExtEntity: boltz.BaseExtEntity{Id: clientConfigV1TypeId},
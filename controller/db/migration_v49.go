@@ -0,0 +1,29 @@
+package db
+
+import (
+	"github.com/openziti/storage/boltz"
+	"time"
+)
+
+func (m *Migrations) addProcessHashPostureCheck(step *boltz.MigrationStep) {
+	processHashCheckType := &PostureCheckType{
+		BaseExtEntity: boltz.BaseExtEntity{
+			Id:        PostureCheckTypeProcessHash,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Tags:      map[string]interface{}{},
+			Migrate:   false,
+		},
+		Name: "Process Hash Check",
+		OperatingSystems: []OperatingSystem{
+			{OsType: "Windows", OsVersions: []string{}},
+			{OsType: "macOS", OsVersions: []string{}},
+			{OsType: "Linux", OsVersions: []string{}},
+		},
+	}
+
+	if err := m.stores.PostureCheckType.Create(step.Ctx, processHashCheckType); err != nil {
+		step.SetError(err)
+		return
+	}
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"github.com/openziti/storage/boltz"
+	"time"
+)
+
+func (m *Migrations) addTpmAttestationPostureCheck(step *boltz.MigrationStep) {
+	tpmCheckType := &PostureCheckType{
+		BaseExtEntity: boltz.BaseExtEntity{
+			Id:        PostureCheckTypeTpm,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Tags:      map[string]interface{}{},
+			Migrate:   false,
+		},
+		Name: "TPM Attestation Check",
+		OperatingSystems: []OperatingSystem{
+			{OsType: "Windows", OsVersions: []string{}},
+			{OsType: "Linux", OsVersions: []string{}},
+		},
+	}
+
+	if err := m.stores.PostureCheckType.Create(step.Ctx, tpmCheckType); err != nil {
+		step.SetError(err)
+		return
+	}
+}
@@ -25,7 +25,7 @@ import (
 )
 
 const (
-	CurrentDbVersion = 43
+	CurrentDbVersion = 55
 	FieldVersion     = "version"
 )
 
@@ -205,6 +205,61 @@ func (m *Migrations) migrate(step *boltz.MigrationStep) int {
 		m.createOrUpdateConfigType(step, proxyConfigTypeV1)
 	}
 
+	if step.CurrentVersion < 44 {
+		m.createOrUpdateConfigType(step, qosConfigTypeV1)
+	}
+
+	if step.CurrentVersion < 45 {
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV1ConfigType, nil))
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV2ConfigType, nil))
+	}
+
+	if step.CurrentVersion < 46 {
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, interceptV1ConfigType, nil))
+	}
+
+	if step.CurrentVersion < 47 {
+		m.createOrUpdateConfigType(step, e2eEncryptionConfigTypeV1)
+	}
+
+	if step.CurrentVersion < 48 {
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, interceptV1ConfigType, nil))
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV1ConfigType, nil))
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV2ConfigType, nil))
+	}
+
+	if step.CurrentVersion < 49 {
+		m.addProcessHashPostureCheck(step)
+	}
+
+	if step.CurrentVersion < 50 {
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, interceptV1ConfigType, nil))
+	}
+
+	if step.CurrentVersion < 51 {
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV1ConfigType, nil))
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV2ConfigType, nil))
+	}
+
+	if step.CurrentVersion < 52 {
+		m.addTpmAttestationPostureCheck(step)
+	}
+
+	if step.CurrentVersion < 53 {
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV1ConfigType, nil))
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV2ConfigType, nil))
+	}
+
+	if step.CurrentVersion < 54 {
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV1ConfigType, nil))
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV2ConfigType, nil))
+	}
+
+	if step.CurrentVersion < 55 {
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV1ConfigType, nil))
+		step.SetError(m.stores.ConfigType.Update(step.Ctx, hostV2ConfigType, nil))
+	}
+
 	// current version
 	if step.CurrentVersion <= CurrentDbVersion {
 		return CurrentDbVersion
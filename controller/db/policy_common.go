@@ -160,7 +160,7 @@ func (store *baseStore[E]) updateServicePolicyRelatedRoles(ctx *roleAttributeCha
 	for ; cursor.IsValid(); cursor.Next() {
 		policyId := cursor.Current()
 		roleSet := ctx.rolesSymbol.EvalStringList(ctx.tx(), policyId)
-		roles, ids, err := splitRolesAndIds(roleSet)
+		roles, ids, expressions, err := splitRoleEntries(roleSet)
 		if err != nil {
 			ctx.SetError(err)
 			return
@@ -216,7 +216,7 @@ func (store *baseStore[E]) updateServicePolicyRelatedRoles(ctx *roleAttributeCha
 				"semantic": semantic,
 				"symbol":   ctx.rolesSymbol.GetName(),
 			})
-		evaluatePolicyAgainstEntity(ctx, semantic, entityId, policyId, ids, roles, entityRoles, log)
+		evaluatePolicyAgainstEntity(ctx, semantic, entityId, policyId, ids, roles, expressions, entityRoles, log)
 	}
 
 	ctx.processServicePolicyEvents()
@@ -239,7 +239,7 @@ func EvaluatePolicy(ctx *roleAttributeChangeContext, policy Policy, roleAttribut
 		})
 
 	roleSet := ctx.rolesSymbol.EvalStringList(ctx.tx(), policyId)
-	roles, ids, err := splitRolesAndIds(roleSet)
+	roles, ids, expressions, err := splitRoleEntries(roleSet)
 	log.Tracef("roleSet: %v", roleSet)
 	if err != nil {
 		ctx.SetError(err)
@@ -247,6 +247,7 @@ func EvaluatePolicy(ctx *roleAttributeChangeContext, policy Policy, roleAttribut
 	}
 	log.Tracef("roles: %v", roles)
 	log.Tracef("ids: %v", ids)
+	log.Tracef("expressions: %v", expressions)
 
 	if err := validateEntityIds(ctx.tx(), ctx.linkCollection.GetLinkedSymbol().GetStore(), ctx.rolesSymbol.GetName(), ids); err != nil {
 		ctx.SetError(err)
@@ -257,7 +258,7 @@ func EvaluatePolicy(ctx *roleAttributeChangeContext, policy Policy, roleAttribut
 	for ; cursor.IsValid(); cursor.Next() {
 		entityId := cursor.Current()
 		entityRoleAttributes := roleAttributesSymbol.EvalStringList(ctx.tx(), entityId)
-		match, change := evaluatePolicyAgainstEntity(ctx, semantic, entityId, policyId, ids, roles, entityRoleAttributes, log)
+		match, change := evaluatePolicyAgainstEntity(ctx, semantic, entityId, policyId, ids, roles, expressions, entityRoleAttributes, log)
 		log.Tracef("evaluating %v match: %v, change: %v", string(entityId), match, change)
 	}
 	ctx.processServicePolicyEvents()
@@ -284,7 +285,7 @@ func UpdateRelatedRoles(ctx *roleAttributeChangeContext, entityId []byte, newRol
 	for ; cursor.IsValid(); cursor.Next() {
 		policyId := cursor.Current()
 		roleSet := ctx.rolesSymbol.EvalStringList(ctx.tx(), policyId)
-		roles, ids, err := splitRolesAndIds(roleSet)
+		roles, ids, expressions, err := splitRoleEntries(roleSet)
 		if err != nil {
 			ctx.SetError(err)
 			return
@@ -301,16 +302,15 @@ func UpdateRelatedRoles(ctx *roleAttributeChangeContext, entityId []byte, newRol
 				"semantic": semantic,
 				"symbol":   ctx.rolesSymbol.GetName(),
 			})
-		evaluatePolicyAgainstEntity(ctx, semantic, entityId, policyId, ids, roles, entityRoles, log)
+		evaluatePolicyAgainstEntity(ctx, semantic, entityId, policyId, ids, roles, expressions, entityRoles, log)
 	}
 
 	ctx.processServicePolicyEvents()
 }
 
-func evaluatePolicyAgainstEntity(ctx *roleAttributeChangeContext, semantic string, entityId, policyId []byte, ids, roles, roleAttributes []string, log *logrus.Entry) (bool, bool) {
+func evaluatePolicyAgainstEntity(ctx *roleAttributeChangeContext, semantic string, entityId, policyId []byte, ids, roles []string, expressions []*RoleExpression, roleAttributes []string, log *logrus.Entry) (bool, bool) {
 	if stringz.Contains(ids, string(entityId)) || stringz.Contains(roles, "all") ||
-		(strings.EqualFold(semantic, SemanticAllOf) && len(roles) > 0 && stringz.ContainsAll(roleAttributes, roles...)) ||
-		(strings.EqualFold(semantic, SemanticAnyOf) && len(roles) > 0 && stringz.ContainsAny(roleAttributes, roles...)) {
+		rolesMatch(semantic, roles, expressions, roleAttributes) {
 		return true, ProcessEntityPolicyMatched(ctx, entityId, policyId, log)
 	} else {
 		return false, ProcessEntityPolicyUnmatched(ctx, entityId, policyId, log)
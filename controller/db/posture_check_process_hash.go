@@ -0,0 +1,106 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package db
+
+import (
+	"strings"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/storage/boltz"
+)
+
+const (
+	FieldPostureCheckProcessHashOsType    = "osType"
+	FieldPostureCheckProcessHashPath      = "path"
+	FieldPostureCheckProcessHashHashes    = "hashes"
+	FieldPostureCheckProcessHashProcesses = "processes"
+)
+
+// PostureCheckProcessHash passes if any of the processes for the client's operating system is running from a
+// binary with one of the allowed SHA-256 hashes
+type PostureCheckProcessHash struct {
+	Processes []*ProcessHash `json:"processes"`
+}
+
+type ProcessHash struct {
+	OsType string   `json:"osType"`
+	Path   string   `json:"path"`
+	Hashes []string `json:"hashes"`
+}
+
+func newPostureCheckProcessHash() PostureCheckSubType {
+	return &PostureCheckProcessHash{}
+}
+
+func (entity *PostureCheckProcessHash) GetTypeId() string {
+	return PostureCheckTypeProcessHash
+}
+
+func (entity *PostureCheckProcessHash) LoadValues(bucket *boltz.TypedBucket) {
+	processesBucket := bucket.GetBucket(FieldPostureCheckProcessHashProcesses)
+	if processesBucket == nil {
+		return
+	}
+
+	processCursor := processesBucket.Cursor()
+
+	for key, _ := processCursor.First(); key != nil; key, _ = processCursor.Next() {
+		procBucket := processesBucket.GetBucket(string(key))
+		proc := &ProcessHash{}
+
+		proc.OsType = procBucket.GetStringOrError(FieldPostureCheckProcessHashOsType)
+		proc.Path = procBucket.GetStringOrError(FieldPostureCheckProcessHashPath)
+		proc.Hashes = procBucket.GetStringList(FieldPostureCheckProcessHashHashes)
+
+		entity.Processes = append(entity.Processes, proc)
+	}
+}
+
+func (entity *PostureCheckProcessHash) SetValues(ctx *boltz.PersistContext, bucket *boltz.TypedBucket) {
+	processesBucket := bucket.GetOrCreateBucket(FieldPostureCheckProcessHashProcesses)
+
+	seenKeys := map[string]struct{}{}
+	for _, proc := range entity.Processes {
+		for i, hash := range proc.Hashes {
+			proc.Hashes[i] = strings.ToLower(hash)
+		}
+
+		key := proc.OsType + "-" + proc.Path
+		seenKeys[key] = struct{}{}
+
+		procBucket := processesBucket.GetOrCreateBucket(key)
+
+		procBucket.SetString(FieldPostureCheckProcessHashOsType, proc.OsType, ctx.FieldChecker)
+		procBucket.SetString(FieldPostureCheckProcessHashPath, proc.Path, ctx.FieldChecker)
+		procBucket.SetStringList(FieldPostureCheckProcessHashHashes, proc.Hashes, ctx.FieldChecker)
+	}
+
+	processCursor := processesBucket.Cursor()
+
+	var removeKeys [][]byte
+	for key, _ := processCursor.First(); key != nil; key, _ = processCursor.Next() {
+		if _, ok := seenKeys[string(key)]; !ok {
+			removeKeys = append(removeKeys, key)
+		}
+	}
+
+	for _, key := range removeKeys {
+		if err := processesBucket.DeleteBucket(key); err != nil {
+			pfxlog.Logger().Debugf("error deleting process hash key %s: %v", string(key), err)
+		}
+	}
+}
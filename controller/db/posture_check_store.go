@@ -37,8 +37,10 @@ const (
 	PostureCheckTypeDomain       = "DOMAIN"
 	PostureCheckTypeProcess      = "PROCESS"
 	PostureCheckTypeProcessMulti = "PROCESS_MULTI"
+	PostureCheckTypeProcessHash  = "PROCESS_HASH"
 	PostureCheckTypeMAC          = "MAC"
 	PostureCheckTypeMFA          = "MFA"
+	PostureCheckTypeTpm          = "TPM_ATTESTATION"
 )
 
 var postureCheckSubTypeMap = map[string]newPostureCheckSubType{
@@ -46,8 +48,10 @@ var postureCheckSubTypeMap = map[string]newPostureCheckSubType{
 	PostureCheckTypeDomain:       newPostureCheckWindowsDomain,
 	PostureCheckTypeProcess:      newPostureCheckProcess,
 	PostureCheckTypeProcessMulti: newPostureCheckProcessMulti,
+	PostureCheckTypeProcessHash:  newPostureCheckProcessHash,
 	PostureCheckTypeMAC:          newPostureCheckMacAddresses,
 	PostureCheckTypeMFA:          newPostureCheckMfa,
+	PostureCheckTypeTpm:          newPostureCheckTpmAttestation,
 }
 
 type newPostureCheckSubType func() PostureCheckSubType
@@ -0,0 +1,101 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package db
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/storage/boltz"
+)
+
+const (
+	FieldPostureCheckTpmPcrs      = "pcrs"
+	FieldPostureCheckTpmPcrIndex  = "index"
+	FieldPostureCheckTpmPcrValues = "values"
+)
+
+// PostureCheckTpmAttestation passes if the client has attested with a trusted TPM during the current API session
+// and every listed PCR was quoted with one of its allowed SHA-256 values
+type PostureCheckTpmAttestation struct {
+	Pcrs []*TpmPcr `json:"pcrs"`
+}
+
+type TpmPcr struct {
+	Index  int      `json:"index"`
+	Values []string `json:"values"`
+}
+
+func newPostureCheckTpmAttestation() PostureCheckSubType {
+	return &PostureCheckTpmAttestation{}
+}
+
+func (entity *PostureCheckTpmAttestation) GetTypeId() string {
+	return PostureCheckTypeTpm
+}
+
+func (entity *PostureCheckTpmAttestation) LoadValues(bucket *boltz.TypedBucket) {
+	pcrsBucket := bucket.GetBucket(FieldPostureCheckTpmPcrs)
+	if pcrsBucket == nil {
+		return
+	}
+
+	cursor := pcrsBucket.Cursor()
+
+	for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+		pcrBucket := pcrsBucket.GetBucket(string(key))
+		pcr := &TpmPcr{
+			Index:  int(pcrBucket.GetInt32WithDefault(FieldPostureCheckTpmPcrIndex, 0)),
+			Values: pcrBucket.GetStringList(FieldPostureCheckTpmPcrValues),
+		}
+		entity.Pcrs = append(entity.Pcrs, pcr)
+	}
+}
+
+func (entity *PostureCheckTpmAttestation) SetValues(ctx *boltz.PersistContext, bucket *boltz.TypedBucket) {
+	pcrsBucket := bucket.GetOrCreateBucket(FieldPostureCheckTpmPcrs)
+
+	seenKeys := map[string]struct{}{}
+	for _, pcr := range entity.Pcrs {
+		for i, value := range pcr.Values {
+			pcr.Values[i] = strings.ToLower(value)
+		}
+
+		key := strconv.Itoa(pcr.Index)
+		seenKeys[key] = struct{}{}
+
+		pcrBucket := pcrsBucket.GetOrCreateBucket(key)
+		pcrBucket.SetInt32(FieldPostureCheckTpmPcrIndex, int32(pcr.Index), ctx.FieldChecker)
+		pcrBucket.SetStringList(FieldPostureCheckTpmPcrValues, pcr.Values, ctx.FieldChecker)
+	}
+
+	cursor := pcrsBucket.Cursor()
+
+	var removeKeys [][]byte
+	for key, _ := cursor.First(); key != nil; key, _ = cursor.Next() {
+		if _, ok := seenKeys[string(key)]; !ok {
+			removeKeys = append(removeKeys, key)
+		}
+	}
+
+	for _, key := range removeKeys {
+		if err := pcrsBucket.DeleteBucket(key); err != nil {
+			pfxlog.Logger().Debugf("error deleting tpm pcr key %s: %v", string(key), err)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package db
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/openziti/foundation/v2/stringz"
+	"github.com/pkg/errors"
+)
+
+const (
+	roleExprAnd = "and"
+	roleExprOr  = "or"
+	roleExprNot = "not"
+)
+
+// RoleExpression is a boolean expression over role attributes, which can be used as a policy role entry in place of
+// a single attribute. Attributes are prefixed with #, as in other role entries, and may be combined with and, or, not
+// and parentheses. not binds tightest, followed by and, then or. For example:
+//
+//	#finance and #laptop and not #contractor
+//	(#finance or #accounting) and not #contractor
+//
+// Expressions are combined with the other role entries of a policy using the policy semantic. With AllOf, every
+// attribute and expression must match. With AnyOf, at least one must match.
+type RoleExpression struct {
+	source string
+	root   roleExprNode
+}
+
+// IsRoleExpression returns true if the role entry should be treated as an expression. So that existing role
+// attributes containing spaces keep their meaning, an entry is only an expression if it starts with not or a
+// parenthesis, or if it contains an operator and at least two attributes.
+func IsRoleExpression(entry string) bool {
+	words := strings.Fields(entry)
+	if len(words) < 2 {
+		return false
+	}
+
+	if strings.HasPrefix(words[0], "(") || strings.EqualFold(words[0], roleExprNot) {
+		return true
+	}
+
+	hasOperator := false
+	attributeCount := 0
+	for _, word := range words {
+		if isRoleExprOperator(word) {
+			hasOperator = true
+		} else if strings.HasPrefix(strings.TrimLeft(word, "("), RolePrefix) {
+			attributeCount++
+		}
+	}
+	return hasOperator && attributeCount > 1
+}
+
+// ParseRoleExpression parses a role expression, returning an error describing the first problem found, if the
+// expression is invalid
+func ParseRoleExpression(expression string) (*RoleExpression, error) {
+	parser := &roleExprParser{
+		tokens: tokenizeRoleExpression(expression),
+	}
+
+	if len(parser.tokens) == 0 {
+		return nil, errors.New("role expression is empty")
+	}
+
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.pos < len(parser.tokens) {
+		return nil, errors.Errorf("unexpected '%s' at position %d of role expression", parser.tokens[parser.pos], parser.pos+1)
+	}
+
+	return &RoleExpression{
+		source: expression,
+		root:   root,
+	}, nil
+}
+
+// Matches returns true if an entity with the given role attributes is selected by the expression
+func (self *RoleExpression) Matches(roleAttributes []string) bool {
+	return self.root.eval(roleAttributes)
+}
+
+// Attributes returns the distinct attributes referenced by the expression, without the # prefix, in sorted order
+func (self *RoleExpression) Attributes() []string {
+	set := map[string]struct{}{}
+	self.root.collectAttributes(set)
+	result := stringz.SetToSlice(set)
+	sort.Strings(result)
+	return result
+}
+
+// String returns the expression in a normalized form, with parentheses around every and and or
+func (self *RoleExpression) String() string {
+	return self.root.String()
+}
+
+// Source returns the expression as it was given
+func (self *RoleExpression) Source() string {
+	return self.source
+}
+
+type roleExprNode interface {
+	eval(roleAttributes []string) bool
+	collectAttributes(set map[string]struct{})
+	String() string
+}
+
+type roleExprAttribute string
+
+func (self roleExprAttribute) eval(roleAttributes []string) bool {
+	return self == "all" || stringz.Contains(roleAttributes, string(self))
+}
+
+func (self roleExprAttribute) collectAttributes(set map[string]struct{}) {
+	set[string(self)] = struct{}{}
+}
+
+func (self roleExprAttribute) String() string {
+	return RolePrefix + string(self)
+}
+
+type roleExprNegation struct {
+	child roleExprNode
+}
+
+func (self *roleExprNegation) eval(roleAttributes []string) bool {
+	return !self.child.eval(roleAttributes)
+}
+
+func (self *roleExprNegation) collectAttributes(set map[string]struct{}) {
+	self.child.collectAttributes(set)
+}
+
+func (self *roleExprNegation) String() string {
+	return roleExprNot + " " + self.child.String()
+}
+
+type roleExprJunction struct {
+	op       string
+	children []roleExprNode
+}
+
+func (self *roleExprJunction) eval(roleAttributes []string) bool {
+	isAnd := self.op == roleExprAnd
+	for _, child := range self.children {
+		if matched := child.eval(roleAttributes); matched != isAnd {
+			// a false child decides an and, a true child decides an or
+			return matched
+		}
+	}
+	return isAnd
+}
+
+func (self *roleExprJunction) collectAttributes(set map[string]struct{}) {
+	for _, child := range self.children {
+		child.collectAttributes(set)
+	}
+}
+
+func (self *roleExprJunction) String() string {
+	var parts []string
+	for _, child := range self.children {
+		parts = append(parts, child.String())
+	}
+	return "(" + strings.Join(parts, " "+self.op+" ") + ")"
+}
+
+type roleExprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (self *roleExprParser) peek() string {
+	if self.pos < len(self.tokens) {
+		return self.tokens[self.pos]
+	}
+	return ""
+}
+
+func (self *roleExprParser) parseOr() (roleExprNode, error) {
+	return self.parseJunction(roleExprOr, self.parseAnd)
+}
+
+func (self *roleExprParser) parseAnd() (roleExprNode, error) {
+	return self.parseJunction(roleExprAnd, self.parseUnary)
+}
+
+func (self *roleExprParser) parseJunction(op string, parseChild func() (roleExprNode, error)) (roleExprNode, error) {
+	first, err := parseChild()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []roleExprNode{first}
+	for strings.EqualFold(self.peek(), op) {
+		self.pos++
+		child, err := parseChild()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &roleExprJunction{op: op, children: children}, nil
+}
+
+func (self *roleExprParser) parseUnary() (roleExprNode, error) {
+	token := self.peek()
+	position := self.pos + 1
+
+	switch {
+	case token == "":
+		return nil, errors.New("role expression ended unexpectedly, expected an attribute, 'not' or '('")
+	case strings.EqualFold(token, roleExprNot):
+		self.pos++
+		child, err := self.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &roleExprNegation{child: child}, nil
+	case token == "(":
+		self.pos++
+		child, err := self.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if self.peek() != ")" {
+			return nil, errors.Errorf("missing ')' to close '(' at position %d of role expression", position)
+		}
+		self.pos++
+		return child, nil
+	case strings.HasPrefix(token, RolePrefix) && len(token) > len(RolePrefix):
+		self.pos++
+		return roleExprAttribute(strings.TrimPrefix(token, RolePrefix)), nil
+	case strings.HasPrefix(token, EntityPrefix):
+		return nil, errors.Errorf("'%s' at position %d of role expression is an entity reference. Only role attributes, prefixed with %s, may be used in expressions", token, position, RolePrefix)
+	default:
+		return nil, errors.Errorf("unexpected '%s' at position %d of role expression, expected an attribute prefixed with %s, 'not' or '('", token, position, RolePrefix)
+	}
+}
+
+func isRoleExprOperator(word string) bool {
+	return strings.EqualFold(word, roleExprAnd) || strings.EqualFold(word, roleExprOr) || strings.EqualFold(word, roleExprNot)
+}
+
+// tokenizeRoleExpression splits an expression on whitespace. Parentheses are separate tokens when they open or close
+// a word, so they can be written next to attributes, as in (#a or #b)
+func tokenizeRoleExpression(expression string) []string {
+	var result []string
+	for _, word := range strings.Fields(expression) {
+		for strings.HasPrefix(word, "(") {
+			result = append(result, "(")
+			word = word[1:]
+		}
+
+		closing := 0
+		for strings.HasSuffix(word, ")") {
+			closing++
+			word = word[:len(word)-1]
+		}
+
+		if word != "" {
+			result = append(result, word)
+		}
+
+		for i := 0; i < closing; i++ {
+			result = append(result, ")")
+		}
+	}
+	return result
+}
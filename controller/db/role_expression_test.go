@@ -0,0 +1,115 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRoleExpression(t *testing.T) {
+	req := require.New(t)
+
+	req.True(IsRoleExpression("#finance and #laptop"))
+	req.True(IsRoleExpression("#finance or #laptop"))
+	req.True(IsRoleExpression("not #contractor"))
+	req.True(IsRoleExpression("(#finance or #laptop) and #desktop"))
+	req.True(IsRoleExpression("NOT #contractor"))
+
+	req.False(IsRoleExpression("#finance"))
+	req.False(IsRoleExpression("@finance"))
+	req.False(IsRoleExpression("#parsley, sage, rosemary and don't forget thyme"))
+	req.False(IsRoleExpression("#salt and pepper"))
+	req.False(IsRoleExpression("#not"))
+}
+
+func TestParseRoleExpression(t *testing.T) {
+	t.Run("precedence", func(t *testing.T) {
+		req := require.New(t)
+		expr, err := ParseRoleExpression("#a or #b and not #c")
+		req.NoError(err)
+		req.Equal("(#a or (#b and not #c))", expr.String())
+		req.Equal([]string{"a", "b", "c"}, expr.Attributes())
+	})
+
+	t.Run("parentheses", func(t *testing.T) {
+		req := require.New(t)
+		expr, err := ParseRoleExpression("(#finance or #accounting) and not (#contractor)")
+		req.NoError(err)
+		req.Equal("((#finance or #accounting) and not #contractor)", expr.String())
+		req.Equal("(#finance or #accounting) and not (#contractor)", expr.Source())
+
+		req.True(expr.Matches([]string{"finance", "laptop"}))
+		req.True(expr.Matches([]string{"accounting"}))
+		req.False(expr.Matches([]string{"accounting", "contractor"}))
+		req.False(expr.Matches([]string{"laptop"}))
+		req.False(expr.Matches(nil))
+	})
+
+	t.Run("not matches entities without attributes", func(t *testing.T) {
+		req := require.New(t)
+		expr, err := ParseRoleExpression("not #contractor")
+		req.NoError(err)
+		req.True(expr.Matches(nil))
+		req.False(expr.Matches([]string{"contractor"}))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		req := require.New(t)
+		expr, err := ParseRoleExpression("#all and not #contractor")
+		req.NoError(err)
+		req.True(expr.Matches([]string{"finance"}))
+		req.False(expr.Matches([]string{"contractor"}))
+	})
+
+	t.Run("invalid expressions", func(t *testing.T) {
+		for _, invalid := range []string{
+			"",
+			"#a and",
+			"#a and and #b",
+			"(#a or #b",
+			"#a or #b)",
+			"#a and @someIdentity",
+			"#a and b",
+			"not",
+			"# and #b",
+		} {
+			_, err := ParseRoleExpression(invalid)
+			require.Error(t, err, "expected '%s' to be invalid", invalid)
+		}
+	})
+}
+
+func TestRolesMatch(t *testing.T) {
+	req := require.New(t)
+
+	expr, err := ParseRoleExpression("#finance or #accounting")
+	req.NoError(err)
+	exprs := []*RoleExpression{expr}
+
+	req.True(rolesMatch(SemanticAllOf, []string{"laptop"}, exprs, []string{"laptop", "finance"}))
+	req.False(rolesMatch(SemanticAllOf, []string{"laptop"}, exprs, []string{"finance"}))
+	req.False(rolesMatch(SemanticAllOf, []string{"laptop"}, exprs, []string{"laptop"}))
+
+	req.True(rolesMatch(SemanticAnyOf, []string{"laptop"}, exprs, []string{"laptop"}))
+	req.True(rolesMatch(SemanticAnyOf, []string{"laptop"}, exprs, []string{"finance"}))
+	req.False(rolesMatch(SemanticAnyOf, []string{"laptop"}, exprs, []string{"desktop"}))
+
+	req.False(rolesMatch(SemanticAllOf, nil, nil, []string{"laptop"}))
+	req.False(rolesMatch(SemanticAnyOf, nil, nil, []string{"laptop"}))
+}
@@ -24,21 +24,25 @@ import (
 )
 
 const (
-	EntityTypeRouters      = "routers"
-	FieldRouterFingerprint = "fingerprint"
-	FieldRouterCost        = "cost"
-	FieldRouterNoTraversal = "noTraversal"
-	FieldRouterDisabled    = "disabled"
+	EntityTypeRouters        = "routers"
+	FieldRouterFingerprint   = "fingerprint"
+	FieldRouterCost          = "cost"
+	FieldRouterNoTraversal   = "noTraversal"
+	FieldRouterDisabled      = "disabled"
+	FieldRouterDraining      = "draining"
+	FieldRouterDrainDeadline = "drainDeadline"
 )
 
 type Router struct {
 	boltz.BaseExtEntity
-	Name        string       `json:"name"`
-	Fingerprint *string      `json:"fingerprint"`
-	Cost        uint16       `json:"cost"`
-	NoTraversal bool         `json:"noTraversal"`
-	Disabled    bool         `json:"disabled"`
-	Interfaces  []*Interface `json:"interfaces"`
+	Name          string       `json:"name"`
+	Fingerprint   *string      `json:"fingerprint"`
+	Cost          uint16       `json:"cost"`
+	NoTraversal   bool         `json:"noTraversal"`
+	Disabled      bool         `json:"disabled"`
+	Draining      bool         `json:"draining"`
+	DrainDeadline *time.Time   `json:"drainDeadline"`
+	Interfaces    []*Interface `json:"interfaces"`
 }
 
 func (entity *Router) GetEntityType() string {
@@ -79,6 +83,8 @@ func (store *routerStoreImpl) initializeLocal() {
 	store.AddSymbol(FieldRouterCost, ast.NodeTypeInt64)
 	store.AddSymbol(FieldRouterNoTraversal, ast.NodeTypeBool)
 	store.AddSymbol(FieldRouterDisabled, ast.NodeTypeBool)
+	store.AddSymbol(FieldRouterDraining, ast.NodeTypeBool)
+	store.AddSymbol(FieldRouterDrainDeadline, ast.NodeTypeDatetime)
 }
 
 func (store *routerStoreImpl) initializeLinked() {
@@ -95,6 +101,8 @@ func (self *routerStoreImpl) FillEntity(entity *Router, bucket *boltz.TypedBucke
 	entity.Cost = uint16(bucket.GetInt32WithDefault(FieldRouterCost, 0))
 	entity.NoTraversal = bucket.GetBoolWithDefault(FieldRouterNoTraversal, false)
 	entity.Disabled = bucket.GetBoolWithDefault(FieldRouterDisabled, false)
+	entity.Draining = bucket.GetBoolWithDefault(FieldRouterDraining, false)
+	entity.DrainDeadline = bucket.GetTime(FieldRouterDrainDeadline)
 	entity.Interfaces = loadInterfaces(bucket)
 }
 
@@ -105,6 +113,8 @@ func (self *routerStoreImpl) PersistEntity(entity *Router, ctx *boltz.PersistCon
 	ctx.SetInt32(FieldRouterCost, int32(entity.Cost))
 	ctx.SetBool(FieldRouterNoTraversal, entity.NoTraversal)
 	ctx.SetBool(FieldRouterDisabled, entity.Disabled)
+	ctx.SetBool(FieldRouterDraining, entity.Draining)
+	ctx.SetTimeP(FieldRouterDrainDeadline, entity.DrainDeadline)
 	storeInterfaces(entity.Interfaces, ctx)
 }
 
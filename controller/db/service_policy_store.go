@@ -8,6 +8,7 @@ import (
 	"github.com/openziti/storage/boltz"
 	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
 	"sort"
+	"time"
 )
 
 type PolicyType string
@@ -47,6 +48,12 @@ func GetPolicyTypeForId(policyTypeId int32) PolicyType {
 const (
 	FieldServicePolicyType = "type"
 
+	FieldServicePolicySchedule          = "schedule"
+	FieldServicePolicyScheduleTimezone  = "timezone"
+	FieldServicePolicyScheduleWindows   = "windows"
+	FieldServicePolicyScheduleNotBefore = "notBefore"
+	FieldServicePolicyScheduleNotAfter  = "notAfter"
+
 	PolicyTypeInvalidName = "Invalid"
 	PolicyTypeDialName    = "Dial"
 	PolicyTypeBindName    = "Bind"
@@ -58,12 +65,21 @@ const (
 
 type ServicePolicy struct {
 	boltz.BaseExtEntity
-	PolicyType        PolicyType `json:"policyType"`
-	Name              string     `json:"name"`
-	Semantic          string     `json:"semantic"`
-	IdentityRoles     []string   `json:"identityRoles"`
-	ServiceRoles      []string   `json:"serviceRoles"`
-	PostureCheckRoles []string   `json:"postureCheckRoles"`
+	PolicyType        PolicyType             `json:"policyType"`
+	Name              string                 `json:"name"`
+	Semantic          string                 `json:"semantic"`
+	IdentityRoles     []string               `json:"identityRoles"`
+	ServiceRoles      []string               `json:"serviceRoles"`
+	PostureCheckRoles []string               `json:"postureCheckRoles"`
+	Schedule          *ServicePolicySchedule `json:"schedule"`
+}
+
+// ServicePolicySchedule limits when a service policy grants access. Windows are evaluated in the given timezone.
+type ServicePolicySchedule struct {
+	Timezone  string     `json:"timezone"`
+	Windows   []string   `json:"windows"`
+	NotBefore *time.Time `json:"notBefore"`
+	NotAfter  *time.Time `json:"notAfter"`
 }
 
 func (entity *ServicePolicy) GetName() string {
@@ -155,6 +171,40 @@ func (store *servicePolicyStoreImpl) FillEntity(entity *ServicePolicy, bucket *b
 	entity.IdentityRoles = bucket.GetStringList(FieldIdentityRoles)
 	entity.ServiceRoles = bucket.GetStringList(FieldServiceRoles)
 	entity.PostureCheckRoles = bucket.GetStringList(FieldPostureCheckRoles)
+
+	if scheduleBucket := bucket.GetBucket(FieldServicePolicySchedule); scheduleBucket != nil {
+		entity.Schedule = &ServicePolicySchedule{
+			Timezone:  scheduleBucket.GetStringWithDefault(FieldServicePolicyScheduleTimezone, ""),
+			Windows:   scheduleBucket.GetStringList(FieldServicePolicyScheduleWindows),
+			NotBefore: scheduleBucket.GetTime(FieldServicePolicyScheduleNotBefore),
+			NotAfter:  scheduleBucket.GetTime(FieldServicePolicyScheduleNotAfter),
+		}
+	}
+}
+
+func (store *servicePolicyStoreImpl) persistSchedule(entity *ServicePolicy, ctx *boltz.PersistContext) {
+	if !ctx.ProceedWithSet(FieldServicePolicySchedule) {
+		return
+	}
+
+	if entity.Schedule == nil {
+		if ctx.Bucket.GetBucket(FieldServicePolicySchedule) != nil {
+			ctx.Bucket.SetError(ctx.Bucket.DeleteBucket([]byte(FieldServicePolicySchedule)))
+		}
+		return
+	}
+
+	scheduleBucket, err := ctx.Bucket.EmptyBucket(FieldServicePolicySchedule)
+	if err != nil {
+		ctx.Bucket.SetError(err)
+		return
+	}
+
+	scheduleBucket.SetString(FieldServicePolicyScheduleTimezone, entity.Schedule.Timezone, nil)
+	scheduleBucket.SetStringList(FieldServicePolicyScheduleWindows, entity.Schedule.Windows, nil)
+	scheduleBucket.SetTimeP(FieldServicePolicyScheduleNotBefore, entity.Schedule.NotBefore, nil)
+	scheduleBucket.SetTimeP(FieldServicePolicyScheduleNotAfter, entity.Schedule.NotAfter, nil)
+	ctx.Bucket.SetError(scheduleBucket.Err)
 }
 
 func (store *servicePolicyStoreImpl) PersistEntity(entity *ServicePolicy, ctx *boltz.PersistContext) {
@@ -195,6 +245,7 @@ func (store *servicePolicyStoreImpl) PersistEntity(entity *ServicePolicy, ctx *b
 	ctx.SetInt32(FieldServicePolicyType, entity.PolicyType.Id())
 	ctx.SetRequiredString(FieldSemantic, entity.Semantic)
 	servicePolicyStore := ctx.Store.(*servicePolicyStoreImpl)
+	servicePolicyStore.persistSchedule(entity, ctx)
 
 	sort.Strings(entity.ServiceRoles)
 	sort.Strings(entity.IdentityRoles)
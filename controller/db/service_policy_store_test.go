@@ -21,6 +21,7 @@ func Test_ServicePolicyStore(t *testing.T) {
 	t.Run("test create service policies", ctx.testCreateServicePolicy)
 	t.Run("test create/update service policies with invalid entity refs", ctx.testServicePolicyInvalidValues)
 	t.Run("test service policy evaluation", ctx.testServicePolicyRoleEvaluation)
+	t.Run("test service policy role expression evaluation", ctx.testServicePolicyRoleExpressionEvaluation)
 	t.Run("test update/delete referenced entities", ctx.testServicePolicyUpdateDeleteRefs)
 }
 
@@ -117,6 +118,64 @@ func (ctx *TestContext) testServicePolicyInvalidValues(_ *testing.T) {
 	boltztest.RequireDelete(ctx, policy)
 }
 
+func (ctx *TestContext) testServicePolicyRoleExpressionEvaluation(_ *testing.T) {
+	ctx.CleanupAll()
+
+	identityTypeId := ctx.getIdentityTypeId()
+
+	finance := newIdentity(eid.New(), identityTypeId, "finance", "laptop")
+	boltztest.RequireCreate(ctx, finance)
+
+	accounting := newIdentity(eid.New(), identityTypeId, "accounting")
+	boltztest.RequireCreate(ctx, accounting)
+
+	contractor := newIdentity(eid.New(), identityTypeId, "finance", "contractor")
+	boltztest.RequireCreate(ctx, contractor)
+
+	other := newIdentity(eid.New(), identityTypeId)
+	boltztest.RequireCreate(ctx, other)
+
+	service := newEdgeService(eid.New())
+	boltztest.RequireCreate(ctx, service)
+
+	policy := newServicePolicy(eid.New())
+	policy.IdentityRoles = []string{"(#finance or #accounting) and not #contractor"}
+	policy.ServiceRoles = []string{roleRef("all")}
+	boltztest.RequireCreate(ctx, policy)
+
+	expected := []string{finance.Id, accounting.Id}
+	sort.Strings(expected)
+	ctx.Equal(expected, ctx.getRelatedIds(policy, EntityTypeIdentities))
+	ctx.Equal([]string{service.Id}, ctx.getRelatedIds(policy, EntityTypeServices))
+
+	// attribute changes on identities are re-evaluated against the expression
+	contractor.RoleAttributes = []string{"finance"}
+	boltztest.RequireUpdate(ctx, contractor)
+
+	accounting.RoleAttributes = []string{"accounting", "contractor"}
+	boltztest.RequireUpdate(ctx, accounting)
+
+	expected = []string{finance.Id, contractor.Id}
+	sort.Strings(expected)
+	ctx.Equal(expected, ctx.getRelatedIds(policy, EntityTypeIdentities))
+
+	// expressions are combined with plain attributes using the policy semantic
+	policy.IdentityRoles = []string{roleRef("laptop"), "#finance and not #contractor"}
+	boltztest.RequireUpdate(ctx, policy)
+	ctx.Equal([]string{finance.Id}, ctx.getRelatedIds(policy, EntityTypeIdentities))
+
+	policy.Semantic = SemanticAnyOf
+	policy.IdentityRoles = []string{roleRef("contractor"), "not #finance and not #accounting"}
+	boltztest.RequireUpdate(ctx, policy)
+	expected = []string{accounting.Id, other.Id}
+	sort.Strings(expected)
+	ctx.Equal(expected, ctx.getRelatedIds(policy, EntityTypeIdentities))
+
+	policy.IdentityRoles = []string{"#finance and (#laptop"}
+	err := boltztest.Update(ctx, policy)
+	ctx.EqualError(err, fmt.Sprintf("the value '%s' for 'identityRoles' is invalid: invalid role expression: missing ')' to close '(' at position 3 of role expression", policy.IdentityRoles[0]))
+}
+
 func (ctx *TestContext) testServicePolicyUpdateDeleteRefs(_ *testing.T) {
 	ctx.CleanupAll()
 
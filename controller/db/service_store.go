@@ -29,12 +29,14 @@ const (
 	EntityTypeServices             = "services"
 	FieldServiceTerminatorStrategy = "terminatorStrategy"
 	FieldServiceMaxIdleTime        = "maxIdleTime"
+	FieldServiceMaxCircuitLifetime = "maxCircuitLifetime"
 )
 
 type Service struct {
 	boltz.BaseExtEntity
 	Name               string        `json:"name"`
 	MaxIdleTime        time.Duration `json:"maxIdleTime"`
+	MaxCircuitLifetime time.Duration `json:"maxCircuitLifetime"`
 	TerminatorStrategy string        `json:"terminatorStrategy"`
 }
 
@@ -95,12 +97,14 @@ func (store *serviceStoreImpl) FillEntity(entity *Service, bucket *boltz.TypedBu
 	entity.Name = bucket.GetStringOrError(FieldName)
 	entity.TerminatorStrategy = bucket.GetStringWithDefault(FieldServiceTerminatorStrategy, "")
 	entity.MaxIdleTime = time.Duration(bucket.GetInt64WithDefault(FieldServiceMaxIdleTime, 0))
+	entity.MaxCircuitLifetime = time.Duration(bucket.GetInt64WithDefault(FieldServiceMaxCircuitLifetime, 0))
 }
 
 func (store *serviceStoreImpl) PersistEntity(entity *Service, ctx *boltz.PersistContext) {
 	entity.SetBaseValues(ctx)
 	ctx.SetString(FieldName, entity.Name)
 	ctx.SetInt64(FieldServiceMaxIdleTime, int64(entity.MaxIdleTime))
+	ctx.SetInt64(FieldServiceMaxCircuitLifetime, int64(entity.MaxCircuitLifetime))
 
 	if entity.TerminatorStrategy == "" {
 		entity.TerminatorStrategy = xt_smartrouting.Name
@@ -29,6 +29,7 @@ import (
 	"go.etcd.io/bbolt"
 	"go4.org/sort"
 	"reflect"
+	"slices"
 	"sync"
 	"time"
 )
@@ -91,6 +92,32 @@ func (stores *Stores) CheckIntegrityInTx(db boltz.Db, ctx boltz.MutateContext, f
 	return nil
 }
 
+// QuickCheckIntegrity checks the integrity of the stores holding configuration, such as services, policies,
+// routers and terminators. It skips the stores holding high volume, short-lived data, such as sessions and api
+// sessions, so that it's fast enough to run at startup. Problems are reported, but not fixed.
+func (stores *Stores) QuickCheckIntegrity(db boltz.Db, ctx context.Context, errorHandler func(error, bool)) error {
+	skipped := []boltz.Checkable{
+		stores.ApiSession,
+		stores.ApiSessionCertificate,
+		stores.EventualEvent,
+		stores.Revocation,
+		stores.Session,
+	}
+
+	return db.View(func(tx *bbolt.Tx) error {
+		changeCtx := boltz.NewTxMutateContext(ctx, tx)
+		for _, checkable := range stores.checkables {
+			if slices.Contains(skipped, checkable) {
+				continue
+			}
+			if err := checkable.CheckIntegrity(changeCtx, false, errorHandler); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 type Stores struct {
 	EventualEventer EventualEventer
 	internal        *stores
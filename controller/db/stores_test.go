@@ -0,0 +1,97 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/storage/boltztest"
+	"github.com/openziti/ziti/controller/xt"
+)
+
+func Test_QuickCheckIntegrity(t *testing.T) {
+	ctx := NewTestContext(t)
+	defer ctx.Cleanup()
+
+	xt.GlobalRegistry().RegisterFactory(&testStrategyFactory{})
+
+	identity := ctx.RequireNewIdentity(uuid.NewString(), false)
+	apiSession := NewApiSession(identity.Id)
+	boltztest.RequireCreate(ctx, apiSession)
+
+	service := ctx.RequireNewService(uuid.NewString())
+	session := NewSession(apiSession.Id, service.Id)
+	boltztest.RequireCreate(ctx, session)
+
+	terminator := &Terminator{
+		Binding: uuid.NewString(),
+		Address: uuid.NewString(),
+		Service: service.Id,
+		Router:  ctx.requireNewRouter().Id,
+	}
+	boltztest.RequireCreate(ctx, terminator)
+
+	check := func(quick bool) []string {
+		var problems []string
+		errorHandler := func(err error, fixed bool) {
+			ctx.False(fixed)
+			problems = append(problems, err.Error())
+		}
+
+		if quick {
+			ctx.NoError(ctx.stores.QuickCheckIntegrity(ctx.GetDb(), context.Background(), errorHandler))
+		} else {
+			ctx.NoError(ctx.stores.CheckIntegrity(ctx.GetDb(), context.Background(), false, errorHandler))
+		}
+		return problems
+	}
+
+	containsProblem := func(problems []string, prefix string) bool {
+		for _, problem := range problems {
+			if strings.HasPrefix(problem, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ctx.Empty(check(true))
+	ctx.Empty(check(false))
+
+	// point the terminator and session at a service which doesn't exist, bypassing the store constraints
+	ctx.NoError(ctx.GetDb().Update(nil, func(changeCtx boltz.MutateContext) error {
+		tx := changeCtx.Tx()
+		ctx.stores.Terminator.GetEntityBucket(tx, []byte(terminator.Id)).SetString(FieldTerminatorService, "missing", nil)
+		ctx.stores.Session.GetEntityBucket(tx, []byte(session.Id)).SetString(FieldSessionService, "missing", nil)
+		return nil
+	}))
+
+	quickProblems := check(true)
+	ctx.True(containsProblem(quickProblems, "terminators.service has invalid value"), "problems: %v", quickProblems)
+	ctx.False(containsProblem(quickProblems, "sessions.service has invalid value"), "problems: %v", quickProblems)
+
+	fullProblems := check(false)
+	ctx.True(containsProblem(fullProblems, "terminators.service has invalid value"), "problems: %v", fullProblems)
+	ctx.True(containsProblem(fullProblems, "sessions.service has invalid value"), "problems: %v", fullProblems)
+
+	// quick checks never fix problems, so they are still reported
+	ctx.Equal(quickProblems, check(true))
+}
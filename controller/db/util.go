@@ -38,12 +38,16 @@ func validateRolesAndIds(field string, values []string) error {
 
 	var invalidKeys []string
 	for _, entry := range values {
-		if !strings.HasPrefix(entry, RolePrefix) && !strings.HasPrefix(entry, EntityPrefix) {
+		if IsRoleExpression(entry) {
+			if _, err := ParseRoleExpression(entry); err != nil {
+				return errorz.NewFieldError(fmt.Sprintf("invalid role expression: %v", err), field, entry)
+			}
+		} else if !strings.HasPrefix(entry, RolePrefix) && !strings.HasPrefix(entry, EntityPrefix) {
 			invalidKeys = append(invalidKeys, entry)
 		}
 	}
 	if len(invalidKeys) > 0 {
-		return errorz.NewFieldError("role entries must prefixed with # (to indicate role attributes) or @ (to indicate a name or id)", field, invalidKeys)
+		return errorz.NewFieldError("role entries must prefixed with # (to indicate role attributes) or @ (to indicate a name or id), or be role expressions", field, invalidKeys)
 	}
 	return nil
 }
@@ -66,6 +70,68 @@ func splitRolesAndIds(values []string) ([]string, []string, error) {
 	return roles, ids, nil
 }
 
+// splitRoleEntries works like splitRolesAndIds, but also parses role expressions, which are returned separately
+func splitRoleEntries(values []string) ([]string, []string, []*RoleExpression, error) {
+	var expressionEntries []string
+	var plainEntries []string
+	for _, entry := range values {
+		if IsRoleExpression(entry) {
+			expressionEntries = append(expressionEntries, entry)
+		} else {
+			plainEntries = append(plainEntries, entry)
+		}
+	}
+
+	roles, ids, err := splitRolesAndIds(plainEntries)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var expressions []*RoleExpression
+	for _, entry := range expressionEntries {
+		expression, err := ParseRoleExpression(entry)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "invalid role expression '%v'", entry)
+		}
+		expressions = append(expressions, expression)
+	}
+
+	return roles, ids, expressions, nil
+}
+
+// rolesMatch returns true if the given role attributes are matched by the roles and role expressions, using the
+// given semantic. It doesn't handle ids or the all role, which policies check separately.
+func rolesMatch(semantic string, roles []string, expressions []*RoleExpression, roleAttributes []string) bool {
+	if len(roles) == 0 && len(expressions) == 0 {
+		return false
+	}
+
+	if strings.EqualFold(semantic, SemanticAllOf) {
+		if !stringz.ContainsAll(roleAttributes, roles...) {
+			return false
+		}
+		for _, expression := range expressions {
+			if !expression.Matches(roleAttributes) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if strings.EqualFold(semantic, SemanticAnyOf) {
+		if stringz.ContainsAny(roleAttributes, roles...) {
+			return true
+		}
+		for _, expression := range expressions {
+			if expression.Matches(roleAttributes) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func FieldValuesToIds(new []boltz.FieldTypeAndValue) []string {
 	var entityRoles []string
 	for _, fv := range new {
@@ -0,0 +1,192 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package env
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openziti/metrics"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/config"
+	"github.com/openziti/ziti/controller/response"
+	"golang.org/x/time/rate"
+)
+
+const (
+	metricApiRateLimiterRejectedPrefix = "api.rate_limiter."
+	RetryAfterHeader                   = "Retry-After"
+)
+
+// ApiRateLimiter limits the request rate of the edge client and management APIs, per identity and per source IP.
+// Buckets which haven't been used within the idle timeout are discarded. The limits can be changed at runtime with
+// Reload.
+type ApiRateLimiter struct {
+	metricsRegistry metrics.Registry
+	state           atomic.Pointer[apiRateLimiterState]
+}
+
+type apiRateLimiterState struct {
+	config     config.ApiRateLimiterConfig
+	identities *rateLimiterBuckets
+	ips        *rateLimiterBuckets
+}
+
+func newApiRateLimiterState(config config.ApiRateLimiterConfig) *apiRateLimiterState {
+	return &apiRateLimiterState{
+		config:     config,
+		identities: newRateLimiterBuckets(config.PerIdentity, config.IdleTimeout),
+		ips:        newRateLimiterBuckets(config.PerIp, config.IdleTimeout),
+	}
+}
+
+func NewApiRateLimiter(config config.ApiRateLimiterConfig, registry metrics.Registry) *ApiRateLimiter {
+	result := &ApiRateLimiter{
+		metricsRegistry: registry,
+	}
+	result.state.Store(newApiRateLimiterState(config))
+	return result
+}
+
+// GetConfig returns the limits currently in effect
+func (self *ApiRateLimiter) GetConfig() config.ApiRateLimiterConfig {
+	return self.state.Load().config
+}
+
+// Reload applies new limits. Existing buckets are discarded, so every identity and source IP starts over with a full
+// burst under the new limits. Returns false if the limits are unchanged, in which case the buckets are kept.
+func (self *ApiRateLimiter) Reload(config config.ApiRateLimiterConfig) bool {
+	if self.state.Load().config == config {
+		return false
+	}
+	self.state.Store(newApiRateLimiterState(config))
+	return true
+}
+
+// CheckIp returns false and responds with a 429 if the source IP of the request is over its limit
+func (self *ApiRateLimiter) CheckIp(apiName string, rc *response.RequestContext) bool {
+	if self == nil {
+		return true
+	}
+	state := self.state.Load()
+	if !state.config.Enabled {
+		return true
+	}
+	return self.check(apiName, "ip", state.ips, requestSourceIp(rc.Request), rc)
+}
+
+// CheckIdentity returns false and responds with a 429 if the identity making the request is over its limit.
+// Unauthenticated requests are only limited by source IP.
+func (self *ApiRateLimiter) CheckIdentity(apiName string, rc *response.RequestContext) bool {
+	if self == nil || rc.Identity == nil {
+		return true
+	}
+	state := self.state.Load()
+	if !state.config.Enabled {
+		return true
+	}
+	return self.check(apiName, "identity", state.identities, rc.Identity.Id, rc)
+}
+
+func (self *ApiRateLimiter) check(apiName, limitType string, buckets *rateLimiterBuckets, key string, rc *response.RequestContext) bool {
+	allowed, retryAfter := buckets.allow(key, time.Now())
+	if allowed {
+		return true
+	}
+
+	self.metricsRegistry.Meter(metricApiRateLimiterRejectedPrefix + apiName + "." + limitType + ".rejected").Mark(1)
+
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	rc.ResponseWriter.Header().Set(RetryAfterHeader, strconv.FormatInt(max(seconds, 1), 10))
+	rc.RespondWithApiError(apierror.NewRateLimited())
+	return false
+}
+
+func requestSourceIp(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+type rateLimiterBucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+type rateLimiterBuckets struct {
+	limit       config.RateLimit
+	idleTimeout time.Duration
+	lock        sync.Mutex
+	buckets     map[string]*rateLimiterBucket
+	lastSweep   time.Time
+}
+
+func newRateLimiterBuckets(limit config.RateLimit, idleTimeout time.Duration) *rateLimiterBuckets {
+	return &rateLimiterBuckets{
+		limit:       limit,
+		idleTimeout: idleTimeout,
+		buckets:     map[string]*rateLimiterBucket{},
+		lastSweep:   time.Now(),
+	}
+}
+
+// allow takes a token from the bucket for the given key. If none is available, it returns false along with how long
+// until one will be
+func (self *rateLimiterBuckets) allow(key string, now time.Time) (bool, time.Duration) {
+	if self.limit.Rate <= 0 {
+		return true, 0
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if now.Sub(self.lastSweep) > self.idleTimeout {
+		for k, bucket := range self.buckets {
+			if now.Sub(bucket.lastUsed) > self.idleTimeout {
+				delete(self.buckets, k)
+			}
+		}
+		self.lastSweep = now
+	}
+
+	bucket, found := self.buckets[key]
+	if !found {
+		bucket = &rateLimiterBucket{
+			limiter: rate.NewLimiter(rate.Limit(self.limit.Rate), self.limit.Burst),
+		}
+		self.buckets[key] = bucket
+	}
+	bucket.lastUsed = now
+
+	reservation := bucket.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
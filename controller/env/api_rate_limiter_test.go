@@ -0,0 +1,121 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package env
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/controller/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterBuckets(t *testing.T) {
+	t.Run("requests within the burst are allowed", func(t *testing.T) {
+		req := require.New(t)
+		buckets := newRateLimiterBuckets(config.RateLimit{Rate: 1, Burst: 3}, time.Minute)
+		now := time.Now()
+
+		for i := 0; i < 3; i++ {
+			allowed, _ := buckets.allow("a", now)
+			req.True(allowed)
+		}
+
+		allowed, retryAfter := buckets.allow("a", now)
+		req.False(allowed)
+		req.Equal(time.Second, retryAfter)
+
+		allowed, _ = buckets.allow("b", now)
+		req.True(allowed, "keys should have separate buckets")
+
+		allowed, _ = buckets.allow("a", now.Add(time.Second))
+		req.True(allowed)
+	})
+
+	t.Run("rejected requests don't use tokens", func(t *testing.T) {
+		req := require.New(t)
+		buckets := newRateLimiterBuckets(config.RateLimit{Rate: 2, Burst: 1}, time.Minute)
+		now := time.Now()
+
+		allowed, _ := buckets.allow("a", now)
+		req.True(allowed)
+
+		for i := 0; i < 10; i++ {
+			allowed, _ = buckets.allow("a", now)
+			req.False(allowed)
+		}
+
+		allowed, _ = buckets.allow("a", now.Add(500*time.Millisecond))
+		req.True(allowed)
+	})
+
+	t.Run("a rate of zero disables the limit", func(t *testing.T) {
+		req := require.New(t)
+		buckets := newRateLimiterBuckets(config.RateLimit{}, time.Minute)
+		now := time.Now()
+
+		for i := 0; i < 100; i++ {
+			allowed, _ := buckets.allow("a", now)
+			req.True(allowed)
+		}
+		req.Empty(buckets.buckets)
+	})
+
+	t.Run("idle buckets are discarded", func(t *testing.T) {
+		req := require.New(t)
+		buckets := newRateLimiterBuckets(config.RateLimit{Rate: 1, Burst: 1}, time.Minute)
+		now := time.Now()
+
+		buckets.allow("a", now)
+		buckets.allow("b", now.Add(50*time.Second))
+		req.Len(buckets.buckets, 2)
+
+		buckets.allow("b", now.Add(90*time.Second))
+		req.Len(buckets.buckets, 1)
+		req.NotNil(buckets.buckets["b"])
+	})
+}
+
+func TestApiRateLimiterReload(t *testing.T) {
+	req := require.New(t)
+
+	cfg := config.ApiRateLimiterConfig{
+		Enabled:     true,
+		PerIp:       config.RateLimit{Rate: 1, Burst: 1},
+		IdleTimeout: time.Minute,
+	}
+	limiter := NewApiRateLimiter(cfg, nil)
+
+	ips := limiter.state.Load().ips
+	allowed, _ := ips.allow("a", time.Now())
+	req.True(allowed)
+
+	req.False(limiter.Reload(cfg), "unchanged limits shouldn't be reloaded")
+	req.Equal(ips, limiter.state.Load().ips)
+
+	cfg.PerIp.Burst = 5
+	req.True(limiter.Reload(cfg))
+	req.Equal(cfg, limiter.GetConfig())
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		allowed, _ = limiter.state.Load().ips.allow("a", now)
+		req.True(allowed, "reloaded limits should start with a full burst")
+	}
+	allowed, _ = limiter.state.Load().ips.allow("a", now)
+	req.False(allowed)
+}
@@ -110,11 +110,15 @@ type AppEnv struct {
 	StartupTime          time.Time
 	InstanceId           string
 	AuthRateLimiter      rate.AdaptiveRateLimiter
+	ApiRateLimiter       *ApiRateLimiter
 
 	clientApiDefaultSigner *jwtsigner.TlsJwtSigner
 
 	TraceManager *TraceManager
 	timelineId   string
+
+	managementApiHandlers map[string]http.Handler
+	clientApiHandlers     map[string]http.Handler
 }
 
 func (ae *AppEnv) CreateTotpTokenFromAccessClaims(issuer string, claims *common.AccessClaims) (string, *common.TotpClaims, error) {
@@ -684,9 +688,7 @@ func (ae *AppEnv) ProcessZtSession(rc *response.RequestContext, ztSession string
 			rc.ActivePermissions = append(rc.ActivePermissions, permissions.AuthenticatedPermission)
 		}
 
-		if rc.Identity.IsAdmin || rc.Identity.IsDefaultAdmin {
-			rc.ActivePermissions = append(rc.ActivePermissions, permissions.AdminPermission)
-		}
+		addAdminPermissions(rc)
 	}
 
 	return nil
@@ -765,11 +767,22 @@ func (ae *AppEnv) ProcessJwt(rc *response.RequestContext, token *jwt.Token) erro
 
 	rc.ActivePermissions = append(rc.ActivePermissions, permissions.AuthenticatedPermission)
 
+	addAdminPermissions(rc)
+
+	return nil
+}
+
+// addAdminPermissions grants the admin permissions the request's identity is entitled to. Scoped admins also have
+// their scope recorded on the request context, so it can be enforced by the management API.
+func addAdminPermissions(rc *response.RequestContext) {
 	if rc.Identity.IsAdmin || rc.Identity.IsDefaultAdmin {
 		rc.ActivePermissions = append(rc.ActivePermissions, permissions.AdminPermission)
+	} else if stringz.Contains(rc.Identity.RoleAttributes, permissions.ReadOnlyAdminRoleAttribute) {
+		rc.ActivePermissions = append(rc.ActivePermissions, permissions.ReadOnlyAdminPermission)
+	} else if scope := permissions.GetAdminScope(rc.Identity.RoleAttributes, rc.Identity.Tags); scope != "" {
+		rc.ActivePermissions = append(rc.ActivePermissions, permissions.ScopedAdminPermission)
+		rc.AdminScope = scope
 	}
-
-	return nil
 }
 
 // FillRequestContext extracts authentication information from the HTTP request
@@ -933,8 +946,9 @@ func NewAppEnv(host HostController) (*AppEnv, error) {
 			QueueSizeMetric:  metricAuthLimiterCurrentQueuedCount,
 			WindowSizeMetric: metricAuthLimiterCurrentWindowSize,
 		}, host.GetMetricsRegistry(), host.GetCloseNotifyChannel()),
-		TraceManager: NewTraceManager(host.GetCloseNotifyChannel()),
-		timelineId:   timelineId,
+		ApiRateLimiter: NewApiRateLimiter(c.ApiRateLimiter, host.GetMetricsRegistry()),
+		TraceManager:   NewTraceManager(host.GetCloseNotifyChannel()),
+		timelineId:     timelineId,
 	}
 
 	ae.identityRefreshMeter = host.GetMetricsRegistry().Meter("identity.refresh")
@@ -987,9 +1001,19 @@ func NewAppEnv(host HostController) (*AppEnv, error) {
 
 	if host.GetConfig().Edge.Enabled {
 		enrollmentCert := host.GetConfig().Edge.Enrollment.SigningCert.Cert()
-		ae.ApiClientCsrSigner = cert.NewClientSigner(enrollmentCert.Leaf, enrollmentCert.PrivateKey)
-		ae.ApiServerCsrSigner = cert.NewServerSigner(enrollmentCert.Leaf, enrollmentCert.PrivateKey)
-		ae.ControlClientCsrSigner = cert.NewClientSigner(enrollmentCert.Leaf, enrollmentCert.PrivateKey)
+		apiClientSigner := cert.NewClientSigner(enrollmentCert.Leaf, enrollmentCert.PrivateKey)
+		apiServerSigner := cert.NewServerSigner(enrollmentCert.Leaf, enrollmentCert.PrivateKey)
+		controlClientSigner := cert.NewClientSigner(enrollmentCert.Leaf, enrollmentCert.PrivateKey)
+
+		if ocspUrl := host.GetConfig().Edge.Enrollment.OcspUrl; ocspUrl != "" {
+			apiClientSigner.OcspServers = []string{ocspUrl}
+			apiServerSigner.OcspServers = []string{ocspUrl}
+			controlClientSigner.OcspServers = []string{ocspUrl}
+		}
+
+		ae.ApiClientCsrSigner = apiClientSigner
+		ae.ApiServerCsrSigner = apiServerSigner
+		ae.ControlClientCsrSigner = controlClientSigner
 	}
 
 	ae.FingerprintGenerator = cert.NewFingerprintGenerator()
@@ -1158,7 +1182,7 @@ func getMetricTimerName(r *http.Request) string {
 }
 
 // IsAllowed creates a middleware responder that checks permissions before executing the handler.
-func (ae *AppEnv) IsAllowed(responderFunc func(ae *AppEnv, rc *response.RequestContext), request *http.Request, entityId string, entitySubId string, permissions ...permissions.Resolver) openApiMiddleware.Responder {
+func (ae *AppEnv) IsAllowed(responderFunc func(ae *AppEnv, rc *response.RequestContext), request *http.Request, entityId string, entitySubId string, resolvers ...permissions.Resolver) openApiMiddleware.Responder {
 	return openApiMiddleware.ResponderFunc(func(writer http.ResponseWriter, producer runtime.Producer) {
 
 		rc, err := GetRequestContextFromHttpContext(request)
@@ -1177,8 +1201,9 @@ func (ae *AppEnv) IsAllowed(responderFunc func(ae *AppEnv, rc *response.RequestC
 			return
 		}
 
-		for _, permission := range permissions {
-			if !permission.IsAllowed(rc.ActivePermissions...) {
+		requestPermissions := permissions.ForRequest(request.Method, rc.ActivePermissions)
+		for _, permission := range resolvers {
+			if !permission.IsAllowed(requestPermissions...) {
 				rc.RespondWithApiError(errorz.NewUnauthorized())
 				return
 			}
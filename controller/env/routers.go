@@ -16,6 +16,11 @@
 
 package env
 
+import (
+	"net/http"
+	"strings"
+)
+
 var routers []ApiRouter
 
 type AddRouterFunc func(ae *AppEnv)
@@ -35,3 +40,47 @@ func AddRouter(rf ApiRouter) {
 func GetRouters() []ApiRouter {
 	return routers
 }
+
+// AddManagementApiHandler registers a handler for a management API path which isn't part of the generated
+// OpenAPI server. The path is relative to the management API base path. A path ending in a slash also handles
+// every path below it. Handlers must be added during ApiRouter.Register.
+func (ae *AppEnv) AddManagementApiHandler(path string, handler http.Handler) {
+	if ae.managementApiHandlers == nil {
+		ae.managementApiHandlers = map[string]http.Handler{}
+	}
+	ae.managementApiHandlers[path] = handler
+}
+
+// GetManagementApiHandler returns the handler registered for the given management API path, or nil if none was
+// registered
+func (ae *AppEnv) GetManagementApiHandler(path string) http.Handler {
+	return getApiHandler(ae.managementApiHandlers, path)
+}
+
+// AddClientApiHandler registers a handler for a client API path which isn't part of the generated OpenAPI server.
+// Paths are matched the same way as for AddManagementApiHandler. Handlers must be added during ApiRouter.Register.
+func (ae *AppEnv) AddClientApiHandler(path string, handler http.Handler) {
+	if ae.clientApiHandlers == nil {
+		ae.clientApiHandlers = map[string]http.Handler{}
+	}
+	ae.clientApiHandlers[path] = handler
+}
+
+// GetClientApiHandler returns the handler registered for the given client API path, or nil if none was registered
+func (ae *AppEnv) GetClientApiHandler(path string) http.Handler {
+	return getApiHandler(ae.clientApiHandlers, path)
+}
+
+func getApiHandler(handlers map[string]http.Handler, path string) http.Handler {
+	if handler, ok := handlers[path]; ok {
+		return handler
+	}
+
+	for handlerPath, handler := range handlers {
+		if strings.HasSuffix(handlerPath, "/") && strings.HasPrefix(path, handlerPath) {
+			return handler
+		}
+	}
+
+	return nil
+}
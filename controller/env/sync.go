@@ -51,6 +51,8 @@ const (
 	SyncStrategyTypeHeader  = 1013
 	SyncStrategyStateHeader = 1014
 	SyncStrategyLastIndex   = 1015
+	ApiSessionLogIdHeader   = 1016
+	ApiSessionLogSeqHeader  = 1017
 )
 
 // RouterSyncStrategy handles the life cycle of an Edge Router connecting to the controller, synchronizing
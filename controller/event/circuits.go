@@ -31,9 +31,10 @@ const (
 	CircuitUpdated       CircuitEventType = "pathUpdated"
 	CircuitDeleted       CircuitEventType = "deleted"
 	CircuitFailed        CircuitEventType = "failed"
+	CircuitClassified    CircuitEventType = "classified"
 )
 
-var CircuitEventTypes = []CircuitEventType{CircuitCreated, CircuitUpdated, CircuitDeleted, CircuitFailed}
+var CircuitEventTypes = []CircuitEventType{CircuitCreated, CircuitUpdated, CircuitDeleted, CircuitFailed, CircuitClassified}
 
 // A CircuitPath encapsulates information about the circuit's path.
 type CircuitPath struct {
@@ -63,6 +64,22 @@ type CircuitPath struct {
 	TerminatorRemoteAddr string `json:"terminator_remote_addr,omitempty"`
 }
 
+// A CircuitClassification describes what the ingress router recognized in the first bytes the client sent on the
+// circuit. Circuits are only classified when payload classification is enabled on the ingress router.
+type CircuitClassification struct {
+	// The application protocol. One of tls, http, http2 or grpc.
+	Protocol string `json:"protocol"`
+
+	// The TLS server name indication. Only populated for tls circuits.
+	ServerName string `json:"server_name,omitempty"`
+
+	// The requested host. Only populated for http, http2 and grpc circuits.
+	Host string `json:"host,omitempty"`
+
+	// The full gRPC method name, for example /helloworld.Greeter/SayHello. Only populated for grpc circuits.
+	GrpcMethod string `json:"grpc_method,omitempty"`
+}
+
 func (self *CircuitPath) String() string {
 	if len(self.Nodes) < 1 {
 		return "{}"
@@ -87,6 +104,7 @@ func (self *CircuitPath) String() string {
 //   - pathUpdated
 //   - deleted
 //   - failed
+//   - classified
 //
 // Example: Circuit Created Event
 //
@@ -149,6 +167,37 @@ func (self *CircuitPath) String() string {
 //	 }
 //	}
 //
+// Example: Circuit Deleted Event, for a circuit which exceeded its service's max circuit lifetime
+//
+//	{
+//	 "namespace": "circuit",
+//	 "event_src_id": "ctrl_client",
+//	 "timestamp": "2025-01-17T15:09:13.612209442-05:00",
+//	 "version": 2,
+//	 "event_type": "deleted",
+//	 "circuit_id": "rqrucElFe",
+//	 "client_id": "cm614ve9h00fb1xj9dfww20le",
+//	 "service_id": "3pjMOKY2icS8fkQ1lfHmrP",
+//	 "terminator_id": "7JgrjMgEAis7V5q1wjvoB4",
+//	 "instance_id": "",
+//	 "path": {
+//	   "nodes": [
+//	     "5g2QrZxFcw"
+//	   ],
+//	   "links": null,
+//	   "ingress_id": "8dN7",
+//	   "egress_id": "ZnXG"
+//	 },
+//	 "link_count": 0,
+//	 "duration": 3600009203,
+//	 "termination_reason": "MAX_LIFETIME_EXCEEDED",
+//	 "tags": {
+//	   "clientId": "haxn9lB0uc",
+//	   "hostId": "IahyE.5Scw",
+//	   "serviceId": "3pjMOKY2icS8fkQ1lfHmrP"
+//	 }
+//	}
+//
 // Example: Circuit Failed Event
 //
 //	{
@@ -176,6 +225,41 @@ func (self *CircuitPath) String() string {
 //	   "serviceId": "3pjMOKY2icS8fkQ1lfHmrP"
 //	 }
 //	}
+//
+// Example: Circuit Classified Event
+//
+//	{
+//	 "namespace": "circuit",
+//	 "event_src_id": "ctrl_client",
+//	 "timestamp": "2025-01-17T14:09:13.711604233-05:00",
+//	 "version": 2,
+//	 "event_type": "classified",
+//	 "circuit_id": "rqrucElFe",
+//	 "client_id": "cm614ve9h00fb1xj9dfww20le",
+//	 "service_id": "3pjMOKY2icS8fkQ1lfHmrP",
+//	 "terminator_id": "7JgrjMgEAis7V5q1wjvoB4",
+//	 "instance_id": "",
+//	 "path": {
+//	   "nodes": [
+//	     "5g2QrZxFcw"
+//	   ],
+//	   "links": null,
+//	   "ingress_id": "8dN7",
+//	   "egress_id": "ZnXG"
+//	 },
+//	 "link_count": 0,
+//	 "duration": 108595245,
+//	 "classification": {
+//	   "protocol": "grpc",
+//	   "host": "greeter.ziti",
+//	   "grpc_method": "/helloworld.Greeter/SayHello"
+//	 },
+//	 "tags": {
+//	   "clientId": "haxn9lB0uc",
+//	   "hostId": "IahyE.5Scw",
+//	   "serviceId": "3pjMOKY2icS8fkQ1lfHmrP"
+//	 }
+//	}
 type CircuitEvent struct {
 	Namespace  string    `json:"namespace"`
 	EventSrcId string    `json:"event_src_id"`
@@ -217,9 +301,17 @@ type CircuitEvent struct {
 	// The reason the circuit failed. Only populated for circuit failures.
 	FailureCause *string `json:"failure_cause,omitempty"`
 
+	// The limit which caused the circuit to be ended, either MAX_LIFETIME_EXCEEDED or MAX_IDLE_TIME_EXCEEDED. Only
+	// populated for deleted events, when the circuit exceeded the max lifetime or max idle time of its service.
+	TerminationReason *string `json:"termination_reason,omitempty"`
+
 	// How long the circuit has been up. Not populated for circuit creates.
 	Duration *time.Duration `json:"duration,omitempty"`
 
+	// What the ingress router recognized in the circuit's traffic. Populated for classified events, and for
+	// later events once the circuit has been classified.
+	Classification *CircuitClassification `json:"classification,omitempty"`
+
 	// Contains circuit enrichment data. May contain information like the client and/or host
 	// identity ids.
 	Tags map[string]string `json:"tags"`
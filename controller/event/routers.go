@@ -26,17 +26,24 @@ type RouterEventType string
 const (
 	RouterEventNS = "router"
 
-	RouterOnline  RouterEventType = "router-online"
-	RouterOffline RouterEventType = "router-offline"
+	RouterOnline     RouterEventType = "router-online"
+	RouterOffline    RouterEventType = "router-offline"
+	RouterSaturation RouterEventType = "router-saturation"
+
+	RouterSaturationHigh   = "high"
+	RouterSaturationNormal = "normal"
+	RouterSaturationLow    = "low"
 )
 
-// A RouterEvent is generated when a router comes online or goes offline.
+// A RouterEvent is generated when a router comes online or goes offline, or when the saturation of an edge router
+// crosses one of the configured scaling thresholds.
 //
 // Note: In version prior to 1.4.0, the namespace was `fabric.routers`
 //
 // Valid values for router event type are:
 //   - router-online
 //   - router-offline
+//   - router-saturation
 //
 // Example: Router online event
 //
@@ -59,6 +66,28 @@ const (
 //	 "router_id": "JAoyjafljO",
 //	 "router_online": false
 //	}
+//
+// Example: Router saturation event
+//
+//	{
+//	 "namespace": "router",
+//	 "event_src_id": "ctrl1",
+//	 "timestamp": "2025-03-12T09:14:02.117365412-04:00",
+//	 "event_type": "router-saturation",
+//	 "router_id": "JAoyjafljO",
+//	 "router_online": true,
+//	 "saturation": {
+//	   "state": "high",
+//	   "previous_state": "normal",
+//	   "saturation": 0.86,
+//	   "signals": {
+//	     "circuits": 0.41,
+//	     "connections": 0.37,
+//	     "cpu": 0.86,
+//	     "xgress_buffers": 0.02
+//	   }
+//	 }
+//	}
 type RouterEvent struct {
 	Namespace  string    `json:"namespace"`
 	Timestamp  time.Time `json:"timestamp"`
@@ -73,9 +102,31 @@ type RouterEvent struct {
 	// Indicates whether the router is online or not. Redundant given
 	// the router event type. Should likely be removed.
 	RouterOnline bool `json:"router_online"`
+
+	// Set for router-saturation events
+	Saturation *RouterSaturationDetail `json:"saturation,omitempty"`
+}
+
+// RouterSaturationDetail describes the change in saturation which triggered a router-saturation event
+type RouterSaturationDetail struct {
+	// The saturation state the router moved into. One of high, normal or low
+	State string `json:"state"`
+
+	// The saturation state the router was in before
+	PreviousState string `json:"previous_state"`
+
+	// The highest of the normalized signals, from 0 to 1
+	Saturation float64 `json:"saturation"`
+
+	// The normalized saturation signals, keyed by signal name
+	Signals map[string]float64 `json:"signals"`
 }
 
 func (event *RouterEvent) String() string {
+	if event.Saturation != nil {
+		return fmt.Sprintf("%v.%v time=%v routerId=%v state=%v saturation=%.2f",
+			event.Namespace, event.EventType, event.Timestamp, event.RouterId, event.Saturation.State, event.Saturation.Saturation)
+	}
 	return fmt.Sprintf("%v.%v time=%v routerId=%v routerOnline=%v",
 		event.Namespace, event.EventType, event.Timestamp, event.RouterId, event.RouterOnline)
 }
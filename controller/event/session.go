@@ -25,17 +25,20 @@ const SessionEventNS = "session"
 
 const SessionEventTypeCreated = "created"
 const SessionEventTypeDeleted = "deleted"
+const SessionEventTypeDenied = "denied"
 
 const SessionProviderLegacy = "legacy"
 const SessionProviderJwt = "jwt"
 
-// A SessionEvent is emitted when a session is created or deleted.
+// A SessionEvent is emitted when a session is created or deleted, or when a session request is denied because
+// none of the granting service policies are inside their schedule.
 //
 // Note: In version prior to 1.4.0, the namespace was `edge.sessions`
 //
 // Valid values for session type are:
 //   - created
 //   - deleted
+//   - denied
 //
 // Valid session provider are:
 //   - jwt
@@ -72,6 +75,22 @@ const SessionProviderJwt = "jwt"
 //	 "identity_id": "IahyE.5Scw",
 //	 "service_id": "3pjMOKY2icS8fkQ1lfHmrP"
 //	}
+//
+// Example: Dial session denied event
+//
+//	{
+//	 "namespace": "session",
+//	 "event_src_id": "ctrl_client",
+//	 "timestamp": "2025-01-17T18:02:11.104650693-05:00",
+//	 "event_type": "denied",
+//	 "provider" : "legacy",
+//	 "session_type": "Dial",
+//	 "id": "",
+//	 "api_session_id": "cm611bgwn0006dhj9m4cv1obf",
+//	 "identity_id": "IahyE.5Scw",
+//	 "service_id": "3pjMOKY2icS8fkQ1lfHmrP",
+//	 "reason": "outside schedule of service policies: [contractor-access]"
+//	}
 type SessionEvent struct {
 	Namespace  string    `json:"namespace"`
 	EventSrcId string    `json:"event_src_id"`
@@ -100,6 +119,9 @@ type SessionEvent struct {
 
 	// The id of the service that this session is claiming access to.
 	ServiceId string `json:"service_id"`
+
+	// Why the session was denied. Only set for denied events.
+	Reason string `json:"reason,omitempty"`
 }
 
 func (event *SessionEvent) String() string {
@@ -21,6 +21,15 @@ const (
 //   - usage.egress.tx  - A write to an external connection from an egress router
 //   - usage.fabric.rx  - A read from a fabric link to a router
 //   - usage.fabric.tx  - A write to a fabric link from a router
+//   - usage.xgress.retransmits              - Payloads retransmitted by an xgress endpoint
+//   - usage.xgress.retransmitted_bytes      - Bytes retransmitted by an xgress endpoint
+//   - usage.xgress.ack_duplicates           - Duplicate acks received by an xgress endpoint
+//   - usage.xgress.payload_duplicates       - Duplicate payloads received by an xgress endpoint
+//   - usage.xgress.blocked_by_local_window  - Times an xgress endpoint stalled on its own send window
+//   - usage.xgress.blocked_by_remote_window - Times an xgress endpoint stalled on the remote receive window
+//
+// For the xgress types, the usage is a count of events rather than a number of bytes, except for
+// usage.xgress.retransmitted_bytes.
 //
 // Example: Ingress Data Received Usage Event
 //
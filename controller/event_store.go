@@ -0,0 +1,47 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/eventstore"
+)
+
+// initEventStore opens the event store and registers it for event delivery, if an event store is configured
+func (c *Controller) initEventStore() error {
+	cfg := c.config.EventStore
+	if cfg == nil {
+		return nil
+	}
+
+	store, err := eventstore.Open(cfg, c.metricsRegistry, c.shutdownC)
+	if err != nil {
+		return err
+	}
+
+	c.eventStore = store
+	c.eventDispatcher.AddCircuitEventHandler(store)
+	c.eventDispatcher.AddLinkEventHandler(store)
+	c.eventDispatcher.AddSessionEventHandler(store)
+
+	pfxlog.Logger().WithField("path", cfg.Path).
+		WithField("maxSizeMb", cfg.MaxSizeMb).
+		WithField("maxAge", cfg.MaxAge).
+		Info("event store enabled")
+
+	return nil
+}
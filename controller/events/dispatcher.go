@@ -17,9 +17,11 @@
 package events
 
 import (
+	stderr "errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/openziti/storage/boltz"
 	"github.com/openziti/ziti/controller/db"
@@ -91,6 +93,8 @@ func NewDispatcher(closeNotify <-chan struct{}) *Dispatcher {
 	result.RegisterEventHandlerFactory("stdout", StdOutLoggerFactory{})
 	result.RegisterEventHandlerFactory("amqp", AMQPEventLoggerFactory{})
 	result.RegisterEventHandlerFactory("servicebus", ServiceBusEventLoggerFactory{})
+	result.RegisterEventHandlerFactory("kafka", KafkaEventLoggerFactory{})
+	result.RegisterEventHandlerFactory("syslog", SyslogEventLoggerFactory{})
 
 	return result
 }
@@ -128,6 +132,9 @@ type Dispatcher struct {
 	network *network.Network
 	stores  *db.Stores
 
+	configHandlersLock sync.Mutex
+	configHandlers     []interface{}
+
 	entityChangeEventsDispatcher entityChangeEventDispatcher
 	entityTypes                  []string
 	closeNotify                  <-chan struct{}
@@ -144,6 +151,7 @@ func (self *Dispatcher) InitializeNetworkEvents(n *network.Network) {
 	self.initEntityChangeEvents(n)
 
 	self.AddMetricsMapper(ctrlChannelMetricsMapper{}.mapMetrics)
+	self.AddMetricsMapper(apiMetricsMapper{}.mapMetrics)
 	self.AddMetricsMapper((&linkMetricsMapper{network: n}).mapMetrics)
 }
 
@@ -217,6 +225,9 @@ events:
 
 */
 func (self *Dispatcher) WireEventHandlers(eventHandlerConfigs []*EventHandlerConfig) error {
+	self.configHandlersLock.Lock()
+	defer self.configHandlersLock.Unlock()
+
 	logger := pfxlog.Logger()
 	for _, eventHandlerConfig := range eventHandlerConfigs {
 		handler, err := self.createHandler(eventHandlerConfig.Id, eventHandlerConfig.Config)
@@ -224,6 +235,7 @@ func (self *Dispatcher) WireEventHandlers(eventHandlerConfigs []*EventHandlerCon
 			logger.Errorf("Unable to create event handler: %v", err)
 			return err
 		}
+		self.configHandlers = append(self.configHandlers, handler)
 		if err = self.processSubscriptions(handler, eventHandlerConfig); err != nil {
 			logger.Errorf("Unable to process subscription for event handler: %v", err)
 			return err
@@ -233,6 +245,61 @@ func (self *Dispatcher) WireEventHandlers(eventHandlerConfigs []*EventHandlerCon
 	return nil
 }
 
+// ReloadEventHandlers replaces the event handlers created from configuration with new handlers built from the
+// given configs. All new handlers are created and their subscriptions parsed before any existing handler is
+// removed, so an invalid configuration leaves the current handlers in place. Events emitted while the handlers
+// are being swapped may not be delivered.
+func (self *Dispatcher) ReloadEventHandlers(eventHandlerConfigs []*EventHandlerConfig) error {
+	self.configHandlersLock.Lock()
+	defer self.configHandlersLock.Unlock()
+
+	var handlers []interface{}
+	var subscriptions [][]*event.Subscription
+
+	for _, eventHandlerConfig := range eventHandlerConfigs {
+		handler, err := self.createHandler(eventHandlerConfig.Id, eventHandlerConfig.Config)
+		if err == nil {
+			handlers = append(handlers, handler)
+			var subs []*event.Subscription
+			if subs, err = parseSubscriptions(eventHandlerConfig); err == nil {
+				subscriptions = append(subscriptions, subs)
+			}
+		}
+
+		if err != nil {
+			for _, h := range handlers {
+				closeHandler(h)
+			}
+			return err
+		}
+	}
+
+	for _, handler := range self.configHandlers {
+		self.RemoveAllSubscriptions(handler)
+		closeHandler(handler)
+	}
+	self.configHandlers = handlers
+
+	var errList []error
+	for idx, handler := range handlers {
+		if err := self.ProcessSubscriptions(handler, subscriptions[idx]); err != nil {
+			errList = append(errList, errors.Wrapf(err, "unable to process subscriptions for event handler %v", eventHandlerConfigs[idx].Id))
+		}
+	}
+
+	pfxlog.Logger().WithField("handlers", len(handlers)).Info("event handlers reloaded")
+
+	return stderr.Join(errList...)
+}
+
+func closeHandler(handler interface{}) {
+	if closer, ok := handler.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			pfxlog.Logger().WithError(err).Error("error closing event handler")
+		}
+	}
+}
+
 func (self *Dispatcher) createHandler(id interface{}, config map[interface{}]interface{}) (interface{}, error) {
 	handlerVal, ok := config["handler"]
 	if !ok {
@@ -261,15 +328,23 @@ func (self *Dispatcher) createHandler(id interface{}, config map[interface{}]int
 }
 
 func (self *Dispatcher) processSubscriptions(handler interface{}, eventHandlerConfig *EventHandlerConfig) error {
+	subscriptions, err := parseSubscriptions(eventHandlerConfig)
+	if err != nil {
+		return err
+	}
+	return self.ProcessSubscriptions(handler, subscriptions)
+}
+
+func parseSubscriptions(eventHandlerConfig *EventHandlerConfig) ([]*event.Subscription, error) {
 	subs, ok := eventHandlerConfig.Config["subscriptions"]
 
 	if !ok {
-		return errors.Errorf("event handler %v doesn't define any subscriptions", eventHandlerConfig.Id)
+		return nil, errors.Errorf("event handler %v doesn't define any subscriptions", eventHandlerConfig.Id)
 	}
 
 	subscriptionList, ok := subs.([]interface{})
 	if !ok {
-		return errors.Errorf("event handler %v subscriptions is not a list", eventHandlerConfig.Id)
+		return nil, errors.Errorf("event handler %v subscriptions is not a list", eventHandlerConfig.Id)
 	}
 
 	var subscriptions []*event.Subscription
@@ -277,7 +352,7 @@ func (self *Dispatcher) processSubscriptions(handler interface{}, eventHandlerCo
 	for idx, sub := range subscriptionList {
 		subMap, ok := sub.(map[interface{}]interface{})
 		if !ok {
-			return errors.Errorf("The subscription at index %v for event handler %v is not a map", idx, eventHandlerConfig.Id)
+			return nil, errors.Errorf("The subscription at index %v for event handler %v is not a map", idx, eventHandlerConfig.Id)
 		}
 
 		var eventType string
@@ -295,7 +370,7 @@ func (self *Dispatcher) processSubscriptions(handler interface{}, eventHandlerCo
 		}
 
 		if eventType == "" {
-			return errors.Errorf("The subscription at index %v for event handler %v has no type", idx, eventHandlerConfig.Id)
+			return nil, errors.Errorf("The subscription at index %v for event handler %v has no type", idx, eventHandlerConfig.Id)
 		}
 
 		subscriptions = append(subscriptions, &event.Subscription{
@@ -303,7 +378,7 @@ func (self *Dispatcher) processSubscriptions(handler interface{}, eventHandlerCo
 			Options: options,
 		})
 	}
-	return self.ProcessSubscriptions(handler, subscriptions)
+	return subscriptions, nil
 }
 
 func (self *Dispatcher) ProcessSubscriptions(handler interface{}, subscriptions []*event.Subscription) error {
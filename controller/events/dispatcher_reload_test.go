@@ -0,0 +1,79 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package events
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newFileHandlerConfig(id string, path string, eventType string) *EventHandlerConfig {
+	return &EventHandlerConfig{
+		Id: id,
+		Config: map[interface{}]interface{}{
+			"subscriptions": []interface{}{
+				map[interface{}]interface{}{"type": eventType},
+			},
+			"handler": map[interface{}]interface{}{
+				"type":   "file",
+				"format": "json",
+				"path":   path,
+			},
+		},
+	}
+}
+
+func TestReloadEventHandlers(t *testing.T) {
+	req := require.New(t)
+
+	closeNotify := make(chan struct{})
+	defer close(closeNotify)
+
+	dispatcher := NewDispatcher(closeNotify)
+	dir := t.TempDir()
+
+	err := dispatcher.ReloadEventHandlers([]*EventHandlerConfig{
+		newFileHandlerConfig("first", filepath.Join(dir, "first.log"), "circuit"),
+	})
+	req.NoError(err)
+	req.Len(dispatcher.circuitEventHandlers.Value(), 1)
+	first := dispatcher.circuitEventHandlers.Value()[0]
+
+	err = dispatcher.ReloadEventHandlers([]*EventHandlerConfig{
+		newFileHandlerConfig("second", filepath.Join(dir, "second.log"), "circuit"),
+		newFileHandlerConfig("third", filepath.Join(dir, "third.log"), "link"),
+	})
+	req.NoError(err)
+	req.Len(dispatcher.circuitEventHandlers.Value(), 1)
+	req.Len(dispatcher.linkEventHandlers.Value(), 1)
+	req.NotEqual(first, dispatcher.circuitEventHandlers.Value()[0])
+
+	// an invalid config should leave the current handlers in place
+	invalid := newFileHandlerConfig("invalid", filepath.Join(dir, "invalid.log"), "circuit")
+	delete(invalid.Config, "subscriptions")
+	err = dispatcher.ReloadEventHandlers([]*EventHandlerConfig{invalid})
+	req.Error(err)
+	req.Len(dispatcher.circuitEventHandlers.Value(), 1)
+	req.Len(dispatcher.linkEventHandlers.Value(), 1)
+
+	err = dispatcher.ReloadEventHandlers(nil)
+	req.NoError(err)
+	req.Len(dispatcher.circuitEventHandlers.Value(), 0)
+	req.Len(dispatcher.linkEventHandlers.Value(), 0)
+}
@@ -122,12 +122,12 @@ func (self *Dispatcher) registerSessionEventHandler(eventType string, val interf
 		}
 	}
 
-	if len(includeList) == 0 || (len(includeList) == 2 && stringz.ContainsAll(includeList, event.SessionEventTypeCreated, event.SessionEventTypeDeleted)) {
+	if len(includeList) == 0 || (len(includeList) == 3 && stringz.ContainsAll(includeList, event.SessionEventTypeCreated, event.SessionEventTypeDeleted, event.SessionEventTypeDenied)) {
 		self.AddSessionEventHandler(handler)
 	} else {
 		for _, include := range includeList {
-			if include != event.SessionEventTypeCreated && include != event.SessionEventTypeDeleted {
-				return errors.Errorf("invalid include %v for %v. valid values are ['created', 'deleted']", include, event.SessionEventNS)
+			if include != event.SessionEventTypeCreated && include != event.SessionEventTypeDeleted && include != event.SessionEventTypeDenied {
+				return errors.Errorf("invalid include %v for %v. valid values are ['created', 'deleted', 'denied']", include, event.SessionEventNS)
 			}
 		}
 
@@ -29,7 +29,10 @@ type fabricFormatterFactory struct{}
 
 func (f fabricFormatterFactory) NewLoggingHandler(format string, buffer int, out io.WriteCloser) (interface{}, error) {
 	if strings.EqualFold(format, "json") {
-		return NewJsonFormatter(buffer, NewWriterEventSink(out)), nil
+		return &writerEventLogger{
+			JsonFormatter: NewJsonFormatter(buffer, NewWriterEventSink(out)),
+			out:           out,
+		}, nil
 	}
 
 	return nil, errors.Errorf("invalid 'format' for event log output file: %v", format)
@@ -56,7 +59,7 @@ func NewFileEventLogger(formatterFactory LoggingHandlerFactory, stdout bool, con
 		}
 	}
 
-	var output = &newlineWriter{out: os.Stdout}
+	var output = &newlineWriter{out: stdoutWriter{}}
 
 	if !stdout {
 		// allow config to override the max file size
@@ -112,6 +115,31 @@ func NewFileEventLogger(formatterFactory LoggingHandlerFactory, stdout bool, con
 	return nil, errors.New("'format' must be specified for event handler")
 }
 
+// writerEventLogger closes the underlying output when the handler is closed, so that files and connections
+// aren't leaked when event handlers are reloaded
+type writerEventLogger struct {
+	*JsonFormatter
+	out io.Closer
+}
+
+func (self *writerEventLogger) Close() error {
+	if err := self.JsonFormatter.Close(); err != nil {
+		return err
+	}
+	return self.out.Close()
+}
+
+// stdoutWriter writes to stdout, but doesn't close it
+type stdoutWriter struct{}
+
+func (stdoutWriter) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+func (stdoutWriter) Close() error {
+	return nil
+}
+
 type newlineWriter struct {
 	out io.WriteCloser
 }
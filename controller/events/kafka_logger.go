@@ -0,0 +1,363 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	KafkaSaslMechanismPlain       = "plain"
+	KafkaSaslMechanismScramSha256 = "scram-sha-256"
+	KafkaSaslMechanismScramSha512 = "scram-sha-512"
+)
+
+type KafkaEventLoggerFactory struct{}
+
+func (KafkaEventLoggerFactory) NewEventHandler(config map[interface{}]interface{}) (interface{}, error) {
+	return NewKafkaEventLogger(config)
+}
+
+/*
+NewKafkaEventLogger creates an event handler which publishes events to Kafka.
+
+Example configuration:
+
+	events:
+	  kafkaLogger:
+	    subscriptions:
+	      - type: circuit
+	      - type: link
+	    handler:
+	      type: kafka
+	      format: json
+	      brokers:
+	        - kafka1.example.com:9093
+	      # default topic, used for any event types which don't have a topic mapping
+	      topic: ziti-events
+	      # optional per event type topics
+	      topics:
+	        circuit: ziti-circuits
+	      # optional batching settings
+	      batchSize: 100
+	      batchTimeout: 1s
+	      # one of none, one or all. Defaults to one
+	      requiredAcks: one
+	      # one of none, gzip, snappy, lz4 or zstd. Defaults to none
+	      compression: snappy
+	      sasl:
+	        # one of plain, scram-sha-256 or scram-sha-512
+	        mechanism: scram-sha-512
+	        username: ziti
+	        password: secret
+	      tls:
+	        enabled: true
+	        caFile: /etc/kafka/ca.pem
+	        certFile: /etc/kafka/client.pem
+	        keyFile: /etc/kafka/client.key
+*/
+func NewKafkaEventLogger(config map[interface{}]interface{}) (interface{}, error) {
+	bufferSize := 10
+	if value, found := config["bufferSize"]; found {
+		if size, ok := value.(int); ok {
+			bufferSize = size
+		}
+	}
+
+	if value, found := config["format"]; !found {
+		return nil, errors.New("'format' must be specified for event handler")
+	} else if format, ok := value.(string); !ok || !strings.EqualFold(format, "json") {
+		return nil, errors.Errorf("invalid 'format' for kafka event handler: %v. only json is supported", value)
+	}
+
+	conf, err := parseKafkaConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse kafka config")
+	}
+
+	sink := newKafkaEventSink(conf)
+	return &kafkaEventLogger{
+		JsonFormatter: NewJsonFormatter(bufferSize, sink),
+		sink:          sink,
+	}, nil
+}
+
+type kafkaEventLogger struct {
+	*JsonFormatter
+	sink *kafkaEventSink
+}
+
+func (self *kafkaEventLogger) Close() error {
+	if err := self.JsonFormatter.Close(); err != nil {
+		return err
+	}
+	return self.sink.Close()
+}
+
+type kafkaConfig struct {
+	brokers      []string
+	topic        string
+	topics       map[string]string
+	batchSize    int
+	batchTimeout time.Duration
+	requiredAcks kafka.RequiredAcks
+	compression  kafka.Compression
+	sasl         sasl.Mechanism
+	tls          *tls.Config
+}
+
+// getTopic returns the topic for the given event type, falling back to the default topic if the event type has
+// no specific mapping. An empty result means the event should be dropped.
+func (self *kafkaConfig) getTopic(eventType string) string {
+	if topic, found := self.topics[eventType]; found {
+		return topic
+	}
+	return self.topic
+}
+
+func parseKafkaConfig(config map[interface{}]interface{}) (*kafkaConfig, error) {
+	ret := &kafkaConfig{
+		topics:       map[string]string{},
+		batchSize:    100,
+		batchTimeout: time.Second,
+		requiredAcks: kafka.RequireOne,
+	}
+
+	value, found := config["brokers"]
+	if !found {
+		return nil, errors.New("missing kafka brokers")
+	}
+	brokers, ok := value.([]interface{})
+	if !ok || len(brokers) == 0 {
+		return nil, errors.New("kafka brokers must be a non-empty list")
+	}
+	for _, broker := range brokers {
+		ret.brokers = append(ret.brokers, fmt.Sprintf("%v", broker))
+	}
+
+	if value, found = config["topic"]; found {
+		if ret.topic, ok = value.(string); !ok {
+			return nil, errors.New("invalid kafka topic, must be a string")
+		}
+	}
+
+	if value, found = config["topics"]; found {
+		topics, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("invalid kafka topics, must be a map of event type to topic")
+		}
+		for eventType, topic := range topics {
+			ret.topics[fmt.Sprintf("%v", eventType)] = fmt.Sprintf("%v", topic)
+		}
+	}
+
+	if ret.topic == "" && len(ret.topics) == 0 {
+		return nil, errors.New("either topic or topics must be specified for kafka")
+	}
+
+	if value, found = config["batchSize"]; found {
+		if ret.batchSize, ok = value.(int); !ok || ret.batchSize < 1 {
+			return nil, errors.Errorf("invalid kafka batchSize: %v, must be a positive integer", value)
+		}
+	}
+
+	if value, found = config["batchTimeout"]; found {
+		var err error
+		if ret.batchTimeout, err = time.ParseDuration(fmt.Sprintf("%v", value)); err != nil {
+			return nil, errors.Wrapf(err, "invalid kafka batchTimeout: %v", value)
+		}
+	}
+
+	if value, found = config["requiredAcks"]; found {
+		if err := ret.requiredAcks.UnmarshalText([]byte(fmt.Sprintf("%v", value))); err != nil {
+			return nil, errors.Wrap(err, "invalid kafka requiredAcks")
+		}
+	}
+
+	if value, found = config["compression"]; found {
+		if err := ret.compression.UnmarshalText([]byte(fmt.Sprintf("%v", value))); err != nil {
+			return nil, errors.Wrap(err, "invalid kafka compression")
+		}
+	}
+
+	if value, found = config["sasl"]; found {
+		saslConfig, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("invalid kafka sasl config, must be a map")
+		}
+		mechanism, err := parseKafkaSaslConfig(saslConfig)
+		if err != nil {
+			return nil, err
+		}
+		ret.sasl = mechanism
+	}
+
+	if value, found = config["tls"]; found {
+		tlsConfig, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("invalid kafka tls config, must be a map")
+		}
+		tlsCfg, err := parseKafkaTlsConfig(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		ret.tls = tlsCfg
+	}
+
+	return ret, nil
+}
+
+func parseKafkaSaslConfig(config map[interface{}]interface{}) (sasl.Mechanism, error) {
+	mechanism := fmt.Sprintf("%v", config["mechanism"])
+	username, _ := config["username"].(string)
+	password, _ := config["password"].(string)
+
+	if username == "" {
+		return nil, errors.New("kafka sasl username must be specified")
+	}
+
+	switch strings.ToLower(mechanism) {
+	case KafkaSaslMechanismPlain:
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case KafkaSaslMechanismScramSha256:
+		return scram.Mechanism(scram.SHA256, username, password)
+	case KafkaSaslMechanismScramSha512:
+		return scram.Mechanism(scram.SHA512, username, password)
+	}
+
+	return nil, errors.Errorf("invalid kafka sasl mechanism '%s', valid values are %s, %s and %s",
+		mechanism, KafkaSaslMechanismPlain, KafkaSaslMechanismScramSha256, KafkaSaslMechanismScramSha512)
+}
+
+func parseKafkaTlsConfig(config map[interface{}]interface{}) (*tls.Config, error) {
+	if enabled, ok := config["enabled"].(bool); ok && !enabled {
+		return nil, nil
+	}
+
+	ret := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if insecure, ok := config["insecureSkipVerify"].(bool); ok {
+		ret.InsecureSkipVerify = insecure
+	}
+
+	if caFile, ok := config["caFile"].(string); ok && caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read kafka ca file %s", caFile)
+		}
+		ret.RootCAs = x509.NewCertPool()
+		if !ret.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in kafka ca file %s", caFile)
+		}
+	}
+
+	certFile, _ := config["certFile"].(string)
+	keyFile, _ := config["keyFile"].(string)
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load kafka client certificate")
+		}
+		ret.Certificates = []tls.Certificate{cert}
+	}
+
+	return ret, nil
+}
+
+func newKafkaEventSink(config *kafkaConfig) *kafkaEventSink {
+	log := pfxlog.Logger().WithField("brokers", config.brokers)
+
+	return &kafkaEventSink{
+		config: config,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(config.brokers...),
+			Balancer:               &kafka.LeastBytes{},
+			BatchSize:              config.batchSize,
+			BatchTimeout:           config.batchTimeout,
+			RequiredAcks:           config.requiredAcks,
+			Compression:            config.compression,
+			AllowAutoTopicCreation: true,
+			Async:                  true,
+			Transport: &kafka.Transport{
+				TLS:  config.tls,
+				SASL: config.sasl,
+			},
+			Completion: func(messages []kafka.Message, err error) {
+				if err != nil {
+					log.WithError(err).WithField("count", len(messages)).Error("error sending events to kafka")
+				}
+			},
+			ErrorLogger: kafka.LoggerFunc(log.Errorf),
+		},
+	}
+}
+
+var _ event.FormattedEventSink = (*kafkaEventSink)(nil)
+
+// kafkaEventSink publishes formatted events to kafka. Writes are asynchronous, with messages being batched by
+// the kafka writer and sent once either the batch size or batch timeout is reached.
+type kafkaEventSink struct {
+	config *kafkaConfig
+	writer *kafka.Writer
+	closed atomic.Bool
+}
+
+func (self *kafkaEventSink) AcceptFormattedEvent(eventType string, formattedEvent []byte) {
+	if self.closed.Load() {
+		return
+	}
+
+	topic := self.config.getTopic(eventType)
+	if topic == "" {
+		return
+	}
+
+	msg := kafka.Message{
+		Topic: topic,
+		Value: formattedEvent,
+	}
+
+	// with Async set, this only returns an error if the writer is closed or the message is invalid
+	if err := self.writer.WriteMessages(context.Background(), msg); err != nil {
+		logrus.WithError(err).WithField("topic", topic).Error("error queuing event for kafka")
+	}
+}
+
+func (self *kafkaEventSink) Close() error {
+	if self.closed.CompareAndSwap(false, true) {
+		return self.writer.Close()
+	}
+	return nil
+}
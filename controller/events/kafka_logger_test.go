@@ -0,0 +1,87 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKafkaConfig(t *testing.T) {
+	req := require.New(t)
+
+	config, err := parseKafkaConfig(map[interface{}]interface{}{
+		"brokers": []interface{}{"kafka1:9092", "kafka2:9092"},
+		"topic":   "ziti-events",
+		"topics": map[interface{}]interface{}{
+			"circuit": "ziti-circuits",
+		},
+		"batchSize":    50,
+		"batchTimeout": "250ms",
+		"requiredAcks": "all",
+		"compression":  "snappy",
+		"sasl": map[interface{}]interface{}{
+			"mechanism": "scram-sha-512",
+			"username":  "ziti",
+			"password":  "secret",
+		},
+	})
+	req.NoError(err)
+	req.Equal([]string{"kafka1:9092", "kafka2:9092"}, config.brokers)
+	req.Equal("ziti-circuits", config.getTopic("circuit"))
+	req.Equal("ziti-events", config.getTopic("link"))
+	req.Equal(50, config.batchSize)
+	req.Equal(250*time.Millisecond, config.batchTimeout)
+	req.Equal(kafka.RequireAll, config.requiredAcks)
+	req.Equal(kafka.Snappy, config.compression)
+	req.NotNil(config.sasl)
+	req.Equal("SCRAM-SHA-512", config.sasl.Name())
+	req.Nil(config.tls)
+
+	config, err = parseKafkaConfig(map[interface{}]interface{}{
+		"brokers": []interface{}{"kafka1:9092"},
+		"topics": map[interface{}]interface{}{
+			"circuit": "ziti-circuits",
+		},
+	})
+	req.NoError(err)
+	req.Equal("", config.getTopic("link"))
+	req.Equal(kafka.RequireOne, config.requiredAcks)
+
+	_, err = parseKafkaConfig(map[interface{}]interface{}{
+		"topic": "ziti-events",
+	})
+	req.Error(err)
+
+	_, err = parseKafkaConfig(map[interface{}]interface{}{
+		"brokers": []interface{}{"kafka1:9092"},
+	})
+	req.Error(err)
+
+	_, err = parseKafkaConfig(map[interface{}]interface{}{
+		"brokers": []interface{}{"kafka1:9092"},
+		"topic":   "ziti-events",
+		"sasl": map[interface{}]interface{}{
+			"mechanism": "kerberos",
+			"username":  "ziti",
+		},
+	})
+	req.Error(err)
+}
@@ -18,6 +18,7 @@ package events
 
 import (
 	"github.com/openziti/metrics/metrics_pb"
+	"github.com/openziti/ziti/controller/api"
 	"github.com/openziti/ziti/controller/event"
 	"github.com/openziti/ziti/controller/network"
 	"strings"
@@ -34,6 +35,23 @@ func (ctrlChannelMetricsMapper) mapMetrics(_ *metrics_pb.MetricsMessage, event *
 	}
 }
 
+// apiMetricsMapper turns the api, method and route encoded in REST API request metric names into tags
+type apiMetricsMapper struct{}
+
+func (apiMetricsMapper) mapMetrics(_ *metrics_pb.MetricsMessage, event *event.MetricsEvent) {
+	if metric, apiName, method, route, ok := api.ParseApiMetricName(event.Metric); ok {
+		event.Metric = metric
+		sourceTags := event.Tags
+		event.Tags = map[string]string{}
+		for k, v := range sourceTags {
+			event.Tags[k] = v
+		}
+		event.Tags["api"] = apiName
+		event.Tags["method"] = method
+		event.Tags["route"] = route
+	}
+}
+
 type linkMetricsMapper struct {
 	network *network.Network
 }
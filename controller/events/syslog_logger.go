@@ -0,0 +1,534 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package events
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/pkg/errors"
+)
+
+const (
+	SyslogNetworkUdp = "udp"
+	SyslogNetworkTcp = "tcp"
+	SyslogNetworkTls = "tls"
+
+	// SyslogDefaultEnterpriseId is the IANA private enterprise number reserved for documentation, used for the
+	// structured data id if no enterprise id is configured
+	SyslogDefaultEnterpriseId = 32473
+
+	syslogTimestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+	syslogNilValue        = "-"
+)
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+var syslogSeverities = map[string]int{
+	"emergency": 0,
+	"alert":     1,
+	"critical":  2,
+	"error":     3,
+	"warning":   4,
+	"notice":    5,
+	"info":      6,
+	"debug":     7,
+}
+
+type SyslogEventLoggerFactory struct{}
+
+func (SyslogEventLoggerFactory) NewEventHandler(config map[interface{}]interface{}) (interface{}, error) {
+	return NewSyslogEventLogger(config)
+}
+
+/*
+NewSyslogEventLogger creates an event handler which sends events to a syslog server using the RFC 5424 message
+format. The JSON formatted event is used as the message, and the top level event attributes are also included
+as structured data, so they can be indexed without parsing the message.
+
+Example configuration:
+
+	events:
+	  syslogLogger:
+	    subscriptions:
+	      - type: circuit
+	      - type: alert
+	    handler:
+	      type: syslog
+	      format: json
+	      # one of udp, tcp or tls. Defaults to udp
+	      network: tls
+	      address: syslog.example.com:6514
+	      # defaults to local0
+	      facility: local0
+	      # default severity, defaults to info
+	      severity: info
+	      # optional per event type severities
+	      severities:
+	        alert: warning
+	      # defaults to ziti-controller
+	      appName: ziti-controller
+	      # defaults to the host name of the controller
+	      hostname: ctrl1.example.com
+	      # used in the structured data id, ziti@<enterpriseId>. Defaults to 32473
+	      enterpriseId: 32473
+	      tls:
+	        caFile: /etc/syslog/ca.pem
+	        certFile: /etc/syslog/client.pem
+	        keyFile: /etc/syslog/client.key
+*/
+func NewSyslogEventLogger(config map[interface{}]interface{}) (interface{}, error) {
+	bufferSize := 10
+	if value, found := config["bufferSize"]; found {
+		if size, ok := value.(int); ok {
+			bufferSize = size
+		}
+	}
+
+	if value, found := config["format"]; !found {
+		return nil, errors.New("'format' must be specified for event handler")
+	} else if format, ok := value.(string); !ok || !strings.EqualFold(format, "json") {
+		return nil, errors.Errorf("invalid 'format' for syslog event handler: %v. only json is supported", value)
+	}
+
+	conf, err := parseSyslogConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse syslog config")
+	}
+
+	sink := newSyslogEventSink(conf)
+	return &syslogEventLogger{
+		JsonFormatter: NewJsonFormatter(bufferSize, sink),
+		sink:          sink,
+	}, nil
+}
+
+type syslogEventLogger struct {
+	*JsonFormatter
+	sink *syslogEventSink
+}
+
+func (self *syslogEventLogger) Close() error {
+	if err := self.JsonFormatter.Close(); err != nil {
+		return err
+	}
+	return self.sink.Close()
+}
+
+type syslogConfig struct {
+	network      string
+	address      string
+	facility     int
+	severity     int
+	severities   map[string]int
+	appName      string
+	hostname     string
+	enterpriseId int
+	dialTimeout  time.Duration
+	tls          *tls.Config
+}
+
+func (self *syslogConfig) getSeverity(eventType string) int {
+	if severity, found := self.severities[eventType]; found {
+		return severity
+	}
+	return self.severity
+}
+
+func parseSyslogConfig(config map[interface{}]interface{}) (*syslogConfig, error) {
+	ret := &syslogConfig{
+		network:      SyslogNetworkUdp,
+		facility:     syslogFacilities["local0"],
+		severity:     syslogSeverities["info"],
+		severities:   map[string]int{},
+		appName:      "ziti-controller",
+		enterpriseId: SyslogDefaultEnterpriseId,
+		dialTimeout:  5 * time.Second,
+	}
+
+	if value, found := config["network"]; found {
+		ret.network = strings.ToLower(fmt.Sprintf("%v", value))
+		if ret.network != SyslogNetworkUdp && ret.network != SyslogNetworkTcp && ret.network != SyslogNetworkTls {
+			return nil, errors.Errorf("invalid syslog network '%v', valid values are %s, %s and %s",
+				value, SyslogNetworkUdp, SyslogNetworkTcp, SyslogNetworkTls)
+		}
+	}
+
+	var ok bool
+	if ret.address, ok = config["address"].(string); !ok || ret.address == "" {
+		return nil, errors.New("missing syslog address")
+	}
+
+	if _, _, err := net.SplitHostPort(ret.address); err != nil {
+		return nil, errors.Wrapf(err, "invalid syslog address '%s', must be of the form <host>:<port>", ret.address)
+	}
+
+	if value, found := config["facility"]; found {
+		facility, err := parseSyslogLevel(value, syslogFacilities, 23)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid syslog facility")
+		}
+		ret.facility = facility
+	}
+
+	if value, found := config["severity"]; found {
+		severity, err := parseSyslogLevel(value, syslogSeverities, 7)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid syslog severity")
+		}
+		ret.severity = severity
+	}
+
+	if value, found := config["severities"]; found {
+		severities, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("invalid syslog severities, must be a map of event type to severity")
+		}
+		for eventType, v := range severities {
+			severity, err := parseSyslogLevel(v, syslogSeverities, 7)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid syslog severity for event type %v", eventType)
+			}
+			ret.severities[fmt.Sprintf("%v", eventType)] = severity
+		}
+	}
+
+	if value, found := config["appName"]; found {
+		ret.appName = fmt.Sprintf("%v", value)
+	}
+
+	if value, found := config["hostname"]; found {
+		ret.hostname = fmt.Sprintf("%v", value)
+	} else if hostname, err := os.Hostname(); err == nil {
+		ret.hostname = hostname
+	}
+
+	if value, found := config["enterpriseId"]; found {
+		if ret.enterpriseId, ok = value.(int); !ok || ret.enterpriseId < 1 {
+			return nil, errors.Errorf("invalid syslog enterpriseId: %v, must be a positive integer", value)
+		}
+	}
+
+	if value, found := config["dialTimeout"]; found {
+		var err error
+		if ret.dialTimeout, err = time.ParseDuration(fmt.Sprintf("%v", value)); err != nil {
+			return nil, errors.Wrapf(err, "invalid syslog dialTimeout: %v", value)
+		}
+	}
+
+	if value, found := config["tls"]; found {
+		if ret.network != SyslogNetworkTls {
+			return nil, errors.Errorf("syslog tls config is only valid with network %s", SyslogNetworkTls)
+		}
+		tlsConfig, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil, errors.New("invalid syslog tls config, must be a map")
+		}
+		tlsCfg, err := parseSyslogTlsConfig(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		ret.tls = tlsCfg
+	} else if ret.network == SyslogNetworkTls {
+		ret.tls = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return ret, nil
+}
+
+// parseSyslogLevel accepts either a facility/severity name or its numeric value
+func parseSyslogLevel(value interface{}, names map[string]int, max int) (int, error) {
+	if intVal, ok := value.(int); ok {
+		if intVal < 0 || intVal > max {
+			return 0, errors.Errorf("%d is out of range, must be between 0 and %d", intVal, max)
+		}
+		return intVal, nil
+	}
+
+	name := strings.ToLower(fmt.Sprintf("%v", value))
+	if result, found := names[name]; found {
+		return result, nil
+	}
+
+	var validNames []string
+	for k := range names {
+		validNames = append(validNames, k)
+	}
+	sort.Strings(validNames)
+	return 0, errors.Errorf("unknown value '%v', valid values are %s", value, strings.Join(validNames, ", "))
+}
+
+func parseSyslogTlsConfig(config map[interface{}]interface{}) (*tls.Config, error) {
+	ret := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if insecure, ok := config["insecureSkipVerify"].(bool); ok {
+		ret.InsecureSkipVerify = insecure
+	}
+
+	if serverName, ok := config["serverName"].(string); ok {
+		ret.ServerName = serverName
+	}
+
+	if caFile, ok := config["caFile"].(string); ok && caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read syslog ca file %s", caFile)
+		}
+		ret.RootCAs = x509.NewCertPool()
+		if !ret.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in syslog ca file %s", caFile)
+		}
+	}
+
+	certFile, _ := config["certFile"].(string)
+	keyFile, _ := config["keyFile"].(string)
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load syslog client certificate")
+		}
+		ret.Certificates = []tls.Certificate{cert}
+	}
+
+	return ret, nil
+}
+
+func newSyslogEventSink(config *syslogConfig) *syslogEventSink {
+	return &syslogEventSink{
+		config: config,
+		procId: strconv.Itoa(os.Getpid()),
+	}
+}
+
+var _ event.FormattedEventSink = (*syslogEventSink)(nil)
+
+// syslogEventSink sends formatted events to a syslog server. Connections are established lazily and re-established
+// after write failures. Events which can't be delivered are dropped, so a syslog outage doesn't back up the
+// event dispatcher.
+type syslogEventSink struct {
+	config *syslogConfig
+	procId string
+	lock   sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+func (self *syslogEventSink) AcceptFormattedEvent(eventType string, formattedEvent []byte) {
+	msg := self.formatMessage(time.Now(), eventType, formattedEvent)
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.closed {
+		return
+	}
+
+	// a failed write may be due to a stale connection, so retry once on a new connection
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if err = self.write(msg); err == nil {
+			return
+		}
+		self.closeConn()
+	}
+
+	pfxlog.Logger().WithError(err).
+		WithField("address", self.config.address).
+		WithField("eventType", eventType).
+		Error("error sending event to syslog")
+}
+
+func (self *syslogEventSink) write(msg []byte) error {
+	if self.conn == nil {
+		conn, err := self.dial()
+		if err != nil {
+			return err
+		}
+		self.conn = conn
+	}
+
+	if self.config.network == SyslogNetworkUdp {
+		_, err := self.conn.Write(msg)
+		return err
+	}
+
+	// stream transports use octet counting framing, see RFC 5425 and RFC 6587
+	framed := make([]byte, 0, len(msg)+8)
+	framed = strconv.AppendInt(framed, int64(len(msg)), 10)
+	framed = append(framed, ' ')
+	framed = append(framed, msg...)
+	_, err := self.conn.Write(framed)
+	return err
+}
+
+func (self *syslogEventSink) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: self.config.dialTimeout}
+	if self.config.network == SyslogNetworkTls {
+		return tls.DialWithDialer(dialer, "tcp", self.config.address, self.config.tls)
+	}
+	return dialer.Dial(self.config.network, self.config.address)
+}
+
+func (self *syslogEventSink) closeConn() {
+	if self.conn != nil {
+		if err := self.conn.Close(); err != nil {
+			pfxlog.Logger().WithError(err).WithField("address", self.config.address).Debug("error closing syslog connection")
+		}
+		self.conn = nil
+	}
+}
+
+func (self *syslogEventSink) Close() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.closed = true
+	self.closeConn()
+	return nil
+}
+
+// formatMessage creates an RFC 5424 message of the form
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID SD-PARAM...] MSG
+func (self *syslogEventSink) formatMessage(timestamp time.Time, eventType string, formattedEvent []byte) []byte {
+	priority := self.config.facility*8 + self.config.getSeverity(eventType)
+
+	buf := &bytes.Buffer{}
+	_, _ = fmt.Fprintf(buf, "<%d>1 %s %s %s %s %s ",
+		priority,
+		timestamp.Format(syslogTimestampFormat),
+		syslogHeaderField(self.config.hostname, 255),
+		syslogHeaderField(self.config.appName, 48),
+		syslogHeaderField(self.procId, 128),
+		syslogHeaderField(eventType, 32))
+
+	self.writeStructuredData(buf, eventType, formattedEvent)
+	buf.WriteByte(' ')
+	buf.Write(formattedEvent)
+
+	return buf.Bytes()
+}
+
+// writeStructuredData writes the top level scalar attributes of the event as SD-PARAMs. Nested values are only
+// available in the message itself.
+func (self *syslogEventSink) writeStructuredData(buf *bytes.Buffer, eventType string, formattedEvent []byte) {
+	_, _ = fmt.Fprintf(buf, "[ziti@%d eventType=\"%s\"", self.config.enterpriseId, escapeSyslogParamValue(eventType))
+
+	attributes := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(formattedEvent))
+	decoder.UseNumber()
+	if err := decoder.Decode(&attributes); err != nil {
+		pfxlog.Logger().WithError(err).WithField("eventType", eventType).Debug("unable to extract syslog structured data from event")
+	}
+
+	var names []string
+	for name := range attributes {
+		if name != "event_type" && name != "eventType" && isValidSyslogParamName(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var value string
+		switch v := attributes[name].(type) {
+		case string:
+			value = v
+		case json.Number:
+			value = v.String()
+		case bool:
+			value = strconv.FormatBool(v)
+		default:
+			continue
+		}
+		_, _ = fmt.Fprintf(buf, " %s=\"%s\"", name, escapeSyslogParamValue(value))
+	}
+
+	buf.WriteByte(']')
+}
+
+// syslogHeaderField returns the value as a valid header field, which must be printable US-ASCII without spaces,
+// limited to the given length, or the nil value if empty
+func syslogHeaderField(value string, maxLen int) string {
+	result := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+
+	if len(result) > maxLen {
+		result = result[:maxLen]
+	}
+
+	if result == "" {
+		return syslogNilValue
+	}
+	return result
+}
+
+func isValidSyslogParamName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+var syslogParamValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func escapeSyslogParamValue(value string) string {
+	return syslogParamValueEscaper.Replace(value)
+}
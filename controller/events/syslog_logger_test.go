@@ -0,0 +1,176 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package events
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyslogConfig(t *testing.T) {
+	req := require.New(t)
+
+	config, err := parseSyslogConfig(map[interface{}]interface{}{
+		"network":  "tcp",
+		"address":  "syslog:601",
+		"facility": "local3",
+		"severity": "notice",
+		"severities": map[interface{}]interface{}{
+			"alert": "warning",
+			"link":  7,
+		},
+		"hostname":     "ctrl1",
+		"enterpriseId": 12345,
+	})
+	req.NoError(err)
+	req.Equal(SyslogNetworkTcp, config.network)
+	req.Equal(19, config.facility)
+	req.Equal(5, config.getSeverity("circuit"))
+	req.Equal(4, config.getSeverity("alert"))
+	req.Equal(7, config.getSeverity("link"))
+	req.Equal("ctrl1", config.hostname)
+	req.Equal("ziti-controller", config.appName)
+	req.Equal(12345, config.enterpriseId)
+	req.Nil(config.tls)
+
+	config, err = parseSyslogConfig(map[interface{}]interface{}{
+		"network": "tls",
+		"address": "syslog:6514",
+	})
+	req.NoError(err)
+	req.NotNil(config.tls)
+	req.Equal(16, config.facility)
+	req.Equal(6, config.severity)
+	req.Equal(SyslogDefaultEnterpriseId, config.enterpriseId)
+
+	_, err = parseSyslogConfig(map[interface{}]interface{}{})
+	req.Error(err)
+
+	_, err = parseSyslogConfig(map[interface{}]interface{}{
+		"address": "syslog",
+	})
+	req.Error(err)
+
+	_, err = parseSyslogConfig(map[interface{}]interface{}{
+		"network": "http",
+		"address": "syslog:514",
+	})
+	req.Error(err)
+
+	_, err = parseSyslogConfig(map[interface{}]interface{}{
+		"address":  "syslog:514",
+		"facility": "local9",
+	})
+	req.Error(err)
+
+	_, err = parseSyslogConfig(map[interface{}]interface{}{
+		"address":  "syslog:514",
+		"severity": 8,
+	})
+	req.Error(err)
+
+	_, err = parseSyslogConfig(map[interface{}]interface{}{
+		"address": "syslog:514",
+		"tls":     map[interface{}]interface{}{},
+	})
+	req.Error(err)
+}
+
+func TestSyslogFormatMessage(t *testing.T) {
+	req := require.New(t)
+
+	config, err := parseSyslogConfig(map[interface{}]interface{}{
+		"address":  "syslog:514",
+		"hostname": "ctrl 1",
+		"severities": map[interface{}]interface{}{
+			"alert": "warning",
+		},
+	})
+	req.NoError(err)
+
+	sink := newSyslogEventSink(config)
+	sink.procId = "42"
+
+	timestamp := time.Date(2025, 3, 4, 5, 6, 7, 8000, time.UTC)
+	evt := `{"namespace":"alert","event_type":"alert","message":"say \"hi\" [now]","count":3,"ok":true,"tags":{"a":"b"}}`
+	msg := string(sink.formatMessage(timestamp, "alert", []byte(evt)))
+
+	req.Equal(`<132>1 2025-03-04T05:06:07.000008Z ctrl_1 ziti-controller 42 alert `+
+		`[ziti@32473 eventType="alert" count="3" message="say \"hi\" [now\]" namespace="alert" ok="true"] `+evt, msg)
+
+	msg = string(sink.formatMessage(timestamp, "circuit", []byte("not json")))
+	req.Equal(`<134>1 2025-03-04T05:06:07.000008Z ctrl_1 ziti-controller 42 circuit [ziti@32473 eventType="circuit"] not json`, msg)
+}
+
+func TestSyslogTcpFraming(t *testing.T) {
+	req := require.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	req.NoError(err)
+	defer func() { _ = listener.Close() }()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		reader := bufio.NewReader(conn)
+		for {
+			lenStr, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			msgLen, err := strconv.Atoi(strings.TrimSpace(lenStr))
+			if err != nil {
+				return
+			}
+			buf := make([]byte, msgLen)
+			if _, err = io.ReadFull(reader, buf); err != nil {
+				return
+			}
+			received <- string(buf)
+		}
+	}()
+
+	config, err := parseSyslogConfig(map[interface{}]interface{}{
+		"network": "tcp",
+		"address": listener.Addr().String(),
+	})
+	req.NoError(err)
+
+	sink := newSyslogEventSink(config)
+	defer func() { _ = sink.Close() }()
+
+	sink.AcceptFormattedEvent("circuit", []byte(`{"circuit_id":"c1"}`))
+
+	select {
+	case msg := <-received:
+		req.True(strings.HasPrefix(msg, "<134>1 "))
+		req.True(strings.HasSuffix(msg, `[ziti@32473 eventType="circuit" circuit_id="c1"] {"circuit_id":"c1"}`))
+	case <-time.After(5 * time.Second):
+		req.Fail("timed out waiting for syslog message")
+	}
+}
@@ -0,0 +1,151 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package eventstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// Query selects stored events. Events are returned oldest first. Empty fields match everything. A zero End means
+// up to the present.
+type Query struct {
+	Start      time.Time
+	End        time.Time
+	Namespaces []string
+	EventTypes []string
+	EntityId   string
+	Limit      int
+}
+
+type QueryResult struct {
+	Events []*Record `json:"events"`
+
+	// Truncated is set if more events matched than the limit allowed. The next page can be fetched by setting
+	// Start to just after the timestamp of the last event returned.
+	Truncated bool `json:"truncated"`
+}
+
+func (self *Query) Validate() error {
+	if self.Limit < 0 || self.Limit > MaxQueryLimit {
+		return errors.Errorf("invalid limit %d, must be between 1 and %d", self.Limit, MaxQueryLimit)
+	}
+	if !self.End.IsZero() && self.End.Before(self.Start) {
+		return errors.New("end of time range is before the start")
+	}
+	return nil
+}
+
+func (self *Query) matches(record *Record) bool {
+	if len(self.Namespaces) > 0 && !slices.Contains(self.Namespaces, record.Namespace) {
+		return false
+	}
+	if len(self.EventTypes) > 0 && !slices.Contains(self.EventTypes, record.EventType) {
+		return false
+	}
+	return true
+}
+
+// Query returns the stored events matching the query, up to the query limit
+func (self *Store) Query(query *Query) (*QueryResult, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultQueryLimit
+	}
+
+	startKey := eventKey(time.Unix(0, 0), 0)
+	if !query.Start.IsZero() {
+		startKey = eventKey(query.Start, 0)
+	}
+
+	endKey := eventKey(time.Unix(0, math.MaxInt64), math.MaxUint64)
+	if !query.End.IsZero() {
+		endKey = eventKey(query.End, math.MaxUint64)
+	}
+
+	result := &QueryResult{
+		Events: []*Record{},
+	}
+
+	err := self.db.View(func(tx *bbolt.Tx) error {
+		events := tx.Bucket(eventsBucket)
+
+		// accept returns false once no more events are needed
+		accept := func(value []byte) (bool, error) {
+			record := &Record{}
+			if err := json.Unmarshal(value, record); err != nil {
+				return false, errors.Wrap(err, "unable to decode stored event")
+			}
+			if !query.matches(record) {
+				return true, nil
+			}
+			if len(result.Events) == limit {
+				result.Truncated = true
+				return false, nil
+			}
+			result.Events = append(result.Events, record)
+			return true, nil
+		}
+
+		if query.EntityId == "" {
+			cursor := events.Cursor()
+			for key, value := cursor.Seek(startKey); key != nil && bytes.Compare(key, endKey) <= 0; key, value = cursor.Next() {
+				if more, err := accept(value); err != nil || !more {
+					return err
+				}
+			}
+			return nil
+		}
+
+		prefix := indexKey(query.EntityId, nil)
+		cursor := tx.Bucket(indexBucket).Cursor()
+		for key, _ := cursor.Seek(indexKey(query.EntityId, startKey)); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			key := key[len(prefix):]
+			if bytes.Compare(key, endKey) > 0 {
+				break
+			}
+			value := events.Get(key)
+			if value == nil {
+				continue
+			}
+			if more, err := accept(value); err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,338 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package eventstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/metrics"
+	"github.com/openziti/ziti/controller/config"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	flushBatchSize = 500
+	flushInterval  = 250 * time.Millisecond
+	pruneInterval  = time.Minute
+
+	// recordOverhead approximates the per record space used by keys and index entries, on top of the record itself
+	recordOverhead = 64
+)
+
+var (
+	eventsBucket = []byte("events")
+	indexBucket  = []byte("entityIndex")
+	metaBucket   = []byte("meta")
+	sizeKey      = []byte("size")
+)
+
+// Record is a stored event. Event holds the event as it would be sent to other event handlers, while EntityIds lists
+// the ids of the entities the event refers to, which can be used to look it up.
+type Record struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Namespace string          `json:"namespace"`
+	EventType string          `json:"eventType"`
+	EntityIds []string        `json:"entityIds"`
+	Event     json.RawMessage `json:"event"`
+}
+
+// Store retains recent circuit, link and session events in a bbolt file separate from the controller database, so
+// operators can look at what happened on the network without having configured an external event pipeline. Events
+// are queued and written in batches. If the queue fills, events are dropped rather than slowing event dispatch.
+//
+// The store is bounded by size and age. Once either bound is exceeded, the oldest events are removed. bbolt reuses
+// the freed pages, so the file stays close to the configured size, but doesn't shrink.
+type Store struct {
+	config   *config.EventStoreConfig
+	db       *bbolt.DB
+	maxBytes int64
+	queue    chan *Record
+
+	size      atomic.Int64
+	dropped   atomic.Int64
+	lastPrune time.Time
+}
+
+// Open opens or creates the event store file and starts writing queued events. The file is closed once closeNotify
+// is closed.
+func Open(cfg *config.EventStoreConfig, registry metrics.Registry, closeNotify <-chan struct{}) (*Store, error) {
+	store, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.FuncGauge("event_store.size", func() int64 {
+		return store.size.Load()
+	})
+	registry.FuncGauge("event_store.dropped", func() int64 {
+		return store.dropped.Load()
+	})
+
+	go store.run(closeNotify)
+	return store, nil
+}
+
+func open(cfg *config.EventStoreConfig) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0700); err != nil {
+		return nil, errors.Wrapf(err, "unable to create event store directory for [%s]", cfg.Path)
+	}
+
+	db, err := bbolt.Open(cfg.Path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open event store [%s]", cfg.Path)
+	}
+
+	store := &Store{
+		config:   cfg,
+		db:       db,
+		maxBytes: cfg.MaxSizeMb * 1024 * 1024,
+		queue:    make(chan *Record, cfg.QueueSize),
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{eventsBucket, indexBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if value := tx.Bucket(metaBucket).Get(sizeKey); len(value) == 8 {
+			store.size.Store(int64(binary.BigEndian.Uint64(value)))
+		}
+		return nil
+	})
+
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.Wrapf(err, "unable to initialize event store [%s]", cfg.Path)
+	}
+
+	return store, nil
+}
+
+func (self *Store) AcceptCircuitEvent(evt *event.CircuitEvent) {
+	entityIds := []string{evt.CircuitId, evt.ClientId, evt.ServiceId, evt.TerminatorId}
+	entityIds = append(entityIds, evt.Path.Nodes...)
+	entityIds = append(entityIds, evt.Path.Links...)
+	for _, v := range evt.Tags {
+		entityIds = append(entityIds, v)
+	}
+	self.add(evt.Namespace, string(evt.EventType), evt.Timestamp, entityIds, evt)
+}
+
+func (self *Store) AcceptLinkEvent(evt *event.LinkEvent) {
+	self.add(evt.Namespace, string(evt.EventType), evt.Timestamp, []string{evt.LinkId, evt.SrcRouterId, evt.DstRouterId}, evt)
+}
+
+func (self *Store) AcceptSessionEvent(evt *event.SessionEvent) {
+	self.add(evt.Namespace, evt.EventType, evt.Timestamp, []string{evt.Id, evt.ApiSessionId, evt.IdentityId, evt.ServiceId}, evt)
+}
+
+func (self *Store) add(namespace, eventType string, timestamp time.Time, entityIds []string, evt any) {
+	if !self.config.IsStored(namespace) {
+		return
+	}
+
+	encoded, err := json.Marshal(evt)
+	if err != nil {
+		pfxlog.Logger().WithError(err).WithField("namespace", namespace).Error("unable to marshal event for event store")
+		return
+	}
+
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	record := &Record{
+		Timestamp: timestamp,
+		Namespace: namespace,
+		EventType: eventType,
+		EntityIds: uniqueIds(entityIds),
+		Event:     encoded,
+	}
+
+	select {
+	case self.queue <- record:
+	default:
+		if self.dropped.Add(1)%1000 == 1 {
+			pfxlog.Logger().WithField("dropped", self.dropped.Load()).Warn("event store queue full, dropping events")
+		}
+	}
+}
+
+func (self *Store) run(closeNotify <-chan struct{}) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Record
+	flush := func() {
+		if len(batch) > 0 {
+			if err := self.write(batch, time.Now()); err != nil {
+				pfxlog.Logger().WithError(err).WithField("count", len(batch)).Error("unable to write events to event store")
+			}
+			batch = nil
+		}
+	}
+
+	for {
+		select {
+		case record := <-self.queue:
+			batch = append(batch, record)
+			if len(batch) >= flushBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-closeNotify:
+			flush()
+			if err := self.db.Close(); err != nil {
+				pfxlog.Logger().WithError(err).Error("unable to close event store")
+			}
+			return
+		}
+	}
+}
+
+// write stores the given records and removes old events, if the store is over its size or age bounds
+func (self *Store) write(batch []*Record, now time.Time) error {
+	return self.db.Update(func(tx *bbolt.Tx) error {
+		events := tx.Bucket(eventsBucket)
+		index := tx.Bucket(indexBucket)
+		size := self.size.Load()
+
+		for _, record := range batch {
+			value, err := json.Marshal(record)
+			if err != nil {
+				return err
+			}
+
+			seq, err := events.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			key := eventKey(record.Timestamp, seq)
+			if err = events.Put(key, value); err != nil {
+				return err
+			}
+
+			for _, entityId := range record.EntityIds {
+				if err = index.Put(indexKey(entityId, key), nil); err != nil {
+					return err
+				}
+			}
+
+			size += recordSize(value, record.EntityIds)
+		}
+
+		if size > self.maxBytes || now.Sub(self.lastPrune) >= pruneInterval {
+			var err error
+			if size, err = self.prune(tx, size, now); err != nil {
+				return err
+			}
+			self.lastPrune = now
+		}
+
+		if err := tx.Bucket(metaBucket).Put(sizeKey, binary.BigEndian.AppendUint64(nil, uint64(size))); err != nil {
+			return err
+		}
+
+		tx.OnCommit(func() {
+			self.size.Store(size)
+		})
+		return nil
+	})
+}
+
+// prune removes the oldest events until the store is within its size bound and holds no events older than the max
+// age. It returns the new size of the store.
+func (self *Store) prune(tx *bbolt.Tx, size int64, now time.Time) (int64, error) {
+	events := tx.Bucket(eventsBucket)
+	index := tx.Bucket(indexBucket)
+	minTimestamp := now.Add(-self.config.MaxAge).UnixNano()
+
+	cursor := events.Cursor()
+	for key, value := cursor.First(); key != nil; key, value = cursor.First() {
+		if size <= self.maxBytes && int64(binary.BigEndian.Uint64(key)) >= minTimestamp {
+			break
+		}
+
+		record := &Record{}
+		if err := json.Unmarshal(value, record); err != nil {
+			pfxlog.Logger().WithError(err).Error("unable to decode event store record while pruning, removing it")
+		}
+
+		for _, entityId := range record.EntityIds {
+			if err := index.Delete(indexKey(entityId, key)); err != nil {
+				return 0, err
+			}
+		}
+
+		size -= recordSize(value, record.EntityIds)
+		if err := cursor.Delete(); err != nil {
+			return 0, err
+		}
+	}
+
+	if size < 0 {
+		size = 0
+	}
+	return size, nil
+}
+
+func recordSize(value []byte, entityIds []string) int64 {
+	result := int64(len(value) + recordOverhead)
+	for _, entityId := range entityIds {
+		result += int64(len(entityId) + recordOverhead)
+	}
+	return result
+}
+
+// eventKey orders events by time. The sequence keeps keys unique when events share a timestamp.
+func eventKey(timestamp time.Time, seq uint64) []byte {
+	key := binary.BigEndian.AppendUint64(make([]byte, 0, 16), uint64(timestamp.UnixNano()))
+	return binary.BigEndian.AppendUint64(key, seq)
+}
+
+// indexKey maps an entity id to an event. Ids can't contain a zero byte, so the prefix for one id doesn't match
+// another id starting with the same characters.
+func indexKey(entityId string, eventKey []byte) []byte {
+	key := make([]byte, 0, len(entityId)+1+len(eventKey))
+	key = append(key, entityId...)
+	key = append(key, 0)
+	return append(key, eventKey...)
+}
+
+func uniqueIds(ids []string) []string {
+	var result []string
+	seen := map[string]struct{}{}
+	for _, id := range ids {
+		if _, found := seen[id]; id == "" || found {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result
+}
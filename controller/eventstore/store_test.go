@@ -0,0 +1,212 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/controller/config"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	cfg := &config.EventStoreConfig{
+		Path:      filepath.Join(t.TempDir(), "events.db"),
+		MaxSizeMb: config.DefaultEventStoreMaxSizeMb,
+		MaxAge:    config.DefaultEventStoreMaxAge,
+		QueueSize: 100,
+		Namespaces: map[string]struct{}{
+			event.CircuitEventNS: {},
+			event.LinkEventNS:    {},
+			event.SessionEventNS: {},
+		},
+	}
+
+	store, err := open(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = store.db.Close()
+	})
+	return store
+}
+
+// drain writes the queued events, as the run loop would
+func (self *Store) drain(t *testing.T, now time.Time) {
+	var batch []*Record
+	for len(self.queue) > 0 {
+		batch = append(batch, <-self.queue)
+	}
+	require.NoError(t, self.write(batch, now))
+}
+
+func circuitEvent(ts time.Time, eventType event.CircuitEventType, circuitId string, routers ...string) *event.CircuitEvent {
+	return &event.CircuitEvent{
+		Namespace: event.CircuitEventNS,
+		Timestamp: ts,
+		EventType: eventType,
+		CircuitId: circuitId,
+		ServiceId: "svc1",
+		Path: event.CircuitPath{
+			Nodes: routers,
+		},
+	}
+}
+
+func TestQueryByTimeTypeAndEntity(t *testing.T) {
+	req := require.New(t)
+	store := newTestStore(t)
+
+	base := time.Date(2026, 3, 4, 2, 0, 0, 0, time.UTC)
+	store.AcceptCircuitEvent(circuitEvent(base, event.CircuitCreated, "c1", "r1", "r2"))
+	store.AcceptLinkEvent(&event.LinkEvent{
+		Namespace:   event.LinkEventNS,
+		Timestamp:   base.Add(13 * time.Minute),
+		EventType:   event.LinkFault,
+		LinkId:      "l1",
+		SrcRouterId: "r1",
+		DstRouterId: "r3",
+	})
+	store.AcceptCircuitEvent(circuitEvent(base.Add(13*time.Minute+time.Second), event.CircuitFailed, "c2", "r1"))
+	store.AcceptSessionEvent(&event.SessionEvent{
+		Namespace:  event.SessionEventNS,
+		Timestamp:  base.Add(20 * time.Minute),
+		EventType:  event.SessionEventTypeCreated,
+		Id:         "s1",
+		IdentityId: "i1",
+		ServiceId:  "svc1",
+	})
+	store.AcceptCircuitEvent(circuitEvent(base.Add(30*time.Minute), event.CircuitDeleted, "c1", "r1", "r2"))
+	store.drain(t, base.Add(time.Hour))
+
+	result, err := store.Query(&Query{})
+	req.NoError(err)
+	req.Len(result.Events, 5)
+	req.False(result.Truncated)
+	for i := 1; i < len(result.Events); i++ {
+		req.False(result.Events[i].Timestamp.Before(result.Events[i-1].Timestamp))
+	}
+
+	// what happened at 02:13
+	result, err = store.Query(&Query{
+		Start: base.Add(13 * time.Minute),
+		End:   base.Add(14 * time.Minute),
+	})
+	req.NoError(err)
+	req.Len(result.Events, 2)
+	req.Equal(event.LinkEventNS, result.Events[0].Namespace)
+	req.Equal(string(event.CircuitFailed), result.Events[1].EventType)
+
+	evt := &event.CircuitEvent{}
+	req.NoError(json.Unmarshal(result.Events[1].Event, evt))
+	req.Equal("c2", evt.CircuitId)
+
+	result, err = store.Query(&Query{EntityId: "r1"})
+	req.NoError(err)
+	req.Len(result.Events, 4)
+
+	result, err = store.Query(&Query{EntityId: "r1", Namespaces: []string{event.CircuitEventNS}})
+	req.NoError(err)
+	req.Len(result.Events, 3)
+
+	result, err = store.Query(&Query{EntityId: "c1", EventTypes: []string{string(event.CircuitDeleted)}})
+	req.NoError(err)
+	req.Len(result.Events, 1)
+	req.Equal(base.Add(30*time.Minute), result.Events[0].Timestamp.UTC())
+
+	result, err = store.Query(&Query{EntityId: "svc1", End: base.Add(15 * time.Minute)})
+	req.NoError(err)
+	req.Len(result.Events, 2)
+
+	// entity ids are matched exactly, not by prefix
+	result, err = store.Query(&Query{EntityId: "r"})
+	req.NoError(err)
+	req.Len(result.Events, 0)
+
+	result, err = store.Query(&Query{Limit: 2})
+	req.NoError(err)
+	req.Len(result.Events, 2)
+	req.True(result.Truncated)
+
+	_, err = store.Query(&Query{Limit: MaxQueryLimit + 1})
+	req.Error(err)
+
+	_, err = store.Query(&Query{Start: base, End: base.Add(-time.Minute)})
+	req.Error(err)
+}
+
+func TestNamespaceFilteringAndQueueOverflow(t *testing.T) {
+	req := require.New(t)
+	store := newTestStore(t)
+	delete(store.config.Namespaces, event.SessionEventNS)
+
+	store.AcceptSessionEvent(&event.SessionEvent{Namespace: event.SessionEventNS, Id: "s1"})
+	req.Equal(0, len(store.queue))
+
+	for i := 0; i < cap(store.queue)+5; i++ {
+		store.AcceptCircuitEvent(circuitEvent(time.Now(), event.CircuitCreated, fmt.Sprintf("c%d", i)))
+	}
+	req.Equal(cap(store.queue), len(store.queue))
+	req.Equal(int64(5), store.dropped.Load())
+}
+
+func TestPruneByAgeAndSize(t *testing.T) {
+	req := require.New(t)
+	store := newTestStore(t)
+
+	now := time.Now()
+	store.AcceptCircuitEvent(circuitEvent(now.Add(-8*24*time.Hour), event.CircuitCreated, "old", "r1"))
+	for i := 0; i < 50; i++ {
+		store.AcceptCircuitEvent(circuitEvent(now.Add(time.Duration(i-50)*time.Minute), event.CircuitCreated, fmt.Sprintf("c%d", i), "r1"))
+	}
+	store.drain(t, now)
+
+	result, err := store.Query(&Query{EntityId: "old"})
+	req.NoError(err)
+	req.Len(result.Events, 0)
+
+	result, err = store.Query(&Query{Limit: MaxQueryLimit})
+	req.NoError(err)
+	req.Len(result.Events, 50)
+
+	// shrink the store to hold about half the events
+	store.maxBytes = store.size.Load() / 2
+	store.AcceptCircuitEvent(circuitEvent(now, event.CircuitCreated, "new", "r1"))
+	store.drain(t, now)
+	req.LessOrEqual(store.size.Load(), store.maxBytes)
+
+	result, err = store.Query(&Query{Limit: MaxQueryLimit})
+	req.NoError(err)
+	req.Less(len(result.Events), 30)
+	req.Equal("new", result.Events[len(result.Events)-1].EntityIds[0])
+
+	indexed, err := store.Query(&Query{EntityId: "r1", Limit: MaxQueryLimit})
+	req.NoError(err)
+	req.Equal(len(result.Events), len(indexed.Events))
+
+	// the tracked size survives a restart
+	size := store.size.Load()
+	req.NoError(store.db.Close())
+	reopened, err := open(store.config)
+	req.NoError(err)
+	store.db = reopened.db
+	req.Equal(size, reopened.size.Load())
+}
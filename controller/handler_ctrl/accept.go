@@ -24,6 +24,7 @@ import (
 	"github.com/openziti/ziti/controller/xctrl"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/proto"
+	"sync/atomic"
 	"time"
 )
 
@@ -32,7 +33,7 @@ type CtrlAccepter struct {
 	xctrls           []xctrl.Xctrl
 	options          *channel.Options
 	heartbeatOptions *channel.HeartbeatOptions
-	traceHandler     *channel.TraceHandler
+	traceHandler     atomic.Pointer[channel.TraceHandler]
 }
 
 func NewCtrlAccepter(network *network.Network,
@@ -40,13 +41,20 @@ func NewCtrlAccepter(network *network.Network,
 	options *channel.Options,
 	heartbeatOptions *channel.HeartbeatOptions,
 	traceHandler *channel.TraceHandler) *CtrlAccepter {
-	return &CtrlAccepter{
+	result := &CtrlAccepter{
 		network:          network,
 		xctrls:           xctrls,
 		options:          options,
 		heartbeatOptions: heartbeatOptions,
-		traceHandler:     traceHandler,
 	}
+	result.traceHandler.Store(traceHandler)
+	return result
+}
+
+// SetTraceHandler changes the trace handler used for router connections. Only routers connecting after the
+// change will use the new handler. A nil handler disables tracing for new connections.
+func (self *CtrlAccepter) SetTraceHandler(traceHandler *channel.TraceHandler) {
+	self.traceHandler.Store(traceHandler)
 }
 
 func (self *CtrlAccepter) AcceptUnderlay(underlay channel.Underlay) error {
@@ -111,6 +119,14 @@ func (self *CtrlAccepter) Bind(binding channel.Binding) error {
 			}
 			r.SetMetadata(routerMetadata)
 		}
+
+		if r.IsDialOnly() {
+			if len(r.Listeners) > 0 {
+				log.Warn("dial only router advertised link listeners, peers will not be told to dial them")
+			}
+			r.Listeners = nil
+			log.Info("router is link dial only")
+		}
 	} else {
 		return errors.New("channel provided no headers, not accepting router connection as version info not provided")
 	}
@@ -121,8 +137,8 @@ func (self *CtrlAccepter) Bind(binding channel.Binding) error {
 		return errors.Wrap(err, "error binding router")
 	}
 
-	if self.traceHandler != nil {
-		binding.AddPeekHandler(self.traceHandler)
+	if traceHandler := self.traceHandler.Load(); traceHandler != nil {
+		binding.AddPeekHandler(traceHandler)
 	}
 
 	log.Info("accepted new router connection")
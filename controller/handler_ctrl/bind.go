@@ -77,6 +77,9 @@ func (self *bindHandler) BindChannel(binding channel.Binding) error {
 	binding.AddTypedReceiveHandler(newDequiesceRouterHandler(self.router, self.network))
 	binding.AddTypedReceiveHandler(newDecommissionRouterHandler(self.router, self.network))
 	binding.AddTypedReceiveHandler(newUpdateRouterInterfacesHandler(self.router, self.network))
+	binding.AddTypedReceiveHandler(newRouterUpdateStatusHandler(self.router, self.network))
+	binding.AddTypedReceiveHandler(newCircuitClassificationHandler(self.router, self.network))
+	binding.AddTypedReceiveHandler(newCircuitLimitHandler(self.router, self.network))
 	binding.AddTypedReceiveHandler(newPingHandler())
 	binding.AddTypedReceiveHandler(&channel.AsyncFunctionReceiveAdapter{
 		Type:    int32(ctrl_pb.ContentType_ValidateTerminatorsV2ResponseType),
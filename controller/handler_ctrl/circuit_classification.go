@@ -0,0 +1,53 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_ctrl
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/network"
+)
+
+type circuitClassificationHandler struct {
+	baseHandler
+}
+
+func newCircuitClassificationHandler(router *model.Router, network *network.Network) *circuitClassificationHandler {
+	return &circuitClassificationHandler{
+		baseHandler: baseHandler{
+			router:  router,
+			network: network,
+		},
+	}
+}
+
+func (self *circuitClassificationHandler) ContentType() int32 {
+	return ctrl_msg.CircuitClassificationType
+}
+
+func (self *circuitClassificationHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	classification, err := ctrl_msg.DecodeCircuitClassification(msg)
+	if err != nil {
+		pfxlog.ContextLogger(ch.Label()).WithField("routerId", self.router.Id).
+			WithError(err).Error("unable to decode circuit classification")
+		return
+	}
+
+	self.network.AcceptCircuitClassification(self.router.Id, classification)
+}
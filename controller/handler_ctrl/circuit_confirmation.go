@@ -91,7 +91,7 @@ func (self *circuitConfirmationHandler) checkCircuitMaxIdle(circuit *model.Circu
 	log.Infof("removing idle circuit, idle time of %s exceeds max idle time of %s",
 		time.Duration(idleTime).String(), service.MaxIdleTime.String())
 
-	if err := self.n.RemoveCircuit(circuit.Id, true); err != nil {
+	if err := self.n.RemoveCircuitWithReason(circuit.Id, true, network.CircuitTerminationMaxIdleTimeExceeded); err != nil {
 		log.WithError(err).Error("error removing idle circuit which has exceeded max idle time")
 	}
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/openziti/channel/v4"
 	"github.com/openziti/foundation/v2/stringz"
 	"github.com/openziti/identity"
+	"github.com/openziti/ziti/controller/change"
 	"github.com/openziti/ziti/controller/network"
 	"time"
 )
@@ -55,6 +56,14 @@ func (self *ConnectHandler) HandleConnection(hello *channel.Hello, certificates
 		return fmt.Errorf("no certificates provided, unable to verify dialer, routerId: %v", id)
 	}
 
+	// routers using SPIFFE X.509 SVIDs are verified against the SPIFFE trust bundle rather than the controller CAs.
+	// Their fingerprint is updated on connect, since SVIDs are regularly renewed
+	svidVerified, err := self.network.Router.VerifySvid(id, certificates, newSvidChangeContext(id))
+	if err != nil {
+		log.WithError(err).Error("router SVID verification failed")
+		return fmt.Errorf("router SVID verification failed, routerId: %v (%w)", id, err)
+	}
+
 	config := self.identity.ServerTLSConfig()
 
 	opts := x509.VerifyOptions{
@@ -85,7 +94,7 @@ func (self *ConnectHandler) HandleConnection(hello *channel.Hello, certificates
 		}
 	}
 
-	if len(validFingerPrints) == 0 && len(errorList) > 0 {
+	if !svidVerified && len(validFingerPrints) == 0 && len(errorList) > 0 {
 		return errors.Join(errorList...)
 	}
 
@@ -100,14 +109,16 @@ func (self *ConnectHandler) HandleConnection(hello *channel.Hello, certificates
 	}
 
 	if r, err := self.network.GetRouter(id); err == nil {
-		if r.Fingerprint == nil {
+		if svidVerified {
+			log.Debug("router verified using SVID")
+		} else if r.Fingerprint == nil {
 			log.Error("router enrollment incomplete")
 			return fmt.Errorf("router enrollment incomplete, routerId: %v", id)
-		}
-		if !stringz.Contains(validFingerPrints, *r.Fingerprint) {
+		} else if !stringz.Contains(validFingerPrints, *r.Fingerprint) {
 			log.WithField("fp", *r.Fingerprint).WithField("givenFps", validFingerPrints).Error("router fingerprint mismatch")
 			return fmt.Errorf("incorrect fingerprint/unenrolled router, routerId: %v, given fingerprints: %v", id, validFingerPrints)
 		}
+
 		if r.Disabled {
 			log.Error("router disabled")
 			return fmt.Errorf("router disabld, routerId: %v", id)
@@ -119,3 +130,10 @@ func (self *ConnectHandler) HandleConnection(hello *channel.Hello, certificates
 
 	return nil
 }
+
+func newSvidChangeContext(routerId string) *change.Context {
+	return change.New().SetSourceType(change.SourceTypeControlChannel).
+		SetSourceMethod("router.svid.verify").
+		SetChangeAuthorType(change.AuthorTypeRouter).
+		SetChangeAuthorId(routerId)
+}
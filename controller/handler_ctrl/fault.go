@@ -104,11 +104,34 @@ func (h *faultHandler) handleFault(_ *channel.Message, ch channel.Channel, fault
 			UnknownOwner: true,
 		})
 
+	case ctrl_pb.FaultSubject_RouteRepair:
+		h.handleRouteRepair(log, fault)
+
 	default:
 		log.Errorf("unexpected subject (%s)", fault.Subject.String())
 	}
 }
 
+func (h *faultHandler) handleRouteRepair(log *logrus.Entry, fault *ctrl_pb.Fault) {
+	log = log.WithField("linkId", fault.LinkId)
+
+	// The router no longer has the link. If we still think it's usable, fault it, so it's not used for the new routes
+	if link, found := h.network.GetLink(fault.LinkId); found && link.IsUsable() {
+		if link.Src.Id == h.r.Id || link.DstId == h.r.Id {
+			log.Info("link reported missing by route repair request, faulting link")
+			h.handleFaultedLink(log, &ctrl_pb.Fault{Subject: ctrl_pb.FaultSubject_LinkFault, Id: fault.LinkId})
+		} else {
+			log.Warn("route repair request for link which doesn't belong to router")
+		}
+	}
+
+	h.network.RepairRoutes(&network.RouteRepairReport{
+		R:          h.r,
+		LinkId:     fault.LinkId,
+		CircuitIds: strings.Split(fault.Id, " "),
+	})
+}
+
 func (h *faultHandler) handleFaultedLink(log *logrus.Entry, fault *ctrl_pb.Fault) {
 	linkId := fault.Id
 	log = log.WithField("linkId", linkId).WithField("fault.iteration", fault.Iteration)
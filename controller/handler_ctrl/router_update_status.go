@@ -0,0 +1,59 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_ctrl
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/network"
+)
+
+type routerUpdateStatusHandler struct {
+	baseHandler
+}
+
+func newRouterUpdateStatusHandler(router *model.Router, network *network.Network) *routerUpdateStatusHandler {
+	return &routerUpdateStatusHandler{
+		baseHandler: baseHandler{
+			router:  router,
+			network: network,
+		},
+	}
+}
+
+func (self *routerUpdateStatusHandler) ContentType() int32 {
+	return ctrl_msg.RouterUpdateStatusType
+}
+
+func (self *routerUpdateStatusHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	log := pfxlog.ContextLogger(ch.Label()).WithField("routerId", self.router.Id)
+
+	status, err := ctrl_msg.DecodeRouterUpdateStatus(msg)
+	if err != nil {
+		log.WithError(err).Error("unable to decode router update status")
+		return
+	}
+
+	log.WithField("rolloutId", status.RolloutId).
+		WithField("state", status.State).
+		WithField("error", status.Error).
+		Info("received router update status")
+
+	self.network.AcceptRouterUpdateStatus(self.router.Id, status)
+}
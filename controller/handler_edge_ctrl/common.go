@@ -209,6 +209,13 @@ func (self *baseSessionRequestContext) loadSession(sessionToken string, apiSessi
 		return
 	}
 
+	// sessions outlive the schedule windows they were created in, so check the schedule each time they're used
+	scheduleResult := self.env.GetManagers().Session.EvaluateScheduleForService(self.session.IdentityId, self.session.Type, self.session.ServiceId, time.Now())
+	if !scheduleResult.Passed {
+		self.err = outsideSchedule(scheduleResult.Cause.Message)
+		return
+	}
+
 	self.logContext = logcontext.NewContext()
 	traceSpec := self.handler.getAppEnv().TraceManager.GetIdentityTrace(self.apiSession.IdentityId)
 	traceEnabled := traceSpec != nil && time.Now().Before(traceSpec.Until)
@@ -533,6 +540,10 @@ func (self *baseSessionRequestContext) updateTerminator(terminator *model.Termin
 }
 
 func (self *baseSessionRequestContext) newCircuitCreateParms(serviceId string, peerData map[uint32][]byte) model.CreateCircuitParams {
+	return self.newTaggedCircuitCreateParms(serviceId, peerData, nil)
+}
+
+func (self *baseSessionRequestContext) newTaggedCircuitCreateParms(serviceId string, peerData map[uint32][]byte, tags map[string]string) model.CreateCircuitParams {
 	return &sessionCircuitParams{
 		serviceId:    serviceId,
 		sourceRouter: self.sourceRouter,
@@ -540,6 +551,7 @@ func (self *baseSessionRequestContext) newCircuitCreateParms(serviceId string, p
 		logCtx:       self.logContext,
 		deadline:     time.Now().Add(self.handler.getAppEnv().GetHostController().GetNetwork().GetOptions().RouteTimeout),
 		reqCtx:       self,
+		tags:         tags,
 	}
 }
 
@@ -576,7 +588,12 @@ func (self *baseSessionRequestContext) createCircuit(terminatorInstanceId string
 		var err error
 		circuit, err = n.CreateCircuit(params)
 		if err != nil {
-			self.err = internalError(err)
+			var circuitErr network.CircuitError
+			if errors.As(err, &circuitErr) && circuitErr.Cause() == network.CircuitFailureServiceCircuitBreakerOpen {
+				self.err = serviceUnavailable(err.Error())
+			} else {
+				self.err = internalError(err)
+			}
 		}
 
 		if circuit != nil && err == nil {
@@ -613,6 +630,7 @@ type sessionCircuitParams struct {
 	logCtx       logcontext.Context
 	deadline     time.Time
 	reqCtx       *baseSessionRequestContext
+	tags         map[string]string
 }
 
 func (self *sessionCircuitParams) GetServiceId() string {
@@ -628,19 +646,19 @@ func (self *sessionCircuitParams) GetClientId() *identity.TokenId {
 }
 
 func (self *sessionCircuitParams) GetCircuitTags(t xt.CostedTerminator) map[string]string {
-	if t == nil {
-		return map[string]string{
-			"serviceId": self.serviceId,
-			"clientId":  self.reqCtx.session.IdentityId,
-		}
+	result := make(map[string]string, len(self.tags)+3)
+	for k, v := range self.tags {
+		result[k] = v
 	}
 
-	hostId := t.GetHostId()
-	return map[string]string{
-		"serviceId": self.serviceId,
-		"clientId":  self.reqCtx.session.IdentityId,
-		"hostId":    hostId,
+	result[model.CircuitTagServiceId] = self.serviceId
+	result[model.CircuitTagClientId] = self.reqCtx.session.IdentityId
+
+	if t != nil {
+		result[model.CircuitTagHostId] = t.GetHostId()
 	}
+
+	return result
 }
 
 func (self *sessionCircuitParams) GetLogContext() logcontext.Context {
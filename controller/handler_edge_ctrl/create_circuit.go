@@ -26,6 +26,7 @@ import (
 	"github.com/openziti/ziti/controller/db"
 	"github.com/openziti/ziti/controller/env"
 	"github.com/openziti/ziti/controller/model"
+	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -104,6 +105,7 @@ func (self *createCircuitHandler) HandleReceiveCreateCircuitV2(msg *channel.Mess
 	ctx := &CreateCircuitRequestContext{
 		baseSessionRequestContext: baseSessionRequestContext{handler: self, msg: msg, env: self.appEnv},
 		req:                       req,
+		tags:                      req.Tags,
 	}
 
 	self.CreateCircuit(ctx, self.CreateCircuitV2Response)
@@ -128,7 +130,8 @@ func (self *createCircuitHandler) CreateCircuit(ctx *CreateCircuitRequestContext
 	ctx.checkSessionType(db.SessionTypeDial)
 	ctx.verifyIdentityEdgeRouterAccess()
 	ctx.loadService()
-	circuitInfo, peerData := ctx.createCircuit(ctx.req.GetTerminatorInstanceId(), ctx.req.GetPeerData(), ctx.newCircuitCreateParms)
+	ctx.validateCircuitTags()
+	circuitInfo, peerData := ctx.createCircuit(ctx.req.GetTerminatorInstanceId(), ctx.req.GetPeerData(), ctx.newSdkCircuitCreateParms)
 
 	if ctx.err != nil {
 		if circuitInfo != nil {
@@ -166,7 +169,24 @@ type CreateCircuitRequest interface {
 
 type CreateCircuitRequestContext struct {
 	baseSessionRequestContext
-	req CreateCircuitRequest
+	req  CreateCircuitRequest
+	tags map[string]string
+}
+
+func (self *CreateCircuitRequestContext) validateCircuitTags() {
+	if self.err == nil {
+		if err := model.ValidateCircuitTags(self.tags); err != nil {
+			self.err = invalidCircuitTags(err.Error())
+			logrus.
+				WithField("sessionId", self.session.Id).
+				WithField("operation", self.handler.Label()).
+				WithError(err).Error("invalid circuit tags")
+		}
+	}
+}
+
+func (self *CreateCircuitRequestContext) newSdkCircuitCreateParms(serviceId string, peerData map[uint32][]byte) model.CreateCircuitParams {
+	return self.newTaggedCircuitCreateParms(serviceId, peerData, self.tags)
 }
 
 func (self *CreateCircuitRequestContext) GetSessionToken() string {
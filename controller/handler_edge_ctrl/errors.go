@@ -18,6 +18,18 @@ package handler_edge_ctrl
 
 import "github.com/openziti/sdk-golang/ziti/edge"
 
+// ErrorCodeServiceUnavailable is returned to SDKs when a dial is rejected because the service's circuit breaker is
+// open. It's outside the range of error codes defined by the SDK
+const ErrorCodeServiceUnavailable = 1000
+
+// ErrorCodeInvalidCircuitTags is returned to SDKs when a dial is rejected because the circuit tags supplied with it
+// exceed the circuit tag limits, or use a reserved tag
+const ErrorCodeInvalidCircuitTags = 1001
+
+// ErrorCodeOutsideSchedule is returned to SDKs when a dial or bind is rejected because every service policy granting
+// the session is outside its schedule
+const ErrorCodeOutsideSchedule = 1002
+
 type controllerError interface {
 	error
 	ErrorCode() uint32
@@ -98,6 +110,27 @@ func (self TunnelingNotEnabledError) ErrorCode() uint32 {
 	return uint32(edge.ErrorCodeTunnelingNotEnabled)
 }
 
+func serviceUnavailable(msg string) controllerError {
+	return &genericControllerError{
+		msg:       msg,
+		errorCode: ErrorCodeServiceUnavailable,
+	}
+}
+
+func invalidCircuitTags(msg string) controllerError {
+	return &genericControllerError{
+		msg:       msg,
+		errorCode: ErrorCodeInvalidCircuitTags,
+	}
+}
+
+func outsideSchedule(msg string) controllerError {
+	return &genericControllerError{
+		msg:       msg,
+		errorCode: ErrorCodeOutsideSchedule,
+	}
+}
+
 func invalidTerminator(msg string) controllerError {
 	return &genericControllerError{
 		msg:       msg,
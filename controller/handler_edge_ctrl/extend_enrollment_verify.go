@@ -23,6 +23,7 @@ import (
 	"github.com/openziti/channel/v4"
 	nfpem "github.com/openziti/foundation/v2/pem"
 	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	"github.com/openziti/ziti/controller/change"
 	"github.com/openziti/ziti/controller/env"
 	"google.golang.org/protobuf/proto"
 )
@@ -73,6 +74,31 @@ func (h *extendEnrollmentVerifyHandler) HandleReceive(msg *channel.Message, ch c
 		}
 
 		routerId := ch.Id()
+
+		// routers using SPIFFE X.509 SVIDs send renewed SVIDs, rather than certificates from an enrollment extension
+		if svidCerts := nfpem.PemStringToCertificates(verifyMsg.ClientCertPem); len(svidCerts) > 0 {
+			changeCtx := change.New().SetSourceType(change.SourceTypeControlChannel).
+				SetSourceMethod("router.svid.renewed").
+				SetSourceLocal(ch.Underlay().GetLocalAddr().String()).
+				SetSourceRemote(ch.Underlay().GetRemoteAddr().String()).
+				SetChangeAuthorType(change.AuthorTypeRouter).
+				SetChangeAuthorId(routerId)
+
+			verified, err := h.appEnv.Managers.Router.VerifySvid(routerId, svidCerts, changeCtx)
+			if err != nil {
+				h.respond(&edge_ctrl_pb.Error{
+					Code:    "SVID_ERROR",
+					Message: fmt.Sprintf("SVID verification error: %v", err),
+				}, msg, ch)
+				return
+			}
+
+			if verified {
+				h.respond(&edge_ctrl_pb.Error{}, msg, ch)
+				return
+			}
+		}
+
 		edgeRouter, _ := h.appEnv.Managers.EdgeRouter.Read(routerId)
 
 		if edgeRouter != nil {
@@ -0,0 +1,56 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_edge_ctrl
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/controller/env"
+)
+
+type trustBundleHandler struct {
+	appEnv *env.AppEnv
+}
+
+func NewTrustBundleHandler(appEnv *env.AppEnv) channel.TypedReceiveHandler {
+	return &trustBundleHandler{
+		appEnv: appEnv,
+	}
+}
+
+func (h *trustBundleHandler) ContentType() int32 {
+	return ctrl_msg.TrustBundleRequestType
+}
+
+func (h *trustBundleHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	log := pfxlog.Logger().WithField("routerId", ch.Id())
+
+	since, _ := msg.GetStringHeader(ctrl_msg.TrustBundleETagHeader)
+	delta := h.appEnv.Managers.Ca.GetTrustCache().GetRouterTrustBundleDelta(since)
+
+	response, err := ctrl_msg.NewTrustBundleResponse(delta)
+	if err != nil {
+		log.WithError(err).Error("unable to create trust bundle response")
+		return
+	}
+
+	response.ReplyTo(msg)
+	if err = ch.Send(response); err != nil {
+		log.WithError(err).Error("unable to send trust bundle response")
+	}
+}
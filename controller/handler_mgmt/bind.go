@@ -88,6 +88,18 @@ func (bindHandler *BindHandler) BindChannel(binding channel.Binding) error {
 		Handler: validateErtTerminatorsRequestHandler.HandleReceive,
 	})
 
+	captureCircuitRequestHandler := newCaptureCircuitHandler(bindHandler.network)
+	binding.AddTypedReceiveHandler(&channel.AsyncFunctionReceiveAdapter{
+		Type:    captureCircuitRequestHandler.ContentType(),
+		Handler: captureCircuitRequestHandler.HandleReceive,
+	})
+
+	updateLinkDialBackoffRequestHandler := newUpdateLinkDialBackoffHandler(bindHandler.network)
+	binding.AddTypedReceiveHandler(&channel.AsyncFunctionReceiveAdapter{
+		Type:    updateLinkDialBackoffRequestHandler.ContentType(),
+		Handler: updateLinkDialBackoffRequestHandler.HandleReceive,
+	})
+
 	tracesHandler := newStreamTracesHandler(bindHandler.network)
 	binding.AddTypedReceiveHandler(tracesHandler)
 	binding.AddCloseHandler(tracesHandler)
@@ -0,0 +1,219 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_mgmt
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/capture"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/common/pb/mgmt_pb"
+	"github.com/openziti/ziti/common/version"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/network"
+	"google.golang.org/protobuf/proto"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	MaxCircuitCaptureDuration     = 5 * time.Minute
+	DefaultCircuitCaptureDuration = 30 * time.Second
+)
+
+type captureCircuitHandler struct {
+	network *network.Network
+}
+
+func newCaptureCircuitHandler(network *network.Network) *captureCircuitHandler {
+	return &captureCircuitHandler{network: network}
+}
+
+func (*captureCircuitHandler) ContentType() int32 {
+	return int32(mgmt_pb.ContentType_CaptureCircuitRequestType)
+}
+
+func (handler *captureCircuitHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	log := pfxlog.ContextLogger(ch.Label())
+	request := &mgmt_pb.CaptureCircuitRequest{}
+
+	var response *mgmt_pb.CaptureCircuitResponse
+	if err := proto.Unmarshal(msg.Body, request); err != nil {
+		response = &mgmt_pb.CaptureCircuitResponse{Message: fmt.Sprintf("%v: failed to unmarshall request: %v", handler.network.GetAppId(), err)}
+	} else {
+		response = handler.captureCircuit(request)
+	}
+
+	if err := protobufs.MarshalTyped(response).ReplyTo(msg).WithTimeout(30 * time.Second).SendAndWaitForWire(ch); err != nil {
+		log.WithError(err).Error("unexpected error sending capture circuit response")
+	}
+}
+
+type routerCaptureResult struct {
+	router   *model.Router
+	response *ctrl_pb.CaptureCircuitResponse
+	err      error
+}
+
+func (handler *captureCircuitHandler) captureCircuit(request *mgmt_pb.CaptureCircuitRequest) *mgmt_pb.CaptureCircuitResponse {
+	duration := time.Duration(request.DurationMs) * time.Millisecond
+	if duration <= 0 {
+		duration = DefaultCircuitCaptureDuration
+	}
+	if duration > MaxCircuitCaptureDuration {
+		return &mgmt_pb.CaptureCircuitResponse{
+			Message: fmt.Sprintf("capture duration %v exceeds maximum of %v", duration, MaxCircuitCaptureDuration),
+		}
+	}
+
+	circuit, found := handler.network.Circuit.Get(request.CircuitId)
+	if !found {
+		return &mgmt_pb.CaptureCircuitResponse{Message: fmt.Sprintf("no circuit found with id %s", request.CircuitId)}
+	}
+
+	log := pfxlog.Logger().WithField("circuitId", circuit.Id)
+
+	routerRequest := &ctrl_pb.CaptureCircuitRequest{
+		CircuitId:       circuit.Id,
+		DurationMs:      duration.Milliseconds(),
+		IncludePayloads: request.IncludePayloads,
+		SnapLength:      request.SnapLength,
+	}
+
+	response := &mgmt_pb.CaptureCircuitResponse{}
+
+	var routers []*model.Router
+	for _, node := range circuit.Path.Nodes {
+		if router := handler.network.GetConnectedRouter(node.Id); router != nil && router.Control != nil && !router.Control.IsClosed() {
+			routers = append(routers, router)
+		} else {
+			response.RouterErrors = append(response.RouterErrors, fmt.Sprintf("router %s is not connected", node.Id))
+		}
+	}
+
+	results := make([]*routerCaptureResult, len(routers))
+	wg := sync.WaitGroup{}
+	for idx, router := range routers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := &routerCaptureResult{
+				router:   router,
+				response: &ctrl_pb.CaptureCircuitResponse{},
+			}
+			respMsg, err := protobufs.MarshalTyped(routerRequest).WithTimeout(duration + time.Minute).SendForReply(router.Control)
+			result.err = protobufs.TypedResponse(result.response).Unmarshall(respMsg, err)
+			if result.err == nil {
+				result.err = handler.fetchRemainingPages(router, circuit.Id, result.response)
+			}
+			results[idx] = result
+		}()
+	}
+	wg.Wait()
+
+	type capturedPacket struct {
+		interfaceId uint32
+		router      *model.Router
+		payload     *ctrl_pb.CapturedPayload
+	}
+
+	buf := &bytes.Buffer{}
+	writer, err := capture.NewPcapngWriter(buf, "ziti-controller "+version.GetVersion())
+	if err != nil {
+		response.Message = err.Error()
+		return response
+	}
+
+	snapLength := request.SnapLength
+	if snapLength == 0 {
+		snapLength = 65535
+	}
+
+	var packets []*capturedPacket
+	for _, result := range results {
+		if result.err == nil && !result.response.Success {
+			result.err = fmt.Errorf("%s", result.response.Message)
+		}
+		if result.err != nil {
+			log.WithField("routerId", result.router.Id).WithError(result.err).Error("circuit capture failed on router")
+			response.RouterErrors = append(response.RouterErrors, fmt.Sprintf("router %s: %v", result.router.Id, result.err))
+			continue
+		}
+
+		interfaceId, err := writer.AddInterface(fmt.Sprintf("%s/%s", result.router.Name, result.router.Id), capture.LinkTypeUser0, snapLength)
+		if err != nil {
+			response.Message = err.Error()
+			return response
+		}
+
+		response.Truncated = response.Truncated || result.response.Truncated
+		for _, payload := range result.response.Payloads {
+			packets = append(packets, &capturedPacket{
+				interfaceId: interfaceId,
+				router:      result.router,
+				payload:     payload,
+			})
+		}
+	}
+
+	sort.SliceStable(packets, func(i, j int) bool {
+		return packets[i].payload.Timestamp < packets[j].payload.Timestamp
+	})
+
+	for _, packet := range packets {
+		p := packet.payload
+		comment := fmt.Sprintf("circuit=%s router=%s src=%s dst=%s seq=%d flags=%d originator=%d retransmit=%v",
+			circuit.Id, packet.router.Id, p.SrcAddr, p.DstAddr, p.Sequence, p.Flags, p.Originator, p.Retransmit)
+		if err = writer.WritePacket(packet.interfaceId, time.Unix(0, p.Timestamp), p.Data, p.Length, comment); err != nil {
+			response.Message = err.Error()
+			return response
+		}
+	}
+
+	response.Success = true
+	response.Pcapng = buf.Bytes()
+	response.PacketCount = uint64(len(packets))
+	return response
+}
+
+// fetchRemainingPages requests the remaining pages of a finished capture from the router, appending their payloads
+// to the response. Routers return captures in pages, so no single control channel message holds an entire capture.
+func (handler *captureCircuitHandler) fetchRemainingPages(router *model.Router, circuitId string, response *ctrl_pb.CaptureCircuitResponse) error {
+	pageRequest := &ctrl_pb.CaptureCircuitRequest{
+		CircuitId: circuitId,
+		NextPage:  true,
+	}
+
+	for response.Success && response.More {
+		page := &ctrl_pb.CaptureCircuitResponse{}
+		respMsg, err := protobufs.MarshalTyped(pageRequest).WithTimeout(30 * time.Second).SendForReply(router.Control)
+		if err = protobufs.TypedResponse(page).Unmarshall(respMsg, err); err != nil {
+			return err
+		}
+		if !page.Success {
+			return fmt.Errorf("%s", page.Message)
+		}
+		response.Payloads = append(response.Payloads, page.Payloads...)
+		response.Truncated = response.Truncated || page.Truncated
+		response.More = page.More
+	}
+	return nil
+}
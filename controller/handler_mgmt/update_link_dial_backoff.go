@@ -0,0 +1,78 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_mgmt
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/common/pb/mgmt_pb"
+	"github.com/openziti/ziti/controller/network"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+type updateLinkDialBackoffHandler struct {
+	network *network.Network
+}
+
+func newUpdateLinkDialBackoffHandler(network *network.Network) *updateLinkDialBackoffHandler {
+	return &updateLinkDialBackoffHandler{network: network}
+}
+
+func (*updateLinkDialBackoffHandler) ContentType() int32 {
+	return int32(mgmt_pb.ContentType_UpdateLinkDialBackoffRequestType)
+}
+
+func (handler *updateLinkDialBackoffHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	log := pfxlog.ContextLogger(ch.Label())
+	request := &mgmt_pb.UpdateLinkDialBackoffRequest{}
+	response := &mgmt_pb.UpdateLinkDialBackoffResponse{}
+
+	if err := proto.Unmarshal(msg.Body, request); err != nil {
+		response.Message = err.Error()
+	} else if request.Clear {
+		response.RouterCount = uint32(handler.network.ClearLinkDialBackoff(request.Group))
+		response.Success = true
+	} else {
+		count, err := handler.network.UpdateLinkDialBackoff(request.Group, toCtrlDialBackoff(request.Healthy), toCtrlDialBackoff(request.Unhealthy))
+		if err != nil {
+			response.Message = err.Error()
+		} else {
+			response.RouterCount = uint32(count)
+			response.Success = true
+		}
+	}
+
+	if err := protobufs.MarshalTyped(response).ReplyTo(msg).WithTimeout(5 * time.Second).Send(ch); err != nil {
+		log.WithError(err).Error("unexpected error sending update link dial backoff response")
+	}
+}
+
+func toCtrlDialBackoff(backoff *mgmt_pb.LinkDialBackoff) *ctrl_pb.DialBackoff {
+	if backoff == nil {
+		return nil
+	}
+	return &ctrl_pb.DialBackoff{
+		MinRetryIntervalMs: backoff.MinRetryIntervalMs,
+		MaxRetryIntervalMs: backoff.MaxRetryIntervalMs,
+		RetryBackoffFactor: backoff.RetryBackoffFactor,
+		Jitter:             backoff.Jitter,
+		MaxAttempts:        backoff.MaxAttempts,
+	}
+}
@@ -4,36 +4,81 @@ import (
 	"context"
 	gosundheit "github.com/AppsFlyer/go-sundheit"
 	"github.com/AppsFlyer/go-sundheit/checks"
+	"github.com/michaelquigley/pfxlog"
 	"github.com/openziti/metrics"
 	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/config"
 	"github.com/pkg/errors"
 	"go.etcd.io/bbolt"
 	"sync/atomic"
 	"time"
 )
 
+const (
+	boltCheckName                  = "bolt.read"
+	startupIntegrityCheckMaxLogged = 100
+)
+
 func (c *Controller) initializeHealthChecks() (gosundheit.Health, error) {
 	healthChecker := gosundheit.New()
-	check, err := checks.NewPingCheck("bolt.read", &boltPinger{
+	if err := c.registerBoltCheck(healthChecker, &c.config.HealthChecks); err != nil {
+		return nil, err
+	}
+	return healthChecker, nil
+}
+
+func (c *Controller) registerBoltCheck(healthChecker gosundheit.Health, cfg *config.HealthChecksConfig) error {
+	check, err := checks.NewPingCheck(boltCheckName, &boltPinger{
 		dbProvider:  c.network.GetDb,
 		openReadTxs: c.GetNetwork().GetMetricsRegistry().Gauge("bolt.open_read_txs"),
 	})
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = healthChecker.RegisterCheck(check,
-		gosundheit.InitialDelay(c.config.HealthChecks.BoltCheck.InitialDelay),
-		gosundheit.ExecutionPeriod(c.config.HealthChecks.BoltCheck.Interval),
-		gosundheit.ExecutionTimeout(c.config.HealthChecks.BoltCheck.Timeout),
+	return healthChecker.RegisterCheck(check,
+		gosundheit.InitialDelay(cfg.BoltCheck.InitialDelay),
+		gosundheit.ExecutionPeriod(cfg.BoltCheck.Interval),
+		gosundheit.ExecutionTimeout(cfg.BoltCheck.Timeout),
 		gosundheit.InitiallyPassing(true))
+}
 
-	if err != nil {
-		return nil, err
+// reloadHealthChecks re-registers the health checks, so that the new intervals and timeouts take effect
+func (c *Controller) reloadHealthChecks(cfg *config.HealthChecksConfig) error {
+	if c.healthChecker == nil {
+		return nil
 	}
+	c.healthChecker.Deregister(boltCheckName)
+	return c.registerBoltCheck(c.healthChecker, cfg)
+}
 
-	return healthChecker, nil
+// runStartupIntegrityCheck runs a quick, read-only check of the database's referential integrity. Problems are
+// logged, but not fixed, as they should be reviewed first. They can be fixed using the database integrity check
+// API, or offline using ziti ops db repair
+func (c *Controller) runStartupIntegrityCheck() {
+	log := pfxlog.Logger()
+	start := time.Now()
+
+	problems := 0
+	errorHandler := func(err error, _ bool) {
+		problems++
+		if problems <= startupIntegrityCheckMaxLogged {
+			log.WithError(err).Warn("database integrity problem found at startup")
+		}
+	}
+
+	if err := c.network.GetStores().QuickCheckIntegrity(c.network.GetDb(), context.Background(), errorHandler); err != nil {
+		log.WithError(err).Error("startup database integrity check failed")
+		return
+	}
+
+	if problems > 0 {
+		log.WithField("problems", problems).WithField("elapsed", time.Since(start)).
+			Warn("startup database integrity check found problems, run a full integrity check to review and fix them")
+	} else {
+		log.WithField("elapsed", time.Since(start)).Info("startup database integrity check found no problems")
+	}
 }
 
 type boltPinger struct {
@@ -20,6 +20,14 @@ const (
 	AdminPermission                 = "ADMIN"
 	AuthenticatedPermission         = "AUTHENTICATED"
 	PartiallyAuthenticatePermission = "PARTIAL_AUTH"
+	ReadOnlyAdminPermission         = "READ_ONLY_ADMIN"
+	ScopedAdminPermission           = "SCOPED_ADMIN"
+
+	// ReadOnlyAdminRoleAttribute grants the ReadOnlyAdminPermission to identities which have it
+	ReadOnlyAdminRoleAttribute = "ziti.read-only-admin"
+
+	// ScopedAdminRoleAttribute grants the ScopedAdminPermission to identities which have it and a scope tag
+	ScopedAdminRoleAttribute = "ziti.scoped-admin"
 )
 
 type Resolver interface {
@@ -0,0 +1,42 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package permissions
+
+import "net/http"
+
+// IsReadOnlyMethod returns true if requests using the given HTTP method don't modify anything
+func IsReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// ForRequest returns the permissions which apply to a request using the given HTTP method. Read only admins are
+// treated as admins for requests which don't modify anything.
+func ForRequest(method string, identityPerms []string) []string {
+	if !IsReadOnlyMethod(method) {
+		return identityPerms
+	}
+
+	for _, p := range identityPerms {
+		if p == ReadOnlyAdminPermission {
+			result := make([]string, 0, len(identityPerms)+1)
+			result = append(result, identityPerms...)
+			return append(result, AdminPermission)
+		}
+	}
+
+	return identityPerms
+}
@@ -0,0 +1,40 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package permissions
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForRequest(t *testing.T) {
+	req := require.New(t)
+
+	readOnly := []string{AuthenticatedPermission, ReadOnlyAdminPermission}
+	req.True(IsAdmin().IsAllowed(ForRequest(http.MethodGet, readOnly)...))
+	req.True(IsAdmin().IsAllowed(ForRequest(http.MethodHead, readOnly)...))
+	req.False(IsAdmin().IsAllowed(ForRequest(http.MethodPost, readOnly)...))
+	req.False(IsAdmin().IsAllowed(ForRequest(http.MethodPut, readOnly)...))
+	req.False(IsAdmin().IsAllowed(ForRequest(http.MethodPatch, readOnly)...))
+	req.False(IsAdmin().IsAllowed(ForRequest(http.MethodDelete, readOnly)...))
+	req.Len(readOnly, 2)
+
+	authOnly := []string{AuthenticatedPermission}
+	req.False(IsAdmin().IsAllowed(ForRequest(http.MethodGet, authOnly)...))
+}
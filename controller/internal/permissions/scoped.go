@@ -0,0 +1,40 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package permissions
+
+import "github.com/openziti/foundation/v2/stringz"
+
+// ScopeTag is the tag holding the administrative scope of an entity. Scoped admins may only manage entities whose
+// scope tag matches their own.
+const ScopeTag = "scope"
+
+// GetAdminScope returns the scope a scoped admin is restricted to, or an empty string if the identity with the given
+// role attributes and tags isn't a scoped admin
+func GetAdminScope(roleAttributes []string, tags map[string]interface{}) string {
+	if !stringz.Contains(roleAttributes, ScopedAdminRoleAttribute) {
+		return ""
+	}
+	return GetScope(tags)
+}
+
+// GetScope returns the value of the scope tag in the given tags, or an empty string if it isn't set to a string
+func GetScope(tags map[string]interface{}) string {
+	if scope, ok := tags[ScopeTag].(string); ok {
+		return scope
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package permissions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAdminScope(t *testing.T) {
+	t.Run("scoped admin with a scope tag has a scope", func(t *testing.T) {
+		req := require.New(t)
+		scope := GetAdminScope([]string{"ops", ScopedAdminRoleAttribute}, map[string]interface{}{ScopeTag: "sales"})
+		req.Equal("sales", scope)
+	})
+
+	t.Run("scoped admin without a scope tag has no scope", func(t *testing.T) {
+		req := require.New(t)
+		req.Empty(GetAdminScope([]string{ScopedAdminRoleAttribute}, nil))
+		req.Empty(GetAdminScope([]string{ScopedAdminRoleAttribute}, map[string]interface{}{ScopeTag: 12}))
+	})
+
+	t.Run("identity without the role attribute has no scope", func(t *testing.T) {
+		req := require.New(t)
+		req.Empty(GetAdminScope([]string{"ops"}, map[string]interface{}{ScopeTag: "sales"}))
+	})
+}
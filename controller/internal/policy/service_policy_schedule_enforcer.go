@@ -0,0 +1,110 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/storage/ast"
+	"github.com/openziti/ziti/common/runner"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/env"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	ServicePolicyScheduleEnforcerRun     = "service.policy.schedule.enforcer.run"
+	ServicePolicyScheduleEnforcerDeletes = "service.policy.schedule.enforcer.deletes"
+)
+
+// ServicePolicyScheduleEnforcer removes sessions once none of the service policies granting them are inside their
+// schedule. New sessions are checked when they're created, this handles sessions created while a window was open.
+type ServicePolicyScheduleEnforcer struct {
+	appEnv *env.AppEnv
+	*runner.BaseOperation
+}
+
+func NewServicePolicyScheduleEnforcer(appEnv *env.AppEnv, f time.Duration) *ServicePolicyScheduleEnforcer {
+	return &ServicePolicyScheduleEnforcer{
+		appEnv:        appEnv,
+		BaseOperation: runner.NewBaseOperation("ServicePolicyScheduleEnforcer", f),
+	}
+}
+
+func (enforcer *ServicePolicyScheduleEnforcer) Run() error {
+	startTime := time.Now()
+
+	defer func() {
+		enforcer.appEnv.GetMetricsRegistry().Timer(ServicePolicyScheduleEnforcerRun).UpdateSince(startTime)
+	}()
+
+	identityIds := map[string]struct{}{}
+
+	err := enforcer.appEnv.GetDb().View(func(tx *bbolt.Tx) error {
+		store := enforcer.appEnv.GetStores().ServicePolicy
+		for cursor := store.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
+			policy, err := store.LoadById(tx, string(cursor.Current()))
+			if err != nil {
+				return err
+			}
+
+			if policy.Schedule == nil {
+				continue
+			}
+
+			for _, identityId := range store.GetRelatedEntitiesIdList(tx, policy.Id, db.EntityTypeIdentities) {
+				identityIds[identityId] = struct{}{}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var sessionsToRemove []string
+	for identityId := range identityIds {
+		result, err := enforcer.appEnv.GetManagers().Session.Query(fmt.Sprintf(`apiSession.identity = "%v"`, identityId))
+		if err != nil {
+			return err
+		}
+
+		for _, session := range result.Sessions {
+			scheduleResult := enforcer.appEnv.GetManagers().Session.EvaluateScheduleForService(identityId, session.Type, session.ServiceId, startTime)
+			if !scheduleResult.Passed {
+				pfxlog.Logger().WithField("sessionId", session.Id).
+					WithField("identityId", identityId).
+					WithField("serviceId", session.ServiceId).
+					Debug("removing session outside of service policy schedule")
+				sessionsToRemove = append(sessionsToRemove, session.Id)
+			}
+		}
+	}
+
+	ctx := change.New().SetSourceType("service-policy.schedule.enforcer").SetChangeAuthorType(change.AuthorTypeController)
+	for _, sessionId := range sessionsToRemove {
+		_ = enforcer.appEnv.GetManagers().Session.Delete(sessionId, ctx)
+	}
+
+	enforcer.appEnv.GetMetricsRegistry().Meter(ServicePolicyScheduleEnforcerDeletes).Mark(int64(len(sessionsToRemove)))
+
+	return nil
+}
@@ -55,8 +55,19 @@ func (factory *ApiSessionLinkFactoryImpl) Links(entity models.Entity) rest_model
 	}
 }
 
-func MapApiSessionToRestInterface(ae *env.AppEnv, _ *response.RequestContext, apiSession *model.ApiSession) (interface{}, error) {
-	return MapApiSessionToRestModel(ae, apiSession)
+func MapApiSessionToRestInterface(ae *env.AppEnv, rc *response.RequestContext, apiSession *model.ApiSession) (interface{}, error) {
+	result, err := MapApiSessionToRestModel(ae, apiSession)
+	if err == nil && hideSessionToken(rc, apiSession.IdentityId) {
+		result.Token = new(string)
+	}
+	return result, err
+}
+
+// hideSessionToken returns true if the token of an api session or session owned by the given identity should not be
+// shown to the requester. Read only admins can see the sessions of other identities, but anyone with a token can act
+// as the identity, so they're only given the tokens of their own sessions.
+func hideSessionToken(rc *response.RequestContext, identityId string) bool {
+	return hideEnrollmentSecrets(rc) && (rc.Identity == nil || rc.Identity.Id != identityId)
 }
 
 func MapApiSessionToRestModel(ae *env.AppEnv, apiSession *model.ApiSession) (*rest_model.APISessionDetail, error) {
@@ -131,6 +131,7 @@ func ListWithEnvelopeFactory(rc *response.RequestContext, toEnvelope ApiListEnve
 		rc.RespondWithError(err)
 		return
 	}
+	qo.RequiredPredicate = rc.ListFilter
 
 	result, err := f(rc, qo)
 
@@ -539,7 +540,9 @@ func ListAssociations(rc *response.RequestContext, listF listAssocF) {
 
 	if err != nil {
 		rc.RespondWithError(err)
+		return
 	}
+	queryOptions.RequiredPredicate = rc.ListFilter
 
 	result, err := listF(rc, id, queryOptions)
 
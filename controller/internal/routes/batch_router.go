@@ -0,0 +1,432 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/api"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/command"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/models"
+	"github.com/openziti/ziti/controller/response"
+)
+
+const (
+	BatchPath = "/batch"
+
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpPatch  = "patch"
+	BatchOpDelete = "delete"
+
+	BatchStatusOk      = "ok"
+	BatchStatusFailed  = "failed"
+	BatchStatusSkipped = "skipped"
+
+	MaxBatchOperations = 5000
+)
+
+func init() {
+	r := NewBatchRouter()
+	env.AddRouter(r)
+}
+
+// BatchRequest is the body accepted by the batch endpoint
+type BatchRequest struct {
+	Operations []*BatchOperation `json:"operations"`
+}
+
+// BatchOperation is a single create, update, patch or delete. Data holds the same JSON body which would be sent to
+// the entity specific endpoint.
+type BatchOperation struct {
+	Op         string          `json:"op"`
+	EntityType string          `json:"entityType"`
+	Id         string          `json:"id,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+type BatchOperationResult struct {
+	Index      int    `json:"index"`
+	Op         string `json:"op"`
+	EntityType string `json:"entityType"`
+	Id         string `json:"id,omitempty"`
+	Status     string `json:"status"`
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+type BatchResult struct {
+	Applied bool                    `json:"applied"`
+	Results []*BatchOperationResult `json:"results"`
+}
+
+type batchCommandFactory func(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error)
+
+type BatchRouter struct {
+	factories map[string]map[string]batchCommandFactory
+}
+
+func NewBatchRouter() *BatchRouter {
+	result := &BatchRouter{
+		factories: map[string]map[string]batchCommandFactory{},
+	}
+
+	result.factories[EntityNameIdentity] = map[string]batchCommandFactory{
+		BatchOpCreate: batchCreateIdentity,
+		BatchOpUpdate: batchUpdateIdentity,
+		BatchOpPatch:  batchPatchIdentity,
+		BatchOpDelete: func(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+			return op.Id, ae.Managers.Identity.NewDeleteCommand(op.Id, ctx), nil
+		},
+	}
+
+	result.factories[EntityNameService] = map[string]batchCommandFactory{
+		BatchOpCreate: batchCreateService,
+		BatchOpUpdate: batchUpdateService,
+		BatchOpPatch:  batchPatchService,
+		BatchOpDelete: func(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+			return op.Id, ae.Managers.EdgeService.NewDeleteCommand(op.Id, ctx), nil
+		},
+	}
+
+	result.factories[EntityNameServicePolicy] = map[string]batchCommandFactory{
+		BatchOpCreate: batchCreateServicePolicy,
+		BatchOpUpdate: batchUpdateServicePolicy,
+		BatchOpPatch:  batchPatchServicePolicy,
+		BatchOpDelete: func(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+			return op.Id, ae.Managers.ServicePolicy.NewDeleteCommand(op.Id, ctx), nil
+		},
+	}
+
+	result.factories[EntityNameEdgeRouterPolicy] = map[string]batchCommandFactory{
+		BatchOpCreate: batchCreateEdgeRouterPolicy,
+		BatchOpUpdate: batchUpdateEdgeRouterPolicy,
+		BatchOpPatch:  batchPatchEdgeRouterPolicy,
+		BatchOpDelete: func(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+			return op.Id, ae.Managers.EdgeRouterPolicy.NewDeleteCommand(op.Id, ctx), nil
+		},
+	}
+
+	result.factories[EntityNameServiceEdgeRouterPolicy] = map[string]batchCommandFactory{
+		BatchOpCreate: batchCreateServiceEdgeRouterPolicy,
+		BatchOpUpdate: batchUpdateServiceEdgeRouterPolicy,
+		BatchOpPatch:  batchPatchServiceEdgeRouterPolicy,
+		BatchOpDelete: func(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+			return op.Id, ae.Managers.ServiceEdgeRouterPolicy.NewDeleteCommand(op.Id, ctx), nil
+		},
+	}
+
+	return result
+}
+
+func (r *BatchRouter) Register(ae *env.AppEnv) {
+	ae.AddManagementApiHandler(BatchPath, http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.Batch, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	}))
+}
+
+func (r *BatchRouter) Batch(ae *env.AppEnv, rc *response.RequestContext) {
+	if rc.Request.Method != http.MethodPost {
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+		return
+	}
+
+	batch := &BatchRequest{}
+	if err := json.Unmarshal(rc.Body, batch); err != nil {
+		rc.RespondWithCouldNotParseBody(err)
+		return
+	}
+
+	if len(batch.Operations) == 0 {
+		rc.RespondWithApiError(errorz.NewFieldApiError(errorz.NewFieldError("at least one operation is required", "operations", nil)))
+		return
+	}
+
+	if len(batch.Operations) > MaxBatchOperations {
+		msg := fmt.Sprintf("a batch may contain at most %d operations", MaxBatchOperations)
+		rc.RespondWithApiError(errorz.NewFieldApiError(errorz.NewFieldError(msg, "operations", len(batch.Operations))))
+		return
+	}
+
+	changeCtx := rc.NewChangeContext()
+	result := &BatchResult{}
+	var cmds []command.Command
+	var mappingErr *errorz.ApiError
+
+	for idx, op := range batch.Operations {
+		opResult := &BatchOperationResult{
+			Index:  idx,
+			Status: BatchStatusSkipped,
+		}
+		result.Results = append(result.Results, opResult)
+
+		if op == nil {
+			mappingErr = batchSetFailed(opResult, errorz.NewCouldNotValidate(errors.New("operation may not be null")))
+			continue
+		}
+
+		opResult.Op = op.Op
+		opResult.EntityType = op.EntityType
+		opResult.Id = op.Id
+
+		id, cmd, err := r.newCommand(ae, op, changeCtx)
+		if err != nil {
+			mappingErr = batchSetFailed(opResult, models.ToApiErrorWithDefault(err, errorz.NewCouldNotValidate))
+			continue
+		}
+		opResult.Id = id
+		cmds = append(cmds, cmd)
+	}
+
+	// nothing is dispatched unless every operation could be mapped to a command
+	if mappingErr != nil {
+		rc.Respond(&rest_model.Empty{Data: result, Meta: &rest_model.Meta{}}, mappingErr.Status)
+		return
+	}
+
+	if err := ae.Managers.Command.DispatchBatch(cmds, changeCtx); err != nil {
+		var itemErr *model.BatchItemError
+		if !errors.As(err, &itemErr) || itemErr.Index >= len(result.Results) {
+			rc.RespondWithError(err)
+			return
+		}
+
+		apiErr := batchSetFailed(result.Results[itemErr.Index], models.ToApiError(itemErr.Cause))
+		rc.Respond(&rest_model.Empty{Data: result, Meta: &rest_model.Meta{}}, apiErr.Status)
+		return
+	}
+
+	result.Applied = true
+	for _, opResult := range result.Results {
+		opResult.Status = BatchStatusOk
+	}
+
+	rc.RespondWithOk(result, &rest_model.Meta{})
+}
+
+func (r *BatchRouter) newCommand(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	entityFactories, ok := r.factories[op.EntityType]
+	if !ok {
+		return "", nil, errorz.NewFieldError("unsupported entity type", "entityType", op.EntityType)
+	}
+
+	factory, ok := entityFactories[op.Op]
+	if !ok {
+		return "", nil, errorz.NewFieldError("unsupported operation", "op", op.Op)
+	}
+
+	if op.Op != BatchOpCreate && op.Id == "" {
+		return "", nil, errorz.NewFieldError("id is required", "id", op.Id)
+	}
+
+	return factory(ae, op, ctx)
+}
+
+func batchSetFailed(opResult *BatchOperationResult, apiErr *errorz.ApiError) *errorz.ApiError {
+	opResult.Status = BatchStatusFailed
+	opResult.Code = apiErr.Code
+	opResult.Message = apiErr.Message
+	if apiErr.Cause != nil {
+		opResult.Message = fmt.Sprintf("%s: %s", apiErr.Message, apiErr.Cause.Error())
+	}
+	return apiErr
+}
+
+type batchValidatable interface {
+	Validate(formats strfmt.Registry) error
+}
+
+func batchUnmarshal(op *BatchOperation, target batchValidatable) error {
+	if len(op.Data) == 0 {
+		return errorz.NewFieldError("data is required", "data", nil)
+	}
+	if err := json.Unmarshal(op.Data, target); err != nil {
+		return apierror.NewCouldNotParseBody(err)
+	}
+	return target.Validate(strfmt.Default)
+}
+
+func batchPatchFields(op *BatchOperation, target batchValidatable) (fields.UpdatedFields, error) {
+	if err := batchUnmarshal(op, target); err != nil {
+		return nil, err
+	}
+	updatedFields, err := api.GetFields(op.Data)
+	if err != nil {
+		return nil, apierror.NewCouldNotParseBody(err)
+	}
+	return updatedFields, nil
+}
+
+func batchCreateIdentity(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	create := &rest_model.IdentityCreate{}
+	if err := batchUnmarshal(op, create); err != nil {
+		return "", nil, err
+	}
+	identity, enrollments := MapCreateIdentityToModel(create, getIdentityTypeId(ae, *create.Type))
+	cmd := ae.Managers.Identity.NewCreateWithEnrollmentsCmd(identity, enrollments, ctx)
+	return identity.Id, cmd, nil
+}
+
+func batchUpdateIdentity(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	update := &rest_model.IdentityUpdate{}
+	if err := batchUnmarshal(op, update); err != nil {
+		return "", nil, err
+	}
+	identity := MapUpdateIdentityToModel(op.Id, update, getIdentityTypeId(ae, *update.Type))
+	return op.Id, model.NewUpdateEntityCommand[*model.Identity](ae.Managers.Identity, identity, nil, ctx), nil
+}
+
+func batchPatchIdentity(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	patch := &rest_model.IdentityPatch{}
+	updatedFields, err := batchPatchFields(op, patch)
+	if err != nil {
+		return "", nil, err
+	}
+	updatedFields = updatedFields.FilterMaps(boltz.FieldTags, db.FieldIdentityAppData, db.FieldIdentityServiceHostingCosts, db.FieldIdentityServiceHostingPrecedences)
+	identity := MapPatchIdentityToModel(op.Id, patch, getIdentityTypeId(ae, patch.Type))
+	return op.Id, model.NewUpdateEntityCommand[*model.Identity](ae.Managers.Identity, identity, updatedFields, ctx), nil
+}
+
+func batchCreateService(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	create := &rest_model.ServiceCreate{}
+	if err := batchUnmarshal(op, create); err != nil {
+		return "", nil, err
+	}
+	cmd := model.NewCreateEntityCommand[*model.EdgeService](ae.Managers.EdgeService, MapCreateServiceToModel(create), ctx)
+	return cmd.Entity.Id, cmd, nil
+}
+
+func batchUpdateService(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	update := &rest_model.ServiceUpdate{}
+	if err := batchUnmarshal(op, update); err != nil {
+		return "", nil, err
+	}
+	svc := preserveMaxCircuitLifetime(ae, MapUpdateServiceToModel(op.Id, update))
+	return op.Id, ae.Managers.EdgeService.NewUpdateCommand(svc, nil, ctx), nil
+}
+
+func batchPatchService(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	patch := &rest_model.ServicePatch{}
+	updatedFields, err := batchPatchFields(op, patch)
+	if err != nil {
+		return "", nil, err
+	}
+	updatedFields = updatedFields.FilterMaps("tags").MapField("maxIdleTimeMillis", "maxIdleTime")
+	return op.Id, ae.Managers.EdgeService.NewUpdateCommand(MapPatchServiceToModel(op.Id, patch), updatedFields, ctx), nil
+}
+
+func batchCreateServicePolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	create := &rest_model.ServicePolicyCreate{}
+	if err := batchUnmarshal(op, create); err != nil {
+		return "", nil, err
+	}
+	cmd := model.NewCreateEntityCommand[*model.ServicePolicy](ae.Managers.ServicePolicy, MapCreateServicePolicyToModel(create), ctx)
+	return cmd.Entity.Id, cmd, nil
+}
+
+func batchUpdateServicePolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	update := &rest_model.ServicePolicyUpdate{}
+	if err := batchUnmarshal(op, update); err != nil {
+		return "", nil, err
+	}
+	policy := MapUpdateServicePolicyToModel(op.Id, update)
+	if existing, err := ae.Managers.ServicePolicy.Read(op.Id); err == nil {
+		policy.Schedule = existing.Schedule
+	}
+	return op.Id, model.NewUpdateEntityCommand[*model.ServicePolicy](ae.Managers.ServicePolicy, policy, nil, ctx), nil
+}
+
+func batchPatchServicePolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	patch := &rest_model.ServicePolicyPatch{}
+	updatedFields, err := batchPatchFields(op, patch)
+	if err != nil {
+		return "", nil, err
+	}
+	policy := MapPatchServicePolicyToModel(op.Id, patch)
+	return op.Id, model.NewUpdateEntityCommand[*model.ServicePolicy](ae.Managers.ServicePolicy, policy, updatedFields.FilterMaps("tags"), ctx), nil
+}
+
+func batchCreateEdgeRouterPolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	create := &rest_model.EdgeRouterPolicyCreate{}
+	if err := batchUnmarshal(op, create); err != nil {
+		return "", nil, err
+	}
+	cmd := model.NewCreateEntityCommand[*model.EdgeRouterPolicy](ae.Managers.EdgeRouterPolicy, MapCreateEdgeRouterPolicyToModel(create), ctx)
+	return cmd.Entity.Id, cmd, nil
+}
+
+func batchUpdateEdgeRouterPolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	update := &rest_model.EdgeRouterPolicyUpdate{}
+	if err := batchUnmarshal(op, update); err != nil {
+		return "", nil, err
+	}
+	policy := MapUpdateEdgeRouterPolicyToModel(op.Id, update)
+	return op.Id, model.NewUpdateEntityCommand[*model.EdgeRouterPolicy](ae.Managers.EdgeRouterPolicy, policy, nil, ctx), nil
+}
+
+func batchPatchEdgeRouterPolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	patch := &rest_model.EdgeRouterPolicyPatch{}
+	updatedFields, err := batchPatchFields(op, patch)
+	if err != nil {
+		return "", nil, err
+	}
+	policy := MapPatchEdgeRouterPolicyToModel(op.Id, patch)
+	return op.Id, model.NewUpdateEntityCommand[*model.EdgeRouterPolicy](ae.Managers.EdgeRouterPolicy, policy, updatedFields.FilterMaps("tags"), ctx), nil
+}
+
+func batchCreateServiceEdgeRouterPolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	create := &rest_model.ServiceEdgeRouterPolicyCreate{}
+	if err := batchUnmarshal(op, create); err != nil {
+		return "", nil, err
+	}
+	cmd := model.NewCreateEntityCommand[*model.ServiceEdgeRouterPolicy](ae.Managers.ServiceEdgeRouterPolicy, MapCreateServiceEdgeRouterPolicyToModel(create), ctx)
+	return cmd.Entity.Id, cmd, nil
+}
+
+func batchUpdateServiceEdgeRouterPolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	update := &rest_model.ServiceEdgeRouterPolicyUpdate{}
+	if err := batchUnmarshal(op, update); err != nil {
+		return "", nil, err
+	}
+	policy := MapUpdateServiceEdgeRouterPolicyToModel(op.Id, update)
+	return op.Id, model.NewUpdateEntityCommand[*model.ServiceEdgeRouterPolicy](ae.Managers.ServiceEdgeRouterPolicy, policy, nil, ctx), nil
+}
+
+func batchPatchServiceEdgeRouterPolicy(ae *env.AppEnv, op *BatchOperation, ctx *change.Context) (string, command.Command, error) {
+	patch := &rest_model.ServiceEdgeRouterPolicyPatch{}
+	updatedFields, err := batchPatchFields(op, patch)
+	if err != nil {
+		return "", nil, err
+	}
+	policy := MapPatchServiceEdgeRouterPolicyToModel(op.Id, patch)
+	return op.Id, model.NewUpdateEntityCommand[*model.ServiceEdgeRouterPolicy](ae.Managers.ServiceEdgeRouterPolicy, policy, updatedFields.FilterMaps("tags"), ctx), nil
+}
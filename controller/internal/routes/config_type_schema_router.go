@@ -0,0 +1,181 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/response"
+)
+
+// ConfigTypeSchemasPath is the management API path used to inspect and publish config type schema versions, as
+// /config-type-schemas/<config type id>. Registered config migrations can be re-run with a POST to
+// /config-type-schemas/<config type id>/migrate.
+const ConfigTypeSchemasPath = "/config-type-schemas"
+
+func init() {
+	r := NewConfigTypeSchemaRouter()
+	env.AddRouter(r)
+}
+
+// ConfigTypeSchemaPublishBody is the body accepted when publishing a new schema version. If version isn't set, the
+// version following the current version is used.
+type ConfigTypeSchemaPublishBody struct {
+	Version uint32                 `json:"version"`
+	Schema  map[string]interface{} `json:"schema"`
+}
+
+type ConfigTypeSchemaVersionDetail struct {
+	Version     uint32                 `json:"version"`
+	Current     bool                   `json:"current"`
+	ConfigCount int                    `json:"configCount"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+type ConfigTypeSchemaDetail struct {
+	ConfigTypeId   string                           `json:"configTypeId"`
+	ConfigTypeName string                           `json:"configTypeName"`
+	CurrentVersion uint32                           `json:"currentVersion"`
+	Versions       []*ConfigTypeSchemaVersionDetail `json:"versions"`
+}
+
+type ConfigMigrationResultDetail struct {
+	ConfigTypeId  string            `json:"configTypeId"`
+	SchemaVersion uint32            `json:"schemaVersion"`
+	Migrated      []string          `json:"migrated"`
+	Current       []string          `json:"current"`
+	Pending       []string          `json:"pending"`
+	Failed        map[string]string `json:"failed"`
+}
+
+type ConfigTypeSchemaRouter struct {
+	BasePath string
+}
+
+func NewConfigTypeSchemaRouter() *ConfigTypeSchemaRouter {
+	return &ConfigTypeSchemaRouter{
+		BasePath: ConfigTypeSchemasPath,
+	}
+}
+
+func (r *ConfigTypeSchemaRouter) Register(ae *env.AppEnv) {
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.handle, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	})
+
+	ae.AddManagementApiHandler(r.BasePath+"/", handler)
+}
+
+func (r *ConfigTypeSchemaRouter) handle(ae *env.AppEnv, rc *response.RequestContext) {
+	_, subPath, _ := strings.Cut(rc.Request.URL.Path, r.BasePath)
+	id, action, _ := strings.Cut(strings.Trim(subPath, "/"), "/")
+
+	if id == "" || (action != "" && action != "migrate") {
+		rc.RespondWithApiError(errorz.NewNotFound())
+		return
+	}
+
+	rc.SetEntityId(id)
+
+	switch {
+	case action == "" && rc.Request.Method == http.MethodGet:
+		r.Detail(ae, rc, id)
+	case action == "" && rc.Request.Method == http.MethodPost:
+		r.Publish(ae, rc, id)
+	case action == "migrate" && rc.Request.Method == http.MethodPost:
+		r.Migrate(ae, rc, id)
+	default:
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+	}
+}
+
+func (r *ConfigTypeSchemaRouter) Detail(ae *env.AppEnv, rc *response.RequestContext, id string) {
+	configType, err := ae.Managers.ConfigType.Read(id)
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	counts, err := ae.Managers.ConfigType.GetConfigCountsBySchemaVersion(id)
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	result := &ConfigTypeSchemaDetail{
+		ConfigTypeId:   configType.Id,
+		ConfigTypeName: configType.Name,
+		CurrentVersion: configType.GetSchemaVersion(),
+	}
+
+	for _, version := range configType.GetSchemaVersions() {
+		result.Versions = append(result.Versions, &ConfigTypeSchemaVersionDetail{
+			Version:     version,
+			Current:     version == configType.GetSchemaVersion(),
+			ConfigCount: counts[version],
+			Schema:      configType.GetSchema(version),
+		})
+	}
+
+	rc.RespondWithOk(result, &rest_model.Meta{})
+}
+
+func (r *ConfigTypeSchemaRouter) Publish(ae *env.AppEnv, rc *response.RequestContext, id string) {
+	body := &ConfigTypeSchemaPublishBody{}
+	if err := json.Unmarshal(rc.Body, body); err != nil {
+		rc.RespondWithCouldNotParseBody(err)
+		return
+	}
+
+	result, err := ae.Managers.ConfigType.PublishSchemaVersion(id, body.Version, body.Schema, rc.NewChangeContext())
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	rc.RespondWithOk(mapConfigMigrationResultToRest(result), &rest_model.Meta{})
+}
+
+func (r *ConfigTypeSchemaRouter) Migrate(ae *env.AppEnv, rc *response.RequestContext, id string) {
+	result, err := ae.Managers.ConfigType.MigrateConfigs(id, rc.NewChangeContext())
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	rc.RespondWithOk(mapConfigMigrationResultToRest(result), &rest_model.Meta{})
+}
+
+func mapConfigMigrationResultToRest(result *model.ConfigMigrationResult) *ConfigMigrationResultDetail {
+	return &ConfigMigrationResultDetail{
+		ConfigTypeId:  result.ConfigTypeId,
+		SchemaVersion: result.SchemaVersion,
+		Migrated:      result.Migrated,
+		Current:       result.Current,
+		Pending:       result.Pending,
+		Failed:        result.Failed,
+	}
+}
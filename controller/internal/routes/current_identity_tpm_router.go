@@ -0,0 +1,293 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/foundation/v2/errorz"
+	nfpem "github.com/openziti/foundation/v2/pem"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/response"
+	"github.com/openziti/ziti/controller/tpm"
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TpmAttestationPath is the client API path used to submit TPM attestation evidence
+	TpmAttestationPath = "/" + EntityNameCurrentIdentity + "/tpm-attestation"
+	// TpmAttestationChallengePath is the client API path used to request a TPM attestation challenge
+	TpmAttestationChallengePath = TpmAttestationPath + "/challenge"
+
+	tpmSecretSize = 32
+	tpmNonceSize  = 32
+)
+
+func init() {
+	r := NewCurrentIdentityTpmRouter()
+	env.AddRouter(r)
+}
+
+// TpmChallengeBody is the body accepted when requesting a TPM attestation challenge. EkCertificate holds the PEM
+// encoded endorsement key certificate, optionally followed by the intermediate certificates needed to verify it.
+// AkPublic is the TPMT_PUBLIC of the attestation key.
+type TpmChallengeBody struct {
+	EkCertificate string `json:"ekCertificate"`
+	AkPublic      []byte `json:"akPublic"`
+}
+
+// TpmChallengeDetail is returned for a TPM attestation challenge. CredentialBlob and EncryptedSecret are passed to
+// TPM2_ActivateCredential to recover the secret, and Nonce must be used as the qualifying data of the quote.
+type TpmChallengeDetail struct {
+	CredentialBlob  []byte    `json:"credentialBlob"`
+	EncryptedSecret []byte    `json:"encryptedSecret"`
+	Nonce           []byte    `json:"nonce"`
+	ExpiresAt       time.Time `json:"expiresAt"`
+}
+
+// TpmAttestationBody is the body accepted when submitting TPM attestation evidence. Secret is the activated
+// credential, Quote and Signature are the TPMS_ATTEST and TPMT_SIGNATURE returned by TPM2_Quote, and Pcrs holds the
+// hex encoded SHA-256 bank values of the quoted PCRs, keyed by PCR index.
+type TpmAttestationBody struct {
+	Secret    []byte         `json:"secret"`
+	Quote     []byte         `json:"quote"`
+	Signature []byte         `json:"signature"`
+	Pcrs      map[int]string `json:"pcrs"`
+}
+
+type tpmChallenge struct {
+	secret        []byte
+	nonce         []byte
+	ak            *tpm.Public
+	ekFingerprint string
+	expiresAt     time.Time
+}
+
+// CurrentIdentityTpmRouter lets clients attest to the TPM they are running on. A client first requests a challenge
+// with its endorsement key certificate and attestation key. The controller checks the certificate against the
+// configured roots and wraps a secret which only that TPM can recover, and only while it holds the attestation key.
+// The client then returns the secret along with a quote signed by the attestation key. A successful attestation is
+// recorded as posture data for the API session, where TPM attestation posture checks evaluate it.
+//
+// Challenges are held in memory, so both requests must be made to the same controller.
+type CurrentIdentityTpmRouter struct {
+	challenges cmap.ConcurrentMap[string, *tpmChallenge]
+}
+
+func NewCurrentIdentityTpmRouter() *CurrentIdentityTpmRouter {
+	return &CurrentIdentityTpmRouter{
+		challenges: cmap.New[*tpmChallenge](),
+	}
+}
+
+func (r *CurrentIdentityTpmRouter) Register(ae *env.AppEnv) {
+	ae.AddClientApiHandler(TpmAttestationChallengePath, r.newHandler(ae, r.createChallenge))
+	ae.AddClientApiHandler(TpmAttestationPath, r.newHandler(ae, r.attest))
+
+	if ae.GetConfig().Edge.TpmAttestation.Enabled() {
+		r.startChallengeCleanup(ae.GetConfig().Edge.TpmAttestation.ChallengeTimeout, ae.GetCloseNotifyChannel())
+	}
+}
+
+func (r *CurrentIdentityTpmRouter) newHandler(ae *env.AppEnv, f func(ae *env.AppEnv, rc *response.RequestContext)) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(func(ae *env.AppEnv, rc *response.RequestContext) {
+			if rc.Request.Method != http.MethodPost {
+				rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+				return
+			}
+
+			if !ae.GetConfig().Edge.TpmAttestation.Enabled() {
+				rc.RespondWithApiError(apierror.NewTpmAttestationDisabled())
+				return
+			}
+
+			f(ae, rc)
+		}, request, "", "", permissions.IsAuthenticated()).WriteResponse(writer, runtime.JSONProducer())
+	})
+}
+
+func (r *CurrentIdentityTpmRouter) startChallengeCleanup(timeout time.Duration, closeNotify <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(timeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				now := time.Now()
+				var expired []string
+				r.challenges.IterCb(func(apiSessionId string, challenge *tpmChallenge) {
+					if now.After(challenge.expiresAt) {
+						expired = append(expired, apiSessionId)
+					}
+				})
+
+				for _, apiSessionId := range expired {
+					r.challenges.RemoveCb(apiSessionId, func(_ string, challenge *tpmChallenge, exists bool) bool {
+						return exists && now.After(challenge.expiresAt)
+					})
+				}
+			case <-closeNotify:
+				return
+			}
+		}
+	}()
+}
+
+func (r *CurrentIdentityTpmRouter) createChallenge(ae *env.AppEnv, rc *response.RequestContext) {
+	body := &TpmChallengeBody{}
+	if err := json.Unmarshal(rc.Body, body); err != nil {
+		rc.RespondWithApiError(apierror.NewCouldNotParseBody(err))
+		return
+	}
+
+	certs := nfpem.PemBytesToCertificates([]byte(body.EkCertificate))
+	if len(certs) == 0 {
+		rc.RespondWithError(errorz.NewFieldError("a PEM encoded endorsement key certificate is required", "ekCertificate", nil))
+		return
+	}
+
+	ekCert := certs[0]
+	ek, ok := ekCert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		rc.RespondWithApiError(apierror.NewTpmAttestationFailed(errors.Errorf("only RSA endorsement keys are supported, got %T", ekCert.PublicKey)))
+		return
+	}
+
+	attestationConfig := &ae.GetConfig().Edge.TpmAttestation
+
+	intermediates := attestationConfig.EkIntermediates.Clone()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if err := tpm.VerifyEkCertificate(ekCert, intermediates, attestationConfig.EkRoots); err != nil {
+		rc.RespondWithApiError(apierror.NewTpmAttestationFailed(err))
+		return
+	}
+
+	ak, err := tpm.ParsePublic(body.AkPublic)
+	if err != nil {
+		rc.RespondWithError(errorz.NewFieldError(err.Error(), "akPublic", nil))
+		return
+	}
+
+	if err = ak.CheckAttestationKey(); err != nil {
+		rc.RespondWithApiError(apierror.NewTpmAttestationFailed(err))
+		return
+	}
+
+	akName, err := ak.Name()
+	if err != nil {
+		rc.RespondWithApiError(apierror.NewTpmAttestationFailed(err))
+		return
+	}
+
+	challenge := &tpmChallenge{
+		secret:        make([]byte, tpmSecretSize),
+		nonce:         make([]byte, tpmNonceSize),
+		ak:            ak,
+		ekFingerprint: ae.GetFingerprintGenerator().FromCert(ekCert),
+		expiresAt:     time.Now().Add(attestationConfig.ChallengeTimeout),
+	}
+
+	if _, err = rand.Read(challenge.secret); err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	if _, err = rand.Read(challenge.nonce); err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	credentialBlob, encryptedSecret, err := tpm.MakeCredential(ek, akName, challenge.secret)
+	if err != nil {
+		rc.RespondWithApiError(apierror.NewTpmAttestationFailed(err))
+		return
+	}
+
+	r.challenges.Set(rc.ApiSession.Id, challenge)
+
+	rc.RespondWithOk(&TpmChallengeDetail{
+		CredentialBlob:  credentialBlob,
+		EncryptedSecret: encryptedSecret,
+		Nonce:           challenge.nonce,
+		ExpiresAt:       challenge.expiresAt,
+	}, nil)
+}
+
+func (r *CurrentIdentityTpmRouter) attest(ae *env.AppEnv, rc *response.RequestContext) {
+	body := &TpmAttestationBody{}
+	if err := json.Unmarshal(rc.Body, body); err != nil {
+		rc.RespondWithApiError(apierror.NewCouldNotParseBody(err))
+		return
+	}
+
+	// a challenge may only be answered once, whether or not the attestation succeeds
+	challenge, found := r.challenges.Pop(rc.ApiSession.Id)
+	if !found || time.Now().After(challenge.expiresAt) {
+		rc.RespondWithApiError(apierror.NewTpmChallengeNotFound())
+		return
+	}
+
+	if subtle.ConstantTimeCompare(body.Secret, challenge.secret) != 1 {
+		rc.RespondWithApiError(apierror.NewTpmAttestationFailed(errors.New("the activated credential secret doesn't match")))
+		return
+	}
+
+	pcrValues := map[int][]byte{}
+	for idx, value := range body.Pcrs {
+		decoded, err := hex.DecodeString(value)
+		if err != nil {
+			rc.RespondWithError(errorz.NewFieldError("pcr values must be hex encoded", "pcrs", value))
+			return
+		}
+		pcrValues[idx] = decoded
+	}
+
+	if _, err := tpm.VerifyQuote(challenge.ak, body.Quote, body.Signature, challenge.nonce, pcrValues); err != nil {
+		rc.RespondWithApiError(apierror.NewTpmAttestationFailed(err))
+		return
+	}
+
+	pcrs := map[int]string{}
+	for idx, value := range pcrValues {
+		pcrs[idx] = hex.EncodeToString(value)
+	}
+
+	ae.Managers.PostureResponse.SetTpmPosture(rc.Identity.Id, rc.ApiSession.Id, challenge.ekFingerprint, pcrs)
+
+	pfxlog.Logger().WithField("identityId", rc.Identity.Id).
+		WithField("apiSessionId", rc.ApiSession.Id).
+		WithField("ekFingerprint", challenge.ekFingerprint).
+		Info("tpm attestation succeeded")
+
+	rc.RespondWithEmptyOk()
+}
@@ -86,6 +86,13 @@ func (r *EdgeRouterPolicyRouter) Detail(ae *env.AppEnv, rc *response.RequestCont
 }
 
 func (r *EdgeRouterPolicyRouter) Create(ae *env.AppEnv, rc *response.RequestContext, params edge_router_policy.CreateEdgeRouterPolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyDryRun(rc, func() (*model.PolicyAccessDelta, error) {
+			return ae.Managers.EdgeRouterPolicy.DryRunCreate(MapCreateEdgeRouterPolicyToModel(params.Policy), rc.NewChangeContext())
+		})
+		return
+	}
+
 	Create(rc, rc, EdgeRouterPolicyLinkFactory, func() (string, error) {
 		return MapCreate(ae.Managers.EdgeRouterPolicy.Create, MapCreateEdgeRouterPolicyToModel(params.Policy), rc)
 	})
@@ -96,12 +103,26 @@ func (r *EdgeRouterPolicyRouter) Delete(ae *env.AppEnv, rc *response.RequestCont
 }
 
 func (r *EdgeRouterPolicyRouter) Update(ae *env.AppEnv, rc *response.RequestContext, params edge_router_policy.UpdateEdgeRouterPolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyDryRun(rc, func() (*model.PolicyAccessDelta, error) {
+			return ae.Managers.EdgeRouterPolicy.DryRunUpdate(MapUpdateEdgeRouterPolicyToModel(params.ID, params.Policy), nil, rc.NewChangeContext())
+		})
+		return
+	}
+
 	Update(rc, func(id string) error {
 		return ae.Managers.EdgeRouterPolicy.Update(MapUpdateEdgeRouterPolicyToModel(params.ID, params.Policy), nil, rc.NewChangeContext())
 	})
 }
 
 func (r *EdgeRouterPolicyRouter) Patch(ae *env.AppEnv, rc *response.RequestContext, params edge_router_policy.PatchEdgeRouterPolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyPatchDryRun(rc, func(fields fields.UpdatedFields) (*model.PolicyAccessDelta, error) {
+			return ae.Managers.EdgeRouterPolicy.DryRunUpdate(MapPatchEdgeRouterPolicyToModel(params.ID, params.Policy), fields.FilterMaps("tags"), rc.NewChangeContext())
+		})
+		return
+	}
+
 	Patch(rc, func(id string, fields fields.UpdatedFields) error {
 		return ae.Managers.EdgeRouterPolicy.Update(MapPatchEdgeRouterPolicyToModel(params.ID, params.Policy), fields.FilterMaps("tags"), rc.NewChangeContext())
 	})
@@ -0,0 +1,176 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/network"
+	"github.com/openziti/ziti/controller/response"
+)
+
+// EdgeRouterScalingHintsPath is the management API path used to read the autoscaling hints built from the saturation
+// signals reported by edge routers. /edge-router-scaling-hints lists all edge routers along with a fleet summary,
+// and /edge-router-scaling-hints/<edge router id> returns a single edge router.
+const EdgeRouterScalingHintsPath = "/edge-router-scaling-hints"
+
+func init() {
+	r := NewEdgeRouterScalingHintRouter()
+	env.AddRouter(r)
+}
+
+type EdgeRouterScalingHintDetail struct {
+	EdgeRouterId   string             `json:"edgeRouterId"`
+	EdgeRouterName string             `json:"edgeRouterName"`
+	Online         bool               `json:"online"`
+	Stale          bool               `json:"stale"`
+	ReportedAt     time.Time          `json:"reportedAt"`
+	Saturation     float64            `json:"saturation"`
+	State          string             `json:"state"`
+	Recommendation string             `json:"recommendation"`
+	Signals        map[string]float64 `json:"signals"`
+	Raw            map[string]int64   `json:"raw"`
+}
+
+type EdgeRouterScalingSummaryDetail struct {
+	RouterCount        int     `json:"routerCount"`
+	AverageSaturation  float64 `json:"averageSaturation"`
+	MaxSaturation      float64 `json:"maxSaturation"`
+	ScaleUpCount       int     `json:"scaleUpCount"`
+	ScaleDownCount     int     `json:"scaleDownCount"`
+	Recommendation     string  `json:"recommendation"`
+	ScaleUpThreshold   float64 `json:"scaleUpThreshold"`
+	ScaleDownThreshold float64 `json:"scaleDownThreshold"`
+}
+
+type EdgeRouterScalingHintsDetail struct {
+	Summary *EdgeRouterScalingSummaryDetail `json:"summary"`
+	Routers []*EdgeRouterScalingHintDetail  `json:"routers"`
+}
+
+type EdgeRouterScalingHintRouter struct {
+	BasePath string
+}
+
+func NewEdgeRouterScalingHintRouter() *EdgeRouterScalingHintRouter {
+	return &EdgeRouterScalingHintRouter{
+		BasePath: EdgeRouterScalingHintsPath,
+	}
+}
+
+func (r *EdgeRouterScalingHintRouter) Register(ae *env.AppEnv) {
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.handle, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	})
+
+	ae.AddManagementApiHandler(r.BasePath, handler)
+	ae.AddManagementApiHandler(r.BasePath+"/", handler)
+}
+
+func (r *EdgeRouterScalingHintRouter) handle(ae *env.AppEnv, rc *response.RequestContext) {
+	if rc.Request.Method != http.MethodGet {
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+		return
+	}
+
+	_, subPath, _ := strings.Cut(rc.Request.URL.Path, r.BasePath)
+	id := strings.Trim(subPath, "/")
+
+	if strings.Contains(id, "/") {
+		rc.RespondWithApiError(errorz.NewNotFound())
+		return
+	}
+
+	if id == "" {
+		r.List(ae, rc)
+		return
+	}
+
+	rc.SetEntityId(id)
+	r.Detail(ae, rc, id)
+}
+
+func (r *EdgeRouterScalingHintRouter) List(ae *env.AppEnv, rc *response.RequestContext) {
+	n := ae.GetHostController().GetNetwork()
+	hints, summary := n.GetRouterScalingHints()
+
+	options := n.GetOptions().RouterScaling
+	result := &EdgeRouterScalingHintsDetail{
+		Summary: &EdgeRouterScalingSummaryDetail{
+			RouterCount:        summary.RouterCount,
+			AverageSaturation:  summary.AverageSaturation,
+			MaxSaturation:      summary.MaxSaturation,
+			ScaleUpCount:       summary.ScaleUpCount,
+			ScaleDownCount:     summary.ScaleDownCount,
+			Recommendation:     summary.Recommendation,
+			ScaleUpThreshold:   options.ScaleUpThreshold,
+			ScaleDownThreshold: options.ScaleDownThreshold,
+		},
+		Routers: []*EdgeRouterScalingHintDetail{},
+	}
+
+	for _, hint := range hints {
+		edgeRouter, err := ae.Managers.EdgeRouter.Read(hint.RouterId)
+		if err != nil {
+			// fabric only routers which happen to report edge metrics aren't included
+			continue
+		}
+		result.Routers = append(result.Routers, mapEdgeRouterScalingHintToRest(n, edgeRouter.Name, hint))
+	}
+
+	rc.RespondWithOk(result, &rest_model.Meta{})
+}
+
+func (r *EdgeRouterScalingHintRouter) Detail(ae *env.AppEnv, rc *response.RequestContext, id string) {
+	edgeRouter, err := ae.Managers.EdgeRouter.Read(id)
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	n := ae.GetHostController().GetNetwork()
+	hint, found := n.GetRouterScalingHint(id)
+	if !found {
+		rc.RespondWithApiError(errorz.NewNotFound())
+		return
+	}
+
+	rc.RespondWithOk(mapEdgeRouterScalingHintToRest(n, edgeRouter.Name, hint), &rest_model.Meta{})
+}
+
+func mapEdgeRouterScalingHintToRest(n *network.Network, name string, hint *network.RouterScalingHint) *EdgeRouterScalingHintDetail {
+	return &EdgeRouterScalingHintDetail{
+		EdgeRouterId:   hint.RouterId,
+		EdgeRouterName: name,
+		Online:         n.GetConnectedRouter(hint.RouterId) != nil,
+		Stale:          hint.Stale,
+		ReportedAt:     hint.ReportedAt,
+		Saturation:     hint.Saturation,
+		State:          hint.State,
+		Recommendation: hint.Recommendation,
+		Signals:        hint.Signals,
+		Raw:            hint.Raw,
+	}
+}
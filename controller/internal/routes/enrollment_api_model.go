@@ -19,7 +19,9 @@ package routes
 import (
 	"github.com/go-openapi/strfmt"
 	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/util"
 	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
 	"github.com/openziti/ziti/controller/model"
 	"github.com/openziti/ziti/controller/response"
 	"github.com/openziti/foundation/v2/stringz"
@@ -29,8 +31,19 @@ const EntityNameEnrollment = "enrollments"
 
 var EnrollmentLinkFactory = NewBasicLinkFactory(EntityNameEnrollment)
 
-func MapEnrollmentToRestEntity(ae *env.AppEnv, _ *response.RequestContext, enrollment *model.Enrollment) (interface{}, error) {
-	return MapEnrollmentToRestModel(ae, enrollment)
+func MapEnrollmentToRestEntity(ae *env.AppEnv, rc *response.RequestContext, enrollment *model.Enrollment) (interface{}, error) {
+	result, err := MapEnrollmentToRestModel(ae, enrollment)
+	if err == nil && hideEnrollmentSecrets(rc) {
+		result.JWT = ""
+		result.Token = util.Ptr("")
+	}
+	return result, err
+}
+
+// hideEnrollmentSecrets returns true if enrollment JWTs and tokens should not be shown to the requester. Read only
+// admins may view identities, routers and enrollments, but must not be able to complete pending enrollments.
+func hideEnrollmentSecrets(rc *response.RequestContext) bool {
+	return rc != nil && !permissions.IsAdmin().IsAllowed(rc.ActivePermissions...)
 }
 
 func MapEnrollmentToRestModel(ae *env.AppEnv, enrollment *model.Enrollment) (*rest_model.EnrollmentDetail, error) {
@@ -205,8 +205,23 @@ func MapPatchIdentityToModel(id string, identity *rest_model.IdentityPatch, iden
 	return ret
 }
 
-func MapIdentityToRestEntity(ae *env.AppEnv, _ *response.RequestContext, entity *model.Identity) (interface{}, error) {
-	return MapIdentityToRestModel(ae, entity)
+func MapIdentityToRestEntity(ae *env.AppEnv, rc *response.RequestContext, entity *model.Identity) (interface{}, error) {
+	result, err := MapIdentityToRestModel(ae, entity)
+	if err == nil && result.Enrollment != nil && hideEnrollmentSecrets(rc) {
+		if result.Enrollment.Ott != nil {
+			result.Enrollment.Ott.JWT = ""
+			result.Enrollment.Ott.Token = ""
+		}
+		if result.Enrollment.Ottca != nil {
+			result.Enrollment.Ottca.JWT = ""
+			result.Enrollment.Ottca.Token = ""
+		}
+		if result.Enrollment.Updb != nil {
+			result.Enrollment.Updb.JWT = ""
+			result.Enrollment.Updb.Token = ""
+		}
+	}
+	return result, err
 }
 
 func MapIdentityToRestModel(ae *env.AppEnv, identity *model.Identity) (*rest_model.IdentityDetail, error) {
@@ -0,0 +1,221 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/response"
+)
+
+const (
+	// PolicyAdvisorPath is the management API path which explains why an identity can't dial or bind a service
+	PolicyAdvisorPath = "/policy-advisor"
+)
+
+func init() {
+	r := NewPolicyAdvisorRouter()
+	env.AddRouter(r)
+}
+
+// PolicyAdvisorDiagnosis is the response of the policy advisor endpoint. Accessible is false if any finding has
+// error severity.
+type PolicyAdvisorDiagnosis struct {
+	Identity            *rest_model.EntityRef         `json:"identity"`
+	Service             *rest_model.EntityRef         `json:"service"`
+	Type                string                        `json:"type"`
+	Accessible          bool                          `json:"accessible"`
+	IsDialAllowed       bool                          `json:"isDialAllowed"`
+	IsBindAllowed       bool                          `json:"isBindAllowed"`
+	IdentityRouterCount int                           `json:"identityRouterCount"`
+	ServiceRouterCount  int                           `json:"serviceRouterCount"`
+	CommonRouters       []*rest_model.RouterEntityRef `json:"commonRouters"`
+	Findings            []*PolicyAdvisorFinding       `json:"findings"`
+}
+
+type PolicyAdvisorFinding struct {
+	Code            string                      `json:"code"`
+	Severity        string                      `json:"severity"`
+	Message         string                      `json:"message"`
+	PolicyIds       []string                    `json:"policyIds,omitempty"`
+	PostureFailures []*rest_model.PolicyFailure `json:"postureFailures,omitempty"`
+	Suggestions     []*PolicyAdvisorSuggestion  `json:"suggestions"`
+}
+
+// PolicyAdvisorSuggestion is a batch operation which would resolve a finding. Suggestions can be reviewed and then
+// applied by posting them to the batch endpoint.
+type PolicyAdvisorSuggestion struct {
+	Description string `json:"description"`
+	BatchOperation
+}
+
+type PolicyAdvisorRouter struct{}
+
+func NewPolicyAdvisorRouter() *PolicyAdvisorRouter {
+	return &PolicyAdvisorRouter{}
+}
+
+func (r *PolicyAdvisorRouter) Register(ae *env.AppEnv) {
+	ae.AddManagementApiHandler(PolicyAdvisorPath, http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.Diagnose, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	}))
+}
+
+// Diagnose handles GET <base>/policy-advisor?identityId=<id>&serviceId=<id>&type=<dial|bind>. The type defaults to
+// dial.
+func (r *PolicyAdvisorRouter) Diagnose(ae *env.AppEnv, rc *response.RequestContext) {
+	if rc.Request.Method != http.MethodGet {
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+		return
+	}
+
+	params := rc.Request.URL.Query()
+
+	identityId := params.Get("identityId")
+	if identityId == "" {
+		rc.RespondWithApiError(errorz.NewFieldApiError(errorz.NewFieldError("identityId is required", "identityId", nil)))
+		return
+	}
+
+	serviceId := params.Get("serviceId")
+	if serviceId == "" {
+		rc.RespondWithApiError(errorz.NewFieldApiError(errorz.NewFieldError("serviceId is required", "serviceId", nil)))
+		return
+	}
+
+	policyType := db.PolicyTypeDialName
+	if value := params.Get("type"); strings.EqualFold(value, db.PolicyTypeBindName) {
+		policyType = db.PolicyTypeBindName
+	} else if value != "" && !strings.EqualFold(value, db.PolicyTypeDialName) {
+		rc.RespondWithApiError(errorz.NewFieldApiError(errorz.NewFieldError("type must be dial or bind", "type", value)))
+		return
+	}
+
+	result, err := ae.Managers.PolicyAdvisor.DiagnoseServiceAccess(identityId, serviceId, policyType)
+	if err != nil {
+		if boltz.IsErrNotFoundErr(err) {
+			rc.RespondWithNotFoundWithCause(err)
+			return
+		}
+		rc.RespondWithError(err)
+		return
+	}
+
+	rc.RespondWithOk(MapAdvisorDiagnosisToRestEntity(result), &rest_model.Meta{})
+}
+
+func MapAdvisorDiagnosisToRestEntity(entity *model.AdvisorDiagnosis) *PolicyAdvisorDiagnosis {
+	result := &PolicyAdvisorDiagnosis{
+		Identity:            ToEntityRef(entity.Identity.Name, entity.Identity, IdentityLinkFactory),
+		Service:             ToEntityRef(entity.Service.Name, entity.Service, ServiceLinkFactory),
+		Type:                entity.PolicyType,
+		Accessible:          entity.IsAccessible(),
+		IsDialAllowed:       entity.IsDialAllowed,
+		IsBindAllowed:       entity.IsBindAllowed,
+		IdentityRouterCount: entity.IdentityRouterCount,
+		ServiceRouterCount:  entity.ServiceRouterCount,
+		CommonRouters:       []*rest_model.RouterEntityRef{},
+		Findings:            []*PolicyAdvisorFinding{},
+	}
+
+	for _, router := range entity.CommonRouters {
+		result.CommonRouters = append(result.CommonRouters, &rest_model.RouterEntityRef{
+			EntityRef: *ToEntityRef(router.Router.Name, router.Router, EdgeRouterLinkFactory),
+			IsOnline:  &router.IsOnline,
+		})
+	}
+
+	for _, finding := range entity.Findings {
+		restFinding := &PolicyAdvisorFinding{
+			Code:        finding.Code,
+			Severity:    finding.Severity,
+			Message:     finding.Message,
+			PolicyIds:   finding.PolicyIds,
+			Suggestions: []*PolicyAdvisorSuggestion{},
+		}
+
+		for _, failure := range finding.PostureFailures {
+			restFinding.PostureFailures = append(restFinding.PostureFailures, MapPosturePolicyFailureToRestModel(failure))
+		}
+
+		for _, suggestion := range finding.Suggestions {
+			restFinding.Suggestions = append(restFinding.Suggestions, MapAdvisorSuggestionToBatchOperation(suggestion))
+		}
+
+		result.Findings = append(result.Findings, restFinding)
+	}
+
+	return result
+}
+
+// MapAdvisorSuggestionToBatchOperation builds the batch operation for a suggestion. Data holds the same body which
+// would be sent to the policy endpoint.
+func MapAdvisorSuggestionToBatchOperation(suggestion *model.AdvisorSuggestion) *PolicyAdvisorSuggestion {
+	data := map[string]any{}
+	if suggestion.Name != "" {
+		data["name"] = suggestion.Name
+	}
+	if suggestion.PolicyType != "" {
+		data["type"] = suggestion.PolicyType
+	}
+	if suggestion.Semantic != "" {
+		data["semantic"] = suggestion.Semantic
+	}
+	if suggestion.IdentityRoles != nil {
+		data["identityRoles"] = suggestion.IdentityRoles
+	}
+	if suggestion.ServiceRoles != nil {
+		data["serviceRoles"] = suggestion.ServiceRoles
+	}
+	if suggestion.EdgeRouterRoles != nil {
+		data["edgeRouterRoles"] = suggestion.EdgeRouterRoles
+	}
+
+	var entityType string
+	switch suggestion.EntityType {
+	case db.EntityTypeServicePolicies:
+		entityType = EntityNameServicePolicy
+	case db.EntityTypeEdgeRouterPolicies:
+		entityType = EntityNameEdgeRouterPolicy
+	case db.EntityTypeServiceEdgeRouterPolicies:
+		entityType = EntityNameServiceEdgeRouterPolicy
+	}
+
+	// a map of strings and string slices always marshals
+	encoded, _ := json.Marshal(data)
+
+	return &PolicyAdvisorSuggestion{
+		Description: suggestion.Description,
+		BatchOperation: BatchOperation{
+			Op:         suggestion.Op,
+			EntityType: entityType,
+			Id:         suggestion.PolicyId,
+			Data:       encoded,
+		},
+	}
+}
@@ -0,0 +1,95 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"strconv"
+
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/ziti/controller/api"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/response"
+)
+
+// PolicyDryRunParam is the query parameter which, when set to true on a service policy, edge router policy or service
+// edge router policy create, update or patch, returns the access changes the request would cause instead of
+// applying it
+const PolicyDryRunParam = "dryRun"
+
+type PolicyAccessChangeDetail struct {
+	PolicyType string `json:"policyType,omitempty"`
+	SourceType string `json:"sourceType"`
+	SourceId   string `json:"sourceId"`
+	SourceName string `json:"sourceName"`
+	TargetType string `json:"targetType"`
+	TargetId   string `json:"targetId"`
+	TargetName string `json:"targetName"`
+}
+
+type PolicyDryRunDetail struct {
+	Gained []*PolicyAccessChangeDetail `json:"gained"`
+	Lost   []*PolicyAccessChangeDetail `json:"lost"`
+}
+
+func IsPolicyDryRun(rc *response.RequestContext) bool {
+	dryRun, _ := strconv.ParseBool(rc.Request.URL.Query().Get(PolicyDryRunParam))
+	return dryRun
+}
+
+func PolicyDryRun(rc *response.RequestContext, dryRunF func() (*model.PolicyAccessDelta, error)) {
+	delta, err := dryRunF()
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+	rc.RespondWithOk(mapPolicyAccessDeltaToRest(delta), &rest_model.Meta{})
+}
+
+func PolicyPatchDryRun(rc *response.RequestContext, dryRunF func(fields fields.UpdatedFields) (*model.PolicyAccessDelta, error)) {
+	jsonFields, err := api.GetFields(rc.Body)
+	if err != nil {
+		rc.RespondWithCouldNotParseBody(err)
+		return
+	}
+	PolicyDryRun(rc, func() (*model.PolicyAccessDelta, error) {
+		return dryRunF(jsonFields)
+	})
+}
+
+func mapPolicyAccessDeltaToRest(delta *model.PolicyAccessDelta) *PolicyDryRunDetail {
+	return &PolicyDryRunDetail{
+		Gained: mapPolicyAccessChangesToRest(delta.Gained),
+		Lost:   mapPolicyAccessChangesToRest(delta.Lost),
+	}
+}
+
+func mapPolicyAccessChangesToRest(changes []*model.PolicyAccessChange) []*PolicyAccessChangeDetail {
+	result := make([]*PolicyAccessChangeDetail, 0, len(changes))
+	for _, change := range changes {
+		result = append(result, &PolicyAccessChangeDetail{
+			PolicyType: change.PolicyType,
+			SourceType: change.SourceType,
+			SourceId:   change.SourceId,
+			SourceName: change.SourceName,
+			TargetType: change.TargetType,
+			TargetId:   change.TargetId,
+			TargetName: change.TargetName,
+		})
+	}
+	return result
+}
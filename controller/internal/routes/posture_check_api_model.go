@@ -369,6 +369,41 @@ func MapPostureCheckToRestModel(ae *env.AppEnv, rc *response.RequestContext, i *
 			detail.Processes = append(detail.Processes, newProc)
 		}
 
+		ret = detail
+		setBaseEntityDetailsOnPostureCheck(ret, i)
+	case *model.PostureCheckProcessHash:
+		semantic := rest_model.SemanticAnyOf
+		detail := &PostureCheckProcessHashDetail{
+			PostureCheckProcessMultiDetail: rest_model.PostureCheckProcessMultiDetail{
+				Processes: []*rest_model.ProcessMulti{},
+				Semantic:  &semantic,
+			},
+		}
+
+		for _, process := range subType.Processes {
+			osType := rest_model.OsType(process.OsType)
+			detail.Processes = append(detail.Processes, &rest_model.ProcessMulti{
+				Hashes:             process.Hashes,
+				OsType:             &osType,
+				Path:               &process.Path,
+				SignerFingerprints: []string{},
+			})
+		}
+
+		ret = detail
+		setBaseEntityDetailsOnPostureCheck(ret, i)
+	case *model.PostureCheckTpmAttestation:
+		detail := &PostureCheckTpmAttestationDetail{
+			Pcrs: []*PostureCheckTpmPcrRef{},
+		}
+
+		for _, pcr := range subType.Pcrs {
+			detail.Pcrs = append(detail.Pcrs, &PostureCheckTpmPcrRef{
+				Index:  pcr.Index,
+				Values: pcr.Values,
+			})
+		}
+
 		ret = detail
 		setBaseEntityDetailsOnPostureCheck(ret, i)
 	}
@@ -0,0 +1,192 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/models"
+	"github.com/openziti/ziti/controller/response"
+)
+
+// ProcessHashPostureChecksPath is the management API path used to create and update PROCESS_HASH posture checks.
+// The generated posture check endpoints don't know about this type, but can list, read and delete these checks.
+const ProcessHashPostureChecksPath = "/process-hash-posture-checks"
+
+func init() {
+	r := NewPostureCheckProcessHashRouter()
+	env.AddRouter(r)
+}
+
+// PostureCheckProcessHashBody is the body accepted when creating or updating a PROCESS_HASH posture check
+type PostureCheckProcessHashBody struct {
+	Name           string                        `json:"name"`
+	RoleAttributes []string                      `json:"roleAttributes"`
+	Tags           map[string]interface{}        `json:"tags"`
+	Processes      []*PostureCheckProcessHashRef `json:"processes"`
+}
+
+type PostureCheckProcessHashRef struct {
+	OsType string   `json:"osType"`
+	Path   string   `json:"path"`
+	Hashes []string `json:"hashes"`
+}
+
+// PostureCheckProcessHashDetail renders a PROCESS_HASH posture check using the process multi detail, with the
+// type id replaced
+type PostureCheckProcessHashDetail struct {
+	rest_model.PostureCheckProcessMultiDetail
+}
+
+func (m *PostureCheckProcessHashDetail) TypeID() string {
+	return model.PostureCheckTypeProcessHash
+}
+
+func (m PostureCheckProcessHashDetail) MarshalJSON() ([]byte, error) {
+	data, err := m.PostureCheckProcessMultiDetail.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	if fields["typeId"], err = json.Marshal(model.PostureCheckTypeProcessHash); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+type PostureCheckProcessHashRouter struct {
+	BasePath string
+}
+
+func NewPostureCheckProcessHashRouter() *PostureCheckProcessHashRouter {
+	return &PostureCheckProcessHashRouter{
+		BasePath: ProcessHashPostureChecksPath,
+	}
+}
+
+func (r *PostureCheckProcessHashRouter) Register(ae *env.AppEnv) {
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.handle, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	})
+
+	ae.AddManagementApiHandler(r.BasePath, handler)
+	ae.AddManagementApiHandler(r.BasePath+"/", handler)
+}
+
+func (r *PostureCheckProcessHashRouter) handle(ae *env.AppEnv, rc *response.RequestContext) {
+	_, subPath, _ := strings.Cut(rc.Request.URL.Path, r.BasePath)
+	id := strings.Trim(subPath, "/")
+
+	switch {
+	case id == "" && rc.Request.Method == http.MethodPost:
+		r.Create(ae, rc)
+	case id != "" && !strings.Contains(id, "/") && rc.Request.Method == http.MethodPut:
+		rc.SetEntityId(id)
+		r.Update(ae, rc)
+	default:
+		rc.RespondWithApiError(errorz.NewNotFound())
+	}
+}
+
+func (r *PostureCheckProcessHashRouter) Create(ae *env.AppEnv, rc *response.RequestContext) {
+	Create(rc, rc, PostureCheckLinkFactory, func() (string, error) {
+		check, err := mapProcessHashPostureCheckToModel("", rc.Body)
+		if err != nil {
+			return "", err
+		}
+		return MapCreate(ae.Managers.PostureCheck.Create, check, rc)
+	})
+}
+
+func (r *PostureCheckProcessHashRouter) Update(ae *env.AppEnv, rc *response.RequestContext) {
+	Update(rc, func(id string) error {
+		existing, err := ae.Managers.PostureCheck.Read(id)
+		if err != nil {
+			return err
+		}
+
+		if existing.TypeId != model.PostureCheckTypeProcessHash {
+			return errorz.NewFieldError("posture check is not a process hash check", "typeId", existing.TypeId)
+		}
+
+		check, err := mapProcessHashPostureCheckToModel(id, rc.Body)
+		if err != nil {
+			return err
+		}
+		return ae.Managers.PostureCheck.Update(check, nil, rc.NewChangeContext())
+	})
+}
+
+func mapProcessHashPostureCheckToModel(id string, body []byte) (*model.PostureCheck, error) {
+	checkBody := &PostureCheckProcessHashBody{
+		RoleAttributes: []string{},
+		Tags:           map[string]interface{}{},
+	}
+	if err := json.Unmarshal(body, checkBody); err != nil {
+		return nil, apierror.NewCouldNotParseBody(err)
+	}
+
+	if checkBody.Name == "" {
+		return nil, errorz.NewFieldError("name is required", "name", checkBody.Name)
+	}
+
+	subType := &model.PostureCheckProcessHash{
+		PostureCheckId: id,
+	}
+
+	for _, process := range checkBody.Processes {
+		if process == nil {
+			continue
+		}
+		subType.Processes = append(subType.Processes, &model.ProcessHash{
+			OsType: process.OsType,
+			Path:   process.Path,
+			Hashes: process.Hashes,
+		})
+	}
+
+	if err := subType.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &model.PostureCheck{
+		BaseEntity: models.BaseEntity{
+			Id:   id,
+			Tags: checkBody.Tags,
+		},
+		Version:        1,
+		Name:           checkBody.Name,
+		TypeId:         model.PostureCheckTypeProcessHash,
+		RoleAttributes: checkBody.RoleAttributes,
+		SubType:        subType,
+	}, nil
+}
@@ -0,0 +1,198 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/models"
+	"github.com/openziti/ziti/controller/response"
+)
+
+// TpmAttestationPostureChecksPath is the management API path used to create and update TPM_ATTESTATION posture
+// checks. The generated posture check endpoints don't know about this type, but can list, read and delete these
+// checks.
+const TpmAttestationPostureChecksPath = "/tpm-attestation-posture-checks"
+
+func init() {
+	r := NewPostureCheckTpmAttestationRouter()
+	env.AddRouter(r)
+}
+
+// PostureCheckTpmAttestationBody is the body accepted when creating or updating a TPM_ATTESTATION posture check
+type PostureCheckTpmAttestationBody struct {
+	Name           string                   `json:"name"`
+	RoleAttributes []string                 `json:"roleAttributes"`
+	Tags           map[string]interface{}   `json:"tags"`
+	Pcrs           []*PostureCheckTpmPcrRef `json:"pcrs"`
+}
+
+type PostureCheckTpmPcrRef struct {
+	Index  int      `json:"index"`
+	Values []string `json:"values"`
+}
+
+// PostureCheckTpmAttestationDetail renders a TPM_ATTESTATION posture check. It reuses the domain detail for the
+// common posture check fields, replacing the domains with the PCRs and the type id.
+type PostureCheckTpmAttestationDetail struct {
+	rest_model.PostureCheckDomainDetail
+	Pcrs []*PostureCheckTpmPcrRef
+}
+
+func (m *PostureCheckTpmAttestationDetail) TypeID() string {
+	return model.PostureCheckTypeTpm
+}
+
+func (m PostureCheckTpmAttestationDetail) MarshalJSON() ([]byte, error) {
+	data, err := m.PostureCheckDomainDetail.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	delete(fields, "domains")
+
+	if fields["typeId"], err = json.Marshal(model.PostureCheckTypeTpm); err != nil {
+		return nil, err
+	}
+
+	if fields["pcrs"], err = json.Marshal(m.Pcrs); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+type PostureCheckTpmAttestationRouter struct {
+	BasePath string
+}
+
+func NewPostureCheckTpmAttestationRouter() *PostureCheckTpmAttestationRouter {
+	return &PostureCheckTpmAttestationRouter{
+		BasePath: TpmAttestationPostureChecksPath,
+	}
+}
+
+func (r *PostureCheckTpmAttestationRouter) Register(ae *env.AppEnv) {
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.handle, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	})
+
+	ae.AddManagementApiHandler(r.BasePath, handler)
+	ae.AddManagementApiHandler(r.BasePath+"/", handler)
+}
+
+func (r *PostureCheckTpmAttestationRouter) handle(ae *env.AppEnv, rc *response.RequestContext) {
+	_, subPath, _ := strings.Cut(rc.Request.URL.Path, r.BasePath)
+	id := strings.Trim(subPath, "/")
+
+	switch {
+	case id == "" && rc.Request.Method == http.MethodPost:
+		r.Create(ae, rc)
+	case id != "" && !strings.Contains(id, "/") && rc.Request.Method == http.MethodPut:
+		rc.SetEntityId(id)
+		r.Update(ae, rc)
+	default:
+		rc.RespondWithApiError(errorz.NewNotFound())
+	}
+}
+
+func (r *PostureCheckTpmAttestationRouter) Create(ae *env.AppEnv, rc *response.RequestContext) {
+	Create(rc, rc, PostureCheckLinkFactory, func() (string, error) {
+		check, err := mapTpmAttestationPostureCheckToModel("", rc.Body)
+		if err != nil {
+			return "", err
+		}
+		return MapCreate(ae.Managers.PostureCheck.Create, check, rc)
+	})
+}
+
+func (r *PostureCheckTpmAttestationRouter) Update(ae *env.AppEnv, rc *response.RequestContext) {
+	Update(rc, func(id string) error {
+		existing, err := ae.Managers.PostureCheck.Read(id)
+		if err != nil {
+			return err
+		}
+
+		if existing.TypeId != model.PostureCheckTypeTpm {
+			return errorz.NewFieldError("posture check is not a tpm attestation check", "typeId", existing.TypeId)
+		}
+
+		check, err := mapTpmAttestationPostureCheckToModel(id, rc.Body)
+		if err != nil {
+			return err
+		}
+		return ae.Managers.PostureCheck.Update(check, nil, rc.NewChangeContext())
+	})
+}
+
+func mapTpmAttestationPostureCheckToModel(id string, body []byte) (*model.PostureCheck, error) {
+	checkBody := &PostureCheckTpmAttestationBody{
+		RoleAttributes: []string{},
+		Tags:           map[string]interface{}{},
+	}
+	if err := json.Unmarshal(body, checkBody); err != nil {
+		return nil, apierror.NewCouldNotParseBody(err)
+	}
+
+	if checkBody.Name == "" {
+		return nil, errorz.NewFieldError("name is required", "name", checkBody.Name)
+	}
+
+	subType := &model.PostureCheckTpmAttestation{
+		PostureCheckId: id,
+	}
+
+	for _, pcr := range checkBody.Pcrs {
+		if pcr == nil {
+			continue
+		}
+		subType.Pcrs = append(subType.Pcrs, &model.TpmPcr{
+			Index:  pcr.Index,
+			Values: pcr.Values,
+		})
+	}
+
+	if err := subType.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &model.PostureCheck{
+		BaseEntity: models.BaseEntity{
+			Id:   id,
+			Tags: checkBody.Tags,
+		},
+		Version:        1,
+		Name:           checkBody.Name,
+		TypeId:         model.PostureCheckTypeTpm,
+		RoleAttributes: checkBody.RoleAttributes,
+		SubType:        subType,
+	}, nil
+}
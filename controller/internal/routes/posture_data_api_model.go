@@ -353,32 +353,7 @@ func MapPostureDataFailedSessionRequestToRestModel(modelFailedSessionRequests []
 		}
 
 		for _, modelPolicyFailure := range modelFailedSessionRequest.PolicyFailures {
-			policyFailure := &rest_model.PolicyFailure{
-				PolicyID:   modelPolicyFailure.PolicyId,
-				PolicyName: modelPolicyFailure.PolicyName,
-			}
-
-			checks := []rest_model.PostureCheckFailure{}
-
-			for _, pdCheck := range modelPolicyFailure.Checks {
-				switch pdCheck.PostureCheckType {
-				case string(rest_model.PostureCheckTypePROCESS):
-					checks = append(checks, MapPostureCheckFailureProcessToRestModel(pdCheck))
-				case string(rest_model.PostureCheckTypePROCESSMULTI):
-					checks = append(checks, MapPostureCheckFailureProcessMultiToRestModel(pdCheck))
-				case string(rest_model.PostureCheckTypeDOMAIN):
-					checks = append(checks, MapPostureCheckFailureDomainToRestModel(pdCheck))
-				case string(rest_model.PostureCheckTypeMAC):
-					checks = append(checks, MapPostureCheckFailureMacToRestModel(pdCheck))
-				case string(rest_model.PostureCheckTypeOS):
-					checks = append(checks, MapPostureCheckFailureOsToRestModel(pdCheck))
-				case string(rest_model.PostureCheckTypeMFA):
-					checks = append(checks, MapPostureCheckFailureMfaToRestModel(pdCheck))
-				}
-				policyFailure.SetChecks(checks)
-			}
-
-			failedSessionRequest.PolicyFailures = append(failedSessionRequest.PolicyFailures, policyFailure)
+			failedSessionRequest.PolicyFailures = append(failedSessionRequest.PolicyFailures, MapPosturePolicyFailureToRestModel(modelPolicyFailure))
 		}
 
 		ret = append(ret, failedSessionRequest)
@@ -387,6 +362,35 @@ func MapPostureDataFailedSessionRequestToRestModel(modelFailedSessionRequests []
 	return ret
 }
 
+func MapPosturePolicyFailureToRestModel(modelPolicyFailure *model.PosturePolicyFailure) *rest_model.PolicyFailure {
+	policyFailure := &rest_model.PolicyFailure{
+		PolicyID:   modelPolicyFailure.PolicyId,
+		PolicyName: modelPolicyFailure.PolicyName,
+	}
+
+	checks := []rest_model.PostureCheckFailure{}
+
+	for _, pdCheck := range modelPolicyFailure.Checks {
+		switch pdCheck.PostureCheckType {
+		case string(rest_model.PostureCheckTypePROCESS):
+			checks = append(checks, MapPostureCheckFailureProcessToRestModel(pdCheck))
+		case string(rest_model.PostureCheckTypePROCESSMULTI), model.PostureCheckTypeProcessHash:
+			checks = append(checks, MapPostureCheckFailureProcessMultiToRestModel(pdCheck))
+		case string(rest_model.PostureCheckTypeDOMAIN):
+			checks = append(checks, MapPostureCheckFailureDomainToRestModel(pdCheck))
+		case string(rest_model.PostureCheckTypeMAC):
+			checks = append(checks, MapPostureCheckFailureMacToRestModel(pdCheck))
+		case string(rest_model.PostureCheckTypeOS):
+			checks = append(checks, MapPostureCheckFailureOsToRestModel(pdCheck))
+		case string(rest_model.PostureCheckTypeMFA):
+			checks = append(checks, MapPostureCheckFailureMfaToRestModel(pdCheck))
+		}
+		policyFailure.SetChecks(checks)
+	}
+
+	return policyFailure
+}
+
 func toStrFmtDateTime(time time.Time) strfmt.DateTime {
 	return strfmt.DateTime(time)
 }
@@ -34,13 +34,16 @@ type PublicQueryOptions struct {
 	Predicate string
 	Sort      string
 	Paging    *Paging
+
+	// RequiredPredicate is combined with the parsed Predicate, so results must match both
+	RequiredPredicate string
 }
 
 func (qo *PublicQueryOptions) String() string {
 	if qo == nil {
 		return "nil"
 	}
-	return fmt.Sprintf("[QueryOption Predicate: '%v', Sort: '%v', Paging: '%v']", qo.Predicate, qo.Sort, qo.Paging)
+	return fmt.Sprintf("[QueryOption Predicate: '%v', RequiredPredicate: '%v', Sort: '%v', Paging: '%v']", qo.Predicate, qo.RequiredPredicate, qo.Sort, qo.Paging)
 }
 
 func (qo *PublicQueryOptions) getFullQuery(store boltz.Store) (ast.Query, error) {
@@ -57,6 +60,14 @@ func (qo *PublicQueryOptions) getFullQuery(store boltz.Store) (ast.Query, error)
 		return nil, errorz.NewInvalidFilter(err)
 	}
 
+	if qo.RequiredPredicate != "" {
+		requiredQuery, err := ast.Parse(store, qo.RequiredPredicate)
+		if err != nil {
+			return nil, err
+		}
+		query.SetPredicate(ast.NewAndExprNode(query.GetPredicate(), requiredQuery.GetPredicate()))
+	}
+
 	pfxlog.Logger().Debugf("query: %v", qo)
 
 	if qo.Paging != nil {
@@ -0,0 +1,138 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/response"
+)
+
+const (
+	// RoleExpressionEvaluatePath is the management API path which validates a role expression and reports the
+	// identities it selects
+	RoleExpressionEvaluatePath = "/role-expression-evaluate"
+
+	DefaultRoleExpressionMatchLimit = 100
+	MaxRoleExpressionMatchLimit     = 1000
+)
+
+func init() {
+	r := NewRoleExpressionRouter()
+	env.AddRouter(r)
+}
+
+// RoleExpressionEvaluateRequest is the body accepted by the role expression evaluation endpoint. Limit bounds how
+// many matching identities are listed. It defaults to 100 and may be at most 1000.
+type RoleExpressionEvaluateRequest struct {
+	Expression string `json:"expression"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+type RoleExpressionEvaluation struct {
+	Valid      bool                       `json:"valid"`
+	Error      string                     `json:"error,omitempty"`
+	Normalized string                     `json:"normalized,omitempty"`
+	Attributes []string                   `json:"attributes,omitempty"`
+	MatchCount int                        `json:"matchCount"`
+	Matches    []*RoleExpressionEvalMatch `json:"matches,omitempty"`
+	Truncated  bool                       `json:"truncated,omitempty"`
+}
+
+type RoleExpressionEvalMatch struct {
+	Id             string   `json:"id"`
+	Name           string   `json:"name"`
+	RoleAttributes []string `json:"roleAttributes"`
+}
+
+type RoleExpressionRouter struct{}
+
+func NewRoleExpressionRouter() *RoleExpressionRouter {
+	return &RoleExpressionRouter{}
+}
+
+func (r *RoleExpressionRouter) Register(ae *env.AppEnv) {
+	ae.AddManagementApiHandler(RoleExpressionEvaluatePath, http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.Evaluate, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	}))
+}
+
+func (r *RoleExpressionRouter) Evaluate(ae *env.AppEnv, rc *response.RequestContext) {
+	if rc.Request.Method != http.MethodPost {
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+		return
+	}
+
+	req := &RoleExpressionEvaluateRequest{}
+	if err := json.Unmarshal(rc.Body, req); err != nil {
+		rc.RespondWithCouldNotParseBody(err)
+		return
+	}
+
+	req.Expression = strings.TrimSpace(req.Expression)
+	if req.Expression == "" {
+		rc.RespondWithApiError(errorz.NewFieldApiError(errorz.NewFieldError("expression is required", "expression", nil)))
+		return
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = DefaultRoleExpressionMatchLimit
+	} else if req.Limit > MaxRoleExpressionMatchLimit {
+		req.Limit = MaxRoleExpressionMatchLimit
+	}
+
+	result := &RoleExpressionEvaluation{}
+
+	// single attributes are accepted too, so an expression can be built up a term at a time
+	expression, err := db.ParseRoleExpression(req.Expression)
+	if err != nil {
+		result.Error = err.Error()
+		rc.RespondWithOk(result, &rest_model.Meta{})
+		return
+	}
+
+	result.Valid = true
+	result.Normalized = expression.String()
+	result.Attributes = expression.Attributes()
+
+	matches, count, err := ae.Managers.Identity.EvaluateRoleExpression(expression, req.Limit)
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	result.MatchCount = count
+	result.Truncated = count > len(matches)
+	for _, match := range matches {
+		result.Matches = append(result.Matches, &RoleExpressionEvalMatch{
+			Id:             match.Id,
+			Name:           match.Name,
+			RoleAttributes: match.RoleAttributes,
+		})
+	}
+
+	rc.RespondWithOk(result, &rest_model.Meta{})
+}
@@ -57,8 +57,13 @@ func MapPatchTransitRouterToModel(id string, router *rest_model.RouterPatch) *mo
 	return ret
 }
 
-func MapTransitRouterToRestEntity(ae *env.AppEnv, _ *response.RequestContext, router *model.TransitRouter) (interface{}, error) {
-	return MapTransitRouterToRestModel(ae, router)
+func MapTransitRouterToRestEntity(ae *env.AppEnv, rc *response.RequestContext, router *model.TransitRouter) (interface{}, error) {
+	result, err := MapTransitRouterToRestModel(ae, router)
+	if err == nil && hideEnrollmentSecrets(rc) {
+		result.EnrollmentJWT = nil
+		result.EnrollmentToken = nil
+	}
+	return result, err
 }
 
 func MapTransitRouterToRestModel(ae *env.AppEnv, router *model.TransitRouter) (*rest_model.RouterDetail, error) {
@@ -0,0 +1,326 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/network"
+	"github.com/openziti/ziti/controller/response"
+	"github.com/pkg/errors"
+)
+
+// RouterUpdatesPath is the management API path used to orchestrate router software updates. The release routers
+// should run is managed at /router-updates/release. Rollouts of that release are started with a POST to
+// /router-updates/rollouts, inspected with a GET to /router-updates/rollouts/<rollout id> and cancelled with a
+// DELETE to the same path.
+const RouterUpdatesPath = "/router-updates"
+
+func init() {
+	r := NewRouterUpdateRouter()
+	env.AddRouter(r)
+}
+
+type RouterReleaseArtifactDetail struct {
+	Os     string `json:"os"`
+	Arch   string `json:"arch"`
+	Url    string `json:"url"`
+	Sha256 string `json:"sha256"`
+}
+
+type RouterReleaseDetail struct {
+	Version   string                         `json:"version"`
+	Artifacts []*RouterReleaseArtifactDetail `json:"artifacts"`
+}
+
+// RouterRolloutCreateBody is the body accepted when starting a rollout. Timeouts are go durations, such as 5m. Unset
+// values use the defaults.
+type RouterRolloutCreateBody struct {
+	RouterIds      []string `json:"routerIds"`
+	BatchSize      int      `json:"batchSize"`
+	MaxFailures    int      `json:"maxFailures"`
+	StageTimeout   string   `json:"stageTimeout"`
+	DrainTimeout   string   `json:"drainTimeout"`
+	RestartTimeout string   `json:"restartTimeout"`
+}
+
+type RouterRolloutRouterDetail struct {
+	RouterId        string    `json:"routerId"`
+	PreviousVersion string    `json:"previousVersion"`
+	State           string    `json:"state"`
+	Error           string    `json:"error,omitempty"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+type RouterRolloutDetail struct {
+	Id             string                       `json:"id"`
+	Version        string                       `json:"version"`
+	State          string                       `json:"state"`
+	Error          string                       `json:"error,omitempty"`
+	BatchSize      int                          `json:"batchSize"`
+	MaxFailures    int                          `json:"maxFailures"`
+	Failures       int                          `json:"failures"`
+	StageTimeout   string                       `json:"stageTimeout"`
+	DrainTimeout   string                       `json:"drainTimeout"`
+	RestartTimeout string                       `json:"restartTimeout"`
+	CreatedAt      time.Time                    `json:"createdAt"`
+	FinishedAt     *time.Time                   `json:"finishedAt,omitempty"`
+	Routers        []*RouterRolloutRouterDetail `json:"routers"`
+}
+
+type RouterUpdateRouter struct {
+	BasePath string
+}
+
+func NewRouterUpdateRouter() *RouterUpdateRouter {
+	return &RouterUpdateRouter{
+		BasePath: RouterUpdatesPath,
+	}
+}
+
+func (r *RouterUpdateRouter) Register(ae *env.AppEnv) {
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.handle, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	})
+
+	ae.AddManagementApiHandler(r.BasePath+"/", handler)
+}
+
+func (r *RouterUpdateRouter) handle(ae *env.AppEnv, rc *response.RequestContext) {
+	_, subPath, _ := strings.Cut(rc.Request.URL.Path, r.BasePath)
+	resource, id, _ := strings.Cut(strings.Trim(subPath, "/"), "/")
+
+	if strings.Contains(id, "/") {
+		rc.RespondWithApiError(errorz.NewNotFound())
+		return
+	}
+
+	switch {
+	case resource == "release" && id == "":
+		r.handleRelease(ae, rc)
+	case resource == "rollouts" && id == "":
+		r.handleRollouts(ae, rc)
+	case resource == "rollouts":
+		rc.SetEntityId(id)
+		r.handleRollout(ae, rc, id)
+	default:
+		rc.RespondWithApiError(errorz.NewNotFound())
+	}
+}
+
+func (r *RouterUpdateRouter) handleRelease(ae *env.AppEnv, rc *response.RequestContext) {
+	n := ae.GetHostController().GetNetwork()
+
+	switch rc.Request.Method {
+	case http.MethodGet:
+		release := n.GetRouterRelease()
+		if release == nil {
+			rc.RespondWithApiError(errorz.NewNotFound())
+			return
+		}
+		rc.RespondWithOk(mapRouterReleaseToRest(release), &rest_model.Meta{})
+	case http.MethodPut:
+		body := &RouterReleaseDetail{}
+		if err := json.Unmarshal(rc.Body, body); err != nil {
+			rc.RespondWithCouldNotParseBody(err)
+			return
+		}
+
+		release := &network.RouterRelease{
+			Version: body.Version,
+		}
+		for _, artifact := range body.Artifacts {
+			if artifact == nil {
+				continue
+			}
+			release.Artifacts = append(release.Artifacts, &network.RouterReleaseArtifact{
+				Os:     artifact.Os,
+				Arch:   artifact.Arch,
+				Url:    artifact.Url,
+				Sha256: artifact.Sha256,
+			})
+		}
+
+		if err := n.SetRouterRelease(release); err != nil {
+			rc.RespondWithError(err)
+			return
+		}
+		rc.RespondWithOk(mapRouterReleaseToRest(release), &rest_model.Meta{})
+	case http.MethodDelete:
+		n.ClearRouterRelease()
+		rc.RespondWithEmptyOk()
+	default:
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+	}
+}
+
+func (r *RouterUpdateRouter) handleRollouts(ae *env.AppEnv, rc *response.RequestContext) {
+	n := ae.GetHostController().GetNetwork()
+
+	switch rc.Request.Method {
+	case http.MethodGet:
+		result := []*RouterRolloutDetail{}
+		for _, rollout := range n.GetRouterRollouts() {
+			result = append(result, mapRouterRolloutToRest(rollout))
+		}
+		rc.RespondWithOk(result, &rest_model.Meta{})
+	case http.MethodPost:
+		body := &RouterRolloutCreateBody{}
+		if len(rc.Body) > 0 {
+			if err := json.Unmarshal(rc.Body, body); err != nil {
+				rc.RespondWithCouldNotParseBody(err)
+				return
+			}
+		}
+
+		options := network.RouterRolloutOptions{
+			RouterIds:   body.RouterIds,
+			BatchSize:   body.BatchSize,
+			MaxFailures: body.MaxFailures,
+		}
+
+		for _, routerId := range body.RouterIds {
+			if _, err := n.GetRouter(routerId); err != nil {
+				rc.RespondWithError(errorz.NewFieldError("router not found", "routerIds", routerId))
+				return
+			}
+		}
+
+		var err error
+		if options.StageTimeout, err = parseRolloutTimeout("stageTimeout", body.StageTimeout); err != nil {
+			rc.RespondWithError(err)
+			return
+		}
+		if options.DrainTimeout, err = parseRolloutTimeout("drainTimeout", body.DrainTimeout); err != nil {
+			rc.RespondWithError(err)
+			return
+		}
+		if options.RestartTimeout, err = parseRolloutTimeout("restartTimeout", body.RestartTimeout); err != nil {
+			rc.RespondWithError(err)
+			return
+		}
+
+		rollout, err := n.StartRouterRollout(options)
+		if err != nil {
+			respondWithRouterUpdateError(rc, err)
+			return
+		}
+		rc.SetEntityId(rollout.Id)
+		rc.RespondWithOk(mapRouterRolloutToRest(rollout), &rest_model.Meta{})
+	default:
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+	}
+}
+
+func (r *RouterUpdateRouter) handleRollout(ae *env.AppEnv, rc *response.RequestContext, id string) {
+	n := ae.GetHostController().GetNetwork()
+
+	switch rc.Request.Method {
+	case http.MethodGet:
+		rollout, found := n.GetRouterRollout(id)
+		if !found {
+			rc.RespondWithApiError(errorz.NewNotFound())
+			return
+		}
+		rc.RespondWithOk(mapRouterRolloutToRest(rollout), &rest_model.Meta{})
+	case http.MethodDelete:
+		rollout, err := n.CancelRouterRollout(id)
+		if err != nil {
+			respondWithRouterUpdateError(rc, err)
+			return
+		}
+		rc.RespondWithOk(mapRouterRolloutToRest(rollout), &rest_model.Meta{})
+	default:
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+	}
+}
+
+// respondWithRouterUpdateError reports rollouts which can't be started or cancelled in the rollout's current state
+// as validation failures, rather than as unhandled errors
+func respondWithRouterUpdateError(rc *response.RequestContext, err error) {
+	var apiErr *errorz.ApiError
+	var fieldErr *errorz.FieldError
+	if errors.As(err, &apiErr) || errors.As(err, &fieldErr) {
+		rc.RespondWithError(err)
+		return
+	}
+	rc.RespondWithApiError(errorz.NewCouldNotValidate(err))
+}
+
+func parseRolloutTimeout(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	result, err := time.ParseDuration(value)
+	if err != nil || result <= 0 {
+		return 0, errorz.NewFieldError("must be a positive duration, such as 5m", field, value)
+	}
+	return result, nil
+}
+
+func mapRouterReleaseToRest(release *network.RouterRelease) *RouterReleaseDetail {
+	result := &RouterReleaseDetail{
+		Version:   release.Version,
+		Artifacts: []*RouterReleaseArtifactDetail{},
+	}
+	for _, artifact := range release.Artifacts {
+		result.Artifacts = append(result.Artifacts, &RouterReleaseArtifactDetail{
+			Os:     artifact.Os,
+			Arch:   artifact.Arch,
+			Url:    artifact.Url,
+			Sha256: artifact.Sha256,
+		})
+	}
+	return result
+}
+
+func mapRouterRolloutToRest(rollout *network.RouterRollout) *RouterRolloutDetail {
+	result := &RouterRolloutDetail{
+		Id:             rollout.Id,
+		Version:        rollout.Version,
+		State:          rollout.State,
+		Error:          rollout.Error,
+		BatchSize:      rollout.Options.BatchSize,
+		MaxFailures:    rollout.Options.MaxFailures,
+		Failures:       rollout.Failures,
+		StageTimeout:   rollout.Options.StageTimeout.String(),
+		DrainTimeout:   rollout.Options.DrainTimeout.String(),
+		RestartTimeout: rollout.Options.RestartTimeout.String(),
+		CreatedAt:      rollout.CreatedAt,
+		FinishedAt:     rollout.FinishedAt,
+		Routers:        []*RouterRolloutRouterDetail{},
+	}
+	for _, r := range rollout.Routers {
+		result.Routers = append(result.Routers, &RouterRolloutRouterDetail{
+			RouterId:        r.RouterId,
+			PreviousVersion: r.PreviousVersion,
+			State:           r.State,
+			Error:           r.Error,
+			UpdatedAt:       r.UpdatedAt,
+		})
+	}
+	return result
+}
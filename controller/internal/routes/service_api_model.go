@@ -282,6 +282,16 @@ func PostureCheckToQueries(check *model.PostureCheck) *rest_model.PostureQuery {
 				Path:   process.Path,
 			})
 		}
+	case model.PostureCheckTypeProcessHash:
+		// clients answer process hash checks the same way as process multi checks, so present them as such
+		*ret.QueryType = rest_model.PostureCheckTypePROCESSMULTI
+		processCheck := check.SubType.(*model.PostureCheckProcessHash)
+		for _, process := range processCheck.Processes {
+			ret.Processes = append(ret.Processes, &rest_model.PostureQueryProcess{
+				OsType: rest_model.OsType(process.OsType),
+				Path:   process.Path,
+			})
+		}
 	}
 
 	return ret
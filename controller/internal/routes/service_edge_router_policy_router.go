@@ -86,6 +86,13 @@ func (r *ServiceEdgeRouterPolicyRouter) Detail(ae *env.AppEnv, rc *response.Requ
 }
 
 func (r *ServiceEdgeRouterPolicyRouter) Create(ae *env.AppEnv, rc *response.RequestContext, params service_edge_router_policy.CreateServiceEdgeRouterPolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyDryRun(rc, func() (*model.PolicyAccessDelta, error) {
+			return ae.Managers.ServiceEdgeRouterPolicy.DryRunCreate(MapCreateServiceEdgeRouterPolicyToModel(params.Policy), rc.NewChangeContext())
+		})
+		return
+	}
+
 	Create(rc, rc, ServiceEdgeRouterPolicyLinkFactory, func() (string, error) {
 		return MapCreate(ae.Managers.ServiceEdgeRouterPolicy.Create, MapCreateServiceEdgeRouterPolicyToModel(params.Policy), rc)
 	})
@@ -96,12 +103,26 @@ func (r *ServiceEdgeRouterPolicyRouter) Delete(ae *env.AppEnv, rc *response.Requ
 }
 
 func (r *ServiceEdgeRouterPolicyRouter) Update(ae *env.AppEnv, rc *response.RequestContext, params service_edge_router_policy.UpdateServiceEdgeRouterPolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyDryRun(rc, func() (*model.PolicyAccessDelta, error) {
+			return ae.Managers.ServiceEdgeRouterPolicy.DryRunUpdate(MapUpdateServiceEdgeRouterPolicyToModel(params.ID, params.Policy), nil, rc.NewChangeContext())
+		})
+		return
+	}
+
 	Update(rc, func(id string) error {
 		return ae.Managers.ServiceEdgeRouterPolicy.Update(MapUpdateServiceEdgeRouterPolicyToModel(params.ID, params.Policy), nil, rc.NewChangeContext())
 	})
 }
 
 func (r *ServiceEdgeRouterPolicyRouter) Patch(ae *env.AppEnv, rc *response.RequestContext, params service_edge_router_policy.PatchServiceEdgeRouterPolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyPatchDryRun(rc, func(fields fields.UpdatedFields) (*model.PolicyAccessDelta, error) {
+			return ae.Managers.ServiceEdgeRouterPolicy.DryRunUpdate(MapPatchServiceEdgeRouterPolicyToModel(params.ID, params.Policy), fields.FilterMaps("tags"), rc.NewChangeContext())
+		})
+		return
+	}
+
 	Patch(rc, func(id string, fields fields.UpdatedFields) error {
 		return ae.Managers.ServiceEdgeRouterPolicy.Update(MapPatchServiceEdgeRouterPolicyToModel(params.ID, params.Policy), fields.FilterMaps("tags"), rc.NewChangeContext())
 	})
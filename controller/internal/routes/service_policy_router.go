@@ -90,6 +90,13 @@ func (r *ServicePolicyRouter) Detail(ae *env.AppEnv, rc *response.RequestContext
 }
 
 func (r *ServicePolicyRouter) Create(ae *env.AppEnv, rc *response.RequestContext, params service_policy.CreateServicePolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyDryRun(rc, func() (*model.PolicyAccessDelta, error) {
+			return ae.Managers.ServicePolicy.DryRunCreate(MapCreateServicePolicyToModel(params.Policy), rc.NewChangeContext())
+		})
+		return
+	}
+
 	Create(rc, rc, ServicePolicyLinkFactory, func() (string, error) {
 		return MapCreate(ae.Managers.ServicePolicy.Create, MapCreateServicePolicyToModel(params.Policy), rc)
 	})
@@ -100,12 +107,47 @@ func (r *ServicePolicyRouter) Delete(ae *env.AppEnv, rc *response.RequestContext
 }
 
 func (r *ServicePolicyRouter) Update(ae *env.AppEnv, rc *response.RequestContext, params service_policy.UpdateServicePolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyDryRun(rc, func() (*model.PolicyAccessDelta, error) {
+			policy, err := r.mapUpdate(ae, params)
+			if err != nil {
+				return nil, err
+			}
+			return ae.Managers.ServicePolicy.DryRunUpdate(policy, nil, rc.NewChangeContext())
+		})
+		return
+	}
+
 	Update(rc, func(id string) error {
-		return ae.Managers.ServicePolicy.Update(MapUpdateServicePolicyToModel(params.ID, params.Policy), nil, rc.NewChangeContext())
+		policy, err := r.mapUpdate(ae, params)
+		if err != nil {
+			return err
+		}
+		return ae.Managers.ServicePolicy.Update(policy, nil, rc.NewChangeContext())
 	})
 }
 
+func (r *ServicePolicyRouter) mapUpdate(ae *env.AppEnv, params service_policy.UpdateServicePolicyParams) (*model.ServicePolicy, error) {
+	policy := MapUpdateServicePolicyToModel(params.ID, params.Policy)
+
+	// schedules are managed through their own endpoint and aren't part of the service policy body, so keep the
+	// current one
+	existing, err := ae.Managers.ServicePolicy.Read(params.ID)
+	if err != nil {
+		return nil, err
+	}
+	policy.Schedule = existing.Schedule
+	return policy, nil
+}
+
 func (r *ServicePolicyRouter) Patch(ae *env.AppEnv, rc *response.RequestContext, params service_policy.PatchServicePolicyParams) {
+	if IsPolicyDryRun(rc) {
+		PolicyPatchDryRun(rc, func(fields fields.UpdatedFields) (*model.PolicyAccessDelta, error) {
+			return ae.Managers.ServicePolicy.DryRunUpdate(MapPatchServicePolicyToModel(params.ID, params.Policy), fields.FilterMaps("tags"), rc.NewChangeContext())
+		})
+		return
+	}
+
 	Patch(rc, func(id string, fields fields.UpdatedFields) error {
 		return ae.Managers.ServicePolicy.Update(MapPatchServicePolicyToModel(params.ID, params.Policy), fields.FilterMaps("tags"), rc.NewChangeContext())
 	})
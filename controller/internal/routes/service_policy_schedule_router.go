@@ -0,0 +1,166 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/response"
+)
+
+// ServicePolicySchedulesPath is the management API path used to read, set and remove the schedule of a service
+// policy, as /service-policy-schedules/<service policy id>. The generated service policy endpoints don't know
+// about schedules.
+const ServicePolicySchedulesPath = "/service-policy-schedules"
+
+func init() {
+	r := NewServicePolicyScheduleRouter()
+	env.AddRouter(r)
+}
+
+// ServicePolicyScheduleBody is the body accepted when setting a service policy schedule and returned when reading one
+type ServicePolicyScheduleBody struct {
+	Timezone  string     `json:"timezone"`
+	Windows   []string   `json:"windows"`
+	NotBefore *time.Time `json:"notBefore"`
+	NotAfter  *time.Time `json:"notAfter"`
+}
+
+type ServicePolicyScheduleDetail struct {
+	ServicePolicyId   string                     `json:"servicePolicyId"`
+	ServicePolicyName string                     `json:"servicePolicyName"`
+	Schedule          *ServicePolicyScheduleBody `json:"schedule"`
+	Active            bool                       `json:"active"`
+}
+
+type ServicePolicyScheduleRouter struct {
+	BasePath string
+}
+
+func NewServicePolicyScheduleRouter() *ServicePolicyScheduleRouter {
+	return &ServicePolicyScheduleRouter{
+		BasePath: ServicePolicySchedulesPath,
+	}
+}
+
+func (r *ServicePolicyScheduleRouter) Register(ae *env.AppEnv) {
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.handle, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	})
+
+	ae.AddManagementApiHandler(r.BasePath+"/", handler)
+}
+
+func (r *ServicePolicyScheduleRouter) handle(ae *env.AppEnv, rc *response.RequestContext) {
+	_, subPath, _ := strings.Cut(rc.Request.URL.Path, r.BasePath)
+	id := strings.Trim(subPath, "/")
+
+	if id == "" || strings.Contains(id, "/") {
+		rc.RespondWithApiError(errorz.NewNotFound())
+		return
+	}
+
+	rc.SetEntityId(id)
+
+	switch rc.Request.Method {
+	case http.MethodGet:
+		r.Detail(ae, rc, id)
+	case http.MethodPut:
+		r.Update(ae, rc, id)
+	case http.MethodDelete:
+		r.Delete(ae, rc, id)
+	default:
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+	}
+}
+
+func (r *ServicePolicyScheduleRouter) Detail(ae *env.AppEnv, rc *response.RequestContext, id string) {
+	policy, err := ae.Managers.ServicePolicy.Read(id)
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	rc.RespondWithOk(mapServicePolicyScheduleToRest(policy), &rest_model.Meta{})
+}
+
+func (r *ServicePolicyScheduleRouter) Update(ae *env.AppEnv, rc *response.RequestContext, id string) {
+	body := &ServicePolicyScheduleBody{}
+	if err := json.Unmarshal(rc.Body, body); err != nil {
+		rc.RespondWithCouldNotParseBody(err)
+		return
+	}
+
+	r.setSchedule(ae, rc, id, &model.ServicePolicySchedule{
+		Timezone:  body.Timezone,
+		Windows:   body.Windows,
+		NotBefore: body.NotBefore,
+		NotAfter:  body.NotAfter,
+	})
+}
+
+func (r *ServicePolicyScheduleRouter) Delete(ae *env.AppEnv, rc *response.RequestContext, id string) {
+	r.setSchedule(ae, rc, id, nil)
+}
+
+func (r *ServicePolicyScheduleRouter) setSchedule(ae *env.AppEnv, rc *response.RequestContext, id string, schedule *model.ServicePolicySchedule) {
+	policy, err := ae.Managers.ServicePolicy.Read(id)
+	if err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	policy.Schedule = schedule
+	checker := fields.UpdatedFieldsMap{db.FieldServicePolicySchedule: struct{}{}}
+	if err = ae.Managers.ServicePolicy.Update(policy, checker, rc.NewChangeContext()); err != nil {
+		rc.RespondWithError(err)
+		return
+	}
+
+	rc.RespondWithOk(mapServicePolicyScheduleToRest(policy), &rest_model.Meta{})
+}
+
+func mapServicePolicyScheduleToRest(policy *model.ServicePolicy) *ServicePolicyScheduleDetail {
+	result := &ServicePolicyScheduleDetail{
+		ServicePolicyId:   policy.Id,
+		ServicePolicyName: policy.Name,
+		Active:            policy.Schedule == nil || policy.Schedule.IsActive(time.Now()),
+	}
+
+	if policy.Schedule != nil {
+		result.Schedule = &ServicePolicyScheduleBody{
+			Timezone:  policy.Schedule.Timezone,
+			Windows:   policy.Schedule.Windows,
+			NotBefore: policy.Schedule.NotBefore,
+			NotAfter:  policy.Schedule.NotAfter,
+		}
+	}
+
+	return result
+}
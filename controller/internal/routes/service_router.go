@@ -253,10 +253,20 @@ func (r *ServiceRouter) Delete(ae *env.AppEnv, rc *response.RequestContext) {
 
 func (r *ServiceRouter) Update(ae *env.AppEnv, rc *response.RequestContext, params managementService.UpdateServiceParams) {
 	Update(rc, func(id string) error {
-		return ae.Managers.EdgeService.Update(MapUpdateServiceToModel(params.ID, params.Service), nil, rc.NewChangeContext())
+		svc := preserveMaxCircuitLifetime(ae, MapUpdateServiceToModel(params.ID, params.Service))
+		return ae.Managers.EdgeService.Update(svc, nil, rc.NewChangeContext())
 	})
 }
 
+// preserveMaxCircuitLifetime copies the max circuit lifetime from the stored service. The setting is only exposed by
+// the fabric api, so it would otherwise be cleared by edge api updates, which replace all fields.
+func preserveMaxCircuitLifetime(ae *env.AppEnv, svc *model.EdgeService) *model.EdgeService {
+	if current, _ := ae.Managers.EdgeService.Read(svc.Id); current != nil {
+		svc.MaxCircuitLifetime = current.MaxCircuitLifetime
+	}
+	return svc
+}
+
 func (r *ServiceRouter) Patch(ae *env.AppEnv, rc *response.RequestContext, params managementService.PatchServiceParams) {
 	Patch(rc, func(id string, fields fields.UpdatedFields) error {
 		return ae.Managers.EdgeService.Update(MapPatchServiceToModel(params.ID, params.Service), fields.FilterMaps("tags").MapField("maxIdleTimeMillis", "maxIdleTime"), rc.NewChangeContext())
@@ -67,8 +67,12 @@ func MapCreateSessionToModel(identityId, apiSessionId string, session *rest_mode
 	return ret
 }
 
-func MapSessionToRestEntity(ae *env.AppEnv, _ *response.RequestContext, session *model.Session) (interface{}, error) {
-	return MapSessionToRestModel(ae, session)
+func MapSessionToRestEntity(ae *env.AppEnv, rc *response.RequestContext, session *model.Session) (interface{}, error) {
+	result, err := MapSessionToRestModel(ae, session)
+	if err == nil && hideSessionToken(rc, session.IdentityId) {
+		result.Token = new(string)
+	}
+	return result, err
 }
 
 func MapSessionToRestModel(ae *env.AppEnv, sessionModel *model.Session) (*rest_model.SessionManagementDetail, error) {
@@ -29,6 +29,7 @@ import (
 	"github.com/openziti/metrics"
 	"github.com/openziti/ziti/controller/env"
 	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/model"
 	"github.com/openziti/ziti/controller/response"
 )
 
@@ -95,7 +96,12 @@ func (r *SessionRouter) List(ae *env.AppEnv, rc *response.RequestContext) {
 			return nil, err
 		}
 
-		result, err := ae.Managers.Session.PublicQueryForIdentity(rc.Identity, query)
+		var result *model.SessionListResult
+		if canReadAllSessions(rc) {
+			result, err = ae.Managers.Session.PublicQuery(query)
+		} else {
+			result, err = ae.Managers.Session.PublicQueryForIdentity(rc.Identity, query)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -110,14 +116,26 @@ func (r *SessionRouter) List(ae *env.AppEnv, rc *response.RequestContext) {
 func (r *SessionRouter) Detail(ae *env.AppEnv, rc *response.RequestContext) {
 	// DetailWithHandler won't do search limiting by logged in user
 	Detail(rc, func(rc *response.RequestContext, id string) (interface{}, error) {
-		service, err := ae.Managers.Session.ReadForIdentity(id, rc.ApiSession.IdentityId)
+		var session *model.Session
+		var err error
+		if canReadAllSessions(rc) {
+			session, err = ae.Managers.Session.Read(id)
+		} else {
+			session, err = ae.Managers.Session.ReadForIdentity(id, rc.ApiSession.IdentityId)
+		}
 		if err != nil {
 			return nil, err
 		}
-		return MapSessionToRestEntity(ae, rc, service)
+		return MapSessionToRestEntity(ae, rc, session)
 	})
 }
 
+// canReadAllSessions returns true if the requester may read the sessions of all identities, rather than only their
+// own. This is the case for admins and, for requests which don't modify anything, read only admins.
+func canReadAllSessions(rc *response.RequestContext) bool {
+	return permissions.IsAdmin().IsAllowed(permissions.ForRequest(rc.Request.Method, rc.ActivePermissions)...)
+}
+
 func (r *SessionRouter) Delete(ae *env.AppEnv, rc *response.RequestContext) {
 	Delete(rc, func(rc *response.RequestContext, id string) error {
 		return ae.Managers.Session.DeleteForIdentity(id, rc.ApiSession.IdentityId, rc.NewChangeContext())
@@ -0,0 +1,513 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/foundation/v2/stringz"
+	"github.com/openziti/storage/ast"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/common"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/response"
+)
+
+const (
+	// TokenIntrospectPath is the management API path which reports what the controller knows about an api session
+	// or session token
+	TokenIntrospectPath = "/token-introspect"
+
+	TokenFormatLegacy = "legacy"
+	TokenFormatJwt    = "jwt"
+
+	TokenTypeApiSession = "apiSession"
+	TokenTypeSession    = "session"
+
+	// MaxTokenIntrospectServices limits how many services are evaluated when introspecting an api session token
+	MaxTokenIntrospectServices = 100
+)
+
+func init() {
+	r := NewTokenIntrospectRouter()
+	env.AddRouter(r)
+}
+
+// TokenIntrospectRequest is the body accepted by the token introspection endpoint. When introspecting an api session
+// token, ServiceId limits the reported service access to a single service.
+type TokenIntrospectRequest struct {
+	Token     string `json:"token"`
+	ServiceId string `json:"serviceId,omitempty"`
+}
+
+type TokenIntrospection struct {
+	Valid               bool                          `json:"valid"`
+	Reason              string                        `json:"reason,omitempty"`
+	Format              string                        `json:"format"`
+	Type                string                        `json:"type,omitempty"`
+	Issuer              string                        `json:"issuer,omitempty"`
+	IssuingControllerId string                        `json:"issuingControllerId,omitempty"`
+	EvaluatedBy         string                        `json:"evaluatedBy"`
+	IssuedAt            *time.Time                    `json:"issuedAt,omitempty"`
+	ExpiresAt           *time.Time                    `json:"expiresAt,omitempty"`
+	Identity            *TokenIntrospectionIdentity   `json:"identity,omitempty"`
+	ApiSession          *TokenIntrospectionApiSession `json:"apiSession,omitempty"`
+	Session             *TokenIntrospectionSession    `json:"session,omitempty"`
+	Posture             *TokenIntrospectionPosture    `json:"posture,omitempty"`
+	Services            []*TokenIntrospectionService  `json:"services,omitempty"`
+	ServicesTruncated   bool                          `json:"servicesTruncated,omitempty"`
+}
+
+type TokenIntrospectionIdentity struct {
+	Id            string     `json:"id"`
+	Name          string     `json:"name"`
+	IsAdmin       bool       `json:"isAdmin"`
+	Disabled      bool       `json:"disabled"`
+	DisabledUntil *time.Time `json:"disabledUntil,omitempty"`
+	AuthPolicyId  string     `json:"authPolicyId"`
+}
+
+type TokenIntrospectionApiSession struct {
+	Id              string   `json:"id"`
+	AuthenticatorId string   `json:"authenticatorId,omitempty"`
+	MfaComplete     bool     `json:"mfaComplete"`
+	MfaRequired     bool     `json:"mfaRequired"`
+	IPAddress       string   `json:"ipAddress,omitempty"`
+	ConfigTypes     []string `json:"configTypes,omitempty"`
+}
+
+type TokenIntrospectionSession struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	ApiSessionId string `json:"apiSessionId"`
+	ServiceId    string `json:"serviceId"`
+	ServiceName  string `json:"serviceName,omitempty"`
+}
+
+// TokenIntrospectionPosture summarizes the posture data this controller holds for the identity. Posture data is
+// held in memory by the controller the SDK submitted it to, so other controllers in a cluster may have none.
+type TokenIntrospectionPosture struct {
+	HasData                bool       `json:"hasData"`
+	OsType                 string     `json:"osType,omitempty"`
+	OsVersion              string     `json:"osVersion,omitempty"`
+	Domain                 string     `json:"domain,omitempty"`
+	MacAddresses           []string   `json:"macAddresses,omitempty"`
+	ProcessCount           int        `json:"processCount"`
+	MfaPassedAt            *time.Time `json:"mfaPassedAt,omitempty"`
+	SessionRequestFailures int        `json:"sessionRequestFailures"`
+}
+
+type TokenIntrospectionService struct {
+	Id          string                             `json:"id"`
+	Name        string                             `json:"name"`
+	Permissions []string                           `json:"permissions"`
+	Access      []*TokenIntrospectionServiceAccess `json:"access,omitempty"`
+}
+
+// TokenIntrospectionServiceAccess reports whether a session of the given type would currently be allowed, given the
+// service policy schedules and posture checks
+type TokenIntrospectionServiceAccess struct {
+	Type           string      `json:"type"`
+	Allowed        bool        `json:"allowed"`
+	SchedulePassed bool        `json:"schedulePassed"`
+	PosturePassed  bool        `json:"posturePassed"`
+	Reason         string      `json:"reason,omitempty"`
+	Details        interface{} `json:"details,omitempty"`
+}
+
+type TokenIntrospectRouter struct{}
+
+func NewTokenIntrospectRouter() *TokenIntrospectRouter {
+	return &TokenIntrospectRouter{}
+}
+
+func (r *TokenIntrospectRouter) Register(ae *env.AppEnv) {
+	ae.AddManagementApiHandler(TokenIntrospectPath, http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ae.IsAllowed(r.Introspect, request, "", "", permissions.IsAdmin()).WriteResponse(writer, runtime.JSONProducer())
+	}))
+}
+
+func (r *TokenIntrospectRouter) Introspect(ae *env.AppEnv, rc *response.RequestContext) {
+	if rc.Request.Method != http.MethodPost {
+		rc.RespondWithApiError(apierror.NewMethodNotAllowed())
+		return
+	}
+
+	req := &TokenIntrospectRequest{}
+	if err := json.Unmarshal(rc.Body, req); err != nil {
+		rc.RespondWithCouldNotParseBody(err)
+		return
+	}
+
+	req.Token = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(req.Token), "Bearer "))
+	if req.Token == "" {
+		rc.RespondWithApiError(errorz.NewFieldApiError(errorz.NewFieldError("token is required", "token", nil)))
+		return
+	}
+
+	result := &TokenIntrospection{
+		EvaluatedBy: ae.GetId(),
+	}
+
+	if strings.Count(req.Token, ".") == 2 {
+		result.Format = TokenFormatJwt
+		r.introspectJwt(ae, req.Token, result)
+	} else {
+		result.Format = TokenFormatLegacy
+		r.introspectLegacy(ae, req.Token, result)
+	}
+
+	if result.Valid && result.ExpiresAt != nil && result.ExpiresAt.Before(time.Now()) {
+		result.Valid = false
+		result.Reason = "token has expired"
+	}
+
+	if result.Identity != nil {
+		if result.Identity.Disabled && result.Valid {
+			result.Valid = false
+			result.Reason = "identity is disabled"
+		}
+
+		apiSessionId := ""
+		if result.ApiSession != nil {
+			apiSessionId = result.ApiSession.Id
+		} else if result.Session != nil {
+			apiSessionId = result.Session.ApiSessionId
+		}
+
+		result.Posture = r.getPosture(ae, result.Identity.Id, apiSessionId)
+
+		if err := r.addServiceAccess(ae, req, result, apiSessionId); err != nil {
+			rc.RespondWithError(err)
+			return
+		}
+	}
+
+	rc.RespondWithOk(result, &rest_model.Meta{})
+}
+
+func (r *TokenIntrospectRouter) introspectLegacy(ae *env.AppEnv, token string, result *TokenIntrospection) {
+	apiSession, err := ae.Managers.ApiSession.ReadByToken(token)
+	if err == nil {
+		result.Valid = true
+		result.Type = TokenTypeApiSession
+		result.IssuedAt = &apiSession.CreatedAt
+		result.ExpiresAt = &apiSession.ExpiresAt
+		result.ApiSession = &TokenIntrospectionApiSession{
+			Id:              apiSession.Id,
+			AuthenticatorId: apiSession.AuthenticatorId,
+			MfaComplete:     apiSession.MfaComplete,
+			MfaRequired:     apiSession.MfaRequired,
+			IPAddress:       apiSession.IPAddress,
+			ConfigTypes:     stringz.SetToSlice(apiSession.ConfigTypes),
+		}
+		r.setIdentity(ae, apiSession.IdentityId, result)
+		return
+	}
+
+	if !boltz.IsErrNotFoundErr(err) {
+		result.Reason = fmt.Sprintf("unable to look up api session: %v", err)
+		return
+	}
+
+	session, err := ae.Managers.Session.ReadByToken(token)
+	if err != nil {
+		if boltz.IsErrNotFoundErr(err) {
+			result.Reason = "no api session or session found for token"
+		} else {
+			result.Reason = fmt.Sprintf("unable to look up session: %v", err)
+		}
+		return
+	}
+
+	result.Valid = true
+	result.Type = TokenTypeSession
+	result.IssuedAt = &session.CreatedAt
+	r.setSession(ae, session.Id, session.Type, session.ApiSessionId, session.ServiceId, result)
+
+	apiSession, err = ae.Managers.ApiSession.Read(session.ApiSessionId)
+	if err != nil {
+		result.Valid = false
+		result.Reason = fmt.Sprintf("api session %s of session is no longer present", session.ApiSessionId)
+	} else {
+		result.ExpiresAt = &apiSession.ExpiresAt
+	}
+
+	r.setIdentity(ae, session.IdentityId, result)
+}
+
+func (r *TokenIntrospectRouter) introspectJwt(ae *env.AppEnv, token string, result *TokenIntrospection) {
+	unverified, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		result.Reason = fmt.Sprintf("unable to parse token: %v", err)
+		return
+	}
+
+	if issuer, err := unverified.Claims.GetIssuer(); err == nil {
+		result.Issuer = issuer
+		result.IssuingControllerId = r.getIssuingControllerId(ae, issuer)
+	}
+
+	tokenType, _ := unverified.Claims.(jwt.MapClaims)["z_t"].(string)
+
+	switch tokenType {
+	case common.TokenTypeAccess:
+		result.Type = TokenTypeApiSession
+		accessClaims, err := ae.ValidateAccessToken(token)
+		if err != nil {
+			result.Reason = err.Error()
+			r.setUnverifiedTimes(unverified, result)
+			return
+		}
+
+		result.Valid = true
+		issuedAt := accessClaims.IssuedAt.AsTime()
+		expiresAt := accessClaims.Expiration.AsTime()
+		result.IssuedAt = &issuedAt
+		result.ExpiresAt = &expiresAt
+		result.ApiSession = &TokenIntrospectionApiSession{
+			Id:              accessClaims.ApiSessionId,
+			AuthenticatorId: accessClaims.AuthenticatorId,
+			MfaComplete:     accessClaims.TotpComplete(),
+			IPAddress:       accessClaims.RemoteAddress,
+			ConfigTypes:     accessClaims.ConfigTypes,
+		}
+		r.setIdentity(ae, accessClaims.Subject, result)
+
+	case common.TokenTypeServiceAccess:
+		result.Type = TokenTypeSession
+		serviceClaims, err := ae.ValidateServiceAccessToken(token, nil)
+		if err != nil {
+			result.Reason = err.Error()
+			r.setUnverifiedTimes(unverified, result)
+			return
+		}
+
+		result.Valid = true
+		if serviceClaims.IssuedAt != nil {
+			result.IssuedAt = &serviceClaims.IssuedAt.Time
+		}
+		if serviceClaims.ExpiresAt != nil {
+			result.ExpiresAt = &serviceClaims.ExpiresAt.Time
+		}
+		r.setSession(ae, serviceClaims.ID, serviceClaims.Type, serviceClaims.ApiSessionId, serviceClaims.Subject, result)
+		r.setIdentity(ae, serviceClaims.IdentityId, result)
+
+	case common.TokenTypeRefresh:
+		result.Reason = "refresh tokens can't be used as api session or session tokens"
+
+	default:
+		result.Reason = fmt.Sprintf("unsupported token type '%s'", tokenType)
+	}
+}
+
+func (r *TokenIntrospectRouter) setUnverifiedTimes(token *jwt.Token, result *TokenIntrospection) {
+	if issuedAt, err := token.Claims.GetIssuedAt(); err == nil && issuedAt != nil {
+		result.IssuedAt = &issuedAt.Time
+	}
+	if expiresAt, err := token.Claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		result.ExpiresAt = &expiresAt.Time
+	}
+}
+
+func (r *TokenIntrospectRouter) setIdentity(ae *env.AppEnv, identityId string, result *TokenIntrospection) {
+	identity, err := ae.Managers.Identity.Read(identityId)
+	if err != nil {
+		result.Valid = false
+		result.Reason = fmt.Sprintf("identity %s not found", identityId)
+		return
+	}
+
+	result.Identity = &TokenIntrospectionIdentity{
+		Id:            identity.Id,
+		Name:          identity.Name,
+		IsAdmin:       identity.IsAdmin || identity.IsDefaultAdmin,
+		Disabled:      identity.Disabled,
+		DisabledUntil: identity.DisabledUntil,
+		AuthPolicyId:  identity.AuthPolicyId,
+	}
+}
+
+func (r *TokenIntrospectRouter) setSession(ae *env.AppEnv, id, sessionType, apiSessionId, serviceId string, result *TokenIntrospection) {
+	result.Session = &TokenIntrospectionSession{
+		Id:           id,
+		Type:         sessionType,
+		ApiSessionId: apiSessionId,
+		ServiceId:    serviceId,
+	}
+
+	if service, err := ae.Managers.EdgeService.Read(serviceId); err == nil {
+		result.Session.ServiceName = service.Name
+	}
+}
+
+// getIssuingControllerId matches the issuer of a token against the API addresses of the controllers in the cluster
+func (r *TokenIntrospectRouter) getIssuingControllerId(ae *env.AppEnv, issuer string) string {
+	issuerUrl, err := url.Parse(issuer)
+	if err != nil || issuerUrl.Host == "" {
+		return ""
+	}
+
+	controllers, err := ae.Managers.Controller.BaseList("true limit none")
+	if err != nil {
+		return ""
+	}
+
+	for _, ctrl := range controllers.Entities {
+		for _, apiAddresses := range ctrl.ApiAddresses {
+			for _, apiAddress := range apiAddresses {
+				if apiUrl, err := url.Parse(apiAddress.Url); err == nil && strings.EqualFold(apiUrl.Host, issuerUrl.Host) {
+					return ctrl.Id
+				}
+			}
+		}
+	}
+
+	if strings.EqualFold(issuer, ae.RootIssuer()) || strings.EqualFold(issuer, ae.OidcIssuer()) {
+		return ae.GetId()
+	}
+
+	return ""
+}
+
+func (r *TokenIntrospectRouter) getPosture(ae *env.AppEnv, identityId, apiSessionId string) *TokenIntrospectionPosture {
+	result := &TokenIntrospectionPosture{}
+
+	ae.Managers.PostureResponse.WithPostureData(identityId, func(data *model.PostureData) {
+		result.HasData = data.Os.PostureResponse != nil || data.Domain.PostureResponse != nil ||
+			data.Mac.PostureResponse != nil || len(data.Processes) > 0 || len(data.ApiSessions) > 0
+		result.OsType = data.Os.Type
+		result.OsVersion = data.Os.Version
+		result.Domain = data.Domain.Name
+		result.MacAddresses = data.Mac.Addresses
+		result.ProcessCount = len(data.Processes)
+		result.SessionRequestFailures = len(data.SessionRequestFailures)
+
+		if apiSessionData, found := data.ApiSessions[apiSessionId]; found {
+			result.MfaPassedAt = apiSessionData.GetPassedMfaAt()
+		}
+	})
+
+	return result
+}
+
+// addServiceAccess reports which services the identity can access and whether sessions would currently be allowed.
+// For a session token, only the session's service is reported.
+func (r *TokenIntrospectRouter) addServiceAccess(ae *env.AppEnv, req *TokenIntrospectRequest, result *TokenIntrospection, apiSessionId string) error {
+	identityId := result.Identity.Id
+
+	serviceId := req.ServiceId
+	if result.Session != nil {
+		serviceId = result.Session.ServiceId
+	}
+
+	var services []*model.ServiceDetail
+	if serviceId != "" {
+		service, err := ae.Managers.EdgeService.ReadForIdentity(serviceId, identityId, nil)
+		if err != nil && !boltz.IsErrNotFoundErr(err) {
+			return err
+		}
+		if service != nil {
+			services = append(services, service)
+		} else if result.Session != nil && result.Valid {
+			result.Valid = false
+			result.Reason = fmt.Sprintf("identity no longer has access to service %s", serviceId)
+		}
+	} else {
+		query, err := ast.Parse(ae.GetStores().EdgeService, fmt.Sprintf("true sort by name limit %d", MaxTokenIntrospectServices+1))
+		if err != nil {
+			return err
+		}
+
+		list, err := ae.Managers.EdgeService.QueryForIdentity(identityId, nil, query)
+		if err != nil {
+			return err
+		}
+
+		services = list.Services
+		if len(services) > MaxTokenIntrospectServices {
+			services = services[:MaxTokenIntrospectServices]
+			result.ServicesTruncated = true
+		}
+	}
+
+	for _, service := range services {
+		introspectedService := &TokenIntrospectionService{
+			Id:          service.Id,
+			Name:        service.Name,
+			Permissions: service.Permissions,
+		}
+
+		for _, sessionType := range []string{db.SessionTypeDial, db.SessionTypeBind} {
+			if result.Session != nil && result.Session.Type != sessionType {
+				continue
+			}
+
+			if !stringz.Contains(service.Permissions, sessionType) {
+				if result.Session != nil && result.Valid {
+					result.Valid = false
+					result.Reason = fmt.Sprintf("identity no longer has %s access to service %s", sessionType, service.Name)
+				}
+				continue
+			}
+
+			introspectedService.Access = append(introspectedService.Access, r.getAccess(ae, identityId, apiSessionId, sessionType, service))
+		}
+
+		result.Services = append(result.Services, introspectedService)
+	}
+
+	return nil
+}
+
+func (r *TokenIntrospectRouter) getAccess(ae *env.AppEnv, identityId, apiSessionId, sessionType string, service *model.ServiceDetail) *TokenIntrospectionServiceAccess {
+	access := &TokenIntrospectionServiceAccess{
+		Type:           sessionType,
+		SchedulePassed: true,
+		PosturePassed:  true,
+	}
+
+	scheduleResult := ae.Managers.Session.EvaluateScheduleForService(identityId, sessionType, service.Id, time.Now())
+	if !scheduleResult.Passed {
+		access.SchedulePassed = false
+		access.Reason = scheduleResult.Cause.Message
+		access.Details = scheduleResult.Cause.DataMap
+	} else {
+		postureResult := ae.Managers.Session.EvaluatePostureForService(identityId, apiSessionId, sessionType, service.Id, service.Name)
+		if !postureResult.Passed {
+			access.PosturePassed = false
+			access.Reason = postureResult.Cause.Message
+			access.Details = postureResult.Cause.DataMap
+		}
+	}
+
+	access.Allowed = access.SchedulePassed && access.PosturePassed
+	return access
+}
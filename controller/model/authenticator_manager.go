@@ -479,6 +479,14 @@ func (self *AuthenticatorManager) ExtendCertForIdentity(identityId string, authe
 
 	}
 
+	// when a key roll has been requested, renewing the certificate for the current key isn't enough
+	if authenticatorCert.IsKeyRollRequested && publicKeySha256(csr.PublicKey) == PublicKeySha256(peer) {
+		apiErr := apierror.NewCouldNotProcessCsr()
+		apiErr.Cause = errors.New("a key roll was requested, the csr must be for a new key")
+		apiErr.AppendCause = true
+		return nil, apiErr
+	}
+
 	currentCerts := nfpem.PemStringToCertificates(authenticatorCert.Pem)
 
 	if len(currentCerts) == 0 {
@@ -643,7 +651,11 @@ func (self *AuthenticatorManager) VerifyExtendCertForIdentity(isJwtBacked bool,
 }
 
 func PublicKeySha256(cert *x509.Certificate) string {
-	pubKeyBytes, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+	return publicKeySha256(cert.PublicKey)
+}
+
+func publicKeySha256(publicKey any) string {
+	pubKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return ""
 	}
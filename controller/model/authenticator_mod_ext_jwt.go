@@ -110,9 +110,33 @@ func NewAuthModuleExtJwt(env Env) *AuthModuleExtJwt {
 
 	ret.loadExistingSigners()
 
+	go ret.refreshSigners(env.GetCloseNotifyChannel())
+
 	return ret
 }
 
+// refreshSigners periodically re-resolves signers which load their keys remotely, so that key rotation by the
+// provider is picked up even if no token with an unknown kid has been seen yet
+func (a *AuthModuleExtJwt) refreshSigners(closeNotify <-chan struct{}) {
+	ticker := time.NewTicker(JwksRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, signerRec := range a.signers.Items() {
+				if signerRec.usesRemoteKeys() {
+					if err := signerRec.Resolve(true); err != nil {
+						signerRec.logger().WithError(err).Error("could not refresh external jwt signer keys")
+					}
+				}
+			}
+		case <-closeNotify:
+			return
+		}
+	}
+}
+
 type pubKey struct {
 	pubKey any
 	chain  []*x509.Certificate
@@ -127,6 +151,19 @@ type signerRecord struct {
 	externalJwtSigner *db.ExternalJwtSigner
 
 	jwksResolver jwks.Resolver
+
+	discovery            *OidcDiscoveryDocument
+	discoveryLastRequest time.Time
+	discoveryResolver    OidcDiscoveryResolver
+}
+
+func (r *signerRecord) logger() *logrus.Entry {
+	return pfxlog.Logger().WithField("id", r.externalJwtSigner.Id).WithField("name", r.externalJwtSigner.Name)
+}
+
+// usesRemoteKeys returns true if the signer's keys are loaded from a JWKS endpoint, either configured or discovered
+func (r *signerRecord) usesRemoteKeys() bool {
+	return r.externalJwtSigner.CertPem == nil
 }
 
 func (r *signerRecord) PubKeyByKid(kid string) (pubKey, bool) {
@@ -173,64 +210,75 @@ func (r *signerRecord) Resolve(force bool) error {
 		return nil
 
 	} else if r.externalJwtSigner.JwksEndpoint != nil {
-		if (!r.jwksLastRequest.IsZero() && time.Since(r.jwksLastRequest) < JwksQueryTimeout) && !force {
-			return nil
-		}
+		return r.resolveJwks(*r.externalJwtSigner.JwksEndpoint, force)
+	} else if r.externalJwtSigner.Issuer != nil {
+		return r.resolveDiscovery(force)
+	}
 
-		r.jwksLastRequest = time.Now()
+	return errors.New("instructed to add external jwt signer that does not have a certificate PEM, JWKS endpoint or discoverable issuer")
+}
 
-		jwksResponse, _, err := r.jwksResolver.Get(*r.externalJwtSigner.JwksEndpoint)
+// resolveJwks loads the signing keys from the given JWKS endpoint. The keys replace the previously loaded set,
+// so keys which have been retired by the provider are no longer accepted.
+func (r *signerRecord) resolveJwks(jwksEndpoint string, force bool) error {
+	if (!r.jwksLastRequest.IsZero() && time.Since(r.jwksLastRequest) < JwksQueryTimeout) && !force {
+		return nil
+	}
 
-		if err != nil {
-			return fmt.Errorf("could not resolve jwks endpoint: %v", err)
-		}
+	r.jwksLastRequest = time.Now()
 
-		for _, key := range jwksResponse.Keys {
-			//if we have an x509chain the first must be the signing key
-			if len(key.X509Chain) != 0 {
-				// x5c is the only attribute with padding according to
-				// RFC 7517 Section-4.7 "x5c" (X.509 Certificate Chain) Parameter
-				x509Der, err := base64.StdEncoding.DecodeString(key.X509Chain[0])
+	jwksResponse, _, err := r.jwksResolver.Get(jwksEndpoint)
 
-				if err != nil {
-					return fmt.Errorf("could not parse JWKS keys: %v", err)
-				}
+	if err != nil {
+		return fmt.Errorf("could not resolve jwks endpoint: %v", err)
+	}
 
-				certs, err := x509.ParseCertificates(x509Der)
+	kidToPubKey := map[string]pubKey{}
 
-				if err != nil {
-					return fmt.Errorf("could not parse JWKS DER as x509: %v", err)
-				}
+	for _, key := range jwksResponse.Keys {
+		//if we have an x509chain the first must be the signing key
+		if len(key.X509Chain) != 0 {
+			// x5c is the only attribute with padding according to
+			// RFC 7517 Section-4.7 "x5c" (X.509 Certificate Chain) Parameter
+			x509Der, err := base64.StdEncoding.DecodeString(key.X509Chain[0])
 
-				if len(certs) == 0 {
-					return fmt.Errorf("no ceritficates parsed")
-				}
+			if err != nil {
+				return fmt.Errorf("could not parse JWKS keys: %v", err)
+			}
 
-				r.kidToPubKey[key.KeyId] = pubKey{
-					pubKey: certs[0].PublicKey,
-					chain:  certs,
-				}
-			} else {
-				//else the key properties are the only way to construct the public key
-				k, err := jwks.KeyToPublicKey(key)
+			certs, err := x509.ParseCertificates(x509Der)
 
-				if err != nil {
-					return err
-				}
+			if err != nil {
+				return fmt.Errorf("could not parse JWKS DER as x509: %v", err)
+			}
 
-				r.kidToPubKey[key.KeyId] = pubKey{
-					pubKey: k,
-				}
+			if len(certs) == 0 {
+				return fmt.Errorf("no ceritficates parsed")
 			}
 
-		}
+			kidToPubKey[key.KeyId] = pubKey{
+				pubKey: certs[0].PublicKey,
+				chain:  certs,
+			}
+		} else {
+			//else the key properties are the only way to construct the public key
+			k, err := jwks.KeyToPublicKey(key)
 
-		r.jwksResponse = jwksResponse
+			if err != nil {
+				return err
+			}
+
+			kidToPubKey[key.KeyId] = pubKey{
+				pubKey: k,
+			}
+		}
 
-		return nil
 	}
 
-	return errors.New("instructed to add external jwt signer that does not have a certificate PEM or JWKS endpoint")
+	r.kidToPubKey = kidToPubKey
+	r.jwksResponse = jwksResponse
+
+	return nil
 }
 
 func (a *AuthModuleExtJwt) CanHandle(method string) bool {
@@ -473,6 +521,7 @@ func (a *AuthModuleExtJwt) addSigner(signer *db.ExternalJwtSigner) {
 	signerRec := &signerRecord{
 		externalJwtSigner: signer,
 		jwksResolver:      &jwks.HttpResolver{},
+		discoveryResolver: &HttpOidcDiscoveryResolver{},
 		kidToPubKey:       map[string]pubKey{},
 	}
 
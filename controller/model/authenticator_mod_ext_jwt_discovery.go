@@ -0,0 +1,159 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openziti/foundation/v2/stringz"
+)
+
+const (
+	OidcDiscoveryPath = "/.well-known/openid-configuration"
+
+	// JwksRefreshInterval is how often signers using a JWKS endpoint or OIDC discovery are re-resolved, so
+	// that rotated keys are picked up and retired keys are dropped
+	JwksRefreshInterval = 15 * time.Minute
+
+	OidcDiscoveryQueryTimeout = 10 * time.Second
+)
+
+// OidcDiscoveryDocument holds the subset of the OIDC discovery document used to configure external jwt signers
+type OidcDiscoveryDocument struct {
+	Issuer                string   `json:"issuer"`
+	JwksUri               string   `json:"jwks_uri"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	ClaimsSupported       []string `json:"claims_supported"`
+	ScopesSupported       []string `json:"scopes_supported"`
+}
+
+// OidcDiscoveryResolver retrieves the OIDC discovery document for an issuer
+type OidcDiscoveryResolver interface {
+	Get(issuer string) (*OidcDiscoveryDocument, error)
+}
+
+type HttpOidcDiscoveryResolver struct {
+	Client *http.Client
+}
+
+func (self *HttpOidcDiscoveryResolver) Get(issuer string) (*OidcDiscoveryDocument, error) {
+	discoveryUrl, err := GetOidcDiscoveryUrl(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	client := self.Client
+	if client == nil {
+		client = &http.Client{Timeout: OidcDiscoveryQueryTimeout}
+	}
+
+	resp, err := client.Get(discoveryUrl)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve oidc discovery document from %s: %w", discoveryUrl, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not retrieve oidc discovery document from %s: unexpected status %s", discoveryUrl, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, fmt.Errorf("could not read oidc discovery document from %s: %w", discoveryUrl, err)
+	}
+
+	result := &OidcDiscoveryDocument{}
+	if err = json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("could not parse oidc discovery document from %s: %w", discoveryUrl, err)
+	}
+
+	return result, nil
+}
+
+// GetOidcDiscoveryUrl returns the location of the discovery document for the given issuer, as defined by
+// OpenID Connect Discovery 1.0 section 4
+func GetOidcDiscoveryUrl(issuer string) (string, error) {
+	issuerUrl, err := url.Parse(issuer)
+	if err != nil {
+		return "", fmt.Errorf("issuer [%s] is not a valid url: %w", issuer, err)
+	}
+
+	if (issuerUrl.Scheme != "https" && issuerUrl.Scheme != "http") || issuerUrl.Host == "" {
+		return "", fmt.Errorf("issuer [%s] is not an http(s) url, oidc discovery is not possible", issuer)
+	}
+
+	return strings.TrimSuffix(issuer, "/") + OidcDiscoveryPath, nil
+}
+
+// resolveDiscovery loads the signer's configuration from the issuer's OIDC discovery document, re-reading it
+// every JwksRefreshInterval, and then resolves the keys from the discovered JWKS endpoint
+func (r *signerRecord) resolveDiscovery(force bool) error {
+	if force || r.discovery == nil || time.Since(r.discoveryLastRequest) >= JwksRefreshInterval {
+		if err := r.refreshDiscovery(force); err != nil {
+			return err
+		}
+	}
+
+	return r.resolveJwks(r.discovery.JwksUri, force)
+}
+
+func (r *signerRecord) refreshDiscovery(force bool) error {
+	issuer := *r.externalJwtSigner.Issuer
+
+	if !force && !r.discoveryLastRequest.IsZero() && time.Since(r.discoveryLastRequest) < JwksQueryTimeout {
+		if r.discovery == nil {
+			return fmt.Errorf("oidc discovery for issuer [%s] has not succeeded yet", issuer)
+		}
+		return nil
+	}
+
+	r.discoveryLastRequest = time.Now()
+
+	discovery, err := r.discoveryResolver.Get(issuer)
+	if err != nil {
+		if r.discovery == nil {
+			return err
+		}
+		// keep using the previous configuration, the provider may only be temporarily unavailable
+		r.logger().WithError(err).Warn("could not refresh oidc discovery document, using previously discovered configuration")
+		return nil
+	}
+
+	if discovery.Issuer != issuer {
+		return fmt.Errorf("oidc discovery document issuer [%s] does not match signer issuer [%s]", discovery.Issuer, issuer)
+	}
+
+	if discovery.JwksUri == "" {
+		return fmt.Errorf("oidc discovery document for issuer [%s] has no jwks_uri", issuer)
+	}
+
+	if claimsProperty := r.externalJwtSigner.ClaimsProperty; claimsProperty != nil && len(discovery.ClaimsSupported) > 0 &&
+		!stringz.Contains(discovery.ClaimsSupported, *claimsProperty) {
+		r.logger().WithField("claimsProperty", *claimsProperty).
+			WithField("claimsSupported", discovery.ClaimsSupported).
+			Warn("claims property is not listed as supported in the oidc discovery document")
+	}
+
+	r.discovery = discovery
+	return nil
+}
@@ -109,6 +109,129 @@ func Test_signerRecord_Resolve(t *testing.T) {
 	})
 }
 
+func Test_signerRecord_ResolveDiscovery(t *testing.T) {
+	issuer := "https://issuer.example.com"
+	jwksUri := "https://issuer.example.com/keys"
+
+	testRootCa := newRootCa()
+
+	newSignerRecord := func(jwksResolver *testJwksProvider, discoveryResolver *testOidcDiscoveryResolver) *signerRecord {
+		return &signerRecord{
+			kidToPubKey: map[string]pubKey{},
+			externalJwtSigner: &db.ExternalJwtSigner{
+				BaseExtEntity: boltz.BaseExtEntity{
+					Id:        "fake-id",
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				},
+				Name:    "test1",
+				Issuer:  &issuer,
+				Enabled: true,
+			},
+			jwksResolver:      jwksResolver,
+			discoveryResolver: discoveryResolver,
+		}
+	}
+
+	t.Run("keys are resolved from the discovered jwks endpoint", func(t *testing.T) {
+		req := require.New(t)
+
+		jwksResolver, err := newTestJwksResolver()
+		req.NoError(err)
+
+		leaf1KeyPair := testRootCa.NewLeafWithAKID()
+		leaf1Key, err := newKey(leaf1KeyPair.cert, []*x509.Certificate{leaf1KeyPair.cert, testRootCa.cert})
+		req.NoError(err)
+		jwksResolver.AddKey(leaf1Key, leaf1KeyPair.key)
+
+		discoveryResolver := &testOidcDiscoveryResolver{
+			document: &OidcDiscoveryDocument{
+				Issuer:  issuer,
+				JwksUri: jwksUri,
+			},
+		}
+
+		signerRec := newSignerRecord(jwksResolver, discoveryResolver)
+
+		req.NoError(signerRec.Resolve(false))
+		req.Equal([]string{issuer}, discoveryResolver.callIssuers)
+		req.Equal([]string{jwksUri}, jwksResolver.callUrls)
+
+		_, found := signerRec.PubKeyByKid(leaf1Key.KeyId)
+		req.True(found)
+
+		t.Run("discovery document is not re-read until the refresh interval has passed", func(t *testing.T) {
+			req := require.New(t)
+
+			time.Sleep(JwksQueryTimeout)
+
+			req.NoError(signerRec.Resolve(false))
+			req.Len(discoveryResolver.callIssuers, 1)
+			req.Equal(2, jwksResolver.callCount)
+		})
+
+		t.Run("retired keys are dropped when keys are rolled over", func(t *testing.T) {
+			req := require.New(t)
+
+			leaf2KeyPair := testRootCa.NewLeafWithAKID()
+			leaf2Key, err := newKey(leaf2KeyPair.cert, []*x509.Certificate{leaf2KeyPair.cert, testRootCa.cert})
+			req.NoError(err)
+
+			jwksResolver.response.Keys = nil
+			jwksResolver.AddKey(leaf2Key, leaf2KeyPair.key)
+
+			req.NoError(signerRec.Resolve(true))
+			req.Len(discoveryResolver.callIssuers, 2)
+
+			_, found := signerRec.PubKeyByKid(leaf1Key.KeyId)
+			req.False(found)
+
+			_, found = signerRec.PubKeyByKid(leaf2Key.KeyId)
+			req.True(found)
+		})
+	})
+
+	t.Run("a discovery document for a different issuer is rejected", func(t *testing.T) {
+		req := require.New(t)
+
+		jwksResolver, err := newTestJwksResolver()
+		req.NoError(err)
+
+		discoveryResolver := &testOidcDiscoveryResolver{
+			document: &OidcDiscoveryDocument{
+				Issuer:  "https://other.example.com",
+				JwksUri: jwksUri,
+			},
+		}
+
+		signerRec := newSignerRecord(jwksResolver, discoveryResolver)
+
+		req.Error(signerRec.Resolve(false))
+		req.Equal(0, jwksResolver.callCount)
+	})
+
+	t.Run("discovery url is derived from the issuer", func(t *testing.T) {
+		req := require.New(t)
+
+		discoveryUrl, err := GetOidcDiscoveryUrl("https://issuer.example.com/realms/test/")
+		req.NoError(err)
+		req.Equal("https://issuer.example.com/realms/test/.well-known/openid-configuration", discoveryUrl)
+
+		_, err = GetOidcDiscoveryUrl("my-issuer")
+		req.Error(err)
+	})
+}
+
+type testOidcDiscoveryResolver struct {
+	callIssuers []string
+	document    *OidcDiscoveryDocument
+}
+
+func (self *testOidcDiscoveryResolver) Get(issuer string) (*OidcDiscoveryDocument, error) {
+	self.callIssuers = append(self.callIssuers, issuer)
+	return self.document, nil
+}
+
 var currentSerial int64 = 1
 
 type certPair struct {
@@ -200,29 +200,33 @@ func (self *baseEntityManager[ME, PE]) updateEntityBatch(modelEntity edgeEntity[
 
 func (self *baseEntityManager[ME, PE]) updateEntity(modelEntity ME, checker boltz.FieldChecker, ctx boltz.MutateContext) error {
 	return self.GetDb().Update(ctx, func(ctx boltz.MutateContext) error {
-		existing, found, err := self.GetStore().FindById(ctx.Tx(), modelEntity.GetId())
-		if err != nil {
-			return err
-		}
-		if !found {
-			return boltz.NewNotFoundError(self.GetStore().GetSingularEntityType(), "id", modelEntity.GetId())
-		}
+		return self.updateEntityInTx(ctx, modelEntity, checker)
+	})
+}
 
-		boltEntity, err := modelEntity.toBoltEntityForUpdate(ctx.Tx(), self.env, checker)
-		if err != nil {
-			return err
-		}
+func (self *baseEntityManager[ME, PE]) updateEntityInTx(ctx boltz.MutateContext, modelEntity ME, checker boltz.FieldChecker) error {
+	existing, found, err := self.GetStore().FindById(ctx.Tx(), modelEntity.GetId())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return boltz.NewNotFoundError(self.GetStore().GetSingularEntityType(), "id", modelEntity.GetId())
+	}
 
-		if err = self.ValidateNameOnUpdate(ctx, boltEntity, existing, checker); err != nil {
-			return nil
-		}
+	boltEntity, err := modelEntity.toBoltEntityForUpdate(ctx.Tx(), self.env, checker)
+	if err != nil {
+		return err
+	}
 
-		if err := self.GetStore().Update(ctx, boltEntity, checker); err != nil {
-			pfxlog.Logger().WithError(err).Errorf("could not update %v entity", self.GetStore().GetEntityType())
-			return err
-		}
+	if err = self.ValidateNameOnUpdate(ctx, boltEntity, existing, checker); err != nil {
 		return nil
-	})
+	}
+
+	if err := self.GetStore().Update(ctx, boltEntity, checker); err != nil {
+		pfxlog.Logger().WithError(err).Errorf("could not update %v entity", self.GetStore().GetEntityType())
+		return err
+	}
+	return nil
 }
 
 func (self *baseEntityManager[ME, PE]) Read(id string) (ME, error) {
@@ -298,12 +302,16 @@ func (self *baseEntityManager[ME, PE]) readEntityByQuery(query string) (models.E
 }
 
 func (self *baseEntityManager[ME, PE]) Delete(id string, ctx *change.Context) error {
-	cmd := &command.DeleteEntityCommand{
+	return self.Dispatch(self.NewDeleteCommand(id, ctx))
+}
+
+// NewDeleteCommand returns a command which will delete the entity with the given id
+func (self *baseEntityManager[ME, PE]) NewDeleteCommand(id string, ctx *change.Context) *command.DeleteEntityCommand {
+	return &command.DeleteEntityCommand{
 		Context: ctx,
 		Deleter: self.impl, // needs to be impl, otherwise we will miss overrides to GetEntityTypeId
 		Id:      id,
 	}
-	return self.Dispatch(cmd)
 }
 
 func (self *baseEntityManager[ME, PE]) ApplyDelete(cmd *command.DeleteEntityCommand, ctx boltz.MutateContext) error {
@@ -0,0 +1,126 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/common/pb/cmd_pb"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/command"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// BatchItemError reports which command in a batch caused the batch to fail
+type BatchItemError struct {
+	Index int
+	Cause error
+}
+
+func (self *BatchItemError) Error() string {
+	return fmt.Sprintf("batch command at index %d failed: %v", self.Index, self.Cause)
+}
+
+func (self *BatchItemError) Unwrap() error {
+	return self.Cause
+}
+
+// BatchCommand applies a list of commands in a single transaction. Either all the commands are applied, or
+// none of them are.
+type BatchCommand struct {
+	Context  *change.Context
+	Commands []command.Command
+	env      Env
+}
+
+// DispatchBatch applies the given commands atomically. If a command fails, the returned error will be a
+// *BatchItemError identifying the failed command.
+func (self *CommandManager) DispatchBatch(cmds []command.Command, ctx *change.Context) error {
+	cmd := &BatchCommand{
+		Context:  ctx,
+		Commands: cmds,
+		env:      self.env,
+	}
+	return self.env.GetManagers().Dispatcher.Dispatch(cmd)
+}
+
+func (self *BatchCommand) Validate() error {
+	for idx, cmd := range self.Commands {
+		if _, ok := cmd.(*BatchCommand); ok {
+			return &BatchItemError{Index: idx, Cause: errors.New("batch commands may not be nested")}
+		}
+		if validatable, ok := cmd.(command.Validatable); ok {
+			if err := validatable.Validate(); err != nil {
+				return &BatchItemError{Index: idx, Cause: err}
+			}
+		}
+	}
+	return nil
+}
+
+func (self *BatchCommand) Apply(ctx boltz.MutateContext) error {
+	return self.env.GetDb().Update(ctx, func(ctx boltz.MutateContext) error {
+		for idx, cmd := range self.Commands {
+			if err := cmd.Apply(ctx); err != nil {
+				return &BatchItemError{Index: idx, Cause: err}
+			}
+		}
+		return nil
+	})
+}
+
+func (self *BatchCommand) Encode() ([]byte, error) {
+	msg := &cmd_pb.BatchCommand{}
+	for idx, cmd := range self.Commands {
+		encoded, err := cmd.Encode()
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to encode batch command at index %d", idx)
+		}
+		msg.Commands = append(msg.Commands, encoded)
+	}
+	return cmd_pb.EncodeProtobuf(msg)
+}
+
+func (self *BatchCommand) GetChangeContext() *change.Context {
+	return self.Context
+}
+
+func (self *CommandManager) decodeBatchCommand(_ int32, data []byte) (command.Command, error) {
+	msg := &cmd_pb.BatchCommand{}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	result := &BatchCommand{
+		env: self.env,
+	}
+
+	for idx, encoded := range msg.Commands {
+		cmd, err := self.Decoders.Decode(encoded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to decode batch command at index %d", idx)
+		}
+		if result.Context == nil {
+			result.Context = cmd.GetChangeContext()
+		}
+		result.Commands = append(result.Commands, cmd)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,104 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/common/eid"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/command"
+	"github.com/openziti/ziti/controller/db"
+)
+
+func TestBatchCommand(t *testing.T) {
+	ctx := NewTestContext(t)
+	defer ctx.Cleanup()
+	ctx.Init()
+
+	t.Run("all commands in a batch are applied", ctx.testBatchApplied)
+	t.Run("a failed command rolls back the batch", ctx.testBatchRolledBack)
+}
+
+func (ctx *TestContext) testBatchApplied(t *testing.T) {
+	existing := ctx.requireNewService()
+
+	service := &EdgeService{Name: eid.New()}
+	service.Id = eid.New()
+	identity := &Identity{Name: eid.New(), IdentityTypeId: db.DefaultIdentityType}
+	identity.Id = eid.New()
+
+	// the policy references entities created earlier in the same batch
+	policy := &ServicePolicy{
+		Name:          eid.New(),
+		PolicyType:    db.PolicyTypeDialName,
+		Semantic:      db.SemanticAllOf,
+		IdentityRoles: []string{"@" + identity.Id},
+		ServiceRoles:  []string{"@" + service.Id},
+	}
+
+	changeCtx := change.New()
+	err := ctx.managers.Command.DispatchBatch([]command.Command{
+		NewCreateEntityCommand[*EdgeService](ctx.managers.EdgeService, service, changeCtx),
+		ctx.managers.Identity.NewCreateWithEnrollmentsCmd(identity, nil, changeCtx),
+		NewCreateEntityCommand[*ServicePolicy](ctx.managers.ServicePolicy, policy, changeCtx),
+		ctx.managers.EdgeService.NewDeleteCommand(existing.Id, changeCtx),
+	}, changeCtx)
+	ctx.NoError(err)
+
+	_, err = ctx.managers.EdgeService.Read(service.Id)
+	ctx.NoError(err)
+
+	_, err = ctx.managers.Identity.Read(identity.Id)
+	ctx.NoError(err)
+
+	readPolicy, err := ctx.managers.ServicePolicy.Read(policy.Id)
+	ctx.NoError(err)
+	ctx.Equal([]string{"@" + identity.Id}, readPolicy.IdentityRoles)
+
+	_, err = ctx.managers.EdgeService.Read(existing.Id)
+	ctx.True(boltz.IsErrNotFoundErr(err))
+}
+
+func (ctx *TestContext) testBatchRolledBack(t *testing.T) {
+	existing := ctx.requireNewService()
+
+	service := &EdgeService{Name: eid.New()}
+	duplicate := &EdgeService{Name: existing.Name}
+
+	changeCtx := change.New()
+	err := ctx.managers.Command.DispatchBatch([]command.Command{
+		NewCreateEntityCommand[*EdgeService](ctx.managers.EdgeService, service, changeCtx),
+		ctx.managers.EdgeService.NewDeleteCommand(existing.Id, changeCtx),
+		ctx.managers.EdgeService.NewDeleteCommand(eid.New(), changeCtx),
+		NewCreateEntityCommand[*EdgeService](ctx.managers.EdgeService, duplicate, changeCtx),
+	}, changeCtx)
+	ctx.Error(err)
+
+	var batchErr *BatchItemError
+	ctx.True(errors.As(err, &batchErr))
+	ctx.Equal(2, batchErr.Index)
+	ctx.True(boltz.IsErrNotFoundErr(err))
+
+	_, err = ctx.managers.EdgeService.Read(service.Id)
+	ctx.True(boltz.IsErrNotFoundErr(err))
+
+	_, err = ctx.managers.EdgeService.Read(existing.Id)
+	ctx.NoError(err)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/openziti/storage/ast"
 	"github.com/openziti/storage/boltz"
 	"github.com/openziti/ziti/common/pb/edge_cmd_pb"
+	"github.com/openziti/ziti/common/trustbundle"
 	"github.com/openziti/ziti/controller/change"
 	"github.com/openziti/ziti/controller/command"
 	"github.com/openziti/ziti/controller/db"
@@ -76,6 +77,13 @@ type TrustCache struct {
 	// a pool of all roots, intermediates and 3rd parties
 	allPool *x509.CertPool
 
+	// the trust bundle distributed to clients, with recent versions for calculating deltas
+	trustBundles *trustbundle.History
+
+	// the subset of the trust bundle distributed to routers. Routers use their CA bundle for controller and link
+	// connections, so it only holds the controller's roots and intermediates, not 3rd party CAs.
+	routerTrustBundles *trustbundle.History
+
 	sync.RWMutex
 	initOnce sync.Once
 }
@@ -91,6 +99,43 @@ func (self *TrustCache) GetAllPool() *x509.CertPool {
 	return self.allPool
 }
 
+// GetTrustBundle returns the current trust bundle: the controller's roots and intermediates, followed by the 3rd
+// party CAs which are active for authentication
+func (self *TrustCache) GetTrustBundle() *trustbundle.Bundle {
+	self.RLock()
+	defer self.RUnlock()
+
+	if self.trustBundles == nil {
+		return trustbundle.NewBundle(nil)
+	}
+
+	return self.trustBundles.Current()
+}
+
+// GetTrustBundleDelta returns the changes to the trust bundle since the version with the given ETag
+func (self *TrustCache) GetTrustBundleDelta(since string) *trustbundle.Delta {
+	self.RLock()
+	defer self.RUnlock()
+
+	if self.trustBundles == nil {
+		return trustbundle.NewHistory(1).Delta(since)
+	}
+
+	return self.trustBundles.Delta(since)
+}
+
+// GetRouterTrustBundleDelta returns the changes to the router trust bundle since the version with the given ETag
+func (self *TrustCache) GetRouterTrustBundleDelta(since string) *trustbundle.Delta {
+	self.RLock()
+	defer self.RUnlock()
+
+	if self.routerTrustBundles == nil {
+		return trustbundle.NewHistory(1).Delta(since)
+	}
+
+	return self.routerTrustBundles.Delta(since)
+}
+
 type CaManager struct {
 	baseEntityManager[*Ca, *db.Ca]
 	cache TrustCache
@@ -173,9 +218,37 @@ func (self *CaManager) RefreshActiveAuthCaCertCache() error {
 
 	self.cache.allPool = allPool
 
+	// a partial bundle would tell routers to drop the CAs which weren't read, so keep the previous one on error
+	if err == nil {
+		self.updateTrustBundle(newStaticFirstPartyTrustAnchors, newThirdPartyTrustAnchors)
+	}
+
 	return err
 }
 
+func (self *CaManager) updateTrustBundle(firstParty, thirdParty []*x509.Certificate) {
+	var certs []*trustbundle.Certificate
+	for _, cert := range firstParty {
+		certs = append(certs, trustbundle.NewCertificate(cert, trustbundle.SourceController))
+	}
+	for _, cert := range thirdParty {
+		certs = append(certs, trustbundle.NewCertificate(cert, trustbundle.SourceThirdParty))
+	}
+
+	if self.cache.trustBundles == nil {
+		self.cache.trustBundles = trustbundle.NewHistory(trustbundle.DefaultHistoryLength)
+		self.cache.routerTrustBundles = trustbundle.NewHistory(trustbundle.DefaultHistoryLength)
+	}
+
+	bundle := trustbundle.NewBundle(certs)
+	if self.cache.trustBundles.Update(bundle) {
+		pfxlog.Logger().WithField("etag", self.cache.trustBundles.Current().ETag).
+			WithField("certificates", len(certs)).
+			Info("trust bundle updated")
+	}
+	self.cache.routerTrustBundles.Update(bundle.FilterSource(trustbundle.SourceController))
+}
+
 func (self *CaManager) newModelEntity() *Ca {
 	return &Ca{}
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/openziti/storage/objectz"
 	"github.com/openziti/ziti/common/datastructures"
 	"github.com/openziti/ziti/common/logcontext"
+	"github.com/openziti/ziti/controller/event"
 	"github.com/openziti/ziti/controller/xt"
 	"github.com/orcaman/concurrent-map/v2"
 	"sync/atomic"
@@ -28,16 +29,18 @@ import (
 )
 
 type Circuit struct {
-	Id         string
-	ClientId   string
-	ServiceId  string
-	Terminator xt.CostedTerminator
-	Path       *Path
-	Tags       map[string]string
-	Rerouting  atomic.Bool
-	PeerData   xt.PeerData
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	Id             string
+	ClientId       string
+	ServiceId      string
+	Terminator     xt.CostedTerminator
+	Path           *Path
+	Tags           map[string]string
+	Rerouting      atomic.Bool
+	PathPin        atomic.Pointer[[]string]
+	Classification atomic.Pointer[event.CircuitClassification]
+	PeerData       xt.PeerData
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 func (self *Circuit) GetId() string {
@@ -0,0 +1,68 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	MaxCircuitTags           = 16
+	MaxCircuitTagKeyLength   = 64
+	MaxCircuitTagValueLength = 256
+	MaxCircuitTagsSize       = 2048
+
+	CircuitTagServiceId = "serviceId"
+	CircuitTagClientId  = "clientId"
+	CircuitTagHostId    = "hostId"
+)
+
+// ReservedCircuitTags are set by the controller on every circuit and can't be supplied by the dialing client
+var ReservedCircuitTags = []string{CircuitTagServiceId, CircuitTagClientId, CircuitTagHostId}
+
+// ValidateCircuitTags checks tags supplied by a dialing client against the circuit tag limits. Tags are carried in
+// circuit events, route messages and usage metrics, so their number and size are bounded.
+func ValidateCircuitTags(tags map[string]string) error {
+	if len(tags) > MaxCircuitTags {
+		return errors.Errorf("too many circuit tags, %d provided, limit is %d", len(tags), MaxCircuitTags)
+	}
+
+	size := 0
+	for k, v := range tags {
+		if k == "" {
+			return errors.New("circuit tag keys may not be empty")
+		}
+		if len(k) > MaxCircuitTagKeyLength {
+			return errors.Errorf("circuit tag key '%s' is too long, limit is %d bytes", k, MaxCircuitTagKeyLength)
+		}
+		if len(v) > MaxCircuitTagValueLength {
+			return errors.Errorf("value for circuit tag '%s' is too long, limit is %d bytes", k, MaxCircuitTagValueLength)
+		}
+		for _, reserved := range ReservedCircuitTags {
+			if k == reserved {
+				return errors.Errorf("circuit tag '%s' is reserved", k)
+			}
+		}
+		size += len(k) + len(v)
+	}
+
+	if size > MaxCircuitTagsSize {
+		return errors.Errorf("circuit tags are too large, %d bytes provided, limit is %d bytes", size, MaxCircuitTagsSize)
+	}
+
+	return nil
+}
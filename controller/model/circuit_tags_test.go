@@ -0,0 +1,52 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCircuitTags(t *testing.T) {
+	req := require.New(t)
+
+	req.NoError(ValidateCircuitTags(nil))
+	req.NoError(ValidateCircuitTags(map[string]string{"costCenter": "cc-1234", "team": ""}))
+
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxCircuitTags; i++ {
+		tooMany[fmt.Sprintf("tag%d", i)] = "x"
+	}
+	req.ErrorContains(ValidateCircuitTags(tooMany), "too many circuit tags")
+
+	req.ErrorContains(ValidateCircuitTags(map[string]string{"": "x"}), "may not be empty")
+	req.ErrorContains(ValidateCircuitTags(map[string]string{strings.Repeat("k", MaxCircuitTagKeyLength+1): "x"}), "key")
+	req.ErrorContains(ValidateCircuitTags(map[string]string{"k": strings.Repeat("v", MaxCircuitTagValueLength+1)}), "value")
+
+	for _, reserved := range ReservedCircuitTags {
+		req.ErrorContains(ValidateCircuitTags(map[string]string{reserved: "x"}), "reserved")
+	}
+
+	tooLarge := map[string]string{}
+	for i := 0; i < MaxCircuitTags; i++ {
+		tooLarge[fmt.Sprintf("tag%d", i)] = strings.Repeat("v", MaxCircuitTagValueLength)
+	}
+	req.ErrorContains(ValidateCircuitTags(tooLarge), "too large")
+}
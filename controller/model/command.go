@@ -47,6 +47,7 @@ func (self *CommandManager) registerGenericCommands() {
 	self.Decoders.RegisterF(int32(cmd_pb.CommandType_CreateEntityType), self.decodeCreateEntityCommand)
 	self.Decoders.RegisterF(int32(cmd_pb.CommandType_UpdateEntityType), self.decodeUpdateEntityCommand)
 	self.Decoders.RegisterF(int32(cmd_pb.CommandType_DeleteEntityType), self.decodeDeleteEntityCommand)
+	self.Decoders.RegisterF(int32(cmd_pb.CommandType_BatchType), self.decodeBatchCommand)
 }
 
 func (self *CommandManager) decodeCreateEntityCommand(_ int32, data []byte) (command.Command, error) {
@@ -111,27 +112,34 @@ type updater[T models.Entity] interface {
 }
 
 func DispatchCreate[T models.Entity](c creator[T], entity T, ctx *change.Context) error {
+	return c.Dispatch(NewCreateEntityCommand[T](c, entity, ctx))
+}
+
+func DispatchUpdate[T models.Entity](u updater[T], entity T, updatedFields fields.UpdatedFields, ctx *change.Context) error {
+	return u.Dispatch(NewUpdateEntityCommand[T](u, entity, updatedFields, ctx))
+}
+
+// NewCreateEntityCommand returns a command which will create the given entity. If the entity doesn't have an id,
+// one will be assigned.
+func NewCreateEntityCommand[T models.Entity](c command.EntityCreator[T], entity T, ctx *change.Context) *command.CreateEntityCommand[T] {
 	if entity.GetId() == "" {
 		id := idgen.MustNewUUIDString()
 		entity.SetId(id)
 	}
 
-	cmd := &command.CreateEntityCommand[T]{
+	return &command.CreateEntityCommand[T]{
 		Context: ctx,
 		Creator: c,
 		Entity:  entity,
 	}
-
-	return c.Dispatch(cmd)
 }
 
-func DispatchUpdate[T models.Entity](u updater[T], entity T, updatedFields fields.UpdatedFields, ctx *change.Context) error {
-	cmd := &command.UpdateEntityCommand[T]{
+// NewUpdateEntityCommand returns a command which will update the given entity
+func NewUpdateEntityCommand[T models.Entity](u command.EntityUpdater[T], entity T, updatedFields fields.UpdatedFields, ctx *change.Context) *command.UpdateEntityCommand[T] {
+	return &command.UpdateEntityCommand[T]{
 		Context:       ctx,
 		Updater:       u,
 		Entity:        entity,
 		UpdatedFields: updatedFields,
 	}
-
-	return u.Dispatch(cmd)
 }
@@ -65,6 +65,10 @@ func (self *ConfigManager) Update(entity *Config, checker fields.UpdatedFields,
 func (self *ConfigManager) ApplyUpdate(cmd *command.UpdateEntityCommand[*Config], ctx boltz.MutateContext) error {
 	var checker boltz.FieldChecker = self
 	if cmd.UpdatedFields != nil {
+		// data is always validated against, and stored with, a specific schema version
+		if cmd.UpdatedFields.IsUpdated(db.FieldConfigData) {
+			cmd.UpdatedFields.AddField(db.FieldConfigSchemaVersion)
+		}
 		checker = &AndFieldChecker{first: self, second: cmd.UpdatedFields}
 	}
 	return self.updateEntity(cmd.Entity, checker, ctx)
@@ -102,11 +106,12 @@ func (self *ConfigManager) Marshall(entity *Config) ([]byte, error) {
 	}
 
 	msg := &edge_cmd_pb.Config{
-		Id:           entity.Id,
-		Name:         entity.Name,
-		ConfigTypeId: entity.TypeId,
-		Data:         data,
-		Tags:         tags,
+		Id:            entity.Id,
+		Name:          entity.Name,
+		ConfigTypeId:  entity.TypeId,
+		Data:          data,
+		Tags:          tags,
+		SchemaVersion: entity.SchemaVersion,
 	}
 
 	return proto.Marshal(msg)
@@ -128,8 +133,9 @@ func (self *ConfigManager) Unmarshall(bytes []byte) (*Config, error) {
 			Id:   msg.Id,
 			Tags: edge_cmd_pb.DecodeTags(msg.Tags),
 		},
-		Name:   msg.Name,
-		TypeId: msg.ConfigTypeId,
-		Data:   data,
+		Name:          msg.Name,
+		TypeId:        msg.ConfigTypeId,
+		Data:          data,
+		SchemaVersion: msg.SchemaVersion,
 	}, nil
 }
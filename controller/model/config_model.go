@@ -17,6 +17,8 @@
 package model
 
 import (
+	"fmt"
+
 	"github.com/openziti/foundation/v2/errorz"
 	"github.com/openziti/storage/boltz"
 	"github.com/openziti/ziti/controller/apierror"
@@ -31,6 +33,9 @@ type Config struct {
 	Name   string
 	TypeId string
 	Data   map[string]interface{}
+	// SchemaVersion is the config type schema version the data was written against. If not set, the current
+	// version of the config type is used.
+	SchemaVersion uint32
 }
 
 func (entity *Config) toBoltEntity(tx *bbolt.Tx, env Env) (*db.Config, error) {
@@ -50,8 +55,18 @@ func (entity *Config) toBoltEntity(tx *bbolt.Tx, env Env) (*db.Config, error) {
 		entity.TypeId = currentConfig.TypeId
 	}
 
-	if configType, _ := env.GetManagers().ConfigType.readInTx(tx, entity.TypeId); configType != nil && len(configType.Schema) > 0 {
-		compileSchema, err := configType.GetCompiledSchema()
+	configType, _ := env.GetManagers().ConfigType.readInTx(tx, entity.TypeId)
+	if configType != nil && entity.SchemaVersion == 0 {
+		entity.SchemaVersion = configType.GetSchemaVersion()
+	}
+
+	if configType != nil && !configType.HasSchemaVersion(entity.SchemaVersion) {
+		return nil, errorz.NewFieldError(fmt.Sprintf("config type %v has no schema version %d", configType.Name, entity.SchemaVersion),
+			db.FieldConfigSchemaVersion, entity.SchemaVersion)
+	}
+
+	if configType != nil && len(configType.GetSchema(entity.SchemaVersion)) > 0 {
+		compileSchema, err := configType.GetCompiledSchemaForVersion(entity.SchemaVersion)
 		if err != nil {
 			return nil, err
 		}
@@ -70,6 +85,7 @@ func (entity *Config) toBoltEntity(tx *bbolt.Tx, env Env) (*db.Config, error) {
 		Name:          entity.Name,
 		Type:          entity.TypeId,
 		Data:          entity.Data,
+		SchemaVersion: entity.SchemaVersion,
 	}, nil
 }
 
@@ -89,5 +105,9 @@ func (entity *Config) fillFrom(_ Env, _ *bbolt.Tx, boltConfig *db.Config) error
 	entity.Name = boltConfig.Name
 	entity.TypeId = boltConfig.Type
 	entity.Data = boltConfig.Data
+	entity.SchemaVersion = boltConfig.SchemaVersion
+	if entity.SchemaVersion == 0 {
+		entity.SchemaVersion = 1
+	}
 	return nil
 }
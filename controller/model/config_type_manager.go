@@ -27,6 +27,7 @@ import (
 	"github.com/openziti/ziti/controller/db"
 	"github.com/openziti/ziti/controller/fields"
 	"github.com/openziti/ziti/controller/models"
+	cmap "github.com/orcaman/concurrent-map/v2"
 	"go.etcd.io/bbolt"
 	"google.golang.org/protobuf/proto"
 )
@@ -38,6 +39,7 @@ const (
 func NewConfigTypeManager(env Env) *ConfigTypeManager {
 	manager := &ConfigTypeManager{
 		baseEntityManager: newBaseEntityManager[*ConfigType, *db.ConfigType](env, env.GetStores().ConfigType),
+		migrations:        cmap.New[[]*ConfigMigration](),
 	}
 	manager.impl = manager
 
@@ -48,6 +50,7 @@ func NewConfigTypeManager(env Env) *ConfigTypeManager {
 
 type ConfigTypeManager struct {
 	baseEntityManager[*ConfigType, *db.ConfigType]
+	migrations cmap.ConcurrentMap[string, []*ConfigMigration]
 }
 
 func (self *ConfigTypeManager) newModelEntity() *ConfigType {
@@ -125,11 +128,20 @@ func (self *ConfigTypeManager) Marshall(entity *ConfigType) ([]byte, error) {
 		return nil, err
 	}
 
+	var schemaHistory []byte
+	if len(entity.SchemaHistory) > 0 {
+		if schemaHistory, err = json.Marshal(entity.SchemaHistory); err != nil {
+			return nil, err
+		}
+	}
+
 	msg := &edge_cmd_pb.ConfigType{
-		Id:     entity.Id,
-		Name:   entity.Name,
-		Schema: schema,
-		Tags:   tags,
+		Id:            entity.Id,
+		Name:          entity.Name,
+		Schema:        schema,
+		Tags:          tags,
+		SchemaVersion: entity.SchemaVersion,
+		SchemaHistory: schemaHistory,
 	}
 
 	return proto.Marshal(msg)
@@ -146,12 +158,21 @@ func (self *ConfigTypeManager) Unmarshall(bytes []byte) (*ConfigType, error) {
 		return nil, err
 	}
 
+	var schemaHistory map[uint32]map[string]interface{}
+	if len(msg.SchemaHistory) > 0 {
+		if err := json.Unmarshal(msg.SchemaHistory, &schemaHistory); err != nil {
+			return nil, err
+		}
+	}
+
 	return &ConfigType{
 		BaseEntity: models.BaseEntity{
 			Id:   msg.Id,
 			Tags: edge_cmd_pb.DecodeTags(msg.Tags),
 		},
-		Name:   msg.Name,
-		Schema: schema,
+		Name:          msg.Name,
+		Schema:        schema,
+		SchemaVersion: msg.SchemaVersion,
+		SchemaHistory: schemaHistory,
 	}, nil
 }
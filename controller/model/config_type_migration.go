@@ -0,0 +1,243 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+// ConfigMigrationFunc upgrades config data written against one schema version to a later version
+type ConfigMigrationFunc func(data map[string]interface{}) (map[string]interface{}, error)
+
+// ConfigMigration upgrades configs of a config type from FromVersion to ToVersion. Migrations are chained, so a config
+// at version 1 can be brought to version 3 by a 1->2 and a 2->3 migration.
+type ConfigMigration struct {
+	FromVersion uint32
+	ToVersion   uint32
+	Migrate     ConfigMigrationFunc
+}
+
+// ConfigMigrationResult reports what happened to the configs of a config type when migrating them to the current
+// schema version
+type ConfigMigrationResult struct {
+	ConfigTypeId  string
+	SchemaVersion uint32
+	// Migrated holds the ids of configs which were upgraded to the current schema version
+	Migrated []string
+	// Current holds the ids of configs which were already at the current schema version
+	Current []string
+	// Pending holds the ids of configs for which no migration path to the current schema version is registered. They
+	// remain valid at their existing schema version.
+	Pending []string
+	// Failed holds the errors of configs whose migration or validation failed, keyed by config id. They also remain
+	// at their existing schema version.
+	Failed map[string]string
+}
+
+// RegisterMigration adds a migration for configs of the config type with the given name. Migrations run on the
+// controller when a new schema version is published, or when migrations are re-run explicitly.
+func (self *ConfigTypeManager) RegisterMigration(configTypeName string, migration *ConfigMigration) error {
+	if migration == nil || migration.Migrate == nil {
+		return errors.New("migration function must be provided")
+	}
+	if migration.ToVersion <= migration.FromVersion {
+		return errors.Errorf("invalid migration for config type %v, to version %d must be greater than from version %d",
+			configTypeName, migration.ToVersion, migration.FromVersion)
+	}
+
+	self.migrations.Upsert(configTypeName, nil, func(exist bool, current []*ConfigMigration, _ []*ConfigMigration) []*ConfigMigration {
+		result := append([]*ConfigMigration{}, current...)
+		return append(result, migration)
+	})
+	return nil
+}
+
+// getMigrationPath returns the chain of migrations which takes configs from the given version to the target version,
+// preferring the largest steps, or nil if there is no such chain
+func (self *ConfigTypeManager) getMigrationPath(configTypeName string, from, to uint32) []*ConfigMigration {
+	migrations, _ := self.migrations.Get(configTypeName)
+
+	var result []*ConfigMigration
+	for from < to {
+		var next *ConfigMigration
+		for _, migration := range migrations {
+			if migration.FromVersion == from && migration.ToVersion <= to && (next == nil || migration.ToVersion > next.ToVersion) {
+				next = migration
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		result = append(result, next)
+		from = next.ToVersion
+	}
+	return result
+}
+
+// PublishSchemaVersion makes the given schema the current schema of the config type. The previous schema is kept, so
+// existing configs remain valid, and registered migrations are then used to upgrade configs to the new version. If
+// version is 0, the version following the current version is used.
+func (self *ConfigTypeManager) PublishSchemaVersion(id string, version uint32, schema map[string]interface{}, ctx *change.Context) (*ConfigMigrationResult, error) {
+	configType, err := self.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	currentVersion := configType.GetSchemaVersion()
+	if version == 0 {
+		version = currentVersion + 1
+	}
+
+	if version <= currentVersion {
+		return nil, errorz.NewFieldError(fmt.Sprintf("schema version must be greater than the current version %d", currentVersion),
+			db.FieldConfigTypeSchemaVersion, version)
+	}
+
+	if len(schema) == 0 {
+		return nil, errorz.NewFieldError("schema is required", db.FieldConfigTypeSchema, schema)
+	}
+
+	history := map[uint32]map[string]interface{}{}
+	for k, v := range configType.SchemaHistory {
+		history[k] = v
+	}
+	if len(configType.Schema) > 0 {
+		history[currentVersion] = configType.Schema
+	}
+
+	configType.Schema = schema
+	configType.SchemaVersion = version
+	configType.SchemaHistory = history
+
+	checker := fields.UpdatedFieldsMap{
+		db.FieldConfigTypeSchema:        struct{}{},
+		db.FieldConfigTypeSchemaVersion: struct{}{},
+		db.FieldConfigTypeSchemaHistory: struct{}{},
+	}
+
+	if err = self.Update(configType, checker, ctx); err != nil {
+		return nil, err
+	}
+
+	return self.MigrateConfigs(id, ctx)
+}
+
+// MigrateConfigs upgrades the configs of the given config type to the current schema version using the registered
+// migrations. Configs which can't be migrated are left at their existing schema version and reported in the result.
+func (self *ConfigTypeManager) MigrateConfigs(id string, ctx *change.Context) (*ConfigMigrationResult, error) {
+	configType, err := self.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var configIds []string
+	err = self.iterateRelatedEntities(id, db.EntityTypeConfigs, func(tx *bbolt.Tx, relatedId string) error {
+		configIds = append(configIds, relatedId)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	target := configType.GetSchemaVersion()
+	result := &ConfigMigrationResult{
+		ConfigTypeId:  id,
+		SchemaVersion: target,
+		Failed:        map[string]string{},
+	}
+
+	log := pfxlog.Logger().WithField("configTypeId", id).WithField("schemaVersion", target)
+	configManager := self.env.GetManagers().Config
+
+	for _, configId := range configIds {
+		config, err := configManager.Read(configId)
+		if err != nil {
+			result.Failed[configId] = err.Error()
+			continue
+		}
+
+		if config.SchemaVersion >= target {
+			result.Current = append(result.Current, configId)
+			continue
+		}
+
+		path := self.getMigrationPath(configType.Name, config.SchemaVersion, target)
+		if path == nil {
+			result.Pending = append(result.Pending, configId)
+			continue
+		}
+
+		data := config.Data
+		for _, migration := range path {
+			if data, err = migration.Migrate(data); err != nil {
+				err = errors.Wrapf(err, "migration from schema version %d to %d failed", migration.FromVersion, migration.ToVersion)
+				break
+			}
+		}
+
+		if err == nil {
+			config.Data = data
+			config.SchemaVersion = target
+			checker := fields.UpdatedFieldsMap{
+				db.FieldConfigData:          struct{}{},
+				db.FieldConfigSchemaVersion: struct{}{},
+			}
+			err = configManager.Update(config, checker, ctx)
+		}
+
+		if err != nil {
+			log.WithField("configId", configId).WithError(err).Warn("unable to migrate config to current schema version")
+			result.Failed[configId] = err.Error()
+			continue
+		}
+
+		result.Migrated = append(result.Migrated, configId)
+	}
+
+	sort.Strings(result.Migrated)
+	sort.Strings(result.Current)
+	sort.Strings(result.Pending)
+
+	log.Infof("config migration complete, migrated: %d, current: %d, pending: %d, failed: %d",
+		len(result.Migrated), len(result.Current), len(result.Pending), len(result.Failed))
+
+	return result, nil
+}
+
+// GetConfigCountsBySchemaVersion returns how many configs of the given config type are at each schema version
+func (self *ConfigTypeManager) GetConfigCountsBySchemaVersion(id string) (map[uint32]int, error) {
+	result := map[uint32]int{}
+	configManager := self.env.GetManagers().Config
+	err := self.iterateRelatedEntities(id, db.EntityTypeConfigs, func(tx *bbolt.Tx, relatedId string) error {
+		config, err := configManager.readInTx(tx, relatedId)
+		if err != nil {
+			return err
+		}
+		result[config.SchemaVersion]++
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,82 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigTypeManager_getMigrationPath(t *testing.T) {
+	noop := func(data map[string]interface{}) (map[string]interface{}, error) {
+		return data, nil
+	}
+
+	manager := &ConfigTypeManager{migrations: cmap.New[[]*ConfigMigration]()}
+
+	req := require.New(t)
+	req.NoError(manager.RegisterMigration("test", &ConfigMigration{FromVersion: 1, ToVersion: 2, Migrate: noop}))
+	req.NoError(manager.RegisterMigration("test", &ConfigMigration{FromVersion: 2, ToVersion: 3, Migrate: noop}))
+	req.NoError(manager.RegisterMigration("test", &ConfigMigration{FromVersion: 2, ToVersion: 4, Migrate: noop}))
+	req.Error(manager.RegisterMigration("test", &ConfigMigration{FromVersion: 3, ToVersion: 3, Migrate: noop}))
+	req.Error(manager.RegisterMigration("test", &ConfigMigration{FromVersion: 3, ToVersion: 4}))
+
+	t.Run("chains migrations", func(t *testing.T) {
+		req := require.New(t)
+		path := manager.getMigrationPath("test", 1, 3)
+		req.Len(path, 2)
+		req.Equal(uint32(2), path[0].ToVersion)
+		req.Equal(uint32(3), path[1].ToVersion)
+	})
+
+	t.Run("prefers the largest step", func(t *testing.T) {
+		req := require.New(t)
+		path := manager.getMigrationPath("test", 1, 4)
+		req.Len(path, 2)
+		req.Equal(uint32(4), path[1].ToVersion)
+	})
+
+	t.Run("returns nil if there is no path", func(t *testing.T) {
+		req := require.New(t)
+		req.Nil(manager.getMigrationPath("test", 3, 4))
+		req.Nil(manager.getMigrationPath("other", 1, 2))
+	})
+}
+
+func TestConfigType_SchemaVersions(t *testing.T) {
+	req := require.New(t)
+
+	configType := &ConfigType{
+		Schema: map[string]interface{}{"type": "object"},
+	}
+	req.Equal(uint32(1), configType.GetSchemaVersion())
+	req.True(configType.HasSchemaVersion(1))
+	req.False(configType.HasSchemaVersion(2))
+
+	configType.SchemaVersion = 3
+	configType.SchemaHistory = map[uint32]map[string]interface{}{
+		1: {"type": "object", "required": []interface{}{"a"}},
+		2: {"type": "object", "required": []interface{}{"b"}},
+	}
+	req.Equal([]uint32{1, 2, 3}, configType.GetSchemaVersions())
+	req.Equal(configType.Schema, configType.GetSchema(3))
+	req.Equal(configType.SchemaHistory[2], configType.GetSchema(2))
+	req.Nil(configType.GetSchema(4))
+	req.True(configType.HasSchemaVersion(2))
+}
@@ -25,19 +25,72 @@ import (
 	"github.com/pkg/errors"
 	"github.com/xeipuuv/gojsonschema"
 	"go.etcd.io/bbolt"
+	"sort"
 )
 
 type ConfigType struct {
 	models.BaseEntity
 	Name   string
 	Schema map[string]interface{}
+	// SchemaVersion is the version of Schema. Config types created before schema versioning are at version 1.
+	SchemaVersion uint32
+	// SchemaHistory holds the schemas of previously published versions, keyed by version
+	SchemaHistory map[uint32]map[string]interface{}
+}
+
+// GetSchemaVersion returns the current schema version, treating an unset version as version 1
+func (entity *ConfigType) GetSchemaVersion() uint32 {
+	if entity.SchemaVersion == 0 {
+		return 1
+	}
+	return entity.SchemaVersion
+}
+
+// HasSchemaVersion returns true if the given version is the current version or a previously published version
+func (entity *ConfigType) HasSchemaVersion(version uint32) bool {
+	if version == entity.GetSchemaVersion() {
+		return true
+	}
+	_, found := entity.SchemaHistory[version]
+	return found
+}
+
+// GetSchemaVersions returns all known schema versions, in ascending order
+func (entity *ConfigType) GetSchemaVersions() []uint32 {
+	result := []uint32{entity.GetSchemaVersion()}
+	for version := range entity.SchemaHistory {
+		if version != entity.GetSchemaVersion() {
+			result = append(result, version)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// GetSchema returns the schema for the given version, or nil if the version isn't known
+func (entity *ConfigType) GetSchema(version uint32) map[string]interface{} {
+	if version == entity.GetSchemaVersion() {
+		return entity.Schema
+	}
+	return entity.SchemaHistory[version]
 }
 
 func (entity *ConfigType) GetCompiledSchema() (*gojsonschema.Schema, error) {
-	if len(entity.Schema) == 0 {
-		return nil, errors.Errorf("no schema defined on config type %v", entity.Name)
+	return entity.GetCompiledSchemaForVersion(entity.GetSchemaVersion())
+}
+
+func (entity *ConfigType) GetCompiledSchemaForVersion(version uint32) (*gojsonschema.Schema, error) {
+	schema := entity.GetSchema(version)
+	if len(schema) == 0 {
+		return nil, errors.Errorf("no schema defined on config type %v for version %d", entity.Name, version)
 	}
-	entitySchemaLoader := gojsonschema.NewGoLoader(entity.Schema)
+	return compileConfigTypeSchema(schema)
+}
+
+func compileConfigTypeSchema(schema map[string]interface{}) (*gojsonschema.Schema, error) {
+	entitySchemaLoader := gojsonschema.NewGoLoader(schema)
 	schemaLoader := gojsonschema.NewSchemaLoader()
 	return schemaLoader.Compile(entitySchemaLoader)
 }
@@ -48,7 +101,7 @@ func (entity *ConfigType) toBoltEntity() (*db.ConfigType, error) {
 	}
 
 	if len(entity.Schema) > 0 {
-		if _, err := entity.GetCompiledSchema(); err != nil {
+		if _, err := compileConfigTypeSchema(entity.Schema); err != nil {
 			return nil, errorz.NewFieldError(fmt.Sprintf("invalid schema %v", err), "schema", entity.Schema)
 		}
 		if entity.Schema["type"] != "object" {
@@ -59,6 +112,8 @@ func (entity *ConfigType) toBoltEntity() (*db.ConfigType, error) {
 		BaseExtEntity: *boltz.NewExtEntity(entity.Id, entity.Tags),
 		Name:          entity.Name,
 		Schema:        entity.Schema,
+		SchemaVersion: entity.SchemaVersion,
+		SchemaHistory: entity.SchemaHistory,
 	}, nil
 }
 
@@ -66,7 +121,21 @@ func (entity *ConfigType) toBoltEntityForCreate(*bbolt.Tx, Env) (*db.ConfigType,
 	return entity.toBoltEntity()
 }
 
-func (entity *ConfigType) toBoltEntityForUpdate(*bbolt.Tx, Env, boltz.FieldChecker) (*db.ConfigType, error) {
+func (entity *ConfigType) toBoltEntityForUpdate(tx *bbolt.Tx, env Env, _ boltz.FieldChecker) (*db.ConfigType, error) {
+	current, err := env.GetManagers().ConfigType.readInTx(tx, entity.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// updates which don't specify a version, such as edits through the REST API, change the current schema in place
+	if entity.SchemaVersion == 0 {
+		entity.SchemaVersion = current.SchemaVersion
+		entity.SchemaHistory = current.SchemaHistory
+	} else if entity.SchemaVersion < current.GetSchemaVersion() {
+		return nil, errorz.NewFieldError(fmt.Sprintf("schema version may not be lowered from %d", current.GetSchemaVersion()),
+			db.FieldConfigTypeSchemaVersion, entity.SchemaVersion)
+	}
+
 	return entity.toBoltEntity()
 }
 
@@ -74,5 +143,7 @@ func (entity *ConfigType) fillFrom(_ Env, _ *bbolt.Tx, boltConfigType *db.Config
 	entity.FillCommon(boltConfigType)
 	entity.Name = boltConfigType.Name
 	entity.Schema = boltConfigType.Schema
+	entity.SchemaVersion = boltConfigType.SchemaVersion
+	entity.SchemaHistory = boltConfigType.SchemaHistory
 	return nil
 }
@@ -162,6 +162,12 @@ func (self *EdgeRouterManager) ApplyUpdate(cmd *command.UpdateEntityCommand[*Edg
 		} else {
 			checker = &AndFieldChecker{first: self.allowedFieldsChecker, second: cmd.UpdatedFields}
 		}
+	} else if checker == nil {
+		// drain state is managed through the fabric router and isn't part of the edge router model
+		checker = NotFieldChecker{
+			db.FieldRouterDraining:      struct{}{},
+			db.FieldRouterDrainDeadline: struct{}{},
+		}
 	}
 	return self.updateEntity(cmd.Entity, checker, ctx)
 }
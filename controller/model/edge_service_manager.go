@@ -71,10 +71,15 @@ func (self *EdgeServiceManager) ApplyCreate(cmd *command.CreateEntityCommand[*Ed
 }
 
 func (self *EdgeServiceManager) Update(entity *EdgeService, checker fields.UpdatedFields, ctx *change.Context) error {
+	return self.Dispatch(self.NewUpdateCommand(entity, checker, ctx))
+}
+
+// NewUpdateCommand returns a command which will update the given service
+func (self *EdgeServiceManager) NewUpdateCommand(entity *EdgeService, checker fields.UpdatedFields, ctx *change.Context) *command.UpdateEntityCommand[*EdgeService] {
 	if checker != nil {
 		checker = checker.RemoveFields("encryptionRequired")
 	}
-	return DispatchUpdate[*EdgeService](self, entity, checker, ctx)
+	return NewUpdateEntityCommand[*EdgeService](self, entity, checker, ctx)
 }
 
 func (self *EdgeServiceManager) ApplyUpdate(cmd *command.UpdateEntityCommand[*EdgeService], ctx boltz.MutateContext) error {
@@ -222,6 +227,7 @@ func (self *EdgeServiceManager) Marshall(entity *EdgeService) ([]byte, error) {
 		Id:                 entity.Id,
 		Name:               entity.Name,
 		MaxIdleTime:        int64(entity.MaxIdleTime),
+		MaxCircuitLifetime: int64(entity.MaxCircuitLifetime),
 		Tags:               tags,
 		TerminatorStrategy: entity.TerminatorStrategy,
 		RoleAttributes:     entity.RoleAttributes,
@@ -245,6 +251,7 @@ func (self *EdgeServiceManager) Unmarshall(bytes []byte) (*EdgeService, error) {
 		},
 		Name:               msg.Name,
 		MaxIdleTime:        time.Duration(msg.MaxIdleTime),
+		MaxCircuitLifetime: time.Duration(msg.MaxCircuitLifetime),
 		TerminatorStrategy: msg.TerminatorStrategy,
 		RoleAttributes:     msg.RoleAttributes,
 		Configs:            msg.Configs,
@@ -390,6 +397,48 @@ func (self *EdgeServiceManager) GetPolicyPostureChecks(identityId, serviceId str
 	return policyIdToChecks
 }
 
+type PolicySchedule struct {
+	Schedule   *ServicePolicySchedule
+	PolicyType db.PolicyType
+	PolicyName string
+}
+
+// GetPolicySchedules returns the service policies granting the identity access to the service, keyed by policy id.
+// Policies without a schedule are included with a nil schedule.
+func (self *EdgeServiceManager) GetPolicySchedules(identityId, serviceId string) map[string]*PolicySchedule {
+	result := map[string]*PolicySchedule{}
+
+	servicePolicyStore := self.env.GetStores().ServicePolicy
+	serviceLinks := servicePolicyStore.GetLinkCollection(db.EntityTypeServices)
+
+	_ = self.GetDb().View(func(tx *bbolt.Tx) error {
+		policyCursor := self.env.GetStores().Identity.GetRelatedEntitiesCursor(tx, identityId, db.EntityTypeServicePolicies, true)
+		policyCursor = ast.NewFilteredCursor(policyCursor, func(policyId []byte) bool {
+			return serviceLinks.IsLinked(tx, policyId, []byte(serviceId))
+		})
+
+		for policyCursor.IsValid() {
+			policyId := string(policyCursor.Current())
+			policyCursor.Next()
+
+			policy, err := servicePolicyStore.LoadById(tx, policyId)
+			if err != nil {
+				pfxlog.Logger().WithError(err).WithField("policyId", policyId).Error("unable to load service policy")
+				continue
+			}
+
+			result[policyId] = &PolicySchedule{
+				Schedule:   newServicePolicyScheduleFromBolt(policy.Schedule),
+				PolicyType: policy.PolicyType,
+				PolicyName: policy.Name,
+			}
+		}
+		return nil
+	})
+
+	return result
+}
+
 type ServiceDetailLister struct {
 	manager *EdgeServiceManager
 }
@@ -30,6 +30,7 @@ type EdgeService struct {
 	models.BaseEntity
 	Name               string        `json:"name"`
 	MaxIdleTime        time.Duration `json:"maxIdleTime"`
+	MaxCircuitLifetime time.Duration `json:"maxCircuitLifetime"`
 	TerminatorStrategy string        `json:"terminatorStrategy"`
 	RoleAttributes     []string      `json:"roleAttributes"`
 	Configs            []string      `json:"configs"`
@@ -46,6 +47,7 @@ func (entity *EdgeService) toBoltEntity(tx *bbolt.Tx, env Env) (*db.EdgeService,
 			BaseExtEntity:      *boltz.NewExtEntity(entity.Id, entity.Tags),
 			Name:               entity.Name,
 			MaxIdleTime:        entity.MaxIdleTime,
+			MaxCircuitLifetime: entity.MaxCircuitLifetime,
 			TerminatorStrategy: entity.TerminatorStrategy,
 		},
 		RoleAttributes:     entity.RoleAttributes,
@@ -89,6 +91,7 @@ func (entity *EdgeService) toBoltEntityForUpdate(tx *bbolt.Tx, env Env, _ boltz.
 func (entity *EdgeService) fillFrom(_ Env, _ *bbolt.Tx, boltService *db.EdgeService) error {
 	entity.FillCommon(boltService)
 	entity.Name = boltService.Name
+	entity.MaxCircuitLifetime = boltService.MaxCircuitLifetime
 	entity.TerminatorStrategy = boltService.TerminatorStrategy
 	entity.RoleAttributes = boltService.RoleAttributes
 	entity.Configs = boltService.Configs
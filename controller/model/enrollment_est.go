@@ -0,0 +1,279 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/common/cert"
+	"github.com/openziti/ziti/controller/apierror"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/fields"
+)
+
+// EstEnroll processes an EST (RFC 7030) simpleenroll request, which is authenticated by an enrollment token. Identity
+// ott enrollments are processed by the ott enrollment method. Router enrollments issue the router's client
+// certificate. Routers request their server certificate separately, see EstEnrollRouterServerCert. Returns the PEM
+// chain of the issued certificate.
+func (self *EnrollmentManager) EstEnroll(token string, csrPem []byte, ctx *change.Context) (string, error) {
+	enrollment, err := self.ReadByToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	if enrollment == nil {
+		return "", apierror.NewInvalidEnrollmentToken()
+	}
+
+	if enrollment.ExpiresAt == nil || enrollment.ExpiresAt.Before(time.Now()) {
+		return "", apierror.NewEnrollmentExpired()
+	}
+
+	if enrollment.IdentityId != nil {
+		// only ott enrollments can be processed with a csr alone
+		if enrollment.Method != db.MethodEnrollOtt {
+			return "", apierror.NewInvalidEnrollMethod()
+		}
+
+		result, err := self.Enroll(&EnrollmentContextHttp{
+			Data: &EnrollmentData{
+				ClientCsrPem: csrPem,
+			},
+			Token:         token,
+			Method:        db.MethodEnrollOtt,
+			ChangeContext: ctx,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		certs, ok := result.Content.(*rest_model.EnrollmentCerts)
+		if !ok {
+			return "", errorz.NewUnhandled(fmt.Errorf("unexpected enrollment result content %T", result.Content))
+		}
+		return certs.Cert, nil
+	}
+
+	if enrollment.EdgeRouterId != nil {
+		edgeRouter, _ := self.env.GetManagers().EdgeRouter.Read(*enrollment.EdgeRouterId)
+		if edgeRouter == nil {
+			return "", apierror.NewInvalidEnrollmentToken()
+		}
+
+		ctx.SetChangeAuthorType(change.AuthorTypeRouter).SetChangeAuthorId(edgeRouter.Id).SetChangeAuthorName(edgeRouter.Name)
+
+		chainPem, err := self.estIssueEdgeRouterClientCert(edgeRouter, csrPem, ctx)
+		if err != nil {
+			return "", err
+		}
+
+		if err = self.Delete(enrollment.Id, ctx); err != nil {
+			return "", fmt.Errorf("could not delete enrollment: %w", err)
+		}
+		return chainPem, nil
+	}
+
+	if enrollment.TransitRouterId != nil {
+		txRouter, _ := self.env.GetManagers().TransitRouter.Read(*enrollment.TransitRouterId)
+		if txRouter == nil {
+			return "", apierror.NewInvalidEnrollmentToken()
+		}
+
+		ctx.SetChangeAuthorType(change.AuthorTypeRouter).SetChangeAuthorId(txRouter.Id).SetChangeAuthorName(txRouter.Name)
+
+		chainPem, err := self.estIssueTransitRouterClientCert(txRouter, csrPem, ctx)
+		if err != nil {
+			return "", err
+		}
+
+		if err = self.Delete(enrollment.Id, ctx); err != nil {
+			return "", fmt.Errorf("could not delete enrollment: %w", err)
+		}
+		return chainPem, nil
+	}
+
+	return "", apierror.NewInvalidEnrollmentToken()
+}
+
+// EstEnrollRouterServerCert issues a server certificate to an enrolled router, identified by the fingerprint of its
+// client certificate. The certificate gets the SANs requested in the CSR.
+func (self *EnrollmentManager) EstEnrollRouterServerCert(peerCerts []*x509.Certificate, csrPem []byte) (string, error) {
+	fingerprint := self.estPeerFingerprint(peerCerts)
+	if fingerprint == "" {
+		return "", errorz.NewUnauthorized()
+	}
+
+	edgeRouter, _ := self.env.GetManagers().EdgeRouter.ReadOneByFingerprint(fingerprint)
+	txRouter, _ := self.env.GetManagers().TransitRouter.ReadOneByFingerprint(fingerprint)
+	if edgeRouter == nil && txRouter == nil {
+		return "", errorz.NewUnauthorized()
+	}
+
+	enrollmentModule := self.env.GetEnrollRegistry().GetByMethod(MethodEnrollEdgeRouterOtt).(*EnrollModuleEr)
+	serverCertRaw, err := enrollmentModule.ProcessServerCsrPem(csrPem)
+	if err != nil {
+		return "", err
+	}
+
+	return self.GetCertChainPem(serverCertRaw)
+}
+
+// EstReenroll processes an EST simplereenroll request, authenticated by the client certificate being renewed. Routers
+// get a new client certificate, which replaces the current one. Identities get a new certificate for their
+// certificate authenticator, which replaces the current one without a separate verification step.
+func (self *EnrollmentManager) EstReenroll(peerCerts []*x509.Certificate, csrPem []byte, ctx *change.Context) (string, error) {
+	if !estPeerCertValid(peerCerts, time.Now()) {
+		return "", errorz.NewUnauthorized()
+	}
+
+	fingerprint := self.estPeerFingerprint(peerCerts)
+	if fingerprint == "" {
+		return "", errorz.NewUnauthorized()
+	}
+
+	if edgeRouter, _ := self.env.GetManagers().EdgeRouter.ReadOneByFingerprint(fingerprint); edgeRouter != nil {
+		ctx.SetChangeAuthorType(change.AuthorTypeRouter).SetChangeAuthorId(edgeRouter.Id).SetChangeAuthorName(edgeRouter.Name)
+		return self.estIssueEdgeRouterClientCert(edgeRouter, csrPem, ctx)
+	}
+
+	if txRouter, _ := self.env.GetManagers().TransitRouter.ReadOneByFingerprint(fingerprint); txRouter != nil {
+		ctx.SetChangeAuthorType(change.AuthorTypeRouter).SetChangeAuthorId(txRouter.Id).SetChangeAuthorName(txRouter.Name)
+		return self.estIssueTransitRouterClientCert(txRouter, csrPem, ctx)
+	}
+
+	authenticator, _ := self.env.GetManagers().Authenticator.ReadByFingerprint(fingerprint)
+	if authenticator == nil {
+		return "", errorz.NewUnauthorized()
+	}
+
+	identity, _ := self.env.GetManagers().Identity.Read(authenticator.IdentityId)
+	if identity == nil {
+		return "", errorz.NewUnauthorized()
+	}
+
+	ctx.SetChangeAuthorType(change.AuthorTypeIdentity).SetChangeAuthorId(identity.Id).SetChangeAuthorName(identity.Name)
+
+	authenticatorManager := self.env.GetManagers().Authenticator
+	chainPem, err := authenticatorManager.ExtendCertForIdentity(identity.Id, authenticator.Id, peerCerts, string(csrPem), ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err = authenticatorManager.VerifyExtendCertForIdentity(true, "", identity.Id, authenticator.Id, string(chainPem), ctx); err != nil {
+		return "", err
+	}
+
+	return string(chainPem), nil
+}
+
+// estPeerFingerprint returns the fingerprint of the first non-CA client certificate, or an empty string if there
+// isn't one
+func (self *EnrollmentManager) estPeerFingerprint(peerCerts []*x509.Certificate) string {
+	for _, peerCert := range peerCerts {
+		if !peerCert.IsCA {
+			return self.env.GetFingerprintGenerator().FromCert(peerCert)
+		}
+	}
+	return ""
+}
+
+// estPeerCertValid returns true if the first non-CA client certificate is within its validity period at the given time.
+// Reenrollment is authenticated by fingerprint, so an expired or not yet valid certificate would otherwise be accepted.
+func estPeerCertValid(peerCerts []*x509.Certificate, now time.Time) bool {
+	for _, peerCert := range peerCerts {
+		if !peerCert.IsCA {
+			return !now.Before(peerCert.NotBefore) && !now.After(peerCert.NotAfter)
+		}
+	}
+	return false
+}
+
+func (self *EnrollmentManager) estIssueEdgeRouterClientCert(edgeRouter *EdgeRouter, csrPem []byte, ctx *change.Context) (string, error) {
+	enrollmentModule := self.env.GetEnrollRegistry().GetByMethod(MethodEnrollEdgeRouterOtt).(*EnrollModuleEr)
+
+	clientCertRaw, err := enrollmentModule.ProcessClientCsrPem(csrPem, edgeRouter.Id)
+	if err != nil {
+		return "", err
+	}
+
+	clientChainPem, err := self.GetCertChainPem(clientCertRaw)
+	if err != nil {
+		return "", err
+	}
+
+	fingerprint := self.env.GetFingerprintGenerator().FromRaw(clientCertRaw)
+	edgeRouter.CertPem = &clientChainPem
+	edgeRouter.Fingerprint = &fingerprint
+	edgeRouter.IsVerified = true
+
+	err = self.env.GetManagers().EdgeRouter.Update(edgeRouter, true, fields.UpdatedFieldsMap{
+		db.FieldEdgeRouterCertPEM:    struct{}{},
+		db.FieldRouterFingerprint:    struct{}{},
+		db.FieldEdgeRouterIsVerified: struct{}{},
+	}, ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not update edge router: %w", err)
+	}
+
+	return clientChainPem, nil
+}
+
+func (self *EnrollmentManager) estIssueTransitRouterClientCert(txRouter *TransitRouter, csrPem []byte, ctx *change.Context) (string, error) {
+	clientCsr, err := cert.ParseCsrPem(csrPem)
+	if err != nil {
+		apiErr := apierror.NewCouldNotProcessCsr()
+		apiErr.Cause = err
+		apiErr.AppendCause = true
+		return "", apiErr
+	}
+
+	signingOpts := &cert.SigningOpts{
+		DNSNames:       clientCsr.DNSNames,
+		EmailAddresses: clientCsr.EmailAddresses,
+		IPAddresses:    clientCsr.IPAddresses,
+		URIs:           clientCsr.URIs,
+	}
+
+	// as with trott enrollment, the common name is always the router id
+	clientCsr.Subject.CommonName = txRouter.Id
+
+	clientCertRaw, err := self.env.GetControlClientCsrSigner().SignCsr(clientCsr, signingOpts)
+	if err != nil {
+		return "", apierror.NewCouldNotProcessCsr()
+	}
+
+	clientChainPem, err := self.GetCertChainPem(clientCertRaw)
+	if err != nil {
+		return "", err
+	}
+
+	fingerprint := self.env.GetFingerprintGenerator().FromRaw(clientCertRaw)
+	txRouter.Fingerprint = &fingerprint
+	txRouter.IsVerified = true
+
+	err = self.env.GetManagers().TransitRouter.Update(txRouter, true, nil, ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not update router: %w", err)
+	}
+
+	return clientChainPem, nil
+}
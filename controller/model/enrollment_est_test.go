@@ -0,0 +1,50 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstPeerCertValid(t *testing.T) {
+	now := time.Now()
+	ca := &x509.Certificate{IsCA: true, NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+
+	t.Run("current certificate is valid", func(t *testing.T) {
+		leaf := &x509.Certificate{NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+		require.True(t, estPeerCertValid([]*x509.Certificate{leaf, ca}, now))
+	})
+
+	t.Run("expired certificate is invalid", func(t *testing.T) {
+		leaf := &x509.Certificate{NotBefore: now.Add(-2 * time.Hour), NotAfter: now.Add(-time.Hour)}
+		require.False(t, estPeerCertValid([]*x509.Certificate{leaf, ca}, now))
+	})
+
+	t.Run("not yet valid certificate is invalid", func(t *testing.T) {
+		leaf := &x509.Certificate{NotBefore: now.Add(time.Hour), NotAfter: now.Add(2 * time.Hour)}
+		require.False(t, estPeerCertValid([]*x509.Certificate{leaf, ca}, now))
+	})
+
+	t.Run("chain without a client certificate is invalid", func(t *testing.T) {
+		require.False(t, estPeerCertValid([]*x509.Certificate{ca}, now))
+		require.False(t, estPeerCertValid(nil, now))
+	})
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/openziti/foundation/v2/errorz"
 	"github.com/openziti/metrics"
 	"github.com/openziti/sdk-golang/ziti"
+	"github.com/openziti/storage/ast"
 	"github.com/openziti/storage/boltz"
 	"github.com/openziti/ziti/common/eid"
 	"github.com/openziti/ziti/common/inspect"
@@ -97,6 +98,11 @@ func (self *IdentityManager) ApplyCreate(cmd *command.CreateEntityCommand[*Ident
 }
 
 func (self *IdentityManager) CreateWithEnrollments(identityModel *Identity, enrollmentsModels []*Enrollment, ctx *change.Context) error {
+	return self.Dispatch(self.NewCreateWithEnrollmentsCmd(identityModel, enrollmentsModels, ctx))
+}
+
+// NewCreateWithEnrollmentsCmd returns a command which will create the given identity along with its enrollments
+func (self *IdentityManager) NewCreateWithEnrollmentsCmd(identityModel *Identity, enrollmentsModels []*Enrollment, ctx *change.Context) *CreateIdentityWithEnrollmentsCmd {
 	if identityModel.Id == "" {
 		identityModel.Id = eid.New()
 	}
@@ -108,14 +114,12 @@ func (self *IdentityManager) CreateWithEnrollments(identityModel *Identity, enro
 		enrollment.IdentityId = &identityModel.Id
 	}
 
-	cmd := &CreateIdentityWithEnrollmentsCmd{
+	return &CreateIdentityWithEnrollmentsCmd{
 		manager:     self,
 		identity:    identityModel,
 		enrollments: enrollmentsModels,
 		ctx:         ctx,
 	}
-
-	return self.Dispatch(cmd)
 }
 
 func (self *IdentityManager) ApplyCreateWithEnrollments(cmd *CreateIdentityWithEnrollmentsCmd, ctx boltz.MutateContext) error {
@@ -471,6 +475,46 @@ func (self *IdentityManager) QueryRoleAttributes(queryString string) ([]string,
 	return self.queryRoleAttributes(index, queryString)
 }
 
+// RoleExpressionMatch is an identity selected by a role expression
+type RoleExpressionMatch struct {
+	Id             string
+	Name           string
+	RoleAttributes []string
+}
+
+// EvaluateRoleExpression returns the identities selected by the given role expression, in id order. At most limit
+// matches are returned, along with the total number of identities matched.
+func (self *IdentityManager) EvaluateRoleExpression(expression *db.RoleExpression, limit int) ([]*RoleExpressionMatch, int, error) {
+	store := self.env.GetStores().Identity
+	roleAttributesSymbol := store.GetRoleAttributesIndex().GetSymbol()
+	nameSymbol := store.GetSymbol(db.FieldName)
+
+	var matches []*RoleExpressionMatch
+	count := 0
+
+	err := self.GetDb().View(func(tx *bbolt.Tx) error {
+		for cursor := store.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
+			roleAttributes := roleAttributesSymbol.EvalStringList(tx, cursor.Current())
+			if !expression.Matches(roleAttributes) {
+				continue
+			}
+
+			count++
+			if len(matches) < limit {
+				_, name := nameSymbol.Eval(tx, cursor.Current())
+				matches = append(matches, &RoleExpressionMatch{
+					Id:             string(cursor.Current()),
+					Name:           string(name),
+					RoleAttributes: roleAttributes,
+				})
+			}
+		}
+		return nil
+	})
+
+	return matches, count, err
+}
+
 func (self *IdentityManager) PatchInfo(identity *Identity, checker boltz.FieldChecker, changeCtx *change.Context) error {
 	start := time.Now()
 
@@ -0,0 +1,102 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	// LinkCostFunctionLatency uses the link static cost plus the latency reported by each side of the link, in
+	// milliseconds. This is the default.
+	LinkCostFunctionLatency = "latency"
+
+	// LinkCostFunctionStatic uses only the link static cost, ignoring latency
+	LinkCostFunctionStatic = "static"
+)
+
+// LinkCostFunction calculates the cost of traversing a link. When selecting paths, lower cost links are preferred.
+// Implementations are called frequently during path selection and so should be cheap and must be safe for
+// concurrent use.
+type LinkCostFunction interface {
+	GetLinkCost(link *Link) int64
+}
+
+type LinkCostFunctionF func(link *Link) int64
+
+func (f LinkCostFunctionF) GetLinkCost(link *Link) int64 {
+	return f(link)
+}
+
+var linkCostFunctions = &linkCostRegistry{
+	functions: map[string]LinkCostFunction{
+		LinkCostFunctionLatency: LinkCostFunctionF(func(link *Link) int64 {
+			return link.GetCost()
+		}),
+		LinkCostFunctionStatic: LinkCostFunctionF(func(link *Link) int64 {
+			return int64(link.GetStaticCost())
+		}),
+	},
+}
+
+type linkCostRegistry struct {
+	functions map[string]LinkCostFunction
+	lock      sync.RWMutex
+}
+
+// RegisterLinkCostFunction makes a link cost function available for selection by name, via the network.linkCost
+// section of the controller configuration. Returns an error if a function is already registered with the given name.
+func RegisterLinkCostFunction(name string, f LinkCostFunction) error {
+	linkCostFunctions.lock.Lock()
+	defer linkCostFunctions.lock.Unlock()
+
+	if _, found := linkCostFunctions.functions[name]; found {
+		return fmt.Errorf("link cost function with name '%s' already registered", name)
+	}
+	linkCostFunctions.functions[name] = f
+	return nil
+}
+
+// GetLinkCostFunction returns the link cost function registered with the given name, if one exists
+func GetLinkCostFunction(name string) (LinkCostFunction, bool) {
+	linkCostFunctions.lock.RLock()
+	defer linkCostFunctions.lock.RUnlock()
+
+	result, found := linkCostFunctions.functions[name]
+	return result, found
+}
+
+// GetLinkCostFunctionNames returns the names of all registered link cost functions, in sorted order
+func GetLinkCostFunctionNames() []string {
+	linkCostFunctions.lock.RLock()
+	defer linkCostFunctions.lock.RUnlock()
+
+	var result []string
+	for name := range linkCostFunctions.functions {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// DefaultLinkCostFunction returns the latency based link cost function
+func DefaultLinkCostFunction() LinkCostFunction {
+	result, _ := GetLinkCostFunction(LinkCostFunctionLatency)
+	return result
+}
@@ -213,7 +213,7 @@ func (self *LinkManager) ConnectedNeighborsOfRouter(router *Router) []*Router {
 	return neighbors
 }
 
-func (self *LinkManager) LeastExpensiveLink(a, b *Router) (*Link, bool) {
+func (self *LinkManager) LeastExpensiveLink(a, b *Router, costF LinkCostFunction) (*Link, bool) {
 	var selected *Link
 	var cost int64 = math.MaxInt64
 
@@ -221,7 +221,7 @@ func (self *LinkManager) LeastExpensiveLink(a, b *Router) (*Link, bool) {
 	links := linksByRouter[b.Id]
 	for _, link := range links {
 		if link.IsUsable() {
-			linkCost := link.GetCost()
+			linkCost := costF.GetLinkCost(link)
 			if link.DstId == b.Id {
 				if linkCost < cost {
 					selected = link
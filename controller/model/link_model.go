@@ -41,6 +41,8 @@ type Link struct {
 	StaticCost  int32
 	connState   concurrenz.AtomicValue[*ctrl_pb.LinkConnState]
 	usable      atomic.Bool
+	srcPathMtu  atomic.Uint32
+	dstPathMtu  atomic.Uint32
 	lock        sync.Mutex
 }
 
@@ -154,6 +156,26 @@ func (link *Link) SetDstLatency(latency int64) {
 	link.RecalculateCost()
 }
 
+func (link *Link) SetSrcPathMtu(pathMtu uint32) {
+	link.srcPathMtu.Store(pathMtu)
+}
+
+func (link *Link) SetDstPathMtu(pathMtu uint32) {
+	link.dstPathMtu.Store(pathMtu)
+}
+
+// GetPathMtu returns the smallest path MTU reported by either end of the link, or 0 if neither end has reported one
+func (link *Link) GetPathMtu() uint32 {
+	return minNonZero(link.srcPathMtu.Load(), link.dstPathMtu.Load())
+}
+
+func minNonZero(a, b uint32) uint32 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
 func (link *Link) RecalculateCost() {
 	cost := int64(link.GetStaticCost()) + link.GetSrcLatency()/1_000_000 + link.GetDstLatency()/1_000_000
 	atomic.StoreInt64(&link.Cost, cost)
@@ -18,6 +18,8 @@ package model
 
 import (
 	"fmt"
+
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
 )
 
 type Path struct {
@@ -32,9 +34,14 @@ type Path struct {
 }
 
 func (self *Path) Cost(minRouterCost uint16) int64 {
+	return self.CostWith(minRouterCost, DefaultLinkCostFunction())
+}
+
+// CostWith returns the cost of the path, using the given function to calculate link costs
+func (self *Path) CostWith(minRouterCost uint16, costF LinkCostFunction) int64 {
 	var cost int64
 	for _, l := range self.Links {
-		cost += l.GetCost()
+		cost += costF.GetLinkCost(l)
 	}
 	for _, r := range self.Nodes {
 		cost += int64(max(r.Cost, minRouterCost))
@@ -94,3 +101,29 @@ func (self *Path) UsesLink(l *Link) bool {
 	}
 	return false
 }
+
+func (self *Path) UsesLinkId(linkId string) bool {
+	for _, l := range self.Links {
+		if l.Id == linkId {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPathMtu returns the smallest path MTU of the links in the path, or 0 if none of the links have reported one.
+// Payloads are only fragmented if every router on the path can reassemble them, so 0 is also returned if any router
+// on the path doesn't advertise payload fragmentation support.
+func (self *Path) GetPathMtu() uint32 {
+	for _, node := range self.Nodes {
+		if !node.HasCapability(ctrl_pb.RouterCapability_PayloadFragmentation) {
+			return 0
+		}
+	}
+
+	var result uint32
+	for _, l := range self.Links {
+		result = minNonZero(result, l.GetPathMtu())
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathGetPathMtu(t *testing.T) {
+	req := require.New(t)
+
+	newRouter := func(capabilities ...ctrl_pb.RouterCapability) *Router {
+		return &Router{Metadata: &ctrl_pb.RouterMetadata{Capabilities: capabilities}}
+	}
+
+	link1 := &Link{}
+	link1.SetSrcPathMtu(1400)
+	link2 := &Link{}
+	link2.SetDstPathMtu(1200)
+
+	path := &Path{
+		Nodes: []*Router{
+			newRouter(ctrl_pb.RouterCapability_PayloadFragmentation),
+			newRouter(ctrl_pb.RouterCapability_PayloadFragmentation),
+			newRouter(ctrl_pb.RouterCapability_LinkManagement, ctrl_pb.RouterCapability_PayloadFragmentation),
+		},
+		Links: []*Link{link1, link2},
+	}
+	req.Equal(uint32(1200), path.GetPathMtu())
+
+	// routers which can't reassemble fragments must never be sent a path MTU
+	path.Nodes[2] = newRouter(ctrl_pb.RouterCapability_LinkManagement)
+	req.Equal(uint32(0), path.GetPathMtu())
+
+	path.Nodes[2] = &Router{}
+	req.Equal(uint32(0), path.GetPathMtu())
+}
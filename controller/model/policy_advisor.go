@@ -33,30 +33,35 @@ type AdvisorServiceReachability struct {
 }
 
 func (advisor *PolicyAdvisor) AnalyzeServiceReachability(identityId, serviceId string) (*AdvisorServiceReachability, error) {
+	result, _, _, err := advisor.analyzeServiceReachability(identityId, serviceId)
+	return result, err
+}
+
+func (advisor *PolicyAdvisor) analyzeServiceReachability(identityId, serviceId string) (*AdvisorServiceReachability, map[string]*AdvisorEdgeRouter, map[string]struct{}, error) {
 	identity, err := advisor.env.GetManagers().Identity.Read(identityId)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	service, err := advisor.env.GetManagers().EdgeService.Read(serviceId)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	permissions, err := advisor.getServicePermissions(identityId, serviceId)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	edgeRouters, err := advisor.getIdentityEdgeRouters(identityId)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	serviceEdgeRouters, err := advisor.getServiceEdgeRouters(serviceId)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	result := &AdvisorServiceReachability{
@@ -74,7 +79,7 @@ func (advisor *PolicyAdvisor) AnalyzeServiceReachability(identityId, serviceId s
 		}
 	}
 
-	return result, nil
+	return result, edgeRouters, serviceEdgeRouters, nil
 }
 
 func (advisor *PolicyAdvisor) getServicePermissions(identityId, serviceId string) ([]string, error) {
@@ -0,0 +1,363 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openziti/ziti/controller/db"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	AdvisorSeverityError = "error"
+	AdvisorSeverityInfo  = "info"
+
+	AdvisorFindingIdentityDisabled         = "IDENTITY_DISABLED"
+	AdvisorFindingNoServicePolicy          = "NO_SERVICE_POLICY"
+	AdvisorFindingPolicyOutsideSchedule    = "POLICY_OUTSIDE_SCHEDULE"
+	AdvisorFindingPostureCheckFailed       = "POSTURE_CHECK_FAILED"
+	AdvisorFindingPostureNotEvaluated      = "POSTURE_NOT_EVALUATED"
+	AdvisorFindingNoIdentityEdgeRouters    = "NO_IDENTITY_EDGE_ROUTERS"
+	AdvisorFindingNoServiceEdgeRouters     = "NO_SERVICE_EDGE_ROUTERS"
+	AdvisorFindingNoCommonEdgeRouters      = "NO_COMMON_EDGE_ROUTERS"
+	AdvisorFindingCommonEdgeRoutersOffline = "COMMON_EDGE_ROUTERS_OFFLINE"
+
+	AdvisorSuggestionCreate = "create"
+	AdvisorSuggestionPatch  = "patch"
+
+	// maxAdvisorPatchSuggestions limits how many existing policies are suggested for extension
+	maxAdvisorPatchSuggestions = 3
+)
+
+// AdvisorDiagnosis explains whether an identity can dial or bind a service and, if it can't, why not
+type AdvisorDiagnosis struct {
+	*AdvisorServiceReachability
+	PolicyType string
+	Findings   []*AdvisorFinding
+}
+
+// IsAccessible returns true if nothing was found which prevents access
+func (self *AdvisorDiagnosis) IsAccessible() bool {
+	for _, finding := range self.Findings {
+		if finding.Severity == AdvisorSeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// AdvisorFinding is a single reason access fails, or may fail. PolicyIds lists the policies the finding refers to.
+// PostureFailures is set for failed posture checks.
+type AdvisorFinding struct {
+	Code            string
+	Severity        string
+	Message         string
+	PolicyIds       []string
+	PostureFailures []*PosturePolicyFailure
+	Suggestions     []*AdvisorSuggestion
+}
+
+// AdvisorSuggestion is a policy change which would resolve a finding. EntityType is the db entity type of the
+// policy. For creates, the role fields hold the roles of the new policy. For patches, a non-nil role field holds the
+// complete new value of the field, which is the existing roles plus the missing one.
+type AdvisorSuggestion struct {
+	Description     string
+	Op              string
+	EntityType      string
+	PolicyId        string
+	Name            string
+	PolicyType      string
+	Semantic        string
+	IdentityRoles   []string
+	ServiceRoles    []string
+	EdgeRouterRoles []string
+}
+
+// DiagnoseServiceAccess checks everything required for the identity to dial or bind the service, depending on the
+// policy type, and suggests policy changes for the problems found
+func (advisor *PolicyAdvisor) DiagnoseServiceAccess(identityId, serviceId, policyType string) (*AdvisorDiagnosis, error) {
+	if policyType != db.PolicyTypeDialName && policyType != db.PolicyTypeBindName {
+		return nil, errors.Errorf("invalid policy type '%s', must be '%s' or '%s'", policyType, db.PolicyTypeDialName, db.PolicyTypeBindName)
+	}
+
+	reachability, identityEdgeRouters, serviceEdgeRouters, err := advisor.analyzeServiceReachability(identityId, serviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AdvisorDiagnosis{
+		AdvisorServiceReachability: reachability,
+		PolicyType:                 policyType,
+	}
+
+	identity := reachability.Identity
+	service := reachability.Service
+
+	if identity.Disabled {
+		result.addFinding(AdvisorFindingIdentityDisabled, AdvisorSeverityError, "identity %s is disabled", identity.Name)
+	}
+
+	isAllowed := reachability.IsDialAllowed
+	if policyType == db.PolicyTypeBindName {
+		isAllowed = reachability.IsBindAllowed
+	}
+
+	if !isAllowed {
+		finding := result.addFinding(AdvisorFindingNoServicePolicy, AdvisorSeverityError,
+			"no %s service policy grants identity %s access to service %s", policyType, identity.Name, service.Name)
+		if finding.Suggestions, err = advisor.suggestServicePolicyChanges(identity, service, policyType); err != nil {
+			return nil, err
+		}
+	} else {
+		scheduleResult := advisor.env.GetManagers().Session.EvaluateScheduleForService(identityId, policyType, serviceId, time.Now())
+		if !scheduleResult.Passed {
+			finding := result.addFinding(AdvisorFindingPolicyOutsideSchedule, AdvisorSeverityError,
+				"every %s service policy granting identity %s access to service %s is outside its schedule", policyType, identity.Name, service.Name)
+			finding.PolicyIds = scheduleResult.OutOfWindowPolicyIds
+		}
+
+		if finding := advisor.diagnosePosture(identity, service, policyType); finding != nil {
+			result.Findings = append(result.Findings, finding)
+		}
+	}
+
+	identityOnlineRouters := identityOnlineRouterRoles(identityEdgeRouters)
+	serviceOnlineRouters := advisor.serviceOnlineRouterRoles(serviceEdgeRouters)
+
+	if reachability.IdentityRouterCount == 0 {
+		finding := result.addFinding(AdvisorFindingNoIdentityEdgeRouters, AdvisorSeverityError,
+			"no edge router policy grants identity %s access to any edge routers", identity.Name)
+		finding.Suggestions = append(finding.Suggestions, newIdentityEdgeRoutersSuggestion(identity, serviceOnlineRouters))
+	}
+
+	if reachability.ServiceRouterCount == 0 {
+		finding := result.addFinding(AdvisorFindingNoServiceEdgeRouters, AdvisorSeverityError,
+			"no service edge router policy grants service %s access to any edge routers", service.Name)
+		finding.Suggestions = append(finding.Suggestions, newServiceEdgeRoutersSuggestion(service, identityOnlineRouters))
+	}
+
+	if reachability.IdentityRouterCount > 0 && reachability.ServiceRouterCount > 0 {
+		var onlineCommon, offlineCommon []string
+		for _, edgeRouter := range reachability.CommonRouters {
+			if edgeRouter.IsOnline {
+				onlineCommon = append(onlineCommon, edgeRouter.Router.Name)
+			} else {
+				offlineCommon = append(offlineCommon, edgeRouter.Router.Name)
+			}
+		}
+
+		if len(reachability.CommonRouters) == 0 {
+			finding := result.addFinding(AdvisorFindingNoCommonEdgeRouters, AdvisorSeverityError,
+				"identity %s and service %s have no edge routers in common", identity.Name, service.Name)
+			if len(identityOnlineRouters) > 0 {
+				finding.Suggestions = append(finding.Suggestions, newServiceEdgeRoutersSuggestion(service, identityOnlineRouters))
+			}
+			if len(serviceOnlineRouters) > 0 {
+				finding.Suggestions = append(finding.Suggestions, newIdentityEdgeRoutersSuggestion(identity, serviceOnlineRouters))
+			}
+		} else if len(onlineCommon) == 0 {
+			sort.Strings(offlineCommon)
+			finding := result.addFinding(AdvisorFindingCommonEdgeRoutersOffline, AdvisorSeverityError,
+				"the edge routers identity %s and service %s have in common are all offline: %s. bring them online or grant access to online routers",
+				identity.Name, service.Name, strings.Join(offlineCommon, ", "))
+			if len(identityOnlineRouters) > 0 {
+				finding.Suggestions = append(finding.Suggestions, newServiceEdgeRoutersSuggestion(service, identityOnlineRouters))
+			} else if len(serviceOnlineRouters) > 0 {
+				finding.Suggestions = append(finding.Suggestions, newIdentityEdgeRoutersSuggestion(identity, serviceOnlineRouters))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (self *AdvisorDiagnosis) addFinding(code, severity, msg string, args ...any) *AdvisorFinding {
+	finding := &AdvisorFinding{
+		Code:     code,
+		Severity: severity,
+		Message:  fmt.Sprintf(msg, args...),
+	}
+	self.Findings = append(self.Findings, finding)
+	return finding
+}
+
+// suggestServicePolicyChanges suggests adding the identity to existing AnyOf policies of the right type which already
+// include the service, as well as creating a new policy. Adding a role to an AllOf policy would narrow it rather than
+// adding the identity, so those aren't suggested.
+func (advisor *PolicyAdvisor) suggestServicePolicyChanges(identity *Identity, service *EdgeService, policyType string) ([]*AdvisorSuggestion, error) {
+	var result []*AdvisorSuggestion
+
+	policyIterator := func(tx *bbolt.Tx, policyId string) error {
+		if len(result) >= maxAdvisorPatchSuggestions {
+			return nil
+		}
+		policy, err := advisor.env.GetManagers().ServicePolicy.readInTx(tx, policyId)
+		if err != nil {
+			return err
+		}
+		if policy.PolicyType != policyType || policy.Semantic != db.SemanticAnyOf {
+			return nil
+		}
+		result = append(result, &AdvisorSuggestion{
+			Description:   fmt.Sprintf("add identity %s to the identity roles of %s service policy %s", identity.Name, policyType, policy.Name),
+			Op:            AdvisorSuggestionPatch,
+			EntityType:    db.EntityTypeServicePolicies,
+			PolicyId:      policy.Id,
+			IdentityRoles: append(append([]string{}, policy.IdentityRoles...), "@"+identity.Id),
+		})
+		return nil
+	}
+
+	if err := advisor.env.GetManagers().EdgeService.iterateRelatedEntities(service.Id, db.EntityTypeServicePolicies, policyIterator); err != nil {
+		return nil, err
+	}
+
+	result = append(result, &AdvisorSuggestion{
+		Description:   fmt.Sprintf("create a %s service policy granting identity %s access to service %s", policyType, identity.Name, service.Name),
+		Op:            AdvisorSuggestionCreate,
+		EntityType:    db.EntityTypeServicePolicies,
+		Name:          fmt.Sprintf("%s-%s-%s", identity.Name, service.Name, strings.ToLower(policyType)),
+		PolicyType:    policyType,
+		Semantic:      db.SemanticAnyOf,
+		IdentityRoles: []string{"@" + identity.Id},
+		ServiceRoles:  []string{"@" + service.Id},
+	})
+
+	return result, nil
+}
+
+// diagnosePosture checks whether the identity passes the posture checks of at least one policy granting access, in
+// at least one of its api sessions. Posture is tracked per api session, so it can't be evaluated for an identity
+// which isn't logged in.
+func (advisor *PolicyAdvisor) diagnosePosture(identity *Identity, service *EdgeService, policyType string) *AdvisorFinding {
+	hasChecks := false
+	for _, policyChecks := range advisor.env.GetManagers().EdgeService.GetPolicyPostureChecks(identity.Id, service.Id) {
+		if policyChecks.PolicyType.String() == policyType && len(policyChecks.PostureChecks) > 0 {
+			hasChecks = true
+			break
+		}
+	}
+
+	if !hasChecks {
+		return nil
+	}
+
+	var apiSessionIds []string
+	if postureData := advisor.env.GetManagers().PostureResponse.PostureData(identity.Id); postureData != nil {
+		for apiSessionId := range postureData.ApiSessions {
+			apiSessionIds = append(apiSessionIds, apiSessionId)
+		}
+	}
+	sort.Strings(apiSessionIds)
+
+	if len(apiSessionIds) == 0 {
+		return &AdvisorFinding{
+			Code:     AdvisorFindingPostureNotEvaluated,
+			Severity: AdvisorSeverityInfo,
+			Message:  fmt.Sprintf("access to service %s requires posture checks, which can't be evaluated until identity %s logs in", service.Name, identity.Name),
+		}
+	}
+
+	var failure *PostureSessionRequestFailure
+	for _, apiSessionId := range apiSessionIds {
+		postureResult := advisor.env.GetManagers().Session.EvaluatePostureForService(identity.Id, apiSessionId, policyType, service.Id, service.Name)
+		if postureResult.Passed {
+			return nil
+		}
+		if failure == nil {
+			failure = postureResult.Failure
+		}
+	}
+
+	result := &AdvisorFinding{
+		Code:     AdvisorFindingPostureCheckFailed,
+		Severity: AdvisorSeverityError,
+		Message: fmt.Sprintf("identity %s fails the posture checks of every %s service policy granting access to service %s, in all of its %d api sessions",
+			identity.Name, policyType, service.Name, len(apiSessionIds)),
+	}
+
+	if failure != nil {
+		result.PostureFailures = failure.PolicyFailures
+		for _, policyFailure := range failure.PolicyFailures {
+			result.PolicyIds = append(result.PolicyIds, policyFailure.PolicyId)
+		}
+		sort.Strings(result.PolicyIds)
+	}
+
+	return result
+}
+
+func (advisor *PolicyAdvisor) serviceOnlineRouterRoles(edgeRouterIds map[string]struct{}) []string {
+	var result []string
+	for edgeRouterId := range edgeRouterIds {
+		if advisor.env.IsEdgeRouterOnline(edgeRouterId) {
+			result = append(result, "@"+edgeRouterId)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func identityOnlineRouterRoles(edgeRouters map[string]*AdvisorEdgeRouter) []string {
+	var result []string
+	for edgeRouterId, edgeRouter := range edgeRouters {
+		if edgeRouter.IsOnline {
+			result = append(result, "@"+edgeRouterId)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func newIdentityEdgeRoutersSuggestion(identity *Identity, edgeRouterRoles []string) *AdvisorSuggestion {
+	description := fmt.Sprintf("create an edge router policy granting identity %s access to the online edge routers of the service", identity.Name)
+	if len(edgeRouterRoles) == 0 {
+		description = fmt.Sprintf("create an edge router policy granting identity %s access to all edge routers", identity.Name)
+		edgeRouterRoles = []string{"#all"}
+	}
+	return &AdvisorSuggestion{
+		Description:     description,
+		Op:              AdvisorSuggestionCreate,
+		EntityType:      db.EntityTypeEdgeRouterPolicies,
+		Name:            identity.Name + "-edge-routers",
+		Semantic:        db.SemanticAnyOf,
+		IdentityRoles:   []string{"@" + identity.Id},
+		EdgeRouterRoles: edgeRouterRoles,
+	}
+}
+
+func newServiceEdgeRoutersSuggestion(service *EdgeService, edgeRouterRoles []string) *AdvisorSuggestion {
+	description := fmt.Sprintf("create a service edge router policy granting service %s access to the online edge routers of the identity", service.Name)
+	if len(edgeRouterRoles) == 0 {
+		description = fmt.Sprintf("create a service edge router policy granting service %s access to all edge routers", service.Name)
+		edgeRouterRoles = []string{"#all"}
+	}
+	return &AdvisorSuggestion{
+		Description:     description,
+		Op:              AdvisorSuggestionCreate,
+		EntityType:      db.EntityTypeServiceEdgeRouterPolicies,
+		Name:            service.Name + "-edge-routers",
+		Semantic:        db.SemanticAnyOf,
+		ServiceRoles:    []string{"@" + service.Id},
+		EdgeRouterRoles: edgeRouterRoles,
+	}
+}
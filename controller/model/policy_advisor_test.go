@@ -0,0 +1,150 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+
+	"github.com/openziti/ziti/common/eid"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyAdvisorDiagnosis(t *testing.T) {
+	ctx := NewTestContext(t)
+	defer ctx.Cleanup()
+	ctx.Init()
+
+	t.Run("missing policies", ctx.testAdvisorMissingPolicies)
+	t.Run("edge router problems", ctx.testAdvisorEdgeRouters)
+}
+
+func (ctx *TestContext) diagnose(identity *Identity, service *EdgeService, policyType string) *AdvisorDiagnosis {
+	result, err := ctx.managers.PolicyAdvisor.DiagnoseServiceAccess(identity.Id, service.Id, policyType)
+	ctx.NoError(err)
+	return result
+}
+
+func findingCodes(diagnosis *AdvisorDiagnosis) []string {
+	var result []string
+	for _, finding := range diagnosis.Findings {
+		result = append(result, finding.Code)
+	}
+	return result
+}
+
+func (ctx *TestContext) testAdvisorMissingPolicies(t *testing.T) {
+	req := require.New(t)
+
+	identity := ctx.requireNewIdentity(false)
+	otherIdentity := ctx.requireNewIdentity(false)
+	service := ctx.requireNewService()
+
+	diagnosis := ctx.diagnose(identity, service, db.PolicyTypeDialName)
+	req.False(diagnosis.IsAccessible())
+	req.Equal([]string{AdvisorFindingNoServicePolicy, AdvisorFindingNoIdentityEdgeRouters, AdvisorFindingNoServiceEdgeRouters}, findingCodes(diagnosis))
+
+	req.Len(diagnosis.Findings[0].Suggestions, 1)
+	create := diagnosis.Findings[0].Suggestions[0]
+	req.Equal(AdvisorSuggestionCreate, create.Op)
+	req.Equal(db.EntityTypeServicePolicies, create.EntityType)
+	req.Equal(db.PolicyTypeDialName, create.PolicyType)
+	req.Equal(ss("@"+identity.Id), create.IdentityRoles)
+	req.Equal(ss("@"+service.Id), create.ServiceRoles)
+
+	// with no online routers anywhere, the identity and service are given all routers
+	req.Equal(ss("#all"), diagnosis.Findings[1].Suggestions[0].EdgeRouterRoles)
+	req.Equal(db.EntityTypeEdgeRouterPolicies, diagnosis.Findings[1].Suggestions[0].EntityType)
+	req.Equal(ss("#all"), diagnosis.Findings[2].Suggestions[0].EdgeRouterRoles)
+	req.Equal(db.EntityTypeServiceEdgeRouterPolicies, diagnosis.Findings[2].Suggestions[0].EntityType)
+
+	// existing AnyOf policies for the service can be extended. AllOf policies can't, adding a role would narrow them.
+	anyOfPolicy := &ServicePolicy{
+		Name:          eid.New(),
+		Semantic:      db.SemanticAnyOf,
+		IdentityRoles: ss("@" + otherIdentity.Id),
+		ServiceRoles:  ss("@" + service.Id),
+		PolicyType:    db.PolicyTypeDialName,
+	}
+	req.NoError(ctx.managers.ServicePolicy.Create(anyOfPolicy, change.New()))
+	ctx.requireNewServicePolicy(db.PolicyTypeDialName, ss("@"+otherIdentity.Id), ss("@"+service.Id))
+
+	diagnosis = ctx.diagnose(identity, service, db.PolicyTypeDialName)
+	suggestions := diagnosis.Findings[0].Suggestions
+	req.Len(suggestions, 2)
+	req.Equal(AdvisorSuggestionPatch, suggestions[0].Op)
+	req.Equal(anyOfPolicy.Id, suggestions[0].PolicyId)
+	req.Equal(ss("@"+otherIdentity.Id, "@"+identity.Id), suggestions[0].IdentityRoles)
+	req.Nil(suggestions[0].ServiceRoles)
+	req.Equal(AdvisorSuggestionCreate, suggestions[1].Op)
+
+	// applying the create suggestion resolves the finding
+	req.NoError(ctx.managers.ServicePolicy.Create(&ServicePolicy{
+		Name:          create.Name,
+		Semantic:      create.Semantic,
+		IdentityRoles: create.IdentityRoles,
+		ServiceRoles:  create.ServiceRoles,
+		PolicyType:    create.PolicyType,
+	}, change.New()))
+
+	diagnosis = ctx.diagnose(identity, service, db.PolicyTypeDialName)
+	req.True(diagnosis.IsDialAllowed)
+	req.NotContains(findingCodes(diagnosis), AdvisorFindingNoServicePolicy)
+
+	diagnosis = ctx.diagnose(identity, service, db.PolicyTypeBindName)
+	req.Contains(findingCodes(diagnosis), AdvisorFindingNoServicePolicy)
+	req.Equal(db.PolicyTypeBindName, diagnosis.Findings[0].Suggestions[0].PolicyType)
+
+	_, err := ctx.managers.PolicyAdvisor.DiagnoseServiceAccess(identity.Id, service.Id, "invalid")
+	req.Error(err)
+}
+
+func (ctx *TestContext) testAdvisorEdgeRouters(t *testing.T) {
+	req := require.New(t)
+
+	identity := ctx.requireNewIdentity(false)
+	service := ctx.requireNewService()
+	onlineRouter := ctx.requireNewEdgeRouter()
+	offlineRouter := ctx.requireNewEdgeRouter()
+	ctx.setEdgeRouterOnline(onlineRouter.Id, true)
+
+	ctx.requireNewServicePolicy(db.PolicyTypeDialName, ss("@"+identity.Id), ss("@"+service.Id))
+	ctx.requireNewEdgeRouterPolicy(ss("@"+identity.Id), ss("@"+offlineRouter.Id))
+	ctx.requireNewServiceNewEdgeRouterPolicy(ss("@"+service.Id), ss("@"+onlineRouter.Id))
+
+	// the identity has no online routers, so only giving the identity the service's routers helps
+	diagnosis := ctx.diagnose(identity, service, db.PolicyTypeDialName)
+	req.Equal([]string{AdvisorFindingNoCommonEdgeRouters}, findingCodes(diagnosis))
+	req.Len(diagnosis.Findings[0].Suggestions, 1)
+	suggestion := diagnosis.Findings[0].Suggestions[0]
+	req.Equal(db.EntityTypeEdgeRouterPolicies, suggestion.EntityType)
+	req.Equal(ss("@"+identity.Id), suggestion.IdentityRoles)
+	req.Equal(ss("@"+onlineRouter.Id), suggestion.EdgeRouterRoles)
+
+	ctx.requireNewServiceNewEdgeRouterPolicy(ss("@"+service.Id), ss("@"+offlineRouter.Id))
+	diagnosis = ctx.diagnose(identity, service, db.PolicyTypeDialName)
+	req.Equal([]string{AdvisorFindingCommonEdgeRoutersOffline}, findingCodes(diagnosis))
+	req.Contains(diagnosis.Findings[0].Message, offlineRouter.Name)
+	req.Equal(ss("@"+onlineRouter.Id), diagnosis.Findings[0].Suggestions[0].EdgeRouterRoles)
+
+	ctx.requireNewEdgeRouterPolicy(ss("@"+identity.Id), suggestion.EdgeRouterRoles)
+	diagnosis = ctx.diagnose(identity, service, db.PolicyTypeDialName)
+	req.True(diagnosis.IsAccessible())
+	req.Empty(diagnosis.Findings)
+	req.Len(diagnosis.CommonRouters, 2)
+}
@@ -0,0 +1,284 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"sort"
+
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+// errPolicyDryRunComplete is returned from dry run transactions to make sure they're rolled back
+var errPolicyDryRunComplete = errors.New("policy dry run complete, rolling back")
+
+// PolicyAccessChange is a source and target pairing whose access would change if a policy change were applied. For
+// service policies the source is an identity, the target is a service and the policy type is Dial or Bind. For edge
+// router policies the source is an identity and the target is an edge router. For service edge router policies the
+// source is a service and the target is an edge router.
+type PolicyAccessChange struct {
+	PolicyType string
+	SourceType string
+	SourceId   string
+	SourceName string
+	TargetType string
+	TargetId   string
+	TargetName string
+}
+
+// PolicyAccessDelta lists the source and target pairings which would gain or lose access if a policy change were
+// applied. A pairing is only included if no other policy grants it, since otherwise its access wouldn't change.
+type PolicyAccessDelta struct {
+	Gained []*PolicyAccessChange
+	Lost   []*PolicyAccessChange
+}
+
+type policyAccessKey struct {
+	policyType string
+	sourceId   string
+	targetId   string
+}
+
+// policyAccessSpec describes how a type of policy grants access. The source and target fields are the names of the
+// policy's related entity fields. The access collection is the reference counted denormalized link collection from
+// source to target which all policies of the type contribute to.
+type policyAccessSpec struct {
+	policyStore      boltz.Store
+	sourceField      string
+	targetField      string
+	sourceStore      boltz.Store
+	targetStore      boltz.Store
+	accessCollection func(tx *bbolt.Tx, policyId string) (string, boltz.RefCountedLinkCollection, bool)
+}
+
+func (self *policyAccessSpec) getGrants(tx *bbolt.Tx, policyId string, collections map[string]boltz.RefCountedLinkCollection) map[policyAccessKey]struct{} {
+	result := map[policyAccessKey]struct{}{}
+	if policyId == "" {
+		return result
+	}
+
+	policyType, collection, found := self.accessCollection(tx, policyId)
+	if !found {
+		return result
+	}
+	collections[policyType] = collection
+
+	sourceIds := self.policyStore.GetRelatedEntitiesIdList(tx, policyId, self.sourceField)
+	targetIds := self.policyStore.GetRelatedEntitiesIdList(tx, policyId, self.targetField)
+	for _, sourceId := range sourceIds {
+		for _, targetId := range targetIds {
+			result[policyAccessKey{policyType: policyType, sourceId: sourceId, targetId: targetId}] = struct{}{}
+		}
+	}
+	return result
+}
+
+func (self *policyAccessSpec) newChange(tx *bbolt.Tx, key policyAccessKey) *PolicyAccessChange {
+	return &PolicyAccessChange{
+		PolicyType: key.policyType,
+		SourceType: self.sourceStore.GetSingularEntityType(),
+		SourceId:   key.sourceId,
+		SourceName: getEntityName(tx, self.sourceStore, key.sourceId),
+		TargetType: self.targetStore.GetSingularEntityType(),
+		TargetId:   key.targetId,
+		TargetName: getEntityName(tx, self.targetStore, key.targetId),
+	}
+}
+
+// dryRunPolicyChange applies a policy change in a transaction which is always rolled back, and reports which
+// pairings gained or lost access as a result. It compares the pairings granted by the policy before and after the
+// change. Since the access collections are reference counted, a newly granted pairing only gains access if this
+// policy is now its only grant, and a pairing which is no longer granted only loses access if nothing else grants it.
+//
+// The change is applied directly to the local datastore, rather than being dispatched, so nothing is persisted or
+// replicated.
+func dryRunPolicyChange(env Env, spec *policyAccessSpec, policyId string, ctx *change.Context, apply func(ctx boltz.MutateContext) (string, error)) (*PolicyAccessDelta, error) {
+	result := &PolicyAccessDelta{}
+
+	err := env.GetDb().Update(ctx.NewMutateContext(), func(ctx boltz.MutateContext) error {
+		tx := ctx.Tx()
+		collections := map[string]boltz.RefCountedLinkCollection{}
+		before := spec.getGrants(tx, policyId, collections)
+
+		id, err := apply(ctx)
+		if err != nil {
+			return err
+		}
+
+		after := spec.getGrants(tx, id, collections)
+
+		for key := range after {
+			if _, found := before[key]; !found && getAccessCount(tx, collections[key.policyType], key) == 1 {
+				result.Gained = append(result.Gained, spec.newChange(tx, key))
+			}
+		}
+
+		for key := range before {
+			if _, found := after[key]; !found && getAccessCount(tx, collections[key.policyType], key) == 0 {
+				result.Lost = append(result.Lost, spec.newChange(tx, key))
+			}
+		}
+
+		return errPolicyDryRunComplete
+	})
+
+	if !errors.Is(err, errPolicyDryRunComplete) {
+		return nil, err
+	}
+
+	sortPolicyAccessChanges(result.Gained)
+	sortPolicyAccessChanges(result.Lost)
+	return result, nil
+}
+
+func getAccessCount(tx *bbolt.Tx, collection boltz.RefCountedLinkCollection, key policyAccessKey) int32 {
+	if count := collection.GetLinkCount(tx, []byte(key.sourceId), []byte(key.targetId)); count != nil {
+		return *count
+	}
+	return 0
+}
+
+func getEntityName(tx *bbolt.Tx, store boltz.Store, id string) string {
+	symbol := store.GetSymbol(db.FieldName)
+	if symbol == nil {
+		return ""
+	}
+	if _, val := symbol.Eval(tx, []byte(id)); val != nil {
+		return string(val)
+	}
+	return ""
+}
+
+func sortPolicyAccessChanges(changes []*PolicyAccessChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.PolicyType != b.PolicyType {
+			return a.PolicyType < b.PolicyType
+		}
+		if a.SourceName != b.SourceName {
+			return a.SourceName < b.SourceName
+		}
+		return a.TargetName < b.TargetName
+	})
+}
+
+func (self *ServicePolicyManager) getAccessSpec() *policyAccessSpec {
+	stores := self.env.GetStores()
+	return &policyAccessSpec{
+		policyStore: stores.ServicePolicy,
+		sourceField: db.EntityTypeIdentities,
+		targetField: db.EntityTypeServices,
+		sourceStore: stores.Identity,
+		targetStore: stores.EdgeService,
+		accessCollection: func(tx *bbolt.Tx, policyId string) (string, boltz.RefCountedLinkCollection, bool) {
+			policy, found, err := stores.ServicePolicy.FindById(tx, policyId)
+			if err != nil || !found {
+				return "", nil, false
+			}
+			if policy.PolicyType == db.PolicyTypeBind {
+				return db.PolicyTypeBindName, stores.Identity.GetRefCountedLinkCollection(db.FieldIdentityBindServices), true
+			}
+			return db.PolicyTypeDialName, stores.Identity.GetRefCountedLinkCollection(db.FieldIdentityDialServices), true
+		},
+	}
+}
+
+// DryRunCreate reports which identities would gain access to which services if the given policy were created,
+// without creating it
+func (self *ServicePolicyManager) DryRunCreate(entity *ServicePolicy, ctx *change.Context) (*PolicyAccessDelta, error) {
+	return dryRunPolicyChange(self.env, self.getAccessSpec(), "", ctx, func(ctx boltz.MutateContext) (string, error) {
+		return self.createEntityInTx(ctx, entity)
+	})
+}
+
+// DryRunUpdate reports which identities would gain or lose access to which services if the given policy update were
+// applied, without applying it
+func (self *ServicePolicyManager) DryRunUpdate(entity *ServicePolicy, checker fields.UpdatedFields, ctx *change.Context) (*PolicyAccessDelta, error) {
+	return dryRunPolicyChange(self.env, self.getAccessSpec(), entity.Id, ctx, func(ctx boltz.MutateContext) (string, error) {
+		return entity.Id, self.updateEntityInTx(ctx, entity, checker)
+	})
+}
+
+func (self *EdgeRouterPolicyManager) getAccessSpec() *policyAccessSpec {
+	stores := self.env.GetStores()
+	return &policyAccessSpec{
+		policyStore: stores.EdgeRouterPolicy,
+		sourceField: db.EntityTypeIdentities,
+		targetField: db.EntityTypeRouters,
+		sourceStore: stores.Identity,
+		targetStore: stores.EdgeRouter,
+		accessCollection: func(tx *bbolt.Tx, policyId string) (string, boltz.RefCountedLinkCollection, bool) {
+			if !stores.EdgeRouterPolicy.IsEntityPresent(tx, policyId) {
+				return "", nil, false
+			}
+			return "", stores.Identity.GetRefCountedLinkCollection(db.EntityTypeRouters), true
+		},
+	}
+}
+
+// DryRunCreate reports which identities would gain access to which edge routers if the given policy were created,
+// without creating it
+func (self *EdgeRouterPolicyManager) DryRunCreate(entity *EdgeRouterPolicy, ctx *change.Context) (*PolicyAccessDelta, error) {
+	return dryRunPolicyChange(self.env, self.getAccessSpec(), "", ctx, func(ctx boltz.MutateContext) (string, error) {
+		return self.createEntityInTx(ctx, entity)
+	})
+}
+
+// DryRunUpdate reports which identities would gain or lose access to which edge routers if the given policy update
+// were applied, without applying it
+func (self *EdgeRouterPolicyManager) DryRunUpdate(entity *EdgeRouterPolicy, checker fields.UpdatedFields, ctx *change.Context) (*PolicyAccessDelta, error) {
+	return dryRunPolicyChange(self.env, self.getAccessSpec(), entity.Id, ctx, func(ctx boltz.MutateContext) (string, error) {
+		return entity.Id, self.updateEntityInTx(ctx, entity, checker)
+	})
+}
+
+func (self *ServiceEdgeRouterPolicyManager) getAccessSpec() *policyAccessSpec {
+	stores := self.env.GetStores()
+	return &policyAccessSpec{
+		policyStore: stores.ServiceEdgeRouterPolicy,
+		sourceField: db.EntityTypeServices,
+		targetField: db.EntityTypeRouters,
+		sourceStore: stores.EdgeService,
+		targetStore: stores.EdgeRouter,
+		accessCollection: func(tx *bbolt.Tx, policyId string) (string, boltz.RefCountedLinkCollection, bool) {
+			if !stores.ServiceEdgeRouterPolicy.IsEntityPresent(tx, policyId) {
+				return "", nil, false
+			}
+			return "", stores.EdgeService.GetRefCountedLinkCollection(db.FieldEdgeRouters), true
+		},
+	}
+}
+
+// DryRunCreate reports which services would become usable on which edge routers if the given policy were created,
+// without creating it
+func (self *ServiceEdgeRouterPolicyManager) DryRunCreate(entity *ServiceEdgeRouterPolicy, ctx *change.Context) (*PolicyAccessDelta, error) {
+	return dryRunPolicyChange(self.env, self.getAccessSpec(), "", ctx, func(ctx boltz.MutateContext) (string, error) {
+		return self.createEntityInTx(ctx, entity)
+	})
+}
+
+// DryRunUpdate reports which services would become usable or unusable on which edge routers if the given policy
+// update were applied, without applying it
+func (self *ServiceEdgeRouterPolicyManager) DryRunUpdate(entity *ServiceEdgeRouterPolicy, checker fields.UpdatedFields, ctx *change.Context) (*PolicyAccessDelta, error) {
+	return dryRunPolicyChange(self.env, self.getAccessSpec(), entity.Id, ctx, func(ctx boltz.MutateContext) (string, error) {
+		return entity.Id, self.updateEntityInTx(ctx, entity, checker)
+	})
+}
@@ -0,0 +1,142 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+
+	"github.com/openziti/foundation/v2/stringz"
+	"github.com/openziti/ziti/common/eid"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestPolicyDryRun(t *testing.T) {
+	ctx := NewTestContext(t)
+	defer ctx.Cleanup()
+	ctx.Init()
+
+	t.Run("service policy dry runs", ctx.testServicePolicyDryRun)
+	t.Run("edge router policy dry runs", ctx.testEdgeRouterPolicyDryRun)
+}
+
+func (ctx *TestContext) testServicePolicyDryRun(t *testing.T) {
+	req := require.New(t)
+
+	identity1 := ctx.requireNewIdentity(false)
+	identity2 := ctx.requireNewIdentity(false)
+	service := ctx.requireNewService()
+
+	existing := ctx.requireNewServicePolicy(db.PolicyTypeDialName, ss("@"+identity1.Id), ss("@"+service.Id))
+
+	newPolicy := &ServicePolicy{
+		Name:          eid.New(),
+		Semantic:      db.SemanticAllOf,
+		IdentityRoles: ss("@"+identity1.Id, "@"+identity2.Id),
+		ServiceRoles:  ss("@" + service.Id),
+		PolicyType:    db.PolicyTypeDialName,
+	}
+
+	delta, err := ctx.managers.ServicePolicy.DryRunCreate(newPolicy, change.New())
+	req.NoError(err)
+	req.Empty(delta.Lost)
+	req.Len(delta.Gained, 1)
+	req.Equal(db.PolicyTypeDialName, delta.Gained[0].PolicyType)
+	req.Equal(identity2.Id, delta.Gained[0].SourceId)
+	req.Equal(identity2.Name, delta.Gained[0].SourceName)
+	req.Equal(service.Id, delta.Gained[0].TargetId)
+	req.Equal(service.Name, delta.Gained[0].TargetName)
+
+	_, err = ctx.managers.ServicePolicy.Read(newPolicy.Id)
+	req.Error(err, "dry run should not have created the policy")
+	req.True(ctx.isDialable(identity1.Id, service.Id))
+	req.False(ctx.isDialable(identity2.Id, service.Id))
+
+	existing.IdentityRoles = ss("@" + identity2.Id)
+	delta, err = ctx.managers.ServicePolicy.DryRunUpdate(existing, fields.UpdatedFieldsMap{db.FieldIdentityRoles: struct{}{}}, change.New())
+	req.NoError(err)
+	req.Len(delta.Gained, 1)
+	req.Equal(identity2.Id, delta.Gained[0].SourceId)
+	req.Len(delta.Lost, 1)
+	req.Equal(identity1.Id, delta.Lost[0].SourceId)
+
+	req.True(ctx.isDialable(identity1.Id, service.Id), "dry run should not have updated the policy")
+	req.False(ctx.isDialable(identity2.Id, service.Id))
+
+	existing.IdentityRoles = ss("@" + identity1.Id)
+	existing.PolicyType = db.PolicyTypeBindName
+	delta, err = ctx.managers.ServicePolicy.DryRunUpdate(existing, nil, change.New())
+	req.NoError(err)
+	req.Len(delta.Gained, 1)
+	req.Equal(db.PolicyTypeBindName, delta.Gained[0].PolicyType)
+	req.Equal(identity1.Id, delta.Gained[0].SourceId)
+	req.Len(delta.Lost, 1)
+	req.Equal(db.PolicyTypeDialName, delta.Lost[0].PolicyType)
+	req.Equal(identity1.Id, delta.Lost[0].SourceId)
+
+	// a second policy granting the same access means removing it from the first doesn't change anything
+	ctx.requireNewServicePolicy(db.PolicyTypeDialName, ss("@"+identity1.Id), ss("@"+service.Id))
+	existing.IdentityRoles = ss("@" + identity2.Id)
+	existing.PolicyType = db.PolicyTypeDialName
+	delta, err = ctx.managers.ServicePolicy.DryRunUpdate(existing, nil, change.New())
+	req.NoError(err)
+	req.Len(delta.Gained, 1)
+	req.Equal(identity2.Id, delta.Gained[0].SourceId)
+	req.Empty(delta.Lost)
+
+	existing.IdentityRoles = ss("@" + eid.New())
+	_, err = ctx.managers.ServicePolicy.DryRunUpdate(existing, nil, change.New())
+	req.Error(err, "dry runs should report validation errors")
+}
+
+func (ctx *TestContext) testEdgeRouterPolicyDryRun(t *testing.T) {
+	req := require.New(t)
+
+	identity := ctx.requireNewIdentity(false)
+	edgeRouter := ctx.requireNewEdgeRouter()
+
+	policy := &EdgeRouterPolicy{
+		Name:            eid.New(),
+		Semantic:        db.SemanticAllOf,
+		IdentityRoles:   ss("@" + identity.Id),
+		EdgeRouterRoles: ss("@" + edgeRouter.Id),
+	}
+
+	delta, err := ctx.managers.EdgeRouterPolicy.DryRunCreate(policy, change.New())
+	req.NoError(err)
+	req.Empty(delta.Lost)
+	req.Len(delta.Gained, 1)
+	req.Equal("", delta.Gained[0].PolicyType)
+	req.Equal(identity.Id, delta.Gained[0].SourceId)
+	req.Equal(edgeRouter.Id, delta.Gained[0].TargetId)
+	req.Equal(edgeRouter.Name, delta.Gained[0].TargetName)
+
+	_, err = ctx.managers.EdgeRouterPolicy.Read(policy.Id)
+	req.Error(err, "dry run should not have created the policy")
+}
+
+func (ctx *TestContext) isDialable(identityId, serviceId string) bool {
+	var result bool
+	ctx.NoError(ctx.GetDb().View(func(tx *bbolt.Tx) error {
+		result = stringz.Contains(ctx.GetStores().Identity.GetRelatedEntitiesIdList(tx, identityId, db.FieldIdentityDialServices), serviceId)
+		return nil
+	}))
+	return result
+}
@@ -63,8 +63,10 @@ const (
 	PostureCheckTypeDomain       = "DOMAIN"
 	PostureCheckTypeProcess      = "PROCESS"
 	PostureCheckTypeProcessMulti = "PROCESS_MULTI"
+	PostureCheckTypeProcessHash  = "PROCESS_HASH"
 	PostureCheckTypeMAC          = "MAC"
 	PostureCheckTypeMFA          = "MFA"
+	PostureCheckTypeTpm          = "TPM_ATTESTATION"
 )
 
 var postureCheckSubTypeMap = map[string]newPostureCheckSubType{
@@ -72,8 +74,10 @@ var postureCheckSubTypeMap = map[string]newPostureCheckSubType{
 	PostureCheckTypeDomain:       newPostureCheckWindowsDomains,
 	PostureCheckTypeProcess:      newPostureCheckProcess,
 	PostureCheckTypeProcessMulti: newPostureCheckProcessMulti,
+	PostureCheckTypeProcessHash:  newPostureCheckProcessHash,
 	PostureCheckTypeMAC:          newPostureCheckMacAddresses,
 	PostureCheckTypeMFA:          newPostureCheckMfa,
+	PostureCheckTypeTpm:          newPostureCheckTpmAttestation,
 }
 
 func newSubType(typeId string) PostureCheckSubType {
@@ -0,0 +1,235 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/common/pb/edge_cmd_pb"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+var _ PostureCheckSubType = &PostureCheckProcessHash{}
+
+// PostureCheckProcessHash passes if a process listed for the client's operating system is running from a binary
+// with one of the allowed SHA-256 hashes. Unlike process checks, hashes are required and processes for other
+// operating systems are ignored, so a single check can cover clients on several operating systems.
+type PostureCheckProcessHash struct {
+	PostureCheckId string
+	Processes      []*ProcessHash
+}
+
+type ProcessHash struct {
+	OsType string
+	Path   string
+	Hashes []string
+}
+
+func (p *PostureCheckProcessHash) TypeId() string {
+	return db.PostureCheckTypeProcessHash
+}
+
+// Validate checks that at least one process is given and that every process has a path and only valid SHA-256 hashes
+func (p *PostureCheckProcessHash) Validate() error {
+	if len(p.Processes) == 0 {
+		return errorz.NewFieldError("at least one process is required", "processes", nil)
+	}
+
+	for idx, process := range p.Processes {
+		if process.OsType == "" {
+			return errorz.NewFieldError("os type is required", fmt.Sprintf("processes[%d].osType", idx), process.OsType)
+		}
+
+		if process.Path == "" {
+			return errorz.NewFieldError("path is required", fmt.Sprintf("processes[%d].path", idx), process.Path)
+		}
+
+		if len(process.Hashes) == 0 {
+			return errorz.NewFieldError("at least one hash is required", fmt.Sprintf("processes[%d].hashes", idx), nil)
+		}
+
+		for _, hash := range process.Hashes {
+			if !isSha256Hex(hash) {
+				return errorz.NewFieldError("hashes must be hex encoded SHA-256 values", fmt.Sprintf("processes[%d].hashes", idx), hash)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isSha256Hex(val string) bool {
+	if len(val) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(val)
+	return err == nil
+}
+
+// The process hash check is stored in raft using the process multi message, as the shape of the data is the same.
+// The posture check type id distinguishes the two.
+func (p *PostureCheckProcessHash) fillProtobuf(msg *edge_cmd_pb.PostureCheck) {
+	processMultiMsg := &edge_cmd_pb.PostureCheck_ProcessMulti{
+		Semantic: db.SemanticAnyOf,
+	}
+
+	for _, process := range p.Processes {
+		processMultiMsg.Processes = append(processMultiMsg.Processes, &edge_cmd_pb.PostureCheck_Process{
+			OsType: process.OsType,
+			Path:   process.Path,
+			Hashes: process.Hashes,
+		})
+	}
+
+	msg.Subtype = &edge_cmd_pb.PostureCheck_ProcessMulti_{
+		ProcessMulti: processMultiMsg,
+	}
+}
+
+func (p *PostureCheckProcessHash) fillFromProtobuf(msg *edge_cmd_pb.PostureCheck) error {
+	if processMulti_, ok := msg.Subtype.(*edge_cmd_pb.PostureCheck_ProcessMulti_); ok {
+		if processMulti := processMulti_.ProcessMulti; processMulti != nil {
+			p.PostureCheckId = msg.Id
+			for _, process := range processMulti.Processes {
+				p.Processes = append(p.Processes, &ProcessHash{
+					OsType: process.OsType,
+					Path:   process.Path,
+					Hashes: process.Hashes,
+				})
+			}
+		}
+	} else {
+		return errors.Errorf("expected posture check sub type data of process multi, but got %T", msg.Subtype)
+	}
+	return nil
+}
+
+func (p *PostureCheckProcessHash) LastUpdatedAt(string, *PostureData) *time.Time {
+	return nil
+}
+
+func (p *PostureCheckProcessHash) GetTimeoutSeconds() int64 {
+	return PostureCheckNoTimeout
+}
+
+func (p *PostureCheckProcessHash) GetTimeoutRemainingSeconds(string, *PostureData) int64 {
+	return PostureCheckNoTimeout
+}
+
+func (p *PostureCheckProcessHash) FailureValues(_ string, pd *PostureData) PostureCheckFailureValues {
+	_, actual := p.evaluate(pd)
+	return &PostureCheckFailureValuesProcessMulti{
+		ActualValue:   actual,
+		ExpectedValue: *p.toProcessMulti(),
+	}
+}
+
+func (p *PostureCheckProcessHash) Evaluate(_ string, pd *PostureData) bool {
+	passed, _ := p.evaluate(pd)
+	return passed
+}
+
+// evaluate returns true if any process applicable to the client's operating system is running with an allowed hash.
+// If no operating system has been reported, all processes are applicable. On failure, the submitted process data for
+// the applicable processes is returned.
+func (p *PostureCheckProcessHash) evaluate(pd *PostureData) (bool, []PostureResponseProcess) {
+	actualValues := []PostureResponseProcess{}
+
+	for _, process := range p.Processes {
+		if pd.Os.Type != "" && !strings.EqualFold(pd.Os.Type, process.OsType) {
+			continue
+		}
+
+		processData, ok := pd.ProcessPathMap[process.Path]
+		if !ok {
+			continue
+		}
+
+		if processData.IsRunning && !processData.TimedOut {
+			for _, hash := range process.Hashes {
+				if strings.EqualFold(hash, processData.BinaryHash) {
+					return true, nil
+				}
+			}
+		}
+
+		actualValues = append(actualValues, *processData)
+	}
+
+	return false, actualValues
+}
+
+func (p *PostureCheckProcessHash) toProcessMulti() *PostureCheckProcessMulti {
+	result := &PostureCheckProcessMulti{
+		PostureCheckId: p.PostureCheckId,
+		Semantic:       db.SemanticAnyOf,
+	}
+
+	for _, process := range p.Processes {
+		result.Processes = append(result.Processes, &ProcessMulti{
+			OsType: process.OsType,
+			Path:   process.Path,
+			Hashes: process.Hashes,
+		})
+	}
+
+	return result
+}
+
+func newPostureCheckProcessHash() PostureCheckSubType {
+	return &PostureCheckProcessHash{}
+}
+
+func (p *PostureCheckProcessHash) fillFrom(_ Env, _ *bbolt.Tx, check *db.PostureCheck, subType db.PostureCheckSubType) error {
+	subCheck, ok := subType.(*db.PostureCheckProcessHash)
+
+	if !ok || subCheck == nil {
+		return fmt.Errorf("could not convert process hash check to bolt type")
+	}
+
+	p.PostureCheckId = check.Id
+
+	for _, process := range subCheck.Processes {
+		p.Processes = append(p.Processes, &ProcessHash{
+			OsType: process.OsType,
+			Path:   process.Path,
+			Hashes: process.Hashes,
+		})
+	}
+
+	return nil
+}
+
+func (p *PostureCheckProcessHash) toBoltEntityForCreate(*bbolt.Tx, Env) (db.PostureCheckSubType, error) {
+	ret := &db.PostureCheckProcessHash{}
+
+	for _, process := range p.Processes {
+		ret.Processes = append(ret.Processes, &db.ProcessHash{
+			OsType: process.OsType,
+			Path:   process.Path,
+			Hashes: process.Hashes,
+		})
+	}
+
+	return ret, nil
+}
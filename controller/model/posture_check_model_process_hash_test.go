@@ -0,0 +1,172 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostureCheckModelProcessHash_Evaluate(t *testing.T) {
+
+	t.Run("returns true for running process with allowed hash", func(t *testing.T) {
+		processCheck, postureData := newMatchingProcessHashCheckAndData()
+
+		req := require.New(t)
+		req.True(processCheck.Evaluate("", postureData))
+	})
+
+	t.Run("returns true for allowed hash with mismatched case", func(t *testing.T) {
+		processCheck, postureData := newMatchingProcessHashCheckAndData()
+		processCheck.Processes[0].Hashes[1] = strings.ToUpper(processCheck.Processes[0].Hashes[1])
+
+		req := require.New(t)
+		req.True(processCheck.Evaluate("", postureData))
+	})
+
+	t.Run("returns true if no operating system has been reported", func(t *testing.T) {
+		processCheck, postureData := newMatchingProcessHashCheckAndData()
+		postureData.Os.Type = ""
+
+		req := require.New(t)
+		req.True(processCheck.Evaluate("", postureData))
+	})
+
+	t.Run("returns false if not running", func(t *testing.T) {
+		processCheck, postureData := newMatchingProcessHashCheckAndData()
+		postureData.Processes[0].IsRunning = false
+
+		req := require.New(t)
+		req.False(processCheck.Evaluate("", postureData))
+	})
+
+	t.Run("returns false if timed out", func(t *testing.T) {
+		processCheck, postureData := newMatchingProcessHashCheckAndData()
+		postureData.Processes[0].TimedOut = true
+
+		req := require.New(t)
+		req.False(processCheck.Evaluate("", postureData))
+	})
+
+	t.Run("returns false if hash is not allowed", func(t *testing.T) {
+		processCheck, postureData := newMatchingProcessHashCheckAndData()
+		postureData.Processes[0].BinaryHash = strings.Repeat("0", 64)
+
+		req := require.New(t)
+		req.False(processCheck.Evaluate("", postureData))
+	})
+
+	t.Run("returns false if the process is only allowed for another operating system", func(t *testing.T) {
+		processCheck, postureData := newMatchingProcessHashCheckAndData()
+		postureData.Os.Type = "Linux"
+
+		req := require.New(t)
+		req.False(processCheck.Evaluate("", postureData))
+
+		failureValues := processCheck.FailureValues("", postureData).(*PostureCheckFailureValuesProcessMulti)
+		req.Empty(failureValues.ActualValue)
+		req.Len(failureValues.ExpectedValue.Processes, 2)
+	})
+
+	t.Run("returns true for a process allowed for the reported operating system", func(t *testing.T) {
+		processCheck, postureData := newMatchingProcessHashCheckAndData()
+		postureData.Os.Type = "Linux"
+		postureResponseProcess := &PostureResponseProcess{
+			PostureResponse: &PostureResponse{
+				PostureCheckId: processCheck.PostureCheckId,
+				TypeId:         PostureCheckTypeProcess,
+				LastUpdatedAt:  time.Now(),
+			},
+			Path:       processCheck.Processes[1].Path,
+			IsRunning:  true,
+			BinaryHash: strings.ToUpper(processCheck.Processes[1].Hashes[0]),
+		}
+		postureResponseProcess.Apply(postureData)
+
+		req := require.New(t)
+		req.True(processCheck.Evaluate("", postureData))
+	})
+}
+
+func TestPostureCheckModelProcessHash_Validate(t *testing.T) {
+	req := require.New(t)
+
+	processCheck, _ := newMatchingProcessHashCheckAndData()
+	req.NoError(processCheck.Validate())
+
+	processCheck.Processes[0].Hashes = append(processCheck.Processes[0].Hashes, "abc")
+	req.Error(processCheck.Validate())
+
+	processCheck, _ = newMatchingProcessHashCheckAndData()
+	processCheck.Processes[0].Hashes = nil
+	req.Error(processCheck.Validate())
+
+	processCheck, _ = newMatchingProcessHashCheckAndData()
+	processCheck.Processes[0].Path = ""
+	req.Error(processCheck.Validate())
+
+	processCheck.Processes = nil
+	req.Error(processCheck.Validate())
+}
+
+func newMatchingProcessHashCheckAndData() (*PostureCheckProcessHash, *PostureData) {
+	postureCheckId := "8f3d1c2"
+	binaryHash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	path := `C:\some\path\some.exe`
+
+	postureResponseProcess := &PostureResponseProcess{
+		PostureResponse: &PostureResponse{
+			PostureCheckId: postureCheckId,
+			TypeId:         PostureCheckTypeProcess,
+			TimedOut:       false,
+			LastUpdatedAt:  time.Now(),
+		},
+		Path:       path,
+		IsRunning:  true,
+		BinaryHash: binaryHash,
+	}
+
+	validPostureData := newPostureData()
+	validPostureData.Os.Type = "Windows"
+	postureResponseProcess.Apply(validPostureData)
+
+	processCheck := &PostureCheckProcessHash{
+		PostureCheckId: postureCheckId,
+		Processes: []*ProcessHash{
+			{
+				OsType: "Windows",
+				Path:   path,
+				Hashes: []string{
+					strings.Repeat("1", 64),
+					binaryHash,
+				},
+			},
+			{
+				OsType: "Linux",
+				Path:   "/usr/bin/agent",
+				Hashes: []string{
+					strings.Repeat("ab", 32),
+				},
+			},
+		},
+	}
+
+	return processCheck, validPostureData
+}
@@ -0,0 +1,227 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/common/pb/edge_cmd_pb"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+// TpmMaxPcrIndex is the highest PCR index a TPM 2.0 PC client platform provides
+const TpmMaxPcrIndex = 23
+
+var _ PostureCheckSubType = &PostureCheckTpmAttestation{}
+
+// PostureCheckTpmAttestation passes if the client attested with a TPM whose endorsement key certificate chains to a
+// configured root, during the current API session, and every listed PCR was quoted with one of its allowed values.
+// A check without PCRs only requires that the client has a trusted TPM.
+type PostureCheckTpmAttestation struct {
+	PostureCheckId string
+	Pcrs           []*TpmPcr
+}
+
+// TpmPcr lists the allowed hex encoded SHA-256 bank values of one PCR
+type TpmPcr struct {
+	Index  int
+	Values []string
+}
+
+func (p *PostureCheckTpmAttestation) TypeId() string {
+	return db.PostureCheckTypeTpm
+}
+
+// Validate checks that each PCR index is valid and listed once, and that every PCR has only valid SHA-256 values
+func (p *PostureCheckTpmAttestation) Validate() error {
+	seen := map[int]struct{}{}
+
+	for idx, pcr := range p.Pcrs {
+		if pcr.Index < 0 || pcr.Index > TpmMaxPcrIndex {
+			return errorz.NewFieldError(fmt.Sprintf("index must be between 0 and %d", TpmMaxPcrIndex), fmt.Sprintf("pcrs[%d].index", idx), pcr.Index)
+		}
+
+		if _, found := seen[pcr.Index]; found {
+			return errorz.NewFieldError("each pcr may only be listed once", fmt.Sprintf("pcrs[%d].index", idx), pcr.Index)
+		}
+		seen[pcr.Index] = struct{}{}
+
+		if len(pcr.Values) == 0 {
+			return errorz.NewFieldError("at least one value is required", fmt.Sprintf("pcrs[%d].values", idx), nil)
+		}
+
+		for _, value := range pcr.Values {
+			if !isSha256Hex(value) {
+				return errorz.NewFieldError("values must be hex encoded SHA-256 values", fmt.Sprintf("pcrs[%d].values", idx), value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// The TPM attestation check is stored in raft using the process multi message. Each PCR is stored as a process, with
+// the PCR index as the path and the allowed values as the hashes. The posture check type id distinguishes the two.
+func (p *PostureCheckTpmAttestation) fillProtobuf(msg *edge_cmd_pb.PostureCheck) {
+	processMultiMsg := &edge_cmd_pb.PostureCheck_ProcessMulti{
+		Semantic: db.SemanticAllOf,
+	}
+
+	for _, pcr := range p.Pcrs {
+		processMultiMsg.Processes = append(processMultiMsg.Processes, &edge_cmd_pb.PostureCheck_Process{
+			Path:   strconv.Itoa(pcr.Index),
+			Hashes: pcr.Values,
+		})
+	}
+
+	msg.Subtype = &edge_cmd_pb.PostureCheck_ProcessMulti_{
+		ProcessMulti: processMultiMsg,
+	}
+}
+
+func (p *PostureCheckTpmAttestation) fillFromProtobuf(msg *edge_cmd_pb.PostureCheck) error {
+	processMulti_, ok := msg.Subtype.(*edge_cmd_pb.PostureCheck_ProcessMulti_)
+	if !ok {
+		return errors.Errorf("expected posture check sub type data of process multi, but got %T", msg.Subtype)
+	}
+
+	p.PostureCheckId = msg.Id
+
+	if processMulti := processMulti_.ProcessMulti; processMulti != nil {
+		for _, process := range processMulti.Processes {
+			index, err := strconv.Atoi(process.Path)
+			if err != nil {
+				return errors.Wrapf(err, "invalid pcr index %s", process.Path)
+			}
+			p.Pcrs = append(p.Pcrs, &TpmPcr{
+				Index:  index,
+				Values: process.Hashes,
+			})
+		}
+	}
+
+	return nil
+}
+
+func (p *PostureCheckTpmAttestation) LastUpdatedAt(apiSessionId string, pd *PostureData) *time.Time {
+	if apiSessionData := pd.ApiSessions[apiSessionId]; apiSessionData != nil && apiSessionData.Tpm != nil {
+		return &apiSessionData.Tpm.LastUpdatedAt
+	}
+	return nil
+}
+
+func (p *PostureCheckTpmAttestation) GetTimeoutSeconds() int64 {
+	return PostureCheckNoTimeout
+}
+
+func (p *PostureCheckTpmAttestation) GetTimeoutRemainingSeconds(string, *PostureData) int64 {
+	return PostureCheckNoTimeout
+}
+
+func (p *PostureCheckTpmAttestation) FailureValues(apiSessionId string, pd *PostureData) PostureCheckFailureValues {
+	ret := &PostureCheckFailureValuesTpmAttestation{
+		ExpectedValue: p.Pcrs,
+	}
+
+	if apiSessionData := pd.ApiSessions[apiSessionId]; apiSessionData != nil && apiSessionData.Tpm != nil {
+		ret.ActualValue = apiSessionData.Tpm
+	}
+
+	return ret
+}
+
+func (p *PostureCheckTpmAttestation) Evaluate(apiSessionId string, pd *PostureData) bool {
+	apiSessionData := pd.ApiSessions[apiSessionId]
+	if apiSessionData == nil || apiSessionData.Tpm == nil {
+		return false
+	}
+
+	for _, pcr := range p.Pcrs {
+		actual, found := apiSessionData.Tpm.Pcrs[pcr.Index]
+		if !found {
+			return false
+		}
+
+		if !slices.ContainsFunc(pcr.Values, func(value string) bool {
+			return strings.EqualFold(value, actual)
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newPostureCheckTpmAttestation() PostureCheckSubType {
+	return &PostureCheckTpmAttestation{}
+}
+
+func (p *PostureCheckTpmAttestation) fillFrom(_ Env, _ *bbolt.Tx, check *db.PostureCheck, subType db.PostureCheckSubType) error {
+	subCheck, ok := subType.(*db.PostureCheckTpmAttestation)
+
+	if !ok || subCheck == nil {
+		return fmt.Errorf("could not convert tpm attestation check to bolt type")
+	}
+
+	p.PostureCheckId = check.Id
+
+	for _, pcr := range subCheck.Pcrs {
+		p.Pcrs = append(p.Pcrs, &TpmPcr{
+			Index:  pcr.Index,
+			Values: pcr.Values,
+		})
+	}
+
+	slices.SortFunc(p.Pcrs, func(a, b *TpmPcr) int {
+		return a.Index - b.Index
+	})
+
+	return nil
+}
+
+func (p *PostureCheckTpmAttestation) toBoltEntityForCreate(*bbolt.Tx, Env) (db.PostureCheckSubType, error) {
+	ret := &db.PostureCheckTpmAttestation{}
+
+	for _, pcr := range p.Pcrs {
+		ret.Pcrs = append(ret.Pcrs, &db.TpmPcr{
+			Index:  pcr.Index,
+			Values: pcr.Values,
+		})
+	}
+
+	return ret, nil
+}
+
+type PostureCheckFailureValuesTpmAttestation struct {
+	ActualValue   *PostureResponseTpm
+	ExpectedValue []*TpmPcr
+}
+
+func (p PostureCheckFailureValuesTpmAttestation) Expected() interface{} {
+	return p.ExpectedValue
+}
+
+func (p PostureCheckFailureValuesTpmAttestation) Actual() interface{} {
+	return p.ActualValue
+}
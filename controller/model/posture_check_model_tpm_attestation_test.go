@@ -0,0 +1,159 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/common/pb/edge_cmd_pb"
+	"github.com/stretchr/testify/require"
+)
+
+const tpmTestApiSessionId = "a1b2c3"
+
+func TestPostureCheckModelTpmAttestation_Evaluate(t *testing.T) {
+	t.Run("returns true for attested pcrs with allowed values", func(t *testing.T) {
+		tpmCheck, postureData := newMatchingTpmCheckAndData()
+
+		req := require.New(t)
+		req.True(tpmCheck.Evaluate(tpmTestApiSessionId, postureData))
+		req.NotNil(tpmCheck.LastUpdatedAt(tpmTestApiSessionId, postureData))
+	})
+
+	t.Run("returns true for allowed values with mismatched case", func(t *testing.T) {
+		tpmCheck, postureData := newMatchingTpmCheckAndData()
+		tpmCheck.Pcrs[0].Values[0] = strings.ToUpper(tpmCheck.Pcrs[0].Values[0])
+
+		req := require.New(t)
+		req.True(tpmCheck.Evaluate(tpmTestApiSessionId, postureData))
+	})
+
+	t.Run("returns true without pcrs if the api session attested", func(t *testing.T) {
+		tpmCheck, postureData := newMatchingTpmCheckAndData()
+		tpmCheck.Pcrs = nil
+
+		req := require.New(t)
+		req.True(tpmCheck.Evaluate(tpmTestApiSessionId, postureData))
+	})
+
+	t.Run("returns false if another api session attested", func(t *testing.T) {
+		tpmCheck, postureData := newMatchingTpmCheckAndData()
+
+		req := require.New(t)
+		req.False(tpmCheck.Evaluate("other", postureData))
+
+		failureValues := tpmCheck.FailureValues("other", postureData).(*PostureCheckFailureValuesTpmAttestation)
+		req.Nil(failureValues.ActualValue)
+		req.Len(failureValues.ExpectedValue, 2)
+	})
+
+	t.Run("returns false if a pcr value isn't allowed", func(t *testing.T) {
+		tpmCheck, postureData := newMatchingTpmCheckAndData()
+		postureData.ApiSessions[tpmTestApiSessionId].Tpm.Pcrs[7] = strings.Repeat("0", 64)
+
+		req := require.New(t)
+		req.False(tpmCheck.Evaluate(tpmTestApiSessionId, postureData))
+	})
+
+	t.Run("returns false if a pcr wasn't quoted", func(t *testing.T) {
+		tpmCheck, postureData := newMatchingTpmCheckAndData()
+		delete(postureData.ApiSessions[tpmTestApiSessionId].Tpm.Pcrs, 0)
+
+		req := require.New(t)
+		req.False(tpmCheck.Evaluate(tpmTestApiSessionId, postureData))
+	})
+}
+
+func TestPostureCheckModelTpmAttestation_Validate(t *testing.T) {
+	req := require.New(t)
+
+	tpmCheck, _ := newMatchingTpmCheckAndData()
+	req.NoError(tpmCheck.Validate())
+
+	tpmCheck.Pcrs[0].Values = append(tpmCheck.Pcrs[0].Values, "abc")
+	req.Error(tpmCheck.Validate())
+
+	tpmCheck, _ = newMatchingTpmCheckAndData()
+	tpmCheck.Pcrs[0].Values = nil
+	req.Error(tpmCheck.Validate())
+
+	tpmCheck, _ = newMatchingTpmCheckAndData()
+	tpmCheck.Pcrs[0].Index = TpmMaxPcrIndex + 1
+	req.Error(tpmCheck.Validate())
+
+	tpmCheck, _ = newMatchingTpmCheckAndData()
+	tpmCheck.Pcrs[1].Index = tpmCheck.Pcrs[0].Index
+	req.Error(tpmCheck.Validate())
+
+	tpmCheck.Pcrs = nil
+	req.NoError(tpmCheck.Validate())
+}
+
+func TestPostureCheckModelTpmAttestation_Protobuf(t *testing.T) {
+	req := require.New(t)
+
+	tpmCheck, _ := newMatchingTpmCheckAndData()
+
+	msg := &edge_cmd_pb.PostureCheck{Id: tpmCheck.PostureCheckId}
+	tpmCheck.fillProtobuf(msg)
+
+	decoded := &PostureCheckTpmAttestation{}
+	req.NoError(decoded.fillFromProtobuf(msg))
+	req.Equal(tpmCheck, decoded)
+}
+
+func newMatchingTpmCheckAndData() (*PostureCheckTpmAttestation, *PostureData) {
+	postureCheckId := "5c2e9a1"
+	pcr0 := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	pcr7 := strings.Repeat("ab", 32)
+
+	postureResponseTpm := &PostureResponseTpm{
+		PostureResponse: &PostureResponse{
+			PostureCheckId: PostureCheckTypeTpm,
+			TypeId:         PostureCheckTypeTpm,
+			LastUpdatedAt:  time.Now(),
+		},
+		ApiSessionId:  tpmTestApiSessionId,
+		EkFingerprint: "ek",
+		Pcrs: map[int]string{
+			0:  pcr0,
+			7:  pcr7,
+			14: strings.Repeat("1", 64),
+		},
+	}
+
+	validPostureData := newPostureData()
+	postureResponseTpm.Apply(validPostureData)
+
+	tpmCheck := &PostureCheckTpmAttestation{
+		PostureCheckId: postureCheckId,
+		Pcrs: []*TpmPcr{
+			{
+				Index:  0,
+				Values: []string{pcr0},
+			},
+			{
+				Index:  7,
+				Values: []string{strings.Repeat("2", 64), pcr7},
+			},
+		},
+	}
+
+	return tpmCheck, validPostureData
+}
@@ -72,6 +72,28 @@ func (self *PostureResponseManager) SetMfaPosture(identityId string, apiSessionI
 	self.Create(identityId, []*PostureResponse{postureResponse})
 }
 
+// SetTpmPosture records a successful TPM attestation, and the PCR values it quoted, for a specific API Session owned
+// by an identity
+func (self *PostureResponseManager) SetTpmPosture(identityId string, apiSessionId string, ekFingerprint string, pcrs map[int]string) {
+	postureResponse := &PostureResponse{
+		PostureCheckId: PostureCheckTypeTpm,
+		TypeId:         PostureCheckTypeTpm,
+		TimedOut:       false,
+		LastUpdatedAt:  time.Now().UTC(),
+	}
+
+	postureSubType := &PostureResponseTpm{
+		ApiSessionId:  apiSessionId,
+		EkFingerprint: ekFingerprint,
+		Pcrs:          pcrs,
+	}
+
+	postureResponse.SubType = postureSubType
+	postureSubType.PostureResponse = postureResponse
+
+	self.Create(identityId, []*PostureResponse{postureResponse})
+}
+
 // SetMfaPostureForIdentity sets the MFA passing status for all API Sessions associated to an identity
 func (self *PostureResponseManager) SetMfaPostureForIdentity(identityId string, isPassed bool) {
 	postureResponse := &PostureResponse{
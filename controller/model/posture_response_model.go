@@ -351,6 +351,7 @@ type ApiSessionPostureData struct {
 	Mfa           *PostureResponseMfa           `json:"mfa"`
 	EndpointState *PostureResponseEndpointState `json:"endpointState"`
 	SdkInfo       *SdkInfo
+	Tpm           *PostureResponseTpm `json:"tpm"`
 }
 
 func (self *ApiSessionPostureData) GetPassedMfaAt() *time.Time {
@@ -251,7 +251,43 @@ func (self *RouterManager) ApplyUpdate(cmd *command.UpdateEntityCommand[*Router]
 		})
 	}
 
-	return self.updateEntity(cmd.Entity, cmd.UpdatedFields, ctx)
+	var checker boltz.FieldChecker = cmd.UpdatedFields
+	if cmd.UpdatedFields == nil {
+		// drain state is only changed when explicitly requested
+		checker = NotFieldChecker{
+			db.FieldRouterDraining:      struct{}{},
+			db.FieldRouterDrainDeadline: struct{}{},
+		}
+	} else if cmd.UpdatedFields.IsUpdated(db.FieldRouterDraining) {
+		checker = cmd.UpdatedFields.AddField(db.FieldRouterDrainDeadline)
+		if err := self.applyDrainChange(cmd, ctx); err != nil {
+			return err
+		}
+	}
+
+	return self.updateEntity(cmd.Entity, checker, ctx)
+}
+
+// applyDrainChange quiesces the router's terminators when a drain starts and restores them when the drain ends
+func (self *RouterManager) applyDrainChange(cmd *command.UpdateEntityCommand[*Router], ctx boltz.MutateContext) error {
+	current, err := self.Store.LoadById(ctx.Tx(), cmd.Entity.Id)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Entity.Draining {
+		cmd.Entity.DrainDeadline = nil
+	}
+
+	if cmd.Entity.Draining && !current.Draining {
+		return self.ApplyQuiesce(cmd, ctx)
+	}
+
+	if !cmd.Entity.Draining && current.Draining {
+		return self.ApplyDequiesce(cmd, ctx)
+	}
+
+	return nil
 }
 
 // QuiesceRouter marks all terminators on the router as failed, so that new traffic will avoid this router, if there's
@@ -382,6 +418,8 @@ func (self *RouterManager) UpdateCachedRouter(id string) {
 			v.Cost = router.Cost
 			v.NoTraversal = router.NoTraversal
 			v.Disabled = router.Disabled
+			v.Draining = router.Draining
+			v.DrainDeadline = router.DrainDeadline
 
 			if v.Disabled {
 				if ctrl := v.Control; ctrl != nil {
@@ -421,6 +459,11 @@ func (self *RouterManager) Marshall(entity *Router) ([]byte, error) {
 		NoTraversal: entity.NoTraversal,
 		Disabled:    entity.Disabled,
 		Tags:        tags,
+		Draining:    entity.Draining,
+	}
+
+	if entity.DrainDeadline != nil {
+		msg.DrainDeadline = entity.DrainDeadline.UnixMilli()
 	}
 
 	for _, intf := range entity.Interfaces {
@@ -459,6 +502,12 @@ func (self *RouterManager) Unmarshall(bytes []byte) (*Router, error) {
 		Cost:        uint16(msg.Cost),
 		NoTraversal: msg.NoTraversal,
 		Disabled:    msg.Disabled,
+		Draining:    msg.Draining,
+	}
+
+	if msg.DrainDeadline != 0 {
+		drainDeadline := time.UnixMilli(msg.DrainDeadline)
+		result.DrainDeadline = &drainDeadline
 	}
 
 	for _, intf := range msg.Interfaces {
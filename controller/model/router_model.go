@@ -37,19 +37,21 @@ type Listener interface {
 
 type Router struct {
 	models.BaseEntity
-	Name        string
-	Fingerprint *string
-	Listeners   []*ctrl_pb.Listener
-	Control     channel.Channel
-	Connected   atomic.Bool
-	ConnectTime time.Time
-	VersionInfo *versions.VersionInfo
-	routerLinks RouterLinks
-	Cost        uint16
-	NoTraversal bool
-	Disabled    bool
-	Metadata    *ctrl_pb.RouterMetadata
-	Interfaces  []*Interface
+	Name          string
+	Fingerprint   *string
+	Listeners     []*ctrl_pb.Listener
+	Control       channel.Channel
+	Connected     atomic.Bool
+	ConnectTime   time.Time
+	VersionInfo   *versions.VersionInfo
+	routerLinks   RouterLinks
+	Cost          uint16
+	NoTraversal   bool
+	Disabled      bool
+	Draining      bool
+	DrainDeadline *time.Time
+	Metadata      *ctrl_pb.RouterMetadata
+	Interfaces    []*Interface
 }
 
 func (entity *Router) GetLinks() []*Link {
@@ -68,6 +70,8 @@ func (entity *Router) toBoltEntityForCreate(*bbolt.Tx, Env) (*db.Router, error)
 		Cost:          entity.Cost,
 		NoTraversal:   entity.NoTraversal,
 		Disabled:      entity.Disabled,
+		Draining:      entity.Draining,
+		DrainDeadline: entity.DrainDeadline,
 		Interfaces:    InterfacesToBolt(entity.Interfaces),
 	}, nil
 }
@@ -78,6 +82,8 @@ func (entity *Router) fillFrom(_ Env, _ *bbolt.Tx, boltRouter *db.Router) error
 	entity.Cost = boltRouter.Cost
 	entity.NoTraversal = boltRouter.NoTraversal
 	entity.Disabled = boltRouter.Disabled
+	entity.Draining = boltRouter.Draining
+	entity.DrainDeadline = boltRouter.DrainDeadline
 	entity.Interfaces = InterfacesFromBolt(boltRouter.Interfaces)
 	entity.FillCommon(boltRouter)
 	return nil
@@ -104,6 +110,18 @@ func (entity *Router) HasCapability(capability ctrl_pb.RouterCapability) bool {
 	return entity.Metadata != nil && genext.Contains(entity.Metadata.Capabilities, capability)
 }
 
+// IsDialOnly returns true if the router can't accept inbound link connections. Dial only routers dial links to
+// their peers, but are never advertised to peers as link destinations.
+func (entity *Router) IsDialOnly() bool {
+	return entity.HasCapability(ctrl_pb.RouterCapability_LinkDialOnly)
+}
+
+// IsDrainExpired returns true if the router is draining and the drain deadline has passed. Circuits which are still
+// using the router at that point are moved off it or closed.
+func (entity *Router) IsDrainExpired(now time.Time) bool {
+	return entity.Draining && entity.DrainDeadline != nil && now.After(*entity.DrainDeadline)
+}
+
 func (entity *Router) SupportsRouterLinkMgmt() bool {
 	if entity.VersionInfo == nil {
 		return true
@@ -0,0 +1,145 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"crypto/x509"
+	"strings"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/common/cert"
+	"github.com/openziti/ziti/common/spiffehlp"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/pkg/errors"
+)
+
+// VerifySvid checks if the given certificate chain is an X.509 SVID issued to the given router, as configured in the
+// spiffeEnrollment controller config section. If the certificates are not an SVID from the configured trust domain,
+// false is returned along with a nil error, so that callers may fall back to regular certificate checks. If the SVID
+// is valid for the router and its fingerprint doesn't match the router's stored fingerprint, the router is updated to
+// use the new fingerprint. This both completes enrollment for newly created routers and handles SVID renewals.
+func (self *RouterManager) VerifySvid(routerId string, certs []*x509.Certificate, ctx *change.Context) (bool, error) {
+	spiffeConfig := self.env.GetConfig().SpiffeEnrollment
+	if spiffeConfig == nil {
+		return false, nil
+	}
+
+	trustBundle, err := spiffeConfig.GetTrustBundle()
+	if err != nil {
+		return false, err
+	}
+
+	spiffeId, leaf, err := spiffehlp.VerifyX509Svid(certs, trustBundle)
+	if err != nil {
+		pfxlog.Logger().WithField("routerId", routerId).WithError(err).Debug("router certificates are not a valid SVID")
+		return false, nil
+	}
+
+	if !strings.EqualFold(spiffeId.Host, spiffeConfig.TrustDomain) {
+		return false, nil
+	}
+
+	if expected := spiffeConfig.RouterSpiffeId(routerId); spiffeId.String() != expected {
+		return false, errors.Errorf("SVID SPIFFE id [%s] doesn't match expected SPIFFE id [%s] for router", spiffeId.String(), expected)
+	}
+
+	fingerprint := self.env.GetFingerprintGenerator().FromCert(leaf)
+
+	var certPems []string
+	for _, c := range certs {
+		certPem, err := cert.RawToPem(c.Raw)
+		if err != nil {
+			return false, err
+		}
+		certPems = append(certPems, string(certPem))
+	}
+	certPem := strings.Join(certPems, "")
+
+	log := pfxlog.Logger().WithField("routerId", routerId).
+		WithField("spiffeId", spiffeId.String()).
+		WithField("fingerprint", fingerprint)
+
+	if edgeRouter, _ := self.env.GetManagers().EdgeRouter.Read(routerId); edgeRouter != nil {
+		if edgeRouter.Fingerprint != nil && *edgeRouter.Fingerprint == fingerprint {
+			return true, nil
+		}
+
+		edgeRouter.Fingerprint = &fingerprint
+		edgeRouter.CertPem = &certPem
+		edgeRouter.IsVerified = true
+
+		err = self.env.GetManagers().EdgeRouter.Update(edgeRouter, true, fields.UpdatedFieldsMap{
+			db.FieldRouterFingerprint:    struct{}{},
+			db.FieldEdgeRouterCertPEM:    struct{}{},
+			db.FieldEdgeRouterIsVerified: struct{}{},
+		}, ctx)
+
+		if err != nil {
+			return false, err
+		}
+
+		log.Info("edge router fingerprint updated from SVID")
+		return true, self.deleteRouterEnrollments(routerId, self.env.GetManagers().EdgeRouter.CollectEnrollments, ctx)
+	}
+
+	if transitRouter, _ := self.env.GetManagers().TransitRouter.Read(routerId); transitRouter != nil {
+		if transitRouter.Fingerprint != nil && *transitRouter.Fingerprint == fingerprint {
+			return true, nil
+		}
+
+		transitRouter.Fingerprint = &fingerprint
+		transitRouter.IsVerified = true
+
+		err = self.env.GetManagers().TransitRouter.Update(transitRouter, true, fields.UpdatedFieldsMap{
+			db.FieldRouterFingerprint:       struct{}{},
+			db.FieldTransitRouterIsVerified: struct{}{},
+		}, ctx)
+
+		if err != nil {
+			return false, err
+		}
+
+		log.Info("router fingerprint updated from SVID")
+		return true, self.deleteRouterEnrollments(routerId, self.env.GetManagers().TransitRouter.CollectEnrollments, ctx)
+	}
+
+	return false, errors.Errorf("no router found with id %s", routerId)
+}
+
+// deleteRouterEnrollments removes any outstanding JWT enrollments, since they aren't needed once a router has
+// authenticated with an SVID
+func (self *RouterManager) deleteRouterEnrollments(routerId string, collect func(id string, collector func(entity *Enrollment) error) error, ctx *change.Context) error {
+	var enrollmentIds []string
+	err := collect(routerId, func(entity *Enrollment) error {
+		enrollmentIds = append(enrollmentIds, entity.Id)
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, enrollmentId := range enrollmentIds {
+		if err = self.env.GetManagers().Enrollment.Delete(enrollmentId, ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
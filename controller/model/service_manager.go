@@ -158,6 +158,7 @@ func (self *ServiceManager) Marshall(entity *Service) ([]byte, error) {
 		Id:                 entity.Id,
 		Name:               entity.Name,
 		MaxIdleTime:        int64(entity.MaxIdleTime),
+		MaxCircuitLifetime: int64(entity.MaxCircuitLifetime),
 		TerminatorStrategy: entity.TerminatorStrategy,
 		Tags:               tags,
 	}
@@ -178,6 +179,7 @@ func (self *ServiceManager) Unmarshall(bytes []byte) (*Service, error) {
 		},
 		Name:               msg.Name,
 		MaxIdleTime:        time.Duration(msg.MaxIdleTime),
+		MaxCircuitLifetime: time.Duration(msg.MaxCircuitLifetime),
 		TerminatorStrategy: msg.TerminatorStrategy,
 	}, nil
 }
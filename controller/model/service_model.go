@@ -30,6 +30,7 @@ type Service struct {
 	TerminatorStrategy string
 	Terminators        []*Terminator
 	MaxIdleTime        time.Duration
+	MaxCircuitLifetime time.Duration
 }
 
 func (entity *Service) GetName() string {
@@ -45,6 +46,7 @@ func (entity *Service) toBoltEntityForCreate(*bbolt.Tx, Env) (*db.Service, error
 		BaseExtEntity:      *boltz.NewExtEntity(entity.Id, entity.Tags),
 		Name:               entity.Name,
 		MaxIdleTime:        entity.MaxIdleTime,
+		MaxCircuitLifetime: entity.MaxCircuitLifetime,
 		TerminatorStrategy: entity.TerminatorStrategy,
 	}, nil
 }
@@ -52,6 +54,7 @@ func (entity *Service) toBoltEntityForCreate(*bbolt.Tx, Env) (*db.Service, error
 func (entity *Service) fillFrom(env Env, tx *bbolt.Tx, boltService *db.Service) error {
 	entity.Name = boltService.Name
 	entity.MaxIdleTime = boltService.MaxIdleTime
+	entity.MaxCircuitLifetime = boltService.MaxCircuitLifetime
 	entity.TerminatorStrategy = boltService.TerminatorStrategy
 	entity.FillCommon(boltService)
 
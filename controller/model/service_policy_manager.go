@@ -81,6 +81,13 @@ func (self *ServicePolicyManager) Marshall(entity *ServicePolicy) ([]byte, error
 		PolicyType:        entity.PolicyType,
 	}
 
+	if entity.Schedule != nil {
+		msg.ScheduleTimezone = entity.Schedule.Timezone
+		msg.ScheduleWindows = entity.Schedule.Windows
+		msg.ScheduleNotBefore = timePtrToPb(entity.Schedule.NotBefore)
+		msg.ScheduleNotAfter = timePtrToPb(entity.Schedule.NotAfter)
+	}
+
 	return proto.Marshal(msg)
 }
 
@@ -90,7 +97,7 @@ func (self *ServicePolicyManager) Unmarshall(bytes []byte) (*ServicePolicy, erro
 		return nil, err
 	}
 
-	return &ServicePolicy{
+	result := &ServicePolicy{
 		BaseEntity: models.BaseEntity{
 			Id:   msg.Id,
 			Tags: edge_cmd_pb.DecodeTags(msg.Tags),
@@ -101,7 +108,18 @@ func (self *ServicePolicyManager) Unmarshall(bytes []byte) (*ServicePolicy, erro
 		ServiceRoles:      msg.ServiceRoles,
 		PostureCheckRoles: msg.PostureCheckRoles,
 		PolicyType:        msg.PolicyType,
-	}, nil
+	}
+
+	if msg.ScheduleTimezone != "" || len(msg.ScheduleWindows) > 0 || msg.ScheduleNotBefore != nil || msg.ScheduleNotAfter != nil {
+		result.Schedule = &ServicePolicySchedule{
+			Timezone:  msg.ScheduleTimezone,
+			Windows:   msg.ScheduleWindows,
+			NotBefore: pbTimeToTimePtr(msg.ScheduleNotBefore),
+			NotAfter:  pbTimeToTimePtr(msg.ScheduleNotAfter),
+		}
+	}
+
+	return result, nil
 }
 
 type AssociatedIdsResult struct {
@@ -34,6 +34,7 @@ type ServicePolicy struct {
 	IdentityRoles     []string
 	ServiceRoles      []string
 	PostureCheckRoles []string
+	Schedule          *ServicePolicySchedule
 }
 
 func (entity *ServicePolicy) validatePolicyType() error {
@@ -51,6 +52,12 @@ func (entity *ServicePolicy) toBoltEntity(checker boltz.FieldChecker) (*db.Servi
 		}
 	}
 
+	if entity.Schedule != nil && (checker == nil || checker.IsUpdated(db.FieldServicePolicySchedule)) {
+		if err := entity.Schedule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	policyType := db.PolicyTypeInvalid
 	if strings.EqualFold(entity.PolicyType, db.PolicyTypeDialName) {
 		policyType = db.PolicyTypeDial
@@ -66,6 +73,7 @@ func (entity *ServicePolicy) toBoltEntity(checker boltz.FieldChecker) (*db.Servi
 		IdentityRoles:     entity.IdentityRoles,
 		ServiceRoles:      entity.ServiceRoles,
 		PostureCheckRoles: entity.PostureCheckRoles,
+		Schedule:          entity.Schedule.toBolt(),
 	}, nil
 }
 
@@ -85,5 +93,6 @@ func (entity *ServicePolicy) fillFrom(_ Env, _ *bbolt.Tx, boltServicePolicy *db.
 	entity.ServiceRoles = boltServicePolicy.ServiceRoles
 	entity.IdentityRoles = boltServicePolicy.IdentityRoles
 	entity.PostureCheckRoles = boltServicePolicy.PostureCheckRoles
+	entity.Schedule = newServicePolicyScheduleFromBolt(boltServicePolicy.Schedule)
 	return nil
 }
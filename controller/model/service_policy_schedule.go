@@ -0,0 +1,90 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/common/schedule"
+	"github.com/openziti/ziti/controller/db"
+)
+
+// ServicePolicySchedule limits when a service policy grants access. See schedule.Schedule for how schedules are
+// evaluated.
+type ServicePolicySchedule struct {
+	Timezone  string
+	Windows   []string
+	NotBefore *time.Time
+	NotAfter  *time.Time
+}
+
+func (self *ServicePolicySchedule) Validate() error {
+	if _, err := schedule.LoadLocation(self.Timezone); err != nil {
+		return errorz.NewFieldError(err.Error(), "schedule.timezone", self.Timezone)
+	}
+
+	for _, window := range self.Windows {
+		if err := schedule.ValidateWindow(window); err != nil {
+			return errorz.NewFieldError(err.Error(), "schedule.windows", window)
+		}
+	}
+
+	if self.NotBefore != nil && self.NotAfter != nil && !self.NotAfter.After(*self.NotBefore) {
+		return errorz.NewFieldError("notAfter must be after notBefore", "schedule.notAfter", self.NotAfter)
+	}
+
+	return nil
+}
+
+// IsActive returns true if the schedule allows access at the given time. Invalid schedules never allow access.
+func (self *ServicePolicySchedule) IsActive(t time.Time) bool {
+	return self.toSchedule().IsActive(t)
+}
+
+func (self *ServicePolicySchedule) toSchedule() *schedule.Schedule {
+	return &schedule.Schedule{
+		Timezone:  self.Timezone,
+		Windows:   self.Windows,
+		NotBefore: self.NotBefore,
+		NotAfter:  self.NotAfter,
+	}
+}
+
+func (self *ServicePolicySchedule) toBolt() *db.ServicePolicySchedule {
+	if self == nil {
+		return nil
+	}
+	return &db.ServicePolicySchedule{
+		Timezone:  self.Timezone,
+		Windows:   self.Windows,
+		NotBefore: self.NotBefore,
+		NotAfter:  self.NotAfter,
+	}
+}
+
+func newServicePolicyScheduleFromBolt(schedule *db.ServicePolicySchedule) *ServicePolicySchedule {
+	if schedule == nil {
+		return nil
+	}
+	return &ServicePolicySchedule{
+		Timezone:  schedule.Timezone,
+		Windows:   schedule.Windows,
+		NotBefore: schedule.NotBefore,
+		NotAfter:  schedule.NotAfter,
+	}
+}
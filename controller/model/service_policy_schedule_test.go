@@ -0,0 +1,125 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServicePolicyScheduleValidate(t *testing.T) {
+	valid := []string{
+		"mon-fri 09:00-17:00",
+		"Sat,Sun 10:00-14:00",
+		"* 22:00-06:00",
+		"fri-mon 00:00-24:00",
+		"08:30-12:00",
+		"wednesday",
+	}
+
+	for _, window := range valid {
+		schedule := &ServicePolicySchedule{Windows: []string{window}}
+		require.NoError(t, schedule.Validate(), window)
+	}
+
+	invalid := []string{
+		"",
+		"mon-fri 09:00",
+		"mon-fri 09:00-09:00",
+		"mon-fri 09:00-25:00",
+		"mon-fri 09:60-17:00",
+		"someday 09:00-17:00",
+		"mon 24:00-02:00",
+		"mon 09:00-17:00 extra",
+	}
+
+	for _, window := range invalid {
+		schedule := &ServicePolicySchedule{Windows: []string{window}}
+		require.Error(t, schedule.Validate(), window)
+	}
+
+	require.Error(t, (&ServicePolicySchedule{Timezone: "Not/AZone"}).Validate())
+
+	now := time.Now()
+	require.Error(t, (&ServicePolicySchedule{NotBefore: &now, NotAfter: &now}).Validate())
+}
+
+func TestServicePolicyScheduleIsActive(t *testing.T) {
+	at := func(day, clock string) time.Time {
+		result, err := time.Parse("2006-01-02 15:04", day+" "+clock)
+		require.NoError(t, err)
+		return result
+	}
+
+	// 2025-01-06 is a Monday
+	monday := "2025-01-06"
+	friday := "2025-01-10"
+	saturday := "2025-01-11"
+
+	t.Run("weekday business hours", func(t *testing.T) {
+		req := require.New(t)
+		schedule := &ServicePolicySchedule{Windows: []string{"mon-fri 09:00-17:00"}}
+
+		req.True(schedule.IsActive(at(monday, "09:00")))
+		req.True(schedule.IsActive(at(friday, "16:59")))
+		req.False(schedule.IsActive(at(friday, "17:00")))
+		req.False(schedule.IsActive(at(monday, "08:59")))
+		req.False(schedule.IsActive(at(saturday, "12:00")))
+	})
+
+	t.Run("window past midnight", func(t *testing.T) {
+		req := require.New(t)
+		schedule := &ServicePolicySchedule{Windows: []string{"fri 22:00-06:00"}}
+
+		req.True(schedule.IsActive(at(friday, "23:30")))
+		req.True(schedule.IsActive(at(saturday, "05:59")))
+		req.False(schedule.IsActive(at(saturday, "06:00")))
+		req.False(schedule.IsActive(at(monday, "23:30")))
+	})
+
+	t.Run("timezone", func(t *testing.T) {
+		req := require.New(t)
+		schedule := &ServicePolicySchedule{
+			Timezone: "America/New_York",
+			Windows:  []string{"mon 09:00-17:00"},
+		}
+
+		// 09:00 in New York in January is 14:00 UTC
+		req.False(schedule.IsActive(at(monday, "09:00")))
+		req.True(schedule.IsActive(at(monday, "14:00")))
+		req.True(schedule.IsActive(at(monday, "21:59")))
+		req.False(schedule.IsActive(at(monday, "22:00")))
+	})
+
+	t.Run("validity range", func(t *testing.T) {
+		req := require.New(t)
+		notBefore := at(monday, "00:00")
+		notAfter := at(friday, "00:00")
+		schedule := &ServicePolicySchedule{NotBefore: &notBefore, NotAfter: &notAfter}
+
+		req.False(schedule.IsActive(at("2025-01-05", "23:59")))
+		req.True(schedule.IsActive(at(monday, "00:00")))
+		req.True(schedule.IsActive(at("2025-01-09", "23:59")))
+		req.False(schedule.IsActive(at(friday, "00:00")))
+
+		schedule.Windows = []string{"mon 09:00-17:00"}
+		req.True(schedule.IsActive(at(monday, "10:00")))
+		req.False(schedule.IsActive(at("2025-01-13", "10:00")))
+	})
+}
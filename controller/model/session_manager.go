@@ -60,6 +60,65 @@ type SessionPostureResult struct {
 	Cause            *fabricApiError.GenericCauseError
 }
 
+type SessionScheduleResult struct {
+	Passed               bool
+	OutOfWindowPolicyIds []string
+	Cause                *fabricApiError.GenericCauseError
+}
+
+// EvaluateScheduleForService checks whether any of the service policies of the given session type granting the
+// identity access to the service is inside its schedule. Policies without a schedule are always inside it.
+func (self *SessionManager) EvaluateScheduleForService(identityId, sessionType, serviceId string, now time.Time) *SessionScheduleResult {
+	result := &SessionScheduleResult{
+		Passed: true,
+	}
+
+	hasMatchingPolicies := false
+	hasActivePolicies := false
+	var outOfWindowPolicyNames []string
+
+	for policyId, policySchedule := range self.GetEnv().GetManagers().EdgeService.GetPolicySchedules(identityId, serviceId) {
+		if policySchedule.PolicyType.String() != sessionType {
+			continue
+		}
+		hasMatchingPolicies = true
+
+		if policySchedule.Schedule == nil || policySchedule.Schedule.IsActive(now) {
+			hasActivePolicies = true
+		} else {
+			result.OutOfWindowPolicyIds = append(result.OutOfWindowPolicyIds, policyId)
+			outOfWindowPolicyNames = append(outOfWindowPolicyNames, policySchedule.PolicyName)
+		}
+	}
+
+	if hasMatchingPolicies && !hasActivePolicies {
+		result.Passed = false
+		result.Cause = &fabricApiError.GenericCauseError{
+			Message: fmt.Sprintf("outside schedule of service policies: %v", outOfWindowPolicyNames),
+			DataMap: map[string]interface{}{
+				"policyIds": result.OutOfWindowPolicyIds,
+			},
+		}
+	}
+
+	return result
+}
+
+func (self *SessionManager) emitSessionDenied(entity *Session, provider string, reason string) {
+	self.env.GetEventDispatcher().AcceptSessionEvent(&event.SessionEvent{
+		Namespace:    event.SessionEventNS,
+		EventSrcId:   self.env.GetId(),
+		Timestamp:    time.Now(),
+		EventType:    event.SessionEventTypeDenied,
+		Provider:     provider,
+		SessionType:  entity.Type,
+		ApiSessionId: entity.ApiSessionId,
+		IdentityId:   entity.IdentityId,
+		ServiceId:    entity.ServiceId,
+		Reason:       reason,
+	})
+}
+
 func (self *SessionManager) EvaluatePostureForService(identityId, apiSessionId, sessionType, serviceId, serviceName string) *SessionPostureResult {
 
 	failureByPostureCheckId := map[string]*PostureCheckFailure{} //cache individual check status
@@ -68,6 +127,12 @@ func (self *SessionManager) EvaluatePostureForService(identityId, apiSessionId,
 
 	policyPostureCheckMap := self.GetEnv().GetManagers().EdgeService.GetPolicyPostureChecks(identityId, serviceId)
 
+	// policies outside their schedule don't grant access, regardless of posture
+	scheduleResult := self.EvaluateScheduleForService(identityId, sessionType, serviceId, time.Now())
+	for _, policyId := range scheduleResult.OutOfWindowPolicyIds {
+		delete(policyPostureCheckMap, policyId)
+	}
+
 	failedPolicies := map[string][]*PostureCheckFailure{}
 	failedPoliciesIdToName := map[string]string{}
 
@@ -178,6 +243,16 @@ func (self *SessionManager) CreateJwt(entity *Session, isLegacy bool) (string, e
 		return "", errorz.NewFieldError("service not found", "ServiceId", entity.ServiceId)
 	}
 
+	provider := event.SessionProviderJwt
+	if isLegacy {
+		provider = event.SessionProviderLegacy
+	}
+
+	if scheduleResult := self.EvaluateScheduleForService(entity.IdentityId, entity.Type, service.Id, time.Now()); !scheduleResult.Passed {
+		self.emitSessionDenied(entity, provider, scheduleResult.Cause.Message)
+		return "", apierror.NewOutsideSchedule(scheduleResult.Cause)
+	}
+
 	if isLegacy {
 		policyResult := self.EvaluatePostureForService(entity.IdentityId, entity.ApiSessionId, entity.Type, service.Id, service.Name)
 
@@ -273,6 +348,12 @@ func (self *SessionManager) Create(entity *Session, ctx *change.Context) (string
 		return "", errorz.NewFieldError("service not found", "ServiceId", entity.ServiceId)
 	}
 
+	if scheduleResult := self.EvaluateScheduleForService(apiSession.IdentityId, entity.Type, service.Id, time.Now()); !scheduleResult.Passed {
+		entity.IdentityId = apiSession.IdentityId
+		self.emitSessionDenied(entity, event.SessionProviderLegacy, scheduleResult.Cause.Message)
+		return "", apierror.NewOutsideSchedule(scheduleResult.Cause)
+	}
+
 	policyResult := self.EvaluatePostureForService(apiSession.IdentityId, apiSession.Id, entity.Type, service.Id, service.Name)
 
 	if !policyResult.Passed {
@@ -387,6 +468,11 @@ func (self *SessionManager) Delete(id string, ctx *change.Context) error {
 	return self.deleteEntity(id, ctx)
 }
 
+// PublicQuery returns the sessions of all identities matching the given query
+func (self *SessionManager) PublicQuery(query ast.Query) (*SessionListResult, error) {
+	return self.querySessions(query)
+}
+
 func (self *SessionManager) PublicQueryForIdentity(sessionIdentity *Identity, query ast.Query) (*SessionListResult, error) {
 	if sessionIdentity.IsAdmin {
 		return self.querySessions(query)
@@ -389,6 +389,7 @@ func (result *TerminatorListResult) collect(tx *bbolt.Tx, ids []string, qmd *mod
 
 type RoutingTerminator struct {
 	RouteCost uint32
+	PathCost  uint32
 	*Terminator
 }
 
@@ -396,6 +397,10 @@ func (r *RoutingTerminator) GetRouteCost() uint32 {
 	return r.RouteCost
 }
 
+func (r *RoutingTerminator) GetPathCost() uint32 {
+	return r.PathCost
+}
+
 type DeleteTerminatorsBatchCommand struct {
 	Context *change.Context
 	Manager *TerminatorManager
@@ -51,6 +51,8 @@ type TestContext struct {
 	closeNotify     chan struct{}
 	dispatcher      command.Dispatcher
 	eventDispatcher event.Dispatcher
+
+	onlineEdgeRouters map[string]bool
 }
 
 func (ctx *TestContext) CreateTotpTokenFromAccessClaims(issuer string, claims *common.AccessClaims) (string, *common.TotpClaims, error) {
@@ -158,8 +160,15 @@ func (ctx *TestContext) GetControlClientCsrSigner() cert.Signer {
 	return nil
 }
 
-func (ctx *TestContext) IsEdgeRouterOnline(string) bool {
-	panic("implement me")
+func (ctx *TestContext) IsEdgeRouterOnline(id string) bool {
+	return ctx.onlineEdgeRouters[id]
+}
+
+func (ctx *TestContext) setEdgeRouterOnline(id string, online bool) {
+	if ctx.onlineEdgeRouters == nil {
+		ctx.onlineEdgeRouters = map[string]bool{}
+	}
+	ctx.onlineEdgeRouters[id] = online
 }
 
 func (ctx *TestContext) GetMetricsRegistry() metrics.Registry {
@@ -17,6 +17,9 @@
 package network
 
 import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
 	"github.com/openziti/ziti/controller/event"
 	"github.com/openziti/ziti/controller/model"
 	"github.com/openziti/ziti/controller/xt"
@@ -44,6 +47,15 @@ func (network *Network) fillCircuitPath(e *event.CircuitEvent, path *model.Path)
 }
 
 func (network *Network) CircuitEvent(eventType event.CircuitEventType, circuit *model.Circuit, creationTimespan *time.Duration) {
+	network.circuitEvent(eventType, circuit, creationTimespan, "")
+}
+
+func (network *Network) circuitEvent(eventType event.CircuitEventType, circuit *model.Circuit, creationTimespan *time.Duration, reason CircuitTerminationReason) {
+	var terminationReason *string
+	if strReason := string(reason); strReason != "" {
+		terminationReason = &strReason
+	}
+
 	var cost *uint32
 	var duration *time.Duration
 	if eventType == event.CircuitCreated {
@@ -55,26 +67,118 @@ func (network *Network) CircuitEvent(eventType event.CircuitEventType, circuit *
 	}
 
 	circuitEvent := &event.CircuitEvent{
-		Namespace:        event.CircuitEventNS,
-		Version:          event.CircuitEventsVersion,
-		EventType:        eventType,
-		EventSrcId:       network.GetAppId(),
-		CircuitId:        circuit.Id,
-		Timestamp:        time.Now(),
-		ClientId:         circuit.ClientId,
-		ServiceId:        circuit.ServiceId,
-		TerminatorId:     circuit.Terminator.GetId(),
-		InstanceId:       circuit.Terminator.GetInstanceId(),
-		CreationTimespan: creationTimespan,
-		Cost:             cost,
-		Duration:         duration,
-		Tags:             circuit.Tags,
+		Namespace:         event.CircuitEventNS,
+		Version:           event.CircuitEventsVersion,
+		EventType:         eventType,
+		EventSrcId:        network.GetAppId(),
+		CircuitId:         circuit.Id,
+		Timestamp:         time.Now(),
+		ClientId:          circuit.ClientId,
+		ServiceId:         circuit.ServiceId,
+		TerminatorId:      circuit.Terminator.GetId(),
+		InstanceId:        circuit.Terminator.GetInstanceId(),
+		CreationTimespan:  creationTimespan,
+		Cost:              cost,
+		Duration:          duration,
+		Classification:    circuit.Classification.Load(),
+		TerminationReason: terminationReason,
+		Tags:              circuit.Tags,
 	}
 
 	network.fillCircuitPath(circuitEvent, circuit.Path)
 	network.eventDispatcher.AcceptCircuitEvent(circuitEvent)
 }
 
+// AcceptCircuitClassification records what a circuit's ingress router recognized in the circuit's traffic and emits
+// a circuit classified event. Classifications from routers other than the ingress router, and repeat
+// classifications, are ignored.
+func (network *Network) AcceptCircuitClassification(routerId string, classification *ctrl_msg.CircuitClassification) {
+	log := pfxlog.Logger().WithField("routerId", routerId).WithField("circuitId", classification.CircuitId)
+
+	circuit, found := network.GetCircuit(classification.CircuitId)
+	if !found {
+		log.Debug("received classification for unknown circuit")
+		return
+	}
+
+	if circuit.Path == nil || len(circuit.Path.Nodes) == 0 || circuit.Path.Nodes[0].Id != routerId {
+		log.Warn("received circuit classification from router which isn't the circuit's ingress router")
+		return
+	}
+
+	eventClassification := &event.CircuitClassification{
+		Protocol:   classification.Protocol,
+		ServerName: classification.ServerName,
+		Host:       classification.Host,
+		GrpcMethod: classification.GrpcMethod,
+	}
+
+	if !circuit.Classification.CompareAndSwap(nil, eventClassification) {
+		return
+	}
+
+	network.CircuitEvent(event.CircuitClassified, circuit, nil)
+}
+
+// AcceptCircuitLimitExceeded handles a report from one of a circuit's endpoint routers that the circuit has exceeded
+// the max lifetime or max idle time of its service. The circuit is removed and the deleted event records the limit
+// which was exceeded.
+func (network *Network) AcceptCircuitLimitExceeded(routerId string, report *ctrl_msg.CircuitLimitExceeded) {
+	log := pfxlog.Logger().WithField("routerId", routerId).
+		WithField("circuitId", report.CircuitId).
+		WithField("limit", report.Limit).
+		WithField("elapsed", report.Elapsed.String())
+
+	circuit, found := network.GetCircuit(report.CircuitId)
+	if !found {
+		log.Debug("received circuit limit exceeded for unknown circuit")
+		return
+	}
+
+	if !circuit.IsEndpointRouter(routerId) {
+		log.Warn("received circuit limit exceeded from router which isn't an endpoint router for the circuit")
+		return
+	}
+
+	reason := CircuitTerminationMaxIdleTimeExceeded
+	if report.Limit == ctrl_msg.CircuitLimitMaxLifetime {
+		reason = CircuitTerminationMaxLifetimeExceeded
+	}
+
+	log.Info("removing circuit which has exceeded service limit")
+	if err := network.RemoveCircuitWithReason(circuit.Id, true, reason); err != nil {
+		log.WithError(err).Error("error removing circuit which has exceeded service limit")
+	}
+}
+
+// setCircuitLimits sets the max idle time and max lifetime of the circuit's service on the route messages for the
+// initiating and terminating routers, which enforce them. The max lifetime is sent as the time remaining, so that
+// routes sent on reroute don't extend the circuit's lifetime.
+func (network *Network) setCircuitLimits(rms []*ctrl_pb.Route, svc *model.Service, createdAt time.Time) {
+	if len(rms) == 0 {
+		return
+	}
+
+	var maxLifetime time.Duration
+	if svc.MaxCircuitLifetime > 0 {
+		maxLifetime = max(svc.MaxCircuitLifetime-time.Since(createdAt), time.Millisecond)
+	}
+
+	for _, rm := range []*ctrl_pb.Route{rms[0], rms[len(rms)-1]} {
+		rm.MaxIdleTime = uint64(svc.MaxIdleTime)
+		rm.MaxLifetime = uint64(maxLifetime)
+	}
+}
+
+// CircuitTerminationReason is recorded in circuit deleted events when a circuit was ended because it exceeded a
+// limit set on its service
+type CircuitTerminationReason string
+
+const (
+	CircuitTerminationMaxLifetimeExceeded CircuitTerminationReason = "MAX_LIFETIME_EXCEEDED"
+	CircuitTerminationMaxIdleTimeExceeded CircuitTerminationReason = "MAX_IDLE_TIME_EXCEEDED"
+)
+
 type CircuitFailureCause string
 
 const (
@@ -92,6 +196,7 @@ const (
 	CircuitFailureRouterErrMisconfiguredTerminator CircuitFailureCause = "ROUTER_ERR_MISCONFIGURED_TERMINATOR"
 	CircuitFailureRouterErrDialTimedOut            CircuitFailureCause = "ROUTER_ERR_DIAL_TIMED_OUT"
 	CircuitFailureRouterErrDialConnRefused         CircuitFailureCause = "ROUTER_ERR_CONN_REFUSED"
+	CircuitFailureServiceCircuitBreakerOpen        CircuitFailureCause = "SERVICE_CIRCUIT_BREAKER_OPEN"
 )
 
 type CircuitError interface {
@@ -0,0 +1,71 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"errors"
+
+	"github.com/openziti/ziti/controller/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// circuitTracer creates spans for circuit creation. Until a tracer provider is configured, spans are no-ops.
+var circuitTracer = otel.Tracer("github.com/openziti/ziti/controller/network")
+
+func startCircuitCreateSpan(params model.CreateCircuitParams) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("ziti.service.id", params.GetServiceId()),
+	}
+
+	if clientId := params.GetClientId(); clientId != nil {
+		attrs = append(attrs, attribute.String("ziti.client.id", clientId.Token))
+	}
+
+	if srcRouter := params.GetSourceRouter(); srcRouter != nil {
+		attrs = append(attrs, attribute.String("ziti.router.id", srcRouter.Id))
+	}
+
+	return circuitTracer.Start(context.Background(), "circuit.create", trace.WithAttributes(attrs...))
+}
+
+func endCircuitCreateSpan(span trace.Span, circuit *model.Circuit, err error) {
+	if circuit != nil {
+		span.SetAttributes(attribute.String("ziti.circuit.id", circuit.Id))
+		if circuit.Terminator != nil {
+			span.SetAttributes(attribute.String("ziti.terminator.id", circuit.Terminator.GetId()))
+		}
+	}
+
+	var circuitErr CircuitError
+	if errors.As(err, &circuitErr) {
+		span.SetAttributes(attribute.String("ziti.circuit.failure_cause", string(circuitErr.Cause())))
+	}
+
+	endSpan(span, err)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -28,6 +28,13 @@ type ForwardingFaultReport struct {
 	UnknownOwner bool
 }
 
+// RouteRepairReport is sent by a router when it has circuits which are forwarded to a link it no longer has
+type RouteRepairReport struct {
+	R          *model.Router
+	LinkId     string
+	CircuitIds []string
+}
+
 func (network *Network) fault(ffr *ForwardingFaultReport) {
 	logrus.Infof("network fault processing for [%d] circuits", len(ffr.CircuitIds))
 	for _, circuitId := range ffr.CircuitIds {
@@ -53,3 +60,37 @@ func (network *Network) fault(ffr *ForwardingFaultReport) {
 		}
 	}
 }
+
+func (network *Network) RepairRoutes(rrr *RouteRepairReport) {
+	go network.repairRoutes(rrr)
+}
+
+func (network *Network) repairRoutes(rrr *RouteRepairReport) {
+	log := pfxlog.Logger().WithField("routerId", rrr.R.Id).WithField("linkId", rrr.LinkId)
+	log.Infof("route repair requested for [%d] circuits", len(rrr.CircuitIds))
+
+	for _, circuitId := range rrr.CircuitIds {
+		log := log.WithField("circuitId", circuitId)
+		circuit, found := network.Circuit.Get(circuitId)
+		if !found {
+			if err := sendUnroute(rrr.R, circuitId, true); err == nil {
+				log.Info("sent unroute for circuit to router in response to route repair request")
+			} else {
+				log.WithError(err).Error("error sending unroute for circuit to router in response to route repair request")
+			}
+			continue
+		}
+
+		// the circuit may already have been rerouted, because of a link fault, with the route not yet at the router
+		if !circuit.Path.UsesLinkId(rrr.LinkId) {
+			log.Debug("circuit no longer uses link, route repair not needed")
+			continue
+		}
+
+		if success := network.rerouteCircuitWithTries(circuit, 2); success {
+			log.Info("rerouted circuit in response to route repair request from router")
+		} else {
+			log.Info("error rerouting circuit in response to route repair request from router, circuit removed")
+		}
+	}
+}
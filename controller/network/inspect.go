@@ -29,6 +29,7 @@ import (
 	"github.com/openziti/ziti/controller/model"
 	"github.com/openziti/ziti/controller/raft"
 	"github.com/openziti/ziti/controller/xt"
+	"go.etcd.io/bbolt"
 	"regexp"
 	"strings"
 	"sync"
@@ -194,6 +195,15 @@ func (ctx *inspectRequestContext) InspectLocal(name string) {
 	} else if lc == inspect.RouterIdentityConnectionStatusesKey {
 		result := ctx.network.env.GetManagers().Identity.GetConnectionTracker().Inspect()
 		ctx.handleLocalJsonResponse(name, result)
+	} else if lc == inspect.BoltStatsKey {
+		result, err := ctx.inspectBoltStats()
+		if err != nil {
+			ctx.appendError(ctx.network.GetAppId(), err.Error())
+			return
+		}
+		ctx.handleLocalJsonResponse(name, result)
+	} else if handled, val, err := inspect.InspectRuntime(name); handled {
+		ctx.handleLocalStringResponse(name, val, err)
 	} else {
 		for _, inspectTarget := range ctx.network.inspectionTargets.Value() {
 			if handled, val, err := inspectTarget(lc); handled {
@@ -203,6 +213,37 @@ func (ctx *inspectRequestContext) InspectLocal(name string) {
 	}
 }
 
+func (ctx *inspectRequestContext) inspectBoltStats() (*inspect.BoltStats, error) {
+	result := &inspect.BoltStats{}
+	err := ctx.network.GetDb().View(func(tx *bbolt.Tx) error {
+		stats := tx.DB().Stats()
+		result.Path = tx.DB().Path()
+		result.SizeBytes = tx.Size()
+		result.FreePages = stats.FreePageN
+		result.PendingPages = stats.PendingPageN
+		result.FreeAllocBytes = stats.FreeAlloc
+		result.FreelistInuseBytes = stats.FreelistInuse
+		result.ReadTxTotal = stats.TxN
+		result.ReadTxOpen = stats.OpenTxN
+		result.Tx = &inspect.BoltTxStats{
+			PageCount:     stats.TxStats.GetPageCount(),
+			PageAlloc:     stats.TxStats.GetPageAlloc(),
+			CursorCount:   stats.TxStats.GetCursorCount(),
+			NodeCount:     stats.TxStats.GetNodeCount(),
+			NodeDeref:     stats.TxStats.GetNodeDeref(),
+			Rebalance:     stats.TxStats.GetRebalance(),
+			RebalanceTime: stats.TxStats.GetRebalanceTime().String(),
+			Split:         stats.TxStats.GetSplit(),
+			Spill:         stats.TxStats.GetSpill(),
+			SpillTime:     stats.TxStats.GetSpillTime().String(),
+			Write:         stats.TxStats.GetWrite(),
+			WriteTime:     stats.TxStats.GetWriteTime().String(),
+		}
+		return nil
+	})
+	return result, err
+}
+
 func (ctx *inspectRequestContext) handleLocalJsonResponse(key string, val interface{}) {
 	js, err := json.Marshal(val)
 	if err != nil {
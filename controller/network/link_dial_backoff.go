@@ -0,0 +1,155 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/controller/model"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	MinLinkDialRetryInterval = 10 * time.Millisecond
+	MaxLinkDialRetryInterval = 24 * time.Hour
+	MinLinkDialBackoffFactor = 1
+	MaxLinkDialBackoffFactor = 100
+	MaxLinkDialJitter        = 1
+)
+
+// UpdateLinkDialBackoff sets the link dial backoff override for the given link group and pushes the full set of
+// overrides to all connected routers. Routers which connect later receive the overrides on connect. Overrides are
+// held in memory and are not shared between controllers. Returns the number of routers the overrides were sent to.
+func (network *Network) UpdateLinkDialBackoff(group string, healthy, unhealthy *ctrl_pb.DialBackoff) (int, error) {
+	if group == "" {
+		return 0, fmt.Errorf("link group must be specified")
+	}
+
+	if healthy == nil && unhealthy == nil {
+		return 0, fmt.Errorf("at least one of healthy or unhealthy link dial backoff must be specified")
+	}
+
+	if err := validateDialBackoff("healthy", healthy); err != nil {
+		return 0, err
+	}
+
+	if err := validateDialBackoff("unhealthy", unhealthy); err != nil {
+		return 0, err
+	}
+
+	network.linkDialBackoff.Set(group, &ctrl_pb.LinkGroupDialBackoff{
+		Group:     group,
+		Healthy:   healthy,
+		Unhealthy: unhealthy,
+	})
+
+	pfxlog.Logger().WithField("group", group).Info("link dial backoff override updated")
+
+	return network.sendLinkDialBackoff(network.AllConnectedRouters()...), nil
+}
+
+// ClearLinkDialBackoff removes the link dial backoff override for the given link group, so routers revert to their
+// locally configured backoff. Returns the number of routers the updated overrides were sent to.
+func (network *Network) ClearLinkDialBackoff(group string) int {
+	network.linkDialBackoff.Remove(group)
+	pfxlog.Logger().WithField("group", group).Info("link dial backoff override cleared")
+	return network.sendLinkDialBackoff(network.AllConnectedRouters()...)
+}
+
+func (network *Network) getLinkDialBackoffSettings() *ctrl_pb.LinkDialBackoffSettings {
+	result := &ctrl_pb.LinkDialBackoffSettings{}
+	for _, v := range network.linkDialBackoff.Items() {
+		result.Groups = append(result.Groups, v)
+	}
+	return result
+}
+
+func (network *Network) sendLinkDialBackoff(routers ...*model.Router) int {
+	settings := network.getLinkDialBackoffSettings()
+	body, err := proto.Marshal(settings)
+	if err != nil {
+		pfxlog.Logger().WithError(err).Error("unable to marshal link dial backoff settings")
+		return 0
+	}
+
+	msg := &ctrl_pb.Settings{
+		Data: map[int32][]byte{
+			int32(ctrl_pb.SettingTypes_LinkDialBackoff): body,
+		},
+	}
+
+	count := 0
+	for _, router := range routers {
+		if router.Control == nil || router.Control.IsClosed() {
+			continue
+		}
+		if err = protobufs.MarshalTyped(msg).WithTimeout(time.Second).Send(router.Control); err != nil {
+			pfxlog.Logger().WithField("routerId", router.Id).WithError(err).Error("unable to send link dial backoff settings")
+		} else {
+			count++
+		}
+	}
+	return count
+}
+
+func validateDialBackoff(name string, backoff *ctrl_pb.DialBackoff) error {
+	if backoff == nil {
+		return nil
+	}
+
+	for field, ms := range map[string]int64{"minRetryInterval": backoff.MinRetryIntervalMs, "maxRetryInterval": backoff.MaxRetryIntervalMs} {
+		if ms == 0 {
+			continue
+		}
+		interval := time.Duration(ms) * time.Millisecond
+		if interval < MinLinkDialRetryInterval || interval > MaxLinkDialRetryInterval {
+			return fmt.Errorf("%s %s of %v must be between %v and %v", name, field, interval, MinLinkDialRetryInterval, MaxLinkDialRetryInterval)
+		}
+	}
+
+	if backoff.MinRetryIntervalMs > 0 && backoff.MaxRetryIntervalMs > 0 && backoff.MinRetryIntervalMs > backoff.MaxRetryIntervalMs {
+		return fmt.Errorf("%s minRetryInterval must not be larger than maxRetryInterval", name)
+	}
+
+	if backoff.RetryBackoffFactor != 0 && (backoff.RetryBackoffFactor < MinLinkDialBackoffFactor || backoff.RetryBackoffFactor > MaxLinkDialBackoffFactor) {
+		return fmt.Errorf("%s retryBackoffFactor of %v must be between %v and %v", name, backoff.RetryBackoffFactor, MinLinkDialBackoffFactor, MaxLinkDialBackoffFactor)
+	}
+
+	if backoff.Jitter < 0 || backoff.Jitter > MaxLinkDialJitter {
+		return fmt.Errorf("%s jitter of %v must be between 0 and %v", name, backoff.Jitter, MaxLinkDialJitter)
+	}
+
+	return nil
+}
+
+// linkDialBackoffPresenceHandler sends link dial backoff overrides to routers as they connect
+type linkDialBackoffPresenceHandler struct {
+	network *Network
+}
+
+func (self *linkDialBackoffPresenceHandler) RouterConnected(r *model.Router) {
+	if !self.network.linkDialBackoff.IsEmpty() {
+		self.network.sendLinkDialBackoff(r)
+	}
+}
+
+func (self *linkDialBackoffPresenceHandler) RouterDisconnected(*model.Router) {
+}
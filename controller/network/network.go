@@ -19,6 +19,7 @@ package network
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -57,9 +58,12 @@ import (
 	"github.com/openziti/ziti/controller/idgen"
 	"github.com/openziti/ziti/controller/model"
 	"github.com/openziti/ziti/controller/xt"
+	cmap "github.com/orcaman/concurrent-map/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/teris-io/shortid"
 	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -115,6 +119,13 @@ type Network struct {
 	Inspections       *InspectionsManager
 	RouterMessaging   *RouterMessaging
 	inspectionTargets concurrenz.CopyOnWriteSlice[InspectTarget]
+	servicePathPins   cmap.ConcurrentMap[string, []string]
+	linkDialBackoff   cmap.ConcurrentMap[string, *ctrl_pb.LinkGroupDialBackoff]
+
+	serviceCircuitBreakers *serviceCircuitBreakers
+	routerScaling          *routerScalingTracker
+	routerUpdates          *routerUpdateManager
+	drainReroutes          *drainRerouteTracker
 }
 
 func NewNetwork(config Config, env model.Env) (*Network, error) {
@@ -153,7 +164,21 @@ func NewNetwork(config Config, env model.Env) (*Network, error) {
 		serviceInvalidTerminatorCounter:           serviceEventMetrics.IntervalCounter("service.dial.terminator.invalid", time.Minute),
 		serviceMisconfiguredTerminatorCounter:     serviceEventMetrics.IntervalCounter("service.dial.terminator.misconfigured", time.Minute),
 
-		config: config,
+		config:          config,
+		servicePathPins: cmap.New[[]string](),
+		linkDialBackoff: cmap.New[*ctrl_pb.LinkGroupDialBackoff](),
+
+		serviceCircuitBreakers: newServiceCircuitBreakers(config.GetOptions()),
+		routerScaling:          newRouterScalingTracker(config.GetOptions(), config.GetEventDispatcher(), config.GetId().Token),
+		drainReroutes:          newDrainRerouteTracker(),
+	}
+
+	if err := network.validateLinkCostConfig(); err != nil {
+		return nil, err
+	}
+
+	if decayConfig := network.options.TerminatorCostDecay; decayConfig.Default != nil || len(decayConfig.ServiceOverrides) > 0 {
+		xt.SetCostDecayProvider(network.getTerminatorCostDecay)
 	}
 
 	env.GetManagers().Command.Decoders.RegisterF(int32(cmd_pb.CommandType_SyncSnapshot), network.decodeSyncSnapshotCommand)
@@ -163,14 +188,17 @@ func NewNetwork(config Config, env model.Env) (*Network, error) {
 		return nil, err
 	}
 	network.Inspections = NewInspectionsManager(network)
+	network.routerUpdates = newRouterUpdateManager(network)
 	network.RouterMessaging = NewRouterMessaging(env, routerCommPool)
 
 	env.GetManagers().Router.Store.AddEntityIdListener(network.HandleRouterDelete, boltz.EntityDeletedAsync)
+	env.GetStores().Service.AddEntityIdListener(network.serviceCircuitBreakers.remove, boltz.EntityDeletedAsync)
 
 	network.AddCapability("ziti.fabric")
 	network.showOptions()
 	network.relayControllerMetrics()
 	network.AddRouterPresenceHandler(network.RouterMessaging)
+	network.AddRouterPresenceHandler(&linkDialBackoffPresenceHandler{network: network})
 	go network.RouterMessaging.run()
 
 	return network, nil
@@ -179,6 +207,7 @@ func NewNetwork(config Config, env model.Env) (*Network, error) {
 func (self *Network) HandleRouterDelete(id string) {
 	self.routerDeleted(id)
 	self.RouterMessaging.RouterDeleted(id)
+	self.routerScaling.remove(id)
 }
 
 func (self *Network) decodeSyncSnapshotCommand(_ int32, data []byte) (command.Command, error) {
@@ -577,6 +606,13 @@ func (network *Network) RerouteLink(l *model.Link) {
 }
 
 func (network *Network) CreateCircuit(params model.CreateCircuitParams) (*model.Circuit, error) {
+	ctx, span := startCircuitCreateSpan(params)
+	circuit, err := network.createCircuit(ctx, params)
+	endCircuitCreateSpan(span, circuit, err)
+	return circuit, err
+}
+
+func (network *Network) createCircuit(spanCtx context.Context, params model.CreateCircuitParams) (*model.Circuit, error) {
 	clientId := params.GetClientId()
 	service := params.GetServiceId()
 	ctx := params.GetLogContext()
@@ -610,18 +646,42 @@ func (network *Network) CreateCircuit(params model.CreateCircuitParams) (*model.
 	rs := network.newRouteSender(circuitId)
 	defer func() { network.removeRouteSender(rs) }()
 	for {
+		attemptCtx, attemptSpan := circuitTracer.Start(spanCtx, "circuit.create.attempt",
+			oteltrace.WithAttributes(attribute.Int64("ziti.circuit.attempt", int64(attempt))))
+
 		// 2: Find Service
+		_, stepSpan := circuitTracer.Start(attemptCtx, "service.read")
 		svc, err := network.Service.Read(serviceId)
+		endSpan(stepSpan, err)
 		if err != nil {
+			endSpan(attemptSpan, err)
 			network.CircuitFailedEvent(circuitId, params, startTime, nil, nil, CircuitFailureInvalidService)
 			network.ServiceDialOtherError(serviceId)
 			return circuit, err
 		}
 		logger = logger.WithField("serviceName", svc.Name)
 
+		// 2a: check service circuit breaker. Retries within the circuit creation are always allowed, so a trial dial
+		// gets the same retries as any other dial
+		if attempt == 0 {
+			if breakerErr := network.serviceCircuitBreakers.allow(svc.Id); breakerErr != nil {
+				endSpan(attemptSpan, breakerErr)
+				network.CircuitFailedEvent(circuitId, params, startTime, nil, nil, breakerErr.Cause())
+				network.ServiceDialOtherError(serviceId)
+				return circuit, breakerErr
+			}
+		}
+
 		// 3: select terminator
+		_, stepSpan = circuitTracer.Start(attemptCtx, "terminator.select",
+			oteltrace.WithAttributes(attribute.String("ziti.service.terminator_strategy", svc.TerminatorStrategy)))
 		strategy, terminator, pathNodes, strategyData, circuitErr := network.selectPath(params, svc, instanceId, ctx)
+		if terminator != nil {
+			stepSpan.SetAttributes(attribute.String("ziti.terminator.id", terminator.GetId()))
+		}
+		endSpan(stepSpan, circuitErr)
 		if circuitErr != nil {
+			endSpan(attemptSpan, circuitErr)
 			network.CircuitFailedEvent(circuitId, params, startTime, nil, nil, circuitErr.Cause())
 			network.ServiceDialOtherError(serviceId)
 			return circuit, circuitErr
@@ -629,9 +689,25 @@ func (network *Network) CreateCircuit(params model.CreateCircuitParams) (*model.
 
 		circuit.Terminator = terminator
 
+		_, stepSpan = circuitTracer.Start(attemptCtx, "path.create")
+		if routerIds, pinned := network.servicePathPins.Get(svc.Id); pinned {
+			if pathNodes, err = network.pinnedPathNodes(pathNodes[0], pathNodes[len(pathNodes)-1], routerIds); err != nil {
+				endSpan(stepSpan, err)
+				endSpan(attemptSpan, err)
+				network.CircuitFailedEvent(circuitId, params, startTime, nil, terminator, CircuitFailureNoPath)
+				network.ServiceDialOtherError(serviceId)
+				return circuit, newCircuitErrWrap(CircuitFailureNoPath, err)
+			}
+		}
+
 		// 4: Create Path
-		path, pathErr := network.CreatePathWithNodes(pathNodes)
+		path, pathErr := network.createPathWithNodes(pathNodes, network.getLinkCostFunction(svc.Id))
+		if path != nil {
+			stepSpan.SetAttributes(attribute.String("ziti.circuit.path", path.String()))
+		}
+		endSpan(stepSpan, pathErr)
 		if pathErr != nil {
+			endSpan(attemptSpan, pathErr)
 			network.CircuitFailedEvent(circuitId, params, startTime, nil, terminator, pathErr.Cause())
 			network.ServiceDialOtherError(serviceId)
 			return circuit, pathErr
@@ -647,6 +723,7 @@ func (network *Network) CreateCircuit(params model.CreateCircuitParams) (*model.
 		// 4a: Create Route Messages
 		rms := network.CreateRouteMessages(path, attempt, circuitId, terminator, deadline)
 		rms[len(rms)-1].Egress.PeerData = clientId.Data
+		network.setCircuitLimits(rms, svc, time.Now())
 		for _, msg := range rms {
 			msg.Context = &ctrl_pb.Context{
 				Fields:      ctx.GetStringFields(),
@@ -657,13 +734,20 @@ func (network *Network) CreateCircuit(params model.CreateCircuitParams) (*model.
 
 		// 5: Routing
 		logger.Debug("route attempt for circuit")
+		_, stepSpan = circuitTracer.Start(attemptCtx, "route.dispatch",
+			oteltrace.WithAttributes(attribute.Int("ziti.circuit.route_count", len(rms))))
 		peerData, cleanups, circuitErr := rs.route(attempt, path, rms, strategy, terminator, ctx.Clone())
+		endSpan(stepSpan, circuitErr)
+		endSpan(attemptSpan, circuitErr)
 		for k, v := range cleanups {
 			allCleanups[k] = v
 		}
 		if circuitErr != nil {
 			logger.WithError(circuitErr).Warn("route attempt for circuit failed")
 			network.CircuitFailedEvent(circuitId, params, startTime, path, terminator, circuitErr.Cause())
+			if circuitErr.Cause().isTerminatorFailure() {
+				network.serviceCircuitBreakers.recordFailure(svc.Id)
+			}
 			attempt++
 			ctx.WithField("attemptNumber", attempt+1)
 			logger = logger.WithField("attemptNumber", attempt+1)
@@ -731,6 +815,7 @@ func (network *Network) CreateCircuit(params model.CreateCircuitParams) (*model.
 		circuit.UpdatedAt = now
 
 		network.Circuit.Add(circuit)
+		network.serviceCircuitBreakers.recordSuccess(svc.Id)
 		creationTimespan := time.Since(startTime)
 		network.CircuitEvent(event.CircuitCreated, circuit, &creationTimespan)
 
@@ -770,6 +855,7 @@ func (network *Network) selectPath(params model.CreateCircuitParams, svc *model.
 
 	hasOfflineRouters := false
 	pathError := false
+	costF := network.getLinkCostFunction(svc.Id)
 
 	for _, terminator := range svc.Terminators {
 		if terminator.InstanceId != instanceId {
@@ -789,7 +875,7 @@ func (network *Network) selectPath(params model.CreateCircuitParams, svc *model.
 				continue
 			}
 
-			path, cost, err := network.shortestPath(params.GetSourceRouter(), dstR)
+			path, cost, err := network.shortestPathWithCostF(params.GetSourceRouter(), dstR, costF)
 			if err != nil {
 				log.Debugf("error while calculating path for service %v: %v", svc.Id, err)
 				errList = append(errList, err)
@@ -807,6 +893,7 @@ func (network *Network) selectPath(params model.CreateCircuitParams, svc *model.
 		costedTerminator := &model.RoutingTerminator{
 			Terminator: terminator,
 			RouteCost:  biasedCost,
+			PathCost:   pathAndCost.cost,
 		}
 		weightedTerminators = append(weightedTerminators, costedTerminator)
 	}
@@ -827,6 +914,8 @@ func (network *Network) selectPath(params model.CreateCircuitParams, svc *model.
 		return nil, nil, nil, nil, newCircuitErrorf(CircuitFailureNoTerminators, "service %v has no terminators for instanceId %v", svc.Id, instanceId)
 	}
 
+	weightedTerminators = network.filterDrainingTerminators(weightedTerminators)
+
 	strategy, err := network.strategyRegistry.GetStrategy(svc.TerminatorStrategy)
 	if err != nil {
 		return nil, nil, nil, nil, newCircuitErrWrap(CircuitFailureInvalidStrategy, err)
@@ -871,6 +960,11 @@ func (network *Network) selectPath(params model.CreateCircuitParams, svc *model.
 }
 
 func (network *Network) RemoveCircuit(circuitId string, now bool) error {
+	return network.RemoveCircuitWithReason(circuitId, now, "")
+}
+
+// RemoveCircuitWithReason removes the circuit, recording the given termination reason in the circuit deleted event
+func (network *Network) RemoveCircuitWithReason(circuitId string, now bool, reason CircuitTerminationReason) error {
 	log := pfxlog.Logger().WithField("circuitId", circuitId)
 
 	if circuit, found := network.Circuit.Get(circuitId); found {
@@ -882,7 +976,7 @@ func (network *Network) RemoveCircuit(circuitId string, now bool) error {
 		}
 
 		network.Circuit.Remove(circuit)
-		network.CircuitEvent(event.CircuitDeleted, circuit, nil)
+		network.circuitEvent(event.CircuitDeleted, circuit, nil, reason)
 
 		if svc, err := network.Service.Read(circuit.ServiceId); err == nil {
 			if strategy, err := network.strategyRegistry.GetStrategy(svc.TerminatorStrategy); strategy != nil {
@@ -924,10 +1018,10 @@ func (network *Network) CreatePath(srcR, dstR *model.Router) (*model.Path, error
 	return network.UpdatePath(path)
 }
 
-func (network *Network) setLinks(path *model.Path) error {
+func (network *Network) setLinks(path *model.Path, costF model.LinkCostFunction) error {
 	if len(path.Nodes) > 1 {
 		for i := 0; i < len(path.Nodes)-1; i++ {
-			if link, found := network.Link.LeastExpensiveLink(path.Nodes[i], path.Nodes[i+1]); found {
+			if link, found := network.Link.LeastExpensiveLink(path.Nodes[i], path.Nodes[i+1], costF); found {
 				path.Links = append(path.Links, link)
 			} else {
 				return fmt.Errorf("no link from r/%v to r/%v", path.Nodes[i].Id, path.Nodes[i+1].Id)
@@ -950,6 +1044,14 @@ func (network *Network) Run() {
 	ticker := time.NewTicker(time.Duration(network.options.CycleSeconds) * time.Second)
 	defer ticker.Stop()
 
+	// if smart rerouting has its own interval, it runs on its own schedule instead of on every cycle
+	var smartC <-chan time.Time
+	if network.options.Smart.Interval > 0 {
+		smartTicker := time.NewTicker(network.options.Smart.Interval)
+		defer smartTicker.Stop()
+		smartC = smartTicker.C
+	}
+
 	for {
 		select {
 		case <-network.assembleAndCleanC:
@@ -962,9 +1064,15 @@ func (network *Network) Run() {
 		case <-ticker.C:
 			network.assemble()
 			network.clean()
-			network.smart()
+			if smartC == nil {
+				network.smart()
+			}
+			network.enforceDrainDeadlines()
 			network.Link.ScanForDeadLinks()
 
+		case <-smartC:
+			network.smart()
+
 		case <-network.closeNotify:
 			network.eventDispatcher.RemoveMetricsMessageHandler(network)
 			network.metricsRegistry.DisposeAll()
@@ -1130,11 +1238,14 @@ func (network *Network) rerouteCircuit(circuit *model.Circuit, deadline time.Tim
 
 		log.Warn("rerouting circuit")
 
-		if cq, err := network.UpdatePath(circuit.Path); err == nil {
+		if cq, err := network.UpdateCircuitPath(circuit); err == nil {
 			circuit.Path = cq
 			circuit.UpdatedAt = time.Now()
 
 			rms := network.CreateRouteMessages(cq, SmartRerouteAttempt, circuit.Id, circuit.Terminator, deadline)
+			if svc, _ := network.Service.Read(circuit.ServiceId); svc != nil {
+				network.setCircuitLimits(rms, svc, circuit.CreatedAt)
+			}
 
 			for i := 0; i < len(cq.Nodes); i++ {
 				if _, err := sendRoute(cq.Nodes[i], rms[i], network.options.RouteTimeout); err != nil {
@@ -1165,6 +1276,9 @@ func (network *Network) smartReroute(circuit *model.Circuit, cq *model.Path, dea
 		circuit.UpdatedAt = time.Now()
 
 		rms := network.CreateRouteMessages(cq, SmartRerouteAttempt, circuit.Id, circuit.Terminator, deadline)
+		if svc, _ := network.Service.Read(circuit.ServiceId); svc != nil {
+			network.setCircuitLimits(rms, svc, circuit.CreatedAt)
+		}
 
 		for i := 0; i < len(cq.Nodes); i++ {
 			if _, err := sendRoute(cq.Nodes[i], rms[i], network.options.RouteTimeout); err != nil {
@@ -1195,6 +1309,8 @@ func (network *Network) AcceptMetricsMsg(metrics *metrics_pb.MetricsMessage) {
 		return
 	}
 
+	network.routerScaling.acceptMetrics(router.Id, metrics)
+
 	for _, link := range network.GetAllLinksForRouter(router.Id) {
 		metricId := "link." + link.Id + ".latency"
 		var latencyCost int64
@@ -1218,6 +1334,14 @@ func (network *Network) AcceptMetricsMsg(metrics *metrics_pb.MetricsMessage) {
 				log.Warnf("link not for router")
 			}
 		}
+
+		if pathMtu, ok := metrics.IntValues["link."+link.Id+".path_mtu"]; ok && pathMtu >= 0 && pathMtu <= math.MaxUint32 {
+			if link.Src.Id == router.Id {
+				link.SetSrcPathMtu(uint32(pathMtu))
+			} else if link.DstId == router.Id {
+				link.SetDstPathMtu(uint32(pathMtu))
+			}
+		}
 	}
 }
 
@@ -14,6 +14,7 @@ import (
 func (network *Network) CreateRouteMessages(path *model.Path, attempt uint32, circuitId string, terminator xt.Terminator, deadline time.Time) []*ctrl_pb.Route {
 	var routeMessages []*ctrl_pb.Route
 	remainingTime := time.Until(deadline)
+	pathMtu := path.GetPathMtu()
 	if len(path.Links) == 0 {
 		// single router path
 		routeMessage := &ctrl_pb.Route{CircuitId: circuitId, Attempt: attempt, Timeout: uint64(remainingTime)}
@@ -90,10 +91,17 @@ func (network *Network) CreateRouteMessages(path *model.Path, attempt uint32, ci
 			routeMessages = append(routeMessages, routeMessage)
 		}
 	}
+	for _, routeMessage := range routeMessages {
+		routeMessage.PathMtu = pathMtu
+	}
 	return routeMessages
 }
 
 func (network *Network) CreatePathWithNodes(nodes []*model.Router) (*model.Path, CircuitError) {
+	return network.createPathWithNodes(nodes, network.getLinkCostFunction(""))
+}
+
+func (network *Network) createPathWithNodes(nodes []*model.Router, costF model.LinkCostFunction) (*model.Path, CircuitError) {
 	ingressId, err := idgen.NewUUIDString()
 	if err != nil {
 		return nil, newCircuitErrWrap(CircuitFailureIdGenerationError, err)
@@ -109,16 +117,31 @@ func (network *Network) CreatePathWithNodes(nodes []*model.Router) (*model.Path,
 		IngressId: ingressId,
 		EgressId:  egressId,
 	}
-	if err := network.setLinks(path); err != nil {
+	if err := network.setLinks(path, costF); err != nil {
 		return nil, newCircuitErrWrap(CircuitFailurePathMissingLink, err)
 	}
 	return path, nil
 }
 
 func (network *Network) UpdatePath(path *model.Path) (*model.Path, error) {
+	return network.updatePath(path, network.getLinkCostFunction(""))
+}
+
+// UpdateCircuitPath calculates the current best path for the given circuit, using the link cost function
+// configured for the circuit's service. If the circuit or its service has a path pin, the pinned path is
+// returned instead.
+func (network *Network) UpdateCircuitPath(circuit *model.Circuit) (*model.Path, error) {
+	costF := network.getLinkCostFunction(circuit.ServiceId)
+	if routerIds, pinned := network.getPathPin(circuit); pinned {
+		return network.pinnedPath(circuit.Path, routerIds, costF)
+	}
+	return network.updatePath(circuit.Path, costF)
+}
+
+func (network *Network) updatePath(path *model.Path, costF model.LinkCostFunction) (*model.Path, error) {
 	srcR := path.Nodes[0]
 	dstR := path.Nodes[len(path.Nodes)-1]
-	nodes, _, err := network.shortestPath(srcR, dstR)
+	nodes, _, err := network.shortestPathWithCostF(srcR, dstR, costF)
 	if err != nil {
 		return nil, err
 	}
@@ -132,13 +155,17 @@ func (network *Network) UpdatePath(path *model.Path) (*model.Path, error) {
 		TerminatorLocalAddr:  path.TerminatorLocalAddr,
 		TerminatorRemoteAddr: path.TerminatorRemoteAddr,
 	}
-	if err := network.setLinks(path2); err != nil {
+	if err := network.setLinks(path2, costF); err != nil {
 		return nil, err
 	}
 	return path2, nil
 }
 
 func (network *Network) shortestPath(srcR *model.Router, dstR *model.Router) ([]*model.Router, int64, error) {
+	return network.shortestPathWithCostF(srcR, dstR, network.getLinkCostFunction(""))
+}
+
+func (network *Network) shortestPathWithCostF(srcR *model.Router, dstR *model.Router, costF model.LinkCostFunction) ([]*model.Router, int64, error) {
 	if srcR == nil || dstR == nil {
 		return nil, 0, errors.New("not routable (!srcR||!dstR)")
 	}
@@ -170,9 +197,9 @@ func (network *Network) shortestPath(srcR *model.Router, dstR *model.Router) ([]
 		for _, r := range neighbors {
 			if _, found := unvisited[r]; found {
 				var cost int64 = math.MaxInt32 + 1
-				if l, found := network.Link.LeastExpensiveLink(r, u); found {
-					if !r.NoTraversal || r == srcR || r == dstR {
-						cost = l.GetCost() + int64(max(r.Cost, minRouterCost))
+				if l, found := network.Link.LeastExpensiveLink(r, u, costF); found {
+					if (!r.NoTraversal && !r.Draining) || r == srcR || r == dstR {
+						cost = costF.GetLinkCost(l) + int64(max(r.Cost, minRouterCost))
 					}
 				}
 
@@ -209,3 +236,42 @@ func (network *Network) shortestPath(srcR *model.Router, dstR *model.Router) ([]
 
 	return routerPath, dist[dstR], nil
 }
+
+// getLinkCostFunction returns the link cost function to use when computing paths for the given service. Service
+// overrides may be keyed by either service id or service name. Configured function names are validated when the
+// network is created, so the fallback to the default function should only happen if the configuration is invalid.
+func (network *Network) getLinkCostFunction(serviceId string) model.LinkCostFunction {
+	linkCostConfig := &network.options.LinkCost
+	name := linkCostConfig.Function
+
+	if serviceId != "" && len(linkCostConfig.ServiceOverrides) > 0 {
+		if override, found := linkCostConfig.ServiceOverrides[serviceId]; found {
+			name = override
+		} else if svc, _ := network.Service.Read(serviceId); svc != nil {
+			if override, found := linkCostConfig.ServiceOverrides[svc.Name]; found {
+				name = override
+			}
+		}
+	}
+
+	if costF, found := model.GetLinkCostFunction(name); found {
+		return costF
+	}
+	return model.DefaultLinkCostFunction()
+}
+
+func (network *Network) validateLinkCostConfig() error {
+	linkCostConfig := &network.options.LinkCost
+	if _, found := model.GetLinkCostFunction(linkCostConfig.Function); !found {
+		return fmt.Errorf("invalid network.linkCost.function '%s', valid values: %v",
+			linkCostConfig.Function, model.GetLinkCostFunctionNames())
+	}
+
+	for service, name := range linkCostConfig.ServiceOverrides {
+		if _, found := model.GetLinkCostFunction(name); !found {
+			return fmt.Errorf("invalid link cost function '%s' for service '%s' in network.linkCost.serviceOverrides, valid values: %v",
+				name, service, model.GetLinkCostFunctionNames())
+		}
+	}
+	return nil
+}
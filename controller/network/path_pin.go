@@ -0,0 +1,210 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/model"
+)
+
+// PathPin describes a path pin on a circuit or service. A pin is an ordered list of routers which paths must
+// traverse. The initiating and terminating routers are always at the ends of the path and don't need to be included.
+type PathPin struct {
+	CircuitId string   `json:"circuitId,omitempty"`
+	ServiceId string   `json:"serviceId,omitempty"`
+	RouterIds []string `json:"routerIds"`
+}
+
+// PinCircuitPath pins the given circuit to a path through the given routers and moves the circuit onto that path.
+// While pinned, smart routing won't move the circuit, and if the path fails the circuit can only be rerouted onto
+// the pinned path.
+func (network *Network) PinCircuitPath(circuitId string, routerIds []string) error {
+	circuit, found := network.GetCircuit(circuitId)
+	if !found {
+		return InvalidCircuitError{circuitId: circuitId}
+	}
+
+	if _, err := network.pinnedPath(circuit.Path, routerIds, network.getLinkCostFunction(circuit.ServiceId)); err != nil {
+		return err
+	}
+
+	routerIds = slices.Clone(routerIds)
+	circuit.PathPin.Store(&routerIds)
+
+	pfxlog.Logger().WithField("circuitId", circuitId).WithField("routerIds", routerIds).Info("circuit path pinned")
+
+	return network.rerouteCircuit(circuit, time.Now().Add(network.options.RouteTimeout))
+}
+
+// UnpinCircuitPath removes the path pin from the given circuit. The circuit stays on its current path until smart
+// routing or a reroute moves it.
+func (network *Network) UnpinCircuitPath(circuitId string) error {
+	circuit, found := network.GetCircuit(circuitId)
+	if !found {
+		return InvalidCircuitError{circuitId: circuitId}
+	}
+	circuit.PathPin.Store(nil)
+	pfxlog.Logger().WithField("circuitId", circuitId).Info("circuit path unpinned")
+	return nil
+}
+
+// PinServicePath pins new and existing circuits for the given service to a path through the given routers. Circuit
+// level pins take precedence over service pins. Service pins are held in memory and are not shared between
+// controllers, since circuits are owned by the controller which created them.
+func (network *Network) PinServicePath(serviceId string, routerIds []string) error {
+	if _, err := network.Service.Read(serviceId); err != nil {
+		return err
+	}
+
+	if len(routerIds) == 0 {
+		return fmt.Errorf("path pin for service %v must contain at least one router", serviceId)
+	}
+
+	for _, routerId := range routerIds {
+		if _, err := network.Router.Read(routerId); err != nil {
+			return err
+		}
+	}
+
+	network.servicePathPins.Set(serviceId, slices.Clone(routerIds))
+	pfxlog.Logger().WithField("serviceId", serviceId).WithField("routerIds", routerIds).Info("service path pinned")
+
+	var errList []error
+	for _, circuit := range network.GetAllCircuits() {
+		if circuit.ServiceId == serviceId && circuit.PathPin.Load() == nil {
+			if err := network.rerouteCircuit(circuit, time.Now().Add(network.options.RouteTimeout)); err != nil {
+				errList = append(errList, fmt.Errorf("unable to move circuit %v to pinned path: %w", circuit.Id, err))
+			}
+		}
+	}
+
+	if len(errList) > 0 {
+		return fmt.Errorf("service path pinned, but some circuits could not be moved: %v", errList)
+	}
+	return nil
+}
+
+// UnpinServicePath removes the path pin for the given service
+func (network *Network) UnpinServicePath(serviceId string) bool {
+	_, found := network.servicePathPins.Pop(serviceId)
+	if found {
+		pfxlog.Logger().WithField("serviceId", serviceId).Info("service path unpinned")
+	}
+	return found
+}
+
+// GetPathPins returns all current circuit and service path pins
+func (network *Network) GetPathPins() []*PathPin {
+	var result []*PathPin
+
+	for serviceId, routerIds := range network.servicePathPins.Items() {
+		result = append(result, &PathPin{
+			ServiceId: serviceId,
+			RouterIds: routerIds,
+		})
+	}
+
+	for _, circuit := range network.GetAllCircuits() {
+		if routerIds := circuit.PathPin.Load(); routerIds != nil {
+			result = append(result, &PathPin{
+				CircuitId: circuit.Id,
+				ServiceId: circuit.ServiceId,
+				RouterIds: *routerIds,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ServiceId != result[j].ServiceId {
+			return result[i].ServiceId < result[j].ServiceId
+		}
+		return result[i].CircuitId < result[j].CircuitId
+	})
+
+	return result
+}
+
+func (network *Network) getPathPin(circuit *model.Circuit) ([]string, bool) {
+	if routerIds := circuit.PathPin.Load(); routerIds != nil {
+		return *routerIds, true
+	}
+	return network.servicePathPins.Get(circuit.ServiceId)
+}
+
+func (network *Network) pinnedPath(path *model.Path, routerIds []string, costF model.LinkCostFunction) (*model.Path, error) {
+	nodes, err := network.pinnedPathNodes(path.Nodes[0], path.Nodes[len(path.Nodes)-1], routerIds)
+	if err != nil {
+		return nil, err
+	}
+
+	path2 := &model.Path{
+		Nodes:                nodes,
+		IngressId:            path.IngressId,
+		EgressId:             path.EgressId,
+		InitiatorLocalAddr:   path.InitiatorLocalAddr,
+		InitiatorRemoteAddr:  path.InitiatorRemoteAddr,
+		TerminatorLocalAddr:  path.TerminatorLocalAddr,
+		TerminatorRemoteAddr: path.TerminatorRemoteAddr,
+	}
+	if err = network.setLinks(path2, costF); err != nil {
+		return nil, err
+	}
+	return path2, nil
+}
+
+// pinnedPathNodes builds the list of routers for a path from srcR to dstR which goes through the given routers,
+// in order. The pinned routers may include srcR and dstR, but no router may be visited twice.
+func (network *Network) pinnedPathNodes(srcR, dstR *model.Router, routerIds []string) ([]*model.Router, error) {
+	if len(routerIds) == 0 {
+		return nil, fmt.Errorf("path pin must contain at least one router")
+	}
+
+	nodes := []*model.Router{srcR}
+	appendNode := func(r *model.Router) error {
+		if nodes[len(nodes)-1].Id == r.Id {
+			return nil
+		}
+		for _, node := range nodes {
+			if node.Id == r.Id {
+				return fmt.Errorf("path pin would visit router %v more than once", r.Id)
+			}
+		}
+		nodes = append(nodes, r)
+		return nil
+	}
+
+	for _, routerId := range routerIds {
+		r := network.GetConnectedRouter(routerId)
+		if r == nil {
+			return nil, fmt.Errorf("pinned router %v is not connected", routerId)
+		}
+		if err := appendNode(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := appendNode(dstR); err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,104 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"testing"
+
+	"github.com/openziti/transport/v2/tcp"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinnedPath(t *testing.T) {
+	ctx := model.NewTestContext(t)
+	defer ctx.Cleanup()
+
+	req := require.New(t)
+
+	config := newTestConfig(ctx)
+	defer close(config.closeNotify)
+
+	network, err := NewNetwork(config, ctx)
+	req.NoError(err)
+
+	transportAddr, err := tcp.AddressParser{}.Parse("tcp:0.0.0.0:0")
+	req.NoError(err)
+
+	var routers []*model.Router
+	for _, id := range []string{"r0", "r1", "r2", "r3", "r4"} {
+		r := model.NewRouterForTest(id, "", transportAddr, nil, 0, false)
+		network.Router.MarkConnected(r)
+		routers = append(routers, r)
+	}
+	r0, r1, r3 := routers[0], routers[1], routers[3]
+
+	// the path through r2 is cheapest, r4 isn't linked to anything
+	newCostedPathTestLink(network, "l0", r0, r1, 10, 1)
+	newCostedPathTestLink(network, "l1", r0, routers[2], 1, 1)
+	newCostedPathTestLink(network, "l2", r1, r3, 10, 1)
+	newCostedPathTestLink(network, "l3", routers[2], r3, 1, 1)
+
+	path, err := network.CreatePath(r0, r3)
+	req.NoError(err)
+	req.Equal("r2", path.Nodes[1].Id)
+
+	circuit := &model.Circuit{
+		Id:        "c0",
+		ServiceId: "svc1",
+		Path:      path,
+	}
+
+	updatedPath, err := network.UpdateCircuitPath(circuit)
+	req.NoError(err)
+	req.True(updatedPath.EqualPath(path))
+
+	network.servicePathPins.Set("svc1", []string{"r1"})
+	updatedPath, err = network.UpdateCircuitPath(circuit)
+	req.NoError(err)
+	req.Len(updatedPath.Nodes, 3)
+	req.Equal("r1", updatedPath.Nodes[1].Id)
+	req.Equal(path.IngressId, updatedPath.IngressId)
+	req.Equal(path.EgressId, updatedPath.EgressId)
+
+	// circuit pins take precedence over service pins, and may include the initiating and terminating routers
+	routerIds := []string{"r0", "r2", "r3"}
+	circuit.PathPin.Store(&routerIds)
+	updatedPath, err = network.UpdateCircuitPath(circuit)
+	req.NoError(err)
+	req.True(updatedPath.EqualPath(path))
+
+	routerIds = []string{"r4"}
+	_, err = network.UpdateCircuitPath(circuit)
+	req.ErrorContains(err, "no link")
+
+	routerIds = []string{"r1", "r0"}
+	_, err = network.UpdateCircuitPath(circuit)
+	req.ErrorContains(err, "more than once")
+
+	routerIds = []string{"r5"}
+	_, err = network.UpdateCircuitPath(circuit)
+	req.ErrorContains(err, "not connected")
+
+	circuit.PathPin.Store(nil)
+	req.True(network.UnpinServicePath("svc1"))
+	req.False(network.UnpinServicePath("svc1"))
+
+	updatedPath, err = network.UpdateCircuitPath(circuit)
+	req.NoError(err)
+	req.True(updatedPath.EqualPath(path))
+}
@@ -18,6 +18,7 @@ package network
 
 import (
 	config2 "github.com/openziti/ziti/controller/config"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
 	"github.com/openziti/ziti/controller/model"
 	"testing"
 	"time"
@@ -597,6 +598,78 @@ func TestMinRouterCost(t *testing.T) {
 	req.Equal(int64(222), cost)
 }
 
+func TestShortestPathWithLinkCostFunction(t *testing.T) {
+	ctx := model.NewTestContext(t)
+	defer ctx.Cleanup()
+
+	req := require.New(t)
+
+	config := newTestConfig(ctx)
+	defer close(config.closeNotify)
+
+	network, err := NewNetwork(config, ctx)
+	req.NoError(err)
+
+	addr := "tcp:0.0.0.0:0"
+	transportAddr, err := tcp.AddressParser{}.Parse(addr)
+	req.NoError(err)
+
+	r0 := model.NewRouterForTest("r0", "", transportAddr, nil, 0, false)
+	network.Router.MarkConnected(r0)
+
+	r1 := model.NewRouterForTest("r1", "", transportAddr, nil, 0, false)
+	network.Router.MarkConnected(r1)
+
+	r2 := model.NewRouterForTest("r2", "", transportAddr, nil, 0, false)
+	network.Router.MarkConnected(r2)
+
+	r3 := model.NewRouterForTest("r3", "", transportAddr, nil, 0, false)
+	network.Router.MarkConnected(r3)
+
+	// the path through r1 has the lowest static cost, the path through r2 has the lowest latency
+	newCostedPathTestLink(network, "l0", r0, r1, 1, 50)
+	newCostedPathTestLink(network, "l1", r0, r2, 10, 1)
+	newCostedPathTestLink(network, "l2", r1, r3, 1, 50)
+	newCostedPathTestLink(network, "l3", r2, r3, 10, 1)
+
+	path, _, err := network.shortestPath(r0, r3)
+	req.NoError(err)
+	req.Len(path, 3)
+	req.Equal("r2", path[1].Id)
+
+	network.options.LinkCost.ServiceOverrides = map[string]string{"svc1": model.LinkCostFunctionStatic}
+	req.NoError(network.validateLinkCostConfig())
+
+	path, _, err = network.shortestPathWithCostF(r0, r3, network.getLinkCostFunction("svc1"))
+	req.NoError(err)
+	req.Len(path, 3)
+	req.Equal("r1", path[1].Id)
+
+	path, _, err = network.shortestPathWithCostF(r0, r3, network.getLinkCostFunction("svc2"))
+	req.NoError(err)
+	req.Len(path, 3)
+	req.Equal("r2", path[1].Id)
+
+	network.options.LinkCost.Function = model.LinkCostFunctionStatic
+	path, _, err = network.shortestPath(r0, r3)
+	req.NoError(err)
+	req.Len(path, 3)
+	req.Equal("r1", path[1].Id)
+
+	network.options.LinkCost.Function = "invalid"
+	req.Error(network.validateLinkCostConfig())
+}
+
+func newCostedPathTestLink(network *Network, id string, srcR, destR *model.Router, staticCost int32, latencyMillis int64) *model.Link {
+	l := model.NewTestLink(id, srcR, destR)
+	l.SrcLatency = latencyMillis * 1_000_000
+	l.DstLatency = latencyMillis * 1_000_000
+	l.SetStaticCost(staticCost)
+	l.SetState(model.Connected)
+	network.Link.Add(l)
+	return l
+}
+
 func newPathTestLink(network *Network, id string, srcR, destR *model.Router) *model.Link {
 	l := model.NewTestLink(id, srcR, destR)
 	l.SrcLatency = 0
@@ -606,3 +679,33 @@ func newPathTestLink(network *Network, id string, srcR, destR *model.Router) *mo
 	network.Link.Add(l)
 	return l
 }
+
+func TestSetCircuitLimits(t *testing.T) {
+	req := require.New(t)
+	network := &Network{}
+
+	svc := &model.Service{
+		MaxIdleTime:        time.Minute,
+		MaxCircuitLifetime: time.Hour,
+	}
+
+	rms := []*ctrl_pb.Route{{}, {}, {}}
+	network.setCircuitLimits(rms, svc, time.Now().Add(-10*time.Minute))
+
+	// only the initiating and terminating routers enforce limits
+	for _, rm := range []*ctrl_pb.Route{rms[0], rms[2]} {
+		req.Equal(uint64(time.Minute), rm.MaxIdleTime)
+		req.LessOrEqual(rm.MaxLifetime, uint64(50*time.Minute))
+		req.Greater(rm.MaxLifetime, uint64(49*time.Minute))
+	}
+	req.Zero(rms[1].MaxIdleTime)
+	req.Zero(rms[1].MaxLifetime)
+
+	// circuits past their lifetime on reroute are still sent a lifetime, so they're ended right away
+	network.setCircuitLimits(rms, svc, time.Now().Add(-2*time.Hour))
+	req.Equal(uint64(time.Millisecond), rms[0].MaxLifetime)
+
+	svc.MaxCircuitLifetime = 0
+	network.setCircuitLimits(rms, svc, time.Now())
+	req.Zero(rms[0].MaxLifetime)
+}
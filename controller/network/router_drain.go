@@ -0,0 +1,155 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/xt"
+)
+
+// filterDrainingTerminators removes terminators on draining routers, as long as there are terminators on routers
+// which aren't draining. If every terminator is on a draining router, they're all returned, so the service stays
+// reachable until the drain completes.
+func (network *Network) filterDrainingTerminators(terminators []xt.CostedTerminator) []xt.CostedTerminator {
+	var result []xt.CostedTerminator
+	for _, terminator := range terminators {
+		if r := network.Router.GetConnected(terminator.GetRouterId()); r == nil || !r.Draining {
+			result = append(result, terminator)
+		}
+	}
+
+	if len(result) == 0 {
+		return terminators
+	}
+	return result
+}
+
+const (
+	// drainRerouteMaxAttempts is how many times a circuit on a drained router is rerouted before it's closed instead
+	drainRerouteMaxAttempts = 5
+
+	// drainRerouteInitialBackoff is the wait after the first failed reroute. It doubles after each failure.
+	drainRerouteInitialBackoff = 5 * time.Second
+)
+
+// drainRerouteTracker tracks failed reroutes of circuits off drained routers, so they're retried with backoff and
+// only a bounded number of times. It's only used from the network run loop, so it isn't synchronized.
+type drainRerouteTracker struct {
+	circuits map[string]*drainRerouteState
+}
+
+type drainRerouteState struct {
+	attempts    int
+	nextAttempt time.Time
+}
+
+func newDrainRerouteTracker() *drainRerouteTracker {
+	return &drainRerouteTracker{
+		circuits: map[string]*drainRerouteState{},
+	}
+}
+
+// shouldAttempt returns false if the circuit's last reroute failed and it's still backing off
+func (self *drainRerouteTracker) shouldAttempt(circuitId string, now time.Time) bool {
+	state, found := self.circuits[circuitId]
+	return !found || !now.Before(state.nextAttempt)
+}
+
+// failed records a failed reroute. It returns true once the circuit has used up its attempts.
+func (self *drainRerouteTracker) failed(circuitId string, now time.Time) bool {
+	state, found := self.circuits[circuitId]
+	if !found {
+		state = &drainRerouteState{}
+		self.circuits[circuitId] = state
+	}
+	state.attempts++
+	if state.attempts >= drainRerouteMaxAttempts {
+		delete(self.circuits, circuitId)
+		return true
+	}
+	state.nextAttempt = now.Add(drainRerouteInitialBackoff << (state.attempts - 1))
+	return false
+}
+
+func (self *drainRerouteTracker) remove(circuitId string) {
+	delete(self.circuits, circuitId)
+}
+
+// retain discards the state of circuits which aren't in the given set
+func (self *drainRerouteTracker) retain(circuitIds map[string]struct{}) {
+	for circuitId := range self.circuits {
+		if _, found := circuitIds[circuitId]; !found {
+			delete(self.circuits, circuitId)
+		}
+	}
+}
+
+// enforceDrainDeadlines moves circuits off draining routers whose drain deadline has passed. Circuits which use
+// the router as a transit hop are rerouted. Failed reroutes are retried with backoff, and the circuit is closed if
+// it can't be rerouted after drainRerouteMaxAttempts. Circuits which start or end on the router can't be moved, so
+// they are closed.
+func (network *Network) enforceDrainDeadlines() {
+	now := time.Now()
+	expired := map[string]struct{}{}
+	for _, r := range network.AllConnectedRouters() {
+		if r.IsDrainExpired(now) {
+			expired[r.Id] = struct{}{}
+		}
+	}
+
+	pending := map[string]struct{}{}
+	defer network.drainReroutes.retain(pending)
+
+	if len(expired) == 0 {
+		return
+	}
+
+	for _, circuit := range network.GetAllCircuits() {
+		nodes := circuit.Path.Nodes
+		for idx, r := range nodes {
+			if _, found := expired[r.Id]; !found {
+				continue
+			}
+
+			log := pfxlog.Logger().WithField("circuitId", circuit.Id).WithField("routerId", r.Id)
+			if idx == 0 || idx == len(nodes)-1 {
+				log.Info("drain deadline passed, closing circuit")
+				if err := network.RemoveCircuit(circuit.Id, true); err != nil {
+					log.WithError(err).Error("unable to close circuit on drained router")
+				}
+			} else if network.drainReroutes.shouldAttempt(circuit.Id, now) {
+				log.Info("drain deadline passed, rerouting circuit")
+				if err := network.rerouteCircuit(circuit, now.Add(network.options.RouteTimeout)); err == nil {
+					network.drainReroutes.remove(circuit.Id)
+				} else if network.drainReroutes.failed(circuit.Id, now) {
+					log.WithError(err).Error("unable to reroute circuit off drained router, closing circuit")
+					if err = network.RemoveCircuit(circuit.Id, true); err != nil {
+						log.WithError(err).Error("unable to close circuit on drained router")
+					}
+				} else {
+					log.WithError(err).Error("unable to reroute circuit off drained router, will retry")
+					pending[circuit.Id] = struct{}{}
+				}
+			} else {
+				pending[circuit.Id] = struct{}{}
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/transport/v2/tcp"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/xt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainingRouters(t *testing.T) {
+	ctx := model.NewTestContext(t)
+	defer ctx.Cleanup()
+
+	req := require.New(t)
+
+	config := newTestConfig(ctx)
+	defer close(config.closeNotify)
+
+	network, err := NewNetwork(config, ctx)
+	req.NoError(err)
+
+	transportAddr, err := tcp.AddressParser{}.Parse("tcp:0.0.0.0:0")
+	req.NoError(err)
+
+	var routers []*model.Router
+	for _, id := range []string{"r0", "r1", "r2", "r3"} {
+		r := model.NewRouterForTest(id, "", transportAddr, nil, 0, false)
+		network.Router.MarkConnected(r)
+		routers = append(routers, r)
+	}
+	r0, r1, r2, r3 := routers[0], routers[1], routers[2], routers[3]
+
+	// the path through r1 is cheapest
+	newCostedPathTestLink(network, "l0", r0, r1, 1, 1)
+	newCostedPathTestLink(network, "l1", r0, r2, 10, 1)
+	newCostedPathTestLink(network, "l2", r1, r3, 1, 1)
+	newCostedPathTestLink(network, "l3", r2, r3, 10, 1)
+
+	path, err := network.CreatePath(r0, r3)
+	req.NoError(err)
+	req.Equal("r1", path.Nodes[1].Id)
+
+	// draining routers aren't used as transit hops for new paths
+	r1.Draining = true
+	path, err = network.CreatePath(r0, r3)
+	req.NoError(err)
+	req.Equal("r2", path.Nodes[1].Id)
+
+	// draining routers can still start and end paths
+	path, err = network.CreatePath(r0, r1)
+	req.NoError(err)
+	req.Equal("r1", path.Nodes[len(path.Nodes)-1].Id)
+
+	t1 := &model.RoutingTerminator{Terminator: &model.Terminator{Router: "r1"}}
+	t2 := &model.RoutingTerminator{Terminator: &model.Terminator{Router: "r2"}}
+
+	// terminators on draining routers are only used if there's no alternative
+	filtered := network.filterDrainingTerminators([]xt.CostedTerminator{t1, t2})
+	req.Equal([]xt.CostedTerminator{t2}, filtered)
+
+	filtered = network.filterDrainingTerminators([]xt.CostedTerminator{t1})
+	req.Equal([]xt.CostedTerminator{t1}, filtered)
+}
+
+func TestDrainRerouteTracker(t *testing.T) {
+	req := require.New(t)
+
+	tracker := newDrainRerouteTracker()
+	now := time.Now()
+
+	req.True(tracker.shouldAttempt("c1", now))
+
+	backoff := drainRerouteInitialBackoff
+	for i := 1; i < drainRerouteMaxAttempts; i++ {
+		req.False(tracker.failed("c1", now))
+		req.False(tracker.shouldAttempt("c1", now.Add(backoff-time.Millisecond)))
+		now = now.Add(backoff)
+		req.True(tracker.shouldAttempt("c1", now))
+		backoff *= 2
+	}
+
+	req.True(tracker.failed("c1", now), "circuit should be given up on after the last attempt")
+	req.Empty(tracker.circuits)
+
+	req.False(tracker.failed("c2", now))
+	req.False(tracker.failed("c3", now))
+	tracker.remove("c2")
+	req.True(tracker.shouldAttempt("c2", now))
+
+	tracker.retain(map[string]struct{}{})
+	req.Empty(tracker.circuits)
+}
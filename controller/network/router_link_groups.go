@@ -0,0 +1,147 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/storage/ast"
+	"github.com/openziti/ziti/common/linkgroups"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/model"
+	"go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+// loadLinkGroups initializes the link groups assigned to edge routers through role attributes. It's called from
+// the event loop before any events are processed.
+func (self *RouterMessaging) loadLinkGroups() {
+	store := self.env.GetStores().EdgeRouter
+	err := self.env.GetDb().View(func(tx *bbolt.Tx) error {
+		for cursor := store.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
+			entity, found, err := store.FindById(tx, string(cursor.Current()))
+			if err != nil {
+				return err
+			}
+			if found {
+				if groups := linkgroups.FromRoleAttributes(entity.RoleAttributes); len(groups) > 0 {
+					self.linkGroups[entity.Id] = groups
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		pfxlog.Logger().WithError(err).Error("unable to load router link groups")
+	}
+}
+
+func (self *RouterMessaging) EdgeRouterChanged(entity *db.EdgeRouter) {
+	self.queueEvent(&linkGroupsChangedEvent{
+		routerId: entity.Id,
+		groups:   linkgroups.FromRoleAttributes(entity.RoleAttributes),
+	})
+}
+
+func (self *RouterMessaging) EdgeRouterDeleted(entity *db.EdgeRouter) {
+	self.queueEvent(&linkGroupsChangedEvent{
+		routerId: entity.Id,
+	})
+}
+
+// getPeerListeners returns the given router's listeners, with listener groups adjusted for any link groups
+// assigned to the router
+func (self *RouterMessaging) getPeerListeners(router *model.Router) []*ctrl_pb.Listener {
+	groups := self.linkGroups[router.Id]
+	if len(groups) == 0 {
+		return router.Listeners
+	}
+
+	var result []*ctrl_pb.Listener
+	for _, listener := range router.Listeners {
+		listener = proto.Clone(listener).(*ctrl_pb.Listener)
+		listener.Groups = linkgroups.Apply(listener.Groups, groups)
+		result = append(result, listener)
+	}
+	return result
+}
+
+// sendLinkGroups sends the router the link groups assigned to it, so it can apply them to its link dialers
+func (self *RouterMessaging) sendLinkGroups(routerId string) {
+	router := self.managers.Router.GetConnected(routerId)
+	if router == nil || router.Control == nil {
+		return
+	}
+
+	value, err := proto.Marshal(&ctrl_pb.LinkGroupSettings{Groups: self.linkGroups[routerId]})
+	if err != nil {
+		pfxlog.Logger().WithError(err).WithField("routerId", routerId).Error("unable to marshal link group settings")
+		return
+	}
+
+	settings := &ctrl_pb.Settings{
+		Data: map[int32][]byte{
+			int32(ctrl_pb.SettingTypes_LinkGroups): value,
+		},
+	}
+
+	body, err := proto.Marshal(settings)
+	if err != nil {
+		pfxlog.Logger().WithError(err).WithField("routerId", routerId).Error("unable to marshal settings")
+		return
+	}
+
+	queueErr := self.routerCommPool.QueueOrError(func() {
+		msg := channel.NewMessage(int32(ctrl_pb.ContentType_SettingsType), body)
+		if err := msg.WithTimeout(time.Second).SendAndWaitForWire(router.Control); err != nil {
+			pfxlog.Logger().WithError(err).WithField("routerId", routerId).Error("failed to send link groups to router")
+		}
+	})
+
+	if queueErr != nil {
+		pfxlog.Logger().WithError(queueErr).WithField("routerId", routerId).Error("unable to queue link groups send to router")
+	}
+}
+
+type linkGroupsChangedEvent struct {
+	routerId string
+	groups   []string
+}
+
+func (self *linkGroupsChangedEvent) handle(c *RouterMessaging) {
+	if linkgroups.Equal(c.linkGroups[self.routerId], self.groups) {
+		return
+	}
+
+	if len(self.groups) == 0 {
+		delete(c.linkGroups, self.routerId)
+	} else {
+		c.linkGroups[self.routerId] = self.groups
+	}
+
+	pfxlog.Logger().WithField("routerId", self.routerId).
+		WithField("groups", self.groups).
+		Info("router link groups changed")
+
+	c.sendLinkGroups(self.routerId)
+
+	// peers need the updated listener groups
+	(&routerChangedEvent{routerId: self.routerId}).handle(c)
+}
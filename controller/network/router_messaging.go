@@ -69,9 +69,12 @@ func NewRouterMessaging(env model.Env, routerCommPool goroutines.Pool) *RouterMe
 		terminatorValidations:   map[string]*terminatorValidations{},
 		routerCommPool:          routerCommPool,
 		queuedTerminatorDeletes: map[string]struct{}{},
+		linkGroups:              map[string][]string{},
 	}
 
 	env.GetManagers().Terminator.GetStore().AddEntityEventListenerF(result.TerminatorCreated, boltz.EntityCreated)
+	env.GetStores().EdgeRouter.AddEntityEventListenerF(result.EdgeRouterChanged, boltz.EntityCreatedAsync, boltz.EntityUpdatedAsync)
+	env.GetStores().EdgeRouter.AddEntityEventListenerF(result.EdgeRouterDeleted, boltz.EntityDeletedAsync)
 
 	return result
 }
@@ -87,6 +90,9 @@ type RouterMessaging struct {
 	markerCounter           atomic.Uint64
 	deleteInProgress        atomic.Bool
 	deleteStarted           concurrenz.AtomicValue[time.Time]
+
+	// linkGroups holds the link groups assigned to routers through role attributes. Only accessed from the event loop
+	linkGroups map[string][]string
 }
 
 func (self *RouterMessaging) getNextMarker() uint64 {
@@ -133,6 +139,8 @@ func (self *RouterMessaging) run() {
 	ticker := time.NewTicker(time.Second * 30)
 	defer ticker.Stop()
 
+	self.loadLinkGroups()
+
 	for {
 		select {
 		case evt := <-self.eventsC:
@@ -216,7 +224,7 @@ func (self *RouterMessaging) syncStates() {
 					Id:        routerId,
 					Version:   router.VersionInfo.Version,
 					State:     ctrl_pb.PeerState_Healthy,
-					Listeners: router.Listeners,
+					Listeners: self.getPeerListeners(router),
 				})
 			} else {
 				exists, err := self.managers.Router.Exists(routerId)
@@ -506,6 +514,10 @@ func (self *routerChangedEvent) handle(c *RouterMessaging) {
 		WithField("connected", self.connected).
 		Info("calculating router updates for router")
 
+	if self.connected && len(c.linkGroups[self.routerId]) > 0 {
+		c.sendLinkGroups(self.routerId)
+	}
+
 	routers := c.managers.Router.AllConnected()
 
 	var sourceRouterState *routerUpdates
@@ -0,0 +1,303 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/metrics/metrics_pb"
+	"github.com/openziti/ziti/controller/config"
+	"github.com/openziti/ziti/controller/event"
+	cmap "github.com/orcaman/concurrent-map/v2"
+)
+
+const (
+	RouterScalingSignalCpu           = "cpu"
+	RouterScalingSignalConnections   = "connections"
+	RouterScalingSignalCircuits      = "circuits"
+	RouterScalingSignalXgressBuffers = "xgress_buffers"
+
+	RouterScalingScaleUp   = "scale-up"
+	RouterScalingSteady    = "steady"
+	RouterScalingScaleDown = "scale-down"
+
+	routerMetricEdgeConnections     = "edge.connections"
+	routerMetricForwarderCircuits   = "forwarder.circuits"
+	routerMetricHostCpuPercent      = "host.cpu.percent"
+	routerMetricXgressBlockedWindow = "xgress.blocked_by_local_window"
+)
+
+// RouterScalingHint is the most recent saturation reading for an edge router, normalized for use by autoscalers.
+// Each signal is in the range 0 to 1, except connections and circuits, which may exceed 1 when a router is over the
+// configured capacity. Saturation is the highest of the signals.
+type RouterScalingHint struct {
+	RouterId       string
+	Signals        map[string]float64
+	Raw            map[string]int64
+	Saturation     float64
+	State          string
+	Recommendation string
+	ReportedAt     time.Time
+	Stale          bool
+}
+
+// RouterScalingSummary aggregates the hints of all edge routers which have reported recently
+type RouterScalingSummary struct {
+	RouterCount       int
+	AverageSaturation float64
+	MaxSaturation     float64
+	ScaleUpCount      int
+	ScaleDownCount    int
+	Recommendation    string
+}
+
+// routerScalingTracker keeps the latest saturation signals reported by each edge router, in the metrics messages
+// routers send on their metrics report interval. Routers are considered edge routers if they report the
+// edge.connections gauge. When a router's saturation moves between the low, normal and high bands defined by the
+// scaling thresholds, a router-saturation event is emitted.
+//
+// Hints are held in memory by each controller, for the routers connected to it.
+type routerScalingTracker struct {
+	config     *config.NetworkConfig
+	dispatcher event.Dispatcher
+	ctrlId     string
+	hints      cmap.ConcurrentMap[string, *routerScalingState]
+}
+
+type routerScalingState struct {
+	sync.Mutex
+	hint RouterScalingHint
+}
+
+func newRouterScalingTracker(config *config.NetworkConfig, dispatcher event.Dispatcher, ctrlId string) *routerScalingTracker {
+	return &routerScalingTracker{
+		config:     config,
+		dispatcher: dispatcher,
+		ctrlId:     ctrlId,
+		hints:      cmap.New[*routerScalingState](),
+	}
+}
+
+func (self *routerScalingTracker) acceptMetrics(routerId string, msg *metrics_pb.MetricsMessage) {
+	connections, isEdge := msg.IntValues[routerMetricEdgeConnections]
+	if !isEdge {
+		return
+	}
+
+	options := self.config.RouterScaling
+	circuits := msg.IntValues[routerMetricForwarderCircuits]
+
+	raw := map[string]int64{
+		routerMetricEdgeConnections:   connections,
+		routerMetricForwarderCircuits: circuits,
+	}
+
+	signals := map[string]float64{
+		RouterScalingSignalConnections: float64(connections) / float64(max(options.ConnectionCapacity, 1)),
+		RouterScalingSignalCircuits:    float64(circuits) / float64(max(options.CircuitCapacity, 1)),
+	}
+
+	if cpu, found := msg.IntValues[routerMetricHostCpuPercent]; found {
+		raw[routerMetricHostCpuPercent] = cpu
+		signals[RouterScalingSignalCpu] = clampSignal(float64(cpu) / 100)
+	}
+
+	if blocked, found := msg.IntValues[routerMetricXgressBlockedWindow]; found {
+		raw[routerMetricXgressBlockedWindow] = blocked
+		signals[RouterScalingSignalXgressBuffers] = clampSignal(float64(blocked) / float64(max(circuits, 1)))
+	}
+
+	var saturation float64
+	for _, v := range signals {
+		saturation = max(saturation, v)
+	}
+	saturation = math.Round(saturation*1000) / 1000
+
+	state := self.getState(saturation)
+
+	reportedAt := time.Now()
+	if msg.Timestamp != nil {
+		reportedAt = msg.Timestamp.AsTime()
+	}
+
+	hint := RouterScalingHint{
+		RouterId:       routerId,
+		Signals:        signals,
+		Raw:            raw,
+		Saturation:     saturation,
+		State:          state,
+		Recommendation: getScalingRecommendation(state),
+		ReportedAt:     reportedAt,
+	}
+
+	entry := self.hints.Upsert(routerId, nil, func(exist bool, valueInMap *routerScalingState, newValue *routerScalingState) *routerScalingState {
+		if exist {
+			return valueInMap
+		}
+		return &routerScalingState{}
+	})
+
+	entry.Lock()
+	previousState := entry.hint.State
+	entry.hint = hint
+	entry.Unlock()
+
+	if previousState != "" && previousState != state {
+		self.emitSaturationEvent(hint, previousState)
+	}
+}
+
+func (self *routerScalingTracker) getState(saturation float64) string {
+	if saturation >= self.config.RouterScaling.ScaleUpThreshold {
+		return event.RouterSaturationHigh
+	}
+	if saturation <= self.config.RouterScaling.ScaleDownThreshold {
+		return event.RouterSaturationLow
+	}
+	return event.RouterSaturationNormal
+}
+
+func (self *routerScalingTracker) emitSaturationEvent(hint RouterScalingHint, previousState string) {
+	pfxlog.Logger().WithField("routerId", hint.RouterId).
+		WithField("state", hint.State).
+		WithField("previousState", previousState).
+		WithField("saturation", hint.Saturation).
+		Info("router saturation crossed scaling threshold")
+
+	signals := make(map[string]float64, len(hint.Signals))
+	for k, v := range hint.Signals {
+		signals[k] = math.Round(v*1000) / 1000
+	}
+
+	self.dispatcher.AcceptRouterEvent(&event.RouterEvent{
+		Namespace:    event.RouterEventNS,
+		EventSrcId:   self.ctrlId,
+		EventType:    event.RouterSaturation,
+		Timestamp:    time.Now(),
+		RouterId:     hint.RouterId,
+		RouterOnline: true,
+		Saturation: &event.RouterSaturationDetail{
+			State:         hint.State,
+			PreviousState: previousState,
+			Saturation:    hint.Saturation,
+			Signals:       signals,
+		},
+	})
+}
+
+func (self *routerScalingTracker) get(routerId string) (*RouterScalingHint, bool) {
+	entry, found := self.hints.Get(routerId)
+	if !found {
+		return nil, false
+	}
+
+	entry.Lock()
+	result := entry.hint
+	entry.Unlock()
+
+	result.Stale = time.Since(result.ReportedAt) > self.config.RouterScaling.StaleAfter
+	return &result, true
+}
+
+func (self *routerScalingTracker) getAll() []*RouterScalingHint {
+	var result []*RouterScalingHint
+	for _, routerId := range self.hints.Keys() {
+		if hint, found := self.get(routerId); found {
+			result = append(result, hint)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].RouterId < result[j].RouterId
+	})
+	return result
+}
+
+func (self *routerScalingTracker) remove(routerId string) {
+	self.hints.Remove(routerId)
+}
+
+// summarize aggregates the given hints, skipping stale ones. The fleet recommendation is scale-up if any router is
+// saturated, scale-down if the average saturation is low enough that removing a router would leave the rest below
+// the scale up threshold, and steady otherwise.
+func (self *routerScalingTracker) summarize(hints []*RouterScalingHint) *RouterScalingSummary {
+	result := &RouterScalingSummary{
+		Recommendation: RouterScalingSteady,
+	}
+
+	var total float64
+	for _, hint := range hints {
+		if hint.Stale {
+			continue
+		}
+		result.RouterCount++
+		total += hint.Saturation
+		result.MaxSaturation = max(result.MaxSaturation, hint.Saturation)
+		switch hint.Recommendation {
+		case RouterScalingScaleUp:
+			result.ScaleUpCount++
+		case RouterScalingScaleDown:
+			result.ScaleDownCount++
+		}
+	}
+
+	if result.RouterCount == 0 {
+		return result
+	}
+
+	result.AverageSaturation = math.Round(total/float64(result.RouterCount)*1000) / 1000
+
+	if result.ScaleUpCount > 0 {
+		result.Recommendation = RouterScalingScaleUp
+	} else if result.RouterCount > 1 &&
+		result.AverageSaturation <= self.config.RouterScaling.ScaleDownThreshold &&
+		total/float64(result.RouterCount-1) < self.config.RouterScaling.ScaleUpThreshold {
+		result.Recommendation = RouterScalingScaleDown
+	}
+
+	return result
+}
+
+func getScalingRecommendation(state string) string {
+	switch state {
+	case event.RouterSaturationHigh:
+		return RouterScalingScaleUp
+	case event.RouterSaturationLow:
+		return RouterScalingScaleDown
+	default:
+		return RouterScalingSteady
+	}
+}
+
+func clampSignal(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
+
+// GetRouterScalingHint returns the latest scaling hint for the given edge router, if it has reported one
+func (network *Network) GetRouterScalingHint(routerId string) (*RouterScalingHint, bool) {
+	return network.routerScaling.get(routerId)
+}
+
+// GetRouterScalingHints returns the latest scaling hints for all edge routers which have reported one, along with
+// a summary across the routers whose hints aren't stale
+func (network *Network) GetRouterScalingHints() ([]*RouterScalingHint, *RouterScalingSummary) {
+	hints := network.routerScaling.getAll()
+	return hints, network.routerScaling.summarize(hints)
+}
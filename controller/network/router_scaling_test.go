@@ -0,0 +1,162 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/metrics/metrics_pb"
+	"github.com/openziti/ziti/controller/config"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type routerEventCollector struct {
+	event.DispatcherMock
+	events []*event.RouterEvent
+}
+
+func (self *routerEventCollector) AcceptRouterEvent(evt *event.RouterEvent) {
+	self.events = append(self.events, evt)
+}
+
+func newScalingMetricsMsg(values map[string]int64) *metrics_pb.MetricsMessage {
+	return &metrics_pb.MetricsMessage{
+		Timestamp: timestamppb.Now(),
+		IntValues: values,
+	}
+}
+
+func TestRouterScalingTracker(t *testing.T) {
+	req := require.New(t)
+
+	options := config.DefaultNetworkConfig()
+	options.RouterScaling.ConnectionCapacity = 100
+	options.RouterScaling.CircuitCapacity = 1000
+
+	dispatcher := &routerEventCollector{}
+	tracker := newRouterScalingTracker(options, dispatcher, "ctrl1")
+
+	// routers which don't report edge connections aren't edge routers
+	tracker.acceptMetrics("fabric", newScalingMetricsMsg(map[string]int64{"forwarder.circuits": 900}))
+	_, found := tracker.get("fabric")
+	req.False(found)
+
+	tracker.acceptMetrics("er1", newScalingMetricsMsg(map[string]int64{
+		"edge.connections":               50,
+		"forwarder.circuits":             200,
+		"host.cpu.percent":               40,
+		"xgress.blocked_by_local_window": 10,
+	}))
+
+	hint, found := tracker.get("er1")
+	req.True(found)
+	req.InDelta(0.5, hint.Signals[RouterScalingSignalConnections], 0.0001)
+	req.InDelta(0.2, hint.Signals[RouterScalingSignalCircuits], 0.0001)
+	req.InDelta(0.4, hint.Signals[RouterScalingSignalCpu], 0.0001)
+	req.InDelta(0.05, hint.Signals[RouterScalingSignalXgressBuffers], 0.0001)
+	req.InDelta(0.5, hint.Saturation, 0.0001)
+	req.Equal(event.RouterSaturationNormal, hint.State)
+	req.Equal(RouterScalingSteady, hint.Recommendation)
+	req.False(hint.Stale)
+
+	// the first report doesn't generate an event, since there's no previous state
+	req.Empty(dispatcher.events)
+
+	tracker.acceptMetrics("er1", newScalingMetricsMsg(map[string]int64{
+		"edge.connections":   50,
+		"forwarder.circuits": 200,
+		"host.cpu.percent":   93,
+	}))
+
+	hint, _ = tracker.get("er1")
+	req.Equal(event.RouterSaturationHigh, hint.State)
+	req.Equal(RouterScalingScaleUp, hint.Recommendation)
+	req.Len(dispatcher.events, 1)
+	req.Equal(event.RouterSaturation, dispatcher.events[0].EventType)
+	req.Equal("er1", dispatcher.events[0].RouterId)
+	req.Equal(event.RouterSaturationHigh, dispatcher.events[0].Saturation.State)
+	req.Equal(event.RouterSaturationNormal, dispatcher.events[0].Saturation.PreviousState)
+
+	// staying in the same band doesn't generate another event
+	tracker.acceptMetrics("er1", newScalingMetricsMsg(map[string]int64{
+		"edge.connections":   50,
+		"forwarder.circuits": 200,
+		"host.cpu.percent":   95,
+	}))
+	req.Len(dispatcher.events, 1)
+
+	tracker.acceptMetrics("er2", newScalingMetricsMsg(map[string]int64{
+		"edge.connections":   10,
+		"forwarder.circuits": 10,
+		"host.cpu.percent":   5,
+	}))
+
+	hints := tracker.getAll()
+	req.Len(hints, 2)
+	req.Equal("er1", hints[0].RouterId)
+	req.Equal("er2", hints[1].RouterId)
+	req.Equal(RouterScalingScaleDown, hints[1].Recommendation)
+
+	summary := tracker.summarize(hints)
+	req.Equal(2, summary.RouterCount)
+	req.Equal(1, summary.ScaleUpCount)
+	req.Equal(1, summary.ScaleDownCount)
+	req.InDelta(0.95, summary.MaxSaturation, 0.0001)
+	req.Equal(RouterScalingScaleUp, summary.Recommendation)
+
+	// stale hints are reported, but left out of the summary
+	options.RouterScaling.StaleAfter = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	hint, _ = tracker.get("er1")
+	req.True(hint.Stale)
+	summary = tracker.summarize(tracker.getAll())
+	req.Equal(0, summary.RouterCount)
+	req.Equal(RouterScalingSteady, summary.Recommendation)
+
+	tracker.remove("er1")
+	_, found = tracker.get("er1")
+	req.False(found)
+}
+
+func TestRouterScalingSummaryScaleDown(t *testing.T) {
+	req := require.New(t)
+
+	options := config.DefaultNetworkConfig()
+	tracker := newRouterScalingTracker(options, &routerEventCollector{}, "ctrl1")
+
+	hints := []*RouterScalingHint{
+		{RouterId: "er1", Saturation: 0.2, Recommendation: RouterScalingScaleDown},
+		{RouterId: "er2", Saturation: 0.25, Recommendation: RouterScalingScaleDown},
+		{RouterId: "er3", Saturation: 0.3, Recommendation: RouterScalingScaleDown},
+	}
+	req.Equal(RouterScalingScaleDown, tracker.summarize(hints).Recommendation)
+
+	// a single router is never scaled down
+	req.Equal(RouterScalingSteady, tracker.summarize(hints[:1]).Recommendation)
+
+	// if the remaining routers would be saturated, hold steady
+	options.RouterScaling.ScaleUpThreshold = 0.5
+	options.RouterScaling.ScaleDownThreshold = 0.45
+	hints = []*RouterScalingHint{
+		{RouterId: "er1", Saturation: 0.3, Recommendation: RouterScalingScaleDown},
+		{RouterId: "er2", Saturation: 0.3, Recommendation: RouterScalingScaleDown},
+	}
+	req.Equal(RouterScalingSteady, tracker.summarize(hints).Recommendation)
+}
@@ -0,0 +1,689 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/common/eid"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	RouterRolloutRunning   = "running"
+	RouterRolloutCompleted = "completed"
+	RouterRolloutFailed    = "failed"
+	RouterRolloutCancelled = "cancelled"
+
+	RouterUpdatePending    = "pending"
+	RouterUpdateStaging    = "staging"
+	RouterUpdateDraining   = "draining"
+	RouterUpdateRestarting = "restarting"
+	RouterUpdateCompleted  = "completed"
+	RouterUpdateSkipped    = "skipped"
+	RouterUpdateFailed     = "failed"
+	RouterUpdateCancelled  = "cancelled"
+
+	DefaultRouterRolloutStageTimeout   = 5 * time.Minute
+	DefaultRouterRolloutDrainTimeout   = 5 * time.Minute
+	DefaultRouterRolloutRestartTimeout = 2 * time.Minute
+
+	routerRolloutHistorySize  = 10
+	routerRolloutPollInterval = time.Second
+)
+
+var errRouterRolloutCancelled = errors.New("rollout cancelled")
+
+// RouterRelease is the router version which rollouts install. It has one artifact per supported os and
+// architecture.
+type RouterRelease struct {
+	Version   string
+	Artifacts []*RouterReleaseArtifact
+}
+
+// RouterReleaseArtifact is a router binary, which routers download from Url and verify against Sha256
+type RouterReleaseArtifact struct {
+	Os     string
+	Arch   string
+	Url    string
+	Sha256 string
+}
+
+func (self *RouterRelease) getArtifact(os, arch string) *RouterReleaseArtifact {
+	for _, artifact := range self.Artifacts {
+		if artifact.Os == os && artifact.Arch == arch {
+			return artifact
+		}
+	}
+	return nil
+}
+
+// RouterRolloutOptions control how a rollout moves through the routers. If RouterIds is empty, all connected
+// routers are updated. Routers are updated BatchSize at a time, and the rollout stops once more than MaxFailures
+// routers have failed to update.
+type RouterRolloutOptions struct {
+	RouterIds      []string
+	BatchSize      int
+	MaxFailures    int
+	StageTimeout   time.Duration
+	DrainTimeout   time.Duration
+	RestartTimeout time.Duration
+}
+
+// RouterRollout is a snapshot of the progress of a rollout
+type RouterRollout struct {
+	Id         string
+	Version    string
+	State      string
+	Error      string
+	Options    RouterRolloutOptions
+	Routers    []*RouterRolloutRouter
+	Failures   int
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// RouterRolloutRouter is the progress of a single router within a rollout
+type RouterRolloutRouter struct {
+	RouterId        string
+	PreviousVersion string
+	State           string
+	Error           string
+	UpdatedAt       time.Time
+}
+
+// routerUpdateManager moves routers to the current release. Each rollout stages the new binary on a batch of
+// routers, drains them, has them swap in the new binary and restart, and then waits for them to reconnect with the
+// new version before undraining them and moving on to the next batch.
+//
+// Releases and rollouts are held in memory by the controller which runs the rollout. Routers report update progress
+// back to the controller they are connected to.
+type routerUpdateManager struct {
+	network  *Network
+	lock     sync.Mutex
+	release  *RouterRelease
+	rollouts []*routerRollout
+	waiters  map[string]chan *ctrl_msg.RouterUpdateStatus
+}
+
+type routerRollout struct {
+	RouterRollout
+	release *RouterRelease
+	cancelC chan struct{}
+}
+
+func newRouterUpdateManager(network *Network) *routerUpdateManager {
+	return &routerUpdateManager{
+		network: network,
+		waiters: map[string]chan *ctrl_msg.RouterUpdateStatus{},
+	}
+}
+
+func (self *routerUpdateManager) setRelease(release *RouterRelease) error {
+	if release.Version == "" {
+		return errorz.NewFieldError("version is required", "version", release.Version)
+	}
+
+	if len(release.Artifacts) == 0 {
+		return errorz.NewFieldError("at least one artifact is required", "artifacts", release.Artifacts)
+	}
+
+	for idx, artifact := range release.Artifacts {
+		field := fmt.Sprintf("artifacts[%d]", idx)
+		if artifact.Os == "" || artifact.Arch == "" || artifact.Url == "" || artifact.Sha256 == "" {
+			return errorz.NewFieldError("artifacts require an os, arch, url and sha256", field, artifact)
+		}
+		if release.getArtifact(artifact.Os, artifact.Arch) != artifact {
+			return errorz.NewFieldError("duplicate artifact for "+artifact.Os+"/"+artifact.Arch, field, artifact)
+		}
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.release = release
+	return nil
+}
+
+func (self *routerUpdateManager) getRelease() *RouterRelease {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.release
+}
+
+func (self *routerUpdateManager) clearRelease() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.release = nil
+}
+
+func (self *routerUpdateManager) startRollout(options RouterRolloutOptions) (*RouterRollout, error) {
+	if options.BatchSize < 0 {
+		return nil, errorz.NewFieldError("batch size may not be negative", "batchSize", options.BatchSize)
+	}
+	if options.MaxFailures < 0 {
+		return nil, errorz.NewFieldError("max failures may not be negative", "maxFailures", options.MaxFailures)
+	}
+	if options.BatchSize == 0 {
+		options.BatchSize = 1
+	}
+	if options.StageTimeout <= 0 {
+		options.StageTimeout = DefaultRouterRolloutStageTimeout
+	}
+	if options.DrainTimeout <= 0 {
+		options.DrainTimeout = DefaultRouterRolloutDrainTimeout
+	}
+	if options.RestartTimeout <= 0 {
+		options.RestartTimeout = DefaultRouterRolloutRestartTimeout
+	}
+
+	routerIds := options.RouterIds
+	if len(routerIds) == 0 {
+		for _, r := range self.network.AllConnectedRouters() {
+			routerIds = append(routerIds, r.Id)
+		}
+		slices.Sort(routerIds)
+	}
+
+	if len(routerIds) == 0 {
+		return nil, errors.New("no routers are connected, nothing to update")
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.release == nil {
+		return nil, errors.New("no router release has been set")
+	}
+
+	for _, rollout := range self.rollouts {
+		if rollout.State == RouterRolloutRunning {
+			return nil, errors.Errorf("rollout %s is already running", rollout.Id)
+		}
+	}
+
+	now := time.Now()
+	rollout := &routerRollout{
+		RouterRollout: RouterRollout{
+			Id:        eid.New(),
+			Version:   self.release.Version,
+			State:     RouterRolloutRunning,
+			Options:   options,
+			CreatedAt: now,
+		},
+		release: self.release,
+		cancelC: make(chan struct{}),
+	}
+
+	for _, routerId := range routerIds {
+		rollout.Routers = append(rollout.Routers, &RouterRolloutRouter{
+			RouterId:  routerId,
+			State:     RouterUpdatePending,
+			UpdatedAt: now,
+		})
+	}
+
+	self.rollouts = append(self.rollouts, rollout)
+	if len(self.rollouts) > routerRolloutHistorySize {
+		self.rollouts = self.rollouts[len(self.rollouts)-routerRolloutHistorySize:]
+	}
+
+	go self.run(rollout)
+
+	return rollout.snapshot(), nil
+}
+
+func (self *routerUpdateManager) cancelRollout(id string) (*RouterRollout, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	rollout := self.findRollout(id)
+	if rollout == nil {
+		return nil, errorz.NewNotFound()
+	}
+
+	if rollout.State != RouterRolloutRunning {
+		return nil, errors.Errorf("rollout %s is %s and can't be cancelled", id, rollout.State)
+	}
+
+	select {
+	case <-rollout.cancelC:
+	default:
+		close(rollout.cancelC)
+	}
+
+	return rollout.snapshot(), nil
+}
+
+func (self *routerUpdateManager) getRollout(id string) (*RouterRollout, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if rollout := self.findRollout(id); rollout != nil {
+		return rollout.snapshot(), true
+	}
+	return nil, false
+}
+
+func (self *routerUpdateManager) getRollouts() []*RouterRollout {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	var result []*RouterRollout
+	for _, rollout := range self.rollouts {
+		result = append(result, rollout.snapshot())
+	}
+	return result
+}
+
+func (self *routerUpdateManager) findRollout(id string) *routerRollout {
+	for _, rollout := range self.rollouts {
+		if rollout.Id == id {
+			return rollout
+		}
+	}
+	return nil
+}
+
+// snapshot must be called with the manager lock held
+func (self *routerRollout) snapshot() *RouterRollout {
+	result := self.RouterRollout
+	result.Routers = make([]*RouterRolloutRouter, 0, len(self.Routers))
+	for _, r := range self.Routers {
+		routerCopy := *r
+		result.Routers = append(result.Routers, &routerCopy)
+	}
+	return &result
+}
+
+func (self *routerUpdateManager) setRouterState(router *RouterRolloutRouter, state string, err error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	router.State = state
+	router.UpdatedAt = time.Now()
+	if err != nil {
+		router.Error = err.Error()
+	}
+}
+
+func (self *routerUpdateManager) run(rollout *routerRollout) {
+	log := pfxlog.Logger().WithField("rolloutId", rollout.Id).WithField("version", rollout.Version)
+	log.WithField("routers", len(rollout.Routers)).Info("starting router update rollout")
+
+	state, err := self.runBatches(rollout, log)
+
+	self.lock.Lock()
+	now := time.Now()
+	rollout.State = state
+	rollout.FinishedAt = &now
+	if err != nil {
+		rollout.Error = err.Error()
+	}
+	for _, r := range rollout.Routers {
+		if r.State == RouterUpdatePending {
+			r.State = RouterUpdateCancelled
+			r.UpdatedAt = now
+		}
+	}
+	self.lock.Unlock()
+
+	log = log.WithField("state", state)
+	if err != nil {
+		log = log.WithError(err)
+	}
+	log.Info("router update rollout finished")
+}
+
+func (self *routerUpdateManager) runBatches(rollout *routerRollout, log *logrus.Entry) (string, error) {
+	batchSize := rollout.Options.BatchSize
+	for start := 0; start < len(rollout.Routers); start += batchSize {
+		select {
+		case <-rollout.cancelC:
+			return RouterRolloutCancelled, errRouterRolloutCancelled
+		case <-self.network.GetCloseNotify():
+			return RouterRolloutCancelled, errors.New("controller shutting down")
+		default:
+		}
+
+		batch := rollout.Routers[start:min(start+batchSize, len(rollout.Routers))]
+
+		var wg sync.WaitGroup
+		for _, r := range batch {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				self.updateRouter(rollout, r, log.WithField("routerId", r.RouterId))
+			}()
+		}
+		wg.Wait()
+
+		self.lock.Lock()
+		failures := 0
+		for _, r := range rollout.Routers {
+			if r.State == RouterUpdateFailed {
+				failures++
+			}
+		}
+		rollout.Failures = failures
+		self.lock.Unlock()
+
+		if failures > rollout.Options.MaxFailures {
+			return RouterRolloutFailed, errors.Errorf("%d routers failed to update, which is more than the %d failures allowed",
+				failures, rollout.Options.MaxFailures)
+		}
+	}
+
+	return RouterRolloutCompleted, nil
+}
+
+func (self *routerUpdateManager) updateRouter(rollout *routerRollout, router *RouterRolloutRouter, log *logrus.Entry) {
+	r := self.network.GetConnectedRouter(router.RouterId)
+	if r == nil {
+		log.Error("router not connected, unable to update")
+		self.setRouterState(router, RouterUpdateFailed, errors.New("router not connected"))
+		return
+	}
+
+	var os, arch string
+	if r.VersionInfo != nil {
+		self.lock.Lock()
+		router.PreviousVersion = r.VersionInfo.Version
+		self.lock.Unlock()
+
+		if r.VersionInfo.Version == rollout.Version {
+			log.Info("router already running target version, skipping")
+			self.setRouterState(router, RouterUpdateSkipped, nil)
+			return
+		}
+		os, arch = r.VersionInfo.OS, r.VersionInfo.Arch
+	}
+
+	artifact := rollout.release.getArtifact(os, arch)
+	if artifact == nil {
+		err := errors.Errorf("release %s has no artifact for %s/%s", rollout.Version, os, arch)
+		log.WithError(err).Error("unable to update router")
+		self.setRouterState(router, RouterUpdateFailed, err)
+		return
+	}
+
+	waiterKey := router.RouterId + "/" + rollout.Id
+	statusC := make(chan *ctrl_msg.RouterUpdateStatus, 4)
+	self.lock.Lock()
+	self.waiters[waiterKey] = statusC
+	self.lock.Unlock()
+
+	defer func() {
+		self.lock.Lock()
+		delete(self.waiters, waiterKey)
+		self.lock.Unlock()
+	}()
+
+	if err := self.stage(rollout, r, artifact, statusC); err != nil {
+		log.WithError(err).Error("unable to stage router update")
+		self.setRouterState(router, self.failureState(err), err)
+		self.sendRequest(r, &ctrl_msg.RouterUpdateRequest{
+			RolloutId: rollout.Id,
+			Phase:     ctrl_msg.RouterUpdatePhaseDiscard,
+			Version:   rollout.Version,
+		}, log)
+		return
+	}
+
+	self.setRouterState(router, RouterUpdateDraining, nil)
+	drained, err := self.drain(rollout, r)
+	if err != nil {
+		log.WithError(err).Error("unable to drain router for update")
+		self.setRouterState(router, self.failureState(err), err)
+		return
+	}
+
+	if drained {
+		// only undrain the router if the rollout drained it. Routers drained by an operator stay drained.
+		defer self.undrain(r, log)
+	}
+
+	self.setRouterState(router, RouterUpdateRestarting, nil)
+	if err = self.apply(rollout, r, statusC); err != nil {
+		log.WithError(err).Error("unable to apply router update")
+		self.setRouterState(router, self.failureState(err), err)
+		return
+	}
+
+	log.Info("router updated")
+	self.setRouterState(router, RouterUpdateCompleted, nil)
+}
+
+func (self *routerUpdateManager) failureState(err error) string {
+	if errors.Is(err, errRouterRolloutCancelled) {
+		return RouterUpdateCancelled
+	}
+	return RouterUpdateFailed
+}
+
+func (self *routerUpdateManager) stage(rollout *routerRollout, r *model.Router, artifact *RouterReleaseArtifact, statusC <-chan *ctrl_msg.RouterUpdateStatus) error {
+	err := r.Control.Send(self.newRequest(rollout, ctrl_msg.RouterUpdatePhaseStage, artifact).ToMessage())
+	if err != nil {
+		return errors.Wrap(err, "unable to send stage request")
+	}
+
+	timeout := time.After(rollout.Options.StageTimeout)
+	for {
+		select {
+		case status := <-statusC:
+			switch status.State {
+			case ctrl_msg.RouterUpdateStateStaged:
+				return nil
+			case ctrl_msg.RouterUpdateStateFailed:
+				return errors.New(status.Error)
+			}
+		case <-timeout:
+			return errors.Errorf("router did not stage update within %v", rollout.Options.StageTimeout)
+		case <-rollout.cancelC:
+			return errRouterRolloutCancelled
+		}
+	}
+}
+
+// drain puts the router into drain mode, unless it's already draining, and waits for circuits to move off it or for
+// the drain timeout to pass. Returns true if the router was drained by the rollout.
+func (self *routerUpdateManager) drain(rollout *routerRollout, r *model.Router) (bool, error) {
+	drained := false
+	if !r.Draining {
+		deadline := time.Now().Add(rollout.Options.DrainTimeout)
+		if err := self.setDraining(r.Id, true, &deadline); err != nil {
+			return false, errors.Wrap(err, "unable to drain router")
+		}
+		drained = true
+	}
+
+	timeout := time.After(rollout.Options.DrainTimeout)
+	ticker := time.NewTicker(routerRolloutPollInterval)
+	defer ticker.Stop()
+
+	for self.hasCircuits(r.Id) {
+		select {
+		case <-ticker.C:
+		case <-timeout:
+			return drained, nil
+		case <-rollout.cancelC:
+			return drained, errRouterRolloutCancelled
+		}
+	}
+
+	return drained, nil
+}
+
+func (self *routerUpdateManager) undrain(r *model.Router, log *logrus.Entry) {
+	if err := self.setDraining(r.Id, false, nil); err != nil {
+		log.WithError(err).Error("unable to undrain router after update")
+	}
+}
+
+func (self *routerUpdateManager) setDraining(routerId string, draining bool, deadline *time.Time) error {
+	changeCtx := change.New().SetChangeAuthorName(self.network.env.GetId()).
+		SetChangeAuthorType(change.AuthorTypeController).
+		SetSourceType(change.SourceTypeControlChannel)
+
+	return self.network.Router.Update(&model.Router{
+		BaseEntity:    models.BaseEntity{Id: routerId},
+		Draining:      draining,
+		DrainDeadline: deadline,
+	}, fields.UpdatedFieldsMap{db.FieldRouterDraining: struct{}{}}, changeCtx)
+}
+
+func (self *routerUpdateManager) hasCircuits(routerId string) bool {
+	for _, circuit := range self.network.GetAllCircuits() {
+		for _, node := range circuit.Path.Nodes {
+			if node.Id == routerId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apply has the router install the staged binary and restart, then waits for it to reconnect running the new
+// version
+func (self *routerUpdateManager) apply(rollout *routerRollout, r *model.Router, statusC <-chan *ctrl_msg.RouterUpdateStatus) error {
+	applyTime := time.Now()
+	err := r.Control.Send(self.newRequest(rollout, ctrl_msg.RouterUpdatePhaseApply, nil).ToMessage())
+	if err != nil {
+		return errors.Wrap(err, "unable to send apply request")
+	}
+
+	timeout := time.After(rollout.Options.RestartTimeout)
+	ticker := time.NewTicker(routerRolloutPollInterval)
+	defer ticker.Stop()
+
+	cancelC := rollout.cancelC
+	for {
+		select {
+		case status := <-statusC:
+			if status.State == ctrl_msg.RouterUpdateStateFailed {
+				return errors.New(status.Error)
+			}
+		case <-ticker.C:
+			current := self.network.GetConnectedRouter(r.Id)
+			if current != nil && current.ConnectTime.After(applyTime) {
+				if current.VersionInfo != nil && current.VersionInfo.Version == rollout.Version {
+					return nil
+				}
+				version := ""
+				if current.VersionInfo != nil {
+					version = current.VersionInfo.Version
+				}
+				return errors.Errorf("router reconnected running version %s, expected %s", version, rollout.Version)
+			}
+		case <-timeout:
+			return errors.Errorf("router did not reconnect with version %s within %v", rollout.Version, rollout.Options.RestartTimeout)
+		case <-cancelC:
+			// the router may already be restarting, so keep waiting for it rather than leaving it drained
+			cancelC = nil
+		}
+	}
+}
+
+func (self *routerUpdateManager) newRequest(rollout *routerRollout, phase string, artifact *RouterReleaseArtifact) *ctrl_msg.RouterUpdateRequest {
+	result := &ctrl_msg.RouterUpdateRequest{
+		RolloutId: rollout.Id,
+		Phase:     phase,
+		Version:   rollout.Version,
+	}
+	if artifact != nil {
+		result.Url = artifact.Url
+		result.Sha256 = artifact.Sha256
+	}
+	return result
+}
+
+func (self *routerUpdateManager) sendRequest(r *model.Router, request *ctrl_msg.RouterUpdateRequest, log *logrus.Entry) {
+	if err := r.Control.Send(request.ToMessage()); err != nil {
+		log.WithError(err).WithField("phase", request.Phase).Error("unable to send router update request")
+	}
+}
+
+func (self *routerUpdateManager) acceptStatus(routerId string, status *ctrl_msg.RouterUpdateStatus) {
+	log := pfxlog.Logger().WithField("routerId", routerId).
+		WithField("rolloutId", status.RolloutId).
+		WithField("state", status.State)
+
+	self.lock.Lock()
+	statusC, found := self.waiters[routerId+"/"+status.RolloutId]
+	self.lock.Unlock()
+
+	if !found {
+		log.Debug("no rollout waiting on router update status, ignoring")
+		return
+	}
+
+	select {
+	case statusC <- status:
+	default:
+		log.Warn("router update status dropped, rollout not keeping up")
+	}
+}
+
+// GetRouterRelease returns the router release which rollouts install, if one has been set
+func (network *Network) GetRouterRelease() *RouterRelease {
+	return network.routerUpdates.getRelease()
+}
+
+// SetRouterRelease sets the router release which rollouts install. It doesn't affect rollouts which are already
+// running.
+func (network *Network) SetRouterRelease(release *RouterRelease) error {
+	return network.routerUpdates.setRelease(release)
+}
+
+func (network *Network) ClearRouterRelease() {
+	network.routerUpdates.clearRelease()
+}
+
+// StartRouterRollout starts updating routers to the current router release. Only one rollout may run at a time.
+func (network *Network) StartRouterRollout(options RouterRolloutOptions) (*RouterRollout, error) {
+	return network.routerUpdates.startRollout(options)
+}
+
+// CancelRouterRollout stops a running rollout. Routers which are already restarting are allowed to finish.
+func (network *Network) CancelRouterRollout(id string) (*RouterRollout, error) {
+	return network.routerUpdates.cancelRollout(id)
+}
+
+func (network *Network) GetRouterRollout(id string) (*RouterRollout, bool) {
+	return network.routerUpdates.getRollout(id)
+}
+
+// GetRouterRollouts returns the most recent rollouts run by this controller, oldest first
+func (network *Network) GetRouterRollouts() []*RouterRollout {
+	return network.routerUpdates.getRollouts()
+}
+
+// AcceptRouterUpdateStatus handles router update progress reported by a router
+func (network *Network) AcceptRouterUpdateStatus(routerId string, status *ctrl_msg.RouterUpdateStatus) {
+	network.routerUpdates.acceptStatus(routerId, status)
+}
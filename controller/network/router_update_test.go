@@ -0,0 +1,109 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/foundation/v2/versions"
+	"github.com/openziti/transport/v2/tcp"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouterRollouts(t *testing.T) {
+	ctx := model.NewTestContext(t)
+	defer ctx.Cleanup()
+
+	req := require.New(t)
+
+	config := newTestConfig(ctx)
+	defer close(config.closeNotify)
+
+	network, err := NewNetwork(config, ctx)
+	req.NoError(err)
+
+	_, err = network.StartRouterRollout(RouterRolloutOptions{})
+	req.Error(err, "no routers are connected")
+
+	req.Error(network.SetRouterRelease(&RouterRelease{Version: "v1.7.0"}))
+	req.Error(network.SetRouterRelease(&RouterRelease{
+		Version: "v1.7.0",
+		Artifacts: []*RouterReleaseArtifact{
+			{Os: "linux", Arch: "amd64", Url: "https://example.com/ziti", Sha256: "abc"},
+			{Os: "linux", Arch: "amd64", Url: "https://example.com/ziti2", Sha256: "def"},
+		},
+	}))
+	req.Nil(network.GetRouterRelease())
+
+	transportAddr, err := tcp.AddressParser{}.Parse("tcp:0.0.0.0:0")
+	req.NoError(err)
+
+	r0 := model.NewRouterForTest("r0", "", transportAddr, nil, 0, false)
+	r0.VersionInfo = &versions.VersionInfo{Version: "v1.7.0", OS: "linux", Arch: "amd64"}
+	network.Router.MarkConnected(r0)
+
+	r1 := model.NewRouterForTest("r1", "", transportAddr, nil, 0, false)
+	r1.VersionInfo = &versions.VersionInfo{Version: "v1.6.0", OS: "windows", Arch: "arm64"}
+	network.Router.MarkConnected(r1)
+
+	_, err = network.StartRouterRollout(RouterRolloutOptions{})
+	req.Error(err, "no release set")
+
+	req.NoError(network.SetRouterRelease(&RouterRelease{
+		Version: "v1.7.0",
+		Artifacts: []*RouterReleaseArtifact{
+			{Os: "linux", Arch: "amd64", Url: "https://example.com/ziti", Sha256: "abc"},
+		},
+	}))
+
+	// r0 is already running the release, and there's no artifact for r1
+	rollout, err := network.StartRouterRollout(RouterRolloutOptions{})
+	req.NoError(err)
+	req.Equal(RouterRolloutRunning, rollout.State)
+	req.Equal(1, rollout.Options.BatchSize)
+	req.Len(rollout.Routers, 2)
+
+	req.Eventually(func() bool {
+		current, found := network.GetRouterRollout(rollout.Id)
+		return found && current.State != RouterRolloutRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rollout, _ = network.GetRouterRollout(rollout.Id)
+	req.Equal(RouterRolloutFailed, rollout.State)
+	req.NotNil(rollout.FinishedAt)
+	req.Equal(1, rollout.Failures)
+	req.Equal(RouterUpdateSkipped, rollout.Routers[0].State)
+	req.Equal("v1.7.0", rollout.Routers[0].PreviousVersion)
+	req.Equal(RouterUpdateFailed, rollout.Routers[1].State)
+	req.Contains(rollout.Routers[1].Error, "no artifact for windows/arm64")
+
+	_, err = network.CancelRouterRollout(rollout.Id)
+	req.Error(err, "finished rollouts can't be cancelled")
+
+	// with a failure allowed, the rollout runs to the end
+	rollout, err = network.StartRouterRollout(RouterRolloutOptions{MaxFailures: 1})
+	req.NoError(err)
+
+	req.Eventually(func() bool {
+		current, found := network.GetRouterRollout(rollout.Id)
+		return found && current.State == RouterRolloutCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+
+	req.Len(network.GetRouterRollouts(), 2)
+}
@@ -0,0 +1,210 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/config"
+	cmap "github.com/orcaman/concurrent-map/v2"
+)
+
+const (
+	CircuitBreakerClosed   = "closed"
+	CircuitBreakerOpen     = "open"
+	CircuitBreakerHalfOpen = "half-open"
+)
+
+// serviceCircuitBreakers track terminator failures per service. Once a service has FailureThreshold consecutive
+// terminator failures, with no more than Window between the first and the last, its breaker opens and dials are
+// rejected without selecting a terminator or routing. After OpenDuration, a single trial dial is let through. If it
+// succeeds the breaker closes, otherwise it opens again.
+//
+// Breaker state is kept in memory, by each controller, for the circuits it creates.
+type serviceCircuitBreakers struct {
+	config   *config.NetworkConfig
+	breakers cmap.ConcurrentMap[string, *serviceCircuitBreaker]
+}
+
+func newServiceCircuitBreakers(config *config.NetworkConfig) *serviceCircuitBreakers {
+	return &serviceCircuitBreakers{
+		config:   config,
+		breakers: cmap.New[*serviceCircuitBreaker](),
+	}
+}
+
+func (self *serviceCircuitBreakers) enabled() bool {
+	return self.config.ServiceCircuitBreaker.FailureThreshold > 0
+}
+
+// allow returns an error if dials to the given service should be rejected
+func (self *serviceCircuitBreakers) allow(serviceId string) CircuitError {
+	if !self.enabled() {
+		return nil
+	}
+
+	breaker, found := self.breakers.Get(serviceId)
+	if !found {
+		return nil
+	}
+
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	now := time.Now()
+	openDuration := self.config.ServiceCircuitBreaker.OpenDuration
+
+	switch breaker.state {
+	case CircuitBreakerOpen:
+		if now.Sub(breaker.openedAt) < openDuration {
+			return newCircuitErrorf(CircuitFailureServiceCircuitBreakerOpen,
+				"circuit breaker open for service %v, dials rejected until %v", serviceId, breaker.openedAt.Add(openDuration).Format(time.RFC3339))
+		}
+		breaker.state = CircuitBreakerHalfOpen
+		breaker.trialStartedAt = now
+		pfxlog.Logger().WithField("serviceId", serviceId).Info("service circuit breaker half-open, allowing trial dial")
+	case CircuitBreakerHalfOpen:
+		// if a trial dial didn't report back, for example because it failed for reasons unrelated to terminators,
+		// allow another one
+		if now.Sub(breaker.trialStartedAt) < openDuration {
+			return newCircuitErrorf(CircuitFailureServiceCircuitBreakerOpen,
+				"circuit breaker open for service %v, trial dial in progress", serviceId)
+		}
+		breaker.trialStartedAt = now
+	}
+
+	return nil
+}
+
+func (self *serviceCircuitBreakers) recordFailure(serviceId string) {
+	if !self.enabled() {
+		return
+	}
+
+	breaker := self.breakers.Upsert(serviceId, nil, func(exist bool, valueInMap *serviceCircuitBreaker, _ *serviceCircuitBreaker) *serviceCircuitBreaker {
+		if exist {
+			return valueInMap
+		}
+		return &serviceCircuitBreaker{state: CircuitBreakerClosed}
+	})
+
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	now := time.Now()
+	cfg := &self.config.ServiceCircuitBreaker
+
+	switch breaker.state {
+	case CircuitBreakerHalfOpen:
+		breaker.open(serviceId, now, "trial dial failed")
+	case CircuitBreakerClosed:
+		if breaker.consecutiveFailures == 0 || now.Sub(breaker.firstFailure) > cfg.Window {
+			breaker.consecutiveFailures = 1
+			breaker.firstFailure = now
+		} else {
+			breaker.consecutiveFailures++
+		}
+
+		if breaker.consecutiveFailures >= cfg.FailureThreshold {
+			breaker.open(serviceId, now, "failure threshold reached")
+		}
+	}
+}
+
+func (self *serviceCircuitBreakers) recordSuccess(serviceId string) {
+	if !self.enabled() {
+		return
+	}
+
+	breaker, found := self.breakers.Get(serviceId)
+	if !found {
+		return
+	}
+
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	if breaker.state != CircuitBreakerClosed {
+		pfxlog.Logger().WithField("serviceId", serviceId).Info("service circuit breaker closed")
+	}
+	breaker.state = CircuitBreakerClosed
+	breaker.consecutiveFailures = 0
+}
+
+// getState returns the breaker state for the given service, or an empty string if circuit breakers are disabled
+func (self *serviceCircuitBreakers) getState(serviceId string) string {
+	if !self.enabled() {
+		return ""
+	}
+
+	breaker, found := self.breakers.Get(serviceId)
+	if !found {
+		return CircuitBreakerClosed
+	}
+
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	if breaker.state == CircuitBreakerOpen && time.Since(breaker.openedAt) >= self.config.ServiceCircuitBreaker.OpenDuration {
+		return CircuitBreakerHalfOpen
+	}
+	return breaker.state
+}
+
+func (self *serviceCircuitBreakers) remove(serviceId string) {
+	self.breakers.Remove(serviceId)
+}
+
+type serviceCircuitBreaker struct {
+	sync.Mutex
+	state               string
+	consecutiveFailures uint32
+	firstFailure        time.Time
+	openedAt            time.Time
+	trialStartedAt      time.Time
+}
+
+func (self *serviceCircuitBreaker) open(serviceId string, now time.Time, reason string) {
+	pfxlog.Logger().WithField("serviceId", serviceId).
+		WithField("consecutiveFailures", self.consecutiveFailures).
+		WithField("reason", reason).
+		Warn("service circuit breaker opened")
+
+	self.state = CircuitBreakerOpen
+	self.openedAt = now
+	self.consecutiveFailures = 0
+}
+
+// isTerminatorFailure returns true if the cause indicates that the terminator, or the application behind it, failed
+func (cause CircuitFailureCause) isTerminatorFailure() bool {
+	switch cause {
+	case CircuitFailureRouterErrInvalidTerminator,
+		CircuitFailureRouterErrMisconfiguredTerminator,
+		CircuitFailureRouterErrDialTimedOut,
+		CircuitFailureRouterErrDialConnRefused:
+		return true
+	}
+	return false
+}
+
+// GetServiceCircuitBreakerState returns the state of the circuit breaker for the given service, or an empty string
+// if service circuit breakers aren't enabled
+func (network *Network) GetServiceCircuitBreakerState(serviceId string) string {
+	return network.serviceCircuitBreakers.getState(serviceId)
+}
@@ -0,0 +1,97 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/controller/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceCircuitBreaker(t *testing.T) {
+	req := require.New(t)
+
+	options := config.DefaultNetworkConfig()
+	breakers := newServiceCircuitBreakers(options)
+
+	// disabled by default
+	for i := 0; i < 10; i++ {
+		breakers.recordFailure("svc")
+	}
+	req.Nil(breakers.allow("svc"))
+	req.Equal("", breakers.getState("svc"))
+
+	options.ServiceCircuitBreaker.FailureThreshold = 3
+	options.ServiceCircuitBreaker.OpenDuration = 50 * time.Millisecond
+	req.Equal(CircuitBreakerClosed, breakers.getState("svc"))
+
+	// a success resets the failure count
+	breakers.recordFailure("svc")
+	breakers.recordFailure("svc")
+	breakers.recordSuccess("svc")
+	breakers.recordFailure("svc")
+	req.Nil(breakers.allow("svc"))
+
+	breakers.recordFailure("svc")
+	breakers.recordFailure("svc")
+	req.Equal(CircuitBreakerOpen, breakers.getState("svc"))
+
+	err := breakers.allow("svc")
+	req.NotNil(err)
+	req.Equal(CircuitFailureServiceCircuitBreakerOpen, err.Cause())
+
+	// other services aren't affected
+	req.Nil(breakers.allow("other"))
+
+	// after the open duration, one trial dial is allowed
+	time.Sleep(60 * time.Millisecond)
+	req.Equal(CircuitBreakerHalfOpen, breakers.getState("svc"))
+	req.Nil(breakers.allow("svc"))
+	req.NotNil(breakers.allow("svc"))
+
+	// a failed trial opens the breaker again
+	breakers.recordFailure("svc")
+	req.Equal(CircuitBreakerOpen, breakers.getState("svc"))
+	req.NotNil(breakers.allow("svc"))
+
+	// a successful trial closes it
+	time.Sleep(60 * time.Millisecond)
+	req.Nil(breakers.allow("svc"))
+	breakers.recordSuccess("svc")
+	req.Equal(CircuitBreakerClosed, breakers.getState("svc"))
+	req.Nil(breakers.allow("svc"))
+}
+
+func TestServiceCircuitBreakerWindow(t *testing.T) {
+	req := require.New(t)
+
+	options := config.DefaultNetworkConfig()
+	options.ServiceCircuitBreaker.FailureThreshold = 2
+	options.ServiceCircuitBreaker.Window = 20 * time.Millisecond
+	breakers := newServiceCircuitBreakers(options)
+
+	// failures spread out further than the window don't open the breaker
+	breakers.recordFailure("svc")
+	time.Sleep(30 * time.Millisecond)
+	breakers.recordFailure("svc")
+	req.Equal(CircuitBreakerClosed, breakers.getState("svc"))
+
+	breakers.recordFailure("svc")
+	req.Equal(CircuitBreakerOpen, breakers.getState("svc"))
+}
@@ -37,10 +37,10 @@ func (network *Network) smart() {
 	}
 }
 
-func (network *Network) calculateCircuitCost(path *model.Path) int64 {
+func (network *Network) calculateCircuitCost(path *model.Path, costF model.LinkCostFunction) int64 {
 	var cost int64
 	for _, l := range path.Links {
-		cost += l.GetCost()
+		cost += costF.GetLinkCost(l)
 	}
 	for _, cachedRouter := range path.Nodes {
 		if currentRouter := network.GetConnectedRouter(cachedRouter.Id); currentRouter != nil {
@@ -70,7 +70,7 @@ func (network *Network) getRerouteCandidates() []*newCircuitPath {
 	circuitCosts := make(map[string]int64)
 	var orderedCircuits []string
 	for _, circuit := range circuits {
-		circuitCosts[circuit.Id] = network.calculateCircuitCost(circuit.Path)
+		circuitCosts[circuit.Id] = network.calculateCircuitCost(circuit.Path, network.getLinkCostFunction(circuit.ServiceId))
 		orderedCircuits = append(orderedCircuits, circuit.Id)
 	}
 
@@ -94,15 +94,23 @@ func (network *Network) getRerouteCandidates() []*newCircuitPath {
 		ceiling = int(network.options.Smart.RerouteCap)
 	}
 	log.Tracef("smart reroute ceiling [%d]", ceiling)
+	now := time.Now()
 	for _, circuitId := range orderedCircuits {
+		if count >= ceiling {
+			break
+		}
 		if circuit, found := network.GetCircuit(circuitId); found {
-			if updatedPath, err := network.UpdatePath(circuit.Path); err == nil {
+			if network.isInRerouteCooldown(circuit, now) {
+				log.Tracef("skipping [c/%s], path changed within cooldown", circuit.Id)
+				continue
+			}
+			if updatedPath, err := network.UpdateCircuitPath(circuit); err == nil {
 				pathChanged := !updatedPath.EqualPath(circuit.Path)
 				oldCost := circuitCosts[circuitId]
-				newCost := updatedPath.Cost(minRouterCost)
+				newCost := updatedPath.CostWith(minRouterCost, network.getLinkCostFunction(circuit.ServiceId))
 				costDelta := oldCost - newCost
 				log.Tracef("old cost: %v, new cost: %v, delta: %v", oldCost, newCost, costDelta)
-				if count < ceiling && pathChanged && costDelta >= int64(network.options.Smart.MinCostDelta) {
+				if pathChanged && network.isSignificantImprovement(oldCost, costDelta) {
 					count++
 					candidates = append(candidates, &newCircuitPath{
 						circuit: circuit,
@@ -117,6 +125,22 @@ func (network *Network) getRerouteCandidates() []*newCircuitPath {
 	return candidates
 }
 
+// isInRerouteCooldown returns true if the circuit's path changed too recently for it to be moved again
+func (network *Network) isInRerouteCooldown(circuit *model.Circuit, now time.Time) bool {
+	cooldown := network.options.Smart.CircuitCooldown
+	return cooldown > 0 && now.Sub(circuit.UpdatedAt) < cooldown
+}
+
+// isSignificantImprovement returns true if lowering a circuit's cost by costDelta is worth moving the circuit. The
+// delta must meet the configured minimum cost delta and, if configured, the minimum fractional improvement
+func (network *Network) isSignificantImprovement(oldCost, costDelta int64) bool {
+	if costDelta < int64(network.options.Smart.MinCostDelta) {
+		return false
+	}
+	minImprovement := network.options.Smart.MinImprovement
+	return minImprovement <= 0 || float64(costDelta) >= float64(minImprovement)*float64(oldCost)
+}
+
 type newCircuitPath struct {
 	circuit *model.Circuit
 	path    *model.Path
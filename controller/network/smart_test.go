@@ -1,6 +1,7 @@
 package network
 
 import (
+	"github.com/openziti/ziti/controller/config"
 	"github.com/openziti/ziti/controller/model"
 	"testing"
 	"time"
@@ -123,3 +124,26 @@ func TestSmartRerouteMinCostDelta(t *testing.T) {
 	assert.Equal(t, "l0", candidate.path.Links[0].Id)
 	assert.Equal(t, "l2", candidate.path.Links[1].Id)
 }
+
+func TestSmartRerouteThresholds(t *testing.T) {
+	network := &Network{options: config.DefaultNetworkConfig()}
+	network.options.Smart.MinCostDelta = 15
+
+	assert.False(t, network.isSignificantImprovement(100, 14))
+	assert.True(t, network.isSignificantImprovement(100, 15))
+
+	network.options.Smart.MinImprovement = 0.25
+	assert.False(t, network.isSignificantImprovement(100, 24))
+	assert.True(t, network.isSignificantImprovement(100, 25))
+	assert.False(t, network.isSignificantImprovement(40, 14))
+
+	now := time.Now()
+	circuit := &model.Circuit{UpdatedAt: now.Add(-time.Minute)}
+	assert.False(t, network.isInRerouteCooldown(circuit, now))
+
+	network.options.Smart.CircuitCooldown = 5 * time.Minute
+	assert.True(t, network.isInRerouteCooldown(circuit, now))
+
+	circuit.UpdatedAt = now.Add(-10 * time.Minute)
+	assert.False(t, network.isInRerouteCooldown(circuit, now))
+}
@@ -0,0 +1,39 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package network
+
+import "github.com/openziti/ziti/controller/xt"
+
+// getTerminatorCostDecay returns the cost decay to use for terminators of the given service. Service overrides may be
+// keyed by either service id or service name. Returns nil if no decay is configured, in which case strategies use
+// their built-in decay.
+func (network *Network) getTerminatorCostDecay(serviceId string) *xt.CostDecay {
+	decayConfig := &network.options.TerminatorCostDecay
+
+	if serviceId != "" && len(decayConfig.ServiceOverrides) > 0 {
+		if override, found := decayConfig.ServiceOverrides[serviceId]; found {
+			return override
+		}
+		if svc, _ := network.Service.Read(serviceId); svc != nil {
+			if override, found := decayConfig.ServiceOverrides[svc.Name]; found {
+				return override
+			}
+		}
+	}
+
+	return decayConfig.Default
+}
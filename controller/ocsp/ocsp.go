@@ -0,0 +1,67 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package ocsp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultResponseTtl = time.Minute
+	MinResponseTtl     = time.Second
+
+	ContentTypeRequest  = "application/ocsp-request"
+	ContentTypeResponse = "application/ocsp-response"
+
+	// maxRequestSize bounds the size of POSTed requests. OCSP requests for a single certificate are small
+	maxRequestSize = 10 * 1024
+)
+
+// Config defines how the OCSP responder answers requests
+type Config struct {
+	// ResponseTtl is how long responses are valid for. Clients, including routers, may cache responses up to this long,
+	// so it bounds how long it takes for a revocation to be seen
+	ResponseTtl time.Duration
+}
+
+// LoadConfig parses the options of the ocsp api binding. Example:
+//
+//	apis:
+//	  - binding: ocsp
+//	    options:
+//	      responseTtl: 1m
+func LoadConfig(options map[interface{}]interface{}) (*Config, error) {
+	result := &Config{
+		ResponseTtl: DefaultResponseTtl,
+	}
+
+	if value, found := options["responseTtl"]; found {
+		ttl, err := time.ParseDuration(fmt.Sprintf("%v", value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid ocsp api responseTtl [%v]", value)
+		}
+		if ttl < MinResponseTtl {
+			return nil, errors.Errorf("invalid ocsp api responseTtl [%v], must be at least %v", value, MinResponseTtl)
+		}
+		result.ResponseTtl = ttl
+	}
+
+	return result, nil
+}
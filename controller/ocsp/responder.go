@@ -0,0 +1,172 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package ocsp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/common/cert"
+	"golang.org/x/crypto/ocsp"
+)
+
+// Status is the revocation status of a certificate, as reported by a StatusSource
+type Status struct {
+	// Status is one of ocsp.Good, ocsp.Revoked or ocsp.Unknown
+	Status           int
+	RevokedAt        time.Time
+	RevocationReason int
+}
+
+// StatusSource looks up the revocation status of certificates issued by the controller
+type StatusSource interface {
+	GetStatus(serial *big.Int) (*Status, error)
+}
+
+// Responder is an OCSP responder (RFC 6960) for certificates issued by the controller's signing certificate. Requests
+// may be sent either as a POST or as a GET with the base64 encoded request as the last path element. Responses are
+// signed by the signing certificate itself, so clients don't need an additional trust anchor.
+type Responder struct {
+	config *Config
+	signer cert.Signer
+	source StatusSource
+}
+
+func NewResponder(config *Config, signer cert.Signer, source StatusSource) *Responder {
+	return &Responder{
+		config: config,
+		signer: signer,
+		source: source,
+	}
+}
+
+func (self *Responder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log := pfxlog.Logger().WithField("remote", r.RemoteAddr)
+
+	reqBytes, err := self.readRequest(r)
+	if err != nil {
+		log.WithError(err).Debug("unable to read ocsp request")
+		self.writeResponse(w, ocsp.MalformedRequestErrorResponse, 0)
+		return
+	}
+
+	req, err := ocsp.ParseRequest(reqBytes)
+	if err != nil {
+		log.WithError(err).Debug("unable to parse ocsp request")
+		self.writeResponse(w, ocsp.MalformedRequestErrorResponse, 0)
+		return
+	}
+
+	issuer := self.signer.Cert()
+	if !isIssuer(req, issuer) {
+		log.WithField("serial", req.SerialNumber.String()).Debug("ocsp request for certificate not issued by this controller")
+		self.writeResponse(w, ocsp.UnauthorizedErrorResponse, 0)
+		return
+	}
+
+	status, err := self.source.GetStatus(req.SerialNumber)
+	if err != nil {
+		log.WithError(err).WithField("serial", req.SerialNumber.String()).Error("unable to determine certificate status")
+		self.writeResponse(w, ocsp.InternalErrorErrorResponse, 0)
+		return
+	}
+
+	now := time.Now().Truncate(time.Second)
+	template := ocsp.Response{
+		Status:           status.Status,
+		SerialNumber:     req.SerialNumber,
+		ThisUpdate:       now,
+		NextUpdate:       now.Add(self.config.ResponseTtl),
+		RevokedAt:        status.RevokedAt,
+		RevocationReason: status.RevocationReason,
+		IssuerHash:       req.HashAlgorithm,
+	}
+
+	resp, err := ocsp.CreateResponse(issuer, issuer, template, self.signer.Signer())
+	if err != nil {
+		log.WithError(err).Error("unable to create ocsp response")
+		self.writeResponse(w, ocsp.InternalErrorErrorResponse, 0)
+		return
+	}
+
+	self.writeResponse(w, resp, self.config.ResponseTtl)
+}
+
+func (self *Responder) readRequest(r *http.Request) ([]byte, error) {
+	switch r.Method {
+	case http.MethodPost:
+		return io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	case http.MethodGet:
+		// base64 may contain '/', which must be escaped in the request, so split the path before unescaping it
+		escapedPath := r.URL.EscapedPath()
+		encoded, err := url.PathUnescape(escapedPath[strings.LastIndex(escapedPath, "/")+1:])
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	default:
+		return nil, http.ErrNotSupported
+	}
+}
+
+func (self *Responder) writeResponse(w http.ResponseWriter, resp []byte, ttl time.Duration) {
+	w.Header().Set("Content-Type", ContentTypeResponse)
+	if ttl > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d, public, no-transform, must-revalidate", int64(ttl.Seconds())))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(resp); err != nil {
+		pfxlog.Logger().WithError(err).Debug("unable to write ocsp response")
+	}
+}
+
+// isIssuer returns true if the request identifies the given certificate as the issuer, by hashes of its subject and
+// public key
+func isIssuer(req *ocsp.Request, issuer *x509.Certificate) bool {
+	if issuer == nil || !req.HashAlgorithm.Available() {
+		return false
+	}
+
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return false
+	}
+
+	return bytes.Equal(hash(req.HashAlgorithm, issuer.RawSubject), req.IssuerNameHash) &&
+		bytes.Equal(hash(req.HashAlgorithm, spki.PublicKey.RightAlign()), req.IssuerKeyHash)
+}
+
+func hash(alg crypto.Hash, data []byte) []byte {
+	h := alg.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
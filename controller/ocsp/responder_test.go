@@ -0,0 +1,183 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package ocsp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/common/cert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type testStatusSource map[string]*Status
+
+func (self testStatusSource) GetStatus(serial *big.Int) (*Status, error) {
+	if status, found := self[serial.String()]; found {
+		return status, nil
+	}
+	return &Status{Status: ocsp.Unknown}, nil
+}
+
+func newTestCa(t *testing.T, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	caCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return caCert, key
+}
+
+func newTestLeaf(t *testing.T, signer cert.Signer) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	csrDer, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "leaf"}}, key)
+	require.NoError(t, err)
+	csr, err := x509.ParseCertificateRequest(csrDer)
+	require.NoError(t, err)
+
+	der, err := signer.SignCsr(csr, nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return leaf
+}
+
+func TestResponder(t *testing.T) {
+	req := require.New(t)
+
+	caCert, caKey := newTestCa(t, "ca")
+	signer := cert.NewClientSigner(caCert, caKey)
+	signer.OcspServers = []string{"https://ctrl.example.com/ocsp"}
+
+	good := newTestLeaf(t, signer)
+	revoked := newTestLeaf(t, signer)
+	unknown := newTestLeaf(t, signer)
+	req.Equal(signer.OcspServers, good.OCSPServer)
+	req.Equal(1, good.SerialNumber.Sign())
+
+	revokedAt := time.Now().Add(-time.Minute).Truncate(time.Second).UTC()
+	source := testStatusSource{
+		good.SerialNumber.String():    {Status: ocsp.Good},
+		revoked.SerialNumber.String(): {Status: ocsp.Revoked, RevokedAt: revokedAt, RevocationReason: ocsp.CertificateHold},
+	}
+
+	server := httptest.NewServer(NewResponder(&Config{ResponseTtl: time.Minute}, signer, source))
+	defer server.Close()
+
+	post := func(leaf, issuer *x509.Certificate) []byte {
+		ocspReq, err := ocsp.CreateRequest(leaf, issuer, nil)
+		req.NoError(err)
+		resp, err := http.Post(server.URL+"/ocsp", ContentTypeRequest, bytes.NewReader(ocspReq))
+		req.NoError(err)
+		defer func() { _ = resp.Body.Close() }()
+		req.Equal(ContentTypeResponse, resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		req.NoError(err)
+		return body
+	}
+
+	t.Run("good", func(t *testing.T) {
+		resp, err := ocsp.ParseResponseForCert(post(good, caCert), good, caCert)
+		req.NoError(err)
+		req.Equal(ocsp.Good, resp.Status)
+		req.True(resp.NextUpdate.After(resp.ThisUpdate))
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		resp, err := ocsp.ParseResponseForCert(post(revoked, caCert), revoked, caCert)
+		req.NoError(err)
+		req.Equal(ocsp.Revoked, resp.Status)
+		req.Equal(ocsp.CertificateHold, resp.RevocationReason)
+		req.True(revokedAt.Equal(resp.RevokedAt))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		resp, err := ocsp.ParseResponseForCert(post(unknown, caCert), unknown, caCert)
+		req.NoError(err)
+		req.Equal(ocsp.Unknown, resp.Status)
+	})
+
+	t.Run("other issuer is unauthorized", func(t *testing.T) {
+		otherCa, otherKey := newTestCa(t, "other")
+		other := newTestLeaf(t, cert.NewClientSigner(otherCa, otherKey))
+		_, err := ocsp.ParseResponseForCert(post(other, otherCa), other, otherCa)
+		var responseErr ocsp.ResponseError
+		req.ErrorAs(err, &responseErr)
+		req.Equal(ocsp.Unauthorized, responseErr.Status)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		ocspReq, err := ocsp.CreateRequest(good, caCert, nil)
+		req.NoError(err)
+		resp, err := http.Get(server.URL + "/ocsp/" + url.PathEscape(base64.StdEncoding.EncodeToString(ocspReq)))
+		req.NoError(err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		req.NoError(err)
+
+		ocspResp, err := ocsp.ParseResponseForCert(body, good, caCert)
+		req.NoError(err)
+		req.Equal(ocsp.Good, ocspResp.Status)
+	})
+
+	t.Run("get with escaped slashes", func(t *testing.T) {
+		payload := []byte{0xff, 0xff, 0xff, 0x01}
+		encoded := base64.StdEncoding.EncodeToString(payload)
+		req.Contains(encoded, "/")
+
+		r := httptest.NewRequest(http.MethodGet, "/ocsp/"+url.PathEscape(encoded), nil)
+		decoded, err := (&Responder{}).readRequest(r)
+		req.NoError(err)
+		req.Equal(payload, decoded)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/ocsp", ContentTypeRequest, bytes.NewReader([]byte("junk")))
+		req.NoError(err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		req.NoError(err)
+		req.Equal(ocsp.MalformedRequestErrorResponse, body)
+	})
+}
@@ -0,0 +1,290 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package ocsp
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	nfpem "github.com/openziti/foundation/v2/pem"
+	"github.com/openziti/storage/ast"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/model"
+	"go.etcd.io/bbolt"
+	"golang.org/x/crypto/ocsp"
+)
+
+// pruneInterval is how often index entries for expired certificates are removed
+const pruneInterval = time.Hour
+
+type Env interface {
+	GetDb() boltz.Db
+	GetStores() *db.Stores
+	GetManagers() *model.Managers
+}
+
+type certKind int
+
+const (
+	certKindAuthenticator certKind = iota
+	certKindEdgeRouter
+	certKindApiSessionCert
+)
+
+type indexEntry struct {
+	kind        certKind
+	fingerprint string
+	notAfter    time.Time
+	revokedAt   *time.Time
+}
+
+// ModelStatusSource determines certificate status from the entities which hold certificates issued by the controller:
+// cert authenticators, edge routers and api session certificates. OCSP requests only identify certificates by serial
+// number, so an index of serial to certificate fingerprint is kept. Entries are added as certificates are stored and are
+// kept after the holding entity is removed, so removal can be reported as revocation. A certificate is good as long as
+// an entity still holds it, and is on hold while the owning identity is disabled.
+//
+// Certificates which were removed before the controller started, and api session certificates for JWT based sessions,
+// which aren't stored, are reported as unknown.
+type ModelStatusSource struct {
+	env       Env
+	lock      sync.Mutex
+	index     map[string][]*indexEntry
+	lastPrune time.Time
+}
+
+func NewModelStatusSource(env Env) (*ModelStatusSource, error) {
+	result := &ModelStatusSource{
+		env:       env,
+		index:     map[string][]*indexEntry{},
+		lastPrune: time.Now(),
+	}
+
+	stores := env.GetStores()
+	stores.Authenticator.AddEntityEventListenerF(result.authenticatorChanged, boltz.EntityCreatedAsync, boltz.EntityUpdatedAsync)
+	stores.EdgeRouter.AddEntityEventListenerF(result.edgeRouterChanged, boltz.EntityCreatedAsync, boltz.EntityUpdatedAsync)
+	stores.ApiSessionCertificate.AddEntityEventListenerF(result.apiSessionCertChanged, boltz.EntityCreatedAsync)
+
+	err := env.GetDb().View(func(tx *bbolt.Tx) error {
+		for cursor := stores.Authenticator.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
+			if entity, found, err := stores.Authenticator.FindById(tx, string(cursor.Current())); err != nil {
+				return err
+			} else if found {
+				result.authenticatorChanged(entity)
+			}
+		}
+
+		for cursor := stores.EdgeRouter.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
+			if entity, found, err := stores.EdgeRouter.FindById(tx, string(cursor.Current())); err != nil {
+				return err
+			} else if found {
+				result.edgeRouterChanged(entity)
+			}
+		}
+
+		for cursor := stores.ApiSessionCertificate.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
+			if entity, found, err := stores.ApiSessionCertificate.FindById(tx, string(cursor.Current())); err != nil {
+				return err
+			} else if found {
+				result.apiSessionCertChanged(entity)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (self *ModelStatusSource) authenticatorChanged(entity *db.Authenticator) {
+	if authCert := entity.ToCert(); authCert != nil && authCert.Pem != "" {
+		self.add(certKindAuthenticator, authCert.Fingerprint, authCert.Pem)
+	}
+}
+
+func (self *ModelStatusSource) edgeRouterChanged(entity *db.EdgeRouter) {
+	if entity.CertPem != nil && entity.Fingerprint != nil {
+		self.add(certKindEdgeRouter, *entity.Fingerprint, *entity.CertPem)
+	}
+}
+
+func (self *ModelStatusSource) apiSessionCertChanged(entity *db.ApiSessionCertificate) {
+	if entity.PEM != "" {
+		self.add(certKindApiSessionCert, entity.Fingerprint, entity.PEM)
+	}
+}
+
+func (self *ModelStatusSource) add(kind certKind, fingerprint string, certPem string) {
+	certs := nfpem.PemBytesToCertificates([]byte(certPem))
+	if len(certs) == 0 || fingerprint == "" {
+		return
+	}
+
+	leaf := certs[0]
+	key := leaf.SerialNumber.String()
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, entry := range self.index[key] {
+		if entry.fingerprint == fingerprint {
+			return
+		}
+	}
+
+	self.index[key] = append(self.index[key], &indexEntry{
+		kind:        kind,
+		fingerprint: fingerprint,
+		notAfter:    leaf.NotAfter,
+	})
+
+	if time.Since(self.lastPrune) > pruneInterval {
+		self.pruneExpired()
+	}
+}
+
+func (self *ModelStatusSource) pruneExpired() {
+	now := time.Now()
+	for key, entries := range self.index {
+		var current []*indexEntry
+		for _, entry := range entries {
+			if entry.notAfter.After(now) {
+				current = append(current, entry)
+			}
+		}
+		if len(current) == 0 {
+			delete(self.index, key)
+		} else {
+			self.index[key] = current
+		}
+	}
+	self.lastPrune = now
+}
+
+func (self *ModelStatusSource) getEntries(serial *big.Int) []*indexEntry {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	entries := self.index[serial.String()]
+	result := make([]*indexEntry, len(entries))
+	copy(result, entries)
+	return result
+}
+
+func (self *ModelStatusSource) GetStatus(serial *big.Int) (*Status, error) {
+	entries := self.getEntries(serial)
+	if len(entries) == 0 {
+		return &Status{Status: ocsp.Unknown}, nil
+	}
+
+	// serials issued by older controllers were small and may collide, so the certificate is good if any of the
+	// certificates with this serial are still held
+	var hold *Status
+	var revoked []*indexEntry
+	for _, entry := range entries {
+		held, disabledAt, err := self.isHeld(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if !held {
+			revoked = append(revoked, entry)
+		} else if disabledAt == nil {
+			return &Status{Status: ocsp.Good}, nil
+		} else if hold == nil {
+			hold = &Status{
+				Status:           ocsp.Revoked,
+				RevokedAt:        *disabledAt,
+				RevocationReason: ocsp.CertificateHold,
+			}
+		}
+	}
+
+	if hold != nil {
+		return hold, nil
+	}
+
+	return &Status{
+		Status:           ocsp.Revoked,
+		RevokedAt:        self.markRevoked(revoked[0]),
+		RevocationReason: ocsp.Unspecified,
+	}, nil
+}
+
+// markRevoked records when the certificate was first seen to be no longer held, which is used as its revocation time
+func (self *ModelStatusSource) markRevoked(entry *indexEntry) time.Time {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if entry.revokedAt == nil {
+		now := time.Now()
+		entry.revokedAt = &now
+	}
+	return *entry.revokedAt
+}
+
+// isHeld returns true if an entity still holds the certificate. If the certificate belongs to a disabled identity,
+// the time the identity was disabled is also returned
+func (self *ModelStatusSource) isHeld(entry *indexEntry) (bool, *time.Time, error) {
+	managers := self.env.GetManagers()
+
+	switch entry.kind {
+	case certKindAuthenticator:
+		authenticator, err := managers.Authenticator.ReadByFingerprint(entry.fingerprint)
+		if err != nil || authenticator == nil {
+			return false, nil, err
+		}
+		identity, err := managers.Identity.Read(authenticator.IdentityId)
+		if err != nil {
+			if boltz.IsErrNotFoundErr(err) {
+				return false, nil, nil
+			}
+			return false, nil, err
+		}
+		if identity.Disabled {
+			disabledAt := time.Now()
+			if identity.DisabledAt != nil {
+				disabledAt = *identity.DisabledAt
+			}
+			return true, &disabledAt, nil
+		}
+		return true, nil, nil
+
+	case certKindEdgeRouter:
+		edgeRouter, err := managers.EdgeRouter.ReadOneByFingerprint(entry.fingerprint)
+		return edgeRouter != nil, nil, err
+
+	case certKindApiSessionCert:
+		var held bool
+		err := self.env.GetDb().View(func(tx *bbolt.Tx) error {
+			query := fmt.Sprintf(`%s = "%s" limit 1`, db.FieldApiSessionCertificateFingerprint, entry.fingerprint)
+			ids, _, err := self.env.GetStores().ApiSessionCertificate.QueryIds(tx, query)
+			held = len(ids) > 0
+			return err
+		})
+		return held, nil, err
+	}
+
+	pfxlog.Logger().Errorf("unhandled certificate kind %d", entry.kind)
+	return false, nil, nil
+}
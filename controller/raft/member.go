@@ -249,3 +249,67 @@ func (self *Controller) ForwardToAddr(addr string, req protobufs.TypedMessage) e
 
 	return errors.Errorf("unexpected response type %v", result.ContentType)
 }
+
+// WaitForPeerAdded blocks until the local node has applied a cluster configuration containing a member at the
+// given address with the given suffrage, or until the timeout expires
+func (self *Controller) WaitForPeerAdded(addr string, isVoter bool, timeout time.Duration) error {
+	return self.waitForConfiguration(fmt.Sprintf("peer at %s to be added", addr), timeout, func(cfg raft.Configuration) bool {
+		for _, srv := range cfg.Servers {
+			if string(srv.Address) == addr {
+				return (srv.Suffrage == raft.Voter) == isVoter
+			}
+		}
+		return false
+	})
+}
+
+// WaitForPeerRemoved blocks until the local node has applied a cluster configuration which no longer contains the
+// given member, or until the timeout expires
+func (self *Controller) WaitForPeerRemoved(id string, timeout time.Duration) error {
+	return self.waitForConfiguration(fmt.Sprintf("peer %s to be removed", id), timeout, func(cfg raft.Configuration) bool {
+		for _, srv := range cfg.Servers {
+			if string(srv.ID) == id {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WaitForLeaderChange blocks until the local node sees a leader other than the given previous leader. If a target
+// id is given, the new leader must have that id.
+func (self *Controller) WaitForLeaderChange(previousLeaderId, targetId string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, leaderId := self.GetRaft().LeaderWithID()
+		if leaderId != "" && string(leaderId) != previousLeaderId && (targetId == "" || string(leaderId) == targetId) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("timed out waiting for leadership transfer, current leader: '%s'", leaderId)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (self *Controller) waitForConfiguration(desc string, timeout time.Duration, f func(cfg raft.Configuration) bool) error {
+	r := self.GetRaft()
+	deadline := time.Now().Add(timeout)
+	for {
+		configFuture := r.GetConfiguration()
+		if err := configFuture.Error(); err != nil {
+			return errors.Wrap(err, "failed to get raft configuration")
+		}
+
+		// the latest configuration may not be committed yet, so also wait for it to be applied locally
+		if f(configFuture.Configuration()) && r.AppliedIndex() >= configFuture.Index() {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.Errorf("timed out waiting for %s", desc)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package raft
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+)
+
+type testFsm struct{}
+
+func (self testFsm) Apply(*raft.Log) interface{} {
+	return nil
+}
+
+func (self testFsm) Snapshot() (raft.FSMSnapshot, error) {
+	return nil, nil
+}
+
+func (self testFsm) Restore(snapshot io.ReadCloser) error {
+	return snapshot.Close()
+}
+
+// newTestRaftController starts a single node, in memory raft cluster and waits for it to become leader
+func newTestRaftController(t *testing.T) (*Controller, raft.ServerAddress) {
+	req := require.New(t)
+
+	conf := raft.DefaultConfig()
+	conf.LocalID = "ctrl1"
+	conf.HeartbeatTimeout = 50 * time.Millisecond
+	conf.ElectionTimeout = 50 * time.Millisecond
+	conf.LeaderLeaseTimeout = 50 * time.Millisecond
+	conf.Logger = hclog.NewNullLogger()
+
+	addr, transport := raft.NewInmemTransport("")
+	store := raft.NewInmemStore()
+	r, err := raft.NewRaft(conf, testFsm{}, store, store, raft.NewInmemSnapshotStore(), transport)
+	req.NoError(err)
+
+	t.Cleanup(func() {
+		_ = r.Shutdown().Error()
+	})
+
+	req.NoError(r.BootstrapCluster(raft.Configuration{
+		Servers: []raft.Server{{ID: conf.LocalID, Address: addr, Suffrage: raft.Voter}},
+	}).Error())
+
+	select {
+	case isLeader := <-r.LeaderCh():
+		req.True(isLeader)
+	case <-time.After(5 * time.Second):
+		req.FailNow("timed out waiting for leadership")
+	}
+
+	return &Controller{Raft: r}, addr
+}
+
+func TestWaitForPeerChanges(t *testing.T) {
+	ctrl, addr := newTestRaftController(t)
+
+	t.Run("existing voters are found", func(t *testing.T) {
+		req := require.New(t)
+		req.NoError(ctrl.WaitForPeerAdded(string(addr), true, time.Second))
+	})
+
+	t.Run("suffrage must match", func(t *testing.T) {
+		req := require.New(t)
+		err := ctrl.WaitForPeerAdded(string(addr), false, 200*time.Millisecond)
+		req.EqualError(err, "timed out waiting for peer at "+string(addr)+" to be added")
+	})
+
+	t.Run("added non-voters are found", func(t *testing.T) {
+		req := require.New(t)
+		req.NoError(ctrl.GetRaft().AddNonvoter("ctrl2", "ctrl2-addr", 0, time.Second).Error())
+		req.NoError(ctrl.WaitForPeerAdded("ctrl2-addr", false, time.Second))
+	})
+
+	t.Run("missing peers time out", func(t *testing.T) {
+		req := require.New(t)
+		err := ctrl.WaitForPeerAdded("ctrl3-addr", true, 200*time.Millisecond)
+		req.EqualError(err, "timed out waiting for peer at ctrl3-addr to be added")
+	})
+
+	t.Run("removed peers are found", func(t *testing.T) {
+		req := require.New(t)
+		req.NoError(ctrl.GetRaft().RemoveServer("ctrl2", 0, time.Second).Error())
+		req.NoError(ctrl.WaitForPeerRemoved("ctrl2", time.Second))
+	})
+
+	t.Run("peers still present time out", func(t *testing.T) {
+		req := require.New(t)
+		err := ctrl.WaitForPeerRemoved("ctrl1", 200*time.Millisecond)
+		req.EqualError(err, "timed out waiting for peer ctrl1 to be removed")
+	})
+}
+
+func TestWaitForLeaderChange(t *testing.T) {
+	ctrl, _ := newTestRaftController(t)
+
+	t.Run("a leader other than the previous leader is found", func(t *testing.T) {
+		req := require.New(t)
+		req.NoError(ctrl.WaitForLeaderChange("", "", time.Second))
+		req.NoError(ctrl.WaitForLeaderChange("ctrl0", "ctrl1", time.Second))
+	})
+
+	t.Run("an unchanged leader times out", func(t *testing.T) {
+		req := require.New(t)
+		err := ctrl.WaitForLeaderChange("ctrl1", "", 200*time.Millisecond)
+		req.EqualError(err, "timed out waiting for leadership transfer, current leader: 'ctrl1'")
+	})
+
+	t.Run("a leader other than the target times out", func(t *testing.T) {
+		req := require.New(t)
+		err := ctrl.WaitForLeaderChange("ctrl0", "ctrl2", 200*time.Millisecond)
+		req.EqualError(err, "timed out waiting for leadership transfer, current leader: 'ctrl1'")
+	})
+}
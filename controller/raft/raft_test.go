@@ -0,0 +1,232 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package raft
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/identity"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/common/pb/cmd_pb"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/command"
+	"github.com/openziti/ziti/controller/peermsg"
+	"github.com/openziti/ziti/controller/raft/mesh"
+	"github.com/stretchr/testify/require"
+)
+
+type testCommand struct {
+	value string
+}
+
+func (self *testCommand) Apply(boltz.MutateContext) error {
+	return nil
+}
+
+func (self *testCommand) GetChangeContext() *change.Context {
+	return change.New()
+}
+
+func (self *testCommand) Encode() ([]byte, error) {
+	return []byte(self.value), nil
+}
+
+// recordingFsm records the entries applied to it and reports applied indexes to the node's index tracker, as the
+// real fsm does
+type recordingFsm struct {
+	testFsm
+	lock         sync.Mutex
+	applied      []string
+	indexTracker IndexTracker
+}
+
+func (self *recordingFsm) Apply(log *raft.Log) interface{} {
+	self.lock.Lock()
+	self.applied = append(self.applied, string(log.Data))
+	self.lock.Unlock()
+	self.indexTracker.NotifyOfIndex(log.Index)
+	return nil
+}
+
+func (self *recordingFsm) getApplied() []string {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return append([]string(nil), self.applied...)
+}
+
+// testMesh connects a follower to the leader over the given channel
+type testMesh struct {
+	mesh.Mesh
+	leaderCh channel.Channel
+}
+
+func (self *testMesh) IsReadOnly() bool {
+	return false
+}
+
+func (self *testMesh) GetOrConnectPeer(address string, _ time.Duration) (*mesh.Peer, error) {
+	return &mesh.Peer{Address: address, Channel: self.leaderCh}, nil
+}
+
+type testRaftNode struct {
+	ctrl *Controller
+	fsm  *recordingFsm
+}
+
+// newTestRaftCluster starts an in memory raft cluster with the given number of nodes and waits for a leader to be
+// elected. The leader is returned first.
+func newTestRaftCluster(t *testing.T, count int) []*testRaftNode {
+	req := require.New(t)
+
+	var nodes []*testRaftNode
+	var transports []*raft.InmemTransport
+	var servers []raft.Server
+	for i := 0; i < count; i++ {
+		addr, transport := raft.NewInmemTransport("")
+		transports = append(transports, transport)
+		servers = append(servers, raft.Server{
+			ID:       raft.ServerID("ctrl" + string(rune('1'+i))),
+			Address:  addr,
+			Suffrage: raft.Voter,
+		})
+	}
+
+	for i, transport := range transports {
+		for j, other := range transports {
+			if i != j {
+				transport.Connect(other.LocalAddr(), other)
+			}
+		}
+	}
+
+	for i, transport := range transports {
+		conf := raft.DefaultConfig()
+		conf.LocalID = servers[i].ID
+		conf.HeartbeatTimeout = 50 * time.Millisecond
+		conf.ElectionTimeout = 50 * time.Millisecond
+		conf.LeaderLeaseTimeout = 50 * time.Millisecond
+		conf.Logger = hclog.NewNullLogger()
+
+		indexTracker := NewIndexTracker()
+		fsm := &recordingFsm{indexTracker: indexTracker}
+		store := raft.NewInmemStore()
+		r, err := raft.NewRaft(conf, fsm, store, store, raft.NewInmemSnapshotStore(), transport)
+		req.NoError(err)
+
+		t.Cleanup(func() {
+			_ = r.Shutdown().Error()
+		})
+
+		req.NoError(r.BootstrapCluster(raft.Configuration{Servers: servers}).Error())
+
+		nodes = append(nodes, &testRaftNode{
+			ctrl: &Controller{
+				Raft:               r,
+				indexTracker:       indexTracker,
+				commandRateLimiter: command.NoOpRateLimiter{},
+				errorMappers:       map[string]func(map[string]any) error{},
+			},
+			fsm: fsm,
+		})
+	}
+
+	req.Eventually(func() bool {
+		for i, node := range nodes {
+			if node.ctrl.IsLeader() {
+				nodes[0], nodes[i] = nodes[i], nodes[0]
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for _, node := range nodes[1:] {
+		req.Eventually(func() bool {
+			return node.ctrl.GetLeaderAddr() != ""
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	return nodes
+}
+
+// connectToLeader creates a channel from a follower to the leader. The leader applies forwarded commands the same
+// way the peer command handler does.
+func connectToLeader(t *testing.T, leader *Controller) channel.Channel {
+	req := require.New(t)
+
+	leaderConn, followerConn := net.Pipe()
+
+	// close the connections rather than the channels, so the channels shut themselves down from their rx goroutines
+	t.Cleanup(func() {
+		_ = leaderConn.Close()
+		_ = followerConn.Close()
+	})
+
+	bindHandler := channel.BindHandlerF(func(binding channel.Binding) error {
+		binding.AddReceiveHandlerF(int32(cmd_pb.ContentType_NewLogEntryType), func(m *channel.Message, ch channel.Channel) {
+			var reply *channel.Message
+			if idx, err := leader.ApplyEncodedCommand(m.Body); err != nil {
+				reply = channel.NewMessage(int32(cmd_pb.ContentType_ErrorResponseType), []byte(err.Error()))
+			} else {
+				reply = channel.NewMessage(int32(cmd_pb.ContentType_SuccessResponseType), nil)
+				reply.PutUint64Header(int32(peermsg.HeaderIndex), idx)
+			}
+			reply.ReplyTo(m)
+			_ = reply.WithTimeout(time.Second).SendAndWaitForWire(ch)
+		})
+		return nil
+	})
+
+	leaderChErr := make(chan error, 1)
+	go func() {
+		listener := channel.NewExistingConnListener(&identity.TokenId{Token: "leader"}, leaderConn, nil)
+		_, err := channel.NewChannel("leader", listener, bindHandler, nil)
+		leaderChErr <- err
+	}()
+
+	dialer := channel.NewExistingConnDialer(&identity.TokenId{Token: "follower"}, followerConn, nil)
+	ch, err := channel.NewChannel("follower", dialer, nil, channel.DefaultOptions())
+	req.NoError(err)
+	req.NoError(<-leaderChErr)
+	return ch
+}
+
+func TestDispatchForwardsToLeader(t *testing.T) {
+	req := require.New(t)
+
+	nodes := newTestRaftCluster(t, 2)
+	leader, follower := nodes[0], nodes[1]
+	req.False(follower.ctrl.IsLeader())
+
+	leader.ctrl.Mesh = &testMesh{}
+	follower.ctrl.Mesh = &testMesh{leaderCh: connectToLeader(t, leader.ctrl)}
+
+	req.NoError(follower.ctrl.Dispatch(&testCommand{value: "from-follower"}))
+
+	// the leader replies once it has applied the command, and dispatch then waits for the follower to apply it
+	req.Contains(leader.fsm.getApplied(), "from-follower")
+	req.Contains(follower.fsm.getApplied(), "from-follower")
+
+	req.NoError(leader.ctrl.Dispatch(&testCommand{value: "from-leader"}))
+	req.Contains(leader.fsm.getApplied(), "from-leader")
+}
@@ -0,0 +1,123 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/transport/v2/tls"
+	"github.com/openziti/ziti/controller/command"
+	"github.com/openziti/ziti/controller/config"
+	"github.com/pkg/errors"
+)
+
+// ReloadConfig re-reads the controller config file and applies changes to the sections which can be safely
+// changed at runtime: event handlers, trace settings, the tls handshake timeout and rate limiter, health check
+// timings and the edge API rate limiter. Changes to any other section are ignored until the controller is restarted.
+//
+// Sections are applied in order and the names of the sections which were changed are returned. If a section
+// fails to apply, the sections applied before it remain in effect.
+func (c *Controller) ReloadConfig() ([]string, error) {
+	if !c.reloadEnabled.Load() {
+		return nil, errors.New("controller has not finished starting, unable to reload config")
+	}
+
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	log := pfxlog.Logger()
+
+	cfg, err := c.config.LoadReloadableConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load controller config")
+	}
+
+	var reloaded []string
+
+	if !reflect.DeepEqual(cfg.Events, c.eventsConfig) {
+		if err = c.eventDispatcher.ReloadEventHandlers(getEventHandlerConfigs(cfg.Events)); err != nil {
+			return reloaded, errors.Wrap(err, "unable to reload event handlers")
+		}
+		c.eventsConfig = cfg.Events
+		reloaded = append(reloaded, "events")
+	}
+
+	if cfg.TracePath != c.config.Trace.Path {
+		if err = c.reloadTrace(cfg.TracePath); err != nil {
+			return reloaded, errors.Wrap(err, "unable to reload trace settings")
+		}
+		reloaded = append(reloaded, "trace")
+	}
+
+	if cfg.HealthChecks != c.config.HealthChecks {
+		if err = c.reloadHealthChecks(&cfg.HealthChecks); err != nil {
+			return reloaded, errors.Wrap(err, "unable to reload health checks")
+		}
+		c.config.HealthChecks = cfg.HealthChecks
+		reloaded = append(reloaded, "healthChecks")
+	}
+
+	if cfg.TlsHandshakeTimeout != c.config.TlsHandshakeTimeout || cfg.TlsHandshakeRateLimiter != c.config.TlsHandshakeRateLimiter {
+		c.reloadTls(cfg)
+		reloaded = append(reloaded, "tls")
+	}
+
+	if cfg.ApiRateLimiter != nil && c.config.Edge != nil && c.env != nil && c.env.ApiRateLimiter.Reload(*cfg.ApiRateLimiter) {
+		c.config.Edge.ApiRateLimiter = *cfg.ApiRateLimiter
+		reloaded = append(reloaded, "apiRateLimiter")
+	}
+
+	log.WithField("sections", reloaded).Info("controller config reloaded")
+	return reloaded, nil
+}
+
+// reloadTrace replaces the trace handler used for new router connections. Existing router connections continue
+// to trace to the previous handler, if any, until they reconnect.
+func (c *Controller) reloadTrace(path string) error {
+	var handler *channel.TraceHandler
+
+	if path != "" {
+		var err error
+		if handler, err = config.NewTraceHandler(path, c.config.Id.Token); err != nil {
+			return err
+		}
+		for _, x := range c.xctrls {
+			c.addXctrlTraceDecoders(handler, x)
+		}
+	}
+
+	if c.ctrlAccepter != nil {
+		c.ctrlAccepter.SetTraceHandler(handler)
+	}
+
+	c.config.Trace.Path = path
+	c.config.Trace.Handler = handler
+	return nil
+}
+
+func (c *Controller) reloadTls(cfg *config.ReloadableConfig) {
+	tls.SetSharedListenerHandshakeTimeout(cfg.TlsHandshakeTimeout)
+	c.config.TlsHandshakeTimeout = cfg.TlsHandshakeTimeout
+
+	if cfg.TlsHandshakeRateLimiter != c.config.TlsHandshakeRateLimiter {
+		// handshakes already in progress complete against the previous limiter, which is cleaned up on shutdown
+		tls.SetSharedListenerRateLimiter(command.NewAdaptiveRateLimitTracker(cfg.TlsHandshakeRateLimiter, c.metricsRegistry, c.shutdownC))
+		c.config.TlsHandshakeRateLimiter = cfg.TlsHandshakeRateLimiter
+	}
+}
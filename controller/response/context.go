@@ -52,6 +52,12 @@ type RequestContext struct {
 	ResponseWriter    http.ResponseWriter
 	Request           *http.Request
 
+	// The scope a scoped admin is restricted to, empty for other identities
+	AdminScope string
+
+	// A filter which list requests are restricted to, in addition to the request's own filter
+	ListFilter string
+
 	entityId    string
 	entitySubId string
 	Body        []byte
@@ -59,6 +59,13 @@ type RouterDetail struct {
 	// Required: true
 	Disabled *bool `json:"disabled"`
 
+	// drain deadline
+	// Format: date-time
+	DrainDeadline *strfmt.DateTime `json:"drainDeadline,omitempty"`
+
+	// draining
+	Draining bool `json:"draining,omitempty"`
+
 	// fingerprint
 	// Required: true
 	Fingerprint *string `json:"fingerprint"`
@@ -98,6 +105,10 @@ func (m *RouterDetail) UnmarshalJSON(raw []byte) error {
 
 		Disabled *bool `json:"disabled"`
 
+		DrainDeadline *strfmt.DateTime `json:"drainDeadline,omitempty"`
+
+		Draining bool `json:"draining,omitempty"`
+
 		Fingerprint *string `json:"fingerprint"`
 
 		Interfaces []*Interface `json:"interfaces"`
@@ -120,6 +131,10 @@ func (m *RouterDetail) UnmarshalJSON(raw []byte) error {
 
 	m.Disabled = dataAO1.Disabled
 
+	m.DrainDeadline = dataAO1.DrainDeadline
+
+	m.Draining = dataAO1.Draining
+
 	m.Fingerprint = dataAO1.Fingerprint
 
 	m.Interfaces = dataAO1.Interfaces
@@ -151,6 +166,10 @@ func (m RouterDetail) MarshalJSON() ([]byte, error) {
 
 		Disabled *bool `json:"disabled"`
 
+		DrainDeadline *strfmt.DateTime `json:"drainDeadline,omitempty"`
+
+		Draining bool `json:"draining,omitempty"`
+
 		Fingerprint *string `json:"fingerprint"`
 
 		Interfaces []*Interface `json:"interfaces"`
@@ -170,6 +189,10 @@ func (m RouterDetail) MarshalJSON() ([]byte, error) {
 
 	dataAO1.Disabled = m.Disabled
 
+	dataAO1.DrainDeadline = m.DrainDeadline
+
+	dataAO1.Draining = m.Draining
+
 	dataAO1.Fingerprint = m.Fingerprint
 
 	dataAO1.Interfaces = m.Interfaces
@@ -211,6 +234,10 @@ func (m *RouterDetail) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateDrainDeadline(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateFingerprint(formats); err != nil {
 		res = append(res, err)
 	}
@@ -276,6 +303,19 @@ func (m *RouterDetail) validateDisabled(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *RouterDetail) validateDrainDeadline(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.DrainDeadline) { // not required
+		return nil
+	}
+
+	if err := validate.FormatOf("drainDeadline", "body", "date-time", m.DrainDeadline.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (m *RouterDetail) validateFingerprint(formats strfmt.Registry) error {
 
 	if err := validate.Required("fingerprint", "body", m.Fingerprint); err != nil {
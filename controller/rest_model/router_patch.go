@@ -51,6 +51,17 @@ type RouterPatch struct {
 	// disabled
 	Disabled *bool `json:"disabled,omitempty"`
 
+	// drain deadline
+	// Format: date-time
+	DrainDeadline *strfmt.DateTime `json:"drainDeadline,omitempty"`
+
+	// Sets the drain deadline this many seconds from now, using the controller's clock. Takes precedence over drainDeadline
+	// Minimum: 1
+	DrainTimeoutSeconds *int64 `json:"drainTimeoutSeconds,omitempty"`
+
+	// draining
+	Draining *bool `json:"draining,omitempty"`
+
 	// fingerprint
 	Fingerprint *string `json:"fingerprint,omitempty"`
 
@@ -72,6 +83,14 @@ func (m *RouterPatch) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateDrainDeadline(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateDrainTimeoutSeconds(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateTags(formats); err != nil {
 		res = append(res, err)
 	}
@@ -98,6 +117,30 @@ func (m *RouterPatch) validateCost(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *RouterPatch) validateDrainDeadline(formats strfmt.Registry) error {
+	if swag.IsZero(m.DrainDeadline) { // not required
+		return nil
+	}
+
+	if err := validate.FormatOf("drainDeadline", "body", "date-time", m.DrainDeadline.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *RouterPatch) validateDrainTimeoutSeconds(formats strfmt.Registry) error {
+	if swag.IsZero(m.DrainTimeoutSeconds) { // not required
+		return nil
+	}
+
+	if err := validate.MinimumInt("drainTimeoutSeconds", "body", *m.DrainTimeoutSeconds, 1, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (m *RouterPatch) validateTags(formats strfmt.Registry) error {
 	if swag.IsZero(m.Tags) { // not required
 		return nil
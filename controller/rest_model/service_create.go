@@ -43,6 +43,12 @@ import (
 // swagger:model serviceCreate
 type ServiceCreate struct {
 
+	// max circuit lifetime millis
+	MaxCircuitLifetimeMillis int64 `json:"maxCircuitLifetimeMillis,omitempty"`
+
+	// max idle time millis
+	MaxIdleTimeMillis int64 `json:"maxIdleTimeMillis,omitempty"`
+
 	// name
 	// Required: true
 	Name *string `json:"name"`
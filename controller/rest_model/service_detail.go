@@ -44,6 +44,15 @@ import (
 type ServiceDetail struct {
 	BaseEntity
 
+	// circuit breaker state
+	CircuitBreakerState string `json:"circuitBreakerState,omitempty"`
+
+	// max circuit lifetime millis
+	MaxCircuitLifetimeMillis int64 `json:"maxCircuitLifetimeMillis,omitempty"`
+
+	// max idle time millis
+	MaxIdleTimeMillis int64 `json:"maxIdleTimeMillis,omitempty"`
+
 	// name
 	// Required: true
 	Name *string `json:"name"`
@@ -64,6 +73,12 @@ func (m *ServiceDetail) UnmarshalJSON(raw []byte) error {
 
 	// AO1
 	var dataAO1 struct {
+		CircuitBreakerState string `json:"circuitBreakerState,omitempty"`
+
+		MaxCircuitLifetimeMillis int64 `json:"maxCircuitLifetimeMillis,omitempty"`
+
+		MaxIdleTimeMillis int64 `json:"maxIdleTimeMillis,omitempty"`
+
 		Name *string `json:"name"`
 
 		TerminatorStrategy *string `json:"terminatorStrategy"`
@@ -72,6 +87,12 @@ func (m *ServiceDetail) UnmarshalJSON(raw []byte) error {
 		return err
 	}
 
+	m.CircuitBreakerState = dataAO1.CircuitBreakerState
+
+	m.MaxCircuitLifetimeMillis = dataAO1.MaxCircuitLifetimeMillis
+
+	m.MaxIdleTimeMillis = dataAO1.MaxIdleTimeMillis
+
 	m.Name = dataAO1.Name
 
 	m.TerminatorStrategy = dataAO1.TerminatorStrategy
@@ -89,11 +110,23 @@ func (m ServiceDetail) MarshalJSON() ([]byte, error) {
 	}
 	_parts = append(_parts, aO0)
 	var dataAO1 struct {
+		CircuitBreakerState string `json:"circuitBreakerState,omitempty"`
+
+		MaxCircuitLifetimeMillis int64 `json:"maxCircuitLifetimeMillis,omitempty"`
+
+		MaxIdleTimeMillis int64 `json:"maxIdleTimeMillis,omitempty"`
+
 		Name *string `json:"name"`
 
 		TerminatorStrategy *string `json:"terminatorStrategy"`
 	}
 
+	dataAO1.CircuitBreakerState = m.CircuitBreakerState
+
+	dataAO1.MaxCircuitLifetimeMillis = m.MaxCircuitLifetimeMillis
+
+	dataAO1.MaxIdleTimeMillis = m.MaxIdleTimeMillis
+
 	dataAO1.Name = m.Name
 
 	dataAO1.TerminatorStrategy = m.TerminatorStrategy
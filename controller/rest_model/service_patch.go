@@ -42,6 +42,12 @@ import (
 // swagger:model servicePatch
 type ServicePatch struct {
 
+	// max circuit lifetime millis
+	MaxCircuitLifetimeMillis int64 `json:"maxCircuitLifetimeMillis,omitempty"`
+
+	// max idle time millis
+	MaxIdleTimeMillis int64 `json:"maxIdleTimeMillis,omitempty"`
+
 	// name
 	Name string `json:"name,omitempty"`
 
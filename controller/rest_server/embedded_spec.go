@@ -2195,6 +2195,14 @@ func init() {
             "disabled": {
               "type": "boolean"
             },
+            "drainDeadline": {
+              "type": "string",
+              "format": "date-time",
+              "x-nullable": true
+            },
+            "draining": {
+              "type": "boolean"
+            },
             "fingerprint": {
               "type": "string"
             },
@@ -2256,6 +2264,21 @@ func init() {
           "type": "boolean",
           "x-nullable": true
         },
+        "drainDeadline": {
+          "type": "string",
+          "format": "date-time",
+          "x-nullable": true
+        },
+        "drainTimeoutSeconds": {
+          "description": "Sets the drain deadline this many seconds from now, using the controller's clock. Takes precedence over drainDeadline",
+          "type": "integer",
+          "minimum": 1,
+          "x-nullable": true
+        },
+        "draining": {
+          "type": "boolean",
+          "x-nullable": true
+        },
         "fingerprint": {
           "type": "string",
           "x-nullable": true
@@ -2309,6 +2332,14 @@ func init() {
         "name"
       ],
       "properties": {
+        "maxCircuitLifetimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
+        "maxIdleTimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
         "name": {
           "type": "string"
         },
@@ -2333,6 +2364,17 @@ func init() {
             "terminatorStrategy"
           ],
           "properties": {
+            "circuitBreakerState": {
+              "type": "string"
+            },
+            "maxCircuitLifetimeMillis": {
+              "type": "integer",
+              "format": "int64"
+            },
+            "maxIdleTimeMillis": {
+              "type": "integer",
+              "format": "int64"
+            },
             "name": {
               "type": "string"
             },
@@ -2352,6 +2394,14 @@ func init() {
     "servicePatch": {
       "type": "object",
       "properties": {
+        "maxCircuitLifetimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
+        "maxIdleTimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
         "name": {
           "type": "string"
         },
@@ -2369,6 +2419,14 @@ func init() {
         "name"
       ],
       "properties": {
+        "maxCircuitLifetimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
+        "maxIdleTimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
         "name": {
           "type": "string"
         },
@@ -7986,6 +8044,14 @@ func init() {
             "disabled": {
               "type": "boolean"
             },
+            "drainDeadline": {
+              "type": "string",
+              "format": "date-time",
+              "x-nullable": true
+            },
+            "draining": {
+              "type": "boolean"
+            },
             "fingerprint": {
               "type": "string"
             },
@@ -8048,6 +8114,21 @@ func init() {
           "type": "boolean",
           "x-nullable": true
         },
+        "drainDeadline": {
+          "type": "string",
+          "format": "date-time",
+          "x-nullable": true
+        },
+        "drainTimeoutSeconds": {
+          "description": "Sets the drain deadline this many seconds from now, using the controller's clock. Takes precedence over drainDeadline",
+          "type": "integer",
+          "minimum": 1,
+          "x-nullable": true
+        },
+        "draining": {
+          "type": "boolean",
+          "x-nullable": true
+        },
         "fingerprint": {
           "type": "string",
           "x-nullable": true
@@ -8102,6 +8183,14 @@ func init() {
         "name"
       ],
       "properties": {
+        "maxCircuitLifetimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
+        "maxIdleTimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
         "name": {
           "type": "string"
         },
@@ -8126,6 +8215,17 @@ func init() {
             "terminatorStrategy"
           ],
           "properties": {
+            "circuitBreakerState": {
+              "type": "string"
+            },
+            "maxCircuitLifetimeMillis": {
+              "type": "integer",
+              "format": "int64"
+            },
+            "maxIdleTimeMillis": {
+              "type": "integer",
+              "format": "int64"
+            },
             "name": {
               "type": "string"
             },
@@ -8145,6 +8245,14 @@ func init() {
     "servicePatch": {
       "type": "object",
       "properties": {
+        "maxCircuitLifetimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
+        "maxIdleTimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
         "name": {
           "type": "string"
         },
@@ -8162,6 +8270,14 @@ func init() {
         "name"
       ],
       "properties": {
+        "maxCircuitLifetimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
+        "maxIdleTimeMillis": {
+          "type": "integer",
+          "format": "int64"
+        },
         "name": {
           "type": "string"
         },
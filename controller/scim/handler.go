@@ -0,0 +1,944 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package scim
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/pkg/errors"
+)
+
+const (
+	usersPath  = "/Users"
+	groupsPath = "/Groups"
+)
+
+var filterRegex = regexp.MustCompile(`^\s*([A-Za-z.]+)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+var memberFilterRegex = regexp.MustCompile(`^\s*members\s*\[\s*value\s+(?i:eq)\s+"([^"]*)"\s*\]\s*$`)
+
+type apiError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (self *apiError) Error() string {
+	return self.detail
+}
+
+func newApiError(status int, scimType string, detail string, args ...interface{}) *apiError {
+	return &apiError{
+		status:   status,
+		scimType: scimType,
+		detail:   fmt.Sprintf(detail, args...),
+	}
+}
+
+func notFoundError(resourceType, id string) *apiError {
+	return newApiError(http.StatusNotFound, "", "%s %s not found", resourceType, id)
+}
+
+// Handler implements the subset of the SCIM 2.0 protocol (RFC 7644) which identity providers use to provision
+// users and groups. Users map to identities, and groups map to role attributes: a member of a group is given the
+// role attribute named after the group, optionally prefixed. Groups have no state of their own, so a group exists
+// as long as it has members.
+type Handler struct {
+	basePath string
+	config   *Config
+	store    Store
+	lock     sync.Mutex
+}
+
+func NewHandler(basePath string, config *Config, store Store) *Handler {
+	return &Handler{
+		basePath: strings.TrimSuffix(basePath, "/"),
+		config:   config,
+		store:    store,
+	}
+}
+
+func (self *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if !self.config.isAuthorized(request.Header.Get("Authorization")) {
+		self.writeError(writer, newApiError(http.StatusUnauthorized, "", "a valid bearer token is required"))
+		return
+	}
+
+	path := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, self.basePath), "/")
+	resource, id, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	var result interface{}
+	var status int
+	var err error
+
+	switch resource {
+	case "ServiceProviderConfig":
+		result, status, err = self.getServiceProviderConfig(), http.StatusOK, nil
+	case "ResourceTypes":
+		result, status, err = self.getResourceTypes(), http.StatusOK, nil
+	case "Users":
+		result, status, err = self.serveUsers(request, id)
+	case "Groups":
+		result, status, err = self.serveGroups(request, id)
+	default:
+		err = newApiError(http.StatusNotFound, "", "unknown resource %s", resource)
+	}
+
+	if err != nil {
+		self.writeError(writer, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", ContentType)
+	writer.WriteHeader(status)
+	if result != nil {
+		if err = json.NewEncoder(writer).Encode(result); err != nil {
+			pfxlog.Logger().WithError(err).Error("unable to write scim response")
+		}
+	}
+}
+
+func (self *Handler) writeError(writer http.ResponseWriter, err error) {
+	apiErr := &apiError{}
+	if !errors.As(err, &apiErr) {
+		pfxlog.Logger().WithError(err).Error("scim request failed")
+		apiErr = newApiError(http.StatusInternalServerError, "", "%s", err.Error())
+	}
+
+	writer.Header().Set("Content-Type", ContentType)
+	writer.WriteHeader(apiErr.status)
+	_ = json.NewEncoder(writer).Encode(&Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(apiErr.status),
+		ScimType: apiErr.scimType,
+		Detail:   apiErr.detail,
+	})
+}
+
+func (self *Handler) serveUsers(request *http.Request, id string) (interface{}, int, error) {
+	switch {
+	case request.Method == http.MethodGet && id == "":
+		return self.listUsers(request)
+	case request.Method == http.MethodGet:
+		return self.getUser(id)
+	case request.Method == http.MethodPost && id == "":
+		return self.createUser(request)
+	case request.Method == http.MethodPut && id != "":
+		return self.replaceUser(request, id)
+	case request.Method == http.MethodPatch && id != "":
+		return self.patchUser(request, id)
+	case request.Method == http.MethodDelete && id != "":
+		return self.deleteUser(id)
+	}
+	return nil, 0, newApiError(http.StatusMethodNotAllowed, "", "%s not supported on %s", request.Method, request.URL.Path)
+}
+
+func (self *Handler) serveGroups(request *http.Request, id string) (interface{}, int, error) {
+	switch {
+	case request.Method == http.MethodGet && id == "":
+		return self.listGroups(request)
+	case request.Method == http.MethodGet:
+		return self.getGroup(id)
+	case request.Method == http.MethodPost && id == "":
+		return self.createGroup(request)
+	case request.Method == http.MethodPut && id != "":
+		return self.replaceGroup(request, id)
+	case request.Method == http.MethodPatch && id != "":
+		return self.patchGroup(request, id)
+	case request.Method == http.MethodDelete && id != "":
+		return self.deleteGroup(id)
+	}
+	return nil, 0, newApiError(http.StatusMethodNotAllowed, "", "%s not supported on %s", request.Method, request.URL.Path)
+}
+
+func (self *Handler) getServiceProviderConfig() interface{} {
+	supported := func(v bool) map[string]interface{} {
+		return map[string]interface{}{"supported": v}
+	}
+	return map[string]interface{}{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 1000},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication using a static bearer token",
+		}},
+		"meta": &Meta{ResourceType: "ServiceProviderConfig", Location: self.basePath + "/ServiceProviderConfig"},
+	}
+}
+
+func (self *Handler) getResourceTypes() interface{} {
+	resourceType := func(name, endpoint, schema string) map[string]interface{} {
+		return map[string]interface{}{
+			"schemas":  []string{SchemaResourceType},
+			"id":       name,
+			"name":     name,
+			"endpoint": endpoint,
+			"schema":   schema,
+			"meta":     &Meta{ResourceType: "ResourceType", Location: self.basePath + "/ResourceTypes/" + name},
+		}
+	}
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: 2,
+		StartIndex:   1,
+		ItemsPerPage: 2,
+		Resources: []interface{}{
+			resourceType("User", usersPath, SchemaUser),
+			resourceType("Group", groupsPath, SchemaGroup),
+		},
+	}
+}
+
+func (self *Handler) toUser(identity *Identity) *User {
+	active := !identity.Disabled
+	createdAt := identity.CreatedAt
+	updatedAt := identity.UpdatedAt
+
+	user := &User{
+		Schemas:     []string{SchemaUser},
+		Id:          identity.Id,
+		ExternalId:  identity.ScimExternalId,
+		UserName:    identity.Name,
+		DisplayName: identity.DisplayName,
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      &createdAt,
+			LastModified: &updatedAt,
+			Location:     self.basePath + usersPath + "/" + identity.Id,
+		},
+	}
+
+	for _, attr := range identity.RoleAttributes {
+		if name, ok := self.groupName(attr); ok {
+			user.Groups = append(user.Groups, &Member{
+				Value:   groupId(name),
+				Display: name,
+				Ref:     self.basePath + groupsPath + "/" + groupId(name),
+			})
+		}
+	}
+
+	return user
+}
+
+func (self *Handler) listUsers(request *http.Request) (interface{}, int, error) {
+	identities, err := self.store.ListIdentities()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if filter := request.URL.Query().Get("filter"); filter != "" {
+		attr, value, err := parseFilter(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var getter func(identity *Identity) string
+		switch strings.ToLower(attr) {
+		case "id":
+			getter = func(identity *Identity) string { return identity.Id }
+		case "username":
+			getter = func(identity *Identity) string { return identity.Name }
+		case "externalid":
+			getter = func(identity *Identity) string { return identity.ScimExternalId }
+		case "displayname":
+			getter = func(identity *Identity) string { return identity.DisplayName }
+		default:
+			return nil, 0, newApiError(http.StatusBadRequest, "invalidFilter", "filtering users on %s is not supported", attr)
+		}
+
+		identities = slices.DeleteFunc(identities, func(identity *Identity) bool {
+			// userName is case-insensitive per RFC 7643
+			return !strings.EqualFold(getter(identity), value)
+		})
+	}
+
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].Name < identities[j].Name
+	})
+
+	var resources []interface{}
+	for _, identity := range identities {
+		resources = append(resources, self.toUser(identity))
+	}
+
+	result, err := paginate(request, resources)
+	return result, http.StatusOK, err
+}
+
+func (self *Handler) getUser(id string) (interface{}, int, error) {
+	identity, err := self.store.GetIdentity(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if identity == nil {
+		return nil, 0, notFoundError("User", id)
+	}
+	return self.toUser(identity), http.StatusOK, nil
+}
+
+func (self *Handler) applyUser(user *User, identity *Identity) error {
+	if user.UserName == "" {
+		return newApiError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	identity.Name = user.UserName
+	identity.ScimExternalId = user.ExternalId
+	identity.DisplayName = user.DisplayName
+	if user.Active != nil {
+		identity.Disabled = !*user.Active
+	}
+	return nil
+}
+
+func (self *Handler) createUser(request *http.Request) (interface{}, int, error) {
+	user := &User{}
+	if err := decodeBody(request, user); err != nil {
+		return nil, 0, err
+	}
+
+	identity := &Identity{}
+	if err := self.applyUser(user, identity); err != nil {
+		return nil, 0, err
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	id, err := self.store.CreateIdentity(identity)
+	if errors.Is(err, ErrConflict) {
+		return nil, 0, newApiError(http.StatusConflict, "uniqueness", "an identity named %s already exists", identity.Name)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pfxlog.Logger().WithField("identityId", id).WithField("name", identity.Name).Info("identity provisioned via scim")
+	return self.getUserWithStatus(id, http.StatusCreated)
+}
+
+func (self *Handler) getUserWithStatus(id string, status int) (interface{}, int, error) {
+	result, _, err := self.getUser(id)
+	return result, status, err
+}
+
+func (self *Handler) replaceUser(request *http.Request, id string) (interface{}, int, error) {
+	user := &User{}
+	if err := decodeBody(request, user); err != nil {
+		return nil, 0, err
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	identity, err := self.store.GetIdentity(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if identity == nil {
+		return nil, 0, notFoundError("User", id)
+	}
+
+	if err = self.applyUser(user, identity); err != nil {
+		return nil, 0, err
+	}
+
+	if err = self.store.UpdateIdentity(identity); err != nil {
+		return nil, 0, err
+	}
+	return self.getUserWithStatus(id, http.StatusOK)
+}
+
+func (self *Handler) patchUser(request *http.Request, id string) (interface{}, int, error) {
+	patch := &PatchRequest{}
+	if err := decodeBody(request, patch); err != nil {
+		return nil, 0, err
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	identity, err := self.store.GetIdentity(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if identity == nil {
+		return nil, 0, notFoundError("User", id)
+	}
+
+	for _, op := range patch.Operations {
+		values := map[string]interface{}{}
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			if op.Path == "" {
+				m, ok := op.Value.(map[string]interface{})
+				if !ok {
+					return nil, 0, newApiError(http.StatusBadRequest, "invalidValue", "patch without a path requires an object value")
+				}
+				values = m
+			} else {
+				values[op.Path] = op.Value
+			}
+		case "remove":
+			if op.Path == "" {
+				return nil, 0, newApiError(http.StatusBadRequest, "noTarget", "remove requires a path")
+			}
+			values[op.Path] = nil
+		default:
+			return nil, 0, newApiError(http.StatusBadRequest, "invalidSyntax", "unsupported patch op %s", op.Op)
+		}
+
+		for path, value := range values {
+			if err = applyUserValue(identity, path, value); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	if err = self.store.UpdateIdentity(identity); err != nil {
+		return nil, 0, err
+	}
+	return self.getUserWithStatus(id, http.StatusOK)
+}
+
+func applyUserValue(identity *Identity, path string, value interface{}) error {
+	str := func() string {
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprintf("%v", value)
+	}
+
+	switch strings.ToLower(path) {
+	case "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		identity.Disabled = !active
+	case "username":
+		if str() == "" {
+			return newApiError(http.StatusBadRequest, "invalidValue", "userName may not be removed")
+		}
+		identity.Name = str()
+	case "externalid":
+		identity.ScimExternalId = str()
+	case "displayname":
+		identity.DisplayName = str()
+	default:
+		// attributes which don't map to identities, such as emails and names, are accepted and ignored
+	}
+	return nil
+}
+
+func (self *Handler) deleteUser(id string) (interface{}, int, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	identity, err := self.store.GetIdentity(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if identity == nil {
+		return nil, 0, notFoundError("User", id)
+	}
+
+	if err = self.store.DeleteIdentity(id); err != nil {
+		return nil, 0, err
+	}
+
+	pfxlog.Logger().WithField("identityId", id).WithField("name", identity.Name).Info("identity deprovisioned via scim")
+	return nil, http.StatusNoContent, nil
+}
+
+func (self *Handler) groupName(attr string) (string, bool) {
+	name, found := strings.CutPrefix(attr, self.config.GroupAttributePrefix)
+	return name, found && name != ""
+}
+
+func (self *Handler) groupAttribute(name string) string {
+	return self.config.GroupAttributePrefix + name
+}
+
+func groupId(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name))
+}
+
+func groupNameFromId(id string) (string, error) {
+	name, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil || len(name) == 0 {
+		return "", notFoundError("Group", id)
+	}
+	return string(name), nil
+}
+
+func (self *Handler) toGroup(name string, members []*Identity) *Group {
+	group := &Group{
+		Schemas:     []string{SchemaGroup},
+		Id:          groupId(name),
+		DisplayName: name,
+		Members:     []*Member{},
+		Meta: &Meta{
+			ResourceType: "Group",
+			Location:     self.basePath + groupsPath + "/" + groupId(name),
+		},
+	}
+
+	for _, member := range members {
+		group.Members = append(group.Members, &Member{
+			Value:   member.Id,
+			Display: member.Name,
+			Ref:     self.basePath + usersPath + "/" + member.Id,
+		})
+	}
+
+	return group
+}
+
+func (self *Handler) getGroupMembers(name string) ([]*Identity, error) {
+	identities, err := self.store.ListIdentities()
+	if err != nil {
+		return nil, err
+	}
+
+	attr := self.groupAttribute(name)
+	return slices.DeleteFunc(identities, func(identity *Identity) bool {
+		return !slices.Contains(identity.RoleAttributes, attr)
+	}), nil
+}
+
+func (self *Handler) listGroups(request *http.Request) (interface{}, int, error) {
+	identities, err := self.store.ListIdentities()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	groups := map[string][]*Identity{}
+	for _, identity := range identities {
+		for _, attr := range identity.RoleAttributes {
+			if name, ok := self.groupName(attr); ok {
+				groups[name] = append(groups[name], identity)
+			}
+		}
+	}
+
+	if filter := request.URL.Query().Get("filter"); filter != "" {
+		attr, value, err := parseFilter(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var name string
+		switch strings.ToLower(attr) {
+		case "displayname":
+			name = value
+		case "id":
+			if name, err = groupNameFromId(value); err != nil {
+				name = ""
+			}
+		default:
+			return nil, 0, newApiError(http.StatusBadRequest, "invalidFilter", "filtering groups on %s is not supported", attr)
+		}
+
+		filtered := map[string][]*Identity{}
+		if members, found := groups[name]; found {
+			filtered[name] = members
+		}
+		groups = filtered
+	}
+
+	var names []string
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	excludeMembers := strings.Contains(request.URL.Query().Get("excludedAttributes"), "members")
+
+	var resources []interface{}
+	for _, name := range names {
+		group := self.toGroup(name, groups[name])
+		if excludeMembers {
+			group.Members = nil
+		}
+		resources = append(resources, group)
+	}
+
+	result, err := paginate(request, resources)
+	return result, http.StatusOK, err
+}
+
+func (self *Handler) getGroup(id string) (interface{}, int, error) {
+	name, err := groupNameFromId(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	members, err := self.getGroupMembers(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return self.toGroup(name, members), http.StatusOK, nil
+}
+
+func (self *Handler) createGroup(request *http.Request) (interface{}, int, error) {
+	group := &Group{}
+	if err := decodeBody(request, group); err != nil {
+		return nil, 0, err
+	}
+
+	if group.DisplayName == "" {
+		return nil, 0, newApiError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if err := self.setMembers(group.DisplayName, memberIds(group.Members), true); err != nil {
+		return nil, 0, err
+	}
+
+	result, _, err := self.getGroup(groupId(group.DisplayName))
+	return result, http.StatusCreated, err
+}
+
+func (self *Handler) replaceGroup(request *http.Request, id string) (interface{}, int, error) {
+	name, err := groupNameFromId(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	group := &Group{}
+	if err = decodeBody(request, group); err != nil {
+		return nil, 0, err
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if group.DisplayName != "" && group.DisplayName != name {
+		if err = self.renameGroup(name, group.DisplayName); err != nil {
+			return nil, 0, err
+		}
+		name = group.DisplayName
+	}
+
+	if err = self.replaceMembers(name, memberIds(group.Members)); err != nil {
+		return nil, 0, err
+	}
+
+	return self.getGroup(groupId(name))
+}
+
+func (self *Handler) patchGroup(request *http.Request, id string) (interface{}, int, error) {
+	name, err := groupNameFromId(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	patch := &PatchRequest{}
+	if err = decodeBody(request, patch); err != nil {
+		return nil, 0, err
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, op := range patch.Operations {
+		path := strings.TrimSpace(op.Path)
+		opName := strings.ToLower(op.Op)
+
+		if match := memberFilterRegex.FindStringSubmatch(path); match != nil {
+			if opName != "remove" {
+				return nil, 0, newApiError(http.StatusBadRequest, "invalidPath", "only remove is supported for filtered member paths")
+			}
+			if err = self.setMembers(name, []string{match[1]}, false); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(path, "members"):
+			ids, err := patchMemberIds(op.Value)
+			if err != nil {
+				return nil, 0, err
+			}
+			switch opName {
+			case "add":
+				err = self.setMembers(name, ids, true)
+			case "remove":
+				if op.Value == nil {
+					err = self.replaceMembers(name, nil)
+				} else {
+					err = self.setMembers(name, ids, false)
+				}
+			case "replace":
+				err = self.replaceMembers(name, ids)
+			default:
+				err = newApiError(http.StatusBadRequest, "invalidSyntax", "unsupported patch op %s", op.Op)
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+		case strings.EqualFold(path, "displayName"):
+			newName := fmt.Sprintf("%v", op.Value)
+			if opName != "replace" || op.Value == nil || newName == "" {
+				return nil, 0, newApiError(http.StatusBadRequest, "invalidValue", "displayName may only be replaced")
+			}
+			if err = self.renameGroup(name, newName); err != nil {
+				return nil, 0, err
+			}
+			name = newName
+		case path == "":
+			values, ok := op.Value.(map[string]interface{})
+			if !ok {
+				return nil, 0, newApiError(http.StatusBadRequest, "invalidValue", "patch without a path requires an object value")
+			}
+			if value, found := values["displayName"]; found {
+				if newName := fmt.Sprintf("%v", value); newName != "" && newName != name {
+					if err = self.renameGroup(name, newName); err != nil {
+						return nil, 0, err
+					}
+					name = newName
+				}
+			}
+			if value, found := values["members"]; found {
+				ids, err := patchMemberIds(value)
+				if err != nil {
+					return nil, 0, err
+				}
+				if opName == "add" {
+					err = self.setMembers(name, ids, true)
+				} else {
+					err = self.replaceMembers(name, ids)
+				}
+				if err != nil {
+					return nil, 0, err
+				}
+			}
+		default:
+			return nil, 0, newApiError(http.StatusBadRequest, "invalidPath", "unsupported group path %s", op.Path)
+		}
+	}
+
+	return self.getGroup(groupId(name))
+}
+
+func (self *Handler) deleteGroup(id string) (interface{}, int, error) {
+	name, err := groupNameFromId(id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if err = self.replaceMembers(name, nil); err != nil {
+		return nil, 0, err
+	}
+	return nil, http.StatusNoContent, nil
+}
+
+// setMembers adds or removes the group role attribute on the given identities. Ids which don't belong to a SCIM
+// managed identity are rejected.
+func (self *Handler) setMembers(name string, ids []string, member bool) error {
+	attr := self.groupAttribute(name)
+	for _, id := range ids {
+		identity, err := self.store.GetIdentity(id)
+		if err != nil {
+			return err
+		}
+		if identity == nil {
+			return newApiError(http.StatusBadRequest, "invalidValue", "member %s is not a provisioned user", id)
+		}
+
+		hasAttr := slices.Contains(identity.RoleAttributes, attr)
+		if member == hasAttr {
+			continue
+		}
+
+		if member {
+			identity.RoleAttributes = append(slices.Clone(identity.RoleAttributes), attr)
+		} else {
+			identity.RoleAttributes = slices.DeleteFunc(slices.Clone(identity.RoleAttributes), func(s string) bool {
+				return s == attr
+			})
+		}
+
+		if err = self.store.UpdateIdentity(identity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *Handler) replaceMembers(name string, ids []string) error {
+	current, err := self.getGroupMembers(name)
+	if err != nil {
+		return err
+	}
+
+	var remove []string
+	for _, identity := range current {
+		if !slices.Contains(ids, identity.Id) {
+			remove = append(remove, identity.Id)
+		}
+	}
+
+	if err = self.setMembers(name, remove, false); err != nil {
+		return err
+	}
+	return self.setMembers(name, ids, true)
+}
+
+func (self *Handler) renameGroup(name, newName string) error {
+	members, err := self.getGroupMembers(name)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for _, member := range members {
+		ids = append(ids, member.Id)
+	}
+
+	if err = self.setMembers(newName, ids, true); err != nil {
+		return err
+	}
+	return self.setMembers(name, ids, false)
+}
+
+func memberIds(members []*Member) []string {
+	var result []string
+	for _, member := range members {
+		if member != nil && member.Value != "" {
+			result = append(result, member.Value)
+		}
+	}
+	return result
+}
+
+func patchMemberIds(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		list = []interface{}{value}
+	}
+
+	var result []string
+	for _, v := range list {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, newApiError(http.StatusBadRequest, "invalidValue", "members must be objects with a value")
+		}
+		if id, ok := m["value"].(string); ok && id != "" {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+func parseFilter(filter string) (string, string, error) {
+	match := filterRegex.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", newApiError(http.StatusBadRequest, "invalidFilter", "unsupported filter %s, only 'attribute eq \"value\"' is supported", filter)
+	}
+	value, err := strconv.Unquote(`"` + match[2] + `"`)
+	if err != nil {
+		value = match[2]
+	}
+	return match[1], value, nil
+}
+
+func parseBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		result, err := strconv.ParseBool(strings.ToLower(v))
+		if err == nil {
+			return result, nil
+		}
+	}
+	return false, newApiError(http.StatusBadRequest, "invalidValue", "invalid boolean value %v", value)
+}
+
+func decodeBody(request *http.Request, target interface{}) error {
+	if err := json.NewDecoder(request.Body).Decode(target); err != nil {
+		return newApiError(http.StatusBadRequest, "invalidSyntax", "invalid request body: %v", err)
+	}
+	return nil
+}
+
+func paginate(request *http.Request, resources []interface{}) (*ListResponse, error) {
+	startIndex := 1
+	count := len(resources)
+
+	if value := request.URL.Query().Get("startIndex"); value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, newApiError(http.StatusBadRequest, "invalidValue", "invalid startIndex %s", value)
+		}
+		if v > 1 {
+			startIndex = v
+		}
+	}
+
+	if value := request.URL.Query().Get("count"); value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, newApiError(http.StatusBadRequest, "invalidValue", "invalid count %s", value)
+		}
+		if v >= 0 {
+			count = v
+		}
+	}
+
+	result := &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		Resources:    []interface{}{},
+	}
+
+	if start := startIndex - 1; start < len(resources) {
+		end := min(start+count, len(resources))
+		result.Resources = resources[start:end]
+	}
+	result.ItemsPerPage = len(result.Resources)
+
+	return result, nil
+}
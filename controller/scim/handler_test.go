@@ -0,0 +1,200 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memStore struct {
+	identities map[string]*Identity
+	nextId     int
+}
+
+func (self *memStore) ListIdentities() ([]*Identity, error) {
+	var result []*Identity
+	for _, identity := range self.identities {
+		copied := *identity
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (self *memStore) GetIdentity(id string) (*Identity, error) {
+	if identity, found := self.identities[id]; found {
+		copied := *identity
+		copied.RoleAttributes = slices.Clone(identity.RoleAttributes)
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (self *memStore) CreateIdentity(identity *Identity) (string, error) {
+	for _, existing := range self.identities {
+		if existing.Name == identity.Name {
+			return "", ErrConflict
+		}
+	}
+	self.nextId++
+	identity.Id = fmt.Sprintf("id%d", self.nextId)
+	self.identities[identity.Id] = identity
+	return identity.Id, nil
+}
+
+func (self *memStore) UpdateIdentity(identity *Identity) error {
+	self.identities[identity.Id] = identity
+	return nil
+}
+
+func (self *memStore) DeleteIdentity(id string) error {
+	delete(self.identities, id)
+	return nil
+}
+
+type testContext struct {
+	*require.Assertions
+	handler *Handler
+	store   *memStore
+}
+
+func newTestContext(t *testing.T) *testContext {
+	store := &memStore{identities: map[string]*Identity{}}
+	return &testContext{
+		Assertions: require.New(t),
+		store:      store,
+		handler:    NewHandler("/scim/v2", &Config{Token: "secret", GroupAttributePrefix: "idp-"}, store),
+	}
+}
+
+func (self *testContext) request(method, path, body string, result interface{}) int {
+	req := httptest.NewRequest(method, "/scim/v2"+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	recorder := httptest.NewRecorder()
+	self.handler.ServeHTTP(recorder, req)
+	if result != nil && recorder.Body.Len() > 0 {
+		self.NoError(json.Unmarshal(recorder.Body.Bytes(), result))
+	}
+	return recorder.Code
+}
+
+func TestScimRequiresToken(t *testing.T) {
+	ctx := newTestContext(t)
+	req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	recorder := httptest.NewRecorder()
+	ctx.handler.ServeHTTP(recorder, req)
+	ctx.Equal(http.StatusUnauthorized, recorder.Code)
+}
+
+func TestScimUserLifecycle(t *testing.T) {
+	ctx := newTestContext(t)
+
+	user := &User{}
+	status := ctx.request(http.MethodPost, "/Users", `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"alice@example.com","externalId":"00u1","active":true}`, user)
+	ctx.Equal(http.StatusCreated, status)
+	ctx.Equal("alice@example.com", user.UserName)
+	ctx.Equal("00u1", user.ExternalId)
+	ctx.True(*user.Active)
+
+	status = ctx.request(http.MethodPost, "/Users", `{"userName":"alice@example.com"}`, nil)
+	ctx.Equal(http.StatusConflict, status)
+
+	list := &ListResponse{}
+	ctx.Equal(http.StatusOK, ctx.request(http.MethodGet, `/Users?filter=userName+eq+%22ALICE@example.com%22`, "", list))
+	ctx.Equal(1, list.TotalResults)
+
+	ctx.Equal(http.StatusOK, ctx.request(http.MethodGet, `/Users?filter=userName+eq+%22bob%22`, "", list))
+	ctx.Equal(0, list.TotalResults)
+
+	status = ctx.request(http.MethodPatch, "/Users/"+user.Id, `{"Operations":[{"op":"Replace","path":"active","value":"False"}]}`, user)
+	ctx.Equal(http.StatusOK, status)
+	ctx.False(*user.Active)
+	ctx.True(ctx.store.identities[user.Id].Disabled)
+
+	status = ctx.request(http.MethodPatch, "/Users/"+user.Id, `{"Operations":[{"op":"replace","value":{"active":true,"displayName":"Alice"}}]}`, user)
+	ctx.Equal(http.StatusOK, status)
+	ctx.True(*user.Active)
+	ctx.Equal("Alice", user.DisplayName)
+
+	ctx.Equal(http.StatusNoContent, ctx.request(http.MethodDelete, "/Users/"+user.Id, "", nil))
+	ctx.Equal(http.StatusNotFound, ctx.request(http.MethodGet, "/Users/"+user.Id, "", nil))
+}
+
+func TestScimGroupsMapToRoleAttributes(t *testing.T) {
+	ctx := newTestContext(t)
+
+	alice := &User{}
+	bob := &User{}
+	ctx.Equal(http.StatusCreated, ctx.request(http.MethodPost, "/Users", `{"userName":"alice"}`, alice))
+	ctx.Equal(http.StatusCreated, ctx.request(http.MethodPost, "/Users", `{"userName":"bob"}`, bob))
+	ctx.store.identities[bob.Id].RoleAttributes = []string{"manual"}
+
+	group := &Group{}
+	status := ctx.request(http.MethodPost, "/Groups", fmt.Sprintf(`{"displayName":"Engineering","members":[{"value":"%s"}]}`, alice.Id), group)
+	ctx.Equal(http.StatusCreated, status)
+	ctx.Equal("Engineering", group.DisplayName)
+	ctx.Len(group.Members, 1)
+	ctx.Equal([]string{"idp-Engineering"}, ctx.store.identities[alice.Id].RoleAttributes)
+
+	status = ctx.request(http.MethodPatch, "/Groups/"+group.Id, fmt.Sprintf(`{"Operations":[{"op":"add","path":"members","value":[{"value":"%s"}]}]}`, bob.Id), group)
+	ctx.Equal(http.StatusOK, status)
+	ctx.Len(group.Members, 2)
+	ctx.Equal([]string{"manual", "idp-Engineering"}, ctx.store.identities[bob.Id].RoleAttributes)
+
+	status = ctx.request(http.MethodPatch, "/Groups/"+group.Id, fmt.Sprintf(`{"Operations":[{"op":"remove","path":"members[value eq \"%s\"]"}]}`, alice.Id), group)
+	ctx.Equal(http.StatusOK, status)
+	ctx.Len(group.Members, 1)
+	ctx.Empty(ctx.store.identities[alice.Id].RoleAttributes)
+
+	status = ctx.request(http.MethodPatch, "/Groups/"+group.Id, `{"Operations":[{"op":"replace","path":"displayName","value":"Platform"}]}`, group)
+	ctx.Equal(http.StatusOK, status)
+	ctx.Equal("Platform", group.DisplayName)
+	ctx.Equal([]string{"manual", "idp-Platform"}, ctx.store.identities[bob.Id].RoleAttributes)
+
+	user := &User{}
+	ctx.Equal(http.StatusOK, ctx.request(http.MethodGet, "/Users/"+bob.Id, "", user))
+	ctx.Len(user.Groups, 1)
+	ctx.Equal("Platform", user.Groups[0].Display)
+
+	list := &ListResponse{}
+	ctx.Equal(http.StatusOK, ctx.request(http.MethodGet, "/Groups", "", list))
+	ctx.Equal(1, list.TotalResults)
+
+	ctx.Equal(http.StatusNoContent, ctx.request(http.MethodDelete, "/Groups/"+group.Id, "", nil))
+	ctx.Equal([]string{"manual"}, ctx.store.identities[bob.Id].RoleAttributes)
+}
+
+func TestScimPagination(t *testing.T) {
+	ctx := newTestContext(t)
+	for i := 0; i < 5; i++ {
+		ctx.Equal(http.StatusCreated, ctx.request(http.MethodPost, "/Users", fmt.Sprintf(`{"userName":"user%d"}`, i), nil))
+	}
+
+	list := &ListResponse{}
+	ctx.Equal(http.StatusOK, ctx.request(http.MethodGet, "/Users?startIndex=4&count=10", "", list))
+	ctx.Equal(5, list.TotalResults)
+	ctx.Equal(2, list.ItemsPerPage)
+	ctx.Equal(4, list.StartIndex)
+}
@@ -0,0 +1,187 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package scim
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+
+	ContentType = "application/scim+json"
+
+	ExternalIdSourceUserName   = "userName"
+	ExternalIdSourceExternalId = "externalId"
+
+	// TagManaged marks identities which were created through SCIM. Only these identities are visible to, and may be
+	// modified by, the SCIM API
+	TagManaged = "scimManaged"
+
+	// TagExternalId holds the IdP's id for the user
+	TagExternalId = "scimExternalId"
+
+	// TagDisplayName holds the user's display name, as provided by the IdP
+	TagDisplayName = "scimDisplayName"
+)
+
+// Config defines how the SCIM API authenticates the identity provider and how SCIM resources map to identities
+type Config struct {
+	// Token is the bearer token the identity provider must present
+	Token string
+
+	// AuthPolicy is the name of the auth policy assigned to provisioned identities. If empty, the default auth
+	// policy is used
+	AuthPolicy string
+
+	// GroupAttributePrefix is prepended to group names to form the role attribute assigned to group members
+	GroupAttributePrefix string
+
+	// ExternalIdSource selects which user field is used as the identity external id, used to match identities
+	// to external JWT claims. One of userName or externalId
+	ExternalIdSource string
+}
+
+// LoadConfig parses the options of the scim api binding. Example:
+//
+//	apis:
+//	  - binding: scim
+//	    options:
+//	      tokenFile: /etc/ziti/scim.token
+//	      authPolicy: sso-only
+//	      groupAttributePrefix: "idp-"
+//	      externalIdSource: userName
+func LoadConfig(options map[interface{}]interface{}) (*Config, error) {
+	result := &Config{
+		ExternalIdSource: ExternalIdSourceUserName,
+	}
+
+	if value, found := options["token"]; found {
+		result.Token = fmt.Sprintf("%v", value)
+	}
+
+	if value, found := options["tokenFile"]; found {
+		if result.Token != "" {
+			return nil, errors.New("invalid scim api options, only one of token and tokenFile may be set")
+		}
+		data, err := os.ReadFile(fmt.Sprintf("%v", value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read scim api tokenFile [%v]", value)
+		}
+		result.Token = strings.TrimSpace(string(data))
+	}
+
+	if result.Token == "" {
+		return nil, errors.New("invalid scim api options, a token or tokenFile is required")
+	}
+
+	if value, found := options["authPolicy"]; found {
+		result.AuthPolicy = fmt.Sprintf("%v", value)
+	}
+
+	if value, found := options["groupAttributePrefix"]; found {
+		result.GroupAttributePrefix = fmt.Sprintf("%v", value)
+	}
+
+	if value, found := options["externalIdSource"]; found {
+		result.ExternalIdSource = fmt.Sprintf("%v", value)
+		if result.ExternalIdSource != ExternalIdSourceUserName && result.ExternalIdSource != ExternalIdSourceExternalId {
+			return nil, errors.Errorf("invalid scim api externalIdSource [%v], must be one of %s or %s",
+				value, ExternalIdSourceUserName, ExternalIdSourceExternalId)
+		}
+	}
+
+	return result, nil
+}
+
+func (self *Config) isAuthorized(header string) bool {
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(self.Token)) == 1
+}
+
+type Meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+type User struct {
+	Schemas     []string  `json:"schemas"`
+	Id          string    `json:"id,omitempty"`
+	ExternalId  string    `json:"externalId,omitempty"`
+	UserName    string    `json:"userName"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Active      *bool     `json:"active,omitempty"`
+	Groups      []*Member `json:"groups,omitempty"`
+	Meta        *Meta     `json:"meta,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string  `json:"schemas"`
+	Id          string    `json:"id,omitempty"`
+	DisplayName string    `json:"displayName"`
+	Members     []*Member `json:"members,omitempty"`
+	Meta        *Meta     `json:"meta,omitempty"`
+}
+
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type PatchRequest struct {
+	Schemas    []string          `json:"schemas"`
+	Operations []*PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
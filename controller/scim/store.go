@@ -0,0 +1,250 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package scim
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/fields"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/models"
+	"github.com/pkg/errors"
+)
+
+var ErrConflict = errors.New("an identity with the given name already exists")
+
+// Identity is the subset of an identity which SCIM provisioning manages
+type Identity struct {
+	Id             string
+	Name           string
+	ExternalId     string
+	ScimExternalId string
+	DisplayName    string
+	Disabled       bool
+	RoleAttributes []string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Store provides access to SCIM managed identities. Identities which weren't created through SCIM are never
+// returned, so that an identity provider can't take over or remove them.
+type Store interface {
+	ListIdentities() ([]*Identity, error)
+	// GetIdentity returns nil if there is no SCIM managed identity with the given id
+	GetIdentity(id string) (*Identity, error)
+	// CreateIdentity returns ErrConflict if an identity with the same name already exists
+	CreateIdentity(identity *Identity) (string, error)
+	UpdateIdentity(identity *Identity) error
+	DeleteIdentity(id string) error
+}
+
+func NewModelStore(managers *model.Managers, config *Config) Store {
+	return &modelStore{
+		managers: managers,
+		config:   config,
+	}
+}
+
+type modelStore struct {
+	managers *model.Managers
+	config   *Config
+}
+
+func (self *modelStore) newChangeContext() *change.Context {
+	return change.New().
+		SetSourceType(change.SourceTypeRest).
+		SetSourceMethod("scim").
+		SetChangeAuthorType(change.AuthorTypeUnattributed).
+		SetChangeAuthorName("scim")
+}
+
+func (self *modelStore) ListIdentities() ([]*Identity, error) {
+	result, err := self.managers.Identity.BaseList(fmt.Sprintf("tags.%s = true limit none", TagManaged))
+	if err != nil {
+		return nil, err
+	}
+
+	var identities []*Identity
+	for _, entity := range result.GetEntities() {
+		identities = append(identities, fromModel(entity))
+	}
+	return identities, nil
+}
+
+func (self *modelStore) GetIdentity(id string) (*Identity, error) {
+	entity, err := self.managers.Identity.Read(id)
+	if err != nil {
+		if boltz.IsErrNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if managed, _ := entity.Tags[TagManaged].(bool); !managed {
+		return nil, nil
+	}
+	return fromModel(entity), nil
+}
+
+func (self *modelStore) CreateIdentity(identity *Identity) (string, error) {
+	if existing, err := self.managers.Identity.ReadByName(identity.Name); err == nil && existing != nil {
+		return "", ErrConflict
+	} else if err != nil && !boltz.IsErrNotFoundErr(err) {
+		return "", err
+	}
+
+	authPolicyId := db.DefaultAuthPolicyId
+	if self.config.AuthPolicy != "" {
+		result, err := self.managers.AuthPolicy.BaseList(fmt.Sprintf(`name = "%s"`, self.config.AuthPolicy))
+		if err != nil {
+			return "", err
+		}
+		if len(result.GetEntities()) == 0 {
+			return "", errors.Errorf("scim auth policy %s not found", self.config.AuthPolicy)
+		}
+		authPolicyId = result.GetEntities()[0].Id
+	}
+
+	entity := &model.Identity{
+		BaseEntity: models.BaseEntity{
+			Tags: self.toTags(identity),
+		},
+		Name:           identity.Name,
+		IdentityTypeId: db.DefaultIdentityType,
+		RoleAttributes: identity.RoleAttributes,
+		AuthPolicyId:   authPolicyId,
+		ExternalId:     self.toExternalId(identity),
+	}
+
+	ctx := self.newChangeContext()
+	if err := self.managers.Identity.Create(entity, ctx); err != nil {
+		return "", err
+	}
+
+	if identity.Disabled {
+		if err := self.managers.Identity.Disable(entity.Id, 0, ctx); err != nil {
+			return entity.Id, err
+		}
+	}
+
+	return entity.Id, nil
+}
+
+func (self *modelStore) UpdateIdentity(identity *Identity) error {
+	current, err := self.managers.Identity.Read(identity.Id)
+	if err != nil {
+		return err
+	}
+	if managed, _ := current.Tags[TagManaged].(bool); !managed {
+		return boltz.NewNotFoundError(db.EntityTypeIdentities, "id", identity.Id)
+	}
+
+	// preserve any tags added outside of scim
+	tags := map[string]interface{}{}
+	for k, v := range current.Tags {
+		if k != TagExternalId && k != TagDisplayName {
+			tags[k] = v
+		}
+	}
+	for k, v := range self.toTags(identity) {
+		tags[k] = v
+	}
+
+	ctx := self.newChangeContext()
+	entity := &model.Identity{
+		BaseEntity: models.BaseEntity{
+			Id:   identity.Id,
+			Tags: tags,
+		},
+		Name:           identity.Name,
+		RoleAttributes: identity.RoleAttributes,
+		ExternalId:     self.toExternalId(identity),
+	}
+
+	updatedFields := fields.UpdatedFieldsMap{
+		db.FieldName:               struct{}{},
+		db.FieldRoleAttributes:     struct{}{},
+		db.FieldIdentityExternalId: struct{}{},
+		boltz.FieldTags:            struct{}{},
+	}
+
+	if err = self.managers.Identity.Update(entity, updatedFields, ctx); err != nil {
+		return err
+	}
+
+	if identity.Disabled && !current.Disabled {
+		return self.managers.Identity.Disable(identity.Id, 0, ctx)
+	}
+
+	if !identity.Disabled && current.Disabled {
+		return self.managers.Identity.Enable(identity.Id, ctx)
+	}
+
+	return nil
+}
+
+func (self *modelStore) DeleteIdentity(id string) error {
+	return self.managers.Identity.Delete(id, self.newChangeContext())
+}
+
+func (self *modelStore) toTags(identity *Identity) map[string]interface{} {
+	tags := map[string]interface{}{
+		TagManaged: true,
+	}
+	if identity.ScimExternalId != "" {
+		tags[TagExternalId] = identity.ScimExternalId
+	}
+	if identity.DisplayName != "" {
+		tags[TagDisplayName] = identity.DisplayName
+	}
+	return tags
+}
+
+func (self *modelStore) toExternalId(identity *Identity) *string {
+	externalId := identity.Name
+	if self.config.ExternalIdSource == ExternalIdSourceExternalId {
+		externalId = identity.ScimExternalId
+	}
+	if externalId == "" {
+		return nil
+	}
+	return &externalId
+}
+
+func fromModel(entity *model.Identity) *Identity {
+	result := &Identity{
+		Id:             entity.Id,
+		Name:           entity.Name,
+		Disabled:       entity.Disabled,
+		RoleAttributes: entity.RoleAttributes,
+		CreatedAt:      entity.CreatedAt,
+		UpdatedAt:      entity.UpdatedAt,
+	}
+
+	if entity.ExternalId != nil {
+		result.ExternalId = *entity.ExternalId
+	}
+
+	result.ScimExternalId, _ = entity.Tags[TagExternalId].(string)
+	result.DisplayName, _ = entity.Tags[TagDisplayName].(string)
+
+	return result
+}
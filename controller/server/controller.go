@@ -46,10 +46,11 @@ type Controller struct {
 }
 
 const (
-	policyMinFreq     = 1 * time.Second
-	policyMaxFreq     = 1 * time.Hour
-	policyAppWanFreq  = 1 * time.Second
-	policySessionFreq = 5 * time.Second
+	policyMinFreq      = 1 * time.Second
+	policyMaxFreq      = 1 * time.Hour
+	policyAppWanFreq   = 1 * time.Second
+	policySessionFreq  = 5 * time.Second
+	policyScheduleFreq = 30 * time.Second
 )
 
 func NewController(host env.HostController) (*Controller, error) {
@@ -125,6 +126,7 @@ func (c *Controller) GetCtrlHandlers(binding channel.Binding) []channel.TypedRec
 		handler_edge_ctrl.NewExtendEnrollmentHandler(c.AppEnv),
 		handler_edge_ctrl.NewExtendEnrollmentVerifyHandler(c.AppEnv),
 		handler_edge_ctrl.NewConnectEventsHandler(c.AppEnv),
+		handler_edge_ctrl.NewTrustBundleHandler(c.AppEnv),
 	}
 
 	result = append(result, c.AppEnv.Broker.GetReceiveHandlers()...)
@@ -176,6 +178,7 @@ func (c *Controller) Initialize() {
 		RouterTxBufferSize:       100,
 		HelloSendTimeout:         10 * time.Second,
 		SessionChunkSize:         100,
+		ApiSessionLogSize:        10000,
 	}))
 
 	servicePolicyEnforcer := policy.NewServicePolicyEnforcer(c.AppEnv, policyAppWanFreq)
@@ -186,6 +189,14 @@ func (c *Controller) Initialize() {
 			Fatalf("could not add service policy enforcer")
 	}
 
+	scheduleEnforcer := policy.NewServicePolicyScheduleEnforcer(c.AppEnv, policyScheduleFreq)
+	if err := c.policyEngine.AddOperation(scheduleEnforcer); err != nil {
+		log.WithField("cause", err).
+			WithField("enforcerName", scheduleEnforcer.GetName()).
+			WithField("enforcerId", scheduleEnforcer.GetId()).
+			Fatalf("could not add service policy schedule enforcer")
+	}
+
 	sessionEnforcer := policy.NewSessionEnforcer(c.AppEnv, policySessionFreq, c.config.SessionTimeoutDuration())
 	if err := c.policyEngine.AddOperation(sessionEnforcer); err != nil {
 		log.WithField("cause", err).
@@ -35,7 +35,7 @@ func (o OnConnectSettingsHandler) RouterConnected(r *model.Router) {
 
 		if body, err := proto.Marshal(settingsMsg); err == nil {
 			msg := channel.NewMessage(int32(ctrl_pb.ContentType_SettingsType), body)
-			if err := r.Control.Send(msg); err == nil {
+			if err := r.Control.Send(msg); err != nil {
 				pfxlog.Logger().WithError(err).WithFields(map[string]interface{}{
 					"routerId": r.Id,
 					"channel":  r.Control.LogicalName(),
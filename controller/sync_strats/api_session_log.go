@@ -0,0 +1,124 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package sync_strats
+
+import (
+	"sync"
+
+	"github.com/lucsky/cuid"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/controller/env"
+)
+
+// apiSessionLogEntry is a single api session added, updated or removed message, as sent to routers
+type apiSessionLogEntry struct {
+	seq         uint64
+	contentType int32
+	body        []byte
+	headers     map[int32][]byte
+}
+
+func (entry *apiSessionLogEntry) toMsg(logId string) *channel.Message {
+	msg := channel.NewMessage(entry.contentType, entry.body)
+	for k, v := range entry.headers {
+		msg.Headers[k] = v
+	}
+	msg.Headers[env.ApiSessionLogIdHeader] = []byte(logId)
+	msg.Headers.PutUint64Header(env.ApiSessionLogSeqHeader, entry.seq)
+	return msg
+}
+
+// apiSessionLog keeps the most recent api session changes sent to routers, each with a sequence number. Routers
+// track the last sequence they've seen and report it when they reconnect, so that they can be sent only the changes
+// they missed, rather than the full set of api sessions. The log id changes every time the controller starts, since
+// the log is only held in memory.
+//
+// The log lock is held while live changes are queued for routers, so that every router sees changes in sequence order.
+// Nothing blocks on a router while the lock is held.
+type apiSessionLog struct {
+	sync.Mutex
+	id      string
+	seq     uint64
+	size    int
+	entries []*apiSessionLogEntry
+	next    int
+}
+
+func newApiSessionLog(size int) *apiSessionLog {
+	return &apiSessionLog{
+		id:      cuid.New(),
+		size:    size,
+		entries: make([]*apiSessionLogEntry, 0, size),
+	}
+}
+
+// append adds a change to the log, evicting the oldest change if the log is full. Must be called with the lock held.
+func (log *apiSessionLog) append(contentType int32, body []byte, headers map[int32][]byte) *apiSessionLogEntry {
+	log.seq++
+	entry := &apiSessionLogEntry{
+		seq:         log.seq,
+		contentType: contentType,
+		body:        body,
+		headers:     headers,
+	}
+
+	if log.size <= 0 {
+		return entry
+	}
+
+	if len(log.entries) < log.size {
+		log.entries = append(log.entries, entry)
+	} else {
+		log.entries[log.next] = entry
+	}
+	log.next = (log.next + 1) % log.size
+
+	return entry
+}
+
+// current returns the log id and the sequence number of the latest change. Must be called with the lock held.
+func (log *apiSessionLog) current() (string, uint64) {
+	return log.id, log.seq
+}
+
+// replayFrom returns the changes after the given sequence number, in order. If the log id doesn't match, or some of
+// the changes are no longer in the log, false is returned and the router needs a full sync. Must be called with the
+// lock held.
+func (log *apiSessionLog) replayFrom(logId string, seq uint64) ([]*apiSessionLogEntry, bool) {
+	if logId != log.id || seq > log.seq {
+		return nil, false
+	}
+
+	count := log.seq - seq
+	if count == 0 {
+		return nil, true
+	}
+
+	if count > uint64(len(log.entries)) {
+		return nil, false
+	}
+
+	result := make([]*apiSessionLogEntry, 0, count)
+	start := log.next - int(count)
+	if start < 0 {
+		start += len(log.entries)
+	}
+	for i := 0; i < int(count); i++ {
+		result = append(result, log.entries[(start+i)%len(log.entries)])
+	}
+	return result, true
+}
@@ -0,0 +1,126 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package sync_strats
+
+import (
+	"testing"
+
+	"github.com/openziti/ziti/controller/env"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ApiSessionLog(t *testing.T) {
+	appendN := func(log *apiSessionLog, n int) {
+		for i := 0; i < n; i++ {
+			log.append(env.ApiSessionRemovedType, []byte{byte(i)}, nil)
+		}
+	}
+
+	getSeqs := func(entries []*apiSessionLogEntry) []uint64 {
+		var result []uint64
+		for _, entry := range entries {
+			result = append(result, entry.seq)
+		}
+		return result
+	}
+
+	t.Run("replays changes after the given sequence", func(t *testing.T) {
+		req := require.New(t)
+		log := newApiSessionLog(10)
+		appendN(log, 5)
+
+		entries, ok := log.replayFrom(log.id, 2)
+		req.True(ok)
+		req.Equal([]uint64{3, 4, 5}, getSeqs(entries))
+	})
+
+	t.Run("nothing to replay when current", func(t *testing.T) {
+		req := require.New(t)
+		log := newApiSessionLog(10)
+		appendN(log, 5)
+
+		entries, ok := log.replayFrom(log.id, 5)
+		req.True(ok)
+		req.Empty(entries)
+	})
+
+	t.Run("replays across wrap around", func(t *testing.T) {
+		req := require.New(t)
+		log := newApiSessionLog(10)
+		appendN(log, 25)
+
+		entries, ok := log.replayFrom(log.id, 15)
+		req.True(ok)
+		req.Equal([]uint64{16, 17, 18, 19, 20, 21, 22, 23, 24, 25}, getSeqs(entries))
+
+		entries, ok = log.replayFrom(log.id, 22)
+		req.True(ok)
+		req.Equal([]uint64{23, 24, 25}, getSeqs(entries))
+	})
+
+	t.Run("can't replay evicted changes", func(t *testing.T) {
+		req := require.New(t)
+		log := newApiSessionLog(10)
+		appendN(log, 25)
+
+		_, ok := log.replayFrom(log.id, 14)
+		req.False(ok)
+	})
+
+	t.Run("can't replay from a different log", func(t *testing.T) {
+		req := require.New(t)
+		log := newApiSessionLog(10)
+		appendN(log, 5)
+
+		_, ok := log.replayFrom("other", 2)
+		req.False(ok)
+
+		_, ok = log.replayFrom(log.id, 6)
+		req.False(ok)
+	})
+
+	t.Run("size zero disables replay", func(t *testing.T) {
+		req := require.New(t)
+		log := newApiSessionLog(0)
+		appendN(log, 5)
+
+		logId, seq := log.current()
+		req.Equal(log.id, logId)
+		req.Equal(uint64(5), seq)
+
+		_, ok := log.replayFrom(log.id, 4)
+		req.False(ok)
+	})
+
+	t.Run("messages carry log headers", func(t *testing.T) {
+		req := require.New(t)
+		log := newApiSessionLog(10)
+		entry := log.append(env.ApiSessionUpdatedType, []byte("body"), map[int32][]byte{
+			env.SyncStrategyTypeHeader: []byte(RouterSyncStrategyInstant),
+		})
+
+		msg := entry.toMsg(log.id)
+		req.Equal(env.ApiSessionUpdatedType, msg.ContentType)
+		req.Equal([]byte("body"), msg.Body)
+		req.Equal([]byte(RouterSyncStrategyInstant), msg.Headers[env.SyncStrategyTypeHeader])
+		req.Equal([]byte(log.id), msg.Headers[env.ApiSessionLogIdHeader])
+
+		seq, ok := msg.Headers.GetUint64Header(env.ApiSessionLogSeqHeader)
+		req.True(ok)
+		req.Equal(uint64(1), seq)
+	})
+}
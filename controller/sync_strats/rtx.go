@@ -53,6 +53,9 @@ type RouterSender struct {
 	lastIndexSent    uint64
 	running          atomic.Bool
 	timelineId       string
+	apiSessionLogId  string
+	apiSessionLogSeq uint64
+	apiSessionsLive  bool
 
 	SupportsRouterModel bool
 
@@ -86,6 +89,44 @@ func (rtx *RouterSender) GetState() env.RouterStateValues {
 	return rtx.Values()
 }
 
+// setApiSessionLogPosition records the last api session change the router reported seeing in its client hello
+func (rtx *RouterSender) setApiSessionLogPosition(logId string, seq uint64) {
+	rtx.Lock()
+	defer rtx.Unlock()
+	rtx.apiSessionLogId = logId
+	rtx.apiSessionLogSeq = seq
+}
+
+func (rtx *RouterSender) getApiSessionLogPosition() (string, uint64) {
+	rtx.Lock()
+	defer rtx.Unlock()
+	return rtx.apiSessionLogId, rtx.apiSessionLogSeq
+}
+
+// setApiSessionsLive marks the router as ready to be sent api session changes as they happen. Until then, changes
+// are covered by the replay or full sync the router gets once its client hello is received.
+func (rtx *RouterSender) setApiSessionsLive() {
+	rtx.Lock()
+	defer rtx.Unlock()
+	rtx.apiSessionsLive = true
+}
+
+func (rtx *RouterSender) isApiSessionsLive() bool {
+	rtx.Lock()
+	defer rtx.Unlock()
+	return rtx.apiSessionsLive
+}
+
+// fallBehind stops sending api session changes to a router which couldn't keep up with them. The router is caught up
+// from the given position, the last change it was sent, when it's next synced.
+func (rtx *RouterSender) fallBehind(logId string, seq uint64) {
+	rtx.Lock()
+	defer rtx.Unlock()
+	rtx.apiSessionsLive = false
+	rtx.apiSessionLogId = logId
+	rtx.apiSessionLogSeq = seq
+}
+
 func (rtx *RouterSender) Stop() {
 	if rtx.running.CompareAndSwap(true, false) {
 		close(rtx.closeNotify)
@@ -274,6 +315,21 @@ func (rtx *RouterSender) Send(msg *channel.Message) error {
 	return nil
 }
 
+// trySend queues the message for the router without blocking. It returns false if the router's send buffer is full
+// or the sender is stopped.
+func (rtx *RouterSender) trySend(msg *channel.Message) bool {
+	if !rtx.running.Load() || rtx.Router.Control.IsClosed() {
+		return false
+	}
+
+	select {
+	case rtx.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
 // Map used make working with internal RouterSender easier as sync.Map accepts and returns interface{}
 type routerTxMap struct {
 	internalMap cmap.ConcurrentMap[string, *RouterSender] //id -> RouterSender
@@ -47,6 +47,7 @@ import (
 	"github.com/openziti/ziti/controller/model"
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"go.etcd.io/bbolt"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -68,6 +69,7 @@ var _ env.RouterSyncStrategy = (*InstantStrategy)(nil)
 // - RouterTxBufferSize         - max number of messages buffered to be send to a router
 // - HelloSendTimeout           - the max amount of time per worker to wait to send hellos
 // - SessionChunkSize           - the number of sessions to send in each message
+// - ApiSessionLogSize          - the number of api session changes kept for replay to reconnecting routers, 0 disables replay
 type InstantStrategyOptions struct {
 	MaxQueuedRouterConnects  int32
 	MaxQueuedClientHellos    int32
@@ -76,6 +78,7 @@ type InstantStrategyOptions struct {
 	RouterTxBufferSize       int
 	HelloSendTimeout         time.Duration
 	SessionChunkSize         int
+	ApiSessionLogSize        int
 }
 
 // InstantStrategy assumes that on connect, the router requires and instant
@@ -91,10 +94,15 @@ type InstantStrategyOptions struct {
 //     the receivedClientHelloQueue channel which buffers up to options.MaxQueuedClientHellos
 //  7. A startSynchronizeWorker will pick up the RouterSender from the receivedClientHelloQueue and being to
 //     send data to the edge router via the RouterSender
+//
+// Api session changes are numbered and kept in the apiSessionLog. They are sent to a router once its client hello has
+// been handled. If the router reported the last change it saw in its client hello, and the log still has every
+// change since, only those changes are sent instead of a full sync.
 type InstantStrategy struct {
 	InstantStrategyOptions
 
-	rtxMap *routerTxMap
+	rtxMap        *routerTxMap
+	apiSessionLog *apiSessionLog
 
 	helloHandler  channel.TypedReceiveHandler
 	resyncHandler channel.TypedReceiveHandler
@@ -258,11 +266,16 @@ func NewInstantStrategy(ae *env.AppEnv, options InstantStrategyOptions) *Instant
 		pfxlog.Logger().Panicf("SessionChunkSize for InstantStrategy cannot be less than 1, got %d", options.SessionChunkSize)
 	}
 
+	if options.ApiSessionLogSize < 0 {
+		pfxlog.Logger().Panicf("ApiSessionLogSize for InstantStrategy cannot be less than 0, got %d", options.ApiSessionLogSize)
+	}
+
 	strategy := &InstantStrategy{
 		InstantStrategyOptions: options,
 		rtxMap: &routerTxMap{
 			internalMap: cmap.New[*RouterSender](),
 		},
+		apiSessionLog:            newApiSessionLog(options.ApiSessionLogSize),
 		ae:                       ae,
 		routerConnectedQueue:     make(chan *RouterSender, options.MaxQueuedRouterConnects),
 		receivedClientHelloQueue: make(chan *RouterSender, options.MaxQueuedClientHellos),
@@ -395,9 +408,16 @@ func (strategy *InstantStrategy) ApiSessionAdded(apiSession *db.ApiSession) {
 		IsLast:   true,
 		Sequence: 0,
 	}
+	stateBytes, _ := json.Marshal(state)
 
-	strategy.rtxMap.Range(func(rtx *RouterSender) {
-		_ = strategy.sendApiSessionAdded(rtx, false, state, []*edge_ctrl_pb.ApiSession{apiSessionProto})
+	content, _ := proto.Marshal(&edge_ctrl_pb.ApiSessionAdded{
+		IsFullState: false,
+		ApiSessions: []*edge_ctrl_pb.ApiSession{apiSessionProto},
+	})
+
+	strategy.broadcastApiSessionChange(env.ApiSessionAddedType, content, map[int32][]byte{
+		env.SyncStrategyTypeHeader:  []byte(strategy.Type()),
+		env.SyncStrategyStateHeader: stateBytes,
 	})
 }
 
@@ -418,12 +438,10 @@ func (strategy *InstantStrategy) ApiSessionUpdated(apiSession *db.ApiSession, _
 		ApiSessions: []*edge_ctrl_pb.ApiSession{apiSessionProto},
 	}
 
-	strategy.rtxMap.Range(func(rtx *RouterSender) {
-		content, _ := proto.Marshal(apiSessionAdded)
-		msg := channel.NewMessage(env.ApiSessionUpdatedType, content)
-		msg.Headers[env.SyncStrategyTypeHeader] = []byte(strategy.Type())
-		msg.Headers[env.SyncStrategyStateHeader] = nil
-		_ = rtx.Send(msg)
+	content, _ := proto.Marshal(apiSessionAdded)
+	strategy.broadcastApiSessionChange(env.ApiSessionUpdatedType, content, map[int32][]byte{
+		env.SyncStrategyTypeHeader:  []byte(strategy.Type()),
+		env.SyncStrategyStateHeader: nil,
 	})
 }
 
@@ -432,11 +450,89 @@ func (strategy *InstantStrategy) ApiSessionDeleted(apiSession *db.ApiSession) {
 		Tokens: []string{apiSession.Token},
 	}
 
+	content, _ := proto.Marshal(sessionRemoved)
+	strategy.broadcastApiSessionChange(env.ApiSessionRemovedType, content, nil)
+}
+
+// broadcastApiSessionChange records an api session change in the api session log and queues it for all routers which
+// have finished their hello. Changes are queued with the log lock held, so routers receive them in sequence order, but
+// never block on a router: a router whose send buffer is full stops getting live changes and is resynced, catching up
+// from the log.
+func (strategy *InstantStrategy) broadcastApiSessionChange(contentType int32, content []byte, headers map[int32][]byte) {
+	var behind []*RouterSender
+
+	strategy.apiSessionLog.Lock()
+	entry := strategy.apiSessionLog.append(contentType, content, headers)
+	logId := strategy.apiSessionLog.id
 	strategy.rtxMap.Range(func(rtx *RouterSender) {
-		content, _ := proto.Marshal(sessionRemoved)
-		msg := channel.NewMessage(env.ApiSessionRemovedType, content)
-		_ = rtx.Send(msg)
+		if rtx.isApiSessionsLive() && !rtx.trySend(entry.toMsg(logId)) {
+			rtx.fallBehind(logId, entry.seq-1)
+			behind = append(behind, rtx)
+		}
 	})
+	strategy.apiSessionLog.Unlock()
+
+	for _, rtx := range behind {
+		if !rtx.running.Load() {
+			continue
+		}
+
+		rtx.SetSyncStatus(env.RouterSyncResyncWait)
+		rtx.logger().WithField("strategy", strategy.Type()).
+			WithField("apiSessionLogSeq", entry.seq-1).
+			Warn("router send buffer full, api session changes will be replayed, queuing resync")
+		strategy.queueClientHello(rtx)
+	}
+}
+
+// replayApiSessionChanges sends the router the api session changes made since the last one it reported seeing in its
+// client hello, and starts sending it changes as they happen. If the router didn't report a position, or the changes
+// are no longer in the log, false is returned and the router needs a full sync.
+//
+// Changes are copied out of the log with the lock held and sent after it's released, so a slow router doesn't hold up
+// api session changes for everyone else. This repeats until the router has caught up, which is checked with the lock
+// held, so no change is missed between the replay and the router going live.
+func (strategy *InstantStrategy) replayApiSessionChanges(rtx *RouterSender) (bool, error) {
+	logId, seq := rtx.getApiSessionLogPosition()
+	if logId == "" {
+		return false, nil
+	}
+
+	startSeq := seq
+	changeCount := 0
+
+	for {
+		strategy.apiSessionLog.Lock()
+		entries, ok := strategy.apiSessionLog.replayFrom(logId, seq)
+		if ok && len(entries) == 0 {
+			rtx.setApiSessionsLive()
+		}
+		strategy.apiSessionLog.Unlock()
+
+		if !ok {
+			return false, nil
+		}
+
+		if len(entries) == 0 {
+			break
+		}
+
+		for _, entry := range entries {
+			if err := rtx.Send(entry.toMsg(logId)); err != nil {
+				return true, err
+			}
+		}
+
+		seq = entries[len(entries)-1].seq
+		changeCount += len(entries)
+	}
+
+	rtx.logger().WithField("strategy", strategy.Type()).
+		WithField("apiSessionLogSeq", startSeq).
+		WithField("changeCount", changeCount).
+		Info("router reported last api session change seen, sent missed changes instead of full sync")
+
+	return true, nil
 }
 
 func (strategy *InstantStrategy) SessionDeleted(session *db.Session) {
@@ -561,6 +657,9 @@ func (strategy *InstantStrategy) ReceiveResync(routerId string, _ *edge_ctrl_pb.
 
 	rtx.SetSyncStatus(env.RouterSyncResyncWait)
 
+	// the router has asked for a full sync, so whatever it reported in its hello no longer applies
+	rtx.setApiSessionLogPosition("", 0)
+
 	rtx.logger().WithField("strategy", strategy.Type()).Info("received resync from router, queuing")
 
 	strategy.queueClientHello(rtx)
@@ -649,6 +748,13 @@ func (strategy *InstantStrategy) ReceiveClientHello(routerId string, msg *channe
 		}
 	}
 
+	if logId, ok := msg.Headers[env.ApiSessionLogIdHeader]; ok {
+		if seq, ok := msg.Headers.GetUint64Header(env.ApiSessionLogSeqHeader); ok {
+			rtx.setApiSessionLogPosition(string(logId), seq)
+			logger = logger.WithField("apiSessionLogSeq", seq)
+		}
+	}
+
 	protocols := map[string]string{}
 
 	if len(respHello.Listeners) > 0 {
@@ -694,54 +800,10 @@ func (strategy *InstantStrategy) synchronize(rtx *RouterSender) {
 	logger := rtx.logger().WithField("strategy", strategy.Type()).WithField("SupportsRouterModel", rtx.SupportsRouterModel)
 	logger.Info("started synchronizing edge router")
 
-	chunkSize := 100
-	err := strategy.ae.GetDb().View(func(tx *bbolt.Tx) error {
-		var apiSessions []*edge_ctrl_pb.ApiSession
-
-		state := &InstantSyncState{
-			Id:       cuid.New(),
-			IsLast:   true,
-			Sequence: 0,
-		}
-
-		for cursor := strategy.ae.GetStores().ApiSession.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
-			current := cursor.Current()
-
-			apiSession, err := strategy.ae.GetStores().ApiSession.LoadById(tx, string(current))
-
-			if err != nil {
-				logger.WithError(err).WithField("apiSessionId", string(current)).Errorf("error querying api session [%s]: %v", string(current), err)
-				continue
-			}
-
-			apiSessionProto, err := apiSessionToProtoWithTx(tx, strategy.ae, apiSession.Token, apiSession.IdentityId, apiSession.Id)
-
-			if err != nil {
-				logger.WithError(err).WithField("apiSessionId", string(current)).Errorf("error turning apiSession [%s] into proto: %v", string(current), err)
-				continue
-			}
-
-			apiSessions = append(apiSessions, apiSessionProto)
-
-			if len(apiSessions) >= chunkSize {
-				state.IsLast = !cursor.IsValid()
-				if err = strategy.sendApiSessionAdded(rtx, true, state, apiSessions); err != nil {
-					return err
-				}
-
-				state.Sequence = state.Sequence + 1
-				apiSessions = []*edge_ctrl_pb.ApiSession{}
-			}
-		}
-
-		if len(apiSessions) > 0 {
-			state.IsLast = true
-			if err := strategy.sendApiSessionAdded(rtx, true, state, apiSessions); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	replayed, err := strategy.replayApiSessionChanges(rtx)
+	if err == nil && !replayed {
+		err = strategy.syncApiSessions(rtx, logger)
+	}
 
 	if err != nil {
 		logger.WithError(err).Error("failure synchronizing api sessions")
@@ -785,7 +847,74 @@ func (strategy *InstantStrategy) synchronize(rtx *RouterSender) {
 	rtx.SetSyncStatus(env.RouterSyncDone)
 }
 
-func (strategy *InstantStrategy) sendApiSessionAdded(rtx *RouterSender, isFullState bool, state *InstantSyncState, apiSessions []*edge_ctrl_pb.ApiSession) error {
+// syncApiSessions sends the router the full set of api sessions, in chunks. The first, empty, chunk is queued with the
+// api session log lock held, without blocking, and carries the current log position. Changes after that position are sent to the router
+// as they happen, so the router can check their sequence from there.
+func (strategy *InstantStrategy) syncApiSessions(rtx *RouterSender, logger *logrus.Entry) error {
+	state := &InstantSyncState{
+		Id:       cuid.New(),
+		IsLast:   false,
+		Sequence: 0,
+	}
+
+	strategy.apiSessionLog.Lock()
+	logId, logSeq := strategy.apiSessionLog.current()
+	queued := rtx.trySend(strategy.newApiSessionAddedMsg(true, state, nil, logId, logSeq))
+	if queued {
+		rtx.setApiSessionsLive()
+	}
+	strategy.apiSessionLog.Unlock()
+
+	if !queued {
+		return errors.Errorf("unable to queue api session sync for router [%s], send buffer full or sender stopped", rtx.Router.Id)
+	}
+
+	state.Sequence = 1
+
+	chunkSize := 100
+	return strategy.ae.GetDb().View(func(tx *bbolt.Tx) error {
+		var apiSessions []*edge_ctrl_pb.ApiSession
+
+		for cursor := strategy.ae.GetStores().ApiSession.IterateIds(tx, ast.BoolNodeTrue); cursor.IsValid(); cursor.Next() {
+			current := cursor.Current()
+
+			apiSession, err := strategy.ae.GetStores().ApiSession.LoadById(tx, string(current))
+
+			if err != nil {
+				logger.WithError(err).WithField("apiSessionId", string(current)).Errorf("error querying api session [%s]: %v", string(current), err)
+				continue
+			}
+
+			apiSessionProto, err := apiSessionToProtoWithTx(tx, strategy.ae, apiSession.Token, apiSession.IdentityId, apiSession.Id)
+
+			if err != nil {
+				logger.WithError(err).WithField("apiSessionId", string(current)).Errorf("error turning apiSession [%s] into proto: %v", string(current), err)
+				continue
+			}
+
+			apiSessions = append(apiSessions, apiSessionProto)
+
+			if len(apiSessions) >= chunkSize {
+				if err = strategy.sendApiSessionAdded(rtx, true, state, apiSessions, logId, logSeq); err != nil {
+					return err
+				}
+
+				state.Sequence = state.Sequence + 1
+				apiSessions = []*edge_ctrl_pb.ApiSession{}
+			}
+		}
+
+		// always send a last chunk, even if empty, so the router knows the sync is complete
+		state.IsLast = true
+		return strategy.sendApiSessionAdded(rtx, true, state, apiSessions, logId, logSeq)
+	})
+}
+
+func (strategy *InstantStrategy) sendApiSessionAdded(rtx *RouterSender, isFullState bool, state *InstantSyncState, apiSessions []*edge_ctrl_pb.ApiSession, logId string, logSeq uint64) error {
+	return rtx.Send(strategy.newApiSessionAddedMsg(isFullState, state, apiSessions, logId, logSeq))
+}
+
+func (strategy *InstantStrategy) newApiSessionAddedMsg(isFullState bool, state *InstantSyncState, apiSessions []*edge_ctrl_pb.ApiSession, logId string, logSeq uint64) *channel.Message {
 	stateBytes, _ := json.Marshal(state)
 
 	msgContent := &edge_ctrl_pb.ApiSessionAdded{
@@ -799,8 +928,10 @@ func (strategy *InstantStrategy) sendApiSessionAdded(rtx *RouterSender, isFullSt
 
 	msg.Headers[env.SyncStrategyTypeHeader] = []byte(strategy.Type())
 	msg.Headers[env.SyncStrategyStateHeader] = stateBytes
+	msg.Headers[env.ApiSessionLogIdHeader] = []byte(logId)
+	msg.Headers.PutUint64Header(env.ApiSessionLogSeqHeader, logSeq)
 
-	return rtx.Send(msg)
+	return msg
 }
 
 func (strategy *InstantStrategy) handleRouterModelEvents(eventChannel <-chan *edge_ctrl_pb.DataState_ChangeSet) {
@@ -1151,6 +1282,20 @@ func (strategy *InstantStrategy) ValidatePostureChecks(tx *bbolt.Tx, rdm *common
 			} else {
 				result = append(result, fmt.Errorf("for posture check %s, sub type not process multi, rather: %T", t.Id, v.Subtype))
 			}
+		case *db.PostureCheckProcessHash:
+			if rdmSubType, ok := v.Subtype.(*edge_ctrl_pb.DataState_PostureCheck_ProcessMulti_); ok && rdmSubType.ProcessMulti != nil {
+				result = diffVals("posture check", t.Id, "process hash list len", len(subType.Processes), len(rdmSubType.ProcessMulti.Processes), result)
+				if len(subType.Processes) == len(rdmSubType.ProcessMulti.Processes) {
+					for idx, process := range subType.Processes {
+						rdmProcess := rdmSubType.ProcessMulti.Processes[idx]
+						result = diffJson("posture check", t.Id, fmt.Sprintf("process %d hashes", idx), process.Hashes, rdmProcess.Hashes, result)
+						result = diffVals("posture check", t.Id, fmt.Sprintf("process %d path", idx), process.Path, rdmProcess.Path, result)
+						result = diffVals("posture check", t.Id, fmt.Sprintf("process %d os type", idx), process.OsType, rdmProcess.OsType, result)
+					}
+				}
+			} else {
+				result = append(result, fmt.Errorf("for posture check %s, sub type not process multi, rather: %T", t.Id, v.Subtype))
+			}
 		}
 
 		return result
@@ -1459,6 +1604,17 @@ func newServicePolicy(storeModel *db.ServicePolicy) *edge_ctrl_pb.DataState_Serv
 		PolicyType: edge_ctrl_pb.PolicyType(storeModel.PolicyType.Id()),
 	}
 
+	if schedule := storeModel.Schedule; schedule != nil {
+		servicePolicy.ScheduleTimezone = schedule.Timezone
+		servicePolicy.ScheduleWindows = schedule.Windows
+		if schedule.NotBefore != nil {
+			servicePolicy.ScheduleNotBefore = timestamppb.New(*schedule.NotBefore)
+		}
+		if schedule.NotAfter != nil {
+			servicePolicy.ScheduleNotAfter = timestamppb.New(*schedule.NotAfter)
+		}
+	}
+
 	return servicePolicy
 }
 
@@ -1575,6 +1731,23 @@ func newPostureCheck(postureModel *db.PostureCheck) *edge_ctrl_pb.DataState_Post
 			processList.ProcessMulti.Processes = append(processList.ProcessMulti.Processes, newProc)
 		}
 
+		newVal.Subtype = processList
+	case *db.PostureCheckProcessHash:
+		// routers evaluate process hash checks as process multi checks which pass if any process matches
+		processList := &edge_ctrl_pb.DataState_PostureCheck_ProcessMulti_{
+			ProcessMulti: &edge_ctrl_pb.DataState_PostureCheck_ProcessMulti{
+				Semantic: db.SemanticAnyOf,
+			},
+		}
+
+		for _, process := range subType.Processes {
+			processList.ProcessMulti.Processes = append(processList.ProcessMulti.Processes, &edge_ctrl_pb.DataState_PostureCheck_Process{
+				OsType: process.OsType,
+				Path:   process.Path,
+				Hashes: process.Hashes,
+			})
+		}
+
 		newVal.Subtype = processList
 	case *db.PostureCheckMfa:
 		newVal.Subtype = &edge_ctrl_pb.DataState_PostureCheck_Mfa_{
@@ -0,0 +1,173 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package sync_strats
+
+import (
+	"testing"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/model"
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type testControlChannel struct {
+	channel.Channel
+}
+
+func (ch *testControlChannel) IsClosed() bool {
+	return false
+}
+
+func newTestStrategy(logSize int) *InstantStrategy {
+	return &InstantStrategy{
+		rtxMap: &routerTxMap{
+			internalMap: cmap.New[*RouterSender](),
+		},
+		apiSessionLog:            newApiSessionLog(logSize),
+		receivedClientHelloQueue: make(chan *RouterSender, 10),
+		stopNotify:               make(chan struct{}),
+	}
+}
+
+// newTestRouterSender returns a sender which nothing reads from, so its send buffer fills up
+func newTestRouterSender(strategy *InstantStrategy, id string, sendBufferSize int) *RouterSender {
+	rtx := &RouterSender{
+		Id:          id,
+		Router:      &model.Router{Control: &testControlChannel{}},
+		send:        make(chan *channel.Message, sendBufferSize),
+		closeNotify: make(chan struct{}),
+		RouterState: env.NewLockingRouterStatus(),
+	}
+	rtx.Router.Id = id
+	rtx.running.Store(true)
+	strategy.rtxMap.Add(id, rtx)
+	return rtx
+}
+
+func getMsgSeqs(rtx *RouterSender) []uint64 {
+	var result []uint64
+	for len(rtx.send) > 0 {
+		msg := <-rtx.send
+		seq, _ := msg.GetUint64Header(env.ApiSessionLogSeqHeader)
+		result = append(result, seq)
+	}
+	return result
+}
+
+func TestInstantStrategy_BroadcastApiSessionChange(t *testing.T) {
+	t.Run("full router doesn't block other routers", func(t *testing.T) {
+		req := require.New(t)
+		strategy := newTestStrategy(10)
+
+		slow := newTestRouterSender(strategy, "slow", 1)
+		slow.setApiSessionsLive()
+		fast := newTestRouterSender(strategy, "fast", 10)
+		fast.setApiSessionsLive()
+
+		for i := 0; i < 3; i++ {
+			strategy.broadcastApiSessionChange(env.ApiSessionRemovedType, []byte{byte(i)}, nil)
+		}
+
+		req.Equal([]uint64{1, 2, 3}, getMsgSeqs(fast))
+		req.True(fast.isApiSessionsLive())
+
+		req.Equal([]uint64{1}, getMsgSeqs(slow))
+		req.False(slow.isApiSessionsLive())
+		logId, seq := slow.getApiSessionLogPosition()
+		req.Equal(strategy.apiSessionLog.id, logId)
+		req.Equal(uint64(1), seq)
+		req.Equal(env.RouterSyncResyncWait, slow.GetState().SyncStatus)
+
+		req.Len(strategy.receivedClientHelloQueue, 1)
+		req.Equal(slow, <-strategy.receivedClientHelloQueue)
+	})
+
+	t.Run("routers which aren't live aren't sent changes", func(t *testing.T) {
+		req := require.New(t)
+		strategy := newTestStrategy(10)
+		rtx := newTestRouterSender(strategy, "router", 10)
+
+		strategy.broadcastApiSessionChange(env.ApiSessionRemovedType, nil, nil)
+		req.Empty(getMsgSeqs(rtx))
+		req.Len(strategy.receivedClientHelloQueue, 0)
+	})
+}
+
+func TestInstantStrategy_ReplayApiSessionChanges(t *testing.T) {
+	t.Run("router behind is caught up and goes live", func(t *testing.T) {
+		req := require.New(t)
+		strategy := newTestStrategy(10)
+		for i := 0; i < 5; i++ {
+			strategy.broadcastApiSessionChange(env.ApiSessionRemovedType, nil, nil)
+		}
+
+		rtx := newTestRouterSender(strategy, "router", 10)
+		rtx.setApiSessionLogPosition(strategy.apiSessionLog.id, 2)
+
+		replayed, err := strategy.replayApiSessionChanges(rtx)
+		req.NoError(err)
+		req.True(replayed)
+		req.True(rtx.isApiSessionsLive())
+		req.Equal([]uint64{3, 4, 5}, getMsgSeqs(rtx))
+
+		strategy.broadcastApiSessionChange(env.ApiSessionRemovedType, nil, nil)
+		req.Equal([]uint64{6}, getMsgSeqs(rtx))
+	})
+
+	t.Run("router lagging live changes is caught up from where it fell behind", func(t *testing.T) {
+		req := require.New(t)
+		strategy := newTestStrategy(10)
+		rtx := newTestRouterSender(strategy, "router", 2)
+		rtx.setApiSessionsLive()
+
+		for i := 0; i < 4; i++ {
+			strategy.broadcastApiSessionChange(env.ApiSessionRemovedType, nil, nil)
+		}
+		req.Equal([]uint64{1, 2}, getMsgSeqs(rtx))
+		req.False(rtx.isApiSessionsLive())
+
+		// more changes happen before the resync is picked up
+		strategy.broadcastApiSessionChange(env.ApiSessionRemovedType, nil, nil)
+		req.Empty(getMsgSeqs(rtx))
+
+		rtx.send = make(chan *channel.Message, 10)
+		replayed, err := strategy.replayApiSessionChanges(rtx)
+		req.NoError(err)
+		req.True(replayed)
+		req.True(rtx.isApiSessionsLive())
+		req.Equal([]uint64{3, 4, 5}, getMsgSeqs(rtx))
+	})
+
+	t.Run("evicted changes need a full sync", func(t *testing.T) {
+		req := require.New(t)
+		strategy := newTestStrategy(3)
+		for i := 0; i < 5; i++ {
+			strategy.broadcastApiSessionChange(env.ApiSessionRemovedType, nil, nil)
+		}
+
+		rtx := newTestRouterSender(strategy, "router", 10)
+		rtx.setApiSessionLogPosition(strategy.apiSessionLog.id, 1)
+
+		replayed, err := strategy.replayApiSessionChanges(rtx)
+		req.NoError(err)
+		req.False(replayed)
+		req.False(rtx.isApiSessionsLive())
+		req.Empty(getMsgSeqs(rtx))
+	})
+}
@@ -0,0 +1,113 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package tpm
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	credentialSeedSize = 16
+	credentialKeyBits  = 128
+	// MaxSecretSize is the largest secret which can be wrapped, the size of a TPM2B_DIGEST holding a SHA-512 hash
+	MaxSecretSize = 64
+)
+
+// MakeCredential wraps secret so that it can only be recovered by TPM2_ActivateCredential, on the TPM holding the
+// given RSA endorsement key, and only while the key named akName is loaded in that same TPM. It returns the
+// TPM2B_ID_OBJECT credential blob and the TPM2B_ENCRYPTED_SECRET, both in the wire format TPM2_ActivateCredential
+// takes.
+//
+// The endorsement key is assumed to be a standard storage key, using the name algorithm of the attestation key with
+// AES-128 CFB as its symmetric algorithm, as the TCG EK templates define.
+func MakeCredential(ek *rsa.PublicKey, akName []byte, secret []byte) ([]byte, []byte, error) {
+	if len(akName) < 2 {
+		return nil, nil, errors.New("invalid attestation key name")
+	}
+	if len(secret) == 0 || len(secret) > MaxSecretSize {
+		return nil, nil, errors.Errorf("secret must be between 1 and %d bytes", MaxSecretSize)
+	}
+
+	hash, err := HashForAlg(binary.BigEndian.Uint16(akName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seed := make([]byte, credentialSeedSize)
+	if _, err = io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, nil, err
+	}
+
+	encSeed, err := rsa.EncryptOAEP(hash.New(), rand.Reader, ek, seed, []byte("IDENTITY\x00"))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to encrypt credential seed to endorsement key")
+	}
+
+	symKey := KDFa(hash, seed, "STORAGE", akName, nil, credentialKeyBits)
+	block, err := aes.NewCipher(symKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encIdentity := binary.BigEndian.AppendUint16(nil, uint16(len(secret)))
+	encIdentity = append(encIdentity, secret...)
+	// CFB mode is deprecated in the standard library, but it's the mode the TPM uses to decrypt the credential
+	cipher.NewCFBEncrypter(block, make([]byte, aes.BlockSize)).XORKeyStream(encIdentity, encIdentity) //nolint:staticcheck
+
+	hmacKey := KDFa(hash, seed, "INTEGRITY", nil, nil, hash.Size()*8)
+	mac := hmac.New(hash.New, hmacKey)
+	mac.Write(encIdentity)
+	mac.Write(akName)
+	integrity := mac.Sum(nil)
+
+	idObject := binary.BigEndian.AppendUint16(nil, uint16(len(integrity)))
+	idObject = append(idObject, integrity...)
+	idObject = append(idObject, encIdentity...)
+
+	credentialBlob := binary.BigEndian.AppendUint16(nil, uint16(len(idObject)))
+	credentialBlob = append(credentialBlob, idObject...)
+
+	encryptedSecret := binary.BigEndian.AppendUint16(nil, uint16(len(encSeed)))
+	encryptedSecret = append(encryptedSecret, encSeed...)
+
+	return credentialBlob, encryptedSecret, nil
+}
+
+// KDFa is the counter mode key derivation function from the TPM 2.0 specification, part 1, section 11.4.10.2
+func KDFa(hash crypto.Hash, key []byte, label string, contextU, contextV []byte, bits int) []byte {
+	var result []byte
+	for counter := uint32(1); len(result)*8 < bits; counter++ {
+		mac := hmac.New(hash.New, key)
+		_ = binary.Write(mac, binary.BigEndian, counter)
+		mac.Write([]byte(label))
+		mac.Write([]byte{0})
+		mac.Write(contextU)
+		mac.Write(contextV)
+		_ = binary.Write(mac, binary.BigEndian, uint32(bits))
+		result = mac.Sum(result)
+	}
+	return result[:(bits+7)/8]
+}
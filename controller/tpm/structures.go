@@ -0,0 +1,371 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package tpm verifies TPM 2.0 attestation evidence. It parses the TPM structures clients send, wraps activation
+// secrets for an endorsement key (the software half of TPM2_MakeCredential) and checks quotes against an
+// attestation key. It doesn't talk to a TPM itself.
+package tpm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AlgRSA    uint16 = 0x0001
+	AlgSHA1   uint16 = 0x0004
+	AlgSHA256 uint16 = 0x000B
+	AlgSHA384 uint16 = 0x000C
+	AlgSHA512 uint16 = 0x000D
+	AlgNull   uint16 = 0x0010
+	AlgRSASSA uint16 = 0x0014
+	AlgRSAES  uint16 = 0x0015
+	AlgRSAPSS uint16 = 0x0016
+	AlgECDSA  uint16 = 0x0018
+	AlgECDAA  uint16 = 0x001A
+	AlgECC    uint16 = 0x0023
+
+	EccNistP256 uint16 = 0x0003
+	EccNistP384 uint16 = 0x0004
+	EccNistP521 uint16 = 0x0005
+
+	AttrFixedTPM            uint32 = 0x00000002
+	AttrFixedParent         uint32 = 0x00000010
+	AttrSensitiveDataOrigin uint32 = 0x00000020
+	AttrRestricted          uint32 = 0x00010000
+	AttrDecrypt             uint32 = 0x00020000
+	AttrSign                uint32 = 0x00040000
+
+	// generatedValue marks structures created by the TPM itself (TPM_GENERATED_VALUE)
+	generatedValue uint32 = 0xff544347
+	// stAttestQuote is the structure tag of a TPMS_ATTEST produced by TPM2_Quote
+	stAttestQuote uint16 = 0x8018
+)
+
+// akAttributes are the attributes an attestation key must have. A restricted signing key only signs data the TPM
+// generated, so it can't be used to sign a forged quote.
+const akAttributes = AttrFixedTPM | AttrFixedParent | AttrSensitiveDataOrigin | AttrRestricted | AttrSign
+
+// HashForAlg returns the hash function for the given TPM hash algorithm id
+func HashForAlg(alg uint16) (crypto.Hash, error) {
+	switch alg {
+	case AlgSHA1:
+		return crypto.SHA1, nil
+	case AlgSHA256:
+		return crypto.SHA256, nil
+	case AlgSHA384:
+		return crypto.SHA384, nil
+	case AlgSHA512:
+		return crypto.SHA512, nil
+	}
+	return 0, errors.Errorf("unsupported hash algorithm 0x%04x", alg)
+}
+
+// Public is a parsed TPMT_PUBLIC, the public area of a TPM key
+type Public struct {
+	Type       uint16
+	NameAlg    uint16
+	Attributes uint32
+	Key        crypto.PublicKey
+	raw        []byte
+}
+
+// ParsePublic parses a TPMT_PUBLIC. A TPM2B_PUBLIC, which has a leading size, is also accepted.
+func ParsePublic(data []byte) (*Public, error) {
+	if len(data) > 2 && int(binary.BigEndian.Uint16(data)) == len(data)-2 {
+		data = data[2:]
+	}
+
+	r := &reader{data: data}
+	result := &Public{
+		Type:       r.u16(),
+		NameAlg:    r.u16(),
+		Attributes: r.u32(),
+	}
+	r.sized() // authPolicy
+
+	// symmetric definition, only used by storage keys
+	if symAlg := r.u16(); symAlg != AlgNull {
+		r.u16() // key bits
+		r.u16() // mode
+	}
+
+	switch result.Type {
+	case AlgRSA:
+		if scheme := r.u16(); scheme != AlgNull && scheme != AlgRSAES {
+			r.u16() // scheme hash
+		}
+		r.u16() // key bits
+		exponent := r.u32()
+		if exponent == 0 {
+			exponent = 65537
+		}
+		modulus := r.sized()
+		if r.err == nil {
+			result.Key = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(modulus),
+				E: int(exponent),
+			}
+		}
+	case AlgECC:
+		if scheme := r.u16(); scheme != AlgNull {
+			r.u16() // scheme hash
+			if scheme == AlgECDAA {
+				r.u16() // count
+			}
+		}
+		curveId := r.u16()
+		if kdf := r.u16(); kdf != AlgNull {
+			r.u16() // kdf hash
+		}
+		x := r.sized()
+		y := r.sized()
+		if r.err == nil {
+			curve, err := curveForId(curveId)
+			if err != nil {
+				return nil, err
+			}
+			result.Key = &ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}
+		}
+	default:
+		return nil, errors.Errorf("unsupported key type 0x%04x", result.Type)
+	}
+
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid TPM public area")
+	}
+
+	if !r.done() {
+		return nil, errors.New("invalid TPM public area, unexpected trailing data")
+	}
+
+	result.raw = data
+	return result, nil
+}
+
+func curveForId(curveId uint16) (elliptic.Curve, error) {
+	switch curveId {
+	case EccNistP256:
+		return elliptic.P256(), nil
+	case EccNistP384:
+		return elliptic.P384(), nil
+	case EccNistP521:
+		return elliptic.P521(), nil
+	}
+	return nil, errors.Errorf("unsupported ECC curve 0x%04x", curveId)
+}
+
+// Name returns the TPM name of the key, the name algorithm id followed by the digest of the public area
+func (self *Public) Name() ([]byte, error) {
+	hash, err := HashForAlg(self.NameAlg)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write(self.raw)
+	return h.Sum(binary.BigEndian.AppendUint16(nil, self.NameAlg)), nil
+}
+
+// CheckAttestationKey returns an error if the key isn't a restricted signing key which was created in, and can't
+// leave, the TPM
+func (self *Public) CheckAttestationKey() error {
+	if self.Attributes&akAttributes != akAttributes {
+		return errors.Errorf("key attributes 0x%08x are missing required attestation key attributes 0x%08x", self.Attributes, akAttributes)
+	}
+	if self.Attributes&AttrDecrypt != 0 {
+		return errors.New("attestation keys may not be decryption keys")
+	}
+	return nil
+}
+
+// PcrSelection lists the PCRs selected from one PCR bank
+type PcrSelection struct {
+	Hash uint16
+	Pcrs []int
+}
+
+// Quote is a parsed TPMS_ATTEST produced by TPM2_Quote
+type Quote struct {
+	QualifiedSigner []byte
+	ExtraData       []byte
+	ResetCount      uint32
+	RestartCount    uint32
+	PcrSelections   []PcrSelection
+	PcrDigest       []byte
+	raw             []byte
+}
+
+// ParseQuote parses the TPMS_ATTEST returned by TPM2_Quote. A TPM2B_ATTEST, which has a leading size, is also
+// accepted.
+func ParseQuote(data []byte) (*Quote, error) {
+	if len(data) > 6 && binary.BigEndian.Uint32(data) != generatedValue && binary.BigEndian.Uint32(data[2:]) == generatedValue {
+		data = data[2:]
+	}
+
+	r := &reader{data: data}
+	if magic := r.u32(); r.err == nil && magic != generatedValue {
+		return nil, errors.New("quote wasn't generated by a TPM")
+	}
+	if tag := r.u16(); r.err == nil && tag != stAttestQuote {
+		return nil, errors.Errorf("attestation structure isn't a quote, type is 0x%04x", tag)
+	}
+
+	result := &Quote{
+		QualifiedSigner: r.sized(),
+		ExtraData:       r.sized(),
+	}
+
+	r.u64() // clock
+	result.ResetCount = r.u32()
+	result.RestartCount = r.u32()
+	r.bytes(1) // safe
+	r.u64()    // firmware version
+
+	count := r.u32()
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		selection := PcrSelection{
+			Hash: r.u16(),
+		}
+		size := r.bytes(1)
+		if r.err != nil {
+			break
+		}
+		bitmap := r.bytes(int(size[0]))
+		for idx, b := range bitmap {
+			for bit := 0; bit < 8; bit++ {
+				if b&(1<<bit) != 0 {
+					selection.Pcrs = append(selection.Pcrs, idx*8+bit)
+				}
+			}
+		}
+		result.PcrSelections = append(result.PcrSelections, selection)
+	}
+
+	result.PcrDigest = r.sized()
+
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid TPM quote")
+	}
+
+	if !r.done() {
+		return nil, errors.New("invalid TPM quote, unexpected trailing data")
+	}
+
+	result.raw = data
+	return result, nil
+}
+
+// Signature is a parsed TPMT_SIGNATURE
+type Signature struct {
+	Alg  uint16
+	Hash uint16
+	RSA  []byte
+	R    *big.Int
+	S    *big.Int
+}
+
+// ParseSignature parses a TPMT_SIGNATURE
+func ParseSignature(data []byte) (*Signature, error) {
+	r := &reader{data: data}
+	result := &Signature{
+		Alg:  r.u16(),
+		Hash: r.u16(),
+	}
+
+	switch result.Alg {
+	case AlgRSASSA, AlgRSAPSS:
+		result.RSA = r.sized()
+	case AlgECDSA:
+		result.R = new(big.Int).SetBytes(r.sized())
+		result.S = new(big.Int).SetBytes(r.sized())
+	default:
+		if r.err == nil {
+			return nil, errors.Errorf("unsupported signature algorithm 0x%04x", result.Alg)
+		}
+	}
+
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid TPM signature")
+	}
+
+	if !r.done() {
+		return nil, errors.New("invalid TPM signature, unexpected trailing data")
+	}
+
+	return result, nil
+}
+
+var errTruncated = errors.New("data is truncated")
+
+// reader reads the big-endian values TPM structures are made of. Once a read fails, all following reads return
+// zero values and the error is kept in err.
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (self *reader) bytes(n int) []byte {
+	if self.err != nil {
+		return nil
+	}
+	if len(self.data) < n {
+		self.err = errTruncated
+		return nil
+	}
+	result := self.data[:n]
+	self.data = self.data[n:]
+	return result
+}
+
+func (self *reader) u16() uint16 {
+	if b := self.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (self *reader) u32() uint32 {
+	if b := self.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (self *reader) u64() uint64 {
+	if b := self.bytes(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+// sized reads a TPM2B structure, a 16 bit size followed by that many bytes
+func (self *reader) sized() []byte {
+	return self.bytes(int(self.u16()))
+}
+
+func (self *reader) done() bool {
+	return self.err == nil && len(self.data) == 0
+}
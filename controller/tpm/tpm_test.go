@@ -0,0 +1,328 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package tpm
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func marshalSized(out []byte, data []byte) []byte {
+	out = binary.BigEndian.AppendUint16(out, uint16(len(data)))
+	return append(out, data...)
+}
+
+func marshalEccPublic(key *ecdsa.PublicKey, attributes uint32) []byte {
+	var out []byte
+	out = binary.BigEndian.AppendUint16(out, AlgECC)
+	out = binary.BigEndian.AppendUint16(out, AlgSHA256)
+	out = binary.BigEndian.AppendUint32(out, attributes)
+	out = marshalSized(out, nil)
+	out = binary.BigEndian.AppendUint16(out, AlgNull)
+	out = binary.BigEndian.AppendUint16(out, AlgECDSA)
+	out = binary.BigEndian.AppendUint16(out, AlgSHA256)
+	out = binary.BigEndian.AppendUint16(out, EccNistP256)
+	out = binary.BigEndian.AppendUint16(out, AlgNull)
+	out = marshalSized(out, key.X.FillBytes(make([]byte, 32)))
+	out = marshalSized(out, key.Y.FillBytes(make([]byte, 32)))
+	return out
+}
+
+func marshalQuote(nonce []byte, pcrs []int, pcrDigest []byte) []byte {
+	var out []byte
+	out = binary.BigEndian.AppendUint32(out, generatedValue)
+	out = binary.BigEndian.AppendUint16(out, stAttestQuote)
+	out = marshalSized(out, []byte("signer"))
+	out = marshalSized(out, nonce)
+	out = binary.BigEndian.AppendUint64(out, 1000)
+	out = binary.BigEndian.AppendUint32(out, 1)
+	out = binary.BigEndian.AppendUint32(out, 2)
+	out = append(out, 1)
+	out = binary.BigEndian.AppendUint64(out, 0x20191023)
+	out = binary.BigEndian.AppendUint32(out, 1)
+	out = binary.BigEndian.AppendUint16(out, AlgSHA256)
+	bitmap := make([]byte, 3)
+	for _, pcr := range pcrs {
+		bitmap[pcr/8] |= 1 << (pcr % 8)
+	}
+	out = append(out, byte(len(bitmap)))
+	out = append(out, bitmap...)
+	return marshalSized(out, pcrDigest)
+}
+
+func signQuote(t *testing.T, key *ecdsa.PrivateKey, quote []byte) []byte {
+	digest := sha256.Sum256(quote)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	var out []byte
+	out = binary.BigEndian.AppendUint16(out, AlgECDSA)
+	out = binary.BigEndian.AppendUint16(out, AlgSHA256)
+	out = marshalSized(out, r.Bytes())
+	return marshalSized(out, s.Bytes())
+}
+
+type quoteFixture struct {
+	ak        *Public
+	key       *ecdsa.PrivateKey
+	nonce     []byte
+	pcrs      map[int][]byte
+	quote     []byte
+	signature []byte
+}
+
+func newQuoteFixture(t *testing.T) *quoteFixture {
+	req := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req.NoError(err)
+
+	ak, err := ParsePublic(marshalEccPublic(&key.PublicKey, akAttributes))
+	req.NoError(err)
+
+	pcr0 := sha256.Sum256([]byte("firmware"))
+	pcr7 := sha256.Sum256([]byte("secure boot"))
+	pcrs := map[int][]byte{0: pcr0[:], 7: pcr7[:]}
+	pcrDigest := sha256.Sum256(append(pcr0[:], pcr7[:]...))
+
+	nonce := []byte("0123456789abcdef0123456789abcdef")
+	quote := marshalQuote(nonce, []int{0, 7}, pcrDigest[:])
+
+	return &quoteFixture{
+		ak:        ak,
+		key:       key,
+		nonce:     nonce,
+		pcrs:      pcrs,
+		quote:     quote,
+		signature: signQuote(t, key, quote),
+	}
+}
+
+func TestParsePublic(t *testing.T) {
+	req := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req.NoError(err)
+
+	raw := marshalEccPublic(&key.PublicKey, akAttributes)
+
+	pub, err := ParsePublic(raw)
+	req.NoError(err)
+	req.Equal(AlgECC, pub.Type)
+	req.True(key.PublicKey.Equal(pub.Key))
+	req.NoError(pub.CheckAttestationKey())
+
+	name, err := pub.Name()
+	req.NoError(err)
+	digest := sha256.Sum256(raw)
+	req.Equal(append([]byte{0, 0x0B}, digest[:]...), name)
+
+	// the TPM2B form should parse to the same key
+	sized, err := ParsePublic(marshalSized(nil, raw))
+	req.NoError(err)
+	sizedName, err := sized.Name()
+	req.NoError(err)
+	req.Equal(name, sizedName)
+
+	_, err = ParsePublic(raw[:len(raw)-1])
+	req.Error(err)
+
+	unrestricted, err := ParsePublic(marshalEccPublic(&key.PublicKey, akAttributes&^AttrRestricted))
+	req.NoError(err)
+	req.Error(unrestricted.CheckAttestationKey())
+}
+
+func TestVerifyQuote(t *testing.T) {
+	t.Run("a valid quote verifies", func(t *testing.T) {
+		req := require.New(t)
+		f := newQuoteFixture(t)
+
+		quote, err := VerifyQuote(f.ak, f.quote, f.signature, f.nonce, f.pcrs)
+		req.NoError(err)
+		req.Equal([]PcrSelection{{Hash: AlgSHA256, Pcrs: []int{0, 7}}}, quote.PcrSelections)
+		req.Equal(uint32(1), quote.ResetCount)
+	})
+
+	t.Run("a different nonce fails", func(t *testing.T) {
+		f := newQuoteFixture(t)
+		_, err := VerifyQuote(f.ak, f.quote, f.signature, []byte("other"), f.pcrs)
+		require.ErrorContains(t, err, "nonce")
+	})
+
+	t.Run("a tampered quote fails", func(t *testing.T) {
+		f := newQuoteFixture(t)
+		f.quote[len(f.quote)-1] ^= 1
+		_, err := VerifyQuote(f.ak, f.quote, f.signature, f.nonce, f.pcrs)
+		require.ErrorContains(t, err, "signature")
+	})
+
+	t.Run("a quote signed by another key fails", func(t *testing.T) {
+		f := newQuoteFixture(t)
+		other := newQuoteFixture(t)
+		_, err := VerifyQuote(other.ak, f.quote, f.signature, f.nonce, f.pcrs)
+		require.ErrorContains(t, err, "signature")
+	})
+
+	t.Run("modified PCR values fail", func(t *testing.T) {
+		f := newQuoteFixture(t)
+		f.pcrs[7] = make([]byte, 32)
+		_, err := VerifyQuote(f.ak, f.quote, f.signature, f.nonce, f.pcrs)
+		require.ErrorContains(t, err, "PCR digest")
+	})
+
+	t.Run("missing PCR values fail", func(t *testing.T) {
+		f := newQuoteFixture(t)
+		delete(f.pcrs, 0)
+		_, err := VerifyQuote(f.ak, f.quote, f.signature, f.nonce, f.pcrs)
+		require.Error(t, err)
+	})
+}
+
+// activateCredential is the software equivalent of TPM2_ActivateCredential
+func activateCredential(ek *rsa.PrivateKey, akName, credentialBlob, encryptedSecret []byte) ([]byte, error) {
+	r := &reader{data: encryptedSecret}
+	encSeed := r.sized()
+	r = &reader{data: credentialBlob}
+	idObject := &reader{data: r.sized()}
+	integrity := idObject.sized()
+	encIdentity := idObject.data
+	if r.err != nil || idObject.err != nil {
+		return nil, errors.New("malformed credential")
+	}
+
+	seed, err := rsa.DecryptOAEP(sha256.New(), nil, ek, encSeed, []byte("IDENTITY\x00"))
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, KDFa(crypto.SHA256, seed, "INTEGRITY", nil, nil, 256))
+	mac.Write(encIdentity)
+	mac.Write(akName)
+	if !hmac.Equal(mac.Sum(nil), integrity) {
+		return nil, errors.New("integrity check failed")
+	}
+
+	block, err := aes.NewCipher(KDFa(crypto.SHA256, seed, "STORAGE", akName, nil, 128))
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(encIdentity))
+	cipher.NewCFBDecrypter(block, make([]byte, aes.BlockSize)).XORKeyStream(plain, encIdentity) //nolint:staticcheck
+
+	r = &reader{data: plain}
+	secret := r.sized()
+	if !r.done() {
+		return nil, errors.New("malformed credential")
+	}
+	return secret, nil
+}
+
+func TestMakeCredential(t *testing.T) {
+	req := require.New(t)
+
+	ek, err := rsa.GenerateKey(rand.Reader, 2048)
+	req.NoError(err)
+
+	f := newQuoteFixture(t)
+	akName, err := f.ak.Name()
+	req.NoError(err)
+
+	secret := []byte("activate me")
+	credentialBlob, encryptedSecret, err := MakeCredential(&ek.PublicKey, akName, secret)
+	req.NoError(err)
+
+	activated, err := activateCredential(ek, akName, credentialBlob, encryptedSecret)
+	req.NoError(err)
+	req.Equal(secret, activated)
+
+	// the credential is bound to the attestation key name
+	otherName := append([]byte{}, akName...)
+	otherName[len(otherName)-1] ^= 1
+	_, err = activateCredential(ek, otherName, credentialBlob, encryptedSecret)
+	req.Error(err)
+
+	_, _, err = MakeCredential(&ek.PublicKey, akName, make([]byte, MaxSecretSize+1))
+	req.Error(err)
+}
+
+func TestVerifyEkCertificate(t *testing.T) {
+	req := require.New(t)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req.NoError(err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "TPM Manufacturer Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	req.NoError(err)
+	caCert, err := x509.ParseCertificate(caDer)
+	req.NoError(err)
+
+	// EK certificates identify the TPM in a critical SAN holding only a directory name
+	tpmName, err := asn1.Marshal(pkix.Name{CommonName: "id:54504D00"}.ToRDNSequence())
+	req.NoError(err)
+	san, err := asn1.Marshal([]asn1.RawValue{{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true, Bytes: tpmName}})
+	req.NoError(err)
+
+	ek, err := rsa.GenerateKey(rand.Reader, 2048)
+	req.NoError(err)
+
+	ekTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment,
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidSubjectAltName, Critical: true, Value: san},
+		},
+	}
+	ekDer, err := x509.CreateCertificate(rand.Reader, ekTemplate, caCert, &ek.PublicKey, caKey)
+	req.NoError(err)
+	ekCert, err := x509.ParseCertificate(ekDer)
+	req.NoError(err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	req.NoError(VerifyEkCertificate(ekCert, nil, roots))
+
+	req.Error(VerifyEkCertificate(ekCert, nil, x509.NewCertPool()))
+	req.Error(VerifyEkCertificate(ekCert, nil, nil))
+}
@@ -0,0 +1,159 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package tpm
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// PcrBankHash is the only PCR bank quotes may select. Every TPM 2.0 implementation supports it.
+const PcrBankHash = AlgSHA256
+
+var oidSubjectAltName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// VerifyEkCertificate checks that an endorsement key certificate chains to one of the given roots. EK certificates
+// carry the TPM manufacturer, model and version as directory names in a critical subject alternative name
+// extension, which the x509 package doesn't understand, so that extension is accepted. EK certificates don't use
+// the standard extended key usages, so any usage is accepted.
+func VerifyEkCertificate(cert *x509.Certificate, intermediates, roots *x509.CertPool) error {
+	if roots == nil {
+		return errors.New("no endorsement key roots configured")
+	}
+
+	verifyCert := *cert
+	verifyCert.UnhandledCriticalExtensions = slices.DeleteFunc(slices.Clone(cert.UnhandledCriticalExtensions), func(oid asn1.ObjectIdentifier) bool {
+		return oid.Equal(oidSubjectAltName)
+	})
+
+	_, err := verifyCert.Verify(x509.VerifyOptions{
+		Intermediates: intermediates,
+		Roots:         roots,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return errors.Wrap(err, "endorsement key certificate isn't trusted")
+	}
+	return nil
+}
+
+// VerifyQuote checks that the quote was signed by the attestation key, includes the expected nonce, and covers
+// exactly the given SHA-256 PCR values, keyed by PCR index.
+func VerifyQuote(ak *Public, quoteData, signatureData, nonce []byte, pcrs map[int][]byte) (*Quote, error) {
+	if err := ak.CheckAttestationKey(); err != nil {
+		return nil, err
+	}
+
+	quote, err := ParseQuote(quoteData)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := ParseSignature(signatureData)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare(quote.ExtraData, nonce) != 1 {
+		return nil, errors.New("quote doesn't include the expected nonce")
+	}
+
+	hash, err := HashForAlg(sig.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = verifySignature(ak, sig, hash, quote.raw); err != nil {
+		return nil, err
+	}
+
+	if err = verifyPcrs(quote, hash, pcrs); err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+func verifySignature(ak *Public, sig *Signature, hash crypto.Hash, data []byte) error {
+	h := hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	switch key := ak.Key.(type) {
+	case *rsa.PublicKey:
+		switch sig.Alg {
+		case AlgRSASSA:
+			if err := rsa.VerifyPKCS1v15(key, hash, digest, sig.RSA); err != nil {
+				return errors.Wrap(err, "invalid quote signature")
+			}
+			return nil
+		case AlgRSAPSS:
+			if err := rsa.VerifyPSS(key, hash, digest, sig.RSA, nil); err != nil {
+				return errors.Wrap(err, "invalid quote signature")
+			}
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if sig.Alg == AlgECDSA {
+			if !ecdsa.Verify(key, digest, sig.R, sig.S) {
+				return errors.New("invalid quote signature")
+			}
+			return nil
+		}
+	}
+
+	return errors.Errorf("signature algorithm 0x%04x doesn't match attestation key type 0x%04x", sig.Alg, ak.Type)
+}
+
+// verifyPcrs checks the PCR values against the quote. The TPM computes the PCR digest over the selected PCR values,
+// concatenated in ascending index order, using the hash algorithm of the signing scheme.
+func verifyPcrs(quote *Quote, hash crypto.Hash, pcrs map[int][]byte) error {
+	if len(quote.PcrSelections) != 1 || quote.PcrSelections[0].Hash != PcrBankHash {
+		return errors.New("quote must select PCRs from the SHA-256 bank only")
+	}
+
+	selected := quote.PcrSelections[0].Pcrs
+	if len(selected) != len(pcrs) {
+		return errors.Errorf("quote selects %d PCRs, but %d PCR values were provided", len(selected), len(pcrs))
+	}
+
+	h := hash.New()
+	for _, idx := range selected {
+		value, found := pcrs[idx]
+		if !found {
+			return errors.Errorf("quote selects PCR %d, but no value was provided for it", idx)
+		}
+		if len(value) != crypto.SHA256.Size() {
+			return errors.Errorf("value for PCR %d is %d bytes, expected %d", idx, len(value), crypto.SHA256.Size())
+		}
+		h.Write(value)
+	}
+
+	if !bytes.Equal(h.Sum(nil), quote.PcrDigest) {
+		return errors.New("PCR values don't match the quoted PCR digest")
+	}
+
+	return nil
+}
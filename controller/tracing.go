@@ -0,0 +1,88 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// startTracing installs an OTLP/HTTP exporting tracer provider if tracing is configured. The provider is flushed and
+// shut down when the controller shuts down.
+func (c *Controller) startTracing() error {
+	tracingConfig := c.config.Tracing
+	if tracingConfig == nil {
+		return nil
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(tracingConfig.Endpoint),
+	}
+
+	if tracingConfig.UrlPath != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(tracingConfig.UrlPath))
+	}
+
+	if tracingConfig.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if len(tracingConfig.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(tracingConfig.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", tracingConfig.ServiceName),
+		attribute.String("service.instance.id", c.config.Id.Token),
+		attribute.String("service.version", c.versionProvider.Version()),
+	)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(tracingConfig.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	pfxlog.Logger().WithField("endpoint", tracingConfig.Endpoint).
+		WithField("sampleRatio", tracingConfig.SampleRatio).
+		Info("exporting circuit creation traces via OTLP")
+
+	go func() {
+		<-c.shutdownC
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			pfxlog.Logger().WithError(err).Error("failed to shut down tracer provider")
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,207 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/openziti/ziti/controller/network"
+)
+
+const (
+	CircuitEventsWsPath = "/circuit-events"
+
+	circuitEventsQueueSize    = 1024
+	circuitEventsWriteTimeout = 10 * time.Second
+	circuitEventsPingInterval = 30 * time.Second
+)
+
+// CircuitEventsFilter restricts which circuit events are sent to a websocket subscriber. Empty filter lists
+// match everything.
+type CircuitEventsFilter struct {
+	ServiceIds []string
+	RouterIds  []string
+	EventTypes []event.CircuitEventType
+}
+
+// NewCircuitEventsFilter creates a filter from the query parameters of a websocket upgrade request. Supported
+// parameters are serviceId, routerId and type, each of which may be repeated.
+func NewCircuitEventsFilter(query url.Values) (*CircuitEventsFilter, error) {
+	result := &CircuitEventsFilter{
+		ServiceIds: query["serviceId"],
+		RouterIds:  query["routerId"],
+	}
+
+	for _, eventType := range query["type"] {
+		if !slices.Contains(event.CircuitEventTypes, event.CircuitEventType(eventType)) {
+			return nil, fmt.Errorf("invalid circuit event type '%s'. valid values are %+v", eventType, event.CircuitEventTypes)
+		}
+		result.EventTypes = append(result.EventTypes, event.CircuitEventType(eventType))
+	}
+
+	return result, nil
+}
+
+func (self *CircuitEventsFilter) Matches(evt *event.CircuitEvent) bool {
+	if len(self.EventTypes) > 0 && !slices.Contains(self.EventTypes, evt.EventType) {
+		return false
+	}
+
+	if len(self.ServiceIds) > 0 && !slices.Contains(self.ServiceIds, evt.ServiceId) {
+		return false
+	}
+
+	if len(self.RouterIds) > 0 {
+		for _, routerId := range evt.Path.Nodes {
+			if slices.Contains(self.RouterIds, routerId) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func newCircuitEventsWsHandler(network *network.Network) http.Handler {
+	return &circuitEventsWsHandler{
+		network: network,
+	}
+}
+
+// circuitEventsWsHandler upgrades requests to websockets and streams circuit events, formatted as JSON, to the
+// client until the connection is closed.
+type circuitEventsWsHandler struct {
+	network *network.Network
+}
+
+func (self *circuitEventsWsHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	log := pfxlog.Logger().WithField("remoteAddr", request.RemoteAddr)
+
+	filter, err := NewCircuitEventsFilter(request.URL.Query())
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		log.WithError(err).Error("unable to upgrade circuit events request to websocket")
+		return
+	}
+
+	stream := &circuitEventsStream{
+		conn:        conn,
+		filter:      filter,
+		eventC:      make(chan *event.CircuitEvent, circuitEventsQueueSize),
+		closeNotify: make(chan struct{}),
+	}
+
+	dispatcher := self.network.GetEventDispatcher()
+	dispatcher.AddCircuitEventHandler(stream)
+	defer dispatcher.RemoveCircuitEventHandler(stream)
+
+	log.Info("circuit events websocket subscriber connected")
+	go stream.readLoop()
+	stream.writeLoop()
+	log.Info("circuit events websocket subscriber disconnected")
+}
+
+type circuitEventsStream struct {
+	conn        *websocket.Conn
+	filter      *CircuitEventsFilter
+	eventC      chan *event.CircuitEvent
+	closed      atomic.Bool
+	overflowed  atomic.Bool
+	closeNotify chan struct{}
+}
+
+func (self *circuitEventsStream) AcceptCircuitEvent(evt *event.CircuitEvent) {
+	if !self.filter.Matches(evt) {
+		return
+	}
+
+	select {
+	case self.eventC <- evt:
+	case <-self.closeNotify:
+	default:
+		// if the subscriber can't keep up, disconnect rather than silently dropping events. The client
+		// can re-subscribe, knowing that there's a gap in the stream
+		if self.overflowed.CompareAndSwap(false, true) {
+			self.close()
+		}
+	}
+}
+
+// readLoop is required to process control messages, including client initiated close
+func (self *circuitEventsStream) readLoop() {
+	defer self.close()
+	for {
+		if _, _, err := self.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (self *circuitEventsStream) writeLoop() {
+	log := pfxlog.Logger()
+	defer func() {
+		if self.overflowed.Load() {
+			msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "circuit event queue overflow")
+			_ = self.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		}
+		_ = self.conn.Close()
+	}()
+
+	pingTicker := time.NewTicker(circuitEventsPingInterval)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case evt := <-self.eventC:
+			_ = self.conn.SetWriteDeadline(time.Now().Add(circuitEventsWriteTimeout))
+			if err := self.conn.WriteJSON(evt); err != nil {
+				log.WithError(err).Debug("error writing circuit event to websocket, closing")
+				self.close()
+				return
+			}
+		case <-pingTicker.C:
+			if err := self.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(circuitEventsWriteTimeout)); err != nil {
+				log.WithError(err).Debug("error writing ping to websocket, closing")
+				self.close()
+				return
+			}
+		case <-self.closeNotify:
+			return
+		}
+	}
+}
+
+func (self *circuitEventsStream) close() {
+	if self.closed.CompareAndSwap(false, true) {
+		close(self.closeNotify)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/openziti/ziti/controller/event"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitEventsFilter(t *testing.T) {
+	req := require.New(t)
+
+	evt := &event.CircuitEvent{
+		EventType: event.CircuitCreated,
+		ServiceId: "svc1",
+		Path: event.CircuitPath{
+			Nodes: []string{"r1", "r2", "r3"},
+		},
+	}
+
+	filter, err := NewCircuitEventsFilter(url.Values{})
+	req.NoError(err)
+	req.True(filter.Matches(evt))
+
+	filter, err = NewCircuitEventsFilter(url.Values{"serviceId": {"svc2", "svc1"}})
+	req.NoError(err)
+	req.True(filter.Matches(evt))
+
+	filter, err = NewCircuitEventsFilter(url.Values{"serviceId": {"svc2"}})
+	req.NoError(err)
+	req.False(filter.Matches(evt))
+
+	filter, err = NewCircuitEventsFilter(url.Values{"routerId": {"r2"}, "type": {"created", "deleted"}})
+	req.NoError(err)
+	req.True(filter.Matches(evt))
+
+	filter, err = NewCircuitEventsFilter(url.Values{"routerId": {"r4"}})
+	req.NoError(err)
+	req.False(filter.Matches(evt))
+
+	filter, err = NewCircuitEventsFilter(url.Values{"type": {"deleted"}})
+	req.NoError(err)
+	req.False(filter.Matches(evt))
+
+	_, err = NewCircuitEventsFilter(url.Values{"type": {"invalid"}})
+	req.Error(err)
+}
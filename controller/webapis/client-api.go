@@ -34,7 +34,12 @@ import (
 	"github.com/pkg/errors"
 )
 
-const ZitiInstanceId = "ziti-instance-id"
+const (
+	ZitiInstanceId = "ziti-instance-id"
+
+	clientApiRateLimiterName     = "client"
+	managementApiRateLimiterName = "management"
+)
 
 var _ xweb.ApiHandlerFactory = &ClientApiFactory{}
 
@@ -125,7 +130,7 @@ func (clientApi ClientApiHandler) RootPath() string {
 }
 
 func (clientApi ClientApiHandler) IsHandler(r *http.Request) bool {
-	return strings.HasPrefix(r.URL.Path, clientApi.RootPath()) || r.URL.Path == WellKnownEstCaCerts || r.URL.Path == VersionPath || r.URL.Path == RootPath
+	return strings.HasPrefix(r.URL.Path, clientApi.RootPath()) || r.URL.Path == WellKnownEstCaCerts || r.URL.Path == WellKnownTrustBundle || isEstEnrollPath(r.URL.Path) || r.URL.Path == VersionPath || r.URL.Path == RootPath
 }
 
 func (clientApi ClientApiHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
@@ -148,11 +153,19 @@ func NewClientApiHandler(ae *env.AppEnv, options map[interface{}]interface{}) (*
 }
 
 func (clientApi ClientApiHandler) newHandler(ae *env.AppEnv) http.Handler {
-	innerClientHandler := ae.ClientApi.Serve(nil)
+	innerClientHandler := ae.ClientApi.Serve(api.MetricsRouteBuilder)
 
 	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		rw.Header().Set(ZitiInstanceId, ae.InstanceId)
 
+		if r.URL.Path == WellKnownTrustBundle {
+			serveTrustBundle(ae, rw, r)
+			return
+		}
+
+		// EST enrollment paths are served outside the generated API, but are still subject to the rate limiter
+		isEstEnroll := isEstEnrollPath(r.URL.Path)
+
 		//if not /edge prefix and not /fabric, translate to "/edge/client/v<latest>", this is a hack
 		//that should be removed once non-prefixed URLs are no longer used.
 		//This will affect older go-lang enrolled SDKs and the C-SDK.
@@ -195,17 +208,39 @@ func (clientApi ClientApiHandler) newHandler(ae *env.AppEnv) http.Handler {
 
 		api.AddRequestContextToHttpContext(r, rc)
 
+		if !ae.ApiRateLimiter.CheckIp(clientApiRateLimiterName, rc) {
+			return
+		}
+
+		if isEstEnroll {
+			serveEst(ae, rw, r)
+			return
+		}
+
 		err := ae.FillRequestContext(rc)
 		if err != nil {
 			rc.RespondWithError(err)
 			return
 		}
 
+		if !ae.ApiRateLimiter.CheckIdentity(clientApiRateLimiterName, rc) {
+			return
+		}
+
 		//after request context is filled so that api session is present for session expiration headers
 		response.AddHeaders(rc)
 
+		if subPath, found := strings.CutPrefix(r.URL.Path, ClientRestApiBaseUrlLatest); found {
+			if extraHandler := ae.GetClientApiHandler(subPath); extraHandler != nil {
+				api.SetMetricsRoute(r, subPath)
+				extraHandler.ServeHTTP(rw, r)
+				return
+			}
+		}
+
 		innerClientHandler.ServeHTTP(rw, r)
 	})
 
-	return api.TimeoutHandler(api.WrapCorsHandler(handler), 10*time.Second, apierror.NewTimeoutError(), response.EdgeResponseMapper{})
+	timeoutHandler := api.TimeoutHandler(api.WrapCorsHandler(handler), 10*time.Second, apierror.NewTimeoutError(), response.EdgeResponseMapper{})
+	return api.WrapMetricsHandler(timeoutHandler, ae.GetMetricsRegistry(), ClientApiBinding)
 }
@@ -0,0 +1,83 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/michaelquigley/pfxlog"
+)
+
+const (
+	ConfigReloadPath = "/config/reload"
+)
+
+// ConfigReloader re-reads the controller configuration file and applies the sections which can be changed at
+// runtime, returning the names of the sections which changed
+type ConfigReloader interface {
+	ReloadConfig() ([]string, error)
+}
+
+type ConfigReloadResult struct {
+	Reloaded []string `json:"reloaded"`
+	Error    string   `json:"error,omitempty"`
+}
+
+func newConfigReloadHandler(reloader ConfigReloader) http.Handler {
+	return &configReloadHandler{
+		reloader: reloader,
+	}
+}
+
+// configReloadHandler triggers a config reload on POST. The response body reports which sections were reloaded,
+// or why the reload failed.
+type configReloadHandler struct {
+	reloader ConfigReloader
+}
+
+func (self *configReloadHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "config reload must be requested with POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	log := pfxlog.Logger().WithField("remoteAddr", request.RemoteAddr)
+	log.Info("config reload requested via management api")
+
+	status := http.StatusOK
+	result := &ConfigReloadResult{}
+
+	reloaded, err := self.reloader.ReloadConfig()
+	if err != nil {
+		log.WithError(err).Error("config reload failed")
+		status = http.StatusBadRequest
+		result.Error = err.Error()
+	}
+
+	result.Reloaded = reloaded
+	if result.Reloaded == nil {
+		result.Reloaded = []string{}
+	}
+
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(status)
+	if err = json.NewEncoder(writer).Encode(map[string]any{"data": result}); err != nil {
+		log.WithError(err).Error("unable to write config reload response")
+	}
+}
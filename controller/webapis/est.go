@@ -0,0 +1,184 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/fullsailor/pkcs7"
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/ziti/controller/change"
+	"github.com/openziti/ziti/controller/env"
+)
+
+const (
+	WellKnownEstSimpleEnroll       = "/.well-known/est/simpleenroll"
+	WellKnownEstSimpleReenroll     = "/.well-known/est/simplereenroll"
+	WellKnownEstServerSimpleEnroll = "/.well-known/est/server/simpleenroll"
+
+	estContentTypeCerts = "application/pkcs7-mime; smime-type=certs-only"
+	estRealm            = "ziti-est"
+	estMaxRequestSize   = 64 * 1024
+)
+
+func isEstEnrollPath(path string) bool {
+	return path == WellKnownEstSimpleEnroll || path == WellKnownEstSimpleReenroll || path == WellKnownEstServerSimpleEnroll
+}
+
+// serveEst handles EST (RFC 7030) enrollment requests. The body of each request is a base64 encoded PKCS#10 CSR and
+// the response is a base64 encoded PKCS#7 certs-only structure holding the issued certificate.
+//
+//   - simpleenroll is authenticated with HTTP basic auth, where the password is the enrollment token. The username is
+//     ignored. It enrolls identities with ott enrollments and issues the client certificate for router enrollments.
+//   - server/simpleenroll issues a router's server certificate. It's authenticated by the router's client certificate.
+//   - simplereenroll renews the client certificate presented by an identity or router.
+func serveEst(ae *env.AppEnv, rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", "POST")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var token string
+	if r.URL.Path == WellKnownEstSimpleEnroll {
+		_, password, ok := r.BasicAuth()
+		if !ok || password == "" {
+			rw.Header().Set("WWW-Authenticate", `Basic realm="`+estRealm+`"`)
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		token = password
+	}
+
+	csrPem, err := readEstCsr(http.MaxBytesReader(rw, r.Body, estMaxRequestSize))
+	if err != nil {
+		writeEstError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	changeCtx := change.New().SetSourceType(change.SourceTypeRest).
+		SetSourceAuth("est").
+		SetSourceMethod(r.Method).
+		SetSourceLocal(r.Host).
+		SetSourceRemote(r.RemoteAddr).
+		SetChangeAuthorType(change.AuthorTypeUnattributed)
+
+	var peerCerts []*x509.Certificate
+	if r.TLS != nil {
+		peerCerts = r.TLS.PeerCertificates
+	}
+
+	var chainPem string
+
+	switch r.URL.Path {
+	case WellKnownEstSimpleEnroll:
+		chainPem, err = ae.Managers.Enrollment.EstEnroll(token, csrPem, changeCtx)
+	case WellKnownEstServerSimpleEnroll:
+		chainPem, err = ae.Managers.Enrollment.EstEnrollRouterServerCert(peerCerts, csrPem)
+	default:
+		chainPem, err = ae.Managers.Enrollment.EstReenroll(peerCerts, csrPem, changeCtx)
+	}
+
+	if err != nil {
+		var apiErr *errorz.ApiError
+		if errors.As(err, &apiErr) {
+			if apiErr.Status == http.StatusUnauthorized {
+				rw.Header().Set("WWW-Authenticate", `Basic realm="`+estRealm+`"`)
+			}
+			writeEstError(rw, apiErr.Status, apiErr.Message)
+			return
+		}
+		pfxlog.Logger().WithError(err).WithField("path", r.URL.Path).Error("est enrollment failed")
+		writeEstError(rw, http.StatusInternalServerError, "enrollment failed")
+		return
+	}
+
+	body, err := encodeEstCerts(chainPem)
+	if err != nil {
+		pfxlog.Logger().WithError(err).Error("unable to encode est enrollment response")
+		writeEstError(rw, http.StatusInternalServerError, "enrollment failed")
+		return
+	}
+
+	rw.Header().Set("Content-Type", estContentTypeCerts)
+	rw.Header().Set("Content-Transfer-Encoding", "base64")
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(body)
+}
+
+// readEstCsr reads a base64 encoded DER CSR, as sent by EST clients, and returns it PEM encoded. PEM encoded CSRs are
+// accepted as is.
+func readEstCsr(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errors.New("unable to read request body")
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("request body must contain a PKCS#10 certificate request")
+	}
+
+	if bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		return data, nil
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	if err != nil {
+		return nil, errors.New("request body must be a base64 encoded PKCS#10 certificate request")
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// encodeEstCerts converts the leaf certificate from a PEM chain to a base64 encoded PKCS#7 degenerate, written out
+// in 64 byte lines
+func encodeEstCerts(chainPem string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(chainPem))
+	if block == nil {
+		return nil, errors.New("issued certificate is not valid PEM")
+	}
+
+	data, err := pkcs7.DegenerateCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	result := &bytes.Buffer{}
+	for len(encoded) > 64 {
+		result.WriteString(encoded[:64])
+		result.WriteByte('\n')
+		encoded = encoded[64:]
+	}
+	result.WriteString(encoded)
+	return result.Bytes(), nil
+}
+
+func writeEstError(rw http.ResponseWriter, status int, message string) {
+	rw.Header().Set("Content-Type", "text/plain")
+	rw.WriteHeader(status)
+	_, _ = rw.Write([]byte(message + "\n"))
+}
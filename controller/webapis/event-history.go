@@ -0,0 +1,126 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/eventstore"
+)
+
+const (
+	EventHistoryPath = "/event-history"
+)
+
+// EventHistory provides access to the events retained by the controller event store
+type EventHistory interface {
+	Query(query *eventstore.Query) (*eventstore.QueryResult, error)
+}
+
+// NewEventHistoryQuery creates an event store query from request query parameters. Supported parameters are start
+// and end, as RFC3339 timestamps, namespace and eventType, which may be repeated, entityId and limit.
+func NewEventHistoryQuery(params url.Values) (*eventstore.Query, error) {
+	result := &eventstore.Query{
+		Namespaces: params["namespace"],
+		EventTypes: params["eventType"],
+		EntityId:   params.Get("entityId"),
+	}
+
+	var err error
+	if value := params.Get("start"); value != "" {
+		if result.Start, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return nil, fmt.Errorf("invalid start '%s', must be an RFC3339 timestamp", value)
+		}
+	}
+
+	if value := params.Get("end"); value != "" {
+		if result.End, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return nil, fmt.Errorf("invalid end '%s', must be an RFC3339 timestamp", value)
+		}
+	}
+
+	if value := params.Get("limit"); value != "" {
+		if result.Limit, err = strconv.Atoi(value); err != nil || result.Limit < 1 {
+			return nil, fmt.Errorf("invalid limit '%s', must be a positive integer", value)
+		}
+	}
+
+	if err = result.Validate(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func newEventHistoryHandler(history EventHistory) http.Handler {
+	return &eventHistoryHandler{
+		history: history,
+	}
+}
+
+// eventHistoryHandler serves queries against the controller event store:
+//
+//	GET <base>/event-history?start=<time>&end=<time>&namespace=<ns>&eventType=<type>&entityId=<id>&limit=<n>
+type eventHistoryHandler struct {
+	history EventHistory
+}
+
+func (self *eventHistoryHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		writer.Header().Set("Allow", http.MethodGet)
+		self.respondWithError(writer, http.StatusMethodNotAllowed, errors.New("event history may only be queried with GET"))
+		return
+	}
+
+	query, err := NewEventHistoryQuery(request.URL.Query())
+	if err != nil {
+		self.respondWithError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := self.history.Query(query)
+	if err != nil {
+		pfxlog.Logger().WithField("remoteAddr", request.RemoteAddr).WithError(err).Error("event history query failed")
+		self.respondWithError(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	self.respond(writer, http.StatusOK, result)
+}
+
+func (self *eventHistoryHandler) respondWithError(writer http.ResponseWriter, status int, err error) {
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(status)
+	if err = json.NewEncoder(writer).Encode(map[string]any{"error": map[string]any{"message": err.Error()}}); err != nil {
+		pfxlog.Logger().WithError(err).Error("unable to write event history error response")
+	}
+}
+
+func (self *eventHistoryHandler) respond(writer http.ResponseWriter, status int, data any) {
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(status)
+	if err := json.NewEncoder(writer).Encode(map[string]any{"data": data}); err != nil {
+		pfxlog.Logger().WithError(err).Error("unable to write event history response")
+	}
+}
@@ -42,7 +42,7 @@ func (self *fabricWrapper) WrapRequest(handler api_impl.RequestHandler, request
 			return
 		}
 
-		if !permissions.IsAdmin().IsAllowed(rc.ActivePermissions...) {
+		if !permissions.IsAdmin().IsAllowed(permissions.ForRequest(request.Method, rc.ActivePermissions)...) {
 			rc.RespondWithApiError(errorz.NewUnauthorized())
 			return
 		}
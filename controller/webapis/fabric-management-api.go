@@ -27,6 +27,7 @@ import (
 	"github.com/openziti/foundation/v2/concurrenz"
 	"github.com/openziti/identity"
 	"github.com/openziti/xweb/v2"
+	"github.com/openziti/ziti/controller/api"
 	"github.com/openziti/ziti/controller/api_impl"
 	"github.com/openziti/ziti/controller/env"
 	"github.com/openziti/ziti/controller/handler_mgmt"
@@ -46,25 +47,28 @@ const (
 var _ xweb.ApiHandlerFactory = &FabricManagementApiFactory{}
 
 type FabricManagementApiFactory struct {
-	InitFunc    func(managementApi *FabricManagementApiHandler) error
-	network     *network.Network
-	env         *env.AppEnv
-	nodeId      identity.Identity
-	xmgmts      *concurrenz.CopyOnWriteSlice[xmgmt.Xmgmt]
-	MakeDefault bool
+	InitFunc     func(managementApi *FabricManagementApiHandler) error
+	EventHistory EventHistory
+	network      *network.Network
+	env          *env.AppEnv
+	nodeId       identity.Identity
+	xmgmts       *concurrenz.CopyOnWriteSlice[xmgmt.Xmgmt]
+	reloader     ConfigReloader
+	MakeDefault  bool
 }
 
 func (factory *FabricManagementApiFactory) Validate(_ *xweb.InstanceConfig) error {
 	return nil
 }
 
-func NewFabricManagementApiFactory(nodeId identity.Identity, env *env.AppEnv, network *network.Network, xmgmts *concurrenz.CopyOnWriteSlice[xmgmt.Xmgmt]) *FabricManagementApiFactory {
+func NewFabricManagementApiFactory(nodeId identity.Identity, env *env.AppEnv, network *network.Network, xmgmts *concurrenz.CopyOnWriteSlice[xmgmt.Xmgmt], reloader ConfigReloader) *FabricManagementApiFactory {
 	pfxlog.Logger().Infof("initializing management api factory with %d xmgmt instances", len(xmgmts.Value()))
 	return &FabricManagementApiFactory{
 		env:         env,
 		network:     network,
 		nodeId:      nodeId,
 		xmgmts:      xmgmts,
+		reloader:    reloader,
 		MakeDefault: false,
 	}
 }
@@ -99,7 +103,17 @@ func (factory *FabricManagementApiFactory) New(_ *xweb.ServerConfig, options map
 		return nil, err
 	}
 
+	managementApiHandler.handler = api.WrapMetricsHandler(managementApiHandler.handler, factory.network.GetMetricsRegistry(), api_impl.FabricApiBinding)
 	managementApiHandler.bindHandler = handler_mgmt.NewBindHandler(factory.env, factory.network, factory.xmgmts)
+	managementApiHandler.circuitEventsWsHandler = requestWrapper.WrapWsHandler(newCircuitEventsWsHandler(factory.network))
+	managementApiHandler.pathPinsHandler = requestWrapper.WrapWsHandler(newPathPinsHandler(managementApiHandler.pathPinsUrl, factory.network))
+	managementApiHandler.grpcHandler = requestWrapper.WrapWsHandler(newFabricManagementGrpcHandler(factory.network))
+	if factory.reloader != nil {
+		managementApiHandler.configReloadHandler = requestWrapper.WrapWsHandler(newConfigReloadHandler(factory.reloader))
+	}
+	if factory.EventHistory != nil {
+		managementApiHandler.eventHistoryHandler = requestWrapper.WrapWsHandler(newEventHistoryHandler(factory.EventHistory))
+	}
 
 	if factory.InitFunc != nil {
 		if err := factory.InitFunc(managementApiHandler); err != nil {
@@ -120,18 +134,31 @@ func NewFabricManagementApiHandler(fabricApi *operations.ZitiFabricAPI, isDefaul
 	managementApi.handler = managementApi.newHandler()
 	managementApi.wsHandler = requestWrapper.WrapWsHandler(http.HandlerFunc(managementApi.handleWebSocket))
 	managementApi.wsUrl = rest_client.DefaultBasePath + "/ws-api"
+	managementApi.circuitEventsWsUrl = rest_client.DefaultBasePath + CircuitEventsWsPath
+	managementApi.configReloadUrl = rest_client.DefaultBasePath + ConfigReloadPath
+	managementApi.pathPinsUrl = rest_client.DefaultBasePath + PathPinsPath
+	managementApi.eventHistoryUrl = rest_client.DefaultBasePath + EventHistoryPath
 
 	return managementApi, nil
 }
 
 type FabricManagementApiHandler struct {
-	fabricApi   *operations.ZitiFabricAPI
-	handler     http.Handler
-	wsHandler   http.Handler
-	wsUrl       string
-	options     map[interface{}]interface{}
-	bindHandler channel.BindHandler
-	isDefault   bool
+	fabricApi              *operations.ZitiFabricAPI
+	handler                http.Handler
+	wsHandler              http.Handler
+	wsUrl                  string
+	circuitEventsWsHandler http.Handler
+	circuitEventsWsUrl     string
+	configReloadHandler    http.Handler
+	configReloadUrl        string
+	pathPinsHandler        http.Handler
+	pathPinsUrl            string
+	eventHistoryHandler    http.Handler
+	eventHistoryUrl        string
+	grpcHandler            http.Handler
+	options                map[interface{}]interface{}
+	bindHandler            channel.BindHandler
+	isDefault              bool
 }
 
 func (managementApi *FabricManagementApiHandler) Binding() string {
@@ -147,19 +174,29 @@ func (managementApi *FabricManagementApiHandler) RootPath() string {
 }
 
 func (managementApi *FabricManagementApiHandler) IsHandler(r *http.Request) bool {
-	return strings.HasPrefix(r.URL.Path, managementApi.RootPath())
+	return strings.HasPrefix(r.URL.Path, managementApi.RootPath()) || (managementApi.grpcHandler != nil && isGrpcRequest(r))
 }
 
 func (managementApi *FabricManagementApiHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	if request.URL.Path == managementApi.wsUrl {
+	if managementApi.grpcHandler != nil && isGrpcRequest(request) {
+		managementApi.grpcHandler.ServeHTTP(writer, request)
+	} else if request.URL.Path == managementApi.wsUrl {
 		managementApi.wsHandler.ServeHTTP(writer, request)
+	} else if request.URL.Path == managementApi.circuitEventsWsUrl && managementApi.circuitEventsWsHandler != nil {
+		managementApi.circuitEventsWsHandler.ServeHTTP(writer, request)
+	} else if request.URL.Path == managementApi.configReloadUrl && managementApi.configReloadHandler != nil {
+		managementApi.configReloadHandler.ServeHTTP(writer, request)
+	} else if request.URL.Path == managementApi.eventHistoryUrl && managementApi.eventHistoryHandler != nil {
+		managementApi.eventHistoryHandler.ServeHTTP(writer, request)
+	} else if managementApi.pathPinsHandler != nil && (request.URL.Path == managementApi.pathPinsUrl || strings.HasPrefix(request.URL.Path, managementApi.pathPinsUrl+"/")) {
+		managementApi.pathPinsHandler.ServeHTTP(writer, request)
 	} else {
 		managementApi.handler.ServeHTTP(writer, request)
 	}
 }
 
 func (managementApi *FabricManagementApiHandler) newHandler() http.Handler {
-	innerManagementHandler := managementApi.fabricApi.Serve(nil)
+	innerManagementHandler := managementApi.fabricApi.Serve(api.MetricsRouteBuilder)
 	return requestWrapper.WrapHttpHandler(innerManagementHandler)
 }
 
@@ -0,0 +1,638 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/storage/ast"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/common/pb/mgmt_grpc_pb"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/openziti/ziti/controller/model"
+	"github.com/openziti/ziti/controller/models"
+	"github.com/openziti/ziti/controller/network"
+	"github.com/openziti/ziti/controller/xt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const grpcEventsQueueSize = 1024
+
+// isGrpcRequest returns true for gRPC requests, which are served alongside the REST API on the same address
+func isGrpcRequest(request *http.Request) bool {
+	return request.ProtoMajor == 2 && strings.HasPrefix(request.Header.Get("content-type"), "application/grpc")
+}
+
+func newFabricManagementGrpcHandler(network *network.Network) http.Handler {
+	server := grpc.NewServer()
+	mgmt_grpc_pb.RegisterFabricManagementServer(server, &fabricManagementGrpcServer{network: network})
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// streams outlive the server read and write timeouts, so those are cleared for streaming calls
+		if request.URL.Path == mgmt_grpc_pb.FabricManagement_StreamEvents_FullMethodName ||
+			request.URL.Path == mgmt_grpc_pb.FabricManagement_StreamCircuits_FullMethodName {
+			controller := http.NewResponseController(writer)
+			_ = controller.SetReadDeadline(time.Time{})
+			_ = controller.SetWriteDeadline(time.Time{})
+		}
+		server.ServeHTTP(writer, request)
+	})
+}
+
+// fabricManagementGrpcServer implements the gRPC version of the fabric management API. Requests are authenticated
+// by the request wrapper before they get here, the same way websocket requests are.
+type fabricManagementGrpcServer struct {
+	mgmt_grpc_pb.UnimplementedFabricManagementServer
+	network *network.Network
+}
+
+func (self *fabricManagementGrpcServer) ListServices(_ context.Context, request *mgmt_grpc_pb.ListRequest) (*mgmt_grpc_pb.ListServicesResponse, error) {
+	manager := self.network.Managers.Service
+	query, err := parseGrpcQuery(manager.GetStore(), request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manager.BasePreparedList(query)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	response := &mgmt_grpc_pb.ListServicesResponse{
+		Meta: toGrpcListMeta(&result.QueryMetaData),
+	}
+
+	for _, service := range result.Entities {
+		grpcService, err := self.toGrpcService(service)
+		if err != nil {
+			return nil, err
+		}
+		response.Services = append(response.Services, grpcService)
+	}
+
+	return response, nil
+}
+
+func (self *fabricManagementGrpcServer) GetService(_ context.Context, request *mgmt_grpc_pb.GetRequest) (*mgmt_grpc_pb.Service, error) {
+	service, err := self.network.Managers.Service.Read(request.Id)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	return self.toGrpcService(service)
+}
+
+func (self *fabricManagementGrpcServer) toGrpcService(service *model.Service) (*mgmt_grpc_pb.Service, error) {
+	tags, err := toGrpcTags(service.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mgmt_grpc_pb.Service{
+		Id:                  service.Id,
+		Name:                service.Name,
+		TerminatorStrategy:  service.TerminatorStrategy,
+		MaxIdleTime:         durationpb.New(service.MaxIdleTime),
+		CircuitBreakerState: self.network.GetServiceCircuitBreakerState(service.Id),
+		Tags:                tags,
+		CreatedAt:           timestamppb.New(service.CreatedAt),
+		UpdatedAt:           timestamppb.New(service.UpdatedAt),
+	}, nil
+}
+
+func (self *fabricManagementGrpcServer) ListRouters(_ context.Context, request *mgmt_grpc_pb.ListRequest) (*mgmt_grpc_pb.ListRoutersResponse, error) {
+	manager := self.network.Managers.Router
+	query, err := parseGrpcQuery(manager.GetStore(), request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manager.BasePreparedList(query)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	response := &mgmt_grpc_pb.ListRoutersResponse{
+		Meta: toGrpcListMeta(&result.QueryMetaData),
+	}
+
+	for _, router := range result.Entities {
+		grpcRouter, err := self.toGrpcRouter(router)
+		if err != nil {
+			return nil, err
+		}
+		response.Routers = append(response.Routers, grpcRouter)
+	}
+
+	return response, nil
+}
+
+func (self *fabricManagementGrpcServer) GetRouter(_ context.Context, request *mgmt_grpc_pb.GetRequest) (*mgmt_grpc_pb.Router, error) {
+	router, err := self.network.Managers.Router.Read(request.Id)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	return self.toGrpcRouter(router)
+}
+
+func (self *fabricManagementGrpcServer) toGrpcRouter(router *model.Router) (*mgmt_grpc_pb.Router, error) {
+	tags, err := toGrpcTags(router.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &mgmt_grpc_pb.Router{
+		Id:          router.Id,
+		Name:        router.Name,
+		Cost:        uint32(router.Cost),
+		NoTraversal: router.NoTraversal,
+		Disabled:    router.Disabled,
+		Tags:        tags,
+		CreatedAt:   timestamppb.New(router.CreatedAt),
+		UpdatedAt:   timestamppb.New(router.UpdatedAt),
+	}
+
+	if router.Fingerprint != nil {
+		result.Fingerprint = *router.Fingerprint
+	}
+
+	if connected := self.network.GetConnectedRouter(router.Id); connected != nil {
+		result.Connected = true
+		if connected.VersionInfo != nil {
+			result.Version = connected.VersionInfo.Version
+		}
+		for _, listener := range connected.Listeners {
+			result.Listeners = append(result.Listeners, &mgmt_grpc_pb.RouterListener{
+				Address:  listener.GetAddress(),
+				Protocol: listener.GetProtocol(),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (self *fabricManagementGrpcServer) ListTerminators(_ context.Context, request *mgmt_grpc_pb.ListRequest) (*mgmt_grpc_pb.ListTerminatorsResponse, error) {
+	manager := self.network.Managers.Terminator
+	query, err := parseGrpcQuery(manager.GetStore(), request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := manager.BasePreparedList(query)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	response := &mgmt_grpc_pb.ListTerminatorsResponse{
+		Meta: toGrpcListMeta(&result.QueryMetaData),
+	}
+
+	for _, terminator := range result.Entities {
+		grpcTerminator, err := self.toGrpcTerminator(terminator)
+		if err != nil {
+			return nil, err
+		}
+		response.Terminators = append(response.Terminators, grpcTerminator)
+	}
+
+	return response, nil
+}
+
+func (self *fabricManagementGrpcServer) GetTerminator(_ context.Context, request *mgmt_grpc_pb.GetRequest) (*mgmt_grpc_pb.Terminator, error) {
+	terminator, err := self.network.Managers.Terminator.Read(request.Id)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	return self.toGrpcTerminator(terminator)
+}
+
+func (self *fabricManagementGrpcServer) toGrpcTerminator(terminator *model.Terminator) (*mgmt_grpc_pb.Terminator, error) {
+	tags, err := toGrpcTags(terminator.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &mgmt_grpc_pb.Terminator{
+		Id:          terminator.Id,
+		Service:     &mgmt_grpc_pb.EntityRef{Id: terminator.Service},
+		Router:      &mgmt_grpc_pb.EntityRef{Id: terminator.Router},
+		Binding:     terminator.Binding,
+		Address:     terminator.Address,
+		InstanceId:  terminator.InstanceId,
+		HostId:      terminator.HostId,
+		Cost:        uint32(terminator.Cost),
+		DynamicCost: uint32(xt.GlobalCosts().GetDynamicCost(terminator.Id)),
+		Tags:        tags,
+		CreatedAt:   timestamppb.New(terminator.CreatedAt),
+		UpdatedAt:   timestamppb.New(terminator.UpdatedAt),
+	}
+
+	if terminator.Precedence != nil {
+		result.Precedence = terminator.Precedence.String()
+	}
+
+	if service, _ := self.network.Managers.Service.Read(terminator.Service); service != nil {
+		result.Service.Name = service.Name
+	}
+
+	if router, _ := self.network.Managers.Router.Read(terminator.Router); router != nil {
+		result.Router.Name = router.Name
+	}
+
+	return result, nil
+}
+
+func (self *fabricManagementGrpcServer) ListLinks(_ context.Context, request *mgmt_grpc_pb.ListRequest) (*mgmt_grpc_pb.ListLinksResponse, error) {
+	query, err := parseGrpcQuery(self.network.GetLinkStore(), request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	links, count, err := self.network.GetLinkStore().QueryEntitiesC(query)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	response := &mgmt_grpc_pb.ListLinksResponse{
+		Meta: &mgmt_grpc_pb.ListMeta{
+			Count:  count,
+			Limit:  *query.GetLimit(),
+			Offset: *query.GetSkip(),
+		},
+	}
+
+	for _, link := range links {
+		response.Links = append(response.Links, self.toGrpcLink(link))
+	}
+
+	return response, nil
+}
+
+func (self *fabricManagementGrpcServer) GetLink(_ context.Context, request *mgmt_grpc_pb.GetRequest) (*mgmt_grpc_pb.Link, error) {
+	link, found := self.network.GetLink(request.Id)
+	if !found {
+		return nil, toGrpcError(boltz.NewNotFoundError("link", "id", request.Id))
+	}
+	return self.toGrpcLink(link), nil
+}
+
+func (self *fabricManagementGrpcServer) toGrpcLink(link *model.Link) *mgmt_grpc_pb.Link {
+	result := &mgmt_grpc_pb.Link{
+		Id:            link.Id,
+		SourceRouter:  &mgmt_grpc_pb.EntityRef{Id: link.Src.Id, Name: link.Src.Name},
+		DestRouter:    &mgmt_grpc_pb.EntityRef{Id: link.DstId},
+		Protocol:      link.Protocol,
+		State:         link.CurrentState().Mode.String(),
+		Down:          link.IsDown(),
+		Cost:          link.GetCost(),
+		StaticCost:    link.GetStaticCost(),
+		SourceLatency: link.GetSrcLatency(),
+		DestLatency:   link.GetDstLatency(),
+		Iteration:     link.Iteration,
+		PathMtu:       link.GetPathMtu(),
+	}
+
+	destRouter := link.GetDest()
+	if destRouter == nil {
+		destRouter, _ = self.network.Managers.Router.Read(link.DstId)
+	}
+	if destRouter != nil {
+		result.DestRouter.Name = destRouter.Name
+	}
+
+	if connState := link.GetConnsState(); connState != nil {
+		for _, conn := range connState.Conns {
+			result.Connections = append(result.Connections, &mgmt_grpc_pb.LinkConnection{
+				Type:       conn.Type,
+				LocalAddr:  conn.LocalAddr,
+				RemoteAddr: conn.RemoteAddr,
+			})
+		}
+	}
+
+	return result
+}
+
+func (self *fabricManagementGrpcServer) ListCircuits(_ context.Context, request *mgmt_grpc_pb.ListRequest) (*mgmt_grpc_pb.ListCircuitsResponse, error) {
+	query, err := parseGrpcQuery(self.network.GetCircuitStore(), request.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	circuits, count, err := self.network.GetCircuitStore().QueryEntitiesC(query)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	response := &mgmt_grpc_pb.ListCircuitsResponse{
+		Meta: &mgmt_grpc_pb.ListMeta{
+			Count:  count,
+			Limit:  *query.GetLimit(),
+			Offset: *query.GetSkip(),
+		},
+	}
+
+	for _, circuit := range circuits {
+		response.Circuits = append(response.Circuits, self.toGrpcCircuit(circuit))
+	}
+
+	return response, nil
+}
+
+func (self *fabricManagementGrpcServer) GetCircuit(_ context.Context, request *mgmt_grpc_pb.GetRequest) (*mgmt_grpc_pb.Circuit, error) {
+	circuit, found := self.network.GetCircuit(request.Id)
+	if !found {
+		return nil, toGrpcError(boltz.NewNotFoundError("circuit", "id", request.Id))
+	}
+	return self.toGrpcCircuit(circuit), nil
+}
+
+func (self *fabricManagementGrpcServer) toGrpcCircuit(circuit *model.Circuit) *mgmt_grpc_pb.Circuit {
+	result := &mgmt_grpc_pb.Circuit{
+		Id:        circuit.Id,
+		ClientId:  circuit.ClientId,
+		Service:   &mgmt_grpc_pb.EntityRef{Id: circuit.ServiceId},
+		Tags:      circuit.Tags,
+		CreatedAt: timestamppb.New(circuit.CreatedAt),
+		UpdatedAt: timestamppb.New(circuit.UpdatedAt),
+	}
+
+	if circuit.Terminator != nil {
+		result.TerminatorId = circuit.Terminator.GetId()
+	}
+
+	if service, _ := self.network.Managers.Service.Read(circuit.ServiceId); service != nil {
+		result.Service.Name = service.Name
+	}
+
+	if path := circuit.Path; path != nil {
+		result.Path = &mgmt_grpc_pb.CircuitPath{
+			IngressId:            path.IngressId,
+			EgressId:             path.EgressId,
+			InitiatorLocalAddr:   path.InitiatorLocalAddr,
+			InitiatorRemoteAddr:  path.InitiatorRemoteAddr,
+			TerminatorLocalAddr:  path.TerminatorLocalAddr,
+			TerminatorRemoteAddr: path.TerminatorRemoteAddr,
+		}
+		for _, node := range path.Nodes {
+			result.Path.Nodes = append(result.Path.Nodes, node.Id)
+		}
+		for _, link := range path.Links {
+			result.Path.Links = append(result.Path.Links, link.Id)
+		}
+	}
+
+	return result
+}
+
+func (self *fabricManagementGrpcServer) DeleteCircuit(_ context.Context, request *mgmt_grpc_pb.DeleteCircuitRequest) (*mgmt_grpc_pb.DeleteCircuitResponse, error) {
+	if err := self.network.RemoveCircuit(request.Id, request.Immediate); err != nil {
+		return nil, toGrpcError(err)
+	}
+	return &mgmt_grpc_pb.DeleteCircuitResponse{}, nil
+}
+
+func (self *fabricManagementGrpcServer) StreamEvents(request *mgmt_grpc_pb.StreamEventsRequest, stream grpc.ServerStreamingServer[mgmt_grpc_pb.Event]) error {
+	var subscriptions []*event.Subscription
+	for _, subscription := range request.Subscriptions {
+		subscriptions = append(subscriptions, &event.Subscription{
+			Type:    subscription.Type,
+			Options: subscription.Options.AsMap(),
+		})
+	}
+
+	sink := &grpcEventsSink{
+		eventC:      make(chan *mgmt_grpc_pb.Event, grpcEventsQueueSize),
+		closeNotify: make(chan struct{}),
+	}
+
+	dispatcher := self.network.GetEventDispatcher()
+	formatter := dispatcher.GetFormatterFactory("json").NewFormatter(sink)
+	defer func() {
+		dispatcher.RemoveAllSubscriptions(formatter)
+		if err := formatter.Close(); err != nil {
+			pfxlog.Logger().WithError(err).Error("error while closing grpc event stream formatter")
+		}
+	}()
+
+	if err := dispatcher.ProcessSubscriptions(formatter, subscriptions); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	for {
+		select {
+		case evt := <-sink.eventC:
+			if err := stream.Send(evt); err != nil {
+				return err
+			}
+		case <-sink.closeNotify:
+			return status.Error(codes.ResourceExhausted, "event queue overflow")
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// grpcEventsSink queues formatted events for a gRPC events stream
+type grpcEventsSink struct {
+	eventC      chan *mgmt_grpc_pb.Event
+	overflowed  atomic.Bool
+	closeNotify chan struct{}
+}
+
+func (self *grpcEventsSink) AcceptFormattedEvent(eventType string, formattedEvent []byte) {
+	evt := &mgmt_grpc_pb.Event{
+		Type: eventType,
+		Json: string(formattedEvent),
+	}
+
+	select {
+	case self.eventC <- evt:
+	case <-self.closeNotify:
+	default:
+		// as with the websocket streams, end the stream rather than silently dropping events
+		if self.overflowed.CompareAndSwap(false, true) {
+			close(self.closeNotify)
+		}
+	}
+}
+
+func (self *fabricManagementGrpcServer) StreamCircuits(request *mgmt_grpc_pb.StreamCircuitsRequest, stream grpc.ServerStreamingServer[mgmt_grpc_pb.CircuitEvent]) error {
+	filter, err := NewCircuitEventsFilter(url.Values{
+		"serviceId": request.ServiceIds,
+		"routerId":  request.RouterIds,
+		"type":      request.EventTypes,
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	handler := &grpcCircuitEventsHandler{
+		filter:      filter,
+		eventC:      make(chan *event.CircuitEvent, grpcEventsQueueSize),
+		closeNotify: make(chan struct{}),
+	}
+
+	dispatcher := self.network.GetEventDispatcher()
+	dispatcher.AddCircuitEventHandler(handler)
+	defer dispatcher.RemoveCircuitEventHandler(handler)
+
+	for {
+		select {
+		case evt := <-handler.eventC:
+			if err := stream.Send(toGrpcCircuitEvent(evt)); err != nil {
+				return err
+			}
+		case <-handler.closeNotify:
+			return status.Error(codes.ResourceExhausted, "circuit event queue overflow")
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+type grpcCircuitEventsHandler struct {
+	filter      *CircuitEventsFilter
+	eventC      chan *event.CircuitEvent
+	overflowed  atomic.Bool
+	closeNotify chan struct{}
+}
+
+func (self *grpcCircuitEventsHandler) AcceptCircuitEvent(evt *event.CircuitEvent) {
+	if !self.filter.Matches(evt) {
+		return
+	}
+
+	select {
+	case self.eventC <- evt:
+	case <-self.closeNotify:
+	default:
+		if self.overflowed.CompareAndSwap(false, true) {
+			close(self.closeNotify)
+		}
+	}
+}
+
+func toGrpcCircuitEvent(evt *event.CircuitEvent) *mgmt_grpc_pb.CircuitEvent {
+	result := &mgmt_grpc_pb.CircuitEvent{
+		EventType:    string(evt.EventType),
+		EventSrcId:   evt.EventSrcId,
+		Timestamp:    timestamppb.New(evt.Timestamp),
+		CircuitId:    evt.CircuitId,
+		ClientId:     evt.ClientId,
+		ServiceId:    evt.ServiceId,
+		TerminatorId: evt.TerminatorId,
+		InstanceId:   evt.InstanceId,
+		Path: &mgmt_grpc_pb.CircuitPath{
+			Nodes:                evt.Path.Nodes,
+			Links:                evt.Path.Links,
+			IngressId:            evt.Path.IngressId,
+			EgressId:             evt.Path.EgressId,
+			InitiatorLocalAddr:   evt.Path.InitiatorLocalAddr,
+			InitiatorRemoteAddr:  evt.Path.InitiatorRemoteAddr,
+			TerminatorLocalAddr:  evt.Path.TerminatorLocalAddr,
+			TerminatorRemoteAddr: evt.Path.TerminatorRemoteAddr,
+		},
+		LinkCount: uint32(evt.LinkCount),
+		Tags:      evt.Tags,
+	}
+
+	if evt.Cost != nil {
+		result.Cost = *evt.Cost
+	}
+
+	if evt.FailureCause != nil {
+		result.FailureCause = *evt.FailureCause
+	}
+
+	if evt.CreationTimespan != nil {
+		result.CreationTimespan = durationpb.New(*evt.CreationTimespan)
+	}
+
+	if evt.Duration != nil {
+		result.Duration = durationpb.New(*evt.Duration)
+	}
+
+	return result
+}
+
+// parseGrpcQuery parses list filters the same way the REST API does, including the default and maximum limits
+func parseGrpcQuery(symbolTypes ast.SymbolTypes, filter string) (ast.Query, error) {
+	if filter == "" {
+		filter = "true"
+	}
+
+	query, err := ast.Parse(symbolTypes, filter)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+
+	if store, isStore := symbolTypes.(boltz.Store); isStore {
+		if err = boltz.ValidateSymbolsArePublic(query, store); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+		}
+	}
+
+	if query.GetLimit() == nil || *query.GetLimit() <= 0 {
+		query.SetLimit(models.ListLimitDefault)
+	} else if *query.GetLimit() > models.ListLimitMax {
+		query.SetLimit(models.ListLimitMax)
+	}
+
+	if query.GetSkip() == nil || *query.GetSkip() < 0 {
+		query.SetSkip(0)
+	}
+
+	return query, nil
+}
+
+func toGrpcListMeta(metadata *models.QueryMetaData) *mgmt_grpc_pb.ListMeta {
+	return &mgmt_grpc_pb.ListMeta{
+		Count:  metadata.Count,
+		Limit:  metadata.Limit,
+		Offset: metadata.Offset,
+	}
+}
+
+func toGrpcTags(tags map[string]interface{}) (*structpb.Struct, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	result, err := structpb.NewStruct(tags)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to convert tags: %v", err)
+	}
+	return result, nil
+}
+
+func toGrpcError(err error) error {
+	if boltz.IsErrNotFoundErr(err) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,104 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/controller/event"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsGrpcRequest(t *testing.T) {
+	req := require.New(t)
+
+	request := &http.Request{ProtoMajor: 2, Header: http.Header{}}
+	request.Header.Set("content-type", "application/grpc+proto")
+	req.True(isGrpcRequest(request))
+
+	request.ProtoMajor = 1
+	req.False(isGrpcRequest(request))
+
+	request.ProtoMajor = 2
+	request.Header.Set("content-type", "application/json")
+	req.False(isGrpcRequest(request))
+}
+
+func TestToGrpcCircuitEvent(t *testing.T) {
+	req := require.New(t)
+
+	cost := uint32(42)
+	failureCause := "NO_TERMINATORS"
+	creationTimespan := 25 * time.Millisecond
+
+	evt := &event.CircuitEvent{
+		EventType:        event.CircuitFailed,
+		Timestamp:        time.Now(),
+		CircuitId:        "c1",
+		ServiceId:        "svc1",
+		Path:             event.CircuitPath{Nodes: []string{"r1", "r2"}, Links: []string{"l1"}},
+		LinkCount:        1,
+		Cost:             &cost,
+		FailureCause:     &failureCause,
+		CreationTimespan: &creationTimespan,
+		Tags:             map[string]string{"clientId": "i1"},
+	}
+
+	result := toGrpcCircuitEvent(evt)
+	req.Equal("failed", result.EventType)
+	req.Equal("c1", result.CircuitId)
+	req.Equal([]string{"r1", "r2"}, result.Path.Nodes)
+	req.Equal([]string{"l1"}, result.Path.Links)
+	req.Equal(uint32(1), result.LinkCount)
+	req.Equal(cost, result.Cost)
+	req.Equal(failureCause, result.FailureCause)
+	req.Equal(creationTimespan, result.CreationTimespan.AsDuration())
+	req.Nil(result.Duration)
+	req.Equal(evt.Timestamp.UnixNano(), result.Timestamp.AsTime().UnixNano())
+	req.Equal("i1", result.Tags["clientId"])
+}
+
+func TestGrpcCircuitEventsHandlerOverflow(t *testing.T) {
+	req := require.New(t)
+
+	handler := &grpcCircuitEventsHandler{
+		filter:      &CircuitEventsFilter{ServiceIds: []string{"svc1"}},
+		eventC:      make(chan *event.CircuitEvent, 1),
+		closeNotify: make(chan struct{}),
+	}
+
+	handler.AcceptCircuitEvent(&event.CircuitEvent{ServiceId: "svc2"})
+	req.Len(handler.eventC, 0)
+
+	handler.AcceptCircuitEvent(&event.CircuitEvent{ServiceId: "svc1"})
+	req.Len(handler.eventC, 1)
+
+	handler.AcceptCircuitEvent(&event.CircuitEvent{ServiceId: "svc1"})
+	req.True(handler.overflowed.Load())
+
+	select {
+	case <-handler.closeNotify:
+	default:
+		req.Fail("expected close notify to be closed on overflow")
+	}
+
+	// further events are ignored once the stream is closing
+	handler.AcceptCircuitEvent(&event.CircuitEvent{ServiceId: "svc1"})
+	req.Len(handler.eventC, 1)
+}
@@ -92,7 +92,7 @@ func (managementApi ManagementApiHandler) RootPath() string {
 }
 
 func (managementApi ManagementApiHandler) IsHandler(r *http.Request) bool {
-	return strings.HasPrefix(r.URL.Path, managementApi.RootPath()) || r.URL.Path == WellKnownEstCaCerts || r.URL.Path == VersionPath || r.URL.Path == RootPath
+	return strings.HasPrefix(r.URL.Path, managementApi.RootPath()) || r.URL.Path == WellKnownEstCaCerts || r.URL.Path == WellKnownTrustBundle || r.URL.Path == VersionPath || r.URL.Path == RootPath
 }
 
 func (managementApi ManagementApiHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
@@ -111,11 +111,16 @@ func NewManagementApiHandler(ae *env.AppEnv, options map[interface{}]interface{}
 }
 
 func (managementApi ManagementApiHandler) newHandler(ae *env.AppEnv) http.Handler {
-	innerManagementHandler := ae.ManagementApi.Serve(nil)
+	innerManagementHandler := ae.ManagementApi.Serve(api.MetricsRouteBuilder)
 
 	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		rw.Header().Set(ZitiInstanceId, ae.InstanceId)
 
+		if r.URL.Path == WellKnownTrustBundle {
+			serveTrustBundle(ae, rw, r)
+			return
+		}
+
 		if r.URL.Path == ManagementRestApiSpecUrl {
 			rw.Header().Set("content-type", "application/json")
 			rw.WriteHeader(http.StatusOK)
@@ -137,17 +142,39 @@ func (managementApi ManagementApiHandler) newHandler(ae *env.AppEnv) http.Handle
 
 		api.AddRequestContextToHttpContext(r, rc)
 
+		if !ae.ApiRateLimiter.CheckIp(managementApiRateLimiterName, rc) {
+			return
+		}
+
 		err := ae.FillRequestContext(rc)
 		if err != nil {
 			rc.RespondWithError(err)
 			return
 		}
 
+		if !ae.ApiRateLimiter.CheckIdentity(managementApiRateLimiterName, rc) {
+			return
+		}
+
 		//after request context is filled so that api session is present for session expiration headers
 		response.AddHeaders(rc)
 
+		if subPath, found := strings.CutPrefix(r.URL.Path, ManagementRestApiBaseUrlLatest); found {
+			if err = applyAdminScope(ae, rc, subPath); err != nil {
+				rc.RespondWithError(err)
+				return
+			}
+
+			if extraHandler := ae.GetManagementApiHandler(subPath); extraHandler != nil {
+				api.SetMetricsRoute(r, subPath)
+				extraHandler.ServeHTTP(rw, r)
+				return
+			}
+		}
+
 		innerManagementHandler.ServeHTTP(rw, r)
 	})
 
-	return api.TimeoutHandler(api.WrapCorsHandler(handler), 10*time.Second, apierror.NewTimeoutError(), response.EdgeResponseMapper{})
+	timeoutHandler := api.TimeoutHandler(api.WrapCorsHandler(handler), 10*time.Second, apierror.NewTimeoutError(), response.EdgeResponseMapper{})
+	return api.WrapMetricsHandler(timeoutHandler, ae.GetMetricsRegistry(), ManagementApiBinding)
 }
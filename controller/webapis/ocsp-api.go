@@ -0,0 +1,119 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/openziti/xweb/v2"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/ocsp"
+	"github.com/pkg/errors"
+)
+
+var _ xweb.ApiHandlerFactory = &OcspApiFactory{}
+
+// OcspApiFactory creates the OCSP responder, which answers revocation status requests for certificates issued by the
+// controller's edge signing certificate
+type OcspApiFactory struct {
+	appEnv *env.AppEnv
+
+	sourceLock sync.Mutex
+	source     *ocsp.ModelStatusSource
+}
+
+func NewOcspApiFactory(appEnv *env.AppEnv) *OcspApiFactory {
+	return &OcspApiFactory{
+		appEnv: appEnv,
+	}
+}
+
+func (factory *OcspApiFactory) Validate(_ *xweb.InstanceConfig) error {
+	return nil
+}
+
+func (factory *OcspApiFactory) Binding() string {
+	return OcspApiBinding
+}
+
+func (factory *OcspApiFactory) New(_ *xweb.ServerConfig, options map[interface{}]interface{}) (xweb.ApiHandler, error) {
+	config, err := ocsp.LoadConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := factory.appEnv.GetApiClientCsrSigner()
+	if signer == nil {
+		return nil, errors.New("the ocsp api requires the edge enrollment signing certificate to be configured")
+	}
+
+	source, err := factory.getSource()
+	if err != nil {
+		return nil, err
+	}
+
+	return &OcspApiHandler{
+		handler: ocsp.NewResponder(config, signer, source),
+		options: options,
+	}, nil
+}
+
+// getSource returns the status source shared by all ocsp api instances, so the certificate index is only built once
+func (factory *OcspApiFactory) getSource() (*ocsp.ModelStatusSource, error) {
+	factory.sourceLock.Lock()
+	defer factory.sourceLock.Unlock()
+
+	if factory.source == nil {
+		source, err := ocsp.NewModelStatusSource(factory.appEnv)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to index issued certificates for ocsp")
+		}
+		factory.source = source
+	}
+	return factory.source, nil
+}
+
+type OcspApiHandler struct {
+	handler http.Handler
+	options map[interface{}]interface{}
+}
+
+func (self *OcspApiHandler) Binding() string {
+	return OcspApiBinding
+}
+
+func (self *OcspApiHandler) Options() map[interface{}]interface{} {
+	return self.options
+}
+
+func (self *OcspApiHandler) RootPath() string {
+	return OcspApiBaseUrl
+}
+
+func (self *OcspApiHandler) IsHandler(r *http.Request) bool {
+	return r.URL.Path == self.RootPath() || strings.HasPrefix(r.URL.Path, self.RootPath()+"/")
+}
+
+func (self *OcspApiHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	self.handler.ServeHTTP(writer, request)
+}
+
+func (self *OcspApiHandler) IsDefault() bool {
+	return false
+}
@@ -0,0 +1,137 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/network"
+)
+
+const (
+	PathPinsPath = "/path-pins"
+)
+
+type PathPinRequest struct {
+	RouterIds []string `json:"routerIds"`
+}
+
+func newPathPinsHandler(basePath string, network *network.Network) http.Handler {
+	return &pathPinsHandler{
+		basePath: basePath,
+		network:  network,
+	}
+}
+
+// pathPinsHandler manages circuit and service path pins. It serves:
+//
+//	GET    <base>/path-pins
+//	PUT    <base>/path-pins/circuits/<circuitId>
+//	DELETE <base>/path-pins/circuits/<circuitId>
+//	PUT    <base>/path-pins/services/<serviceId>
+//	DELETE <base>/path-pins/services/<serviceId>
+type pathPinsHandler struct {
+	basePath string
+	network  *network.Network
+}
+
+func (self *pathPinsHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	subPath := strings.Trim(strings.TrimPrefix(request.URL.Path, self.basePath), "/")
+
+	if subPath == "" {
+		if request.Method != http.MethodGet {
+			writer.Header().Set("Allow", http.MethodGet)
+			self.respondWithError(writer, http.StatusMethodNotAllowed, errors.New("path pins may only be listed with GET"))
+			return
+		}
+		pins := self.network.GetPathPins()
+		if pins == nil {
+			pins = []*network.PathPin{}
+		}
+		self.respond(writer, http.StatusOK, pins)
+		return
+	}
+
+	entityType, id, _ := strings.Cut(subPath, "/")
+	if id == "" || strings.Contains(id, "/") || (entityType != "circuits" && entityType != "services") {
+		self.respondWithError(writer, http.StatusNotFound, errors.New("path pins may only be set on circuits or services"))
+		return
+	}
+
+	log := pfxlog.Logger().WithField("remoteAddr", request.RemoteAddr).WithField("entityType", entityType).WithField("id", id)
+
+	var err error
+	switch request.Method {
+	case http.MethodPut:
+		pinRequest := &PathPinRequest{}
+		if err = json.NewDecoder(request.Body).Decode(pinRequest); err != nil {
+			self.respondWithError(writer, http.StatusBadRequest, err)
+			return
+		}
+		log.WithField("routerIds", pinRequest.RouterIds).Info("path pin requested via management api")
+		if entityType == "circuits" {
+			err = self.network.PinCircuitPath(id, pinRequest.RouterIds)
+		} else {
+			err = self.network.PinServicePath(id, pinRequest.RouterIds)
+		}
+	case http.MethodDelete:
+		log.Info("path pin removal requested via management api")
+		if entityType == "circuits" {
+			err = self.network.UnpinCircuitPath(id)
+		} else if !self.network.UnpinServicePath(id) {
+			err = boltz.NewNotFoundError("service path pin", "id", id)
+		}
+	default:
+		writer.Header().Set("Allow", http.MethodPut+", "+http.MethodDelete)
+		self.respondWithError(writer, http.StatusMethodNotAllowed, errors.New("path pins may only be set with PUT or removed with DELETE"))
+		return
+	}
+
+	if err != nil {
+		status := http.StatusBadRequest
+		var invalidCircuitErr network.InvalidCircuitError
+		if boltz.IsErrNotFoundErr(err) || errors.As(err, &invalidCircuitErr) {
+			status = http.StatusNotFound
+		}
+		log.WithError(err).Error("path pin update failed")
+		self.respondWithError(writer, status, err)
+		return
+	}
+
+	self.respond(writer, http.StatusOK, map[string]any{})
+}
+
+func (self *pathPinsHandler) respondWithError(writer http.ResponseWriter, status int, err error) {
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(status)
+	if err = json.NewEncoder(writer).Encode(map[string]any{"error": map[string]any{"message": err.Error()}}); err != nil {
+		pfxlog.Logger().WithError(err).Error("unable to write path pin error response")
+	}
+}
+
+func (self *pathPinsHandler) respond(writer http.ResponseWriter, status int, data any) {
+	writer.Header().Set("content-type", "application/json")
+	writer.WriteHeader(status)
+	if err := json.NewEncoder(writer).Encode(map[string]any{"data": data}); err != nil {
+		pfxlog.Logger().WithError(err).Error("unable to write path pin response")
+	}
+}
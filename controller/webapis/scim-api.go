@@ -0,0 +1,91 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/openziti/xweb/v2"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/scim"
+)
+
+var _ xweb.ApiHandlerFactory = &ScimApiFactory{}
+
+// ScimApiFactory creates the SCIM 2.0 provisioning API, which lets identity providers such as Okta or Entra ID
+// create, update and remove identities, and manage role attributes through group membership
+type ScimApiFactory struct {
+	appEnv *env.AppEnv
+}
+
+func NewScimApiFactory(appEnv *env.AppEnv) *ScimApiFactory {
+	return &ScimApiFactory{
+		appEnv: appEnv,
+	}
+}
+
+func (factory *ScimApiFactory) Validate(_ *xweb.InstanceConfig) error {
+	return nil
+}
+
+func (factory *ScimApiFactory) Binding() string {
+	return ScimApiBinding
+}
+
+func (factory *ScimApiFactory) New(_ *xweb.ServerConfig, options map[interface{}]interface{}) (xweb.ApiHandler, error) {
+	config, err := scim.LoadConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	store := scim.NewModelStore(factory.appEnv.GetManagers(), config)
+
+	return &ScimApiHandler{
+		handler: scim.NewHandler(ScimApiBaseUrlV2, config, store),
+		options: options,
+	}, nil
+}
+
+type ScimApiHandler struct {
+	handler http.Handler
+	options map[interface{}]interface{}
+}
+
+func (self *ScimApiHandler) Binding() string {
+	return ScimApiBinding
+}
+
+func (self *ScimApiHandler) Options() map[interface{}]interface{} {
+	return self.options
+}
+
+func (self *ScimApiHandler) RootPath() string {
+	return ScimApiBaseUrlV2
+}
+
+func (self *ScimApiHandler) IsHandler(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, self.RootPath())
+}
+
+func (self *ScimApiHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	self.handler.ServeHTTP(writer, request)
+}
+
+func (self *ScimApiHandler) IsDefault() bool {
+	return false
+}
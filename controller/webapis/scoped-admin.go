@@ -0,0 +1,261 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openziti/foundation/v2/errorz"
+	"github.com/openziti/storage/ast"
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/env"
+	"github.com/openziti/ziti/controller/internal/permissions"
+	"github.com/openziti/ziti/controller/response"
+	"go.etcd.io/bbolt"
+)
+
+// scopedAdminCollection describes a management API collection which scoped admins may manage
+type scopedAdminCollection struct {
+	store func(stores *db.Stores) boltz.Store
+
+	// roleFields maps role attribute fields in create and update bodies to the collection of the entities they
+	// reference. Scoped admins may only reference in scope entities from these fields, and only by id or name.
+	roleFields map[string]string
+
+	// refFields maps fields in create and update bodies holding entity ids or names to the collection of the
+	// entities they reference
+	refFields map[string]string
+}
+
+var scopedAdminCollections = map[string]*scopedAdminCollection{
+	"identities": {
+		store: func(stores *db.Stores) boltz.Store { return stores.Identity },
+	},
+	"services": {
+		store:     func(stores *db.Stores) boltz.Store { return stores.EdgeService },
+		refFields: map[string]string{"configs": "configs"},
+	},
+	"configs": {
+		store: func(stores *db.Stores) boltz.Store { return stores.Config },
+	},
+	"posture-checks": {
+		store: func(stores *db.Stores) boltz.Store { return stores.PostureCheck },
+	},
+	"service-policies": {
+		store: func(stores *db.Stores) boltz.Store { return stores.ServicePolicy },
+		roleFields: map[string]string{
+			"identityRoles":     "identities",
+			"serviceRoles":      "services",
+			"postureCheckRoles": "posture-checks",
+		},
+	},
+	"edge-router-policies": {
+		store:      func(stores *db.Stores) boltz.Store { return stores.EdgeRouterPolicy },
+		roleFields: map[string]string{"identityRoles": "identities"},
+	},
+	"service-edge-router-policies": {
+		store:      func(stores *db.Stores) boltz.Store { return stores.ServiceEdgeRouterPolicy },
+		roleFields: map[string]string{"serviceRoles": "services"},
+	},
+}
+
+// applyAdminScope enforces the scope of scoped admins on management API requests. Requests for the collections in
+// scopedAdminCollections are granted admin permissions if they only touch entities whose scope tag matches the
+// admin's scope, and list filters are narrowed to that scope. Requests touching entities in other scopes are
+// rejected. All other requests are passed through unchanged, so they're limited to the identity's non-admin
+// permissions.
+func applyAdminScope(ae *env.AppEnv, rc *response.RequestContext, subPath string) error {
+	if rc.AdminScope == "" {
+		return nil
+	}
+
+	path := strings.Split(strings.Trim(subPath, "/"), "/")
+	collection, found := scopedAdminCollections[path[0]]
+	if !found {
+		return nil
+	}
+
+	method := rc.Request.Method
+
+	switch len(path) {
+	case 1:
+		switch method {
+		case http.MethodGet:
+			if err := restrictListToScope(rc, collection.store(ae.GetStores())); err != nil {
+				return err
+			}
+		case http.MethodPost:
+			if err := validateScopedBody(ae, rc, collection, true); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	case 2:
+		if err := checkInScope(ae, collection, path[1], rc.AdminScope); err != nil {
+			return err
+		}
+		switch method {
+		case http.MethodGet, http.MethodDelete:
+		case http.MethodPut:
+			if err := validateScopedBody(ae, rc, collection, true); err != nil {
+				return err
+			}
+		case http.MethodPatch:
+			if err := validateScopedBody(ae, rc, collection, false); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	case 3:
+		// related entity lists, such as the service policies of an identity, are allowed if they list a
+		// collection scoped admins can manage
+		relatedCollection, related := scopedAdminCollections[path[2]]
+		if !related || method != http.MethodGet {
+			return nil
+		}
+		if err := checkInScope(ae, collection, path[1], rc.AdminScope); err != nil {
+			return err
+		}
+		if err := restrictListToScope(rc, relatedCollection.store(ae.GetStores())); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	rc.ActivePermissions = append(rc.ActivePermissions, permissions.AdminPermission)
+	return nil
+}
+
+// restrictListToScope narrows a list request to entities in the admin's scope. The request's own filter is parsed
+// on its own first, so filters which don't parse, for example because of unbalanced parentheses, are rejected. The
+// scope filter is then combined with the parsed filter by the list handler, so it can't be escaped by the request's
+// filter.
+func restrictListToScope(rc *response.RequestContext, symbolTypes ast.SymbolTypes) error {
+	if filter := rc.Request.URL.Query().Get("filter"); strings.TrimSpace(filter) != "" {
+		if _, err := ast.Parse(symbolTypes, filter); err != nil {
+			return errorz.NewInvalidFilter(err)
+		}
+	}
+	rc.ListFilter = getScopedFilter(rc.AdminScope)
+	return nil
+}
+
+func getScopedFilter(scope string) string {
+	return fmt.Sprintf(`tags.%s = %s`, permissions.ScopeTag, quoteFilterValue(scope))
+}
+
+var filterValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func quoteFilterValue(val string) string {
+	return `"` + filterValueEscaper.Replace(val) + `"`
+}
+
+// checkInScope returns an unauthorized error unless the entity with the given id or name is in the given scope
+func checkInScope(ae *env.AppEnv, collection *scopedAdminCollection, idOrName string, scope string) error {
+	store := collection.store(ae.GetStores())
+	query := fmt.Sprintf(`(id = %s or name = %s) and %s`,
+		quoteFilterValue(idOrName), quoteFilterValue(idOrName), getScopedFilter(scope))
+
+	var count int64
+	err := ae.GetDb().View(func(tx *bbolt.Tx) error {
+		var err error
+		_, count, err = store.QueryIds(tx, query)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return errorz.NewUnauthorized()
+	}
+
+	return nil
+}
+
+// validateScopedBody checks that create and update bodies keep the entity in the admin's scope and only reference
+// other entities in the same scope. Full bodies must carry the scope tag, patches only need it if they update tags.
+func validateScopedBody(ae *env.AppEnv, rc *response.RequestContext, collection *scopedAdminCollection, full bool) error {
+	body := map[string]interface{}{}
+	if err := json.Unmarshal(rc.Body, &body); err != nil {
+		return errorz.NewCouldNotValidate(err)
+	}
+
+	if tags, found := body["tags"]; found || full {
+		tagMap, _ := tags.(map[string]interface{})
+		if scope := permissions.GetScope(tagMap); scope != rc.AdminScope {
+			fieldErr := errorz.NewFieldError("scope tag must match the admin scope", "tags."+permissions.ScopeTag, scope)
+			return errorz.NewFieldApiError(fieldErr)
+		}
+	}
+
+	if isAdmin, _ := body["isAdmin"].(bool); isAdmin {
+		return errorz.NewFieldApiError(errorz.NewFieldError("scoped admins may not create admins", "isAdmin", isAdmin))
+	}
+
+	if roleAttributes, found := body["roleAttributes"]; found {
+		for _, attr := range toStringList(roleAttributes) {
+			if attr == permissions.ReadOnlyAdminRoleAttribute {
+				fieldErr := errorz.NewFieldError("scoped admins may not grant read only admin", "roleAttributes", attr)
+				return errorz.NewFieldApiError(fieldErr)
+			}
+		}
+	}
+
+	for field, refCollection := range collection.roleFields {
+		for _, role := range toStringList(body[field]) {
+			if !strings.HasPrefix(role, "@") {
+				fieldErr := errorz.NewFieldError("scoped admins may only use roles referencing entities by id or name", field, role)
+				return errorz.NewFieldApiError(fieldErr)
+			}
+			if err := checkInScope(ae, scopedAdminCollections[refCollection], role[1:], rc.AdminScope); err != nil {
+				return err
+			}
+		}
+	}
+
+	for field, refCollection := range collection.refFields {
+		for _, ref := range toStringList(body[field]) {
+			if err := checkInScope(ae, scopedAdminCollections[refCollection], ref, rc.AdminScope); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func toStringList(val interface{}) []string {
+	list, _ := val.([]interface{})
+	var result []string
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		} else {
+			result = append(result, fmt.Sprintf("%v", v))
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/openziti/storage/ast"
+	"github.com/openziti/ziti/controller/response"
+	"github.com/stretchr/testify/require"
+)
+
+type testSymbolTypes map[string]ast.NodeType
+
+func (self testSymbolTypes) GetSymbolType(name string) (ast.NodeType, bool) {
+	nodeType, found := self[name]
+	return nodeType, found
+}
+
+func (self testSymbolTypes) GetSetSymbolTypes(string) ast.SymbolTypes {
+	return nil
+}
+
+func (self testSymbolTypes) IsSet(name string) (bool, bool) {
+	_, found := self[name]
+	return false, found
+}
+
+var testIdentitySymbols = testSymbolTypes{
+	"name":       ast.NodeTypeString,
+	"tags.scope": ast.NodeTypeString,
+}
+
+func Test_getScopedFilter(t *testing.T) {
+	t.Run("filter only matches scope", func(t *testing.T) {
+		req := require.New(t)
+		req.Equal(`tags.scope = "sales"`, getScopedFilter("sales"))
+	})
+
+	t.Run("scope is escaped", func(t *testing.T) {
+		req := require.New(t)
+		req.Equal(`tags.scope = "a\" or true or \\"`, getScopedFilter(`a" or true or \`))
+	})
+
+	t.Run("filter which escapes its grouping is rejected", func(t *testing.T) {
+		req := require.New(t)
+		rc := newScopedListRequestContext("true) or (true", "sales")
+		req.Error(restrictListToScope(rc, testIdentitySymbols))
+		req.Empty(rc.ListFilter)
+	})
+}
+
+func Test_restrictListToScope(t *testing.T) {
+	req := require.New(t)
+	rc := newScopedListRequestContext(`name = "a" or true`, "sales")
+	req.NoError(restrictListToScope(rc, testIdentitySymbols))
+
+	req.Equal(`tags.scope = "sales"`, rc.ListFilter)
+
+	query := rc.Request.URL.Query()
+	req.Equal(`name = "a" or true`, query.Get("filter"))
+	req.Equal("5", query.Get("limit"))
+}
+
+func newScopedListRequestContext(filter, scope string) *response.RequestContext {
+	query := url.Values{}
+	query.Set("filter", filter)
+	query.Set("limit", "5")
+	return &response.RequestContext{
+		Request:    httptest.NewRequest("GET", "/edge/management/v1/identities?"+query.Encode(), nil),
+		AdminScope: scope,
+	}
+}
@@ -0,0 +1,113 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webapis
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/env"
+)
+
+const (
+	WellKnownTrustBundle = "/.well-known/ziti/trust-bundle"
+
+	TrustBundleFormatJson = "json"
+
+	trustBundleContentTypePem  = "application/pem-certificate-chain"
+	trustBundleContentTypeJson = "application/json"
+)
+
+// serveTrustBundle serves the network trust bundle: the controller's roots and intermediates, along with the 3rd
+// party CAs which are active for authentication. The bundle is returned as PEM by default. When json is requested,
+// via the format query parameter or the Accept header, a delta is returned instead. The delta is relative to the
+// version named by the since query parameter, or is a full listing if since is missing or no longer known.
+//
+// Responses carry an ETag identifying the bundle version. If-None-Match requests for the current version get a 304.
+// The trust bundle is public, like the EST cacerts endpoint, so no authentication is required.
+func serveTrustBundle(ae *env.AppEnv, rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		rw.Header().Set("Allow", "GET, HEAD")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	trustCache := ae.Managers.Ca.GetTrustCache()
+	bundle := trustCache.GetTrustBundle()
+
+	rw.Header().Set("ETag", `"`+bundle.ETag+`"`)
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Add("Vary", "Accept")
+
+	if etagMatches(r.Header.Get("If-None-Match"), bundle.ETag) {
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if !isTrustBundleJsonRequest(r) {
+		rw.Header().Set("Content-Type", trustBundleContentTypePem)
+		rw.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = rw.Write(bundle.Pem())
+		}
+		return
+	}
+
+	delta := trustCache.GetTrustBundleDelta(r.URL.Query().Get("since"))
+	if delta.ETag != bundle.ETag {
+		// the bundle changed between the two calls, so describe the version the delta is for
+		rw.Header().Set("ETag", `"`+delta.ETag+`"`)
+	}
+
+	body, err := json.Marshal(delta)
+	if err != nil {
+		pfxlog.Logger().WithError(err).Error("unable to marshal trust bundle delta")
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", trustBundleContentTypeJson)
+	rw.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = rw.Write(body)
+	}
+}
+
+func isTrustBundleJsonRequest(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == TrustBundleFormatJson
+	}
+	return strings.Contains(r.Header.Get("Accept"), trustBundleContentTypeJson)
+}
+
+// etagMatches checks an If-None-Match header value, which may hold a list of weak or strong ETags, against the
+// given ETag
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		candidate = strings.TrimPrefix(candidate, "W/")
+		if strings.Trim(candidate, `"`) == etag {
+			return true
+		}
+	}
+	return false
+}
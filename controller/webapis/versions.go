@@ -31,6 +31,8 @@ const (
 	ManagementRestApiBaseUrlV1        = ManagementRestApiBase + RestApiV1
 	ControllerHealthCheckApiBaseUrlV1 = ControllerHealthCheck + RestApiV1
 	OidcRestApiBaseUrl                = "/oidc"
+	ScimApiBaseUrlV2                  = "/scim/v2"
+	OcspApiBaseUrl                    = "/ocsp"
 
 	ClientRestApiBaseUrlLatest     = ClientRestApiBaseUrlV1
 	ManagementRestApiBaseUrlLatest = ManagementRestApiBaseUrlV1
@@ -43,6 +45,8 @@ const (
 	ManagementApiBinding            = "edge-management"
 	OidcApiBinding                  = "edge-oidc"
 	ControllerHealthCheckApiBinding = "health-checks"
+	ScimApiBinding                  = "scim"
+	OcspApiBinding                  = "ocsp"
 )
 
 // AllApiBindingVersions is a map of: API Binding -> Api Version -> API Path
@@ -0,0 +1,152 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const maxRetryInterval = time.Minute
+
+// endpoint queues and delivers events to a single webhook url. Events are delivered in order by a single goroutine.
+type endpoint struct {
+	config      *config.WebhookConfig
+	client      *http.Client
+	queue       chan *Event
+	closeNotify <-chan struct{}
+}
+
+func newEndpoint(config *config.WebhookConfig, closeNotify <-chan struct{}) *endpoint {
+	return &endpoint{
+		config: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+		},
+		queue:       make(chan *Event, config.QueueSize),
+		closeNotify: closeNotify,
+	}
+}
+
+func (self *endpoint) log() *logrus.Entry {
+	return pfxlog.Logger().WithField("webhook", self.config.Name)
+}
+
+func (self *endpoint) enqueue(evt *Event) {
+	if !self.config.IsSubscribed(evt.Type) {
+		return
+	}
+
+	select {
+	case self.queue <- evt:
+	default:
+		self.log().WithField("eventId", evt.Id).WithField("eventType", evt.Type).Error("webhook queue full, dropping event")
+	}
+}
+
+func (self *endpoint) run() {
+	for {
+		select {
+		case evt := <-self.queue:
+			self.deliver(evt)
+		case <-self.closeNotify:
+			return
+		}
+	}
+}
+
+func (self *endpoint) deliver(evt *Event) {
+	log := self.log().WithField("eventId", evt.Id).WithField("eventType", evt.Type)
+
+	body, err := json.Marshal(evt)
+	if err != nil {
+		log.WithError(err).Error("unable to marshal webhook event")
+		return
+	}
+
+	retryInterval := self.config.RetryInterval
+	for attempt := 0; ; attempt++ {
+		retry, err := self.send(evt, body)
+		if err == nil {
+			log.WithField("attempt", attempt+1).Debug("webhook delivered")
+			return
+		}
+
+		if !retry || attempt >= self.config.MaxRetries {
+			log.WithError(err).WithField("attempt", attempt+1).Error("webhook delivery failed, giving up")
+			return
+		}
+
+		log.WithError(err).WithField("attempt", attempt+1).WithField("retryIn", retryInterval).Warn("webhook delivery failed, will retry")
+
+		select {
+		case <-time.After(retryInterval):
+		case <-self.closeNotify:
+			return
+		}
+
+		retryInterval = min(retryInterval*2, maxRetryInterval)
+	}
+}
+
+// send makes a single delivery attempt. If it fails, the returned bool indicates if the failure may be transient
+func (self *endpoint) send(evt *Event, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, self.config.Url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	for k, v := range self.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, evt.Type)
+	req.Header.Set(HeaderDeliveryId, evt.Id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if self.config.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(self.config.Secret, timestamp, body))
+	}
+
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = errors.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return retry, err
+}
@@ -0,0 +1,217 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webhook
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/openziti/ziti/controller/config"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/event"
+)
+
+// pendingTxTimeout is how long entity changes are held waiting for their transaction to commit. Changes which are
+// never committed belong to transactions which were rolled back.
+const pendingTxTimeout = time.Minute
+
+// IdentityResolver looks up identity details for events which only carry the identity id
+type IdentityResolver func(id string) *Identity
+
+// Manager converts entity change and authentication events into identity lifecycle webhook events and
+// dispatches them to the configured endpoints.
+type Manager struct {
+	controllerId     string
+	identityResolver IdentityResolver
+	endpoints        []*endpoint
+
+	lock       sync.Mutex
+	pendingTxs map[string]*pendingTx
+}
+
+// pendingTx collects the identity related changes made in a single transaction
+type pendingTx struct {
+	createdAt             time.Time
+	created               []*Identity
+	deleted               []*Identity
+	enrollmentsRemoved    map[string]struct{}
+	authenticatorsCreated map[string]struct{}
+}
+
+func NewManager(controllerId string, configs []*config.WebhookConfig, identityResolver IdentityResolver, closeNotify <-chan struct{}) *Manager {
+	result := &Manager{
+		controllerId:     controllerId,
+		identityResolver: identityResolver,
+		pendingTxs:       map[string]*pendingTx{},
+	}
+
+	for _, cfg := range configs {
+		ep := newEndpoint(cfg, closeNotify)
+		result.endpoints = append(result.endpoints, ep)
+		go ep.run()
+	}
+
+	return result
+}
+
+func (self *Manager) AcceptEntityChangeEvent(evt *event.EntityChangeEvent) {
+	// in a cluster, every controller sees every change. Only the leader sends webhooks
+	if evt.IsRecoveryEvent || !evt.PropagateIndicator {
+		return
+	}
+
+	if evt.EventType == event.EntityChangeTypeCommitted {
+		self.lock.Lock()
+		tx := self.pendingTxs[evt.EventId]
+		delete(self.pendingTxs, evt.EventId)
+		self.lock.Unlock()
+
+		if tx != nil {
+			self.emitTx(tx)
+		}
+		return
+	}
+
+	switch evt.EntityType {
+	case db.EntityTypeIdentities:
+		if evt.EventType == event.EntityChangeTypeEntityCreated {
+			if identity, ok := evt.FinalState.(*db.Identity); ok {
+				self.getPendingTx(evt.EventId, func(tx *pendingTx) {
+					tx.created = append(tx.created, toIdentity(identity))
+				})
+			}
+		} else if evt.EventType == event.EntityChangeTypeEntityDeleted {
+			if identity, ok := evt.InitialState.(*db.Identity); ok {
+				self.getPendingTx(evt.EventId, func(tx *pendingTx) {
+					tx.deleted = append(tx.deleted, toIdentity(identity))
+				})
+			}
+		}
+	case db.EntityTypeEnrollments:
+		if evt.EventType == event.EntityChangeTypeEntityDeleted {
+			if enrollment, ok := evt.InitialState.(*db.Enrollment); ok && enrollment.IdentityId != nil {
+				self.getPendingTx(evt.EventId, func(tx *pendingTx) {
+					tx.enrollmentsRemoved[*enrollment.IdentityId] = struct{}{}
+				})
+			}
+		}
+	case db.EntityTypeAuthenticators:
+		if evt.EventType == event.EntityChangeTypeEntityCreated {
+			if authenticator, ok := evt.FinalState.(*db.Authenticator); ok {
+				self.getPendingTx(evt.EventId, func(tx *pendingTx) {
+					tx.authenticatorsCreated[authenticator.IdentityId] = struct{}{}
+				})
+			}
+		}
+	}
+}
+
+func (self *Manager) getPendingTx(eventId string, f func(tx *pendingTx)) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	tx, found := self.pendingTxs[eventId]
+	if !found {
+		now := time.Now()
+		for k, v := range self.pendingTxs {
+			if now.Sub(v.createdAt) > pendingTxTimeout {
+				delete(self.pendingTxs, k)
+			}
+		}
+
+		tx = &pendingTx{
+			createdAt:             now,
+			enrollmentsRemoved:    map[string]struct{}{},
+			authenticatorsCreated: map[string]struct{}{},
+		}
+		self.pendingTxs[eventId] = tx
+	}
+
+	f(tx)
+}
+
+func (self *Manager) emitTx(tx *pendingTx) {
+	createdIds := map[string]struct{}{}
+	for _, identity := range tx.created {
+		createdIds[identity.Id] = struct{}{}
+		self.emit(config.WebhookEventIdentityCreated, identity, nil)
+	}
+
+	// an identity has enrolled when an authenticator is created for it, either replacing an enrollment or as part
+	// of creating the identity, as happens with auto-enrollment
+	for identityId := range tx.authenticatorsCreated {
+		_, enrollmentRemoved := tx.enrollmentsRemoved[identityId]
+		_, identityCreated := createdIds[identityId]
+		if enrollmentRemoved || identityCreated {
+			self.emit(config.WebhookEventIdentityEnrolled, self.resolveIdentity(identityId), nil)
+		}
+	}
+
+	for _, identity := range tx.deleted {
+		self.emit(config.WebhookEventIdentityDeleted, identity, nil)
+	}
+}
+
+func (self *Manager) AcceptAuthenticationEvent(evt *event.AuthenticationEvent) {
+	if evt.EventType != event.AuthenticationEventTypeFail || evt.IdentityId == "" {
+		return
+	}
+
+	self.emit(config.WebhookEventIdentityAuthFailed, self.resolveIdentity(evt.IdentityId), &AuthFailure{
+		Method:              evt.Method,
+		Reason:              evt.FailureReason,
+		RemoteAddress:       evt.RemoteAddress,
+		AuthenticatorId:     evt.AuthenticatorId,
+		ExternalJwtSignerId: evt.ExternalJwtSignerId,
+	})
+}
+
+func (self *Manager) resolveIdentity(id string) *Identity {
+	if self.identityResolver != nil {
+		if identity := self.identityResolver(id); identity != nil {
+			return identity
+		}
+	}
+	return &Identity{Id: id}
+}
+
+func (self *Manager) emit(eventType string, identity *Identity, authFailure *AuthFailure) {
+	evt := &Event{
+		Id:           uuid.NewString(),
+		Type:         eventType,
+		Timestamp:    time.Now(),
+		ControllerId: self.controllerId,
+		Identity:     identity,
+		AuthFailure:  authFailure,
+	}
+
+	for _, ep := range self.endpoints {
+		ep.enqueue(evt)
+	}
+}
+
+func toIdentity(identity *db.Identity) *Identity {
+	return &Identity{
+		Id:             identity.Id,
+		Name:           identity.Name,
+		Type:           identity.IdentityTypeId,
+		IsAdmin:        identity.IsAdmin,
+		RoleAttributes: identity.RoleAttributes,
+		ExternalId:     identity.ExternalId,
+	}
+}
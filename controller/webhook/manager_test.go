@@ -0,0 +1,179 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/controller/config"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/stretchr/testify/require"
+)
+
+type testReceiver struct {
+	events   chan *Event
+	failures atomic.Int32
+	secret   string
+	t        *testing.T
+}
+
+func (self *testReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if self.failures.Add(-1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	require.NoError(self.t, err)
+	require.True(self.t, Verify(self.secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)))
+
+	evt := &Event{}
+	require.NoError(self.t, json.Unmarshal(body, evt))
+	require.Equal(self.t, evt.Type, r.Header.Get(HeaderEvent))
+	self.events <- evt
+}
+
+func (self *testReceiver) next() *Event {
+	select {
+	case evt := <-self.events:
+		return evt
+	case <-time.After(5 * time.Second):
+		self.t.Fatal("timed out waiting for webhook event")
+		return nil
+	}
+}
+
+func newTestManager(t *testing.T, events ...string) (*Manager, *testReceiver) {
+	receiver := &testReceiver{
+		events: make(chan *Event, 10),
+		secret: "s3cret",
+		t:      t,
+	}
+
+	server := httptest.NewServer(receiver)
+	closeNotify := make(chan struct{})
+	t.Cleanup(func() {
+		close(closeNotify)
+		server.Close()
+	})
+
+	webhookConfig := &config.WebhookConfig{
+		Name:          "test",
+		Url:           server.URL,
+		Secret:        receiver.secret,
+		Events:        map[string]struct{}{},
+		Timeout:       time.Second,
+		MaxRetries:    3,
+		RetryInterval: 10 * time.Millisecond,
+		QueueSize:     10,
+	}
+	for _, eventType := range events {
+		webhookConfig.Events[eventType] = struct{}{}
+	}
+
+	return NewManager("ctrl1", []*config.WebhookConfig{webhookConfig}, nil, closeNotify), receiver
+}
+
+func entityChange(eventId string, eventType event.EntityChangeEventType, entityType string, initial, final any) *event.EntityChangeEvent {
+	return &event.EntityChangeEvent{
+		EventId:            eventId,
+		EventType:          eventType,
+		EntityType:         entityType,
+		InitialState:       initial,
+		FinalState:         final,
+		PropagateIndicator: true,
+	}
+}
+
+func Test_IdentityLifecycleWebhooks(t *testing.T) {
+	req := require.New(t)
+	manager, receiver := newTestManager(t, config.WebhookEventTypes...)
+
+	identity := &db.Identity{
+		BaseExtEntity: boltz.BaseExtEntity{Id: "id1"},
+		Name:          "test-identity",
+	}
+
+	manager.AcceptEntityChangeEvent(entityChange("tx1", event.EntityChangeTypeEntityCreated, db.EntityTypeIdentities, nil, identity))
+	req.Empty(receiver.events, "events should not be sent until commit")
+	manager.AcceptEntityChangeEvent(entityChange("tx1", event.EntityChangeTypeCommitted, "", nil, nil))
+
+	evt := receiver.next()
+	req.Equal(config.WebhookEventIdentityCreated, evt.Type)
+	req.Equal("id1", evt.Identity.Id)
+	req.Equal("test-identity", evt.Identity.Name)
+	req.Equal("ctrl1", evt.ControllerId)
+
+	identityId := "id1"
+	manager.AcceptEntityChangeEvent(entityChange("tx2", event.EntityChangeTypeEntityDeleted, db.EntityTypeEnrollments,
+		&db.Enrollment{IdentityId: &identityId}, nil))
+	manager.AcceptEntityChangeEvent(entityChange("tx2", event.EntityChangeTypeEntityCreated, db.EntityTypeAuthenticators,
+		nil, &db.Authenticator{IdentityId: identityId}))
+	manager.AcceptEntityChangeEvent(entityChange("tx2", event.EntityChangeTypeCommitted, "", nil, nil))
+
+	evt = receiver.next()
+	req.Equal(config.WebhookEventIdentityEnrolled, evt.Type)
+	req.Equal("id1", evt.Identity.Id)
+
+	manager.AcceptAuthenticationEvent(&event.AuthenticationEvent{
+		EventType:     event.AuthenticationEventTypeFail,
+		Method:        "updb",
+		IdentityId:    "id1",
+		FailureReason: "invalid password",
+	})
+
+	evt = receiver.next()
+	req.Equal(config.WebhookEventIdentityAuthFailed, evt.Type)
+	req.Equal("updb", evt.AuthFailure.Method)
+	req.Equal("invalid password", evt.AuthFailure.Reason)
+
+	// rolled back changes are never committed, so shouldn't be sent
+	manager.AcceptEntityChangeEvent(entityChange("tx3", event.EntityChangeTypeEntityDeleted, db.EntityTypeIdentities, identity, nil))
+	manager.AcceptEntityChangeEvent(entityChange("tx4", event.EntityChangeTypeEntityDeleted, db.EntityTypeIdentities, identity, nil))
+	manager.AcceptEntityChangeEvent(entityChange("tx4", event.EntityChangeTypeCommitted, "", nil, nil))
+
+	evt = receiver.next()
+	req.Equal(config.WebhookEventIdentityDeleted, evt.Type)
+	req.Empty(receiver.events)
+}
+
+func Test_WebhookRetriesAndFiltering(t *testing.T) {
+	req := require.New(t)
+	manager, receiver := newTestManager(t, config.WebhookEventIdentityDeleted)
+	receiver.failures.Store(2)
+
+	identity := &db.Identity{BaseExtEntity: boltz.BaseExtEntity{Id: "id1"}}
+
+	// not subscribed to creates
+	manager.AcceptEntityChangeEvent(entityChange("tx1", event.EntityChangeTypeEntityCreated, db.EntityTypeIdentities, nil, identity))
+	manager.AcceptEntityChangeEvent(entityChange("tx1", event.EntityChangeTypeCommitted, "", nil, nil))
+
+	manager.AcceptEntityChangeEvent(entityChange("tx2", event.EntityChangeTypeEntityDeleted, db.EntityTypeIdentities, identity, nil))
+	manager.AcceptEntityChangeEvent(entityChange("tx2", event.EntityChangeTypeCommitted, "", nil, nil))
+
+	evt := receiver.next()
+	req.Equal(config.WebhookEventIdentityDeleted, evt.Type)
+	req.Equal(int32(-1), receiver.failures.Load())
+}
@@ -0,0 +1,80 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package webhook delivers identity lifecycle events to external HTTP endpoints, so that provisioning systems can
+// react to changes without polling the management API.
+//
+// Each event is POSTed as JSON. If the endpoint has a secret configured, the request is signed with HMAC-SHA256 over
+// the timestamp header value, a period and the request body. The signature is sent hex encoded in the
+// X-Ziti-Webhook-Signature header, prefixed with 'sha256='.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	HeaderEvent      = "X-Ziti-Webhook-Event"
+	HeaderDeliveryId = "X-Ziti-Webhook-Id"
+	HeaderTimestamp  = "X-Ziti-Webhook-Timestamp"
+	HeaderSignature  = "X-Ziti-Webhook-Signature"
+
+	SignaturePrefix = "sha256="
+)
+
+// Event is the body sent to webhook endpoints
+type Event struct {
+	Id           string       `json:"id"`
+	Type         string       `json:"type"`
+	Timestamp    time.Time    `json:"timestamp"`
+	ControllerId string       `json:"controllerId"`
+	Identity     *Identity    `json:"identity"`
+	AuthFailure  *AuthFailure `json:"authFailure,omitempty"`
+}
+
+type Identity struct {
+	Id             string   `json:"id"`
+	Name           string   `json:"name,omitempty"`
+	Type           string   `json:"type,omitempty"`
+	IsAdmin        bool     `json:"isAdmin"`
+	RoleAttributes []string `json:"roleAttributes,omitempty"`
+	ExternalId     *string  `json:"externalId,omitempty"`
+}
+
+type AuthFailure struct {
+	Method              string `json:"method"`
+	Reason              string `json:"reason"`
+	RemoteAddress       string `json:"remoteAddress"`
+	AuthenticatorId     string `json:"authenticatorId,omitempty"`
+	ExternalJwtSignerId string `json:"externalJwtSignerId,omitempty"`
+}
+
+// Sign returns the signature for the given timestamp header value and body
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that the given signature matches the timestamp and body. It's intended for use by receivers.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
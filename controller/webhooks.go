@@ -0,0 +1,52 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/controller/webhook"
+)
+
+// startWebhooks registers the identity lifecycle webhook manager for event delivery, if any webhooks are configured
+func (c *Controller) startWebhooks() {
+	if len(c.config.Webhooks) == 0 {
+		return
+	}
+
+	identityResolver := func(id string) *webhook.Identity {
+		identity, err := c.network.Managers.Identity.Read(id)
+		if err != nil {
+			return nil
+		}
+		return &webhook.Identity{
+			Id:             identity.Id,
+			Name:           identity.Name,
+			Type:           identity.IdentityTypeId,
+			IsAdmin:        identity.IsAdmin,
+			RoleAttributes: identity.RoleAttributes,
+			ExternalId:     identity.ExternalId,
+		}
+	}
+
+	manager := webhook.NewManager(c.config.Id.Token, c.config.Webhooks, identityResolver, c.shutdownC)
+	c.eventDispatcher.AddEntityChangeEventHandler(manager)
+	c.eventDispatcher.AddAuthenticationEventHandler(manager)
+
+	for _, webhookConfig := range c.config.Webhooks {
+		pfxlog.Logger().WithField("webhook", webhookConfig.Name).WithField("url", webhookConfig.Url).Info("identity lifecycle webhook configured")
+	}
+}
@@ -0,0 +1,113 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xt
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// CostDecayCurveExponential halves the failure cost every period
+	CostDecayCurveExponential = "exponential"
+	// CostDecayCurveLinear reduces the failure cost evenly, reaching zero once a period has passed
+	CostDecayCurveLinear = "linear"
+	// CostDecayCurveStep keeps the full failure cost for a period, then drops it to zero
+	CostDecayCurveStep = "step"
+	// CostDecayCurveNone disables decay over time. Failure cost is only reduced by successful dials
+	CostDecayCurveNone = "none"
+)
+
+var CostDecayCurves = []string{CostDecayCurveExponential, CostDecayCurveLinear, CostDecayCurveStep, CostDecayCurveNone}
+
+// CostDecay defines how the failure cost which strategies add to a terminator when dials fail decays back to zero, and
+// so how the terminator's cost returns to its static cost, once dials stop failing
+type CostDecay struct {
+	Curve  string
+	Period time.Duration
+}
+
+func (self *CostDecay) Validate() error {
+	switch self.Curve {
+	case CostDecayCurveExponential, CostDecayCurveLinear, CostDecayCurveStep:
+		if self.Period <= 0 {
+			return fmt.Errorf("a period greater than zero is required for cost decay curve '%s'", self.Curve)
+		}
+	case CostDecayCurveNone:
+	default:
+		return fmt.Errorf("invalid cost decay curve '%s', valid values: %v", self.Curve, CostDecayCurves)
+	}
+	return nil
+}
+
+// Apply returns the failure cost remaining, given the failure cost right after the most recent failure and the time
+// elapsed since that failure
+func (self *CostDecay) Apply(peak uint32, sinceLastFailure time.Duration) uint32 {
+	if sinceLastFailure <= 0 || self.Curve == CostDecayCurveNone {
+		return peak
+	}
+
+	var result float64
+	switch self.Curve {
+	case CostDecayCurveExponential:
+		result = float64(peak) * math.Pow(0.5, float64(sinceLastFailure)/float64(self.Period))
+	case CostDecayCurveLinear:
+		result = float64(peak) * (1 - float64(sinceLastFailure)/float64(self.Period))
+	case CostDecayCurveStep:
+		if sinceLastFailure < self.Period {
+			return peak
+		}
+	default:
+		return peak
+	}
+
+	if result < 1 {
+		return 0
+	}
+	return uint32(result)
+}
+
+func (self *CostDecay) String() string {
+	if self.Curve == CostDecayCurveNone {
+		return self.Curve
+	}
+	return fmt.Sprintf("%s/%s", self.Curve, self.Period)
+}
+
+// CostDecayProvider returns the cost decay to use for terminators of the given service, or nil if the strategy
+// should use its own default
+type CostDecayProvider func(serviceId string) *CostDecay
+
+var costDecayProvider atomic.Pointer[CostDecayProvider]
+
+func SetCostDecayProvider(provider CostDecayProvider) {
+	if provider == nil {
+		costDecayProvider.Store(nil)
+	} else {
+		costDecayProvider.Store(&provider)
+	}
+}
+
+// GetCostDecay returns the configured cost decay for the given service, or nil if none is configured
+func GetCostDecay(serviceId string) *CostDecay {
+	if provider := costDecayProvider.Load(); provider != nil {
+		return (*provider)(serviceId)
+	}
+	return nil
+}
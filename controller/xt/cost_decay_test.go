@@ -0,0 +1,54 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostDecay_Apply(t *testing.T) {
+	req := require.New(t)
+
+	exponential := &CostDecay{Curve: CostDecayCurveExponential, Period: time.Minute}
+	req.Equal(uint32(1000), exponential.Apply(1000, 0))
+	req.Equal(uint32(500), exponential.Apply(1000, time.Minute))
+	req.Equal(uint32(250), exponential.Apply(1000, 2*time.Minute))
+	req.Equal(uint32(0), exponential.Apply(1000, time.Hour))
+
+	linear := &CostDecay{Curve: CostDecayCurveLinear, Period: 10 * time.Minute}
+	req.Equal(uint32(750), linear.Apply(1000, 150*time.Second))
+	req.Equal(uint32(0), linear.Apply(1000, 10*time.Minute))
+	req.Equal(uint32(0), linear.Apply(1000, time.Hour))
+
+	step := &CostDecay{Curve: CostDecayCurveStep, Period: 5 * time.Minute}
+	req.Equal(uint32(1000), step.Apply(1000, 4*time.Minute))
+	req.Equal(uint32(0), step.Apply(1000, 5*time.Minute))
+
+	none := &CostDecay{Curve: CostDecayCurveNone}
+	req.Equal(uint32(1000), none.Apply(1000, time.Hour))
+}
+
+func TestCostDecay_Validate(t *testing.T) {
+	req := require.New(t)
+	req.NoError((&CostDecay{Curve: CostDecayCurveNone}).Validate())
+	req.NoError((&CostDecay{Curve: CostDecayCurveLinear, Period: time.Minute}).Validate())
+	req.Error((&CostDecay{Curve: CostDecayCurveLinear}).Validate())
+	req.Error((&CostDecay{Curve: "cubic", Period: time.Minute}).Validate())
+}
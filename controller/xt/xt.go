@@ -53,6 +53,9 @@ type PeerData map[uint32][]byte
 type CostedTerminator interface {
 	Terminator
 	GetRouteCost() uint32
+	// GetPathCost returns the cost of the fabric path from the initiating router to the terminator's router, without
+	// any terminator costs
+	GetPathCost() uint32
 }
 
 type StrategyChangeEvent interface {
@@ -15,6 +15,10 @@ type TerminatorCosts struct {
 	FailureCost  uint32
 	CachedCost   uint32
 	LastFailure  time.Time
+	// PeakFailureCost is the failure cost right after the most recent failure. Configured cost decay curves are
+	// computed from it
+	PeakFailureCost uint32
+	ServiceId       string
 }
 
 func (self *TerminatorCosts) cache(circuitCost uint32) {
@@ -34,12 +38,19 @@ func (self *TerminatorCosts) Get() uint16 {
 }
 
 func (self *TerminatorCosts) Inspect(terminatorId string) *inspect.TerminatorCostDetail {
-	return &inspect.TerminatorCostDetail{
+	result := &inspect.TerminatorCostDetail{
 		TerminatorId: terminatorId,
 		CircuitCount: self.CircuitCount,
 		FailureCost:  self.FailureCost,
 		CurrentCost:  self.CachedCost,
 	}
+	if !self.LastFailure.IsZero() {
+		result.LastFailure = self.LastFailure.Format(time.RFC3339)
+	}
+	if decay := xt.GetCostDecay(self.ServiceId); decay != nil {
+		result.CostDecay = decay.String()
+	}
+	return result
 }
 
 func NewCostVisitor(circuitCost, failureCost, successCredit uint16) *CostVisitor {
@@ -97,6 +108,8 @@ func (self *CostVisitor) VisitDialFailed(event xt.TerminatorEvent) {
 		}
 		cost.cache(self.CircuitCost)
 		cost.LastFailure = time.Now()
+		cost.PeakFailureCost = cost.FailureCost
+		cost.ServiceId = event.GetTerminator().GetServiceId()
 		return cost
 	})
 }
@@ -195,6 +208,11 @@ func (self *CostVisitor) CreditAllExponential(exponentBasis time.Duration) {
 				cost = &TerminatorCosts{}
 				xt.GlobalCosts().SetDynamicCost(key, cost)
 			} else if cost.FailureCost > 0 {
+				if decay := xt.GetCostDecay(cost.ServiceId); decay != nil {
+					self.applyCostDecay(key, cost, decay)
+					return cost
+				}
+
 				credit64 := uint64(math.Pow(2, float64(time.Since(cost.LastFailure)/exponentBasis)))
 				if credit64 > math.MaxUint32 {
 					credit64 = math.MaxUint32
@@ -218,6 +236,17 @@ func (self *CostVisitor) CreditAllExponential(exponentBasis time.Duration) {
 	}
 }
 
+// applyCostDecay reduces the failure cost to what the configured decay curve allows. Successful dials may have
+// already brought the cost lower than the curve, in which case it's left as is
+func (self *CostVisitor) applyCostDecay(terminatorId string, cost *TerminatorCosts, decay *xt.CostDecay) {
+	decayed := decay.Apply(cost.PeakFailureCost, time.Since(cost.LastFailure))
+	if decayed < cost.FailureCost {
+		pfxlog.Logger().Tracef("cost decay, id: %s, curve: %s, new failure cost: %v", terminatorId, decay, decayed)
+		cost.FailureCost = decayed
+		cost.cache(self.CircuitCost)
+	}
+}
+
 func (self *CostVisitor) NotifyEvent(event xt.TerminatorEvent) {
 	event.Accept(self)
 }
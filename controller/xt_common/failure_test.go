@@ -26,7 +26,7 @@ func (m mockTerminator) GetCost() uint16 {
 }
 
 func (m mockTerminator) GetServiceId() string {
-	panic("implement me")
+	return "test-service"
 }
 
 func (m mockTerminator) GetInstanceId() string {
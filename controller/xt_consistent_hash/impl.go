@@ -0,0 +1,150 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xt_consistent_hash
+
+import (
+	"github.com/openziti/ziti/controller/xt"
+	"github.com/openziti/ziti/controller/xt_common"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+const (
+	Name = "consistent-hash"
+
+	// UnhealthyFailureCost is the failure cost at which a terminator is passed over, and its dials go to the
+	// terminator with the next highest score for the key. With the default failure cost of 20 per failed dial, this
+	// is three consecutive failures
+	UnhealthyFailureCost = 60
+
+	// clientIdTag is the circuit tag holding the id of the dialing identity, see model.CircuitTagClientId
+	clientIdTag = "clientId"
+)
+
+/**
+The consistent hash strategy provides sticky routing without tokens. Dials are keyed on the instance id, if the dial
+addresses a specific instance, and otherwise on the dialing client's identity. Each key is mapped to a terminator
+using rendezvous hashing, so the same client keeps reaching the same terminator, and when terminators are added or
+removed only the keys of the affected terminators move. Terminators which keep failing dials are passed over until
+their failure cost decays. Precedence is respected: terminators are only selected from those with the best
+available precedence.
+*/
+
+func NewFactory() xt.Factory {
+	return &factory{}
+}
+
+type factory struct{}
+
+func (self *factory) GetStrategyName() string {
+	return Name
+}
+
+func (self *factory) NewStrategy() xt.Strategy {
+	strategy := strategy{
+		CostVisitor: *xt_common.NewCostVisitor(2, 20, 2),
+	}
+	strategy.CreditOverTimeExponential(time.Minute, 5*time.Minute)
+	return &strategy
+}
+
+type strategy struct {
+	xt_common.CostVisitor
+}
+
+type circuitTagSource interface {
+	GetCircuitTags(terminator xt.CostedTerminator) map[string]string
+}
+
+func (self *strategy) Select(params xt.CreateCircuitParams, terminators []xt.CostedTerminator) (xt.CostedTerminator, xt.PeerData, error) {
+	terminators = xt.GetRelatedTerminators(terminators)
+
+	key := getHashKey(params)
+	if key == "" || len(terminators) == 1 {
+		return terminators[0], nil, nil
+	}
+
+	var healthy []xt.CostedTerminator
+	for _, t := range terminators {
+		if self.GetFailureCost(t.GetId()) < UnhealthyFailureCost {
+			healthy = append(healthy, t)
+		}
+	}
+
+	if len(healthy) == 0 {
+		healthy = terminators
+	}
+
+	return selectByHash(key, healthy), nil, nil
+}
+
+// getHashKey returns the key used to pick a terminator for the dial, or an empty string if the dial can't be keyed
+func getHashKey(params xt.CreateCircuitParams) string {
+	if params == nil {
+		return ""
+	}
+
+	if instanceId, _, found := strings.Cut(params.GetServiceId(), "@"); found && instanceId != "" {
+		return "instance:" + instanceId
+	}
+
+	if tagSource, ok := params.(circuitTagSource); ok {
+		if clientId := tagSource.GetCircuitTags(nil)[clientIdTag]; clientId != "" {
+			return "client:" + clientId
+		}
+	}
+
+	if clientId := params.GetClientId(); clientId != nil && clientId.Token != "" {
+		return "client:" + clientId.Token
+	}
+
+	return ""
+}
+
+// selectByHash returns the terminator with the highest score for the given key
+func selectByHash(key string, terminators []xt.CostedTerminator) xt.CostedTerminator {
+	var result xt.CostedTerminator
+	var highScore uint64
+
+	for _, t := range terminators {
+		score := getScore(key, t.GetId())
+		if result == nil || score > highScore {
+			result = t
+			highScore = score
+		}
+	}
+
+	return result
+}
+
+func getScore(key, terminatorId string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(terminatorId))
+
+	// fnv doesn't spread inputs which only differ in the last few bytes well, so mix the result, using the
+	// splitmix64 finalizer
+	score := h.Sum64()
+	score ^= score >> 30
+	score *= 0xbf58476d1ce4e5b9
+	score ^= score >> 27
+	score *= 0x94d049bb133111eb
+	score ^= score >> 31
+	return score
+}
@@ -0,0 +1,200 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xt_consistent_hash
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openziti/identity"
+	"github.com/openziti/ziti/common/logcontext"
+	"github.com/openziti/ziti/controller/xt"
+	"github.com/stretchr/testify/require"
+)
+
+type testTerminator struct {
+	id         string
+	precedence xt.Precedence
+}
+
+func (self *testTerminator) GetId() string                { return self.id }
+func (self *testTerminator) GetPrecedence() xt.Precedence { return self.precedence }
+func (self *testTerminator) GetCost() uint16              { return 0 }
+func (self *testTerminator) GetServiceId() string         { return "svc" }
+func (self *testTerminator) GetInstanceId() string        { return "" }
+func (self *testTerminator) GetRouterId() string          { return "r-" + self.id }
+func (self *testTerminator) GetBinding() string           { return "edge" }
+func (self *testTerminator) GetAddress() string           { return "" }
+func (self *testTerminator) GetPeerData() xt.PeerData     { return nil }
+func (self *testTerminator) GetCreatedAt() time.Time      { return time.Time{} }
+func (self *testTerminator) GetHostId() string            { return "" }
+func (self *testTerminator) GetSourceCtrl() string        { return "" }
+func (self *testTerminator) GetRouteCost() uint32         { return 0 }
+func (self *testTerminator) GetPathCost() uint32          { return 0 }
+
+func newTestTerminator(id string) *testTerminator {
+	return &testTerminator{
+		id:         id,
+		precedence: xt.Precedences.Default,
+	}
+}
+
+type testParams struct {
+	serviceId string
+	clientId  *identity.TokenId
+	tags      map[string]string
+}
+
+func (self *testParams) GetServiceId() string              { return self.serviceId }
+func (self *testParams) GetClientId() *identity.TokenId    { return self.clientId }
+func (self *testParams) GetLogContext() logcontext.Context { return logcontext.NewContext() }
+func (self *testParams) GetCircuitTags(xt.CostedTerminator) map[string]string {
+	return self.tags
+}
+
+func newClientParams(identityId string) *testParams {
+	return &testParams{
+		serviceId: "svc",
+		clientId:  &identity.TokenId{Token: "session-" + identityId},
+		tags:      map[string]string{clientIdTag: identityId},
+	}
+}
+
+func newTestTerminators(count int) []xt.CostedTerminator {
+	var result []xt.CostedTerminator
+	for i := 0; i < count; i++ {
+		result = append(result, newTestTerminator(fmt.Sprintf("t%d", i)))
+	}
+	return result
+}
+
+func selectId(t *testing.T, s *strategy, params xt.CreateCircuitParams, terminators []xt.CostedTerminator) string {
+	selected, peerData, err := s.Select(params, terminators)
+	require.NoError(t, err)
+	require.Nil(t, peerData)
+	return selected.GetId()
+}
+
+func TestConsistentHashSelect(t *testing.T) {
+	s := NewFactory().NewStrategy().(*strategy)
+	terminators := newTestTerminators(5)
+
+	t.Run("same client gets the same terminator", func(t *testing.T) {
+		req := require.New(t)
+		first := selectId(t, s, newClientParams("alice"), terminators)
+		for i := 0; i < 10; i++ {
+			req.Equal(first, selectId(t, s, newClientParams("alice"), terminators))
+		}
+
+		// order of the terminators, which changes as costs change, doesn't matter
+		reversed := make([]xt.CostedTerminator, 0, len(terminators))
+		for i := len(terminators) - 1; i >= 0; i-- {
+			reversed = append(reversed, terminators[i])
+		}
+		req.Equal(first, selectId(t, s, newClientParams("alice"), reversed))
+	})
+
+	t.Run("instance id is preferred to client", func(t *testing.T) {
+		req := require.New(t)
+		params := newClientParams("alice")
+		params.serviceId = "instance-1@svc"
+		req.Equal("instance:instance-1", getHashKey(params))
+
+		params.tags = nil
+		params.serviceId = "svc"
+		req.Equal("client:session-alice", getHashKey(params))
+
+		params.clientId = nil
+		req.Equal("", getHashKey(params))
+		req.Equal("", getHashKey(nil))
+	})
+
+	t.Run("clients are spread across terminators", func(t *testing.T) {
+		req := require.New(t)
+		counts := map[string]int{}
+		for i := 0; i < 5000; i++ {
+			counts[selectId(t, s, newClientParams(fmt.Sprintf("client-%d", i)), terminators)]++
+		}
+		req.Len(counts, 5)
+		for id, count := range counts {
+			req.InDelta(1000, count, 150, "terminator %s", id)
+		}
+	})
+
+	t.Run("removing a terminator only moves its clients", func(t *testing.T) {
+		req := require.New(t)
+		remaining := terminators[1:]
+		for i := 0; i < 1000; i++ {
+			params := newClientParams(fmt.Sprintf("client-%d", i))
+			before := selectId(t, s, params, terminators)
+			after := selectId(t, s, params, remaining)
+			if before != "t0" {
+				req.Equal(before, after)
+			} else {
+				req.NotEqual("t0", after)
+			}
+		}
+	})
+}
+
+func TestConsistentHashSelectFailover(t *testing.T) {
+	req := require.New(t)
+
+	s := NewFactory().NewStrategy().(*strategy)
+	terminators := newTestTerminators(3)
+	params := newClientParams("bob")
+
+	first := selectId(t, s, params, terminators)
+
+	var failed xt.CostedTerminator
+	for _, terminator := range terminators {
+		if terminator.GetId() == first {
+			failed = terminator
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		s.VisitDialFailed(xt.NewDialFailedEvent(failed))
+	}
+
+	second := selectId(t, s, params, terminators)
+	req.NotEqual(first, second)
+	req.Equal(second, selectId(t, s, params, terminators))
+
+	// if everything is failing, go back to hashing across all of them
+	for _, terminator := range terminators {
+		for i := 0; i < 3; i++ {
+			s.VisitDialFailed(xt.NewDialFailedEvent(terminator))
+		}
+	}
+	req.Equal(first, selectId(t, s, params, terminators))
+}
+
+func TestConsistentHashSelectRespectsPrecedence(t *testing.T) {
+	req := require.New(t)
+
+	s := NewFactory().NewStrategy().(*strategy)
+
+	required := newTestTerminator("required")
+	required.precedence = xt.Precedences.Required
+	terminators := append([]xt.CostedTerminator{required}, newTestTerminators(3)...)
+
+	for i := 0; i < 100; i++ {
+		req.Equal("required", selectId(t, s, newClientParams(fmt.Sprintf("client-%d", i)), terminators))
+	}
+}
@@ -0,0 +1,101 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xt_proximity
+
+import (
+	"github.com/openziti/ziti/controller/xt"
+	"github.com/openziti/ziti/controller/xt_common"
+	"time"
+)
+
+const (
+	Name = "proximity"
+
+	// PathCostTolerancePercent is how much further away than the nearest terminator, as a percentage of the nearest
+	// terminator's path cost, a terminator may be and still be considered equally near
+	PathCostTolerancePercent = 10
+
+	// UnhealthyFailureCost is the failure cost at which a terminator is passed over for terminators further away.
+	// With the default failure cost of 20 per failed dial, this is three consecutive failures
+	UnhealthyFailureCost = 60
+)
+
+/**
+The proximity strategy provides anycast style service hosting. It selects the terminator nearest to the initiating
+router, as measured by the cost of the fabric path between the routers, ignoring terminator costs. Terminators whose
+path costs are within PathCostTolerancePercent of the nearest are treated as equally near, and the one with the
+lowest overall cost is picked, so load is still spread across the instances in a region. Terminators which keep
+failing dials are passed over in favor of terminators further away, until their failure cost decays. Precedence is
+respected: terminators are only selected from those with the best available precedence.
+*/
+
+func NewFactory() xt.Factory {
+	return &factory{}
+}
+
+type factory struct{}
+
+func (self *factory) GetStrategyName() string {
+	return Name
+}
+
+func (self *factory) NewStrategy() xt.Strategy {
+	strategy := strategy{
+		CostVisitor: *xt_common.NewCostVisitor(2, 20, 2),
+	}
+	strategy.CreditOverTimeExponential(time.Minute, 5*time.Minute)
+	return &strategy
+}
+
+type strategy struct {
+	xt_common.CostVisitor
+}
+
+func (self *strategy) Select(_ xt.CreateCircuitParams, terminators []xt.CostedTerminator) (xt.CostedTerminator, xt.PeerData, error) {
+	terminators = xt.GetRelatedTerminators(terminators)
+
+	var healthy []xt.CostedTerminator
+	for _, t := range terminators {
+		if self.GetFailureCost(t.GetId()) < UnhealthyFailureCost {
+			healthy = append(healthy, t)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return terminators[0], nil, nil
+	}
+
+	return selectNearest(healthy), nil, nil
+}
+
+// selectNearest returns the first terminator, in the given order, whose path cost is within the tolerance of the
+// nearest terminator. Terminators are expected to be sorted by route cost.
+func selectNearest(terminators []xt.CostedTerminator) xt.CostedTerminator {
+	nearest := terminators[0].GetPathCost()
+	for _, t := range terminators[1:] {
+		nearest = min(nearest, t.GetPathCost())
+	}
+
+	limit := uint64(nearest) + uint64(nearest)*PathCostTolerancePercent/100
+	for _, t := range terminators {
+		if uint64(t.GetPathCost()) <= limit {
+			return t
+		}
+	}
+
+	return terminators[0]
+}
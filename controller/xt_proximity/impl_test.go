@@ -0,0 +1,115 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xt_proximity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/controller/xt"
+	"github.com/stretchr/testify/require"
+)
+
+type testTerminator struct {
+	id         string
+	precedence xt.Precedence
+	routeCost  uint32
+	pathCost   uint32
+}
+
+func (self *testTerminator) GetId() string                { return self.id }
+func (self *testTerminator) GetPrecedence() xt.Precedence { return self.precedence }
+func (self *testTerminator) GetCost() uint16              { return 0 }
+func (self *testTerminator) GetServiceId() string         { return "svc" }
+func (self *testTerminator) GetInstanceId() string        { return "" }
+func (self *testTerminator) GetRouterId() string          { return "r-" + self.id }
+func (self *testTerminator) GetBinding() string           { return "edge" }
+func (self *testTerminator) GetAddress() string           { return "" }
+func (self *testTerminator) GetPeerData() xt.PeerData     { return nil }
+func (self *testTerminator) GetCreatedAt() time.Time      { return time.Time{} }
+func (self *testTerminator) GetHostId() string            { return "" }
+func (self *testTerminator) GetSourceCtrl() string        { return "" }
+func (self *testTerminator) GetRouteCost() uint32         { return self.routeCost }
+func (self *testTerminator) GetPathCost() uint32          { return self.pathCost }
+
+func newTestTerminator(id string, routeCost, pathCost uint32) *testTerminator {
+	return &testTerminator{
+		id:         id,
+		precedence: xt.Precedences.Default,
+		routeCost:  routeCost,
+		pathCost:   pathCost,
+	}
+}
+
+func TestProximitySelect(t *testing.T) {
+	req := require.New(t)
+
+	s := NewFactory().NewStrategy().(*strategy)
+
+	// far has a lower overall cost, because near has more circuits, but near is closer
+	far := newTestTerminator("far", 150, 120)
+	near := newTestTerminator("near", 200, 20)
+	nearPeer := newTestTerminator("near-peer", 210, 21)
+	terminators := []xt.CostedTerminator{far, near, nearPeer}
+
+	selected, _, err := s.Select(nil, terminators)
+	req.NoError(err)
+	req.Equal("near", selected.GetId())
+
+	// terminators within the tolerance are equally near, so the lowest overall cost wins
+	nearPeer.routeCost = 190
+	selected, _, err = s.Select(nil, []xt.CostedTerminator{far, nearPeer, near})
+	req.NoError(err)
+	req.Equal("near-peer", selected.GetId())
+
+	// failing terminators are passed over
+	for i := 0; i < 3; i++ {
+		s.VisitDialFailed(xt.NewDialFailedEvent(nearPeer))
+	}
+	selected, _, err = s.Select(nil, []xt.CostedTerminator{far, nearPeer, near})
+	req.NoError(err)
+	req.Equal("near", selected.GetId())
+
+	for i := 0; i < 3; i++ {
+		s.VisitDialFailed(xt.NewDialFailedEvent(near))
+	}
+	selected, _, err = s.Select(nil, []xt.CostedTerminator{far, nearPeer, near})
+	req.NoError(err)
+	req.Equal("far", selected.GetId())
+
+	// if everything is failing, fall back to the lowest cost
+	for i := 0; i < 3; i++ {
+		s.VisitDialFailed(xt.NewDialFailedEvent(far))
+	}
+	selected, _, err = s.Select(nil, []xt.CostedTerminator{far, nearPeer, near})
+	req.NoError(err)
+	req.Equal("far", selected.GetId())
+}
+
+func TestProximitySelectRespectsPrecedence(t *testing.T) {
+	req := require.New(t)
+
+	s := NewFactory().NewStrategy().(*strategy)
+
+	required := newTestTerminator("required", 10, 500)
+	required.precedence = xt.Precedences.Required
+	near := newTestTerminator("near", 200, 20)
+
+	selected, _, err := s.Select(nil, []xt.CostedTerminator{required, near})
+	req.NoError(err)
+	req.Equal("required", selected.GetId())
+}
@@ -23,25 +23,56 @@ import (
 	"time"
 )
 
+const (
+	Name       = "weighted"
+	RandomName = "weighted-random"
+)
+
 /**
 The weighted strategy does random selection of available strategies in proportion to the terminator costs. So if a
 given terminator has twice the fully evaluated cost as another terminator it should ideally be selected roughly half
 as often.
+
+The weighted random strategy selects terminators at random, using the inverse of each terminator's cost as its
+weight. A terminator with half the cost of another is selected twice as often, so load can be distributed in
+proportion to capacity by setting terminator costs.
+
+Both strategies include dynamic costs, such as those from open circuits and failed dials, so busy or failing
+terminators are selected less often. Precedence is respected: terminators are only selected from those with the best
+available precedence.
 */
 
 func NewFactory() xt.Factory {
-	return &factory{}
+	return &factory{
+		name:    Name,
+		selectF: selectByCostShare,
+	}
 }
 
-type factory struct{}
+// NewRandomFactory returns a factory for the weighted random strategy
+func NewRandomFactory() xt.Factory {
+	return &factory{
+		name:    RandomName,
+		selectF: selectByInverseCost,
+	}
+}
+
+// selectF returns the terminator at the given point, in the range [0, 1), of the distribution of terminators
+type selectF func(terminators []xt.CostedTerminator, point float64) xt.CostedTerminator
+
+type factory struct {
+	name    string
+	selectF selectF
+}
 
 func (self *factory) GetStrategyName() string {
-	return "weighted"
+	return self.name
 }
 
 func (self *factory) NewStrategy() xt.Strategy {
 	strategy := &strategy{
 		CostVisitor: *xt_common.NewCostVisitor(2, 20, 2),
+		selectF:     self.selectF,
 	}
 	strategy.CreditOverTimeExponential(time.Minute, 5*time.Minute)
 	return strategy
@@ -49,6 +80,7 @@ func (self *factory) NewStrategy() xt.Strategy {
 
 type strategy struct {
 	xt_common.CostVisitor
+	selectF selectF
 }
 
 func (self *strategy) Select(_ xt.CreateCircuitParams, terminators []xt.CostedTerminator) (xt.CostedTerminator, xt.PeerData, error) {
@@ -56,7 +88,10 @@ func (self *strategy) Select(_ xt.CreateCircuitParams, terminators []xt.CostedTe
 	if len(terminators) == 1 {
 		return terminators[0], nil, nil
 	}
+	return self.selectF(terminators, rand.Float64()), nil, nil
+}
 
+func selectByCostShare(terminators []xt.CostedTerminator, point float64) xt.CostedTerminator {
 	var costIdx []float32
 	totalCost := float32(0)
 	for _, t := range terminators {
@@ -74,12 +109,33 @@ func (self *strategy) Select(_ xt.CreateCircuitParams, terminators []xt.CostedTe
 		costIdx[idx] = total
 	}
 
-	selected := rand.Float32()
+	selected := float32(point)
 	for idx, cost := range costIdx {
 		if selected < cost {
-			return terminators[idx], nil, nil
+			return terminators[idx]
+		}
+	}
+
+	return terminators[0]
+}
+
+// selectByInverseCost returns the terminator at the given point of the cumulative distribution of inverse costs
+func selectByInverseCost(terminators []xt.CostedTerminator, point float64) xt.CostedTerminator {
+	weights := make([]float64, len(terminators))
+	total := float64(0)
+	for idx, t := range terminators {
+		cost := max(t.GetPrecedence().Unbias(t.GetRouteCost()), 1)
+		weights[idx] = 1 / float64(cost)
+		total += weights[idx]
+	}
+
+	remaining := point * total
+	for idx, weight := range weights {
+		if remaining < weight {
+			return terminators[idx]
 		}
+		remaining -= weight
 	}
 
-	return terminators[0], nil, nil
+	return terminators[len(terminators)-1]
 }
@@ -0,0 +1,128 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xt_weighted
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/controller/xt"
+	"github.com/stretchr/testify/require"
+)
+
+type testTerminator struct {
+	id         string
+	precedence xt.Precedence
+	routeCost  uint32
+}
+
+func (self *testTerminator) GetId() string                { return self.id }
+func (self *testTerminator) GetPrecedence() xt.Precedence { return self.precedence }
+func (self *testTerminator) GetCost() uint16              { return 0 }
+func (self *testTerminator) GetServiceId() string         { return "svc" }
+func (self *testTerminator) GetInstanceId() string        { return "" }
+func (self *testTerminator) GetRouterId() string          { return "r-" + self.id }
+func (self *testTerminator) GetBinding() string           { return "edge" }
+func (self *testTerminator) GetAddress() string           { return "" }
+func (self *testTerminator) GetPeerData() xt.PeerData     { return nil }
+func (self *testTerminator) GetCreatedAt() time.Time      { return time.Time{} }
+func (self *testTerminator) GetHostId() string            { return "" }
+func (self *testTerminator) GetSourceCtrl() string        { return "" }
+func (self *testTerminator) GetRouteCost() uint32         { return self.routeCost }
+func (self *testTerminator) GetPathCost() uint32          { return 0 }
+
+func newTestTerminator(id string, cost uint32) *testTerminator {
+	return &testTerminator{
+		id:         id,
+		precedence: xt.Precedences.Default,
+		routeCost:  xt.Precedences.Default.GetBiasedCost(cost),
+	}
+}
+
+func TestSelectByInverseCost(t *testing.T) {
+	req := require.New(t)
+
+	// weights are 1/100, 1/200 and 1/400, so a gets 4/7, b gets 2/7 and c gets 1/7 of the selections
+	a := newTestTerminator("a", 100)
+	b := newTestTerminator("b", 200)
+	c := newTestTerminator("c", 400)
+	terminators := []xt.CostedTerminator{a, b, c}
+
+	req.Equal("a", selectByInverseCost(terminators, 0).GetId())
+	req.Equal("a", selectByInverseCost(terminators, 0.56).GetId())
+	req.Equal("b", selectByInverseCost(terminators, 0.58).GetId())
+	req.Equal("b", selectByInverseCost(terminators, 0.85).GetId())
+	req.Equal("c", selectByInverseCost(terminators, 0.86).GetId())
+	req.Equal("c", selectByInverseCost(terminators, 0.9999).GetId())
+
+	// zero cost terminators are treated as cost 1, rather than dividing by zero
+	zero := newTestTerminator("zero", 0)
+	req.Equal("zero", selectByInverseCost([]xt.CostedTerminator{zero, a}, 0.98).GetId())
+	req.Equal("a", selectByInverseCost([]xt.CostedTerminator{zero, a}, 0.995).GetId())
+}
+
+func TestWeightedRandomSelect(t *testing.T) {
+	req := require.New(t)
+
+	s := NewRandomFactory().NewStrategy().(*strategy)
+
+	a := newTestTerminator("a", 100)
+	b := newTestTerminator("b", 300)
+	terminators := []xt.CostedTerminator{a, b}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		selected, _, err := s.Select(nil, terminators)
+		req.NoError(err)
+		counts[selected.GetId()]++
+	}
+
+	// a should be selected 3/4 of the time
+	req.InDelta(7500, counts["a"], 400)
+	req.InDelta(2500, counts["b"], 400)
+}
+
+func TestWeightedRandomSelectRespectsPrecedence(t *testing.T) {
+	req := require.New(t)
+
+	s := NewRandomFactory().NewStrategy().(*strategy)
+
+	required := newTestTerminator("required", 500)
+	required.precedence = xt.Precedences.Required
+	required.routeCost = xt.Precedences.Required.GetBiasedCost(500)
+	cheap := newTestTerminator("cheap", 10)
+
+	for i := 0; i < 100; i++ {
+		selected, _, err := s.Select(nil, []xt.CostedTerminator{required, cheap})
+		req.NoError(err)
+		req.Equal("required", selected.GetId())
+	}
+}
+
+func TestSelectByCostShare(t *testing.T) {
+	req := require.New(t)
+
+	// a has a quarter of the total cost, so it gets 3/4 of the selections
+	a := newTestTerminator("a", 100)
+	b := newTestTerminator("b", 300)
+	terminators := []xt.CostedTerminator{a, b}
+
+	req.Equal("a", selectByCostShare(terminators, 0).GetId())
+	req.Equal("a", selectByCostShare(terminators, 0.74).GetId())
+	req.Equal("b", selectByCostShare(terminators, 0.76).GetId())
+	req.Equal("b", selectByCostShare(terminators, 0.9999).GetId())
+}
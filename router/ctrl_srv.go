@@ -0,0 +1,135 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/router/env"
+	"github.com/pkg/errors"
+)
+
+const ctrlSrvLookupTimeout = 10 * time.Second
+
+// ctrlSrvDiscovery resolves controller endpoints from DNS SRV records
+type ctrlSrvDiscovery struct {
+	config        *env.CtrlSrvConfig
+	resolver      *net.Resolver
+	lastEndpoints []string
+}
+
+func newCtrlSrvDiscovery(config *env.CtrlSrvConfig) *ctrlSrvDiscovery {
+	result := &ctrlSrvDiscovery{
+		config:   config,
+		resolver: net.DefaultResolver,
+	}
+
+	if config.Resolver != "" {
+		server := config.Resolver
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		result.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				dialer := net.Dialer{}
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return result
+}
+
+func (self *ctrlSrvDiscovery) resolve() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ctrlSrvLookupTimeout)
+	defer cancel()
+
+	_, records, err := self.resolver.LookupSRV(ctx, "", "", self.config.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to look up SRV records for %s", self.config.Name)
+	}
+
+	endpoints := srvRecordsToEndpoints(self.config.Protocol, records)
+	if len(endpoints) == 0 {
+		return nil, errors.Errorf("no usable SRV records found for %s", self.config.Name)
+	}
+
+	return endpoints, nil
+}
+
+// srvRecordsToEndpoints converts SRV records to sorted, de-duplicated controller endpoints. A target of "." means
+// the service isn't available, as per RFC 2782, so those records are skipped.
+func srvRecordsToEndpoints(protocol string, records []*net.SRV) []string {
+	var result []string
+	for _, record := range records {
+		target := strings.TrimSuffix(record.Target, ".")
+		if target == "" || record.Port == 0 {
+			continue
+		}
+		endpoint := fmt.Sprintf("%s:%s", protocol, net.JoinHostPort(target, fmt.Sprint(record.Port)))
+		if !slices.Contains(result, endpoint) {
+			result = append(result, endpoint)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// poll re-resolves the SRV records and returns the endpoints if they changed since the last successful lookup. The
+// endpoints may also be updated by the controllers, so only changes in DNS are applied. Otherwise, a stale DNS entry
+// would keep overwriting the list the controllers sent.
+func (self *ctrlSrvDiscovery) poll() ([]string, bool) {
+	endpoints, err := self.resolve()
+	if err != nil {
+		pfxlog.Logger().WithError(err).Warn("controller SRV lookup failed, keeping current controller endpoints")
+		return nil, false
+	}
+
+	if slices.Equal(endpoints, self.lastEndpoints) {
+		return nil, false
+	}
+
+	self.lastEndpoints = endpoints
+	return endpoints, true
+}
+
+func (self *Router) runCtrlSrvDiscovery(discovery *ctrlSrvDiscovery) {
+	log := pfxlog.Logger().WithField("srvName", discovery.config.Name)
+	log.WithField("refreshInterval", discovery.config.RefreshInterval).Info("starting controller SRV discovery")
+
+	ticker := time.NewTicker(discovery.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if endpoints, changed := discovery.poll(); changed {
+				log.WithField("endpoints", endpoints).Info("controller SRV records changed, updating controller endpoints")
+				self.UpdateCtrlEndpoints(endpoints)
+			}
+		case <-self.GetCloseNotify():
+			return
+		}
+	}
+}
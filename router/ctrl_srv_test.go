@@ -0,0 +1,46 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package router
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_srvRecordsToEndpoints(t *testing.T) {
+	req := require.New(t)
+
+	records := []*net.SRV{
+		{Target: "ctrl2.example.com.", Port: 6262, Priority: 10, Weight: 5},
+		{Target: "ctrl1.example.com.", Port: 6262, Priority: 10, Weight: 5},
+		{Target: "ctrl1.example.com.", Port: 6262, Priority: 20, Weight: 5},
+		{Target: ".", Port: 6262},
+		{Target: "ctrl3.example.com.", Port: 0},
+		{Target: "10.0.0.5", Port: 443},
+	}
+
+	endpoints := srvRecordsToEndpoints("tls", records)
+	req.Equal([]string{
+		"tls:10.0.0.5:443",
+		"tls:ctrl1.example.com:6262",
+		"tls:ctrl2.example.com:6262",
+	}, endpoints)
+
+	req.Empty(srvRecordsToEndpoints("tls", nil))
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/openziti/transport/v2/tls"
 	"github.com/openziti/ziti/controller/command"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -34,7 +35,13 @@ import (
 	"github.com/openziti/sdk-golang/xgress"
 	"github.com/openziti/transport/v2"
 	"github.com/openziti/ziti/common/config"
+	"github.com/openziti/ziti/common/linktls"
+	"github.com/openziti/ziti/common/metrics/sampling"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/mempressure"
+	"github.com/openziti/ziti/router/profwatch"
+	"github.com/openziti/ziti/router/trustbundle"
+	"github.com/openziti/ziti/router/update"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 	yaml3 "gopkg.in/yaml.v3"
@@ -93,6 +100,18 @@ const (
 	MaxConnectEventsFullSyncInterval     = 24 * time.Hour
 
 	InterfaceDiscoveryMapKey = "interfaceDiscovery"
+
+	SpiffeMapKey = "spiffe"
+
+	DefaultSpiffeCheckInterval = 30 * time.Second
+	MinSpiffeCheckInterval     = time.Second
+
+	// CtrlSrvMapKey is the string key for the ctrl.srv section
+	CtrlSrvMapKey = "srv"
+
+	DefaultCtrlSrvProtocol        = "tls"
+	DefaultCtrlSrvRefreshInterval = time.Minute
+	MinCtrlSrvRefreshInterval     = 5 * time.Second
 )
 
 // internalConfigKeys is used to distinguish internally defined configuration vs file configuration
@@ -106,15 +125,25 @@ func LoadConfigMap(path string) (map[interface{}]interface{}, error) {
 		return nil, err
 	}
 
+	cfgmap, err := ParseConfigMap(yamlBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgmap[PathMapKey] = path
+
+	return cfgmap, nil
+}
+
+// ParseConfigMap parses router config YAML which doesn't come from a file, such as a config generated in memory
+func ParseConfigMap(yamlBytes []byte) (map[interface{}]interface{}, error) {
 	cfgmap := make(map[interface{}]interface{})
-	if err = yaml.NewDecoder(bytes.NewReader(yamlBytes)).Decode(&cfgmap); err != nil {
+	if err := yaml.NewDecoder(bytes.NewReader(yamlBytes)).Decode(&cfgmap); err != nil {
 		return nil, err
 	}
 
 	config.InjectEnv(cfgmap)
 
-	cfgmap[PathMapKey] = path
-
 	return cfgmap, nil
 }
 
@@ -124,6 +153,48 @@ type InterfaceDiscoveryConfig struct {
 	MinReportInterval time.Duration
 }
 
+// SpiffeConfig is used when the router identity is an X.509 SVID managed by an external SPIFFE implementation, such as
+// SPIRE. The identity files are expected to be kept up to date externally, for example by spiffe-helper. Instead of
+// extending its enrollment, the router checks for renewed SVIDs and reports them to the controller.
+type SpiffeConfig struct {
+	Enabled       bool
+	CheckInterval time.Duration
+}
+
+// LinkLatencyProbeConfig controls the latency probes sent on links in addition to heartbeats. The probe interval
+// shrinks towards MinInterval while latency is unstable or links are changing and grows back towards MaxInterval
+// while latency is stable. Heartbeats count as latency samples, so no extra probes are sent while the interval is
+// longer than the heartbeat interval.
+type LinkLatencyProbeConfig struct {
+	Adaptive          bool
+	MinInterval       time.Duration
+	MaxInterval       time.Duration
+	Timeout           time.Duration
+	VarianceThreshold float64
+}
+
+// LinkPathMtuConfig controls path MTU discovery on datagram based links. Probes of increasing size are sent between
+// MinSize and MaxSize to find the largest message which makes it across the link. The result is reported to the
+// controller, which includes the smallest path MTU of a circuit's links in its routes, so payloads can be fragmented
+// to fit. Discovery is repeated every Interval, in case the path changes.
+type LinkPathMtuConfig struct {
+	Enabled  bool
+	MinSize  uint32
+	MaxSize  uint32
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// CtrlSrvConfig configures discovery of controller endpoints using DNS SRV records. Each record target and port
+// becomes a controller endpoint using the given transport protocol. Records are resolved at startup and then every
+// RefreshInterval. If Resolver is set, it's used as the DNS server instead of the system resolver.
+type CtrlSrvConfig struct {
+	Name            string
+	Protocol        string
+	RefreshInterval time.Duration
+	Resolver        string
+}
+
 type Config struct {
 	IdConfig       *identity.Config
 	Id             *identity.TokenId
@@ -149,12 +220,18 @@ type Config struct {
 		EndpointsFile         string
 		Heartbeats            HeartbeatOptions
 		StartupTimeout        time.Duration
+		ActiveActive          bool
 		RateLimit             command.AdaptiveRateLimiterConfig
+		Srv                   *CtrlSrvConfig
 	}
 	Link struct {
-		Listeners  []map[interface{}]interface{}
-		Dialers    []map[interface{}]interface{}
-		Heartbeats channel.HeartbeatOptions
+		Listeners    []map[interface{}]interface{}
+		Dialers      []map[interface{}]interface{}
+		Heartbeats   channel.HeartbeatOptions
+		LatencyProbe LinkLatencyProbeConfig
+		PathMtu      LinkPathMtuConfig
+		DialOnly     bool
+		Tls          *linktls.Policy
 	}
 	Dialers   map[string]xgress.OptionsData
 	Listeners []ListenerBinding
@@ -165,6 +242,7 @@ type Config struct {
 		MessageQueueSize      int
 		EventQueueSize        int
 		EnableDataDelayMetric bool
+		Histograms            *sampling.HistogramConfig
 	}
 	HealthChecks struct {
 		CtrlPingCheck struct {
@@ -177,12 +255,33 @@ type Config struct {
 			Interval     time.Duration
 			InitialDelay time.Duration
 		}
+		CtrlChannelCheck struct {
+			MinConnected int
+			Interval     time.Duration
+			InitialDelay time.Duration
+		}
+		TerminatorCheck struct {
+			EstablishTimeout time.Duration
+			Interval         time.Duration
+			InitialDelay     time.Duration
+		}
+		CertExpiryCheck struct {
+			WarnBefore time.Duration
+			FailBefore time.Duration
+			Interval   time.Duration
+		}
+		LivenessChecks []string
 	}
 	ConnectEvents  ConnectEventsConfig
+	MemoryPressure *mempressure.Config
+	ProfWatchdog   *profwatch.Config
+	Update         *update.Config
+	TrustBundle    *trustbundle.Config
 	Proxy          *transport.ProxyConfiguration
 	Plugins        []string
 	Edge           *EdgeConfig
 	IfaceDiscovery InterfaceDiscoveryConfig
+	Spiffe         SpiffeConfig
 	Src            map[interface{}]interface{}
 	path           string
 }
@@ -206,6 +305,19 @@ const (
 
 	DefaultLinkHeartbeatSendInterval = 10 * time.Second
 	DefaultLinkUnresponsiveTimeout   = time.Minute
+
+	DefaultLinkLatencyProbeMinInterval       = time.Second
+	DefaultLinkLatencyProbeMaxInterval       = 30 * time.Second
+	DefaultLinkLatencyProbeTimeout           = 5 * time.Second
+	DefaultLinkLatencyProbeVarianceThreshold = 0.2
+	MinLinkLatencyProbeInterval              = 100 * time.Millisecond
+
+	DefaultLinkPathMtuMinSize  = 576
+	DefaultLinkPathMtuMaxSize  = 1400
+	DefaultLinkPathMtuInterval = 10 * time.Minute
+	DefaultLinkPathMtuTimeout  = 2 * time.Second
+	MinLinkPathMtuSize         = 256
+	MinLinkPathMtuInterval     = time.Minute
 )
 
 // CreateBackup will attempt to use the current path value to create a backup of
@@ -376,6 +488,13 @@ func LoadConfigWithOptions(path string, loadIdentity bool) (*Config, error) {
 		return nil, err
 	}
 
+	return LoadConfigFromMap(cfgmap, loadIdentity)
+}
+
+// LoadConfigFromMap loads the router config from a parsed config map. If the map wasn't loaded from a file, the
+// config can't be saved back, so settings which are normally written next to the config file, such as the
+// controller endpoints file, should be given explicitly.
+func LoadConfigFromMap(cfgmap map[interface{}]interface{}, loadIdentity bool) (*Config, error) {
 	if value, found := cfgmap["v"]; found {
 		if value.(int) != 3 {
 			panic("config version mismatch: see docs for information on config updates")
@@ -544,6 +663,11 @@ func LoadConfigWithOptions(path string, loadIdentity bool) (*Config, error) {
 					return nil, errors.Wrap(err, "invalid value for ctrl.startupTimeout")
 				}
 			}
+			if value, found := submap["activeActive"]; found {
+				if cfg.Ctrl.ActiveActive, ok = value.(bool); !ok {
+					return nil, errors.Errorf("invalid value for ctrl.activeActive, must be a boolean: %v", value)
+				}
+			}
 			if value, found := submap["endpointsFile"]; found {
 				cfg.Ctrl.EndpointsFile = value.(string)
 			} else {
@@ -552,12 +676,31 @@ func LoadConfigWithOptions(path string, loadIdentity bool) (*Config, error) {
 			if err = cfg.loadCtrlRateLimiterConfig(submap); err != nil {
 				return nil, err
 			}
+			if value, found := submap[CtrlSrvMapKey]; found {
+				if cfg.Ctrl.Srv, err = loadCtrlSrvConfig(value); err != nil {
+					return nil, err
+				}
+			}
 		}
 	}
 
 	cfg.Link.Heartbeats = *channel.DefaultHeartbeatOptions()
 	cfg.Link.Heartbeats.SendInterval = DefaultLinkHeartbeatSendInterval
 	cfg.Link.Heartbeats.CloseUnresponsiveTimeout = DefaultLinkUnresponsiveTimeout
+	cfg.Link.LatencyProbe = LinkLatencyProbeConfig{
+		Adaptive:          true,
+		MinInterval:       DefaultLinkLatencyProbeMinInterval,
+		MaxInterval:       DefaultLinkLatencyProbeMaxInterval,
+		Timeout:           DefaultLinkLatencyProbeTimeout,
+		VarianceThreshold: DefaultLinkLatencyProbeVarianceThreshold,
+	}
+	cfg.Link.PathMtu = LinkPathMtuConfig{
+		Enabled:  true,
+		MinSize:  DefaultLinkPathMtuMinSize,
+		MaxSize:  DefaultLinkPathMtuMaxSize,
+		Interval: DefaultLinkPathMtuInterval,
+		Timeout:  DefaultLinkPathMtuTimeout,
+	}
 
 	if value, found := cfgmap["link"]; found {
 		if submap, ok := value.(map[interface{}]interface{}); ok {
@@ -613,6 +756,46 @@ func LoadConfigWithOptions(path string, loadIdentity bool) (*Config, error) {
 					cfg.Link.Heartbeats = *options
 				}
 			}
+
+			if value, found := submap["latencyProbe"]; found {
+				if probeMap, ok := value.(map[interface{}]interface{}); ok {
+					if err := cfg.loadLinkLatencyProbeConfig(probeMap); err != nil {
+						return nil, err
+					}
+				} else {
+					return nil, fmt.Errorf("[link/latencyProbe] must express a map (%v)", value)
+				}
+			}
+
+			if value, found := submap["pathMtu"]; found {
+				if pathMtuMap, ok := value.(map[interface{}]interface{}); ok {
+					if err := cfg.loadLinkPathMtuConfig(pathMtuMap); err != nil {
+						return nil, err
+					}
+				} else {
+					return nil, fmt.Errorf("[link/pathMtu] must express a map (%v)", value)
+				}
+			}
+
+			if value, found := submap["dialOnly"]; found {
+				if dialOnly, ok := value.(bool); ok {
+					cfg.Link.DialOnly = dialOnly
+				} else {
+					return nil, fmt.Errorf("[link/dialOnly] must be a bool (%v)", value)
+				}
+			}
+
+			if value, found := submap["tls"]; found {
+				if tlsMap, ok := value.(map[interface{}]interface{}); ok {
+					policy, err := linktls.LoadPolicy(tlsMap)
+					if err != nil {
+						return nil, fmt.Errorf("invalid [link/tls] (%w)", err)
+					}
+					cfg.Link.Tls = policy
+				} else {
+					return nil, fmt.Errorf("[link/tls] must express a map (%v)", value)
+				}
+			}
 		}
 	}
 
@@ -689,6 +872,44 @@ func LoadConfigWithOptions(path string, loadIdentity bool) (*Config, error) {
 			if value, found := submap["enableDataDelayMetric"]; found {
 				cfg.Metrics.EnableDataDelayMetric = strings.EqualFold("true", fmt.Sprintf("%v", value))
 			}
+			if value, found := submap["histograms"]; found {
+				var err error
+				if cfg.Metrics.Histograms, err = sampling.LoadHistogramConfig(value, "metrics.histograms"); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	cfg.MemoryPressure = mempressure.DefaultConfig()
+	if value, found := cfgmap["memoryPressure"]; found {
+		var err error
+		if cfg.MemoryPressure, err = mempressure.LoadConfig(value, "memoryPressure"); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.ProfWatchdog = profwatch.DefaultConfig()
+	if value, found := cfgmap["profilingWatchdog"]; found {
+		var err error
+		if cfg.ProfWatchdog, err = profwatch.LoadConfig(value, "profilingWatchdog"); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.Update = update.DefaultConfig()
+	if value, found := cfgmap["update"]; found {
+		var err error
+		if cfg.Update, err = update.LoadConfig(value, "update"); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.TrustBundle = trustbundle.DefaultConfig()
+	if value, found := cfgmap["trustBundle"]; found {
+		var err error
+		if cfg.TrustBundle, err = trustbundle.LoadConfig(value, "trustBundle"); err != nil {
+			return nil, err
 		}
 	}
 
@@ -698,6 +919,15 @@ func LoadConfigWithOptions(path string, loadIdentity bool) (*Config, error) {
 	cfg.HealthChecks.LinkCheck.Interval = 5 * time.Second
 	cfg.HealthChecks.LinkCheck.InitialDelay = 1 * time.Second
 	cfg.HealthChecks.LinkCheck.MinLinks = 0
+	cfg.HealthChecks.CtrlChannelCheck.MinConnected = 1
+	cfg.HealthChecks.CtrlChannelCheck.Interval = 5 * time.Second
+	cfg.HealthChecks.CtrlChannelCheck.InitialDelay = 15 * time.Second
+	cfg.HealthChecks.TerminatorCheck.EstablishTimeout = 2 * time.Minute
+	cfg.HealthChecks.TerminatorCheck.Interval = 30 * time.Second
+	cfg.HealthChecks.TerminatorCheck.InitialDelay = 30 * time.Second
+	cfg.HealthChecks.CertExpiryCheck.WarnBefore = 7 * 24 * time.Hour
+	cfg.HealthChecks.CertExpiryCheck.FailBefore = 24 * time.Hour
+	cfg.HealthChecks.CertExpiryCheck.Interval = time.Hour
 
 	if value, found := cfgmap["healthChecks"]; found {
 		if healthChecksMap, ok := value.(map[interface{}]interface{}); ok {
@@ -759,6 +989,96 @@ func LoadConfigWithOptions(path string, loadIdentity bool) (*Config, error) {
 					pfxlog.Logger().Warn("invalid [healthChecks.linkCheck] stanza")
 				}
 			}
+			if value, found := healthChecksMap["ctrlChannelCheck"]; found {
+				if checkMap, ok := value.(map[interface{}]interface{}); ok {
+					if value, found := checkMap["minConnected"]; found {
+						if val, ok := value.(int); ok {
+							cfg.HealthChecks.CtrlChannelCheck.MinConnected = val
+						} else {
+							return nil, errors.Errorf("invalid value [%v] for healthChecks.ctrlChannelCheck.minConnected", value)
+						}
+					}
+					if value, found := checkMap["interval"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.HealthChecks.CtrlChannelCheck.Interval = val
+						} else {
+							return nil, errors.Wrapf(err, "failed to parse healthChecks.ctrlChannelCheck.interval value '%v", value)
+						}
+					}
+					if value, found := checkMap["initialDelay"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.HealthChecks.CtrlChannelCheck.InitialDelay = val
+						} else {
+							return nil, errors.Wrapf(err, "failed to parse healthChecks.ctrlChannelCheck.initialDelay value '%v", value)
+						}
+					}
+				} else {
+					pfxlog.Logger().Warn("invalid [healthChecks.ctrlChannelCheck] stanza")
+				}
+			}
+			if value, found := healthChecksMap["terminatorCheck"]; found {
+				if checkMap, ok := value.(map[interface{}]interface{}); ok {
+					if value, found := checkMap["establishTimeout"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.HealthChecks.TerminatorCheck.EstablishTimeout = val
+						} else {
+							return nil, errors.Wrapf(err, "failed to parse healthChecks.terminatorCheck.establishTimeout value '%v", value)
+						}
+					}
+					if value, found := checkMap["interval"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.HealthChecks.TerminatorCheck.Interval = val
+						} else {
+							return nil, errors.Wrapf(err, "failed to parse healthChecks.terminatorCheck.interval value '%v", value)
+						}
+					}
+					if value, found := checkMap["initialDelay"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.HealthChecks.TerminatorCheck.InitialDelay = val
+						} else {
+							return nil, errors.Wrapf(err, "failed to parse healthChecks.terminatorCheck.initialDelay value '%v", value)
+						}
+					}
+				} else {
+					pfxlog.Logger().Warn("invalid [healthChecks.terminatorCheck] stanza")
+				}
+			}
+			if value, found := healthChecksMap["certExpiryCheck"]; found {
+				if checkMap, ok := value.(map[interface{}]interface{}); ok {
+					if value, found := checkMap["warnBefore"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.HealthChecks.CertExpiryCheck.WarnBefore = val
+						} else {
+							return nil, errors.Wrapf(err, "failed to parse healthChecks.certExpiryCheck.warnBefore value '%v", value)
+						}
+					}
+					if value, found := checkMap["failBefore"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.HealthChecks.CertExpiryCheck.FailBefore = val
+						} else {
+							return nil, errors.Wrapf(err, "failed to parse healthChecks.certExpiryCheck.failBefore value '%v", value)
+						}
+					}
+					if value, found := checkMap["interval"]; found {
+						if val, err := time.ParseDuration(fmt.Sprintf("%v", value)); err == nil {
+							cfg.HealthChecks.CertExpiryCheck.Interval = val
+						} else {
+							return nil, errors.Wrapf(err, "failed to parse healthChecks.certExpiryCheck.interval value '%v", value)
+						}
+					}
+				} else {
+					pfxlog.Logger().Warn("invalid [healthChecks.certExpiryCheck] stanza")
+				}
+			}
+			if value, found := healthChecksMap["livenessChecks"]; found {
+				if list, ok := value.([]interface{}); ok {
+					for _, v := range list {
+						cfg.HealthChecks.LivenessChecks = append(cfg.HealthChecks.LivenessChecks, fmt.Sprintf("%v", v))
+					}
+				} else {
+					pfxlog.Logger().Warn("invalid [healthChecks.livenessChecks] value")
+				}
+			}
 		} else {
 			pfxlog.Logger().Warn("invalid [healthChecks] stanza")
 		}
@@ -967,6 +1287,35 @@ func LoadConfigWithOptions(path string, loadIdentity bool) (*Config, error) {
 		}
 	}
 
+	cfg.Spiffe.CheckInterval = DefaultSpiffeCheckInterval
+
+	if value, found := cfgmap[SpiffeMapKey]; found {
+		if subMap, ok := value.(map[interface{}]interface{}); !ok {
+			return nil, errors.New("invalid spiffe value, should be map")
+		} else {
+			if value, found := subMap["enabled"]; found {
+				cfg.Spiffe.Enabled = strings.EqualFold("true", fmt.Sprintf("%v", value))
+			}
+
+			if value, found := subMap["checkInterval"]; found {
+				if strVal, ok := value.(string); ok {
+					interval, err := time.ParseDuration(strVal)
+					if err != nil {
+						return nil, errors.New("invalid value: spiffe.checkInterval value should be a valid duration")
+					}
+					cfg.Spiffe.CheckInterval = interval
+				} else {
+					return nil, errors.New("invalid value: spiffe.checkInterval value should be a string representing a duration")
+				}
+			}
+		}
+	}
+
+	if cfg.Spiffe.CheckInterval < MinSpiffeCheckInterval {
+		pfxlog.Logger().Warnf("spiffe.checkInterval less than allowed minimum of %s", MinSpiffeCheckInterval.String())
+		cfg.Spiffe.CheckInterval = MinSpiffeCheckInterval
+	}
+
 	return cfg, nil
 }
 
@@ -1008,6 +1357,188 @@ func (c *Config) loadCtrlRateLimiterConfig(cfgmap map[interface{}]interface{}) e
 	return nil
 }
 
+func loadCtrlSrvConfig(value interface{}) (*CtrlSrvConfig, error) {
+	result := &CtrlSrvConfig{
+		Protocol:        DefaultCtrlSrvProtocol,
+		RefreshInterval: DefaultCtrlSrvRefreshInterval,
+	}
+
+	if name, ok := value.(string); ok {
+		result.Name = name
+		return result, nil
+	}
+
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid type for ctrl.srv, should be a record name or a map instead of %T", value)
+	}
+
+	for key, target := range map[string]*string{"name": &result.Name, "protocol": &result.Protocol, "resolver": &result.Resolver} {
+		if value, found := submap[key]; found {
+			strVal, ok := value.(string)
+			if !ok {
+				return nil, errors.Errorf("invalid type for ctrl.srv.%s, should be string instead of %T", key, value)
+			}
+			*target = strVal
+		}
+	}
+
+	if value, found := submap["refreshInterval"]; found {
+		strVal, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("invalid type for ctrl.srv.refreshInterval, should be duration string instead of %T", value)
+		}
+		interval, err := time.ParseDuration(strVal)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value %v for ctrl.srv.refreshInterval", value)
+		}
+		if interval < MinCtrlSrvRefreshInterval {
+			return nil, errors.Errorf("invalid value %v for ctrl.srv.refreshInterval, must be at least %v", value, MinCtrlSrvRefreshInterval)
+		}
+		result.RefreshInterval = interval
+	}
+
+	if result.Name == "" {
+		return nil, errors.New("ctrl.srv.name is required")
+	}
+
+	if result.Protocol == "" {
+		return nil, errors.New("ctrl.srv.protocol may not be empty")
+	}
+
+	return result, nil
+}
+
+func (c *Config) loadLinkLatencyProbeConfig(cfgmap map[interface{}]interface{}) error {
+	probeConfig := &c.Link.LatencyProbe
+
+	if value, found := cfgmap["adaptive"]; found {
+		if adaptive, ok := value.(bool); ok {
+			probeConfig.Adaptive = adaptive
+		} else {
+			return errors.Errorf("invalid type for link.latencyProbe.adaptive, should be bool instead of %T", value)
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"minInterval": &probeConfig.MinInterval,
+		"maxInterval": &probeConfig.MaxInterval,
+		"timeout":     &probeConfig.Timeout,
+	}
+
+	for name, target := range durations {
+		if value, found := cfgmap[name]; found {
+			strVal, ok := value.(string)
+			if !ok {
+				return errors.Errorf("invalid type for link.latencyProbe.%s, should be duration string instead of %T", name, value)
+			}
+			d, err := time.ParseDuration(strVal)
+			if err != nil {
+				return errors.Wrapf(err, "invalid value %v for link.latencyProbe.%s", value, name)
+			}
+			*target = d
+		}
+	}
+
+	if value, found := cfgmap["varianceThreshold"]; found {
+		switch v := value.(type) {
+		case float64:
+			probeConfig.VarianceThreshold = v
+		case int:
+			probeConfig.VarianceThreshold = float64(v)
+		default:
+			return errors.Errorf("invalid type for link.latencyProbe.varianceThreshold, should be number instead of %T", value)
+		}
+	}
+
+	if probeConfig.MinInterval < MinLinkLatencyProbeInterval {
+		return errors.Errorf("invalid value %v for link.latencyProbe.minInterval, must be at least %v",
+			probeConfig.MinInterval, MinLinkLatencyProbeInterval)
+	}
+
+	if probeConfig.MaxInterval < probeConfig.MinInterval {
+		return errors.Errorf("invalid value %v for link.latencyProbe.maxInterval, must be at least minInterval (%v)",
+			probeConfig.MaxInterval, probeConfig.MinInterval)
+	}
+
+	if probeConfig.Timeout <= 0 {
+		return errors.Errorf("invalid value %v for link.latencyProbe.timeout, must be greater than 0", probeConfig.Timeout)
+	}
+
+	if probeConfig.VarianceThreshold <= 0 {
+		return errors.Errorf("invalid value %v for link.latencyProbe.varianceThreshold, must be greater than 0",
+			probeConfig.VarianceThreshold)
+	}
+
+	return nil
+}
+
+func (c *Config) loadLinkPathMtuConfig(cfgmap map[interface{}]interface{}) error {
+	pathMtuConfig := &c.Link.PathMtu
+
+	if value, found := cfgmap["enabled"]; found {
+		if enabled, ok := value.(bool); ok {
+			pathMtuConfig.Enabled = enabled
+		} else {
+			return errors.Errorf("invalid type for link.pathMtu.enabled, should be bool instead of %T", value)
+		}
+	}
+
+	sizes := map[string]*uint32{
+		"minSize": &pathMtuConfig.MinSize,
+		"maxSize": &pathMtuConfig.MaxSize,
+	}
+
+	for name, target := range sizes {
+		if value, found := cfgmap[name]; found {
+			intVal, ok := value.(int)
+			if !ok {
+				return errors.Errorf("invalid type for link.pathMtu.%s, should be int instead of %T", name, value)
+			}
+			if intVal < MinLinkPathMtuSize || intVal > math.MaxUint16 {
+				return errors.Errorf("invalid value %v for link.pathMtu.%s, must be between %d and %d",
+					value, name, MinLinkPathMtuSize, math.MaxUint16)
+			}
+			*target = uint32(intVal)
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"interval": &pathMtuConfig.Interval,
+		"timeout":  &pathMtuConfig.Timeout,
+	}
+
+	for name, target := range durations {
+		if value, found := cfgmap[name]; found {
+			strVal, ok := value.(string)
+			if !ok {
+				return errors.Errorf("invalid type for link.pathMtu.%s, should be duration string instead of %T", name, value)
+			}
+			d, err := time.ParseDuration(strVal)
+			if err != nil {
+				return errors.Wrapf(err, "invalid value %v for link.pathMtu.%s", value, name)
+			}
+			*target = d
+		}
+	}
+
+	if pathMtuConfig.MaxSize < pathMtuConfig.MinSize {
+		return errors.Errorf("invalid value %v for link.pathMtu.maxSize, must be at least minSize (%v)",
+			pathMtuConfig.MaxSize, pathMtuConfig.MinSize)
+	}
+
+	if pathMtuConfig.Interval < MinLinkPathMtuInterval {
+		return errors.Errorf("invalid value %v for link.pathMtu.interval, must be at least %v",
+			pathMtuConfig.Interval, MinLinkPathMtuInterval)
+	}
+
+	if pathMtuConfig.Timeout <= 0 {
+		return errors.Errorf("invalid value %v for link.pathMtu.timeout, must be greater than 0", pathMtuConfig.Timeout)
+	}
+
+	return nil
+}
+
 func (c *Config) SaveControllerEndpoints(endpoints []string) error {
 	endpointsFile := c.Ctrl.EndpointsFile
 
@@ -40,6 +40,8 @@ const (
 	DefaultSessionValidateChunkSize   = 1000
 	DefaultSessionValidateMinInterval = "250ms"
 	DefaultSessionValidateMaxInterval = "1500ms"
+	DefaultOcspCacheDuration          = time.Minute
+	DefaultOcspTimeout                = 5 * time.Second
 )
 
 type EdgeConfig struct {
@@ -53,6 +55,7 @@ type EdgeConfig struct {
 	SessionValidateMaxInterval time.Duration
 	Tcfg                       transport.Configuration
 	ForceExtendEnrollment      bool
+	Ocsp                       OcspConfig
 
 	RouterConfig *Config
 
@@ -69,6 +72,25 @@ type Csr struct {
 	Province           string `yaml:"province"`
 }
 
+// OcspConfig controls checking the revocation status of SDK client certificates with OCSP when they connect to edge
+// listeners
+type OcspConfig struct {
+	Enabled bool
+
+	// Responder overrides the OCSP responder url in client certificates. If not set, only certificates with an OCSP
+	// responder url are checked
+	Responder string
+
+	// CacheDuration is the longest a response will be cached, even if the responder indicates it's valid for longer
+	CacheDuration time.Duration
+
+	// Timeout bounds how long to wait for the responder
+	Timeout time.Duration
+
+	// FailOpen allows connections when the status can't be determined, for example if the responder isn't reachable
+	FailOpen bool
+}
+
 type ApiProxy struct {
 	Enabled  bool
 	Listener string
@@ -189,6 +211,10 @@ func (config *EdgeConfig) LoadEdgeConfigFromMap(configMap map[interface{}]interf
 		return err
 	}
 
+	if err = config.loadOcsp(edgeConfigMap); err != nil {
+		return err
+	}
+
 	if err = config.loadEdgeListener(configMap); err != nil {
 		return err
 	}
@@ -239,6 +265,65 @@ func (config *EdgeConfig) loadApiProxy(edgeConfigMap map[interface{}]interface{}
 	return nil
 }
 
+func (config *EdgeConfig) loadOcsp(edgeConfigMap map[interface{}]interface{}) error {
+	config.Ocsp = OcspConfig{
+		CacheDuration: DefaultOcspCacheDuration,
+		Timeout:       DefaultOcspTimeout,
+		FailOpen:      true,
+	}
+
+	value, found := edgeConfigMap["ocsp"]
+	if !found || value == nil {
+		return nil
+	}
+
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return errors.New("value [edge.ocsp] must be a map")
+	}
+
+	config.Ocsp.Enabled = true
+	if value, found := submap["enabled"]; found {
+		if config.Ocsp.Enabled, ok = value.(bool); !ok {
+			return errors.New("value [edge.ocsp.enabled] must be a boolean")
+		}
+	}
+
+	if value, found := submap["responder"]; found {
+		config.Ocsp.Responder = fmt.Sprintf("%v", value)
+		if _, err := url.Parse(config.Ocsp.Responder); err != nil {
+			return errors.Wrap(err, "invalid value [edge.ocsp.responder]")
+		}
+	}
+
+	if value, found := submap["cacheDuration"]; found {
+		duration, err := time.ParseDuration(fmt.Sprintf("%v", value))
+		if err != nil {
+			return errors.Wrap(err, "invalid value [edge.ocsp.cacheDuration]")
+		}
+		config.Ocsp.CacheDuration = duration
+	}
+
+	if value, found := submap["timeout"]; found {
+		timeout, err := time.ParseDuration(fmt.Sprintf("%v", value))
+		if err != nil {
+			return errors.Wrap(err, "invalid value [edge.ocsp.timeout]")
+		}
+		if timeout <= 0 {
+			return errors.New("value [edge.ocsp.timeout] must be greater than 0")
+		}
+		config.Ocsp.Timeout = timeout
+	}
+
+	if value, found := submap["failOpen"]; found {
+		if config.Ocsp.FailOpen, ok = value.(bool); !ok {
+			return errors.New("value [edge.ocsp.failOpen] must be a boolean")
+		}
+	}
+
+	return nil
+}
+
 func (config *EdgeConfig) loadEdgeListener(rootConfigMap map[interface{}]interface{}) error {
 	subArray := rootConfigMap["listeners"]
 
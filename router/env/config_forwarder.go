@@ -25,6 +25,7 @@ const (
 	DefaultXgressCloseCheckInterval    = 5 * time.Second
 	DefaultXgressDialDwellTime         = 0
 	DefaultFaultTxInterval             = 15 * time.Second
+	DefaultRouteRepairHoldoff          = 5 * time.Second
 	DefaultIdleTxInterval              = 60 * time.Second
 	DefaultIdleCircuitTimeout          = 60 * time.Second
 	DefaultXgressDialWorkerQueueLength = 1000
@@ -40,6 +41,7 @@ const (
 	DefaultLinkDialWorkerCount   = 32
 	MinLinkDialWorkerCount       = 1
 	MaxLinkDialWorkerCount       = 10000
+	MaxLinkDialReconnectRate     = 10000
 
 	DefaultRateLimiterQueueLength   = 5000
 	MinRateLimiterWorkerQueueLength = 1
@@ -57,7 +59,10 @@ type ForwarderOptions struct {
 	IdleCircuitTimeout       time.Duration
 	IdleTxInterval           time.Duration
 	LinkDial                 WorkerPoolOptions
+	LinkDialReconnect        LinkDialPacingOptions
+	PayloadClassification    bool
 	RateLimiter              WorkerPoolOptions
+	RouteRepairHoldoff       time.Duration
 	UnresponsiveLinkTimeout  time.Duration
 	XgressCloseCheckInterval time.Duration
 	XgressDial               WorkerPoolOptions
@@ -69,6 +74,16 @@ type WorkerPoolOptions struct {
 	WorkerCount uint16
 }
 
+// LinkDialPacingOptions limit link dials for a period after the router starts or reconnects to a controller, when
+// many links may need to be dialed at once. A zero Window disables pacing. Zero Concurrency or Rate means that limit
+// isn't applied.
+type LinkDialPacingOptions struct {
+	Window      time.Duration
+	Concurrency uint16
+	Rate        uint16
+	Jitter      time.Duration
+}
+
 func DefaultForwarderOptions() *ForwarderOptions {
 	return &ForwarderOptions{
 		FaultTxInterval:    DefaultFaultTxInterval,
@@ -82,6 +97,7 @@ func DefaultForwarderOptions() *ForwarderOptions {
 			QueueLength: DefaultRateLimiterQueueLength,
 			WorkerCount: DefaultRateLimiterWorkerCount,
 		},
+		RouteRepairHoldoff:       DefaultRouteRepairHoldoff,
 		UnresponsiveLinkTimeout:  DefaultUnresponsiveLinkTimeout,
 		XgressCloseCheckInterval: DefaultXgressCloseCheckInterval,
 		XgressDial: WorkerPoolOptions{
@@ -141,6 +157,46 @@ func LoadForwarderOptions(src map[interface{}]interface{}) (*ForwarderOptions, e
 		}
 	}
 
+	if value, found := src["linkDialReconnectWindow"]; found {
+		if val, err := parseForwarderDuration("linkDialReconnectWindow", value); err != nil {
+			return nil, err
+		} else {
+			options.LinkDialReconnect.Window = val
+		}
+	}
+
+	if value, found := src["linkDialReconnectConcurrency"]; found {
+		if val, ok := value.(int); ok && val >= 0 && val <= MaxLinkDialWorkerCount {
+			options.LinkDialReconnect.Concurrency = uint16(val)
+		} else {
+			return nil, errors.Errorf("invalid value for 'linkDialReconnectConcurrency', expected integer between 0 and %v", MaxLinkDialWorkerCount)
+		}
+	}
+
+	if value, found := src["linkDialReconnectRate"]; found {
+		if val, ok := value.(int); ok && val >= 0 && val <= MaxLinkDialReconnectRate {
+			options.LinkDialReconnect.Rate = uint16(val)
+		} else {
+			return nil, errors.Errorf("invalid value for 'linkDialReconnectRate', expected integer between 0 and %v", MaxLinkDialReconnectRate)
+		}
+	}
+
+	if value, found := src["linkDialReconnectJitter"]; found {
+		if val, err := parseForwarderDuration("linkDialReconnectJitter", value); err != nil {
+			return nil, err
+		} else {
+			options.LinkDialReconnect.Jitter = val
+		}
+	}
+
+	if value, found := src["payloadClassification"]; found {
+		if val, ok := value.(bool); ok {
+			options.PayloadClassification = val
+		} else {
+			return nil, errors.New("invalid value for 'payloadClassification', expected boolean")
+		}
+	}
+
 	if value, found := src["rateLimitedQueueLength"]; found {
 		if length, ok := value.(int); ok {
 			if length < MinRateLimiterWorkerQueueLength || length > MaxRateLimiterWorkerQueueLength {
@@ -163,6 +219,14 @@ func LoadForwarderOptions(src map[interface{}]interface{}) (*ForwarderOptions, e
 		}
 	}
 
+	if value, found := src["routeRepairHoldoff"]; found {
+		if val, ok := value.(int); ok {
+			options.RouteRepairHoldoff = time.Duration(val) * time.Millisecond
+		} else {
+			return nil, errors.New("invalid value for 'routeRepairHoldoff'")
+		}
+	}
+
 	if value, found := src["unresponsiveLinkTimeout"]; found {
 		if val, ok := value.(string); ok {
 			if d, err := time.ParseDuration(val); err != nil {
@@ -221,3 +285,24 @@ func LoadForwarderOptions(src map[interface{}]interface{}) (*ForwarderOptions, e
 
 	return options, nil
 }
+
+// parseForwarderDuration accepts either a duration string, such as 1m30s, or an integer number of milliseconds
+func parseForwarderDuration(name string, value interface{}) (time.Duration, error) {
+	var result time.Duration
+	if val, ok := value.(string); ok {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to parse duration [%s] for '%s'", val, name)
+		}
+		result = d
+	} else if val, ok := value.(int); ok {
+		result = time.Duration(val) * time.Millisecond
+	} else {
+		return 0, errors.Errorf("invalid value for '%s'", name)
+	}
+
+	if result < 0 {
+		return 0, errors.Errorf("invalid duration %v for '%s', must be >= 0", result, name)
+	}
+	return result, nil
+}
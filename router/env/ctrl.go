@@ -59,6 +59,9 @@ type networkCtrl struct {
 	versionInfo      *versions.VersionInfo
 	lastContact      atomic.Int64
 	currentIndex     atomic.Uint64
+
+	// onResponsive is called when the controller starts responding to heartbeats again after being unresponsive
+	onResponsive func()
 }
 
 func (self *networkCtrl) TimeSinceLastContact() time.Duration {
@@ -139,8 +142,8 @@ func (self *networkCtrl) CheckHeartBeat() {
 		self.unresponsive.Store(true)
 	} else if !self.IsConnected() {
 		self.unresponsive.Store(true)
-	} else {
-		self.unresponsive.Store(false)
+	} else if self.unresponsive.Swap(false) && self.onResponsive != nil {
+		self.onResponsive()
 	}
 }
 
@@ -19,6 +19,8 @@ package env
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -53,6 +55,9 @@ const (
 	ControllerReconnected  CtrlEventType = "Reconnected"
 	ControllerRemoved      CtrlEventType = "Removed"
 	ControllerLeaderChange CtrlEventType = "LeaderChange"
+
+	// ControllerResponsive is sent when a controller which was unresponsive starts responding to heartbeats again
+	ControllerResponsive CtrlEventType = "Responsive"
 )
 
 type CtrlEvent struct {
@@ -85,11 +90,15 @@ type NetworkControllers interface {
 
 type CtrlDialer func(address transport.Address, bindHandler channel.BindHandler) error
 
-func NewNetworkControllers(defaultRequestTimeout time.Duration, dialer CtrlDialer, heartbeatOptions *HeartbeatOptions) NetworkControllers {
+// NewNetworkControllers creates the set of controllers the router is connected to. If activeActive is set, requests
+// and model updates are spread across all responsive controllers, rather than going to the most responsive controller
+// or the leader. Non-leader controllers forward model updates to the leader, so any controller can accept them.
+func NewNetworkControllers(defaultRequestTimeout time.Duration, dialer CtrlDialer, heartbeatOptions *HeartbeatOptions, activeActive bool) NetworkControllers {
 	return &networkControllers{
 		ctrlDialer:            dialer,
 		heartbeatOptions:      heartbeatOptions,
 		defaultRequestTimeout: defaultRequestTimeout,
+		activeActive:          activeActive,
 		ctrlEndpoints:         cmap.New[struct{}](),
 	}
 }
@@ -99,6 +108,8 @@ type networkControllers struct {
 	ctrlDialer            CtrlDialer
 	heartbeatOptions      *HeartbeatOptions
 	defaultRequestTimeout time.Duration
+	activeActive          bool
+	nextCtrl              atomic.Uint32
 	ctrlEndpoints         cmap.ConcurrentMap[string, struct{}]
 	ctrls                 concurrenz.CopyOnWriteMap[string, NetworkController]
 	leaderId              concurrenz.AtomicValue[string]
@@ -223,6 +234,9 @@ func (self *networkControllers) connectToControllerWithBackoff(endpoint string)
 
 func (self *networkControllers) Add(address string, ch channel.Channel) error {
 	ctrl := newNetworkCtrl(ch, address, self.heartbeatOptions)
+	ctrl.onResponsive = func() {
+		self.notifyOfChange(ctrl, ControllerResponsive)
+	}
 
 	if versionValue, found := ch.Underlay().Headers()[channel.HelloVersionHeader]; found {
 		if versionInfo, err := versions.StdVersionEncDec.Decode(versionValue); err == nil {
@@ -273,6 +287,12 @@ func (self *networkControllers) GetAll() map[string]NetworkController {
 }
 
 func (self *networkControllers) AnyCtrlChannel() channel.Channel {
+	if self.activeActive {
+		if ch := self.nextResponsiveCtrlChannel(); ch != nil {
+			return ch
+		}
+	}
+
 	var current NetworkController
 	for _, ctrl := range self.ctrls.AsMap() {
 		if current == nil || ctrl.isMoreResponsive(current) {
@@ -290,6 +310,12 @@ func (self *networkControllers) isLeader(controller NetworkController) bool {
 }
 
 func (self *networkControllers) GetModelUpdateCtrlChannel() channel.Channel {
+	if self.activeActive {
+		if ch := self.nextResponsiveCtrlChannel(); ch != nil {
+			return ch
+		}
+	}
+
 	var current NetworkController
 	for _, ctrl := range self.ctrls.AsMap() {
 		if current == nil ||
@@ -304,6 +330,29 @@ func (self *networkControllers) GetModelUpdateCtrlChannel() channel.Channel {
 	return current.Channel()
 }
 
+// nextResponsiveCtrlChannel rotates through the connected, responsive controllers, so load is spread across them and
+// losing a single controller only affects the requests it was handling. Returns nil if no controller is responsive.
+func (self *networkControllers) nextResponsiveCtrlChannel() channel.Channel {
+	var candidates []NetworkController
+	for _, ctrl := range self.ctrls.AsMap() {
+		if ctrl.IsConnected() && !ctrl.IsUnresponsive() {
+			candidates = append(candidates, ctrl)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// map iteration order is random, so sort to keep the rotation stable
+	slices.SortFunc(candidates, func(a, b NetworkController) int {
+		return strings.Compare(a.Channel().Id(), b.Channel().Id())
+	})
+
+	idx := self.nextCtrl.Add(1) % uint32(len(candidates))
+	return candidates[idx].Channel()
+}
+
 func (self *networkControllers) AllResponsiveCtrlChannels() []channel.Channel {
 	var channels []channel.Channel
 	for _, ctrl := range self.ctrls.AsMap() {
@@ -392,7 +441,8 @@ func (self *networkControllers) IsLeaderConnected() bool {
 
 func (self *networkControllers) Inspect() *inspect.ControllerInspectDetails {
 	result := &inspect.ControllerInspectDetails{
-		Controllers: map[string]*inspect.ControllerInspectDetail{},
+		Controllers:  map[string]*inspect.ControllerInspectDetail{},
+		ActiveActive: self.activeActive,
 	}
 
 	for id, ctrl := range self.ctrls.AsMap() {
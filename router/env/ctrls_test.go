@@ -0,0 +1,219 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package env
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/foundation/v2/versions"
+	"github.com/stretchr/testify/require"
+)
+
+type testCtrlUnderlay struct {
+	channel.Underlay
+	connected bool
+	headers   map[int32][]byte
+}
+
+func (self *testCtrlUnderlay) Headers() map[int32][]byte {
+	return self.headers
+}
+
+func (self *testCtrlUnderlay) IsConnected() bool {
+	return self.connected
+}
+
+type testCtrlChannel struct {
+	channel.Channel
+	id       string
+	underlay *testCtrlUnderlay
+}
+
+func (self *testCtrlChannel) Id() string {
+	return self.id
+}
+
+func (self *testCtrlChannel) Underlay() channel.Underlay {
+	return self.underlay
+}
+
+func newTestCtrls(activeActive bool) *networkControllers {
+	return NewNetworkControllers(time.Second, nil, NewDefaultHeartbeatOptions(), activeActive).(*networkControllers)
+}
+
+func addTestCtrl(ctrls *networkControllers, id string, latency time.Duration) *networkCtrl {
+	version, err := versions.StdVersionEncDec.Encode(&versions.VersionInfo{Version: "v1.0.0"})
+	if err != nil {
+		panic(err)
+	}
+
+	ch := &testCtrlChannel{
+		id: id,
+		underlay: &testCtrlUnderlay{
+			connected: true,
+			headers:   map[int32][]byte{channel.HelloVersionHeader: version},
+		},
+	}
+	if err = ctrls.Add("tls:"+id+":6262", ch); err != nil {
+		panic(err)
+	}
+
+	ctrl := ctrls.ctrls.Get(id).(*networkCtrl)
+	ctrl.latency.Store(int64(latency))
+	return ctrl
+}
+
+func nextCtrlIds(count int, f func() channel.Channel) []string {
+	var result []string
+	for i := 0; i < count; i++ {
+		result = append(result, f().Id())
+	}
+	return result
+}
+
+func TestNextResponsiveCtrlChannel(t *testing.T) {
+	t.Run("rotates through controllers in channel id order", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(true)
+		addTestCtrl(ctrls, "c", time.Millisecond)
+		addTestCtrl(ctrls, "a", time.Millisecond)
+		addTestCtrl(ctrls, "b", time.Millisecond)
+
+		ctrls.nextCtrl.Store(0)
+		req.Equal([]string{"b", "c", "a", "b", "c", "a"}, nextCtrlIds(6, ctrls.nextResponsiveCtrlChannel))
+	})
+
+	t.Run("skips unresponsive and disconnected controllers", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(true)
+		addTestCtrl(ctrls, "a", time.Millisecond)
+		addTestCtrl(ctrls, "b", time.Millisecond).unresponsive.Store(true)
+		addTestCtrl(ctrls, "c", time.Millisecond)
+		addTestCtrl(ctrls, "d", time.Millisecond).ch.(*testCtrlChannel).underlay.connected = false
+
+		ctrls.nextCtrl.Store(0)
+		req.Equal([]string{"c", "a", "c", "a"}, nextCtrlIds(4, ctrls.nextResponsiveCtrlChannel))
+	})
+
+	t.Run("returns nil if no controller is responsive", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(true)
+		req.Nil(ctrls.nextResponsiveCtrlChannel())
+		req.Nil(ctrls.AnyCtrlChannel())
+		req.Nil(ctrls.GetModelUpdateCtrlChannel())
+
+		addTestCtrl(ctrls, "a", time.Millisecond).unresponsive.Store(true)
+		req.Nil(ctrls.nextResponsiveCtrlChannel())
+	})
+}
+
+func TestAnyCtrlChannel(t *testing.T) {
+	t.Run("uses the most responsive controller by default", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(false)
+		addTestCtrl(ctrls, "a", 30*time.Millisecond)
+		addTestCtrl(ctrls, "b", 10*time.Millisecond)
+		addTestCtrl(ctrls, "c", 20*time.Millisecond)
+
+		req.Equal([]string{"b", "b", "b"}, nextCtrlIds(3, ctrls.AnyCtrlChannel))
+	})
+
+	t.Run("spreads requests across controllers when active/active", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(true)
+		addTestCtrl(ctrls, "a", 30*time.Millisecond)
+		addTestCtrl(ctrls, "b", 10*time.Millisecond)
+		addTestCtrl(ctrls, "c", 20*time.Millisecond)
+
+		ctrls.nextCtrl.Store(0)
+		req.Equal([]string{"b", "c", "a"}, nextCtrlIds(3, ctrls.AnyCtrlChannel))
+	})
+
+	t.Run("falls back to the most responsive controller when active/active and none are responsive", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(true)
+		addTestCtrl(ctrls, "a", 30*time.Millisecond).unresponsive.Store(true)
+		addTestCtrl(ctrls, "b", 10*time.Millisecond).unresponsive.Store(true)
+
+		req.Equal("b", ctrls.AnyCtrlChannel().Id())
+	})
+}
+
+func TestGetModelUpdateCtrlChannel(t *testing.T) {
+	t.Run("uses the leader by default", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(false)
+		addTestCtrl(ctrls, "a", 10*time.Millisecond)
+		addTestCtrl(ctrls, "b", 20*time.Millisecond)
+		addTestCtrl(ctrls, "c", 30*time.Millisecond)
+		ctrls.UpdateLeader("c")
+
+		req.Equal([]string{"c", "c", "c"}, nextCtrlIds(3, ctrls.GetModelUpdateCtrlChannel))
+	})
+
+	t.Run("skips an unresponsive leader by default", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(false)
+		addTestCtrl(ctrls, "a", 10*time.Millisecond)
+		addTestCtrl(ctrls, "b", 20*time.Millisecond)
+		addTestCtrl(ctrls, "c", 30*time.Millisecond).unresponsive.Store(true)
+		ctrls.UpdateLeader("c")
+
+		req.Equal("a", ctrls.GetModelUpdateCtrlChannel().Id())
+	})
+
+	t.Run("spreads model updates across controllers when active/active", func(t *testing.T) {
+		req := require.New(t)
+		ctrls := newTestCtrls(true)
+		addTestCtrl(ctrls, "a", 10*time.Millisecond)
+		addTestCtrl(ctrls, "b", 20*time.Millisecond)
+		addTestCtrl(ctrls, "c", 30*time.Millisecond)
+		ctrls.UpdateLeader("c")
+
+		ctrls.nextCtrl.Store(0)
+		req.Equal([]string{"b", "c", "a"}, nextCtrlIds(3, ctrls.GetModelUpdateCtrlChannel))
+	})
+}
+
+func TestControllerResponsiveEvent(t *testing.T) {
+	req := require.New(t)
+	ctrls := newTestCtrls(false)
+	ctrl := addTestCtrl(ctrls, "a", time.Millisecond)
+
+	var events []CtrlEventType
+	ctrls.AddChangeListener(CtrlEventListenerFunc(func(event CtrlEvent) {
+		events = append(events, event.Type)
+	}))
+
+	ctrl.CheckHeartBeat()
+	req.Empty(events, "a controller which was already responsive shouldn't generate an event")
+
+	ctrl.latency.Store(int64(time.Minute))
+	ctrl.CheckHeartBeat()
+	req.True(ctrl.IsUnresponsive())
+	req.Empty(events)
+
+	ctrl.latency.Store(int64(time.Millisecond))
+	ctrl.CheckHeartBeat()
+	req.False(ctrl.IsUnresponsive())
+	req.Equal([]CtrlEventType{ControllerResponsive}, events)
+
+	ctrl.CheckHeartBeat()
+	req.Equal([]CtrlEventType{ControllerResponsive}, events)
+}
@@ -28,6 +28,8 @@ import (
 	"github.com/openziti/sdk-golang/xgress"
 	"github.com/openziti/ziti/common"
 	"github.com/openziti/ziti/common/config"
+	"github.com/openziti/ziti/router/mempressure"
+	"github.com/openziti/ziti/router/update"
 	"github.com/openziti/ziti/router/xlink"
 )
 
@@ -58,6 +60,8 @@ type RouterEnv interface {
 	GetConfig() *Config
 	GetForwarder() Forwarder
 	GetXgressMetrics() XgressMetrics
+	GetMemoryPressureMonitor() *mempressure.Monitor
+	GetUpdater() *update.Updater
 	NotifyCertsUpdated()
 	GetAlerter() Alerter
 }
@@ -80,6 +84,7 @@ type Forwarder interface {
 	ReportForwardingFault(circuitId string, ctrlId string)
 	RegisterDestination(circuitId string, address xgress.Address, destination Destination)
 	EndCircuit(circuitId string)
+	CircuitMetrics(x *xgress.Xgress, parent xgress.Metrics) xgress.Metrics
 }
 
 type Destination interface {
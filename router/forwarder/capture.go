@@ -0,0 +1,202 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"fmt"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"google.golang.org/protobuf/proto"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxCaptureRecords limits the number of payloads recorded by a single circuit capture
+	MaxCaptureRecords = 100_000
+
+	// MaxCaptureDataBytes limits the amount of payload data retained by a single circuit capture
+	MaxCaptureDataBytes = 32 * 1024 * 1024
+
+	DefaultCaptureSnapLength = 65535
+
+	// MaxCapturePageBytes limits the encoded size of the payloads returned in a single capture response. Finished
+	// captures which don't fit are returned over multiple responses.
+	MaxCapturePageBytes = 1024 * 1024
+
+	// CapturePageTimeout is how long the remaining pages of a finished capture are kept, waiting to be fetched
+	CapturePageTimeout = time.Minute
+)
+
+// circuitCapture records metadata, and optionally data, for payloads forwarded on a circuit
+type circuitCapture struct {
+	sync.Mutex
+	includePayloads bool
+	snapLength      uint32
+	payloads        []*ctrl_pb.CapturedPayload
+	dataBytes       int
+	truncated       bool
+}
+
+func (self *circuitCapture) record(srcAddr, dstAddr xgress.Address, payload *xgress.Payload, retransmit bool) {
+	self.Lock()
+	defer self.Unlock()
+
+	if len(self.payloads) >= MaxCaptureRecords {
+		self.truncated = true
+		return
+	}
+
+	captured := &ctrl_pb.CapturedPayload{
+		Timestamp:  time.Now().UnixNano(),
+		SrcAddr:    string(srcAddr),
+		DstAddr:    string(dstAddr),
+		Sequence:   int64(payload.Sequence),
+		Flags:      payload.Flags,
+		Length:     uint32(len(payload.Data)),
+		Retransmit: retransmit,
+		Originator: uint32(payload.GetOriginator()),
+	}
+
+	if self.includePayloads {
+		data := payload.Data
+		if uint32(len(data)) > self.snapLength {
+			data = data[:self.snapLength]
+		}
+		if self.dataBytes+len(data) > MaxCaptureDataBytes {
+			self.truncated = true
+		} else {
+			captured.Data = append([]byte(nil), data...)
+			self.dataBytes += len(data)
+		}
+	}
+
+	self.payloads = append(self.payloads, captured)
+}
+
+// StartCapture begins recording payloads forwarded on the given circuit. Only one capture may be active
+// for a circuit at a time.
+func (forwarder *Forwarder) StartCapture(request *ctrl_pb.CaptureCircuitRequest) error {
+	if _, found := forwarder.circuits.getForwardTable(request.CircuitId, false); !found {
+		return fmt.Errorf("no forwarding table for circuit %s", request.CircuitId)
+	}
+
+	snapLength := request.SnapLength
+	if snapLength == 0 {
+		snapLength = DefaultCaptureSnapLength
+	}
+
+	capture := &circuitCapture{
+		includePayloads: request.IncludePayloads,
+		snapLength:      snapLength,
+	}
+
+	if !forwarder.captures.SetIfAbsent(request.CircuitId, capture) {
+		return fmt.Errorf("capture already in progress for circuit %s", request.CircuitId)
+	}
+	forwarder.activeCaptures.Add(1)
+	return nil
+}
+
+// StopCapture ends the capture for the given circuit and returns the first page of recorded payloads. If the
+// response has more set, the remaining pages are returned by NextCapturePage.
+func (forwarder *Forwarder) StopCapture(circuitId string) *ctrl_pb.CaptureCircuitResponse {
+	capture, found := forwarder.captures.Pop(circuitId)
+	if !found {
+		return &ctrl_pb.CaptureCircuitResponse{
+			Message: fmt.Sprintf("no capture in progress for circuit %s", circuitId),
+		}
+	}
+	forwarder.activeCaptures.Add(-1)
+
+	capture.Lock()
+	finished := &finishedCapture{
+		payloads:  capture.payloads,
+		truncated: capture.truncated,
+	}
+	capture.Unlock()
+
+	response := finished.nextPage()
+	if response.More {
+		forwarder.finishedCaptures.Set(circuitId, finished)
+		time.AfterFunc(CapturePageTimeout, func() {
+			forwarder.finishedCaptures.RemoveCb(circuitId, func(key string, v *finishedCapture, exists bool) bool {
+				return exists && v == finished
+			})
+		})
+	}
+	return response
+}
+
+// NextCapturePage returns the next page of payloads from a finished capture of the given circuit
+func (forwarder *Forwarder) NextCapturePage(circuitId string) *ctrl_pb.CaptureCircuitResponse {
+	finished, found := forwarder.finishedCaptures.Get(circuitId)
+	if !found {
+		return &ctrl_pb.CaptureCircuitResponse{
+			Message: fmt.Sprintf("no finished capture with remaining pages for circuit %s", circuitId),
+		}
+	}
+
+	response := finished.nextPage()
+	if !response.More {
+		forwarder.finishedCaptures.RemoveCb(circuitId, func(key string, v *finishedCapture, exists bool) bool {
+			return exists && v == finished
+		})
+	}
+	return response
+}
+
+// finishedCapture holds the payloads of a stopped capture which haven't been returned yet
+type finishedCapture struct {
+	sync.Mutex
+	payloads  []*ctrl_pb.CapturedPayload
+	truncated bool
+}
+
+// nextPage removes and returns as many payloads as fit in MaxCapturePageBytes. At least one payload is always
+// returned, if any remain.
+func (self *finishedCapture) nextPage() *ctrl_pb.CaptureCircuitResponse {
+	self.Lock()
+	defer self.Unlock()
+
+	size := 0
+	count := 0
+	for count < len(self.payloads) {
+		payloadSize := proto.Size(self.payloads[count])
+		if count > 0 && size+payloadSize > MaxCapturePageBytes {
+			break
+		}
+		size += payloadSize
+		count++
+	}
+
+	page := self.payloads[:count]
+	self.payloads = self.payloads[count:]
+
+	return &ctrl_pb.CaptureCircuitResponse{
+		Success:   true,
+		Payloads:  page,
+		Truncated: self.truncated,
+		More:      len(self.payloads) > 0,
+	}
+}
+
+func (forwarder *Forwarder) capturePayload(circuitId string, srcAddr, dstAddr xgress.Address, payload *xgress.Payload, retransmit bool) {
+	if capture, found := forwarder.captures.Get(circuitId); found {
+		capture.record(srcAddr, dstAddr, payload, retransmit)
+	}
+}
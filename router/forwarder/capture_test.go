@@ -0,0 +1,103 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"testing"
+
+	"github.com/openziti/metrics"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapturePages(t *testing.T) {
+	req := require.New(t)
+
+	closeNotify := make(chan struct{})
+	defer close(closeNotify)
+
+	registry := metrics.NewUsageRegistry(metrics.DefaultUsageRegistryConfig("test", closeNotify))
+	forwarder := NewForwarder(registry, nil, env.DefaultForwarderOptions(), closeNotify)
+
+	req.NoError(forwarder.RegisterLink(&testLink{id: "link1"}))
+	req.NoError(forwarder.Route("ctrl", &ctrl_pb.Route{
+		CircuitId: "circuit1",
+		Forwards: []*ctrl_pb.Route_Forward{
+			{SrcAddress: "xg1", DstAddress: "link1"},
+		},
+	}))
+
+	req.NoError(forwarder.StartCapture(&ctrl_pb.CaptureCircuitRequest{
+		CircuitId:       "circuit1",
+		IncludePayloads: true,
+	}))
+
+	// each payload is 64k, so a capture of 40 payloads needs several pages
+	data := make([]byte, 64*1024)
+	for i := 0; i < 40; i++ {
+		req.NoError(forwarder.ForwardPayload("xg1", &xgress.Payload{
+			CircuitId: "circuit1",
+			Sequence:  int32(i),
+			Data:      data,
+		}, 0))
+	}
+
+	response := forwarder.StopCapture("circuit1")
+	req.True(response.Success)
+	req.True(response.More)
+
+	payloads := response.Payloads
+	pages := 1
+	for response.More {
+		response = forwarder.NextCapturePage("circuit1")
+		req.True(response.Success)
+		req.NotEmpty(response.Payloads)
+		payloads = append(payloads, response.Payloads...)
+		pages++
+	}
+
+	req.Len(payloads, 40)
+	req.Greater(pages, 2)
+	for i, payload := range payloads {
+		req.Equal(int64(i), payload.Sequence)
+	}
+
+	response = forwarder.NextCapturePage("circuit1")
+	req.False(response.Success, "all pages have been returned, so the finished capture should be gone")
+}
+
+func TestCapturePageSize(t *testing.T) {
+	req := require.New(t)
+
+	data := make([]byte, 300*1024)
+	finished := &finishedCapture{}
+	for i := 0; i < 10; i++ {
+		finished.payloads = append(finished.payloads, &ctrl_pb.CapturedPayload{Sequence: int64(i), Data: data})
+	}
+
+	for len(finished.payloads) > 0 {
+		page := finished.nextPage()
+		size := 0
+		for _, payload := range page.Payloads {
+			size += len(payload.Data)
+		}
+		req.LessOrEqual(size, MaxCapturePageBytes)
+		req.Equal(len(finished.payloads) > 0, page.More)
+	}
+}
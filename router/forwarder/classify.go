@@ -0,0 +1,345 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/router/env"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	classificationQueueLength = 1024
+
+	tlsRecordTypeHandshake      = 22
+	tlsHandshakeTypeClientHello = 1
+	tlsExtensionServerName      = 0
+	tlsServerNameTypeHostName   = 0
+
+	http2FrameTypeHeaders      = 1
+	http2FrameTypeContinuation = 9
+	http2FlagEndHeaders        = 0x4
+	http2FlagPadded            = 0x8
+	http2FlagPriority          = 0x20
+	http2FrameHeaderLength     = 9
+	http2MaxHeaderTableSize    = 4096
+)
+
+var (
+	http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+	httpMethods  = []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "CONNECT", "TRACE"}
+)
+
+type classificationReport struct {
+	ctrlId         string
+	classification *ctrl_msg.CircuitClassification
+}
+
+// payloadClassifier looks at the first payload a client sends on each circuit routed through this router as the
+// ingress, and reports what it recognizes to the controller which owns the circuit. Payload data is only ever
+// inspected as it passes through the router. Circuits whose data is end-to-end encrypted by the SDKs won't match
+// anything and aren't reported.
+type payloadClassifier struct {
+	ctrls       env.NetworkControllers
+	reports     chan *classificationReport
+	closeNotify <-chan struct{}
+}
+
+func newPayloadClassifier(ctrls env.NetworkControllers, closeNotify <-chan struct{}) *payloadClassifier {
+	result := &payloadClassifier{
+		ctrls:       ctrls,
+		reports:     make(chan *classificationReport, classificationQueueLength),
+		closeNotify: closeNotify,
+	}
+	go result.run()
+	return result
+}
+
+// inspect classifies the payload if it's the first data sent by the circuit initiator into this router
+func (self *payloadClassifier) inspect(ft *forwardTable, srcAddr xgress.Address, payload *xgress.Payload) {
+	if len(payload.Data) == 0 || payload.GetOriginator() != xgress.Initiator || ft.isLinkAddress(srcAddr) {
+		return
+	}
+
+	if !ft.classified.CompareAndSwap(false, true) {
+		return
+	}
+
+	classification := classifyPayload(payload.Data)
+	if classification == nil {
+		return
+	}
+	classification.CircuitId = payload.CircuitId
+
+	select {
+	case self.reports <- &classificationReport{ctrlId: ft.ctrlId, classification: classification}:
+	default:
+		pfxlog.Logger().WithField("circuitId", payload.CircuitId).Debug("classification queue full, dropping circuit classification")
+	}
+}
+
+func (self *payloadClassifier) run() {
+	for {
+		select {
+		case report := <-self.reports:
+			self.send(report)
+		case <-self.closeNotify:
+			return
+		}
+	}
+}
+
+func (self *payloadClassifier) send(report *classificationReport) {
+	log := pfxlog.Logger().WithField("ctrlId", report.ctrlId).WithField("circuitId", report.classification.CircuitId)
+
+	ch := self.ctrls.GetCtrlChannel(report.ctrlId)
+	if ch == nil {
+		log.Debug("no control channel for controller, unable to report circuit classification")
+		return
+	}
+
+	if err := report.classification.ToMessage().WithTimeout(self.ctrls.DefaultRequestTimeout()).Send(ch); err != nil {
+		log.WithError(err).Error("error sending circuit classification")
+	}
+}
+
+// classifyPayload identifies TLS, HTTP/1.x, cleartext HTTP/2 and gRPC from the first bytes sent by a client. It
+// returns nil if the data doesn't match any of them.
+func classifyPayload(data []byte) *ctrl_msg.CircuitClassification {
+	if len(data) > 0 && data[0] == tlsRecordTypeHandshake {
+		return classifyTls(data)
+	}
+	if bytes.HasPrefix(data, http2Preface) {
+		return classifyHttp2(data[len(http2Preface):])
+	}
+	return classifyHttp(data)
+}
+
+// classifyTls extracts the server name indication from a TLS ClientHello. The ClientHello must be complete in the
+// first record.
+func classifyTls(data []byte) *ctrl_msg.CircuitClassification {
+	s := cryptobyte.String(data)
+
+	var recordType uint8
+	var recordVersion uint16
+	var record cryptobyte.String
+	if !s.ReadUint8(&recordType) || !s.ReadUint16(&recordVersion) || !s.ReadUint16LengthPrefixed(&record) {
+		return nil
+	}
+
+	if recordVersion>>8 != 3 {
+		return nil
+	}
+
+	var handshakeType uint8
+	var hello cryptobyte.String
+	if !record.ReadUint8(&handshakeType) || handshakeType != tlsHandshakeTypeClientHello ||
+		!record.ReadUint24LengthPrefixed(&hello) {
+		return nil
+	}
+
+	var sessionId, cipherSuites, compressionMethods cryptobyte.String
+	if !hello.Skip(2+32) ||
+		!hello.ReadUint8LengthPrefixed(&sessionId) ||
+		!hello.ReadUint16LengthPrefixed(&cipherSuites) ||
+		!hello.ReadUint8LengthPrefixed(&compressionMethods) {
+		return nil
+	}
+
+	result := &ctrl_msg.CircuitClassification{
+		Protocol: ctrl_msg.CircuitProtocolTls,
+	}
+
+	var extensions cryptobyte.String
+	if hello.Empty() || !hello.ReadUint16LengthPrefixed(&extensions) {
+		return result
+	}
+
+	for !extensions.Empty() {
+		var extensionType uint16
+		var extension cryptobyte.String
+		if !extensions.ReadUint16(&extensionType) || !extensions.ReadUint16LengthPrefixed(&extension) {
+			return result
+		}
+
+		if extensionType != tlsExtensionServerName {
+			continue
+		}
+
+		var names cryptobyte.String
+		if !extension.ReadUint16LengthPrefixed(&names) {
+			return result
+		}
+
+		for !names.Empty() {
+			var nameType uint8
+			var name cryptobyte.String
+			if !names.ReadUint8(&nameType) || !names.ReadUint16LengthPrefixed(&name) {
+				return result
+			}
+			if nameType == tlsServerNameTypeHostName {
+				result.ServerName = strings.TrimSuffix(string(name), ".")
+				return result
+			}
+		}
+	}
+
+	return result
+}
+
+// classifyHttp recognizes an HTTP/1.x request line and extracts the Host header
+func classifyHttp(data []byte) *ctrl_msg.CircuitClassification {
+	lineEnd := bytes.Index(data, []byte("\r\n"))
+	if lineEnd < 0 {
+		return nil
+	}
+
+	requestLine := string(data[:lineEnd])
+	method, rest, found := strings.Cut(requestLine, " ")
+	if !found || !isHttpMethod(method) {
+		return nil
+	}
+
+	if _, version, found := strings.Cut(rest, " "); !found || !strings.HasPrefix(version, "HTTP/1.") {
+		return nil
+	}
+
+	result := &ctrl_msg.CircuitClassification{
+		Protocol: ctrl_msg.CircuitProtocolHttp,
+	}
+
+	headers := data[lineEnd+2:]
+	for len(headers) > 0 {
+		lineEnd = bytes.Index(headers, []byte("\r\n"))
+		if lineEnd <= 0 {
+			break
+		}
+		name, value, found := strings.Cut(string(headers[:lineEnd]), ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), "host") {
+			result.Host = strings.TrimSpace(value)
+			break
+		}
+		headers = headers[lineEnd+2:]
+	}
+
+	return result
+}
+
+func isHttpMethod(method string) bool {
+	for _, m := range httpMethods {
+		if method == m {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyHttp2 decodes the first HEADERS frame following the HTTP/2 connection preface. Requests with a gRPC
+// content type are reported as gRPC, with the method taken from the request path.
+func classifyHttp2(data []byte) *ctrl_msg.CircuitClassification {
+	result := &ctrl_msg.CircuitClassification{
+		Protocol: ctrl_msg.CircuitProtocolHttp2,
+	}
+
+	block, ok := readHttp2HeaderBlock(data)
+	if !ok {
+		return result
+	}
+
+	fields, err := hpack.NewDecoder(http2MaxHeaderTableSize, nil).DecodeFull(block)
+	if err != nil {
+		return result
+	}
+
+	var path, contentType string
+	for _, field := range fields {
+		switch field.Name {
+		case ":authority":
+			result.Host = field.Value
+		case ":path":
+			path = field.Value
+		case "content-type":
+			contentType = field.Value
+		}
+	}
+
+	if strings.HasPrefix(contentType, "application/grpc") {
+		result.Protocol = ctrl_msg.CircuitProtocolGrpc
+		result.GrpcMethod = path
+	}
+
+	return result
+}
+
+// readHttp2HeaderBlock skips frames until the first HEADERS frame and returns its header block, joined with any
+// CONTINUATION frames. It returns false if the block isn't complete in the given data.
+func readHttp2HeaderBlock(data []byte) ([]byte, bool) {
+	var block []byte
+	inHeaders := false
+
+	for len(data) >= http2FrameHeaderLength {
+		length := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+		frameType := data[3]
+		flags := data[4]
+		if len(data) < http2FrameHeaderLength+length {
+			return nil, false
+		}
+		frame := data[http2FrameHeaderLength : http2FrameHeaderLength+length]
+		data = data[http2FrameHeaderLength+length:]
+
+		if inHeaders {
+			if frameType != http2FrameTypeContinuation {
+				return nil, false
+			}
+		} else if frameType == http2FrameTypeHeaders {
+			inHeaders = true
+			padding := 0
+			if flags&http2FlagPadded != 0 {
+				if len(frame) < 1 {
+					return nil, false
+				}
+				padding = int(frame[0])
+				frame = frame[1:]
+			}
+			if flags&http2FlagPriority != 0 {
+				if len(frame) < 5 {
+					return nil, false
+				}
+				frame = frame[5:]
+			}
+			if padding > len(frame) {
+				return nil, false
+			}
+			frame = frame[:len(frame)-padding]
+		} else {
+			continue
+		}
+
+		block = append(block, frame...)
+		if flags&http2FlagEndHeaders != 0 {
+			return block, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,200 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func captureClientHello(t *testing.T, serverName string) []byte {
+	client, server := net.Pipe()
+	defer func() { _ = server.Close() }()
+
+	go func() {
+		tlsConn := tls.Client(client, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+		_ = tlsConn.Handshake()
+	}()
+
+	require.NoError(t, server.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 16*1024)
+	n, err := server.Read(buf)
+	require.NoError(t, err)
+	_ = client.Close()
+	return buf[:n]
+}
+
+func newHttp2Request(t *testing.T, fields ...hpack.HeaderField) []byte {
+	var block bytes.Buffer
+	encoder := hpack.NewEncoder(&block)
+	for _, field := range fields {
+		require.NoError(t, encoder.WriteField(field))
+	}
+
+	var buf bytes.Buffer
+	buf.Write(http2Preface)
+	framer := http2.NewFramer(&buf, nil)
+	require.NoError(t, framer.WriteSettings(http2.Setting{ID: http2.SettingInitialWindowSize, Val: 65535}))
+	require.NoError(t, framer.WriteWindowUpdate(0, 1<<20))
+
+	headerBlock := block.Bytes()
+	split := len(headerBlock) / 2
+	require.NoError(t, framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: headerBlock[:split],
+		EndHeaders:    false,
+		PadLength:     4,
+	}))
+	require.NoError(t, framer.WriteContinuation(1, true, headerBlock[split:]))
+	return buf.Bytes()
+}
+
+func TestClassifyPayload(t *testing.T) {
+	t.Run("tls with sni", func(t *testing.T) {
+		req := require.New(t)
+		result := classifyPayload(captureClientHello(t, "app.ziti"))
+		req.NotNil(result)
+		req.Equal(ctrl_msg.CircuitProtocolTls, result.Protocol)
+		req.Equal("app.ziti", result.ServerName)
+	})
+
+	t.Run("tls without sni", func(t *testing.T) {
+		req := require.New(t)
+		result := classifyPayload(captureClientHello(t, ""))
+		req.NotNil(result)
+		req.Equal(ctrl_msg.CircuitProtocolTls, result.Protocol)
+		req.Equal("", result.ServerName)
+	})
+
+	t.Run("truncated tls", func(t *testing.T) {
+		req := require.New(t)
+		hello := captureClientHello(t, "app.ziti")
+		req.Nil(classifyPayload(hello[:len(hello)/2]))
+	})
+
+	t.Run("http", func(t *testing.T) {
+		req := require.New(t)
+		result := classifyPayload([]byte("GET /status HTTP/1.1\r\nUser-Agent: test\r\nHOST: web.ziti:8080\r\n\r\n"))
+		req.NotNil(result)
+		req.Equal(ctrl_msg.CircuitProtocolHttp, result.Protocol)
+		req.Equal("web.ziti:8080", result.Host)
+	})
+
+	t.Run("not http", func(t *testing.T) {
+		req := require.New(t)
+		req.Nil(classifyPayload([]byte("SSH-2.0-OpenSSH_9.6\r\n")))
+		req.Nil(classifyPayload([]byte("GET /status\r\n")))
+	})
+
+	t.Run("grpc", func(t *testing.T) {
+		req := require.New(t)
+		data := newHttp2Request(t,
+			hpack.HeaderField{Name: ":method", Value: "POST"},
+			hpack.HeaderField{Name: ":scheme", Value: "http"},
+			hpack.HeaderField{Name: ":authority", Value: "greeter.ziti"},
+			hpack.HeaderField{Name: ":path", Value: "/helloworld.Greeter/SayHello"},
+			hpack.HeaderField{Name: "content-type", Value: "application/grpc+proto"},
+		)
+		result := classifyPayload(data)
+		req.NotNil(result)
+		req.Equal(ctrl_msg.CircuitProtocolGrpc, result.Protocol)
+		req.Equal("greeter.ziti", result.Host)
+		req.Equal("/helloworld.Greeter/SayHello", result.GrpcMethod)
+	})
+
+	t.Run("http2", func(t *testing.T) {
+		req := require.New(t)
+		data := newHttp2Request(t,
+			hpack.HeaderField{Name: ":method", Value: "GET"},
+			hpack.HeaderField{Name: ":scheme", Value: "http"},
+			hpack.HeaderField{Name: ":authority", Value: "web.ziti"},
+			hpack.HeaderField{Name: ":path", Value: "/index.html"},
+		)
+		result := classifyPayload(data)
+		req.NotNil(result)
+		req.Equal(ctrl_msg.CircuitProtocolHttp2, result.Protocol)
+		req.Equal("web.ziti", result.Host)
+		req.Equal("", result.GrpcMethod)
+	})
+
+	t.Run("http2 preface only", func(t *testing.T) {
+		req := require.New(t)
+		result := classifyPayload(http2Preface)
+		req.NotNil(result)
+		req.Equal(ctrl_msg.CircuitProtocolHttp2, result.Protocol)
+		req.Equal("", result.Host)
+	})
+
+	t.Run("encrypted data", func(t *testing.T) {
+		req := require.New(t)
+		data := make([]byte, 1024)
+		_, err := io.ReadFull(rand.Reader, data)
+		req.NoError(err)
+		data[0] = 0x17
+		req.Nil(classifyPayload(data))
+	})
+}
+
+func TestPayloadClassifierInspect(t *testing.T) {
+	req := require.New(t)
+
+	classifier := &payloadClassifier{
+		reports: make(chan *classificationReport, 10),
+	}
+
+	ft := newForwardTable("ctrl1")
+	ft.setForwardAddress("ingress", "link1")
+	ft.setForwardAddress("link1", "ingress")
+	ft.setLinkAddress("link1")
+
+	request := []byte("GET / HTTP/1.1\r\nHost: web.ziti\r\n\r\n")
+	newPayload := func(originator xgress.Originator, data []byte) *xgress.Payload {
+		return &xgress.Payload{
+			CircuitId: "circuit1",
+			Flags:     xgress.SetOriginatorFlag(0, originator),
+			Data:      data,
+		}
+	}
+
+	classifier.inspect(ft, "link1", newPayload(xgress.Initiator, request))
+	classifier.inspect(ft, "ingress", newPayload(xgress.Terminator, request))
+	classifier.inspect(ft, "ingress", newPayload(xgress.Initiator, nil))
+	req.Empty(classifier.reports)
+
+	classifier.inspect(ft, "ingress", newPayload(xgress.Initiator, request))
+	req.Len(classifier.reports, 1)
+
+	report := <-classifier.reports
+	req.Equal("ctrl1", report.ctrlId)
+	req.Equal("circuit1", report.classification.CircuitId)
+	req.Equal(ctrl_msg.CircuitProtocolHttp, report.classification.Protocol)
+	req.Equal("web.ziti", report.classification.Host)
+
+	classifier.inspect(ft, "ingress", newPayload(xgress.Initiator, request))
+	req.Empty(classifier.reports)
+}
@@ -29,38 +29,92 @@ import (
 )
 
 type Faulter struct {
-	ctrls       env.NetworkControllers
-	interval    time.Duration
-	circuitIds  cmap.ConcurrentMap[string, string]
-	closeNotify chan struct{}
+	ctrls              env.NetworkControllers
+	interval           time.Duration
+	circuitIds         cmap.ConcurrentMap[string, string]
+	routeRepairHoldoff time.Duration
+	routeRepairs       cmap.ConcurrentMap[string, *routeRepairRequest]
+	routeRepairTimes   cmap.ConcurrentMap[string, time.Time]
+	routeRepairNotify  chan struct{}
+	closeNotify        chan struct{}
+}
+
+type routeRepairRequest struct {
+	ctrlId string
+	linkId string
 }
 
 type FaultReceiver interface {
 	Report(circuitId string, ctrlId string)
 	NotifyInvalidLink(linkId string)
+	RequestRouteRepair(circuitId string, ctrlId string, linkId string)
 }
 
-func NewFaulter(ctrls env.NetworkControllers, interval time.Duration, closeNotify chan struct{}) *Faulter {
+func NewFaulter(ctrls env.NetworkControllers, interval time.Duration, routeRepairHoldoff time.Duration, closeNotify chan struct{}) *Faulter {
 	f := &Faulter{
-		ctrls:       ctrls,
-		interval:    interval,
-		circuitIds:  cmap.New[string](),
-		closeNotify: closeNotify,
+		ctrls:              ctrls,
+		interval:           interval,
+		circuitIds:         cmap.New[string](),
+		routeRepairHoldoff: routeRepairHoldoff,
+		routeRepairs:       cmap.New[*routeRepairRequest](),
+		routeRepairTimes:   cmap.New[time.Time](),
+		routeRepairNotify:  make(chan struct{}, 1),
+		closeNotify:        closeNotify,
 	}
 
 	if interval > 0 {
 		go f.run()
 	}
 
+	if routeRepairHoldoff > 0 {
+		go f.runRouteRepairs()
+	}
+
 	return f
 }
 
 func (self *Faulter) Report(circuitId string, ctrlId string) {
-	if self.interval > 0 {
+	if self.interval > 0 && !self.isRouteRepairPending(circuitId) {
 		self.circuitIds.Set(circuitId, ctrlId)
 	}
 }
 
+// RequestRouteRepair queues a request for the controller to reroute a circuit whose next hop link is gone. Requests
+// for a circuit are sent at most once per route repair holdoff, to give the controller time to send the new route.
+func (self *Faulter) RequestRouteRepair(circuitId string, ctrlId string, linkId string) {
+	if self.routeRepairHoldoff <= 0 {
+		return
+	}
+
+	now := time.Now()
+	requested := false
+	self.routeRepairTimes.Upsert(circuitId, now, func(exist bool, valueInMap time.Time, newValue time.Time) time.Time {
+		if exist && now.Sub(valueInMap) < self.routeRepairHoldoff {
+			return valueInMap
+		}
+		requested = true
+		return newValue
+	})
+
+	if !requested {
+		return
+	}
+
+	self.routeRepairs.Set(circuitId, &routeRepairRequest{ctrlId: ctrlId, linkId: linkId})
+
+	select {
+	case self.routeRepairNotify <- struct{}{}:
+	default:
+	}
+}
+
+// isRouteRepairPending returns true if a route repair was requested for the circuit within the holdoff. Forwarding
+// faults for the circuit aren't reported in that case, since the controller is already rerouting it.
+func (self *Faulter) isRouteRepairPending(circuitId string) bool {
+	requestTime, found := self.routeRepairTimes.Get(circuitId)
+	return found && time.Since(requestTime) < self.routeRepairHoldoff
+}
+
 func (self *Faulter) NotifyInvalidLink(linkId string) {
 	log := pfxlog.Logger()
 	self.ctrls.ForEach(func(ctrlId string, ch channel.Channel) {
@@ -74,6 +128,63 @@ func (self *Faulter) NotifyInvalidLink(linkId string) {
 	})
 }
 
+func (self *Faulter) runRouteRepairs() {
+	ticker := time.NewTicker(self.routeRepairHoldoff)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-self.routeRepairNotify:
+			self.sendRouteRepairs()
+		case <-ticker.C:
+			self.removeExpiredRouteRepairs()
+		case <-self.closeNotify:
+			return
+		}
+	}
+}
+
+func (self *Faulter) removeExpiredRouteRepairs() {
+	for _, circuitId := range self.routeRepairTimes.Keys() {
+		self.routeRepairTimes.RemoveCb(circuitId, func(key string, requestTime time.Time, exists bool) bool {
+			return exists && time.Since(requestTime) >= self.routeRepairHoldoff
+		})
+	}
+}
+
+func (self *Faulter) sendRouteRepairs() {
+	workload := map[routeRepairRequest][]string{}
+	for _, circuitId := range self.routeRepairs.Keys() {
+		if request, found := self.routeRepairs.Pop(circuitId); found {
+			workload[*request] = append(workload[*request], circuitId)
+		}
+	}
+
+	for request, circuitIds := range workload {
+		log := pfxlog.Logger().WithField("ctrlId", request.ctrlId).
+			WithField("linkId", request.linkId).
+			WithField("circuitCount", len(circuitIds))
+
+		ch := self.ctrls.GetCtrlChannel(request.ctrlId)
+		if ch == nil {
+			log.Error("no control channel for controller, unable to request route repair")
+			continue
+		}
+
+		fault := &ctrl_pb.Fault{
+			Subject: ctrl_pb.FaultSubject_RouteRepair,
+			Id:      strings.Join(circuitIds, " "),
+			LinkId:  request.linkId,
+		}
+
+		if err := protobufs.MarshalTyped(fault).Send(ch); err == nil {
+			log.Warn("requested route repair for circuits forwarded to missing link")
+		} else {
+			log.WithError(err).Error("error sending route repair request")
+		}
+	}
+}
+
 func (self *Faulter) run() {
 	logrus.Infof("started")
 	defer logrus.Errorf("exited")
@@ -0,0 +1,79 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFaulter(routeRepairHoldoff time.Duration) *Faulter {
+	return &Faulter{
+		interval:           time.Minute,
+		circuitIds:         cmap.New[string](),
+		routeRepairHoldoff: routeRepairHoldoff,
+		routeRepairs:       cmap.New[*routeRepairRequest](),
+		routeRepairTimes:   cmap.New[time.Time](),
+		routeRepairNotify:  make(chan struct{}, 1),
+	}
+}
+
+func TestFaulter_RequestRouteRepair(t *testing.T) {
+	req := require.New(t)
+	f := newTestFaulter(time.Minute)
+
+	f.RequestRouteRepair("c1", "ctrl1", "l1")
+	f.RequestRouteRepair("c1", "ctrl1", "l2")
+
+	request, found := f.routeRepairs.Get("c1")
+	req.True(found)
+	req.Equal("l1", request.linkId, "repeat request within holdoff should be ignored")
+	req.Len(f.routeRepairNotify, 1)
+
+	f.Report("c1", "ctrl1")
+	req.False(f.circuitIds.Has("c1"), "forwarding fault should not be reported while route repair is pending")
+
+	f.Report("c2", "ctrl1")
+	req.True(f.circuitIds.Has("c2"))
+}
+
+func TestFaulter_RouteRepairHoldoffExpires(t *testing.T) {
+	req := require.New(t)
+	f := newTestFaulter(time.Millisecond)
+
+	f.RequestRouteRepair("c1", "ctrl1", "l1")
+	time.Sleep(5 * time.Millisecond)
+
+	req.False(f.isRouteRepairPending("c1"))
+	f.removeExpiredRouteRepairs()
+	req.False(f.routeRepairTimes.Has("c1"))
+
+	f.routeRepairs.Remove("c1")
+	f.RequestRouteRepair("c1", "ctrl1", "l2")
+	request, found := f.routeRepairs.Get("c1")
+	req.True(found)
+	req.Equal("l2", request.linkId)
+}
+
+func TestFaulter_RouteRepairDisabled(t *testing.T) {
+	f := newTestFaulter(0)
+	f.RequestRouteRepair("c1", "ctrl1", "l1")
+	require.False(t, f.routeRepairs.Has("c1"))
+}
@@ -0,0 +1,221 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/openziti/metrics"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/ziti/router/env"
+	"github.com/openziti/ziti/router/xlink"
+	"github.com/orcaman/concurrent-map/v2"
+)
+
+const (
+	UsageTypeRetransmits           = "xgress.retransmits"
+	UsageTypeRetransmittedBytes    = "xgress.retransmitted_bytes"
+	UsageTypeDuplicateAcks         = "xgress.ack_duplicates"
+	UsageTypeDuplicatePayloads     = "xgress.payload_duplicates"
+	UsageTypeBlockedByLocalWindow  = "xgress.blocked_by_local_window"
+	UsageTypeBlockedByRemoteWindow = "xgress.blocked_by_remote_window"
+)
+
+// flowMetrics tracks xgress retransmission and flow control events per circuit and per link. Circuit level counts
+// are reported through the usage counters, so they are tagged with the circuit's service and identities. Link level
+// counts are reported as link metrics.
+type flowMetrics struct {
+	registry     metrics.UsageRegistry
+	usageCounter metrics.UsageCounter
+	forwarder    *Forwarder
+	circuits     cmap.ConcurrentMap[string, *circuitFlowMetrics]
+	links        cmap.ConcurrentMap[string, *linkFlowMetrics]
+}
+
+func newFlowMetrics(registry metrics.UsageRegistry, forwarder *Forwarder) *flowMetrics {
+	return &flowMetrics{
+		registry:     registry,
+		usageCounter: registry.UsageCounter("xgressFlowUsage", env.IntervalSize),
+		forwarder:    forwarder,
+		circuits:     cmap.New[*circuitFlowMetrics](),
+		links:        cmap.New[*linkFlowMetrics](),
+	}
+}
+
+func (self *flowMetrics) linkRegistered(linkId string) {
+	self.links.Upsert(linkId, nil, func(exist bool, valueInMap *linkFlowMetrics, _ *linkFlowMetrics) *linkFlowMetrics {
+		if exist {
+			return valueInMap
+		}
+		return self.newLinkFlowMetrics(linkId)
+	})
+}
+
+func (self *flowMetrics) linkUnregistered(linkId string) {
+	if link, found := self.links.Pop(linkId); found {
+		link.dispose()
+	}
+}
+
+func (self *flowMetrics) newLinkFlowMetrics(linkId string) *linkFlowMetrics {
+	prefix := "link." + linkId + "."
+	result := &linkFlowMetrics{
+		retransmitsMeter:           self.registry.Meter(prefix + "rtx.msgrate"),
+		retransmittedBytesMeter:    self.registry.Meter(prefix + "rtx.bytesrate"),
+		duplicateAcksMeter:         self.registry.Meter(prefix + "ack_duplicates"),
+		blockedByLocalWindowMeter:  self.registry.Meter(prefix + "blocked_by_local_window_rate"),
+		blockedByRemoteWindowMeter: self.registry.Meter(prefix + "blocked_by_remote_window_rate"),
+	}
+	result.unackedBytesGauge = self.registry.FuncGauge(prefix+"tx_unacked_payload_bytes", func() int64 {
+		return self.getUnackedBytes(linkId)
+	})
+	return result
+}
+
+func (self *flowMetrics) getUnackedBytes(linkId string) int64 {
+	var result int64
+	for entry := range self.circuits.IterBuffered() {
+		if entry.Val.getLinkId() == linkId {
+			result += entry.Val.unackedBytes.Load()
+		}
+	}
+	return result
+}
+
+func (self *flowMetrics) getLink(linkId string) *linkFlowMetrics {
+	if linkId == "" {
+		return nil
+	}
+	link, _ := self.links.Get(linkId)
+	return link
+}
+
+func (self *flowMetrics) newCircuitMetrics(x *xgress.Xgress, parent xgress.Metrics) xgress.Metrics {
+	result := &circuitFlowMetrics{
+		Metrics:     parent,
+		flowMetrics: self,
+		x:           x,
+	}
+	self.circuits.Set(string(x.Address()), result)
+	return result
+}
+
+func (self *flowMetrics) circuitUnregistered(address xgress.Address) {
+	self.circuits.Remove(string(address))
+}
+
+func (self *flowMetrics) retransmitted(srcAddr xgress.Address, dst env.Destination, size int) {
+	if circuit, found := self.circuits.Get(string(srcAddr)); found {
+		now := time.Now()
+		self.usageCounter.Update(circuit.x, UsageTypeRetransmits, now, 1)
+		self.usageCounter.Update(circuit.x, UsageTypeRetransmittedBytes, now, uint64(size))
+	}
+
+	if link, ok := dst.(xlink.LinkDestination); ok && dst.GetDestinationType() == "link" {
+		if linkMetrics := self.getLink(link.Id()); linkMetrics != nil {
+			linkMetrics.retransmitsMeter.Mark(1)
+			linkMetrics.retransmittedBytesMeter.Mark(int64(size))
+		}
+	}
+}
+
+type linkFlowMetrics struct {
+	retransmitsMeter           metrics.Meter
+	retransmittedBytesMeter    metrics.Meter
+	duplicateAcksMeter         metrics.Meter
+	blockedByLocalWindowMeter  metrics.Meter
+	blockedByRemoteWindowMeter metrics.Meter
+	unackedBytesGauge          metrics.Gauge
+}
+
+func (self *linkFlowMetrics) dispose() {
+	self.retransmitsMeter.Dispose()
+	self.retransmittedBytesMeter.Dispose()
+	self.duplicateAcksMeter.Dispose()
+	self.blockedByLocalWindowMeter.Dispose()
+	self.blockedByRemoteWindowMeter.Dispose()
+	self.unackedBytesGauge.Dispose()
+}
+
+// circuitFlowMetrics wraps the router wide xgress metrics for a single xgress, so flow control events can be
+// attributed to the circuit and to the link the xgress is forwarding to
+type circuitFlowMetrics struct {
+	xgress.Metrics
+	flowMetrics  *flowMetrics
+	x            *xgress.Xgress
+	linkId       atomic.Pointer[string]
+	unackedBytes atomic.Int64
+}
+
+func (self *circuitFlowMetrics) getLinkId() string {
+	if linkId := self.linkId.Load(); linkId != nil {
+		return *linkId
+	}
+	linkId := self.flowMetrics.forwarder.getNextHopLinkId(self.x.CircuitId(), self.x.Address())
+	if linkId != "" {
+		self.linkId.Store(&linkId)
+	}
+	return linkId
+}
+
+func (self *circuitFlowMetrics) getLink() *linkFlowMetrics {
+	return self.flowMetrics.getLink(self.getLinkId())
+}
+
+func (self *circuitFlowMetrics) updateUsage(usageType string) {
+	self.flowMetrics.usageCounter.Update(self.x, usageType, time.Now(), 1)
+}
+
+func (self *circuitFlowMetrics) MarkDuplicateAck() {
+	self.Metrics.MarkDuplicateAck()
+	self.updateUsage(UsageTypeDuplicateAcks)
+	if link := self.getLink(); link != nil {
+		link.duplicateAcksMeter.Mark(1)
+	}
+}
+
+func (self *circuitFlowMetrics) MarkDuplicatePayload() {
+	self.Metrics.MarkDuplicatePayload()
+	self.updateUsage(UsageTypeDuplicatePayloads)
+}
+
+func (self *circuitFlowMetrics) BufferBlockedByLocalWindow() {
+	self.Metrics.BufferBlockedByLocalWindow()
+	self.updateUsage(UsageTypeBlockedByLocalWindow)
+	if link := self.getLink(); link != nil {
+		link.blockedByLocalWindowMeter.Mark(1)
+	}
+}
+
+func (self *circuitFlowMetrics) BufferBlockedByRemoteWindow() {
+	self.Metrics.BufferBlockedByRemoteWindow()
+	self.updateUsage(UsageTypeBlockedByRemoteWindow)
+	if link := self.getLink(); link != nil {
+		link.blockedByRemoteWindowMeter.Mark(1)
+	}
+}
+
+func (self *circuitFlowMetrics) SendPayloadBuffered(payloadSize int64) {
+	self.Metrics.SendPayloadBuffered(payloadSize)
+	self.unackedBytes.Add(payloadSize)
+}
+
+func (self *circuitFlowMetrics) SendPayloadDelivered(payloadSize int64) {
+	self.Metrics.SendPayloadDelivered(payloadSize)
+	self.unackedBytes.Add(-payloadSize)
+}
@@ -0,0 +1,96 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/metrics"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/env"
+	"github.com/stretchr/testify/require"
+)
+
+type testLink struct {
+	id string
+}
+
+func (self *testLink) Id() string {
+	return self.id
+}
+
+func (self *testLink) SendPayload(*xgress.Payload, time.Duration, xgress.PayloadType) error {
+	return nil
+}
+
+func (self *testLink) SendAcknowledgement(*xgress.Acknowledgement) error {
+	return nil
+}
+
+func (self *testLink) SendControl(*xgress.Control) error {
+	return nil
+}
+
+func (self *testLink) InspectCircuit(*xgress.CircuitInspectDetail) {}
+
+func (self *testLink) GetDestinationType() string {
+	return "link"
+}
+
+func TestFlowMetrics(t *testing.T) {
+	req := require.New(t)
+
+	closeNotify := make(chan struct{})
+	defer close(closeNotify)
+
+	registry := metrics.NewUsageRegistry(metrics.DefaultUsageRegistryConfig("test", closeNotify))
+	forwarder := NewForwarder(registry, nil, env.DefaultForwarderOptions(), closeNotify)
+
+	link := &testLink{id: "link1"}
+	req.NoError(forwarder.RegisterLink(link))
+	req.NoError(forwarder.Route("ctrl", &ctrl_pb.Route{
+		CircuitId: "circuit1",
+		Forwards: []*ctrl_pb.Route_Forward{
+			{SrcAddress: "xg1", DstAddress: "link1"},
+		},
+	}))
+
+	x := xgress.NewXgress("circuit1", "ctrl", "xg1", nil, xgress.Initiator, xgress.DefaultOptions(), nil)
+	circuitMetrics := forwarder.CircuitMetrics(x, xgress.NewMetrics(registry))
+
+	circuitMetrics.SendPayloadBuffered(100)
+	circuitMetrics.SendPayloadBuffered(50)
+	circuitMetrics.SendPayloadDelivered(100)
+	circuitMetrics.MarkDuplicateAck()
+	circuitMetrics.BufferBlockedByRemoteWindow()
+
+	req.NoError(forwarder.RetransmitPayload("xg1", &xgress.Payload{CircuitId: "circuit1", Data: []byte("hello")}))
+
+	req.Equal(int64(1), registry.GetMeter("link.link1.rtx.msgrate").Count())
+	req.Equal(int64(5), registry.GetMeter("link.link1.rtx.bytesrate").Count())
+	req.Equal(int64(1), registry.GetMeter("link.link1.ack_duplicates").Count())
+	req.Equal(int64(0), registry.GetMeter("link.link1.blocked_by_local_window_rate").Count())
+	req.Equal(int64(1), registry.GetMeter("link.link1.blocked_by_remote_window_rate").Count())
+	req.Equal(int64(50), registry.GetGauge("link.link1.tx_unacked_payload_bytes").Value())
+	req.Equal(int64(1), registry.GetMeter("xgress.ack_duplicates").Count())
+
+	forwarder.UnregisterLink(link)
+	req.False(registry.IsValidMetric("link.link1.rtx.msgrate"))
+	req.False(registry.IsValidMetric("link.link1.tx_unacked_payload_bytes"))
+}
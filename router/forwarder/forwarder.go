@@ -28,19 +28,26 @@ import (
 	"github.com/openziti/ziti/common/trace"
 	"github.com/openziti/ziti/router/env"
 	"github.com/openziti/ziti/router/xlink"
+	"github.com/orcaman/concurrent-map/v2"
 	"github.com/sirupsen/logrus"
 	"sync/atomic"
 	"time"
 )
 
 type Forwarder struct {
-	circuits        *circuitTable
-	destinations    *destinationTable
-	faulter         FaultReceiver
-	metricsRegistry metrics.UsageRegistry
-	traceController trace.Controller
-	Options         *env.ForwarderOptions
-	CloseNotify     <-chan struct{}
+	circuits         *circuitTable
+	destinations     *destinationTable
+	faulter          FaultReceiver
+	metricsRegistry  metrics.UsageRegistry
+	traceController  trace.Controller
+	Options          *env.ForwarderOptions
+	CloseNotify      <-chan struct{}
+	captures         cmap.ConcurrentMap[string, *circuitCapture]
+	finishedCaptures cmap.ConcurrentMap[string, *finishedCapture]
+	activeCaptures   atomic.Int32
+	flowMetrics      *flowMetrics
+	classifier       *payloadClassifier
+	limiter          *circuitLimiter
 }
 
 type XgressDestination interface {
@@ -51,14 +58,22 @@ type XgressDestination interface {
 
 func NewForwarder(metricsRegistry metrics.UsageRegistry, faulter FaultReceiver, options *env.ForwarderOptions, closeNotify <-chan struct{}) *Forwarder {
 	f := &Forwarder{
-		circuits:        newCircuitTable(),
-		destinations:    newDestinationTable(),
-		faulter:         faulter,
-		metricsRegistry: metricsRegistry,
-		traceController: trace.NewController(closeNotify),
-		Options:         options,
-		CloseNotify:     closeNotify,
+		circuits:         newCircuitTable(),
+		destinations:     newDestinationTable(),
+		faulter:          faulter,
+		metricsRegistry:  metricsRegistry,
+		traceController:  trace.NewController(closeNotify),
+		Options:          options,
+		CloseNotify:      closeNotify,
+		captures:         cmap.New[*circuitCapture](),
+		finishedCaptures: cmap.New[*finishedCapture](),
 	}
+	f.flowMetrics = newFlowMetrics(metricsRegistry, f)
+
+	metricsRegistry.FuncGauge("forwarder.circuits", func() int64 {
+		return int64(f.circuits.circuits.Count())
+	})
+
 	return f
 }
 
@@ -66,6 +81,8 @@ func (forwarder *Forwarder) StartScanner(ctrls env.NetworkControllers) {
 	scanner := newScanner(ctrls, forwarder.Options, forwarder.CloseNotify)
 	scanner.setCircuitTable(forwarder.circuits)
 
+	forwarder.limiter = newCircuitLimiter(ctrls, forwarder)
+
 	if scanner.interval > 0 {
 		go scanner.run()
 	} else {
@@ -73,6 +90,12 @@ func (forwarder *Forwarder) StartScanner(ctrls env.NetworkControllers) {
 	}
 }
 
+// EnablePayloadClassification turns on classification of the first client payload of circuits which enter the
+// network at this router. It must be called before any circuits are routed.
+func (forwarder *Forwarder) EnablePayloadClassification(ctrls env.NetworkControllers) {
+	forwarder.classifier = newPayloadClassifier(ctrls, forwarder.CloseNotify)
+}
+
 func (forwarder *Forwarder) MetricsRegistry() metrics.UsageRegistry {
 	return forwarder.metricsRegistry
 }
@@ -81,6 +104,12 @@ func (forwarder *Forwarder) TraceController() trace.Controller {
 	return forwarder.traceController
 }
 
+// CircuitMetrics returns xgress metrics for the given xgress which, in addition to updating the given router wide
+// metrics, track retransmission and flow control events for the xgress's circuit and the link it forwards to
+func (forwarder *Forwarder) CircuitMetrics(x *xgress.Xgress, parent xgress.Metrics) xgress.Metrics {
+	return forwarder.flowMetrics.newCircuitMetrics(x, parent)
+}
+
 func (forwarder *Forwarder) RegisterDestination(circuitId string, address xgress.Address, destination env.Destination) {
 	forwarder.destinations.addDestination(address, destination)
 	forwarder.destinations.linkDestinationToCircuit(circuitId, address)
@@ -93,6 +122,7 @@ func (forwarder *Forwarder) UnregisterDestinations(circuitId string) {
 			if destination, found := forwarder.destinations.getDestination(address); found {
 				log.Debugf("unregistering destination [@/%v] for circuit", address)
 				forwarder.destinations.removeDestination(address)
+				forwarder.flowMetrics.circuitUnregistered(address)
 				go destination.(XgressDestination).Unrouted()
 			} else {
 				log.Debugf("no destinations found for [@/%v] for circuit", address)
@@ -111,11 +141,15 @@ func (forwarder *Forwarder) HasDestination(address xgress.Address) bool {
 
 func (forwarder *Forwarder) RegisterLink(link xlink.LinkDestination) error {
 	forwarder.destinations.addDestination(xgress.Address(link.Id()), link)
+	forwarder.flowMetrics.linkRegistered(link.Id())
 	return nil
 }
 
 func (forwarder *Forwarder) UnregisterLink(link xlink.LinkDestination) {
 	forwarder.destinations.removeDestinationIfMatches(xgress.Address(link.Id()), link)
+	if !forwarder.HasDestination(xgress.Address(link.Id())) {
+		forwarder.flowMetrics.linkUnregistered(link.Id())
+	}
 }
 
 func (forwarder *Forwarder) Route(ctrlId string, route *ctrl_pb.Route) error {
@@ -138,13 +172,21 @@ func (forwarder *Forwarder) Route(ctrlId string, route *ctrl_pb.Route) error {
 			// It's an ingress destination, which isn't established until after routing has completed
 		}
 		circuitFt.setForwardAddress(xgress.Address(forward.SrcAddress), xgress.Address(forward.DstAddress))
+		if forward.DstType == ctrl_pb.DestType_Link {
+			circuitFt.setLinkAddress(xgress.Address(forward.DstAddress))
+		}
 		pfxlog.Logger().WithFields(logrus.Fields{
 			"circuitId":   circuitId,
 			"source":      forward.SrcAddress,
 			"destination": forward.DstAddress,
 		}).Debug("route added")
 	}
+	circuitFt.pathMtu.Store(route.PathMtu)
+	circuitFt.setLimits(route)
 	forwarder.circuits.setForwardTable(circuitId, circuitFt)
+	if forwarder.limiter != nil && circuitFt.hasLimits() {
+		forwarder.limiter.track(circuitId, circuitFt)
+	}
 	return nil
 }
 
@@ -183,12 +225,26 @@ func (forwarder *Forwarder) forwardPayload(srcAddr xgress.Address, payload *xgre
 				} else if timeout == 0 {
 					payloadType = xgress.PayloadTypeFwd
 				}
-				if err := dst.SendPayload(payload, timeout, payloadType); err != nil {
+				if forwarder.classifier != nil && markActive {
+					forwarder.classifier.inspect(forwardTable, srcAddr, payload)
+				}
+				if forwarder.activeCaptures.Load() > 0 {
+					forwarder.capturePayload(circuitId, srcAddr, dstAddr, payload, !markActive)
+				}
+				start := time.Now()
+				if err := forwarder.sendPayload(forwardTable, dst, payload, timeout, payloadType); err != nil {
 					return err
 				}
+				forwardTable.stats.record(time.Since(start), !markActive)
+				if !markActive {
+					forwarder.flowMetrics.retransmitted(srcAddr, dst, len(payload.Data))
+				}
 				log.WithFields(payload.GetLoggerFields()).Debugf("=> %s", string(dstAddr))
 				return nil
 			} else {
+				if forwardTable.isLinkAddress(dstAddr) {
+					forwarder.requestRouteRepair(circuitId, forwardTable.ctrlId, dstAddr)
+				}
 				return fmt.Errorf("cannot forward payload, no destination for circuit=%v src=%v dst=%v", circuitId, srcAddr, dstAddr)
 			}
 		} else {
@@ -199,6 +255,43 @@ func (forwarder *Forwarder) forwardPayload(srcAddr xgress.Address, payload *xgre
 	}
 }
 
+// sendPayload sends a payload to its next hop. Payloads going to a link are split into fragments if they're too
+// large for the circuit path MTU. Fragments going to an xgress are held until the payload can be reassembled.
+func (forwarder *Forwarder) sendPayload(ft *forwardTable, dst env.Destination, payload *xgress.Payload, timeout time.Duration, payloadType xgress.PayloadType) error {
+	if dst.GetDestinationType() != "link" {
+		reassembled, err := ft.reassembler.add(payload)
+		if err != nil || reassembled == nil {
+			return err
+		}
+		return dst.SendPayload(reassembled, timeout, payloadType)
+	}
+
+	fragments, err := fragmentPayload(payload, ft.pathMtu.Load())
+	if err != nil {
+		return err
+	}
+
+	for _, fragment := range fragments {
+		if err = dst.SendPayload(fragment, timeout, payloadType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getNextHopLinkId returns the id of the link that payloads from the given address are forwarded to, or an empty string
+// if they aren't forwarded over a link
+func (forwarder *Forwarder) getNextHopLinkId(circuitId string, srcAddr xgress.Address) string {
+	if forwardTable, found := forwarder.circuits.getForwardTable(circuitId, false); found {
+		if dstAddr, found := forwardTable.getForwardAddress(srcAddr); found {
+			if dst, found := forwarder.destinations.getDestination(dstAddr); found && dst.GetDestinationType() == "link" {
+				return string(dstAddr)
+			}
+		}
+	}
+	return ""
+}
+
 func (forwarder *Forwarder) ForwardAcknowledgement(srcAddr xgress.Address, acknowledgement *xgress.Acknowledgement) error {
 	log := pfxlog.ContextLogger(string(srcAddr))
 
@@ -213,6 +306,9 @@ func (forwarder *Forwarder) ForwardAcknowledgement(srcAddr xgress.Address, ackno
 				return nil
 
 			} else {
+				if forwardTable.isLinkAddress(dstAddr) {
+					forwarder.requestRouteRepair(circuitId, forwardTable.ctrlId, dstAddr)
+				}
 				return fmt.Errorf("cannot acknowledge, no destination for circuit=%v src=%v dst=%v", circuitId, srcAddr, dstAddr)
 			}
 
@@ -283,6 +379,15 @@ func (forwarder *Forwarder) ReportForwardingFault(circuitId string, ctrlId strin
 	}
 }
 
+// requestRouteRepair asks the controller which owns the circuit to reroute it, because the link it's forwarded to here
+// is gone. Unlike forwarding faults, these are sent right away, so the circuit can be repaired before its endpoints
+// time out.
+func (forwarder *Forwarder) requestRouteRepair(circuitId string, ctrlId string, linkAddr xgress.Address) {
+	if forwarder.faulter != nil {
+		forwarder.faulter.RequestRouteRepair(circuitId, ctrlId, string(linkAddr))
+	}
+}
+
 func (forwarder *Forwarder) Debug() string {
 	return forwarder.circuits.debug() + forwarder.destinations.debug()
 }
@@ -362,6 +467,34 @@ func (forwarder *Forwarder) InspectDestination(address string, detail *inspect.C
 	}
 }
 
+// inspectHop combines the forwarding stats for the circuit with the buffer state of the circuit's xgress instances and
+// the latency of the links the circuit is forwarded over
+func (forwarder *Forwarder) inspectHop(ft *forwardTable, circuitDetail *xgress.CircuitInspectDetail) *inspect.CircuitHopDetail {
+	result := ft.stats.inspect()
+
+	for _, xgDetail := range circuitDetail.XgressDetails {
+		if sendBuffer := xgDetail.SendBufferDetail; sendBuffer != nil {
+			result.QueuedPayloads += sendBuffer.QueuedPayloadCount
+			result.Retransmits += sendBuffer.Retransmits
+			result.DuplicateAcks += sendBuffer.DuplicateAcks
+		}
+	}
+
+	for _, addr := range circuitDetail.Forwards {
+		if dest, _ := forwarder.destinations.getDestination(xgress.Address(addr)); dest == nil || dest.GetDestinationType() != "link" {
+			continue
+		}
+		if latency := forwarder.metricsRegistry.GetHistogram("link." + addr + ".latency"); latency != nil && latency.Count() > 0 {
+			if result.LinkLatency == nil {
+				result.LinkLatency = map[string]string{}
+			}
+			result.LinkLatency[addr] = time.Duration(latency.Mean()).String()
+		}
+	}
+
+	return result
+}
+
 func (forwarder *Forwarder) InspectCircuit(circuitId string, getRelatedGoroutines bool) *xgress.CircuitInspectDetail {
 	if ft, found := forwarder.circuits.circuits.Get(circuitId); found {
 		result := &xgress.CircuitInspectDetail{
@@ -381,6 +514,7 @@ func (forwarder *Forwarder) InspectCircuit(circuitId string, getRelatedGoroutine
 				dest.InspectCircuit(result)
 			}
 		}
+		result.AddRelatedEntity("hop", circuitId, forwarder.inspectHop(ft, result))
 		return result
 	}
 	return nil
@@ -0,0 +1,244 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PayloadFragmentHeader marks a payload which is one fragment of a larger payload. The value holds the fragment
+	// index and the fragment count, as two big endian uint16s.
+	PayloadFragmentHeader uint8 = 0xF0
+
+	// fragmentHeaderAllowance leaves room for headers the channel may add when sending, such as heartbeats
+	fragmentHeaderAllowance = 32
+	minFragmentDataSize     = 64
+	maxFragmentCount        = 1024
+	maxPendingReassemblies  = 256
+	reassemblyTimeout       = 30 * time.Second
+
+	// messageEnvelopeSize is the marshalled size of a V2 channel message without headers or body: the magic,
+	// content type, sequence, header length and body length
+	messageEnvelopeSize = 20
+	// messageHeaderOverhead is the marshalled size of a channel message header without its value: the key and length
+	messageHeaderOverhead = 8
+)
+
+// fragmentPayload splits a payload into fragments which fit in the given path MTU once marshalled. All fragments
+// keep the payload sequence, so the xgress at the other end sees a single payload once they're reassembled. Only the
+// first fragment carries the payload headers. If the payload already fits, or the MTU is too small to leave room
+// for data, the payload is returned as is.
+func fragmentPayload(payload *xgress.Payload, pathMtu uint32) ([]*xgress.Payload, error) {
+	if pathMtu == 0 {
+		return []*xgress.Payload{payload}, nil
+	}
+
+	if _, found := payload.Headers[PayloadFragmentHeader]; found {
+		return []*xgress.Payload{payload}, nil
+	}
+
+	if marshalledPayloadSize(payload)+fragmentHeaderAllowance <= int(pathMtu) {
+		return []*xgress.Payload{payload}, nil
+	}
+
+	overhead := marshalledPayloadSize(newPayloadFragment(payload, nil, 0, 1))
+	fragmentDataSize := int(pathMtu) - overhead - fragmentHeaderAllowance
+	if fragmentDataSize < minFragmentDataSize {
+		return []*xgress.Payload{payload}, nil
+	}
+
+	count := (len(payload.Data) + fragmentDataSize - 1) / fragmentDataSize
+	if count > maxFragmentCount {
+		return nil, errors.Errorf("payload of %d bytes would need %d fragments with path MTU %d, max is %d",
+			len(payload.Data), count, pathMtu, maxFragmentCount)
+	}
+
+	result := make([]*xgress.Payload, 0, count)
+	for i := 0; i < count; i++ {
+		end := min((i+1)*fragmentDataSize, len(payload.Data))
+		result = append(result, newPayloadFragment(payload, payload.Data[i*fragmentDataSize:end], i, count))
+	}
+	return result, nil
+}
+
+func newPayloadFragment(payload *xgress.Payload, data []byte, index, count int) *xgress.Payload {
+	headers := map[uint8][]byte{}
+	if index == 0 {
+		for k, v := range payload.Headers {
+			headers[k] = v
+		}
+	}
+
+	fragmentHeader := make([]byte, 4)
+	binary.BigEndian.PutUint16(fragmentHeader, uint16(index))
+	binary.BigEndian.PutUint16(fragmentHeader[2:], uint16(count))
+	headers[PayloadFragmentHeader] = fragmentHeader
+
+	return &xgress.Payload{
+		CircuitId: payload.CircuitId,
+		Flags:     payload.Flags,
+		RTT:       payload.RTT,
+		Sequence:  payload.Sequence,
+		Headers:   headers,
+		Data:      data,
+	}
+}
+
+// marshalledPayloadSize returns the size of the payload once marshalled as a V2 channel message, including the
+// headers added by xgress.Payload.Marshall. It's checked for every payload on circuits with a path MTU, so it's
+// calculated rather than marshalling the payload.
+func marshalledPayloadSize(payload *xgress.Payload) int {
+	size := messageEnvelopeSize + len(payload.Data)
+	for _, v := range payload.Headers {
+		size += messageHeaderOverhead + len(v)
+	}
+
+	size += messageHeaderOverhead + len(payload.CircuitId)
+	if payload.Flags != 0 {
+		size += messageHeaderOverhead + 4
+	}
+	size += messageHeaderOverhead + 8 // sequence
+	size += messageHeaderOverhead + 2 // rtt
+
+	return size
+}
+
+func getPayloadFragment(payload *xgress.Payload) (int, int, bool) {
+	val, found := payload.Headers[PayloadFragmentHeader]
+	if !found || len(val) != 4 {
+		return 0, 0, false
+	}
+	return int(binary.BigEndian.Uint16(val)), int(binary.BigEndian.Uint16(val[2:])), true
+}
+
+type pendingReassembly struct {
+	fragments [][]byte
+	first     *xgress.Payload
+	received  int
+	started   time.Time
+}
+
+// payloadReassembler collects payload fragments for a circuit until all fragments of a payload have arrived. If a
+// fragment is lost, the sending xgress retransmits the whole payload, which is fragmented the same way again, so
+// incomplete reassemblies are eventually either completed or expired.
+type payloadReassembler struct {
+	lock    sync.Mutex
+	pending map[int32]*pendingReassembly
+}
+
+// add records a fragment. When it completes a payload, the reassembled payload is returned. Payloads which aren't
+// fragments are returned unchanged.
+func (self *payloadReassembler) add(payload *xgress.Payload) (*xgress.Payload, error) {
+	index, count, isFragment := getPayloadFragment(payload)
+	if !isFragment {
+		return payload, nil
+	}
+
+	if count == 0 || index >= count {
+		return nil, errors.Errorf("invalid payload fragment %d of %d", index, count)
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := time.Now()
+	if self.pending == nil {
+		self.pending = map[int32]*pendingReassembly{}
+	}
+
+	current, found := self.pending[payload.Sequence]
+	if !found || len(current.fragments) != count {
+		self.expire(now)
+		current = &pendingReassembly{
+			fragments: make([][]byte, count),
+			started:   now,
+		}
+		self.pending[payload.Sequence] = current
+	}
+
+	if current.fragments[index] == nil {
+		current.fragments[index] = payload.Data
+		if current.fragments[index] == nil {
+			current.fragments[index] = []byte{}
+		}
+		current.received++
+	}
+
+	if index == 0 {
+		current.first = payload
+	}
+
+	if current.received < count {
+		return nil, nil
+	}
+
+	delete(self.pending, payload.Sequence)
+
+	size := 0
+	for _, fragment := range current.fragments {
+		size += len(fragment)
+	}
+
+	data := make([]byte, 0, size)
+	for _, fragment := range current.fragments {
+		data = append(data, fragment...)
+	}
+
+	headers := map[uint8][]byte{}
+	for k, v := range current.first.Headers {
+		if k != PayloadFragmentHeader {
+			headers[k] = v
+		}
+	}
+
+	if len(headers) == 0 {
+		headers = nil
+	}
+
+	return &xgress.Payload{
+		CircuitId: current.first.CircuitId,
+		Flags:     current.first.Flags,
+		RTT:       current.first.RTT,
+		Sequence:  current.first.Sequence,
+		Headers:   headers,
+		Data:      data,
+	}, nil
+}
+
+// expire drops reassemblies which have been waiting too long, and if there are still too many pending, the oldest
+func (self *payloadReassembler) expire(now time.Time) {
+	var oldestSeq int32
+	var oldest *pendingReassembly
+
+	for seq, pending := range self.pending {
+		if now.Sub(pending.started) > reassemblyTimeout {
+			delete(self.pending, seq)
+		} else if oldest == nil || pending.started.Before(oldest.started) {
+			oldestSeq, oldest = seq, pending
+		}
+	}
+
+	if len(self.pending) >= maxPendingReassemblies && oldest != nil {
+		delete(self.pending, oldestSeq)
+	}
+}
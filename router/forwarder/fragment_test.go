@@ -0,0 +1,153 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPayload(t *testing.T, size int) *xgress.Payload {
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	return &xgress.Payload{
+		CircuitId: "Yb3kPz8QvL2mXw9RtN4sHd",
+		Flags:     xgress.SetOriginatorFlag(0, xgress.Terminator),
+		Sequence:  42,
+		Headers:   map[uint8][]byte{0x10: {1}},
+		Data:      data,
+	}
+}
+
+func TestMarshalledPayloadSize(t *testing.T) {
+	req := require.New(t)
+
+	payloads := []*xgress.Payload{
+		newTestPayload(t, 0),
+		newTestPayload(t, 1500),
+		{CircuitId: "c1", Sequence: 1, Data: []byte("hello")},
+	}
+
+	for _, payload := range payloads {
+		marshalled, err := channel.MarshalV2(payload.Marshall())
+		req.NoError(err)
+		req.Equal(len(marshalled), marshalledPayloadSize(payload))
+	}
+}
+
+func TestFragmentPayload(t *testing.T) {
+	req := require.New(t)
+	payload := newTestPayload(t, 4000)
+
+	fragments, err := fragmentPayload(payload, 0)
+	req.NoError(err)
+	req.Equal([]*xgress.Payload{payload}, fragments)
+
+	fragments, err = fragmentPayload(payload, 9000)
+	req.NoError(err)
+	req.Len(fragments, 1)
+	req.Same(payload, fragments[0])
+
+	fragments, err = fragmentPayload(payload, 1200)
+	req.NoError(err)
+	req.Len(fragments, 4)
+
+	for i, fragment := range fragments {
+		marshalled, err := channel.MarshalV2(fragment.Marshall())
+		req.NoError(err)
+		req.LessOrEqual(len(marshalled)+fragmentHeaderAllowance, 1200)
+		req.Equal(payload.Sequence, fragment.Sequence)
+		req.Equal(payload.Flags, fragment.Flags)
+
+		index, count, isFragment := getPayloadFragment(fragment)
+		req.True(isFragment)
+		req.Equal(i, index)
+		req.Equal(4, count)
+
+		_, hasHeader := fragment.Headers[0x10]
+		req.Equal(i == 0, hasHeader)
+	}
+
+	// already fragmented payloads aren't fragmented again
+	refragmented, err := fragmentPayload(fragments[0], 600)
+	req.NoError(err)
+	req.Len(refragmented, 1)
+
+	// an MTU too small to carry data leaves the payload alone
+	fragments, err = fragmentPayload(payload, 100)
+	req.NoError(err)
+	req.Len(fragments, 1)
+}
+
+func TestReassemblePayload(t *testing.T) {
+	req := require.New(t)
+	payload := newTestPayload(t, 5000)
+
+	fragments, err := fragmentPayload(payload, 1400)
+	req.NoError(err)
+	req.Greater(len(fragments), 1)
+
+	reassembler := &payloadReassembler{}
+
+	// deliver out of order, with a duplicate
+	last := len(fragments) - 1
+	ordered := []*xgress.Payload{fragments[last], fragments[1], fragments[1], fragments[0]}
+	ordered = append(ordered, fragments[2:last]...)
+	var result *xgress.Payload
+	for i, fragment := range ordered[:len(ordered)-1] {
+		result, err = reassembler.add(fragment)
+		req.NoError(err)
+		req.Nil(result, "fragment %d", i)
+	}
+
+	result, err = reassembler.add(ordered[len(ordered)-1])
+	req.NoError(err)
+	req.NotNil(result)
+	req.Equal(payload.Data, result.Data)
+	req.Equal(payload.Sequence, result.Sequence)
+	req.Equal(payload.Flags, result.Flags)
+	req.Equal(payload.Headers, result.Headers)
+	req.Empty(reassembler.pending)
+
+	// payloads which aren't fragments pass straight through
+	result, err = reassembler.add(payload)
+	req.NoError(err)
+	req.Same(payload, result)
+}
+
+func TestReassemblerLimitsPending(t *testing.T) {
+	req := require.New(t)
+	reassembler := &payloadReassembler{}
+
+	for i := 0; i < maxPendingReassemblies*2; i++ {
+		payload := newTestPayload(t, 3000)
+		payload.Sequence = int32(i)
+		fragments, err := fragmentPayload(payload, 1400)
+		req.NoError(err)
+		result, err := reassembler.add(fragments[0])
+		req.NoError(err)
+		req.Nil(result)
+	}
+
+	req.LessOrEqual(len(reassembler.pending), maxPendingReassemblies)
+}
@@ -0,0 +1,67 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/openziti/ziti/common/inspect"
+)
+
+// hopStats tracks how long this router takes to hand off payloads for a single circuit to the next destination,
+// which is either the next link on the path or the terminating xgress
+type hopStats struct {
+	forwarded         atomic.Uint64
+	retransmitted     atomic.Uint64
+	totalForwardNanos atomic.Int64
+	maxForwardNanos   atomic.Int64
+}
+
+func (self *hopStats) record(elapsed time.Duration, retransmit bool) {
+	if retransmit {
+		self.retransmitted.Add(1)
+	} else {
+		self.forwarded.Add(1)
+	}
+
+	nanos := elapsed.Nanoseconds()
+	self.totalForwardNanos.Add(nanos)
+	for {
+		current := self.maxForwardNanos.Load()
+		if nanos <= current || self.maxForwardNanos.CompareAndSwap(current, nanos) {
+			return
+		}
+	}
+}
+
+func (self *hopStats) inspect() *inspect.CircuitHopDetail {
+	forwarded := self.forwarded.Load()
+	retransmitted := self.retransmitted.Load()
+
+	var avg time.Duration
+	if total := forwarded + retransmitted; total > 0 {
+		avg = time.Duration(self.totalForwardNanos.Load() / int64(total))
+	}
+
+	return &inspect.CircuitHopDetail{
+		ForwardedPayloads:     forwarded,
+		RetransmittedPayloads: retransmitted,
+		AvgForwardLatency:     avg.String(),
+		MaxForwardLatency:     time.Duration(self.maxForwardNanos.Load()).String(),
+	}
+}
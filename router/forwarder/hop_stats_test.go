@@ -0,0 +1,43 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHopStats(t *testing.T) {
+	req := require.New(t)
+
+	stats := &hopStats{}
+	detail := stats.inspect()
+	req.Equal(uint64(0), detail.ForwardedPayloads)
+	req.Equal("0s", detail.AvgForwardLatency)
+
+	stats.record(10*time.Millisecond, false)
+	stats.record(30*time.Millisecond, false)
+	stats.record(20*time.Millisecond, true)
+
+	detail = stats.inspect()
+	req.Equal(uint64(2), detail.ForwardedPayloads)
+	req.Equal(uint64(1), detail.RetransmittedPayloads)
+	req.Equal("20ms", detail.AvgForwardLatency)
+	req.Equal("30ms", detail.MaxForwardLatency)
+}
@@ -0,0 +1,160 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/env"
+	"github.com/orcaman/concurrent-map/v2"
+)
+
+const (
+	circuitLimitCheckInterval = time.Second
+
+	// how long the controller has to remove a circuit which has exceeded a limit, before the router unroutes it
+	circuitLimitTeardownTimeout = 10 * time.Second
+)
+
+// setLimits stores the max idle time and remaining lifetime sent by the controller. Only the initiating and
+// terminating routers of a circuit are sent limits.
+func (ft *forwardTable) setLimits(route *ctrl_pb.Route) {
+	ft.maxIdleTime.Store(int64(route.MaxIdleTime))
+	if route.MaxLifetime > 0 {
+		ft.expiresAt.Store(time.Now().Add(time.Duration(route.MaxLifetime)).UnixMilli())
+	} else {
+		ft.expiresAt.Store(0)
+	}
+}
+
+func (ft *forwardTable) hasLimits() bool {
+	return ft.maxIdleTime.Load() > 0 || ft.expiresAt.Load() > 0
+}
+
+// exceededLimit returns the limit the circuit has exceeded, if any, along with how long the circuit has been up or
+// idle. The max lifetime takes precedence if both have been exceeded.
+func (ft *forwardTable) exceededLimit(now int64) (string, time.Duration) {
+	if expiresAt := ft.expiresAt.Load(); expiresAt > 0 && now >= expiresAt {
+		return ctrl_msg.CircuitLimitMaxLifetime, time.Duration(now-ft.created) * time.Millisecond
+	}
+
+	idleTime := time.Duration(now-atomic.LoadInt64(&ft.last)) * time.Millisecond
+	if maxIdleTime := time.Duration(ft.maxIdleTime.Load()); maxIdleTime > 0 && idleTime >= maxIdleTime {
+		return ctrl_msg.CircuitLimitMaxIdleTime, idleTime
+	}
+
+	return "", 0
+}
+
+// circuitLimiter enforces the max lifetime and max idle time set by services on circuits which start or end at this
+// router. When a circuit exceeds a limit, the controller which owns the circuit is asked to remove it, so that the
+// whole circuit is torn down and the circuit deleted event records why. If the report can't be sent, or the
+// controller doesn't remove the circuit in time, the circuit is unrouted locally.
+type circuitLimiter struct {
+	circuits    *circuitTable
+	tracked     cmap.ConcurrentMap[string, *forwardTable]
+	report      func(ctrlId string, report *ctrl_msg.CircuitLimitExceeded) error
+	unroute     func(circuitId string)
+	closeNotify <-chan struct{}
+}
+
+func newCircuitLimiter(ctrls env.NetworkControllers, forwarder *Forwarder) *circuitLimiter {
+	result := &circuitLimiter{
+		circuits: forwarder.circuits,
+		tracked:  cmap.New[*forwardTable](),
+		report: func(ctrlId string, report *ctrl_msg.CircuitLimitExceeded) error {
+			ch := ctrls.GetCtrlChannel(ctrlId)
+			if ch == nil {
+				return errors.New("no control channel for controller")
+			}
+			return report.ToMessage().WithTimeout(ctrls.DefaultRequestTimeout()).Send(ch)
+		},
+		unroute: func(circuitId string) {
+			forwarder.Unroute(circuitId, true)
+		},
+		closeNotify: forwarder.CloseNotify,
+	}
+	go result.run()
+	return result
+}
+
+func (self *circuitLimiter) track(circuitId string, ft *forwardTable) {
+	self.tracked.Set(circuitId, ft)
+}
+
+func (self *circuitLimiter) run() {
+	ticker := time.NewTicker(circuitLimitCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			self.check(time.Now().UnixMilli())
+		case <-self.closeNotify:
+			return
+		}
+	}
+}
+
+func (self *circuitLimiter) check(now int64) {
+	for entry := range self.tracked.IterBuffered() {
+		circuitId, ft := entry.Key, entry.Val
+
+		if current, found := self.circuits.getForwardTable(circuitId, false); !found || current != ft || !ft.hasLimits() {
+			self.tracked.RemoveCb(circuitId, func(_ string, v *forwardTable, exists bool) bool {
+				return exists && v == ft
+			})
+			continue
+		}
+
+		limit, elapsed := ft.exceededLimit(now)
+		if limit == "" {
+			continue
+		}
+
+		log := pfxlog.Logger().WithField("circuitId", circuitId).
+			WithField("ctrlId", ft.ctrlId).
+			WithField("limit", limit).
+			WithField("elapsed", elapsed.String())
+
+		if ft.limitHitAt.CompareAndSwap(0, now) {
+			report := &ctrl_msg.CircuitLimitExceeded{
+				CircuitId: circuitId,
+				Limit:     limit,
+				Elapsed:   elapsed,
+			}
+			err := self.report(ft.ctrlId, report)
+			if err == nil {
+				log.Info("circuit exceeded service limit, reported to controller")
+				continue
+			}
+			log.WithError(err).Error("unable to report circuit which exceeded service limit, unrouting circuit")
+		} else if time.Duration(now-ft.limitHitAt.Load())*time.Millisecond < circuitLimitTeardownTimeout {
+			continue
+		} else {
+			log.Warn("circuit which exceeded service limit not removed by controller, unrouting circuit")
+		}
+
+		self.tracked.Remove(circuitId)
+		self.unroute(circuitId)
+	}
+}
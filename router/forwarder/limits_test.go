@@ -0,0 +1,116 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/orcaman/concurrent-map/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardTableExceededLimit(t *testing.T) {
+	req := require.New(t)
+	now := time.Now().UnixMilli()
+
+	ft := newForwardTable("ctrl1")
+	ft.setLimits(&ctrl_pb.Route{})
+	req.False(ft.hasLimits())
+	limit, _ := ft.exceededLimit(now)
+	req.Equal("", limit)
+
+	ft.setLimits(&ctrl_pb.Route{MaxIdleTime: uint64(time.Minute), MaxLifetime: uint64(time.Hour)})
+	req.True(ft.hasLimits())
+
+	atomic.StoreInt64(&ft.last, now-(30*time.Second).Milliseconds())
+	limit, _ = ft.exceededLimit(now)
+	req.Equal("", limit)
+
+	atomic.StoreInt64(&ft.last, now-(2*time.Minute).Milliseconds())
+	limit, elapsed := ft.exceededLimit(now)
+	req.Equal(ctrl_msg.CircuitLimitMaxIdleTime, limit)
+	req.Equal(2*time.Minute, elapsed)
+
+	// lifetime takes precedence over idle time
+	limit, _ = ft.exceededLimit(now + (2 * time.Hour).Milliseconds())
+	req.Equal(ctrl_msg.CircuitLimitMaxLifetime, limit)
+}
+
+func TestCircuitLimiterCheck(t *testing.T) {
+	req := require.New(t)
+
+	var reports []*ctrl_msg.CircuitLimitExceeded
+	var unrouted []string
+	var reportErr error
+
+	limiter := &circuitLimiter{
+		circuits: newCircuitTable(),
+		tracked:  cmap.New[*forwardTable](),
+		report: func(ctrlId string, report *ctrl_msg.CircuitLimitExceeded) error {
+			req.Equal("ctrl1", ctrlId)
+			reports = append(reports, report)
+			return reportErr
+		},
+		unroute: func(circuitId string) {
+			unrouted = append(unrouted, circuitId)
+		},
+	}
+
+	addCircuit := func(circuitId string, maxLifetime time.Duration) *forwardTable {
+		ft := newForwardTable("ctrl1")
+		ft.setLimits(&ctrl_pb.Route{MaxLifetime: uint64(maxLifetime)})
+		limiter.circuits.setForwardTable(circuitId, ft)
+		limiter.track(circuitId, ft)
+		return ft
+	}
+
+	addCircuit("c1", time.Minute)
+	addCircuit("c2", time.Hour)
+	now := time.Now().Add(2 * time.Minute).UnixMilli()
+
+	limiter.check(now)
+	req.Len(reports, 1)
+	req.Equal("c1", reports[0].CircuitId)
+	req.Equal(ctrl_msg.CircuitLimitMaxLifetime, reports[0].Limit)
+	req.Empty(unrouted)
+
+	// reported circuits are only reported once, and are given time to be removed by the controller
+	limiter.check(now + time.Second.Milliseconds())
+	req.Len(reports, 1)
+	req.Empty(unrouted)
+
+	limiter.check(now + circuitLimitTeardownTimeout.Milliseconds())
+	req.Equal([]string{"c1"}, unrouted)
+	req.False(limiter.tracked.Has("c1"))
+
+	// circuits which can't be reported are unrouted immediately
+	reportErr = errors.New("no control channel")
+	addCircuit("c3", time.Minute)
+	limiter.check(now)
+	req.Equal([]string{"c1", "c3"}, unrouted)
+
+	// circuits which have been removed are no longer tracked
+	limiter.circuits.removeForwardTable("c2")
+	limiter.check(now)
+	req.False(limiter.tracked.Has("c2"))
+	req.Len(reports, 2)
+}
@@ -70,13 +70,24 @@ func (st *circuitTable) debug() string {
 type forwardTable struct {
 	ctrlId       string
 	last         int64
+	created      int64
 	destinations cmap.ConcurrentMap[string, string]
+	links        cmap.ConcurrentMap[string, struct{}]
+	stats        hopStats
+	pathMtu      atomic.Uint32
+	reassembler  payloadReassembler
+	classified   atomic.Bool
+	maxIdleTime  atomic.Int64 // nanoseconds, zero if the service doesn't limit idle time
+	expiresAt    atomic.Int64 // unix millis, zero if the service doesn't limit circuit lifetime
+	limitHitAt   atomic.Int64 // unix millis when a limit was first found to be exceeded
 }
 
 func newForwardTable(ctrlId string) *forwardTable {
 	return &forwardTable{
 		ctrlId:       ctrlId,
+		created:      time.Now().UnixMilli(),
 		destinations: cmap.New[string](),
+		links:        cmap.New[struct{}](),
 	}
 }
 
@@ -84,6 +95,15 @@ func (ft *forwardTable) setForwardAddress(src, dst xgress.Address) {
 	ft.destinations.Set(string(src), string(dst))
 }
 
+// setLinkAddress records that the given destination address is a link, rather than an xgress
+func (ft *forwardTable) setLinkAddress(dst xgress.Address) {
+	ft.links.Set(string(dst), struct{}{})
+}
+
+func (ft *forwardTable) isLinkAddress(dst xgress.Address) bool {
+	return ft.links.Has(string(dst))
+}
+
 func (ft *forwardTable) getForwardAddress(src xgress.Address) (xgress.Address, bool) {
 	if dst, found := ft.destinations.Get(string(src)); found {
 		return xgress.Address(dst), true
@@ -0,0 +1,86 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_ctrl
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/forwarder"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+type captureCircuitHandler struct {
+	forwarder *forwarder.Forwarder
+}
+
+func newCaptureCircuitHandler(forwarder *forwarder.Forwarder) *captureCircuitHandler {
+	return &captureCircuitHandler{forwarder: forwarder}
+}
+
+func (*captureCircuitHandler) ContentType() int32 {
+	return int32(ctrl_pb.ContentType_CaptureCircuitRequestType)
+}
+
+func (handler *captureCircuitHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	log := pfxlog.ContextLogger(ch.Label()).Entry
+
+	request := &ctrl_pb.CaptureCircuitRequest{}
+	if err := proto.Unmarshal(msg.Body, request); err != nil {
+		log.WithError(err).Error("unable to unmarshal capture circuit request")
+		handler.respond(msg, ch, &ctrl_pb.CaptureCircuitResponse{Message: err.Error()})
+		return
+	}
+
+	log = log.WithField("circuitId", request.CircuitId)
+
+	if request.NextPage {
+		handler.respond(msg, ch, handler.forwarder.NextCapturePage(request.CircuitId))
+		return
+	}
+
+	if err := handler.forwarder.StartCapture(request); err != nil {
+		log.WithError(err).Error("unable to start circuit capture")
+		handler.respond(msg, ch, &ctrl_pb.CaptureCircuitResponse{Message: err.Error()})
+		return
+	}
+
+	log.Infof("started circuit capture for %v", time.Duration(request.DurationMs)*time.Millisecond)
+
+	go func() {
+		select {
+		case <-time.After(time.Duration(request.DurationMs) * time.Millisecond):
+		case <-handler.forwarder.CloseNotify:
+		}
+		response := handler.forwarder.StopCapture(request.CircuitId)
+		log.WithField("more", response.More).Infof("finished circuit capture, returning first %d payloads", len(response.Payloads))
+		handler.respond(msg, ch, response)
+	}()
+}
+
+func (handler *captureCircuitHandler) respond(msg *channel.Message, ch channel.Channel, response *ctrl_pb.CaptureCircuitResponse) {
+	err := protobufs.MarshalTyped(response).
+		ReplyTo(msg).
+		WithTimeout(30 * time.Second).
+		SendAndWaitForWire(ch)
+
+	if err != nil {
+		pfxlog.ContextLogger(ch.Label()).WithError(err).Error("failed to send capture circuit response")
+	}
+}
@@ -169,6 +169,12 @@ func (context *inspectRequestContext) processLocal() {
 			context.handleJsonResponse(requested, result)
 		} else if strings.EqualFold(lc, inspect.RouterEdgeCircuitsKey) || strings.EqualFold(lc, inspect.RouterSdkCircuitsKey) {
 			context.inspectXgListener(requested)
+		} else if handled, val, err := inspect.InspectRuntime(requested); handled {
+			if err != nil {
+				context.appendError(err.Error())
+			} else {
+				context.appendValue(requested, *val)
+			}
 		}
 	}
 }
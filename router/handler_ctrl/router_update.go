@@ -0,0 +1,114 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_ctrl
+
+import (
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/router/env"
+	"github.com/openziti/ziti/router/update"
+	"github.com/sirupsen/logrus"
+)
+
+type routerUpdateHandler struct {
+	env env.RouterEnv
+}
+
+func newRouterUpdateHandler(env env.RouterEnv) *routerUpdateHandler {
+	return &routerUpdateHandler{env: env}
+}
+
+func (*routerUpdateHandler) ContentType() int32 {
+	return ctrl_msg.RouterUpdateRequestType
+}
+
+func (self *routerUpdateHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	log := pfxlog.ContextLogger(ch.Label()).Entry
+
+	request, err := ctrl_msg.DecodeRouterUpdateRequest(msg)
+	if err != nil {
+		log.WithError(err).Error("unable to decode router update request")
+		return
+	}
+
+	log = log.WithField("rolloutId", request.RolloutId).
+		WithField("phase", request.Phase).
+		WithField("version", request.Version)
+
+	updater := self.env.GetUpdater()
+	if !updater.IsEnabled() {
+		log.Warn("received router update request, but router updates are not enabled")
+		self.sendStatus(log, ch, request, ctrl_msg.RouterUpdateStateFailed, "router updates are not enabled in the router configuration")
+		return
+	}
+
+	log.Info("received router update request")
+
+	switch request.Phase {
+	case ctrl_msg.RouterUpdatePhaseStage:
+		go self.stage(log, ch, updater, request)
+	case ctrl_msg.RouterUpdatePhaseApply:
+		self.apply(log, ch, updater, request)
+	case ctrl_msg.RouterUpdatePhaseDiscard:
+		updater.Discard()
+		self.sendStatus(log, ch, request, ctrl_msg.RouterUpdateStateDiscarded, "")
+	}
+}
+
+func (self *routerUpdateHandler) stage(log *logrus.Entry, ch channel.Channel, updater *update.Updater, request *ctrl_msg.RouterUpdateRequest) {
+	self.sendStatus(log, ch, request, ctrl_msg.RouterUpdateStateDownloading, "")
+
+	if err := updater.Stage(request.Version, request.Url, request.Sha256); err != nil {
+		log.WithError(err).Error("unable to stage router update")
+		self.sendStatus(log, ch, request, ctrl_msg.RouterUpdateStateFailed, err.Error())
+		return
+	}
+
+	self.sendStatus(log, ch, request, ctrl_msg.RouterUpdateStateStaged, "")
+}
+
+func (self *routerUpdateHandler) apply(log *logrus.Entry, ch channel.Channel, updater *update.Updater, request *ctrl_msg.RouterUpdateRequest) {
+	if err := updater.Apply(request.Version); err != nil {
+		log.WithError(err).Error("unable to apply router update")
+		self.sendStatus(log, ch, request, ctrl_msg.RouterUpdateStateFailed, err.Error())
+		return
+	}
+
+	// make sure the controller knows the restart is expected before the connection drops
+	self.sendStatus(log, ch, request, ctrl_msg.RouterUpdateStateRestarting, "")
+
+	if err := updater.Restart(); err != nil {
+		log.WithError(err).Error("unable to restart router after applying update")
+		self.sendStatus(log, ch, request, ctrl_msg.RouterUpdateStateFailed, err.Error())
+	}
+}
+
+func (self *routerUpdateHandler) sendStatus(log *logrus.Entry, ch channel.Channel, request *ctrl_msg.RouterUpdateRequest, state string, errMsg string) {
+	status := &ctrl_msg.RouterUpdateStatus{
+		RolloutId: request.RolloutId,
+		Version:   request.Version,
+		State:     state,
+		Error:     errMsg,
+	}
+
+	if err := status.ToMessage().WithTimeout(10 * time.Second).SendAndWaitForWire(ch); err != nil {
+		log.WithError(err).WithField("state", state).Error("unable to send router update status")
+	}
+}
@@ -19,13 +19,16 @@ package handler_ctrl
 import (
 	"github.com/michaelquigley/pfxlog"
 	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/common/linktls"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/xlink"
 	"google.golang.org/protobuf/proto"
 )
 
-// settingsHandler is a catch-all handler for all settings message (currently only sent on router connect).
+// settingsHandler is a catch-all handler for all settings message
 type settingsHandler struct {
-	updater CtrlAddressUpdater
+	updater      CtrlAddressUpdater
+	linkRegistry xlink.Registry
 }
 
 func (handler *settingsHandler) ContentType() int32 {
@@ -45,6 +48,29 @@ func (handler *settingsHandler) HandleReceive(msg *channel.Message, ch channel.C
 			case int32(ctrl_pb.SettingTypes_NewCtrlAddress):
 				newAddress := string(settingValue)
 				handler.updater.UpdateCtrlEndpoints([]string{newAddress})
+			case int32(ctrl_pb.SettingTypes_LinkDialBackoff):
+				linkDialBackoff := &ctrl_pb.LinkDialBackoffSettings{}
+				if err = proto.Unmarshal(settingValue, linkDialBackoff); err != nil {
+					log.WithError(err).Error("unable to unmarshal link dial backoff settings")
+				} else if handler.linkRegistry != nil {
+					handler.linkRegistry.UpdateDialBackoffOverrides(linkDialBackoff)
+				}
+			case int32(ctrl_pb.SettingTypes_LinkTlsPolicy):
+				settings := &ctrl_pb.LinkTlsPolicySettings{}
+				if err = proto.Unmarshal(settingValue, settings); err != nil {
+					log.WithError(err).Error("unable to unmarshal link tls policy settings")
+				} else if policy, err := linktls.FromSettings(settings); err != nil {
+					log.WithError(err).Error("invalid link tls policy, ignored")
+				} else if handler.linkRegistry != nil {
+					handler.linkRegistry.UpdateTlsPolicy(policy)
+				}
+			case int32(ctrl_pb.SettingTypes_LinkGroups):
+				settings := &ctrl_pb.LinkGroupSettings{}
+				if err = proto.Unmarshal(settingValue, settings); err != nil {
+					log.WithError(err).Error("unable to unmarshal link group settings")
+				} else if handler.linkRegistry != nil {
+					handler.linkRegistry.UpdateLinkGroups(settings.Groups)
+				}
 			default:
 				log.Error("unknown setting type, ignored")
 			}
@@ -54,8 +80,9 @@ func (handler *settingsHandler) HandleReceive(msg *channel.Message, ch channel.C
 	}
 }
 
-func newSettingsHandler(updater CtrlAddressUpdater) channel.TypedReceiveHandler {
+func newSettingsHandler(updater CtrlAddressUpdater, linkRegistry xlink.Registry) channel.TypedReceiveHandler {
 	return &settingsHandler{
-		updater: updater,
+		updater:      updater,
+		linkRegistry: linkRegistry,
 	}
 }
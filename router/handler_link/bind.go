@@ -21,22 +21,28 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func NewBindHandlerFactory(c env.NetworkControllers, f *forwarder.Forwarder, hbo *channel.HeartbeatOptions, mr metrics.Registry, registry xlink.Registry) *bindHandlerFactory {
+func NewBindHandlerFactory(c env.NetworkControllers, f *forwarder.Forwarder, hbo *channel.HeartbeatOptions, lpc *env.LinkLatencyProbeConfig, pmc *env.LinkPathMtuConfig, mr metrics.Registry, registry xlink.Registry) *bindHandlerFactory {
 	return &bindHandlerFactory{
-		ctrl:             c,
-		forwarder:        f,
-		metricsRegistry:  mr,
-		xlinkRegistry:    registry,
-		heartbeatOptions: hbo,
+		ctrl:               c,
+		forwarder:          f,
+		metricsRegistry:    mr,
+		xlinkRegistry:      registry,
+		heartbeatOptions:   hbo,
+		latencyProbeConfig: lpc,
+		pathMtuConfig:      pmc,
+		latencyProbes:      &latencyProbes{},
 	}
 }
 
 type bindHandlerFactory struct {
-	ctrl             env.NetworkControllers
-	forwarder        *forwarder.Forwarder
-	metricsRegistry  metrics.Registry
-	xlinkRegistry    xlink.Registry
-	heartbeatOptions *channel.HeartbeatOptions
+	ctrl               env.NetworkControllers
+	forwarder          *forwarder.Forwarder
+	metricsRegistry    metrics.Registry
+	xlinkRegistry      xlink.Registry
+	heartbeatOptions   *channel.HeartbeatOptions
+	latencyProbeConfig *env.LinkLatencyProbeConfig
+	pathMtuConfig      *env.LinkPathMtuConfig
+	latencyProbes      *latencyProbes
 }
 
 func (self *bindHandlerFactory) NewBindHandler(link xlink.Xlink, latency bool, listenerSide bool) channel.BindHandler {
@@ -75,7 +81,8 @@ func (self *bindHandler) BindChannel(binding channel.Binding) error {
 	binding.SetUserData(self.xlink.Id())
 	binding.AddCloseHandler(newCloseHandler(self.xlink, self.forwarder, self.xlinkRegistry))
 	binding.AddErrorHandler(newErrorHandler(self.xlink, self.ctrl))
-	binding.AddTypedReceiveHandler(newPayloadHandler(self.xlink, self.forwarder))
+	payloadHandler := newPayloadHandler(self.xlink, self.forwarder)
+	binding.AddTypedReceiveHandler(payloadHandler)
 	binding.AddTypedReceiveHandler(newAckHandler(self.xlink, self.forwarder))
 	binding.AddTypedReceiveHandler(&latency.LatencyHandler{})
 	binding.AddTypedReceiveHandler(newControlHandler(self.xlink, self.forwarder))
@@ -83,6 +90,14 @@ func (self *bindHandler) BindChannel(binding channel.Binding) error {
 	binding.AddPeekHandler(trace.NewChannelPeekHandler(self.xlink.Id(), ch, self.forwarder.TraceController()))
 	if self.xlink.LinkProtocol() == "dtls" {
 		binding.AddTransformHandler(xgress.PayloadTransformer{})
+		binding.AddTypedReceiveHandler(&pathMtuProbeHandler{})
+
+		fecRecoveredMeter := self.metricsRegistry.Meter("link." + self.xlink.Id() + ".fec_recovered")
+		binding.AddCloseHandler(channel.CloseHandlerF(func(ch channel.Channel) {
+			fecRecoveredMeter.Dispose()
+		}))
+		payloadHandler.fec = newFecHandler(payloadHandler, fecRecoveredMeter)
+		binding.AddTypedReceiveHandler(payloadHandler.fec)
 	}
 	if err := self.xlink.Init(self.forwarder.MetricsRegistry()); err != nil {
 		return err
@@ -104,6 +119,26 @@ func (self *bindHandler) BindChannel(binding channel.Binding) error {
 		latencySemaphore: concurrenz.NewSemaphore(2),
 		lastResponse:     time.Now().Add(self.heartbeatOptions.CloseUnresponsiveTimeout * 2).UnixMilli(),
 	}
+
+	if self.trackLatency && self.latencyProbeConfig != nil && self.latencyProbeConfig.Adaptive {
+		linkId := self.xlink.Id()
+		prober := newLatencyProber(linkId, ch, self.latencyProbeConfig, latencyMetric)
+		cb.prober = prober
+		self.latencyProbes.add(linkId, prober)
+		binding.AddCloseHandler(channel.CloseHandlerF(func(ch channel.Channel) {
+			self.latencyProbes.remove(linkId)
+		}))
+		go prober.run()
+	}
+
+	if self.xlink.LinkProtocol() == "dtls" && self.pathMtuConfig != nil && self.pathMtuConfig.Enabled {
+		pathMtuMetric := self.metricsRegistry.Gauge("link." + self.xlink.Id() + ".path_mtu")
+		binding.AddCloseHandler(channel.CloseHandlerF(func(ch channel.Channel) {
+			pathMtuMetric.Dispose()
+		}))
+		cb.pathMtuProber = newPathMtuProber(self.xlink.Id(), ch, self.pathMtuConfig, pathMtuMetric)
+	}
+
 	channel.ConfigureHeartbeat(binding, 10*time.Second, time.Second, cb)
 
 	return nil
@@ -147,6 +182,8 @@ func (self *bindHandler) verifyRouter(l xlink.Xlink, ch channel.Channel) error {
 
 type heartbeatCallback struct {
 	latencyMetric    metrics.Histogram
+	prober           *latencyProber
+	pathMtuProber    *pathMtuProber
 	queueTimeMetric  metrics.Histogram
 	lastResponse     int64
 	heartbeatOptions *channel.HeartbeatOptions
@@ -163,7 +200,11 @@ func (self *heartbeatCallback) HeartbeatRespTx(int64) {}
 func (self *heartbeatCallback) HeartbeatRespRx(ts int64) {
 	now := time.Now()
 	self.lastResponse = now.UnixMilli()
-	self.latencyMetric.Update(now.UnixNano() - ts)
+	if self.prober != nil {
+		self.prober.Record(time.Duration(now.UnixNano() - ts))
+	} else {
+		self.latencyMetric.Update(now.UnixNano() - ts)
+	}
 }
 
 func (self *heartbeatCallback) CheckHeartBeat() {
@@ -183,6 +224,10 @@ func (self *heartbeatCallback) CheckHeartBeat() {
 	}
 
 	go self.checkQueueTime()
+
+	if self.pathMtuProber != nil {
+		self.pathMtuProber.CheckPathMtu()
+	}
 }
 
 func (self *heartbeatCallback) checkQueueTime() {
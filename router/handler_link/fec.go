@@ -0,0 +1,69 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_link
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/metrics"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/xlink_transport"
+)
+
+// fecHandler rebuilds payloads lost on datagram links, using the parity messages sent when the other side of the
+// link has FEC enabled. Rebuilt payloads are forwarded the same as received ones, and counted in the
+// link.<id>.fec_recovered meter.
+type fecHandler struct {
+	payloadHandler *payloadHandler
+	decoder        *xlink_transport.FecDecoder
+	recovered      metrics.Meter
+}
+
+func newFecHandler(payloadHandler *payloadHandler, recovered metrics.Meter) *fecHandler {
+	return &fecHandler{
+		payloadHandler: payloadHandler,
+		decoder:        xlink_transport.NewFecDecoder(),
+		recovered:      recovered,
+	}
+}
+
+func (self *fecHandler) ContentType() int32 {
+	return int32(ctrl_pb.ContentType_LinkFecParityType)
+}
+
+func (self *fecHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	recovered, err := self.decoder.AcceptParity(msg)
+	self.handleRecovered(recovered, err, ch)
+}
+
+func (self *fecHandler) acceptPayload(msg *channel.Message, ch channel.Channel) {
+	recovered, err := self.decoder.AcceptPayload(msg)
+	self.handleRecovered(recovered, err, ch)
+}
+
+func (self *fecHandler) handleRecovered(msg *channel.Message, err error, ch channel.Channel) {
+	if err != nil {
+		pfxlog.ContextLogger(ch.Label()).WithField("linkId", self.payloadHandler.link.Id()).
+			WithError(err).Error("unable to recover payload using fec")
+		return
+	}
+
+	if msg != nil {
+		self.recovered.Mark(1)
+		self.payloadHandler.forward(msg, ch)
+	}
+}
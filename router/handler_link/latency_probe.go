@@ -0,0 +1,230 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_link
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/foundation/v2/concurrenz"
+	"github.com/openziti/metrics"
+	"github.com/openziti/ziti/router/env"
+)
+
+const latencyProbeWindowSize = 8
+
+// latencyProbeTuner picks the interval between latency samples. It starts at the configured minimum, so new links
+// get a latency measurement quickly. Unstable latency, probe timeouts and link changes halve the interval, down to
+// the configured minimum. Each stable sample grows it by a quarter, up to the maximum. Latency is considered unstable
+// when the coefficient of variation of the recent samples exceeds the variance threshold.
+type latencyProbeTuner struct {
+	config   *env.LinkLatencyProbeConfig
+	interval time.Duration
+	samples  []float64
+	next     int
+}
+
+func newLatencyProbeTuner(config *env.LinkLatencyProbeConfig) *latencyProbeTuner {
+	return &latencyProbeTuner{
+		config:   config,
+		interval: config.MinInterval,
+	}
+}
+
+func (self *latencyProbeTuner) Interval() time.Duration {
+	return self.interval
+}
+
+func (self *latencyProbeTuner) AddSample(latency time.Duration) {
+	if len(self.samples) < latencyProbeWindowSize {
+		self.samples = append(self.samples, float64(latency))
+	} else {
+		self.samples[self.next] = float64(latency)
+		self.next = (self.next + 1) % latencyProbeWindowSize
+	}
+
+	if self.isUnstable() {
+		self.shorten()
+	} else {
+		self.lengthen()
+	}
+}
+
+func (self *latencyProbeTuner) Timeout() {
+	self.shorten()
+}
+
+func (self *latencyProbeTuner) PathChanged() {
+	self.interval = self.config.MinInterval
+}
+
+func (self *latencyProbeTuner) isUnstable() bool {
+	if len(self.samples) < 2 {
+		return false
+	}
+
+	var sum float64
+	for _, sample := range self.samples {
+		sum += sample
+	}
+	mean := sum / float64(len(self.samples))
+	if mean <= 0 {
+		return false
+	}
+
+	var squares float64
+	for _, sample := range self.samples {
+		squares += (sample - mean) * (sample - mean)
+	}
+	stdDev := math.Sqrt(squares / float64(len(self.samples)))
+
+	return stdDev/mean > self.config.VarianceThreshold
+}
+
+func (self *latencyProbeTuner) shorten() {
+	self.interval = max(self.interval/2, self.config.MinInterval)
+}
+
+func (self *latencyProbeTuner) lengthen() {
+	self.interval = min(self.interval+self.interval/4, self.config.MaxInterval)
+}
+
+// latencyProber records latency samples for a link channel, from heartbeats and from its own probes. A probe is only
+// sent when no sample has been recorded within the current interval.
+type latencyProber struct {
+	linkId        string
+	ch            channel.Channel
+	config        *env.LinkLatencyProbeConfig
+	latencyMetric metrics.Histogram
+	lastSample    atomic.Int64
+	pathChanged   chan struct{}
+
+	lock  sync.Mutex
+	tuner *latencyProbeTuner
+}
+
+func newLatencyProber(linkId string, ch channel.Channel, config *env.LinkLatencyProbeConfig, latencyMetric metrics.Histogram) *latencyProber {
+	result := &latencyProber{
+		linkId:        linkId,
+		ch:            ch,
+		config:        config,
+		latencyMetric: latencyMetric,
+		pathChanged:   make(chan struct{}, 1),
+		tuner:         newLatencyProbeTuner(config),
+	}
+	result.lastSample.Store(time.Now().UnixNano())
+	return result
+}
+
+func (self *latencyProber) Record(latency time.Duration) {
+	self.latencyMetric.Update(latency.Nanoseconds())
+	self.lastSample.Store(time.Now().UnixNano())
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.tuner.AddSample(latency)
+}
+
+func (self *latencyProber) GetInterval() time.Duration {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.tuner.Interval()
+}
+
+func (self *latencyProber) NotifyPathChanged() {
+	self.lock.Lock()
+	self.tuner.PathChanged()
+	self.lock.Unlock()
+
+	select {
+	case self.pathChanged <- struct{}{}:
+	default:
+	}
+}
+
+func (self *latencyProber) run() {
+	log := pfxlog.Logger().WithField("linkId", self.linkId)
+	log.Debug("adaptive latency probe started")
+	defer log.Debug("adaptive latency probe exited")
+
+	ticker := time.NewTicker(self.config.MinInterval)
+	defer ticker.Stop()
+
+	for !self.ch.IsClosed() {
+		select {
+		case <-ticker.C:
+		case <-self.pathChanged:
+		case <-self.ch.CloseNotify():
+			return
+		}
+
+		sinceLastSample := time.Duration(time.Now().UnixNano() - self.lastSample.Load())
+		if sinceLastSample >= self.GetInterval() {
+			self.probe()
+		}
+	}
+}
+
+func (self *latencyProber) probe() {
+	start := time.Now()
+	msg := channel.NewMessage(channel.ContentTypeLatencyType, nil)
+	if _, err := msg.WithPriority(channel.High).WithTimeout(self.config.Timeout).SendForReply(self.ch); err != nil {
+		if self.ch.IsClosed() {
+			return
+		}
+
+		pfxlog.Logger().WithField("linkId", self.linkId).WithError(err).Debug("latency probe failed")
+		// make sure we don't immediately probe again
+		self.lastSample.Store(time.Now().UnixNano())
+
+		if channel.IsTimeout(err) {
+			self.lock.Lock()
+			self.tuner.Timeout()
+			self.lock.Unlock()
+		}
+		return
+	}
+
+	self.Record(time.Since(start))
+}
+
+// latencyProbes tracks the probers for all links, so they can be notified when links come and go
+type latencyProbes struct {
+	probers concurrenz.CopyOnWriteMap[string, *latencyProber]
+}
+
+func (self *latencyProbes) add(linkId string, prober *latencyProber) {
+	self.probers.Put(linkId, prober)
+	self.notifyPathChanged(linkId)
+}
+
+func (self *latencyProbes) remove(linkId string) {
+	self.probers.Delete(linkId)
+	self.notifyPathChanged(linkId)
+}
+
+func (self *latencyProbes) notifyPathChanged(changedLinkId string) {
+	for linkId, prober := range self.probers.AsMap() {
+		if linkId != changedLinkId {
+			prober.NotifyPathChanged()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_link
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/router/env"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLatencyProbeConfig() *env.LinkLatencyProbeConfig {
+	return &env.LinkLatencyProbeConfig{
+		Adaptive:          true,
+		MinInterval:       time.Second,
+		MaxInterval:       30 * time.Second,
+		Timeout:           5 * time.Second,
+		VarianceThreshold: 0.2,
+	}
+}
+
+func TestLatencyProbeTunerBacksOffWhenStable(t *testing.T) {
+	req := require.New(t)
+	tuner := newLatencyProbeTuner(newTestLatencyProbeConfig())
+	req.Equal(time.Second, tuner.Interval())
+
+	for i := 0; i < 50; i++ {
+		tuner.AddSample(20 * time.Millisecond)
+	}
+	req.Equal(30*time.Second, tuner.Interval())
+}
+
+func TestLatencyProbeTunerSpeedsUpWhenUnstable(t *testing.T) {
+	req := require.New(t)
+	tuner := newLatencyProbeTuner(newTestLatencyProbeConfig())
+
+	for i := 0; i < 50; i++ {
+		tuner.AddSample(20 * time.Millisecond)
+	}
+
+	tuner.AddSample(200 * time.Millisecond)
+	req.Equal(15*time.Second, tuner.Interval())
+
+	for i := 0; i < 5; i++ {
+		tuner.AddSample(20 * time.Millisecond)
+	}
+	req.Equal(time.Second, tuner.Interval())
+}
+
+func TestLatencyProbeTunerTimeoutsAndPathChanges(t *testing.T) {
+	req := require.New(t)
+	tuner := newLatencyProbeTuner(newTestLatencyProbeConfig())
+
+	for i := 0; i < 50; i++ {
+		tuner.AddSample(20 * time.Millisecond)
+	}
+
+	tuner.Timeout()
+	req.Equal(15*time.Second, tuner.Interval())
+
+	tuner.PathChanged()
+	req.Equal(time.Second, tuner.Interval())
+
+	tuner.AddSample(20 * time.Millisecond)
+	req.Equal(1250*time.Millisecond, tuner.Interval())
+}
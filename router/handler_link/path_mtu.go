@@ -0,0 +1,151 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package handler_link
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/metrics"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/env"
+	"github.com/pkg/errors"
+)
+
+const pathMtuProbeAttempts = 2
+
+// pathMtuProber finds the largest message which makes it across a datagram link, using a binary search between the
+// configured min and max sizes. A size is considered too large if none of its probes are answered in time. Discovery
+// is started from the heartbeat checks, and repeated every configured interval. The result is reported in the
+// link.<id>.path_mtu gauge, which the controller uses to pick the path MTU for circuits crossing the link.
+type pathMtuProber struct {
+	linkId     string
+	ch         channel.Channel
+	config     *env.LinkPathMtuConfig
+	gauge      metrics.Gauge
+	running    atomic.Bool
+	nextProbe  atomic.Int64
+	lastResult atomic.Uint32
+}
+
+func newPathMtuProber(linkId string, ch channel.Channel, config *env.LinkPathMtuConfig, gauge metrics.Gauge) *pathMtuProber {
+	return &pathMtuProber{
+		linkId: linkId,
+		ch:     ch,
+		config: config,
+		gauge:  gauge,
+	}
+}
+
+// CheckPathMtu starts discovery if it's due and not already running
+func (self *pathMtuProber) CheckPathMtu() {
+	if time.Now().UnixMilli() < self.nextProbe.Load() || self.ch.IsClosed() {
+		return
+	}
+
+	if self.running.CompareAndSwap(false, true) {
+		go self.discover()
+	}
+}
+
+func (self *pathMtuProber) discover() {
+	defer func() {
+		self.nextProbe.Store(time.Now().Add(self.config.Interval).UnixMilli())
+		self.running.Store(false)
+	}()
+
+	log := pfxlog.Logger().WithField("linkId", self.linkId)
+
+	low, high := self.config.MinSize, self.config.MaxSize
+	if !self.probeSize(low) {
+		if self.ch.IsClosed() {
+			return
+		}
+		log.Warnf("path MTU probe of min size %d failed, using min size as path MTU", low)
+		high = low
+	}
+
+	for low < high && !self.ch.IsClosed() {
+		mid := low + (high-low+1)/2
+		if self.probeSize(mid) {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+
+	if self.ch.IsClosed() {
+		return
+	}
+
+	if previous := self.lastResult.Swap(low); previous != low {
+		log.Infof("path MTU changed from %d to %d", previous, low)
+	}
+	self.gauge.Update(int64(low))
+}
+
+func (self *pathMtuProber) probeSize(size uint32) bool {
+	msg, err := newPathMtuProbe(size)
+	if err != nil {
+		pfxlog.Logger().WithField("linkId", self.linkId).WithError(err).Error("unable to create path MTU probe")
+		return false
+	}
+
+	for i := 0; i < pathMtuProbeAttempts; i++ {
+		if _, err = msg.WithTimeout(self.config.Timeout).SendForReply(self.ch); err == nil {
+			return true
+		}
+		if self.ch.IsClosed() || !channel.IsTimeout(err) {
+			pfxlog.Logger().WithField("linkId", self.linkId).WithError(err).Debugf("path MTU probe of size %d failed", size)
+			return false
+		}
+	}
+	return false
+}
+
+// newPathMtuProbe creates a probe message whose marshalled size is the given size
+func newPathMtuProbe(size uint32) (*channel.Message, error) {
+	msg := channel.NewMessage(int32(ctrl_pb.ContentType_LinkMtuProbeType), nil)
+	data, err := channel.MarshalV2(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal path MTU probe")
+	}
+
+	if uint32(len(data)) > size {
+		return nil, errors.Errorf("path MTU probe size %d is smaller than the message overhead of %d", size, len(data))
+	}
+
+	msg.Body = make([]byte, size-uint32(len(data)))
+	return msg, nil
+}
+
+// pathMtuProbeHandler answers path MTU probes. The reply is kept small, since only the probe size is being tested.
+type pathMtuProbeHandler struct{}
+
+func (self *pathMtuProbeHandler) ContentType() int32 {
+	return int32(ctrl_pb.ContentType_LinkMtuProbeType)
+}
+
+func (self *pathMtuProbeHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	reply := channel.NewResult(true, "")
+	reply.ReplyTo(msg)
+	if err := ch.Send(reply); err != nil && !ch.IsClosed() {
+		pfxlog.Logger().WithField("linkId", ch.Id()).WithError(err).Debug("unable to reply to path MTU probe")
+	}
+}
@@ -22,11 +22,13 @@ import (
 	"github.com/openziti/sdk-golang/xgress"
 	"github.com/openziti/ziti/router/forwarder"
 	"github.com/openziti/ziti/router/xlink"
+	"github.com/openziti/ziti/router/xlink_transport"
 )
 
 type payloadHandler struct {
 	link      xlink.Xlink
 	forwarder *forwarder.Forwarder
+	fec       *fecHandler
 }
 
 func newPayloadHandler(link xlink.Xlink, forwarder *forwarder.Forwarder) *payloadHandler {
@@ -41,10 +43,22 @@ func (self *payloadHandler) ContentType() int32 {
 }
 
 func (self *payloadHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	if self.fec != nil {
+		self.fec.acceptPayload(msg, ch)
+	}
+	self.forward(msg, ch)
+}
+
+func (self *payloadHandler) forward(msg *channel.Message, ch channel.Channel) {
 	log := pfxlog.ContextLogger(ch.Label()).
 		WithField("linkId", self.link.Id()).
 		WithField("routerId", self.link.DestinationId())
 
+	if err := xlink_transport.DecompressPayload(msg); err != nil {
+		log.WithError(err).Error("error decompressing payload")
+		return
+	}
+
 	payload, err := xgress.UnmarshallPayload(msg)
 	if err == nil {
 		if err = self.forwarder.ForwardPayload(xgress.Address(self.link.Id()), payload, 0); err != nil {
@@ -39,12 +39,16 @@ func NewBindHandler(env env.RouterEnv, dataPlaneAdapter xgress.DataPlaneAdapter,
 }
 
 func (bindHandler *bindHandler) HandleXgressBind(x *xgress.Xgress) {
-	x.SetDataPlaneAdapter(bindHandler.dataPlaneAdapter)
+	bindHandler.env.GetMemoryPressureMonitor().Bind(x)
+
+	forwarder := bindHandler.env.GetForwarder()
+	circuitMetrics := forwarder.CircuitMetrics(x, bindHandler.dataPlaneAdapter.GetMetrics())
+	x.SetDataPlaneAdapter(newCircuitDataPlaneAdapter(bindHandler.dataPlaneAdapter, circuitMetrics))
 	x.AddPeekHandler(bindHandler.metricsPeekHandler)
 
 	x.AddCloseHandler(bindHandler.closeHandler)
 
-	bindHandler.env.GetForwarder().RegisterDestination(x.CircuitId(), x.Address(), x)
+	forwarder.RegisterDestination(x.CircuitId(), x.Address(), x)
 }
 
 func (bindHandler *bindHandler) GetMetricsPeekHandler() xgress.PeekHandler {
@@ -93,3 +93,20 @@ func (adapter *dataPlaneAdapter) GetPayloadIngester() *xgress.PayloadIngester {
 func (adapter *dataPlaneAdapter) GetMetrics() xgress.Metrics {
 	return adapter.metrics
 }
+
+// circuitDataPlaneAdapter is bound to a single xgress, so metrics reported by the xgress can be attributed to its circuit
+type circuitDataPlaneAdapter struct {
+	xgress.DataPlaneAdapter
+	metrics xgress.Metrics
+}
+
+func newCircuitDataPlaneAdapter(adapter xgress.DataPlaneAdapter, metrics xgress.Metrics) xgress.DataPlaneAdapter {
+	return &circuitDataPlaneAdapter{
+		DataPlaneAdapter: adapter,
+		metrics:          metrics,
+	}
+}
+
+func (adapter *circuitDataPlaneAdapter) GetMetrics() xgress.Metrics {
+	return adapter.metrics
+}
@@ -0,0 +1,274 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"sort"
+	"time"
+
+	"github.com/openziti/ziti/common/inspect"
+	"github.com/openziti/ziti/router/xgress_common"
+	"github.com/openziti/ziti/router/xgress_router"
+	"github.com/pkg/errors"
+)
+
+const terminatorInspectTimeFormat = "2006-01-02 15:04:05"
+
+type ctrlChannelDetail struct {
+	CtrlId               string `json:"ctrlId"`
+	Address              string `json:"address"`
+	Connected            bool   `json:"connected"`
+	Unresponsive         bool   `json:"unresponsive"`
+	Latency              string `json:"latency"`
+	TimeSinceLastContact string `json:"timeSinceLastContact"`
+}
+
+type ctrlChannelHealthDetail struct {
+	ConnectedCount int                  `json:"connectedCount"`
+	MinConnected   int                  `json:"minConnected"`
+	Controllers    []*ctrlChannelDetail `json:"controllers"`
+}
+
+// ctrlChannelHealthCheck reports the state of the control channel to each controller. It fails if fewer than the
+// configured minimum of controllers are connected and responsive.
+type ctrlChannelHealthCheck struct {
+	router       *Router
+	minConnected int
+}
+
+func (self *ctrlChannelHealthCheck) Name() string {
+	return "controller.channels"
+}
+
+func (self *ctrlChannelHealthCheck) Execute(context.Context) (details interface{}, err error) {
+	result := &ctrlChannelHealthDetail{
+		MinConnected: self.minConnected,
+		Controllers:  []*ctrlChannelDetail{},
+	}
+
+	for ctrlId, ctrl := range self.router.ctrls.GetAll() {
+		detail := &ctrlChannelDetail{
+			CtrlId:               ctrlId,
+			Address:              ctrl.Address(),
+			Connected:            ctrl.IsConnected(),
+			Unresponsive:         ctrl.IsUnresponsive(),
+			Latency:              ctrl.Latency().String(),
+			TimeSinceLastContact: ctrl.TimeSinceLastContact().String(),
+		}
+		if detail.Connected && !detail.Unresponsive {
+			result.ConnectedCount++
+		}
+		result.Controllers = append(result.Controllers, detail)
+	}
+
+	sort.Slice(result.Controllers, func(i, j int) bool {
+		return result.Controllers[i].CtrlId < result.Controllers[j].CtrlId
+	})
+
+	if result.ConnectedCount < self.minConnected {
+		return result, errors.Errorf("connected controller count %v less than configured minimum of %v", result.ConnectedCount, self.minConnected)
+	}
+	return result, nil
+}
+
+type terminatorDetail struct {
+	Binding      string `json:"binding"`
+	TerminatorId string `json:"terminatorId"`
+	State        string `json:"state"`
+	CreateTime   string `json:"createTime"`
+}
+
+type terminatorHealthDetail struct {
+	Counts  map[string]int      `json:"counts"`
+	Stalled []*terminatorDetail `json:"stalled"`
+	Errors  []string            `json:"errors,omitempty"`
+}
+
+// terminatorHealthCheck reports the bind status of the terminators hosted by the router, for both SDK hosted services
+// and services hosted by the router embedded tunneler. It fails if any terminator has been waiting to be established
+// with the controller for longer than the configured establish timeout.
+type terminatorHealthCheck struct {
+	router           *Router
+	establishTimeout time.Duration
+}
+
+func (self *terminatorHealthCheck) Name() string {
+	return "terminators"
+}
+
+func (self *terminatorHealthCheck) Execute(ctx context.Context) (details interface{}, err error) {
+	// the edge and tunnel terminators are inspected one after the other, so split the time available between them
+	timeout := 2500 * time.Millisecond
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline) / 2
+	}
+
+	var terminators []*terminatorDetail
+	var errs []string
+
+	if result, ok := self.inspect("edge", inspect.SdkTerminatorsKey, timeout).(*inspect.SdkTerminatorInspectResult); ok && result != nil {
+		for _, entry := range result.Entries {
+			terminators = append(terminators, &terminatorDetail{Binding: "edge", TerminatorId: entry.Id, State: entry.State, CreateTime: entry.CreateTime})
+		}
+		errs = append(errs, result.Errors...)
+	}
+
+	if result, ok := self.inspect("tunnel", inspect.ErtTerminatorsKey, timeout).(*inspect.ErtTerminatorInspectResult); ok && result != nil {
+		for _, entry := range result.Entries {
+			terminators = append(terminators, &terminatorDetail{Binding: "tunnel", TerminatorId: entry.Id, State: entry.State, CreateTime: entry.CreateTime})
+		}
+		errs = append(errs, result.Errors...)
+	}
+
+	return evaluateTerminatorHealth(terminators, errs, self.establishTimeout, time.Now())
+}
+
+func (self *terminatorHealthCheck) inspect(binding string, key string, timeout time.Duration) any {
+	factory, _ := xgress_router.GlobalRegistry().Factory(binding)
+	if factory == nil {
+		return nil
+	}
+
+	dialer, err := factory.CreateDialer(self.router.GetDialerCfg()[binding])
+	if err != nil {
+		return nil
+	}
+
+	if inspectable, ok := dialer.(xgress_router.Inspectable); ok {
+		return inspectable.Inspect(key, timeout)
+	}
+	return nil
+}
+
+func evaluateTerminatorHealth(terminators []*terminatorDetail, errs []string, establishTimeout time.Duration, now time.Time) (*terminatorHealthDetail, error) {
+	result := &terminatorHealthDetail{
+		Counts:  map[string]int{},
+		Stalled: []*terminatorDetail{},
+		Errors:  errs,
+	}
+
+	for _, terminator := range terminators {
+		result.Counts[terminator.State]++
+		if terminator.State != xgress_common.TerminatorStateEstablishing.String() {
+			continue
+		}
+		createTime, err := time.ParseInLocation(terminatorInspectTimeFormat, terminator.CreateTime, time.Local)
+		if err == nil && now.Sub(createTime) > establishTimeout {
+			result.Stalled = append(result.Stalled, terminator)
+		}
+	}
+
+	if len(result.Stalled) > 0 {
+		return result, errors.Errorf("%v terminators not established after %v", len(result.Stalled), establishTimeout)
+	}
+	return result, nil
+}
+
+type certExpiryDetail struct {
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject"`
+	NotAfter  time.Time `json:"notAfter"`
+	ExpiresIn string    `json:"expiresIn"`
+}
+
+type certExpiryHealthDetail struct {
+	Certs   []*certExpiryDetail `json:"certs"`
+	Warning bool                `json:"warning"`
+}
+
+func (self *certExpiryHealthDetail) IsHealthWarning() bool {
+	return self.Warning
+}
+
+// certExpiryHealthCheck reports when the router's client and server certificates expire. Certificates are normally
+// renewed well before they expire, so the check warns if a certificate is inside the warning window and fails if it
+// is inside the failure window.
+type certExpiryHealthCheck struct {
+	router     *Router
+	warnBefore time.Duration
+	failBefore time.Duration
+}
+
+func (self *certExpiryHealthCheck) Name() string {
+	return "cert.expiry"
+}
+
+func (self *certExpiryHealthCheck) Execute(context.Context) (details interface{}, err error) {
+	var certs []*x509.Certificate
+	var names []string
+
+	if cert := getLeafCert(self.router.config.Id.Cert()); cert != nil {
+		certs = append(certs, cert)
+		names = append(names, "client")
+	}
+
+	for _, serverCert := range self.router.config.Id.ServerCert() {
+		if cert := getLeafCert(serverCert); cert != nil {
+			certs = append(certs, cert)
+			names = append(names, "server")
+		}
+	}
+
+	return evaluateCertExpiry(names, certs, self.warnBefore, self.failBefore, time.Now())
+}
+
+func evaluateCertExpiry(names []string, certs []*x509.Certificate, warnBefore, failBefore time.Duration, now time.Time) (*certExpiryHealthDetail, error) {
+	result := &certExpiryHealthDetail{
+		Certs: []*certExpiryDetail{},
+	}
+
+	var expiring []string
+	for i, cert := range certs {
+		expiresIn := cert.NotAfter.Sub(now)
+		result.Certs = append(result.Certs, &certExpiryDetail{
+			Name:      names[i],
+			Subject:   cert.Subject.String(),
+			NotAfter:  cert.NotAfter.UTC(),
+			ExpiresIn: expiresIn.Truncate(time.Second).String(),
+		})
+		if expiresIn < failBefore {
+			expiring = append(expiring, names[i])
+		} else if expiresIn < warnBefore {
+			result.Warning = true
+		}
+	}
+
+	if len(expiring) > 0 {
+		return result, errors.Errorf("certificates %v expire in less than %v", expiring, failBefore)
+	}
+	return result, nil
+}
+
+func getLeafCert(cert *tls.Certificate) *x509.Certificate {
+	if cert == nil {
+		return nil
+	}
+	if cert.Leaf != nil {
+		return cert.Leaf
+	}
+	if len(cert.Certificate) == 0 {
+		return nil
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil
+	}
+	return leaf
+}
@@ -0,0 +1,80 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package router
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateTerminatorHealth(t *testing.T) {
+	req := require.New(t)
+	now := time.Now()
+
+	recent := now.Add(-30 * time.Second).Format(terminatorInspectTimeFormat)
+	old := now.Add(-5 * time.Minute).Format(terminatorInspectTimeFormat)
+
+	terminators := []*terminatorDetail{
+		{Binding: "edge", TerminatorId: "t1", State: "established", CreateTime: old},
+		{Binding: "edge", TerminatorId: "t2", State: "establishing", CreateTime: recent},
+		{Binding: "tunnel", TerminatorId: "t3", State: "established", CreateTime: recent},
+	}
+
+	result, err := evaluateTerminatorHealth(terminators, nil, 2*time.Minute, now)
+	req.NoError(err)
+	req.Equal(2, result.Counts["established"])
+	req.Equal(1, result.Counts["establishing"])
+	req.Empty(result.Stalled)
+
+	terminators = append(terminators, &terminatorDetail{Binding: "tunnel", TerminatorId: "t4", State: "establishing", CreateTime: old})
+	result, err = evaluateTerminatorHealth(terminators, nil, 2*time.Minute, now)
+	req.Error(err)
+	req.Len(result.Stalled, 1)
+	req.Equal("t4", result.Stalled[0].TerminatorId)
+}
+
+func TestEvaluateCertExpiry(t *testing.T) {
+	req := require.New(t)
+	now := time.Now()
+
+	newCert := func(expiresIn time.Duration) *x509.Certificate {
+		return &x509.Certificate{
+			Subject:  pkix.Name{CommonName: "router"},
+			NotAfter: now.Add(expiresIn),
+		}
+	}
+
+	names := []string{"client", "server"}
+
+	result, err := evaluateCertExpiry(names, []*x509.Certificate{newCert(90 * 24 * time.Hour), newCert(90 * 24 * time.Hour)}, 7*24*time.Hour, 24*time.Hour, now)
+	req.NoError(err)
+	req.False(result.IsHealthWarning())
+	req.Len(result.Certs, 2)
+	req.Equal("CN=router", result.Certs[0].Subject)
+
+	result, err = evaluateCertExpiry(names, []*x509.Certificate{newCert(90 * 24 * time.Hour), newCert(3 * 24 * time.Hour)}, 7*24*time.Hour, 24*time.Hour, now)
+	req.NoError(err)
+	req.True(result.IsHealthWarning())
+
+	result, err = evaluateCertExpiry(names, []*x509.Certificate{newCert(time.Hour), newCert(3 * 24 * time.Hour)}, 7*24*time.Hour, 24*time.Hour, now)
+	req.Error(err)
+	req.Contains(err.Error(), "client")
+}
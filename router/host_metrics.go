@@ -0,0 +1,63 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package router
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/metrics"
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+const hostCpuSampleInterval = 10 * time.Second
+
+// startHostMetrics registers gauges describing the load on the host the router is running on. These are used by the
+// controller to build autoscaling hints. CPU usage is sampled on a fixed interval, so that the reported value doesn't
+// depend on how often the gauge is read.
+func startHostMetrics(registry metrics.Registry, closeNotify <-chan struct{}) {
+	var cpuPercent atomic.Int64
+
+	registry.FuncGauge("host.cpu.percent", func() int64 {
+		return cpuPercent.Load()
+	})
+
+	sample := func() {
+		percent, err := cpu.Percent(0, false)
+		if err != nil || len(percent) == 0 {
+			pfxlog.Logger().WithError(err).Debug("unable to sample host cpu usage")
+			return
+		}
+		cpuPercent.Store(int64(math.Round(percent[0])))
+	}
+
+	go func() {
+		ticker := time.NewTicker(hostCpuSampleInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				sample()
+			case <-closeNotify:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,123 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package link
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/xlink"
+	"time"
+)
+
+// UpdateDialBackoffOverrides replaces the set of link dial backoff overrides sent by the controller
+func (self *linkRegistryImpl) UpdateDialBackoffOverrides(settings *ctrl_pb.LinkDialBackoffSettings) {
+	self.queueEvent(&updateDialBackoffOverrides{settings: settings})
+}
+
+// getBackoffConfig returns the backoff config for the given link state, taking into account the health of
+// the destination and any overrides for the dialer's link groups
+func (self *linkRegistryImpl) getBackoffConfig(state *linkState) xlink.BackoffConfig {
+	healthy := state.dest.healthy
+
+	var result xlink.BackoffConfig
+	if healthy {
+		result = state.dialer.GetHealthyBackoffConfig()
+	} else {
+		result = state.dialer.GetUnhealthyBackoffConfig()
+	}
+
+	for _, group := range self.getDialerGroups(state.dialer) {
+		if override, found := self.dialBackoffOverrides[group]; found {
+			if healthy && override.Healthy != nil {
+				return &backoffConfigOverride{base: result, override: override.Healthy}
+			}
+			if !healthy && override.Unhealthy != nil {
+				return &backoffConfigOverride{base: result, override: override.Unhealthy}
+			}
+		}
+	}
+
+	return result
+}
+
+// backoffConfigOverride applies controller provided overrides on top of the locally configured values. Unset
+// values in the override fall back to the local configuration.
+type backoffConfigOverride struct {
+	base     xlink.BackoffConfig
+	override *ctrl_pb.DialBackoff
+}
+
+func (self *backoffConfigOverride) GetMinRetryInterval() time.Duration {
+	if self.override.MinRetryIntervalMs > 0 {
+		return time.Duration(self.override.MinRetryIntervalMs) * time.Millisecond
+	}
+	return self.base.GetMinRetryInterval()
+}
+
+func (self *backoffConfigOverride) GetMaxRetryInterval() time.Duration {
+	if self.override.MaxRetryIntervalMs > 0 {
+		return time.Duration(self.override.MaxRetryIntervalMs) * time.Millisecond
+	}
+	return self.base.GetMaxRetryInterval()
+}
+
+func (self *backoffConfigOverride) GetRetryBackoffFactor() float64 {
+	if self.override.RetryBackoffFactor > 0 {
+		return self.override.RetryBackoffFactor
+	}
+	return self.base.GetRetryBackoffFactor()
+}
+
+func (self *backoffConfigOverride) GetRetryJitter() float64 {
+	if self.override.Jitter > 0 {
+		return self.override.Jitter
+	}
+	return self.base.GetRetryJitter()
+}
+
+func (self *backoffConfigOverride) GetMaxAttempts() uint32 {
+	if self.override.MaxAttempts > 0 {
+		return self.override.MaxAttempts
+	}
+	return self.base.GetMaxAttempts()
+}
+
+type updateDialBackoffOverrides struct {
+	settings *ctrl_pb.LinkDialBackoffSettings
+}
+
+func (self *updateDialBackoffOverrides) Handle(registry *linkRegistryImpl) {
+	overrides := map[string]*ctrl_pb.LinkGroupDialBackoff{}
+	var groups []string
+	for _, groupOverride := range self.settings.Groups {
+		overrides[groupOverride.Group] = groupOverride
+		groups = append(groups, groupOverride.Group)
+	}
+	registry.dialBackoffOverrides = overrides
+
+	pfxlog.Logger().WithField("groups", groups).Info("link dial backoff overrides updated")
+
+	// give links which were given up on another chance, since the policy they gave up under may have changed
+	for _, dest := range registry.destinations {
+		for _, state := range dest.linkMap {
+			if state.status == StatusGaveUp {
+				state.resetDialBackoff()
+				registry.evaluateLinkState(state)
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package link
+
+import (
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/xlink"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testBackoffConfig struct {
+	minRetryInterval time.Duration
+	maxRetryInterval time.Duration
+	factor           float64
+	jitter           float64
+	maxAttempts      uint32
+}
+
+func (self *testBackoffConfig) GetMinRetryInterval() time.Duration { return self.minRetryInterval }
+func (self *testBackoffConfig) GetMaxRetryInterval() time.Duration { return self.maxRetryInterval }
+func (self *testBackoffConfig) GetRetryBackoffFactor() float64     { return self.factor }
+func (self *testBackoffConfig) GetRetryJitter() float64            { return self.jitter }
+func (self *testBackoffConfig) GetMaxAttempts() uint32             { return self.maxAttempts }
+
+type testDialer struct {
+	groups    []string
+	healthy   xlink.BackoffConfig
+	unhealthy xlink.BackoffConfig
+}
+
+func (self *testDialer) Dial(xlink.Dial) (xlink.Xlink, error) {
+	panic("implement me")
+}
+
+func (self *testDialer) GetGroups() []string {
+	return self.groups
+}
+
+func (self *testDialer) GetBinding() string {
+	return ""
+}
+
+func (self *testDialer) GetHealthyBackoffConfig() xlink.BackoffConfig {
+	return self.healthy
+}
+
+func (self *testDialer) GetUnhealthyBackoffConfig() xlink.BackoffConfig {
+	return self.unhealthy
+}
+
+func (self *testDialer) AdoptBinding(xlink.Listener) {}
+
+func newTestBackoffState() (*linkRegistryImpl, *linkState) {
+	registry := &linkRegistryImpl{
+		linkStateQueue: &linkStateHeap{},
+	}
+
+	dialer := &testDialer{
+		groups:    []string{GroupDefault},
+		healthy:   &testBackoffConfig{minRetryInterval: time.Second, maxRetryInterval: time.Minute, factor: 2},
+		unhealthy: &testBackoffConfig{minRetryInterval: time.Minute, maxRetryInterval: time.Hour, factor: 10},
+	}
+
+	state := &linkState{
+		linkKey:      "test",
+		linkId:       "test",
+		status:       StatusDialFailed,
+		dest:         newLinkDest("dest"),
+		dialer:       dialer,
+		allowedDials: -1,
+	}
+
+	return registry, state
+}
+
+func Test_DialBackoffOverride(t *testing.T) {
+	req := require.New(t)
+	registry, state := newTestBackoffState()
+
+	config := registry.getBackoffConfig(state)
+	req.Equal(time.Second, config.GetMinRetryInterval())
+
+	registry.dialBackoffOverrides = map[string]*ctrl_pb.LinkGroupDialBackoff{
+		GroupDefault: {
+			Group:   GroupDefault,
+			Healthy: &ctrl_pb.DialBackoff{MinRetryIntervalMs: 5000, MaxAttempts: 3},
+		},
+	}
+
+	config = registry.getBackoffConfig(state)
+	req.Equal(5*time.Second, config.GetMinRetryInterval())
+	req.Equal(time.Minute, config.GetMaxRetryInterval())
+	req.Equal(float64(2), config.GetRetryBackoffFactor())
+	req.Equal(uint32(3), config.GetMaxAttempts())
+
+	// no unhealthy override, so local config should be used
+	state.dest.healthy = false
+	config = registry.getBackoffConfig(state)
+	req.Equal(time.Minute, config.GetMinRetryInterval())
+	req.Equal(uint32(0), config.GetMaxAttempts())
+}
+
+func Test_DialBackoffJitter(t *testing.T) {
+	req := require.New(t)
+	registry, state := newTestBackoffState()
+	registry.dialBackoffOverrides = map[string]*ctrl_pb.LinkGroupDialBackoff{
+		GroupDefault: {
+			Group:   GroupDefault,
+			Healthy: &ctrl_pb.DialBackoff{Jitter: 0.25},
+		},
+	}
+
+	for i := 0; i < 100; i++ {
+		state.retryDelay = 10 * time.Second
+		state.dialFailed(registry, false)
+		req.GreaterOrEqual(state.retryDelay, time.Duration(float64(10*time.Second)*1.75))
+		req.LessOrEqual(state.retryDelay, time.Duration(float64(10*time.Second)*2.25))
+	}
+}
+
+func Test_DialBackoffGiveUp(t *testing.T) {
+	req := require.New(t)
+	registry, state := newTestBackoffState()
+	state.dialer.(*testDialer).healthy.(*testBackoffConfig).maxAttempts = 3
+
+	state.dialFailed(registry, false)
+	state.dialFailed(registry, false)
+	req.Equal(StatusDialFailed, state.status)
+	req.Equal(2, registry.linkStateQueue.Len())
+
+	state.dialFailed(registry, false)
+	req.Equal(StatusGaveUp, state.status)
+	req.Equal(uint32(3), state.consecutiveFailures)
+	req.Equal(2, registry.linkStateQueue.Len())
+
+	// a gave up link shouldn't be dialed during evaluation, even once its next dial time has passed
+	state.nextDial = time.Time{}
+	registry.evaluateLinkState(state)
+	req.Equal(StatusGaveUp, state.status)
+
+	state.resetDialBackoff()
+	req.Equal(StatusPending, state.status)
+	req.Equal(uint32(0), state.consecutiveFailures)
+}
@@ -0,0 +1,124 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package link
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/openziti/ziti/router/env"
+)
+
+// linkDialPacer limits link dials for a window after the router starts or reconnects to a controller. When a large
+// mesh recovers from a controller restart, every router learns about every other router at about the same time, and
+// would otherwise dial all its links at once. While pacing is active, dials wait for a slot according to the
+// configured rate, plus a random jitter, and at most the configured number of dials run concurrently.
+type linkDialPacer struct {
+	options     env.LinkDialPacingOptions
+	closeNotify <-chan struct{}
+	slots       chan struct{}
+
+	lock       sync.Mutex
+	pacedUntil time.Time
+	nextStart  time.Time
+}
+
+func newLinkDialPacer(options env.LinkDialPacingOptions, closeNotify <-chan struct{}) *linkDialPacer {
+	result := &linkDialPacer{
+		options:     options,
+		closeNotify: closeNotify,
+	}
+
+	if options.Concurrency > 0 {
+		result.slots = make(chan struct{}, options.Concurrency)
+	}
+
+	result.startWindow()
+	return result
+}
+
+// startWindow starts, or extends, the period during which dials are paced
+func (self *linkDialPacer) startWindow() {
+	if self.options.Window <= 0 {
+		return
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.pacedUntil = time.Now().Add(self.options.Window)
+}
+
+func (self *linkDialPacer) isPacing() bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return time.Now().Before(self.pacedUntil)
+}
+
+// getStartDelay reserves the next dial start time and returns how long the caller should wait for it
+func (self *linkDialPacer) getStartDelay() time.Duration {
+	var delay time.Duration
+	if self.options.Jitter > 0 {
+		delay = rand.N(self.options.Jitter)
+	}
+
+	if self.options.Rate == 0 {
+		return delay
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := time.Now()
+	start := self.nextStart
+	if start.Before(now) {
+		start = now
+	}
+	self.nextStart = start.Add(time.Second / time.Duration(self.options.Rate))
+
+	return start.Sub(now) + delay
+}
+
+// acquire waits until a dial may start and returns a function which must be called when the dial is done. It returns
+// false if the router is shutting down.
+func (self *linkDialPacer) acquire() (func(), bool) {
+	noop := func() {}
+	if !self.isPacing() {
+		return noop, true
+	}
+
+	if delay := self.getStartDelay(); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-self.closeNotify:
+			timer.Stop()
+			return noop, false
+		}
+	}
+
+	if self.slots == nil {
+		return noop, true
+	}
+
+	select {
+	case self.slots <- struct{}{}:
+		return func() { <-self.slots }, true
+	case <-self.closeNotify:
+		return noop, false
+	}
+}
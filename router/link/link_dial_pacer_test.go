@@ -0,0 +1,110 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package link
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/router/env"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LinkDialPacer(t *testing.T) {
+	t.Run("no pacing without a window", func(t *testing.T) {
+		req := require.New(t)
+		pacer := newLinkDialPacer(env.LinkDialPacingOptions{Concurrency: 1, Rate: 1}, make(chan struct{}))
+		req.False(pacer.isPacing())
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			release, ok := pacer.acquire()
+			req.True(ok)
+			defer release()
+		}
+		req.Less(time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("dials are spaced by rate", func(t *testing.T) {
+		req := require.New(t)
+		pacer := newLinkDialPacer(env.LinkDialPacingOptions{Window: time.Minute, Rate: 20}, make(chan struct{}))
+		req.True(pacer.isPacing())
+
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			release, ok := pacer.acquire()
+			req.True(ok)
+			release()
+		}
+		req.GreaterOrEqual(time.Since(start), 150*time.Millisecond)
+	})
+
+	t.Run("concurrency is limited", func(t *testing.T) {
+		req := require.New(t)
+		pacer := newLinkDialPacer(env.LinkDialPacingOptions{Window: time.Minute, Concurrency: 2}, make(chan struct{}))
+
+		release1, ok := pacer.acquire()
+		req.True(ok)
+		release2, ok := pacer.acquire()
+		req.True(ok)
+
+		acquired := make(chan struct{})
+		go func() {
+			release, _ := pacer.acquire()
+			release()
+			close(acquired)
+		}()
+
+		select {
+		case <-acquired:
+			req.Fail("dial should wait for a free slot")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		release1()
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			req.Fail("dial should start once a slot is free")
+		}
+		release2()
+	})
+
+	t.Run("close stops waiting dials", func(t *testing.T) {
+		req := require.New(t)
+		closeNotify := make(chan struct{})
+		pacer := newLinkDialPacer(env.LinkDialPacingOptions{Window: time.Minute, Concurrency: 1}, closeNotify)
+
+		release, ok := pacer.acquire()
+		req.True(ok)
+		defer release()
+
+		close(closeNotify)
+		_, ok = pacer.acquire()
+		req.False(ok)
+	})
+
+	t.Run("reconnect starts window", func(t *testing.T) {
+		req := require.New(t)
+		pacer := newLinkDialPacer(env.LinkDialPacingOptions{Window: time.Minute}, make(chan struct{}))
+		pacer.pacedUntil = time.Now().Add(-time.Second)
+		req.False(pacer.isPacing())
+
+		pacer.startWindow()
+		req.True(pacer.isPacing())
+	})
+}
@@ -97,7 +97,7 @@ func (self *linkDestUpdate) ApplyListenerChanges(registry *linkRegistryImpl, des
 
 	for _, listener := range self.listeners {
 		for _, dialer := range registry.env.GetXlinkDialers() {
-			if stringz.ContainsAny(listener.Groups, dialer.GetGroups()...) {
+			if stringz.ContainsAny(listener.Groups, registry.getDialerGroups(dialer)...) {
 				linkKey := registry.GetLinkKey(dialer.GetBinding(), listener.Protocol, self.id, listener.GetLocalBinding())
 
 				delete(currentLinkKeys, linkKey)
@@ -136,8 +136,7 @@ func (self *linkDestUpdate) ApplyListenerChanges(registry *linkRegistryImpl, des
 
 					// if link isn't established, try establishing now
 					if becameHealthy && existingLinkState.status != StatusEstablished {
-						existingLinkState.retryDelay = time.Duration(0)
-						existingLinkState.nextDial = time.Now()
+						existingLinkState.resetDialBackoff()
 						registry.evaluateLinkState(existingLinkState)
 					}
 				}
@@ -175,7 +174,7 @@ func (self *dialRequest) Handle(registry *linkRegistryImpl) {
 	}
 
 	for _, dialer := range registry.env.GetXlinkDialers() {
-		if stringz.ContainsAny(dialer.GetGroups(), GroupDefault) {
+		if stringz.ContainsAny(registry.getDialerGroups(dialer), GroupDefault) {
 			linkKey := registry.GetLinkKey(GroupDefault, self.dial.LinkProtocol, self.dial.RouterId, GroupDefault)
 
 			log := pfxlog.Logger().WithField("routerId", self.dial.RouterId).
@@ -209,8 +208,7 @@ func (self *dialRequest) Handle(registry *linkRegistryImpl) {
 			} else if existingLinkState.status != StatusEstablished {
 				log = log.WithField("linkId", existingLinkState.linkId)
 
-				existingLinkState.retryDelay = time.Duration(0)
-				existingLinkState.nextDial = time.Now()
+				existingLinkState.resetDialBackoff()
 				existingLinkState.allowedDials = 1
 
 				log.Info("dial request received for existing link, re-evaluating")
@@ -252,6 +250,7 @@ func (self *updateLinkStatusForLink) Handle(registry *linkRegistryImpl) {
 	if state.status == StatusEstablished {
 		state.connectedCount++
 		state.retryDelay = time.Duration(0)
+		state.consecutiveFailures = 0
 		state.ctrlsNotified = false
 		state.link = self.link
 		registry.triggerNotify()
@@ -332,13 +331,14 @@ func (self *inspectLinkStatesEvent) Handle(registry *linkRegistryImpl) {
 				Key:               state.linkKey,
 				Status:            state.status.String(),
 				DialAttempts:      state.dialAttempts.Load(),
+				DialFailures:      state.consecutiveFailures,
 				ConnectedCount:    state.connectedCount,
 				RetryDelay:        state.retryDelay.String(),
 				NextDial:          state.nextDial.Format(time.RFC3339),
 				TargetAddress:     state.listener.Address,
 				TargetGroups:      state.listener.Groups,
 				TargetBinding:     state.listener.LocalBinding,
-				DialerGroups:      state.dialer.GetGroups(),
+				DialerGroups:      registry.getDialerGroups(state.dialer),
 				DialerBinding:     state.dialer.GetBinding(),
 				CtrlsNotified:     state.ctrlsNotified,
 				EstablishedLinkId: establishedLinkId,
@@ -0,0 +1,61 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package link
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/common/linkgroups"
+	"github.com/openziti/ziti/router/xlink"
+)
+
+// UpdateLinkGroups replaces the link groups assigned to this router by the controller
+func (self *linkRegistryImpl) UpdateLinkGroups(groups []string) {
+	self.queueEvent(&updateLinkGroups{groups: groups})
+}
+
+// getDialerGroups returns the groups the given dialer belongs to, taking into account any link groups assigned
+// by the controller
+func (self *linkRegistryImpl) getDialerGroups(dialer xlink.Dialer) []string {
+	return linkgroups.Apply(dialer.GetGroups(), self.linkGroups.Load())
+}
+
+type updateLinkGroups struct {
+	groups []string
+}
+
+func (self *updateLinkGroups) Handle(registry *linkRegistryImpl) {
+	if linkgroups.Equal(registry.linkGroups.Load(), self.groups) {
+		return
+	}
+
+	registry.linkGroups.Store(self.groups)
+	pfxlog.Logger().WithField("groups", self.groups).Info("assigned link groups updated")
+
+	// re-evaluate which links should exist. Destinations only known from dial requests have no listeners, so
+	// there's nothing to compare against for those
+	for _, dest := range registry.destinations {
+		if dest.healthy && dest.listeners != nil {
+			update := &linkDestUpdate{
+				id:        dest.id,
+				version:   dest.version.Load(),
+				healthy:   true,
+				listeners: dest.listeners,
+			}
+			update.ApplyListenerChanges(registry, dest, false)
+		}
+	}
+}
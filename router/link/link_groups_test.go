@@ -0,0 +1,47 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package link
+
+import (
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_AssignedLinkGroups(t *testing.T) {
+	req := require.New(t)
+	registry, state := newTestBackoffState()
+
+	req.Equal([]string{GroupDefault}, registry.getDialerGroups(state.dialer))
+
+	registry.dialBackoffOverrides = map[string]*ctrl_pb.LinkGroupDialBackoff{
+		"dmz": {
+			Group:   "dmz",
+			Healthy: &ctrl_pb.DialBackoff{MinRetryIntervalMs: 5000},
+		},
+	}
+
+	req.Equal(time.Second, registry.getBackoffConfig(state).GetMinRetryInterval())
+
+	(&updateLinkGroups{groups: []string{"dmz"}}).Handle(registry)
+	req.Equal([]string{"dmz"}, registry.getDialerGroups(state.dialer))
+	req.Equal(5*time.Second, registry.getBackoffConfig(state).GetMinRetryInterval())
+
+	(&updateLinkGroups{}).Handle(registry)
+	req.Equal([]string{GroupDefault}, registry.getDialerGroups(state.dialer))
+}
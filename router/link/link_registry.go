@@ -22,12 +22,14 @@ import (
 	"github.com/michaelquigley/pfxlog"
 	"github.com/openziti/channel/v4"
 	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/foundation/v2/concurrenz"
 	"github.com/openziti/foundation/v2/debugz"
 	"github.com/openziti/foundation/v2/goroutines"
 	"github.com/openziti/identity"
 	"github.com/openziti/metrics"
 	"github.com/openziti/ziti/common/capabilities"
 	"github.com/openziti/ziti/common/inspect"
+	"github.com/openziti/ziti/common/linktls"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
 	"github.com/openziti/ziti/router/env"
 	"github.com/openziti/ziti/router/xlink"
@@ -44,6 +46,7 @@ type Env interface {
 	GetXlinkDialers() []xlink.Dialer
 	GetCloseNotify() <-chan struct{}
 	GetLinkDialerPool() goroutines.Pool
+	GetLinkDialPacingOptions() env.LinkDialPacingOptions
 	GetRateLimiterPool() goroutines.Pool
 	GetMetricsRegistry() metrics.UsageRegistry
 }
@@ -58,8 +61,17 @@ func NewLinkRegistry(routerEnv Env) xlink.Registry {
 		destinations:   map[string]*linkDest{},
 		linkStateQueue: &linkStateHeap{},
 		triggerNotifyC: make(chan struct{}, 1),
+		triggerSyncC:   make(chan struct{}, 1),
+		dialPacer:      newLinkDialPacer(routerEnv.GetLinkDialPacingOptions(), routerEnv.GetCloseNotify()),
 	}
 
+	// link state changes can't be reported while no controller is responsive, so resend them once one is
+	result.ctrls.AddChangeListener(env.CtrlEventListenerFunc(func(event env.CtrlEvent) {
+		if event.Type == env.ControllerResponsive {
+			result.triggerLinkStateSync()
+		}
+	}))
+
 	go result.run()
 	go result.runGcLinkMetricsLoop()
 
@@ -78,7 +90,17 @@ type linkRegistryImpl struct {
 	linkStateQueue   *linkStateHeap
 	events           chan event
 	triggerNotifyC   chan struct{}
+	triggerSyncC     chan struct{}
 	notifyInProgress atomic.Bool
+	dialPacer        *linkDialPacer
+
+	// dialBackoffOverrides are keyed by link group. They are only accessed from the event loop
+	dialBackoffOverrides map[string]*ctrl_pb.LinkGroupDialBackoff
+
+	tlsPolicy atomic.Pointer[linktls.Policy]
+
+	// linkGroups are the link groups assigned by the controller from the router's role attributes
+	linkGroups concurrenz.AtomicValue[[]string]
 }
 
 func (self *linkRegistryImpl) runGcLinkMetricsLoop() {
@@ -401,6 +423,7 @@ func (self *linkRegistryImpl) NotifyOfReconnect(ch channel.Channel) {
 	defer self.Unlock()
 
 	pfxlog.Logger().WithField("ctrlId", ch.Id()).Info("resending link states after reconnect")
+	self.dialPacer.startWindow()
 	alwaysSend := !capabilities.IsCapable(ch, capabilities.ControllerSingleRouterLinkSource)
 
 	var onComplete []func()
@@ -500,6 +523,8 @@ func (self *linkRegistryImpl) run() {
 			evt.Handle(self)
 		case <-self.triggerNotifyC:
 			self.notifyControllersOfLinks()
+		case <-self.triggerSyncC:
+			self.syncRequiredLinkStates()
 		case <-queueCheckTicker.C:
 			self.evaluateLinkStateQueue()
 			self.notifyControllersOfLinks()
@@ -519,6 +544,13 @@ func (self *linkRegistryImpl) triggerNotify() {
 	}
 }
 
+func (self *linkRegistryImpl) triggerLinkStateSync() {
+	select {
+	case self.triggerSyncC <- struct{}{}:
+	default:
+	}
+}
+
 func (self *linkRegistryImpl) evaluateLinkStateQueue() {
 	now := time.Now()
 	for len(*self.linkStateQueue) > 0 {
@@ -582,7 +614,11 @@ func (self *linkRegistryImpl) syncRequiredLinkStates() {
 
 			for _, ctrlId := range ctrlIds {
 				log := pfxlog.Logger().WithField("ctrlId", ctrlId).WithField("linkId", link.Id())
-				ctrlCh := self.ctrls.GetCtrlChannel(ctrlId)
+				ctrlCh, _ := self.ctrls.GetIfResponsive(ctrlId)
+				if ctrlCh == nil {
+					// resent when the controller reconnects or becomes responsive again
+					continue
+				}
 
 				err := self.env.GetRateLimiterPool().QueueOrError(func() {
 					if err := protobufs.MarshalTyped(message).WithTimeout(100 * time.Millisecond).Send(ctrlCh); err != nil {
@@ -604,7 +640,8 @@ func (self *linkRegistryImpl) syncRequiredLinkStates() {
 func (self *linkRegistryImpl) evaluateLinkState(state *linkState) {
 	log := pfxlog.Logger().WithField("key", state.linkKey)
 
-	couldDial := state.status != StatusEstablished && state.status != StatusDialing && state.nextDial.Before(time.Now())
+	couldDial := state.status != StatusEstablished && state.status != StatusDialing && state.status != StatusGaveUp &&
+		state.nextDial.Before(time.Now())
 
 	if couldDial && state.dialActive.CompareAndSwap(false, true) {
 		state.updateStatus(StatusDialing)
@@ -615,6 +652,12 @@ func (self *linkRegistryImpl) evaluateLinkState(state *linkState) {
 		err := self.env.GetLinkDialerPool().QueueOrError(func() {
 			defer state.dialActive.Store(false)
 
+			release, ok := self.dialPacer.acquire()
+			defer release()
+			if !ok {
+				return
+			}
+
 			link, _ := self.GetLink(state.linkKey)
 			if link != nil {
 				log.Info("link already present, attempting to mark established")
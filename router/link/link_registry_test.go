@@ -62,6 +62,10 @@ func (self *testEnv) GetLinkDialerPool() goroutines.Pool {
 	panic("implement me")
 }
 
+func (self *testEnv) GetLinkDialPacingOptions() env.LinkDialPacingOptions {
+	return env.LinkDialPacingOptions{}
+}
+
 func (self *testEnv) GetRateLimiterPool() goroutines.Pool {
 	panic("implement me")
 }
@@ -183,7 +187,7 @@ func newTestEnv() *testEnv {
 
 	ctrls := env.NewNetworkControllers(time.Second, func(address transport.Address, bindHandler channel.BindHandler) error {
 		return errors.New("implement me")
-	}, env.NewDefaultHeartbeatOptions())
+	}, env.NewDefaultHeartbeatOptions(), false)
 	return &testEnv{
 		metricsRegistry: metricsRegistry,
 		closeNotify:     closeNotify,
@@ -35,6 +35,7 @@ const (
 	StatusLinkFailed  linkStatus = "linkFailed"
 	StatusDestRemoved linkStatus = "destRemoved"
 	StatusEstablished linkStatus = "established"
+	StatusGaveUp      linkStatus = "gaveUp"
 )
 
 type linkStatus string
@@ -58,6 +59,7 @@ type linkDest struct {
 	healthy     bool
 	unhealthyAt time.Time
 	linkMap     map[string]*linkState
+	listeners   []*ctrl_pb.Listener
 }
 
 func (self *linkDest) update(update *linkDestUpdate) {
@@ -69,6 +71,7 @@ func (self *linkDest) update(update *linkDestUpdate) {
 
 	if update.healthy {
 		self.version.Store(update.version)
+		self.listeners = update.listeners
 	}
 }
 
@@ -78,21 +81,22 @@ type linkFault struct {
 }
 
 type linkState struct {
-	linkKey        string
-	linkId         string
-	status         linkStatus
-	dialAttempts   atomic.Uint64
-	connectedCount uint64
-	retryDelay     time.Duration
-	nextDial       time.Time
-	dest           *linkDest
-	listener       *ctrl_pb.Listener
-	dialer         xlink.Dialer
-	allowedDials   int64
-	ctrlsNotified  bool
-	linkFaults     []linkFault
-	dialActive     atomic.Bool
-	link           xlink.Xlink
+	linkKey             string
+	linkId              string
+	status              linkStatus
+	dialAttempts        atomic.Uint64
+	consecutiveFailures uint32
+	connectedCount      uint64
+	retryDelay          time.Duration
+	nextDial            time.Time
+	dest                *linkDest
+	listener            *ctrl_pb.Listener
+	dialer              xlink.Dialer
+	allowedDials        int64
+	ctrlsNotified       bool
+	linkFaults          []linkFault
+	dialActive          atomic.Bool
+	link                xlink.Xlink
 }
 
 func (self *linkState) updateStatus(status linkStatus) {
@@ -111,6 +115,17 @@ func (self *linkState) updateStatus(status linkStatus) {
 	}
 }
 
+// resetDialBackoff clears any accumulated backoff, so the link will be dialed at the next evaluation. If the
+// dialer had given up on the link, it will resume dialing.
+func (self *linkState) resetDialBackoff() {
+	self.retryDelay = time.Duration(0)
+	self.nextDial = time.Now()
+	self.consecutiveFailures = 0
+	if self.status == StatusGaveUp {
+		self.updateStatus(StatusPending)
+	}
+}
+
 func (self *linkState) GetLinkKey() string {
 	return self.linkKey
 }
@@ -192,12 +207,19 @@ func (self *linkState) dialFailed(registry *linkRegistryImpl, applyFailed bool)
 		return
 	}
 
-	backoffConfig := self.dialer.GetHealthyBackoffConfig()
-	if !self.dest.healthy {
-		backoffConfig = self.dialer.GetUnhealthyBackoffConfig()
+	backoffConfig := registry.getBackoffConfig(self)
+
+	self.consecutiveFailures++
+	if maxAttempts := backoffConfig.GetMaxAttempts(); maxAttempts > 0 && self.consecutiveFailures >= maxAttempts {
+		pfxlog.Logger().WithField("key", self.linkKey).
+			WithField("linkId", self.linkId).
+			WithField("consecutiveFailures", self.consecutiveFailures).
+			Warn("giving up on link dial, will retry when destination becomes healthy or dial is requested")
+		self.updateStatus(StatusGaveUp)
+		return
 	}
 
-	factor := backoffConfig.GetRetryBackoffFactor() + (rand.Float64() - 0.5)
+	factor := backoffConfig.GetRetryBackoffFactor() + (rand.Float64()*2-1)*backoffConfig.GetRetryJitter()
 	if factor < 1 {
 		factor = 1
 	}
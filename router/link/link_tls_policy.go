@@ -0,0 +1,43 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package link
+
+import (
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/common/linktls"
+)
+
+// UpdateTlsPolicy replaces the link TLS policy sent by the controller. The policy applies to links dialed or
+// accepted after the update. Established links are not renegotiated.
+func (self *linkRegistryImpl) UpdateTlsPolicy(policy *linktls.Policy) {
+	if policy.IsEmpty() {
+		policy = nil
+	}
+	self.tlsPolicy.Store(policy)
+
+	settings := policy.ToSettings()
+	pfxlog.Logger().
+		WithField("minVersion", settings.MinVersion).
+		WithField("maxVersion", settings.MaxVersion).
+		WithField("cipherSuites", settings.CipherSuites).
+		WithField("curvePreferences", settings.CurvePreferences).
+		Info("link tls policy updated")
+}
+
+func (self *linkRegistryImpl) GetTlsPolicy() *linktls.Policy {
+	return self.tlsPolicy.Load()
+}
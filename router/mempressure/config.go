@@ -0,0 +1,166 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package mempressure
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultCheckInterval     = 5 * time.Second
+	DefaultElevatedThreshold = 0.7
+	DefaultCriticalThreshold = 0.9
+	DefaultElevatedScale     = 0.5
+	DefaultCriticalScale     = 0.125
+)
+
+// Config controls how the router reacts to heap growth. When heap usage crosses a threshold, fraction of Limit,
+// the xgress window and buffer limits of newly bound circuits are scaled down by the matching scale factor.
+type Config struct {
+	Enabled bool
+
+	// Limit is the heap size, in bytes, that thresholds are relative to. If zero, the runtime memory limit
+	// (GOMEMLIMIT) is used. If neither is set, the monitor only reports heap usage and never applies backoff
+	Limit int64
+
+	// CheckInterval is how often heap usage is sampled
+	CheckInterval time.Duration
+
+	ElevatedThreshold float64
+	CriticalThreshold float64
+	ElevatedScale     float64
+	CriticalScale     float64
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		CheckInterval:     DefaultCheckInterval,
+		ElevatedThreshold: DefaultElevatedThreshold,
+		CriticalThreshold: DefaultCriticalThreshold,
+		ElevatedScale:     DefaultElevatedScale,
+		CriticalScale:     DefaultCriticalScale,
+	}
+}
+
+// LoadConfig parses a memory pressure config section, found at the given path. Example:
+//
+//	memoryPressure:
+//	  enabled: true
+//	  limit: 2147483648
+//	  checkInterval: 5s
+//	  elevated:
+//	    threshold: 0.7
+//	    scale: 0.5
+//	  critical:
+//	    threshold: 0.9
+//	    scale: 0.125
+func LoadConfig(value interface{}, path string) (*Config, error) {
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid %s configuration, expected map, got %T", path, value)
+	}
+
+	result := DefaultConfig()
+	result.Enabled = true
+
+	if value, found := submap["enabled"]; found {
+		enabled, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid %s.enabled [%v], must be a boolean", path, value)
+		}
+		result.Enabled = enabled
+	}
+
+	if value, found := submap["limit"]; found {
+		limit, ok := value.(int)
+		if !ok || limit < 0 {
+			return nil, errors.Errorf("invalid %s.limit [%v], must be a non-negative number of bytes", path, value)
+		}
+		result.Limit = int64(limit)
+	}
+
+	if value, found := submap["checkInterval"]; found {
+		interval, err := time.ParseDuration(fmt.Sprintf("%v", value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s.checkInterval [%v]", path, value)
+		}
+		if interval < 100*time.Millisecond {
+			return nil, errors.Errorf("invalid %s.checkInterval [%v], must be at least 100ms", path, value)
+		}
+		result.CheckInterval = interval
+	}
+
+	var err error
+	if value, found := submap["elevated"]; found {
+		if result.ElevatedThreshold, result.ElevatedScale, err = loadLevel(value, path+".elevated", result.ElevatedThreshold, result.ElevatedScale); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, found := submap["critical"]; found {
+		if result.CriticalThreshold, result.CriticalScale, err = loadLevel(value, path+".critical", result.CriticalThreshold, result.CriticalScale); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.CriticalThreshold <= result.ElevatedThreshold {
+		return nil, errors.Errorf("invalid %s, critical.threshold [%v] must be greater than elevated.threshold [%v]",
+			path, result.CriticalThreshold, result.ElevatedThreshold)
+	}
+
+	if result.CriticalScale > result.ElevatedScale {
+		return nil, errors.Errorf("invalid %s, critical.scale [%v] may not be greater than elevated.scale [%v]",
+			path, result.CriticalScale, result.ElevatedScale)
+	}
+
+	return result, nil
+}
+
+func loadLevel(value interface{}, path string, threshold, scale float64) (float64, float64, error) {
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return 0, 0, errors.Errorf("invalid %s configuration, expected map, got %T", path, value)
+	}
+
+	if value, found := submap["threshold"]; found {
+		v, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "invalid %s.threshold [%v]", path, value)
+		}
+		if v <= 0 || v > 1 {
+			return 0, 0, errors.Errorf("invalid %s.threshold [%v], must be greater than 0 and at most 1", path, value)
+		}
+		threshold = v
+	}
+
+	if value, found := submap["scale"]; found {
+		v, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "invalid %s.scale [%v]", path, value)
+		}
+		if v <= 0 || v > 1 {
+			return 0, 0, errors.Errorf("invalid %s.scale [%v], must be greater than 0 and at most 1", path, value)
+		}
+		scale = v
+	}
+
+	return threshold, scale, nil
+}
@@ -0,0 +1,279 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package mempressure
+
+import (
+	"math"
+	"runtime/debug"
+	runtimemetrics "runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/metrics"
+	"github.com/openziti/sdk-golang/xgress"
+)
+
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+type Level int32
+
+const (
+	LevelNormal Level = iota
+	LevelElevated
+	LevelCritical
+)
+
+func (self Level) String() string {
+	switch self {
+	case LevelElevated:
+		return "elevated"
+	case LevelCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// Monitor periodically samples heap usage and classifies it into a pressure level. While the level is above
+// normal, the xgress transmit window and receive buffer limits of circuits are shrunk, so that a router carrying
+// many circuits trades throughput for bounded memory growth, rather than running out of memory.
+//
+// Circuits registered with Bind get limits for the current level when they're bound, and have their limits
+// updated whenever the level changes, until they close.
+type Monitor struct {
+	config    *Config
+	limit     int64
+	level     atomic.Int32
+	heapBytes atomic.Int64
+	scaled    atomic.Int64
+	sample    []runtimemetrics.Sample
+
+	circuitsLock sync.Mutex
+	circuits     map[*xgress.Xgress]*boundCircuit
+}
+
+// boundCircuit holds the limits a circuit was bound with, along with the circuit's own copy of its options, which
+// is adjusted as the pressure level changes
+type boundCircuit struct {
+	base    xgress.Options
+	options *xgress.Options
+}
+
+func NewMonitor(config *Config) *Monitor {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	result := &Monitor{
+		config:   config,
+		limit:    config.Limit,
+		sample:   []runtimemetrics.Sample{{Name: heapObjectsMetric}},
+		circuits: map[*xgress.Xgress]*boundCircuit{},
+	}
+
+	if result.limit == 0 {
+		if runtimeLimit := debug.SetMemoryLimit(-1); runtimeLimit != math.MaxInt64 {
+			result.limit = runtimeLimit
+		}
+	}
+
+	return result
+}
+
+// Start registers the memory pressure metrics and, if enabled, begins sampling heap usage until closeNotify is
+// closed
+func (self *Monitor) Start(registry metrics.Registry, closeNotify <-chan struct{}) {
+	registry.FuncGauge("memory.heap_bytes", func() int64 {
+		return self.heapBytes.Load()
+	})
+	registry.FuncGauge("memory.pressure.level", func() int64 {
+		return int64(self.level.Load())
+	})
+	registry.FuncGauge("memory.pressure.window_scale_pct", func() int64 {
+		return int64(math.Round(self.GetScale() * 100))
+	})
+	registry.FuncGauge("memory.pressure.scaled_binds", func() int64 {
+		return self.scaled.Load()
+	})
+	registry.FuncGauge("memory.pressure.bound_circuits", func() int64 {
+		self.circuitsLock.Lock()
+		defer self.circuitsLock.Unlock()
+		return int64(len(self.circuits))
+	})
+
+	if !self.config.Enabled {
+		return
+	}
+
+	if self.limit <= 0 {
+		pfxlog.Logger().Warn("memory pressure backoff enabled, but no limit is configured and GOMEMLIMIT is not set, backoff will not be applied")
+	} else {
+		pfxlog.Logger().WithField("limit", self.limit).Info("memory pressure backoff enabled")
+	}
+
+	self.check()
+	go self.run(closeNotify)
+}
+
+func (self *Monitor) run(closeNotify <-chan struct{}) {
+	ticker := time.NewTicker(self.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			self.check()
+		case <-closeNotify:
+			return
+		}
+	}
+}
+
+func (self *Monitor) check() {
+	runtimemetrics.Read(self.sample)
+	if self.sample[0].Value.Kind() != runtimemetrics.KindUint64 {
+		return
+	}
+	self.update(int64(self.sample[0].Value.Uint64()))
+}
+
+func (self *Monitor) update(heapBytes int64) {
+	self.heapBytes.Store(heapBytes)
+
+	level := self.classify(heapBytes)
+	if prev := Level(self.level.Swap(int32(level))); prev != level {
+		pfxlog.Logger().WithField("heapBytes", heapBytes).
+			WithField("limit", self.limit).
+			WithField("previous", prev.String()).
+			WithField("level", level.String()).
+			Info("memory pressure level changed")
+		self.updateCircuitLimits()
+	}
+}
+
+func (self *Monitor) classify(heapBytes int64) Level {
+	if !self.config.Enabled || self.limit <= 0 {
+		return LevelNormal
+	}
+
+	usage := float64(heapBytes) / float64(self.limit)
+	if usage >= self.config.CriticalThreshold {
+		return LevelCritical
+	}
+	if usage >= self.config.ElevatedThreshold {
+		return LevelElevated
+	}
+	return LevelNormal
+}
+
+func (self *Monitor) GetLevel() Level {
+	return Level(self.level.Load())
+}
+
+// GetScale returns the factor currently applied to xgress window and buffer limits
+func (self *Monitor) GetScale() float64 {
+	switch self.GetLevel() {
+	case LevelElevated:
+		return self.config.ElevatedScale
+	case LevelCritical:
+		return self.config.CriticalScale
+	default:
+		return 1
+	}
+}
+
+// ApplyLimits returns the options a newly bound circuit should use. When there is no memory pressure the given
+// options are returned unchanged. Otherwise, a copy is returned with the transmit window and receive buffer limits
+// scaled down. Limits are never reduced below the configured minimum transmit window size.
+func (self *Monitor) ApplyLimits(options *xgress.Options) *xgress.Options {
+	scale := self.GetScale()
+	if options == nil || scale >= 1 {
+		return options
+	}
+
+	result := *options
+	result.TxPortalMaxSize = scaleSize(options.TxPortalMaxSize, scale, options.TxPortalMinSize)
+	result.TxPortalStartSize = min(scaleSize(options.TxPortalStartSize, scale, options.TxPortalMinSize), result.TxPortalMaxSize)
+	result.RxBufferSize = scaleSize(options.RxBufferSize, scale, options.TxPortalMinSize)
+	self.scaled.Add(1)
+	return &result
+}
+
+// Bind applies the current limits to a newly bound xgress and, if the monitor is enabled, tracks it so that its
+// limits follow later pressure level changes until it closes. The xgress is given its own copy of its options, so
+// that other circuits sharing the same options aren't affected.
+func (self *Monitor) Bind(x *xgress.Xgress) {
+	if !self.config.Enabled || x.Options == nil {
+		return
+	}
+
+	circuit := &boundCircuit{
+		base: *x.Options,
+	}
+
+	self.circuitsLock.Lock()
+	circuit.options = self.ApplyLimits(x.Options)
+	if circuit.options == x.Options {
+		options := *x.Options
+		circuit.options = &options
+	}
+	x.Options = circuit.options
+	self.circuits[x] = circuit
+	self.circuitsLock.Unlock()
+
+	x.AddCloseHandler(xgress.CloseHandlerF(self.unbind))
+}
+
+func (self *Monitor) unbind(x *xgress.Xgress) {
+	self.circuitsLock.Lock()
+	defer self.circuitsLock.Unlock()
+	delete(self.circuits, x)
+}
+
+// updateCircuitLimits sets the limits of bound circuits for the current pressure level. The xgress reads these
+// limits without synchronization, so they are only changed with atomic stores, and the xgress sees either the old
+// or the new limit. A shrunk transmit window takes effect as the next acks arrive.
+func (self *Monitor) updateCircuitLimits() {
+	scale := self.GetScale()
+
+	self.circuitsLock.Lock()
+	defer self.circuitsLock.Unlock()
+
+	for _, circuit := range self.circuits {
+		txPortalMaxSize := circuit.base.TxPortalMaxSize
+		rxBufferSize := circuit.base.RxBufferSize
+		if scale < 1 {
+			txPortalMaxSize = scaleSize(txPortalMaxSize, scale, circuit.base.TxPortalMinSize)
+			rxBufferSize = scaleSize(rxBufferSize, scale, circuit.base.TxPortalMinSize)
+		}
+		atomic.StoreUint32(&circuit.options.TxPortalMaxSize, txPortalMaxSize)
+		atomic.StoreUint32(&circuit.options.RxBufferSize, rxBufferSize)
+	}
+}
+
+func scaleSize(size uint32, scale float64, floor uint32) uint32 {
+	result := uint32(float64(size) * scale)
+	if result < floor {
+		result = floor
+	}
+	if result > size {
+		result = size
+	}
+	return result
+}
@@ -0,0 +1,168 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package mempressure
+
+import (
+	"testing"
+
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevels(t *testing.T) {
+	req := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.Limit = 1000
+	monitor := NewMonitor(cfg)
+
+	monitor.update(100)
+	req.Equal(LevelNormal, monitor.GetLevel())
+	req.Equal(1.0, monitor.GetScale())
+
+	monitor.update(700)
+	req.Equal(LevelElevated, monitor.GetLevel())
+	req.Equal(DefaultElevatedScale, monitor.GetScale())
+
+	monitor.update(950)
+	req.Equal(LevelCritical, monitor.GetLevel())
+	req.Equal(DefaultCriticalScale, monitor.GetScale())
+
+	monitor.update(200)
+	req.Equal(LevelNormal, monitor.GetLevel())
+}
+
+func TestDisabledNeverApplies(t *testing.T) {
+	req := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Limit = 1000
+	monitor := NewMonitor(cfg)
+
+	monitor.update(2000)
+	req.Equal(LevelNormal, monitor.GetLevel())
+
+	options := xgress.DefaultOptions()
+	req.True(options == monitor.ApplyLimits(options))
+}
+
+func TestApplyLimits(t *testing.T) {
+	req := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.Limit = 1000
+	monitor := NewMonitor(cfg)
+
+	options := xgress.DefaultOptions()
+	req.True(options == monitor.ApplyLimits(options))
+
+	monitor.update(750)
+	scaled := monitor.ApplyLimits(options)
+	req.False(options == scaled)
+	req.Equal(options.TxPortalMaxSize/2, scaled.TxPortalMaxSize)
+	req.Equal(options.RxBufferSize/2, scaled.RxBufferSize)
+	req.LessOrEqual(scaled.TxPortalStartSize, scaled.TxPortalMaxSize)
+	req.Equal(options.TxPortalMinSize, scaled.TxPortalMinSize)
+
+	// the shared options must not be modified
+	req.Equal(xgress.DefaultOptions().TxPortalMaxSize, options.TxPortalMaxSize)
+
+	options.TxPortalMinSize = options.TxPortalMaxSize / 4
+	monitor.update(990)
+	scaled = monitor.ApplyLimits(options)
+	req.Equal(options.TxPortalMinSize, scaled.TxPortalMaxSize)
+	req.Equal(options.TxPortalMinSize, scaled.RxBufferSize)
+}
+
+func TestBoundCircuitsFollowLevel(t *testing.T) {
+	req := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.Limit = 1000
+	monitor := NewMonitor(cfg)
+
+	options := xgress.DefaultOptions()
+	x1 := xgress.NewXgress("c1", "ctrl", "a1", nil, xgress.Initiator, options, nil)
+	x2 := xgress.NewXgress("c2", "ctrl", "a2", nil, xgress.Terminator, options, nil)
+
+	monitor.Bind(x1)
+	req.False(options == x1.Options)
+	req.Equal(options.TxPortalMaxSize, x1.Options.TxPortalMaxSize)
+
+	monitor.update(750)
+	monitor.Bind(x2)
+	req.Equal(options.TxPortalMaxSize/2, x1.Options.TxPortalMaxSize)
+	req.Equal(options.RxBufferSize/2, x1.Options.RxBufferSize)
+	req.Equal(options.TxPortalMaxSize/2, x2.Options.TxPortalMaxSize)
+
+	monitor.update(990)
+	req.Equal(uint32(float64(options.TxPortalMaxSize)*DefaultCriticalScale), x1.Options.TxPortalMaxSize)
+	req.Equal(uint32(float64(options.RxBufferSize)*DefaultCriticalScale), x2.Options.RxBufferSize)
+
+	// closed circuits are no longer updated
+	monitor.unbind(x2)
+	monitor.update(100)
+	req.Equal(options.TxPortalMaxSize, x1.Options.TxPortalMaxSize)
+	req.Equal(options.RxBufferSize, x1.Options.RxBufferSize)
+	req.Equal(uint32(float64(options.RxBufferSize)*DefaultCriticalScale), x2.Options.RxBufferSize)
+
+	// the shared options must not be modified
+	req.Equal(xgress.DefaultOptions().TxPortalMaxSize, options.TxPortalMaxSize)
+}
+
+func TestBindWhenDisabled(t *testing.T) {
+	req := require.New(t)
+
+	monitor := NewMonitor(DefaultConfig())
+	options := xgress.DefaultOptions()
+	x := xgress.NewXgress("c1", "ctrl", "a1", nil, xgress.Initiator, options, nil)
+	monitor.Bind(x)
+	req.True(options == x.Options)
+	req.Empty(monitor.circuits)
+}
+
+func TestLoadConfig(t *testing.T) {
+	req := require.New(t)
+
+	cfg, err := LoadConfig(map[interface{}]interface{}{
+		"limit":         1 << 30,
+		"checkInterval": "1s",
+		"elevated": map[interface{}]interface{}{
+			"threshold": 0.6,
+		},
+		"critical": map[interface{}]interface{}{
+			"scale": 0.25,
+		},
+	}, "memoryPressure")
+	req.NoError(err)
+	req.True(cfg.Enabled)
+	req.Equal(int64(1<<30), cfg.Limit)
+	req.Equal(0.6, cfg.ElevatedThreshold)
+	req.Equal(DefaultElevatedScale, cfg.ElevatedScale)
+	req.Equal(DefaultCriticalThreshold, cfg.CriticalThreshold)
+	req.Equal(0.25, cfg.CriticalScale)
+
+	_, err = LoadConfig(map[interface{}]interface{}{
+		"elevated": map[interface{}]interface{}{
+			"threshold": 0.95,
+		},
+	}, "memoryPressure")
+	req.Error(err)
+}
@@ -0,0 +1,352 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/metrics"
+	"github.com/openziti/xweb/v2"
+	"github.com/openziti/ziti/router/xlink"
+)
+
+const (
+	PrometheusBinding = "metrics"
+	prometheusPrefix  = "ziti_"
+)
+
+var summaryQuantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// linkSingleSegmentMetrics are the link metric names which are a single segment after the link id. Other link
+// metrics, such as link.<id>.tx.bytesrate, have two segments.
+var linkSingleSegmentMetrics = map[string]bool{
+	"latency":                       true,
+	"queue_time":                    true,
+	"ack_duplicates":                true,
+	"blocked_by_local_window_rate":  true,
+	"blocked_by_remote_window_rate": true,
+	"tx_unacked_payload_bytes":      true,
+}
+
+// meanGaugeFamilies are the histogram families which are also reported as a gauge of their mean, so the current
+// value can be graphed or alerted on without picking a quantile
+var meanGaugeFamilies = map[string]bool{
+	"ziti_link_latency": true,
+}
+
+var _ xweb.ApiHandlerFactory = &PrometheusApiFactory{}
+
+// NewPrometheusApiFactory creates an xweb api factory which exposes the given registry in the prometheus text
+// exposition format. The link registry is optional and, if provided, is used to label link metrics with the
+// destination router id.
+func NewPrometheusApiFactory(registry metrics.Registry, links xlink.Registry) *PrometheusApiFactory {
+	return &PrometheusApiFactory{
+		registry: registry,
+		links:    links,
+	}
+}
+
+type PrometheusApiFactory struct {
+	registry metrics.Registry
+	links    xlink.Registry
+}
+
+func (factory *PrometheusApiFactory) Validate(*xweb.InstanceConfig) error {
+	return nil
+}
+
+func (factory *PrometheusApiFactory) Binding() string {
+	return PrometheusBinding
+}
+
+func (factory *PrometheusApiFactory) New(_ *xweb.ServerConfig, options map[interface{}]interface{}) (xweb.ApiHandler, error) {
+	return &PrometheusApiHandler{
+		registry: factory.registry,
+		links:    factory.links,
+		options:  options,
+	}, nil
+}
+
+type PrometheusApiHandler struct {
+	registry metrics.Registry
+	links    xlink.Registry
+	options  map[interface{}]interface{}
+}
+
+func (self *PrometheusApiHandler) Binding() string {
+	return PrometheusBinding
+}
+
+func (self *PrometheusApiHandler) Options() map[interface{}]interface{} {
+	return self.options
+}
+
+func (self *PrometheusApiHandler) RootPath() string {
+	return "/metrics"
+}
+
+func (self *PrometheusApiHandler) IsHandler(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, self.RootPath())
+}
+
+func (self *PrometheusApiHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	writer := NewPrometheusWriter(self.registry.SourceId(), self.getLinkDestination)
+	self.registry.AcceptVisitor(writer)
+
+	if err := writer.Render(w); err != nil {
+		pfxlog.Logger().WithError(err).Error("failure writing prometheus metrics")
+	}
+}
+
+func (self *PrometheusApiHandler) getLinkDestination(linkId string) (string, bool) {
+	if self.links == nil {
+		return "", false
+	}
+	if link, found := self.links.GetLinkById(linkId); found {
+		return link.DestinationId(), true
+	}
+	return "", false
+}
+
+type promSample struct {
+	suffix string
+	labels string
+	value  float64
+}
+
+type promFamily struct {
+	name       string
+	metricType string
+	samples    []promSample
+}
+
+// PrometheusWriter is a metrics.Visitor which collects metrics and renders them in the prometheus text
+// exposition format. Entity ids embedded in metric names, such as link and controller ids, are extracted
+// into labels so that metrics of the same kind share a single metric family.
+type PrometheusWriter struct {
+	sourceId       string
+	linkDestLookup func(linkId string) (string, bool)
+	families       map[string]*promFamily
+}
+
+func NewPrometheusWriter(sourceId string, linkDestLookup func(linkId string) (string, bool)) *PrometheusWriter {
+	return &PrometheusWriter{
+		sourceId:       sourceId,
+		linkDestLookup: linkDestLookup,
+		families:       map[string]*promFamily{},
+	}
+}
+
+func (self *PrometheusWriter) VisitGauge(name string, gauge metrics.Gauge) {
+	familyName, labels := self.mapName(name)
+	self.add(familyName, "gauge", "", labels, float64(gauge.Value()))
+}
+
+func (self *PrometheusWriter) VisitMeter(name string, meter metrics.Meter) {
+	familyName, labels := self.mapName(name)
+	self.add(familyName+"_total", "counter", "", labels, float64(meter.Count()))
+	self.add(familyName+"_m1_rate", "gauge", "", labels, meter.Rate1())
+}
+
+func (self *PrometheusWriter) VisitHistogram(name string, histogram metrics.Histogram) {
+	familyName, labels := self.mapName(name)
+	self.addSummary(familyName, labels, histogram.Percentiles(summaryQuantiles), histogram.Sum(), histogram.Count())
+	if meanGaugeFamilies[familyName] && histogram.Count() > 0 {
+		self.add(familyName+"_mean", "gauge", "", labels, histogram.Mean())
+	}
+}
+
+func (self *PrometheusWriter) VisitTimer(name string, timer metrics.Timer) {
+	familyName, labels := self.mapName(name)
+	self.addSummary(familyName, labels, timer.Percentiles(summaryQuantiles), timer.Sum(), timer.Count())
+}
+
+func (self *PrometheusWriter) addSummary(familyName string, labels map[string]string, percentiles []float64, sum, count int64) {
+	for idx, quantile := range summaryQuantiles {
+		quantileLabels := map[string]string{"quantile": strconv.FormatFloat(quantile, 'g', -1, 64)}
+		for k, v := range labels {
+			quantileLabels[k] = v
+		}
+		self.add(familyName, "summary", "", quantileLabels, percentiles[idx])
+	}
+	self.add(familyName, "summary", "_sum", labels, float64(sum))
+	self.add(familyName, "summary", "_count", labels, float64(count))
+}
+
+func (self *PrometheusWriter) add(familyName, metricType, suffix string, labels map[string]string, value float64) {
+	family, found := self.families[familyName]
+	if !found {
+		family = &promFamily{
+			name:       familyName,
+			metricType: metricType,
+		}
+		self.families[familyName] = family
+	}
+
+	family.samples = append(family.samples, promSample{
+		suffix: suffix,
+		labels: formatLabels(labels),
+		value:  value,
+	})
+}
+
+// mapName converts a registry metric name into a prometheus metric family name and a set of labels. It follows
+// the same conventions as the controller metrics mappers, so that metrics scraped directly from the router line
+// up with those produced by the controller's metrics pipeline.
+func (self *PrometheusWriter) mapName(name string) (string, map[string]string) {
+	labels := map[string]string{
+		"source_id": self.sourceId,
+	}
+
+	if strings.HasPrefix(name, "ctrl.") {
+		if parts := strings.Split(name, ":"); len(parts) > 1 {
+			name = parts[0]
+			labels["ctrl_id"] = parts[1]
+		}
+	} else if strings.HasPrefix(name, "link.") {
+		suffixLen := 2
+		if linkSingleSegmentMetrics[name[strings.LastIndex(name, ".")+1:]] {
+			suffixLen = 1
+		}
+
+		if metricName, linkId, ok := extractId(name, "link.", suffixLen); ok {
+			name = metricName
+			labels["link_id"] = linkId
+			if self.linkDestLookup != nil {
+				if destRouterId, found := self.linkDestLookup(linkId); found {
+					labels["dest_router_id"] = destRouterId
+				}
+			}
+		}
+	}
+
+	return sanitizeMetricName(name), labels
+}
+
+// Render writes the collected metrics to w in the prometheus text exposition format, with families sorted by name
+func (self *PrometheusWriter) Render(w io.Writer) error {
+	var familyNames []string
+	for k := range self.families {
+		familyNames = append(familyNames, k)
+	}
+	sort.Strings(familyNames)
+
+	out := bufio.NewWriter(w)
+	for _, familyName := range familyNames {
+		family := self.families[familyName]
+		sort.SliceStable(family.samples, func(i, j int) bool {
+			if family.samples[i].labels == family.samples[j].labels {
+				return family.samples[i].suffix < family.samples[j].suffix
+			}
+			return family.samples[i].labels < family.samples[j].labels
+		})
+
+		if _, err := fmt.Fprintf(out, "# HELP %[1]s %[1]s\n# TYPE %[1]s %[2]s\n", family.name, family.metricType); err != nil {
+			return err
+		}
+
+		for _, sample := range family.samples {
+			if _, err := fmt.Fprintf(out, "%s%s%s %s\n", family.name, sample.suffix, sample.labels, formatValue(sample.value)); err != nil {
+				return err
+			}
+		}
+	}
+	return out.Flush()
+}
+
+func extractId(name string, prefix string, suffixLen int) (string, string, bool) {
+	rest := strings.TrimPrefix(name, prefix)
+	vals := strings.Split(rest, ".")
+	if len(vals) <= suffixLen {
+		return "", "", false
+	}
+	entityId := strings.Join(vals[:len(vals)-suffixLen], ".")
+	return prefix + rest[len(entityId)+1:], entityId, true
+}
+
+func sanitizeMetricName(name string) string {
+	var sb strings.Builder
+	sb.WriteString(prometheusPrefix)
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			sb.WriteRune(r)
+		} else if r != ':' {
+			sb.WriteRune('_')
+		}
+	}
+	result := sb.String()
+
+	// Prometheus complains about metrics ending in _count, so "fix" that, the same way the controller does
+	if strings.HasSuffix(result, "_count") {
+		result = strings.TrimSuffix(result, "_count") + "_c"
+	}
+	return result
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var keys []string
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteRune('{')
+	for idx, k := range keys {
+		if idx > 0 {
+			sb.WriteRune(',')
+		}
+		sb.WriteString(k)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(labels[k]))
+		sb.WriteRune('"')
+	}
+	sb.WriteRune('}')
+	return sb.String()
+}
+
+func escapeLabelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,89 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/openziti/metrics"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusWriter(t *testing.T) {
+	req := require.New(t)
+
+	registry := metrics.NewRegistry("router1", nil)
+	registry.Gauge("forwarder.circuits").Update(3)
+	registry.Histogram("link.abc.latency").Update(100)
+	registry.Histogram("link.def.latency").Update(200)
+	registry.Meter("link.abc.tx.bytesrate").Mark(10)
+	registry.Histogram("ctrl.tx.msgsize:ctrl1").Update(5)
+	registry.FuncGauge("link.abc.tx_unacked_payload_bytes", func() int64 { return 2048 })
+
+	xgressMetrics := xgress.NewMetrics(registry)
+	xgressMetrics.BufferBlockedByLocalWindow()
+	xgressMetrics.SendPayloadBuffered(1024)
+
+	writer := NewPrometheusWriter(registry.SourceId(), func(linkId string) (string, bool) {
+		if linkId == "abc" {
+			return "router2", true
+		}
+		return "", false
+	})
+	registry.AcceptVisitor(writer)
+
+	buf := &bytes.Buffer{}
+	req.NoError(writer.Render(buf))
+	out := buf.String()
+
+	req.Contains(out, "# TYPE ziti_forwarder_circuits gauge\n")
+	req.Contains(out, `ziti_forwarder_circuits{source_id="router1"} 3`+"\n")
+
+	req.Contains(out, "# TYPE ziti_link_latency summary\n")
+	req.Contains(out, `ziti_link_latency_count{dest_router_id="router2",link_id="abc",source_id="router1"} 1`+"\n")
+	req.Contains(out, `ziti_link_latency_count{link_id="def",source_id="router1"} 1`+"\n")
+	req.Contains(out, `ziti_link_latency{dest_router_id="router2",link_id="abc",quantile="0.5",source_id="router1"} 100`+"\n")
+
+	req.Contains(out, "# TYPE ziti_link_tx_bytesrate_total counter\n")
+	req.Contains(out, `ziti_link_tx_bytesrate_total{dest_router_id="router2",link_id="abc",source_id="router1"} 10`+"\n")
+
+	req.Contains(out, `ziti_ctrl_tx_msgsize_sum{ctrl_id="ctrl1",source_id="router1"} 5`+"\n")
+
+	req.Contains(out, "# TYPE ziti_link_latency_mean gauge\n")
+	req.Contains(out, `ziti_link_latency_mean{dest_router_id="router2",link_id="abc",source_id="router1"} 100`+"\n")
+	req.Contains(out, `ziti_link_latency_mean{link_id="def",source_id="router1"} 200`+"\n")
+
+	req.Contains(out, "# TYPE ziti_link_tx_unacked_payload_bytes gauge\n")
+	req.Contains(out, `ziti_link_tx_unacked_payload_bytes{dest_router_id="router2",link_id="abc",source_id="router1"} 2048`+"\n")
+
+	req.Contains(out, "# TYPE ziti_xgress_tx_unacked_payload_bytes gauge\n")
+	req.Contains(out, `ziti_xgress_tx_unacked_payload_bytes{source_id="router1"} 1024`+"\n")
+	req.Contains(out, `ziti_xgress_tx_unacked_payloads{source_id="router1"} 1`+"\n")
+	req.Contains(out, `ziti_xgress_blocked_by_local_window{source_id="router1"} 1`+"\n")
+
+	// each family should only have a single type declaration
+	req.Equal(1, bytes.Count(buf.Bytes(), []byte("# TYPE ziti_link_latency summary")))
+}
+
+func TestSanitizeMetricName(t *testing.T) {
+	req := require.New(t)
+	req.Equal("ziti_pool_link_dialer_queue_size", sanitizeMetricName("pool.link.dialer.queue_size"))
+	req.Equal("ziti_xgress_acks_c", sanitizeMetricName("xgress.acks.count"))
+	req.Equal("ziti_ctrl_tx_msgsize", sanitizeMetricName("ctrl.tx.msgsize:"))
+}
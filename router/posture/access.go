@@ -1,11 +1,13 @@
 package posture
 
 import (
+	"errors"
 	"fmt"
 	"github.com/michaelquigley/pfxlog"
 	"github.com/openziti/ziti/common"
 	"github.com/openziti/ziti/common/eid"
 	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	"time"
 )
 
 func HasAccess(rdm *common.RouterDataModel, identityId string, serviceId string, data *InstanceData, policyType edge_ctrl_pb.PolicyType) (*common.ServicePolicy, error) {
@@ -43,6 +45,12 @@ func IsPassing(accessPolicies *common.AccessPolicies, data *InstanceData) (*comm
 			Errors: []error{},
 		}
 
+		if !policy.IsScheduleActive(time.Now()) {
+			policyErr.Errors = append(policyErr.Errors, errors.New("service policy is outside its schedule"))
+			*errs = append(*errs, policyErr)
+			continue
+		}
+
 		policy.PostureChecks.IterCb(func(postureCheckId string, _ struct{}) {
 			postureCheck, ok := accessPolicies.PostureChecks[postureCheckId]
 
@@ -0,0 +1,75 @@
+package posture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/common"
+	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newTestServicePolicy(id string) *common.ServicePolicy {
+	return &common.ServicePolicy{
+		DataStateServicePolicy: &edge_ctrl_pb.DataState_ServicePolicy{
+			Id:         id,
+			Name:       id,
+			PolicyType: edge_ctrl_pb.PolicyType_DialPolicy,
+		},
+		Services:      cmap.New[struct{}](),
+		PostureChecks: cmap.New[struct{}](),
+	}
+}
+
+func TestIsPassingSchedules(t *testing.T) {
+	expired := newTestServicePolicy("expired")
+	expired.ScheduleNotAfter = timestamppb.New(time.Now().Add(-time.Hour))
+
+	current := newTestServicePolicy("current")
+	current.ScheduleNotBefore = timestamppb.New(time.Now().Add(-time.Hour))
+	current.ScheduleWindows = []string{"*"}
+
+	unscheduled := newTestServicePolicy("unscheduled")
+
+	t.Run("policies outside their schedule don't grant access", func(t *testing.T) {
+		req := require.New(t)
+		grantingPolicy, errs := IsPassing(&common.AccessPolicies{Policies: []*common.ServicePolicy{expired}}, nil)
+		req.Nil(grantingPolicy)
+		req.NotNil(errs)
+		req.Len(*errs, 1)
+		req.Equal("expired", (*errs)[0].Id)
+	})
+
+	t.Run("policies inside their schedule grant access", func(t *testing.T) {
+		req := require.New(t)
+		grantingPolicy, _ := IsPassing(&common.AccessPolicies{Policies: []*common.ServicePolicy{expired, current}}, nil)
+		req.Equal(current, grantingPolicy)
+	})
+
+	t.Run("policies without a schedule grant access", func(t *testing.T) {
+		req := require.New(t)
+		grantingPolicy, _ := IsPassing(&common.AccessPolicies{Policies: []*common.ServicePolicy{expired, unscheduled}}, nil)
+		req.Equal(unscheduled, grantingPolicy)
+	})
+
+	t.Run("windows are evaluated in the schedule's timezone", func(t *testing.T) {
+		req := require.New(t)
+		policy := newTestServicePolicy("windowed")
+		policy.ScheduleTimezone = "UTC"
+
+		now := time.Now().UTC()
+		if now.Hour() == 23 {
+			now = now.Add(-time.Hour)
+		}
+		policy.ScheduleWindows = []string{now.Format("Mon") + " 23:00-23:59"}
+		req.Equal(now.Hour() == 23, policy.IsScheduleActive(now))
+
+		policy.ScheduleWindows = []string{now.Format("Mon") + " 00:00-23:59"}
+		req.True(policy.IsScheduleActive(now))
+
+		policy.ScheduleTimezone = "Not/AZone"
+		req.False(policy.IsScheduleActive(now))
+	})
+}
@@ -2,6 +2,7 @@ package posture
 
 import (
 	"bytes"
+	"fmt"
 	"sync"
 	"time"
 
@@ -305,7 +306,23 @@ func CtrlCheckToLogic(postureCheck *edge_ctrl_pb.DataState_PostureCheck) Checker
 		}
 	}
 
-	return nil
+	return &UnsupportedCheck{
+		DataState_PostureCheck: postureCheck,
+	}
+}
+
+// UnsupportedCheck fails every evaluation. It's used for posture check types, such as TPM attestation, which only
+// the controller can evaluate.
+type UnsupportedCheck struct {
+	*edge_ctrl_pb.DataState_PostureCheck
+}
+
+func (c *UnsupportedCheck) Evaluate(*InstanceData) *CheckError {
+	return &CheckError{
+		Id:    c.Id,
+		Name:  c.Name,
+		Cause: fmt.Errorf("posture check type %s can't be evaluated by routers", c.TypeId),
+	}
 }
 
 func isProcessListDifferent(listA *edge_client_pb.PostureResponse_ProcessList, listB *edge_client_pb.PostureResponse_ProcessList) bool {
@@ -0,0 +1,211 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package profwatch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultCheckInterval      = 5 * time.Second
+	DefaultCpuThreshold       = 0.9
+	DefaultGoroutineThreshold = 100_000
+	DefaultSustainedFor       = time.Minute
+	DefaultCooldown           = 30 * time.Minute
+	DefaultCpuProfileDuration = 30 * time.Second
+	DefaultMaxFiles           = 30
+	DefaultMaxBytes           = 256 * 1024 * 1024
+)
+
+// Config controls the profiling watchdog. When CPU usage or the goroutine count stays above its threshold for
+// SustainedFor, CPU, heap and goroutine profiles are written to the spool directory. The watchdog is disabled by
+// default.
+type Config struct {
+	Enabled bool
+
+	// CheckInterval is how often CPU usage and the goroutine count are sampled
+	CheckInterval time.Duration
+
+	// CpuThreshold is the fraction of available CPU, as set by GOMAXPROCS, used by the router. Zero disables the check
+	CpuThreshold float64
+
+	// GoroutineThreshold is the number of goroutines. Zero disables the check
+	GoroutineThreshold int
+
+	SustainedFor time.Duration
+
+	// Cooldown is the minimum time between captures, so a long-running spike doesn't fill the spool
+	Cooldown time.Duration
+
+	CpuProfileDuration time.Duration
+
+	SpoolDir string
+	MaxFiles int
+	MaxBytes int64
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		CheckInterval:      DefaultCheckInterval,
+		CpuThreshold:       DefaultCpuThreshold,
+		GoroutineThreshold: DefaultGoroutineThreshold,
+		SustainedFor:       DefaultSustainedFor,
+		Cooldown:           DefaultCooldown,
+		CpuProfileDuration: DefaultCpuProfileDuration,
+		SpoolDir:           filepath.Join(os.TempDir(), "ziti-router-profiles"),
+		MaxFiles:           DefaultMaxFiles,
+		MaxBytes:           DefaultMaxBytes,
+	}
+}
+
+// LoadConfig parses a profiling watchdog config section, found at the given path. Example:
+//
+//	profilingWatchdog:
+//	  enabled: true
+//	  checkInterval: 5s
+//	  cpuThreshold: 0.9
+//	  goroutineThreshold: 100000
+//	  sustainedFor: 1m
+//	  cooldown: 30m
+//	  cpuProfileDuration: 30s
+//	  spool:
+//	    dir: /var/lib/ziti/profiles
+//	    maxFiles: 30
+//	    maxBytes: 268435456
+func LoadConfig(value interface{}, path string) (*Config, error) {
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid %s configuration, expected map, got %T", path, value)
+	}
+
+	result := DefaultConfig()
+	result.Enabled = true
+
+	if value, found := submap["enabled"]; found {
+		enabled, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid %s.enabled [%v], must be a boolean", path, value)
+		}
+		result.Enabled = enabled
+	}
+
+	var err error
+	if value, found := submap["checkInterval"]; found {
+		if result.CheckInterval, err = loadDuration(value, path+".checkInterval", 100*time.Millisecond); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, found := submap["cpuThreshold"]; found {
+		v, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s.cpuThreshold [%v]", path, value)
+		}
+		if v < 0 || v > 1 {
+			return nil, errors.Errorf("invalid %s.cpuThreshold [%v], must be between 0 and 1", path, value)
+		}
+		result.CpuThreshold = v
+	}
+
+	if value, found := submap["goroutineThreshold"]; found {
+		v, ok := value.(int)
+		if !ok || v < 0 {
+			return nil, errors.Errorf("invalid %s.goroutineThreshold [%v], must be a non-negative integer", path, value)
+		}
+		result.GoroutineThreshold = v
+	}
+
+	if value, found := submap["sustainedFor"]; found {
+		if result.SustainedFor, err = loadDuration(value, path+".sustainedFor", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, found := submap["cooldown"]; found {
+		if result.Cooldown, err = loadDuration(value, path+".cooldown", time.Minute); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, found := submap["cpuProfileDuration"]; found {
+		if result.CpuProfileDuration, err = loadDuration(value, path+".cpuProfileDuration", time.Second); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, found := submap["spool"]; found {
+		if err = result.loadSpool(value, path+".spool"); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.CpuThreshold == 0 && result.GoroutineThreshold == 0 {
+		return nil, errors.Errorf("invalid %s, at least one of cpuThreshold and goroutineThreshold must be set", path)
+	}
+
+	return result, nil
+}
+
+func (self *Config) loadSpool(value interface{}, path string) error {
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return errors.Errorf("invalid %s configuration, expected map, got %T", path, value)
+	}
+
+	if value, found := submap["dir"]; found {
+		dir, ok := value.(string)
+		if !ok || dir == "" {
+			return errors.Errorf("invalid %s.dir [%v], must be a non-empty string", path, value)
+		}
+		self.SpoolDir = dir
+	}
+
+	if value, found := submap["maxFiles"]; found {
+		v, ok := value.(int)
+		if !ok || v < 3 {
+			return errors.Errorf("invalid %s.maxFiles [%v], must be at least 3, to hold a single capture", path, value)
+		}
+		self.MaxFiles = v
+	}
+
+	if value, found := submap["maxBytes"]; found {
+		v, ok := value.(int)
+		if !ok || v <= 0 {
+			return errors.Errorf("invalid %s.maxBytes [%v], must be a positive number of bytes", path, value)
+		}
+		self.MaxBytes = int64(v)
+	}
+
+	return nil
+}
+
+func loadDuration(value interface{}, path string, minValue time.Duration) (time.Duration, error) {
+	result, err := time.ParseDuration(fmt.Sprintf("%v", value))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s [%v]", path, value)
+	}
+	if result < minValue {
+		return 0, errors.Errorf("invalid %s [%v], must be at least %v", path, value, minValue)
+	}
+	return result, nil
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package profwatch
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCpuTime returns the user and system CPU time used by the process so far
+func processCpuTime() (time.Duration, bool) {
+	handle, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, false
+	}
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err = syscall.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return 0, false
+	}
+
+	// Filetime values are in 100ns units
+	total := (int64(kernel.HighDateTime)<<32 | int64(kernel.LowDateTime)) + (int64(user.HighDateTime)<<32 | int64(user.LowDateTime))
+	return time.Duration(total * 100), true
+}
@@ -0,0 +1,297 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package profwatch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/metrics"
+	"github.com/openziti/ziti/common/alert"
+	"github.com/pkg/errors"
+)
+
+const profileSuffix = ".pprof"
+
+type Alerter interface {
+	ReportAlert(message string, severity alert.Severity, details []string, relatedEntities map[string]string)
+}
+
+// Watchdog samples the router's CPU usage and goroutine count. When either stays above its threshold for the
+// configured period, CPU, heap and goroutine profiles are captured to a bounded spool directory and an alert is
+// raised, so intermittent spikes can be diagnosed after the fact.
+type Watchdog struct {
+	config  *Config
+	alerter Alerter
+
+	overSince   time.Time
+	lastCapture time.Time
+	capturing   atomic.Bool
+	captures    atomic.Int64
+
+	lastCpuTime    time.Duration
+	lastSampleTime time.Time
+}
+
+func NewWatchdog(config *Config, alerter Alerter) *Watchdog {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Watchdog{
+		config:  config,
+		alerter: alerter,
+	}
+}
+
+// Start registers the watchdog metrics and, if enabled, begins sampling until closeNotify is closed
+func (self *Watchdog) Start(registry metrics.Registry, closeNotify <-chan struct{}) {
+	if !self.config.Enabled {
+		return
+	}
+
+	registry.FuncGauge("profiling.watchdog.captures", func() int64 {
+		return self.captures.Load()
+	})
+
+	if err := os.MkdirAll(self.config.SpoolDir, 0700); err != nil {
+		pfxlog.Logger().WithField("dir", self.config.SpoolDir).WithError(err).
+			Error("unable to create profile spool directory, profiling watchdog not started")
+		return
+	}
+
+	pfxlog.Logger().WithField("dir", self.config.SpoolDir).
+		WithField("cpuThreshold", self.config.CpuThreshold).
+		WithField("goroutineThreshold", self.config.GoroutineThreshold).
+		WithField("sustainedFor", self.config.SustainedFor).
+		Info("profiling watchdog enabled")
+
+	self.sampleCpu(time.Now())
+	go self.run(closeNotify)
+}
+
+func (self *Watchdog) run(closeNotify <-chan struct{}) {
+	ticker := time.NewTicker(self.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			cpuUsage, cpuOk := self.sampleCpu(now)
+			if reason := self.check(now, cpuUsage, cpuOk, runtime.NumGoroutine()); reason != "" {
+				go self.capture(reason, closeNotify)
+			}
+		case <-closeNotify:
+			return
+		}
+	}
+}
+
+// sampleCpu returns the fraction of available CPU used since the last sample
+func (self *Watchdog) sampleCpu(now time.Time) (float64, bool) {
+	cpuTime, ok := processCpuTime()
+	if !ok {
+		return 0, false
+	}
+
+	prevCpuTime, prevSampleTime := self.lastCpuTime, self.lastSampleTime
+	self.lastCpuTime, self.lastSampleTime = cpuTime, now
+
+	if prevSampleTime.IsZero() {
+		return 0, false
+	}
+
+	available := now.Sub(prevSampleTime) * time.Duration(runtime.GOMAXPROCS(0))
+	if available <= 0 {
+		return 0, false
+	}
+
+	return float64(cpuTime-prevCpuTime) / float64(available), true
+}
+
+// check records a sample and returns the reason for capturing profiles, if thresholds have been exceeded for long
+// enough and no capture is running or has run recently. Otherwise, it returns an empty string
+func (self *Watchdog) check(now time.Time, cpuUsage float64, cpuOk bool, goroutines int) string {
+	var reasons []string
+	if cpuOk && self.config.CpuThreshold > 0 && cpuUsage >= self.config.CpuThreshold {
+		reasons = append(reasons, fmt.Sprintf("cpu usage %.0f%% at or above threshold of %.0f%%", cpuUsage*100, self.config.CpuThreshold*100))
+	}
+	if self.config.GoroutineThreshold > 0 && goroutines >= self.config.GoroutineThreshold {
+		reasons = append(reasons, fmt.Sprintf("goroutine count %d at or above threshold of %d", goroutines, self.config.GoroutineThreshold))
+	}
+
+	if len(reasons) == 0 {
+		self.overSince = time.Time{}
+		return ""
+	}
+
+	if self.overSince.IsZero() {
+		self.overSince = now
+	}
+
+	if now.Sub(self.overSince) < self.config.SustainedFor {
+		return ""
+	}
+
+	if !self.lastCapture.IsZero() && now.Sub(self.lastCapture) < self.config.Cooldown {
+		return ""
+	}
+
+	if !self.capturing.CompareAndSwap(false, true) {
+		return ""
+	}
+
+	self.lastCapture = now
+	self.overSince = time.Time{}
+	return fmt.Sprintf("%s for %v", strings.Join(reasons, ", "), self.config.SustainedFor)
+}
+
+func (self *Watchdog) capture(reason string, closeNotify <-chan struct{}) {
+	defer self.capturing.Store(false)
+
+	log := pfxlog.Logger().WithField("reason", reason)
+	log.Warn("router resource usage high, capturing profiles")
+
+	prefix := filepath.Join(self.config.SpoolDir, "router-"+time.Now().UTC().Format("20060102T150405Z"))
+
+	var files []string
+	var errs []string
+
+	if file, err := self.captureCpuProfile(prefix+"-cpu"+profileSuffix, closeNotify); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		files = append(files, file)
+	}
+
+	for _, name := range []string{"heap", "goroutine"} {
+		if file, err := writeProfile(prefix+"-"+name+profileSuffix, name); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			files = append(files, file)
+		}
+	}
+
+	self.captures.Add(1)
+
+	if err := self.pruneSpool(); err != nil {
+		log.WithError(err).Error("unable to prune profile spool")
+	}
+
+	log.WithField("files", files).WithField("errors", errs).Warn("profiles captured")
+
+	if self.alerter != nil {
+		details := append([]string{reason}, files...)
+		for _, err := range errs {
+			details = append(details, "error: "+err)
+		}
+		self.alerter.ReportAlert("router resource usage high, profiles captured", alert.SeverityWarning, details, nil)
+	}
+}
+
+func (self *Watchdog) captureCpuProfile(path string, closeNotify <-chan struct{}) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create cpu profile")
+	}
+
+	if err = pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return "", errors.Wrap(err, "unable to start cpu profile, cpu profiling may already be running")
+	}
+
+	select {
+	case <-time.After(self.config.CpuProfileDuration):
+	case <-closeNotify:
+	}
+
+	pprof.StopCPUProfile()
+	if err = f.Close(); err != nil {
+		return "", errors.Wrap(err, "unable to close cpu profile")
+	}
+	return path, nil
+}
+
+func writeProfile(path, name string) (string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to create %s profile", name)
+	}
+
+	err = pprof.Lookup(name).WriteTo(f, 0)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", errors.Wrapf(err, "unable to write %s profile", name)
+	}
+	return path, nil
+}
+
+// pruneSpool removes the oldest profiles from the spool until it's within the configured file count and size limits
+func (self *Watchdog) pruneSpool() error {
+	entries, err := os.ReadDir(self.config.SpoolDir)
+	if err != nil {
+		return err
+	}
+
+	type spoolFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []spoolFile
+	var totalBytes int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), profileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, spoolFile{
+			path:    filepath.Join(self.config.SpoolDir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+		totalBytes += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	for len(files) > 0 && (len(files) > self.config.MaxFiles || totalBytes > self.config.MaxBytes) {
+		if err = os.Remove(files[0].path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		totalBytes -= files[0].size
+		files = files[1:]
+	}
+
+	return nil
+}
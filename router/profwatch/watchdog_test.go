@@ -0,0 +1,174 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package profwatch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/common/alert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAlerter struct {
+	messages []string
+	details  [][]string
+}
+
+func (self *testAlerter) ReportAlert(message string, _ alert.Severity, details []string, _ map[string]string) {
+	self.messages = append(self.messages, message)
+	self.details = append(self.details, details)
+}
+
+func TestCheckRequiresSustainedUsage(t *testing.T) {
+	req := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.GoroutineThreshold = 100
+	watchdog := NewWatchdog(cfg, nil)
+
+	start := time.Now()
+	req.Equal("", watchdog.check(start, 0.95, true, 10))
+	req.Equal("", watchdog.check(start.Add(30*time.Second), 0.95, true, 10))
+
+	// dropping below the threshold resets the period
+	req.Equal("", watchdog.check(start.Add(45*time.Second), 0.5, true, 10))
+	req.Equal("", watchdog.check(start.Add(90*time.Second), 0.95, true, 10))
+
+	reason := watchdog.check(start.Add(150*time.Second), 0.95, true, 200)
+	req.Contains(reason, "cpu usage 95%")
+	req.Contains(reason, "goroutine count 200")
+}
+
+func TestCheckRespectsCooldown(t *testing.T) {
+	req := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.SustainedFor = 0
+	watchdog := NewWatchdog(cfg, nil)
+
+	start := time.Now()
+	req.NotEqual("", watchdog.check(start, 1, true, 0))
+
+	// a capture is still running
+	req.Equal("", watchdog.check(start.Add(DefaultCooldown+time.Second), 1, true, 0))
+	watchdog.capturing.Store(false)
+
+	req.Equal("", watchdog.check(start.Add(time.Minute), 1, true, 0))
+	req.NotEqual("", watchdog.check(start.Add(DefaultCooldown+time.Second), 1, true, 0))
+}
+
+func TestCheckIgnoresUnavailableCpu(t *testing.T) {
+	req := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.SustainedFor = 0
+	watchdog := NewWatchdog(cfg, nil)
+
+	req.Equal("", watchdog.check(time.Now(), 1, false, 0))
+}
+
+func TestCaptureWritesProfilesAndPrunesSpool(t *testing.T) {
+	req := require.New(t)
+
+	cfg := DefaultConfig()
+	cfg.Enabled = true
+	cfg.SpoolDir = t.TempDir()
+	cfg.CpuProfileDuration = 10 * time.Millisecond
+	cfg.MaxFiles = 5
+
+	for i := 0; i < 4; i++ {
+		path := filepath.Join(cfg.SpoolDir, fmt.Sprintf("router-old-%d%s", i, profileSuffix))
+		req.NoError(os.WriteFile(path, []byte("old"), 0600))
+		modTime := time.Now().Add(-time.Duration(10-i) * time.Hour)
+		req.NoError(os.Chtimes(path, modTime, modTime))
+	}
+	req.NoError(os.WriteFile(filepath.Join(cfg.SpoolDir, "notes.txt"), []byte("keep"), 0600))
+
+	alerter := &testAlerter{}
+	watchdog := NewWatchdog(cfg, alerter)
+	watchdog.capturing.Store(true)
+	watchdog.capture("testing", make(chan struct{}))
+
+	req.False(watchdog.capturing.Load())
+	req.Equal(int64(1), watchdog.captures.Load())
+
+	entries, err := os.ReadDir(cfg.SpoolDir)
+	req.NoError(err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	req.Len(names, 6)
+	req.Contains(names, "notes.txt")
+	req.Contains(names, "router-old-2"+profileSuffix)
+	req.Contains(names, "router-old-3"+profileSuffix)
+	req.NotContains(names, "router-old-0"+profileSuffix)
+
+	for _, suffix := range []string{"-cpu", "-heap", "-goroutine"} {
+		found := false
+		for _, name := range names {
+			if strings.HasSuffix(name, suffix+profileSuffix) && !strings.HasPrefix(name, "router-old") {
+				found = true
+			}
+		}
+		req.True(found, "missing %s profile", suffix)
+	}
+
+	req.Len(alerter.messages, 1)
+	req.Equal("testing", alerter.details[0][0])
+}
+
+func TestLoadConfig(t *testing.T) {
+	req := require.New(t)
+
+	cfg, err := LoadConfig(map[interface{}]interface{}{
+		"cpuThreshold":       0.8,
+		"goroutineThreshold": 5000,
+		"sustainedFor":       "2m",
+		"spool": map[interface{}]interface{}{
+			"dir":      "/tmp/profiles",
+			"maxFiles": 9,
+		},
+	}, "profilingWatchdog")
+	req.NoError(err)
+	req.True(cfg.Enabled)
+	req.Equal(0.8, cfg.CpuThreshold)
+	req.Equal(5000, cfg.GoroutineThreshold)
+	req.Equal(2*time.Minute, cfg.SustainedFor)
+	req.Equal("/tmp/profiles", cfg.SpoolDir)
+	req.Equal(9, cfg.MaxFiles)
+	req.Equal(int64(DefaultMaxBytes), cfg.MaxBytes)
+
+	_, err = LoadConfig(map[interface{}]interface{}{"cpuThreshold": 1.5}, "profilingWatchdog")
+	req.Error(err)
+
+	_, err = LoadConfig(map[interface{}]interface{}{"cpuThreshold": 0, "goroutineThreshold": 0}, "profilingWatchdog")
+	req.Error(err)
+
+	_, err = LoadConfig(map[interface{}]interface{}{"spool": map[interface{}]interface{}{"maxFiles": 2}}, "profilingWatchdog")
+	req.Error(err)
+}
@@ -0,0 +1,66 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package qos
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigTypeName is the name of the config type used to define bandwidth limits for a service. When attached to a
+// service, the limits apply to everyone using the service. When attached as an identity service config override,
+// the limits apply only to that identity's use of the service.
+const ConfigTypeName = "qos.v1"
+
+// Limit defines bandwidth limits, as seen from the edge router. Ingress is traffic from edge clients into the
+// network, egress is traffic from the network to edge clients. A rate of zero means that direction is unlimited.
+type Limit struct {
+	IngressBytesPerSecond int64 `json:"ingressBytesPerSecond"`
+	EgressBytesPerSecond  int64 `json:"egressBytesPerSecond"`
+	BurstBytes            int64 `json:"burstBytes"`
+}
+
+func (self *Limit) IsUnlimited() bool {
+	return self == nil || (self.IngressBytesPerSecond <= 0 && self.EgressBytesPerSecond <= 0)
+}
+
+// Config is the parsed form of a qos.v1 config. Service limits are shared by all connections to the service on
+// a router, while identity limits are tracked separately for each identity using the service.
+type Config struct {
+	Service  *Limit `json:"service"`
+	Identity *Limit `json:"identity"`
+}
+
+func (self *Config) IsUnlimited() bool {
+	return self == nil || (self.Service.IsUnlimited() && self.Identity.IsUnlimited())
+}
+
+func ParseConfig(dataJson string) (*Config, error) {
+	result := &Config{}
+	if err := json.Unmarshal([]byte(dataJson), result); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s config", ConfigTypeName)
+	}
+
+	for _, limit := range []*Limit{result.Service, result.Identity} {
+		if limit != nil && (limit.IngressBytesPerSecond < 0 || limit.EgressBytesPerSecond < 0 || limit.BurstBytes < 0) {
+			return nil, errors.Errorf("invalid %s config, limits may not be negative", ConfigTypeName)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,240 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package qos
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Manager tracks the token buckets used to shape edge connections. Buckets are shared by all connections with
+// the same scope, so that a service or identity limit applies to the total traffic across those connections.
+// Buckets are removed when the last connection using them is closed.
+type Manager struct {
+	lock    sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		buckets: map[string]*bucket{},
+	}
+}
+
+type bucket struct {
+	limit   Limit
+	ingress *rate.Limiter
+	egress  *rate.Limiter
+	refs    int
+}
+
+func newBucket(limit Limit) *bucket {
+	return &bucket{
+		limit:   limit,
+		ingress: rate.NewLimiter(getRateAndBurst(limit.IngressBytesPerSecond, limit.BurstBytes)),
+		egress:  rate.NewLimiter(getRateAndBurst(limit.EgressBytesPerSecond, limit.BurstBytes)),
+	}
+}
+
+func (self *bucket) apply(limit Limit) {
+	self.limit = limit
+	ingressLimit, ingressBurst := getRateAndBurst(limit.IngressBytesPerSecond, limit.BurstBytes)
+	self.ingress.SetLimit(ingressLimit)
+	self.ingress.SetBurst(ingressBurst)
+
+	egressLimit, egressBurst := getRateAndBurst(limit.EgressBytesPerSecond, limit.BurstBytes)
+	self.egress.SetLimit(egressLimit)
+	self.egress.SetBurst(egressBurst)
+}
+
+func getRateAndBurst(bytesPerSecond, burst int64) (rate.Limit, int) {
+	if bytesPerSecond <= 0 {
+		return rate.Inf, 0
+	}
+	if burst <= 0 {
+		burst = bytesPerSecond
+	}
+	return rate.Limit(bytesPerSecond), int(burst)
+}
+
+// NewShaper returns a shaper for a connection from the given identity to the given service. If the config doesn't
+// define any limits, nil is returned. A nil shaper doesn't limit anything, so callers don't need to check. Shapers
+// must be closed when the connection closes.
+func (self *Manager) NewShaper(identityId, serviceId string, config *Config) *Shaper {
+	if config.IsUnlimited() {
+		return nil
+	}
+
+	result := &Shaper{
+		manager: self,
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if !config.Service.IsUnlimited() {
+		result.addBucket("service/"+serviceId, *config.Service)
+	}
+
+	if !config.Identity.IsUnlimited() {
+		result.addBucket("identity/"+identityId+"/"+serviceId, *config.Identity)
+	}
+
+	result.ctx, result.cancel = context.WithCancel(context.Background())
+	return result
+}
+
+func (self *Manager) release(keys []string) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, key := range keys {
+		if b, ok := self.buckets[key]; ok {
+			b.refs--
+			if b.refs <= 0 {
+				delete(self.buckets, key)
+			}
+		}
+	}
+}
+
+// BucketCount returns the number of token buckets currently in use
+func (self *Manager) BucketCount() int {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return len(self.buckets)
+}
+
+// Shaper limits the traffic for a single connection, waiting on each bucket which applies to the connection
+type Shaper struct {
+	manager *Manager
+	keys    []string
+	ingress []*rate.Limiter
+	egress  []*rate.Limiter
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closed  atomic.Bool
+}
+
+// addBucket must be called with the manager lock held. If the bucket already exists with different limits, the
+// limits are updated, so config changes take effect for existing connections once a new connection is made.
+func (self *Shaper) addBucket(key string, limit Limit) {
+	b, ok := self.manager.buckets[key]
+	if !ok {
+		b = newBucket(limit)
+		self.manager.buckets[key] = b
+	} else if b.limit != limit {
+		b.apply(limit)
+	}
+	b.refs++
+
+	self.keys = append(self.keys, key)
+	self.ingress = append(self.ingress, b.ingress)
+	self.egress = append(self.egress, b.egress)
+}
+
+// WaitIngress blocks until n bytes may be sent from the edge client into the network, or the shaper is closed
+func (self *Shaper) WaitIngress(n int) error {
+	if self == nil {
+		return nil
+	}
+	return self.wait(self.ingress, n)
+}
+
+// WaitEgress blocks until n bytes may be sent from the network to the edge client, or the shaper is closed
+func (self *Shaper) WaitEgress(n int) error {
+	if self == nil {
+		return nil
+	}
+	return self.wait(self.egress, n)
+}
+
+func (self *Shaper) wait(limiters []*rate.Limiter, n int) error {
+	for _, limiter := range limiters {
+		if limiter.Limit() == rate.Inf {
+			continue
+		}
+
+		// payloads may be larger than the burst size, so wait for them in burst sized pieces
+		for remaining := n; remaining > 0; {
+			chunk := min(remaining, limiter.Burst())
+			if err := limiter.WaitN(self.ctx, chunk); err != nil {
+				return err
+			}
+			remaining -= chunk
+		}
+	}
+	return nil
+}
+
+// AllowIngress reports whether n bytes may be sent from the edge client into the network now, and if so takes them
+// from the shaper's buckets. It's used where waiting would hold up other connections, in which case the payload is
+// dropped and retransmitted by the sender. Payloads larger than the burst size are allowed once the full burst is
+// available.
+func (self *Shaper) AllowIngress(n int) bool {
+	if self == nil {
+		return true
+	}
+	return self.allow(self.ingress, n)
+}
+
+// AllowEgress reports whether n bytes may be sent from the network to the edge client now, and if so takes them
+// from the shaper's buckets. See AllowIngress.
+func (self *Shaper) AllowEgress(n int) bool {
+	if self == nil {
+		return true
+	}
+	return self.allow(self.egress, n)
+}
+
+func (self *Shaper) allow(limiters []*rate.Limiter, n int) bool {
+	if self.closed.Load() {
+		return false
+	}
+
+	// reserve from every bucket, so that a payload refused by one bucket doesn't use up tokens in the others
+	now := time.Now()
+	var reservations []*rate.Reservation
+	for _, limiter := range limiters {
+		if limiter.Limit() == rate.Inf {
+			continue
+		}
+
+		reservation := limiter.ReserveN(now, min(n, limiter.Burst()))
+		if !reservation.OK() || reservation.DelayFrom(now) > 0 {
+			reservation.CancelAt(now)
+			for _, prev := range reservations {
+				prev.CancelAt(now)
+			}
+			return false
+		}
+		reservations = append(reservations, reservation)
+	}
+	return true
+}
+
+// Close unblocks any pending waits and releases the shaper's buckets
+func (self *Shaper) Close() {
+	if self != nil && self.closed.CompareAndSwap(false, true) {
+		self.cancel()
+		self.manager.release(self.keys)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package qos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	req := require.New(t)
+
+	cfg, err := ParseConfig(`{"service": {"ingressBytesPerSecond": 1000, "burstBytes": 500}}`)
+	req.NoError(err)
+	req.NotNil(cfg.Service)
+	req.Nil(cfg.Identity)
+	req.Equal(int64(1000), cfg.Service.IngressBytesPerSecond)
+	req.Equal(int64(500), cfg.Service.BurstBytes)
+	req.False(cfg.IsUnlimited())
+
+	cfg, err = ParseConfig(`{"identity": {"egressBytesPerSecond": 0}}`)
+	req.NoError(err)
+	req.True(cfg.IsUnlimited())
+
+	_, err = ParseConfig(`{"identity": {"egressBytesPerSecond": -1}}`)
+	req.Error(err)
+}
+
+func TestShaperBucketsShared(t *testing.T) {
+	req := require.New(t)
+
+	manager := NewManager()
+	req.Nil(manager.NewShaper("i1", "s1", &Config{}))
+	req.Equal(0, manager.BucketCount())
+
+	cfg := &Config{
+		Service:  &Limit{IngressBytesPerSecond: 1000},
+		Identity: &Limit{EgressBytesPerSecond: 1000},
+	}
+
+	s1 := manager.NewShaper("i1", "s1", cfg)
+	s2 := manager.NewShaper("i2", "s1", cfg)
+	req.Equal(3, manager.BucketCount())
+	req.Same(s1.ingress[0], s2.ingress[0])
+	req.NotSame(s1.egress[1], s2.egress[1])
+
+	s1.Close()
+	s1.Close()
+	req.Equal(2, manager.BucketCount())
+	s2.Close()
+	req.Equal(0, manager.BucketCount())
+
+	var nilShaper *Shaper
+	req.NoError(nilShaper.WaitIngress(100))
+	req.True(nilShaper.AllowEgress(100))
+	nilShaper.Close()
+}
+
+func TestShaperLimitsRate(t *testing.T) {
+	req := require.New(t)
+
+	manager := NewManager()
+	shaper := manager.NewShaper("i1", "s1", &Config{
+		Service: &Limit{IngressBytesPerSecond: 10_000, BurstBytes: 1_000},
+	})
+	defer shaper.Close()
+
+	// the burst is available immediately, the remaining 2000 bytes should take ~200ms
+	start := time.Now()
+	req.NoError(shaper.WaitIngress(3_000))
+	req.GreaterOrEqual(time.Since(start), 150*time.Millisecond)
+
+	// egress isn't limited
+	start = time.Now()
+	req.NoError(shaper.WaitEgress(1_000_000))
+	req.Less(time.Since(start), 50*time.Millisecond)
+}
+
+func TestShaperCloseUnblocksWait(t *testing.T) {
+	req := require.New(t)
+
+	manager := NewManager()
+	shaper := manager.NewShaper("i1", "s1", &Config{
+		Identity: &Limit{EgressBytesPerSecond: 10, BurstBytes: 10},
+	})
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- shaper.WaitEgress(1_000)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	shaper.Close()
+
+	select {
+	case err := <-errC:
+		req.Error(err)
+	case <-time.After(time.Second):
+		req.FailNow("wait not unblocked by close")
+	}
+}
+
+func TestShaperAllow(t *testing.T) {
+	req := require.New(t)
+
+	manager := NewManager()
+	shaper := manager.NewShaper("i1", "s1", &Config{
+		Service:  &Limit{IngressBytesPerSecond: 1_000, BurstBytes: 1_000},
+		Identity: &Limit{IngressBytesPerSecond: 1_000, BurstBytes: 500},
+	})
+
+	// the identity burst is the smaller, so it's used up first
+	req.True(shaper.AllowIngress(400))
+	req.False(shaper.AllowIngress(400))
+
+	// the refused payload didn't take tokens from the service bucket
+	req.InDelta(600, shaper.ingress[0].Tokens(), 10)
+
+	// payloads larger than the burst are allowed once a full burst is available
+	time.Sleep(500 * time.Millisecond)
+	req.True(shaper.AllowIngress(5_000))
+	req.False(shaper.AllowIngress(1))
+
+	// egress isn't limited
+	req.True(shaper.AllowEgress(1_000_000))
+
+	shaper.Close()
+	req.False(shaper.AllowEgress(1))
+}
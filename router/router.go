@@ -53,7 +53,9 @@ import (
 	"github.com/openziti/ziti/common/alert"
 	"github.com/openziti/ziti/common/config"
 	"github.com/openziti/ziti/common/health"
+	"github.com/openziti/ziti/common/linktls"
 	fabricMetrics "github.com/openziti/ziti/common/metrics"
+	"github.com/openziti/ziti/common/metrics/sampling"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
 	"github.com/openziti/ziti/common/profiler"
 	"github.com/openziti/ziti/common/version"
@@ -65,12 +67,17 @@ import (
 	"github.com/openziti/ziti/router/handler_xgress"
 	"github.com/openziti/ziti/router/interfaces"
 	"github.com/openziti/ziti/router/link"
+	"github.com/openziti/ziti/router/mempressure"
 	routerMetrics "github.com/openziti/ziti/router/metrics"
+	"github.com/openziti/ziti/router/profwatch"
 	"github.com/openziti/ziti/router/state"
+	"github.com/openziti/ziti/router/trustbundle"
+	"github.com/openziti/ziti/router/update"
 	"github.com/openziti/ziti/router/xgress_edge"
 	"github.com/openziti/ziti/router/xgress_edge_transport"
 	"github.com/openziti/ziti/router/xgress_edge_tunnel"
 	"github.com/openziti/ziti/router/xgress_proxy"
+	"github.com/openziti/ziti/router/xgress_proxy_connect"
 	"github.com/openziti/ziti/router/xgress_proxy_udp"
 	"github.com/openziti/ziti/router/xgress_router"
 	"github.com/openziti/ziti/router/xgress_transport"
@@ -88,6 +95,7 @@ type Router struct {
 	config              *env.Config
 	ctrls               env.NetworkControllers
 	ctrlBindhandler     channel.BindHandler
+	ctrlSrvDiscovery    *ctrlSrvDiscovery
 	faulter             *forwarder.Faulter
 	forwarder           *forwarder.Forwarder
 	xrctrls             []env.Xrctrl
@@ -116,6 +124,8 @@ type Router struct {
 	indexWatchers       env.IndexWatchers
 	xgBindHandler       xgress.BindHandler
 	xgMetrics           *routerMetrics.XgressMetrics
+	memPressure         *mempressure.Monitor
+	updater             *update.Updater
 	healthChecker       gosundheit.Health
 	alertReporter       *alert.Reporter
 }
@@ -210,6 +220,14 @@ func (self *Router) GetXgressMetrics() env.XgressMetrics {
 	return self.xgMetrics
 }
 
+func (self *Router) GetMemoryPressureMonitor() *mempressure.Monitor {
+	return self.memPressure
+}
+
+func (self *Router) GetUpdater() *update.Updater {
+	return self.updater
+}
+
 func (self *Router) GetXgressListeners() []xgress_router.Listener {
 	return self.xgressListeners
 }
@@ -223,7 +241,12 @@ func createMetricsRegistry(cfg *env.Config, closeNotify <-chan struct{}) metrics
 	}
 	env.IntervalSize = cfg.Metrics.ReportInterval
 
-	return metrics.NewUsageRegistry(metricsConfig)
+	registry := metrics.NewUsageRegistry(metricsConfig)
+	if cfg.Metrics.Histograms != nil {
+		logrus.Infof("using histogram sampling: %v", cfg.Metrics.Histograms)
+		registry = sampling.NewUsageRegistry(registry, cfg.Metrics.Histograms)
+	}
+	return registry
 }
 
 func Create(cfg *env.Config, versionProvider versions.VersionProvider) *Router {
@@ -245,17 +268,22 @@ func Create(cfg *env.Config, versionProvider versions.VersionProvider) *Router {
 		rdmEnabled:          config.NewConfigValue[bool](),
 		indexWatchers:       env.NewIndexWatchers(),
 		xgMetrics:           routerMetrics.NewXgressMetrics(metricsRegistry),
+		memPressure:         mempressure.NewMonitor(cfg.MemoryPressure),
+		updater:             update.NewUpdater(cfg.Update),
 	}
 
-	router.ctrls = env.NewNetworkControllers(cfg.Ctrl.DefaultRequestTimeout, router.connectToController, &cfg.Ctrl.Heartbeats)
+	router.ctrls = env.NewNetworkControllers(cfg.Ctrl.DefaultRequestTimeout, router.connectToController, &cfg.Ctrl.Heartbeats, cfg.Ctrl.ActiveActive)
 	router.stateManager = state.NewManager(router)
 	router.certManager = state.NewCertExpirationChecker(router)
 	router.alertReporter = alert.NewAlertReporter(router.ctrls, cfg.Id.Token, 1000, 10)
 
 	router.xlinkRegistry = link.NewLinkRegistry(router)
-	router.faulter = forwarder.NewFaulter(router.ctrls, cfg.Forwarder.FaultTxInterval, closeNotify)
+	router.faulter = forwarder.NewFaulter(router.ctrls, cfg.Forwarder.FaultTxInterval, cfg.Forwarder.RouteRepairHoldoff, closeNotify)
 	router.forwarder = forwarder.NewForwarder(metricsRegistry, router.faulter, cfg.Forwarder, closeNotify)
 	router.forwarder.StartScanner(router.ctrls)
+	if cfg.Forwarder.PayloadClassification {
+		router.forwarder.EnablePayloadClassification(router.ctrls)
+	}
 
 	var err error
 	router.ctrlBindhandler, err = handler_ctrl.NewBindHandler(router, router.forwarder, router)
@@ -368,16 +396,24 @@ func (self *Router) Start() error {
 	}
 
 	self.startProfiling()
+	self.memPressure.Start(self.metricsRegistry, self.shutdownC)
+	profwatch.NewWatchdog(self.config.ProfWatchdog, self.alertReporter).Start(self.metricsRegistry, self.shutdownC)
+	trustbundle.NewPoller(self.config.TrustBundle, self.config.Id, self.ctrls).Start(self.shutdownC)
+	startHostMetrics(self.metricsRegistry, self.shutdownC)
 
 	if healthChecker, err := self.initializeHealthChecks(); err != nil {
 		logrus.WithError(err).Fatalf("failed to create health checker")
 	} else {
 		self.healthChecker = healthChecker
-		if err = self.RegisterXWebHandlerFactory(health.NewHealthCheckApiFactory(healthChecker)); err != nil {
+		if err = self.RegisterXWebHandlerFactory(health.NewHealthCheckApiFactory(healthChecker, self.config.HealthChecks.LivenessChecks...)); err != nil {
 			logrus.WithError(err).Fatalf("failed to create health checks api factory")
 		}
 	}
 
+	if err := self.RegisterXWebHandlerFactory(routerMetrics.NewPrometheusApiFactory(self.metricsRegistry, self.xlinkRegistry)); err != nil {
+		logrus.WithError(err).Fatalf("failed to create prometheus metrics api factory")
+	}
+
 	// Register components and plugins
 	if err := self.registerComponents(); err != nil {
 		return err
@@ -562,6 +598,10 @@ func (self *Router) GetLinkDialerPool() goroutines.Pool {
 	return self.linkDialerPool
 }
 
+func (self *Router) GetLinkDialPacingOptions() env.LinkDialPacingOptions {
+	return self.config.Forwarder.LinkDialReconnect
+}
+
 func (self *Router) GetRateLimiterPool() goroutines.Pool {
 	return self.rateLimiterPool
 }
@@ -586,6 +626,16 @@ func (self *Router) GetXLinkRegistry() xlink.Registry {
 	return self.xlinkRegistry
 }
 
+// GetLinkTlsPolicy returns the TLS policy in effect for new links, combining the locally configured policy with
+// the one sent by the controller
+func (self *Router) GetLinkTlsPolicy() *linktls.Policy {
+	var remote *linktls.Policy
+	if self.xlinkRegistry != nil {
+		remote = self.xlinkRegistry.GetTlsPolicy()
+	}
+	return linktls.Merge(self.config.Link.Tls, remote)
+}
+
 func (self *Router) NotifyCertsUpdated() {
 	self.certManager.CertsUpdated()
 }
@@ -597,6 +647,8 @@ func (self *Router) registerComponents() error {
 		self.ctrls,
 		self.forwarder,
 		&self.config.Link.Heartbeats,
+		&self.config.Link.LatencyProbe,
+		&self.config.Link.PathMtu,
 		self.metricsRegistry,
 		self.xlinkRegistry,
 	)
@@ -611,6 +663,7 @@ func (self *Router) registerComponents() error {
 
 	xgress_router.GlobalRegistry().Register("proxy", xgress_proxy.NewFactory(self.config.Id, self.ctrls, self.config.Transport))
 	xgress_router.GlobalRegistry().Register("proxy_udp", xgress_proxy_udp.NewFactory(self.ctrls))
+	xgress_router.GlobalRegistry().Register(xgress_proxy_connect.BindingName, xgress_proxy_connect.NewFactory(self.config.Id, self.ctrls, self.config.Transport))
 	xgress_router.GlobalRegistry().Register("transport", xgress_transport.NewFactory(self.config.Id, self.ctrls, self.config.Transport))
 	xgress_router.GlobalRegistry().Register("transport_udp", xgress_transport_udp.NewFactory(self.config.Id, self.ctrls))
 
@@ -708,6 +761,13 @@ func (self *Router) startXlinkDialers() {
 }
 
 func (self *Router) startXlinkListeners() {
+	if self.config.Link.DialOnly {
+		if len(self.config.Link.Listeners) > 0 {
+			logrus.Warn("router is configured as link dial only, configured link listeners will not be started")
+		}
+		return
+	}
+
 	for _, lmap := range self.config.Link.Listeners {
 		binding := "transport"
 		if bindingVal, ok := lmap["binding"]; ok {
@@ -773,6 +833,10 @@ func (self *Router) startControlPlane() error {
 
 	self.ctrls.UpdateControllerEndpoints(endpoints)
 
+	if self.ctrlSrvDiscovery != nil {
+		go self.runCtrlSrvDiscovery(self.ctrlSrvDiscovery)
+	}
+
 	self.metricsReporter = fabricMetrics.NewControllersReporter(self.ctrls)
 	self.metricsRegistry.StartReporting(self.metricsReporter, self.config.Metrics.ReportInterval, self.config.Metrics.MessageQueueSize)
 
@@ -841,9 +905,14 @@ func (self *Router) connectToController(addr transport.Address, bindHandler chan
 	routerMeta := &ctrl_pb.RouterMetadata{
 		Capabilities: []ctrl_pb.RouterCapability{
 			ctrl_pb.RouterCapability_LinkManagement,
+			ctrl_pb.RouterCapability_PayloadFragmentation,
 		},
 	}
 
+	if self.config.Link.DialOnly {
+		routerMeta.Capabilities = append(routerMeta.Capabilities, ctrl_pb.RouterCapability_LinkDialOnly)
+	}
+
 	if buf, err := proto.Marshal(routerMeta); err != nil {
 		return errors.Wrap(err, "unable to router metadata")
 	} else {
@@ -932,6 +1001,38 @@ func (self *Router) initializeHealthChecks() (gosundheit.Health, error) {
 		return nil, err
 	}
 
+	err = h.RegisterCheck(&ctrlChannelHealthCheck{router: self, minConnected: checkConfig.CtrlChannelCheck.MinConnected},
+		gosundheit.ExecutionPeriod(checkConfig.CtrlChannelCheck.Interval),
+		gosundheit.ExecutionTimeout(5*time.Second),
+		gosundheit.InitiallyPassing(false),
+		gosundheit.InitialDelay(checkConfig.CtrlChannelCheck.InitialDelay),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.RegisterCheck(&terminatorHealthCheck{router: self, establishTimeout: checkConfig.TerminatorCheck.EstablishTimeout},
+		gosundheit.ExecutionPeriod(checkConfig.TerminatorCheck.Interval),
+		gosundheit.ExecutionTimeout(5*time.Second),
+		gosundheit.InitiallyPassing(true),
+		gosundheit.InitialDelay(checkConfig.TerminatorCheck.InitialDelay),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.RegisterCheck(&certExpiryHealthCheck{router: self, warnBefore: checkConfig.CertExpiryCheck.WarnBefore, failBefore: checkConfig.CertExpiryCheck.FailBefore},
+		gosundheit.ExecutionPeriod(checkConfig.CertExpiryCheck.Interval),
+		gosundheit.ExecutionTimeout(5*time.Second),
+		gosundheit.InitiallyPassing(true),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return h, nil
 }
 
@@ -955,6 +1056,15 @@ func (self *Router) getInitialCtrlEndpoints() ([]string, error) {
 		return nil, errors.New("ctrl endpointsFile not configured")
 	}
 
+	if self.config.Ctrl.Srv != nil {
+		self.ctrlSrvDiscovery = newCtrlSrvDiscovery(self.config.Ctrl.Srv)
+		if endpoints, found := self.ctrlSrvDiscovery.poll(); found {
+			log.Infof("using controller endpoints from SRV records for [%v]", self.config.Ctrl.Srv.Name)
+			return endpoints, nil
+		}
+		log.Info("unable to get controller endpoints from SRV records, falling back to endpoints file and config")
+	}
+
 	endpointsFile := self.config.Ctrl.EndpointsFile
 
 	var endpoints []string
@@ -1059,6 +1169,12 @@ type linkDetail struct {
 	Addresses    map[string]linkConnDetail `json:"addresses"`
 }
 
+type linkHealthDetail struct {
+	LinkCount int           `json:"linkCount"`
+	MinLinks  int           `json:"minLinks"`
+	Links     []*linkDetail `json:"links"`
+}
+
 type linkHealthCheck struct {
 	router   *Router
 	minLinks int
@@ -1106,8 +1222,13 @@ func (self *linkHealthCheck) Execute(ctx context.Context) (details interface{},
 			links = append(links, detail)
 		}
 	}
+	result := &linkHealthDetail{
+		LinkCount: len(links),
+		MinLinks:  self.minLinks,
+		Links:     links,
+	}
 	if len(links) < self.minLinks {
-		return links, errors.Errorf("link count %v less than configured minimum of %v", len(links), self.minLinks)
+		return result, errors.Errorf("link count %v less than configured minimum of %v", len(links), self.minLinks)
 	}
-	return links, nil
+	return result, nil
 }
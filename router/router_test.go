@@ -52,7 +52,9 @@ func Test_initializeCtrlEndpoints(t *testing.T) {
 				EndpointsFile         string
 				Heartbeats            env.HeartbeatOptions
 				StartupTimeout        time.Duration
+				ActiveActive          bool
 				RateLimit             command.AdaptiveRateLimiterConfig
+				Srv                   *env.CtrlSrvConfig
 			}{
 				EndpointsFile:    filepath.Join(tmpDir, "endpoints"),
 				InitialEndpoints: []*env.UpdatableAddress{env.NewUpdatableAddress(addr)},
@@ -94,13 +96,15 @@ func Test_updateCtrlEndpoints(t *testing.T) {
 				EndpointsFile         string
 				Heartbeats            env.HeartbeatOptions
 				StartupTimeout        time.Duration
+				ActiveActive          bool
 				RateLimit             command.AdaptiveRateLimiterConfig
+				Srv                   *env.CtrlSrvConfig
 			}{
 				EndpointsFile:    filepath.Join(tmpDir, "endpoints"),
 				InitialEndpoints: []*env.UpdatableAddress{env.NewUpdatableAddress(addr), env.NewUpdatableAddress(addr2)},
 			},
 		},
-		ctrls: env.NewNetworkControllers(time.Minute, ctrlDialer, env.NewDefaultHeartbeatOptions()),
+		ctrls: env.NewNetworkControllers(time.Minute, ctrlDialer, env.NewDefaultHeartbeatOptions(), false),
 	}
 
 	endpoints, err := r.getInitialCtrlEndpoints()
@@ -82,38 +82,52 @@ func (h *ApiSessionAddedHandler) ContentType() int32 {
 }
 
 func (h *ApiSessionAddedHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	req := &edge_ctrl_pb.ApiSessionAdded{}
+	if err := proto.Unmarshal(msg.Body, req); err != nil {
+		pfxlog.Logger().Panic("could not convert message as api session added")
+	}
+
+	var syncStrategyType string
+	var syncState *sync_strats.InstantSyncState
+	var syncHeaderErr error
+
+	// sequence checks must happen in the order messages are received, so they're done before handing off
+	if req.IsFullState {
+		syncStrategyType, syncState, syncHeaderErr = parseInstantSyncHeaders(msg)
+		if syncHeaderErr == nil && syncState.Sequence == 0 {
+			resetApiSessionSequence(h.sm, msg, ch)
+		}
+	} else {
+		checkApiSessionSequence(h.sm, msg, ch)
+	}
+
 	go func() {
-		req := &edge_ctrl_pb.ApiSessionAdded{}
-		if err := proto.Unmarshal(msg.Body, req); err == nil {
-			for _, session := range req.ApiSessions {
-				newApiSession := NewApiSessionTokenFromProtobuf(session, ch.Id())
-				h.sm.AddLegacyApiSession(newApiSession)
+		for _, session := range req.ApiSessions {
+			newApiSession := NewApiSessionTokenFromProtobuf(session, ch.Id())
+			h.sm.AddLegacyApiSession(newApiSession)
+		}
+
+		if req.IsFullState {
+			reqWithState := &apiSessionAddedWithState{
+				ApiSessionAdded: req,
+			}
+
+			if syncHeaderErr == nil {
+				reqWithState.SyncStrategyType = syncStrategyType
+				reqWithState.InstantSyncState = syncState
+			} else {
+				pfxlog.Logger().WithField("msgContentType", msg.ContentType).WithError(syncHeaderErr).Errorf("sync headers not present (old controller) or only partial present(error), treating as legacy: %v", syncHeaderErr)
 			}
 
-			if req.IsFullState {
-				reqWithState := &apiSessionAddedWithState{
-					ApiSessionAdded: req,
-				}
-
-				if syncStrategyType, syncState, err := parseInstantSyncHeaders(msg); err == nil {
-					reqWithState.SyncStrategyType = syncStrategyType
-					reqWithState.InstantSyncState = syncState
-				} else {
-					pfxlog.Logger().WithField("msgContentType", msg.ContentType).WithError(err).Errorf("sync headers not present (old controller) or only partial present(error), treating as legacy: %v", err)
-				}
-
-				h.reqChan <- reqWithState
-			} else if h.sm.IsSyncInProgress() {
-				reqWithState := &apiSessionAddedWithState{
-					SyncStrategyType: string(sync_strats.RouterSyncStrategyInstant),
-					ApiSessionAdded:  req,
-					isPostSyncData:   true,
-					InstantSyncState: &sync_strats.InstantSyncState{},
-				}
-				h.reqChan <- reqWithState
+			h.reqChan <- reqWithState
+		} else if h.sm.IsSyncInProgress() {
+			reqWithState := &apiSessionAddedWithState{
+				SyncStrategyType: string(sync_strats.RouterSyncStrategyInstant),
+				ApiSessionAdded:  req,
+				isPostSyncData:   true,
+				InstantSyncState: &sync_strats.InstantSyncState{},
 			}
-		} else {
-			pfxlog.Logger().Panic("could not convert message as api session added")
+			h.reqChan <- reqWithState
 		}
 	}()
 }
@@ -39,6 +39,8 @@ func (h *apiSessionRemovedHandler) ContentType() int32 {
 }
 
 func (h *apiSessionRemovedHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	checkApiSessionSequence(h.sm, msg, ch)
+
 	go func() {
 		req := &edge_ctrl_pb.ApiSessionRemoved{}
 		if err := proto.Unmarshal(msg.Body, req); err == nil {
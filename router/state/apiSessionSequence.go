@@ -0,0 +1,130 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package state
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	"github.com/openziti/ziti/controller/env"
+)
+
+// ApiSessionSequence tracks the last api session change received from a controller. Controllers number each api
+// session change they send. The router reports the last change it has seen when it reconnects, so the controller
+// can send only the changes that were missed rather than every api session. If a change is missed while connected,
+// the router requests a full sync.
+type ApiSessionSequence struct {
+	lock   sync.Mutex
+	logId  string
+	seq    uint64
+	synced bool
+}
+
+// Accept records a change received from the controller. It returns false if the change shows that earlier changes
+// were missed, in which case a full sync is needed. Once changes have been missed, further changes are ignored until
+// Reset is called at the start of the next full sync.
+func (self *ApiSessionSequence) Accept(logId string, seq uint64) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if !self.synced {
+		return true
+	}
+
+	if logId != self.logId || seq > self.seq+1 {
+		self.synced = false
+		return false
+	}
+
+	if seq == self.seq+1 {
+		self.seq = seq
+	}
+
+	return true
+}
+
+// Reset records the controller log position a full sync was taken at. Changes after that position are sent after
+// the first message of the full sync.
+func (self *ApiSessionSequence) Reset(logId string, seq uint64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.logId = logId
+	self.seq = seq
+	self.synced = logId != ""
+}
+
+// GetPosition returns the last change received, and whether every change up to it has been received
+func (self *ApiSessionSequence) GetPosition() (string, uint64, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.logId, self.seq, self.synced
+}
+
+func getApiSessionLogHeaders(msg *channel.Message) (string, uint64, bool) {
+	logId, ok := msg.Headers[env.ApiSessionLogIdHeader]
+	if !ok {
+		return "", 0, false
+	}
+
+	seq, ok := msg.Headers.GetUint64Header(env.ApiSessionLogSeqHeader)
+	if !ok {
+		return "", 0, false
+	}
+
+	return string(logId), seq, true
+}
+
+// resetApiSessionSequence handles the first message of a full api session sync, which carries the controller log
+// position the sync was taken at. Controllers which don't number their changes leave the sequence unsynced.
+func resetApiSessionSequence(sm Manager, msg *channel.Message, ch channel.Channel) {
+	logId, seq, _ := getApiSessionLogHeaders(msg)
+	sm.GetApiSessionSequence(ch.Id()).Reset(logId, seq)
+}
+
+// checkApiSessionSequence checks the sequence headers of an api session change from a controller and requests a
+// full sync if changes were missed. It must be called in the order messages are received, before the message is
+// handed off for processing. Messages without sequence headers, such as those from older controllers, are ignored.
+func checkApiSessionSequence(sm Manager, msg *channel.Message, ch channel.Channel) {
+	logId, seq, ok := getApiSessionLogHeaders(msg)
+	if !ok {
+		return
+	}
+
+	sequence := sm.GetApiSessionSequence(ch.Id())
+	_, lastSeq, _ := sequence.GetPosition()
+
+	if sequence.Accept(logId, seq) {
+		return
+	}
+
+	pfxlog.Logger().WithField("ctrlId", ch.Id()).
+		WithField("lastSeq", lastSeq).
+		WithField("seq", seq).
+		Warn("missed api session changes from controller, requesting resync")
+
+	resync := &edge_ctrl_pb.RequestClientReSync{
+		Reason: fmt.Sprintf("missed api session changes, last seen: %d, received: %d", lastSeq, seq),
+	}
+	if err := protobufs.MarshalTyped(resync).Send(ch); err != nil {
+		pfxlog.Logger().WithError(err).Error("failed to send request client re-sync message")
+	}
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ApiSessionSequence(t *testing.T) {
+	t.Run("not synced until reset", func(t *testing.T) {
+		req := require.New(t)
+		sequence := &ApiSessionSequence{}
+
+		req.True(sequence.Accept("log1", 5))
+		_, _, synced := sequence.GetPosition()
+		req.False(synced)
+
+		sequence.Reset("log1", 10)
+		logId, seq, synced := sequence.GetPosition()
+		req.True(synced)
+		req.Equal("log1", logId)
+		req.Equal(uint64(10), seq)
+	})
+
+	t.Run("advances on next sequence", func(t *testing.T) {
+		req := require.New(t)
+		sequence := &ApiSessionSequence{}
+		sequence.Reset("log1", 10)
+
+		req.True(sequence.Accept("log1", 11))
+		req.True(sequence.Accept("log1", 12))
+		req.True(sequence.Accept("log1", 12))
+
+		_, seq, synced := sequence.GetPosition()
+		req.True(synced)
+		req.Equal(uint64(12), seq)
+	})
+
+	t.Run("gap requires resync", func(t *testing.T) {
+		req := require.New(t)
+		sequence := &ApiSessionSequence{}
+		sequence.Reset("log1", 10)
+
+		req.False(sequence.Accept("log1", 12))
+		_, seq, synced := sequence.GetPosition()
+		req.False(synced)
+		req.Equal(uint64(10), seq)
+
+		// only reported once, until the next full sync
+		req.True(sequence.Accept("log1", 13))
+
+		sequence.Reset("log1", 13)
+		req.True(sequence.Accept("log1", 14))
+	})
+
+	t.Run("different log requires resync", func(t *testing.T) {
+		req := require.New(t)
+		sequence := &ApiSessionSequence{}
+		sequence.Reset("log1", 10)
+
+		req.False(sequence.Accept("log2", 11))
+	})
+
+	t.Run("reset without log id leaves sequence unsynced", func(t *testing.T) {
+		req := require.New(t)
+		sequence := &ApiSessionSequence{}
+		sequence.Reset("", 0)
+
+		_, _, synced := sequence.GetPosition()
+		req.False(synced)
+		req.True(sequence.Accept("log1", 100))
+	})
+}
@@ -39,6 +39,8 @@ func (h *ApiSessionUpdatedHandler) ContentType() int32 {
 }
 
 func (h *ApiSessionUpdatedHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
+	checkApiSessionSequence(h.sm, msg, ch)
+
 	go func() {
 		req := &edge_ctrl_pb.ApiSessionUpdated{}
 		if err := proto.Unmarshal(msg.Body, req); err == nil {
@@ -54,6 +54,7 @@ type CertExpirationChecker struct {
 	closeNotify  <-chan struct{}
 	ctrls        routerEnv.NetworkControllers
 	edgeConfig   *routerEnv.EdgeConfig
+	spiffe       routerEnv.SpiffeConfig
 	certsUpdated chan struct{}
 
 	isRunning atomic.Bool
@@ -71,6 +72,7 @@ func NewCertExpirationChecker(env CertEnv) *CertExpirationChecker {
 		closeNotify:     env.GetCloseNotify(),
 		ctrls:           env.GetNetworkControllers(),
 		edgeConfig:      env.GetConfig().Edge,
+		spiffe:          env.GetConfig().Spiffe,
 		certsUpdated:    make(chan struct{}, 1),
 		timeoutDuration: DefaultTimeoutDuration,
 	}
@@ -107,6 +109,10 @@ func (self *CertExpirationChecker) Run() error {
 		return errors.New("already running")
 	}
 
+	if self.spiffe.Enabled {
+		return self.runSvidChecks()
+	}
+
 	for {
 		//if we are already requesting then wait for the request to finish (certsUpdated), we give up based on
 		//timeoutDuration, or we are told to shut down
@@ -549,7 +549,7 @@ func newCertChecker() (*CertExpirationChecker, func()) {
 	ctrlDialer := env.CtrlDialer(func(address transport.Address, bindHandler channel.BindHandler) error {
 		return testChannel.Bind(bindHandler)
 	})
-	ctrls := env.NewNetworkControllers(time.Second, ctrlDialer, env.NewDefaultHeartbeatOptions())
+	ctrls := env.NewNetworkControllers(time.Second, ctrlDialer, env.NewDefaultHeartbeatOptions(), false)
 	ctrls.UpdateControllerEndpoints([]string{"tls:localhost:6262"})
 	start := time.Now()
 	for {
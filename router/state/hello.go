@@ -99,6 +99,12 @@ func (h *helloHandler) HandleReceive(msg *channel.Message, ch channel.Channel) {
 				}
 			}
 
+			// lets the controller send only the api session changes missed while disconnected
+			if logId, seq, synced := h.stateManager.GetApiSessionSequence(ch.Id()).GetPosition(); synced {
+				outMsg.Headers[env.ApiSessionLogIdHeader] = []byte(logId)
+				outMsg.Headers.PutUint64Header(env.ApiSessionLogSeqHeader, seq)
+			}
+
 			if err := outMsg.ReplyTo(msg).Send(ch); err != nil {
 				pfxlog.Logger().WithError(err).Error("could not send client hello")
 			}
@@ -198,6 +198,11 @@ type Manager interface {
 	// IsSyncInProgress returns whether a synchronization operation is currently active.
 	IsSyncInProgress() bool
 
+	// GetApiSessionSequence returns the tracker for the api session changes received
+	// from the given controller, used to detect missed changes and to resume from the
+	// last change seen when reconnecting.
+	GetApiSessionSequence(ctrlId string) *ApiSessionSequence
+
 	// VerifyClientCert validates client certificates against the router's trusted
 	// certificate authorities.
 	VerifyClientCert(cert *x509.Certificate) error
@@ -280,6 +285,7 @@ func NewManager(stateEnv env.RouterEnv) Manager {
 		EventEmmiter:             events.New(),
 		legacyApiSessionsByToken: cmap.New[*ApiSessionToken](),
 		recentlyRemovedSessions:  cmap.New[time.Time](),
+		apiSessionSequences:      cmap.New[*ApiSessionSequence](),
 		certCache:                cmap.New[*x509.Certificate](),
 		env:                      stateEnv,
 		routerDataModelPool:      routerDataModelPool,
@@ -293,7 +299,7 @@ func NewManager(stateEnv env.RouterEnv) Manager {
 	result.LoadRouterModel(stateEnv.GetConfig().Edge.Db)
 
 	stateEnv.GetNetworkControllers().AddChangeListener(env.CtrlEventListenerFunc(func(event env.CtrlEvent) {
-		if event.Type != env.ControllerLeaderChange {
+		if event.Type != env.ControllerLeaderChange && event.Type != env.ControllerResponsive {
 			select {
 			case result.endpointsChanged <- event:
 			default:
@@ -404,6 +410,8 @@ type ManagerImpl struct {
 	currentSync        string
 	syncLock           sync.Mutex
 
+	apiSessionSequences cmap.ConcurrentMap[string, *ApiSessionSequence]
+
 	certCache           cmap.ConcurrentMap[string, *x509.Certificate]
 	routerDataModel     atomic.Pointer[common.RouterDataModel]
 	routerDataModelPool goroutines.Pool
@@ -913,6 +921,17 @@ func (sm *ManagerImpl) IsSyncInProgress() bool {
 	return sm.currentSync != ""
 }
 
+// GetApiSessionSequence returns the tracker for the api session changes received
+// from the given controller, creating it if needed.
+func (sm *ManagerImpl) GetApiSessionSequence(ctrlId string) *ApiSessionSequence {
+	return sm.apiSessionSequences.Upsert(ctrlId, nil, func(exist bool, valueInMap *ApiSessionSequence, newValue *ApiSessionSequence) *ApiSessionSequence {
+		if exist {
+			return valueInMap
+		}
+		return &ApiSessionSequence{}
+	})
+}
+
 // AddLegacyApiSession registers controller-synchronized API sessions in the legacy
 // tracking store, specifically handling protobuf-based sessions that require
 // controller state synchronization rather than self-contained JWT tokens.
@@ -0,0 +1,100 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package state
+
+import (
+	"encoding/pem"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/cert"
+	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	"github.com/pkg/errors"
+)
+
+// runSvidChecks is used in place of enrollment extension when the router identity is an X.509 SVID. The SVID is
+// renewed externally and picked up by the identity file watcher. When the certificate changes, the new certificate
+// chain is sent to the controller so it can update the router's fingerprint.
+func (self *CertExpirationChecker) runSvidChecks() error {
+	log := pfxlog.Logger()
+	log.Infof("router identity is a SPIFFE SVID, checking for renewed certificates every %s", self.spiffe.CheckInterval)
+
+	reportedFingerprint := self.getSvidFingerprint()
+
+	ticker := time.NewTicker(self.spiffe.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-self.certsUpdated:
+		case <-self.closeNotify:
+			self.isRunning.Store(false)
+			return nil
+		}
+
+		fingerprint := self.getSvidFingerprint()
+		if fingerprint == "" || fingerprint == reportedFingerprint {
+			continue
+		}
+
+		if err := self.reportSvid(); err != nil {
+			log.WithError(err).WithField("fingerprint", fingerprint).Error("unable to report renewed SVID to controller, will retry")
+			continue
+		}
+
+		log.WithField("fingerprint", fingerprint).Info("renewed SVID reported to controller")
+		reportedFingerprint = fingerprint
+	}
+}
+
+func (self *CertExpirationChecker) getSvidFingerprint() string {
+	tlsCert := self.id.Cert()
+	if tlsCert == nil || len(tlsCert.Certificate) == 0 {
+		return ""
+	}
+	return cert.NewFingerprintGenerator().FromRaw(tlsCert.Certificate[0])
+}
+
+func (self *CertExpirationChecker) reportSvid() error {
+	var certPem []byte
+	for _, raw := range self.id.Cert().Certificate {
+		certPem = append(certPem, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw})...)
+	}
+
+	ctrlCh := self.ctrls.AnyCtrlChannel()
+	if ctrlCh == nil {
+		return errors.New("no controller available")
+	}
+
+	verifyRequest := &edge_ctrl_pb.EnrollmentExtendRouterVerifyRequest{
+		ClientCertPem: string(certPem),
+	}
+
+	replyMsg, err := protobufs.MarshalTyped(verifyRequest).WithTimeout(30 * time.Second).SendForReply(ctrlCh)
+	reply := &edge_ctrl_pb.Error{}
+	if err = protobufs.TypedResponse(reply).Unmarshall(replyMsg, err); err != nil {
+		return err
+	}
+
+	if reply.Code != "" {
+		return errors.Errorf("controller rejected SVID, code: %s, message: %s", reply.Code, reply.Message)
+	}
+
+	return nil
+}
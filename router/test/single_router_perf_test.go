@@ -125,6 +125,8 @@ func (t testFaultReceiver) Report(circuitId string, ctrlId string) {}
 
 func (t testFaultReceiver) NotifyInvalidLink(linkId string) {}
 
+func (t testFaultReceiver) RequestRouteRepair(circuitId string, ctrlId string, linkId string) {}
+
 type testXgCloseHandler struct{}
 
 func (t testXgCloseHandler) HandleXgressClose(x *xgress.Xgress) {
@@ -0,0 +1,78 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package trustbundle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultInterval = time.Hour
+	MinInterval     = 10 * time.Second
+)
+
+// Config controls whether the router keeps its CA bundle in sync with the controller's trust bundle. Syncing is
+// disabled by default.
+type Config struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Interval: DefaultInterval,
+	}
+}
+
+// LoadConfig parses a trust bundle config section, found at the given path. Example:
+//
+//	trustBundle:
+//	  enabled: true
+//	  interval: 1h
+func LoadConfig(value interface{}, path string) (*Config, error) {
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid %s configuration, expected map, got %T", path, value)
+	}
+
+	result := DefaultConfig()
+	result.Enabled = true
+
+	if value, found := submap["enabled"]; found {
+		enabled, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid %s.enabled [%v], must be a boolean", path, value)
+		}
+		result.Enabled = enabled
+	}
+
+	if value, found := submap["interval"]; found {
+		interval, err := time.ParseDuration(fmt.Sprintf("%v", value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s.interval [%v]", path, value)
+		}
+		if interval < MinInterval {
+			return nil, errors.Errorf("invalid %s.interval [%v], must be at least %v", path, value, MinInterval)
+		}
+		result.Interval = interval
+	}
+
+	return result, nil
+}
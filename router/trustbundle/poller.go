@@ -0,0 +1,184 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package trustbundle
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/identity"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/common/trustbundle"
+	"github.com/pkg/errors"
+)
+
+const (
+	initialDelay  = 10 * time.Second
+	retryInterval = time.Minute
+)
+
+// Controllers is the subset of the router's network controllers used to request trust bundle changes
+type Controllers interface {
+	AnyCtrlChannel() channel.Channel
+	DefaultRequestTimeout() time.Duration
+}
+
+// Poller periodically asks a controller for changes to the network trust bundle and applies them to the router's
+// CA bundle file, then reloads the router identity, so CA rotations reach the router without re-enrollment.
+//
+// Only CAs from the controller's own bundle are applied, never third party CAs. The first request after startup gets
+// the full bundle, and only adds missing CAs. CAs are only removed from the
+// file when a later delta reports that they were removed from the trust bundle. CAs in the file which never came
+// from the controller are left alone.
+type Poller struct {
+	config *Config
+	id     *identity.TokenId
+	ctrls  Controllers
+	etag   string
+}
+
+func NewPoller(config *Config, id *identity.TokenId, ctrls Controllers) *Poller {
+	return &Poller{
+		config: config,
+		id:     id,
+		ctrls:  ctrls,
+	}
+}
+
+func (self *Poller) Start(closeNotify <-chan struct{}) {
+	if self.config == nil || !self.config.Enabled {
+		return
+	}
+	go self.run(closeNotify)
+}
+
+func (self *Poller) run(closeNotify <-chan struct{}) {
+	log := pfxlog.Logger()
+	delay := initialDelay
+
+	for {
+		select {
+		case <-time.After(delay):
+		case <-closeNotify:
+			return
+		}
+
+		if err := self.poll(); err != nil {
+			log.WithError(err).Warn("unable to sync trust bundle from controller")
+			delay = min(retryInterval, self.config.Interval)
+		} else {
+			delay = self.config.Interval
+		}
+	}
+}
+
+func (self *Poller) poll() error {
+	ch := self.ctrls.AnyCtrlChannel()
+	if ch == nil {
+		return errors.New("no controller available")
+	}
+
+	reply, err := ctrl_msg.NewTrustBundleRequest(self.etag).WithTimeout(self.ctrls.DefaultRequestTimeout()).SendForReply(ch)
+	if err != nil {
+		return errors.Wrap(err, "trust bundle request failed")
+	}
+
+	delta, err := ctrl_msg.DecodeTrustBundleResponse(reply)
+	if err != nil {
+		return err
+	}
+
+	if err = self.apply(delta); err != nil {
+		return err
+	}
+
+	self.etag = delta.ETag
+	return nil
+}
+
+func (self *Poller) apply(delta *trustbundle.Delta) error {
+	// the router CA bundle is used to verify controller and link connections, so third party CAs, which are only
+	// trusted for identity authentication, must never be written to it
+	delta = delta.FilterSource(trustbundle.SourceController)
+
+	if delta.IsEmpty() {
+		return nil
+	}
+
+	path, isFile := identity.IsFile(self.id.GetConfig().CA)
+	if !isFile {
+		return errors.New("router identity CA bundle isn't stored in a file, unable to apply trust bundle changes")
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read CA bundle [%s]", path)
+	}
+
+	updated, changed, err := trustbundle.Apply(current, delta)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err = writeFileAtomic(path, updated); err != nil {
+		return errors.Wrapf(err, "unable to write CA bundle [%s]", path)
+	}
+
+	pfxlog.Logger().WithField("etag", delta.ETag).
+		WithField("path", path).
+		WithField("added", len(delta.Added)).
+		WithField("removed", len(delta.Removed)).
+		Info("CA bundle updated from controller trust bundle, reloading identity")
+
+	return self.id.Reload()
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err = tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,200 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package trustbundle
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openziti/identity"
+	"github.com/openziti/ziti/common/trustbundle"
+	"github.com/stretchr/testify/require"
+)
+
+type testCa struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCa(t *testing.T, name string) *testCa {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCa{cert: cert, key: key}
+}
+
+func toPem(certs ...*x509.Certificate) []byte {
+	var result []byte
+	for _, cert := range certs {
+		result = append(result, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return result
+}
+
+func newTestIdentity(t *testing.T, caCerts ...*x509.Certificate) (*identity.TokenId, string) {
+	dir := t.TempDir()
+	self := newTestCa(t, "router")
+
+	keyDer, err := x509.MarshalECPrivateKey(self.key)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(dir, "router.cert")
+	keyPath := filepath.Join(dir, "router.key")
+	caPath := filepath.Join(dir, "ca.cert")
+
+	require.NoError(t, os.WriteFile(certPath, toPem(self.cert), 0600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	require.NoError(t, os.WriteFile(caPath, toPem(caCerts...), 0640))
+
+	id, err := identity.LoadIdentity(identity.Config{
+		Cert: certPath,
+		Key:  keyPath,
+		CA:   caPath,
+	})
+	require.NoError(t, err)
+
+	return &identity.TokenId{Identity: id}, caPath
+}
+
+func TestPollerApply(t *testing.T) {
+	local := newTestCa(t, "local")
+	root := newTestCa(t, "root")
+	intermediate := newTestCa(t, "intermediate")
+
+	t.Run("delta is written and identity reloaded", func(t *testing.T) {
+		req := require.New(t)
+		id, caPath := newTestIdentity(t, local.cert, intermediate.cert)
+		poller := NewPoller(DefaultConfig(), id, nil)
+
+		err := poller.apply(&trustbundle.Delta{
+			ETag:    "v2",
+			Added:   []*trustbundle.Certificate{trustbundle.NewCertificate(root.cert, trustbundle.SourceController)},
+			Removed: []string{trustbundle.Fingerprint(intermediate.cert)},
+		})
+		req.NoError(err)
+
+		contents, err := os.ReadFile(caPath)
+		req.NoError(err)
+		req.Equal(toPem(local.cert, root.cert), contents)
+
+		info, err := os.Stat(caPath)
+		req.NoError(err)
+		req.Equal(os.FileMode(0640), info.Mode().Perm())
+
+		pool := id.CaPool()
+		req.Len(pool.Roots(), 2)
+
+		entries, err := os.ReadDir(filepath.Dir(caPath))
+		req.NoError(err)
+		req.Len(entries, 3)
+	})
+
+	t.Run("unchanged bundle isn't rewritten", func(t *testing.T) {
+		req := require.New(t)
+		id, caPath := newTestIdentity(t, root.cert)
+		poller := NewPoller(DefaultConfig(), id, nil)
+
+		before, err := os.Stat(caPath)
+		req.NoError(err)
+
+		err = poller.apply(&trustbundle.Delta{
+			ETag:  "v1",
+			Full:  true,
+			Added: []*trustbundle.Certificate{trustbundle.NewCertificate(root.cert, trustbundle.SourceController)},
+		})
+		req.NoError(err)
+
+		after, err := os.Stat(caPath)
+		req.NoError(err)
+		req.Equal(before.ModTime(), after.ModTime())
+	})
+
+	t.Run("third party CAs aren't written", func(t *testing.T) {
+		req := require.New(t)
+		third := newTestCa(t, "third")
+		id, caPath := newTestIdentity(t, local.cert)
+		poller := NewPoller(DefaultConfig(), id, nil)
+
+		err := poller.apply(&trustbundle.Delta{
+			ETag: "v1",
+			Full: true,
+			Added: []*trustbundle.Certificate{
+				trustbundle.NewCertificate(root.cert, trustbundle.SourceController),
+				trustbundle.NewCertificate(third.cert, trustbundle.SourceThirdParty),
+			},
+		})
+		req.NoError(err)
+
+		contents, err := os.ReadFile(caPath)
+		req.NoError(err)
+		req.Equal(toPem(local.cert, root.cert), contents)
+	})
+
+	t.Run("inline ca can't be updated", func(t *testing.T) {
+		req := require.New(t)
+		id, _ := newTestIdentity(t, root.cert)
+		id.GetConfig().CA = "pem:" + string(toPem(root.cert))
+		poller := NewPoller(DefaultConfig(), id, nil)
+
+		err := poller.apply(&trustbundle.Delta{
+			ETag:  "v1",
+			Added: []*trustbundle.Certificate{trustbundle.NewCertificate(local.cert, trustbundle.SourceController)},
+		})
+		req.Error(err)
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("section enables sync", func(t *testing.T) {
+		req := require.New(t)
+		cfg, err := LoadConfig(map[interface{}]interface{}{"interval": "15m"}, "trustBundle")
+		req.NoError(err)
+		req.True(cfg.Enabled)
+		req.Equal(15*time.Minute, cfg.Interval)
+	})
+
+	t.Run("interval too short", func(t *testing.T) {
+		req := require.New(t)
+		_, err := LoadConfig(map[interface{}]interface{}{"interval": "1s"}, "trustBundle")
+		req.Error(err)
+	})
+}
@@ -0,0 +1,96 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package update
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// RestartExec replaces the running router process with the new binary, keeping the same arguments and environment
+	RestartExec = "exec"
+
+	// RestartExit exits the router with RestartExitCode, relying on a service manager, such as systemd, to start it
+	// again
+	RestartExit = "exit"
+
+	RestartExitCode = 3
+
+	DefaultDownloadTimeout = 5 * time.Minute
+)
+
+// Config controls whether the controller may update the router binary. Updates are disabled by default.
+type Config struct {
+	Enabled         bool
+	Restart         string
+	DownloadTimeout time.Duration
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Restart:         defaultRestart,
+		DownloadTimeout: DefaultDownloadTimeout,
+	}
+}
+
+// LoadConfig parses an update config section, found at the given path. Example:
+//
+//	update:
+//	  enabled: true
+//	  restart: exec
+//	  downloadTimeout: 5m
+func LoadConfig(value interface{}, path string) (*Config, error) {
+	submap, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.Errorf("invalid %s configuration, expected map, got %T", path, value)
+	}
+
+	result := DefaultConfig()
+	result.Enabled = true
+
+	if value, found := submap["enabled"]; found {
+		enabled, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid %s.enabled [%v], must be a boolean", path, value)
+		}
+		result.Enabled = enabled
+	}
+
+	if value, found := submap["restart"]; found {
+		restart := fmt.Sprintf("%v", value)
+		if restart != RestartExec && restart != RestartExit {
+			return nil, errors.Errorf("invalid %s.restart [%v], must be one of %s or %s", path, value, RestartExec, RestartExit)
+		}
+		result.Restart = restart
+	}
+
+	if value, found := submap["downloadTimeout"]; found {
+		timeout, err := time.ParseDuration(fmt.Sprintf("%v", value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s.downloadTimeout [%v]", path, value)
+		}
+		if timeout < time.Second {
+			return nil, errors.Errorf("invalid %s.downloadTimeout [%v], must be at least 1s", path, value)
+		}
+		result.DownloadTimeout = timeout
+	}
+
+	return result, nil
+}
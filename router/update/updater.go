@@ -0,0 +1,237 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package update
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/pkg/errors"
+)
+
+// BackupSuffix is appended to the path of the router binary to name the copy kept when an update is applied
+const BackupSuffix = ".previous"
+
+// Updater replaces the router binary with versions pushed by the controller. A new binary is first staged, which
+// downloads it next to the running binary and verifies its sha256 checksum. Applying the staged binary swaps it in,
+// keeping the previous binary as a backup, after which the router is restarted.
+type Updater struct {
+	config     *Config
+	executable string
+	lock       sync.Mutex
+	staged     *stagedBinary
+}
+
+type stagedBinary struct {
+	version string
+	path    string
+}
+
+func NewUpdater(config *Config) *Updater {
+	return &Updater{
+		config: config,
+	}
+}
+
+func (self *Updater) IsEnabled() bool {
+	return self.config != nil && self.config.Enabled
+}
+
+// GetStagedVersion returns the version of the staged binary, or an empty string if nothing is staged
+func (self *Updater) GetStagedVersion() string {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.staged == nil {
+		return ""
+	}
+	return self.staged.version
+}
+
+func (self *Updater) getExecutable() (string, error) {
+	if self.executable != "" {
+		return self.executable, nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to determine router executable")
+	}
+
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve router executable")
+	}
+
+	self.executable = executable
+	return executable, nil
+}
+
+// Stage downloads the binary for the given version and verifies it against the given hex encoded sha256 checksum.
+// Any previously staged binary is discarded.
+func (self *Updater) Stage(version, url, checksum string) error {
+	expected, err := hex.DecodeString(strings.TrimSpace(checksum))
+	if err != nil || len(expected) != sha256.Size {
+		return errors.Errorf("invalid sha256 checksum [%s]", checksum)
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.discard()
+
+	executable, err := self.getExecutable()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(executable)
+	if err != nil {
+		return errors.Wrapf(err, "unable to stat router executable %s", executable)
+	}
+
+	client := &http.Client{Timeout: self.config.DownloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return errors.Wrapf(err, "unable to download router version %s from %s", version, url)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unable to download router version %s from %s, status: %s", version, url, resp.Status)
+	}
+
+	// the binary is staged in the same directory as the running binary, so that it can be swapped in with a rename
+	f, err := os.CreateTemp(filepath.Dir(executable), filepath.Base(executable)+".update-*")
+	if err != nil {
+		return errors.Wrap(err, "unable to create file for staged router binary")
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return errors.Wrapf(err, "unable to download router version %s from %s", version, url)
+	}
+
+	if actual := hash.Sum(nil); !bytes.Equal(actual, expected) {
+		_ = os.Remove(f.Name())
+		return errors.Errorf("sha256 checksum of downloaded router version %s is %s, expected %s",
+			version, hex.EncodeToString(actual), hex.EncodeToString(expected))
+	}
+
+	if err = os.Chmod(f.Name(), info.Mode().Perm()); err != nil {
+		_ = os.Remove(f.Name())
+		return errors.Wrap(err, "unable to set permissions on staged router binary")
+	}
+
+	self.staged = &stagedBinary{
+		version: version,
+		path:    f.Name(),
+	}
+
+	pfxlog.Logger().WithField("version", version).WithField("path", f.Name()).Info("staged router binary")
+	return nil
+}
+
+// Apply replaces the router binary with the staged binary for the given version. The replaced binary is kept, with
+// BackupSuffix appended to its name. The new binary is used once the router is restarted.
+func (self *Updater) Apply(version string) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.staged == nil {
+		return errors.Errorf("no router binary staged, unable to apply version %s", version)
+	}
+
+	if self.staged.version != version {
+		return errors.Errorf("staged router binary is version %s, unable to apply version %s", self.staged.version, version)
+	}
+
+	executable, err := self.getExecutable()
+	if err != nil {
+		return err
+	}
+
+	backup := executable + BackupSuffix
+	if err = os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to remove previous router binary backup %s", backup)
+	}
+
+	if err = os.Rename(executable, backup); err != nil {
+		return errors.Wrapf(err, "unable to back up router binary to %s", backup)
+	}
+
+	if err = os.Rename(self.staged.path, executable); err != nil {
+		if restoreErr := os.Rename(backup, executable); restoreErr != nil {
+			pfxlog.Logger().WithError(restoreErr).Errorf("unable to restore router binary from %s", backup)
+		}
+		return errors.Wrap(err, "unable to install staged router binary")
+	}
+
+	pfxlog.Logger().WithField("version", version).WithField("backup", backup).Info("installed router binary")
+	self.staged = nil
+	return nil
+}
+
+// Discard removes the staged binary, if there is one
+func (self *Updater) Discard() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.discard()
+}
+
+func (self *Updater) discard() {
+	if self.staged != nil {
+		if err := os.Remove(self.staged.path); err != nil && !os.IsNotExist(err) {
+			pfxlog.Logger().WithError(err).WithField("path", self.staged.path).Error("unable to remove staged router binary")
+		}
+		self.staged = nil
+	}
+}
+
+// Restart restarts the router using the configured restart mode. If the process can't be replaced in place, the
+// router exits with RestartExitCode. Restart only returns if the executable can't be determined.
+func (self *Updater) Restart() error {
+	executable, err := self.getExecutable()
+	if err != nil {
+		return err
+	}
+
+	log := pfxlog.Logger().WithField("restart", self.config.Restart)
+	log.Info("restarting router after update")
+
+	if self.config.Restart == RestartExec {
+		err = execProcess(executable)
+		log.WithError(err).Error("unable to restart router in place, exiting")
+	}
+
+	os.Exit(RestartExitCode)
+	return nil
+}
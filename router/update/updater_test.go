@@ -0,0 +1,142 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUpdater(t *testing.T) (*Updater, string) {
+	executable := filepath.Join(t.TempDir(), "ziti")
+	require.NoError(t, os.WriteFile(executable, []byte("old"), 0755))
+
+	updater := NewUpdater(DefaultConfig())
+	updater.executable = executable
+	return updater, executable
+}
+
+func newTestBinaryServer(t *testing.T, contents string) (string, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ziti" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(contents))
+	}))
+	t.Cleanup(server.Close)
+
+	checksum := sha256.Sum256([]byte(contents))
+	return server.URL + "/ziti", hex.EncodeToString(checksum[:])
+}
+
+func TestStageAndApply(t *testing.T) {
+	req := require.New(t)
+	updater, executable := newTestUpdater(t)
+	url, checksum := newTestBinaryServer(t, "new")
+
+	req.NoError(updater.Stage("v1.2.3", url, checksum))
+	req.Equal("v1.2.3", updater.GetStagedVersion())
+
+	contents, err := os.ReadFile(executable)
+	req.NoError(err)
+	req.Equal("old", string(contents))
+
+	req.Error(updater.Apply("v1.2.4"))
+	req.NoError(updater.Apply("v1.2.3"))
+	req.Equal("", updater.GetStagedVersion())
+
+	contents, err = os.ReadFile(executable)
+	req.NoError(err)
+	req.Equal("new", string(contents))
+
+	contents, err = os.ReadFile(executable + BackupSuffix)
+	req.NoError(err)
+	req.Equal("old", string(contents))
+
+	info, err := os.Stat(executable)
+	req.NoError(err)
+	req.Equal(os.FileMode(0755), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(executable))
+	req.NoError(err)
+	req.Len(entries, 2)
+}
+
+func TestStageRejectsBadDownloads(t *testing.T) {
+	req := require.New(t)
+	updater, executable := newTestUpdater(t)
+	url, _ := newTestBinaryServer(t, "new")
+	_, otherChecksum := newTestBinaryServer(t, "other")
+
+	req.ErrorContains(updater.Stage("v1.2.3", url, otherChecksum), "sha256 checksum")
+	req.Error(updater.Stage("v1.2.3", url+".missing", otherChecksum))
+	req.Error(updater.Stage("v1.2.3", url, "not-a-checksum"))
+	req.Equal("", updater.GetStagedVersion())
+	req.Error(updater.Apply("v1.2.3"))
+
+	entries, err := os.ReadDir(filepath.Dir(executable))
+	req.NoError(err)
+	req.Len(entries, 1)
+}
+
+func TestDiscard(t *testing.T) {
+	req := require.New(t)
+	updater, executable := newTestUpdater(t)
+	url, checksum := newTestBinaryServer(t, "new")
+
+	req.NoError(updater.Stage("v1.2.3", url, checksum))
+	updater.Discard()
+	req.Equal("", updater.GetStagedVersion())
+
+	entries, err := os.ReadDir(filepath.Dir(executable))
+	req.NoError(err)
+	req.Len(entries, 1)
+}
+
+func TestLoadConfig(t *testing.T) {
+	req := require.New(t)
+
+	config, err := LoadConfig(map[interface{}]interface{}{}, "update")
+	req.NoError(err)
+	req.True(config.Enabled)
+	req.Equal(DefaultDownloadTimeout, config.DownloadTimeout)
+
+	config, err = LoadConfig(map[interface{}]interface{}{
+		"enabled":         false,
+		"restart":         RestartExit,
+		"downloadTimeout": "30s",
+	}, "update")
+	req.NoError(err)
+	req.False(config.Enabled)
+	req.Equal(RestartExit, config.Restart)
+	req.Equal(30*time.Second, config.DownloadTimeout)
+
+	_, err = LoadConfig(map[interface{}]interface{}{"restart": "reboot"}, "update")
+	req.Error(err)
+
+	_, err = LoadConfig(map[interface{}]interface{}{"downloadTimeout": "10ms"}, "update")
+	req.Error(err)
+}
@@ -161,6 +161,14 @@ func (self *Acceptor) BindChannel(binding channel.Binding) error {
 		self.connStateTracker.markDisconnected(identityId, ch)
 	}))
 
+	if supportsServiceUpdates, _ := channel.Headers(binding.GetChannel().Headers()).GetBoolHeader(SupportsServiceUpdatesHeader); supportsServiceUpdates {
+		sender := conn.ch.GetControlSender()
+		self.listener.factory.serviceUpdates.add(identityId, sender)
+		binding.AddCloseHandler(channel.CloseHandlerF(func(channel.Channel) {
+			self.listener.factory.serviceUpdates.remove(identityId, sender)
+		}))
+	}
+
 	self.connectSuccessMeter.Mark(1)
 	self.connectionCount.Add(1)
 
@@ -202,7 +210,7 @@ func (d DebugPeekHandler) Close(_ channel.Channel) {
 }
 
 func NewAcceptor(listener *listener, uListener channel.UnderlayListener) *Acceptor {
-	sessionHandler := newSessionConnectHandler(listener.factory.stateManager, listener.options, listener.factory.metricsRegistry)
+	sessionHandler := newSessionConnectHandler(listener.factory.stateManager, listener.options, listener.factory.metricsRegistry, listener.factory.ocspChecker)
 
 	optionsWithBind := listener.options.channelOptions
 	if optionsWithBind == nil {
@@ -457,21 +457,25 @@ type sessionConnectionHandler struct {
 	options                          *Options
 	invalidApiSessionToken           metrics.Meter
 	invalidApiSessionTokenDuringSync metrics.Meter
+	revokedClientCert                metrics.Meter
+	ocspChecker                      *ocspChecker
 }
 
-func newSessionConnectHandler(stateManager state.Manager, options *Options, metricsRegistry metrics.Registry) *sessionConnectionHandler {
+func newSessionConnectHandler(stateManager state.Manager, options *Options, metricsRegistry metrics.Registry, ocspChecker *ocspChecker) *sessionConnectionHandler {
 	return &sessionConnectionHandler{
 		stateManager:                     stateManager,
 		options:                          options,
 		invalidApiSessionToken:           metricsRegistry.Meter("edge.invalid_api_tokens"),
 		invalidApiSessionTokenDuringSync: metricsRegistry.Meter("edge.invalid_api_tokens_during_sync"),
+		revokedClientCert:                metricsRegistry.Meter("edge.revoked_client_certs"),
+		ocspChecker:                      ocspChecker,
 	}
 }
 
 // validateApiSession performs security validation of an incoming SDK connection by verifying
 // the API session token and client certificate authenticity. The validation process includes
-// session token verification, certificate chain validation, fingerprint matching, and SPIFFE
-// ID verification when present.
+// session token verification, certificate chain validation, fingerprint matching, SPIFFE
+// ID verification when present, and an OCSP revocation check when enabled.
 //
 // Failed validation results in connection termination and security event logging.
 //
@@ -530,12 +534,20 @@ func (handler *sessionConnectionHandler) validateApiSession(binding channel.Bind
 		isValid = handler.validateByFingerprint(apiSession, fingerprint)
 	}
 
-	if isValid {
-		return nil
+	if !isValid {
+		_ = ch.Close()
+		return errors.New("invalid client certificate for api session")
+	}
+
+	if handler.ocspChecker != nil {
+		if err := handler.ocspChecker.check(fingerprint, certificates); err != nil {
+			handler.revokedClientCert.Mark(1)
+			_ = ch.Close()
+			return err
+		}
 	}
 
-	_ = ch.Close()
-	return errors.New("invalid client certificate for api session")
+	return nil
 }
 
 // completeBinding finalizes the connection setup after successful validation by registering
@@ -215,6 +215,7 @@ func (dialer *dialer) dialSdkXgress(terminator *edgeTerminator, params xgress_ro
 		connId:         connId,
 		metrics:        dialer.factory.env.GetXgressMetrics(),
 		tags:           params.GetCircuitTags(),
+		qos:            dialer.factory.newQosShaper(terminator.getIdentityId(), terminator.serviceSessionToken.ServiceId),
 	}
 
 	edgeForwarder.RegisterRouting()
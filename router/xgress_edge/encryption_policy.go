@@ -0,0 +1,110 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_edge
+
+import (
+	"encoding/json"
+
+	"github.com/openziti/channel/v4"
+	sdkedge "github.com/openziti/sdk-golang/ziti/edge"
+	"github.com/openziti/ziti/common"
+	"github.com/pkg/errors"
+)
+
+// EncryptionConfigTypeName is the name of the config type used to set the end-to-end encryption policy for a
+// service. When attached as an identity service config override, the policy applies only to that identity's use
+// of the service.
+const EncryptionConfigTypeName = "e2e-encryption.v1"
+
+// EncryptionPolicy controls whether edge routers permit end-to-end encryption between the dialing SDK and the
+// hosting application.
+type EncryptionPolicy string
+
+const (
+	// EncryptionPolicyAllowed leaves the decision to the SDKs. This is the behavior if no policy is configured.
+	EncryptionPolicyAllowed EncryptionPolicy = "allowed"
+
+	// EncryptionPolicyRequired rejects dials and binds which don't provide a public key.
+	EncryptionPolicyRequired EncryptionPolicy = "required"
+
+	// EncryptionPolicyDisabled strips public keys from dials and binds, so no end-to-end encryption is negotiated.
+	EncryptionPolicyDisabled EncryptionPolicy = "disabled"
+)
+
+type encryptionConfig struct {
+	Policy EncryptionPolicy `json:"policy"`
+}
+
+func ParseEncryptionPolicy(dataJson string) (EncryptionPolicy, error) {
+	config := &encryptionConfig{}
+	if err := json.Unmarshal([]byte(dataJson), config); err != nil {
+		return "", errors.Wrapf(err, "invalid %s config", EncryptionConfigTypeName)
+	}
+
+	switch config.Policy {
+	case EncryptionPolicyAllowed, EncryptionPolicyRequired, EncryptionPolicyDisabled:
+		return config.Policy, nil
+	case "":
+		return EncryptionPolicyAllowed, nil
+	default:
+		return "", errors.Errorf("invalid %s config, unknown policy '%s'", EncryptionConfigTypeName, config.Policy)
+	}
+}
+
+// Apply checks the given dial or bind request against the policy. Requests which violate a required policy
+// result in an error. If encryption is disabled, the public key header is removed from the request.
+func (self EncryptionPolicy) Apply(req *channel.Message) error {
+	switch self {
+	case EncryptionPolicyRequired:
+		if _, found := req.Headers[sdkedge.PublicKeyHeader]; !found {
+			return errors.New("service requires end-to-end encryption, but no public key was provided")
+		}
+	case EncryptionPolicyDisabled:
+		delete(req.Headers, sdkedge.PublicKeyHeader)
+	}
+	return nil
+}
+
+// getEncryptionPolicy returns the e2e-encryption.v1 policy which applies to the given identity's use of the given
+// service. If no policy is configured, the policy is allowed. If the policy can't be determined, because the router
+// data model isn't available or the config is invalid, an error is returned and the request should be rejected.
+func (factory *Factory) getEncryptionPolicy(identityId, serviceId string) (EncryptionPolicy, error) {
+	return lookupEncryptionPolicy(factory.stateManager.RouterDataModel(), identityId, serviceId)
+}
+
+func lookupEncryptionPolicy(rdm *common.RouterDataModel, identityId, serviceId string) (EncryptionPolicy, error) {
+	if rdm == nil {
+		return "", errors.New("unable to check end-to-end encryption policy, router data model not available")
+	}
+
+	configs, err := rdm.GetServiceConfigs(identityId, serviceId)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to check end-to-end encryption policy")
+	}
+
+	config, found := configs[EncryptionConfigTypeName]
+	if !found || config.Config == nil {
+		return EncryptionPolicyAllowed, nil
+	}
+
+	policy, err := ParseEncryptionPolicy(config.Config.DataJson)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to apply end-to-end encryption policy from config [%s]", config.Config.Id)
+	}
+
+	return policy, nil
+}
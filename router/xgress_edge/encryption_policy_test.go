@@ -0,0 +1,120 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_edge
+
+import (
+	"testing"
+
+	"github.com/openziti/channel/v4"
+	sdkedge "github.com/openziti/sdk-golang/ziti/edge"
+	"github.com/openziti/ziti/common"
+	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEncryptionPolicy(t *testing.T) {
+	req := require.New(t)
+
+	policy, err := ParseEncryptionPolicy(`{"policy": "required"}`)
+	req.NoError(err)
+	req.Equal(EncryptionPolicyRequired, policy)
+
+	policy, err = ParseEncryptionPolicy(`{}`)
+	req.NoError(err)
+	req.Equal(EncryptionPolicyAllowed, policy)
+
+	_, err = ParseEncryptionPolicy(`{"policy": "sometimes"}`)
+	req.Error(err)
+}
+
+func TestEncryptionPolicyApply(t *testing.T) {
+	req := require.New(t)
+
+	newRequest := func(withKey bool) *channel.Message {
+		msg := channel.NewMessage(sdkedge.ContentTypeConnect, nil)
+		if withKey {
+			msg.Headers[sdkedge.PublicKeyHeader] = []byte("key")
+		}
+		return msg
+	}
+
+	req.NoError(EncryptionPolicyAllowed.Apply(newRequest(false)))
+	req.NoError(EncryptionPolicyRequired.Apply(newRequest(true)))
+	req.Error(EncryptionPolicyRequired.Apply(newRequest(false)))
+
+	msg := newRequest(true)
+	req.NoError(EncryptionPolicyDisabled.Apply(msg))
+	_, found := msg.Headers[sdkedge.PublicKeyHeader]
+	req.False(found)
+}
+
+func TestLookupEncryptionPolicy(t *testing.T) {
+	rdm := common.NewBareRouterDataModel()
+	rdm.ConfigTypes.Set("e2e", &common.ConfigType{
+		DataStateConfigType: &edge_ctrl_pb.DataState_ConfigType{Id: "e2e", Name: EncryptionConfigTypeName},
+	})
+	rdm.Configs.Set("required", &common.Config{
+		DataStateConfig: &edge_ctrl_pb.DataState_Config{Id: "required", TypeId: "e2e", DataJson: `{"policy": "required"}`},
+	})
+	rdm.Configs.Set("invalid", &common.Config{
+		DataStateConfig: &edge_ctrl_pb.DataState_Config{Id: "invalid", TypeId: "e2e", DataJson: `{"policy": "sometimes"}`},
+	})
+	rdm.Identities.Set("identity", &common.Identity{
+		DataStateIdentity: &edge_ctrl_pb.DataState_Identity{Id: "identity"},
+	})
+	rdm.Services.Set("unconfigured", &common.Service{
+		DataStateService: &edge_ctrl_pb.DataState_Service{Id: "unconfigured"},
+	})
+	rdm.Services.Set("required", &common.Service{
+		DataStateService: &edge_ctrl_pb.DataState_Service{Id: "required", Configs: []string{"required"}},
+	})
+	rdm.Services.Set("invalid", &common.Service{
+		DataStateService: &edge_ctrl_pb.DataState_Service{Id: "invalid", Configs: []string{"invalid"}},
+	})
+
+	t.Run("unconfigured services are allowed", func(t *testing.T) {
+		req := require.New(t)
+		policy, err := lookupEncryptionPolicy(rdm, "identity", "unconfigured")
+		req.NoError(err)
+		req.Equal(EncryptionPolicyAllowed, policy)
+	})
+
+	t.Run("configured policy is returned", func(t *testing.T) {
+		req := require.New(t)
+		policy, err := lookupEncryptionPolicy(rdm, "identity", "required")
+		req.NoError(err)
+		req.Equal(EncryptionPolicyRequired, policy)
+	})
+
+	t.Run("invalid config fails closed", func(t *testing.T) {
+		req := require.New(t)
+		_, err := lookupEncryptionPolicy(rdm, "identity", "invalid")
+		req.ErrorContains(err, "invalid")
+	})
+
+	t.Run("unknown service fails closed", func(t *testing.T) {
+		req := require.New(t)
+		_, err := lookupEncryptionPolicy(rdm, "identity", "unknown")
+		req.Error(err)
+	})
+
+	t.Run("missing router data model fails closed", func(t *testing.T) {
+		req := require.New(t)
+		_, err := lookupEncryptionPolicy(nil, "identity", "required")
+		req.ErrorContains(err, "router data model not available")
+	})
+}
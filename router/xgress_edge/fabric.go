@@ -31,6 +31,7 @@ import (
 	"github.com/openziti/sdk-golang/xgress"
 	"github.com/openziti/sdk-golang/ziti/edge"
 	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	"github.com/openziti/ziti/router/qos"
 	"github.com/openziti/ziti/router/state"
 	"github.com/openziti/ziti/router/xgress_common"
 	"github.com/pkg/errors"
@@ -242,9 +243,11 @@ func (self *edgeTerminator) newConnection(connId uint32) (*edgeXgressConn, error
 		mux:        mux,
 		MsgChannel: *edge.NewEdgeMsgChannel(self.edgeClientConn.ch, connId),
 		seq:        NewMsgQueue(4),
+		qos:        self.edgeClientConn.listener.factory.newQosShaper(self.getIdentityId(), self.serviceSessionToken.ServiceId),
 	}
 
 	if err := mux.Add(result); err != nil {
+		result.qos.Close()
 		return nil, err
 	}
 
@@ -272,6 +275,7 @@ type edgeXgressConn struct {
 	onClose func()
 	closed  atomic.Bool
 	ctrlRx  xgress.ControlReceiver
+	qos     *qos.Shaper
 
 	data atomic.Pointer[state.ConnState]
 }
@@ -347,6 +351,9 @@ func (self *edgeXgressConn) ReadPayload() ([]byte, map[uint8][]byte, error) {
 	switch msg.ContentType {
 	case edge.ContentTypeData:
 		log.Debugf("received data message with payload size %v", len(msg.Body))
+		if err := self.qos.WaitIngress(len(msg.Body)); err != nil {
+			return nil, nil, xgress.ErrPeerClosed // the shaper is only closed when the connection is closed
+		}
 		return msg.Body, self.getHeaderMap(msg), nil
 
 	case edge.ContentTypeStateClosed:
@@ -388,6 +395,10 @@ func (self *edgeXgressConn) WritePayload(p []byte, headers map[uint8][]byte) (n
 	self.TraceMsg("write", msg)
 	pfxlog.Logger().WithFields(edge.GetLoggerFields(msg)).Tracef("writing %v bytes", len(p))
 
+	if err = self.qos.WaitEgress(len(p)); err != nil {
+		return 0, err
+	}
+
 	if err = self.GetDefaultSender().Send(msg); err != nil {
 		return 0, err
 	}
@@ -421,6 +432,7 @@ func (self *edgeXgressConn) close(notify bool, reason string) {
 	// to terminate
 	log.Debug("closing channel sequencer, which should cause xgress to close")
 	self.seq.Close()
+	self.qos.Close()
 
 	// we must close the sequencer first, otherwise we can deadlock. The channel rxer can be blocked submitting
 	// the sequencer and then notify send will then be stuck writing to a partially closed channel.
@@ -34,6 +34,7 @@ import (
 	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
 	"github.com/openziti/ziti/router/env"
 	"github.com/openziti/ziti/router/internal/apiproxy"
+	"github.com/openziti/ziti/router/qos"
 	"github.com/openziti/ziti/router/state"
 	"github.com/openziti/ziti/router/xgress_router"
 	"github.com/pkg/errors"
@@ -55,6 +56,9 @@ type Factory struct {
 	env                  env.RouterEnv
 	reconnectionHandlers concurrenz.CopyOnWriteSlice[reconnectionHandler]
 	connectionTracker    *connectionTracker
+	serviceUpdates       *serviceUpdateNotifier
+	qosManager           *qos.Manager
+	ocspChecker          *ocspChecker
 }
 
 func (factory *Factory) Inspect(key string, timeout time.Duration) any {
@@ -98,6 +102,9 @@ func (factory *Factory) addReconnectionHandler(h reconnectionHandler) {
 
 func (factory *Factory) Run(env env.RouterEnv) error {
 	factory.stateManager.StartHeartbeat(env, factory.edgeRouterConfig.HeartbeatIntervalSeconds, env.GetCloseNotify())
+	if factory.ocspChecker != nil {
+		go factory.ocspChecker.runEviction(env.GetCloseNotify())
+	}
 	return nil
 }
 
@@ -123,6 +130,10 @@ func (factory *Factory) LoadConfig(configMap map[interface{}]interface{}) error
 	factory.edgeRouterConfig = factory.routerConfig.Edge
 	factory.env.MarkRouterDataModelRequired()
 
+	if edgeConfig.Ocsp.Enabled {
+		factory.ocspChecker = newOcspChecker(&edgeConfig.Ocsp, factory.env.GetRouterId())
+	}
+
 	go apiproxy.Start(edgeConfig)
 
 	return nil
@@ -139,6 +150,8 @@ func NewFactory(routerConfig *env.Config, env env.RouterEnv, stateManager state.
 		metricsRegistry:   env.GetMetricsRegistry(),
 		env:               env,
 		connectionTracker: newConnectionTracker(env),
+		serviceUpdates:    newServiceUpdateNotifier(stateManager),
+		qosManager:        qos.NewManager(),
 	}
 
 	factory.stateManager.SetConnectionTracker(factory.connectionTracker)
@@ -147,6 +160,37 @@ func NewFactory(routerConfig *env.Config, env env.RouterEnv, stateManager state.
 	return factory
 }
 
+// newQosShaper returns a shaper enforcing any qos.v1 limits which apply to the given identity's use of the given
+// service. If no limits apply, or the config can't be found or parsed, nil is returned, which doesn't limit anything
+func (factory *Factory) newQosShaper(identityId, serviceId string) *qos.Shaper {
+	rdm := factory.stateManager.RouterDataModel()
+	if rdm == nil {
+		return nil
+	}
+
+	configs, err := rdm.GetServiceConfigs(identityId, serviceId)
+	if err != nil {
+		return nil
+	}
+
+	config, found := configs[qos.ConfigTypeName]
+	if !found || config.Config == nil {
+		return nil
+	}
+
+	qosConfig, err := qos.ParseConfig(config.Config.DataJson)
+	if err != nil {
+		pfxlog.Logger().WithError(err).
+			WithField("identityId", identityId).
+			WithField("serviceId", serviceId).
+			WithField("configId", config.Config.Id).
+			Error("unable to apply bandwidth limits")
+		return nil
+	}
+
+	return factory.qosManager.NewShaper(identityId, serviceId, qosConfig)
+}
+
 // CreateListener creates a new Edge Xgress listener
 func (factory *Factory) CreateListener(optionsData xgress.OptionsData) (xgress_router.Listener, error) {
 	if !factory.enabled {
@@ -171,6 +215,7 @@ func (factory *Factory) CreateListener(optionsData xgress.OptionsData) (xgress_r
 		channel.HelloVersionHeader:       versionHeader,
 		edge.SupportsBindSuccessHeader:   {1},
 		edge.SupportsPostureChecksHeader: {1},
+		SupportsServiceUpdatesHeader:     {1},
 	}
 
 	return newListener(factory.env.GetRouterId(), factory, options, headers), nil
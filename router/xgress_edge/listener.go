@@ -43,6 +43,7 @@ import (
 	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
 	"github.com/openziti/ziti/controller/idgen"
 	"github.com/openziti/ziti/router/env"
+	"github.com/openziti/ziti/router/qos"
 	"github.com/openziti/ziti/router/state"
 	"github.com/openziti/ziti/router/xgress_common"
 	"github.com/openziti/ziti/router/xgress_router"
@@ -53,6 +54,10 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// CircuitTagsHeader carries the tags an SDK attaches to a dial, as a string to string map. The tags are sent to the
+// controller when creating the circuit, and are included in circuit events and usage metrics for the circuit
+const CircuitTagsHeader = 1100
+
 var peerHeaderRequestMappings = map[uint32]uint32{
 	uint32(sdkedge.PublicKeyHeader):        uint32(sdkedge.PublicKeyHeader),
 	uint32(sdkedge.CallerIdHeader):         uint32(sdkedge.CallerIdHeader),
@@ -369,6 +374,7 @@ func (self *edgeClientConn) cleanupXgressCircuit(edgeForwarder *xgEdgeForwarder)
 
 	self.forwarder.EndCircuit(circuitId)
 	self.xgCircuits.Remove(circuitId)
+	edgeForwarder.qos.Close()
 
 	// Notify the controller of the xgress fault
 	fault := &ctrl_pb.Fault{Id: circuitId}
@@ -460,6 +466,29 @@ func (self *edgeClientConn) processConnect(req *channel.Message, ch channel.Chan
 		}
 	}
 
+	encryptionPolicy, err := self.listener.factory.getEncryptionPolicy(self.getIdentityId(), serviceSessionToken.ServiceId)
+	if err != nil {
+		errStr := err.Error()
+		log.Error(errStr)
+		self.sendStateClosedReply(errStr, req)
+		return
+	}
+
+	if err = encryptionPolicy.Apply(req); err != nil {
+		errStr := err.Error()
+		log.WithField("encryptionPolicy", encryptionPolicy).Error(errStr)
+		self.sendStateClosedReply(errStr, req)
+		return
+	}
+
+	tags, _, err := req.GetStringToStringMapHeader(CircuitTagsHeader)
+	if err != nil {
+		errStr := fmt.Sprintf("invalid circuit tags (%s)", err.Error())
+		log.Error(errStr)
+		self.sendStateClosedReply(errStr, req)
+		return
+	}
+
 	var handler connectHandler
 	if useXgToSdk, _ := req.GetBoolHeader(sdkedge.UseXgressToSdkHeader); useXgToSdk {
 		log.Debug("use sdk xgress set, setting up sdk flow-control connection")
@@ -468,6 +497,7 @@ func (self *edgeClientConn) processConnect(req *channel.Message, ch channel.Chan
 			ctrlId:         ctrlCh.Id(),
 			originator:     xgress.Initiator,
 			metrics:        self.listener.factory.env.GetXgressMetrics(),
+			qos:            self.listener.factory.newQosShaper(self.getIdentityId(), serviceSessionToken.ServiceId),
 		}
 	} else {
 		handler = &nonXgConnectHandler{}
@@ -495,6 +525,7 @@ func (self *edgeClientConn) processConnect(req *channel.Message, ch channel.Chan
 		Fingerprints:         self.fingerprints.Prints(),
 		TerminatorInstanceId: terminatorIdentity,
 		PeerData:             peerData,
+		Tags:                 tags,
 	}
 
 	response, err := self.sendCreateCircuitRequest(request, ctrlCh)
@@ -549,11 +580,7 @@ func (self *edgeClientConn) sendCreateCircuitRequestV2(req *ctrl_msg.CreateCircu
 		return nil, err
 	}
 	if msg.ContentType == int32(edge_ctrl_pb.ContentType_ErrorType) {
-		msg := string(msg.Body)
-		if msg == "" {
-			msg = "error state returned from controller with no message"
-		}
-		return nil, errors.New(msg)
+		return nil, newCtrlError(msg)
 	}
 
 	if msg.ContentType != int32(edge_ctrl_pb.ContentType_CreateCircuitV2ResponseType) {
@@ -619,6 +646,21 @@ func (self *edgeClientConn) processBind(req *channel.Message, ch channel.Channel
 		}
 	}
 
+	encryptionPolicy, err := self.listener.factory.getEncryptionPolicy(self.getIdentityId(), serviceSessionToken.ServiceId)
+	if err != nil {
+		errStr := err.Error()
+		log.Error(errStr)
+		self.sendStateClosedReply(errStr, req)
+		return
+	}
+
+	if err = encryptionPolicy.Apply(req); err != nil {
+		errStr := err.Error()
+		log.WithField("encryptionPolicy", encryptionPolicy).Error(errStr)
+		self.sendStateClosedReply(errStr, req)
+		return
+	}
+
 	supportsCreateTerminatorV2 := capabilities.IsCapable(ctrlCh, capabilities.ControllerCreateTerminatorV2)
 	if supportsCreateTerminatorV2 {
 		self.processBindV2(serviceSessionToken, req, ch, ctrlCh)
@@ -1128,11 +1170,27 @@ func (self *edgeClientConn) sendConnectedReply(req *channel.Message, response *c
 }
 
 func (self *edgeClientConn) sendStateClosedReply(message string, req *channel.Message) {
+	errorCode, found := req.GetUint32Header(sdkedge.ErrorCodeHeader)
+	self.sendStateClosedReplyWithCode(message, req, errorCode, found)
+}
+
+// sendDialFailedReply sends a state closed reply for a failed fabric dial, including the error code returned by the
+// controller, if there was one
+func (self *edgeClientConn) sendDialFailedReply(err error, req *channel.Message) {
+	var ctrlErr *ctrlError
+	if errors.As(err, &ctrlErr) && ctrlErr.hasErrorCode {
+		self.sendStateClosedReplyWithCode(err.Error(), req, ctrlErr.errorCode, true)
+	} else {
+		self.sendStateClosedReply(err.Error(), req)
+	}
+}
+
+func (self *edgeClientConn) sendStateClosedReplyWithCode(message string, req *channel.Message, errorCode uint32, hasErrorCode bool) {
 	connId, _ := req.GetUint32Header(sdkedge.ConnIdHeader)
 	msg := sdkedge.NewStateClosedMsg(connId, message)
 	msg.ReplyTo(req)
 
-	if errorCode, found := req.GetUint32Header(sdkedge.ErrorCodeHeader); found {
+	if hasErrorCode {
 		msg.PutUint32Header(sdkedge.ErrorCodeHeader, errorCode)
 	}
 
@@ -1225,6 +1283,14 @@ func (self *edgeClientConn) handleXgPayload(msg *channel.Message, ch channel.Cha
 		return
 	}
 
+	// this runs on the channel's receive loop, so rather than wait for the shaper, which would hold up every circuit
+	// on the channel, drop the payload and let the sdk retransmit it
+	if !edgeFwd.qos.AllowIngress(len(payload.Data)) {
+		self.listener.droppedPayloadsMeter.Mark(1)
+		pfxlog.Logger().WithFields(payload.GetLoggerFields()).Debug("payload from xgress sdk over bandwidth limit, dropped")
+		return
+	}
+
 	if err = self.forwarder.ForwardPayload(edgeFwd.address, payload, 0); err != nil {
 		if !channel.IsTimeout(err) {
 			pfxlog.Logger().WithFields(payload.GetLoggerFields()).WithError(err).Error("unable to forward payload")
@@ -1265,17 +1331,36 @@ func (self *edgeClientConn) handleXgAcknowledgement(req *channel.Message, ch cha
 	}
 }
 
+// ctrlError is an error response from the controller. The error code, if the controller provided one, is passed on
+// to the SDK when a dial fails
+type ctrlError struct {
+	msg          string
+	errorCode    uint32
+	hasErrorCode bool
+}
+
+func newCtrlError(msg *channel.Message) *ctrlError {
+	result := &ctrlError{
+		msg: string(msg.Body),
+	}
+	if result.msg == "" {
+		result.msg = "error state returned from controller with no message"
+	}
+	result.errorCode, result.hasErrorCode = msg.GetUint32Header(sdkedge.ErrorCodeHeader)
+	return result
+}
+
+func (self *ctrlError) Error() string {
+	return self.msg
+}
+
 func getResultOrFailure(msg *channel.Message, err error, result protobufs.TypedMessage) error {
 	if err != nil {
 		return err
 	}
 
 	if msg.ContentType == int32(edge_ctrl_pb.ContentType_ErrorType) {
-		msg := string(msg.Body)
-		if msg == "" {
-			msg = "error state returned from controller with no message"
-		}
-		return errors.New(msg)
+		return newCtrlError(msg)
 	}
 
 	if msg.ContentType != result.GetContentType() {
@@ -1309,6 +1394,7 @@ func (self *nonXgConnectHandler) Init(ctx *connectContext) bool {
 		mux:        ctx.SdkConn.msgMux,
 		MsgChannel: *sdkedge.NewEdgeMsgChannel(ctx.SdkConn.ch, ctx.ConnId),
 		seq:        NewMsgQueue(4),
+		qos:        ctx.SdkConn.listener.factory.newQosShaper(ctx.SdkConn.getIdentityId(), ctx.ServiceSessionToken.ServiceId),
 	}
 
 	self.conn.SetData(&state.ConnState{
@@ -1326,6 +1412,7 @@ func (self *nonXgConnectHandler) Init(ctx *connectContext) bool {
 
 	// We can't fix conn id, since it's provided by the client
 	if err := ctx.SdkConn.msgMux.Add(self.conn); err != nil {
+		self.conn.qos.Close()
 		ctx.Log.WithError(err).Error("error adding to msg mux")
 		ctx.SdkConn.sendStateClosedReply(err.Error(), ctx.Req)
 		return false
@@ -1337,7 +1424,7 @@ func (self *nonXgConnectHandler) Init(ctx *connectContext) bool {
 func (self *nonXgConnectHandler) FinishConnect(ctx *connectContext, response *ctrl_msg.CreateCircuitResponse, err error) {
 	if err != nil {
 		ctx.Log.WithError(err).Warn("failed to dial fabric")
-		ctx.SdkConn.sendStateClosedReply(err.Error(), ctx.Req)
+		ctx.SdkConn.sendDialFailedReply(err, ctx.Req)
 		self.conn.close(false, "failed to dial fabric")
 		return
 	}
@@ -1365,6 +1452,7 @@ type xgEdgeForwarder struct {
 	connId     uint32
 	metrics    env.XgressMetrics
 	tags       map[string]string
+	qos        *qos.Shaper
 }
 
 func (self *xgEdgeForwarder) GetDestinationType() string {
@@ -1380,6 +1468,13 @@ func (self *xgEdgeForwarder) GetTags() map[string]string {
 }
 
 func (self *xgEdgeForwarder) SendPayload(payload *xgress.Payload, timeout time.Duration, _ xgress.PayloadType) error {
+	// waiting for the shaper would hold up the link, so payloads over the limit are dropped and retransmitted
+	if !self.qos.AllowEgress(len(payload.Data)) {
+		self.listener.droppedPayloadsMeter.Mark(1)
+		pfxlog.Logger().WithField("circuitId", payload.CircuitId).Debug("payload to xgress sdk over bandwidth limit, dropped")
+		return nil
+	}
+
 	msg := payload.Marshall()
 	msg.PutUint32Header(sdkedge.ConnIdHeader, self.connId)
 	if timeout == 0 {
@@ -1451,12 +1546,13 @@ func (self *xgEdgeForwarder) UnregisterRouting() {
 	pfxlog.Logger().WithField("circuitId", self.circuitId).Debug("routing unregistered")
 	self.forwarder.EndCircuit(self.circuitId)
 	self.xgCircuits.Set(self.circuitId, self)
+	self.qos.Close()
 }
 
 func (self *xgEdgeForwarder) FinishConnect(ctx *connectContext, response *ctrl_msg.CreateCircuitResponse, err error) {
 	if err != nil {
 		ctx.Log.WithError(err).Warn("failed to dial fabric")
-		ctx.SdkConn.sendStateClosedReply(err.Error(), ctx.Req)
+		ctx.SdkConn.sendDialFailedReply(err, ctx.Req)
 		return
 	}
 
@@ -1495,6 +1591,7 @@ func (self *xgEdgeForwarder) Unrouted() {
 	pfxlog.Logger().WithField("circuitId", self.circuitId).Debug("unroute: start")
 	defer pfxlog.Logger().WithField("circuitId", self.circuitId).Debug("unroute: complete")
 	self.xgCircuits.Remove(self.circuitId)
+	self.qos.Close()
 
 	msg := sdkedge.NewStateClosedMsg(self.connId, "xgress unrouted")
 	err := msg.WithPriority(channel.High).WithTimeout(5 * time.Second).SendAndWaitForWire(self.ch.GetDefaultSender())
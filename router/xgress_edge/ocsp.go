@@ -0,0 +1,184 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_edge
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/identity"
+	"github.com/openziti/ziti/router/env"
+	"github.com/orcaman/concurrent-map/v2"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ocsp"
+)
+
+const maxOcspResponseSize = 64 * 1024
+
+type ocspCacheEntry struct {
+	response  *ocsp.Response
+	expiresAt time.Time
+}
+
+// ocspChecker checks the revocation status of client certificates with an OCSP responder, usually the controller's
+// ocsp api. Responses are cached by certificate fingerprint.
+type ocspChecker struct {
+	config *env.OcspConfig
+	id     identity.Identity
+	client *http.Client
+	cache  cmap.ConcurrentMap[string, *ocspCacheEntry]
+}
+
+func newOcspChecker(config *env.OcspConfig, id identity.Identity) *ocspChecker {
+	return &ocspChecker{
+		config: config,
+		id:     id,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					RootCAs: id.CA(),
+				},
+			},
+		},
+		cache: cmap.New[*ocspCacheEntry](),
+	}
+}
+
+// check returns an error if the certificate has been revoked. If the status can't be determined an error is only
+// returned if the checker isn't configured to fail open
+func (self *ocspChecker) check(fingerprint string, certs []*x509.Certificate) error {
+	leaf := certs[0]
+	log := pfxlog.Logger().WithField("fingerprint", fingerprint).WithField("serial", leaf.SerialNumber.String())
+
+	response, err := self.getResponse(fingerprint, certs)
+	if err != nil {
+		if self.config.FailOpen {
+			log.WithError(err).Warn("unable to check client certificate revocation status, allowing connection")
+			return nil
+		}
+		return errors.Wrap(err, "unable to check client certificate revocation status")
+	}
+
+	if response != nil && response.Status == ocsp.Revoked {
+		return fmt.Errorf("client certificate with serial %s was revoked at %s (reason: %d)",
+			leaf.SerialNumber.String(), response.RevokedAt.Format(time.RFC3339), response.RevocationReason)
+	}
+
+	return nil
+}
+
+func (self *ocspChecker) getResponse(fingerprint string, certs []*x509.Certificate) (*ocsp.Response, error) {
+	if entry, found := self.cache.Get(fingerprint); found {
+		if time.Now().Before(entry.expiresAt) {
+			return entry.response, nil
+		}
+		self.cache.Remove(fingerprint)
+	}
+
+	leaf := certs[0]
+	responder := self.config.Responder
+	if responder == "" {
+		if len(leaf.OCSPServer) == 0 {
+			return nil, nil
+		}
+		responder = leaf.OCSPServer[0]
+	}
+
+	chain := self.id.CaPool().GetChain(leaf, certs[1:]...)
+	if len(chain) < 2 {
+		return nil, errors.Errorf("unable to find issuer for certificate with subject [%s]", leaf.Subject.String())
+	}
+	issuer := chain[1]
+
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := self.client.Post(responder, "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("ocsp responder %s returned status %d", responder, httpResp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, maxOcspResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+	if err != nil {
+		var responseErr ocsp.ResponseError
+		if errors.As(err, &responseErr) && responseErr.Status == ocsp.Unauthorized {
+			// the responder doesn't know about this issuer, so it can't tell us anything
+			self.cacheResponse(fingerprint, nil, time.Time{})
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	self.cacheResponse(fingerprint, response, response.NextUpdate)
+	return response, nil
+}
+
+func (self *ocspChecker) cacheResponse(fingerprint string, response *ocsp.Response, nextUpdate time.Time) {
+	expiresAt := time.Now().Add(self.config.CacheDuration)
+	if !nextUpdate.IsZero() && nextUpdate.Before(expiresAt) {
+		expiresAt = nextUpdate
+	}
+	self.cache.Set(fingerprint, &ocspCacheEntry{
+		response:  response,
+		expiresAt: expiresAt,
+	})
+}
+
+// runEviction periodically removes expired responses, so the cache doesn't hold entries for clients which have
+// stopped connecting
+func (self *ocspChecker) runEviction(closeNotify <-chan struct{}) {
+	ticker := time.NewTicker(max(self.config.CacheDuration, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			self.evictExpired()
+		case <-closeNotify:
+			return
+		}
+	}
+}
+
+func (self *ocspChecker) evictExpired() {
+	now := time.Now()
+	for _, key := range self.cache.Keys() {
+		self.cache.RemoveCb(key, func(_ string, entry *ocspCacheEntry, exists bool) bool {
+			return exists && now.After(entry.expiresAt)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_edge
+
+import (
+	"sync"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	sdkedge "github.com/openziti/sdk-golang/ziti/edge"
+	"github.com/openziti/ziti/common"
+)
+
+const (
+	// SupportsServiceUpdatesHeader is set to true in an SDK's hello, by SDKs which want to be told when their
+	// service list changes. Routers set it in their hello response, so SDKs know they can poll less often
+	SupportsServiceUpdatesHeader = 1101
+
+	// ContentTypeServicesChanged is sent to SDKs which set SupportsServiceUpdatesHeader when the services visible
+	// to their identity, or the configs of those services, have changed. It carries the time of the change in the
+	// timestamp header. SDKs should refresh their services from the controller when they receive it, rather than
+	// waiting for their next poll.
+	ContentTypeServicesChanged = 60900
+
+	// servicesChangedHoldoff is how long changes are collected before SDKs are notified, so that bulk policy
+	// changes only trigger a single refresh
+	servicesChangedHoldoff = time.Second
+)
+
+type routerDataModelSource interface {
+	RouterDataModel() *common.RouterDataModel
+}
+
+// serviceUpdateNotifier pushes service change notifications to connected SDKs. While an identity has SDK
+// connections which asked for notifications, the notifier subscribes to the identity's changes in the router data
+// model.
+type serviceUpdateNotifier struct {
+	rdmSource   routerDataModelSource
+	holdoff     time.Duration
+	lock        sync.Mutex
+	subscribers map[string]*serviceUpdateSubscriber
+}
+
+func newServiceUpdateNotifier(rdmSource routerDataModelSource) *serviceUpdateNotifier {
+	return &serviceUpdateNotifier{
+		rdmSource:   rdmSource,
+		holdoff:     servicesChangedHoldoff,
+		subscribers: map[string]*serviceUpdateSubscriber{},
+	}
+}
+
+func (self *serviceUpdateNotifier) add(identityId string, sender channel.Sender) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	subscriber, found := self.subscribers[identityId]
+	if !found {
+		rdm := self.rdmSource.RouterDataModel()
+		if rdm == nil {
+			return
+		}
+
+		subscriber = &serviceUpdateSubscriber{
+			identityId: identityId,
+			holdoff:    self.holdoff,
+		}
+
+		if err := rdm.SubscribeToIdentityChanges(identityId, subscriber, false); err != nil {
+			pfxlog.Logger().WithField("identityId", identityId).WithError(err).
+				Info("unable to subscribe to identity changes, sdk won't be notified of service changes")
+			return
+		}
+		self.subscribers[identityId] = subscriber
+	}
+
+	subscriber.addSender(sender)
+}
+
+func (self *serviceUpdateNotifier) remove(identityId string, sender channel.Sender) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	subscriber, found := self.subscribers[identityId]
+	if !found {
+		return
+	}
+
+	if subscriber.removeSender(sender) == 0 {
+		delete(self.subscribers, identityId)
+		if rdm := self.rdmSource.RouterDataModel(); rdm != nil {
+			rdm.UnsubscribeFromIdentityChanges(identityId, subscriber)
+		}
+	}
+}
+
+type serviceUpdateSubscriber struct {
+	identityId string
+	holdoff    time.Duration
+	lock       sync.Mutex
+	senders    []channel.Sender
+	pending    bool
+}
+
+func (self *serviceUpdateSubscriber) addSender(sender channel.Sender) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.senders = append(self.senders, sender)
+}
+
+func (self *serviceUpdateSubscriber) removeSender(sender channel.Sender) int {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for idx, s := range self.senders {
+		if s == sender {
+			self.senders = append(self.senders[:idx], self.senders[idx+1:]...)
+			break
+		}
+	}
+	return len(self.senders)
+}
+
+func (self *serviceUpdateSubscriber) NotifyIdentityEvent(_ *common.IdentityState, eventType common.IdentityEventType) {
+	// posture check changes can change which services are usable. The full state is sent when subscribing, when the
+	// SDK has just fetched its services, and deleted identities have their connections closed
+	if eventType == common.EventPostureChecksUpdated {
+		self.scheduleNotify()
+	}
+}
+
+func (self *serviceUpdateSubscriber) NotifyServiceChange(*common.IdentityState, *common.IdentityService, common.ServiceEventType) {
+	self.scheduleNotify()
+}
+
+func (self *serviceUpdateSubscriber) scheduleNotify() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if !self.pending {
+		self.pending = true
+		time.AfterFunc(self.holdoff, self.notify)
+	}
+}
+
+func (self *serviceUpdateSubscriber) notify() {
+	self.lock.Lock()
+	self.pending = false
+	senders := append([]channel.Sender(nil), self.senders...)
+	self.lock.Unlock()
+
+	log := pfxlog.Logger().WithField("identityId", self.identityId)
+	timestamp := uint64(time.Now().UnixMilli())
+
+	for _, sender := range senders {
+		msg := channel.NewMessage(ContentTypeServicesChanged, nil)
+		msg.PutUint64Header(sdkedge.TimestampHeader, timestamp)
+		if queued, err := sender.TrySend(msg); err != nil {
+			log.WithError(err).Debug("unable to notify sdk of service changes")
+		} else if !queued {
+			log.Debug("sdk send queue full, sdk wasn't notified of service changes")
+		}
+	}
+}
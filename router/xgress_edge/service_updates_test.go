@@ -0,0 +1,125 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_edge
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openziti/channel/v4"
+	sdkedge "github.com/openziti/sdk-golang/ziti/edge"
+	"github.com/openziti/ziti/common"
+	"github.com/stretchr/testify/require"
+)
+
+type testSender struct {
+	sync.Mutex
+	msgs []*channel.Message
+}
+
+func (self *testSender) Send(s channel.Sendable) error {
+	_, err := self.TrySend(s)
+	return err
+}
+
+func (self *testSender) TrySend(s channel.Sendable) (bool, error) {
+	self.Lock()
+	defer self.Unlock()
+	self.msgs = append(self.msgs, s.Msg())
+	return true, nil
+}
+
+func (self *testSender) CloseNotify() <-chan struct{} {
+	return nil
+}
+
+func (self *testSender) count() int {
+	self.Lock()
+	defer self.Unlock()
+	return len(self.msgs)
+}
+
+func TestServiceUpdateSubscriber(t *testing.T) {
+	newSubscriber := func(senders ...channel.Sender) *serviceUpdateSubscriber {
+		result := &serviceUpdateSubscriber{
+			identityId: "test",
+			holdoff:    50 * time.Millisecond,
+		}
+		for _, sender := range senders {
+			result.addSender(sender)
+		}
+		return result
+	}
+
+	t.Run("changes are batched and sent to every connection", func(t *testing.T) {
+		req := require.New(t)
+		sender1 := &testSender{}
+		sender2 := &testSender{}
+		subscriber := newSubscriber(sender1, sender2)
+
+		subscriber.NotifyServiceChange(nil, nil, common.EventAccessGained)
+		subscriber.NotifyServiceChange(nil, nil, common.EventUpdated)
+		subscriber.NotifyIdentityEvent(nil, common.EventPostureChecksUpdated)
+
+		req.Eventually(func() bool {
+			return sender1.count() == 1 && sender2.count() == 1
+		}, time.Second, 10*time.Millisecond)
+
+		msg := sender1.msgs[0]
+		req.Equal(int32(ContentTypeServicesChanged), msg.ContentType)
+		timestamp, found := msg.GetUint64Header(sdkedge.TimestampHeader)
+		req.True(found)
+		req.InDelta(time.Now().UnixMilli(), int64(timestamp), float64(time.Second.Milliseconds()))
+
+		time.Sleep(100 * time.Millisecond)
+		req.Equal(1, sender1.count())
+
+		subscriber.NotifyServiceChange(nil, nil, common.EventAccessRemoved)
+		req.Eventually(func() bool {
+			return sender1.count() == 2 && sender2.count() == 2
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("full state and identity updates aren't sent", func(t *testing.T) {
+		req := require.New(t)
+		sender := &testSender{}
+		subscriber := newSubscriber(sender)
+
+		subscriber.NotifyIdentityEvent(nil, common.EventFullState)
+		subscriber.NotifyIdentityEvent(nil, common.EventIdentityUpdated)
+
+		time.Sleep(100 * time.Millisecond)
+		req.Equal(0, sender.count())
+	})
+
+	t.Run("removed connections aren't sent to", func(t *testing.T) {
+		req := require.New(t)
+		sender1 := &testSender{}
+		sender2 := &testSender{}
+		subscriber := newSubscriber(sender1, sender2)
+
+		req.Equal(1, subscriber.removeSender(sender1))
+		subscriber.NotifyServiceChange(nil, nil, common.EventUpdated)
+
+		req.Eventually(func() bool {
+			return sender2.count() == 1
+		}, time.Second, 10*time.Millisecond)
+		req.Equal(0, sender1.count())
+		req.Equal(0, subscriber.removeSender(sender2))
+	})
+}
@@ -0,0 +1,105 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package wst
+
+import (
+	"strings"
+	"time"
+
+	"github.com/openziti/transport/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultHandshakeTimeout = 10 * time.Second
+	DefaultPath             = "/ziti-edge"
+
+	protocolHttp1 = "http/1.1"
+	protocolHttp2 = "h2"
+)
+
+// options are loaded from the wst section of the transport configuration, for example:
+//
+//	transport:
+//	  wst:
+//	    path: /ziti-edge
+//	    http2: true
+type options struct {
+	path  string
+	http2 bool
+}
+
+func loadOptions(tcfg transport.Configuration) (*options, error) {
+	result := &options{
+		path:  DefaultPath,
+		http2: true,
+	}
+
+	val, err := tcfg.GetValue(Type, "path")
+	if err != nil {
+		return nil, err
+	}
+	if val != nil {
+		path, ok := val.(string)
+		if !ok || !strings.HasPrefix(path, "/") {
+			return nil, errors.Errorf("invalid value for %s:path [%v], must be a string starting with /", Type, val)
+		}
+		result.path = path
+	}
+
+	val, err = tcfg.GetValue(Type, "http2")
+	if err != nil {
+		return nil, err
+	}
+	if val != nil {
+		http2, ok := val.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid value for %s:http2 [%v], must be boolean", Type, val)
+		}
+		result.http2 = http2
+	}
+
+	return result, nil
+}
+
+// httpProtocols returns the ALPN protocols offered on the outer TLS connection, which carries the HTTP requests
+func (self *options) httpProtocols() []string {
+	if self.http2 {
+		return []string{protocolHttp2, protocolHttp1}
+	}
+	return []string{protocolHttp1}
+}
+
+// dialProtocols returns the ALPN protocols offered by dialers. The shared TLS listener selects the last protocol
+// offered which it has a handler for, so http/2 is offered last, to be preferred where it's available
+func (self *options) dialProtocols() []string {
+	if self.http2 {
+		return []string{protocolHttp1, protocolHttp2}
+	}
+	return []string{protocolHttp1}
+}
+
+func getHandshakeTimeout(tcfg transport.Configuration) (time.Duration, error) {
+	timeout, err := tcfg.GetHandshakeTimeout()
+	if err != nil {
+		return 0, err
+	}
+	if timeout == 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+	return timeout, nil
+}
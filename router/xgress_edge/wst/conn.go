@@ -0,0 +1,160 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package wst
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsConn adapts a websocket to a net.Conn. Each write is sent as a single binary message. Reads consume binary
+// messages in order, ignoring message boundaries.
+type wsConn struct {
+	ws        *websocket.Conn
+	reader    io.Reader
+	writeLock sync.Mutex
+}
+
+func newWsConn(ws *websocket.Conn) *wsConn {
+	return &wsConn{ws: ws}
+}
+
+func (self *wsConn) Read(b []byte) (int, error) {
+	for {
+		if self.reader == nil {
+			msgType, reader, err := self.ws.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			if msgType != websocket.BinaryMessage {
+				continue
+			}
+			self.reader = reader
+		}
+
+		n, err := self.reader.Read(b)
+		if err == io.EOF {
+			self.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (self *wsConn) Write(b []byte) (int, error) {
+	self.writeLock.Lock()
+	defer self.writeLock.Unlock()
+
+	if err := self.ws.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (self *wsConn) Close() error {
+	return self.ws.Close()
+}
+
+func (self *wsConn) LocalAddr() net.Addr {
+	return self.ws.LocalAddr()
+}
+
+func (self *wsConn) RemoteAddr() net.Addr {
+	return self.ws.RemoteAddr()
+}
+
+func (self *wsConn) SetDeadline(t time.Time) error {
+	if err := self.ws.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return self.ws.SetWriteDeadline(t)
+}
+
+func (self *wsConn) SetReadDeadline(t time.Time) error {
+	return self.ws.SetReadDeadline(t)
+}
+
+func (self *wsConn) SetWriteDeadline(t time.Time) error {
+	return self.ws.SetWriteDeadline(t)
+}
+
+// streamConn adapts a full duplex HTTP/2 request to a net.Conn. The request body carries data from the client and
+// the response body carries data from the server. Deadlines aren't supported, callers should use contexts to bound
+// handshakes. The edge channel's heartbeats detect connections which have stopped carrying data.
+type streamConn struct {
+	reader    io.ReadCloser
+	writer    io.Writer
+	flushF    func() error
+	closeF    func() error
+	local     net.Addr
+	remote    net.Addr
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (self *streamConn) Read(b []byte) (int, error) {
+	return self.reader.Read(b)
+}
+
+func (self *streamConn) Write(b []byte) (int, error) {
+	n, err := self.writer.Write(b)
+	if err == nil && self.flushF != nil {
+		err = self.flushF()
+	}
+	return n, err
+}
+
+func (self *streamConn) Close() error {
+	var err error
+	self.closeOnce.Do(func() {
+		close(self.closed)
+		err = self.reader.Close()
+		if self.closeF != nil {
+			if closeErr := self.closeF(); err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
+
+func (self *streamConn) LocalAddr() net.Addr {
+	return self.local
+}
+
+func (self *streamConn) RemoteAddr() net.Addr {
+	return self.remote
+}
+
+func (self *streamConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (self *streamConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (self *streamConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package wst
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/identity"
+	"github.com/openziti/transport/v2"
+	"github.com/openziti/transport/v2/proxies"
+	transporttls "github.com/openziti/transport/v2/tls"
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+)
+
+// DialWithLocalBinding connects to a wst listener, optionally through an HTTP CONNECT proxy. If the listener
+// negotiates HTTP/2, the tunnel is a full duplex HTTP/2 stream, otherwise it's a websocket.
+//
+// The outer TLS connection isn't verified, so that it works through proxies which terminate and inspect TLS. The
+// router is authenticated by the TLS handshake inside the tunnel, using the identity's CA, as for the tls transport.
+func DialWithLocalBinding(a address, name, localBinding string, i *identity.TokenId, timeout time.Duration, tcfg transport.Configuration) (transport.Conn, error) {
+	log := pfxlog.Logger().WithField("dest", a.String())
+
+	opts, err := loadOptions(tcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout == 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+
+	ctx, cancelF := context.WithTimeout(context.Background(), timeout)
+	defer cancelF()
+
+	netConn, err := dialTcp(ctx, a, localBinding, timeout, tcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	outerConn := tls.Client(netConn, &tls.Config{
+		ServerName:         a.hostname,
+		NextProtos:         opts.dialProtocols(),
+		InsecureSkipVerify: true,
+	})
+
+	if err = outerConn.HandshakeContext(ctx); err != nil {
+		_ = netConn.Close()
+		return nil, errors.Wrapf(err, "tls handshake with %s failed", a.String())
+	}
+
+	var tunnel net.Conn
+	if outerConn.ConnectionState().NegotiatedProtocol == protocolHttp2 {
+		tunnel, err = openStream(ctx, a, opts, outerConn)
+	} else {
+		tunnel, err = openWebSocket(a, opts, outerConn, timeout)
+	}
+
+	if err != nil {
+		_ = outerConn.Close()
+		return nil, err
+	}
+
+	innerTlsCfg := i.ClientTLSConfig().Clone()
+	innerTlsCfg.ServerName = a.hostname
+	innerTlsCfg.NextProtos = tcfg.Protocols()
+
+	tlsConn := tls.Client(tunnel, innerTlsCfg)
+	if err = tlsConn.HandshakeContext(ctx); err != nil {
+		_ = tunnel.Close()
+		return nil, errors.Wrapf(err, "tls handshake inside wst tunnel to %s failed", a.String())
+	}
+
+	log.Debugf("dialed wst connection using %s", outerConn.ConnectionState().NegotiatedProtocol)
+
+	detail := &transport.ConnectionDetail{
+		Address: a.String(),
+		InBound: false,
+		Name:    name,
+	}
+
+	return transporttls.NewConnection(detail, tlsConn), nil
+}
+
+func dialTcp(ctx context.Context, a address, localBinding string, timeout time.Duration, tcfg transport.Configuration) (net.Conn, error) {
+	proxyConfig, err := tcfg.GetProxyConfiguration()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get proxy configuration")
+	}
+
+	ip, err := transport.ResolveLocalBinding(localBinding)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if ip != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+
+	if proxyConfig != nil && proxyConfig.Type == transport.ProxyTypeHttpConnect {
+		proxyDialer := proxies.NewHttpConnectProxyDialer(dialer, proxyConfig.Address, proxyConfig.Auth, timeout)
+		conn, err := proxyDialer.Dial("tcp", a.bindableAddress())
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to connect to %s through proxy %s", a.String(), proxyConfig.Address)
+		}
+		return conn, nil
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", a.bindableAddress())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to connect to %s", a.String())
+	}
+	return conn, nil
+}
+
+func openWebSocket(a address, opts *options, conn net.Conn, timeout time.Duration) (net.Conn, error) {
+	// conn is already TLS. Using the wss scheme would make the websocket client wrap it in TLS again
+	u := &url.URL{Scheme: "ws", Host: a.bindableAddress(), Path: opts.path}
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	ws, _, err := websocket.NewClient(conn, u, nil, 0, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "websocket upgrade to %s failed", u.String())
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return newWsConn(ws), nil
+}
+
+func openStream(ctx context.Context, a address, opts *options, conn net.Conn) (net.Conn, error) {
+	clientConn, err := (&http2.Transport{}).NewClientConn(conn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to start http/2 connection to %s", a.String())
+	}
+
+	reader, writer := io.Pipe()
+	u := &url.URL{Scheme: "https", Host: a.bindableAddress(), Path: opts.path}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, u.String(), reader)
+	if err != nil {
+		_ = clientConn.Close()
+		return nil, err
+	}
+
+	respC := make(chan *http.Response, 1)
+	errC := make(chan error, 1)
+	go func() {
+		resp, err := clientConn.RoundTrip(req)
+		if err != nil {
+			errC <- err
+			return
+		}
+		respC <- resp
+	}()
+
+	var resp *http.Response
+	select {
+	case resp = <-respC:
+	case err = <-errC:
+		_ = clientConn.Close()
+		return nil, errors.Wrapf(err, "http/2 stream to %s failed", u.String())
+	case <-ctx.Done():
+		_ = clientConn.Close()
+		return nil, errors.Wrapf(ctx.Err(), "http/2 stream to %s timed out", u.String())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		_ = clientConn.Close()
+		return nil, errors.Errorf("http/2 stream to %s failed with status %s", u.String(), resp.Status)
+	}
+
+	return &streamConn{
+		reader: resp.Body,
+		writer: writer,
+		closeF: func() error {
+			_ = writer.Close()
+			return clientConn.Close()
+		},
+		local:  conn.LocalAddr(),
+		remote: conn.RemoteAddr(),
+		closed: make(chan struct{}),
+	}, nil
+}
@@ -0,0 +1,223 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package wst
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/identity"
+	"github.com/openziti/transport/v2"
+	transporttls "github.com/openziti/transport/v2/tls"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Listen serves the edge protocol tunneled over HTTPS. Clients either upgrade a request to the configured path to
+// a websocket, or, over HTTP/2, send a POST to the path and stream data in the request and response bodies. The
+// outer TLS connection only authenticates the listener, so that it can be terminated and inspected by proxies. A
+// second TLS handshake inside the tunnel authenticates both sides, as for the tls transport.
+//
+// The outer TLS connection is served by the shared TLS listener, using the http/1.1 and h2 ALPN protocols, so a
+// wst listener can share a port with tls listeners using other protocols.
+func Listen(a address, name string, i *identity.TokenId, acceptF func(transport.Conn), tcfg transport.Configuration) (io.Closer, error) {
+	log := pfxlog.ContextLogger(name + "/" + a.String()).Entry
+
+	opts, err := loadOptions(tcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := getHandshakeTimeout(tcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// the outer connection only carries the http requests, clients are authenticated on the inner connection
+	outerTlsCfg := withOverrides(i.ServerTLSConfig(), func(cfg *tls.Config) {
+		cfg.NextProtos = opts.httpProtocols()
+		cfg.ClientAuth = tls.NoClientCert
+	})
+
+	innerTlsCfg := withOverrides(i.ServerTLSConfig(), func(cfg *tls.Config) {
+		cfg.NextProtos = tcfg.Protocols()
+	})
+
+	netListener, err := transporttls.ListenTLS(a.bindableAddress(), name, outerTlsCfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to listen on %s", a.String())
+	}
+
+	result := &acceptor{
+		name:        name,
+		log:         log,
+		opts:        opts,
+		acceptF:     acceptF,
+		timeout:     timeout,
+		innerTlsCfg: innerTlsCfg,
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: timeout,
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+	}
+
+	result.server = &http.Server{
+		Handler:           result,
+		ReadHeaderTimeout: timeout,
+	}
+
+	go func() {
+		if err := result.server.Serve(netListener); err != nil && !result.closed.Load() {
+			log.WithError(err).Error("wst listener failed. Failure not recoverable")
+		}
+		log.Info("exited")
+	}()
+
+	return result, nil
+}
+
+// withOverrides clones the given config and applies the overrides to it. The identity hands back its own config when
+// a client connects, so the overrides are applied to that config as well.
+func withOverrides(cfg *tls.Config, overrideF func(cfg *tls.Config)) *tls.Config {
+	result := cfg.Clone()
+	overrideF(result)
+
+	if getConfigForClient := result.GetConfigForClient; getConfigForClient != nil {
+		result.GetConfigForClient = func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg, err := getConfigForClient(info)
+			if cfg != nil {
+				cfg = cfg.Clone()
+				overrideF(cfg)
+			}
+			return cfg, err
+		}
+	}
+	return result
+}
+
+type acceptor struct {
+	name        string
+	log         *logrus.Entry
+	opts        *options
+	acceptF     func(transport.Conn)
+	timeout     time.Duration
+	innerTlsCfg *tls.Config
+	upgrader    websocket.Upgrader
+	server      *http.Server
+	closed      atomic.Bool
+}
+
+func (self *acceptor) Close() error {
+	if self.closed.CompareAndSwap(false, true) {
+		return self.server.Close()
+	}
+	return nil
+}
+
+func (self *acceptor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != self.opts.path {
+		http.NotFound(w, r)
+		return
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		self.acceptWebSocket(w, r)
+		return
+	}
+
+	if self.opts.http2 && r.ProtoMajor == 2 && r.Method == http.MethodPost {
+		self.acceptStream(w, r)
+		return
+	}
+
+	http.Error(w, "expected websocket upgrade or HTTP/2 stream", http.StatusBadRequest)
+}
+
+func (self *acceptor) acceptWebSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := self.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		self.log.WithField("remote", r.RemoteAddr).WithError(err).Error("websocket upgrade failed")
+		return
+	}
+
+	self.accept(newWsConn(ws))
+}
+
+// acceptStream handles a full duplex HTTP/2 stream. The stream ends when the handler returns, so the handler
+// waits until the connection is closed, or the client goes away.
+func (self *acceptor) acceptStream(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		self.log.WithField("remote", r.RemoteAddr).WithError(err).Error("unable to start http/2 stream")
+		return
+	}
+
+	local, _ := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	remote, _ := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+
+	conn := &streamConn{
+		reader: r.Body,
+		writer: w,
+		flushF: rc.Flush,
+		local:  local,
+		remote: remote,
+		closed: make(chan struct{}),
+	}
+
+	go self.accept(conn)
+
+	select {
+	case <-conn.closed:
+	case <-r.Context().Done():
+		_ = conn.Close()
+	}
+}
+
+// accept runs the inner TLS handshake before handing off the connection, so that the peer certificates are
+// available
+func (self *acceptor) accept(conn net.Conn) {
+	log := self.log.WithField("remote", conn.RemoteAddr().String())
+
+	ctx, cancelF := context.WithTimeout(context.Background(), self.timeout)
+	defer cancelF()
+
+	tlsConn := tls.Server(conn, self.innerTlsCfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		log.WithError(err).Error("tls handshake inside wst tunnel failed")
+		_ = conn.Close()
+		return
+	}
+
+	detail := &transport.ConnectionDetail{
+		Address: Type + ":" + conn.RemoteAddr().String(),
+		InBound: true,
+		Name:    self.name,
+	}
+
+	self.acceptF(transporttls.NewConnection(detail, tlsConn))
+}
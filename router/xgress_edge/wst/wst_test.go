@@ -0,0 +1,269 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package wst
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openziti/identity"
+	"github.com/openziti/transport/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressParser(t *testing.T) {
+	req := require.New(t)
+	parser := AddressParser{}
+
+	addr, err := parser.Parse("wst:127.0.0.1:443")
+	req.NoError(err)
+	req.Equal(Type, addr.Type())
+	req.Equal("wst:127.0.0.1:443", addr.String())
+	req.Equal("127.0.0.1", addr.(transport.HostPortAddress).Hostname())
+	req.Equal(uint16(443), addr.(transport.HostPortAddress).Port())
+
+	_, err = parser.Parse("wss:127.0.0.1:443")
+	req.Error(err)
+
+	_, err = parser.Parse("wst:127.0.0.1")
+	req.Error(err)
+}
+
+func TestLoadOptions(t *testing.T) {
+	req := require.New(t)
+
+	opts, err := loadOptions(nil)
+	req.NoError(err)
+	req.Equal(DefaultPath, opts.path)
+	req.Equal([]string{protocolHttp2, protocolHttp1}, opts.httpProtocols())
+
+	opts, err = loadOptions(transport.Configuration{
+		Type: map[interface{}]interface{}{
+			"path":  "/edge",
+			"http2": false,
+		},
+	})
+	req.NoError(err)
+	req.Equal("/edge", opts.path)
+	req.Equal([]string{protocolHttp1}, opts.httpProtocols())
+
+	_, err = loadOptions(transport.Configuration{
+		Type: map[interface{}]interface{}{
+			"path": "edge",
+		},
+	})
+	req.Error(err)
+}
+
+func TestWebSocketTunnel(t *testing.T) {
+	testTunnel(t, false, nil)
+}
+
+func TestHttp2Tunnel(t *testing.T) {
+	testTunnel(t, true, nil)
+}
+
+func TestTunnelThroughProxy(t *testing.T) {
+	proxyAddr := startConnectProxy(t, "user", "secret")
+
+	testTunnel(t, true, map[interface{}]interface{}{
+		"type":     "http",
+		"address":  proxyAddr,
+		"username": "user",
+		"password": "secret",
+	})
+}
+
+func testTunnel(t *testing.T, http2 bool, proxyConfig map[interface{}]interface{}) {
+	req := require.New(t)
+	id := newTestIdentity(t)
+
+	port := getFreePort(t)
+	addr, err := AddressParser{}.Parse("wst:127.0.0.1:" + strconv.Itoa(port))
+	req.NoError(err)
+
+	tcfg := transport.Configuration{
+		Type: map[interface{}]interface{}{
+			"http2": http2,
+		},
+	}
+
+	acceptedC := make(chan transport.Conn, 1)
+	closer, err := addr.Listen("test", id, func(conn transport.Conn) {
+		acceptedC <- conn
+	}, tcfg)
+	req.NoError(err)
+	defer func() { _ = closer.Close() }()
+
+	dialCfg := transport.Configuration{
+		Type: map[interface{}]interface{}{
+			"http2": http2,
+		},
+	}
+	if proxyConfig != nil {
+		dialCfg[transport.KeyProxy] = proxyConfig
+	}
+
+	conn, err := addr.Dial("test", id, 5*time.Second, dialCfg)
+	req.NoError(err)
+	defer func() { _ = conn.Close() }()
+
+	var accepted transport.Conn
+	select {
+	case accepted = <-acceptedC:
+	case <-time.After(5 * time.Second):
+		req.Fail("connection not accepted")
+	}
+	defer func() { _ = accepted.Close() }()
+
+	req.Len(accepted.PeerCertificates(), 1)
+	req.Equal("test", accepted.PeerCertificates()[0].Subject.CommonName)
+
+	go func() {
+		_, _ = io.Copy(accepted, accepted)
+	}()
+
+	for i := 0; i < 10; i++ {
+		msg := []byte("hello " + strconv.Itoa(i))
+		_, err = conn.Write(msg)
+		req.NoError(err)
+
+		buf := make([]byte, len(msg))
+		_, err = io.ReadFull(conn, buf)
+		req.NoError(err)
+		req.Equal(msg, buf)
+	}
+}
+
+func startConnectProxy(t *testing.T, user, password string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	expectedAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+
+				reader := bufio.NewReader(conn)
+				r, err := http.ReadRequest(reader)
+				if err != nil {
+					return
+				}
+
+				if r.Method != http.MethodConnect || r.Header.Get("Authorization") != expectedAuth {
+					_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+					return
+				}
+
+				target, err := net.Dial("tcp", r.Host)
+				if err != nil {
+					_, _ = conn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+					return
+				}
+				defer func() { _ = target.Close() }()
+
+				_, _ = conn.Write([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+				go func() {
+					_, _ = io.Copy(target, reader)
+				}()
+				_, _ = io.Copy(conn, target)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func getFreePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func newTestIdentity(t *testing.T) *identity.TokenId {
+	req := require.New(t)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req.NoError(err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	req.NoError(err)
+	caCert, err := x509.ParseCertificate(caDer)
+	req.NoError(err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req.NoError(err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	req.NoError(err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	req.NoError(err)
+
+	certPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	caPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDer}))
+	keyPem := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+
+	id, err := identity.LoadIdentity(identity.Config{
+		Key:        "pem:" + keyPem,
+		Cert:       "pem:" + certPem,
+		ServerCert: "pem:" + certPem,
+		CA:         "pem:" + caPem,
+	})
+	req.NoError(err)
+
+	return identity.NewIdentity(id)
+}
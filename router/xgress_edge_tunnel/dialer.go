@@ -56,6 +56,8 @@ func (self *tunneler) Dial(params xgress_router.DialParams) (xt.PeerData, error)
 		return nil, err
 	}
 
+	tunnel.SetSourceIdentity(options, string(circuitId.Data[uint32(edge.CallerIdHeader)]))
+
 	//TODO: Figure out timeout
 	conn, halfClose, err := terminator.context.Dial(options)
 	if err != nil {
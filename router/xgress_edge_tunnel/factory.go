@@ -149,6 +149,8 @@ type Options struct {
 	services         []string
 	udpIdleTimeout   time.Duration
 	udpCheckInterval time.Duration
+
+	healthCheckScriptDir string
 }
 
 func (options *Options) load(data xgress.OptionsData) error {
@@ -267,6 +269,14 @@ func (options *Options) load(data xgress.OptionsData) error {
 			}
 		}
 
+		if value, found := data["healthCheckScriptDir"]; found {
+			if strVal, ok := value.(string); ok {
+				options.healthCheckScriptDir = strVal
+			} else {
+				return errors.Errorf(`invalid value '%v' for healthCheckScriptDir, must be a string value`, value)
+			}
+		}
+
 	}
 
 	return nil
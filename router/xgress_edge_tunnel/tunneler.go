@@ -26,6 +26,7 @@ import (
 	"github.com/openziti/sdk-golang/xgress"
 	"github.com/openziti/ziti/router/state"
 	"github.com/openziti/ziti/tunnel/dns"
+	"github.com/openziti/ziti/tunnel/health"
 	"github.com/openziti/ziti/tunnel/intercept"
 	"github.com/openziti/ziti/tunnel/intercept/host"
 	"github.com/openziti/ziti/tunnel/intercept/proxy"
@@ -73,10 +74,14 @@ func (self *tunneler) Start(notifyClose <-chan struct{}) error {
 	log := pfxlog.Logger()
 	var resolver dns.Resolver
 
+	if err = health.SetScriptCheckDir(self.listenOptions.healthCheckScriptDir); err != nil {
+		return err
+	}
+
 	if strings.HasPrefix(self.listenOptions.mode, "tproxy") {
 		log.WithField("mode", self.listenOptions.mode).Info("creating tproxy interceptor")
 
-		resolver, err = dns.NewResolver(self.listenOptions.resolver, self.listenOptions.dnsUpstream, self.listenOptions.dnsUnanswerable)
+		resolver, err = dns.NewResolver(self.listenOptions.resolver, self.listenOptions.dnsUpstream, self.listenOptions.dnsUnanswerable, false)
 		if err != nil {
 			pfxlog.Logger().WithError(err).Error("failed to start DNS resolver. using dummy resolver")
 			resolver = dns.NewDummyResolver()
@@ -0,0 +1,188 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_proxy_connect
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+var jwtSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// authenticator authenticates proxy clients. A client is accepted if it presents a certificate issued by one of the
+// configured CAs, or a JWT signed by one of the configured signers. If neither is configured, unauthenticated clients
+// are only accepted when explicitly allowed.
+type authenticator struct {
+	caPool               *x509.CertPool
+	jwtKeys              []jwt.VerificationKey
+	jwtIssuer            string
+	jwtAudience          string
+	allowUnauthenticated bool
+}
+
+func loadAuthenticator(authData map[interface{}]interface{}) (*authenticator, error) {
+	result := &authenticator{}
+
+	if value, found := authData["mtlsCaFile"]; found {
+		caFile, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("invalid value for 'auth.mtlsCaFile', must be a string: %v", value)
+		}
+		pemData, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read 'auth.mtlsCaFile' %s", caFile)
+		}
+		result.caPool = x509.NewCertPool()
+		if !result.caPool.AppendCertsFromPEM(pemData) {
+			return nil, errors.Errorf("no certificates found in 'auth.mtlsCaFile' %s", caFile)
+		}
+	}
+
+	if value, found := authData["jwtSignerCertFile"]; found {
+		signerFile, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("invalid value for 'auth.jwtSignerCertFile', must be a string: %v", value)
+		}
+		pemData, err := os.ReadFile(signerFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read 'auth.jwtSignerCertFile' %s", signerFile)
+		}
+		if result.jwtKeys, err = parseVerificationKeys(pemData); err != nil {
+			return nil, errors.Wrapf(err, "invalid 'auth.jwtSignerCertFile' %s", signerFile)
+		}
+	}
+
+	for key, target := range map[string]*string{"jwtIssuer": &result.jwtIssuer, "jwtAudience": &result.jwtAudience} {
+		if value, found := authData[key]; found {
+			strVal, ok := value.(string)
+			if !ok {
+				return nil, errors.Errorf("invalid value for 'auth.%s', must be a string: %v", key, value)
+			}
+			*target = strVal
+		}
+	}
+
+	if value, found := authData["allowUnauthenticated"]; found {
+		allow, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid value for 'auth.allowUnauthenticated', must be a boolean: %v", value)
+		}
+		result.allowUnauthenticated = allow
+	}
+
+	if result.caPool == nil && len(result.jwtKeys) == 0 && !result.allowUnauthenticated {
+		return nil, errors.New("no authentication configured. Set 'auth.mtlsCaFile' and/or 'auth.jwtSignerCertFile', " +
+			"or set 'auth.allowUnauthenticated' to true")
+	}
+
+	return result, nil
+}
+
+// parseVerificationKeys returns the public keys of the certificates and public keys in the given PEM data
+func parseVerificationKeys(pemData []byte) ([]jwt.VerificationKey, error) {
+	var result []jwt.VerificationKey
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, cert.PublicKey)
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, key)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("no certificates or public keys found")
+	}
+	return result, nil
+}
+
+func (self *authenticator) jwtEnabled() bool {
+	return len(self.jwtKeys) > 0
+}
+
+// authenticateCerts returns the common name of the client certificate, if it was issued by a configured CA
+func (self *authenticator) authenticateCerts(certs []*x509.Certificate) (string, bool) {
+	if self.caPool == nil || len(certs) == 0 {
+		return "", false
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         self.caPool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	if err != nil {
+		return "", false
+	}
+
+	return certs[0].Subject.CommonName, true
+}
+
+// authenticateJwt returns the subject of the token, if it's valid and signed by a configured signer
+func (self *authenticator) authenticateJwt(token string) (string, error) {
+	if !self.jwtEnabled() {
+		return "", errors.New("jwt authentication not enabled")
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtSigningMethods),
+		jwt.WithExpirationRequired(),
+	}
+
+	if self.jwtIssuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(self.jwtIssuer))
+	}
+
+	if self.jwtAudience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(self.jwtAudience))
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return jwt.VerificationKeySet{Keys: self.jwtKeys}, nil
+	}, parserOptions...)
+
+	if err != nil {
+		return "", err
+	}
+
+	return claims.Subject, nil
+}
@@ -0,0 +1,72 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_proxy_connect
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/transport/v2"
+	"github.com/pkg/errors"
+)
+
+// proxyConnectConnection reads through the buffered reader used for the proxy handshake, so any data the client sent
+// after the handshake isn't lost
+type proxyConnectConnection struct {
+	transport.Conn
+	reader    *bufio.Reader
+	principal string
+	target    string
+}
+
+func newProxyConnectConnection(peer transport.Conn) *proxyConnectConnection {
+	return &proxyConnectConnection{
+		Conn:   peer,
+		reader: bufio.NewReader(peer),
+	}
+}
+
+func (c *proxyConnectConnection) LogContext() string {
+	if c.target == "" {
+		return c.Detail().String()
+	}
+	return fmt.Sprintf("%s, principal: %s, target: %s", c.Detail().String(), c.principal, c.target)
+}
+
+func (c *proxyConnectConnection) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func (c *proxyConnectConnection) ReadPayload() ([]byte, map[uint8][]byte, error) {
+	buffer := make([]byte, 10240)
+	n, err := c.Read(buffer)
+	return buffer[:n], nil, err
+}
+
+func (c *proxyConnectConnection) WritePayload(p []byte, headers map[uint8][]byte) (n int, err error) {
+	return c.Write(p)
+}
+
+func (c *proxyConnectConnection) HandleControlMsg(controlType xgress.ControlType, headers channel.Headers, responder xgress.ControlReceiver) error {
+	if controlType == xgress.ControlTypeTraceRoute {
+		xgress.RespondToTraceRequest(headers, "xgress/proxy_connect", "", responder)
+		return nil
+	}
+	return errors.Errorf("unhandled control type: %v", controlType)
+}
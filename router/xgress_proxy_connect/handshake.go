@@ -0,0 +1,368 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_proxy_connect
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	protocolHttp   = "http"
+	protocolSocks5 = "socks5"
+
+	socks5Version             = 0x05
+	socks5AuthVersion         = 0x01
+	socks5MethodNoAuth        = 0x00
+	socks5MethodUserPass      = 0x02
+	socks5MethodNoAcceptable  = 0xff
+	socks5CmdConnect          = 0x01
+	socks5AddrIpv4            = 0x01
+	socks5AddrDomain          = 0x03
+	socks5AddrIpv6            = 0x04
+	socks5ReplySucceeded      = 0x00
+	socks5ReplyFailure        = 0x01
+	socks5ReplyNotAllowed     = 0x02
+	socks5ReplyHostUnreach    = 0x04
+	socks5ReplyCmdUnsupported = 0x07
+	socks5ReplyAddrType       = 0x08
+)
+
+type failureReason int
+
+const (
+	failureNoService failureReason = iota
+	failureCircuit
+)
+
+// connectRequest is a successfully authenticated request to connect to host:port, using either HTTP CONNECT or SOCKS5
+type connectRequest struct {
+	protocol  string
+	host      string
+	port      uint16
+	principal string
+	w         io.Writer
+}
+
+func (self *connectRequest) succeed() error {
+	if self.protocol == protocolSocks5 {
+		return writeSocks5Reply(self.w, socks5ReplySucceeded)
+	}
+	return writeHttpResponse(self.w, http.StatusOK, "Connection established", nil)
+}
+
+func (self *connectRequest) fail(reason failureReason) {
+	if self.protocol == protocolSocks5 {
+		if reason == failureNoService {
+			_ = writeSocks5Reply(self.w, socks5ReplyNotAllowed)
+		} else {
+			_ = writeSocks5Reply(self.w, socks5ReplyHostUnreach)
+		}
+		return
+	}
+
+	if reason == failureNoService {
+		_ = writeHttpResponse(self.w, http.StatusForbidden, "", nil)
+	} else {
+		_ = writeHttpResponse(self.w, http.StatusBadGateway, "", nil)
+	}
+}
+
+// readConnectRequest reads and authenticates an HTTP CONNECT or SOCKS5 request. Protocol level errors, such as
+// authentication failures, are reported to the client before returning an error.
+func readConnectRequest(conn *proxyConnectConnection, options *proxyOptions) (*connectRequest, error) {
+	certPrincipal, certAuthenticated := options.auth.authenticateCerts(conn.PeerCertificates())
+
+	first, err := conn.reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] == socks5Version {
+		if !options.socks5 {
+			return nil, errors.New("socks5 request received, but socks5 is not enabled")
+		}
+		return readSocks5Request(conn.reader, conn, options.auth, certPrincipal, certAuthenticated)
+	}
+
+	return readHttpConnectRequest(conn.reader, conn, options.auth, certPrincipal, certAuthenticated)
+}
+
+func readHttpConnectRequest(r *bufio.Reader, w io.Writer, auth *authenticator, principal string, authenticated bool) (*connectRequest, error) {
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		_ = writeHttpResponse(w, http.StatusBadRequest, "", nil)
+		return nil, errors.Wrap(err, "unable to read http request")
+	}
+
+	if req.Method != http.MethodConnect {
+		_ = writeHttpResponse(w, http.StatusMethodNotAllowed, "", map[string]string{"Allow": http.MethodConnect})
+		return nil, errors.Errorf("unsupported http method %s", req.Method)
+	}
+
+	if !authenticated && auth.jwtEnabled() {
+		if token := proxyAuthToken(req.Header.Get("Proxy-Authorization")); token != "" {
+			if principal, err = auth.authenticateJwt(token); err != nil {
+				_ = writeHttpResponse(w, http.StatusProxyAuthRequired, "", map[string]string{"Proxy-Authenticate": `Basic realm="ziti"`})
+				return nil, errors.Wrap(err, "invalid jwt")
+			}
+			authenticated = true
+		}
+	}
+
+	if !authenticated && !auth.allowUnauthenticated {
+		_ = writeHttpResponse(w, http.StatusProxyAuthRequired, "", map[string]string{"Proxy-Authenticate": `Basic realm="ziti"`})
+		return nil, errors.New("client not authenticated")
+	}
+
+	host, port, err := splitHostPort(req.RequestURI)
+	if err != nil {
+		_ = writeHttpResponse(w, http.StatusBadRequest, "", nil)
+		return nil, err
+	}
+
+	return &connectRequest{
+		protocol:  protocolHttp,
+		host:      host,
+		port:      port,
+		principal: principal,
+		w:         w,
+	}, nil
+}
+
+// proxyAuthToken returns the token from a Proxy-Authorization header. Bearer tokens are used as is. As many clients
+// only support basic proxy authentication, the token may also be given as the basic auth password, or as the
+// username if the password is empty.
+func proxyAuthToken(header string) string {
+	scheme, value, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found {
+		return ""
+	}
+
+	if strings.EqualFold(scheme, "bearer") {
+		return strings.TrimSpace(value)
+	}
+
+	if strings.EqualFold(scheme, "basic") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return ""
+		}
+		user, password, _ := strings.Cut(string(decoded), ":")
+		if password != "" {
+			return password
+		}
+		return user
+	}
+
+	return ""
+}
+
+func splitHostPort(hostPort string) (string, uint16, error) {
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "invalid connect target %s", hostPort)
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return "", 0, errors.Errorf("invalid port in connect target %s", hostPort)
+	}
+
+	return host, uint16(port), nil
+}
+
+func writeHttpResponse(w io.Writer, status int, reason string, headers map[string]string) error {
+	if reason == "" {
+		reason = http.StatusText(status)
+	}
+
+	response := fmt.Sprintf("HTTP/1.1 %d %s\r\n", status, reason)
+	for k, v := range headers {
+		response += k + ": " + v + "\r\n"
+	}
+	if status != http.StatusOK {
+		response += "Content-Length: 0\r\nConnection: close\r\n"
+	}
+	response += "\r\n"
+
+	_, err := io.WriteString(w, response)
+	return err
+}
+
+func readSocks5Request(r *bufio.Reader, w io.Writer, auth *authenticator, principal string, authenticated bool) (*connectRequest, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return nil, err
+	}
+
+	offered := func(method byte) bool {
+		for _, m := range methods {
+			if m == method {
+				return true
+			}
+		}
+		return false
+	}
+
+	var method byte = socks5MethodNoAcceptable
+	if (authenticated || auth.allowUnauthenticated) && offered(socks5MethodNoAuth) {
+		method = socks5MethodNoAuth
+	} else if (authenticated || auth.jwtEnabled()) && offered(socks5MethodUserPass) {
+		method = socks5MethodUserPass
+	}
+
+	if _, err := w.Write([]byte{socks5Version, method}); err != nil {
+		return nil, err
+	}
+
+	if method == socks5MethodNoAcceptable {
+		return nil, errors.New("no acceptable socks5 authentication method offered")
+	}
+
+	if method == socks5MethodUserPass {
+		user, password, err := readSocks5UserPass(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if !authenticated {
+			token := password
+			if token == "" {
+				token = user
+			}
+			if principal, err = auth.authenticateJwt(token); err != nil {
+				_, _ = w.Write([]byte{socks5AuthVersion, 0x01})
+				return nil, errors.Wrap(err, "invalid jwt")
+			}
+		}
+
+		if _, err = w.Write([]byte{socks5AuthVersion, 0x00}); err != nil {
+			return nil, err
+		}
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(r, request); err != nil {
+		return nil, err
+	}
+
+	if request[0] != socks5Version {
+		_ = writeSocks5Reply(w, socks5ReplyFailure)
+		return nil, errors.Errorf("invalid socks5 request version %d", request[0])
+	}
+
+	if request[1] != socks5CmdConnect {
+		_ = writeSocks5Reply(w, socks5ReplyCmdUnsupported)
+		return nil, errors.Errorf("unsupported socks5 command %d", request[1])
+	}
+
+	var host string
+	switch request[3] {
+	case socks5AddrIpv4, socks5AddrIpv6:
+		addrLen := net.IPv4len
+		if request[3] == socks5AddrIpv6 {
+			addrLen = net.IPv6len
+		}
+		addr := make([]byte, addrLen)
+		if _, err := io.ReadFull(r, addr); err != nil {
+			return nil, err
+		}
+		host = net.IP(addr).String()
+	case socks5AddrDomain:
+		domainLen, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		domain := make([]byte, domainLen)
+		if _, err = io.ReadFull(r, domain); err != nil {
+			return nil, err
+		}
+		host = string(domain)
+	default:
+		_ = writeSocks5Reply(w, socks5ReplyAddrType)
+		return nil, errors.Errorf("unsupported socks5 address type %d", request[3])
+	}
+
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(r, portBytes); err != nil {
+		return nil, err
+	}
+
+	return &connectRequest{
+		protocol:  protocolSocks5,
+		host:      host,
+		port:      binary.BigEndian.Uint16(portBytes),
+		principal: principal,
+		w:         w,
+	}, nil
+}
+
+// readSocks5UserPass reads a username/password authentication request, as defined in RFC 1929
+func readSocks5UserPass(r *bufio.Reader) (string, string, error) {
+	version, err := r.ReadByte()
+	if err != nil {
+		return "", "", err
+	}
+
+	if version != socks5AuthVersion {
+		return "", "", errors.Errorf("invalid socks5 auth version %d", version)
+	}
+
+	readField := func() (string, error) {
+		fieldLen, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		field := make([]byte, fieldLen)
+		if _, err = io.ReadFull(r, field); err != nil {
+			return "", err
+		}
+		return string(field), nil
+	}
+
+	user, err := readField()
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err := readField()
+	if err != nil {
+		return "", "", err
+	}
+
+	return user, password, nil
+}
+
+func writeSocks5Reply(w io.Writer, reply byte) error {
+	_, err := w.Write([]byte{socks5Version, reply, 0x00, socks5AddrIpv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,218 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_proxy_connect
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJwtAuthenticator(t *testing.T) (*authenticator, func(subject string, expiresIn time.Duration) string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	auth := &authenticator{
+		jwtKeys:     []jwt.VerificationKey{&key.PublicKey},
+		jwtAudience: "proxy",
+	}
+
+	sign := func(subject string, expiresIn time.Duration) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{"proxy"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		}).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+
+	return auth, sign
+}
+
+func TestLoadProxyOptions(t *testing.T) {
+	req := require.New(t)
+
+	_, err := loadProxyOptions(xgress.OptionsData{
+		"services": []interface{}{map[interface{}]interface{}{"host": "*", "service": "all"}},
+	})
+	req.ErrorContains(err, "no authentication configured")
+
+	options, err := loadProxyOptions(xgress.OptionsData{
+		"socks5": true,
+		"auth":   map[interface{}]interface{}{"allowUnauthenticated": true},
+		"services": []interface{}{
+			map[interface{}]interface{}{"host": "db.internal", "port": 5432, "service": "postgres"},
+			map[interface{}]interface{}{"host": "*.Example.com", "service": "example"},
+			map[interface{}]interface{}{"host": "*", "port": 443, "service": "https"},
+		},
+	})
+	req.NoError(err)
+	req.True(options.socks5)
+	req.Equal(DefaultHandshakeTimeout, options.handshakeTimeout)
+
+	req.Equal("postgres", options.serviceFor("DB.internal.", 5432))
+	req.Equal("", options.serviceFor("db.internal", 5433))
+	req.Equal("example", options.serviceFor("wiki.example.com", 80))
+	req.Equal("", options.serviceFor("example.com", 80))
+	req.Equal("https", options.serviceFor("example.com", 443))
+
+	_, err = loadProxyOptions(xgress.OptionsData{
+		"auth":     map[interface{}]interface{}{"allowUnauthenticated": true},
+		"services": []interface{}{map[interface{}]interface{}{"host": "a.*.com", "service": "bad"}},
+	})
+	req.Error(err)
+}
+
+func TestHttpConnectRequest(t *testing.T) {
+	auth, sign := newTestJwtAuthenticator(t)
+
+	t.Run("accepts a bearer jwt", func(t *testing.T) {
+		req := require.New(t)
+		out := &bytes.Buffer{}
+		in := "CONNECT db.internal:5432 HTTP/1.1\r\nHost: db.internal:5432\r\nProxy-Authorization: Bearer " +
+			sign("alice", time.Minute) + "\r\n\r\nearly data"
+		r := bufio.NewReader(strings.NewReader(in))
+
+		request, err := readHttpConnectRequest(r, out, auth, "", false)
+		req.NoError(err)
+		req.Equal("db.internal", request.host)
+		req.Equal(uint16(5432), request.port)
+		req.Equal("alice", request.principal)
+		req.Empty(out.String())
+
+		rest := make([]byte, 32)
+		n, _ := r.Read(rest)
+		req.Equal("early data", string(rest[:n]))
+
+		req.NoError(request.succeed())
+		req.Equal("HTTP/1.1 200 Connection established\r\n\r\n", out.String())
+	})
+
+	t.Run("accepts a jwt as the basic auth password", func(t *testing.T) {
+		req := require.New(t)
+		basic := base64.StdEncoding.EncodeToString([]byte("ignored:" + sign("bob", time.Minute)))
+		in := "CONNECT db.internal:5432 HTTP/1.1\r\nProxy-Authorization: Basic " + basic + "\r\n\r\n"
+
+		request, err := readHttpConnectRequest(bufio.NewReader(strings.NewReader(in)), &bytes.Buffer{}, auth, "", false)
+		req.NoError(err)
+		req.Equal("bob", request.principal)
+	})
+
+	t.Run("rejects missing and expired tokens", func(t *testing.T) {
+		req := require.New(t)
+		out := &bytes.Buffer{}
+		in := "CONNECT db.internal:5432 HTTP/1.1\r\n\r\n"
+		_, err := readHttpConnectRequest(bufio.NewReader(strings.NewReader(in)), out, auth, "", false)
+		req.Error(err)
+		req.True(strings.HasPrefix(out.String(), "HTTP/1.1 407 "))
+
+		out.Reset()
+		in = "CONNECT db.internal:5432 HTTP/1.1\r\nProxy-Authorization: Bearer " + sign("alice", -time.Minute) + "\r\n\r\n"
+		_, err = readHttpConnectRequest(bufio.NewReader(strings.NewReader(in)), out, auth, "", false)
+		req.Error(err)
+		req.True(strings.HasPrefix(out.String(), "HTTP/1.1 407 "))
+	})
+
+	t.Run("accepts certificate authenticated clients", func(t *testing.T) {
+		req := require.New(t)
+		in := "CONNECT db.internal:5432 HTTP/1.1\r\n\r\n"
+		request, err := readHttpConnectRequest(bufio.NewReader(strings.NewReader(in)), &bytes.Buffer{}, auth, "client1", true)
+		req.NoError(err)
+		req.Equal("client1", request.principal)
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		req := require.New(t)
+		out := &bytes.Buffer{}
+		in := "GET http://db.internal/ HTTP/1.1\r\nHost: db.internal\r\n\r\n"
+		_, err := readHttpConnectRequest(bufio.NewReader(strings.NewReader(in)), out, auth, "client1", true)
+		req.Error(err)
+		req.True(strings.HasPrefix(out.String(), "HTTP/1.1 405 "))
+	})
+}
+
+func TestSocks5Request(t *testing.T) {
+	auth, sign := newTestJwtAuthenticator(t)
+
+	t.Run("accepts a jwt as the password", func(t *testing.T) {
+		req := require.New(t)
+		token := sign("alice", time.Minute)
+
+		in := &bytes.Buffer{}
+		in.Write([]byte{socks5Version, 2, socks5MethodNoAuth, socks5MethodUserPass})
+		in.Write([]byte{socks5AuthVersion, 4})
+		in.WriteString("user")
+		in.Write([]byte{byte(len(token))})
+		in.WriteString(token)
+		in.Write([]byte{socks5Version, socks5CmdConnect, 0, socks5AddrDomain, 11})
+		in.WriteString("db.internal")
+		in.Write([]byte{0x15, 0x38})
+
+		out := &bytes.Buffer{}
+		request, err := readSocks5Request(bufio.NewReader(in), out, auth, "", false)
+		req.NoError(err)
+		req.Equal("db.internal", request.host)
+		req.Equal(uint16(5432), request.port)
+		req.Equal("alice", request.principal)
+		req.Equal([]byte{socks5Version, socks5MethodUserPass, socks5AuthVersion, 0x00}, out.Bytes())
+
+		out.Reset()
+		request.fail(failureNoService)
+		req.Equal(byte(socks5ReplyNotAllowed), out.Bytes()[1])
+	})
+
+	t.Run("rejects clients which can't authenticate", func(t *testing.T) {
+		req := require.New(t)
+		in := bytes.NewBuffer([]byte{socks5Version, 1, socks5MethodNoAuth})
+		out := &bytes.Buffer{}
+		_, err := readSocks5Request(bufio.NewReader(in), out, auth, "", false)
+		req.Error(err)
+		req.Equal([]byte{socks5Version, socks5MethodNoAcceptable}, out.Bytes())
+	})
+
+	t.Run("accepts ipv4 destinations without auth when allowed", func(t *testing.T) {
+		req := require.New(t)
+		in := bytes.NewBuffer([]byte{socks5Version, 1, socks5MethodNoAuth,
+			socks5Version, socks5CmdConnect, 0, socks5AddrIpv4, 10, 0, 0, 1, 0x01, 0xbb})
+		out := &bytes.Buffer{}
+		request, err := readSocks5Request(bufio.NewReader(in), out, &authenticator{allowUnauthenticated: true}, "", false)
+		req.NoError(err)
+		req.Equal("10.0.0.1", request.host)
+		req.Equal(uint16(443), request.port)
+	})
+
+	t.Run("rejects unsupported commands", func(t *testing.T) {
+		req := require.New(t)
+		in := bytes.NewBuffer([]byte{socks5Version, 1, socks5MethodNoAuth,
+			socks5Version, 0x02, 0, socks5AddrIpv4, 10, 0, 0, 1, 0x01, 0xbb})
+		out := &bytes.Buffer{}
+		_, err := readSocks5Request(bufio.NewReader(in), out, auth, "client1", true)
+		req.Error(err)
+		req.Equal(byte(socks5ReplyCmdUnsupported), out.Bytes()[3])
+	})
+}
@@ -0,0 +1,135 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_proxy_connect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/foundation/v2/concurrenz"
+	"github.com/openziti/identity"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/transport/v2"
+	"github.com/openziti/ziti/router/env"
+	"github.com/openziti/ziti/router/xgress_router"
+)
+
+func newListener(id *identity.TokenId, ctrl env.NetworkControllers, options *xgress.Options, proxyOptions *proxyOptions, tcfg transport.Configuration) xgress_router.Listener {
+	return &listener{
+		id:           id,
+		ctrl:         ctrl,
+		options:      options,
+		proxyOptions: proxyOptions,
+		tcfg:         tcfg,
+	}
+}
+
+func (listener *listener) Listen(address string, bindHandler xgress.BindHandler) error {
+	if address == "" {
+		return errors.New("address must be specified for proxy_connect listeners")
+	}
+	txAddress, err := transport.ParseAddress(address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on invalid address [%s] (%s)", address, err)
+	}
+
+	acceptF := func(peer transport.Conn) {
+		go listener.handleConnect(peer, bindHandler)
+	}
+
+	socket, err := txAddress.Listen("tcp", listener.id, acceptF, listener.tcfg)
+	if err != nil {
+		return err
+	}
+	listener.socket.Store(socket)
+	return nil
+}
+
+func (listener *listener) handleConnect(peer transport.Conn, bindHandler xgress.BindHandler) {
+	conn := newProxyConnectConnection(peer)
+	log := pfxlog.ContextLogger(conn.LogContext())
+
+	_ = peer.SetDeadline(time.Now().Add(listener.proxyOptions.handshakeTimeout))
+	request, err := readConnectRequest(conn, listener.proxyOptions)
+	if err != nil {
+		log.WithError(err).Debug("proxy handshake failed")
+		_ = peer.Close()
+		return
+	}
+
+	conn.principal = request.principal
+	conn.target = net.JoinHostPort(request.host, strconv.Itoa(int(request.port)))
+	log = pfxlog.ContextLogger(conn.LogContext())
+
+	service := listener.proxyOptions.serviceFor(request.host, request.port)
+	if service == "" {
+		log.Debug("no service mapped for requested destination")
+		request.fail(failureNoService)
+		_ = peer.Close()
+		return
+	}
+
+	// the success response must be written before the circuit starts, as the far side may send data first
+	respondingBindHandler := &respondingBindHandler{
+		BindHandler: bindHandler,
+		onBind: func() {
+			_ = peer.SetDeadline(time.Time{})
+			if err := request.succeed(); err != nil {
+				log.WithError(err).Debug("unable to write proxy connect response")
+			}
+		},
+	}
+
+	xgRequest := &xgress_router.Request{ServiceId: service}
+	response := xgress_router.CreateCircuit(listener.ctrl, conn, xgRequest, respondingBindHandler, listener.options)
+	if !response.Success {
+		log.Errorf("error creating circuit for service %s (%s)", service, response.Message)
+		request.fail(failureCircuit)
+		_ = peer.Close()
+	}
+}
+
+type respondingBindHandler struct {
+	xgress.BindHandler
+	onBind func()
+}
+
+func (self *respondingBindHandler) HandleXgressBind(x *xgress.Xgress) {
+	self.onBind()
+	self.BindHandler.HandleXgressBind(x)
+}
+
+type listener struct {
+	id           *identity.TokenId
+	ctrl         env.NetworkControllers
+	options      *xgress.Options
+	proxyOptions *proxyOptions
+	tcfg         transport.Configuration
+	socket       concurrenz.AtomicValue[io.Closer]
+}
+
+func (listener *listener) Close() error {
+	if socket := listener.socket.Load(); socket != nil {
+		return socket.Close()
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xgress_proxy_connect
+
+import (
+	"strings"
+	"time"
+
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultHandshakeTimeout = 10 * time.Second
+	MinHandshakeTimeout     = 100 * time.Millisecond
+)
+
+type proxyOptions struct {
+	socks5           bool
+	handshakeTimeout time.Duration
+	auth             *authenticator
+	services         []*serviceMapping
+}
+
+// serviceMapping maps requested host:port destinations to a service. The host may be an exact host, a wildcard of
+// the form *.example.com, or * for any host. A port of 0 matches any port.
+type serviceMapping struct {
+	host    string
+	port    uint16
+	service string
+}
+
+func (self *serviceMapping) matches(host string, port uint16) bool {
+	if self.port != 0 && self.port != port {
+		return false
+	}
+
+	if self.host == "*" {
+		return true
+	}
+
+	if suffix, isWildcard := strings.CutPrefix(self.host, "*"); isWildcard {
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+
+	return self.host == host
+}
+
+// serviceFor returns the service mapped to the given destination, or the empty string if there isn't one
+func (self *proxyOptions) serviceFor(host string, port uint16) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, mapping := range self.services {
+		if mapping.matches(host, port) {
+			return mapping.service
+		}
+	}
+	return ""
+}
+
+func loadProxyOptions(optionsData xgress.OptionsData) (*proxyOptions, error) {
+	result := &proxyOptions{
+		handshakeTimeout: DefaultHandshakeTimeout,
+	}
+
+	if value, found := optionsData["socks5"]; found {
+		socks5, ok := value.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid value for 'socks5', must be a boolean: %v", value)
+		}
+		result.socks5 = socks5
+	}
+
+	if value, found := optionsData["handshakeTimeout"]; found {
+		timeoutStr, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("invalid value for 'handshakeTimeout', must be a duration: %v", value)
+		}
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for 'handshakeTimeout': %v", value)
+		}
+		if timeout < MinHandshakeTimeout {
+			return nil, errors.Errorf("invalid value for 'handshakeTimeout', must be at least %v", MinHandshakeTimeout)
+		}
+		result.handshakeTimeout = timeout
+	}
+
+	authData := map[interface{}]interface{}{}
+	if value, found := optionsData["auth"]; found {
+		var ok bool
+		if authData, ok = value.(map[interface{}]interface{}); !ok {
+			return nil, errors.New("invalid value for 'auth', must be a map")
+		}
+	}
+
+	var err error
+	if result.auth, err = loadAuthenticator(authData); err != nil {
+		return nil, err
+	}
+
+	value, found := optionsData["services"]
+	if !found {
+		return nil, errors.New("missing 'services' configuration option")
+	}
+
+	servicesList, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("invalid value for 'services', must be a list")
+	}
+
+	for idx, entry := range servicesList {
+		mapping, err := loadServiceMapping(entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid entry %d in 'services'", idx)
+		}
+		result.services = append(result.services, mapping)
+	}
+
+	if len(result.services) == 0 {
+		return nil, errors.New("at least one entry is required in 'services'")
+	}
+
+	return result, nil
+}
+
+func loadServiceMapping(entry interface{}) (*serviceMapping, error) {
+	entryMap, ok := entry.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("must be a map")
+	}
+
+	result := &serviceMapping{}
+
+	if result.host, ok = entryMap["host"].(string); !ok || result.host == "" {
+		return nil, errors.New("'host' is required")
+	}
+	result.host = strings.ToLower(result.host)
+
+	if strings.Contains(strings.TrimPrefix(result.host, "*"), "*") {
+		return nil, errors.Errorf("invalid host '%s', wildcards are only supported as the first character", result.host)
+	}
+
+	if value, found := entryMap["port"]; found {
+		port, ok := value.(int)
+		if !ok || port < 1 || port > 65535 {
+			return nil, errors.Errorf("invalid port '%v', must be between 1 and 65535", value)
+		}
+		result.port = uint16(port)
+	}
+
+	if result.service, ok = entryMap["service"].(string); !ok || result.service == "" {
+		return nil, errors.New("'service' is required")
+	}
+
+	return result, nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/openziti/sdk-golang/xgress"
 	"github.com/openziti/transport/v2"
 	"github.com/openziti/ziti/common/inspect"
+	"github.com/openziti/ziti/common/linktls"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
 	"time"
 )
@@ -64,6 +65,19 @@ type Registry interface {
 
 	// GetLinkKey returns the link key for the given link parameters
 	GetLinkKey(dialerBinding, protocol, dest, listenerBinding string) string
+
+	// UpdateDialBackoffOverrides replaces the link dial backoff overrides, keyed by link group, sent by the controller
+	UpdateDialBackoffOverrides(settings *ctrl_pb.LinkDialBackoffSettings)
+
+	// UpdateTlsPolicy replaces the link TLS policy sent by the controller
+	UpdateTlsPolicy(policy *linktls.Policy)
+
+	// UpdateLinkGroups replaces the link groups assigned to this router by the controller. If non-empty, they
+	// replace the default link group on this router's link dialers
+	UpdateLinkGroups(groups []string)
+
+	// GetTlsPolicy returns the link TLS policy sent by the controller, or nil if none has been sent
+	GetTlsPolicy() *linktls.Policy
 }
 
 type Forwarder interface {
@@ -107,6 +121,11 @@ type BackoffConfig interface {
 	GetMinRetryInterval() time.Duration
 	GetMaxRetryInterval() time.Duration
 	GetRetryBackoffFactor() float64
+	// GetRetryJitter returns the amount of randomness applied to the backoff factor, as a fraction between 0 and 1
+	GetRetryJitter() float64
+	// GetMaxAttempts returns the number of consecutive failed dials after which the dialer gives up on a link.
+	// Zero means never give up
+	GetMaxAttempts() uint32
 }
 
 type Dialer interface {
@@ -0,0 +1,360 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xlink_transport
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/metrics"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/pierrec/lz4/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CompressionHeader is set on compressed payload messages and holds the id of the algorithm used
+	CompressionHeader = 2280
+
+	CompressionLz4  = "lz4"
+	CompressionZstd = "zstd"
+
+	compressionIdLz4  byte = 1
+	compressionIdZstd byte = 2
+
+	DefaultCompressionMinSize = 128
+
+	// maxDecompressedSize bounds the size a compressed payload may claim, so a bad message can't force a large
+	// allocation
+	maxDecompressedSize = 4 * 1024 * 1024
+)
+
+var compressionIds = map[string]byte{
+	CompressionLz4:  compressionIdLz4,
+	CompressionZstd: compressionIdZstd,
+}
+
+type compressionConfig struct {
+	algorithms []string
+	minSize    int
+}
+
+func defaultCompressionConfig() *compressionConfig {
+	return &compressionConfig{
+		algorithms: []string{CompressionZstd, CompressionLz4},
+		minSize:    DefaultCompressionMinSize,
+	}
+}
+
+func loadCompressionConfig(data map[interface{}]interface{}) (*compressionConfig, error) {
+	config := defaultCompressionConfig()
+
+	if value, found := data["algorithms"]; found {
+		config.algorithms = nil
+		if algorithm, ok := value.(string); ok {
+			config.algorithms = append(config.algorithms, algorithm)
+		} else if algorithms, ok := value.([]interface{}); ok {
+			for _, algorithm := range algorithms {
+				config.algorithms = append(config.algorithms, fmt.Sprint(algorithm))
+			}
+		} else {
+			return nil, errors.Errorf("invalid 'algorithms' value in compression config (%s)", reflect.TypeOf(value))
+		}
+
+		for _, algorithm := range config.algorithms {
+			if _, ok := compressionIds[algorithm]; !ok {
+				return nil, errors.Errorf("unsupported compression algorithm '%s', supported algorithms are %s and %s",
+					algorithm, CompressionZstd, CompressionLz4)
+			}
+		}
+
+		if len(config.algorithms) == 0 {
+			return nil, errors.New("no compression algorithms given in compression config")
+		}
+	}
+
+	if value, found := data["minSize"]; found {
+		if intValue, ok := value.(int); ok && intValue >= 0 {
+			config.minSize = intValue
+		} else {
+			return nil, errors.Errorf("invalid value for compression minSize, must be a non-negative integer: %v", value)
+		}
+	}
+
+	return config, nil
+}
+
+// loadLowBandwidthConfig loads the compression settings for links marked with lowBandwidth. Compression is only
+// offered for low bandwidth links, so nil is returned if the link isn't marked.
+func loadLowBandwidthConfig(data map[interface{}]interface{}, configType string) (*compressionConfig, error) {
+	lowBandwidth := false
+	if value, found := data["lowBandwidth"]; found {
+		if boolValue, ok := value.(bool); ok {
+			lowBandwidth = boolValue
+		} else {
+			return nil, errors.Errorf("invalid 'lowBandwidth' flag in %s config (%s)", configType, reflect.TypeOf(value))
+		}
+	}
+
+	value, found := data["compression"]
+	if !lowBandwidth {
+		if found {
+			return nil, errors.Errorf("'compression' in %s config is only used when 'lowBandwidth' is set", configType)
+		}
+		return nil, nil
+	}
+
+	if !found {
+		return defaultCompressionConfig(), nil
+	}
+
+	if submap, ok := value.(map[interface{}]interface{}); ok {
+		config, err := loadCompressionConfig(submap)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse compression config in %s config", configType)
+		}
+		return config, nil
+	}
+	return nil, fmt.Errorf("invalid 'compression' in %s config (%s)", configType, reflect.TypeOf(value))
+}
+
+// offer returns the value of the LinkHeaderCompression header, listing the algorithms in order of preference
+func (self *compressionConfig) offer() []byte {
+	if self == nil {
+		return nil
+	}
+	return []byte(strings.Join(self.algorithms, ","))
+}
+
+// selectCompression picks the algorithm to use on a link, given what each side offered during the link handshake.
+// The listener's order of preference wins, so both sides arrive at the same choice. An empty string is returned if
+// either side didn't offer compression or there's no algorithm in common.
+func selectCompression(listenerOffer, dialerOffer []byte) string {
+	if len(listenerOffer) == 0 || len(dialerOffer) == 0 {
+		return ""
+	}
+
+	dialerAlgorithms := strings.Split(string(dialerOffer), ",")
+	for _, algorithm := range strings.Split(string(listenerOffer), ",") {
+		if _, supported := compressionIds[algorithm]; supported && slices.Contains(dialerAlgorithms, algorithm) {
+			return algorithm
+		}
+	}
+	return ""
+}
+
+// payloadCompressor compresses the bodies of payloads sent over a low bandwidth link, using the algorithm negotiated
+// when the link was established. Payloads smaller than the configured minimum, or which don't get smaller when
+// compressed, are sent as is.
+type payloadCompressor struct {
+	algorithm string
+	id        byte
+	minSize   int
+
+	uncompressedBytes atomic.Int64
+	compressedBytes   atomic.Int64
+
+	uncompressedMeter metrics.Meter
+	compressedMeter   metrics.Meter
+	ratioGauge        metrics.Gauge
+}
+
+// newPayloadCompressor returns a compressor for links using the given protocol, or nil if no algorithm was
+// negotiated. Compression isn't used on dtls links, where payloads are bounded by the path MTU.
+func newPayloadCompressor(config *compressionConfig, algorithm string, linkProtocol string) *payloadCompressor {
+	if config == nil || algorithm == "" || linkProtocol == "dtls" {
+		return nil
+	}
+	return &payloadCompressor{
+		algorithm: algorithm,
+		id:        compressionIds[algorithm],
+		minSize:   config.minSize,
+	}
+}
+
+// initMetrics registers the link's compression metrics. The ratio is the uncompressed size of payloads sent so far
+// divided by their size on the wire, scaled by 100, so a value of 250 means payloads are 2.5 times smaller.
+func (self *payloadCompressor) initMetrics(registry metrics.Registry, linkId string) {
+	if self == nil {
+		return
+	}
+
+	prefix := "link." + linkId + ".compression."
+	self.uncompressedMeter = registry.Meter(prefix + "uncompressed_bytes")
+	self.compressedMeter = registry.Meter(prefix + "compressed_bytes")
+	self.ratioGauge = registry.FuncGauge(prefix+"ratio", func() int64 {
+		return self.ratio()
+	})
+}
+
+func (self *payloadCompressor) ratio() int64 {
+	compressed := self.compressedBytes.Load()
+	if compressed == 0 {
+		return 100
+	}
+	return self.uncompressedBytes.Load() * 100 / compressed
+}
+
+func (self *payloadCompressor) dispose() {
+	if self == nil || self.uncompressedMeter == nil {
+		return
+	}
+	self.uncompressedMeter.Dispose()
+	self.compressedMeter.Dispose()
+	self.ratioGauge.Dispose()
+}
+
+func (self *payloadCompressor) getAlgorithm() string {
+	if self == nil {
+		return ""
+	}
+	return self.algorithm
+}
+
+// compress replaces the body of the payload message with its compressed form, if that makes it smaller
+func (self *payloadCompressor) compress(msg *channel.Message) {
+	if self == nil || msg.ContentType != xgress.ContentTypePayloadType {
+		return
+	}
+
+	size := len(msg.Body)
+	if size < self.minSize || size == 0 {
+		self.record(size, size)
+		return
+	}
+
+	var compressed []byte
+	var err error
+	if self.id == compressionIdZstd {
+		compressed = getZstdEncoder().EncodeAll(msg.Body, binary.AppendUvarint(nil, uint64(size)))
+	} else {
+		compressed, err = compressLz4(msg.Body)
+	}
+
+	if err != nil || compressed == nil || len(compressed) >= size {
+		self.record(size, size)
+		return
+	}
+
+	msg.Body = compressed
+	msg.Headers[CompressionHeader] = []byte{self.id}
+	self.record(size, len(compressed))
+}
+
+func (self *payloadCompressor) record(uncompressed, compressed int) {
+	self.uncompressedBytes.Add(int64(uncompressed))
+	self.compressedBytes.Add(int64(compressed))
+	if self.uncompressedMeter != nil {
+		self.uncompressedMeter.Mark(int64(uncompressed))
+		self.compressedMeter.Mark(int64(compressed))
+	}
+}
+
+func compressLz4(body []byte) ([]byte, error) {
+	result := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+lz4.CompressBlockBound(len(body))), uint64(len(body)))
+	prefixLen := len(result)
+	n, err := lz4.CompressBlock(body, result[prefixLen:cap(result)], nil)
+	if err != nil || n == 0 {
+		// n is 0 when the data isn't compressible
+		return nil, err
+	}
+	return result[:prefixLen+n], nil
+}
+
+// DecompressPayload restores the body of a payload message compressed by the other side of the link. Messages
+// without the CompressionHeader are left as is. Decompression doesn't depend on link state, as the algorithm is
+// carried with each message.
+func DecompressPayload(msg *channel.Message) error {
+	id, found := msg.Headers[CompressionHeader]
+	if !found {
+		return nil
+	}
+	if len(id) != 1 {
+		return errors.New("invalid compression header on payload")
+	}
+
+	size, prefixLen := binary.Uvarint(msg.Body)
+	if prefixLen <= 0 {
+		return errors.New("compressed payload is missing uncompressed size")
+	}
+	if size > maxDecompressedSize {
+		return errors.Errorf("compressed payload claims uncompressed size of %d, larger than the maximum of %d", size, maxDecompressedSize)
+	}
+
+	var body []byte
+	switch id[0] {
+	case compressionIdZstd:
+		decoded, err := getZstdDecoder().DecodeAll(msg.Body[prefixLen:], make([]byte, 0, size))
+		if err != nil {
+			return errors.Wrap(err, "unable to decompress zstd payload")
+		}
+		body = decoded
+	case compressionIdLz4:
+		body = make([]byte, size)
+		n, err := lz4.UncompressBlock(msg.Body[prefixLen:], body)
+		if err != nil {
+			return errors.Wrap(err, "unable to decompress lz4 payload")
+		}
+		body = body[:n]
+	default:
+		return errors.Errorf("unsupported payload compression algorithm id %d", id[0])
+	}
+
+	if uint64(len(body)) != size {
+		return errors.Errorf("decompressed payload size %d doesn't match expected size %d", len(body), size)
+	}
+
+	msg.Body = body
+	delete(msg.Headers, CompressionHeader)
+	return nil
+}
+
+var zstdEncoder struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+}
+
+var zstdDecoder struct {
+	once    sync.Once
+	decoder *zstd.Decoder
+}
+
+// getZstdEncoder returns the shared zstd encoder. EncodeAll is safe for concurrent use, so one encoder serves all
+// links.
+func getZstdEncoder() *zstd.Encoder {
+	zstdEncoder.once.Do(func() {
+		// only fails on invalid options
+		zstdEncoder.encoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithLowerEncoderMem(true))
+	})
+	return zstdEncoder.encoder
+}
+
+// getZstdDecoder returns the shared zstd decoder. DecodeAll is safe for concurrent use.
+func getZstdDecoder() *zstd.Decoder {
+	zstdDecoder.once.Do(func() {
+		// only fails on invalid options
+		zstdDecoder.decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedSize))
+	})
+	return zstdDecoder.decoder
+}
@@ -0,0 +1,152 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xlink_transport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompressionTestPayload(data []byte) *xgress.Payload {
+	return &xgress.Payload{
+		CircuitId: "circuit",
+		Sequence:  7,
+		Headers:   map[uint8][]byte{1: []byte("header")},
+		Data:      data,
+	}
+}
+
+func TestLoadLowBandwidthConfig(t *testing.T) {
+	req := require.New(t)
+
+	config, err := loadLowBandwidthConfig(map[interface{}]interface{}{}, "dialer")
+	req.NoError(err)
+	req.Nil(config)
+
+	config, err = loadLowBandwidthConfig(map[interface{}]interface{}{"lowBandwidth": true}, "dialer")
+	req.NoError(err)
+	req.Equal([]string{CompressionZstd, CompressionLz4}, config.algorithms)
+	req.Equal(DefaultCompressionMinSize, config.minSize)
+
+	config, err = loadLowBandwidthConfig(map[interface{}]interface{}{
+		"lowBandwidth": true,
+		"compression": map[interface{}]interface{}{
+			"algorithms": []interface{}{"lz4"},
+			"minSize":    64,
+		},
+	}, "listener")
+	req.NoError(err)
+	req.Equal([]string{CompressionLz4}, config.algorithms)
+	req.Equal(64, config.minSize)
+
+	_, err = loadLowBandwidthConfig(map[interface{}]interface{}{
+		"lowBandwidth": true,
+		"compression":  map[interface{}]interface{}{"algorithms": "gzip"},
+	}, "listener")
+	req.Error(err)
+
+	_, err = loadLowBandwidthConfig(map[interface{}]interface{}{
+		"compression": map[interface{}]interface{}{"algorithms": "lz4"},
+	}, "listener")
+	req.Error(err)
+}
+
+func TestSelectCompression(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal(CompressionZstd, selectCompression([]byte("zstd,lz4"), []byte("lz4,zstd")))
+	req.Equal(CompressionLz4, selectCompression([]byte("lz4,zstd"), []byte("zstd,lz4")))
+	req.Equal(CompressionLz4, selectCompression([]byte("zstd,lz4"), []byte("lz4")))
+	req.Equal("", selectCompression([]byte("zstd"), []byte("lz4")))
+	req.Equal("", selectCompression(nil, []byte("lz4")))
+	req.Equal("", selectCompression([]byte("zstd,lz4"), nil))
+	req.Equal("", selectCompression([]byte("brotli"), []byte("brotli")))
+
+	req.Nil(newPayloadCompressor(defaultCompressionConfig(), CompressionZstd, "dtls"))
+	req.Nil(newPayloadCompressor(defaultCompressionConfig(), "", "tls"))
+	req.Nil(newPayloadCompressor(nil, CompressionZstd, "tls"))
+}
+
+func TestPayloadCompressionRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{CompressionZstd, CompressionLz4} {
+		t.Run(algorithm, func(t *testing.T) {
+			req := require.New(t)
+
+			compressor := newPayloadCompressor(defaultCompressionConfig(), algorithm, "tls")
+			req.NotNil(compressor)
+
+			data := bytes.Repeat([]byte("constrained site-to-site link "), 100)
+			msg := newCompressionTestPayload(data).Marshall()
+			compressor.compress(msg)
+
+			req.Contains(msg.Headers, int32(CompressionHeader))
+			req.Less(len(msg.Body), len(data))
+			req.Greater(compressor.ratio(), int64(100))
+
+			req.NoError(DecompressPayload(msg))
+			req.NotContains(msg.Headers, int32(CompressionHeader))
+
+			payload, err := xgress.UnmarshallPayload(msg)
+			req.NoError(err)
+			req.Equal(data, payload.Data)
+			req.Equal("circuit", payload.CircuitId)
+			req.Equal(int32(7), payload.Sequence)
+		})
+	}
+}
+
+func TestPayloadCompressionSkipsSmallAndIncompressible(t *testing.T) {
+	req := require.New(t)
+
+	compressor := newPayloadCompressor(defaultCompressionConfig(), CompressionLz4, "tls")
+
+	small := newCompressionTestPayload([]byte("hello")).Marshall()
+	compressor.compress(small)
+	req.NotContains(small.Headers, int32(CompressionHeader))
+	req.Equal([]byte("hello"), small.Body)
+
+	random := make([]byte, 4096)
+	_, err := rand.Read(random)
+	req.NoError(err)
+
+	incompressible := newCompressionTestPayload(random).Marshall()
+	compressor.compress(incompressible)
+	req.NotContains(incompressible.Headers, int32(CompressionHeader))
+	req.Equal(random, incompressible.Body)
+	req.Equal(int64(100), compressor.ratio())
+
+	// messages without the compression header are left alone
+	req.NoError(DecompressPayload(incompressible))
+	req.Equal(random, incompressible.Body)
+}
+
+func TestDecompressRejectsBadPayloads(t *testing.T) {
+	req := require.New(t)
+
+	msg := newCompressionTestPayload([]byte("data")).Marshall()
+	msg.Headers[CompressionHeader] = []byte{compressionIdLz4}
+	msg.Body = []byte{0xff, 0xff, 0xff, 0xff, 0x7f}
+	req.Error(DecompressPayload(msg))
+
+	msg.Headers[CompressionHeader] = []byte{99}
+	msg.Body = []byte{4, 1, 2, 3, 4}
+	req.Error(DecompressPayload(msg))
+}
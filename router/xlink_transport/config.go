@@ -36,6 +36,10 @@ const (
 	MinRetryBackoffFactor = 1
 	MaxRetryBackoffFactor = 100
 
+	MinRetryJitter     = 0
+	MaxRetryJitter     = 1
+	DefaultRetryJitter = 0.5
+
 	DefaultHealthyMinRetryInterval   = 5 * time.Second
 	DefaultHealthyMaxRetryInterval   = 5 * time.Minute
 	DefaultHealthyRetryBackoffFactor = 1.5
@@ -127,6 +131,24 @@ func loadListenerConfig(data map[interface{}]interface{}) (*listenerConfig, erro
 		config.groups = append(config.groups, link.GroupDefault)
 	}
 
+	if value, found := data["fec"]; found {
+		fec, err := loadFecConfigValue(value, "listener")
+		if err != nil {
+			return nil, err
+		}
+		if fec != nil && config.linkProtocol != "dtls" {
+			pfxlog.Logger().WithField("bind", config.bind.String()).
+				Warn("fec is only used on dtls links, ignoring fec config for link listener")
+		}
+		config.fec = fec
+	}
+
+	compression, err := loadLowBandwidthConfig(data, "listener")
+	if err != nil {
+		return nil, err
+	}
+	config.compression = compression
+
 	if value, found := data["options"]; found {
 		if submap, ok := value.(map[interface{}]interface{}); ok {
 			options, err := channel.LoadOptions(submap)
@@ -151,6 +173,8 @@ type listenerConfig struct {
 	linkProtocol  string
 	linkCostTags  []string
 	groups        []string
+	fec           *fecConfig
+	compression   *compressionConfig
 	options       *channel.Options
 }
 
@@ -249,12 +273,14 @@ func loadDialerConfig(data map[interface{}]interface{}) (*dialerConfig, error) {
 		minRetryInterval:   DefaultHealthyMinRetryInterval,
 		maxRetryInterval:   DefaultHealthyMaxRetryInterval,
 		retryBackoffFactor: DefaultHealthyRetryBackoffFactor,
+		retryJitter:        DefaultRetryJitter,
 	}
 
 	config.unhealthyBackoffConfig = &backoffConfig{
 		minRetryInterval:   DefaultUnhealthyMinRetryInterval,
 		maxRetryInterval:   DefaultUnhealthyMaxRetryInterval,
 		retryBackoffFactor: DefaultUnhealthyRetryBackoffFactor,
+		retryJitter:        DefaultRetryJitter,
 	}
 
 	if value, found := data["healthyDialBackoff"]; found {
@@ -273,10 +299,24 @@ func loadDialerConfig(data map[interface{}]interface{}) (*dialerConfig, error) {
 				return nil, errors.Wrap(err, "failed to parse unhealthyDialBackoff config")
 			}
 		} else {
-			return nil, fmt.Errorf("invalid 'healthyDialBackoff' in dialer config (%s)", reflect.TypeOf(value))
+			return nil, fmt.Errorf("invalid 'unhealthyDialBackoff' in dialer config (%s)", reflect.TypeOf(value))
 		}
 	}
 
+	if value, found := data["fec"]; found {
+		fec, err := loadFecConfigValue(value, "dialer")
+		if err != nil {
+			return nil, err
+		}
+		config.fec = fec
+	}
+
+	compression, err := loadLowBandwidthConfig(data, "dialer")
+	if err != nil {
+		return nil, err
+	}
+	config.compression = compression
+
 	if value, found := data["options"]; found {
 		if submap, ok := value.(map[interface{}]interface{}); ok {
 			options, err := channel.LoadOptions(submap)
@@ -296,6 +336,8 @@ type backoffConfig struct {
 	minRetryInterval   time.Duration
 	maxRetryInterval   time.Duration
 	retryBackoffFactor float64
+	retryJitter        float64
+	maxAttempts        uint32
 }
 
 func (self *backoffConfig) GetMinRetryInterval() time.Duration {
@@ -310,6 +352,14 @@ func (self *backoffConfig) GetRetryBackoffFactor() float64 {
 	return self.retryBackoffFactor
 }
 
+func (self *backoffConfig) GetRetryJitter() float64 {
+	return self.retryJitter
+}
+
+func (self *backoffConfig) GetMaxAttempts() uint32 {
+	return self.maxAttempts
+}
+
 func (self *backoffConfig) load(data map[interface{}]interface{}) error {
 	if value, found := data["retryBackoffFactor"]; found {
 		if floatValue, ok := value.(float64); ok {
@@ -366,6 +416,31 @@ func (self *backoffConfig) load(data map[interface{}]interface{}) error {
 		}
 	}
 
+	if value, found := data["retryJitter"]; found {
+		if floatValue, ok := value.(float64); ok {
+			self.retryJitter = floatValue
+		} else if intValue, ok := value.(int); ok {
+			self.retryJitter = float64(intValue)
+		} else {
+			return errors.Errorf("invalid (non-numeric) value for retryJitter: %v", value)
+		}
+
+		if self.retryJitter < MinRetryJitter {
+			return errors.Errorf("retryJitter of %v is lower than minimum value of %v", self.retryJitter, MinRetryJitter)
+		}
+		if self.retryJitter > MaxRetryJitter {
+			return errors.Errorf("retryJitter of %v is larger than maximum value of %v", self.retryJitter, MaxRetryJitter)
+		}
+	}
+
+	if value, found := data["maxAttempts"]; found {
+		if intValue, ok := value.(int); ok && intValue >= 0 {
+			self.maxAttempts = uint32(intValue)
+		} else {
+			return errors.Errorf("invalid value for maxAttempts, must be a non-negative integer: %v", value)
+		}
+	}
+
 	if self.minRetryInterval > self.maxRetryInterval {
 		return errors.Errorf("minRetryInterval of %v is larger than maxRetryInterval value of %v", self.minRetryInterval, self.maxRetryInterval)
 	}
@@ -380,6 +455,8 @@ type dialerConfig struct {
 	startupDelay           time.Duration
 	localBinding           string
 	groups                 []string
+	fec                    *fecConfig
+	compression            *compressionConfig
 	options                *channel.Options
 	healthyBackoffConfig   *backoffConfig
 	unhealthyBackoffConfig *backoffConfig
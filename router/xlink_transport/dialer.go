@@ -136,6 +136,7 @@ func (self *dialer) dialSplit(linkId *identity.TokenId, address transport.Addres
 		LinkDialedRouterId:      []byte(dial.GetRouterId()),
 	}
 	headers.PutUint32Header(LinkHeaderIteration, dial.GetIteration())
+	self.offerCompression(headers)
 
 	channelDialerConfig := channel.DialerConfig{
 		Identity:        linkId,
@@ -161,6 +162,7 @@ func (self *dialer) dialSplit(linkId *identity.TokenId, address transport.Addres
 			dialAddress:   dial.GetAddress(),
 			iteration:     dial.GetIteration(),
 			dialed:        true,
+			fec:           newFecEncoder(self.config.fec, dial.GetLinkProtocol()),
 		},
 	}
 
@@ -199,6 +201,7 @@ func (self *dialer) dialSingle(linkId *identity.TokenId, address transport.Addre
 		LinkDialedRouterId:      []byte(dial.GetRouterId()),
 	}
 	headers.PutUint32Header(LinkHeaderIteration, dial.GetIteration())
+	self.offerCompression(headers)
 
 	payloadDialer := channel.NewClassicDialer(channel.DialerConfig{
 		Identity:        linkId,
@@ -220,6 +223,7 @@ func (self *dialer) dialSingle(linkId *identity.TokenId, address transport.Addre
 			dialAddress:   dial.GetAddress(),
 			iteration:     dial.GetIteration(),
 			dialed:        true,
+			fec:           newFecEncoder(self.config.fec, dial.GetLinkProtocol()),
 		},
 	}
 
@@ -247,6 +251,7 @@ func (self *dialer) dialMulti(linkId *identity.TokenId, address transport.Addres
 		LinkDialedRouterId:      []byte(dial.GetRouterId()),
 	}
 	headers.PutUint32Header(LinkHeaderIteration, dial.GetIteration())
+	self.offerCompression(headers)
 	headers.PutBoolHeader(channel.IsGroupedHeader, true)
 	headers.PutStringHeader(channel.TypeHeader, ChannelTypeDefault)
 	headers.PutBoolHeader(channel.IsFirstGroupConnection, true)
@@ -271,6 +276,7 @@ func (self *dialer) dialMulti(linkId *identity.TokenId, address transport.Addres
 			dialAddress:   dial.GetAddress(),
 			iteration:     dial.GetIteration(),
 			dialed:        true,
+			fec:           newFecEncoder(self.config.fec, dial.GetLinkProtocol()),
 		},
 	}
 
@@ -310,6 +316,22 @@ func (self *dialer) dialMulti(linkId *identity.TokenId, address transport.Addres
 	return bindHandler.link, nil
 }
 
+// offerCompression adds the compression algorithms this dialer supports to the link hello headers, if the dialer
+// is marked as low bandwidth
+func (self *dialer) offerCompression(headers channel.Headers) {
+	if offer := self.config.compression.offer(); offer != nil {
+		headers[LinkHeaderCompression] = offer
+	}
+}
+
+// newCompressor returns the payload compressor for a dialed link, using the algorithms the listener sent back in its
+// hello response
+func (self *dialer) newCompressor(ch channel.Channel, linkProtocol string) *payloadCompressor {
+	listenerOffer := channel.Headers(ch.Underlay().Headers())[LinkHeaderCompression]
+	algorithm := selectCompression(listenerOffer, self.config.compression.offer())
+	return newPayloadCompressor(self.config.compression, algorithm, linkProtocol)
+}
+
 func (self *dialer) notifyOfLinkChange(ch *DialLinkChannel, link xlink.Xlink) {
 	if ch.GetChannel().IsClosed() { // don't send connection changes for closed links. close notification covers everything
 		return
@@ -368,6 +390,10 @@ func (self *dialBindHandler) BindChannel(binding channel.Binding) error {
 		self.link.ch = NewSingleLinkChannel(binding.GetChannel())
 	}
 
+	if self.link.compressor == nil {
+		self.link.compressor = self.dialer.newCompressor(binding.GetChannel(), self.link.linkProtocol)
+	}
+
 	bindHandler := self.dialer.bindHandlerFactory.NewBindHandler(self.link, true, false)
 	return bindHandler.BindChannel(binding)
 }
@@ -380,6 +406,7 @@ type splitDialBindHandler struct {
 func (self *splitDialBindHandler) bindPayloadChannel(binding channel.Binding) error {
 	return self.link.syncInit(func() error {
 		self.link.payloadCh = binding.GetChannel()
+		self.link.compressor = self.dialer.newCompressor(binding.GetChannel(), self.link.linkProtocol)
 		bindHandler := self.dialer.bindHandlerFactory.NewBindHandler(self.link, true, false)
 		if err := bindHandler.BindChannel(binding); err != nil {
 			return errors.Wrapf(err, "error accepting outgoing payload channel for [l/%s]", self.link.id)
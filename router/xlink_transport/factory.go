@@ -24,6 +24,7 @@ import (
 	"github.com/openziti/identity"
 	"github.com/openziti/metrics"
 	"github.com/openziti/transport/v2"
+	"github.com/openziti/ziti/common/linktls"
 	"github.com/openziti/ziti/router/env"
 	"github.com/openziti/ziti/router/xlink"
 )
@@ -38,6 +39,7 @@ const (
 	LinkHeaderBinding                   = 4
 	LinkHeaderIteration                 = 5
 	LinkDialedRouterId                  = 6
+	LinkHeaderCompression               = 256 // kept clear of the header keys used by channel hellos
 	PayloadChannel          channelType = 1
 	AckChannel              channelType = 2
 )
@@ -59,6 +61,7 @@ type LinkEnv interface {
 	GetRateLimiterPool() goroutines.Pool
 	GetCloseNotify() <-chan struct{}
 	GetRouterId() *identity.TokenId
+	GetLinkTlsPolicy() *linktls.Policy
 }
 
 func NewFactory(accepter xlink.Acceptor,
@@ -92,7 +95,7 @@ func (self *factory) CreateListener(id *identity.TokenId, configData transport.C
 	}
 
 	return &listener{
-		id:                 id,
+		id:                 linktls.NewTokenId(id, self.env.GetLinkTlsPolicy),
 		config:             config,
 		accepter:           self.acceptor,
 		bindHandlerFactory: self.bindHandlerFactory,
@@ -118,7 +121,7 @@ func (self *factory) CreateDialer(id *identity.TokenId, configData transport.Con
 	}
 
 	return &dialer{
-		id:                 id,
+		id:                 linktls.NewTokenId(id, self.env.GetLinkTlsPolicy),
 		config:             config,
 		acceptor:           self.acceptor,
 		bindHandlerFactory: self.bindHandlerFactory,
@@ -0,0 +1,377 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xlink_transport
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/pkg/errors"
+)
+
+const (
+	// FecGroupHeader holds the id of the FEC group a payload or parity message belongs to
+	FecGroupHeader = 2270
+	// FecIndexHeader holds the position of a payload message in its FEC group
+	FecIndexHeader = 2271
+	// FecSizeHeader holds the number of payload messages covered by a parity message
+	FecSizeHeader = 2272
+
+	MinFecRedundancy = 0.01
+	MaxFecRedundancy = 1
+
+	// fecDecoderWindow is the number of recent groups a decoder keeps. Payloads and parity for older groups are
+	// ignored, as are groups which can't be recovered before they fall out of the window.
+	fecDecoderWindow = 32
+)
+
+type fecConfig struct {
+	redundancy float64
+}
+
+// groupSize returns the number of payload messages covered by each parity message
+func (self *fecConfig) groupSize() int {
+	return max(1, int(math.Round(1/self.redundancy)))
+}
+
+func loadFecConfig(data map[interface{}]interface{}) (*fecConfig, error) {
+	config := &fecConfig{}
+
+	if value, found := data["redundancy"]; found {
+		if floatValue, ok := value.(float64); ok {
+			config.redundancy = floatValue
+		} else if intValue, ok := value.(int); ok {
+			config.redundancy = float64(intValue)
+		} else {
+			return nil, errors.Errorf("invalid (non-numeric) value for fec redundancy: %v", value)
+		}
+	} else {
+		return nil, errors.New("missing 'redundancy' in fec config")
+	}
+
+	if config.redundancy == 0 {
+		return nil, nil
+	}
+
+	if config.redundancy < MinFecRedundancy {
+		return nil, errors.Errorf("fec redundancy of %v is lower than minimum value of %v", config.redundancy, MinFecRedundancy)
+	}
+	if config.redundancy > MaxFecRedundancy {
+		return nil, errors.Errorf("fec redundancy of %v is larger than maximum value of %v", config.redundancy, MaxFecRedundancy)
+	}
+
+	return config, nil
+}
+
+func loadFecConfigValue(value interface{}, configType string) (*fecConfig, error) {
+	if submap, ok := value.(map[interface{}]interface{}); ok {
+		config, err := loadFecConfig(submap)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse fec config in %s config", configType)
+		}
+		return config, nil
+	}
+	return nil, fmt.Errorf("invalid 'fec' in %s config (%s)", configType, reflect.TypeOf(value))
+}
+
+// fecEncoder adds forward error correction to the payloads sent over a datagram link. Payload messages are
+// grouped, and after each group is complete a parity message, holding the XOR of the group's payloads, is sent.
+// The receiving router can use the parity to rebuild a single lost payload per group, without waiting for the
+// originating xgress to retransmit it. The redundancy ratio sets the group size, so a ratio of 0.25 sends one
+// parity message for every four payloads.
+type fecEncoder struct {
+	lock      sync.Mutex
+	groupSize int
+	groupId   uint32
+	count     int
+	parity    []byte
+}
+
+// newFecEncoder returns an encoder for links using the given protocol, or nil if FEC isn't configured. FEC is only
+// used on dtls links, since losses on stream links are repaired by the transport.
+func newFecEncoder(config *fecConfig, linkProtocol string) *fecEncoder {
+	if config == nil || linkProtocol != "dtls" {
+		return nil
+	}
+	return &fecEncoder{
+		groupSize: config.groupSize(),
+	}
+}
+
+// encode tags the payload message with its FEC group and position, and adds it to the group parity. If the message
+// completes the group, the parity message for the group is returned.
+func (self *fecEncoder) encode(msg *channel.Message) *channel.Message {
+	if self == nil || msg.ContentType != xgress.ContentTypePayloadType {
+		return nil
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	msg.PutUint32Header(FecGroupHeader, self.groupId)
+	msg.PutUint16Header(FecIndexHeader, uint16(self.count))
+	self.parity = xorFecUnit(self.parity, encodeFecUnit(msg))
+	self.count++
+
+	if self.count < self.groupSize {
+		return nil
+	}
+
+	parity := channel.NewMessage(int32(ctrl_pb.ContentType_LinkFecParityType), self.parity)
+	parity.PutUint32Header(FecGroupHeader, self.groupId)
+	parity.PutUint16Header(FecSizeHeader, uint16(self.count))
+
+	self.groupId++
+	self.count = 0
+	self.parity = nil
+
+	return parity
+}
+
+// FecDecoder rebuilds payloads lost on a link from the parity messages sent by the other side's encoder. A
+// payload can be rebuilt once the parity and all other payloads in its group have arrived.
+type FecDecoder struct {
+	lock   sync.Mutex
+	groups map[uint32]*fecGroup
+	latest uint32
+}
+
+type fecGroup struct {
+	units  map[uint16][]byte
+	parity []byte
+	size   uint16
+	done   bool
+}
+
+func NewFecDecoder() *FecDecoder {
+	return &FecDecoder{
+		groups: map[uint32]*fecGroup{},
+	}
+}
+
+// AcceptPayload records a received payload message. If this lets a lost payload in the same group be rebuilt, the
+// rebuilt message is returned. Payloads which weren't sent with FEC are ignored.
+func (self *FecDecoder) AcceptPayload(msg *channel.Message) (*channel.Message, error) {
+	groupId, ok := msg.GetUint32Header(FecGroupHeader)
+	if !ok {
+		return nil, nil
+	}
+
+	index, ok := msg.GetUint16Header(FecIndexHeader)
+	if !ok {
+		return nil, errors.New("fec payload message is missing group index")
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	group := self.getGroup(groupId)
+	if group == nil || group.done {
+		return nil, nil
+	}
+
+	if _, found := group.units[index]; !found {
+		group.units[index] = encodeFecUnit(msg)
+	}
+
+	return group.recover()
+}
+
+// AcceptParity records a received parity message, returning the rebuilt payload if one of the group's payloads
+// was lost and the rest have arrived
+func (self *FecDecoder) AcceptParity(msg *channel.Message) (*channel.Message, error) {
+	groupId, ok := msg.GetUint32Header(FecGroupHeader)
+	if !ok {
+		return nil, errors.New("fec parity message is missing group id")
+	}
+
+	size, ok := msg.GetUint16Header(FecSizeHeader)
+	if !ok || size == 0 {
+		return nil, errors.New("fec parity message is missing group size")
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	group := self.getGroup(groupId)
+	if group == nil || group.done {
+		return nil, nil
+	}
+
+	group.parity = msg.Body
+	group.size = size
+
+	return group.recover()
+}
+
+func (self *FecDecoder) getGroup(groupId uint32) *fecGroup {
+	if group, found := self.groups[groupId]; found {
+		return group
+	}
+
+	if len(self.groups) > 0 {
+		if int32(self.latest-groupId) >= fecDecoderWindow {
+			return nil
+		}
+
+		if int32(groupId-self.latest) > 0 {
+			self.latest = groupId
+			for id := range self.groups {
+				if int32(self.latest-id) >= fecDecoderWindow {
+					delete(self.groups, id)
+				}
+			}
+		}
+	} else {
+		self.latest = groupId
+	}
+
+	group := &fecGroup{
+		units: map[uint16][]byte{},
+	}
+	self.groups[groupId] = group
+	return group
+}
+
+func (self *fecGroup) recover() (*channel.Message, error) {
+	if self.parity == nil {
+		return nil, nil
+	}
+
+	received := 0
+	for index := range self.units {
+		if index < self.size {
+			received++
+		}
+	}
+
+	if received < int(self.size)-1 {
+		return nil, nil
+	}
+
+	unit := slices.Clone(self.parity)
+	if received < int(self.size) {
+		for index, data := range self.units {
+			if index < self.size {
+				unit = xorFecUnit(unit, data)
+			}
+		}
+	}
+
+	self.done = true
+	self.units = nil
+	self.parity = nil
+
+	if received == int(self.size) {
+		return nil, nil
+	}
+
+	return decodeFecUnit(unit)
+}
+
+// encodeFecUnit returns the parts of a payload message covered by FEC: the xgress headers and the body. Headers
+// added by the channel, such as heartbeats, and the FEC headers themselves aren't included, since they can
+// differ between what's sent and what's received.
+func encodeFecUnit(msg *channel.Message) []byte {
+	var keys []int32
+	for key := range msg.Headers {
+		if key >= xgress.MinHeaderKey && key != FecGroupHeader && key != FecIndexHeader && key != FecSizeHeader {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	size := 2 + 4 + len(msg.Body)
+	for _, key := range keys {
+		size += 8 + len(msg.Headers[key])
+	}
+
+	result := make([]byte, 0, size)
+	result = binary.BigEndian.AppendUint16(result, uint16(len(keys)))
+	for _, key := range keys {
+		value := msg.Headers[key]
+		result = binary.BigEndian.AppendUint32(result, uint32(key))
+		result = binary.BigEndian.AppendUint32(result, uint32(len(value)))
+		result = append(result, value...)
+	}
+	result = binary.BigEndian.AppendUint32(result, uint32(len(msg.Body)))
+	result = append(result, msg.Body...)
+	return result
+}
+
+// decodeFecUnit rebuilds a payload message from a unit created by encodeFecUnit. Units are padded with zeros to
+// the size of the largest unit in their group when XORed, so trailing data is ignored.
+func decodeFecUnit(unit []byte) (*channel.Message, error) {
+	next := func(n int) ([]byte, error) {
+		if len(unit) < n {
+			return nil, errors.New("fec unit is truncated")
+		}
+		result := unit[:n]
+		unit = unit[n:]
+		return result, nil
+	}
+
+	b, err := next(2)
+	if err != nil {
+		return nil, err
+	}
+
+	headerCount := int(binary.BigEndian.Uint16(b))
+	headers := make(channel.Headers, headerCount)
+	for i := 0; i < headerCount; i++ {
+		if b, err = next(8); err != nil {
+			return nil, err
+		}
+		key := int32(binary.BigEndian.Uint32(b))
+		if b, err = next(int(binary.BigEndian.Uint32(b[4:]))); err != nil {
+			return nil, err
+		}
+		headers[key] = slices.Clone(b)
+	}
+
+	if b, err = next(4); err != nil {
+		return nil, err
+	}
+	body, err := next(int(binary.BigEndian.Uint32(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	msg := channel.NewMessage(xgress.ContentTypePayloadType, slices.Clone(body))
+	for key, value := range headers {
+		msg.Headers[key] = value
+	}
+	return msg, nil
+}
+
+// xorFecUnit XORs src into dst, growing dst if src is longer
+func xorFecUnit(dst []byte, src []byte) []byte {
+	if len(src) > len(dst) {
+		dst = append(dst, make([]byte, len(src)-len(dst))...)
+	}
+	for i, v := range src {
+		dst[i] ^= v
+	}
+	return dst
+}
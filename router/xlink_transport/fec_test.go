@@ -0,0 +1,183 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package xlink_transport
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/sdk-golang/xgress"
+	"github.com/stretchr/testify/require"
+)
+
+func newFecTestPayload(seq int32, size int) *channel.Message {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(int(seq) + i)
+	}
+	payload := &xgress.Payload{
+		CircuitId: fmt.Sprintf("circuit-%d", seq%3),
+		Sequence:  seq,
+		Headers:   map[uint8][]byte{1: []byte("header")},
+		Data:      data,
+	}
+	return payload.Marshall()
+}
+
+func TestFecConfigGroupSize(t *testing.T) {
+	req := require.New(t)
+
+	config, err := loadFecConfig(map[interface{}]interface{}{"redundancy": 0.25})
+	req.NoError(err)
+	req.Equal(4, config.groupSize())
+
+	config, err = loadFecConfig(map[interface{}]interface{}{"redundancy": 1})
+	req.NoError(err)
+	req.Equal(1, config.groupSize())
+
+	config, err = loadFecConfig(map[interface{}]interface{}{"redundancy": 0})
+	req.NoError(err)
+	req.Nil(config)
+
+	_, err = loadFecConfig(map[interface{}]interface{}{"redundancy": 1.5})
+	req.Error(err)
+
+	req.Nil(newFecEncoder(&fecConfig{redundancy: 0.25}, "tls"))
+	req.NotNil(newFecEncoder(&fecConfig{redundancy: 0.25}, "dtls"))
+}
+
+func TestFecRecoversLostPayload(t *testing.T) {
+	for lost := 0; lost < 4; lost++ {
+		t.Run(fmt.Sprintf("lost-%d", lost), func(t *testing.T) {
+			req := require.New(t)
+
+			encoder := newFecEncoder(&fecConfig{redundancy: 0.25}, "dtls")
+			decoder := NewFecDecoder()
+
+			var msgs []*channel.Message
+			var parity *channel.Message
+			for i := 0; i < 4; i++ {
+				msg := newFecTestPayload(int32(i), 10+i*20)
+				msgs = append(msgs, msg)
+				parity = encoder.encode(msg)
+				if i < 3 {
+					req.Nil(parity)
+				}
+			}
+			req.NotNil(parity)
+
+			for i, msg := range msgs {
+				if i == lost {
+					continue
+				}
+				msg.PutUint64Header(channel.HeartbeatHeader, 1234)
+				recovered, err := decoder.AcceptPayload(msg)
+				req.NoError(err)
+				req.Nil(recovered)
+			}
+
+			recovered, err := decoder.AcceptParity(parity)
+			req.NoError(err)
+			req.NotNil(recovered)
+
+			expected, err := xgress.UnmarshallPayload(msgs[lost])
+			req.NoError(err)
+			actual, err := xgress.UnmarshallPayload(recovered)
+			req.NoError(err)
+
+			req.Equal(expected.CircuitId, actual.CircuitId)
+			req.Equal(expected.Sequence, actual.Sequence)
+			req.Equal(expected.Headers, actual.Headers)
+			req.Equal(expected.Data, actual.Data)
+		})
+	}
+}
+
+func TestFecParityBeforePayloads(t *testing.T) {
+	req := require.New(t)
+
+	encoder := newFecEncoder(&fecConfig{redundancy: 0.5}, "dtls")
+	decoder := NewFecDecoder()
+
+	first := newFecTestPayload(1, 100)
+	req.Nil(encoder.encode(first))
+	second := newFecTestPayload(2, 50)
+	parity := encoder.encode(second)
+	req.NotNil(parity)
+
+	recovered, err := decoder.AcceptParity(parity)
+	req.NoError(err)
+	req.Nil(recovered)
+
+	recovered, err = decoder.AcceptPayload(second)
+	req.NoError(err)
+	req.NotNil(recovered)
+	req.Equal(first.Body, recovered.Body)
+
+	// the group is complete, so late arrivals are ignored
+	recovered, err = decoder.AcceptPayload(first)
+	req.NoError(err)
+	req.Nil(recovered)
+}
+
+func TestFecNoRecoveryWhenNothingLost(t *testing.T) {
+	req := require.New(t)
+
+	encoder := newFecEncoder(&fecConfig{redundancy: 0.5}, "dtls")
+	decoder := NewFecDecoder()
+
+	first := newFecTestPayload(1, 100)
+	req.Nil(encoder.encode(first))
+	second := newFecTestPayload(2, 50)
+	parity := encoder.encode(second)
+
+	for _, msg := range []*channel.Message{first, second, parity} {
+		var recovered *channel.Message
+		var err error
+		if msg == parity {
+			recovered, err = decoder.AcceptParity(msg)
+		} else {
+			recovered, err = decoder.AcceptPayload(msg)
+		}
+		req.NoError(err)
+		req.Nil(recovered)
+	}
+}
+
+func TestFecDecoderWindow(t *testing.T) {
+	req := require.New(t)
+
+	encoder := newFecEncoder(&fecConfig{redundancy: 1}, "dtls")
+	decoder := NewFecDecoder()
+
+	old := newFecTestPayload(0, 10)
+	oldParity := encoder.encode(old)
+	req.NotNil(oldParity)
+
+	for i := 1; i <= fecDecoderWindow; i++ {
+		msg := newFecTestPayload(int32(i), 10)
+		encoder.encode(msg)
+		_, err := decoder.AcceptPayload(msg)
+		req.NoError(err)
+	}
+
+	recovered, err := decoder.AcceptParity(oldParity)
+	req.NoError(err)
+	req.Nil(recovered)
+	req.Len(decoder.groups, fecDecoderWindow)
+}
@@ -55,6 +55,12 @@ func (self *listener) Listen() error {
 		MessageStrategy:    channel.DatagramMessageStrategy(xgress.UnmarshallPacketPayload),
 	}
 
+	if offer := self.config.compression.offer(); offer != nil {
+		config.Headers = map[int32][]byte{
+			LinkHeaderCompression: offer,
+		}
+	}
+
 	acceptor := channel.NewMultiListener(self.handleGroupedUnderlay, self.handleUngroupedNewUnderlay)
 
 	var err error
@@ -134,6 +140,7 @@ func (self *listener) BindChannel(binding channel.Binding) error {
 	routerVersion := ""
 	dialerBinding := ""
 	var iteration uint32
+	compression := ""
 
 	if headers != nil {
 		var ok bool
@@ -153,6 +160,9 @@ func (self *listener) BindChannel(binding channel.Binding) error {
 			iteration = val
 			log = log.WithField("iteration", iteration)
 		}
+		if compression = selectCompression(self.config.compression.offer(), headers[LinkHeaderCompression]); compression != "" {
+			log = log.WithField("compression", compression)
+		}
 	}
 
 	log.Info("binding link channel")
@@ -162,6 +172,7 @@ func (self *listener) BindChannel(binding channel.Binding) error {
 		routerVersion: routerVersion,
 		dialerBinding: dialerBinding,
 		iteration:     iteration,
+		compression:   compression,
 	}
 
 	if chanType != 0 {
@@ -247,6 +258,8 @@ func (self *listener) getOrCreateSplitLink(connId string, linkMeta *linkMetadata
 				dialAddress:   self.GetAdvertisement(),
 				iteration:     linkMeta.iteration,
 				dialed:        false,
+				fec:           newFecEncoder(self.config.fec, self.GetLinkProtocol()),
+				compressor:    newPayloadCompressor(self.config.compression, linkMeta.compression, self.GetLinkProtocol()),
 			},
 			eventTime: time.Now(),
 		}
@@ -291,6 +304,8 @@ func (self *listener) bindNonSplitChannel(binding channel.Binding, linkMeta *lin
 		dialAddress:   self.GetAdvertisement(),
 		iteration:     linkMeta.iteration,
 		dialed:        false,
+		fec:           newFecEncoder(self.config.fec, self.GetLinkProtocol()),
+		compressor:    newPayloadCompressor(self.config.compression, linkMeta.compression, self.GetLinkProtocol()),
 	}
 
 	if mc, ok := binding.GetChannel().(channel.MultiChannel); ok {
@@ -363,4 +378,5 @@ type linkMetadata struct {
 	routerVersion string
 	dialerBinding string
 	iteration     uint32
+	compression   string
 }
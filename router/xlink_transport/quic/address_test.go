@@ -0,0 +1,82 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package quic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/transport/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressParser(t *testing.T) {
+	req := require.New(t)
+	parser := AddressParser{}
+
+	addr, err := parser.Parse("quic:127.0.0.1:6005")
+	req.NoError(err)
+	req.Equal(Type, addr.Type())
+	req.Equal("quic:127.0.0.1:6005", addr.String())
+
+	hostPortAddr, ok := addr.(transport.HostPortAddress)
+	req.True(ok)
+	req.Equal("127.0.0.1", hostPortAddr.Hostname())
+	req.Equal(uint16(6005), hostPortAddr.Port())
+
+	addr, err = parser.Parse("quic:[::1]:6005")
+	req.NoError(err)
+	req.Equal("::1", addr.(transport.HostPortAddress).Hostname())
+
+	_, err = parser.Parse("tls:127.0.0.1:6005")
+	req.Error(err)
+
+	_, err = parser.Parse("quic:127.0.0.1")
+	req.Error(err)
+
+	_, err = parser.Parse("quic:127.0.0.1:70000")
+	req.Error(err)
+}
+
+func TestLoadOptions(t *testing.T) {
+	req := require.New(t)
+
+	opts, err := loadOptions(nil)
+	req.NoError(err)
+	req.Equal(DefaultKeepAlivePeriod, opts.keepAlivePeriod)
+	req.Equal(DefaultMaxIdleTimeout, opts.maxIdleTimeout)
+	req.True(opts.allow0RTT)
+
+	opts, err = loadOptions(transport.Configuration{
+		Type: map[interface{}]interface{}{
+			"keepAlivePeriod": "5s",
+			"maxIdleTimeout":  "30s",
+			"allow0RTT":       false,
+		},
+	})
+	req.NoError(err)
+	req.Equal(5*time.Second, opts.keepAlivePeriod)
+	req.Equal(30*time.Second, opts.maxIdleTimeout)
+	req.False(opts.allow0RTT)
+
+	_, err = loadOptions(transport.Configuration{
+		Type: map[interface{}]interface{}{
+			"keepAlivePeriod": 5,
+		},
+	})
+	req.Error(err)
+}
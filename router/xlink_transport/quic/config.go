@@ -0,0 +1,120 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package quic
+
+import (
+	"time"
+
+	"github.com/openziti/transport/v2"
+	"github.com/pkg/errors"
+	quicgo "github.com/quic-go/quic-go"
+)
+
+const (
+	DefaultHandshakeTimeout = 10 * time.Second
+	DefaultKeepAlivePeriod  = 15 * time.Second
+	DefaultMaxIdleTimeout   = 60 * time.Second
+
+	// DefaultProtocol is used for ALPN when the transport configuration doesn't specify any protocols. QUIC
+	// requires that at least one application protocol is negotiated.
+	DefaultProtocol = "ziti-quic"
+)
+
+// options are loaded from the quic section of the transport configuration, for example:
+//
+//	transport:
+//	  quic:
+//	    keepAlivePeriod: 15s
+//	    maxIdleTimeout: 60s
+//	    allow0RTT: true
+type options struct {
+	keepAlivePeriod time.Duration
+	maxIdleTimeout  time.Duration
+	allow0RTT       bool
+}
+
+func loadOptions(tcfg transport.Configuration) (*options, error) {
+	result := &options{
+		keepAlivePeriod: DefaultKeepAlivePeriod,
+		maxIdleTimeout:  DefaultMaxIdleTimeout,
+		allow0RTT:       true,
+	}
+
+	var err error
+	if result.keepAlivePeriod, err = getDuration(tcfg, "keepAlivePeriod", result.keepAlivePeriod); err != nil {
+		return nil, err
+	}
+
+	if result.maxIdleTimeout, err = getDuration(tcfg, "maxIdleTimeout", result.maxIdleTimeout); err != nil {
+		return nil, err
+	}
+
+	val, err := tcfg.GetValue(Type, "allow0RTT")
+	if err != nil {
+		return nil, err
+	}
+	if val != nil {
+		allow0RTT, ok := val.(bool)
+		if !ok {
+			return nil, errors.Errorf("invalid value for %s:allow0RTT [%v], must be boolean", Type, val)
+		}
+		result.allow0RTT = allow0RTT
+	}
+
+	return result, nil
+}
+
+func (self *options) toQuicConfig(handshakeTimeout time.Duration) *quicgo.Config {
+	if handshakeTimeout == 0 {
+		handshakeTimeout = DefaultHandshakeTimeout
+	}
+
+	return &quicgo.Config{
+		HandshakeIdleTimeout: handshakeTimeout,
+		MaxIdleTimeout:       self.maxIdleTimeout,
+		KeepAlivePeriod:      self.keepAlivePeriod,
+		Allow0RTT:            self.allow0RTT,
+	}
+}
+
+func getDuration(tcfg transport.Configuration, key string, defaultValue time.Duration) (time.Duration, error) {
+	val, err := tcfg.GetValue(Type, key)
+	if err != nil {
+		return 0, err
+	}
+	if val == nil {
+		return defaultValue, nil
+	}
+
+	strVal, ok := val.(string)
+	if !ok {
+		return 0, errors.Errorf("invalid value for %s:%s [%v], must be a duration string", Type, key, val)
+	}
+
+	result, err := time.ParseDuration(strVal)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to parse %s:%s value '%s' to duration", Type, key, strVal)
+	}
+	return result, nil
+}
+
+func getProtocols(tcfg transport.Configuration) []string {
+	if protocols := tcfg.Protocols(); len(protocols) > 0 {
+		return protocols
+	}
+	return []string{DefaultProtocol}
+}
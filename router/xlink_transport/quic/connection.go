@@ -0,0 +1,73 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package quic
+
+import (
+	"crypto/x509"
+	"io"
+	"net"
+	"sync/atomic"
+
+	"github.com/openziti/transport/v2"
+	quicgo "github.com/quic-go/quic-go"
+)
+
+// Connection adapts a single bidirectional stream on a QUIC connection to a transport.Conn. Each Connection owns
+// its QUIC connection, so closing the Connection also closes the underlying QUIC connection.
+type Connection struct {
+	*quicgo.Stream
+	detail *transport.ConnectionDetail
+	conn   *quicgo.Conn
+	owned  io.Closer
+	closed atomic.Bool
+}
+
+func (self *Connection) Detail() *transport.ConnectionDetail {
+	return self.detail
+}
+
+func (self *Connection) PeerCertificates() []*x509.Certificate {
+	return self.conn.ConnectionState().TLS.PeerCertificates
+}
+
+func (self *Connection) LocalAddr() net.Addr {
+	return self.conn.LocalAddr()
+}
+
+func (self *Connection) RemoteAddr() net.Addr {
+	return self.conn.RemoteAddr()
+}
+
+// Used0RTT returns true if the connection was established using 0-RTT resumption
+func (self *Connection) Used0RTT() bool {
+	return self.conn.ConnectionState().Used0RTT
+}
+
+func (self *Connection) Close() error {
+	if !self.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	_ = self.Stream.Close()
+	err := self.conn.CloseWithError(0, "")
+	if self.owned != nil {
+		if closeErr := self.owned.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/identity"
+	"github.com/openziti/transport/v2"
+	"github.com/pkg/errors"
+	quicgo "github.com/quic-go/quic-go"
+)
+
+const sessionCacheSize = 256
+
+// sessionCache is shared by all dialers, so that TLS session tickets survive connection loss. When a link is
+// re-established to a router we've previously connected to, the session ticket allows the handshake to use 0-RTT
+var sessionCache = tls.NewLRUClientSessionCache(sessionCacheSize)
+
+func DialWithLocalBinding(a address, name, localBinding string, i *identity.TokenId, timeout time.Duration, tcfg transport.Configuration) (transport.Conn, error) {
+	log := pfxlog.Logger().WithField("dest", a.String())
+
+	opts, err := loadOptions(tcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := net.ResolveUDPAddr("udp", a.bindableAddress())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to resolve %s", a.String())
+	}
+
+	ip, err := transport.ResolveLocalBinding(localBinding)
+	if err != nil {
+		return nil, err
+	}
+
+	var localAddr *net.UDPAddr
+	if ip != nil {
+		localAddr = &net.UDPAddr{IP: ip}
+	}
+
+	udpConn, err := net.ListenUDP("udp", localAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsCfg := i.ClientTLSConfig().Clone()
+	tlsCfg.ServerName = a.hostname
+	tlsCfg.NextProtos = getProtocols(tcfg)
+	tlsCfg.ClientSessionCache = sessionCache
+
+	ctx := context.Background()
+	cancelF := func() {}
+	if timeout > 0 {
+		ctx, cancelF = context.WithTimeout(ctx, timeout)
+	}
+	defer cancelF()
+
+	qt := &quicgo.Transport{Conn: udpConn}
+	conn, err := qt.DialEarly(ctx, destination, tlsCfg, opts.toQuicConfig(timeout))
+	if err != nil {
+		_ = qt.Close()
+		_ = udpConn.Close()
+		return nil, errors.Wrap(err, "quic handshake error")
+	}
+
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		_ = conn.CloseWithError(0, "")
+		_ = qt.Close()
+		_ = udpConn.Close()
+		return nil, errors.Wrap(err, "unable to open quic stream")
+	}
+
+	log.Debugf("dialed quic connection, 0-RTT: %v", conn.ConnectionState().Used0RTT)
+
+	return &Connection{
+		Stream: stream,
+		detail: &transport.ConnectionDetail{
+			Address: a.String(),
+			InBound: false,
+			Name:    name,
+		},
+		conn:  conn,
+		owned: &ownedTransport{transport: qt, conn: udpConn},
+	}, nil
+}
+
+// ownedTransport closes a quic transport along with the packet connection it was created with. Closing a
+// quic.Transport doesn't close a packet connection that was passed in.
+type ownedTransport struct {
+	transport *quicgo.Transport
+	conn      net.PacketConn
+}
+
+func (self *ownedTransport) Close() error {
+	err := self.transport.Close()
+	if closeErr := self.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/identity"
+	"github.com/openziti/transport/v2"
+	"github.com/pkg/errors"
+	quicgo "github.com/quic-go/quic-go"
+	"github.com/sirupsen/logrus"
+)
+
+func Listen(a address, name string, i *identity.TokenId, acceptF func(transport.Conn), tcfg transport.Configuration) (io.Closer, error) {
+	log := pfxlog.ContextLogger(name + "/" + a.String()).Entry
+
+	opts, err := loadOptions(tcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := tcfg.GetHandshakeTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout == 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+
+	protocols := getProtocols(tcfg)
+	tlsCfg := i.ServerTLSConfig().Clone()
+	tlsCfg.NextProtos = protocols
+
+	// the identity will hand back its own config when a client connects, which won't have our ALPN protocols set
+	if getConfigForClient := tlsCfg.GetConfigForClient; getConfigForClient != nil {
+		tlsCfg.GetConfigForClient = func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg, err := getConfigForClient(info)
+			if cfg != nil {
+				cfg = cfg.Clone()
+				cfg.NextProtos = protocols
+			}
+			return cfg, err
+		}
+	}
+
+	listener, err := quicgo.ListenAddrEarly(a.bindableAddress(), tlsCfg, opts.toQuicConfig(timeout))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to listen on %s", a.String())
+	}
+
+	result := &acceptor{
+		name:     name,
+		listener: listener,
+		acceptF:  acceptF,
+		timeout:  timeout,
+	}
+
+	go result.acceptLoop(log)
+
+	return result, nil
+}
+
+type acceptor struct {
+	name     string
+	listener *quicgo.EarlyListener
+	acceptF  func(transport.Conn)
+	closed   atomic.Bool
+	timeout  time.Duration
+}
+
+func (self *acceptor) Close() error {
+	if self.closed.CompareAndSwap(false, true) {
+		return self.listener.Close()
+	}
+	return nil
+}
+
+func (self *acceptor) acceptLoop(log *logrus.Entry) {
+	defer log.Info("exited")
+
+	for !self.closed.Load() {
+		conn, err := self.listener.Accept(context.Background())
+		if err != nil {
+			if self.closed.Load() {
+				log.WithError(err).Info("listener closed, exiting")
+				return
+			}
+			log.WithError(err).Error("accept failed. Failure not recoverable. Exiting listen loop")
+			return
+		}
+
+		go self.accept(log, conn)
+	}
+}
+
+// accept waits for the handshake to complete before handing off the connection, so that the peer certificates
+// are available. Any data the client sent as 0-RTT early data will be buffered on the stream in the meantime.
+func (self *acceptor) accept(log *logrus.Entry, conn *quicgo.Conn) {
+	log = log.WithField("remote", conn.RemoteAddr().String())
+
+	ctx, cancelF := context.WithTimeout(context.Background(), self.timeout)
+	defer cancelF()
+
+	select {
+	case <-conn.HandshakeComplete():
+	case <-conn.Context().Done():
+		log.WithError(context.Cause(conn.Context())).Error("quic connection closed during handshake")
+		return
+	case <-ctx.Done():
+		log.Error("quic handshake timed out")
+		_ = conn.CloseWithError(0, "handshake timeout")
+		return
+	}
+
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		log.WithError(err).Error("error accepting quic stream")
+		_ = conn.CloseWithError(0, "")
+		return
+	}
+
+	self.acceptF(&Connection{
+		Stream: stream,
+		detail: &transport.ConnectionDetail{
+			Address: Type + ":" + conn.RemoteAddr().String(),
+			InBound: true,
+			Name:    self.name,
+		},
+		conn: conn,
+	})
+}
@@ -0,0 +1,184 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package quic
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openziti/identity"
+	"github.com/openziti/transport/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialListen(t *testing.T) {
+	req := require.New(t)
+	id := newTestIdentity(t)
+
+	addr, err := AddressParser{}.Parse("quic:127.0.0.1:" + strconv.Itoa(getFreeUdpPort(t)))
+	req.NoError(err)
+
+	tcfg := transport.Configuration{
+		Type: map[interface{}]interface{}{
+			"keepAlivePeriod": "1s",
+		},
+	}
+
+	acceptedC := make(chan transport.Conn, 1)
+	closer, err := addr.Listen("test", id, func(conn transport.Conn) {
+		acceptedC <- conn
+	}, tcfg)
+	req.NoError(err)
+	defer func() { _ = closer.Close() }()
+
+	conn, err := addr.Dial("test", id, 5*time.Second, tcfg)
+	req.NoError(err)
+	defer func() { _ = conn.Close() }()
+
+	req.Equal(addr.String(), conn.Detail().Address)
+	req.False(conn.Detail().InBound)
+	req.Len(conn.PeerCertificates(), 1)
+	req.Equal("test", conn.PeerCertificates()[0].Subject.CommonName)
+
+	// the listener only sees the stream once the dialer sends data on it
+	msg := []byte("hello 0")
+	_, err = conn.Write(msg)
+	req.NoError(err)
+
+	var accepted transport.Conn
+	select {
+	case accepted = <-acceptedC:
+	case <-time.After(5 * time.Second):
+		req.FailNow("connection not accepted")
+	}
+	defer func() { _ = accepted.Close() }()
+
+	req.True(accepted.Detail().InBound)
+	req.Equal("test", accepted.Detail().Name)
+	req.Len(accepted.PeerCertificates(), 1)
+	req.Equal("test", accepted.PeerCertificates()[0].Subject.CommonName)
+
+	go func() {
+		_, _ = io.Copy(accepted, accepted)
+	}()
+
+	for i := 0; i < 10; i++ {
+		if i > 0 {
+			msg = []byte("hello " + strconv.Itoa(i))
+			_, err = conn.Write(msg)
+			req.NoError(err)
+		}
+
+		buf := make([]byte, len(msg))
+		_, err = io.ReadFull(conn, buf)
+		req.NoError(err)
+		req.Equal(msg, buf)
+	}
+
+	req.NoError(conn.Close())
+	req.NoError(conn.Close(), "closing twice should be a no-op")
+
+	_, err = accepted.Read(make([]byte, 1))
+	req.Error(err, "peer close should be seen by the listener side")
+}
+
+func TestDialUntrusted(t *testing.T) {
+	req := require.New(t)
+
+	addr, err := AddressParser{}.Parse("quic:127.0.0.1:" + strconv.Itoa(getFreeUdpPort(t)))
+	req.NoError(err)
+
+	closer, err := addr.Listen("test", newTestIdentity(t), func(conn transport.Conn) {
+		_ = conn.Close()
+	}, transport.Configuration{})
+	req.NoError(err)
+	defer func() { _ = closer.Close() }()
+
+	// a different identity has a different CA, so the server cert isn't trusted
+	_, err = addr.Dial("test", newTestIdentity(t), 2*time.Second, transport.Configuration{})
+	req.ErrorContains(err, "quic handshake error")
+}
+
+func getFreeUdpPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func newTestIdentity(t *testing.T) *identity.TokenId {
+	req := require.New(t)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req.NoError(err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	req.NoError(err)
+	caCert, err := x509.ParseCertificate(caDer)
+	req.NoError(err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req.NoError(err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	req.NoError(err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	req.NoError(err)
+
+	certPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	caPem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDer}))
+	keyPem := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+
+	id, err := identity.LoadIdentity(identity.Config{
+		Key:        "pem:" + keyPem,
+		Cert:       "pem:" + certPem,
+		ServerCert: "pem:" + certPem,
+		CA:         "pem:" + caPem,
+	})
+	req.NoError(err)
+
+	return identity.NewIdentity(id)
+}
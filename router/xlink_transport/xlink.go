@@ -41,6 +41,8 @@ type impl struct {
 	dialed        bool
 	iteration     uint32
 	dupsRejected  uint32
+	fec           *fecEncoder
+	compressor    *payloadCompressor
 
 	droppedMsgMeter    metrics.Meter
 	droppedXgMsgMeter  metrics.Meter
@@ -70,13 +72,20 @@ func (self *impl) Init(metricsRegistry metrics.Registry) error {
 		self.droppedXgMsgMeter = metricsRegistry.Meter("link.dropped_xg_msgs:" + self.id)
 		self.droppedRtxMsgMeter = metricsRegistry.Meter("link.dropped_rtx_msgs:" + self.id)
 		self.droppedFwdMsgMeter = metricsRegistry.Meter("link.dropped_fwd_msgs:" + self.id)
+		self.compressor.initMetrics(metricsRegistry, self.id)
 	}
 	return nil
 }
 
 func (self *impl) SendPayload(msg *xgress.Payload, timeout time.Duration, payloadType xgress.PayloadType) error {
+	payloadMsg := msg.Marshall()
+	self.compressor.compress(payloadMsg)
+	if parity := self.fec.encode(payloadMsg); parity != nil {
+		defer self.sendFecParity(parity)
+	}
+
 	if timeout == 0 {
-		sent, err := self.ch.GetDefaultSender().TrySend(msg.Marshall())
+		sent, err := self.ch.GetDefaultSender().TrySend(payloadMsg)
 		if err == nil && !sent {
 			self.droppedMsgMeter.Mark(1)
 			if payloadType == xgress.PayloadTypeXg {
@@ -90,7 +99,13 @@ func (self *impl) SendPayload(msg *xgress.Payload, timeout time.Duration, payloa
 		return err
 	}
 
-	return msg.Marshall().WithTimeout(timeout).Send(self.ch.GetDefaultSender())
+	return payloadMsg.WithTimeout(timeout).Send(self.ch.GetDefaultSender())
+}
+
+func (self *impl) sendFecParity(msg *channel.Message) {
+	if sent, err := self.ch.GetDefaultSender().TrySend(msg); err == nil && !sent {
+		self.droppedMsgMeter.Mark(1)
+	}
 }
 
 func (self *impl) SendAcknowledgement(msg *xgress.Acknowledgement) error {
@@ -111,6 +126,7 @@ func (self *impl) SendControl(msg *xgress.Control) error {
 
 func (self *impl) Close() error {
 	self.droppedMsgMeter.Dispose()
+	self.compressor.dispose()
 	return self.ch.GetChannel().Close()
 }
 
@@ -161,6 +177,7 @@ func (self *impl) InspectLink() *inspect.LinkInspectDetail {
 	result := GetLinkInspectDetail(self)
 	result.Split = false
 	result.Underlays = self.ch.GetChannel().GetUnderlayCountsByType()
+	result.Compression = self.compressor.getAlgorithm()
 	return result
 }
 
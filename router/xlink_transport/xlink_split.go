@@ -44,6 +44,8 @@ type splitImpl struct {
 	dialed        bool
 	iteration     uint32
 	dupsRejected  uint32
+	fec           *fecEncoder
+	compressor    *payloadCompressor
 	lock          sync.Mutex
 
 	droppedMsgMeter    metrics.Meter
@@ -74,6 +76,7 @@ func (self *splitImpl) Init(metricsRegistry metrics.Registry) error {
 		self.droppedXgMsgMeter = metricsRegistry.Meter("link.dropped_xg_msgs:" + self.id)
 		self.droppedRtxMsgMeter = metricsRegistry.Meter("link.dropped_rtx_msgs:" + self.id)
 		self.droppedFwdMsgMeter = metricsRegistry.Meter("link.dropped_fwd_msgs:" + self.id)
+		self.compressor.initMetrics(metricsRegistry, self.id)
 	}
 	return nil
 }
@@ -85,8 +88,14 @@ func (self *splitImpl) syncInit(f func() error) error {
 }
 
 func (self *splitImpl) SendPayload(msg *xgress.Payload, timeout time.Duration, payloadType xgress.PayloadType) error {
+	payloadMsg := msg.Marshall()
+	self.compressor.compress(payloadMsg)
+	if parity := self.fec.encode(payloadMsg); parity != nil {
+		defer self.sendFecParity(parity)
+	}
+
 	if timeout == 0 {
-		sent, err := self.payloadCh.TrySend(msg.Marshall())
+		sent, err := self.payloadCh.TrySend(payloadMsg)
 		if err == nil && !sent {
 			pfxlog.Logger().WithField("circuitId", msg.CircuitId).Info("dropped payload")
 			self.droppedMsgMeter.Mark(1)
@@ -101,7 +110,13 @@ func (self *splitImpl) SendPayload(msg *xgress.Payload, timeout time.Duration, p
 		return err
 	}
 
-	return msg.Marshall().WithTimeout(timeout).Send(self.payloadCh)
+	return payloadMsg.WithTimeout(timeout).Send(self.payloadCh)
+}
+
+func (self *splitImpl) sendFecParity(msg *channel.Message) {
+	if sent, err := self.payloadCh.TrySend(msg); err == nil && !sent {
+		self.droppedMsgMeter.Mark(1)
+	}
 }
 
 func (self *splitImpl) SendAcknowledgement(msg *xgress.Acknowledgement) error {
@@ -136,6 +151,7 @@ func (self *splitImpl) Close() error {
 	if self.droppedMsgMeter != nil {
 		self.droppedMsgMeter.Dispose()
 	}
+	self.compressor.dispose()
 	var err, err2 error
 	if ch := self.payloadCh; ch != nil {
 		err = ch.Close()
@@ -194,6 +210,7 @@ func (self *splitImpl) InspectLink() *inspect.LinkInspectDetail {
 		"ack":     1,
 		"payload": 1,
 	}
+	result.Compression = self.compressor.getAlgorithm()
 	return result
 }
 
@@ -20,6 +20,7 @@ package tests
 
 import (
 	"github.com/openziti/ziti/common/eid"
+	"net/http"
 	"sort"
 	"testing"
 )
@@ -48,4 +49,73 @@ func Test_ApiSession(t *testing.T) {
 		sort.Strings(session.AuthResponse.ConfigTypes)
 		ctx.Req.Equal(expected, session.AuthResponse.ConfigTypes)
 	})
+	t.Run("read only admins can't read the tokens of other api sessions", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		_, auth := ctx.AdminManagementSession.requireCreateIdentityWithUpdbEnrollment(eid.New(), eid.New(), false, "ziti.read-only-admin")
+		readOnlySession := auth.RequireAuthenticateManagementApi(ctx)
+
+		adminSessionId := *ctx.AdminManagementSession.AuthResponse.ID
+		result := readOnlySession.requireQuery("api-sessions/" + adminSessionId)
+		ctx.Req.Equal(adminSessionId, result.Path("data.id").Data().(string))
+		ctx.Req.Equal("", result.Path("data.token").Data().(string))
+
+		readOnlySessionId := *readOnlySession.AuthResponse.ID
+		result = readOnlySession.requireQuery("api-sessions/" + readOnlySessionId)
+		ctx.Req.Equal(*readOnlySession.AuthResponse.Token, result.Path("data.token").Data().(string))
+
+		result = ctx.AdminManagementSession.requireQuery("api-sessions/" + adminSessionId)
+		ctx.Req.Equal(*ctx.AdminManagementSession.AuthResponse.Token, result.Path("data.token").Data().(string))
+	})
+
+	t.Run("admins can read the tokens of other api sessions", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		_, auth := ctx.AdminManagementSession.requireCreateIdentityWithUpdbEnrollment(eid.New(), eid.New(), false)
+		identitySession, err := auth.AuthenticateClientApi(ctx)
+		ctx.Req.NoError(err)
+
+		result := ctx.AdminManagementSession.requireQuery("api-sessions/" + *identitySession.AuthResponse.ID)
+		ctx.Req.Equal(*identitySession.AuthResponse.Token, result.Path("data.token").Data().(string))
+	})
+
+	t.Run("only admins and the owner can read session tokens", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		ctx.CreateEnrollAndStartEdgeRouter()
+
+		identityRole := eid.New()
+		serviceRole := eid.New()
+		_, auth := ctx.AdminManagementSession.requireCreateIdentityWithUpdbEnrollment(eid.New(), eid.New(), false, identityRole)
+		identitySession, err := auth.AuthenticateClientApi(ctx)
+		ctx.Req.NoError(err)
+
+		service := ctx.AdminManagementSession.requireNewService(s(serviceRole), nil)
+		ctx.AdminManagementSession.requireNewServicePolicy("Dial", s("#"+serviceRole), s("#"+identityRole), nil)
+		ctx.AdminManagementSession.requireNewEdgeRouterPolicy(s("#all"), s("#"+identityRole))
+		ctx.AdminManagementSession.requireNewServiceEdgeRouterPolicy(s("#all"), s("#"+serviceRole))
+
+		resp, err := identitySession.createNewSession(service.Id)
+		ctx.Req.NoError(err)
+		ctx.Req.Equal(http.StatusCreated, resp.StatusCode())
+		session := ctx.parseJson(resp.Body())
+		sessionId := session.Path("data.id").Data().(string)
+
+		result := identitySession.requireQuery("sessions/" + sessionId)
+		sessionToken := result.Path("data.token").Data().(string)
+		ctx.Req.NotEmpty(sessionToken)
+
+		result = ctx.AdminManagementSession.requireQuery("sessions/" + sessionId)
+		ctx.Req.Equal(sessionToken, result.Path("data.token").Data().(string))
+
+		_, readOnlyAuth := ctx.AdminManagementSession.requireCreateIdentityWithUpdbEnrollment(eid.New(), eid.New(), false, "ziti.read-only-admin")
+		readOnlySession := readOnlyAuth.RequireAuthenticateManagementApi(ctx)
+
+		result = readOnlySession.requireQuery("sessions/" + sessionId)
+		ctx.Req.Equal(sessionId, result.Path("data.id").Data().(string))
+		ctx.Req.Equal("", result.Path("data.token").Data().(string))
+
+		result = readOnlySession.requireQuery(`sessions?filter=id="` + sessionId + `"`)
+		sessions, err := result.Path("data").Children()
+		ctx.Req.NoError(err)
+		ctx.Req.Len(sessions, 1)
+		ctx.Req.Equal("", sessions[0].Path("token").Data().(string))
+	})
 }
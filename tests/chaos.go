@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openziti/ziti/router"
+	"github.com/openziti/ziti/tests/testutil"
+)
+
+// addRouterToChaos starts the router with the given index and registers it with the chaos controller. When the
+// chaos controller restarts the router, a new router instance is created from the same configuration and its link
+// registry is re-registered. onStart is called with each new router instance, so the test can accept its control
+// channel and send it peer updates, and onStop is called after each instance is shut down.
+func (ctx *FabricTestContext) addRouterToChaos(chaos *testutil.ChaosController, index uint8, onStart, onStop func(r *router.Router)) *router.Router {
+	r := ctx.startRouter(index)
+	routerId := r.GetRouterId().Token
+	chaos.AddLinkRegistry(routerId, r.GetXlinkRegistry())
+	onStart(r)
+
+	stop := func() error {
+		if err := r.Shutdown(); err != nil {
+			return err
+		}
+		// test router configs bind their link listeners to 6004, 6005, ...
+		if addr := fmt.Sprintf("localhost:%d", 6003+int(index)); ctx.waitForPortClose(addr, 2*time.Second) != nil {
+			return fmt.Errorf("router %s link listener still open at %s", routerId, addr)
+		}
+		onStop(r)
+		return nil
+	}
+
+	start := func() error {
+		r = ctx.startRouter(index)
+		chaos.AddLinkRegistry(routerId, r.GetXlinkRegistry())
+		onStart(r)
+		return nil
+	}
+
+	chaos.AddRouter(testutil.NewChaosTarget(routerId, start, stop))
+	return r
+}
@@ -0,0 +1,181 @@
+//go:build apitests
+
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fullsailor/pkcs7"
+	"github.com/openziti/identity/certtools"
+	"github.com/openziti/ziti/controller/webapis"
+	"gopkg.in/resty.v1"
+)
+
+func Test_EnrollmentEst(t *testing.T) {
+	ctx := NewTestContext(t)
+	defer ctx.Teardown()
+	ctx.StartServer()
+	ctx.RequireAdminManagementApiLogin()
+
+	newEstCsr := func(cn string) (*ecdsa.PrivateKey, string) {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		ctx.Req.NoError(err)
+
+		request, err := certtools.NewCertRequest(map[string]string{
+			"C": "US", "O": "NetFoundry-API-Test", "CN": cn,
+		}, nil)
+		ctx.Req.NoError(err)
+
+		csr, err := x509.CreateCertificateRequest(rand.Reader, request, privateKey)
+		ctx.Req.NoError(err)
+
+		return privateKey, base64.StdEncoding.EncodeToString(csr)
+	}
+
+	parseEstCerts := func(resp *resty.Response) []*x509.Certificate {
+		ctx.Req.Equal(http.StatusOK, resp.StatusCode(), string(resp.Body()))
+		ctx.Req.True(strings.HasPrefix(resp.Header().Get("Content-Type"), "application/pkcs7-mime"))
+
+		der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(resp.Body())), ""))
+		ctx.Req.NoError(err)
+
+		p7, err := pkcs7.Parse(der)
+		ctx.Req.NoError(err)
+		ctx.Req.Len(p7.Certificates, 1)
+		return p7.Certificates
+	}
+
+	estUrl := func(path string) string {
+		return "https://" + ctx.ApiHost + path
+	}
+
+	identity := ctx.AdminManagementSession.RequireNewIdentityWithOtt(false)
+	result := ctx.AdminManagementSession.requireQuery(fmt.Sprintf("identities/%v", identity.Id))
+	token, ok := result.Path("data.enrollment.ott.token").Data().(string)
+	ctx.Req.True(ok)
+
+	var authenticator *certAuthenticator
+
+	t.Run("simpleenroll without credentials is challenged", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		_, csr := newEstCsr(identity.Id)
+
+		resp, err := ctx.NewRestClientWithDefaults().R().
+			SetHeader("Content-Type", "application/pkcs10").
+			SetBody(csr).
+			Post(estUrl(webapis.WellKnownEstSimpleEnroll))
+		ctx.Req.NoError(err)
+		ctx.Req.Equal(http.StatusUnauthorized, resp.StatusCode())
+		ctx.Req.NotEmpty(resp.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("simpleenroll with an invalid token fails", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		_, csr := newEstCsr(identity.Id)
+
+		resp, err := ctx.NewRestClientWithDefaults().R().
+			SetBasicAuth(identity.Id, "not-a-token").
+			SetHeader("Content-Type", "application/pkcs10").
+			SetBody(csr).
+			Post(estUrl(webapis.WellKnownEstSimpleEnroll))
+		ctx.Req.NoError(err)
+		ctx.Req.Equal(http.StatusBadRequest, resp.StatusCode(), string(resp.Body()))
+	})
+
+	t.Run("simpleenroll with the enrollment token issues a certificate", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		key, csr := newEstCsr(identity.Id)
+
+		resp, err := ctx.NewRestClientWithDefaults().R().
+			SetBasicAuth(identity.Id, token).
+			SetHeader("Content-Type", "application/pkcs10").
+			SetBody(csr).
+			Post(estUrl(webapis.WellKnownEstSimpleEnroll))
+		ctx.Req.NoError(err)
+
+		certs := parseEstCerts(resp)
+		authenticator = &certAuthenticator{
+			certs:   certs,
+			key:     key,
+			certPem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw})),
+		}
+
+		authenticator.RequireAuthenticateClientApi(ctx)
+	})
+
+	t.Run("the enrollment token can't be reused", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		_, csr := newEstCsr(identity.Id)
+
+		resp, err := ctx.NewRestClientWithDefaults().R().
+			SetBasicAuth(identity.Id, token).
+			SetHeader("Content-Type", "application/pkcs10").
+			SetBody(csr).
+			Post(estUrl(webapis.WellKnownEstSimpleEnroll))
+		ctx.Req.NoError(err)
+		ctx.Req.Equal(http.StatusBadRequest, resp.StatusCode(), string(resp.Body()))
+	})
+
+	t.Run("simplereenroll without a client certificate fails", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		_, csr := newEstCsr(identity.Id)
+
+		resp, err := ctx.NewRestClientWithDefaults().R().
+			SetHeader("Content-Type", "application/pkcs10").
+			SetBody(csr).
+			Post(estUrl(webapis.WellKnownEstSimpleReenroll))
+		ctx.Req.NoError(err)
+		ctx.Req.Equal(http.StatusUnauthorized, resp.StatusCode(), string(resp.Body()))
+	})
+
+	t.Run("simplereenroll renews the client certificate", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		ctx.Req.NotNil(authenticator)
+		key, csr := newEstCsr(identity.Id)
+
+		client := resty.NewWithClient(ctx.NewHttpClient(ctx.NewTransportWithClientCert(authenticator.certs, authenticator.key)))
+		resp, err := client.R().
+			SetHeader("Content-Type", "application/pkcs10").
+			SetBody(csr).
+			Post(estUrl(webapis.WellKnownEstSimpleReenroll))
+		ctx.Req.NoError(err)
+
+		certs := parseEstCerts(resp)
+		ctx.Req.NotEqual(authenticator.certs[0].Raw, certs[0].Raw)
+
+		renewed := &certAuthenticator{
+			certs:   certs,
+			key:     key,
+			certPem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw})),
+		}
+		renewed.RequireAuthenticateClientApi(ctx)
+
+		_, err = authenticator.AuthenticateClientApi(ctx)
+		ctx.Req.Error(err)
+	})
+}
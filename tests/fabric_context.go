@@ -201,8 +201,15 @@ func (ctx *FabricTestContext) StartServerFor(test string, clean bool) {
 }
 
 func (ctx *FabricTestContext) startRouter(index uint8) *router.Router {
+	return ctx.startRouterWithConfigF(index, nil)
+}
+
+func (ctx *FabricTestContext) startRouterWithConfigF(index uint8, configF func(config *env.Config)) *router.Router {
 	config, err := env.LoadConfig(fmt.Sprintf(FabricRouterConfFile, index))
 	ctx.Req.NoError(err)
+	if configF != nil {
+		configF(config)
+	}
 	r := router.Create(config, versions.NewDefaultVersionProvider())
 	ctx.Req.NoError(r.Start())
 
@@ -0,0 +1,124 @@
+//go:build apitests
+
+package tests
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router"
+	"github.com/openziti/ziti/router/env"
+	"github.com/openziti/ziti/router/xlink_transport"
+	"github.com/openziti/ziti/tests/testutil"
+	"google.golang.org/protobuf/proto"
+)
+
+// linkChaosRegressionSeeds are seeds which previously failed Test_LinkChaos
+var linkChaosRegressionSeeds = []int64{
+	// the controller was marked unresponsive, so link state updates were dropped and never resent
+	1792167613953745182,
+}
+
+// Test_LinkChaos runs a link between two routers through randomly dropped link underlays, control channel latency
+// and restarts of the dialing router, then checks that a single link with all its underlays recovers. Set
+// ZITI_CHAOS_SEED to replay a failing run.
+func Test_LinkChaos(t *testing.T) {
+	seed := time.Now().UnixNano()
+	if val := os.Getenv("ZITI_CHAOS_SEED"); val != "" {
+		var err error
+		seed, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid ZITI_CHAOS_SEED: %v", err)
+		}
+	}
+	runLinkChaos(t, seed)
+}
+
+func Test_LinkChaosRegressions(t *testing.T) {
+	for _, seed := range linkChaosRegressionSeeds {
+		t.Run(strconv.FormatInt(seed, 10), func(t *testing.T) {
+			runLinkChaos(t, seed)
+		})
+	}
+}
+
+func runLinkChaos(t *testing.T, seed int64) {
+	ctx := NewFabricTestContext(t)
+	defer ctx.Teardown()
+	ctx.StartServer()
+	mgmtClient := ctx.createTestFabricRestClient()
+	mgmtClient.EnrollRouter("001", "router-1", "testdata/router/001-client.cert.pem")
+	mgmtClient.EnrollRouter("002", "router-2", "testdata/router/002-client.cert.pem")
+	ctx.Teardown()
+
+	t.Logf("chaos seed: %d", seed)
+
+	chaos := testutil.NewChaosController(seed)
+	chaos.MaxLatency = 50 * time.Millisecond
+
+	// router-1's link listener is advertised through the proxy, so the links router-2 dials can be dropped
+	proxy, err := testutil.NewChaosProxy("127.0.0.1:6104", "127.0.0.1:6004")
+	ctx.Req.NoError(err)
+	defer func() {
+		_ = proxy.Close()
+	}()
+	chaos.AddProxy(proxy)
+
+	ctrlListener := ctx.NewControlChannelListener()
+	defer func() {
+		_ = ctrlListener.Close()
+	}()
+	ctrlUnderlayFactory := chaos.WrapUnderlayFactory("ctrl", ctrlListener)
+
+	linkChecker := testutil.NewLinkChecker(ctx.Req)
+	linkChecker.ExpectUnderlays(xlink_transport.ChannelTypeAck, xlink_transport.ChannelTypeDefault)
+
+	router1 := ctx.startRouterWithConfigF(1, func(config *env.Config) {
+		config.Link.Listeners[0]["advertise"] = "tls:" + proxy.Addr()
+	})
+	chaos.AddLinkRegistry(router1.GetRouterId().Token, router1.GetXlinkRegistry())
+	router1cc := testutil.StartLinkTest(linkChecker, "router-1", ctrlUnderlayFactory, ctx.Req)
+
+	router1Listeners := &ctrl_pb.Listeners{}
+	val, found := router1cc.Underlay().Headers()[int32(ctrl_pb.ControlHeaders_ListenersHeader)]
+	ctx.Req.True(found)
+	ctx.Req.NoError(proto.Unmarshal(val, router1Listeners))
+
+	peerUpdates := &ctrl_pb.PeerStateChanges{
+		Changes: []*ctrl_pb.PeerStateChange{
+			{
+				Id:        router1.GetRouterId().Token,
+				Version:   "v0.0.0",
+				State:     ctrl_pb.PeerState_Healthy,
+				Listeners: router1Listeners.Listeners,
+			},
+		},
+	}
+
+	ctx.addRouterToChaos(chaos, 2, func(*router.Router) {
+		router2cc := testutil.StartLinkTest(linkChecker, "router-2", ctrlUnderlayFactory, ctx.Req)
+		ctx.Req.NoError(protobufs.MarshalTyped(peerUpdates).WithTimeout(time.Second).SendAndWaitForWire(router2cc))
+	}, func(r *router.Router) {
+		linkChecker.RouterDisconnected(r.GetRouterId().Token)
+	})
+
+	linkChecker.RequireActiveLinkCountWithin(1, 5*time.Second)
+	linkChecker.RequireNoErrors()
+
+	chaos.SetEnabledActions(testutil.ChaosDropUnderlay, testutil.ChaosInjectLatency, testutil.ChaosClearLatency,
+		testutil.ChaosKillRouter, testutil.ChaosRestartRouter)
+
+	for _, event := range chaos.Run(20, 250*time.Millisecond) {
+		ctx.Req.NoError(event.Err, "seed: %d, event: %s", seed, event)
+	}
+
+	ctx.Req.NoError(chaos.RestartAll())
+	ctx.Req.NoError(chaos.SetLatency("ctrl", 0, 0))
+
+	linkChecker.RequireActiveLinkCountWithin(1, 20*time.Second)
+	linkChecker.RequireNoErrors()
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/openziti/metrics"
 	"github.com/openziti/sdk-golang/xgress"
 	"github.com/openziti/transport/v2"
+	"github.com/openziti/ziti/common/linktls"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
 	"github.com/openziti/ziti/router/env"
 	"github.com/openziti/ziti/router/link"
@@ -76,6 +77,10 @@ func (self *testRegistryEnv) GetLinkDialerPool() goroutines.Pool {
 	panic("implement me")
 }
 
+func (self *testRegistryEnv) GetLinkDialPacingOptions() env.LinkDialPacingOptions {
+	return env.LinkDialPacingOptions{}
+}
+
 func (self *testRegistryEnv) GetRateLimiterPool() goroutines.Pool {
 	panic("implement me")
 }
@@ -123,7 +128,7 @@ func (self *testDial) GetIteration() uint32 {
 
 func setupEnv() link.Env {
 	closeNotify := make(chan struct{})
-	ctrls := env.NewNetworkControllers(time.Second, nil, env.NewDefaultHeartbeatOptions())
+	ctrls := env.NewNetworkControllers(time.Second, nil, env.NewDefaultHeartbeatOptions(), false)
 	registryConfig := metrics.DefaultUsageRegistryConfig("test", closeNotify)
 	metricsRegistry := metrics.NewUsageRegistry(registryConfig)
 	return &testRegistryEnv{
@@ -142,6 +147,10 @@ func (self *testLinkEnv) GetXLinkRegistry() xlink.Registry {
 	return self.linkRegistry
 }
 
+func (self *testLinkEnv) GetLinkTlsPolicy() *linktls.Policy {
+	return self.linkRegistry.GetTlsPolicy()
+}
+
 func newTestLinkEnv() *testLinkEnv {
 	e := setupEnv()
 	linkRegistry := link.NewLinkRegistry(e)
@@ -346,3 +355,94 @@ func Test_DuplicateLinkWithLinkCloseDialer(t *testing.T) {
 	_ = router2cc.Close()
 	_ = ctrlListener.Close()
 }
+
+func Test_DialOnlyRouterLink(t *testing.T) {
+	ctx := NewFabricTestContext(t)
+	defer ctx.Teardown()
+	ctx.StartServer()
+	mgmtClient := ctx.createTestFabricRestClient()
+	mgmtClient.EnrollRouter("001", "router-1", "testdata/router/001-client.cert.pem")
+	mgmtClient.EnrollRouter("002", "router-2", "testdata/router/002-client.cert.pem")
+	ctx.Teardown()
+
+	ctrlListener := ctx.NewControlChannelListener()
+	router1 := ctx.startRouter(1)
+
+	linkChecker := testutil.NewLinkChecker(ctx.Req)
+	router1cc := testutil.StartLinkTest(linkChecker, "router-1", ctrlListener, ctx.Req)
+
+	router1Listeners := &ctrl_pb.Listeners{}
+	if val, found := router1cc.Underlay().Headers()[int32(ctrl_pb.ControlHeaders_ListenersHeader)]; found {
+		ctx.Req.NoError(proto.Unmarshal(val, router1Listeners))
+	}
+	ctx.Req.NotEmpty(router1Listeners.Listeners)
+
+	router2 := ctx.startRouterWithConfigF(2, func(config *env.Config) {
+		config.Link.DialOnly = true
+	})
+	router2cc := testutil.StartLinkTest(linkChecker, "router-2", ctrlListener, ctx.Req)
+	linkChecker.MarkDialOnly(router2.GetRouterId().Token)
+
+	// a dial only router doesn't listen for or advertise links, and flags itself as dial only
+	ctx.Req.Error(ctx.waitForPort("127.0.0.1:6005", time.Second))
+	_, found := router2cc.Underlay().Headers()[int32(ctrl_pb.ControlHeaders_ListenersHeader)]
+	ctx.Req.False(found)
+
+	router2Metadata := &ctrl_pb.RouterMetadata{}
+	val, found := router2cc.Underlay().Headers()[int32(ctrl_pb.ControlHeaders_RouterMetadataHeader)]
+	ctx.Req.True(found)
+	ctx.Req.NoError(proto.Unmarshal(val, router2Metadata))
+	ctx.Req.Contains(router2Metadata.Capabilities, ctrl_pb.RouterCapability_LinkDialOnly)
+
+	peerUpdates1 := &ctrl_pb.PeerStateChanges{
+		Changes: []*ctrl_pb.PeerStateChange{
+			{
+				Id:        router1.GetRouterId().Token,
+				Version:   "v0.0.0",
+				State:     ctrl_pb.PeerState_Healthy,
+				Listeners: router1Listeners.Listeners,
+			},
+		},
+	}
+	ctx.Req.NoError(protobufs.MarshalTyped(peerUpdates1).WithTimeout(time.Second).SendAndWaitForWire(router2cc))
+
+	peerUpdates2 := &ctrl_pb.PeerStateChanges{
+		Changes: []*ctrl_pb.PeerStateChange{
+			{
+				Id:      router2.GetRouterId().Token,
+				Version: "v0.0.0",
+				State:   ctrl_pb.PeerState_Healthy,
+			},
+		},
+	}
+	ctx.Req.NoError(protobufs.MarshalTyped(peerUpdates2).WithTimeout(time.Second).SendAndWaitForWire(router1cc))
+
+	time.Sleep(time.Second)
+
+	// the only link should be dialed from the dial only router
+	linkChecker.RequireNoErrors()
+	activeLink := linkChecker.RequireOneActiveLink()
+	ctx.Req.Equal(router2.GetRouterId().Token, activeLink.Src)
+	ctx.Req.Equal(router1.GetRouterId().Token, activeLink.Dest)
+
+	// restart router 1. The dial only router should re-establish the link
+	ctx.Req.NoError(router1.Shutdown())
+	ctx.Req.NoError(ctx.waitForPortClose("localhost:6004", 2*time.Second))
+	router1 = ctx.startRouter(1)
+	defer func() {
+		ctx.Req.NoError(router1.Shutdown())
+	}()
+
+	router1cc = testutil.StartLinkTest(linkChecker, "router-1", ctrlListener, ctx.Req)
+	ctx.Req.NoError(protobufs.MarshalTyped(peerUpdates2).WithTimeout(time.Second).SendAndWaitForWire(router1cc))
+	ctx.Req.NoError(protobufs.MarshalTyped(peerUpdates1).WithTimeout(time.Second).SendAndWaitForWire(router2cc))
+
+	time.Sleep(2 * time.Second)
+
+	linkChecker.RequireNoErrors()
+
+	ctx.Teardown()
+	_ = router1cc.Close()
+	_ = router2cc.Close()
+	_ = ctrlListener.Close()
+}
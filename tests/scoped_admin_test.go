@@ -0,0 +1,105 @@
+//go:build apitests
+
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/ziti/common/eid"
+)
+
+func Test_ScopedAdmin(t *testing.T) {
+	ctx := NewTestContext(t)
+	defer ctx.Teardown()
+	ctx.StartServer()
+
+	ctx.RequireAdminManagementApiLogin()
+
+	name := eid.New()
+	password := eid.New()
+	scopedAdmin := &identity{
+		name:           name,
+		identityType:   string(rest_model.IdentityTypeDefault),
+		enrollment:     map[string]interface{}{"updb": name},
+		roleAttributes: s("ziti.scoped-admin"),
+		tags:           map[string]interface{}{"scope": "sales"},
+	}
+	ctx.AdminManagementSession.requireCreateEntity(scopedAdmin)
+	ctx.completeUpdbEnrollment(scopedAdmin.Id, password)
+
+	auth := &updbAuthenticator{
+		Username: name,
+		Password: password,
+	}
+	scopedSession := auth.RequireAuthenticateManagementApi(ctx)
+
+	inScope := newTestIdentity(false)
+	inScope.tags = map[string]interface{}{"scope": "sales"}
+	ctx.AdminManagementSession.requireCreateEntity(inScope)
+
+	outOfScope := newTestIdentity(false)
+	outOfScope.tags = map[string]interface{}{"scope": "finance"}
+	ctx.AdminManagementSession.requireCreateEntity(outOfScope)
+
+	listIds := func(filter string) []string {
+		query := url.Values{}
+		query.Set("filter", filter)
+		result := scopedSession.requireQuery("identities?" + query.Encode())
+		children, err := result.Path("data").Children()
+		ctx.Req.NoError(err)
+
+		var ids []string
+		for _, child := range children {
+			ids = append(ids, child.Path("id").Data().(string))
+		}
+		return ids
+	}
+
+	t.Run("list only returns entities in scope", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		ids := listIds("true")
+		ctx.Req.Contains(ids, inScope.Id)
+		ctx.Req.Contains(ids, scopedAdmin.Id)
+		ctx.Req.NotContains(ids, outOfScope.Id)
+	})
+
+	t.Run("or clauses in the filter can't widen the scope", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		ids := listIds("true or true")
+		ctx.Req.Contains(ids, inScope.Id)
+		ctx.Req.NotContains(ids, outOfScope.Id)
+	})
+
+	t.Run("filters which escape their grouping are rejected", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		query := url.Values{}
+		query.Set("filter", "true) or (true")
+		status, _ := scopedSession.query("identities?" + query.Encode())
+		ctx.Req.Equal(http.StatusBadRequest, status)
+	})
+
+	t.Run("reading an entity in another scope is rejected", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		status, _ := scopedSession.query("identities/" + outOfScope.Id)
+		ctx.Req.Equal(http.StatusUnauthorized, status)
+	})
+}
@@ -0,0 +1,576 @@
+package testutil
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/router/xlink"
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"github.com/pkg/errors"
+)
+
+// ChaosTarget is a component, such as a router or controller, which a ChaosController can stop and restart
+type ChaosTarget interface {
+	Id() string
+	Start() error
+	Stop() error
+}
+
+// NewChaosTarget returns a ChaosTarget which delegates to the given start and stop functions
+func NewChaosTarget(id string, start, stop func() error) ChaosTarget {
+	return &chaosTargetF{
+		id:    id,
+		start: start,
+		stop:  stop,
+	}
+}
+
+type chaosTargetF struct {
+	id    string
+	start func() error
+	stop  func() error
+}
+
+func (self *chaosTargetF) Id() string {
+	return self.id
+}
+
+func (self *chaosTargetF) Start() error {
+	return self.start()
+}
+
+func (self *chaosTargetF) Stop() error {
+	return self.stop()
+}
+
+type ChaosAction string
+
+const (
+	ChaosKillRouter     ChaosAction = "killRouter"
+	ChaosRestartRouter  ChaosAction = "restartRouter"
+	ChaosKillController ChaosAction = "killController"
+	ChaosRestartCtrl    ChaosAction = "restartController"
+	ChaosDropUnderlay   ChaosAction = "dropUnderlay"
+	ChaosInjectLatency  ChaosAction = "injectLatency"
+	ChaosClearLatency   ChaosAction = "clearLatency"
+)
+
+const (
+	chaosTargetController = "controller"
+	chaosTargetRouter     = "router"
+)
+
+// ChaosEvent records an action taken by the ChaosController
+type ChaosEvent struct {
+	Seq    int
+	Action ChaosAction
+	Target string
+	Detail string
+	Err    error
+}
+
+func (self ChaosEvent) String() string {
+	result := fmt.Sprintf("%d: %s %s", self.Seq, self.Action, self.Target)
+	if self.Detail != "" {
+		result += " " + self.Detail
+	}
+	if self.Err != nil {
+		result += " (error: " + self.Err.Error() + ")"
+	}
+	return result
+}
+
+// ChaosController randomly stops and restarts routers and controllers, drops link underlays and injects latency
+// into channels. Link underlays can only be dropped if they go through a ChaosProxy registered with AddProxy. All random choices are made from a single seeded source, and candidates are always considered
+// in sorted order, so a given seed and sequence of calls produces the same sequence of actions.
+type ChaosController struct {
+	seed int64
+	rnd  *rand.Rand
+	lock sync.Mutex
+
+	targets        map[string]map[string]ChaosTarget
+	stopped        map[string]map[string]ChaosTarget
+	linkRegistries cmap.ConcurrentMap[string, xlink.Registry]
+	proxies        []*ChaosProxy
+	latency        map[string]*chaosLatency
+	events         []ChaosEvent
+	enabled        []ChaosAction
+
+	MaxLatency time.Duration
+}
+
+// NewChaosController creates a ChaosController using the given seed. The seed is logged so that failing runs
+// can be reproduced.
+func NewChaosController(seed int64) *ChaosController {
+	pfxlog.Logger().WithField("seed", seed).Info("creating chaos controller")
+	return &ChaosController{
+		seed: seed,
+		rnd:  rand.New(rand.NewSource(seed)),
+		targets: map[string]map[string]ChaosTarget{
+			chaosTargetController: {},
+			chaosTargetRouter:     {},
+		},
+		stopped: map[string]map[string]ChaosTarget{
+			chaosTargetController: {},
+			chaosTargetRouter:     {},
+		},
+		linkRegistries: cmap.New[xlink.Registry](),
+		latency:        map[string]*chaosLatency{},
+		enabled: []ChaosAction{
+			ChaosKillRouter, ChaosRestartRouter, ChaosKillController, ChaosRestartCtrl,
+			ChaosDropUnderlay, ChaosInjectLatency, ChaosClearLatency,
+		},
+		MaxLatency: 250 * time.Millisecond,
+	}
+}
+
+func (self *ChaosController) Seed() int64 {
+	return self.seed
+}
+
+// SetEnabledActions limits the actions which Step may randomly choose from
+func (self *ChaosController) SetEnabledActions(actions ...ChaosAction) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.enabled = append([]ChaosAction(nil), actions...)
+}
+
+func (self *ChaosController) AddRouter(target ChaosTarget) {
+	self.addTarget(chaosTargetRouter, target)
+}
+
+func (self *ChaosController) AddController(target ChaosTarget) {
+	self.addTarget(chaosTargetController, target)
+}
+
+func (self *ChaosController) addTarget(targetType string, target ChaosTarget) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.targets[targetType][target.Id()] = target
+}
+
+// AddLinkRegistry registers the link registry of the given router, so its links can be targeted by DropUnderlay.
+// It doesn't take the controller lock, so restarted routers can re-register from ChaosTarget.Start.
+func (self *ChaosController) AddLinkRegistry(routerId string, registry xlink.Registry) {
+	self.linkRegistries.Set(routerId, registry)
+}
+
+// AddProxy registers a proxy whose connections can be dropped by DropUnderlay
+func (self *ChaosController) AddProxy(proxy *ChaosProxy) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.proxies = append(self.proxies, proxy)
+}
+
+// Events returns the actions taken so far
+func (self *ChaosController) Events() []ChaosEvent {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return append([]ChaosEvent(nil), self.events...)
+}
+
+func (self *ChaosController) record(action ChaosAction, target, detail string, err error) ChaosEvent {
+	event := ChaosEvent{
+		Seq:    len(self.events) + 1,
+		Action: action,
+		Target: target,
+		Detail: detail,
+		Err:    err,
+	}
+	self.events = append(self.events, event)
+	pfxlog.Logger().WithField("seed", self.seed).Infof("chaos: %s", event.String())
+	return event
+}
+
+// Step performs a single randomly chosen action from the enabled actions. Actions with no eligible target are
+// skipped, so Step returns false if nothing could be done.
+func (self *ChaosController) Step() (ChaosEvent, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	candidates := self.rnd.Perm(len(self.enabled))
+	for _, idx := range candidates {
+		if event, ok := self.perform(self.enabled[idx]); ok {
+			return event, true
+		}
+	}
+	return ChaosEvent{}, false
+}
+
+// Run performs count random steps, waiting interval between each
+func (self *ChaosController) Run(count int, interval time.Duration) []ChaosEvent {
+	var result []ChaosEvent
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+		if event, ok := self.Step(); ok {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func (self *ChaosController) perform(action ChaosAction) (ChaosEvent, bool) {
+	switch action {
+	case ChaosKillRouter:
+		return self.stopRandom(action, chaosTargetRouter)
+	case ChaosRestartRouter:
+		return self.startRandom(action, chaosTargetRouter)
+	case ChaosKillController:
+		return self.stopRandom(action, chaosTargetController)
+	case ChaosRestartCtrl:
+		return self.startRandom(action, chaosTargetController)
+	case ChaosDropUnderlay:
+		return self.dropRandomUnderlay()
+	case ChaosInjectLatency:
+		return self.injectRandomLatency()
+	case ChaosClearLatency:
+		return self.clearRandomLatency()
+	}
+	return ChaosEvent{}, false
+}
+
+// KillRandomRouter stops a randomly selected running router
+func (self *ChaosController) KillRandomRouter() (ChaosEvent, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.stopRandom(ChaosKillRouter, chaosTargetRouter)
+}
+
+// RestartRandomRouter starts a randomly selected stopped router
+func (self *ChaosController) RestartRandomRouter() (ChaosEvent, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.startRandom(ChaosRestartRouter, chaosTargetRouter)
+}
+
+// KillRandomController stops a randomly selected running controller
+func (self *ChaosController) KillRandomController() (ChaosEvent, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.stopRandom(ChaosKillController, chaosTargetController)
+}
+
+// RestartRandomController starts a randomly selected stopped controller
+func (self *ChaosController) RestartRandomController() (ChaosEvent, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.startRandom(ChaosRestartCtrl, chaosTargetController)
+}
+
+// RestartAll starts any routers and controllers which were stopped. Controllers are started first.
+func (self *ChaosController) RestartAll() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for _, targetType := range []string{chaosTargetController, chaosTargetRouter} {
+		action := ChaosRestartRouter
+		if targetType == chaosTargetController {
+			action = ChaosRestartCtrl
+		}
+		for _, id := range sortedKeys(self.stopped[targetType]) {
+			if event := self.start(action, targetType, id); event.Err != nil {
+				return event.Err
+			}
+		}
+	}
+	return nil
+}
+
+func (self *ChaosController) stopRandom(action ChaosAction, targetType string) (ChaosEvent, bool) {
+	ids := sortedKeys(self.targets[targetType])
+	if len(ids) == 0 {
+		return ChaosEvent{}, false
+	}
+	id := ids[self.rnd.Intn(len(ids))]
+	target := self.targets[targetType][id]
+	delete(self.targets[targetType], id)
+	self.stopped[targetType][id] = target
+	return self.record(action, id, "", target.Stop()), true
+}
+
+func (self *ChaosController) startRandom(action ChaosAction, targetType string) (ChaosEvent, bool) {
+	ids := sortedKeys(self.stopped[targetType])
+	if len(ids) == 0 {
+		return ChaosEvent{}, false
+	}
+	return self.start(action, targetType, ids[self.rnd.Intn(len(ids))]), true
+}
+
+func (self *ChaosController) start(action ChaosAction, targetType string, id string) ChaosEvent {
+	target := self.stopped[targetType][id]
+	err := target.Start()
+	if err == nil {
+		delete(self.stopped[targetType], id)
+		self.targets[targetType][id] = target
+	}
+	return self.record(action, id, "", err)
+}
+
+// DropUnderlay closes one of the underlays of the given type on the given link, as seen from the given router
+func (self *ChaosController) DropUnderlay(routerId, linkId, underlayType string) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.dropUnderlay(routerId, linkId, underlayType).Err
+}
+
+func (self *ChaosController) dropUnderlay(routerId, linkId, underlayType string) ChaosEvent {
+	detail := fmt.Sprintf("link=%s underlay=%s", linkId, underlayType)
+
+	registry, ok := self.linkRegistries.Get(routerId)
+	if !ok {
+		return self.record(ChaosDropUnderlay, routerId, detail, errors.Errorf("no link registry for router %s", routerId))
+	}
+
+	link, ok := registry.GetLinkById(linkId)
+	if !ok {
+		return self.record(ChaosDropUnderlay, routerId, detail, errors.Errorf("link %s not found on router %s", linkId, routerId))
+	}
+
+	conns := getUnderlayConns(link, underlayType)
+	if len(conns) == 0 {
+		return self.record(ChaosDropUnderlay, routerId, detail, errors.Errorf("link %s has no underlays of type %s", linkId, underlayType))
+	}
+
+	for _, conn := range conns {
+		for _, proxy := range self.proxies {
+			if proxy.CloseConns(conn.LocalAddr, conn.RemoteAddr) > 0 {
+				return self.record(ChaosDropUnderlay, routerId, detail, nil)
+			}
+		}
+	}
+
+	return self.record(ChaosDropUnderlay, routerId, detail, errors.Errorf("link %s %s underlays don't go through a chaos proxy", linkId, underlayType))
+}
+
+func (self *ChaosController) isProxied(conns []*ctrl_pb.LinkConn) bool {
+	for _, conn := range conns {
+		for _, proxy := range self.proxies {
+			if proxy.HasConn(conn.LocalAddr, conn.RemoteAddr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getUnderlayConns returns the link's underlays of the given type, ordered by local address
+func getUnderlayConns(link xlink.Xlink, underlayType string) []*ctrl_pb.LinkConn {
+	var result []*ctrl_pb.LinkConn
+	for _, conn := range link.GetLinkConnState().GetConns() {
+		if conn.Type == underlayType {
+			result = append(result, conn)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LocalAddr < result[j].LocalAddr
+	})
+	return result
+}
+
+func (self *ChaosController) dropRandomUnderlay() (ChaosEvent, bool) {
+	type candidate struct {
+		routerId     string
+		linkId       string
+		underlayType string
+	}
+
+	var candidates []candidate
+	routerIds := self.linkRegistries.Keys()
+	sort.Strings(routerIds)
+	for _, routerId := range routerIds {
+		if _, stopped := self.stopped[chaosTargetRouter][routerId]; stopped {
+			continue
+		}
+		var links []xlink.Xlink
+		registry, _ := self.linkRegistries.Get(routerId)
+		for link := range registry.Iter() {
+			links = append(links, link)
+		}
+		sort.Slice(links, func(i, j int) bool {
+			return links[i].Id() < links[j].Id()
+		})
+		for _, link := range links {
+			if link.IsClosed() {
+				continue
+			}
+			types := map[string]struct{}{}
+			for _, conn := range link.GetLinkConnState().GetConns() {
+				types[conn.Type] = struct{}{}
+			}
+			for _, underlayType := range sortedKeys(types) {
+				if self.isProxied(getUnderlayConns(link, underlayType)) {
+					candidates = append(candidates, candidate{routerId: routerId, linkId: link.Id(), underlayType: underlayType})
+				}
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ChaosEvent{}, false
+	}
+
+	c := candidates[self.rnd.Intn(len(candidates))]
+	return self.dropUnderlay(c.routerId, c.linkId, c.underlayType), true
+}
+
+// WrapUnderlayFactory returns an UnderlayFactory whose underlays can have latency injected, using the given id
+func (self *ChaosController) WrapUnderlayFactory(id string, factory channel.UnderlayFactory) channel.UnderlayFactory {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	l, ok := self.latency[id]
+	if !ok {
+		l = &chaosLatency{
+			rnd: rand.New(rand.NewSource(self.rnd.Int63())),
+		}
+		self.latency[id] = l
+	}
+
+	return &latencyUnderlayFactory{
+		wrapped: factory,
+		latency: l,
+	}
+}
+
+// SetLatency sets the latency range applied to each message sent and received on underlays wrapped with the given id
+func (self *ChaosController) SetLatency(id string, min, max time.Duration) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.setLatency(id, min, max).Err
+}
+
+func (self *ChaosController) setLatency(id string, min, max time.Duration) ChaosEvent {
+	action := ChaosInjectLatency
+	if max == 0 {
+		action = ChaosClearLatency
+	}
+
+	l, ok := self.latency[id]
+	if !ok {
+		return self.record(action, id, "", errors.Errorf("no underlay factory wrapped with id %s", id))
+	}
+
+	if max < min {
+		max = min
+	}
+	l.set(min, max)
+
+	detail := ""
+	if max > 0 {
+		detail = fmt.Sprintf("min=%s max=%s", min, max)
+	}
+	return self.record(action, id, detail, nil)
+}
+
+func (self *ChaosController) injectRandomLatency() (ChaosEvent, bool) {
+	ids := sortedKeys(self.latency)
+	if len(ids) == 0 || self.MaxLatency <= 0 {
+		return ChaosEvent{}, false
+	}
+	id := ids[self.rnd.Intn(len(ids))]
+	max := time.Duration(self.rnd.Int63n(int64(self.MaxLatency))) + 1
+	min := time.Duration(self.rnd.Int63n(int64(max)))
+	return self.setLatency(id, min, max), true
+}
+
+func (self *ChaosController) clearRandomLatency() (ChaosEvent, bool) {
+	var ids []string
+	for _, id := range sortedKeys(self.latency) {
+		if self.latency[id].isActive() {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return ChaosEvent{}, false
+	}
+	return self.setLatency(ids[self.rnd.Intn(len(ids))], 0, 0), true
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+type chaosLatency struct {
+	lock sync.Mutex
+	rnd  *rand.Rand
+	min  time.Duration
+	max  time.Duration
+}
+
+func (self *chaosLatency) set(min, max time.Duration) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.min = min
+	self.max = max
+}
+
+func (self *chaosLatency) isActive() bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.max > 0
+}
+
+func (self *chaosLatency) next() time.Duration {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.max <= 0 {
+		return 0
+	}
+	if self.max == self.min {
+		return self.min
+	}
+	return self.min + time.Duration(self.rnd.Int63n(int64(self.max-self.min)))
+}
+
+func (self *chaosLatency) delay() {
+	if d := self.next(); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+type latencyUnderlayFactory struct {
+	wrapped channel.UnderlayFactory
+	latency *chaosLatency
+}
+
+func (self *latencyUnderlayFactory) Create(timeout time.Duration) (channel.Underlay, error) {
+	underlay, err := self.wrapped.Create(timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &latencyUnderlay{
+		Underlay: underlay,
+		latency:  self.latency,
+	}, nil
+}
+
+type latencyUnderlay struct {
+	channel.Underlay
+	latency *chaosLatency
+}
+
+func (self *latencyUnderlay) Rx() (*channel.Message, error) {
+	msg, err := self.Underlay.Rx()
+	if err == nil {
+		self.latency.delay()
+	}
+	return msg, err
+}
+
+func (self *latencyUnderlay) Tx(m *channel.Message) error {
+	self.latency.delay()
+	return self.Underlay.Tx(m)
+}
@@ -0,0 +1,156 @@
+package testutil
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/michaelquigley/pfxlog"
+)
+
+// ChaosProxy relays TCP connections from a local address to a target address. Components under test connect
+// through the proxy, for example by advertising the proxy address for a router link listener, so that individual
+// connections can be dropped without the components needing any test hooks.
+type ChaosProxy struct {
+	listener net.Listener
+	target   string
+	lock     sync.Mutex
+	conns    map[*chaosProxyConn]struct{}
+	closed   bool
+}
+
+type chaosProxyConn struct {
+	client   net.Conn
+	upstream net.Conn
+}
+
+func (self *chaosProxyConn) close() {
+	_ = self.client.Close()
+	_ = self.upstream.Close()
+}
+
+// matches returns true if either end of the proxied connection, as seen from the client or the target, has one of
+// the given addresses. Addresses use the network:host:port format of link connection state.
+func (self *chaosProxyConn) matches(addrs map[string]struct{}) bool {
+	for _, addr := range []net.Addr{self.client.RemoteAddr(), self.upstream.LocalAddr()} {
+		if _, ok := addrs[addr.Network()+":"+addr.String()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NewChaosProxy starts a proxy listening on listenAddr, relaying connections to targetAddr
+func NewChaosProxy(listenAddr, targetAddr string) (*ChaosProxy, error) {
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := &ChaosProxy{
+		listener: listener,
+		target:   targetAddr,
+		conns:    map[*chaosProxyConn]struct{}{},
+	}
+	go proxy.accept()
+	return proxy, nil
+}
+
+// Addr returns the address the proxy is listening on
+func (self *ChaosProxy) Addr() string {
+	return self.listener.Addr().String()
+}
+
+// Close stops accepting connections and closes all proxied connections
+func (self *ChaosProxy) Close() error {
+	self.lock.Lock()
+	self.closed = true
+	conns := self.conns
+	self.conns = map[*chaosProxyConn]struct{}{}
+	self.lock.Unlock()
+
+	for conn := range conns {
+		conn.close()
+	}
+	return self.listener.Close()
+}
+
+func (self *ChaosProxy) accept() {
+	for {
+		client, err := self.listener.Accept()
+		if err != nil {
+			return
+		}
+		go self.relay(client)
+	}
+}
+
+func (self *ChaosProxy) relay(client net.Conn) {
+	upstream, err := net.Dial("tcp", self.target)
+	if err != nil {
+		pfxlog.Logger().WithError(err).WithField("target", self.target).Error("chaos proxy unable to reach target")
+		_ = client.Close()
+		return
+	}
+
+	conn := &chaosProxyConn{
+		client:   client,
+		upstream: upstream,
+	}
+
+	self.lock.Lock()
+	if self.closed {
+		self.lock.Unlock()
+		conn.close()
+		return
+	}
+	self.conns[conn] = struct{}{}
+	self.lock.Unlock()
+
+	done := make(chan struct{}, 2)
+	copyF := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go copyF(upstream, client)
+	go copyF(client, upstream)
+
+	<-done
+	conn.close()
+
+	self.lock.Lock()
+	delete(self.conns, conn)
+	self.lock.Unlock()
+}
+
+// HasConn returns true if the proxy is relaying a connection with one of the given addresses
+func (self *ChaosProxy) HasConn(addrs ...string) bool {
+	return len(self.findConns(addrs)) > 0
+}
+
+// CloseConns closes proxied connections with any of the given addresses, returning the number closed
+func (self *ChaosProxy) CloseConns(addrs ...string) int {
+	conns := self.findConns(addrs)
+	for _, conn := range conns {
+		conn.close()
+	}
+	return len(conns)
+}
+
+func (self *ChaosProxy) findConns(addrs []string) []*chaosProxyConn {
+	addrSet := map[string]struct{}{}
+	for _, addr := range addrs {
+		addrSet[addr] = struct{}{}
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	var result []*chaosProxyConn
+	for conn := range self.conns {
+		if conn.matches(addrSet) {
+			result = append(result, conn)
+		}
+	}
+	return result
+}
@@ -0,0 +1,182 @@
+package testutil
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testChaosTarget struct {
+	id      string
+	running bool
+	starts  int
+	stops   int
+}
+
+func (self *testChaosTarget) Id() string {
+	return self.id
+}
+
+func (self *testChaosTarget) Start() error {
+	if self.running {
+		return fmt.Errorf("%s already running", self.id)
+	}
+	self.running = true
+	self.starts++
+	return nil
+}
+
+func (self *testChaosTarget) Stop() error {
+	if !self.running {
+		return fmt.Errorf("%s not running", self.id)
+	}
+	self.running = false
+	self.stops++
+	return nil
+}
+
+func newTestChaosController(seed int64) (*ChaosController, []*testChaosTarget) {
+	chaos := NewChaosController(seed)
+	chaos.SetEnabledActions(ChaosKillRouter, ChaosRestartRouter, ChaosKillController, ChaosRestartCtrl)
+
+	var targets []*testChaosTarget
+	for i := 1; i <= 3; i++ {
+		router := &testChaosTarget{id: fmt.Sprintf("router-%d", i), running: true}
+		chaos.AddRouter(router)
+		targets = append(targets, router)
+	}
+	ctrl := &testChaosTarget{id: "ctrl-1", running: true}
+	chaos.AddController(ctrl)
+	targets = append(targets, ctrl)
+
+	return chaos, targets
+}
+
+func TestChaosControllerIsDeterministic(t *testing.T) {
+	req := require.New(t)
+
+	chaos1, _ := newTestChaosController(42)
+	chaos2, _ := newTestChaosController(42)
+
+	events1 := chaos1.Run(25, 0)
+	events2 := chaos2.Run(25, 0)
+
+	req.Len(events1, 25)
+	req.Equal(len(events1), len(events2))
+	for i := range events1 {
+		req.Equal(events1[i].String(), events2[i].String())
+		req.NoError(events1[i].Err)
+	}
+}
+
+func TestChaosControllerRestartAll(t *testing.T) {
+	req := require.New(t)
+
+	chaos, targets := newTestChaosController(7)
+	chaos.Run(10, 0)
+	req.NoError(chaos.RestartAll())
+
+	for _, target := range targets {
+		req.True(target.running, target.id)
+		req.Equal(target.starts, target.stops, target.id)
+	}
+
+	_, ok := chaos.RestartRandomRouter()
+	req.False(ok)
+}
+
+func TestChaosLatency(t *testing.T) {
+	req := require.New(t)
+
+	chaos := NewChaosController(1)
+	chaos.WrapUnderlayFactory("ctrl", nil)
+
+	l := chaos.latency["ctrl"]
+	req.Equal(time.Duration(0), l.next())
+
+	req.NoError(chaos.SetLatency("ctrl", 10*time.Millisecond, 20*time.Millisecond))
+	for i := 0; i < 100; i++ {
+		d := l.next()
+		req.GreaterOrEqual(d, 10*time.Millisecond)
+		req.Less(d, 20*time.Millisecond)
+	}
+
+	req.NoError(chaos.SetLatency("ctrl", 0, 0))
+	req.Equal(time.Duration(0), l.next())
+
+	req.Error(chaos.SetLatency("unknown", 0, time.Millisecond))
+}
+
+func TestChaosProxy(t *testing.T) {
+	req := require.New(t)
+
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	req.NoError(err)
+	defer func() {
+		_ = target.Close()
+	}()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	proxy, err := NewChaosProxy("127.0.0.1:0", target.Addr().String())
+	req.NoError(err)
+	defer func() {
+		_ = proxy.Close()
+	}()
+
+	dial := func() (net.Conn, net.Conn) {
+		client, err := net.Dial("tcp", proxy.Addr())
+		req.NoError(err)
+		select {
+		case server := <-accepted:
+			return client, server
+		case <-time.After(time.Second):
+			req.FailNow("proxied connection not accepted")
+		}
+		return nil, nil
+	}
+
+	client1, server1 := dial()
+	client2, server2 := dial()
+
+	_, err = client1.Write([]byte("hello"))
+	req.NoError(err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(server1, buf)
+	req.NoError(err)
+	req.Equal("hello", string(buf))
+
+	clientAddr := client1.LocalAddr().Network() + ":" + client1.LocalAddr().String()
+	req.Eventually(func() bool {
+		return proxy.HasConn(clientAddr)
+	}, time.Second, 10*time.Millisecond)
+	req.Equal(1, proxy.CloseConns(clientAddr))
+	req.False(proxy.HasConn(clientAddr))
+
+	// only the matching connection is dropped, from both sides
+	_ = server1.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = server1.Read(buf)
+	req.ErrorIs(err, io.EOF)
+
+	_, err = server2.Write([]byte("world"))
+	req.NoError(err)
+	_, err = io.ReadFull(client2, buf)
+	req.NoError(err)
+	req.Equal("world", string(buf))
+
+	serverAddr := server2.RemoteAddr().Network() + ":" + server2.RemoteAddr().String()
+	req.Equal(1, proxy.CloseConns(serverAddr))
+}
@@ -3,32 +3,227 @@ package testutil
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/openziti/channel/v4"
 	"github.com/openziti/ziti/common/handler_common"
 	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
 )
 
+type LinkEventType string
+
+const (
+	LinkEventReported     LinkEventType = "reported"
+	LinkEventFaulted      LinkEventType = "faulted"
+	LinkEventRecovered    LinkEventType = "recovered"
+	LinkEventUnderlayUp   LinkEventType = "underlay-up"
+	LinkEventUnderlayDown LinkEventType = "underlay-down"
+)
+
+const linkEventPollInterval = 10 * time.Millisecond
+
+// reconnectPingContentType is sent by reconnecting channels. The channel library doesn't export it.
+const reconnectPingContentType = -33
+
+// DefaultIgnoredContentTypes are the message types routers send to the controller during link tests which aren't
+// related to links. New checkers ignore them. Tests can ignore other types with IgnoreContentType.
+var DefaultIgnoredContentTypes = map[int32]string{
+	reconnectPingContentType:                           "reconnect ping",
+	channel.ContentTypeHeartbeat:                       "heartbeat",
+	int32(ctrl_pb.ContentType_MetricsType):             "metrics",
+	int32(edge_ctrl_pb.ContentType_ConnectEventsTypes): "connect events",
+}
+
+// LinkEvent is an entry in a link's history. UnderlayType is only set for underlay events.
+type LinkEvent struct {
+	Type         LinkEventType
+	UnderlayType string
+	Timestamp    time.Time
+}
+
+func (self LinkEvent) String() string {
+	if self.UnderlayType != "" {
+		return fmt.Sprintf("%s(%s)@%s", self.Type, self.UnderlayType, self.Timestamp.Format("15:04:05.000"))
+	}
+	return fmt.Sprintf("%s@%s", self.Type, self.Timestamp.Format("15:04:05.000"))
+}
+
 type TestLink struct {
-	Id         string
-	Src        string
-	Dest       string
+	Id                 string
+	Src                string
+	Dest               string
+	FaultCount         int
+	Valid              bool
+	ConnStateIteration uint32
+	Underlays          map[string]*TestUnderlay
+	History            []LinkEvent
+}
+
+func (self *TestLink) recordEvent(eventType LinkEventType, underlayType string) {
+	self.History = append(self.History, LinkEvent{
+		Type:         eventType,
+		UnderlayType: underlayType,
+		Timestamp:    time.Now(),
+	})
+}
+
+// lastEvent returns the most recent event of any of the given types, or nil if there isn't one
+func (self *TestLink) lastEvent(eventTypes ...LinkEventType) *LinkEvent {
+	for i := len(self.History) - 1; i >= 0; i-- {
+		for _, eventType := range eventTypes {
+			if self.History[i].Type == eventType {
+				return &self.History[i]
+			}
+		}
+	}
+	return nil
+}
+
+// TestUnderlay tracks a single connection of a link, such as the payload or ack channel of a split link
+type TestUnderlay struct {
+	Type       string
+	LocalAddr  string
+	RemoteAddr string
 	FaultCount int
-	Valid      bool
+	Up         bool
+}
+
+// updateUnderlays applies the reported connection state to the link. Underlays which were up but are missing
+// from the reported state are marked down and counted as faulted.
+func (self *TestLink) updateUnderlays(connState *ctrl_pb.LinkConnState) {
+	if connState == nil {
+		return
+	}
+
+	if connState.StateIteration < self.ConnStateIteration {
+		return
+	}
+	self.ConnStateIteration = connState.StateIteration
+
+	reported := map[string]struct{}{}
+	for _, conn := range connState.Conns {
+		reported[conn.Type] = struct{}{}
+		underlay, ok := self.Underlays[conn.Type]
+		if !ok {
+			underlay = &TestUnderlay{Type: conn.Type}
+			self.Underlays[conn.Type] = underlay
+		}
+		underlay.LocalAddr = conn.LocalAddr
+		underlay.RemoteAddr = conn.RemoteAddr
+		if !underlay.Up {
+			underlay.Up = true
+			self.recordEvent(LinkEventUnderlayUp, conn.Type)
+		}
+	}
+
+	for underlayType, underlay := range self.Underlays {
+		if _, ok := reported[underlayType]; !ok {
+			self.faultUnderlay(underlay)
+		}
+	}
+}
+
+func (self *TestLink) faultUnderlay(underlay *TestUnderlay) {
+	if underlay.Up {
+		underlay.Up = false
+		underlay.FaultCount++
+		self.recordEvent(LinkEventUnderlayDown, underlay.Type)
+	}
+}
+
+// ActiveUnderlayTypes returns the types of the link's underlays which are currently up
+func (self *TestLink) ActiveUnderlayTypes() []string {
+	var result []string
+	for underlayType, underlay := range self.Underlays {
+		if underlay.Up {
+			result = append(result, underlayType)
+		}
+	}
+	sort.Strings(result)
+	return result
 }
 
 type LinkStateChecker struct {
-	errorC chan error
-	links  map[string]*TestLink
-	req    *require.Assertions
+	errorC            chan error
+	links             map[string]*TestLink
+	pendingStates     map[string]*ctrl_pb.LinkConnState
+	dialOnly          map[string]struct{}
+	expectedUnderlays []string
+	ignoredTypes      map[int32]string
+	expectedTypes     map[int32]string
+	receivedCounts    map[int32]int
+	req               *require.Assertions
 	sync.Mutex
 }
 
+// IgnoreContentType adds a message type which the checker doesn't track. Messages of the type aren't reported as
+// unhandled.
+func (self *LinkStateChecker) IgnoreContentType(contentType int32, name string) {
+	self.Lock()
+	defer self.Unlock()
+	self.ignoredTypes[contentType] = name
+}
+
+// UnignoreContentType removes a message type from the ignored types, including the defaults, so that receiving it is
+// reported as an error
+func (self *LinkStateChecker) UnignoreContentType(contentType int32) {
+	self.Lock()
+	defer self.Unlock()
+	delete(self.ignoredTypes, contentType)
+}
+
+// ExpectContentType adds a message type which the test expects routers to send. Messages of the type aren't reported
+// as unhandled, and RequireExpectedContentTypes checks that at least one was received.
+func (self *LinkStateChecker) ExpectContentType(contentType int32, name string) {
+	self.Lock()
+	defer self.Unlock()
+	self.expectedTypes[contentType] = name
+}
+
+// GetReceivedCount returns how many messages of the given type have been received. Only ignored and expected types
+// are counted.
+func (self *LinkStateChecker) GetReceivedCount(contentType int32) int {
+	self.Lock()
+	defer self.Unlock()
+	return self.receivedCounts[contentType]
+}
+
+// RequireExpectedContentTypes requires that at least one message of each expected type has been received
+func (self *LinkStateChecker) RequireExpectedContentTypes() {
+	self.Lock()
+	defer self.Unlock()
+
+	var missing []string
+	for contentType, name := range self.expectedTypes {
+		if self.receivedCounts[contentType] == 0 {
+			missing = append(missing, fmt.Sprintf("%s (%d)", name, contentType))
+		}
+	}
+	sort.Strings(missing)
+	self.req.Empty(missing, "expected message types not received")
+}
+
+// MarkDialOnly flags the given router as link dial only. Any link reported as dialed to the router is an error.
+func (self *LinkStateChecker) MarkDialOnly(routerId string) {
+	self.Lock()
+	defer self.Unlock()
+	self.dialOnly[routerId] = struct{}{}
+}
+
+// ExpectUnderlays sets the underlay types every active link must have up. A link which is missing any of them
+// will fail RequireActiveLinkCount and RequireOneActiveLink.
+func (self *LinkStateChecker) ExpectUnderlays(underlayTypes ...string) {
+	self.Lock()
+	defer self.Unlock()
+	self.expectedUnderlays = underlayTypes
+}
+
 func (self *LinkStateChecker) reportError(err error) {
 	select {
 	case self.errorC <- err:
@@ -45,24 +240,70 @@ func (self *LinkStateChecker) HandleLink(msg *channel.Message, ch channel.Channe
 		self.reportError(err)
 	}
 
+	self.applyRouterLinks(ch.Id(), routerLinks)
+}
+
+func (self *LinkStateChecker) applyRouterLinks(srcRouterId string, routerLinks *ctrl_pb.RouterLinks) {
 	for _, link := range routerLinks.Links {
+		if _, ok := self.dialOnly[link.DestRouterId]; ok {
+			self.reportError(fmt.Errorf("link %v from %v dialed dial only router %v", link.Id, srcRouterId, link.DestRouterId))
+		}
+
 		testLink, ok := self.links[link.Id]
 		if !ok {
-			self.links[link.Id] = &TestLink{
-				Id:    link.Id,
-				Src:   ch.Id(),
-				Dest:  link.DestRouterId,
-				Valid: true,
+			testLink = &TestLink{
+				Id:        link.Id,
+				Src:       srcRouterId,
+				Dest:      link.DestRouterId,
+				Valid:     true,
+				Underlays: map[string]*TestUnderlay{},
 			}
+			testLink.recordEvent(LinkEventReported, "")
+			self.links[link.Id] = testLink
 		} else {
-			if testLink.Src != ch.Id() {
-				self.reportError(fmt.Errorf("source router change for link %v => %v", testLink.Src, ch.Id()))
+			if testLink.Src != srcRouterId {
+				self.reportError(fmt.Errorf("source router change for link %v => %v", testLink.Src, srcRouterId))
 			}
 			if testLink.Dest != link.DestRouterId {
 				self.reportError(fmt.Errorf("dest router change for link %v => %v", testLink.Dest, link.DestRouterId))
 			}
+			if !testLink.Valid {
+				testLink.recordEvent(LinkEventRecovered, "")
+			}
 			testLink.Valid = true
 		}
+		testLink.updateUnderlays(link.ConnState)
+		self.applyPendingState(testLink)
+	}
+}
+
+func (self *LinkStateChecker) HandleLinkState(msg *channel.Message, _ channel.Channel) {
+	self.Lock()
+	defer self.Unlock()
+
+	update := &ctrl_pb.LinkStateUpdate{}
+	if err := proto.Unmarshal(msg.Body, update); err != nil {
+		self.reportError(err)
+		return
+	}
+
+	if link, found := self.links[update.LinkId]; found {
+		link.updateUnderlays(update.ConnState)
+	} else if update.ConnState != nil {
+		// link state and router links messages can arrive in either order, so state for a link which hasn't been
+		// reported yet is held until it is
+		if pending, found := self.pendingStates[update.LinkId]; !found || pending.StateIteration <= update.ConnState.StateIteration {
+			self.pendingStates[update.LinkId] = update.ConnState
+		}
+	}
+}
+
+// applyPendingState applies link state which arrived before the link was reported. State older than what the link
+// was reported with is ignored.
+func (self *LinkStateChecker) applyPendingState(link *TestLink) {
+	if connState, found := self.pendingStates[link.Id]; found {
+		delete(self.pendingStates, link.Id)
+		link.updateUnderlays(connState)
 	}
 }
 
@@ -78,29 +319,55 @@ func (self *LinkStateChecker) HandleFault(msg *channel.Message, _ channel.Channe
 		}
 	}
 
+	self.applyFault(fault)
+}
+
+func (self *LinkStateChecker) applyFault(fault *ctrl_pb.Fault) {
 	if fault.Subject == ctrl_pb.FaultSubject_LinkFault || fault.Subject == ctrl_pb.FaultSubject_LinkDuplicate {
 		if link, found := self.links[fault.Id]; found {
 			link.FaultCount++
 			link.Valid = false
+			link.recordEvent(LinkEventFaulted, "")
+			for _, underlay := range link.Underlays {
+				link.faultUnderlay(underlay)
+			}
 		} else {
 			self.reportError(fmt.Errorf("no link with Id %s found", fault.Id))
 		}
 	}
 }
 
+// RouterDisconnected faults the valid links to or from the given router, as the controller does when a router
+// disconnects. Tests which stop routers should call it, since a stopped router can't report faults for its own links.
+func (self *LinkStateChecker) RouterDisconnected(routerId string) {
+	self.Lock()
+	defer self.Unlock()
+
+	for _, link := range self.links {
+		if link.Valid && (link.Src == routerId || link.Dest == routerId) {
+			self.applyFault(&ctrl_pb.Fault{
+				Subject: ctrl_pb.FaultSubject_LinkFault,
+				Id:      link.Id,
+			})
+		}
+	}
+}
+
 func (self *LinkStateChecker) HandleOther(msg *channel.Message, _ channel.Channel) {
-	//  -33 = reconnect ping
-	//    5 = heartbeat
-	// 1007 = metrics message
-	// 1053 = LinkState
-	// 201415 = connect events
-	if msg.ContentType == -33 || msg.ContentType == 5 || msg.ContentType == 1007 || msg.ContentType == 1053 ||
-		msg.ContentType == 20415 {
-		logrus.Debug("ignoring heartbeats, reconnect pings and metrics")
-		return
+	self.Lock()
+	defer self.Unlock()
+
+	name, expected := self.expectedTypes[msg.ContentType]
+	if !expected {
+		var ignored bool
+		if name, ignored = self.ignoredTypes[msg.ContentType]; !ignored {
+			self.reportError(fmt.Errorf("unhandled msg of type %v received", msg.ContentType))
+			return
+		}
+		logrus.Debugf("ignoring %s message", name)
 	}
 
-	self.reportError(fmt.Errorf("unhandled msg of type %v received", msg.ContentType))
+	self.receivedCounts[msg.ContentType]++
 }
 
 func (self *LinkStateChecker) RequireNoErrors() {
@@ -122,30 +389,182 @@ func (self *LinkStateChecker) RequireNoErrors() {
 }
 
 func (self *LinkStateChecker) RequireOneActiveLink() *TestLink {
+	return self.RequireActiveLinkCount(1)[0]
+}
+
+// RequireActiveLinkCount requires that exactly n links are active and returns them, ordered by id. If expected
+// underlays have been set, each active link must also have all of them up.
+func (self *LinkStateChecker) RequireActiveLinkCount(n int) []*TestLink {
 	self.Lock()
 	defer self.Unlock()
 
-	var activeLink *TestLink
-
+	var activeLinks []*TestLink
 	for _, link := range self.links {
 		if link.Valid {
-			self.req.Nil(activeLink, "more than one active link found")
-			activeLink = link
+			activeLinks = append(activeLinks, link)
+		}
+	}
+	sort.Slice(activeLinks, func(i, j int) bool {
+		return activeLinks[i].Id < activeLinks[j].Id
+	})
+
+	self.req.Equal(n, len(activeLinks), "expected %d active links, found %d", n, len(activeLinks))
+
+	for _, link := range activeLinks {
+		for _, underlayType := range self.expectedUnderlays {
+			underlay, found := link.Underlays[underlayType]
+			self.req.True(found && underlay.Up, "link %s has no active %s underlay, active underlays: %v",
+				link.Id, underlayType, link.ActiveUnderlayTypes())
 		}
 	}
-	self.req.NotNil(activeLink, "no active link found")
-	return activeLink
+
+	return activeLinks
+}
+
+// RequireActiveLinkCountWithin waits up to the given duration for exactly n links to be active, each with all
+// expected underlays up, and returns them, ordered by id
+func (self *LinkStateChecker) RequireActiveLinkCountWithin(n int, d time.Duration) []*TestLink {
+	self.waitFor(d, func() bool {
+		activeCount := 0
+		for _, link := range self.links {
+			if !link.Valid {
+				continue
+			}
+			activeCount++
+			for _, underlayType := range self.expectedUnderlays {
+				if underlay, found := link.Underlays[underlayType]; !found || !underlay.Up {
+					return false
+				}
+			}
+		}
+		return activeCount == n
+	})
+
+	return self.RequireActiveLinkCount(n)
+}
+
+// RequireUnderlayFaultCount requires that the given underlay of the given link has faulted exactly n times
+func (self *LinkStateChecker) RequireUnderlayFaultCount(linkId string, underlayType string, n int) {
+	self.Lock()
+	defer self.Unlock()
+
+	link, found := self.links[linkId]
+	self.req.True(found, "no link with id %s found", linkId)
+
+	faultCount := 0
+	if underlay, found := link.Underlays[underlayType]; found {
+		faultCount = underlay.FaultCount
+	}
+	self.req.Equal(n, faultCount, "unexpected fault count for %s underlay of link %s", underlayType, linkId)
+}
+
+// GetLinkHistory returns a copy of the events recorded for the given link, oldest first
+func (self *LinkStateChecker) GetLinkHistory(linkId string) []LinkEvent {
+	self.Lock()
+	defer self.Unlock()
+
+	if link, found := self.links[linkId]; found {
+		return append([]LinkEvent(nil), link.History...)
+	}
+	return nil
+}
+
+// waitFor polls the given check, holding the checker lock, until it returns true or the timeout elapses
+func (self *LinkStateChecker) waitFor(timeout time.Duration, check func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		self.Lock()
+		done := check()
+		self.Unlock()
+
+		if done {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(linkEventPollInterval)
+	}
+}
+
+// RequireLinkFaultedWithin waits up to the given duration for the link to be faulted and returns the fault event.
+// A link which faulted before the call and hasn't recovered since passes immediately.
+func (self *LinkStateChecker) RequireLinkFaultedWithin(linkId string, d time.Duration) LinkEvent {
+	var result *LinkEvent
+	faulted := self.waitFor(d, func() bool {
+		if link, found := self.links[linkId]; found && !link.Valid {
+			result = link.lastEvent(LinkEventFaulted)
+		}
+		return result != nil
+	})
+
+	self.req.True(faulted, "link %s not faulted within %v, history: %v", linkId, d, self.GetLinkHistory(linkId))
+	return *result
+}
+
+// RequireLinkRecovered requires that the link is currently valid, and was reported again after its last fault.
+// It returns the recovery event.
+func (self *LinkStateChecker) RequireLinkRecovered(linkId string) LinkEvent {
+	return self.RequireLinkRecoveredWithin(linkId, 0)
+}
+
+// RequireLinkRecoveredWithin waits up to the given duration for the link to recover from its last fault and returns
+// the recovery event
+func (self *LinkStateChecker) RequireLinkRecoveredWithin(linkId string, d time.Duration) LinkEvent {
+	var result *LinkEvent
+	recovered := self.waitFor(d, func() bool {
+		if link, found := self.links[linkId]; found && link.Valid {
+			if event := link.lastEvent(LinkEventFaulted, LinkEventRecovered); event != nil && event.Type == LinkEventRecovered {
+				result = event
+			}
+		}
+		return result != nil
+	})
+
+	self.req.True(recovered, "link %s not recovered within %v, history: %v", linkId, d, self.GetLinkHistory(linkId))
+	return *result
+}
+
+// RequireLinkEventOrder requires that the given event types occur in the link's history in the given order. Other
+// events may occur between them.
+func (self *LinkStateChecker) RequireLinkEventOrder(linkId string, eventTypes ...LinkEventType) {
+	history := self.GetLinkHistory(linkId)
+
+	next := 0
+	for _, event := range history {
+		if next < len(eventTypes) && event.Type == eventTypes[next] {
+			next++
+		}
+	}
+
+	self.req.Equal(len(eventTypes), next, "link %s events %v not found in order, history: %v", linkId, eventTypes, history)
 }
 
 func NewLinkChecker(assertions *require.Assertions) *LinkStateChecker {
 	checker := &LinkStateChecker{
-		errorC: make(chan error, 4),
-		links:  map[string]*TestLink{},
-		req:    assertions,
+		errorC:         make(chan error, 4),
+		links:          map[string]*TestLink{},
+		pendingStates:  map[string]*ctrl_pb.LinkConnState{},
+		dialOnly:       map[string]struct{}{},
+		ignoredTypes:   map[int32]string{},
+		expectedTypes:  map[int32]string{},
+		receivedCounts: map[int32]int{},
+		req:            assertions,
+	}
+	for contentType, name := range DefaultIgnoredContentTypes {
+		checker.ignoredTypes[contentType] = name
 	}
 	return checker
 }
 
+type noopHeartbeatCallback struct{}
+
+func (noopHeartbeatCallback) HeartbeatTx(int64)     {}
+func (noopHeartbeatCallback) HeartbeatRx(int64)     {}
+func (noopHeartbeatCallback) HeartbeatRespTx(int64) {}
+func (noopHeartbeatCallback) HeartbeatRespRx(int64) {}
+func (noopHeartbeatCallback) CheckHeartBeat()       {}
+
 func StartLinkTest(checker *LinkStateChecker, id string, uf channel.UnderlayFactory, assertions *require.Assertions) channel.Channel {
 	bindHandler := func(binding channel.Binding) error {
 		binding.AddReceiveHandlerF(channel.AnyContentType, checker.HandleOther)
@@ -154,6 +573,10 @@ func StartLinkTest(checker *LinkStateChecker, id string, uf channel.UnderlayFact
 		})
 		binding.AddReceiveHandlerF(int32(ctrl_pb.ContentType_RouterLinksType), checker.HandleLink)
 		binding.AddReceiveHandlerF(int32(ctrl_pb.ContentType_FaultType), checker.HandleFault)
+		binding.AddReceiveHandlerF(int32(ctrl_pb.ContentType_LinkState), checker.HandleLinkState)
+
+		// answer router heartbeats as the controller does, otherwise the router marks the controller unresponsive
+		channel.ConfigureHeartbeat(binding, time.Second, 100*time.Millisecond, noopHeartbeatCallback{})
 		return nil
 	}
 
@@ -0,0 +1,212 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/common/ctrl_msg"
+	"github.com/openziti/ziti/common/pb/ctrl_pb"
+	"github.com/openziti/ziti/common/pb/edge_ctrl_pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func reportTestLink(checker *LinkStateChecker, linkId string, stateIteration uint32, underlayTypes ...string) {
+	connState := &ctrl_pb.LinkConnState{StateIteration: stateIteration}
+	for _, underlayType := range underlayTypes {
+		connState.Conns = append(connState.Conns, &ctrl_pb.LinkConn{Type: underlayType})
+	}
+
+	checker.Lock()
+	defer checker.Unlock()
+	checker.applyRouterLinks("router-1", &ctrl_pb.RouterLinks{
+		Links: []*ctrl_pb.RouterLinks_RouterLink{
+			{
+				Id:           linkId,
+				DestRouterId: "router-2",
+				ConnState:    connState,
+			},
+		},
+	})
+}
+
+func faultTestLink(checker *LinkStateChecker, linkId string) {
+	checker.Lock()
+	defer checker.Unlock()
+	checker.applyFault(&ctrl_pb.Fault{
+		Subject: ctrl_pb.FaultSubject_LinkFault,
+		Id:      linkId,
+	})
+}
+
+func getEventTypes(history []LinkEvent) []LinkEventType {
+	var result []LinkEventType
+	for _, event := range history {
+		result = append(result, event.Type)
+	}
+	return result
+}
+
+func TestLinkCheckerRecordsHistory(t *testing.T) {
+	req := require.New(t)
+	checker := NewLinkChecker(req)
+
+	reportTestLink(checker, "l1", 1, "payload", "ack")
+	faultTestLink(checker, "l1")
+	reportTestLink(checker, "l1", 2, "payload")
+
+	history := checker.GetLinkHistory("l1")
+	req.Equal([]LinkEventType{
+		LinkEventReported,
+		LinkEventUnderlayUp,
+		LinkEventUnderlayUp,
+		LinkEventFaulted,
+		LinkEventUnderlayDown,
+		LinkEventUnderlayDown,
+		LinkEventRecovered,
+		LinkEventUnderlayUp,
+	}, getEventTypes(history))
+	req.Equal("payload", history[7].UnderlayType)
+
+	for i := 1; i < len(history); i++ {
+		req.False(history[i].Timestamp.Before(history[i-1].Timestamp))
+	}
+
+	req.Nil(checker.GetLinkHistory("l2"))
+
+	checker.RequireLinkEventOrder("l1", LinkEventReported, LinkEventFaulted, LinkEventRecovered)
+	recovery := checker.RequireLinkRecovered("l1")
+	req.Equal(LinkEventRecovered, recovery.Type)
+	checker.RequireNoErrors()
+}
+
+func TestLinkCheckerWaitsForFault(t *testing.T) {
+	req := require.New(t)
+	checker := NewLinkChecker(req)
+
+	reportTestLink(checker, "l1", 1, "single")
+
+	start := time.Now()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		faultTestLink(checker, "l1")
+	}()
+
+	fault := checker.RequireLinkFaultedWithin("l1", 2*time.Second)
+	req.Equal(LinkEventFaulted, fault.Type)
+	req.True(fault.Timestamp.After(start))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		reportTestLink(checker, "l1", 2, "single")
+	}()
+
+	recovery := checker.RequireLinkRecoveredWithin("l1", 2*time.Second)
+	req.True(recovery.Timestamp.After(fault.Timestamp))
+
+	checker.RequireLinkEventOrder("l1", LinkEventFaulted, LinkEventUnderlayDown, LinkEventRecovered, LinkEventUnderlayUp)
+	checker.RequireNoErrors()
+}
+
+func TestLinkCheckerContentTypes(t *testing.T) {
+	req := require.New(t)
+	checker := NewLinkChecker(req)
+
+	send := func(contentType int32) {
+		checker.HandleOther(channel.NewMessage(contentType, nil), nil)
+	}
+
+	send(channel.ContentTypeHeartbeat)
+	send(int32(edge_ctrl_pb.ContentType_ConnectEventsTypes))
+	req.Equal(1, checker.GetReceivedCount(int32(edge_ctrl_pb.ContentType_ConnectEventsTypes)))
+	checker.RequireNoErrors()
+
+	send(ctrl_msg.CircuitLimitExceededType)
+	req.Len(checker.errorC, 1)
+	<-checker.errorC
+
+	checker.IgnoreContentType(ctrl_msg.CircuitLimitExceededType, "circuit limit exceeded")
+	send(ctrl_msg.CircuitLimitExceededType)
+	checker.RequireNoErrors()
+
+	checker.UnignoreContentType(channel.ContentTypeHeartbeat)
+	send(channel.ContentTypeHeartbeat)
+	req.Len(checker.errorC, 1)
+	<-checker.errorC
+
+	checker.ExpectContentType(ctrl_msg.RouterUpdateStatusType, "router update status")
+	checker.ExpectContentType(ctrl_msg.CircuitClassificationType, "circuit classification")
+	send(ctrl_msg.RouterUpdateStatusType)
+	req.Equal(1, checker.GetReceivedCount(ctrl_msg.RouterUpdateStatusType))
+	checker.RequireNoErrors()
+
+	recorder := &assertionRecorder{}
+	checker.req = require.New(recorder)
+	checker.RequireExpectedContentTypes()
+	req.True(recorder.failed)
+
+	send(ctrl_msg.CircuitClassificationType)
+	recorder.failed = false
+	checker.RequireExpectedContentTypes()
+	req.False(recorder.failed)
+}
+
+// assertionRecorder is a require.TestingT which records failures, rather than failing the test
+type assertionRecorder struct {
+	failed bool
+}
+
+func (self *assertionRecorder) Errorf(string, ...interface{}) {
+	self.failed = true
+}
+
+func (self *assertionRecorder) FailNow() {
+	self.failed = true
+}
+
+func TestLinkCheckerRouterDisconnected(t *testing.T) {
+	req := require.New(t)
+	checker := NewLinkChecker(req)
+
+	reportTestLink(checker, "l1", 1, "payload", "ack")
+	checker.RouterDisconnected("router-3")
+	checker.RequireOneActiveLink()
+
+	checker.RouterDisconnected("router-2")
+	checker.RequireActiveLinkCount(0)
+	checker.RequireLinkFaultedWithin("l1", 0)
+	checker.RequireUnderlayFaultCount("l1", "payload", 1)
+	checker.RequireNoErrors()
+}
+
+func TestLinkCheckerEarlyLinkState(t *testing.T) {
+	req := require.New(t)
+	checker := NewLinkChecker(req)
+
+	sendLinkState := func(linkId string, stateIteration uint32, underlayTypes ...string) {
+		update := &ctrl_pb.LinkStateUpdate{
+			LinkId:    linkId,
+			ConnState: &ctrl_pb.LinkConnState{StateIteration: stateIteration},
+		}
+		for _, underlayType := range underlayTypes {
+			update.ConnState.Conns = append(update.ConnState.Conns, &ctrl_pb.LinkConn{Type: underlayType})
+		}
+		body, err := proto.Marshal(update)
+		req.NoError(err)
+		checker.HandleLinkState(channel.NewMessage(int32(ctrl_pb.ContentType_LinkState), body), nil)
+	}
+
+	// state which arrives before the link is reported is applied once it is
+	sendLinkState("l1", 2, "payload", "ack")
+	reportTestLink(checker, "l1", 1, "payload")
+	req.Equal([]string{"ack", "payload"}, checker.RequireOneActiveLink().ActiveUnderlayTypes())
+
+	// state older than the reported state is discarded
+	sendLinkState("l2", 1, "payload")
+	reportTestLink(checker, "l2", 2, "payload", "ack")
+	links := checker.RequireActiveLinkCount(2)
+	req.Equal([]string{"ack", "payload"}, links[1].ActiveUnderlayTypes())
+
+	checker.RequireNoErrors()
+}
@@ -0,0 +1,160 @@
+//go:build apitests
+
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Jeffail/gabs"
+	"github.com/openziti/ziti/common/eid"
+)
+
+func Test_TokenIntrospect(t *testing.T) {
+	ctx := NewTestContext(t)
+	defer ctx.Teardown()
+	ctx.StartServer()
+	ctx.RequireAdminManagementApiLogin()
+	ctx.CreateEnrollAndStartEdgeRouter()
+
+	identityRole := eid.New()
+	serviceRole := eid.New()
+	postureCheckRole := eid.New()
+	domain := "domain1"
+
+	identity, auth := ctx.AdminManagementSession.requireCreateIdentityWithUpdbEnrollment(eid.New(), eid.New(), false, identityRole)
+	identitySession, err := auth.AuthenticateClientApi(ctx)
+	ctx.Req.NoError(err)
+
+	service := ctx.AdminManagementSession.requireNewService(s(serviceRole), nil)
+	postureCheck := ctx.AdminManagementSession.requireNewPostureCheckDomain(s(domain), s(postureCheckRole))
+	ctx.AdminManagementSession.requireNewServicePolicyWithSemantic("Dial", "AllOf", s("#"+serviceRole), s("#"+identityRole), s("#"+postureCheckRole))
+	ctx.AdminManagementSession.requireNewEdgeRouterPolicy(s("#all"), s("#"+identityRole))
+	ctx.AdminManagementSession.requireNewServiceEdgeRouterPolicy(s("#all"), s("#"+serviceRole))
+
+	introspect := func(body map[string]string) (int, *gabs.Container) {
+		resp, err := ctx.AdminManagementSession.newAuthenticatedRequest().SetBody(body).Post("token-introspect")
+		ctx.Req.NoError(err)
+		ctx.logJson(resp.Body())
+		return resp.StatusCode(), ctx.parseJson(resp.Body())
+	}
+
+	requireIntrospect := func(body map[string]string) *gabs.Container {
+		status, result := introspect(body)
+		ctx.Req.Equal(http.StatusOK, status)
+		return result.Path("data")
+	}
+
+	t.Run("a token is required", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		status, _ := introspect(map[string]string{"token": " "})
+		ctx.Req.Equal(http.StatusBadRequest, status)
+	})
+
+	t.Run("unknown tokens are reported as invalid", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		result := requireIntrospect(map[string]string{"token": eid.New()})
+		ctx.Req.False(result.Path("valid").Data().(bool))
+		ctx.Req.Equal("legacy", result.Path("format").Data().(string))
+		ctx.Req.Equal("no api session or session found for token", result.Path("reason").Data().(string))
+		ctx.Req.False(result.Exists("identity"))
+	})
+
+	t.Run("api session tokens report the identity and failing posture", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		result := requireIntrospect(map[string]string{"token": "Bearer " + *identitySession.AuthResponse.Token})
+		ctx.Req.True(result.Path("valid").Data().(bool))
+		ctx.Req.Equal("legacy", result.Path("format").Data().(string))
+		ctx.Req.Equal("apiSession", result.Path("type").Data().(string))
+		ctx.Req.Equal(*identitySession.AuthResponse.ID, result.Path("apiSession.id").Data().(string))
+		ctx.Req.Equal(identity.Id, result.Path("identity.id").Data().(string))
+		ctx.Req.False(result.Path("identity.isAdmin").Data().(bool))
+		ctx.Req.False(result.Exists("posture", "domain"))
+
+		services, err := result.Path("services").Children()
+		ctx.Req.NoError(err)
+		ctx.Req.Len(services, 1)
+		ctx.Req.Equal(service.Id, services[0].Path("id").Data().(string))
+
+		access, err := services[0].Path("access").Children()
+		ctx.Req.NoError(err)
+		ctx.Req.Len(access, 1, "only dial access is granted")
+		ctx.Req.Equal("Dial", access[0].Path("type").Data().(string))
+		ctx.Req.False(access[0].Path("allowed").Data().(bool))
+		ctx.Req.True(access[0].Path("schedulePassed").Data().(bool))
+		ctx.Req.False(access[0].Path("posturePassed").Data().(bool))
+	})
+
+	identitySession.requireNewPostureResponseDomain(postureCheck.id, domain)
+
+	t.Run("api session tokens report passing posture once posture data is submitted", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		result := requireIntrospect(map[string]string{
+			"token":     *identitySession.AuthResponse.Token,
+			"serviceId": service.Id,
+		})
+		ctx.Req.True(result.Path("valid").Data().(bool))
+		ctx.Req.True(result.Path("posture.hasData").Data().(bool))
+		ctx.Req.Equal(domain, result.Path("posture.domain").Data().(string))
+
+		services, err := result.Path("services").Children()
+		ctx.Req.NoError(err)
+		ctx.Req.Len(services, 1)
+
+		access, err := services[0].Path("access").Children()
+		ctx.Req.NoError(err)
+		ctx.Req.Len(access, 1)
+		ctx.Req.True(access[0].Path("allowed").Data().(bool))
+	})
+
+	t.Run("session tokens report the session and its service", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		resp, err := identitySession.createNewSession(service.Id)
+		ctx.Req.NoError(err)
+		ctx.Req.Equal(http.StatusCreated, resp.StatusCode())
+		session := ctx.parseJson(resp.Body())
+		sessionId := session.Path("data.id").Data().(string)
+		sessionToken := session.Path("data.token").Data().(string)
+
+		result := requireIntrospect(map[string]string{"token": sessionToken})
+		ctx.Req.True(result.Path("valid").Data().(bool))
+		ctx.Req.Equal("session", result.Path("type").Data().(string))
+		ctx.Req.Equal(sessionId, result.Path("session.id").Data().(string))
+		ctx.Req.Equal("Dial", result.Path("session.type").Data().(string))
+		ctx.Req.Equal(*identitySession.AuthResponse.ID, result.Path("session.apiSessionId").Data().(string))
+		ctx.Req.Equal(service.Id, result.Path("session.serviceId").Data().(string))
+		ctx.Req.Equal(service.Name, result.Path("session.serviceName").Data().(string))
+		ctx.Req.Equal(identity.Id, result.Path("identity.id").Data().(string))
+
+		services, err := result.Path("services").Children()
+		ctx.Req.NoError(err)
+		ctx.Req.Len(services, 1)
+		ctx.Req.Equal(service.Id, services[0].Path("id").Data().(string))
+	})
+
+	t.Run("non-admins can't introspect tokens", func(t *testing.T) {
+		ctx.testContextChanged(t)
+		managementSession := auth.RequireAuthenticateManagementApi(ctx)
+		resp, err := managementSession.newAuthenticatedRequest().
+			SetBody(map[string]string{"token": *identitySession.AuthResponse.Token}).
+			Post("token-introspect")
+		ctx.Req.NoError(err)
+		ctx.Req.Equal(http.StatusUnauthorized, resp.StatusCode())
+	})
+}
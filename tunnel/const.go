@@ -7,6 +7,7 @@ const (
 	DestinationPortKey     = "dst_port"
 	SourceAddrKey          = "source_addr"
 
-	SourceIpKey   = "src_ip"
-	SourcePortKey = "src_port"
+	SourceIpKey       = "src_ip"
+	SourcePortKey     = "src_port"
+	SourceIdentityKey = "src_identity"
 )
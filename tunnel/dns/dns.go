@@ -1,4 +1,4 @@
-//go:build !linux
+//go:build !linux && !darwin
 
 /*
 	Copyright NetFoundry Inc.
@@ -18,9 +18,9 @@
 
 package dns
 
-// these functions are only implemented when OS=linux
+// these functions are only implemented when OS=linux or OS=darwin
 
-func NewDnsServer(addr string, upstreamConfig string, unanswered unansweredDisposition) (Resolver, error) {
+func NewDnsServer(addr string, upstreamConfig string, unanswered unansweredDisposition, splitDns bool) (Resolver, error) {
 	return nil, nil
 }
 
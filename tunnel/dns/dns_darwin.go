@@ -0,0 +1,32 @@
+//go:build darwin
+
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+// NewDnsServer only starts the dns server when split dns is enabled. Without it, there's no supported way to put the
+// tunneler's server in front of the system resolver.
+func NewDnsServer(addr string, upstreamConfig string, unanswered unansweredDisposition, splitDns bool) (Resolver, error) {
+	if !splitDns {
+		return nil, nil
+	}
+	return newDnsServer(addr, upstreamConfig, unanswered, splitDns)
+}
+
+func flushDnsCaches() {
+	// not needed, changes to scoped resolvers are picked up by the system without a flush
+}
@@ -19,82 +19,12 @@
 package dns
 
 import (
-	"fmt"
-	"github.com/miekg/dns"
 	"github.com/sirupsen/logrus"
-	"net"
-	"net/url"
 	"os/exec"
-	"sync"
-	"time"
 )
 
-func NewDnsServer(addr string, upstreamConfig string, unanswered unansweredDisposition) (Resolver, error) {
-	log.Infof("starting dns server...")
-	s := &dns.Server{
-		Addr: addr,
-		Net:  "udp",
-	}
-
-	names := make(map[string]net.IP)
-	r := &resolver{
-		server:     s,
-		names:      names,
-		ips:        make(map[string]string),
-		namesMtx:   sync.Mutex{},
-		domains:    make(map[string]*domainEntry),
-		domainsMtx: sync.Mutex{},
-		unanswered: unanswered,
-	}
-
-	// Configure upstream DNS server if provided
-	if upstreamConfig != "" {
-		upstreamURL, err := url.Parse(upstreamConfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse upstream DNS configuration '%s': %w", upstreamConfig, err)
-		}
-		
-		if upstreamURL.Scheme == "udp" || upstreamURL.Scheme == "tcp" {
-			r.upstreamServer = upstreamURL.Host
-			r.upstreamClient = &dns.Client{
-				Net:     upstreamURL.Scheme,
-				Timeout: 5 * time.Second,
-			}
-			log.Infof("configured upstream DNS server: %s over %s", r.upstreamServer, upstreamURL.Scheme)
-		} else {
-			return nil, fmt.Errorf("unsupported upstream DNS scheme '%s'. Only 'udp://' and 'tcp://' are supported", upstreamURL.Scheme)
-		}
-	}
-	s.Handler = r
-
-	errChan := make(chan error)
-	go func() {
-		errChan <- s.ListenAndServe()
-	}()
-
-	select {
-	case err := <-errChan:
-		if err != nil {
-			return nil, fmt.Errorf("dns server failed to start: %w", err)
-		} else {
-			return nil, fmt.Errorf("dns server stopped prematurely")
-		}
-	case <-time.After(2 * time.Second):
-		log.Infof("dns server running at %s", s.Addr)
-	}
-
-	const resolverConfigHelp = "ziti-tunnel runs an internal DNS server which must be first in the host's\n" +
-		"resolver configuration. On systems that use NetManager/dhclient, this can\n" +
-		"be achieved by adding the following to /etc/dhcp/dhclient.conf:\n" +
-		"\n" +
-		"    prepend domain-name-servers %s;\n\n"
-
-	err := r.testSystemResolver()
-	if err != nil {
-		log.Errorf("system resolver test failed: %s\n\n"+resolverConfigHelp, err, addr)
-	}
-
-	return r, nil
+func NewDnsServer(addr string, upstreamConfig string, unanswered unansweredDisposition, splitDns bool) (Resolver, error) {
+	return newDnsServer(addr, upstreamConfig, unanswered, splitDns)
 }
 
 func flushDnsCaches() {
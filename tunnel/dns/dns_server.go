@@ -0,0 +1,109 @@
+//go:build linux || darwin
+
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"github.com/miekg/dns"
+	"net"
+	"sync"
+	"time"
+)
+
+// newDnsServer starts the tunneler dns server. If splitDns is set, the operating system is configured to send queries
+// for intercepted domains to the server, instead of the server having to be the system resolver.
+func newDnsServer(addr string, upstreamConfig string, unanswered unansweredDisposition, splitDns bool) (Resolver, error) {
+	log.Infof("starting dns server...")
+
+	// the split dns target is set up first, as on linux it provides the address the server listens on
+	var split splitDnsTarget
+	if splitDns {
+		var err error
+		if split, err = newSplitDnsTarget(addr); err != nil {
+			return nil, fmt.Errorf("unable to configure split dns: %w", err)
+		}
+	}
+
+	s := &dns.Server{
+		Addr: addr,
+		Net:  "udp",
+	}
+
+	names := make(map[string]net.IP)
+	r := &resolver{
+		server:     s,
+		names:      names,
+		ips:        make(map[string]string),
+		namesMtx:   sync.Mutex{},
+		domains:    make(map[string]*domainEntry),
+		domainsMtx: sync.Mutex{},
+		unanswered: unanswered,
+	}
+
+	// Configure upstream DNS servers if provided
+	if upstreamConfig != "" {
+		u, err := newUpstream(upstreamConfig)
+		if err != nil {
+			return nil, err
+		}
+		r.upstream = u
+		log.Infof("configured upstream DNS server: %s", u)
+	}
+	s.Handler = r
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- s.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		if split != nil {
+			if cleanupErr := split.Cleanup(); cleanupErr != nil {
+				log.WithError(cleanupErr).Error("failed to clean up split dns configuration")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dns server failed to start: %w", err)
+		} else {
+			return nil, fmt.Errorf("dns server stopped prematurely")
+		}
+	case <-time.After(2 * time.Second):
+		log.Infof("dns server running at %s", s.Addr)
+	}
+
+	if split != nil {
+		r.split = newSplitDnsUpdater(split, r.routingDomains)
+		log.Infof("split dns enabled, only queries for intercepted domains will be sent to %s", addr)
+		return r, nil
+	}
+
+	const resolverConfigHelp = "ziti-tunnel runs an internal DNS server which must be first in the host's\n" +
+		"resolver configuration. On systems that use NetManager/dhclient, this can\n" +
+		"be achieved by adding the following to /etc/dhcp/dhclient.conf:\n" +
+		"\n" +
+		"    prepend domain-name-servers %s;\n\n"
+
+	err := r.testSystemResolver()
+	if err != nil {
+		log.Errorf("system resolver test failed: %s\n\n"+resolverConfigHelp, err, addr)
+	}
+
+	return r, nil
+}
@@ -34,6 +34,14 @@ func (d dummy) RemoveHostname(_ string) net.IP {
 func (d dummy) RemoveDomain(_ string) {
 }
 
+func (d dummy) AddDomainPattern(_ string, _ func(string) (net.IP, error)) error {
+	pfxlog.Logger().Warnf("dummy resolver does not store hostname/ip mappings")
+	return nil
+}
+
+func (d dummy) RemoveDomainPattern(_ string) {
+}
+
 func (d dummy) Cleanup() error {
 	return nil
 }
@@ -56,6 +56,12 @@ func (h *hostFile) AddDomain(name string, _ func(string) (net.IP, error)) error
 
 func (h *hostFile) RemoveDomain(string) {}
 
+func (h *hostFile) AddDomainPattern(pattern string, _ func(string) (net.IP, error)) error {
+	return fmt.Errorf("cannot add domain pattern[%s] to hostfile resolver", pattern)
+}
+
+func (h *hostFile) RemoveDomainPattern(string) {}
+
 func (h *hostFile) Lookup(_ net.IP) (string, error) {
 	return "", fmt.Errorf("not implemented")
 }
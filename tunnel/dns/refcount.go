@@ -33,6 +33,14 @@ func (self *RefCountingResolver) RemoveDomain(name string) {
 	self.wrapped.RemoveDomain(name)
 }
 
+func (self *RefCountingResolver) AddDomainPattern(pattern string, cb func(string) (net.IP, error)) error {
+	return self.wrapped.AddDomainPattern(pattern, cb)
+}
+
+func (self *RefCountingResolver) RemoveDomainPattern(pattern string) {
+	self.wrapped.RemoveDomainPattern(pattern)
+}
+
 func (self *RefCountingResolver) AddHostname(s string, ip net.IP) error {
 	err := self.wrapped.AddHostname(s, ip)
 	if err != nil {
@@ -16,7 +16,10 @@
 
 package dns
 
-import "net"
+import (
+	"net"
+	"regexp"
+)
 
 type Resolver interface {
 	AddHostname(string, net.IP) error
@@ -25,6 +28,8 @@ type Resolver interface {
 	LookupIP(string) (net.IP, bool)
 	RemoveHostname(string) net.IP
 	RemoveDomain(string)
+	AddDomainPattern(string, func(string) (net.IP, error)) error
+	RemoveDomainPattern(string)
 	Cleanup() error
 }
 
@@ -32,3 +37,9 @@ type domainEntry struct {
 	name  string
 	getIP func(string) (net.IP, error)
 }
+
+// patternEntry matches queried hostnames, without the trailing dot, against a regular expression
+type patternEntry struct {
+	domainEntry
+	pattern *regexp.Regexp
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"net"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
 )
@@ -49,10 +50,11 @@ type resolver struct {
 	ips            map[string]string
 	namesMtx       sync.Mutex
 	domains        map[string]*domainEntry
+	patterns       []*patternEntry
 	domainsMtx     sync.Mutex
-	upstreamServer string
-	upstreamClient *dns.Client
+	upstream       upstream
 	unanswered     unansweredDisposition
+	split          *splitDnsUpdater
 }
 
 func parseUnansweredDisposition(raw string) (unansweredDisposition, error) {
@@ -67,7 +69,9 @@ func parseUnansweredDisposition(raw string) (unansweredDisposition, error) {
 	return unansweredRefused, fmt.Errorf("invalid unanswerable response '%s': must be one of timeout, servfail, or refused", raw)
 }
 
-func NewResolver(config string, upstreamConfig string, unansweredConfig string) (Resolver, error) {
+// NewResolver creates the resolver described by config. If splitDns is set, the system resolver is configured to send
+// queries for intercepted names to the tunneler, rather than the tunneler having to be the system's only resolver.
+func NewResolver(config string, upstreamConfig string, unansweredConfig string, splitDns bool) (Resolver, error) {
 	flushDnsCaches()
 	if config == "" {
 		return nil, nil
@@ -108,9 +112,12 @@ func NewResolver(config string, upstreamConfig string, unansweredConfig string)
 
 	switch resolverURL.Scheme {
 	case "", "file":
+		if splitDns {
+			return nil, fmt.Errorf("split dns requires a 'udp://' resolver, not '%s'", config)
+		}
 		return NewRefCountingResolver(NewHostFile(resolverURL.Path)), nil
 	case "udp":
-		dnsResolver, err := NewDnsServer(resolverURL.Host, upstreamConfig, unanswered)
+		dnsResolver, err := NewDnsServer(resolverURL.Host, upstreamConfig, unanswered, splitDns)
 		if err != nil {
 			return nil, err
 		}
@@ -183,17 +190,31 @@ func (r *resolver) getAddress(name string) (net.IP, error) {
 		}
 	}
 
+	hostname := strings.TrimSuffix(strings.ToLower(name), ".")
+	for _, pe := range r.patterns {
+		if pe.pattern.MatchString(hostname) {
+			name = name[:len(name)-1]
+			ip, err := pe.getIP(name)
+			if err != nil {
+				return nil, err
+			}
+			log.Debugf("assigned %v => %v, matching pattern %v", name, ip, pe.name)
+			_ = r.AddHostname(name, ip) // this resolver impl never returns an error
+			return ip, err
+		}
+	}
+
 	return nil, errors.New("not found")
 }
 
 func (r *resolver) queryUpstream(query *dns.Msg) (*dns.Msg, error) {
-	if r.upstreamServer == "" || r.upstreamClient == nil {
+	if r.upstream == nil {
 		return nil, errors.New("no upstream server configured")
 	}
 
-	log.Debugf("forwarding query to upstream server %s: %s", r.upstreamServer, query.Question[0].Name)
-	
-	response, _, err := r.upstreamClient.Exchange(query, r.upstreamServer)
+	log.Debugf("forwarding query to upstream server %s: %s", r.upstream, query.Question[0].Name)
+
+	response, err := r.upstream.Exchange(query)
 	if err != nil {
 		log.Warnf("upstream query failed: %v", err)
 		return nil, err
@@ -207,7 +228,7 @@ func (r *resolver) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
 	log.Tracef("received:\n%s\n", query.String())
 	msg := dns.Msg{}
 	msg.SetReply(query)
-	msg.RecursionAvailable = r.upstreamServer != ""
+	msg.RecursionAvailable = r.upstream != nil
 	q := query.Question[0]
 	switch q.Qtype {
 	case dns.TypeA:
@@ -228,7 +249,7 @@ func (r *resolver) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
 			}
 			return
 		}
-		if r.upstreamServer != "" {
+		if r.upstream != nil {
 			if upstreamResp, err := r.queryUpstream(query); err == nil {
 				err := w.WriteMsg(upstreamResp)
 				if err != nil {
@@ -251,7 +272,7 @@ func (r *resolver) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
 			return
 		}
 
-		if r.upstreamServer != "" {
+		if r.upstream != nil {
 			if upstreamResp, err := r.queryUpstream(query); err == nil {
 				if err := w.WriteMsg(upstreamResp); err != nil {
 					log.Errorf("write failed: %s", err)
@@ -264,6 +285,16 @@ func (r *resolver) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
 		return
 	}
 
+	// other query types are never intercepted, so they're answered upstream, if possible
+	if r.upstream != nil {
+		if upstreamResp, err := r.queryUpstream(query); err == nil {
+			if err := w.WriteMsg(upstreamResp); err != nil {
+				log.Errorf("write failed: %s", err)
+			}
+			return
+		}
+	}
+
 	r.handleUnanswerable(w, query)
 }
 
@@ -276,7 +307,7 @@ func (r *resolver) handleUnanswerable(w dns.ResponseWriter, query *dns.Msg) {
 		log.Tracef("unanswerable query for %s: responding with SERVFAIL", query.Question[0].Name)
 		resp := dns.Msg{}
 		resp.SetReply(query)
-		resp.RecursionAvailable = r.upstreamServer != ""
+		resp.RecursionAvailable = r.upstream != nil
 		resp.Rcode = dns.RcodeServerFailure
 		if err := w.WriteMsg(&resp); err != nil {
 			log.Errorf("write failed: %s", err)
@@ -285,7 +316,7 @@ func (r *resolver) handleUnanswerable(w dns.ResponseWriter, query *dns.Msg) {
 		log.Tracef("unanswerable query for %s: responding with REFUSED", query.Question[0].Name)
 		resp := dns.Msg{}
 		resp.SetReply(query)
-		resp.RecursionAvailable = r.upstreamServer != ""
+		resp.RecursionAvailable = r.upstream != nil
 		resp.Rcode = dns.RcodeRefused
 		if err := w.WriteMsg(&resp); err != nil {
 			log.Errorf("write failed: %s", err)
@@ -303,6 +334,7 @@ func (r *resolver) AddDomain(name string, ipCB func(string) (net.IP, error)) err
 		getIP: ipCB,
 	}
 
+	defer r.split.schedule()
 	r.domainsMtx.Lock()
 	defer r.domainsMtx.Unlock()
 	if _, found := r.domains[domainSfx]; found {
@@ -319,13 +351,58 @@ func (r *resolver) RemoveDomain(name string) {
 		return
 	}
 	domainSfx := name[1:] + "."
+	defer r.split.schedule()
 	r.domainsMtx.Lock()
 	defer r.domainsMtx.Unlock()
 	log.Infof("removing domain %s from resolver", domainSfx)
 	delete(r.domains, domainSfx)
 }
 
+func (r *resolver) AddDomainPattern(pattern string, ipCB func(string) (net.IP, error)) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid domain pattern '%s': %w", pattern, err)
+	}
+	entry := &patternEntry{
+		domainEntry: domainEntry{
+			name:  pattern,
+			getIP: ipCB,
+		},
+		pattern: re,
+	}
+
+	if r.split != nil {
+		log.Warnf("domain pattern '%s' can't be expressed as a split dns domain, names matching it won't be sent to the tunneler", pattern)
+	}
+
+	r.domainsMtx.Lock()
+	defer r.domainsMtx.Unlock()
+	for idx, existing := range r.patterns {
+		if existing.name == pattern {
+			log.Warnf("domain pattern[%v] is overwriting registered domain pattern", pattern)
+			r.patterns[idx] = entry
+			return nil
+		}
+	}
+	r.patterns = append(r.patterns, entry)
+
+	return nil
+}
+
+func (r *resolver) RemoveDomainPattern(pattern string) {
+	r.domainsMtx.Lock()
+	defer r.domainsMtx.Unlock()
+	for idx, existing := range r.patterns {
+		if existing.name == pattern {
+			log.Infof("removing domain pattern %s from resolver", pattern)
+			r.patterns = append(r.patterns[:idx], r.patterns[idx+1:]...)
+			return
+		}
+	}
+}
+
 func (r *resolver) AddHostname(hostname string, ip net.IP) error {
+	defer r.split.schedule()
 	r.namesMtx.Lock()
 	defer r.namesMtx.Unlock()
 
@@ -355,6 +432,7 @@ func (r *resolver) Lookup(ip net.IP) (string, error) {
 }
 
 func (r *resolver) RemoveHostname(hostname string) net.IP {
+	defer r.split.schedule()
 	r.namesMtx.Lock()
 	defer r.namesMtx.Unlock()
 
@@ -372,5 +450,8 @@ func (r *resolver) RemoveHostname(hostname string) net.IP {
 
 func (r *resolver) Cleanup() error {
 	log.Debug("shutting down")
+	if err := r.split.cleanup(); err != nil {
+		log.WithError(err).Error("failed to remove split dns configuration")
+	}
 	return r.server.Shutdown()
 }
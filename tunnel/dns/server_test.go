@@ -0,0 +1,61 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolverDomainPatterns(t *testing.T) {
+	req := require.New(t)
+
+	r := &resolver{
+		names:   map[string]net.IP{},
+		ips:     map[string]string{},
+		domains: map[string]*domainEntry{},
+	}
+
+	var requested []string
+	ipCB := func(host string) (net.IP, error) {
+		requested = append(requested, host)
+		return net.IPv4(100, 64, 0, byte(len(requested))), nil
+	}
+
+	req.Error(r.AddDomainPattern("(", ipCB))
+	req.NoError(r.AddDomainPattern(`(?i)^(?:[a-z]+-\d+\.internal\.corp)$`, ipCB))
+
+	ip, err := r.getAddress("web-01.Internal.Corp.")
+	req.NoError(err)
+	req.Equal(net.IPv4(100, 64, 0, 1), ip)
+	req.Equal([]string{"web-01.Internal.Corp"}, requested)
+
+	// second lookup is served from the names map
+	ip, err = r.getAddress("web-01.internal.corp.")
+	req.NoError(err)
+	req.Equal(net.IPv4(100, 64, 0, 1), ip)
+	req.Len(requested, 1)
+
+	_, err = r.getAddress("web.internal.corp.")
+	req.Error(err)
+
+	r.RemoveDomainPattern(`(?i)^(?:[a-z]+-\d+\.internal\.corp)$`)
+	_, err = r.getAddress("db-02.internal.corp.")
+	req.Error(err)
+}
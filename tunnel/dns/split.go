@@ -0,0 +1,284 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// splitDnsUpdateDelay batches domain changes, so a burst of service updates results in a single update of the system
+// dns configuration
+const splitDnsUpdateDelay = 250 * time.Millisecond
+
+// splitDnsTarget routes queries for a set of domains to the tunneler dns server, leaving the system resolver in
+// place for everything else. Each domain also covers its subdomains.
+type splitDnsTarget interface {
+	SetDomains(domains []string) error
+	Cleanup() error
+}
+
+// splitDnsUpdater keeps the domains routed to the tunneler in sync with the intercepted hostnames and wildcard domains
+type splitDnsUpdater struct {
+	target   splitDnsTarget
+	domainsF func() []string
+
+	lock      sync.Mutex
+	timer     *time.Timer
+	closed    bool
+	applyLock sync.Mutex
+	applied   []string
+}
+
+func newSplitDnsUpdater(target splitDnsTarget, domainsF func() []string) *splitDnsUpdater {
+	return &splitDnsUpdater{
+		target:   target,
+		domainsF: domainsF,
+	}
+}
+
+// schedule queues an update of the routed domains. It's safe to call on a nil updater, which is used when split dns
+// isn't enabled.
+func (self *splitDnsUpdater) schedule() {
+	if self == nil {
+		return
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.timer == nil && !self.closed {
+		self.timer = time.AfterFunc(splitDnsUpdateDelay, self.apply)
+	}
+}
+
+func (self *splitDnsUpdater) apply() {
+	self.lock.Lock()
+	self.timer = nil
+	closed := self.closed
+	self.lock.Unlock()
+
+	if closed {
+		return
+	}
+
+	self.applyLock.Lock()
+	defer self.applyLock.Unlock()
+
+	domains := self.domainsF()
+	if slices.Equal(domains, self.applied) {
+		return
+	}
+
+	if err := self.target.SetDomains(domains); err != nil {
+		log.WithError(err).Errorf("failed to update split dns domains to %v", domains)
+		return
+	}
+
+	log.Infof("split dns now routing %d domains to the tunneler: %v", len(domains), domains)
+	self.applied = domains
+}
+
+func (self *splitDnsUpdater) cleanup() error {
+	if self == nil {
+		return nil
+	}
+
+	self.lock.Lock()
+	self.closed = true
+	if self.timer != nil {
+		self.timer.Stop()
+		self.timer = nil
+	}
+	self.lock.Unlock()
+
+	self.applyLock.Lock()
+	defer self.applyLock.Unlock()
+	return self.target.Cleanup()
+}
+
+// routingDomains returns the domains which need to be routed to the tunneler, in sorted order. Wildcard domains are
+// routed as is. Hostnames are routed individually, unless they fall under a routed wildcard domain.
+func (r *resolver) routingDomains() []string {
+	set := map[string]struct{}{}
+
+	r.domainsMtx.Lock()
+	for domainSfx := range r.domains {
+		set[strings.ToLower(strings.Trim(domainSfx, "."))] = struct{}{}
+	}
+	r.domainsMtx.Unlock()
+
+	var hostnames []string
+	r.namesMtx.Lock()
+	for name := range r.names {
+		hostnames = append(hostnames, strings.TrimSuffix(name, "."))
+	}
+	r.namesMtx.Unlock()
+
+	for _, hostname := range hostnames {
+		if !isCoveredByDomain(hostname, set) {
+			set[hostname] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(set))
+	for domain := range set {
+		if domain != "" {
+			result = append(result, domain)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func isCoveredByDomain(hostname string, domains map[string]struct{}) bool {
+	for {
+		idx := strings.IndexByte(hostname, '.')
+		if idx < 0 {
+			return false
+		}
+		hostname = hostname[idx+1:]
+		if _, found := domains[hostname]; found {
+			return true
+		}
+	}
+}
+
+// scopedResolverMarker identifies scoped resolver files written by the tunneler, so files written by other
+// software, such as vpn clients, are never changed or removed
+const scopedResolverMarker = "# managed by ziti-tunnel"
+
+// scopedResolverTarget writes one resolver file per domain, in the resolver(5) format read by macOS. Each file sends
+// queries for its domain to the tunneler dns server.
+type scopedResolverTarget struct {
+	dir     string
+	content []byte
+	current map[string]struct{}
+}
+
+func newScopedResolverTarget(dir string, addr string) (*scopedResolverTarget, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dns server address '%s': %w", addr, err)
+	}
+	if net.ParseIP(host) == nil {
+		return nil, fmt.Errorf("invalid dns server address '%s', split dns requires an ip address", addr)
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create resolver directory %s: %w", dir, err)
+	}
+
+	result := &scopedResolverTarget{
+		dir:     dir,
+		content: []byte(fmt.Sprintf("%s\nnameserver %s\nport %s\n", scopedResolverMarker, host, port)),
+		current: map[string]struct{}{},
+	}
+
+	// remove files left behind if the tunneler didn't shut down cleanly
+	if err = result.removeStale(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (self *scopedResolverTarget) SetDomains(domains []string) error {
+	wanted := map[string]struct{}{}
+	var errs []string
+
+	for _, domain := range domains {
+		wanted[domain] = struct{}{}
+		if _, found := self.current[domain]; found {
+			continue
+		}
+
+		if strings.ContainsAny(domain, `/\`) || strings.HasPrefix(domain, ".") {
+			log.Warnf("domain %s can't be used as a scoped resolver, not routing it to the tunneler", domain)
+			continue
+		}
+
+		path := filepath.Join(self.dir, domain)
+		if existing, err := os.ReadFile(path); err == nil && !isScopedResolverFile(existing) {
+			log.Warnf("scoped resolver %s is managed by other software, not routing %s to the tunneler", path, domain)
+			continue
+		}
+
+		if err := os.WriteFile(path, self.content, 0644); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		self.current[domain] = struct{}{}
+	}
+
+	for domain := range self.current {
+		if _, found := wanted[domain]; !found {
+			if err := self.remove(domain); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to update scoped resolvers: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (self *scopedResolverTarget) Cleanup() error {
+	return self.SetDomains(nil)
+}
+
+func (self *scopedResolverTarget) remove(domain string) error {
+	if err := os.Remove(filepath.Join(self.dir, domain)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(self.current, domain)
+	return nil
+}
+
+func (self *scopedResolverTarget) removeStale() error {
+	entries, err := os.ReadDir(self.dir)
+	if err != nil {
+		return fmt.Errorf("unable to read resolver directory %s: %w", self.dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(self.dir, entry.Name())
+		if content, err := os.ReadFile(path); err == nil && isScopedResolverFile(content) {
+			if err = os.Remove(path); err != nil {
+				return fmt.Errorf("unable to remove stale scoped resolver %s: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isScopedResolverFile(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(scopedResolverMarker+"\n"))
+}
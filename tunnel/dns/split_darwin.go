@@ -0,0 +1,26 @@
+//go:build darwin
+
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+// scopedResolverDir is read by macOS for per-domain resolver configuration. See resolver(5).
+const scopedResolverDir = "/etc/resolver"
+
+func newSplitDnsTarget(addr string) (splitDnsTarget, error) {
+	return newScopedResolverTarget(scopedResolverDir, addr)
+}
@@ -0,0 +1,146 @@
+//go:build linux
+
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// splitDnsLinkName is the dummy interface which carries the tunneler's dns configuration in systemd-resolved.
+// resolved only accepts per-domain routing for a network link, and ignores the loopback interface.
+const splitDnsLinkName = "ziti-dns"
+
+func newSplitDnsTarget(addr string) (splitDnsTarget, error) {
+	return newResolvedTarget(addr)
+}
+
+// resolvedTarget routes domains to the tunneler through systemd-resolved. A dummy link is created holding the dns
+// server's address. The link is given the tunneler's dns server and the domains as routing-only domains, and is
+// never used as the default route, so other lookups are handled as before.
+type resolvedTarget struct {
+	resolvectlPath string
+	server         string
+}
+
+func newResolvedTarget(addr string) (*resolvedTarget, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dns server address '%s': %w", addr, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.To4() == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return nil, fmt.Errorf("split dns with systemd-resolved requires the resolver to listen on a non-loopback "+
+			"ipv4 address, such as udp://169.254.53.1:53, not '%s'", addr)
+	}
+
+	resolvectl, err := exec.LookPath("resolvectl")
+	if err != nil {
+		return nil, fmt.Errorf("resolvectl not found, split dns requires systemd-resolved: %w", err)
+	}
+
+	result := &resolvedTarget{
+		resolvectlPath: resolvectl,
+		server:         host,
+	}
+	if port != "53" {
+		result.server = host + ":" + port
+	}
+
+	if err = result.createLink(host); err != nil {
+		return nil, err
+	}
+
+	if err = result.resolvectl("dns", splitDnsLinkName, result.server); err != nil {
+		_ = result.deleteLink()
+		return nil, err
+	}
+
+	if err = result.resolvectl("default-route", splitDnsLinkName, "false"); err != nil {
+		_ = result.Cleanup()
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (self *resolvedTarget) SetDomains(domains []string) error {
+	args := []string{"domain", splitDnsLinkName}
+	if len(domains) == 0 {
+		args = append(args, "")
+	}
+	for _, domain := range domains {
+		// the ~ prefix makes it a routing-only domain, which isn't used as a search domain
+		args = append(args, "~"+domain)
+	}
+	return self.resolvectl(args...)
+}
+
+func (self *resolvedTarget) Cleanup() error {
+	var errs []string
+	if err := self.resolvectl("revert", splitDnsLinkName); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := self.deleteLink(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove split dns configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (self *resolvedTarget) createLink(host string) error {
+	// a link left behind by a previous run is reused
+	if err := exec.Command("ip", "link", "show", splitDnsLinkName).Run(); err != nil {
+		if err = runCommand("ip", "link", "add", splitDnsLinkName, "type", "dummy"); err != nil {
+			return err
+		}
+	}
+
+	if err := runCommand("ip", "addr", "replace", host+"/32", "dev", splitDnsLinkName); err != nil {
+		_ = self.deleteLink()
+		return err
+	}
+
+	if err := runCommand("ip", "link", "set", splitDnsLinkName, "up"); err != nil {
+		_ = self.deleteLink()
+		return err
+	}
+	return nil
+}
+
+func (self *resolvedTarget) deleteLink() error {
+	return runCommand("ip", "link", "delete", splitDnsLinkName)
+}
+
+func (self *resolvedTarget) resolvectl(args ...string) error {
+	return runCommand(self.resolvectlPath, args...)
+}
+
+func runCommand(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("'%s %s' failed: %w (%s)", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testSplitDnsTarget struct {
+	sync.Mutex
+	updates [][]string
+	cleaned bool
+}
+
+func (self *testSplitDnsTarget) SetDomains(domains []string) error {
+	self.Lock()
+	defer self.Unlock()
+	self.updates = append(self.updates, domains)
+	return nil
+}
+
+func (self *testSplitDnsTarget) Cleanup() error {
+	self.Lock()
+	defer self.Unlock()
+	self.cleaned = true
+	return nil
+}
+
+func (self *testSplitDnsTarget) getUpdates() [][]string {
+	self.Lock()
+	defer self.Unlock()
+	return append([][]string(nil), self.updates...)
+}
+
+func TestSplitDnsRoutingDomains(t *testing.T) {
+	req := require.New(t)
+
+	target := &testSplitDnsTarget{}
+	r := &resolver{
+		names:   map[string]net.IP{},
+		ips:     map[string]string{},
+		domains: map[string]*domainEntry{},
+	}
+	r.split = newSplitDnsUpdater(target, r.routingDomains)
+
+	ipCB := func(string) (net.IP, error) {
+		return net.IPv4(100, 64, 0, 1), nil
+	}
+
+	req.NoError(r.AddDomain("*.corp.example.com", ipCB))
+	req.NoError(r.AddHostname("db.corp.example.com", net.IPv4(100, 64, 0, 2)))
+	req.NoError(r.AddHostname("Wiki.Internal", net.IPv4(100, 64, 0, 3)))
+
+	// changes are batched into a single update
+	req.Eventually(func() bool {
+		return len(target.getUpdates()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	req.Equal([]string{"corp.example.com", "wiki.internal"}, target.getUpdates()[0])
+
+	// names resolved under a routed domain don't change the routed domains
+	_, err := r.getAddress("app.corp.example.com.")
+	req.NoError(err)
+	time.Sleep(2 * splitDnsUpdateDelay)
+	req.Len(target.getUpdates(), 1)
+
+	r.RemoveDomain("*.corp.example.com")
+	req.Eventually(func() bool {
+		return len(target.getUpdates()) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	req.Equal([]string{"app.corp.example.com", "db.corp.example.com", "wiki.internal"}, target.getUpdates()[1])
+
+	req.NoError(r.split.cleanup())
+	req.True(target.cleaned)
+
+	r.RemoveHostname("wiki.internal")
+	time.Sleep(2 * splitDnsUpdateDelay)
+	req.Len(target.getUpdates(), 2)
+}
+
+func TestScopedResolverTarget(t *testing.T) {
+	req := require.New(t)
+	dir := t.TempDir()
+
+	foreign := []byte("nameserver 10.0.0.53\n")
+	req.NoError(os.WriteFile(filepath.Join(dir, "vpn.example.com"), foreign, 0644))
+	req.NoError(os.WriteFile(filepath.Join(dir, "stale.example.com"), []byte(scopedResolverMarker+"\nnameserver 127.0.0.1\n"), 0644))
+
+	_, err := newScopedResolverTarget(dir, "localhost:53")
+	req.Error(err)
+
+	target, err := newScopedResolverTarget(dir, "127.0.0.1:5353")
+	req.NoError(err)
+
+	// leftovers from a previous run are removed
+	_, err = os.Stat(filepath.Join(dir, "stale.example.com"))
+	req.True(os.IsNotExist(err))
+
+	req.NoError(target.SetDomains([]string{"corp.example.com", "vpn.example.com", "wiki.internal"}))
+
+	content, err := os.ReadFile(filepath.Join(dir, "corp.example.com"))
+	req.NoError(err)
+	req.Equal(scopedResolverMarker+"\nnameserver 127.0.0.1\nport 5353\n", string(content))
+
+	// files written by other software are left alone
+	content, err = os.ReadFile(filepath.Join(dir, "vpn.example.com"))
+	req.NoError(err)
+	req.Equal(foreign, content)
+
+	req.NoError(target.SetDomains([]string{"wiki.internal"}))
+	_, err = os.Stat(filepath.Join(dir, "corp.example.com"))
+	req.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "wiki.internal"))
+	req.NoError(err)
+
+	req.NoError(target.Cleanup())
+	entries, err := os.ReadDir(dir)
+	req.NoError(err)
+	req.Len(entries, 1)
+	req.Equal("vpn.example.com", entries[0].Name())
+}
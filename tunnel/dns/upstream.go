@@ -0,0 +1,243 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	upstreamTimeout    = 5 * time.Second
+	dnsOverTlsPort     = "853"
+	dnsMessageMimeType = "application/dns-message"
+	maxDnsMessageSize  = 65535
+)
+
+// upstream forwards queries which the tunneler can't answer itself
+type upstream interface {
+	Exchange(query *dns.Msg) (*dns.Msg, error)
+	String() string
+}
+
+// newUpstream parses a comma separated list of upstream URLs. Supported schemes are udp://, tcp://, tls:// for DNS
+// over TLS and https:// for DNS over HTTPS. When more than one upstream is given, they're tried in order until one
+// answers.
+func newUpstream(config string) (upstream, error) {
+	var upstreams multiUpstream
+	for _, entry := range strings.Split(config, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		u, err := parseUpstream(entry)
+		if err != nil {
+			return nil, err
+		}
+		upstreams = append(upstreams, u)
+	}
+
+	if len(upstreams) == 0 {
+		return nil, fmt.Errorf("no upstream DNS servers found in '%s'", config)
+	}
+
+	if len(upstreams) == 1 {
+		return upstreams[0], nil
+	}
+	return upstreams, nil
+}
+
+func parseUpstream(config string) (upstream, error) {
+	upstreamURL, err := url.Parse(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse upstream DNS configuration '%s': %w", config, err)
+	}
+
+	switch upstreamURL.Scheme {
+	case "udp", "tcp":
+		return &dnsUpstream{
+			addr: upstreamURL.Host,
+			client: &dns.Client{
+				Net:     upstreamURL.Scheme,
+				Timeout: upstreamTimeout,
+			},
+		}, nil
+	case "tls":
+		addr := upstreamURL.Host
+		if upstreamURL.Port() == "" {
+			addr = net.JoinHostPort(upstreamURL.Hostname(), dnsOverTlsPort)
+		}
+		serverName := upstreamURL.Query().Get("serverName")
+		if serverName == "" {
+			serverName = upstreamURL.Hostname()
+		}
+		return &dnsUpstream{
+			addr: addr,
+			client: &dns.Client{
+				Net:     "tcp-tls",
+				Timeout: upstreamTimeout,
+				TLSConfig: &tls.Config{
+					ServerName: serverName,
+					MinVersion: tls.VersionTLS12,
+				},
+			},
+		}, nil
+	case "https":
+		return newDohUpstream(upstreamURL)
+	}
+
+	return nil, fmt.Errorf("unsupported upstream DNS scheme '%s'. Only 'udp://', 'tcp://', 'tls://' and 'https://' are supported", upstreamURL.Scheme)
+}
+
+// dnsUpstream handles plain DNS over UDP or TCP, as well as DNS over TLS
+type dnsUpstream struct {
+	addr   string
+	client *dns.Client
+}
+
+func (self *dnsUpstream) Exchange(query *dns.Msg) (*dns.Msg, error) {
+	response, _, err := self.client.Exchange(query, self.addr)
+	return response, err
+}
+
+func (self *dnsUpstream) String() string {
+	return fmt.Sprintf("%s over %s", self.addr, self.client.Net)
+}
+
+// dohUpstream implements DNS over HTTPS, as described in RFC 8484
+type dohUpstream struct {
+	url    string
+	client *http.Client
+}
+
+// newDohUpstream creates a DNS over HTTPS upstream. Since the upstream host name would otherwise be resolved by the
+// system resolver, which is likely the tunneler itself, the address to connect to may be given with the bootstrap
+// query parameter. For example: https://dns.quad9.net/dns-query?bootstrap=9.9.9.9
+func newDohUpstream(upstreamURL *url.URL) (*dohUpstream, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	query := upstreamURL.Query()
+	if bootstrap := query.Get("bootstrap"); bootstrap != "" {
+		if net.ParseIP(bootstrap) == nil {
+			return nil, fmt.Errorf("invalid bootstrap address '%s' for upstream DNS server %s, must be an IP address", bootstrap, upstreamURL.Host)
+		}
+
+		port := upstreamURL.Port()
+		if port == "" {
+			port = "443"
+		}
+		bootstrapAddr := net.JoinHostPort(bootstrap, port)
+
+		dialer := &net.Dialer{Timeout: upstreamTimeout}
+		transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, bootstrapAddr)
+		}
+
+		query.Del("bootstrap")
+		upstreamURL.RawQuery = query.Encode()
+	}
+
+	return &dohUpstream{
+		url: upstreamURL.String(),
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   upstreamTimeout,
+		},
+	}, nil
+}
+
+func (self *dohUpstream) Exchange(query *dns.Msg) (*dns.Msg, error) {
+	// RFC 8484 recommends a message id of zero, to make responses more cacheable
+	id := query.Id
+	query.Id = 0
+	packed, err := query.Pack()
+	query.Id = id
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, self.url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dnsMessageMimeType)
+	req.Header.Set("Accept", dnsMessageMimeType)
+
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream DNS server %s returned HTTP status %d", self.url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDnsMessageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dns.Msg{}
+	if err = response.Unpack(body); err != nil {
+		return nil, err
+	}
+	response.Id = id
+	return response, nil
+}
+
+func (self *dohUpstream) String() string {
+	return self.url + " over https"
+}
+
+// multiUpstream tries each upstream in order, returning the first answer
+type multiUpstream []upstream
+
+func (self multiUpstream) Exchange(query *dns.Msg) (*dns.Msg, error) {
+	var errList []error
+	for _, u := range self {
+		response, err := u.Exchange(query)
+		if err == nil {
+			return response, nil
+		}
+		log.Debugf("upstream DNS server %s failed: %v", u, err)
+		errList = append(errList, err)
+	}
+	return nil, errors.Join(errList...)
+}
+
+func (self multiUpstream) String() string {
+	var names []string
+	for _, u := range self {
+		names = append(names, u.String())
+	}
+	return strings.Join(names, ", ")
+}
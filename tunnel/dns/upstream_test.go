@@ -0,0 +1,110 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package dns
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUpstream(t *testing.T) {
+	req := require.New(t)
+
+	u, err := newUpstream("udp://10.96.0.10:53")
+	req.NoError(err)
+	req.Equal("10.96.0.10:53 over udp", u.String())
+
+	u, err = newUpstream("tls://9.9.9.9?serverName=dns.quad9.net")
+	req.NoError(err)
+	dot := u.(*dnsUpstream)
+	req.Equal("9.9.9.9:853", dot.addr)
+	req.Equal("tcp-tls", dot.client.Net)
+	req.Equal("dns.quad9.net", dot.client.TLSConfig.ServerName)
+
+	u, err = newUpstream("https://dns.quad9.net/dns-query?bootstrap=9.9.9.9")
+	req.NoError(err)
+	req.Equal("https://dns.quad9.net/dns-query", u.(*dohUpstream).url)
+
+	u, err = newUpstream("https://dns.quad9.net/dns-query, tcp://8.8.8.8:53")
+	req.NoError(err)
+	req.Len(u.(multiUpstream), 2)
+
+	_, err = newUpstream("https://dns.quad9.net/dns-query?bootstrap=dns.quad9.net")
+	req.ErrorContains(err, "must be an IP address")
+
+	_, err = newUpstream("quic://dns.quad9.net")
+	req.ErrorContains(err, "unsupported upstream DNS scheme 'quic'")
+
+	_, err = newUpstream(" , ")
+	req.Error(err)
+}
+
+func TestDohUpstream(t *testing.T) {
+	req := require.New(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dns-query" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil || r.Header.Get("Content-Type") != dnsMessageMimeType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		query := &dns.Msg{}
+		if err = query.Unpack(body); err != nil || query.Id != 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		response := &dns.Msg{}
+		response.SetReply(query)
+		response.Answer = append(response.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   net.IPv4(10, 0, 0, 1),
+		})
+		packed, _ := response.Pack()
+		w.Header().Set("Content-Type", dnsMessageMimeType)
+		_, _ = w.Write(packed)
+	}))
+	defer server.Close()
+
+	u, err := newUpstream(server.URL + "/broken," + server.URL + "/dns-query")
+	req.NoError(err)
+	for _, entry := range u.(multiUpstream) {
+		entry.(*dohUpstream).client = server.Client()
+	}
+
+	query := &dns.Msg{}
+	query.SetQuestion("example.com.", dns.TypeA)
+	query.Id = 1234
+
+	response, err := u.Exchange(query)
+	req.NoError(err)
+	req.Equal(uint16(1234), response.Id)
+	req.Len(response.Answer, 1)
+	req.Equal("10.0.0.1", response.Answer[0].(*dns.A).A.String())
+}
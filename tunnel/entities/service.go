@@ -32,11 +32,12 @@ const (
 )
 
 type ServiceConfig struct {
-	Protocol   string
-	Hostname   string
-	Port       int
-	PortChecks []*health.PortCheckDefinition
-	HttpChecks []*health.HttpCheckDefinition
+	Protocol     string
+	Hostname     string
+	Port         int
+	PortChecks   []*health.PortCheckDefinition
+	HttpChecks   []*health.HttpCheckDefinition
+	ScriptChecks []*health.ScriptCheckDefinition
 }
 
 func (self *ServiceConfig) GetPortChecks() []*health.PortCheckDefinition {
@@ -47,6 +48,10 @@ func (self *ServiceConfig) GetHttpChecks() []*health.HttpCheckDefinition {
 	return self.HttpChecks
 }
 
+func (self *ServiceConfig) GetScriptChecks() []*health.ScriptCheckDefinition {
+	return self.ScriptChecks
+}
+
 func (s *ServiceConfig) String() string {
 	return fmt.Sprintf("%v:%v:%v", s.Protocol, s.Hostname, s.Port)
 }
@@ -61,11 +66,12 @@ func (self *ServiceConfig) ToInterceptV1Config() *InterceptV1Config {
 
 func (self *ServiceConfig) ToHostV2Config() *HostV2Config {
 	terminator := &HostV1Config{
-		Protocol:   self.Protocol,
-		Address:    self.Hostname,
-		Port:       self.Port,
-		PortChecks: self.PortChecks,
-		HttpChecks: self.HttpChecks,
+		Protocol:     self.Protocol,
+		Address:      self.Hostname,
+		Port:         self.Port,
+		PortChecks:   self.PortChecks,
+		HttpChecks:   self.HttpChecks,
+		ScriptChecks: self.ScriptChecks,
 	}
 
 	return &HostV2Config{
@@ -85,6 +91,58 @@ type HostV1ListenOptions struct {
 	Precedence            *string
 }
 
+// UdpOptions controls how udp flows for a service are tracked. Unset values fall back to the tunneler defaults
+type UdpOptions struct {
+	IdleTimeout     *time.Duration
+	MaxDatagramSize *int
+	MaxConnections  *int
+}
+
+func (self *UdpOptions) GetIdleTimeout(defaultTimeout time.Duration) time.Duration {
+	if self != nil && self.IdleTimeout != nil && *self.IdleTimeout > 0 {
+		return *self.IdleTimeout
+	}
+	return defaultTimeout
+}
+
+// GetMaxDatagramSize returns the configured maximum datagram size, or 0 if no limit is configured
+func (self *UdpOptions) GetMaxDatagramSize() int {
+	if self != nil && self.MaxDatagramSize != nil && *self.MaxDatagramSize > 0 {
+		return *self.MaxDatagramSize
+	}
+	return 0
+}
+
+// GetMaxConnections returns the configured maximum number of udp flows, or 0 if no limit is configured
+func (self *UdpOptions) GetMaxConnections() int {
+	if self != nil && self.MaxConnections != nil && *self.MaxConnections > 0 {
+		return *self.MaxConnections
+	}
+	return 0
+}
+
+// ConnectionPoolOptions enables reuse of tcp connections to hosted servers. Unset values fall back to the tunneler
+// defaults
+type ConnectionPoolOptions struct {
+	MaxIdle     *int
+	IdleTimeout *time.Duration
+}
+
+// GetMaxIdle returns the maximum number of idle connections kept per dialed address
+func (self *ConnectionPoolOptions) GetMaxIdle(defaultMaxIdle int) int {
+	if self != nil && self.MaxIdle != nil && *self.MaxIdle > 0 {
+		return *self.MaxIdle
+	}
+	return defaultMaxIdle
+}
+
+func (self *ConnectionPoolOptions) GetIdleTimeout(defaultTimeout time.Duration) time.Duration {
+	if self != nil && self.IdleTimeout != nil && *self.IdleTimeout > 0 {
+		return *self.IdleTimeout
+	}
+	return defaultTimeout
+}
+
 type AddressTranslation struct {
 	From         string
 	To           string
@@ -109,6 +167,14 @@ func (self *allowConfig) GetAllowedAddresses() []string {
 	return self.AllowedAddresses
 }
 
+// ProtocolUnix is the host.v1 protocol used to host a service on a Unix domain socket. The socket path is given as
+// the address, and no port is used.
+const ProtocolUnix = "unix"
+
+// ProxyProtocolV2 is the host.v1 proxyProtocol setting which sends a PROXY protocol v2 header to the hosted
+// application on new tcp connections
+const ProxyProtocolV2 = "v2"
+
 type HostV1Config struct {
 	Protocol                   string
 	ForwardProtocol            bool
@@ -122,11 +188,15 @@ type HostV1Config struct {
 	AllowedPortRanges          []*PortRange
 	AllowedSourceAddresses     []string
 
-	PortChecks []*health.PortCheckDefinition
-	HttpChecks []*health.HttpCheckDefinition
+	PortChecks   []*health.PortCheckDefinition
+	HttpChecks   []*health.HttpCheckDefinition
+	ScriptChecks []*health.ScriptCheckDefinition
 
-	ListenOptions *HostV1ListenOptions
-	Proxy         *ProxyConfiguration
+	ListenOptions  *HostV1ListenOptions
+	Proxy          *ProxyConfiguration
+	ProxyProtocol  string
+	UdpOptions     *UdpOptions
+	ConnectionPool *ConnectionPoolOptions
 
 	allowedAddrs []allowedAddress
 }
@@ -256,6 +326,10 @@ func (self *HostV1Config) GetHttpChecks() []*health.HttpCheckDefinition {
 	return self.HttpChecks
 }
 
+func (self *HostV1Config) GetScriptChecks() []*health.ScriptCheckDefinition {
+	return self.ScriptChecks
+}
+
 func (self *HostV1Config) getValue(options map[string]interface{}, key string) (string, error) {
 	val, ok := options[key]
 	if !ok {
@@ -359,11 +433,15 @@ type PortRange struct {
 
 type InterceptV1Config struct {
 	Addresses              []string
+	AddressPatterns        []string // regular expressions matched against queried hostnames
+	ExcludedAddresses      []string // IPs/CIDRs carved out of the intercepted addresses
 	PortRanges             []*PortRange
 	Protocols              []string
 	SourceIp               *string
 	DialOptions            *DialOptions
 	AllowedSourceAddresses []string // white list for source IPs/CIDRs that will be intercepted
+	UdpOptions             *UdpOptions
+	Priority               int // precedence over other services when intercepted addresses overlap
 }
 
 type TemplateFunc func(sourceAddr net.Addr, destAddr net.Addr) string
@@ -448,6 +526,13 @@ func (self *Service) GetDialIdentity(sourceAddr net.Addr, destAddr net.Addr) str
 	return self.DialIdentityProvider(sourceAddr, destAddr)
 }
 
+func (self *Service) GetInterceptUdpOptions() *UdpOptions {
+	if self.InterceptV1Config == nil {
+		return nil
+	}
+	return self.InterceptV1Config.UdpOptions
+}
+
 func (self *Service) GetSourceIpTemplate() string {
 	if self.InterceptV1Config == nil {
 		return ""
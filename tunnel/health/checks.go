@@ -49,6 +49,15 @@ func (self *ActionDefinition) CreateAction() (Action, error) {
 		result.actionImpl = func(state *ServiceState) {
 			state.nextPrecedence = edge.PrecedenceFailed
 		}
+	} else if self.Action == "demote" {
+		// demote lowers precedence one step at a time, required -> default -> failed
+		result.actionImpl = func(state *ServiceState) {
+			if state.nextPrecedence == edge.PrecedenceRequired {
+				state.nextPrecedence = edge.PrecedenceDefault
+			} else {
+				state.nextPrecedence = edge.PrecedenceFailed
+			}
+		}
 	} else if self.Action == "send event" {
 		result.actionImpl = func(state *ServiceState) {
 			state.sendEvent = true
@@ -161,9 +170,10 @@ func (self *actionImpl) Invoke(state *ServiceState) {
 }
 
 type BaseCheckDefinition struct {
-	Interval time.Duration
-	Timeout  time.Duration
-	Actions  []*ActionDefinition
+	Interval          time.Duration
+	Timeout           time.Duration
+	Actions           []*ActionDefinition
+	UseDefaultActions bool
 }
 
 func (self *BaseCheckDefinition) GetInterval() time.Duration {
@@ -174,9 +184,31 @@ func (self *BaseCheckDefinition) GetTimeout() time.Duration {
 	return self.Timeout
 }
 
+// DefaultActions are used for checks which set useDefaultActions and don't define any actions. The terminator is
+// marked failed after three consecutive failures, and restored to its configured precedence once the check passes
+// again. Checks without actions which don't opt in keep running without any effect, as they always have.
+var DefaultActions = []*ActionDefinition{
+	{
+		Trigger:           "fail",
+		ConsecutiveEvents: &defaultFailuresBeforeUnhealthy,
+		Action:            "mark unhealthy",
+	},
+	{
+		Trigger: "pass",
+		Action:  "mark healthy",
+	},
+}
+
+var defaultFailuresBeforeUnhealthy uint16 = 3
+
 func (self *BaseCheckDefinition) CreateActions() ([]Action, error) {
+	actionDefinitions := self.Actions
+	if len(actionDefinitions) == 0 && self.UseDefaultActions {
+		actionDefinitions = DefaultActions
+	}
+
 	var result []Action
-	for _, actionDefinition := range self.Actions {
+	for _, actionDefinition := range actionDefinitions {
 		action, err := actionDefinition.CreateAction()
 		if err != nil {
 			return nil, err
@@ -2,9 +2,15 @@ package health
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/mitchellh/mapstructure"
+	"github.com/openziti/sdk-golang/ziti"
+	"github.com/openziti/sdk-golang/ziti/edge"
 	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -80,3 +86,77 @@ func Test_LoadPingTest(t *testing.T) {
 	req.Nil(pingCheck.Actions[3].Duration)
 	req.Equal("decrease cost 5", pingCheck.Actions[3].Action)
 }
+
+func Test_DemoteAction(t *testing.T) {
+	req := require.New(t)
+
+	actionDef := &ActionDefinition{Trigger: "fail", Action: "demote"}
+	action, err := actionDef.CreateAction()
+	req.NoError(err)
+
+	state := NewServiceState("test", ziti.PrecedenceRequired, 0, nil)
+	action.Invoke(state)
+	req.Equal(edge.PrecedenceDefault, state.nextPrecedence)
+
+	action.Invoke(state)
+	req.Equal(edge.PrecedenceFailed, state.nextPrecedence)
+}
+
+func Test_DefaultActions(t *testing.T) {
+	req := require.New(t)
+
+	checkDef := &PortCheckDefinition{Address: "localhost:5554"}
+	actions, err := checkDef.CreateActions()
+	req.NoError(err)
+	req.Empty(actions)
+
+	checkDef.UseDefaultActions = true
+	actions, err = checkDef.CreateActions()
+	req.NoError(err)
+	req.Equal(len(DefaultActions), len(actions))
+
+	checkDef.Actions = []*ActionDefinition{{Trigger: "fail", Action: "demote"}}
+	actions, err = checkDef.CreateActions()
+	req.NoError(err)
+	req.Equal(1, len(actions))
+}
+
+func Test_ScriptCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("script checks test uses shell scripts")
+	}
+
+	req := require.New(t)
+
+	dir := t.TempDir()
+	req.NoError(os.WriteFile(filepath.Join(dir, "pass.sh"), []byte("#!/bin/sh\necho ok $1\n"), 0755))
+	req.NoError(os.WriteFile(filepath.Join(dir, "fail.sh"), []byte("#!/bin/sh\necho broken\nexit 1\n"), 0755))
+
+	newCheck := func(script string, args ...string) Check {
+		checkDef := &ScriptCheckDefinition{Script: script, Args: args}
+		check, err := checkDef.CreateCheck("test")
+		req.NoError(err)
+		return check
+	}
+
+	req.NoError(SetScriptCheckDir(""))
+	_, err := newCheck("pass.sh").Execute(context.Background())
+	req.Error(err)
+
+	req.NoError(SetScriptCheckDir(dir))
+	defer func() { _ = SetScriptCheckDir("") }()
+
+	details, err := newCheck("pass.sh", "foo").Execute(context.Background())
+	req.NoError(err)
+	req.Equal("ok foo", details)
+
+	details, err = newCheck("fail.sh").Execute(context.Background())
+	req.Error(err)
+	req.Equal("broken", details)
+
+	_, err = newCheck("../pass.sh").Execute(context.Background())
+	req.Error(err)
+
+	_, err = newCheck(filepath.Join(dir, "pass.sh")).Execute(context.Background())
+	req.Error(err)
+}
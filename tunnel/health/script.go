@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+const maxScriptOutput = 1024
+
+var scriptCheckDir atomic.Pointer[string]
+
+// SetScriptCheckDir sets the directory that script health checks are loaded from. Script check definitions come from
+// service configs managed on the controller, so scripts may only be run from a directory explicitly enabled on the
+// host. Until a directory is set, script checks always fail.
+func SetScriptCheckDir(dir string) error {
+	if dir == "" {
+		scriptCheckDir.Store(nil)
+		return nil
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return errors.Wrapf(err, "invalid health check script directory '%v'", dir)
+	}
+	scriptCheckDir.Store(&absDir)
+	return nil
+}
+
+type ScriptCheckDefinition struct {
+	BaseCheckDefinition `mapstructure:",squash"`
+	Script              string
+	Args                []string
+}
+
+func (self *ScriptCheckDefinition) String() string {
+	return fmt.Sprintf("script-check script=%v, args=%v, interval=%v, timeout=%v", self.Script, self.Args, self.Interval, self.Timeout)
+}
+
+func (self *ScriptCheckDefinition) GetType() string {
+	return "script"
+}
+
+func (self *ScriptCheckDefinition) CreateCheck(name string) (Check, error) {
+	return &scriptCheck{
+		name:   name,
+		script: self.Script,
+		args:   self.Args,
+	}, nil
+}
+
+// scriptCheck runs a script from the script check directory. The check passes if the script exits with status 0.
+type scriptCheck struct {
+	name   string
+	script string
+	args   []string
+}
+
+func (self *scriptCheck) Name() string {
+	return self.name
+}
+
+func (self *scriptCheck) Execute(ctx context.Context) (interface{}, error) {
+	path, err := self.resolvePath()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := exec.CommandContext(ctx, path, self.args...).CombinedOutput()
+	details := strings.TrimSpace(string(output))
+	if len(details) > maxScriptOutput {
+		details = details[:maxScriptOutput]
+	}
+
+	if err != nil {
+		return details, errors.Wrapf(err, "health check script '%v' failed", self.script)
+	}
+
+	return details, nil
+}
+
+func (self *scriptCheck) resolvePath() (string, error) {
+	dir := scriptCheckDir.Load()
+	if dir == nil {
+		return "", errors.Errorf("unable to run health check script '%v', script health checks are not enabled on this host", self.script)
+	}
+
+	script := filepath.Clean(self.script)
+	if self.script == "" || filepath.IsAbs(script) || script == ".." || strings.HasPrefix(script, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("invalid health check script '%v', must be a relative path inside the script directory", self.script)
+	}
+
+	return filepath.Join(*dir, script), nil
+}
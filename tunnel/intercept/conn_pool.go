@@ -0,0 +1,310 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package intercept
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/tunnel/entities"
+)
+
+const (
+	defaultConnPoolMaxIdle     = 10
+	defaultConnPoolIdleTimeout = 30 * time.Second
+
+	connPoolSweepInterval = time.Second
+
+	// connPoolProbeTimeout is how long to wait for data or a close from the server when checking that a connection
+	// is idle. Reads with a deadline in the past fail without looking at the socket, so the deadline has to be in
+	// the future.
+	connPoolProbeTimeout = time.Millisecond
+)
+
+type idleConn struct {
+	conn      net.Conn
+	idleSince time.Time
+}
+
+// connPool keeps tcp connections to a hosted server open once the circuit using them ends, so later circuits can
+// reuse them instead of dialing the server again. Idle connections are kept per dialed address. A connection is only
+// pooled if it saw no errors and the server hasn't closed it or sent data which wasn't read. Idle connections are
+// closed once they've been idle for longer than the idle timeout, or if the address already has the maximum number
+// of idle connections.
+//
+// The pool can't tell where a request ends, so it's only suitable for protocols where the client waits for each
+// response before closing its connection, such as http with keep-alive.
+type connPool struct {
+	maxIdle     int
+	idleTimeout time.Duration
+	dial        func(address string) (net.Conn, error)
+
+	lock        sync.Mutex
+	idle        map[string][]*idleConn
+	closed      bool
+	closeNotify chan struct{}
+}
+
+func newConnPool(options *entities.ConnectionPoolOptions, dial func(address string) (net.Conn, error)) *connPool {
+	result := &connPool{
+		maxIdle:     options.GetMaxIdle(defaultConnPoolMaxIdle),
+		idleTimeout: options.GetIdleTimeout(defaultConnPoolIdleTimeout),
+		dial:        dial,
+		idle:        map[string][]*idleConn{},
+		closeNotify: make(chan struct{}),
+	}
+	go result.run()
+	return result
+}
+
+// get returns a pooled connection to the given address, dialing a new one if no idle connection is available.
+// Closing the returned connection hands it back to the pool.
+func (self *connPool) get(address string) (net.Conn, error) {
+	for {
+		conn := self.takeIdle(address)
+		if conn == nil {
+			break
+		}
+		if isConnIdle(conn) {
+			pfxlog.Logger().WithField("address", address).Debug("reusing pooled connection")
+			return newPooledConn(self, address, conn), nil
+		}
+		_ = conn.Close()
+	}
+
+	conn, err := self.dial(address)
+	if err != nil {
+		return nil, err
+	}
+	return newPooledConn(self, address, conn), nil
+}
+
+// takeIdle removes the most recently used idle connection for the address from the pool. Using the most recent one
+// lets connections which aren't needed during quieter periods time out.
+func (self *connPool) takeIdle(address string) net.Conn {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	conns := self.idle[address]
+	if len(conns) == 0 {
+		return nil
+	}
+
+	result := conns[len(conns)-1]
+	conns[len(conns)-1] = nil
+	if len(conns) == 1 {
+		delete(self.idle, address)
+	} else {
+		self.idle[address] = conns[:len(conns)-1]
+	}
+	return result.conn
+}
+
+func (self *connPool) put(address string, conn net.Conn) {
+	if !isConnIdle(conn) {
+		_ = conn.Close()
+		return
+	}
+
+	self.lock.Lock()
+	pooled := !self.closed && len(self.idle[address]) < self.maxIdle
+	if pooled {
+		self.idle[address] = append(self.idle[address], &idleConn{
+			conn:      conn,
+			idleSince: time.Now(),
+		})
+	}
+	self.lock.Unlock()
+
+	if !pooled {
+		_ = conn.Close()
+	}
+}
+
+func (self *connPool) run() {
+	ticker := time.NewTicker(connPoolSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			self.expire(time.Now())
+		case <-self.closeNotify:
+			return
+		}
+	}
+}
+
+// expire closes connections which have been idle for longer than the idle timeout
+func (self *connPool) expire(now time.Time) {
+	var expired []net.Conn
+
+	self.lock.Lock()
+	for address, conns := range self.idle {
+		// connections are added as they become idle, so the expired ones are at the start
+		count := 0
+		for count < len(conns) && now.Sub(conns[count].idleSince) >= self.idleTimeout {
+			expired = append(expired, conns[count].conn)
+			count++
+		}
+		if count == len(conns) {
+			delete(self.idle, address)
+		} else if count > 0 {
+			self.idle[address] = append([]*idleConn(nil), conns[count:]...)
+		}
+	}
+	self.lock.Unlock()
+
+	for _, conn := range expired {
+		_ = conn.Close()
+	}
+}
+
+func (self *connPool) idleCount() int {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	result := 0
+	for _, conns := range self.idle {
+		result += len(conns)
+	}
+	return result
+}
+
+// close closes all idle connections and stops pooling. It's safe to call on a nil pool. Connections in use are
+// closed when their circuits end.
+func (self *connPool) close() {
+	if self == nil {
+		return
+	}
+
+	self.lock.Lock()
+	if self.closed {
+		self.lock.Unlock()
+		return
+	}
+	self.closed = true
+	close(self.closeNotify)
+	idle := self.idle
+	self.idle = map[string][]*idleConn{}
+	self.lock.Unlock()
+
+	for _, conns := range idle {
+		for _, c := range conns {
+			_ = c.conn.Close()
+		}
+	}
+}
+
+// isConnIdle checks that the server hasn't closed the connection or sent any data, which would belong to a previous
+// request and would be delivered to the wrong client. It leaves the connection without deadlines.
+func isConnIdle(conn net.Conn) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(connPoolProbeTimeout)); err != nil {
+		return false
+	}
+
+	var buf [1]byte
+	_, err := conn.Read(buf[:])
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return false
+	}
+
+	return conn.SetDeadline(time.Time{}) == nil
+}
+
+// pooledConn is a connection handed out by a connPool. Closing it returns the underlying connection to the pool,
+// unless a read or write failed, in which case the underlying connection is closed.
+type pooledConn struct {
+	net.Conn
+	pool      *connPool
+	address   string
+	readLock  sync.Mutex
+	writeLock sync.Mutex
+	released  atomic.Bool
+	failed    atomic.Bool
+}
+
+func newPooledConn(pool *connPool, address string, conn net.Conn) *pooledConn {
+	return &pooledConn{
+		Conn:    conn,
+		pool:    pool,
+		address: address,
+	}
+}
+
+func (self *pooledConn) Read(b []byte) (int, error) {
+	self.readLock.Lock()
+	defer self.readLock.Unlock()
+
+	if self.released.Load() {
+		return 0, io.EOF
+	}
+
+	n, err := self.Conn.Read(b)
+	if err != nil {
+		if self.released.Load() {
+			// interrupted by Close, the connection is still usable
+			return n, io.EOF
+		}
+		self.failed.Store(true)
+	}
+	return n, err
+}
+
+func (self *pooledConn) Write(b []byte) (int, error) {
+	self.writeLock.Lock()
+	defer self.writeLock.Unlock()
+
+	if self.released.Load() {
+		return 0, net.ErrClosed
+	}
+
+	n, err := self.Conn.Write(b)
+	if err != nil {
+		// the server may have received part of the data, so the connection can't be reused
+		self.failed.Store(true)
+	}
+	return n, err
+}
+
+func (self *pooledConn) Close() error {
+	if !self.released.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	// interrupt reads and writes in progress and wait for them to return, so the connection is no longer used by
+	// this circuit once it's back in the pool
+	_ = self.Conn.SetDeadline(time.Now())
+	self.readLock.Lock()
+	self.writeLock.Lock()
+	defer self.readLock.Unlock()
+	defer self.writeLock.Unlock()
+
+	if self.failed.Load() {
+		return self.Conn.Close()
+	}
+
+	self.pool.put(self.address, self.Conn)
+	return nil
+}
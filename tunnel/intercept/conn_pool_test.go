@@ -0,0 +1,240 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package intercept
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/tunnel"
+	"github.com/openziti/ziti/tunnel/entities"
+	"github.com/stretchr/testify/require"
+)
+
+// lineServer answers each line it receives with the same line. 'twice' is answered twice and 'quit' closes the
+// connection.
+type lineServer struct {
+	listener net.Listener
+	accepted atomic.Int32
+}
+
+func newLineServer(t *testing.T) *lineServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	result := &lineServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			result.accepted.Add(1)
+			go result.serve(conn)
+		}
+	}()
+	return result
+}
+
+func (self *lineServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil || line == "quit\n" {
+			return
+		}
+		if line == "twice\n" {
+			line += line
+		}
+		if _, err = conn.Write([]byte(line)); err != nil {
+			return
+		}
+	}
+}
+
+func (self *lineServer) newHostingContext(options *entities.ConnectionPoolOptions) *hostingContext {
+	addr := self.listener.Addr().(*net.TCPAddr)
+	result := &hostingContext{
+		service: &entities.Service{},
+		config: &entities.HostV1Config{
+			Protocol:       "tcp",
+			Address:        addr.IP.String(),
+			Port:           addr.Port,
+			ConnectionPool: options,
+		},
+		dialTimeout: time.Second,
+	}
+	result.connPool = newConnPool(options, result.dialPooled)
+	return result
+}
+
+func roundTrip(t *testing.T, conn net.Conn, line string) {
+	_, err := conn.Write([]byte(line + "\n"))
+	require.NoError(t, err)
+	buf := make([]byte, len(line)+1)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, line+"\n", string(buf))
+}
+
+func TestConnPoolReusesConnections(t *testing.T) {
+	req := require.New(t)
+	server := newLineServer(t)
+	ctx := server.newHostingContext(&entities.ConnectionPoolOptions{})
+	defer ctx.OnClose()
+
+	conn, halfClose, err := ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	req.False(halfClose)
+	roundTrip(t, conn, "one")
+	req.NoError(conn.Close())
+	req.Equal(1, ctx.connPool.idleCount())
+
+	conn, _, err = ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	roundTrip(t, conn, "two")
+
+	// a second concurrent client needs its own connection
+	conn2, _, err := ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	roundTrip(t, conn2, "three")
+	req.NoError(conn.Close())
+	req.NoError(conn2.Close())
+
+	req.Equal(int32(2), server.accepted.Load())
+	req.Equal(2, ctx.connPool.idleCount())
+
+	// connections spoofing a client source address are never pooled
+	conn, halfClose, err = ctx.Dial(map[string]interface{}{tunnel.SourceAddrKey: "127.0.0.1"})
+	req.NoError(err)
+	req.True(halfClose)
+	_, isPooled := conn.(*pooledConn)
+	req.False(isPooled)
+	_ = conn.Close()
+}
+
+func TestConnPoolDiscardsUnusableConnections(t *testing.T) {
+	req := require.New(t)
+	server := newLineServer(t)
+	ctx := server.newHostingContext(&entities.ConnectionPoolOptions{})
+	defer ctx.OnClose()
+
+	// the second answer is never read, so it would be delivered to the next client
+	conn, _, err := ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	roundTrip(t, conn, "twice")
+	time.Sleep(50 * time.Millisecond)
+	req.NoError(conn.Close())
+	req.Equal(0, ctx.connPool.idleCount())
+
+	conn, _, err = ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	roundTrip(t, conn, "one")
+	req.NoError(conn.Close())
+	req.Equal(1, ctx.connPool.idleCount())
+
+	// the server closes the idle connection
+	conn, _, err = ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	_, err = conn.Write([]byte("quit\n"))
+	req.NoError(err)
+	req.NoError(conn.Close())
+	time.Sleep(50 * time.Millisecond)
+
+	conn, _, err = ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	roundTrip(t, conn, "two")
+	req.NoError(conn.Close())
+	req.Equal(int32(3), server.accepted.Load())
+}
+
+func TestConnPoolCloseInterruptsRead(t *testing.T) {
+	req := require.New(t)
+	server := newLineServer(t)
+	ctx := server.newHostingContext(&entities.ConnectionPoolOptions{})
+	defer ctx.OnClose()
+
+	conn, _, err := ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	roundTrip(t, conn, "one")
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 16))
+		readErr <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	req.NoError(conn.Close())
+
+	select {
+	case err = <-readErr:
+		req.ErrorIs(err, io.EOF)
+	case <-time.After(5 * time.Second):
+		req.Fail("read not interrupted by close")
+	}
+
+	_, err = conn.Write([]byte("two\n"))
+	req.ErrorIs(err, net.ErrClosed)
+	req.Equal(1, ctx.connPool.idleCount())
+}
+
+func TestConnPoolLimitsIdleConnections(t *testing.T) {
+	req := require.New(t)
+	server := newLineServer(t)
+
+	maxIdle := 2
+	idleTimeout := time.Minute
+	ctx := server.newHostingContext(&entities.ConnectionPoolOptions{
+		MaxIdle:     &maxIdle,
+		IdleTimeout: &idleTimeout,
+	})
+	defer ctx.OnClose()
+
+	var conns []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, _, err := ctx.Dial(map[string]interface{}{})
+		req.NoError(err)
+		roundTrip(t, conn, strconv.Itoa(i))
+		conns = append(conns, conn)
+	}
+
+	for _, conn := range conns {
+		req.NoError(conn.Close())
+	}
+	req.Equal(2, ctx.connPool.idleCount())
+
+	ctx.connPool.expire(time.Now().Add(30 * time.Second))
+	req.Equal(2, ctx.connPool.idleCount())
+
+	ctx.connPool.expire(time.Now().Add(idleTimeout))
+	req.Equal(0, ctx.connPool.idleCount())
+
+	ctx.OnClose()
+	conn, _, err := ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	roundTrip(t, conn, "closed")
+	req.NoError(conn.Close())
+	req.Equal(0, ctx.connPool.idleCount())
+}
@@ -41,6 +41,7 @@ import (
 type healthChecksProvider interface {
 	GetPortChecks() []*health.PortCheckDefinition
 	GetHttpChecks() []*health.HttpCheckDefinition
+	GetScriptChecks() []*health.ScriptCheckDefinition
 }
 
 func createHostingContexts(service *entities.Service, identity *rest_model.IdentityDetail, tracker AddressTracker) []tunnel.HostingContext {
@@ -66,6 +67,17 @@ func newDefaultHostingContext(identity *rest_model.IdentityDetail, service *enti
 		return nil
 	}
 
+	if config.Protocol == entities.ProtocolUnix && !config.ForwardProtocol {
+		if config.ForwardAddress || config.ForwardPort {
+			log.Error("configuration specifies 'unix' protocol with 'ForwardAddress' or 'ForwardPort'")
+			return nil
+		}
+		if config.Address == "" {
+			log.Error("configuration specifies 'unix' protocol without a socket path in 'Address'")
+			return nil
+		}
+	}
+
 	var addrTranslations []addrTranslation
 	if config.ForwardAddress {
 		if len(config.AllowedAddresses) < 1 {
@@ -135,7 +147,7 @@ func newDefaultHostingContext(identity *rest_model.IdentityDetail, service *enti
 		}
 	}
 
-	return &hostingContext{
+	result := &hostingContext{
 		service:          service,
 		options:          listenOptions,
 		proxyConf:        proxyConf,
@@ -145,6 +157,15 @@ func newDefaultHostingContext(identity *rest_model.IdentityDetail, service *enti
 		addrTranslations: addrTranslations,
 	}
 
+	if config.ConnectionPool != nil {
+		if config.ProxyProtocol == entities.ProxyProtocolV2 {
+			log.Warn("configuration specifies 'connectionPool' with 'proxyProtocol'. connections carry the address of a single client, so they won't be pooled")
+		} else {
+			result.connPool = newConnPool(config.ConnectionPool, result.dialPooled)
+		}
+	}
+
+	return result
 }
 
 // map input IP/cidr to output IP/cidr
@@ -163,6 +184,7 @@ type hostingContext struct {
 	addrTracker      AddressTracker
 	addrTranslations []addrTranslation
 	dialWrapper      tunnel.DialWrapper
+	connPool         *connPool
 }
 
 func (self *hostingContext) SetDialWrapper(dialWrapper tunnel.DialWrapper) {
@@ -253,9 +275,28 @@ func (self *hostingContext) dialAddress(options map[string]interface{}, protocol
 		conn, err = dialer.Dial(protocol, address)
 	}
 
+	if err == nil && isUdp && self.config.UdpOptions != nil {
+		conn = newUdpFlowConn(conn, self.config.UdpOptions)
+	}
+
 	return conn, enableHalfClose, err
 }
 
+// dialPooled dials new connections for the connection pool. Pooled connections are shared between clients, so
+// they're never dialed from a client specific source address.
+func (self *hostingContext) dialPooled(address string) (net.Conn, error) {
+	conn, _, err := self.dialAddress(nil, "tcp", address)
+	return conn, err
+}
+
+// dialUnix connects to a Unix domain socket. Source addresses, proxies and dial wrappers only apply to ip
+// connections, so they aren't used.
+func (self *hostingContext) dialUnix(path string) (net.Conn, bool, error) {
+	dialer := &net.Dialer{Timeout: self.dialTimeout}
+	conn, err := dialer.Dial(entities.ProtocolUnix, path)
+	return conn, true, err
+}
+
 func (self *hostingContext) SetCloseCallback(f func()) {
 	self.onClose = f
 }
@@ -273,6 +314,8 @@ func (self *hostingContext) OnClose() {
 		}
 	}
 
+	self.connPool.close()
+
 	if self.onClose != nil {
 		self.onClose()
 	}
@@ -289,6 +332,10 @@ func (self *hostingContext) getHealthChecks(provider healthChecksProvider) []hea
 		checkDefinitions = append(checkDefinitions, checkDef)
 	}
 
+	for _, checkDef := range provider.GetScriptChecks() {
+		checkDefinitions = append(checkDefinitions, checkDef)
+	}
+
 	return checkDefinitions
 }
 
@@ -392,6 +439,13 @@ func (self *hostingContext) Dial(options map[string]interface{}) (net.Conn, bool
 		return nil, false, err
 	}
 
+	if protocol == entities.ProtocolUnix {
+		if self.config.ForwardProtocol {
+			return nil, false, errors.Errorf("protocol '%s' may not be forwarded", protocol)
+		}
+		return self.dialUnix(self.config.Address)
+	}
+
 	address, err := self.config.GetAddress(options)
 	if err != nil {
 		return nil, false, err
@@ -406,7 +460,25 @@ func (self *hostingContext) Dial(options map[string]interface{}) (net.Conn, bool
 		return nil, false, err
 	}
 
-	return self.dialAddress(options, protocol, xAddress+":"+port)
+	if sourceAddr, _ := options[tunnel.SourceAddrKey].(string); protocol == "tcp" && self.connPool != nil && sourceAddr == "" {
+		// a half-closed connection can't be reused, so pooled connections are always fully closed
+		conn, err := self.connPool.get(xAddress + ":" + port)
+		return conn, false, err
+	}
+
+	conn, halfClose, err := self.dialAddress(options, protocol, xAddress+":"+port)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if protocol == "tcp" && self.config.ProxyProtocol == entities.ProxyProtocolV2 {
+		if _, err = conn.Write(newProxyProtocolV2Header(options, conn.RemoteAddr())); err != nil {
+			_ = conn.Close()
+			return nil, false, errors.Wrap(err, "unable to send proxy protocol header")
+		}
+	}
+
+	return conn, halfClose, nil
 }
 
 func getDefaultOptions(service *entities.Service, identity *rest_model.IdentityDetail, config *entities.HostV1Config) (*ziti.ListenOptions, error) {
@@ -0,0 +1,78 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package intercept
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/tunnel"
+	"github.com/openziti/ziti/tunnel/entities"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostingContextDialsUnixSocket(t *testing.T) {
+	req := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "hosted.sock")
+	listener, err := net.Listen("unix", path)
+	req.NoError(err)
+	defer func() { _ = listener.Close() }()
+
+	accepted := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		buf, _ := io.ReadAll(conn)
+		accepted <- string(buf)
+	}()
+
+	ctx := &hostingContext{
+		config: &entities.HostV1Config{
+			Protocol: entities.ProtocolUnix,
+			Address:  path,
+		},
+		dialTimeout: time.Second,
+	}
+
+	conn, halfClose, err := ctx.Dial(map[string]interface{}{})
+	req.NoError(err)
+	req.True(halfClose)
+
+	_, err = conn.Write([]byte("hello"))
+	req.NoError(err)
+	req.NoError(conn.(*net.UnixConn).CloseWrite())
+
+	select {
+	case msg := <-accepted:
+		req.Equal("hello", msg)
+	case <-time.After(5 * time.Second):
+		req.Fail("unix socket connection not accepted")
+	}
+	_ = conn.Close()
+
+	ctx.config.ForwardProtocol = true
+	ctx.config.AllowedProtocols = []string{entities.ProtocolUnix}
+	_, _, err = ctx.Dial(map[string]interface{}{tunnel.DestinationProtocolKey: entities.ProtocolUnix})
+	req.Error(err)
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/openziti/ziti/tunnel/dns"
 	"github.com/openziti/ziti/tunnel/entities"
+	"github.com/openziti/ziti/tunnel/utils"
 	"github.com/pkg/errors"
 	"net"
 )
@@ -48,6 +49,8 @@ type InterceptAddress struct {
 	lowPort       uint16
 	highPort      uint16
 	protocol      string
+	priority      int
+	service       string
 	TproxySpec    []string
 	AcceptSpec    []string
 }
@@ -72,13 +75,21 @@ func (addr *InterceptAddress) HighPort() uint16 {
 	return addr.highPort
 }
 
+func (addr *InterceptAddress) Priority() int {
+	return addr.priority
+}
+
+func (addr *InterceptAddress) Service() string {
+	return addr.service
+}
+
 func (addr *InterceptAddress) Contains(ip net.IP, port uint16) bool {
 	return addr.cidr.Contains(ip) && port >= addr.lowPort && port <= addr.highPort
 }
 
 func (addr *InterceptAddress) String() string {
-	return fmt.Sprintf("cidr: %v, cidrAddr: %p, lowPort: %v, highPort: %v, protocol: %v, tproxySpec: %v, acceptSpec: %v",
-		addr.cidr, addr.cidr, addr.lowPort, addr.highPort, addr.protocol, addr.TproxySpec, addr.AcceptSpec)
+	return fmt.Sprintf("cidr: %v, cidrAddr: %p, lowPort: %v, highPort: %v, protocol: %v, priority: %v, tproxySpec: %v, acceptSpec: %v",
+		addr.cidr, addr.cidr, addr.lowPort, addr.highPort, addr.protocol, addr.priority, addr.TproxySpec, addr.AcceptSpec)
 }
 
 type InterceptAddrCB interface {
@@ -86,23 +97,44 @@ type InterceptAddrCB interface {
 }
 
 func GetInterceptAddresses(service *entities.Service, protocols []string, resolver dns.Resolver, addressCB InterceptAddrCB) error {
-	for _, addr := range service.InterceptV1Config.Addresses {
-		err := getInterceptIP(service, addr, resolver, func(ipNet *net.IPNet, routeRequired bool) {
+	var excluded []*net.IPNet
+	for _, addr := range service.InterceptV1Config.ExcludedAddresses {
+		ipNet, err := utils.GetCidr(addr)
+		if err != nil {
+			return errors.Wrapf(err, "invalid excluded address %v", addr)
+		}
+		excluded = append(excluded, ipNet)
+	}
+
+	applyAddress := func(ipNet *net.IPNet, routeRequired bool) {
+		for _, includedNet := range utils.ExcludeCidrs(ipNet, excluded) {
 			for _, protocol := range protocols {
 				for _, portRange := range service.InterceptV1Config.PortRanges {
 					addr := &InterceptAddress{
-						cidr:          ipNet,
+						cidr:          includedNet,
 						routeRequired: routeRequired,
 						lowPort:       portRange.Low,
 						highPort:      portRange.High,
-						protocol:      protocol}
+						protocol:      protocol,
+						priority:      service.InterceptV1Config.Priority,
+						service:       *service.Name,
+					}
 					addressCB.Apply(addr)
 				}
 			}
-		})
-		if err != nil {
+		}
+	}
+
+	for _, addr := range service.InterceptV1Config.Addresses {
+		if err := getInterceptIP(service, addr, resolver, applyAddress); err != nil {
 			return errors.Wrapf(err, "failed to get intercept IP address for %v", addr)
 		}
 	}
+
+	for _, pattern := range service.InterceptV1Config.AddressPatterns {
+		if err := addInterceptPattern(service, pattern, resolver, applyAddress); err != nil {
+			return errors.Wrapf(err, "failed to add intercept address pattern %v", pattern)
+		}
+	}
 	return nil
 }
@@ -108,6 +108,19 @@ func getDnsIp(host string, addrCB func(*net.IPNet, bool), svc *entities.Service,
 	return ip.AsSlice(), nil
 }
 
+// addInterceptPattern registers a regular expression with the resolver. As with wildcard domains, IPs are
+// allocated when matching hostnames are queried. Patterns must match the whole hostname and are case-insensitive.
+func addInterceptPattern(svc *entities.Service, pattern string, resolver dns.Resolver, addrCB func(*net.IPNet, bool)) error {
+	anchored := "(?i)^(?:" + pattern + ")$"
+	err := resolver.AddDomainPattern(anchored, func(host string) (net.IP, error) {
+		return getDnsIp(host, addrCB, svc, resolver)
+	})
+	if err == nil {
+		svc.AddCleanupAction(func() { resolver.RemoveDomainPattern(anchored) })
+	}
+	return err
+}
+
 func getInterceptIP(svc *entities.Service, hostname string, resolver dns.Resolver, addrCB func(*net.IPNet, bool)) error {
 	logger := pfxlog.Logger()
 
@@ -0,0 +1,89 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package intercept
+
+import (
+	"cmp"
+	"net"
+	"slices"
+	"strings"
+)
+
+// ComparePrecedence orders intercept addresses by precedence, returning a negative number if a should be matched
+// before b. Addresses with a higher priority come first. Within a priority, longer prefixes come first, so that
+// specific carve-outs are matched ahead of broad catch-all ranges, followed by narrower port ranges. Remaining ties
+// are broken by service name, so the order doesn't depend on the order in which services were intercepted.
+func ComparePrecedence(a, b *InterceptAddress) int {
+	if result := cmp.Compare(b.priority, a.priority); result != 0 {
+		return result
+	}
+
+	aBits, _ := a.cidr.Mask.Size()
+	bBits, _ := b.cidr.Mask.Size()
+	if result := cmp.Compare(bBits, aBits); result != 0 {
+		return result
+	}
+
+	if result := cmp.Compare(a.highPort-a.lowPort, b.highPort-b.lowPort); result != 0 {
+		return result
+	}
+
+	return strings.Compare(a.service, b.service)
+}
+
+// PrecedenceList holds intercept addresses in precedence order. Interceptors which evaluate their rules in order,
+// such as iptables chains, use the index returned by Add to place each rule ahead of the rules it takes precedence
+// over.
+type PrecedenceList struct {
+	addrs []*InterceptAddress
+}
+
+// Add inserts the address after all addresses which take precedence over it, or are equal to it, and returns the
+// index it was inserted at
+func (self *PrecedenceList) Add(addr *InterceptAddress) int {
+	idx, _ := slices.BinarySearchFunc(self.addrs, addr, func(e, target *InterceptAddress) int {
+		if ComparePrecedence(e, target) <= 0 {
+			return -1
+		}
+		return 1
+	})
+	self.addrs = slices.Insert(self.addrs, idx, addr)
+	return idx
+}
+
+// Remove removes the address, returning false if it wasn't in the list
+func (self *PrecedenceList) Remove(addr *InterceptAddress) bool {
+	if idx := slices.Index(self.addrs, addr); idx >= 0 {
+		self.addrs = slices.Delete(self.addrs, idx, idx+1)
+		return true
+	}
+	return false
+}
+
+// Match returns the address with the highest precedence which covers the given destination, or nil if none do
+func (self *PrecedenceList) Match(protocol string, ip net.IP, port uint16) *InterceptAddress {
+	for _, addr := range self.addrs {
+		if addr.protocol == protocol && addr.Contains(ip, port) {
+			return addr
+		}
+	}
+	return nil
+}
+
+func (self *PrecedenceList) Len() int {
+	return len(self.addrs)
+}
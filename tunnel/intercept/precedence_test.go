@@ -0,0 +1,116 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package intercept
+
+import (
+	"net"
+	"testing"
+
+	"github.com/openziti/ziti/tunnel/entities"
+	"github.com/stretchr/testify/require"
+)
+
+type addressCollector []*InterceptAddress
+
+func (self *addressCollector) Apply(addr *InterceptAddress) {
+	*self = append(*self, addr)
+}
+
+func newPrecedenceTestService(name string, priority int, lowPort, highPort uint16, addresses ...string) *entities.Service {
+	svc := &entities.Service{
+		InterceptV1Config: &entities.InterceptV1Config{
+			Addresses:  addresses,
+			PortRanges: []*entities.PortRange{{Low: lowPort, High: highPort}},
+			Protocols:  []string{"tcp"},
+			Priority:   priority,
+		},
+	}
+	svc.Name = &name
+	return svc
+}
+
+func Test_PrecedenceList(t *testing.T) {
+	req := require.New(t)
+
+	list := &PrecedenceList{}
+	add := func(svc *entities.Service) []*InterceptAddress {
+		var addrs addressCollector
+		req.NoError(GetInterceptAddresses(svc, svc.InterceptV1Config.Protocols, nil, &addrs))
+		for _, addr := range addrs {
+			list.Add(addr)
+		}
+		return addrs
+	}
+
+	match := func(ip string, port uint16) string {
+		addr := list.Match("tcp", net.ParseIP(ip), port)
+		if addr == nil {
+			return ""
+		}
+		return addr.Service()
+	}
+
+	// added in the order which would leave the catch-all matching everything if rules were simply prepended
+	add(newPrecedenceTestService("carve-out", 0, 1, 65535, "10.1.0.0/16"))
+	host := add(newPrecedenceTestService("host", 0, 1, 65535, "10.1.2.3"))
+	add(newPrecedenceTestService("catch-all", 0, 1, 65535, "10.0.0.0/8"))
+
+	req.Equal("catch-all", match("10.2.0.1", 443))
+	req.Equal("carve-out", match("10.1.0.1", 443))
+	req.Equal("host", match("10.1.2.3", 443))
+	req.Equal("", match("192.168.1.1", 443))
+
+	// a higher priority wins, even over a more specific address
+	add(newPrecedenceTestService("ssh", 10, 22, 22, "10.0.0.0/8"))
+	req.Equal("ssh", match("10.1.2.3", 22))
+	req.Equal("host", match("10.1.2.3", 443))
+
+	// with the same priority and prefix, the narrower port range wins
+	add(newPrecedenceTestService("web", 0, 443, 443, "10.1.0.0/16"))
+	req.Equal("web", match("10.1.0.1", 443))
+	req.Equal("carve-out", match("10.1.0.1", 80))
+
+	// identical addresses are ordered by service name, regardless of the order they're added in
+	add(newPrecedenceTestService("z-dup", 0, 443, 443, "10.1.0.0/16"))
+	add(newPrecedenceTestService("a-dup", 0, 443, 443, "10.1.0.0/16"))
+	req.Equal("a-dup", match("10.1.0.1", 443))
+
+	for _, addr := range host {
+		req.True(list.Remove(addr))
+		req.False(list.Remove(addr))
+	}
+	req.Equal("carve-out", match("10.1.2.3", 80))
+}
+
+func Test_PrecedenceListAddIndex(t *testing.T) {
+	req := require.New(t)
+
+	_, broad, _ := net.ParseCIDR("10.0.0.0/8")
+	_, narrow, _ := net.ParseCIDR("10.1.0.0/16")
+
+	newAddr := func(cidr *net.IPNet, priority int, service string) *InterceptAddress {
+		return &InterceptAddress{cidr: cidr, lowPort: 1, highPort: 65535, protocol: "tcp", priority: priority, service: service}
+	}
+
+	list := &PrecedenceList{}
+	req.Equal(0, list.Add(newAddr(broad, 0, "a")))
+	req.Equal(0, list.Add(newAddr(narrow, 0, "b")))
+	req.Equal(2, list.Add(newAddr(broad, 0, "c")))
+	req.Equal(0, list.Add(newAddr(broad, 5, "d")))
+	req.Equal(4, list.Add(newAddr(broad, -1, "e")))
+	req.Equal(5, list.Len())
+}
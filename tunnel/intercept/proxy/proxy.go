@@ -290,7 +290,7 @@ func (p *interceptor) handleUDP(service *Service) error {
 		service: service.TunnelService,
 		conn:    udpPacketConn,
 	}
-	vconnManager := udp_vconn.NewManager(service.TunnelService.FabricProvider, udp_vconn.NewUnlimitedConnectionPolicy(), udp_vconn.NewDefaultExpirationPolicy())
+	vconnManager := udp_vconn.NewServiceManager(service.TunnelService, udp_vconn.NewDefaultExpirationPolicy())
 	go reader.generateReadEvents(vconnManager)
 	return nil
 }
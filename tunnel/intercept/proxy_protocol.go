@@ -0,0 +1,112 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package intercept
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+
+	"github.com/openziti/ziti/tunnel"
+)
+
+var proxyProtocolV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+const (
+	proxyProtocolV2Proxy = 0x21
+
+	proxyProtocolFamilyUnspec = 0x00
+	proxyProtocolFamilyTcp4   = 0x11
+	proxyProtocolFamilyTcp6   = 0x21
+
+	proxyProtocolTlvAuthority = 0x02
+
+	// proxyProtocolTlvIdentity is the custom PROXY protocol v2 TLV type carrying the name of the dialing identity
+	proxyProtocolTlvIdentity = 0xE0
+)
+
+// newProxyProtocolV2Header builds a PROXY protocol v2 header for a tcp connection dialed on behalf of a ziti
+// client. The source is the intercepted client address and the destination is the address the client connected
+// to, both taken from the dial options. If the client address isn't known, the address family is left unspecified,
+// so the hosted application uses the connection's own addresses. The intercepted hostname and dialing identity
+// are sent as TLVs, when known.
+func newProxyProtocolV2Header(options map[string]interface{}, backendAddr net.Addr) []byte {
+	srcIp, srcPort := getProxyProtocolAddr(options, tunnel.SourceIpKey, tunnel.SourcePortKey)
+	dstIp, dstPort := getProxyProtocolAddr(options, tunnel.DestinationIpKey, tunnel.DestinationPortKey)
+	if dstIp == nil {
+		if tcpAddr, ok := backendAddr.(*net.TCPAddr); ok {
+			dstIp, dstPort = tcpAddr.IP, uint16(tcpAddr.Port)
+		}
+	}
+
+	family := byte(proxyProtocolFamilyUnspec)
+	addrs := &bytes.Buffer{}
+
+	if srcIp != nil && dstIp != nil {
+		if srcIp.To4() != nil && dstIp.To4() != nil {
+			family = proxyProtocolFamilyTcp4
+			addrs.Write(srcIp.To4())
+			addrs.Write(dstIp.To4())
+		} else {
+			family = proxyProtocolFamilyTcp6
+			addrs.Write(srcIp.To16())
+			addrs.Write(dstIp.To16())
+		}
+		_ = binary.Write(addrs, binary.BigEndian, srcPort)
+		_ = binary.Write(addrs, binary.BigEndian, dstPort)
+	}
+
+	if hostname, _ := options[tunnel.DestinationHostname].(string); hostname != "" {
+		writeProxyProtocolTlv(addrs, proxyProtocolTlvAuthority, hostname)
+	}
+
+	if identity, _ := options[tunnel.SourceIdentityKey].(string); identity != "" {
+		writeProxyProtocolTlv(addrs, proxyProtocolTlvIdentity, identity)
+	}
+
+	header := &bytes.Buffer{}
+	header.Write(proxyProtocolV2Signature)
+	header.WriteByte(proxyProtocolV2Proxy)
+	header.WriteByte(family)
+	_ = binary.Write(header, binary.BigEndian, uint16(addrs.Len()))
+	header.Write(addrs.Bytes())
+
+	return header.Bytes()
+}
+
+func getProxyProtocolAddr(options map[string]interface{}, ipKey, portKey string) (net.IP, uint16) {
+	ipStr, _ := options[ipKey].(string)
+	portStr, _ := options[portKey].(string)
+
+	ip := net.ParseIP(ipStr)
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if ip == nil || err != nil {
+		return nil, 0
+	}
+	return ip, uint16(port)
+}
+
+func writeProxyProtocolTlv(buf *bytes.Buffer, tlvType byte, value string) {
+	// the header length is a uint16, so oversized values are dropped rather than truncated
+	if len(value) > 1024 {
+		return
+	}
+	buf.WriteByte(tlvType)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(value)))
+	buf.WriteString(value)
+}
@@ -0,0 +1,86 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package intercept
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/openziti/ziti/tunnel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProxyProtocolV2Header(t *testing.T) {
+	backendAddr := &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 8080}
+
+	t.Run("ipv4 addresses and tlvs", func(t *testing.T) {
+		req := require.New(t)
+		options := map[string]interface{}{
+			tunnel.SourceIpKey:         "192.168.1.10",
+			tunnel.SourcePortKey:       "40000",
+			tunnel.DestinationIpKey:    "100.64.0.3",
+			tunnel.DestinationPortKey:  "443",
+			tunnel.DestinationHostname: "app.ziti",
+			tunnel.SourceIdentityKey:   "alice",
+		}
+
+		header := newProxyProtocolV2Header(options, backendAddr)
+		req.Equal(proxyProtocolV2Signature, header[:12])
+		req.Equal(byte(0x21), header[12])
+		req.Equal(byte(proxyProtocolFamilyTcp4), header[13])
+		req.Equal(int(binary.BigEndian.Uint16(header[14:16])), len(header)-16)
+
+		body := header[16:]
+		req.Equal(net.ParseIP("192.168.1.10").To4(), net.IP(body[0:4]))
+		req.Equal(net.ParseIP("100.64.0.3").To4(), net.IP(body[4:8]))
+		req.Equal(uint16(40000), binary.BigEndian.Uint16(body[8:10]))
+		req.Equal(uint16(443), binary.BigEndian.Uint16(body[10:12]))
+
+		tlvs := body[12:]
+		req.Equal([]byte{proxyProtocolTlvAuthority, 0, 8}, tlvs[:3])
+		req.Equal("app.ziti", string(tlvs[3:11]))
+		req.Equal([]byte{proxyProtocolTlvIdentity, 0, 5}, tlvs[11:14])
+		req.Equal("alice", string(tlvs[14:]))
+	})
+
+	t.Run("ipv6 source with backend destination", func(t *testing.T) {
+		req := require.New(t)
+		options := map[string]interface{}{
+			tunnel.SourceIpKey:   "fd00::1",
+			tunnel.SourcePortKey: "5000",
+		}
+
+		header := newProxyProtocolV2Header(options, backendAddr)
+		req.Equal(byte(proxyProtocolFamilyTcp6), header[13])
+		req.Equal(uint16(36), binary.BigEndian.Uint16(header[14:16]))
+
+		body := header[16:]
+		req.Equal(net.ParseIP("fd00::1"), net.IP(body[0:16]))
+		req.Equal(net.ParseIP("10.0.0.5").To16(), net.IP(body[16:32]))
+		req.Equal(uint16(5000), binary.BigEndian.Uint16(body[32:34]))
+		req.Equal(uint16(8080), binary.BigEndian.Uint16(body[34:36]))
+	})
+
+	t.Run("unknown client address leaves the family unspecified", func(t *testing.T) {
+		req := require.New(t)
+		header := newProxyProtocolV2Header(map[string]interface{}{}, backendAddr)
+		req.Len(header, 16)
+		req.Equal(byte(proxyProtocolFamilyUnspec), header[13])
+		req.Equal(uint16(0), binary.BigEndian.Uint16(header[14:16]))
+	})
+}
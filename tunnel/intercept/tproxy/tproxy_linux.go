@@ -23,6 +23,7 @@ import (
 	"net"
 	"os/exec"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -166,6 +167,10 @@ type interceptor struct {
 	serviceProxies   cmap.ConcurrentMap[string, *tProxy]
 	ipt              *iptables.IPTables
 	proxyInterceptor intercept.Interceptor
+
+	// tproxy rules are kept in precedence order, since the first matching rule in the chain is used
+	rulesLock sync.Mutex
+	rules     intercept.PrecedenceList
 }
 
 func (self *interceptor) Stop() {
@@ -271,6 +276,32 @@ func (self *interceptor) newTproxy(service *entities.Service, resolver dns.Resol
 	return t, t.Intercept(resolver, tracker)
 }
 
+// insertTproxyRule inserts the rule for the given address into the intercept chain, ahead of the rules for any
+// addresses it takes precedence over. This lets services intercept a specific cidr inside a broader cidr which is
+// intercepted by another service.
+func (self *interceptor) insertTproxyRule(addr *intercept.InterceptAddress) error {
+	self.rulesLock.Lock()
+	defer self.rulesLock.Unlock()
+
+	pos := self.rules.Add(addr) + 1
+	pfxlog.Logger().Infof("Adding rule iptables -t %v -I %v %v %v", mangleTable, dstChain, pos, addr.TproxySpec)
+	if err := self.ipt.Insert(mangleTable, dstChain, pos, addr.TproxySpec...); err != nil {
+		self.rules.Remove(addr)
+		return errors.Wrap(err, "failed to insert rule")
+	}
+	return nil
+}
+
+func (self *interceptor) deleteTproxyRule(addr *intercept.InterceptAddress) error {
+	self.rulesLock.Lock()
+	defer self.rulesLock.Unlock()
+
+	if !self.rules.Remove(addr) {
+		return nil
+	}
+	return self.ipt.Delete(mangleTable, dstChain, addr.TproxySpec...)
+}
+
 func (self *interceptor) addIptablesChain(ipt *iptables.IPTables, table, srcChain, dstChain string) error {
 	chains, err := ipt.ListChains(table)
 	if err != nil {
@@ -334,7 +365,7 @@ func (self *tProxy) acceptTCP() {
 
 func (self *tProxy) acceptUDP() {
 	expirationPolicy := udp_vconn.NewTimeoutExpirationPolicy(self.interceptor.udpIdleTimeout, self.interceptor.udpCheckInterval)
-	vconnMgr := udp_vconn.NewManager(self.service.GetFabricProvider(), udp_vconn.NewUnlimitedConnectionPolicy(), expirationPolicy)
+	vconnMgr := udp_vconn.NewServiceManager(self.service, expirationPolicy)
 	self.generateReadEvents(vconnMgr)
 }
 
@@ -535,6 +566,8 @@ func (self *tProxy) addInterceptAddr(interceptAddr *intercept.InterceptAddress,
 		if err := router.AddLocalAddress(ipNet, "lo"); err != nil {
 			return errors.Wrapf(err, "failed to add local route %v", ipNet)
 		}
+		// services may intercept the same cidr, so the route is only removed once none of them are using it
+		tracker.AddAddress(ipNet.String())
 	}
 	self.addresses = append(self.addresses, interceptAddr)
 
@@ -575,9 +608,8 @@ func (self *tProxy) addInterceptAddr(interceptAddr *intercept.InterceptAddress,
 			fmt.Sprintf("--on-port=%d", port.GetPort()),
 		)
 
-		pfxlog.Logger().Infof("Adding rule iptables -t %v -A %v %v", mangleTable, dstChain, interceptAddr.TproxySpec)
-		if err := self.interceptor.ipt.Insert(mangleTable, dstChain, 1, interceptAddr.TproxySpec...); err != nil {
-			return errors.Wrap(err, "failed to insert rule")
+		if err := self.interceptor.insertTproxyRule(interceptAddr); err != nil {
+			return err
 		}
 
 		if self.interceptor.lanIf != "" {
@@ -683,7 +715,7 @@ func (self *tProxy) StopIntercepting(tracker intercept.AddressTracker) error {
 			}
 		} else {
 			log.Infof("Removing rule iptables -t %v -A %v %v", mangleTable, dstChain, addr.TproxySpec)
-			err := self.interceptor.ipt.Delete(mangleTable, dstChain, addr.TproxySpec...)
+			err := self.interceptor.deleteTproxyRule(addr)
 			if err != nil {
 				errorList = append(errorList, err)
 				log.WithError(err).Errorf("failed to remove iptables rule for service %s", *self.service.Name)
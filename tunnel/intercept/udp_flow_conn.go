@@ -0,0 +1,104 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package intercept
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/ziti/tunnel/entities"
+)
+
+// udpFlowConn applies the udp options from a host config to a dialed udp connection. Oversized datagrams are dropped
+// and, if an idle timeout is configured, reads return io.EOF once no traffic has been seen in either direction for
+// longer than the timeout, which closes the circuit
+type udpFlowConn struct {
+	net.Conn
+	idleTimeout     time.Duration
+	maxDatagramSize int
+	lastUse         atomic.Int64
+}
+
+func newUdpFlowConn(conn net.Conn, options *entities.UdpOptions) net.Conn {
+	result := &udpFlowConn{
+		Conn:            conn,
+		idleTimeout:     options.GetIdleTimeout(0),
+		maxDatagramSize: options.GetMaxDatagramSize(),
+	}
+	result.markUsed()
+	return result
+}
+
+func (self *udpFlowConn) markUsed() {
+	self.lastUse.Store(time.Now().UnixNano())
+}
+
+func (self *udpFlowConn) idleTime() time.Duration {
+	return time.Since(time.Unix(0, self.lastUse.Load()))
+}
+
+func (self *udpFlowConn) isOversized(n int) bool {
+	return self.maxDatagramSize > 0 && n > self.maxDatagramSize
+}
+
+func (self *udpFlowConn) Read(b []byte) (int, error) {
+	for {
+		if self.idleTimeout > 0 {
+			if err := self.Conn.SetReadDeadline(time.Now().Add(self.idleTimeout - self.idleTime())); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := self.Conn.Read(b)
+		if err != nil {
+			var netErr net.Error
+			if self.idleTimeout > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+				if self.idleTime() < self.idleTimeout {
+					continue
+				}
+				pfxlog.Logger().WithField("remoteAddr", self.RemoteAddr().String()).
+					Debugf("udp flow idle for longer than %v, closing", self.idleTimeout)
+				return 0, io.EOF
+			}
+			return n, err
+		}
+
+		if self.isOversized(n) {
+			pfxlog.Logger().WithField("remoteAddr", self.RemoteAddr().String()).
+				Debugf("udp->ziti: dropping datagram larger than %v bytes", self.maxDatagramSize)
+			continue
+		}
+
+		self.markUsed()
+		return n, nil
+	}
+}
+
+func (self *udpFlowConn) Write(b []byte) (int, error) {
+	if self.isOversized(len(b)) {
+		pfxlog.Logger().WithField("remoteAddr", self.RemoteAddr().String()).
+			Debugf("ziti->udp: dropping datagram larger than %v bytes", self.maxDatagramSize)
+		return len(b), nil
+	}
+	n, err := self.Conn.Write(b)
+	self.markUsed()
+	return n, err
+}
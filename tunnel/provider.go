@@ -150,6 +150,8 @@ func (self *contextProvider) accept(listener edge.Listener, hostCtx HostingConte
 			continue
 		}
 
+		SetSourceIdentity(options, conn.SourceIdentifier())
+
 		externalConn, halfClose, err := hostCtx.Dial(options)
 		if err != nil {
 			logger.WithError(err).Error("dial failed")
@@ -38,6 +38,13 @@ type Service interface {
 
 func DialAndRun(fabricProvider FabricProvider, service Service, instanceId string, clientConn net.Conn, appInfo map[string]string, halfClose bool) {
 	log := pfxlog.Logger().WithField("service", service.GetName()).WithField("src", clientConn.RemoteAddr().String())
+
+	// send the client address, so hosting tunnelers can pass it on to hosted applications
+	if srcIp, srcPort := GetIpAndPort(clientConn.RemoteAddr()); srcIp != "" {
+		appInfo[SourceIpKey] = srcIp
+		appInfo[SourcePortKey] = srcPort
+	}
+
 	appInfoJson, err := json.Marshal(appInfo)
 	if err != nil {
 		log.WithError(err).Error("unable to marshal appInfo")
@@ -67,6 +74,16 @@ func GetIpAndPort(addr net.Addr) (string, string) {
 	return "", ""
 }
 
+// SetSourceIdentity records the name of the dialing identity in the given dial options, replacing anything the
+// dialer sent in its app data
+func SetSourceIdentity(options map[string]interface{}, identity string) {
+	if identity == "" {
+		delete(options, SourceIdentityKey)
+	} else {
+		options[SourceIdentityKey] = identity
+	}
+}
+
 func GetAppInfo(protocol, dstHostname, dstIp, dstPort, sourceAddr string) map[string]string {
 	result := map[string]string{}
 	result[DestinationProtocolKey] = protocol
@@ -76,13 +76,28 @@ func (u UnpooledBuffer) Release() {
 }
 
 func NewManager(provider tunnel.FabricProvider, newConnPolicy NewConnPolicy, expirationPolicy ConnExpirationPolicy) Manager {
-	manager := &manager{
+	manager := newManager(provider, newConnPolicy, expirationPolicy)
+	go manager.run()
+	return manager
+}
+
+func newManager(provider tunnel.FabricProvider, newConnPolicy NewConnPolicy, expirationPolicy ConnExpirationPolicy) *manager {
+	return &manager{
 		eventC:           make(chan Event, 4),
 		provider:         provider,
 		connMap:          make(map[string]*udpConn),
 		newConnPolicy:    newConnPolicy,
 		expirationPolicy: expirationPolicy,
 	}
+}
+
+// NewServiceManager creates a Manager which applies the udp options from the service's intercept.v1 config, falling
+// back to the given default expiration policy if no idle timeout is configured
+func NewServiceManager(service *entities.Service, defaultExpiration ConnExpirationPolicy) Manager {
+	options := service.GetInterceptUdpOptions()
+	newConnPolicy, expirationPolicy := NewServicePolicies(options, defaultExpiration)
+	manager := newManager(service.GetFabricProvider(), newConnPolicy, expirationPolicy)
+	manager.maxDatagramSize = options.GetMaxDatagramSize()
 
 	go manager.run()
 	return manager
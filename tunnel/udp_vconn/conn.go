@@ -37,6 +37,8 @@ type udpConn struct {
 	closed      atomic.Bool
 	leftOver    []byte
 	leftOverBuf mempool.PooledBuffer
+
+	maxDatagramSize int
 }
 
 func (conn *udpConn) Service() string {
@@ -44,6 +46,11 @@ func (conn *udpConn) Service() string {
 }
 
 func (conn *udpConn) Accept(buffer mempool.PooledBuffer) {
+	if conn.isOversized(buffer.GetPayload()) {
+		buffer.Release()
+		logrus.WithField("udpConnId", conn.srcAddr.String()).Debugf("udp->ziti: dropping datagram larger than %v bytes", conn.maxDatagramSize)
+		return
+	}
 	logrus.WithField("udpConnId", conn.srcAddr.String()).Debugf("udp->ziti: queuing")
 	select {
 	case conn.readC <- buffer:
@@ -53,6 +60,10 @@ func (conn *udpConn) Accept(buffer mempool.PooledBuffer) {
 	}
 }
 
+func (conn *udpConn) isOversized(b []byte) bool {
+	return conn.maxDatagramSize > 0 && len(b) > conn.maxDatagramSize
+}
+
 func (conn *udpConn) markUsed() {
 	conn.lastUse.Store(time.Now())
 }
@@ -146,6 +157,10 @@ func (conn *udpConn) Read(b []byte) (n int, err error) {
 
 func (conn *udpConn) Write(b []byte) (int, error) {
 	pfxlog.Logger().WithField("udpConnId", conn.srcAddr.String()).Debugf("ziti->udp: %v bytes", len(b))
+	if conn.isOversized(b) {
+		pfxlog.Logger().WithField("udpConnId", conn.srcAddr.String()).Debugf("ziti->udp: dropping datagram larger than %v bytes", conn.maxDatagramSize)
+		return len(b), nil
+	}
 	// TODO: UDP chunking, MTU chunking?
 	n, err := conn.writeConn.WriteTo(b, conn.srcAddr)
 	conn.markUsed()
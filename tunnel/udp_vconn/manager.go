@@ -34,6 +34,7 @@ type manager struct {
 	connMap          map[string]*udpConn
 	newConnPolicy    NewConnPolicy
 	expirationPolicy ConnExpirationPolicy
+	maxDatagramSize  int
 }
 
 func (manager *manager) QueueEvent(event Event) {
@@ -95,6 +96,8 @@ func (manager *manager) CreateWriteQueue(targetAddr *net.UDPAddr, srcAddr net.Ad
 		srcAddr:     srcAddr,
 		manager:     manager,
 		writeConn:   writeConn,
+
+		maxDatagramSize: manager.maxDatagramSize,
 	}
 	conn.markUsed()
 	manager.connMap[srcAddr.String()] = conn
@@ -17,6 +17,7 @@
 package udp_vconn
 
 import (
+	"github.com/openziti/ziti/tunnel/entities"
 	"time"
 )
 
@@ -84,3 +85,23 @@ func (policy *timeoutExpirationPolicy) IsExpired(now, lastUsed time.Time) bool {
 func (policy *timeoutExpirationPolicy) PollFrequency() time.Duration {
 	return policy.checkInterval
 }
+
+// NewServicePolicies returns the connection and expiration policies for a service with the given udp options. If
+// no idle timeout is configured, the given default expiration policy is used
+func NewServicePolicies(options *entities.UdpOptions, defaultExpiration ConnExpirationPolicy) (NewConnPolicy, ConnExpirationPolicy) {
+	newConnPolicy := NewUnlimitedConnectionPolicy()
+	if maxConnections := options.GetMaxConnections(); maxConnections > 0 {
+		newConnPolicy = NewLimitedConnectionPolicyDropLRU(uint32(maxConnections))
+	}
+
+	expirationPolicy := defaultExpiration
+	if timeout := options.GetIdleTimeout(0); timeout > 0 {
+		checkInterval := defaultExpiration.PollFrequency()
+		if half := timeout / 2; half > 0 && half < checkInterval {
+			checkInterval = half
+		}
+		expirationPolicy = NewTimeoutExpirationPolicy(timeout, checkInterval)
+	}
+
+	return newConnPolicy, expirationPolicy
+}
@@ -0,0 +1,62 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package udp_vconn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/tunnel/entities"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServicePoliciesDefaults(t *testing.T) {
+	req := require.New(t)
+
+	defaultExpiration := NewTimeoutExpirationPolicy(30*time.Second, 10*time.Second)
+	newConnPolicy, expirationPolicy := NewServicePolicies(nil, defaultExpiration)
+	req.Equal(Allow, newConnPolicy.NewConnection(100000))
+	req.Equal(defaultExpiration, expirationPolicy)
+
+	newConnPolicy, expirationPolicy = NewServicePolicies(&entities.UdpOptions{}, defaultExpiration)
+	req.Equal(Allow, newConnPolicy.NewConnection(100000))
+	req.Equal(defaultExpiration, expirationPolicy)
+}
+
+func TestNewServicePolicies(t *testing.T) {
+	req := require.New(t)
+
+	idleTimeout := 2 * time.Hour
+	maxConnections := 10
+	options := &entities.UdpOptions{
+		IdleTimeout:    &idleTimeout,
+		MaxConnections: &maxConnections,
+	}
+
+	newConnPolicy, expirationPolicy := NewServicePolicies(options, NewDefaultExpirationPolicy())
+	req.Equal(Allow, newConnPolicy.NewConnection(9))
+	req.Equal(AllowDropLRU, newConnPolicy.NewConnection(10))
+
+	now := time.Now()
+	req.False(expirationPolicy.IsExpired(now, now.Add(-time.Hour)))
+	req.True(expirationPolicy.IsExpired(now, now.Add(-3*time.Hour)))
+	req.Equal(30*time.Second, expirationPolicy.PollFrequency())
+
+	idleTimeout = 10 * time.Second
+	_, expirationPolicy = NewServicePolicies(options, NewDefaultExpirationPolicy())
+	req.Equal(5*time.Second, expirationPolicy.PollFrequency())
+}
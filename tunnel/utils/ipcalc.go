@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+
+	"github.com/gaissmai/extnetip"
 )
 
 func GetCidr(ipOrCidr string) (*net.IPNet, error) {
@@ -41,3 +43,63 @@ func GetCidr(ipOrCidr string) (*net.IPNet, error) {
 
 	return nil, fmt.Errorf("failed to parse '%v' as IP or CIDR", ipOrCidr)
 }
+
+// ExcludeCidrs returns the set of CIDRs which covers ipNet, minus any addresses in the excluded CIDRs. If nothing
+// is excluded, ipNet is returned as is.
+func ExcludeCidrs(ipNet *net.IPNet, excluded []*net.IPNet) []*net.IPNet {
+	if len(excluded) == 0 {
+		return []*net.IPNet{ipNet}
+	}
+
+	pfx, ok := toPrefix(ipNet)
+	if !ok {
+		return []*net.IPNet{ipNet}
+	}
+
+	var excludedPfxs []netip.Prefix
+	for _, e := range excluded {
+		if ePfx, ok := toPrefix(e); ok && ePfx.Addr().Is4() == pfx.Addr().Is4() {
+			excludedPfxs = append(excludedPfxs, ePfx)
+		}
+	}
+
+	var result []*net.IPNet
+	for _, remaining := range excludePrefixes(pfx, excludedPfxs) {
+		result = append(result, &net.IPNet{
+			IP:   remaining.Addr().AsSlice(),
+			Mask: net.CIDRMask(remaining.Bits(), remaining.Addr().BitLen()),
+		})
+	}
+	return result
+}
+
+func excludePrefixes(pfx netip.Prefix, excluded []netip.Prefix) []netip.Prefix {
+	for _, e := range excluded {
+		if !e.Overlaps(pfx) {
+			continue
+		}
+		if e.Bits() <= pfx.Bits() {
+			return nil
+		}
+
+		// the excluded range is inside this one, so split it in half and check each half
+		lower := netip.PrefixFrom(pfx.Addr(), pfx.Bits()+1)
+		_, lowerLast := extnetip.Range(lower)
+		upper := netip.PrefixFrom(lowerLast.Next(), pfx.Bits()+1)
+		return append(excludePrefixes(lower, excluded), excludePrefixes(upper, excluded)...)
+	}
+	return []netip.Prefix{pfx}
+}
+
+func toPrefix(ipNet *net.IPNet) (netip.Prefix, bool) {
+	addr, ok := netip.AddrFromSlice(ipNet.IP)
+	if !ok {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+	ones, _ := ipNet.Mask.Size()
+	if addr.Is4() && ones > 32 {
+		ones -= 96
+	}
+	return netip.PrefixFrom(addr, ones).Masked(), true
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcludeCidrs(t *testing.T) {
+	req := require.New(t)
+
+	parse := func(cidrs ...string) []*net.IPNet {
+		var result []*net.IPNet
+		for _, cidr := range cidrs {
+			ipNet, err := GetCidr(cidr)
+			req.NoError(err)
+			result = append(result, ipNet)
+		}
+		return result
+	}
+
+	toStrings := func(ipNets []*net.IPNet) []string {
+		var result []string
+		for _, ipNet := range ipNets {
+			result = append(result, ipNet.String())
+		}
+		return result
+	}
+
+	base := parse("10.0.0.0/8")[0]
+
+	req.Equal([]string{"10.0.0.0/8"}, toStrings(ExcludeCidrs(base, nil)))
+	req.Equal([]string{"10.0.0.0/8"}, toStrings(ExcludeCidrs(base, parse("192.168.0.0/16", "fd00::/8"))))
+	req.Empty(ExcludeCidrs(base, parse("10.0.0.0/7")))
+
+	req.Equal([]string{"10.0.0.0/9"}, toStrings(ExcludeCidrs(base, parse("10.128.0.0/9"))))
+
+	req.Equal([]string{
+		"10.0.0.0/14",
+		"10.4.0.0/16",
+		"10.6.0.0/15",
+		"10.8.0.0/13",
+		"10.16.0.0/12",
+		"10.32.0.0/11",
+		"10.64.0.0/10",
+		"10.128.0.0/9",
+	}, toStrings(ExcludeCidrs(base, parse("10.5.0.0/16"))))
+
+	req.Equal([]string{"10.1.2.0/31"}, toStrings(ExcludeCidrs(parse("10.1.2.0/30")[0], parse("10.1.2.2", "10.1.2.3"))))
+}
@@ -139,6 +139,25 @@ func (self *AgentOptions) AddAgentOptions(cmd *cobra.Command) {
 	cmd.Flags().DurationVar(&self.timeout, "timeout", 5*time.Second, "Operation timeout")
 }
 
+// clusterWaitOptions lets cluster membership commands wait until the change has been committed. Membership changes
+// may be sent to any controller, and are forwarded to the current leader.
+type clusterWaitOptions struct {
+	wait time.Duration
+}
+
+func (self *clusterWaitOptions) addWaitFlag(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&self.wait, "wait", 0, "Wait up to the given duration for the change to be committed to the cluster")
+}
+
+// prepare adds the wait header to the request, if requested, and returns the timeout to use for the request
+func (self *clusterWaitOptions) prepare(msg *channel.Message, timeout time.Duration) time.Duration {
+	if self.wait > 0 {
+		msg.PutUint64Header(controller.AgentWaitTimeoutHeader, uint64(self.wait.Milliseconds()))
+		return timeout + self.wait
+	}
+	return timeout
+}
+
 func (self *AgentOptions) GetProcess() (*agent.Process, error) {
 	procList, err := agent.GetGopsProcesses()
 	if err != nil {
@@ -28,6 +28,7 @@ import (
 
 type AgentClusterAddAction struct {
 	AgentOptions
+	clusterWaitOptions
 	Voter bool
 }
 
@@ -53,6 +54,7 @@ func NewAgentClusterAdd(p common.OptionsProvider) *cobra.Command {
 	}
 
 	action.AddAgentOptions(cmd)
+	action.addWaitFlag(cmd)
 	cmd.Flags().BoolVar(&action.Voter, "voter", true, "Is this member a voting member")
 
 	return cmd
@@ -63,7 +65,7 @@ func (self *AgentClusterAddAction) makeRequest(ch channel.Channel) error {
 	msg.PutStringHeader(controller.AgentAddrHeader, self.Args[0])
 	msg.PutBoolHeader(controller.AgentIsVoterHeader, self.Voter)
 
-	reply, err := msg.WithTimeout(self.timeout).SendForReply(ch)
+	reply, err := msg.WithTimeout(self.prepare(msg, self.timeout)).SendForReply(ch)
 	if err != nil {
 		return err
 	}
@@ -28,6 +28,7 @@ import (
 
 type AgentClusterRemoveAction struct {
 	AgentOptions
+	clusterWaitOptions
 }
 
 func NewAgentClusterRemove(p common.OptionsProvider) *cobra.Command {
@@ -49,6 +50,7 @@ func NewAgentClusterRemove(p common.OptionsProvider) *cobra.Command {
 		},
 	}
 	action.AddAgentOptions(cmd)
+	action.addWaitFlag(cmd)
 	return cmd
 }
 
@@ -56,7 +58,7 @@ func (o *AgentClusterRemoveAction) makeRequest(ch channel.Channel) error {
 	msg := channel.NewMessage(int32(mgmt_pb.ContentType_RaftRemovePeerRequestType), nil)
 
 	msg.PutStringHeader(controller.AgentIdHeader, o.Args[0])
-	reply, err := msg.WithTimeout(o.timeout).SendForReply(ch)
+	reply, err := msg.WithTimeout(o.prepare(msg, o.timeout)).SendForReply(ch)
 
 	if err != nil {
 		return err
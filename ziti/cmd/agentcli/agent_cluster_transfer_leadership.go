@@ -28,6 +28,7 @@ import (
 
 type AgentTransferLeadershipAction struct {
 	AgentOptions
+	clusterWaitOptions
 }
 
 func NewAgentTransferLeadership(p common.OptionsProvider) *cobra.Command {
@@ -49,6 +50,7 @@ func NewAgentTransferLeadership(p common.OptionsProvider) *cobra.Command {
 		},
 	}
 	action.AddAgentOptions(cmd)
+	action.addWaitFlag(cmd)
 	return cmd
 }
 
@@ -58,7 +60,7 @@ func (o *AgentTransferLeadershipAction) makeRequest(ch channel.Channel) error {
 	if len(o.Args) > 0 {
 		msg.PutStringHeader(controller.AgentIdHeader, o.Args[0])
 	}
-	reply, err := msg.WithTimeout(o.timeout).SendForReply(ch)
+	reply, err := msg.WithTimeout(o.prepare(msg, o.timeout)).SendForReply(ch)
 
 	if err != nil {
 		return err
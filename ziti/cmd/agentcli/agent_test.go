@@ -0,0 +1,38 @@
+package agentcli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/controller"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterWaitOptions(t *testing.T) {
+	t.Run("without --wait no header is sent", func(t *testing.T) {
+		req := require.New(t)
+		options := &clusterWaitOptions{}
+		msg := channel.NewMessage(int32(0), nil)
+
+		req.Equal(5*time.Second, options.prepare(msg, 5*time.Second))
+		_, found := msg.GetUint64Header(controller.AgentWaitTimeoutHeader)
+		req.False(found)
+	})
+
+	t.Run("--wait sends the wait in millis and extends the request timeout", func(t *testing.T) {
+		req := require.New(t)
+		options := &clusterWaitOptions{}
+		cmd := &cobra.Command{}
+		options.addWaitFlag(cmd)
+		req.NoError(cmd.ParseFlags([]string{"--wait", "1m30s"}))
+
+		msg := channel.NewMessage(int32(0), nil)
+		req.Equal(95*time.Second, options.prepare(msg, 5*time.Second))
+
+		waitMillis, found := msg.GetUint64Header(controller.AgentWaitTimeoutHeader)
+		req.True(found)
+		req.Equal(uint64(90_000), waitMillis)
+	})
+}
@@ -22,9 +22,11 @@ import (
 	"github.com/Jeffail/gabs"
 	ziticobra "github.com/openziti/ziti/internal/cobra"
 	"github.com/openziti/ziti/ziti/cmd/common"
+	"github.com/openziti/ziti/ziti/util"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"io"
+	"strings"
 )
 
 const CommonFlagKey = "common"
@@ -35,6 +37,8 @@ type Options struct {
 	OutputJSONRequest  bool
 	OutputJSONResponse bool
 	OutputCSV          bool
+	OutputFormat       string
+	OutputQuery        string
 	OptionsMap         map[string]any
 }
 
@@ -65,12 +69,68 @@ func (options *Options) AddCommonFlags(cmd *cobra.Command) {
 	addCommonFlag(cmd, "output-json")
 	cmd.Flags().BoolVar(&options.OutputJSONRequest, "output-request-json", false, "Output the full JSON request to the Ziti Edge Controller")
 	addCommonFlag(cmd, "output-request-json")
+	// a few commands use --output for an output file
+	if cmd.Flags().Lookup("output") == nil {
+		cmd.Flags().Var(&outputFormatFlag{options: options}, "output", "Output format for controller responses ["+strings.Join(util.OutputFormats, "|")+"]")
+		addCommonFlag(cmd, "output")
+	}
+	cmd.Flags().Var(&outputQueryFlag{options: options}, "query", "JSONPath expression used to select values from controller responses, for example '$.data[*].id'")
+	addCommonFlag(cmd, "query")
 	cmd.Flags().IntVarP(&options.Timeout, "timeout", "", 5, "Timeout for REST operations (specified in seconds)")
 	addCommonFlag(cmd, "timeout")
 	cmd.Flags().BoolVarP(&options.Verbose, "verbose", "", false, "Enable verbose logging")
 	addCommonFlag(cmd, "verbose")
 }
 
+// outputFormatFlag sets the output format used for controller responses. Any format other than table means the
+// response is printed instead of the usual human-readable output, as with --output-json.
+type outputFormatFlag struct {
+	options *Options
+}
+
+func (self *outputFormatFlag) String() string {
+	return self.options.OutputFormat
+}
+
+func (self *outputFormatFlag) Set(value string) error {
+	if err := util.ValidateOutputFormat(value); err != nil {
+		return err
+	}
+	self.options.OutputFormat = strings.ToLower(value)
+	self.options.OutputJSONResponse = self.options.OutputFormat != util.OutputFormatTable || self.options.OutputQuery != ""
+	util.OutputFormat = self.options.OutputFormat
+	return nil
+}
+
+func (self *outputFormatFlag) Type() string {
+	return "string"
+}
+
+// outputQueryFlag sets the JSONPath query applied to controller responses. Like --output-json, it replaces the usual
+// human-readable output.
+type outputQueryFlag struct {
+	options *Options
+}
+
+func (self *outputQueryFlag) String() string {
+	return self.options.OutputQuery
+}
+
+func (self *outputQueryFlag) Set(value string) error {
+	query, err := util.CompileJsonPath(value)
+	if err != nil {
+		return err
+	}
+	self.options.OutputQuery = value
+	self.options.OutputJSONResponse = true
+	util.OutputQuery = query
+	return nil
+}
+
+func (self *outputQueryFlag) Type() string {
+	return "string"
+}
+
 func (options *Options) LogCreateResult(entityType string, result *gabs.Container, err error) error {
 	if err != nil {
 		return err
@@ -24,7 +24,10 @@ import (
 	edgeSubCmd "github.com/openziti/ziti/controller/subcmd"
 	"github.com/openziti/ziti/ziti/cmd/ascode/importer"
 	"github.com/openziti/ziti/ziti/cmd/ops"
+	"github.com/openziti/ziti/ziti/cmd/ops/apply"
 	"github.com/openziti/ziti/ziti/cmd/ops/database"
+	"github.com/openziti/ziti/ziti/cmd/ops/snapshot"
+	"github.com/openziti/ziti/ziti/cmd/ops/traffic"
 	"github.com/openziti/ziti/ziti/cmd/ops/verify"
 	"github.com/openziti/ziti/ziti/enroll"
 	"github.com/openziti/ziti/ziti/run"
@@ -169,8 +172,11 @@ func NewV1CmdRoot(in io.Reader, out, err io.Writer, cmd *cobra.Command) *cobra.C
 	opsCommands.AddCommand(ops.NewCmdLogFormat(out, err))
 	opsCommands.AddCommand(ops.NewUnwrapIdentityFileCommand(out, err))
 	opsCommands.AddCommand(verify.NewVerifyCommand(out, err, context.Background()))
+	opsCommands.AddCommand(traffic.NewTrafficCmd(out, err))
+	opsCommands.AddCommand(snapshot.NewSnapshotCmd(out, err))
 	opsCommands.AddCommand(exporter.NewExportCmd(out, err))
 	opsCommands.AddCommand(importer.NewImportCmd(out, err))
+	opsCommands.AddCommand(apply.NewApplyCmd(out, err))
 
 	groups := templates.CommandGroups{
 		{
@@ -196,6 +202,7 @@ func NewV1CmdRoot(in io.Reader, out, err io.Writer, cmd *cobra.Command) *cobra.C
 			Commands: []*cobra.Command{
 				fabricCommand,
 				edgeCommand,
+				fabric.NewDashboardCmd(p),
 			},
 		},
 		{
@@ -280,8 +287,11 @@ func NewV2CmdRoot(in io.Reader, out, err io.Writer, cmd *cobra.Command) *cobra.C
 	opsCommands.AddCommand(ops.NewCmdLogFormat(out, err))
 	opsCommands.AddCommand(ops.NewUnwrapIdentityFileCommand(out, err))
 	opsCommands.AddCommand(verify.NewVerifyCommand(out, err, context.Background()))
+	opsCommands.AddCommand(traffic.NewTrafficCmd(out, err))
+	opsCommands.AddCommand(snapshot.NewSnapshotCmd(out, err))
 	opsCommands.AddCommand(exporter.NewExportCmd(out, err))
 	opsCommands.AddCommand(importer.NewImportCmd(out, err))
+	opsCommands.AddCommand(apply.NewApplyCmd(out, err))
 
 	groups := templates.CommandGroups{
 		{
@@ -307,6 +317,7 @@ func NewV2CmdRoot(in io.Reader, out, err io.Writer, cmd *cobra.Command) *cobra.C
 			Commands: []*cobra.Command{
 				fabricCommand,
 				edgeCommand,
+				fabric.NewDashboardCmd(p),
 			},
 		},
 		{
@@ -422,7 +433,11 @@ func NewRouterCmd() *cobra.Command {
 	runRouterCmd := run.NewRunRouterCmd()
 	runRouterCmd.Use = "run <config>"
 
+	runRouterFromEnvCmd := run.NewRunRouterFromEnvCmd()
+	runRouterFromEnvCmd.Use = "run-from-env"
+
 	cmd.AddCommand(runRouterCmd)
+	cmd.AddCommand(runRouterFromEnvCmd)
 	cmd.AddCommand(enroll.NewEnrollEdgeRouterCmd())
 
 	versionCmd := common.NewVersionCmd()
@@ -166,6 +166,7 @@ type RouterTemplateValues struct {
 	Wss                WSSRouterTemplateValues
 	Forwarder          RouterForwarderTemplateValues
 	Listener           RouterListenerTemplateValues
+	Link               RouterLinkTemplateValues
 	IsHA               bool
 }
 
@@ -185,6 +186,13 @@ type EdgeRouterTemplateValues struct {
 	CsrSans          string
 }
 
+// RouterLinkTemplateValues restricts which routers a router will form links with. When empty, the link
+// listener and dialer use the default link group.
+type RouterLinkTemplateValues struct {
+	ListenerGroups []string
+	DialerGroups   []string
+}
+
 type WSSRouterTemplateValues struct {
 	WriteTimeout      time.Duration
 	ReadTimeout       time.Duration
@@ -17,12 +17,15 @@
 package create
 
 import (
+	"bytes"
 	_ "embed"
 	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"os"
 	"strings"
+	"text/template"
 )
 
 const (
@@ -92,6 +95,52 @@ func NewCmdCreateConfigRouter(routerOptions *CreateConfigRouterOptions) *NewCrea
 	return cmd
 }
 
+// RenderRouterConfig renders a router config of the given type, edge or fabric, from the ZITI_* environment
+// variables and the given options, in the same way as `ziti create config router`. The config is returned
+// rather than written out.
+func RenderRouterConfig(routerType string, options *CreateConfigRouterOptions) ([]byte, error) {
+	data := &ConfigTemplateValues{}
+	data.PopulateConfigValues()
+	data.Router.Name = validateRouterName(options.RouterName)
+	SetZitiRouterIdentity(&data.Router, data.Router.Name)
+	data.Router.IsHA = options.IsHA
+
+	out := &bytes.Buffer{}
+
+	switch routerType {
+	case "edge":
+		if options.TunnelerMode == "" {
+			options.TunnelerMode = defaultTunnelerMode
+		}
+		if err := options.validateEdgeRouter(); err != nil {
+			return nil, err
+		}
+		data.Router.IsWss = options.WssEnabled
+		data.Router.IsPrivate = options.IsPrivate
+		data.Router.TunnelerMode = options.TunnelerMode
+		data.Router.Edge.LanInterface = options.LanInterface
+		data.Router.Edge.Resolver = cmdhelper.GetZitiEdgeRouterResolver()
+		data.Router.Edge.DnsSvcIpRange = cmdhelper.GetZitiEdgeRouterDnsSvcIpRange()
+		if err := options.renderEdgeRouter(data, out); err != nil {
+			return nil, err
+		}
+	case "fabric":
+		data.Router.IsFabric = true
+		data.Router.IsPrivate = options.IsPrivate
+		tmpl, err := template.New("fabric-router-config").Parse(routerConfigFabricTemplate)
+		if err != nil {
+			return nil, err
+		}
+		if err = tmpl.Execute(out, data); err != nil {
+			return nil, errors.Wrap(err, "unable to execute template")
+		}
+	default:
+		return nil, errors.Errorf("unknown router type [%s], should be \"edge\" or \"fabric\"", routerType)
+	}
+
+	return out.Bytes(), nil
+}
+
 func (options *CreateConfigRouterOptions) addFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVarP(&options.RouterName, optionRouterName, "n", "", "name of the router")
 	err := cmd.MarkPersistentFlagRequired(optionRouterName)
@@ -20,6 +20,7 @@ import (
 	_ "embed"
 	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
 	"github.com/openziti/ziti/ziti/cmd/templates"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -108,21 +109,7 @@ func (options *CreateConfigRouterOptions) addEdgeFlags(cmd *cobra.Command) {
 
 // run implements the command
 func (options *CreateConfigRouterOptions) runEdgeRouter(data *ConfigTemplateValues) error {
-	// Ensure private and wss are not both used
-	if options.IsPrivate && options.WssEnabled {
-		return errors.New("Flags for private and wss configs are mutually exclusive. You must choose private or wss, not both")
-	}
-
-	// Make sure the tunneler mode is valid
-	if options.TunnelerMode != hostTunMode &&
-		options.TunnelerMode != tproxyTunMode &&
-		options.TunnelerMode != proxyTunMode &&
-		options.TunnelerMode != noneTunMode {
-		return errors.New("Unknown tunneler mode [" + options.TunnelerMode + "] provided, should be \"" + noneTunMode + "\", \"" + hostTunMode + "\", \"" + proxyTunMode + "\", or \"" + tproxyTunMode + "\"")
-	}
-
-	tmpl, err := template.New("edge-router-config").Parse(routerConfigEdgeTemplate)
-	if err != nil {
+	if err := options.validateEdgeRouter(); err != nil {
 		return err
 	}
 
@@ -134,6 +121,7 @@ func (options *CreateConfigRouterOptions) runEdgeRouter(data *ConfigTemplateValu
 			return err
 		}
 
+		var err error
 		f, err = os.Create(options.Output)
 		logrus.Debugf("Created output file: %s", options.Output)
 		if err != nil {
@@ -144,11 +132,41 @@ func (options *CreateConfigRouterOptions) runEdgeRouter(data *ConfigTemplateValu
 	}
 	defer func() { _ = f.Close() }()
 
-	if err := tmpl.Execute(f, data); err != nil {
-		return errors.Wrap(err, "unable to execute template")
+	if err := options.renderEdgeRouter(data, f); err != nil {
+		return err
 	}
 
 	logrus.Debugf("Edge Router configuration generated successfully and written to: %s", options.Output)
 
 	return nil
 }
+
+func (options *CreateConfigRouterOptions) validateEdgeRouter() error {
+	// Ensure private and wss are not both used
+	if options.IsPrivate && options.WssEnabled {
+		return errors.New("Flags for private and wss configs are mutually exclusive. You must choose private or wss, not both")
+	}
+
+	// Make sure the tunneler mode is valid
+	if options.TunnelerMode != hostTunMode &&
+		options.TunnelerMode != tproxyTunMode &&
+		options.TunnelerMode != proxyTunMode &&
+		options.TunnelerMode != noneTunMode {
+		return errors.New("Unknown tunneler mode [" + options.TunnelerMode + "] provided, should be \"" + noneTunMode + "\", \"" + hostTunMode + "\", \"" + proxyTunMode + "\", or \"" + tproxyTunMode + "\"")
+	}
+
+	return nil
+}
+
+func (options *CreateConfigRouterOptions) renderEdgeRouter(data *ConfigTemplateValues, out io.Writer) error {
+	tmpl, err := template.New("edge-router-config").Parse(routerConfigEdgeTemplate)
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.Execute(out, data); err != nil {
+		return errors.Wrap(err, "unable to execute template")
+	}
+
+	return nil
+}
@@ -309,3 +309,51 @@ func TestAltServerCerts(t *testing.T) {
 	assert.Equal(t, certPath, rtv.AltServerCert)
 	assert.Equal(t, keyPath, rtv.AltServerKey)
 }
+
+func TestRenderRouterConfig(t *testing.T) {
+	clearEnvAndInitializeTestData()
+	_ = os.Setenv(constants.CtrlAdvertisedAddressVarName, "ctrl.example.org")
+	_ = os.Setenv(constants.CtrlAdvertisedPortVarName, "6262")
+
+	out, err := RenderRouterConfig("edge", &CreateConfigRouterOptions{RouterName: "envRouter", TunnelerMode: tproxyTunMode})
+	assert.NoError(t, err)
+
+	config := RouterConfig{}
+	assert.NoError(t, yaml.Unmarshal(out, &config))
+	assert.Equal(t, "tls:ctrl.example.org:6262", config.Ctrl.Endpoint)
+	assert.Equal(t, cmdhelper.GetZitiHome()+"/envRouter.cert", config.Identity.Cert)
+
+	foundTunnel := false
+	for _, listener := range config.Listeners {
+		if listener.Binding == "tunnel" {
+			foundTunnel = true
+			assert.Equal(t, tproxyTunMode, listener.Options.Mode)
+		}
+	}
+	assert.True(t, foundTunnel)
+
+	// tunneler mode defaults to host
+	out, err = RenderRouterConfig("edge", &CreateConfigRouterOptions{RouterName: "envRouter"})
+	assert.NoError(t, err)
+	config = RouterConfig{}
+	assert.NoError(t, yaml.Unmarshal(out, &config))
+	for _, listener := range config.Listeners {
+		if listener.Binding == "tunnel" {
+			assert.Equal(t, hostTunMode, listener.Options.Mode)
+		}
+	}
+
+	out, err = RenderRouterConfig("fabric", &CreateConfigRouterOptions{RouterName: "envRouter"})
+	assert.NoError(t, err)
+	config = RouterConfig{}
+	assert.NoError(t, yaml.Unmarshal(out, &config))
+	for _, listener := range config.Listeners {
+		assert.NotEqual(t, "edge", listener.Binding)
+	}
+
+	_, err = RenderRouterConfig("edge", &CreateConfigRouterOptions{RouterName: "envRouter", TunnelerMode: "bogus"})
+	assert.Error(t, err)
+
+	_, err = RenderRouterConfig("other", &CreateConfigRouterOptions{RouterName: "envRouter"})
+	assert.Error(t, err)
+}
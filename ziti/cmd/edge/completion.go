@@ -0,0 +1,249 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// completionTimeout is how long, in seconds, completion waits for the controller, so a slow or unreachable
+	// controller doesn't hang the shell
+	completionTimeout  = 2
+	completionCacheTtl = 30 * time.Second
+	completionLimit    = 500
+)
+
+type completionEntity struct {
+	Id   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type completionCache struct {
+	CreatedAt time.Time          `json:"createdAt"`
+	Complete  bool               `json:"complete"`
+	Entities  []completionEntity `json:"entities"`
+}
+
+// completionLister lists entities for completion. It's a variable so tests can replace it.
+var completionLister = func(entityType string, filter string) ([]*gabs.Container, *api.Paging, error) {
+	params := url.Values{}
+	params.Add("filter", filter)
+	return ListEntitiesOfType(entityType, params, false, nil, completionTimeout, false)
+}
+
+// isNamedEntityType returns false for entity types which can only be referenced by id
+func isNamedEntityType(entityType string) bool {
+	switch entityType {
+	case "terminators", "api-sessions", "sessions", "authenticators", "enrollments":
+		return false
+	}
+	return true
+}
+
+// completeEntities returns a completion function for commands taking entities of the given type as arguments. It
+// queries the logged in controller and completes entity names, or ids for entity types without names. Results are
+// cached per login for a short time, so repeated completions don't each wait on the controller. If maxArgs is
+// greater than zero, only that many arguments are completed.
+func completeEntities(entityType string, maxArgs int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if maxArgs > 0 && len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		cachePath, err := getCompletionCachePath(entityType)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		entities, err := getCompletionEntities(cachePath, entityType, toComplete)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return matchCompletions(entities, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// withEntityCompletion completes the first argument of the given command, or of its subcommands if it only groups
+// other commands, with entities of the given type
+func withEntityCompletion(cmd *cobra.Command, entityType string) *cobra.Command {
+	if cmd.HasSubCommands() {
+		for _, child := range cmd.Commands() {
+			withEntityCompletion(child, entityType)
+		}
+	} else {
+		cmd.ValidArgsFunction = completeEntities(entityType, 1)
+	}
+	return cmd
+}
+
+// getCompletionCachePath returns the cache file for the given entity type. Caches are kept per controller and
+// identity, since different logins may see different entities.
+func getCompletionCachePath(entityType string) (string, error) {
+	config, _, err := util.LoadRestClientConfig()
+	if err != nil {
+		return "", err
+	}
+
+	clientIdentity, err := util.LoadSelectedIdentity()
+	if err != nil {
+		return "", err
+	}
+
+	baseUrl, err := clientIdentity.GetBaseUrlForApi(util.EdgeAPI)
+	if err != nil {
+		return "", err
+	}
+
+	configDir, err := util.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	key := sha256.Sum256([]byte(config.GetIdentity() + "|" + baseUrl))
+	return filepath.Join(configDir, "completion-cache", hex.EncodeToString(key[:8]), entityType+".json"), nil
+}
+
+// getCompletionEntities returns the entities of the given type, from the cache if it's fresh. If the controller
+// has more entities than are cached, entities matching what's being completed are queried directly.
+func getCompletionEntities(cachePath, entityType, toComplete string) ([]completionEntity, error) {
+	cache := loadCompletionCache(cachePath)
+	if cache == nil {
+		filter := fmt.Sprintf("true limit %d", completionLimit)
+		entities, complete, err := listCompletionEntities(entityType, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		cache = &completionCache{
+			CreatedAt: time.Now(),
+			Complete:  complete,
+			Entities:  entities,
+		}
+		saveCompletionCache(cachePath, cache)
+	}
+
+	if cache.Complete || toComplete == "" {
+		return cache.Entities, nil
+	}
+
+	field := "id"
+	if isNamedEntityType(entityType) {
+		field = "name"
+	}
+	filter := fmt.Sprintf("%s contains %s limit %d", field, quoteFilterValue(toComplete), completionLimit)
+	entities, _, err := listCompletionEntities(entityType, filter)
+	return entities, err
+}
+
+func quoteFilterValue(val string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(val) + `"`
+}
+
+func listCompletionEntities(entityType, filter string) ([]completionEntity, bool, error) {
+	children, paging, err := completionLister(entityType, filter)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result []completionEntity
+	for _, child := range children {
+		id, _ := child.Path("id").Data().(string)
+		name, _ := child.Path("name").Data().(string)
+		if !isNamedEntityType(entityType) {
+			name = ""
+		}
+		result = append(result, completionEntity{Id: id, Name: name})
+	}
+
+	complete := paging == nil || paging.Count <= int64(len(result))
+	return result, complete, nil
+}
+
+func loadCompletionCache(path string) *completionCache {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	cache := &completionCache{}
+	if err = json.Unmarshal(data, cache); err != nil {
+		return nil
+	}
+
+	if time.Since(cache.CreatedAt) > completionCacheTtl {
+		return nil
+	}
+
+	return cache
+}
+
+// saveCompletionCache writes the cache. Failures are ignored, as the cache is only an optimization.
+func saveCompletionCache(path string, cache *completionCache) {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	_ = os.WriteFile(path, data, 0600)
+}
+
+// matchCompletions returns the names, or ids for unnamed entities, which start with toComplete, skipping entities
+// already given as arguments. Named entities are described by their id.
+func matchCompletions(entities []completionEntity, args []string, toComplete string) []cobra.Completion {
+	var result []cobra.Completion
+	for _, entity := range entities {
+		value := entity.Name
+		if value == "" {
+			value = entity.Id
+		}
+
+		if !strings.HasPrefix(value, toComplete) || slices.Contains(args, value) || slices.Contains(args, entity.Id) {
+			continue
+		}
+
+		if value == entity.Id {
+			result = append(result, value)
+		} else {
+			result = append(result, cobra.CompletionWithDesc(value, entity.Id))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
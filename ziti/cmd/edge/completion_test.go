@@ -0,0 +1,97 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/gabs"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchCompletions(t *testing.T) {
+	entities := []completionEntity{
+		{Id: "s2", Name: "web"},
+		{Id: "s1", Name: "ssh"},
+		{Id: "s3", Name: "sftp"},
+	}
+
+	t.Run("names matching the prefix are completed with ids as descriptions", func(t *testing.T) {
+		req := require.New(t)
+		result := matchCompletions(entities, nil, "s")
+		req.Equal([]cobra.Completion{"sftp\ts3", "ssh\ts1"}, result)
+	})
+
+	t.Run("entities already given as arguments are skipped", func(t *testing.T) {
+		req := require.New(t)
+		result := matchCompletions(entities, []string{"ssh", "s2"}, "")
+		req.Equal([]cobra.Completion{"sftp\ts3"}, result)
+	})
+
+	t.Run("unnamed entities are completed by id", func(t *testing.T) {
+		req := require.New(t)
+		result := matchCompletions([]completionEntity{{Id: "t1"}, {Id: "x1"}}, nil, "t")
+		req.Equal([]cobra.Completion{"t1"}, result)
+	})
+}
+
+func TestGetCompletionEntities(t *testing.T) {
+	var filters []string
+	total := int64(2)
+
+	origLister := completionLister
+	defer func() { completionLister = origLister }()
+
+	completionLister = func(entityType string, filter string) ([]*gabs.Container, *api.Paging, error) {
+		filters = append(filters, filter)
+		ssh, _ := gabs.ParseJSON([]byte(`{"id": "s1", "name": "ssh"}`))
+		web, _ := gabs.ParseJSON([]byte(`{"id": "s2", "name": "web"}`))
+		return []*gabs.Container{ssh, web}, &api.Paging{Count: total}, nil
+	}
+
+	t.Run("results are cached", func(t *testing.T) {
+		req := require.New(t)
+		filters = nil
+		cachePath := filepath.Join(t.TempDir(), "services.json")
+
+		entities, err := getCompletionEntities(cachePath, "services", "s")
+		req.NoError(err)
+		req.Len(entities, 2)
+
+		entities, err = getCompletionEntities(cachePath, "services", "w")
+		req.NoError(err)
+		req.Len(entities, 2)
+		req.Equal([]string{"true limit 500"}, filters)
+	})
+
+	t.Run("incomplete caches query for what's being completed", func(t *testing.T) {
+		req := require.New(t)
+		filters = nil
+		total = 1000
+		cachePath := filepath.Join(t.TempDir(), "services.json")
+
+		_, err := getCompletionEntities(cachePath, "services", "")
+		req.NoError(err)
+
+		_, err = getCompletionEntities(cachePath, "services", `s"h`)
+		req.NoError(err)
+		req.Equal([]string{"true limit 500", `name contains "s\"h" limit 500`}, filters)
+	})
+}
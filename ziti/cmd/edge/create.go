@@ -49,12 +49,13 @@ func newCreateCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCreateEdgeRouterPolicyCmd(out, errOut))
 	cmd.AddCommand(newCreateEnrollmentCmd(out, errOut))
 	cmd.AddCommand(newCreateIdentityCmd(out, errOut))
+	cmd.AddCommand(newCreateIdentitiesCmd(out, errOut))
 	cmd.AddCommand(newCreatePostureCheckCmd(out, errOut))
 	cmd.AddCommand(newCreateServiceCmd(out, errOut))
 	cmd.AddCommand(NewCreateServiceEdgeRouterPolicyCmd(out, errOut))
 	cmd.AddCommand(newCreateServicePolicyCmd(out, errOut))
 	cmd.AddCommand(newCreateTerminatorCmd(out, errOut))
-	cmd.AddCommand(newCreateTransitRouterCmd(out, errOut))
+	cmd.AddCommand(NewCreateTransitRouterCmd(out, errOut))
 	cmd.AddCommand(newCreateExtJwtSignerCmd(out, errOut))
 	cmd.AddCommand(newCreateAuthPolicyCmd(out, errOut))
 
@@ -17,6 +17,7 @@
 package edge
 
 import (
+	"errors"
 	"fmt"
 	"github.com/openziti/edge-api/rest_management_api_client/certificate_authority"
 	"github.com/openziti/edge-api/rest_model"
@@ -32,6 +33,7 @@ type createCaOptions struct {
 	api.EntityOptions
 	Ca                     rest_model.CaCreate
 	IdentityRolesFromFlags []string
+	Spiffe                 bool
 }
 
 // newCreateCaCmd creates the 'edge controller create ca local' command for the given entity type
@@ -108,6 +110,7 @@ func newCreateCaCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.Flags().StringVarP(options.Ca.ExternalIDClaim.MatcherCriteria, "matcher-criteria", "x", "", "criteria used with the given matcher")
 	cmd.Flags().StringVarP(options.Ca.ExternalIDClaim.Parser, "parser", "p", "", "the parser to use on found external ids")
 	cmd.Flags().StringVarP(options.Ca.ExternalIDClaim.ParserCriteria, "parser-criteria", "z", "", "criteria used with the given parser")
+	cmd.Flags().BoolVar(&options.Spiffe, "spiffe", false, "Use the spiffe:// URI SAN as the external id, for identities authenticating with SPIFFE X.509 SVIDs")
 
 	options.AddCommonFlags(cmd)
 
@@ -132,6 +135,17 @@ func runCreateCa(options *createCaOptions) (err error) {
 		params.Ca.Tags.SubTags[k] = v
 	}
 
+	if options.Spiffe {
+		if *params.Ca.ExternalIDClaim.Location != "" {
+			return errors.New("--spiffe may not be combined with --location")
+		}
+		params.Ca.ExternalIDClaim.Location = Ptr(rest_model.ExternalIDClaimLocationSANURI)
+		params.Ca.ExternalIDClaim.Matcher = Ptr(rest_model.ExternalIDClaimMatcherSCHEME)
+		params.Ca.ExternalIDClaim.MatcherCriteria = Ptr("spiffe")
+		params.Ca.ExternalIDClaim.Parser = Ptr(rest_model.ExternalIDClaimParserNONE)
+		params.Ca.ExternalIDClaim.ParserCriteria = Ptr("")
+	}
+
 	//clear external id claims if location is not set
 	if params.Ca.ExternalIDClaim.Location == nil || *params.Ca.ExternalIDClaim.Location == "" {
 		params.Ca.ExternalIDClaim = nil
@@ -62,9 +62,9 @@ func newCreateExtJwtSignerCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	}
 
 	cmd := &cobra.Command{
-		Use:     "ext-jwt-signer <name> <issuer> (-u <jwksEndpoint>|-p <cert pem>|-f <cert file>) [-a <audience> -c <claimProperty> --client-id <clientId> --scope <scope1> --scope <scopeN> -xe --target-token=ACCESS|ID]",
+		Use:     "ext-jwt-signer <name> <issuer> [-u <jwksEndpoint>|-p <cert pem>|-f <cert file>] [-a <audience> -c <claimProperty> --client-id <clientId> --scope <scope1> --scope <scopeN> -xe --target-token=ACCESS|ID]",
 		Short:   "creates an external JWT signer managed by the Ziti Edge Controller",
-		Long:    "creates an external JWT signer managed by the Ziti Edge Controller",
+		Long:    "creates an external JWT signer managed by the Ziti Edge Controller. If no JWKS endpoint or certificate is given, keys are located using the issuer's OIDC discovery document",
 		Aliases: []string{"external-jwt-signer"},
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 2 {
@@ -153,6 +153,9 @@ func runCreateExtJwtSigner(options *createExtJwtSignerOptions) (err error) {
 		}
 		pemStr := string(pem)
 		options.ExtJwtSigner.CertPem = &pemStr
+	} else if !hasCertPem {
+		// no key source given, the controller will use OIDC discovery on the issuer
+		options.ExtJwtSigner.CertPem = nil
 	}
 
 	for k, v := range options.GetTags() {
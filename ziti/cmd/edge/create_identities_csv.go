@@ -0,0 +1,320 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/openziti/edge-api/rest_management_api_client"
+	"github.com/openziti/edge-api/rest_management_api_client/identity"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/sdk-golang/ziti"
+	"github.com/openziti/sdk-golang/ziti/enroll"
+	"github.com/openziti/ziti/internal/rest/mgmt"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type createIdentitiesOptions struct {
+	api.Options
+	csvFile          string
+	outputDir        string
+	stateFile        string
+	roleAttributes   []string
+	attributeColumns []string
+	enroll           bool
+	keyAlg           ziti.KeyAlgVar
+	failFast         bool
+}
+
+// newCreateIdentitiesCmd creates the 'edge create identities' command
+func newCreateIdentitiesCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &createIdentitiesOptions{
+		Options: api.Options{
+			CommonOptions: common.CommonOptions{Out: out, Err: errOut},
+		},
+	}
+	_ = options.keyAlg.Set("RSA")
+
+	cmd := &cobra.Command{
+		Use:   "identities --from-csv <file>",
+		Short: "creates identities in bulk from a CSV file",
+		Long: "Creates identities in bulk from a CSV file with a header line. Only the name column is required. The\n" +
+			"admin, externalId, authPolicy, updb and roleAttributes columns are used if present. An enrollment JWT is\n" +
+			"written to the output directory for each identity, or with --enroll, ott enrollments are completed and the\n" +
+			"identity files written instead. Progress is recorded in a state file, so if some identities fail, running\n" +
+			"the same command again retries only the identities which didn't complete.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := runCreateIdentities(options)
+			cmdhelper.CheckErr(err)
+		},
+		SuggestFor: []string{},
+	}
+
+	cmd.Flags().StringVar(&options.csvFile, "from-csv", "", "CSV file listing the identities to create")
+	cmd.Flags().StringVarP(&options.outputDir, "output-dir", "d", ".", "Directory to write enrollment JWTs or identity files to")
+	cmd.Flags().StringVar(&options.stateFile, "state-file", "", "File used to track progress, so a failed run can be resumed. Defaults to <csv file name>.state.json in the output directory")
+	cmd.Flags().StringSliceVarP(&options.roleAttributes, "role-attributes", "a", nil, "comma-separated role attributes to give every identity")
+	cmd.Flags().StringSliceVar(&options.attributeColumns, "role-attribute-columns", nil, "comma-separated CSV columns whose values are added as role attributes")
+	cmd.Flags().BoolVar(&options.enroll, "enroll", false, "Complete ott enrollments and write identity files instead of JWTs")
+	cmd.Flags().VarP(&options.keyAlg, "keyAlg", "K", "Crypto algorithm to use when generating private keys for --enroll")
+	cmd.Flags().BoolVar(&options.failFast, "fail-fast", false, "Stop at the first identity which fails, instead of continuing with the rest")
+	_ = cmd.MarkFlagRequired("from-csv")
+	options.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+func runCreateIdentities(o *createIdentitiesOptions) error {
+	csvFile, err := os.Open(o.csvFile)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open identity CSV %s", o.csvFile)
+	}
+	rows, err := ParseIdentityCsv(csvFile, o.attributeColumns)
+	_ = csvFile.Close()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(o.outputDir, 0700); err != nil {
+		return errors.Wrapf(err, "unable to create output directory %s", o.outputDir)
+	}
+
+	if o.stateFile == "" {
+		o.stateFile = filepath.Join(o.outputDir, filepath.Base(o.csvFile)+".state.json")
+	}
+
+	state, err := LoadIdentityCsvState(o.stateFile)
+	if err != nil {
+		return err
+	}
+	state.Source = o.csvFile
+
+	client, err := util.NewEdgeManagementClient(o)
+	if err != nil {
+		return err
+	}
+
+	creator := &csvIdentityCreator{
+		options:      o,
+		client:       client,
+		state:        state,
+		authPolicies: map[string]string{},
+	}
+
+	for _, row := range rows {
+		entry := state.Get(row.Name)
+		if err = creator.createIdentity(row, entry); err != nil {
+			creator.failed++
+			entry.Update(IdentityCsvStatusFailed, err)
+			_, _ = fmt.Fprintf(o.Err, "failed to create identity %s from line %d: %v\n", row.Name, row.Line, err)
+		}
+
+		if saveErr := state.Save(o.stateFile); saveErr != nil {
+			return saveErr
+		}
+
+		if err != nil && o.failFast {
+			break
+		}
+	}
+
+	_, _ = fmt.Fprintf(o.Out, "identities created: %d, already complete: %d, failed: %d\n", creator.created, creator.skipped, creator.failed)
+
+	if creator.failed > 0 {
+		return errors.Errorf("%d identities failed, fix the errors and run the command again to resume, progress is recorded in %s", creator.failed, o.stateFile)
+	}
+	return nil
+}
+
+type csvIdentityCreator struct {
+	options      *createIdentitiesOptions
+	client       *rest_management_api_client.ZitiEdgeManagement
+	state        *IdentityCsvState
+	authPolicies map[string]string
+	created      int
+	skipped      int
+	failed       int
+}
+
+func (self *csvIdentityCreator) createIdentity(row *IdentityCsvRow, entry *IdentityCsvStateEntry) error {
+	if entry.Status == IdentityCsvStatusComplete {
+		if _, err := os.Stat(entry.OutputFile); err == nil {
+			self.skipped++
+			return nil
+		}
+	}
+
+	if entry.Id == "" {
+		if existing := mgmt.IdentityFromFilter(self.client, mgmt.NameFilter(row.Name)); existing != nil {
+			return errors.Errorf("identity already exists with id %s and wasn't created by this import", *existing.ID)
+		}
+
+		id, err := self.create(row)
+		if err != nil {
+			return err
+		}
+
+		entry.Id = id
+		entry.Update(IdentityCsvStatusCreated, nil)
+		if err = self.state.Save(self.options.stateFile); err != nil {
+			return err
+		}
+		self.created++
+		_, _ = fmt.Fprintf(self.options.Out, "created identity %s with id %s\n", row.Name, id)
+	}
+
+	outputFile, err := self.writeOutput(row, entry.Id)
+	if err != nil {
+		return err
+	}
+
+	entry.OutputFile = outputFile
+	entry.Update(IdentityCsvStatusComplete, nil)
+	return nil
+}
+
+func (self *csvIdentityCreator) create(row *IdentityCsvRow) (string, error) {
+	identityType := rest_model.IdentityTypeDefault
+	roleAttributes := rest_model.Attributes(append(append([]string{}, self.options.roleAttributes...), row.RoleAttributes...))
+	isAdmin := row.IsAdmin
+
+	create := &rest_model.IdentityCreate{
+		Name:           &row.Name,
+		Type:           &identityType,
+		IsAdmin:        &isAdmin,
+		RoleAttributes: &roleAttributes,
+		Enrollment:     &rest_model.IdentityCreateEnrollment{},
+	}
+
+	if row.Updb != "" {
+		create.Enrollment.Updb = row.Updb
+	} else {
+		create.Enrollment.Ott = true
+	}
+
+	if row.ExternalId != "" {
+		create.ExternalID = &row.ExternalId
+	}
+
+	if row.AuthPolicy != "" {
+		authPolicyId, err := self.getAuthPolicyId(row.AuthPolicy)
+		if err != nil {
+			return "", err
+		}
+		create.AuthPolicyID = &authPolicyId
+	}
+
+	resp, err := self.client.Identity.CreateIdentity(&identity.CreateIdentityParams{
+		Identity: create,
+		Context:  context.Background(),
+	}, nil)
+	if err != nil {
+		return "", util.WrapIfApiError(err)
+	}
+
+	return resp.GetPayload().Data.ID, nil
+}
+
+// writeOutput writes the enrollment JWT of the identity, or if requested, completes an ott enrollment and writes the
+// resulting identity file. Updb enrollments need a password chosen by the user, so their JWTs are always written.
+func (self *csvIdentityCreator) writeOutput(row *IdentityCsvRow, id string) (string, error) {
+	params := &identity.DetailIdentityParams{
+		ID:      id,
+		Context: context.Background(),
+	}
+	resp, err := self.client.Identity.DetailIdentity(params, nil)
+	if err != nil {
+		return "", errors.Wrapf(util.WrapIfApiError(err), "unable to read identity %s", id)
+	}
+
+	enrollments := resp.GetPayload().Data.Enrollment
+	jwt := ""
+	if enrollments != nil && enrollments.Ott != nil {
+		jwt = enrollments.Ott.JWT
+	} else if enrollments != nil && enrollments.Updb != nil {
+		jwt = enrollments.Updb.JWT
+	}
+
+	if jwt == "" {
+		return "", errors.Errorf("identity %s has no pending enrollment, it may already be enrolled", id)
+	}
+
+	if self.options.enroll && enrollments.Ott != nil {
+		outputFile := filepath.Join(self.options.outputDir, row.FileName(".json"))
+		if err = self.enroll(jwt, outputFile); err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(self.options.Out, "enrolled identity %s, identity file written to %s\n", row.Name, outputFile)
+		return outputFile, nil
+	}
+
+	outputFile := filepath.Join(self.options.outputDir, row.FileName(".jwt"))
+	if err = os.WriteFile(outputFile, []byte(jwt), 0600); err != nil {
+		return "", errors.Wrapf(err, "unable to write enrollment JWT to %s", outputFile)
+	}
+	_, _ = fmt.Fprintf(self.options.Out, "enrollment JWT for identity %s written to %s\n", row.Name, outputFile)
+	return outputFile, nil
+}
+
+func (self *csvIdentityCreator) enroll(jwt string, outputFile string) error {
+	token, _, err := enroll.ParseToken(jwt)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse enrollment JWT")
+	}
+
+	conf, err := enroll.Enroll(enroll.EnrollmentFlags{
+		Token:   token,
+		KeyAlg:  self.options.keyAlg,
+		Verbose: self.options.Verbose,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to complete enrollment")
+	}
+
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal identity file")
+	}
+
+	return errors.Wrapf(os.WriteFile(outputFile, data, 0600), "unable to write identity file to %s", outputFile)
+}
+
+func (self *csvIdentityCreator) getAuthPolicyId(name string) (string, error) {
+	if id, found := self.authPolicies[name]; found {
+		return id, nil
+	}
+
+	id, err := mapNameToID("auth-policies", name, self.options.Options)
+	if err != nil {
+		return "", err
+	}
+	self.authPolicies[name] = id
+	return id, nil
+}
@@ -35,7 +35,7 @@ type createTransitRouterOptions struct {
 	disabled      bool
 }
 
-func newCreateTransitRouterCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+func NewCreateTransitRouterCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	options := &createTransitRouterOptions{
 		EntityOptions: api.NewEntityOptions(out, errOut)}
 
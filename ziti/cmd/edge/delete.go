@@ -93,7 +93,8 @@ func newDeleteCmdForEntityType(entityType string, options *deleteOptions, aliase
 			err := runDeleteEntityOfType(options, getPlural(entityType))
 			cmdhelper.CheckErr(err)
 		},
-		SuggestFor: []string{},
+		SuggestFor:        []string{},
+		ValidArgsFunction: completeEntities(getPlural(entityType), 0),
 	}
 
 	// allow interspersing positional args and flags
@@ -131,7 +132,7 @@ func newDeleteWhereCmdForEntityType(entityType string, options *deleteOptions) *
 func runDeleteEntityOfType(o *deleteOptions, entityType string) error {
 	var err error
 	ids := o.Args
-	if entityType != "terminators" && entityType != "api-sessions" && entityType != "sessions" && entityType != "authenticators" && entityType != "enrollments" {
+	if isNamedEntityType(entityType) {
 		if ids, err = mapNamesToIDs(entityType, *o.Options, true, ids...); err != nil {
 			return err
 		}
@@ -0,0 +1,357 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/openziti/edge-api/rest_management_api_client"
+	"github.com/openziti/edge-api/rest_management_api_client/authenticator"
+	"github.com/openziti/edge-api/rest_management_api_client/certificate_authority"
+	"github.com/openziti/edge-api/rest_management_api_client/enrollment"
+	"github.com/openziti/edge-api/rest_management_api_client/identity"
+	"github.com/openziti/ziti/internal/rest/mgmt"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type exportIdentityOptions struct {
+	api.Options
+	filter     string
+	outputFile string
+	identityBundleKeyOptions
+}
+
+func newExportCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "exports entities managed by the Ziti Edge Controller in a portable format",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdhelper.CheckErr(cmd.Help())
+		},
+	}
+
+	cmd.AddCommand(newExportIdentityCmd(out, errOut))
+
+	return cmd
+}
+
+func newExportIdentityCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &exportIdentityOptions{
+		Options: api.Options{
+			CommonOptions: common.CommonOptions{Out: out, Err: errOut},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "identity [<idOrName> ...]",
+		Short: "exports identities, with their enrollments, authenticators, role attributes and app data, to a signed bundle",
+		Long: "Exports identities, with their enrollments, authenticators, role attributes and app data, to a signed JSON bundle\n" +
+			"which can be imported into another network or controller using 'ziti edge import identity'. Identities may be\n" +
+			"given by id or name, or selected using --filter. The bundle is signed with the given key, which must also be\n" +
+			"provided when importing. Passwords and private keys are never exported.",
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := runExportIdentity(options)
+			cmdhelper.CheckErr(err)
+		},
+		SuggestFor: []string{},
+	}
+
+	// allow interspersing positional args and flags
+	cmd.Flags().SetInterspersed(true)
+	cmd.Flags().StringVar(&options.filter, "filter", "", "Export all identities matching the given filter, e.g. 'roleAttributes contains \"dc1\"'")
+	cmd.Flags().StringVarP(&options.outputFile, "output-file", "o", "", "File to write the bundle to. Defaults to stdout")
+	options.addSigningKeyFlags(cmd)
+	options.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+func runExportIdentity(o *exportIdentityOptions) error {
+	signingKey, err := o.getSigningKey()
+	if err != nil {
+		return err
+	}
+
+	if signingKey == nil {
+		return errors.Errorf("a signing key is required, use --signing-key-file, --signing-key or %s", IdentityBundleSigningKeyEnvVar)
+	}
+
+	if len(o.Args) == 0 && o.filter == "" {
+		return errors.New("no identities selected, provide identity ids or names, or a --filter")
+	}
+
+	client, err := util.NewEdgeManagementClient(o)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	if len(o.Args) > 0 {
+		if ids, err = mapNamesToIDs("identities", o.Options, false, o.Args...); err != nil {
+			return err
+		}
+	}
+
+	if o.filter != "" {
+		filtered, err := listIdentityIds(client, o.filter)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, filtered...)
+	}
+
+	exporter := &identityExporter{
+		client:       client,
+		authPolicies: map[string]string{},
+		cas:          map[string]string{},
+	}
+
+	bundle := &IdentityBundle{
+		ExportedAt: time.Now().UTC(),
+		Identities: []*IdentityBundleEntry{},
+	}
+
+	if restClientIdentity, err := util.LoadSelectedIdentity(); err == nil {
+		bundle.Source, _ = restClientIdentity.GetBaseUrlForApi(util.EdgeAPI)
+	}
+
+	exported := map[string]struct{}{}
+	for _, id := range ids {
+		if _, found := exported[id]; found {
+			continue
+		}
+		exported[id] = struct{}{}
+
+		entry, err := exporter.exportIdentity(id)
+		if err != nil {
+			return err
+		}
+		bundle.Identities = append(bundle.Identities, entry)
+	}
+
+	signedBundle, err := bundle.Sign(signingKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(signedBundle, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if o.outputFile == "" {
+		_, err = fmt.Fprintln(o.Out, string(data))
+		return err
+	}
+
+	if err = os.WriteFile(o.outputFile, data, 0600); err != nil {
+		return errors.Wrapf(err, "unable to write identity bundle to %s", o.outputFile)
+	}
+
+	_, err = fmt.Fprintf(o.Err, "exported %d identities to %s\n", len(bundle.Identities), o.outputFile)
+	return err
+}
+
+func listIdentityIds(client *rest_management_api_client.ZitiEdgeManagement, filter string) ([]string, error) {
+	var result []string
+	offset := int64(0)
+	limit := int64(500)
+
+	for {
+		resp, err := client.Identity.ListIdentities(&identity.ListIdentitiesParams{
+			Filter:  &filter,
+			Offset:  &offset,
+			Limit:   &limit,
+			Context: context.Background(),
+		}, nil)
+		if err != nil {
+			return nil, util.WrapIfApiError(err)
+		}
+
+		for _, detail := range resp.GetPayload().Data {
+			result = append(result, *detail.ID)
+		}
+
+		offset += limit
+		if pagination := resp.GetPayload().Meta.Pagination; pagination == nil || offset >= *pagination.TotalCount {
+			return result, nil
+		}
+	}
+}
+
+type identityExporter struct {
+	client       *rest_management_api_client.ZitiEdgeManagement
+	authPolicies map[string]string
+	cas          map[string]string
+}
+
+func (self *identityExporter) exportIdentity(id string) (*IdentityBundleEntry, error) {
+	resp, err := self.client.Identity.DetailIdentity(&identity.DetailIdentityParams{ID: id, Context: context.Background()}, nil)
+	if err != nil {
+		return nil, util.WrapIfApiError(err)
+	}
+	detail := resp.GetPayload().Data
+
+	if detail.IsDefaultAdmin != nil && *detail.IsDefaultAdmin {
+		return nil, errors.Errorf("identity %s is the default admin and can't be exported", *detail.Name)
+	}
+
+	result := &IdentityBundleEntry{
+		Name:                     *detail.Name,
+		IsAdmin:                  detail.IsAdmin != nil && *detail.IsAdmin,
+		RoleAttributes:           []string{},
+		DefaultHostingPrecedence: string(detail.DefaultHostingPrecedence),
+	}
+
+	if detail.TypeID != nil {
+		result.Type = *detail.TypeID
+	}
+
+	if detail.ExternalID != nil {
+		result.ExternalId = *detail.ExternalID
+	}
+
+	if detail.RoleAttributes != nil {
+		result.RoleAttributes = append(result.RoleAttributes, *detail.RoleAttributes...)
+	}
+
+	if detail.AppData != nil && len(detail.AppData.SubTags) > 0 {
+		result.AppData = detail.AppData.SubTags
+	}
+
+	if detail.Tags != nil && len(detail.Tags.SubTags) > 0 {
+		result.Tags = detail.Tags.SubTags
+	}
+
+	if detail.DefaultHostingCost != nil {
+		cost := int64(*detail.DefaultHostingCost)
+		result.DefaultHostingCost = &cost
+	}
+
+	if detail.AuthPolicyID != nil {
+		if result.AuthPolicy, err = self.getAuthPolicyName(*detail.AuthPolicyID); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Enrollments, err = self.exportEnrollments(id); err != nil {
+		return nil, err
+	}
+
+	if result.Authenticators, err = self.exportAuthenticators(id); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (self *identityExporter) exportEnrollments(identityId string) ([]*IdentityBundleEnrollment, error) {
+	filter := fmt.Sprintf(`identity="%s"`, identityId)
+	resp, err := self.client.Enrollment.ListEnrollments(&enrollment.ListEnrollmentsParams{
+		Filter:  &filter,
+		Context: context.Background(),
+	}, nil)
+	if err != nil {
+		return nil, util.WrapIfApiError(err)
+	}
+
+	var result []*IdentityBundleEnrollment
+	for _, detail := range resp.GetPayload().Data {
+		if detail.Method == nil || detail.ExpiresAt == nil {
+			continue
+		}
+
+		entry := &IdentityBundleEnrollment{
+			Method:    *detail.Method,
+			ExpiresAt: time.Time(*detail.ExpiresAt).UTC(),
+			Username:  detail.Username,
+		}
+
+		if detail.CaID != nil && *detail.CaID != "" {
+			if entry.Ca, err = self.getCaName(*detail.CaID); err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func (self *identityExporter) exportAuthenticators(identityId string) ([]*IdentityBundleAuthenticator, error) {
+	filter := fmt.Sprintf(`identity="%s"`, identityId)
+	resp, err := self.client.Authenticator.ListAuthenticators(&authenticator.ListAuthenticatorsParams{
+		Filter:  &filter,
+		Context: context.Background(),
+	}, nil)
+	if err != nil {
+		return nil, util.WrapIfApiError(err)
+	}
+
+	var result []*IdentityBundleAuthenticator
+	for _, detail := range resp.GetPayload().Data {
+		if detail.Method == nil {
+			continue
+		}
+		result = append(result, &IdentityBundleAuthenticator{
+			Method:      *detail.Method,
+			Username:    detail.Username,
+			CertPem:     detail.CertPem,
+			Fingerprint: detail.Fingerprint,
+		})
+	}
+	return result, nil
+}
+
+func (self *identityExporter) getAuthPolicyName(id string) (string, error) {
+	if name, found := self.authPolicies[id]; found {
+		return name, nil
+	}
+
+	detail := mgmt.AuthPolicyFromFilter(self.client, fmt.Sprintf(`id="%s"`, id))
+	if detail == nil || detail.Name == nil {
+		return "", errors.Errorf("unable to find auth policy with id %s", id)
+	}
+	self.authPolicies[id] = *detail.Name
+	return *detail.Name, nil
+}
+
+func (self *identityExporter) getCaName(id string) (string, error) {
+	if name, found := self.cas[id]; found {
+		return name, nil
+	}
+
+	resp, err := self.client.CertificateAuthority.DetailCa(&certificate_authority.DetailCaParams{ID: id, Context: context.Background()}, nil)
+	if err != nil {
+		return "", util.WrapIfApiError(err)
+	}
+	name := *resp.GetPayload().Data.Name
+	self.cas[id] = name
+	return name, nil
+}
@@ -0,0 +1,227 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	IdentityBundleVersion      = 2
+	IdentityBundleSigAlgorithm = "HMAC-SHA256"
+
+	// IdentityBundleSigningKeyEnvVar names the environment variable the bundle signing key is read from, if it's
+	// not given on the command line
+	IdentityBundleSigningKeyEnvVar = "ZITI_IDENTITY_BUNDLE_SIGNING_KEY"
+)
+
+// SignedIdentityBundle is the form an identity bundle is written in. The bundle is carried as raw JSON in Payload
+// and the signature is computed over the compacted payload bytes, so values such as large numbers in app data or
+// tags don't have to survive being decoded and encoded again for the signature to match.
+type SignedIdentityBundle struct {
+	Version   int                      `json:"version"`
+	Payload   json.RawMessage          `json:"payload"`
+	Signature *IdentityBundleSignature `json:"signature,omitempty"`
+}
+
+// IdentityBundle is a portable representation of a set of identities, used to move identities between networks or
+// controllers. Passwords and private keys are never part of a bundle. Pending enrollments are recreated on import,
+// which issues new enrollment tokens, and updb authenticators are carried over as updb enrollments for the same
+// username.
+type IdentityBundle struct {
+	ExportedAt time.Time              `json:"exportedAt"`
+	Source     string                 `json:"source,omitempty"`
+	Identities []*IdentityBundleEntry `json:"identities"`
+}
+
+type IdentityBundleEntry struct {
+	Name                     string                         `json:"name"`
+	Type                     string                         `json:"type"`
+	IsAdmin                  bool                           `json:"isAdmin"`
+	ExternalId               string                         `json:"externalId,omitempty"`
+	AuthPolicy               string                         `json:"authPolicy,omitempty"`
+	RoleAttributes           []string                       `json:"roleAttributes"`
+	AppData                  map[string]interface{}         `json:"appData,omitempty"`
+	Tags                     map[string]interface{}         `json:"tags,omitempty"`
+	DefaultHostingCost       *int64                         `json:"defaultHostingCost,omitempty"`
+	DefaultHostingPrecedence string                         `json:"defaultHostingPrecedence,omitempty"`
+	Enrollments              []*IdentityBundleEnrollment    `json:"enrollments,omitempty"`
+	Authenticators           []*IdentityBundleAuthenticator `json:"authenticators,omitempty"`
+}
+
+type IdentityBundleEnrollment struct {
+	Method    string    `json:"method"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Ca        string    `json:"ca,omitempty"`
+	Username  string    `json:"username,omitempty"`
+}
+
+type IdentityBundleAuthenticator struct {
+	Method      string `json:"method"`
+	Username    string `json:"username,omitempty"`
+	CertPem     string `json:"certPem,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+type IdentityBundleSignature struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+}
+
+func identityBundleDigest(payload []byte, key []byte) ([]byte, error) {
+	compacted := &bytes.Buffer{}
+	if err := json.Compact(compacted, payload); err != nil {
+		return nil, errors.Wrap(err, "invalid identity bundle payload")
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(compacted.Bytes())
+	return mac.Sum(nil), nil
+}
+
+// Sign encodes the bundle and signs the encoded bytes using the given shared key
+func (self *IdentityBundle) Sign(key []byte) (*SignedIdentityBundle, error) {
+	if len(key) == 0 {
+		return nil, errors.New("a signing key is required to sign an identity bundle")
+	}
+
+	payload, err := json.Marshal(self)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encode identity bundle")
+	}
+
+	digest, err := identityBundleDigest(payload, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to sign identity bundle")
+	}
+
+	return &SignedIdentityBundle{
+		Version: IdentityBundleVersion,
+		Payload: payload,
+		Signature: &IdentityBundleSignature{
+			Algorithm: IdentityBundleSigAlgorithm,
+			Value:     base64.StdEncoding.EncodeToString(digest),
+		},
+	}, nil
+}
+
+// Verify checks that the bundle payload was signed with the given shared key and hasn't been modified since
+func (self *SignedIdentityBundle) Verify(key []byte) error {
+	if self.Signature == nil {
+		return errors.New("identity bundle is not signed")
+	}
+
+	if self.Signature.Algorithm != IdentityBundleSigAlgorithm {
+		return errors.Errorf("unsupported identity bundle signature algorithm [%s]", self.Signature.Algorithm)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(self.Signature.Value)
+	if err != nil {
+		return errors.Wrap(err, "invalid identity bundle signature encoding")
+	}
+
+	digest, err := identityBundleDigest(self.Payload, key)
+	if err != nil {
+		return errors.Wrap(err, "unable to verify identity bundle")
+	}
+
+	if !hmac.Equal(signature, digest) {
+		return errors.New("identity bundle signature does not match, the bundle was modified or the signing key is wrong")
+	}
+	return nil
+}
+
+// Bundle decodes the bundle payload. Numbers in app data and tags are decoded as json.Number, so they're passed on
+// unchanged when the identities are imported.
+func (self *SignedIdentityBundle) Bundle() (*IdentityBundle, error) {
+	decoder := json.NewDecoder(bytes.NewReader(self.Payload))
+	decoder.UseNumber()
+
+	result := &IdentityBundle{}
+	if err := decoder.Decode(result); err != nil {
+		return nil, errors.Wrap(err, "unable to parse identity bundle payload")
+	}
+	return result, nil
+}
+
+// ParseIdentityBundle decodes a signed identity bundle, checking that the bundle version is supported. The payload
+// isn't decoded until Bundle is called, so it can be verified first.
+func ParseIdentityBundle(data []byte) (*SignedIdentityBundle, error) {
+	result := &SignedIdentityBundle{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, errors.Wrap(err, "unable to parse identity bundle")
+	}
+
+	if result.Version != IdentityBundleVersion {
+		return nil, errors.Errorf("unsupported identity bundle version %d, expected %d", result.Version, IdentityBundleVersion)
+	}
+
+	if len(result.Payload) == 0 {
+		return nil, errors.New("identity bundle has no payload")
+	}
+	return result, nil
+}
+
+// identityBundleKeyOptions holds the ways the shared bundle signing key can be provided. Keys given with
+// --signing-key are visible in the process list, so --signing-key-file or the environment variable are preferred.
+type identityBundleKeyOptions struct {
+	signingKey     string
+	signingKeyFile string
+}
+
+func (self *identityBundleKeyOptions) addSigningKeyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&self.signingKey, "signing-key", "", "Shared key used to sign the bundle. "+
+		"Visible to other users in the process list, prefer --signing-key-file or "+IdentityBundleSigningKeyEnvVar)
+	cmd.Flags().StringVar(&self.signingKeyFile, "signing-key-file", "", "File containing the shared key used to sign the bundle")
+	cmd.MarkFlagsMutuallyExclusive("signing-key", "signing-key-file")
+}
+
+// getSigningKey returns the signing key from --signing-key-file, --signing-key or the environment, in that order.
+// Returns nil if no key was provided.
+func (self *identityBundleKeyOptions) getSigningKey() ([]byte, error) {
+	if self.signingKeyFile != "" {
+		data, err := os.ReadFile(self.signingKeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read signing key file %s", self.signingKeyFile)
+		}
+		key := strings.TrimRight(string(data), "\r\n")
+		if key == "" {
+			return nil, errors.Errorf("signing key file %s is empty", self.signingKeyFile)
+		}
+		return []byte(key), nil
+	}
+
+	if self.signingKey != "" {
+		return []byte(self.signingKey), nil
+	}
+
+	if key := os.Getenv(IdentityBundleSigningKeyEnvVar); key != "" {
+		return []byte(key), nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,179 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIdentityBundle() *IdentityBundle {
+	cost := int64(10)
+	return &IdentityBundle{
+		ExportedAt: time.Now().UTC(),
+		Identities: []*IdentityBundleEntry{
+			{
+				Name:               "test-identity",
+				Type:               "Default",
+				AuthPolicy:         "Default",
+				RoleAttributes:     []string{"dc1", "web"},
+				AppData:            map[string]interface{}{"region": "us-east", "priority": 5},
+				DefaultHostingCost: &cost,
+				Enrollments: []*IdentityBundleEnrollment{
+					{Method: "ott", ExpiresAt: time.Now().Add(time.Hour).UTC()},
+				},
+				Authenticators: []*IdentityBundleAuthenticator{
+					{Method: "updb", Username: "test"},
+				},
+			},
+		},
+	}
+}
+
+func TestIdentityBundleSignAndVerify(t *testing.T) {
+	req := require.New(t)
+
+	signed, err := newTestIdentityBundle().Sign([]byte("secret"))
+	req.NoError(err)
+	req.NoError(signed.Verify([]byte("secret")))
+	req.Error(signed.Verify([]byte("wrong")))
+
+	data, err := json.MarshalIndent(signed, "", "  ")
+	req.NoError(err)
+
+	parsed, err := ParseIdentityBundle(data)
+	req.NoError(err)
+	req.NoError(parsed.Verify([]byte("secret")))
+
+	bundle, err := parsed.Bundle()
+	req.NoError(err)
+	req.Equal("test-identity", bundle.Identities[0].Name)
+	req.Equal([]string{"dc1", "web"}, bundle.Identities[0].RoleAttributes)
+
+	tampered, err := ParseIdentityBundle(bytes.Replace(data, []byte(`"isAdmin": false`), []byte(`"isAdmin": true`), 1))
+	req.NoError(err)
+	req.Error(tampered.Verify([]byte("secret")))
+
+	parsed.Signature = nil
+	req.Error(parsed.Verify([]byte("secret")))
+}
+
+func TestIdentityBundleLargeNumbers(t *testing.T) {
+	req := require.New(t)
+
+	// larger than 2^53, so it can't be represented exactly as a float64
+	bundle := newTestIdentityBundle()
+	bundle.Identities[0].Tags = map[string]interface{}{"serial": json.Number("9007199254740993")}
+
+	signed, err := bundle.Sign([]byte("secret"))
+	req.NoError(err)
+
+	data, err := json.MarshalIndent(signed, "", "  ")
+	req.NoError(err)
+	req.Contains(string(data), "9007199254740993")
+
+	parsed, err := ParseIdentityBundle(data)
+	req.NoError(err)
+	req.NoError(parsed.Verify([]byte("secret")))
+
+	parsedBundle, err := parsed.Bundle()
+	req.NoError(err)
+	req.Equal(json.Number("9007199254740993"), parsedBundle.Identities[0].Tags["serial"])
+
+	tags, err := json.Marshal(parsedBundle.Identities[0].Tags)
+	req.NoError(err)
+	req.Equal(`{"serial":9007199254740993}`, string(tags))
+}
+
+func TestIdentityBundleRequiresKey(t *testing.T) {
+	req := require.New(t)
+	_, err := newTestIdentityBundle().Sign(nil)
+	req.Error(err)
+}
+
+func TestParseIdentityBundleVersion(t *testing.T) {
+	req := require.New(t)
+
+	_, err := ParseIdentityBundle([]byte(`{"version": 1, "identities": []}`))
+	req.Error(err)
+
+	_, err = ParseIdentityBundle([]byte(`{"version": 2}`))
+	req.ErrorContains(err, "no payload")
+
+	_, err = ParseIdentityBundle([]byte(`not json`))
+	req.Error(err)
+}
+
+func TestIdentityBundleSigningKey(t *testing.T) {
+	t.Run("no key returns nil", func(t *testing.T) {
+		req := require.New(t)
+		t.Setenv(IdentityBundleSigningKeyEnvVar, "")
+		key, err := (&identityBundleKeyOptions{}).getSigningKey()
+		req.NoError(err)
+		req.Nil(key)
+	})
+
+	t.Run("key is read from the environment", func(t *testing.T) {
+		req := require.New(t)
+		t.Setenv(IdentityBundleSigningKeyEnvVar, "env-secret")
+		key, err := (&identityBundleKeyOptions{}).getSigningKey()
+		req.NoError(err)
+		req.Equal("env-secret", string(key))
+
+		key, err = (&identityBundleKeyOptions{signingKey: "flag-secret"}).getSigningKey()
+		req.NoError(err)
+		req.Equal("flag-secret", string(key), "flag takes precedence over the environment")
+	})
+
+	t.Run("key is read from a file without the trailing newline", func(t *testing.T) {
+		req := require.New(t)
+		t.Setenv(IdentityBundleSigningKeyEnvVar, "env-secret")
+		keyFile := filepath.Join(t.TempDir(), "key")
+		req.NoError(os.WriteFile(keyFile, []byte(" file secret\n"), 0600))
+
+		key, err := (&identityBundleKeyOptions{signingKeyFile: keyFile}).getSigningKey()
+		req.NoError(err)
+		req.Equal(" file secret", string(key))
+	})
+
+	t.Run("empty or missing key files fail", func(t *testing.T) {
+		req := require.New(t)
+		keyFile := filepath.Join(t.TempDir(), "key")
+		_, err := (&identityBundleKeyOptions{signingKeyFile: keyFile}).getSigningKey()
+		req.ErrorContains(err, "unable to read signing key file")
+
+		req.NoError(os.WriteFile(keyFile, []byte("\n"), 0600))
+		_, err = (&identityBundleKeyOptions{signingKeyFile: keyFile}).getSigningKey()
+		req.ErrorContains(err, "is empty")
+	})
+
+	t.Run("key flags are mutually exclusive", func(t *testing.T) {
+		req := require.New(t)
+		cmd := newExportIdentityCmd(io.Discard, io.Discard)
+		cmd.SetArgs([]string{"--signing-key", "secret", "--signing-key-file", "key", "test"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		req.ErrorContains(cmd.Execute(), "none of the others can be")
+	})
+}
@@ -0,0 +1,260 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	IdentityCsvColumnName           = "name"
+	IdentityCsvColumnAdmin          = "admin"
+	IdentityCsvColumnExternalId     = "externalid"
+	IdentityCsvColumnAuthPolicy     = "authpolicy"
+	IdentityCsvColumnUpdb           = "updb"
+	IdentityCsvColumnRoleAttributes = "roleattributes"
+
+	IdentityCsvStatusCreated  = "created"
+	IdentityCsvStatusComplete = "complete"
+	IdentityCsvStatusFailed   = "failed"
+)
+
+var identityCsvFileNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// IdentityCsvRow is an identity to create, read from one line of a CSV file
+type IdentityCsvRow struct {
+	Line           int
+	Name           string
+	IsAdmin        bool
+	ExternalId     string
+	AuthPolicy     string
+	Updb           string
+	RoleAttributes []string
+}
+
+// FileName returns the name used for files written for this identity, with any characters which aren't safe in file
+// names replaced
+func (self *IdentityCsvRow) FileName(ext string) string {
+	return identityCsvFileNameInvalidChars.ReplaceAllString(self.Name, "_") + ext
+}
+
+// ParseIdentityCsv reads identities from CSV. The first line must be a header naming the columns. Only the name
+// column is required. Headers are matched ignoring case, spaces, dashes and underscores, so 'Auth Policy' and
+// 'auth_policy' both work. The roleAttributes column holds a comma or semicolon separated list of attributes. The
+// value of each column listed in attributeColumns is added as a role attribute as well. Other columns are ignored.
+func ParseIdentityCsv(r io.Reader, attributeColumns []string) ([]*IdentityCsvRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("identity CSV is empty")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read identity CSV header")
+	}
+
+	columns := map[string]int{}
+	for idx, val := range header {
+		columns[normalizeIdentityCsvColumn(val)] = idx
+	}
+
+	if _, found := columns[IdentityCsvColumnName]; !found {
+		return nil, errors.Errorf("identity CSV must have a '%s' column", IdentityCsvColumnName)
+	}
+
+	var attributeIdx []int
+	for _, column := range attributeColumns {
+		idx, found := columns[normalizeIdentityCsvColumn(column)]
+		if !found {
+			return nil, errors.Errorf("role attribute column '%s' not found in identity CSV", column)
+		}
+		attributeIdx = append(attributeIdx, idx)
+	}
+
+	var result []*IdentityCsvRow
+	names := map[string]int{}
+	fileNames := map[string]int{}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read identity CSV")
+		}
+
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			if idx, found := columns[column]; found && idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+
+		if isBlankCsvRecord(record) {
+			continue
+		}
+
+		row := &IdentityCsvRow{
+			Line:       line,
+			Name:       value(IdentityCsvColumnName),
+			ExternalId: value(IdentityCsvColumnExternalId),
+			AuthPolicy: value(IdentityCsvColumnAuthPolicy),
+			Updb:       value(IdentityCsvColumnUpdb),
+		}
+
+		if row.Name == "" {
+			return nil, errors.Errorf("identity CSV line %d has no name", line)
+		}
+
+		if prevLine, found := names[row.Name]; found {
+			return nil, errors.Errorf("identity CSV line %d has the same name as line %d: %s", line, prevLine, row.Name)
+		}
+		names[row.Name] = line
+
+		if prevLine, found := fileNames[row.FileName("")]; found {
+			return nil, errors.Errorf("identity CSV line %d would write the same files as line %d: %s", line, prevLine, row.Name)
+		}
+		fileNames[row.FileName("")] = line
+
+		if admin := value(IdentityCsvColumnAdmin); admin != "" {
+			if row.IsAdmin, err = strconv.ParseBool(admin); err != nil {
+				return nil, errors.Errorf("identity CSV line %d has invalid admin value '%s'", line, admin)
+			}
+		}
+
+		attrs := strings.FieldsFunc(value(IdentityCsvColumnRoleAttributes), func(r rune) bool {
+			return r == ',' || r == ';'
+		})
+		for _, idx := range attributeIdx {
+			if idx < len(record) {
+				attrs = append(attrs, record[idx])
+			}
+		}
+
+		for _, attr := range attrs {
+			attr = strings.TrimSpace(attr)
+			if attr != "" && !slices.Contains(row.RoleAttributes, attr) {
+				row.RoleAttributes = append(row.RoleAttributes, attr)
+			}
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+func normalizeIdentityCsvColumn(val string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(val)))
+}
+
+func isBlankCsvRecord(record []string) bool {
+	for _, val := range record {
+		if strings.TrimSpace(val) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// IdentityCsvState records the progress of a CSV identity import, so an interrupted or partially failed import can
+// be run again without creating identities twice
+type IdentityCsvState struct {
+	Source     string                            `json:"source"`
+	Identities map[string]*IdentityCsvStateEntry `json:"identities"`
+}
+
+type IdentityCsvStateEntry struct {
+	Id         string    `json:"id,omitempty"`
+	Status     string    `json:"status"`
+	OutputFile string    `json:"outputFile,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// LoadIdentityCsvState reads import state from the given file. If the file doesn't exist, empty state is returned.
+func LoadIdentityCsvState(path string) (*IdentityCsvState, error) {
+	result := &IdentityCsvState{
+		Identities: map[string]*IdentityCsvStateEntry{},
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read identity import state from %s", path)
+	}
+
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse identity import state from %s", path)
+	}
+
+	if result.Identities == nil {
+		result.Identities = map[string]*IdentityCsvStateEntry{}
+	}
+
+	return result, nil
+}
+
+// Save writes the state to a temporary file, then moves it into place, so the state file is never left half written
+func (self *IdentityCsvState) Save(path string) error {
+	data, err := json.MarshalIndent(self, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal identity import state")
+	}
+
+	tmpPath := path + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0600); err != nil {
+		return errors.Wrapf(err, "unable to write identity import state to %s", tmpPath)
+	}
+
+	return errors.Wrapf(os.Rename(tmpPath, path), "unable to write identity import state to %s", path)
+}
+
+// Get returns the state for the named identity, creating it if necessary
+func (self *IdentityCsvState) Get(name string) *IdentityCsvStateEntry {
+	entry, found := self.Identities[name]
+	if !found {
+		entry = &IdentityCsvStateEntry{}
+		self.Identities[name] = entry
+	}
+	return entry
+}
+
+func (self *IdentityCsvStateEntry) Update(status string, err error) {
+	self.Status = status
+	self.Error = ""
+	if err != nil {
+		self.Error = err.Error()
+	}
+	self.UpdatedAt = time.Now().UTC()
+}
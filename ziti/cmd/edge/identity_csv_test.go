@@ -0,0 +1,98 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIdentityCsv(t *testing.T) {
+	req := require.New(t)
+
+	data := "Name,Admin,External ID,auth_policy,updb,Role Attributes,Department,Site\n" +
+		"alice,true,ext-1,Default,,\"vpn,web\",sales,nyc\n" +
+		"bob,,,,bob@example.com,vpn;db,,lon\n" +
+		",,,,,,,\n" +
+		"carol/laptop\n"
+
+	rows, err := ParseIdentityCsv(strings.NewReader(data), []string{"department", "site"})
+	req.NoError(err)
+	req.Len(rows, 3)
+
+	req.Equal("alice", rows[0].Name)
+	req.True(rows[0].IsAdmin)
+	req.Equal("ext-1", rows[0].ExternalId)
+	req.Equal("Default", rows[0].AuthPolicy)
+	req.Equal([]string{"vpn", "web", "sales", "nyc"}, rows[0].RoleAttributes)
+
+	req.Equal("bob", rows[1].Name)
+	req.False(rows[1].IsAdmin)
+	req.Equal("bob@example.com", rows[1].Updb)
+	req.Equal([]string{"vpn", "db", "lon"}, rows[1].RoleAttributes)
+
+	req.Equal("carol/laptop", rows[2].Name)
+	req.Equal(5, rows[2].Line)
+	req.Equal("carol_laptop.jwt", rows[2].FileName(".jwt"))
+}
+
+func TestParseIdentityCsvErrors(t *testing.T) {
+	req := require.New(t)
+
+	_, err := ParseIdentityCsv(strings.NewReader(""), nil)
+	req.Error(err)
+
+	_, err = ParseIdentityCsv(strings.NewReader("id,roleAttributes\na,b\n"), nil)
+	req.Error(err)
+
+	_, err = ParseIdentityCsv(strings.NewReader("name\na\n"), []string{"site"})
+	req.Error(err)
+
+	_, err = ParseIdentityCsv(strings.NewReader("name,admin\na,maybe\n"), nil)
+	req.Error(err)
+
+	_, err = ParseIdentityCsv(strings.NewReader("name\na\nb\na\n"), nil)
+	req.ErrorContains(err, "line 4")
+
+	_, err = ParseIdentityCsv(strings.NewReader("name\na/b\na:b\n"), nil)
+	req.Error(err)
+}
+
+func TestIdentityCsvState(t *testing.T) {
+	req := require.New(t)
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := LoadIdentityCsvState(path)
+	req.NoError(err)
+	req.Empty(state.Identities)
+
+	state.Get("alice").Id = "a1"
+	state.Get("alice").Update(IdentityCsvStatusComplete, nil)
+	state.Get("bob").Update(IdentityCsvStatusFailed, errors.New("boom"))
+	req.NoError(state.Save(path))
+
+	loaded, err := LoadIdentityCsvState(path)
+	req.NoError(err)
+	req.Equal("a1", loaded.Get("alice").Id)
+	req.Equal(IdentityCsvStatusComplete, loaded.Get("alice").Status)
+	req.Equal(IdentityCsvStatusFailed, loaded.Get("bob").Status)
+	req.Equal("boom", loaded.Get("bob").Error)
+}
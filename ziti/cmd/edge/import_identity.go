@@ -0,0 +1,347 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/openziti/edge-api/rest_management_api_client"
+	"github.com/openziti/edge-api/rest_management_api_client/authenticator"
+	"github.com/openziti/edge-api/rest_management_api_client/enrollment"
+	"github.com/openziti/edge-api/rest_management_api_client/identity"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/ziti/internal/rest/mgmt"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type importIdentityOptions struct {
+	api.Options
+	identityBundleKeyOptions
+	skipVerify         bool
+	enrollmentDuration time.Duration
+}
+
+func newImportCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "imports entities exported with 'ziti edge export'",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdhelper.CheckErr(cmd.Help())
+		},
+	}
+
+	cmd.AddCommand(newImportIdentityCmd(out, errOut))
+
+	return cmd
+}
+
+func newImportIdentityCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &importIdentityOptions{
+		Options: api.Options{
+			CommonOptions: common.CommonOptions{Out: out, Err: errOut},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "identity <bundleFile>",
+		Short: "imports identities from a bundle created with 'ziti edge export identity'",
+		Long: "Imports identities from a bundle created with 'ziti edge export identity'. The bundle signature is checked\n" +
+			"using the given key before anything is created. Identities which already exist are skipped. Auth policies and\n" +
+			"CAs are matched by name and must already exist. Pending enrollments are recreated with new tokens, cert\n" +
+			"authenticators are copied and updb authenticators are recreated as updb enrollments, as passwords are not exported.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := runImportIdentity(options)
+			cmdhelper.CheckErr(err)
+		},
+		SuggestFor: []string{},
+	}
+
+	// allow interspersing positional args and flags
+	cmd.Flags().SetInterspersed(true)
+	options.addSigningKeyFlags(cmd)
+	cmd.Flags().BoolVar(&options.skipVerify, "skip-verify", false, "Import the bundle without checking its signature")
+	cmd.Flags().DurationVar(&options.enrollmentDuration, "enrollment-duration", 24*time.Hour, "How long recreated enrollments are valid for")
+	options.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+func runImportIdentity(o *importIdentityOptions) error {
+	data, err := os.ReadFile(o.Args[0])
+	if err != nil {
+		return errors.Wrapf(err, "unable to read identity bundle %s", o.Args[0])
+	}
+
+	signedBundle, err := ParseIdentityBundle(data)
+	if err != nil {
+		return err
+	}
+
+	if !o.skipVerify {
+		signingKey, err := o.getSigningKey()
+		if err != nil {
+			return err
+		}
+		if signingKey == nil {
+			return errors.Errorf("a signing key is required, unless --skip-verify is set. Use --signing-key-file, --signing-key or %s", IdentityBundleSigningKeyEnvVar)
+		}
+		if err = signedBundle.Verify(signingKey); err != nil {
+			return err
+		}
+	}
+
+	bundle, err := signedBundle.Bundle()
+	if err != nil {
+		return err
+	}
+
+	client, err := util.NewEdgeManagementClient(o)
+	if err != nil {
+		return err
+	}
+
+	importer := &identityImporter{
+		options:      o,
+		client:       client,
+		authPolicies: map[string]string{},
+		cas:          map[string]string{},
+	}
+
+	for _, entry := range bundle.Identities {
+		if err = importer.importIdentity(entry); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(o.Out, "identities created: %d, skipped: %d, warnings: %d\n", importer.created, importer.skipped, importer.warnings)
+	return err
+}
+
+type identityImporter struct {
+	options      *importIdentityOptions
+	client       *rest_management_api_client.ZitiEdgeManagement
+	authPolicies map[string]string
+	cas          map[string]string
+	created      int
+	skipped      int
+	warnings     int
+}
+
+func (self *identityImporter) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(self.options.Out, format, args...)
+}
+
+func (self *identityImporter) warnf(format string, args ...interface{}) {
+	self.warnings++
+	_, _ = fmt.Fprintf(self.options.Err, "warning: "+format, args...)
+}
+
+func (self *identityImporter) importIdentity(entry *IdentityBundleEntry) error {
+	if existing := mgmt.IdentityFromFilter(self.client, mgmt.NameFilter(entry.Name)); existing != nil {
+		self.skipped++
+		self.printf("identity %s already exists with id %s, skipping\n", entry.Name, *existing.ID)
+		return nil
+	}
+
+	identityType := rest_model.IdentityTypeDefault
+	switch strings.ToLower(entry.Type) {
+	case "", "default":
+	case "user":
+		identityType = rest_model.IdentityTypeUser
+	case "device":
+		identityType = rest_model.IdentityTypeDevice
+	case "service":
+		identityType = rest_model.IdentityTypeService
+	case "router":
+		self.skipped++
+		self.warnf("identity %s belongs to a router, router identities are created with the router, skipping\n", entry.Name)
+		return nil
+	default:
+		return errors.Errorf("identity %s has unsupported type %s", entry.Name, entry.Type)
+	}
+
+	roleAttributes := rest_model.Attributes(entry.RoleAttributes)
+	create := &rest_model.IdentityCreate{
+		Name:                     &entry.Name,
+		Type:                     &identityType,
+		IsAdmin:                  &entry.IsAdmin,
+		RoleAttributes:           &roleAttributes,
+		DefaultHostingPrecedence: rest_model.TerminatorPrecedence(entry.DefaultHostingPrecedence),
+	}
+
+	if entry.ExternalId != "" {
+		create.ExternalID = &entry.ExternalId
+	}
+
+	if len(entry.AppData) > 0 {
+		create.AppData = &rest_model.Tags{SubTags: entry.AppData}
+	}
+
+	if len(entry.Tags) > 0 {
+		create.Tags = &rest_model.Tags{SubTags: entry.Tags}
+	}
+
+	if entry.DefaultHostingCost != nil {
+		cost := rest_model.TerminatorCost(*entry.DefaultHostingCost)
+		create.DefaultHostingCost = &cost
+	}
+
+	if entry.AuthPolicy != "" && entry.AuthPolicy != "Default" {
+		authPolicyId, err := self.getAuthPolicyId(entry.AuthPolicy)
+		if err != nil {
+			return errors.Wrapf(err, "unable to import identity %s", entry.Name)
+		}
+		create.AuthPolicyID = &authPolicyId
+	}
+
+	resp, err := self.client.Identity.CreateIdentity(&identity.CreateIdentityParams{
+		Identity: create,
+		Context:  context.Background(),
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(util.WrapIfApiError(err), "unable to create identity %s", entry.Name)
+	}
+
+	identityId := resp.GetPayload().Data.ID
+	self.created++
+	self.printf("created identity %s with id %s\n", entry.Name, identityId)
+
+	updbUsernames := map[string]struct{}{}
+	for _, auth := range entry.Authenticators {
+		switch auth.Method {
+		case "cert":
+			self.importCertAuthenticator(entry.Name, identityId, auth)
+		case "updb":
+			updbUsernames[auth.Username] = struct{}{}
+			self.createEnrollment(entry.Name, identityId, rest_model.EnrollmentCreateMethodUpdb, "", auth.Username)
+		default:
+			self.warnf("identity %s has an authenticator with unsupported method %s, skipping\n", entry.Name, auth.Method)
+		}
+	}
+
+	now := time.Now()
+	for _, pending := range entry.Enrollments {
+		if pending.ExpiresAt.Before(now) {
+			self.printf("enrollment %s for identity %s expired at %s, skipping\n", pending.Method, entry.Name, pending.ExpiresAt)
+			continue
+		}
+
+		switch pending.Method {
+		case rest_model.EnrollmentCreateMethodOtt:
+			self.createEnrollment(entry.Name, identityId, pending.Method, "", "")
+		case rest_model.EnrollmentCreateMethodOttca:
+			caId, err := self.getCaId(pending.Ca)
+			if err != nil {
+				self.warnf("unable to recreate ottca enrollment for identity %s: %v\n", entry.Name, err)
+				continue
+			}
+			self.createEnrollment(entry.Name, identityId, pending.Method, caId, "")
+		case rest_model.EnrollmentCreateMethodUpdb:
+			if _, found := updbUsernames[pending.Username]; !found {
+				self.createEnrollment(entry.Name, identityId, pending.Method, "", pending.Username)
+			}
+		default:
+			self.warnf("identity %s has an enrollment with unsupported method %s, skipping\n", entry.Name, pending.Method)
+		}
+	}
+
+	return nil
+}
+
+func (self *identityImporter) importCertAuthenticator(name, identityId string, auth *IdentityBundleAuthenticator) {
+	method := "cert"
+	_, err := self.client.Authenticator.CreateAuthenticator(&authenticator.CreateAuthenticatorParams{
+		Authenticator: &rest_model.AuthenticatorCreate{
+			IdentityID: &identityId,
+			Method:     &method,
+			CertPem:    auth.CertPem,
+		},
+		Context: context.Background(),
+	}, nil)
+	if err != nil {
+		self.warnf("unable to create cert authenticator with fingerprint %s for identity %s: %v\n", auth.Fingerprint, name, util.WrapIfApiError(err))
+		return
+	}
+	self.printf("created cert authenticator with fingerprint %s for identity %s\n", auth.Fingerprint, name)
+}
+
+func (self *identityImporter) createEnrollment(name, identityId, method, caId, username string) {
+	expiresAt := strfmt.DateTime(time.Now().Add(self.options.enrollmentDuration))
+	create := &rest_model.EnrollmentCreate{
+		ExpiresAt:  &expiresAt,
+		IdentityID: &identityId,
+		Method:     &method,
+	}
+
+	if caId != "" {
+		create.CaID = &caId
+	}
+
+	if username != "" {
+		create.Username = &username
+	}
+
+	resp, err := self.client.Enrollment.CreateEnrollment(&enrollment.CreateEnrollmentParams{
+		Enrollment: create,
+		Context:    context.Background(),
+	}, nil)
+	if err != nil {
+		self.warnf("unable to create %s enrollment for identity %s: %v\n", method, name, util.WrapIfApiError(err))
+		return
+	}
+	self.printf("created %s enrollment %s for identity %s\n", method, resp.GetPayload().Data.ID, name)
+}
+
+func (self *identityImporter) getAuthPolicyId(name string) (string, error) {
+	if id, found := self.authPolicies[name]; found {
+		return id, nil
+	}
+
+	detail := mgmt.AuthPolicyFromFilter(self.client, mgmt.NameFilter(name))
+	if detail == nil {
+		return "", errors.Errorf("no auth policy found with name %s", name)
+	}
+	self.authPolicies[name] = *detail.ID
+	return *detail.ID, nil
+}
+
+func (self *identityImporter) getCaId(name string) (string, error) {
+	if id, found := self.cas[name]; found {
+		return id, nil
+	}
+
+	detail := mgmt.CertificateAuthorityFromFilter(self.client, mgmt.NameFilter(name))
+	if detail == nil {
+		return "", errors.Errorf("no CA found with name %s", name)
+	}
+	self.cas[name] = *detail.ID
+	return *detail.ID, nil
+}
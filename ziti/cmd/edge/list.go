@@ -92,7 +92,7 @@ func newListCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	networkJwtOptionOutput := ""
 	networkJwtOptions.OptionsMap["output"] = &networkJwtOptionOutput
 	networkJwtsCmd := newListCmdForEntityType("network-jwts", runListNetworkJwts, networkJwtOptions, "network-jwts")
-	networkJwtsCmd.Flags().StringVarP(&networkJwtOptionOutput, "output", "o", "", "if specified, will output the default network jwt to a file")
+	networkJwtsCmd.Flags().StringVarP(&networkJwtOptionOutput, "output-file", "o", "", "if specified, will output the default network jwt to a file")
 	cmd.AddCommand(networkJwtsCmd)
 
 	cmd.AddCommand(newListCmdForEntityType("terminators", runListTerminators, newOptions()))
@@ -342,6 +342,7 @@ func (o *LoginOptions) Run() error {
 			CaCert:    o.CaCert,
 			ReadOnly:  o.ReadOnly,
 		}
+		loginIdentity.ClusterUrls = o.discoverClusterUrls(host)
 		o.Printf("Saving identity '%v' to %v\n", id, configFile)
 		config.EdgeIdentities[id] = loginIdentity
 
@@ -350,6 +351,34 @@ func (o *LoginOptions) Run() error {
 	return nil
 }
 
+// discoverClusterUrls returns the management API URLs of all controllers, if the controller is part of a cluster.
+// Failures aren't fatal, as the login is still usable against the single controller.
+func (o *LoginOptions) discoverClusterUrls(host string) []string {
+	client := util.NewClient()
+	if o.CaCert != "" {
+		client.SetRootCertificate(o.CaCert)
+	} else if o.FileCertCreds != nil && o.FileCertCreds.CaPool != nil {
+		client.SetTLSClientConfig(&tls.Config{RootCAs: o.FileCertCreds.CaPool})
+	}
+	client.SetTimeout(time.Duration(o.Timeout) * time.Second)
+	client.SetDebug(o.Verbose)
+
+	clusterUrls, err := util.DiscoverClusterUrls(client, host, o.Token)
+	if err != nil {
+		if o.Verbose {
+			o.Printf("unable to discover cluster controllers: %v\n", err)
+		}
+		return nil
+	}
+
+	if len(clusterUrls) < 2 {
+		return nil
+	}
+
+	o.Printf("Controller is part of a cluster, saving %v controller urls: %v\n", len(clusterUrls), strings.Join(clusterUrls, ", "))
+	return clusterUrls
+}
+
 func (o *LoginOptions) ConfigureCerts(host string, ctrlUrl *url.URL) error {
 	isServerTrusted, err := util.IsServerTrusted(host)
 	if err != nil {
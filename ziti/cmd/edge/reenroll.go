@@ -33,6 +33,7 @@ func newReEnrollCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	}
 
 	cmd.AddCommand(newReEnrollEdgeRouterCmd(out, errOut))
+	cmd.AddCommand(newReEnrollIdentityCmd(out, errOut))
 
 	return cmd
 }
@@ -0,0 +1,309 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/openziti/edge-api/rest_client_api_client/current_api_session"
+	"github.com/openziti/edge-api/rest_model"
+	nfpem "github.com/openziti/foundation/v2/pem"
+	"github.com/openziti/identity"
+	edge_apis "github.com/openziti/sdk-golang/edge-apis"
+	"github.com/openziti/sdk-golang/ziti"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type reEnrollIdentityOptions struct {
+	common.CommonOptions
+	outputPath string
+	keyAlg     ziti.KeyAlgVar
+	keepKey    bool
+}
+
+func newReEnrollIdentityCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &reEnrollIdentityOptions{
+		CommonOptions: common.CommonOptions{Out: out, Err: errOut},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "identity <identity file>",
+		Short: "rotates the key and certificate of an enrolled identity, using the identity itself to authenticate",
+		Long: "Authenticates to the controller with the given identity file, generates a new key pair and has the " +
+			"identity's certificate authenticator extended with a certificate for the new key. The identity keeps its id, " +
+			"role attributes and policies. The updated identity file is written back in place, unless --out is given.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := runReEnrollIdentity(options)
+			cmdhelper.CheckErr(err)
+		},
+		SuggestFor: []string{},
+	}
+
+	// allow interspersing positional args and flags
+	cmd.Flags().SetInterspersed(true)
+	cmd.Flags().StringVarP(&options.outputPath, "out", "o", "", "File to write the updated identity to. Defaults to overwriting the identity file")
+	cmd.Flags().BoolVar(&options.keepKey, "keep-key", false, "Keep the current key and only renew the certificate")
+
+	if err := options.keyAlg.Set("EC"); err != nil { // set default
+		panic(err)
+	}
+	cmd.Flags().VarP(&options.keyAlg, "keyAlg", "a", "Crypto algorithm to use when generating the new private key")
+
+	return cmd
+}
+
+func runReEnrollIdentity(o *reEnrollIdentityOptions) error {
+	identityFile := o.Args[0]
+	outputPath := o.outputPath
+	if outputPath == "" {
+		outputPath = identityFile
+	}
+
+	raw, err := os.ReadFile(identityFile)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read identity file %s", identityFile)
+	}
+
+	cfg, err := ziti.NewConfigFromFile(identityFile)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load identity file %s", identityFile)
+	}
+
+	id, err := identity.LoadIdentity(cfg.ID)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load identity from %s", identityFile)
+	}
+
+	tlsCert := id.Cert()
+	if tlsCert == nil || len(tlsCert.Certificate) == 0 {
+		return errors.Errorf("identity file %s does not contain a certificate", identityFile)
+	}
+
+	currentCert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		return errors.Wrap(err, "unable to parse identity certificate")
+	}
+
+	apiUrls, err := getIdentityApiUrls(cfg)
+	if err != nil {
+		return err
+	}
+
+	client := edge_apis.NewClientApiClient(apiUrls, id.CA(), nil)
+	if _, err = client.Authenticate(edge_apis.NewIdentityCredentials(id), nil); err != nil {
+		return errors.Wrap(err, "unable to authenticate with identity")
+	}
+
+	authenticatorId, err := findIdentityCertAuthenticator(client, currentCert)
+	if err != nil {
+		return err
+	}
+
+	var key crypto.PrivateKey
+	var keyPem []byte
+	if o.keepKey {
+		if key, err = identity.LoadKey(cfg.ID.Key); err != nil {
+			return errors.Wrap(err, "unable to load current key")
+		}
+	} else if key, keyPem, err = generateIdentityKey(o.keyAlg); err != nil {
+		return err
+	}
+
+	csrPem, err := createIdentityCsr(currentCert, key)
+	if err != nil {
+		return err
+	}
+
+	extendParams := current_api_session.NewExtendCurrentIdentityAuthenticatorParams()
+	extendParams.ID = authenticatorId
+	extendParams.Extend = &rest_model.IdentityExtendEnrollmentRequest{
+		ClientCertCsr: &csrPem,
+	}
+
+	extendResp, err := client.API.CurrentAPISession.ExtendCurrentIdentityAuthenticator(extendParams, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to extend certificate authenticator")
+	}
+
+	newCerts := extendResp.Payload.Data
+	if newCerts == nil || newCerts.ClientCert == "" {
+		return errors.New("controller did not return a new certificate")
+	}
+
+	updated, err := updateIdentityFile(raw, newCerts.ClientCert, keyPem, newCerts.Ca)
+	if err != nil {
+		return err
+	}
+
+	// The new certificate only becomes valid once verified, so the updated identity is staged alongside the output
+	// file and only moved into place once the controller has accepted the verification. Until then, the current
+	// certificate stays valid.
+	stagedPath := outputPath + ".new"
+	if err = os.WriteFile(stagedPath, updated, 0600); err != nil {
+		return errors.Wrapf(err, "unable to write updated identity to %s", stagedPath)
+	}
+
+	verifyParams := current_api_session.NewExtendVerifyCurrentIdentityAuthenticatorParams()
+	verifyParams.ID = authenticatorId
+	verifyParams.Extend = &rest_model.IdentityExtendValidateEnrollmentRequest{
+		ClientCert: &newCerts.ClientCert,
+	}
+
+	if _, err = client.API.CurrentAPISession.ExtendVerifyCurrentIdentityAuthenticator(verifyParams, nil); err != nil {
+		_ = os.Remove(stagedPath)
+		return errors.Wrap(err, "unable to verify extended certificate, the current certificate is still valid")
+	}
+
+	if err = os.Rename(stagedPath, outputPath); err != nil {
+		return errors.Wrapf(err, "certificate rotated, but the updated identity could not be moved into place. it has been left in %s", stagedPath)
+	}
+
+	o.Printf("re-enroll identity with authenticator %v: %v\n", authenticatorId, color.New(color.FgGreen, color.Bold).Sprint("OK"))
+	o.Printf("updated identity written to %v\n", outputPath)
+	return nil
+}
+
+func getIdentityApiUrls(cfg *ziti.Config) ([]*url.URL, error) {
+	addresses := cfg.ZtAPIs
+	if len(addresses) == 0 && cfg.ZtAPI != "" {
+		addresses = []string{cfg.ZtAPI}
+	}
+
+	if len(addresses) == 0 {
+		return nil, errors.New("identity file does not contain a controller address")
+	}
+
+	var result []*url.URL
+	for _, address := range addresses {
+		apiUrl, err := url.Parse(address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid controller address '%s'", address)
+		}
+		result = append(result, apiUrl)
+	}
+	return result, nil
+}
+
+// findIdentityCertAuthenticator returns the id of the certificate authenticator matching the certificate the
+// identity authenticated with
+func findIdentityCertAuthenticator(client *edge_apis.ClientApiClient, cert *x509.Certificate) (string, error) {
+	fingerprint := nfpem.FingerprintFromCertificate(cert)
+
+	params := current_api_session.NewListCurrentIdentityAuthenticatorsParams()
+	resp, err := client.API.CurrentAPISession.ListCurrentIdentityAuthenticators(params, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to list authenticators for identity")
+	}
+
+	for _, authenticator := range resp.Payload.Data {
+		if authenticator.Method == nil || *authenticator.Method != "cert" {
+			continue
+		}
+		if strings.EqualFold(authenticator.Fingerprint, fingerprint) {
+			if !authenticator.IsIssuedByNetwork {
+				return "", errors.New("the identity certificate was not issued by the network and can not be rotated, use 'ziti edge update authenticator cert --re-enroll' instead")
+			}
+			return *authenticator.ID, nil
+		}
+	}
+
+	return "", errors.Errorf("no certificate authenticator found with fingerprint %s", fingerprint)
+}
+
+func generateIdentityKey(keyAlg ziti.KeyAlgVar) (crypto.PrivateKey, []byte, error) {
+	if keyAlg.EC() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "unable to generate EC key")
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "unable to marshal EC key")
+		}
+		return key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+	}
+
+	if keyAlg.RSA() {
+		key, err := rsa.GenerateKey(rand.Reader, 4096)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "unable to generate RSA key")
+		}
+		return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), nil
+	}
+
+	return nil, nil, errors.Errorf("unsupported key algorithm: %s", keyAlg.Get())
+}
+
+func createIdentityCsr(cert *x509.Certificate, key crypto.PrivateKey) (string, error) {
+	template := &x509.CertificateRequest{
+		Subject: cert.Subject,
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create certificate signing request")
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
+
+// updateIdentityFile replaces the certificate, and optionally the key and CA bundle, of an identity file. Other
+// fields of the identity file are left untouched. The new values are stored inline, so any key or certificate files
+// the identity previously referenced are not modified.
+func updateIdentityFile(raw []byte, certPem string, keyPem []byte, caPem string) ([]byte, error) {
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, errors.Wrap(err, "unable to parse identity file")
+	}
+
+	idSection, ok := doc["id"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("identity file does not contain an id section")
+	}
+
+	idSection["cert"] = "pem:" + certPem
+	if len(keyPem) > 0 {
+		idSection["key"] = "pem:" + string(keyPem)
+	}
+	if caPem != "" {
+		idSection["ca"] = "pem:" + caPem
+	}
+
+	result, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encode identity file")
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/openziti/sdk-golang/ziti"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateIdentityKeyAndCsr(t *testing.T) {
+	req := require.New(t)
+
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "test-identity"},
+	}
+
+	var keyAlg ziti.KeyAlgVar
+	req.NoError(keyAlg.Set("EC"))
+
+	key, keyPem, err := generateIdentityKey(keyAlg)
+	req.NoError(err)
+	req.IsType(&ecdsa.PrivateKey{}, key)
+
+	block, _ := pem.Decode(keyPem)
+	req.NotNil(block)
+	req.Equal("EC PRIVATE KEY", block.Type)
+
+	csrPem, err := createIdentityCsr(cert, key)
+	req.NoError(err)
+
+	block, _ = pem.Decode([]byte(csrPem))
+	req.NotNil(block)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	req.NoError(err)
+	req.NoError(csr.CheckSignature())
+	req.Equal("test-identity", csr.Subject.CommonName)
+
+	req.NoError(keyAlg.Set("RSA"))
+	key, keyPem, err = generateIdentityKey(keyAlg)
+	req.NoError(err)
+	req.IsType(&rsa.PrivateKey{}, key)
+	block, _ = pem.Decode(keyPem)
+	req.NotNil(block)
+	req.Equal("RSA PRIVATE KEY", block.Type)
+}
+
+func TestUpdateIdentityFile(t *testing.T) {
+	req := require.New(t)
+
+	raw := []byte(`{
+  "ztAPI": "https://ctrl.example.com:1280/edge/client/v1",
+  "configTypes": ["intercept.v1"],
+  "id": {
+    "cert": "pem:old-cert",
+    "key": "file:///etc/ziti/identity.key",
+    "ca": "pem:old-ca"
+  }
+}`)
+
+	updated, err := updateIdentityFile(raw, "new-cert", []byte("new-key"), "")
+	req.NoError(err)
+
+	doc := map[string]interface{}{}
+	req.NoError(json.Unmarshal(updated, &doc))
+	req.Equal("https://ctrl.example.com:1280/edge/client/v1", doc["ztAPI"])
+	req.Equal([]interface{}{"intercept.v1"}, doc["configTypes"])
+
+	id := doc["id"].(map[string]interface{})
+	req.Equal("pem:new-cert", id["cert"])
+	req.Equal("pem:new-key", id["key"])
+	req.Equal("pem:old-ca", id["ca"])
+
+	// keeping the key leaves the key reference alone
+	updated, err = updateIdentityFile(raw, "new-cert", nil, "new-ca")
+	req.NoError(err)
+
+	doc = map[string]interface{}{}
+	req.NoError(json.Unmarshal(updated, &doc))
+	id = doc["id"].(map[string]interface{})
+	req.Equal("file:///etc/ziti/identity.key", id["key"])
+	req.Equal("pem:new-ca", id["ca"])
+
+	_, err = updateIdentityFile([]byte(`{"ztAPI": "https://ctrl"}`), "new-cert", nil, "")
+	req.Error(err)
+}
@@ -36,6 +36,7 @@ func NewCmdEdge(out io.Writer, errOut io.Writer, p common.OptionsProvider) *cobr
 	cmd.AddCommand(NewLoginCmd(out, errOut))
 	cmd.AddCommand(newLogoutCmd(out, errOut))
 	cmd.AddCommand(newUseCmd(out, errOut))
+	cmd.AddCommand(newUseClusterCmd(out, errOut))
 	cmd.AddCommand(newListCmd(out, errOut))
 	cmd.AddCommand(newUpdateCmd(out, errOut))
 	cmd.AddCommand(newVersionCmd(out, errOut))
@@ -46,6 +47,8 @@ func NewCmdEdge(out io.Writer, errOut io.Writer, p common.OptionsProvider) *cobr
 	cmd.AddCommand(newTraceRouteCmd(out, errOut))
 	cmd.AddCommand(newShowCmd(out, errOut))
 	cmd.AddCommand(newReEnrollCmd(out, errOut))
+	cmd.AddCommand(newExportCmd(out, errOut))
+	cmd.AddCommand(newImportCmd(out, errOut))
 	cmd.AddCommand(newValidateCommand(p))
 	cmd.AddCommand(enroll.NewEnrollIdentityCommand(p))
 
@@ -39,6 +39,7 @@ func newShowCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 
 	showCmd.AddCommand(newShowConfigTypeAction(out, errOut))
 	showCmd.AddCommand(newShowConfigAction(out, errOut))
+	showCmd.AddCommand(newShowTokenAction(out, errOut))
 	return showCmd
 }
 
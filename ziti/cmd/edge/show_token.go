@@ -0,0 +1,199 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/resty.v1"
+)
+
+type showTokenAction struct {
+	api.Options
+	service string
+}
+
+func newShowTokenAction(out io.Writer, errOut io.Writer) *cobra.Command {
+	action := &showTokenAction{
+		Options: api.Options{
+			CommonOptions: common.CommonOptions{
+				Out: out,
+				Err: errOut,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "token <api session or session token | ->",
+		Short: "displays what the controller knows about an api session or session token",
+		Long: "Displays the identity, expiry, issuing controller, posture data and service access of an api session or\n" +
+			"session token, as seen by the controller. Both legacy and JWT tokens are supported. Use - to read the token\n" +
+			"from stdin, so it doesn't end up in shell history.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			action.Cmd = cmd
+			action.Args = args
+			return action.run(args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&action.service, "service", "s", "", "Only report access to the given service, by id or name, when showing an api session token")
+	action.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+func (self *showTokenAction) run(token string) error {
+	if token == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return errors.Wrap(err, "unable to read token from stdin")
+		}
+		token = string(data)
+	}
+
+	request := map[string]string{
+		"token": strings.TrimSpace(token),
+	}
+
+	if self.service != "" {
+		serviceId, err := mapNameToID("services", self.service, self.Options)
+		if err != nil {
+			return err
+		}
+		request["serviceId"] = serviceId
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	result, err := util.EdgeControllerRequest("token-introspect", self.Out, self.OutputJSONResponse, self.Timeout, self.Verbose,
+		func(request *resty.Request, url string) (*resty.Response, error) {
+			return request.SetBody(body).Post(url)
+		})
+	if err != nil {
+		return err
+	}
+
+	if self.OutputJSONResponse {
+		return nil
+	}
+
+	return self.output(api.Wrap(result.Path("data")))
+}
+
+func (self *showTokenAction) output(data *api.GabsWrapper) error {
+	out := self.Cmd.OutOrStdout()
+
+	valid := "yes"
+	if !data.Bool("valid") {
+		valid = "no"
+		if reason := data.String("reason"); reason != "" {
+			valid += " (" + reason + ")"
+		}
+	}
+
+	issuer := data.String("issuer")
+	if ctrlId := data.String("issuingControllerId"); ctrlId != "" {
+		issuer += " (controller " + ctrlId + ")"
+	}
+
+	_, _ = fmt.Fprintf(out, "Valid:        %s\n", valid)
+	_, _ = fmt.Fprintf(out, "Type:         %s %s\n", data.String("format"), data.String("type"))
+	if issuer != "" {
+		_, _ = fmt.Fprintf(out, "Issuer:       %s\n", issuer)
+	}
+	_, _ = fmt.Fprintf(out, "Evaluated by: %s\n", data.String("evaluatedBy"))
+	_, _ = fmt.Fprintf(out, "Issued at:    %s\n", data.String("issuedAt"))
+	_, _ = fmt.Fprintf(out, "Expires at:   %s\n", data.String("expiresAt"))
+
+	if data.Exists("identity") {
+		flags := ""
+		if data.Bool("identity.isAdmin") {
+			flags += ", admin"
+		}
+		if data.Bool("identity.disabled") {
+			flags += ", disabled"
+		}
+		_, _ = fmt.Fprintf(out, "Identity:     %s (%s)%s\n", data.String("identity.name"), data.String("identity.id"), flags)
+	}
+
+	if data.Exists("apiSession") {
+		_, _ = fmt.Fprintf(out, "API session:  %s, mfa complete: %v\n", data.String("apiSession.id"), data.Bool("apiSession.mfaComplete"))
+	}
+
+	if data.Exists("session") {
+		_, _ = fmt.Fprintf(out, "Session:      %s, %s %s (%s), api session %s\n", data.String("session.id"), data.String("session.type"),
+			data.String("session.serviceName"), data.String("session.serviceId"), data.String("session.apiSessionId"))
+	}
+
+	if data.Exists("posture") {
+		if data.Bool("posture.hasData") {
+			_, _ = fmt.Fprintf(out, "Posture:      os %s %s, domain %s, mac addresses %s, processes %v, session failures %v\n",
+				data.String("posture.osType"), data.String("posture.osVersion"), data.String("posture.domain"),
+				strings.Join(data.StringSlice("posture.macAddresses"), ","), data.Float64("posture.processCount"),
+				data.Float64("posture.sessionRequestFailures"))
+		} else {
+			_, _ = fmt.Fprintf(out, "Posture:      no posture data on this controller\n")
+		}
+	}
+
+	services, _ := data.Path("services").Children()
+	if len(services) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(out)
+	t := table.NewWriter()
+	t.SetStyle(table.StyleRounded)
+	t.AppendHeader(table.Row{"Service", "Permissions", "Type", "Allowed", "Reason"})
+
+	for _, service := range services {
+		serviceWrapper := api.Wrap(service)
+		accessList, _ := service.Path("access").Children()
+		for _, access := range accessList {
+			accessWrapper := api.Wrap(access)
+			t.AppendRow(table.Row{
+				serviceWrapper.String("name"),
+				strings.Join(serviceWrapper.StringSlice("permissions"), ","),
+				accessWrapper.String("type"),
+				accessWrapper.Bool("allowed"),
+				accessWrapper.String("reason"),
+			})
+		}
+	}
+
+	api.RenderTable(&self.Options, t, nil)
+
+	if data.Bool("servicesTruncated") {
+		_, _ = fmt.Fprintln(out, "only the first services are shown, use --service to check a specific service")
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Jeffail/gabs"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func runShowTokenOutput(t *testing.T, data string) string {
+	req := require.New(t)
+
+	container, err := gabs.ParseJSON([]byte(data))
+	req.NoError(err)
+
+	out := &bytes.Buffer{}
+	action := &showTokenAction{}
+	action.Cmd = &cobra.Command{}
+	action.Cmd.SetOut(out)
+	req.NoError(action.output(api.Wrap(container)))
+	return out.String()
+}
+
+func TestShowTokenOutput(t *testing.T) {
+	t.Run("invalid tokens show the reason", func(t *testing.T) {
+		req := require.New(t)
+		out := runShowTokenOutput(t, `{
+			"valid": false,
+			"reason": "no api session or session found for token",
+			"format": "legacy",
+			"evaluatedBy": "ctrl1"
+		}`)
+
+		req.Contains(out, "Valid:        no (no api session or session found for token)\n")
+		req.Contains(out, "Evaluated by: ctrl1\n")
+		req.NotContains(out, "Issuer:")
+		req.NotContains(out, "Identity:")
+		req.NotContains(out, "Service")
+	})
+
+	t.Run("session tokens show the identity, session and service access", func(t *testing.T) {
+		req := require.New(t)
+		out := runShowTokenOutput(t, `{
+			"valid": true,
+			"format": "jwt",
+			"type": "session",
+			"issuer": "https://ctrl1.example.com:1280/oidc",
+			"issuingControllerId": "ctrl1",
+			"evaluatedBy": "ctrl2",
+			"identity": {"id": "id1", "name": "client", "isAdmin": false, "disabled": true},
+			"session": {"id": "s1", "type": "Dial", "apiSessionId": "as1", "serviceId": "svc1", "serviceName": "echo"},
+			"posture": {"hasData": false},
+			"services": [{
+				"id": "svc1",
+				"name": "echo",
+				"permissions": ["Dial"],
+				"access": [{"type": "Dial", "allowed": false, "schedulePassed": true, "posturePassed": false, "reason": "posture check failed"}]
+			}],
+			"servicesTruncated": true
+		}`)
+
+		req.Contains(out, "Valid:        yes\n")
+		req.Contains(out, "Type:         jwt session\n")
+		req.Contains(out, "Issuer:       https://ctrl1.example.com:1280/oidc (controller ctrl1)\n")
+		req.Contains(out, "Identity:     client (id1), disabled\n")
+		req.Contains(out, "Session:      s1, Dial echo (svc1), api session as1\n")
+		req.Contains(out, "Posture:      no posture data on this controller\n")
+		req.Contains(out, "posture check failed")
+		req.Contains(out, "only the first services are shown")
+	})
+}
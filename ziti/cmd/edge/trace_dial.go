@@ -0,0 +1,328 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/gabs"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/foundation/v2/stringz"
+	"github.com/openziti/sdk-golang/ziti"
+	"github.com/openziti/sdk-golang/ziti/edge"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	traceStageOk   = "OK"
+	traceStageFail = "FAIL"
+	traceStageSkip = "SKIP"
+)
+
+// dialTracer performs a synthetic dial of a service as an identity and reports on each stage of the dial. Stages
+// which need management API access, such as looking up the circuit path, are skipped if the CLI isn't logged in
+// as an administrator.
+type dialTracer struct {
+	*traceIdentityOptions
+	ctx         ziti.Context
+	advice      *gabs.Container
+	adminFailed bool
+}
+
+func runTraceIdentityDial(o *traceIdentityOptions) error {
+	if o.configFile == "" {
+		return errors.New("--config-file is required when tracing a dial")
+	}
+
+	cfg, err := ziti.NewConfigFromFile(o.configFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := ziti.NewContext(cfg)
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	tracer := &dialTracer{
+		traceIdentityOptions: o,
+		ctx:                  ctx,
+	}
+
+	_, err = fmt.Fprintf(o.Out, "tracing dial of service %v by identity %v\n\n", o.service, o.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if err = tracer.run(); err != nil {
+		return err
+	}
+
+	if tracer.adminFailed {
+		_, err = fmt.Fprintln(o.Out, "\nSome stages were skipped. To include them, use ziti edge login first and be an administrator.")
+	}
+	return err
+}
+
+func (self *dialTracer) run() error {
+	if err := self.authenticate(); err != nil {
+		return err
+	}
+
+	if err := self.evaluatePolicies(); err != nil {
+		return err
+	}
+
+	conn, err := self.dial()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err = conn.Close(); err != nil {
+			logrus.WithError(err).Error("failed to close connection")
+		}
+	}()
+
+	circuit := self.getCircuit(conn.GetCircuitId())
+
+	if err = self.tracePath(conn, circuit); err != nil {
+		return err
+	}
+
+	self.reportTerminator(circuit)
+
+	return self.measureFirstByte(conn)
+}
+
+func (self *dialTracer) authenticate() error {
+	start := time.Now()
+	if err := self.ctx.Authenticate(); err != nil {
+		return self.fail("authentication", time.Since(start), err)
+	}
+	elapsed := time.Since(start)
+
+	currentIdentity, err := self.ctx.GetCurrentIdentity()
+	if err != nil {
+		return self.fail("authentication", elapsed, err)
+	}
+
+	identityId := stringz.OrEmpty(currentIdentity.ID)
+	identityName := stringz.OrEmpty(currentIdentity.Name)
+	if self.Args[0] != identityId && self.Args[0] != identityName {
+		err = errors.Errorf("config file is for identity %v (%v), not %v", identityName, identityId, self.Args[0])
+		return self.fail("authentication", elapsed, err)
+	}
+
+	self.reportStage("authentication", traceStageOk, elapsed, fmt.Sprintf("authenticated as %v (%v)", identityName, identityId))
+	return nil
+}
+
+func (self *dialTracer) evaluatePolicies() error {
+	start := time.Now()
+	service, found := self.ctx.GetService(self.service)
+	elapsed := time.Since(start)
+
+	if !found {
+		return self.fail("policy evaluation", elapsed, errors.Errorf("service %v not visible to identity. Check service policies", self.service))
+	}
+
+	dialAllowed := false
+	for _, permission := range service.Permissions {
+		if permission == rest_model.DialBindDial {
+			dialAllowed = true
+		}
+	}
+
+	if !dialAllowed {
+		return self.fail("policy evaluation", elapsed, errors.Errorf("identity does not have dial access to service %v. Check service policies", self.service))
+	}
+
+	self.advice = self.getPolicyAdvice(stringz.OrEmpty(service.ID))
+	if self.advice == nil {
+		self.reportStage("policy evaluation", traceStageOk, elapsed, "service visible with dial permission")
+		return nil
+	}
+
+	identityRouterCount, _ := self.advice.S("data", "identityRouterCount").Data().(float64)
+	serviceRouterCount, _ := self.advice.S("data", "serviceRouterCount").Data().(float64)
+	self.reportStage("policy evaluation", traceStageOk, elapsed,
+		fmt.Sprintf("service visible with dial permission. identity routers: %v, service routers: %v", identityRouterCount, serviceRouterCount))
+	return nil
+}
+
+func (self *dialTracer) dial() (edge.Conn, error) {
+	routerDetail := ""
+	if self.advice != nil {
+		commonRouters, _ := self.advice.S("data", "commonRouters").Children()
+		onlineCount := 0
+		for _, commonRouter := range commonRouters {
+			if isOnline, _ := commonRouter.S("isOnline").Data().(bool); isOnline {
+				onlineCount++
+			}
+		}
+		routerDetail = fmt.Sprintf(" (%v of %v common routers online)", onlineCount, len(commonRouters))
+	}
+
+	start := time.Now()
+	conn, err := self.ctx.DialWithOptions(self.service, &ziti.DialOptions{
+		ConnectTimeout: self.dialTimeout,
+	})
+	elapsed := time.Since(start)
+
+	if err != nil {
+		return nil, self.fail("edge router selection", elapsed, errors.Wrapf(err, "dial failed%v", routerDetail))
+	}
+
+	routerLabel := conn.GetRouterId()
+	if name, err := mapIdToName("edge-routers", routerLabel, self.Options); err == nil {
+		routerLabel = fmt.Sprintf("%v (%v)", name, routerLabel)
+	}
+
+	self.reportStage("edge router selection", traceStageOk, elapsed, fmt.Sprintf("dialed via %v%v", routerLabel, routerDetail))
+	return conn, nil
+}
+
+func (self *dialTracer) tracePath(conn edge.Conn, circuit *gabs.Container) error {
+	result, err := conn.TraceRoute(math.MaxUint32, self.dialTimeout)
+	if err != nil {
+		return self.fail("circuit path", 0, err)
+	}
+
+	if result.Error != "" {
+		return self.fail("circuit path", result.Time, errors.Errorf("%v[%v] reported: %v", result.HopType, result.HopId, result.Error))
+	}
+
+	if circuit == nil {
+		self.reportStage("circuit path", traceStageOk, result.Time, fmt.Sprintf("circuit %v ends at %v[%v]", conn.GetCircuitId(), result.HopType, result.HopId))
+		return nil
+	}
+
+	nodes, _ := circuit.S("data", "path", "nodes").Children()
+	links, _ := circuit.S("data", "path", "links").Children()
+
+	pathLabel := strings.Builder{}
+	for idx, node := range nodes {
+		if idx > 0 && idx-1 < len(links) {
+			linkId, _ := links[idx-1].S("id").Data().(string)
+			pathLabel.WriteString(" -> l/")
+			pathLabel.WriteString(linkId)
+			pathLabel.WriteString(" -> ")
+		}
+		nodeName, _ := node.S("name").Data().(string)
+		pathLabel.WriteString("r/")
+		pathLabel.WriteString(nodeName)
+	}
+
+	self.reportStage("circuit path", traceStageOk, result.Time, fmt.Sprintf("circuit %v: %v", conn.GetCircuitId(), pathLabel.String()))
+	return nil
+}
+
+func (self *dialTracer) reportTerminator(circuit *gabs.Container) {
+	if circuit == nil {
+		self.reportStage("terminator chosen", traceStageSkip, 0, "requires admin access")
+		return
+	}
+
+	terminatorId, _ := circuit.S("data", "terminator", "id").Data().(string)
+	terminator, err := util.ControllerList(util.FabricAPI, "terminators/"+terminatorId, nil, false, self.Out, self.Options.Timeout, self.Options.Verbose)
+	if err != nil {
+		self.reportStage("terminator chosen", traceStageOk, 0, terminatorId)
+		return
+	}
+
+	routerName, _ := terminator.S("data", "router", "name").Data().(string)
+	binding, _ := terminator.S("data", "binding").Data().(string)
+	address, _ := terminator.S("data", "address").Data().(string)
+	precedence, _ := terminator.S("data", "precedence").Data().(string)
+
+	self.reportStage("terminator chosen", traceStageOk, 0,
+		fmt.Sprintf("%v on router %v, binding: %v, address: %v, precedence: %v", terminatorId, routerName, binding, address, precedence))
+}
+
+func (self *dialTracer) measureFirstByte(conn edge.Conn) error {
+	start := time.Now()
+	if self.probe != "" {
+		if _, err := conn.Write([]byte(self.probe)); err != nil {
+			return self.fail("first byte", time.Since(start), err)
+		}
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(self.dialTimeout)); err != nil {
+		return self.fail("first byte", 0, err)
+	}
+
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		var timeoutErr interface{ Timeout() bool }
+		if errors.As(err, &timeoutErr) && timeoutErr.Timeout() {
+			detail := fmt.Sprintf("no data received within %v. Use --probe to send data for protocols where the client speaks first", self.dialTimeout)
+			self.reportStage("first byte", traceStageSkip, time.Since(start), detail)
+			return nil
+		}
+		return self.fail("first byte", time.Since(start), err)
+	}
+
+	self.reportStage("first byte", traceStageOk, time.Since(start), "first byte received from hosting application")
+	return nil
+}
+
+// getPolicyAdvice returns the controller's policy advice for the identity and service, or nil if the CLI doesn't
+// have management access
+func (self *dialTracer) getPolicyAdvice(serviceId string) *gabs.Container {
+	identityId, err := mapNameToID("identities", self.Args[0], self.Options)
+	if err != nil {
+		self.adminFailed = true
+		return nil
+	}
+
+	result, err := util.EdgeControllerList("identities/"+identityId+"/policy-advice/"+serviceId, nil, false, self.Out, self.Options.Timeout, self.Options.Verbose)
+	if err != nil {
+		self.adminFailed = true
+		return nil
+	}
+	return result
+}
+
+// getCircuit returns the fabric's view of the circuit, or nil if the CLI doesn't have management access
+func (self *dialTracer) getCircuit(circuitId string) *gabs.Container {
+	result, err := util.ControllerList(util.FabricAPI, "circuits/"+circuitId, nil, false, self.Out, self.Options.Timeout, self.Options.Verbose)
+	if err != nil {
+		self.adminFailed = true
+		return nil
+	}
+	return result
+}
+
+func (self *dialTracer) fail(stage string, elapsed time.Duration, err error) error {
+	self.reportStage(stage, traceStageFail, elapsed, err.Error())
+	return errors.Errorf("dial trace failed at stage: %v", stage)
+}
+
+func (self *dialTracer) reportStage(stage, status string, elapsed time.Duration, detail string) {
+	elapsedLabel := ""
+	if elapsed > 0 {
+		elapsedLabel = elapsed.Round(time.Microsecond).String()
+	}
+	_, _ = fmt.Fprintf(self.Out, "%-22v %-4v %12v  %v\n", stage, status, elapsedLabel, detail)
+}
@@ -0,0 +1,222 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/sdk-golang/ziti"
+	"github.com/openziti/sdk-golang/ziti/edge"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	"github.com/stretchr/testify/require"
+)
+
+// testTraceContext implements the parts of ziti.Context used when tracing a dial
+type testTraceContext struct {
+	ziti.Context
+	identity *rest_model.IdentityDetail
+	service  *rest_model.ServiceDetail
+	conn     *testTraceConn
+}
+
+func (self *testTraceContext) Authenticate() error {
+	return nil
+}
+
+func (self *testTraceContext) GetCurrentIdentity() (*rest_model.IdentityDetail, error) {
+	return self.identity, nil
+}
+
+func (self *testTraceContext) GetService(serviceName string) (*rest_model.ServiceDetail, bool) {
+	if self.service == nil || *self.service.Name != serviceName {
+		return nil, false
+	}
+	return self.service, true
+}
+
+func (self *testTraceContext) DialWithOptions(string, *ziti.DialOptions) (edge.Conn, error) {
+	return self.conn, nil
+}
+
+// testTraceConn implements the parts of edge.Conn used when tracing a dial
+type testTraceConn struct {
+	edge.Conn
+	traceResult *edge.TraceRouteResult
+	readErr     error
+	written     bytes.Buffer
+	closed      bool
+}
+
+func (self *testTraceConn) GetRouterId() string {
+	return "er1"
+}
+
+func (self *testTraceConn) GetCircuitId() string {
+	return "circuit1"
+}
+
+func (self *testTraceConn) TraceRoute(uint32, time.Duration) (*edge.TraceRouteResult, error) {
+	return self.traceResult, nil
+}
+
+func (self *testTraceConn) Write(b []byte) (int, error) {
+	return self.written.Write(b)
+}
+
+func (self *testTraceConn) Read(b []byte) (int, error) {
+	if self.readErr != nil {
+		return 0, self.readErr
+	}
+	b[0] = 'x'
+	return 1, nil
+}
+
+func (self *testTraceConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (self *testTraceConn) Close() error {
+	self.closed = true
+	return nil
+}
+
+func newTestDialTracer(t *testing.T) (*dialTracer, *testTraceContext, *bytes.Buffer) {
+	// no CLI login, so the stages which need management access are skipped
+	t.Setenv("ZITI_CONFIG_DIR", t.TempDir())
+
+	out := &bytes.Buffer{}
+	ctx := &testTraceContext{
+		identity: &rest_model.IdentityDetail{
+			BaseEntity: rest_model.BaseEntity{ID: ptr("id1")},
+			Name:       ptr("client"),
+		},
+		service: &rest_model.ServiceDetail{
+			BaseEntity:  rest_model.BaseEntity{ID: ptr("svc1")},
+			Name:        ptr("echo"),
+			Permissions: []rest_model.DialBind{rest_model.DialBindDial},
+		},
+		conn: &testTraceConn{
+			traceResult: &edge.TraceRouteResult{
+				Hops:    1,
+				Time:    time.Millisecond,
+				HopType: "forwarder",
+				HopId:   "r2",
+			},
+		},
+	}
+
+	tracer := &dialTracer{
+		traceIdentityOptions: &traceIdentityOptions{
+			Options: api.Options{
+				CommonOptions: common.CommonOptions{
+					Out:  out,
+					Args: []string{"client"},
+				},
+			},
+			service:     "echo",
+			dialTimeout: time.Second,
+		},
+		ctx: ctx,
+	}
+	return tracer, ctx, out
+}
+
+func requireStage(t *testing.T, out, stage, status, detail string) {
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, stage+" ") {
+			require.Equal(t, status, strings.Fields(line[len(stage):])[0], "line: %s", line)
+			require.Contains(t, line, detail)
+			return
+		}
+	}
+	require.Failf(t, "stage not reported", "stage: %s, output:\n%s", stage, out)
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestDialTracer(t *testing.T) {
+	t.Run("successful dials report each stage", func(t *testing.T) {
+		req := require.New(t)
+		tracer, ctx, out := newTestDialTracer(t)
+		tracer.probe = "hello"
+
+		req.NoError(tracer.run())
+		requireStage(t, out.String(), "authentication", traceStageOk, "authenticated as client (id1)")
+		requireStage(t, out.String(), "policy evaluation", traceStageOk, "service visible with dial permission")
+		requireStage(t, out.String(), "edge router selection", traceStageOk, "dialed via er1")
+		requireStage(t, out.String(), "circuit path", traceStageOk, "circuit circuit1 ends at forwarder[r2]")
+		requireStage(t, out.String(), "terminator chosen", traceStageSkip, "requires admin access")
+		requireStage(t, out.String(), "first byte", traceStageOk, "first byte received")
+		req.True(tracer.adminFailed)
+		req.Equal("hello", ctx.conn.written.String())
+		req.True(ctx.conn.closed)
+	})
+
+	t.Run("the config file must be for the traced identity", func(t *testing.T) {
+		req := require.New(t)
+		tracer, _, out := newTestDialTracer(t)
+		tracer.Args = []string{"other"}
+
+		req.EqualError(tracer.run(), "dial trace failed at stage: authentication")
+		requireStage(t, out.String(), "authentication", traceStageFail, "config file is for identity client (id1), not other")
+	})
+
+	t.Run("services which aren't visible fail policy evaluation", func(t *testing.T) {
+		req := require.New(t)
+		tracer, _, out := newTestDialTracer(t)
+		tracer.service = "missing"
+
+		req.EqualError(tracer.run(), "dial trace failed at stage: policy evaluation")
+		requireStage(t, out.String(), "policy evaluation", traceStageFail, "service missing not visible to identity")
+	})
+
+	t.Run("services without dial permission fail policy evaluation", func(t *testing.T) {
+		req := require.New(t)
+		tracer, ctx, out := newTestDialTracer(t)
+		ctx.service.Permissions = []rest_model.DialBind{rest_model.DialBindBind}
+
+		req.EqualError(tracer.run(), "dial trace failed at stage: policy evaluation")
+		requireStage(t, out.String(), "policy evaluation", traceStageFail, "identity does not have dial access to service echo")
+	})
+
+	t.Run("trace route errors fail the circuit path", func(t *testing.T) {
+		req := require.New(t)
+		tracer, ctx, out := newTestDialTracer(t)
+		ctx.conn.traceResult.Error = "no route"
+
+		req.EqualError(tracer.run(), "dial trace failed at stage: circuit path")
+		requireStage(t, out.String(), "circuit path", traceStageFail, "forwarder[r2] reported: no route")
+		req.True(ctx.conn.closed)
+	})
+
+	t.Run("read timeouts skip the first byte", func(t *testing.T) {
+		req := require.New(t)
+		tracer, ctx, out := newTestDialTracer(t)
+		ctx.conn.readErr = os.ErrDeadlineExceeded
+
+		req.NoError(tracer.run())
+		requireStage(t, out.String(), "first byte", traceStageSkip, "Use --probe to send data")
+	})
+}
@@ -24,6 +24,7 @@ import (
 	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
 	"github.com/spf13/cobra"
 	"io"
+	"time"
 )
 
 type traceIdentityOptions struct {
@@ -31,6 +32,11 @@ type traceIdentityOptions struct {
 	disable  bool
 	duration string
 	traceId  string
+
+	service     string
+	configFile  string
+	dialTimeout time.Duration
+	probe       string
 }
 
 // newCreateIdentityCmd creates the 'edge controller create identity' command
@@ -53,13 +59,22 @@ func newTraceIdentityCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	}
 
 	cmd := &cobra.Command{
-		Use:   "identity",
+		Use:   "identity <identity> [channels...]",
 		Short: "enables/disables tracing for sessions from an identity managed by the Ziti Edge Controller",
-		Args:  cobra.MinimumNArgs(1),
+		Long: "Enables or disables tracing for sessions from an identity managed by the Ziti Edge Controller.\n\n" +
+			"If --service is given, instead performs a synthetic dial of the service as the identity, using the identity's " +
+			"config file, and reports on each stage of the dial: authentication, policy evaluation, edge router selection, " +
+			"circuit path, terminator chosen and first byte latency.",
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			options.Cmd = cmd
 			options.Args = args
-			err := runTraceIdentity(options)
+			var err error
+			if options.service != "" {
+				err = runTraceIdentityDial(options)
+			} else {
+				err = runTraceIdentity(options)
+			}
 			cmdhelper.CheckErr(err)
 		},
 		SuggestFor: []string{},
@@ -70,6 +85,10 @@ func newTraceIdentityCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.Flags().BoolVar(&options.disable, "disable", false, "Disables tracing for the identity (default false)")
 	cmd.Flags().StringVarP(&options.duration, "duration", "d", "10m", "how long to enable tracing for (default 10 minutes)")
 	cmd.Flags().StringVar(&options.traceId, "trace-id", "", "Unique id to use when tracing")
+	cmd.Flags().StringVarP(&options.service, "service", "s", "", "Service to dial. If set, a synthetic dial of the service is traced")
+	cmd.Flags().StringVarP(&options.configFile, "config-file", "c", "", "Path to identity config file, used when tracing a dial")
+	cmd.Flags().DurationVar(&options.dialTimeout, "dial-timeout", 5*time.Second, "Timeout for each stage when tracing a dial")
+	cmd.Flags().StringVar(&options.probe, "probe", "", "Data to send after dialing when measuring first byte latency, for protocols where the client speaks first")
 
 	options.AddCommonFlags(cmd)
 
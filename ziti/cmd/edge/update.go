@@ -39,20 +39,20 @@ func newUpdateCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	}
 
 	cmd.AddCommand(newUpdateAuthenticatorCmd(out, errOut))
-	cmd.AddCommand(newUpdateConfigCmd(out, errOut))
-	cmd.AddCommand(newUpdateConfigTypeCmd(out, errOut))
-	cmd.AddCommand(newUpdateCaCmd(out, errOut))
-	cmd.AddCommand(newUpdateEdgeRouterCmd(out, errOut))
-	cmd.AddCommand(newUpdateEdgeRouterPolicyCmd(out, errOut))
-	cmd.AddCommand(newUpdateIdentityCmd(out, errOut))
+	cmd.AddCommand(withEntityCompletion(newUpdateConfigCmd(out, errOut), "configs"))
+	cmd.AddCommand(withEntityCompletion(newUpdateConfigTypeCmd(out, errOut), "config-types"))
+	cmd.AddCommand(withEntityCompletion(newUpdateCaCmd(out, errOut), "cas"))
+	cmd.AddCommand(withEntityCompletion(newUpdateEdgeRouterCmd(out, errOut), "edge-routers"))
+	cmd.AddCommand(withEntityCompletion(newUpdateEdgeRouterPolicyCmd(out, errOut), "edge-router-policies"))
+	cmd.AddCommand(withEntityCompletion(newUpdateIdentityCmd(out, errOut), "identities"))
 	cmd.AddCommand(newUpdateIdentityConfigsCmd(out, errOut))
-	cmd.AddCommand(newUpdateServiceCmd(out, errOut))
-	cmd.AddCommand(newUpdateServicePolicyCmd(out, errOut))
-	cmd.AddCommand(newUpdateServiceEdgeRouterPolicyCmd(out, errOut))
-	cmd.AddCommand(newUpdateTerminatorCmd(out, errOut))
-	cmd.AddCommand(newUpdatePostureCheckCmd(out, errOut))
-	cmd.AddCommand(newUpdateExtJwtSignerCmd(out, errOut))
-	cmd.AddCommand(newUpdateAuthPolicySignerCmd(out, errOut))
+	cmd.AddCommand(withEntityCompletion(newUpdateServiceCmd(out, errOut), "services"))
+	cmd.AddCommand(withEntityCompletion(newUpdateServicePolicyCmd(out, errOut), "service-policies"))
+	cmd.AddCommand(withEntityCompletion(newUpdateServiceEdgeRouterPolicyCmd(out, errOut), "service-edge-router-policies"))
+	cmd.AddCommand(withEntityCompletion(newUpdateTerminatorCmd(out, errOut), "terminators"))
+	cmd.AddCommand(withEntityCompletion(newUpdatePostureCheckCmd(out, errOut), "posture-checks"))
+	cmd.AddCommand(withEntityCompletion(newUpdateExtJwtSignerCmd(out, errOut), "external-jwt-signers"))
+	cmd.AddCommand(withEntityCompletion(newUpdateAuthPolicySignerCmd(out, errOut), "auth-policies"))
 
 	return cmd
 }
@@ -0,0 +1,222 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package edge
+
+import (
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openziti/edge-api/rest_management_api_client"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// useClusterOptions are the flags for use-cluster commands
+type useClusterOptions struct {
+	api.Options
+	controller string
+	add        []string
+	remove     []string
+	refresh    bool
+}
+
+// newUseClusterCmd creates the command
+func newUseClusterCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &useClusterOptions{
+		Options: api.Options{
+			CommonOptions: common.CommonOptions{Out: out, Err: errOut},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "use-cluster [identity]",
+		Short: "manages the controllers a saved login fails over between when connected to a controller cluster",
+		Long: "Without arguments or flags, lists the controllers of each saved login. If an identity is given, it's made the default. " +
+			"The flags change the controllers of the given, or currently selected, login.",
+		Args: cobra.RangeArgs(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			cmdhelper.CheckErr(err)
+		},
+		SuggestFor: []string{},
+	}
+
+	// allow interspersing positional args and flags
+	cmd.Flags().SetInterspersed(true)
+	cmd.Flags().StringVar(&options.controller, "controller", "", "The controller url to send requests to first")
+	cmd.Flags().StringSliceVar(&options.add, "add", nil, "Controller urls to add to the cluster")
+	cmd.Flags().StringSliceVar(&options.remove, "remove", nil, "Controller urls to remove from the cluster")
+	cmd.Flags().BoolVar(&options.refresh, "refresh", false, "Replace the controller urls with the controllers currently in the cluster")
+	options.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+// Run implements this command
+func (o *useClusterOptions) Run() error {
+	config, configFile, err := util.LoadRestClientConfig()
+	if err != nil {
+		return err
+	}
+
+	id := config.GetIdentity()
+	if len(o.Args) > 0 {
+		id = o.Args[0]
+	}
+
+	updating := o.controller != "" || len(o.add) > 0 || len(o.remove) > 0 || o.refresh
+	if len(o.Args) == 0 && !updating {
+		o.listClusters(config)
+		return nil
+	}
+
+	identity, found := config.EdgeIdentities[id]
+	if !found {
+		return errors.Errorf("no identity '%v' found in CLI config %v", id, configFile)
+	}
+
+	if o.refresh {
+		if err = o.refreshClusterUrls(identity); err != nil {
+			return err
+		}
+	}
+
+	for _, u := range o.add {
+		clusterUrl, err := normalizeClusterUrl(u)
+		if err != nil {
+			return err
+		}
+		identity.ClusterUrls = appendUniqueUrl(identity.GetClusterUrls(), clusterUrl)
+	}
+
+	for _, u := range o.remove {
+		clusterUrl, err := normalizeClusterUrl(u)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(clusterUrl, identity.Url) {
+			return errors.Errorf("can't remove the active controller %v, select another controller with --controller first", clusterUrl)
+		}
+		identity.ClusterUrls = removeUrl(identity.ClusterUrls, clusterUrl)
+	}
+
+	if o.controller != "" {
+		clusterUrl, err := normalizeClusterUrl(o.controller)
+		if err != nil {
+			return err
+		}
+		identity.SetActiveUrl(clusterUrl)
+	}
+
+	if clusterUrls := identity.GetClusterUrls(); len(clusterUrls) > 1 {
+		identity.ClusterUrls = clusterUrls
+	} else {
+		identity.ClusterUrls = nil
+	}
+
+	if len(o.Args) > 0 {
+		config.Default = id
+		o.Printf("Setting identity '%v' as default in %v\n", id, configFile)
+	}
+
+	o.printCluster(id, identity)
+	return util.PersistRestClientConfig(config)
+}
+
+func (o *useClusterOptions) refreshClusterUrls(identity *util.RestClientEdgeIdentity) error {
+	client, err := identity.NewClient(time.Duration(o.Timeout)*time.Second, o.Verbose)
+	if err != nil {
+		return err
+	}
+
+	clusterUrls, err := util.DiscoverClusterUrls(client, identity.Url, identity.Token)
+	if err != nil {
+		return errors.Wrap(err, "unable to refresh cluster controllers, you may need to log in again")
+	}
+
+	identity.ClusterUrls = clusterUrls
+	return nil
+}
+
+func (o *useClusterOptions) listClusters(config *util.RestClientConfig) {
+	var ids []string
+	for id := range config.EdgeIdentities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		o.printCluster(id, config.EdgeIdentities[id])
+	}
+}
+
+func (o *useClusterOptions) printCluster(id string, identity *util.RestClientEdgeIdentity) {
+	o.Printf("id: %v | controllers: %v\n", id, len(identity.GetClusterUrls()))
+	for _, u := range identity.GetClusterUrls() {
+		o.Printf("    active: %5v | url: %v\n", strings.EqualFold(u, identity.Url), u)
+	}
+}
+
+// normalizeClusterUrl accepts controller urls in the forms accepted by login, and returns the management API url
+func normalizeClusterUrl(u string) (string, error) {
+	if !strings.HasPrefix(u, "http") {
+		u = "https://" + u
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid controller url %v", u)
+	}
+
+	if parsed.Host == "" {
+		return "", errors.Errorf("invalid controller url %v, no host found", u)
+	}
+
+	if parsed.Path == "" || parsed.Path == "/" {
+		parsed.Path = rest_management_api_client.DefaultBasePath
+	}
+
+	return strings.TrimSuffix(parsed.String(), "/"), nil
+}
+
+func appendUniqueUrl(urls []string, u string) []string {
+	for _, existing := range urls {
+		if strings.EqualFold(existing, u) {
+			return urls
+		}
+	}
+	return append(urls, u)
+}
+
+func removeUrl(urls []string, u string) []string {
+	var result []string
+	for _, existing := range urls {
+		if !strings.EqualFold(existing, u) {
+			result = append(result, existing)
+		}
+	}
+	return result
+}
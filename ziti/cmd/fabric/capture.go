@@ -0,0 +1,125 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"fmt"
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/pb/mgmt_pb"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+func newCaptureCommand(p common.OptionsProvider) *cobra.Command {
+	captureCmd := &cobra.Command{
+		Use:   "capture",
+		Short: "capture network traffic",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdhelper.CheckErr(cmd.Help())
+		},
+	}
+
+	captureCmd.AddCommand(NewCaptureCircuitCmd(p))
+	return captureCmd
+}
+
+type captureCircuitAction struct {
+	api.Options
+	duration        time.Duration
+	includePayloads bool
+	snapLength      uint32
+	output          string
+}
+
+func NewCaptureCircuitCmd(p common.OptionsProvider) *cobra.Command {
+	action := captureCircuitAction{
+		Options: api.Options{
+			CommonOptions: p(),
+		},
+	}
+
+	captureCircuitCmd := &cobra.Command{
+		Use:     "circuit <circuit id>",
+		Short:   "Capture payloads forwarded on a circuit by the routers along its path to a pcap-ng file",
+		Example: "ziti fabric capture circuit VKqyVC3mPn --duration 30s --output circuit.pcapng",
+		Args:    cobra.ExactArgs(1),
+		RunE:    action.captureCircuit,
+	}
+
+	captureCircuitCmd.Flags().StringVarP(&action.output, "output", "o", "", "File to write the capture to. Defaults to circuit-<circuit id>.pcapng")
+	action.AddCommonFlags(captureCircuitCmd)
+	captureCircuitCmd.Flags().DurationVar(&action.duration, "duration", 30*time.Second, "How long to capture for")
+	captureCircuitCmd.Flags().BoolVar(&action.includePayloads, "payloads", false, "Include payload data, rather than just payload metadata")
+	captureCircuitCmd.Flags().Uint32Var(&action.snapLength, "snap-length", 0, "Maximum number of bytes of payload data to capture per payload. Defaults to 65535")
+	return captureCircuitCmd
+}
+
+func (self *captureCircuitAction) captureCircuit(_ *cobra.Command, args []string) error {
+	circuitId := args[0]
+
+	ch, err := api.NewWsMgmtChannel(nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = ch.Close()
+	}()
+
+	request := &mgmt_pb.CaptureCircuitRequest{
+		CircuitId:       circuitId,
+		DurationMs:      self.duration.Milliseconds(),
+		IncludePayloads: self.includePayloads,
+		SnapLength:      self.snapLength,
+	}
+
+	fmt.Printf("capturing circuit %s for %v\n", circuitId, self.duration)
+
+	timeout := self.duration + time.Duration(self.Timeout)*time.Second
+	responseMsg, err := protobufs.MarshalTyped(request).WithTimeout(timeout).SendForReply(ch)
+
+	response := &mgmt_pb.CaptureCircuitResponse{}
+	if err = protobufs.TypedResponse(response).Unmarshall(responseMsg, err); err != nil {
+		return err
+	}
+
+	for _, routerErr := range response.RouterErrors {
+		fmt.Printf("warning: %s\n", routerErr)
+	}
+
+	if !response.Success {
+		return fmt.Errorf("circuit capture failed: %s", response.Message)
+	}
+
+	output := self.output
+	if output == "" {
+		output = fmt.Sprintf("circuit-%s.pcapng", circuitId)
+	}
+
+	if err = os.WriteFile(output, response.Pcapng, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %d packets to %s\n", response.PacketCount, output)
+	if response.Truncated {
+		fmt.Println("warning: capture limits were reached, capture is incomplete")
+	}
+	return nil
+}
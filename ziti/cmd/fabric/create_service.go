@@ -21,11 +21,14 @@ import (
 	"github.com/openziti/ziti/ziti/cmd/api"
 	"github.com/openziti/ziti/ziti/cmd/common"
 	"github.com/spf13/cobra"
+	"time"
 )
 
 type createServiceOptions struct {
 	api.Options
 	terminatorStrategy string
+	maxIdleTime        time.Duration
+	maxCircuitLifetime time.Duration
 	tags               map[string]string
 }
 
@@ -50,6 +53,8 @@ func newCreateServiceCmd(p common.OptionsProvider) *cobra.Command {
 	cmd.Flags().SetInterspersed(true)
 	cmd.Flags().StringToStringVarP(&options.tags, "tags", "t", nil, "Add tags to service definition")
 	cmd.Flags().StringVar(&options.terminatorStrategy, "terminator-strategy", "", "Specifies the terminator strategy for the service")
+	cmd.Flags().DurationVar(&options.maxIdleTime, "max-idle-time", 0, "Time after which idle circuit will be terminated. Defaults to 0, which indicates no limit on idle circuits")
+	cmd.Flags().DurationVar(&options.maxCircuitLifetime, "max-circuit-lifetime", 0, "Time after which circuits will be terminated, regardless of activity. Defaults to 0, which indicates no limit on circuit lifetime")
 	options.AddCommonFlags(cmd)
 
 	return cmd
@@ -63,6 +68,8 @@ func (o *createServiceOptions) createService(_ *cobra.Command, args []string) (e
 	if o.terminatorStrategy != "" {
 		api.SetJSONValue(entityData, o.terminatorStrategy, "terminatorStrategy")
 	}
+	api.SetJSONValue(entityData, o.maxIdleTime.Milliseconds(), "maxIdleTimeMillis")
+	api.SetJSONValue(entityData, o.maxCircuitLifetime.Milliseconds(), "maxCircuitLifetimeMillis")
 
 	api.SetJSONValue(entityData, o.tags, "tags")
 
@@ -0,0 +1,481 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/controller/event"
+	fabricRestModel "github.com/openziti/ziti/controller/rest_client"
+	"github.com/openziti/ziti/controller/rest_client/circuit"
+	"github.com/openziti/ziti/controller/rest_client/link"
+	"github.com/openziti/ziti/controller/rest_client/router"
+	"github.com/openziti/ziti/controller/rest_model"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	ansiEnterAltScreen = "\x1b[?1049h\x1b[?25l"
+	ansiExitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	ansiClearScreen    = "\x1b[H\x1b[2J"
+)
+
+type dashboardAction struct {
+	api.Options
+	refreshInterval time.Duration
+	maxLinks        int
+	maxFaults       int
+
+	client *fabricRestModel.ZitiFabric
+
+	lock         sync.Mutex
+	snapshot     *dashboardSnapshot
+	faults       []map[string]interface{}
+	streamClosed bool
+	redraw       chan struct{}
+}
+
+type dashboardSnapshot struct {
+	routers       rest_model.RouterList
+	links         rest_model.LinkList
+	circuits      rest_model.CircuitList
+	circuitsTotal int64
+	updatedAt     time.Time
+	err           error
+}
+
+// NewDashboardCmd creates the `ziti dashboard` command, an interactive terminal view of network health
+func NewDashboardCmd(p common.OptionsProvider) *cobra.Command {
+	action := &dashboardAction{
+		Options: api.Options{
+			CommonOptions: p(),
+		},
+		redraw: make(chan struct{}, 1),
+	}
+
+	dashboardCmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Interactive terminal dashboard showing router, link, circuit and fault status",
+		Long: "Interactive terminal dashboard showing router health, link status, circuit counts and recent fault events.\n" +
+			"Router, link and circuit state is polled from the fabric management API. Faults (failed circuits, faulted links\n" +
+			"and routers going offline) are streamed from the controller as they happen.\n\n" +
+			"Keys: 'r' refreshes immediately, 'q' or ctrl-c exits.",
+		Args: cobra.ExactArgs(0),
+		RunE: action.run,
+	}
+
+	action.AddCommonFlags(dashboardCmd)
+	dashboardCmd.Flags().DurationVar(&action.refreshInterval, "refresh", 5*time.Second, "How often to poll the controller for router, link and circuit state")
+	dashboardCmd.Flags().IntVar(&action.maxLinks, "max-links", 20, "Maximum number of links to show. Unhealthy links are shown first")
+	dashboardCmd.Flags().IntVar(&action.maxFaults, "max-faults", 10, "Number of recent fault events to show")
+	return dashboardCmd
+}
+
+func (self *dashboardAction) run(_ *cobra.Command, _ []string) error {
+	if self.refreshInterval < time.Second {
+		return errors.Errorf("invalid refresh interval %v, must be at least 1s", self.refreshInterval)
+	}
+
+	stdinFd := int(os.Stdin.Fd())
+	stdoutFd := int(os.Stdout.Fd())
+	if !term.IsTerminal(stdinFd) || !term.IsTerminal(stdoutFd) {
+		return errors.New("ziti dashboard requires an interactive terminal")
+	}
+
+	client, err := util.NewFabricManagementClient(&self.Options)
+	if err != nil {
+		return err
+	}
+	self.client = client
+
+	// fail early, before taking over the terminal, if the controller isn't reachable
+	snapshot := self.fetch()
+	if snapshot.err != nil {
+		return snapshot.err
+	}
+	self.snapshot = snapshot
+
+	subscriptions := []*event.Subscription{
+		{Type: event.CircuitEventNS},
+		{Type: event.LinkEventNS},
+		{Type: event.RouterEventNS},
+	}
+
+	closeNotify, err := startEventStream(&self.Options, subscriptions, self)
+	if err != nil {
+		return err
+	}
+
+	oldState, err := term.MakeRaw(stdinFd)
+	if err != nil {
+		return errors.Wrap(err, "unable to put terminal into raw mode")
+	}
+	_, _ = fmt.Fprint(os.Stdout, ansiEnterAltScreen)
+
+	defer func() {
+		_, _ = fmt.Fprint(os.Stdout, ansiExitAltScreen)
+		_ = term.Restore(stdinFd, oldState)
+	}()
+
+	keys := make(chan byte, 1)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if n, err := os.Stdin.Read(buf); err != nil {
+				close(keys)
+				return
+			} else if n == 1 {
+				keys <- buf[0]
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(self.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		self.render(stdoutFd)
+
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch key {
+			case 'q', 'Q', 3: // 3 == ctrl-c, which raw mode delivers as a key rather than a signal
+				return nil
+			case 'r', 'R':
+				self.refresh()
+			}
+		case <-ticker.C:
+			self.refresh()
+		case <-self.redraw:
+		case <-closeNotify:
+			closeNotify = nil
+			self.lock.Lock()
+			self.streamClosed = true
+			self.lock.Unlock()
+		}
+	}
+}
+
+func (self *dashboardAction) refresh() {
+	snapshot := self.fetch()
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if snapshot.err != nil && self.snapshot != nil {
+		// keep showing the last good state, but surface the error
+		previous := *self.snapshot
+		previous.err = snapshot.err
+		snapshot = &previous
+	}
+	self.snapshot = snapshot
+}
+
+func (self *dashboardAction) fetch() *dashboardSnapshot {
+	result := &dashboardSnapshot{
+		updatedAt: time.Now(),
+	}
+
+	filter := "true limit none"
+
+	ctx, cancelF := self.GetContext()
+	defer cancelF()
+
+	routers, err := self.client.Router.ListRouters(&router.ListRoutersParams{
+		Filter:  &filter,
+		Context: ctx,
+	})
+	if err != nil {
+		result.err = errors.Wrap(err, "unable to list routers")
+		return result
+	}
+	result.routers = routers.Payload.Data
+
+	links, err := self.client.Link.ListLinks(&link.ListLinksParams{
+		Filter:  &filter,
+		Context: ctx,
+	})
+	if err != nil {
+		result.err = errors.Wrap(err, "unable to list links")
+		return result
+	}
+	result.links = links.Payload.Data
+
+	circuits, err := self.client.Circuit.ListCircuits(&circuit.ListCircuitsParams{
+		Filter:  &filter,
+		Context: ctx,
+	})
+	if err != nil {
+		result.err = errors.Wrap(err, "unable to list circuits")
+		return result
+	}
+	result.circuits = circuits.Payload.Data
+	result.circuitsTotal = int64(len(result.circuits))
+	if meta := circuits.Payload.Meta; meta != nil && meta.Pagination != nil && meta.Pagination.TotalCount != nil {
+		result.circuitsTotal = *meta.Pagination.TotalCount
+	}
+
+	return result
+}
+
+func (self *dashboardAction) HandleReceive(msg *channel.Message, _ channel.Channel) {
+	evt := map[string]interface{}{}
+	if err := json.Unmarshal(msg.Body, &evt); err != nil {
+		return
+	}
+
+	if !isFaultEvent(evt) {
+		return
+	}
+
+	self.lock.Lock()
+	self.faults = append(self.faults, evt)
+	if len(self.faults) > self.maxFaults {
+		self.faults = self.faults[len(self.faults)-self.maxFaults:]
+	}
+	self.lock.Unlock()
+
+	select {
+	case self.redraw <- struct{}{}:
+	default:
+	}
+}
+
+func isFaultEvent(evt map[string]interface{}) bool {
+	eventType := getEventString(evt, "event_type")
+	switch getEventString(evt, "namespace") {
+	case event.CircuitEventNS:
+		return eventType == string(event.CircuitFailed)
+	case event.LinkEventNS:
+		return eventType == string(event.LinkFault)
+	case event.RouterEventNS:
+		return eventType == string(event.RouterOffline)
+	}
+	return false
+}
+
+func (self *dashboardAction) render(fd int) {
+	width, _, err := term.GetSize(fd)
+	if err != nil || width <= 0 {
+		width = 120
+	}
+
+	self.lock.Lock()
+	out := &strings.Builder{}
+	self.renderHeader(out)
+	self.renderRouters(out)
+	self.renderLinks(out)
+	self.renderFaults(out)
+	self.lock.Unlock()
+
+	// raw mode turns off output post-processing, so lines need explicit carriage returns
+	screen := &strings.Builder{}
+	screen.WriteString(ansiClearScreen)
+	for _, line := range strings.Split(out.String(), "\n") {
+		screen.WriteString(text.Trim(line, width))
+		screen.WriteString("\r\n")
+	}
+	_, _ = fmt.Fprint(os.Stdout, screen.String())
+}
+
+func (self *dashboardAction) renderHeader(out *strings.Builder) {
+	snapshot := self.snapshot
+
+	onlineRouters := 0
+	for _, r := range snapshot.routers {
+		if valOrDefault(r.Connected) {
+			onlineRouters++
+		}
+	}
+
+	upLinks := 0
+	for _, l := range snapshot.links {
+		if !valOrDefault(l.Down) {
+			upLinks++
+		}
+	}
+
+	_, _ = fmt.Fprintf(out, "Ziti Dashboard    updated: %s    refresh: %v    [r] refresh  [q] quit\n",
+		snapshot.updatedAt.Format(time.TimeOnly), self.refreshInterval)
+	_, _ = fmt.Fprintf(out, "Routers online: %d/%d    Links up: %d/%d    Circuits: %d\n",
+		onlineRouters, len(snapshot.routers), upLinks, len(snapshot.links), snapshot.circuitsTotal)
+
+	if snapshot.err != nil {
+		_, _ = fmt.Fprintf(out, "%s\n", text.FgRed.Sprintf("refresh failed: %v", snapshot.err))
+	}
+	if self.streamClosed {
+		_, _ = fmt.Fprintf(out, "%s\n", text.FgRed.Sprint("event stream closed, fault events are no longer being received"))
+	}
+	out.WriteString("\n")
+}
+
+func (self *dashboardAction) renderRouters(out *strings.Builder) {
+	linkCounts := map[string]int{}
+	for _, l := range self.snapshot.links {
+		if valOrDefault(l.Down) {
+			continue
+		}
+		if l.SourceRouter != nil {
+			linkCounts[l.SourceRouter.ID]++
+		}
+		if l.DestRouter != nil {
+			linkCounts[l.DestRouter.ID]++
+		}
+	}
+
+	circuitCounts := map[string]int{}
+	for _, c := range self.snapshot.circuits {
+		if c.Path == nil {
+			continue
+		}
+		for _, node := range c.Path.Nodes {
+			circuitCounts[node.ID]++
+		}
+	}
+
+	routers := append(rest_model.RouterList{}, self.snapshot.routers...)
+	sort.Slice(routers, func(i, j int) bool {
+		return valOrDefault(routers[i].Name) < valOrDefault(routers[j].Name)
+	})
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleRounded)
+	t.SetTitle("Routers")
+	t.AppendHeader(table.Row{"Name", "Status", "Links", "Circuits", "Cost", "Version"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 3, Align: text.AlignRight},
+		{Number: 4, Align: text.AlignRight},
+		{Number: 5, Align: text.AlignRight},
+	})
+
+	for _, r := range routers {
+		id := valOrDefault(r.ID)
+		var version string
+		if r.VersionInfo != nil {
+			version = r.VersionInfo.Version
+		}
+		t.AppendRow(table.Row{
+			valOrDefault(r.Name),
+			routerStatus(r),
+			linkCounts[id],
+			circuitCounts[id],
+			valOrDefault(r.Cost),
+			version,
+		})
+	}
+
+	out.WriteString(t.Render())
+	out.WriteString("\n\n")
+}
+
+func routerStatus(r *rest_model.RouterDetail) string {
+	if !valOrDefault(r.Connected) {
+		return text.FgRed.Sprint("offline")
+	}
+	if valOrDefault(r.Disabled) {
+		return text.FgYellow.Sprint("disabled")
+	}
+	if r.Draining {
+		return text.FgYellow.Sprint("draining")
+	}
+	return text.FgGreen.Sprint("online")
+}
+
+func (self *dashboardAction) renderLinks(out *strings.Builder) {
+	links := append(rest_model.LinkList{}, self.snapshot.links...)
+	sort.SliceStable(links, func(i, j int) bool {
+		iDown, jDown := valOrDefault(links[i].Down), valOrDefault(links[j].Down)
+		if iDown != jDown {
+			return iDown
+		}
+		return valOrDefault(links[i].ID) < valOrDefault(links[j].ID)
+	})
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleRounded)
+	t.SetTitle("Links")
+	t.AppendHeader(table.Row{"ID", "Dialer", "Acceptor", "State", "Status", "Cost", "Src Latency", "Dst Latency"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 6, Align: text.AlignRight},
+		{Number: 7, Align: text.AlignRight},
+		{Number: 8, Align: text.AlignRight},
+	})
+
+	for idx, l := range links {
+		if idx == self.maxLinks {
+			t.AppendFooter(table.Row{fmt.Sprintf("%d more", len(links)-self.maxLinks)})
+			break
+		}
+
+		status := text.FgGreen.Sprint("up")
+		if valOrDefault(l.Down) {
+			status = text.FgRed.Sprint("down")
+		}
+
+		var src, dst string
+		if l.SourceRouter != nil {
+			src = l.SourceRouter.Name
+		}
+		if l.DestRouter != nil {
+			dst = l.DestRouter.Name
+		}
+
+		t.AppendRow(table.Row{
+			valOrDefault(l.ID),
+			src,
+			dst,
+			valOrDefault(l.State),
+			status,
+			valOrDefault(l.Cost),
+			fmt.Sprintf("%.1fms", float64(valOrDefault(l.SourceLatency))/1_000_000),
+			fmt.Sprintf("%.1fms", float64(valOrDefault(l.DestLatency))/1_000_000),
+		})
+	}
+
+	out.WriteString(t.Render())
+	out.WriteString("\n\n")
+}
+
+func (self *dashboardAction) renderFaults(out *strings.Builder) {
+	out.WriteString("Recent Faults\n")
+	if len(self.faults) == 0 {
+		out.WriteString("  none since dashboard started\n")
+		return
+	}
+
+	_, _ = fmt.Fprintf(out, eventsTailRowFormat, "TIMESTAMP", "NAMESPACE", "EVENT TYPE", "ID", "DETAILS")
+	for i := len(self.faults) - 1; i >= 0; i-- {
+		writeEventRow(out, self.faults[i])
+	}
+}
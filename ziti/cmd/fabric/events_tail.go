@@ -0,0 +1,305 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openziti/channel/v4"
+	"github.com/openziti/ziti/controller/event"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	eventsTailFormatJson  = "json"
+	eventsTailFormatTable = "table"
+
+	eventsTailRowFormat = "%-24s  %-10s  %-14s  %-24s  %s\n"
+)
+
+type eventsTailAction struct {
+	api.Options
+	circuits    bool
+	links       bool
+	routers     bool
+	terminators bool
+	filters     []string
+	format      string
+
+	eventFilters []*eventFilter
+	headerDone   bool
+}
+
+func NewEventsTailCmd(p common.OptionsProvider) *cobra.Command {
+	action := eventsTailAction{
+		Options: api.Options{
+			CommonOptions: p(),
+		},
+	}
+
+	tailCmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Live-tail circuit, link, terminator and router events",
+		Long: "Live-tail circuit, link, terminator and router events. If no event types are selected, all four are included.\n\n" +
+			"Filters have the form <field><op><value>, where op is one of =, != or ~ (regular expression match).\n" +
+			"Fields are the JSON field names of the event, with nested fields separated by dots. If a field is a list,\n" +
+			"the filter matches if any element matches. All filters must match for an event to be shown.",
+		Example: "ziti fabric events tail --circuits --filter service_id=myService --filter event_type!=pathUpdated\n" +
+			"ziti fabric events tail --filter 'path.nodes~^router-east' --format json",
+		Args: cobra.ExactArgs(0),
+		RunE: action.tail,
+	}
+
+	action.AddCommonFlags(tailCmd)
+	tailCmd.Flags().BoolVar(&action.circuits, "circuits", false, "Include circuit events")
+	tailCmd.Flags().BoolVar(&action.links, "links", false, "Include link events")
+	tailCmd.Flags().BoolVar(&action.routers, "routers", false, "Include router events")
+	tailCmd.Flags().BoolVar(&action.terminators, "terminators", false, "Include terminator events")
+	tailCmd.Flags().StringArrayVar(&action.filters, "filter", nil, "Only show events matching the given filter expression. May be specified multiple times")
+	tailCmd.Flags().StringVar(&action.format, "format", eventsTailFormatTable, "Output format. Valid values: [json, table]")
+	return tailCmd
+}
+
+func (self *eventsTailAction) tail(_ *cobra.Command, _ []string) error {
+	if self.format != eventsTailFormatJson && self.format != eventsTailFormatTable {
+		return errors.Errorf("invalid format '%s', valid values are [%s, %s]", self.format, eventsTailFormatJson, eventsTailFormatTable)
+	}
+
+	for _, filterExpr := range self.filters {
+		filter, err := parseEventFilter(filterExpr)
+		if err != nil {
+			return err
+		}
+		self.eventFilters = append(self.eventFilters, filter)
+	}
+
+	if !self.circuits && !self.links && !self.routers && !self.terminators {
+		self.circuits, self.links, self.routers, self.terminators = true, true, true, true
+	}
+
+	var subscriptions []*event.Subscription
+	if self.circuits {
+		subscriptions = append(subscriptions, &event.Subscription{Type: event.CircuitEventNS})
+	}
+	if self.links {
+		subscriptions = append(subscriptions, &event.Subscription{Type: event.LinkEventNS})
+	}
+	if self.routers {
+		subscriptions = append(subscriptions, &event.Subscription{Type: event.RouterEventNS})
+	}
+	if self.terminators {
+		subscriptions = append(subscriptions, &event.Subscription{Type: event.TerminatorEventNS})
+	}
+
+	closeNotify, err := startEventStream(&self.Options, subscriptions, self)
+	if err != nil {
+		return err
+	}
+
+	<-closeNotify
+	return nil
+}
+
+func (self *eventsTailAction) HandleReceive(msg *channel.Message, _ channel.Channel) {
+	evt := map[string]interface{}{}
+	if err := json.Unmarshal(msg.Body, &evt); err != nil {
+		_, _ = fmt.Fprintf(self.Err, "unable to parse event: %v\n", err)
+		return
+	}
+
+	for _, filter := range self.eventFilters {
+		if !filter.Matches(evt) {
+			return
+		}
+	}
+
+	if self.format == eventsTailFormatJson {
+		_, _ = fmt.Fprintln(self.Out, string(msg.Body))
+		return
+	}
+
+	if !self.headerDone {
+		_, _ = fmt.Fprintf(self.Out, eventsTailRowFormat, "TIMESTAMP", "NAMESPACE", "EVENT TYPE", "ID", "DETAILS")
+		self.headerDone = true
+	}
+	writeEventRow(self.Out, evt)
+}
+
+func writeEventRow(out io.Writer, evt map[string]interface{}) {
+	namespace := getEventString(evt, "namespace")
+	timestamp := getEventString(evt, "timestamp")
+	if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+		timestamp = t.Local().Format("2006-01-02 15:04:05.000")
+	}
+
+	var id string
+	var details []string
+	addDetail := func(label, field string) {
+		if val := getEventString(evt, field); val != "" {
+			details = append(details, label+"="+val)
+		}
+	}
+
+	switch namespace {
+	case event.CircuitEventNS:
+		id = getEventString(evt, "circuit_id")
+		addDetail("service", "service_id")
+		addDetail("client", "client_id")
+		addDetail("terminator", "terminator_id")
+		if nodes, ok := getEventValues(evt, "path.nodes"); ok && len(nodes) > 0 {
+			details = append(details, "path="+strings.Join(nodes, "->"))
+		}
+		addDetail("cost", "path_cost")
+		addDetail("cause", "failure_cause")
+	case event.LinkEventNS:
+		id = getEventString(evt, "link_id")
+		addDetail("src", "src_router_id")
+		addDetail("dst", "dst_router_id")
+		addDetail("protocol", "protocol")
+		addDetail("cost", "cost")
+	case event.TerminatorEventNS:
+		id = getEventString(evt, "terminator_id")
+		addDetail("service", "service_id")
+		addDetail("router", "router_id")
+		addDetail("routerOnline", "router_online")
+		addDetail("precedence", "precedence")
+	case event.RouterEventNS:
+		id = getEventString(evt, "router_id")
+		addDetail("online", "router_online")
+	}
+
+	_, _ = fmt.Fprintf(out, eventsTailRowFormat, timestamp, namespace, getEventString(evt, "event_type"), id, strings.Join(details, " "))
+}
+
+type eventFilterOp string
+
+const (
+	eventFilterOpEquals    eventFilterOp = "="
+	eventFilterOpNotEquals eventFilterOp = "!="
+	eventFilterOpMatches   eventFilterOp = "~"
+)
+
+// eventFilter matches a single field of a JSON formatted event against a value or regular expression
+type eventFilter struct {
+	field string
+	op    eventFilterOp
+	value string
+	regex *regexp.Regexp
+}
+
+func parseEventFilter(expr string) (*eventFilter, error) {
+	idx := strings.IndexAny(expr, "!=~")
+	if idx < 1 {
+		return nil, errors.Errorf("invalid filter '%s', expected <field><op><value>, where op is one of =, != or ~", expr)
+	}
+
+	result := &eventFilter{
+		field: strings.TrimSpace(expr[:idx]),
+	}
+
+	rest := expr[idx:]
+	switch {
+	case strings.HasPrefix(rest, string(eventFilterOpNotEquals)):
+		result.op = eventFilterOpNotEquals
+	case strings.HasPrefix(rest, string(eventFilterOpEquals)):
+		result.op = eventFilterOpEquals
+	case strings.HasPrefix(rest, string(eventFilterOpMatches)):
+		result.op = eventFilterOpMatches
+	default:
+		return nil, errors.Errorf("invalid filter '%s', expected <field><op><value>, where op is one of =, != or ~", expr)
+	}
+	result.value = rest[len(result.op):]
+
+	if result.op == eventFilterOpMatches {
+		regex, err := regexp.Compile(result.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regular expression in filter '%s'", expr)
+		}
+		result.regex = regex
+	}
+
+	return result, nil
+}
+
+func (self *eventFilter) Matches(evt map[string]interface{}) bool {
+	values, _ := getEventValues(evt, self.field)
+
+	if self.op == eventFilterOpNotEquals {
+		for _, val := range values {
+			if val == self.value {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, val := range values {
+		if self.op == eventFilterOpEquals && val == self.value {
+			return true
+		}
+		if self.op == eventFilterOpMatches && self.regex.MatchString(val) {
+			return true
+		}
+	}
+	return false
+}
+
+func getEventString(evt map[string]interface{}, field string) string {
+	values, _ := getEventValues(evt, field)
+	return strings.Join(values, ",")
+}
+
+// getEventValues returns the string representations of the value found at the given dotted field path. If the
+// value is a list, a string is returned for each element.
+func getEventValues(evt map[string]interface{}, field string) ([]string, bool) {
+	var current interface{} = evt
+	for _, key := range strings.Split(field, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok || current == nil {
+			return nil, false
+		}
+	}
+
+	if list, ok := current.([]interface{}); ok {
+		var result []string
+		for _, v := range list {
+			result = append(result, formatEventValue(v))
+		}
+		return result, true
+	}
+
+	return []string{formatEventValue(current)}, true
+}
+
+func formatEventValue(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
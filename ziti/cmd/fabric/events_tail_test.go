@@ -0,0 +1,79 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCircuitEvent = `{
+	"namespace": "circuit",
+	"event_type": "created",
+	"circuit_id": "c1",
+	"timestamp": "2025-01-02T03:04:05.123Z",
+	"service_id": "svc1",
+	"path": {"nodes": ["r1", "r2"], "links": ["l1"]},
+	"path_cost": 1200000
+}`
+
+func TestEventFilters(t *testing.T) {
+	req := require.New(t)
+
+	evt := map[string]interface{}{}
+	req.NoError(json.Unmarshal([]byte(testCircuitEvent), &evt))
+
+	matches := func(expr string) bool {
+		filter, err := parseEventFilter(expr)
+		req.NoError(err)
+		return filter.Matches(evt)
+	}
+
+	req.True(matches("service_id=svc1"))
+	req.False(matches("service_id=svc2"))
+	req.True(matches("event_type!=deleted"))
+	req.False(matches("event_type!=created"))
+	req.True(matches("path.nodes=r2"))
+	req.False(matches("path.nodes!=r2"))
+	req.True(matches("circuit_id~^c[0-9]$"))
+	req.True(matches("path_cost=1200000"))
+	req.False(matches("missing=foo"))
+	req.True(matches("missing!=foo"))
+
+	for _, invalid := range []string{"", "=foo", "service_id", "service_id~[a-"} {
+		_, err := parseEventFilter(invalid)
+		req.Error(err, invalid)
+	}
+}
+
+func TestWriteEventRow(t *testing.T) {
+	req := require.New(t)
+
+	evt := map[string]interface{}{}
+	req.NoError(json.Unmarshal([]byte(testCircuitEvent), &evt))
+
+	buf := &bytes.Buffer{}
+	writeEventRow(buf, evt)
+	out := buf.String()
+	req.Contains(out, "circuit")
+	req.Contains(out, "created")
+	req.Contains(out, "c1")
+	req.Contains(out, "service=svc1 path=r1->r2 cost=1200000")
+}
@@ -43,6 +43,10 @@ func newInspectCmd(p common.OptionsProvider) *cobra.Command {
 	cmd.AddCommand(action.newInspectSubCmd(p, "router-controllers", "gets information about the state of a router's connections to its controllers"))
 	cmd.AddCommand(action.newInspectSubCmd(p, "terminator-costs", "gets information about terminator dynamic costs"))
 	cmd.AddCommand(action.newInspectSubCmd(p, inspectCommon.RouterIdentityConnectionStatusesKey, "gets information about controller identity state"))
+	cmd.AddCommand(action.newInspectSubCmd(p, inspectCommon.GoroutinesKey, "gets goroutine stacks, grouped by stack, from the requested nodes"))
+	cmd.AddCommand(action.newInspectSubCmd(p, inspectCommon.MutexProfileKey, "gets mutex contention profiles from the requested nodes, enabling mutex profiling if needed"))
+	cmd.AddCommand(action.newInspectSubCmd(p, inspectCommon.BlockProfileKey, "gets blocking profiles from the requested nodes, enabling block profiling if needed"))
+	cmd.AddCommand(action.newInspectSubCmd(p, inspectCommon.BoltStatsKey, "gets bbolt database and transaction statistics from the requested controllers"))
 
 	inspectCircuitsAction := &InspectCircuitsAction{InspectAction: *newInspectAction(p)}
 	cmd.AddCommand(inspectCircuitsAction.newCobraCmd())
@@ -99,10 +103,10 @@ func (self *InspectAction) run(_ *cobra.Command, args []string) error {
 	return self.inspect(args[0], args[1:]...)
 }
 
-func (self *InspectAction) inspect(appRegex string, requestValues ...string) error {
+func (self *InspectAction) getInspectResults(appRegex string, requestValues ...string) (*rest_model.InspectResponse, error) {
 	client, err := util.NewFabricManagementClient(self)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	inspectOk, err := client.Inspect.Inspect(&inspect.InspectParams{
@@ -113,6 +117,15 @@ func (self *InspectAction) inspect(appRegex string, requestValues ...string) err
 		Context: context.Background(),
 	})
 
+	if err != nil {
+		return nil, err
+	}
+
+	return inspectOk.Payload, nil
+}
+
+func (self *InspectAction) inspect(appRegex string, requestValues ...string) error {
+	result, err := self.getInspectResults(appRegex, requestValues...)
 	if err != nil {
 		return err
 	}
@@ -121,7 +134,6 @@ func (self *InspectAction) inspect(appRegex string, requestValues ...string) err
 		return nil
 	}
 
-	result := inspectOk.Payload
 	if *result.Success {
 		fmt.Printf("Results: (%d)\n", len(result.Values))
 		for idx, value := range result.Values {
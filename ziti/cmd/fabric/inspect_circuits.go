@@ -1,17 +1,30 @@
 package fabric
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/openziti/foundation/v2/stringz"
+	inspectCommon "github.com/openziti/ziti/common/inspect"
+	"github.com/openziti/ziti/controller/rest_client/circuit"
+	"github.com/openziti/ziti/controller/rest_model"
+	"github.com/openziti/ziti/ziti/util"
 	"github.com/spf13/cobra"
 )
 
 type InspectCircuitsAction struct {
 	InspectAction
 	includeStacks bool
+	hops          bool
 }
 
 func (self *InspectCircuitsAction) addFlags(cmd *cobra.Command) *cobra.Command {
 	self.InspectAction.addFlags(cmd)
 	cmd.Flags().BoolVar(&self.includeStacks, "include-stacks", false, "Include stack information")
+	cmd.Flags().BoolVar(&self.hops, "hops", false, "Summarize queue depth, forwarding latency and retransmits for each router, in path order")
 	return cmd
 }
 
@@ -36,5 +49,167 @@ func (self *InspectCircuitsAction) runInspectCircuit(_ *cobra.Command, args []st
 	if len(args) > 1 {
 		appRegex = args[1]
 	}
+
+	if self.hops {
+		return self.inspectHops(args[0], appRegex, requestedValue)
+	}
 	return self.inspect(appRegex, requestedValue)
 }
+
+func (self *InspectCircuitsAction) inspectHops(circuitId string, appRegex string, requestedValue string) error {
+	result, err := self.getInspectResults(appRegex, requestedValue)
+	if err != nil {
+		return err
+	}
+
+	if self.OutputResponseJson() {
+		return nil
+	}
+
+	if !*result.Success {
+		fmt.Printf("\nEncountered errors: (%d)\n", len(result.Errors))
+		for _, err := range result.Errors {
+			fmt.Printf("\t%v\n", err)
+		}
+		return nil
+	}
+
+	hops := map[string]*inspectCommon.CircuitHopDetail{}
+	for _, value := range result.Values {
+		hop, err := getCircuitHopDetail(circuitId, value.Value)
+		if err != nil {
+			return fmt.Errorf("unable to read circuit detail from %s (%w)", stringz.OrEmpty(value.AppID), err)
+		}
+		if hop != nil {
+			hops[stringz.OrEmpty(value.AppID)] = hop
+		}
+	}
+
+	// the circuit may already be gone from the controller, in which case we fall back to ordering by router id
+	var path []*rest_model.EntityRef
+	if circuitPath, err := self.getCircuitPath(circuitId); err != nil {
+		fmt.Printf("unable to get path for circuit %s, routers will not be shown in path order (%v)\n", circuitId, err)
+	} else {
+		path = circuitPath.Nodes
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleRounded)
+	t.AppendHeader(table.Row{"Hop", "Router", "Queued", "Forwarded", "Retransmitted", "Avg Fwd Latency", "Max Fwd Latency", "Retransmits", "Dup Acks", "Link Latency"})
+
+	for idx, node := range orderCircuitHops(path, hops) {
+		row := table.Row{idx + 1, node.Name}
+		if hop := hops[node.ID]; hop != nil {
+			row = append(row, hop.QueuedPayloads, hop.ForwardedPayloads, hop.RetransmittedPayloads,
+				hop.AvgForwardLatency, hop.MaxForwardLatency, hop.Retransmits, hop.DuplicateAcks, formatLinkLatency(hop.LinkLatency))
+		} else {
+			row = append(row, "no data")
+		}
+		t.AppendRow(row)
+	}
+
+	fmt.Println(t.Render())
+	return nil
+}
+
+func (self *InspectCircuitsAction) getCircuitPath(circuitId string) (*rest_model.Path, error) {
+	client, err := util.NewFabricManagementClient(self)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, err := client.Circuit.DetailCircuit(&circuit.DetailCircuitParams{
+		ID:      circuitId,
+		Context: context.Background(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if detail.Payload.Data == nil || detail.Payload.Data.Path == nil {
+		return nil, fmt.Errorf("no path returned for circuit %s", circuitId)
+	}
+	return detail.Payload.Data.Path, nil
+}
+
+// getCircuitHopDetail extracts the hop summary for the given circuit from a router's circuit inspect result
+func getCircuitHopDetail(circuitId string, value any) (*inspectCommon.CircuitHopDetail, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if strVal, ok := value.(string); ok {
+		return nil, fmt.Errorf("unexpected result: %s", strVal)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// related entities of other types have different structures, so only decode the hop entries
+	circuitDetail := struct {
+		RelatedEntities map[string]json.RawMessage `json:"relatedEntities"`
+	}{}
+	if err = json.Unmarshal(data, &circuitDetail); err != nil {
+		return nil, err
+	}
+
+	hopData, found := circuitDetail.RelatedEntities["hop"]
+	if !found {
+		return nil, nil
+	}
+
+	hops := map[string]*inspectCommon.CircuitHopDetail{}
+	if err = json.Unmarshal(hopData, &hops); err != nil {
+		return nil, err
+	}
+	return hops[circuitId], nil
+}
+
+// orderCircuitHops returns the routers in path order, followed by any routers which reported on the circuit but
+// aren't in the path, sorted by id
+func orderCircuitHops(path []*rest_model.EntityRef, hops map[string]*inspectCommon.CircuitHopDetail) []*rest_model.EntityRef {
+	var result []*rest_model.EntityRef
+	seen := map[string]struct{}{}
+	for _, node := range path {
+		if node == nil {
+			continue
+		}
+		if node.Name == "" {
+			node.Name = node.ID
+		}
+		result = append(result, node)
+		seen[node.ID] = struct{}{}
+	}
+
+	var extra []string
+	for routerId := range hops {
+		if _, found := seen[routerId]; !found {
+			extra = append(extra, routerId)
+		}
+	}
+	sort.Strings(extra)
+
+	for _, routerId := range extra {
+		result = append(result, &rest_model.EntityRef{ID: routerId, Name: routerId})
+	}
+	return result
+}
+
+func formatLinkLatency(linkLatency map[string]string) string {
+	var linkIds []string
+	for linkId := range linkLatency {
+		linkIds = append(linkIds, linkId)
+	}
+	sort.Strings(linkIds)
+
+	result := ""
+	for idx, linkId := range linkIds {
+		if idx > 0 {
+			result += "\n"
+		}
+		result += linkId + ": " + linkLatency[linkId]
+	}
+	return result
+}
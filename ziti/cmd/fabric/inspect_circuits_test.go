@@ -0,0 +1,81 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/openziti/ziti/common/inspect"
+	"github.com/openziti/ziti/controller/rest_model"
+	"github.com/stretchr/testify/require"
+)
+
+const testCircuitInspectResult = `{
+	"circuitId": "c1",
+	"forwards": {"abc": "l1"},
+	"relatedEntities": {
+		"link": {"l1": {"id": "l1", "protocol": "tls"}},
+		"hop": {"c1": {"forwardedPayloads": 10, "queuedPayloads": 3, "retransmits": 2, "avgForwardLatency": "5µs", "linkLatency": {"l1": "2ms"}}}
+	}
+}`
+
+func TestGetCircuitHopDetail(t *testing.T) {
+	req := require.New(t)
+
+	var value any
+	req.NoError(json.Unmarshal([]byte(testCircuitInspectResult), &value))
+
+	hop, err := getCircuitHopDetail("c1", value)
+	req.NoError(err)
+	req.NotNil(hop)
+	req.Equal(uint64(10), hop.ForwardedPayloads)
+	req.Equal(3, hop.QueuedPayloads)
+	req.Equal(uint32(2), hop.Retransmits)
+	req.Equal("5µs", hop.AvgForwardLatency)
+	req.Equal("2ms", hop.LinkLatency["l1"])
+
+	hop, err = getCircuitHopDetail("c2", value)
+	req.NoError(err)
+	req.Nil(hop)
+
+	_, err = getCircuitHopDetail("c1", "circuit not found")
+	req.Error(err)
+}
+
+func TestOrderCircuitHops(t *testing.T) {
+	req := require.New(t)
+
+	path := []*rest_model.EntityRef{
+		{ID: "r2", Name: "router-2"},
+		{ID: "r1"},
+	}
+	hops := map[string]*inspect.CircuitHopDetail{
+		"r1": {},
+		"r3": {},
+		"r0": {},
+	}
+
+	var ids []string
+	var names []string
+	for _, node := range orderCircuitHops(path, hops) {
+		ids = append(ids, node.ID)
+		names = append(names, node.Name)
+	}
+	req.Equal([]string{"r2", "r1", "r0", "r3"}, ids)
+	req.Equal([]string{"router-2", "r1", "r0", "r3"}, names)
+}
@@ -250,13 +250,18 @@ func runListServices(o *api.Options) error {
 func outputServices(o *api.Options, result *service.ListServicesOK) error {
 	t := table.NewWriter()
 	t.SetStyle(table.StyleRounded)
-	t.AppendHeader(table.Row{"ID", "Name", "Terminator Strategy"})
+	t.AppendHeader(table.Row{"ID", "Name", "Terminator Strategy", "Circuit Breaker"})
 
 	for _, entity := range result.Payload.Data {
+		breakerState := entity.CircuitBreakerState
+		if breakerState == "" {
+			breakerState = "-"
+		}
 		t.AppendRow(table.Row{
 			valOrDefault(entity.ID),
 			valOrDefault(entity.Name),
 			valOrDefault(entity.TerminatorStrategy),
+			breakerState,
 		})
 	}
 
@@ -281,7 +286,7 @@ func runListRouters(o *api.Options) error {
 func outputRouters(o *api.Options, result *router.ListRoutersOK) error {
 	t := table.NewWriter()
 	t.SetStyle(table.StyleRounded)
-	t.AppendHeader(table.Row{"ID", "Name", "Online", "Cost", "No Traversal", "Disabled", "Version", "Listeners"})
+	t.AppendHeader(table.Row{"ID", "Name", "Online", "Cost", "No Traversal", "Disabled", "Draining", "Version", "Listeners"})
 
 	for _, entity := range result.Payload.Data {
 		var version string
@@ -300,6 +305,7 @@ func outputRouters(o *api.Options, result *router.ListRoutersOK) error {
 			valOrDefault(entity.Cost),
 			valOrDefault(entity.NoTraversal),
 			valOrDefault(entity.Disabled),
+			entity.Draining,
 			version,
 			strings.Join(listeners, "\n")})
 	}
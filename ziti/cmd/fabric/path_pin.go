@@ -0,0 +1,234 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Jeffail/gabs"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/spf13/cobra"
+	"gopkg.in/resty.v1"
+)
+
+const pathPinsPath = "path-pins"
+
+func newPinCommand(p common.OptionsProvider) *cobra.Command {
+	pinCmd := &cobra.Command{
+		Use:   "pin",
+		Short: "pin circuits to a specific path through the network, overriding smart routing",
+		Long: "Pins circuits to a path through the given routers, in order. The initiating and terminating routers are " +
+			"always used at the ends of the path and don't need to be specified. Pins are held in memory by the " +
+			"controller which owns the circuits and are not persisted.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdhelper.CheckErr(cmd.Help())
+		},
+	}
+
+	pinCmd.AddCommand(newPinEntityCmd(p, "circuit"))
+	pinCmd.AddCommand(newPinEntityCmd(p, "service"))
+	pinCmd.AddCommand(newListPathPinsCmd(p))
+
+	return pinCmd
+}
+
+func newUnpinCommand(p common.OptionsProvider) *cobra.Command {
+	unpinCmd := &cobra.Command{
+		Use:   "unpin",
+		Short: "remove a path pin, returning circuits to smart routing",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdhelper.CheckErr(cmd.Help())
+		},
+	}
+
+	unpinCmd.AddCommand(newUnpinEntityCmd(p, "circuit"))
+	unpinCmd.AddCommand(newUnpinEntityCmd(p, "service"))
+
+	return unpinCmd
+}
+
+func newPinEntityCmd(p common.OptionsProvider, entityType string) *cobra.Command {
+	options := &api.Options{CommonOptions: p()}
+
+	use := entityType + " <circuitId> <router> [<router>...]"
+	if entityType == "service" {
+		use = entityType + " <service id or name> <router> [<router>...]"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: fmt.Sprintf("pin %vs to a path through the given routers", entityType),
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := runPinPath(options, entityType)
+			cmdhelper.CheckErr(err)
+		},
+		SuggestFor: []string{},
+	}
+
+	// allow interspersing positional args and flags
+	cmd.Flags().SetInterspersed(true)
+	options.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+func newUnpinEntityCmd(p common.OptionsProvider, entityType string) *cobra.Command {
+	options := &api.Options{CommonOptions: p()}
+
+	use := entityType + " <circuitId>"
+	if entityType == "service" {
+		use = entityType + " <service id or name>"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: fmt.Sprintf("remove the path pin from a %v", entityType),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := runUnpinPath(options, entityType)
+			cmdhelper.CheckErr(err)
+		},
+		SuggestFor: []string{},
+	}
+
+	options.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+func newListPathPinsCmd(p common.OptionsProvider) *cobra.Command {
+	options := &api.Options{CommonOptions: p()}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list circuit and service path pins",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := runListPathPins(options)
+			cmdhelper.CheckErr(err)
+		},
+		SuggestFor: []string{},
+	}
+
+	options.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+func getPathPinTarget(o *api.Options, entityType string) (string, error) {
+	if entityType == "service" {
+		serviceId, err := api.MapNameToID(util.FabricAPI, "services", o, o.Args[0])
+		if err != nil {
+			return "", err
+		}
+		return "services/" + url.PathEscape(serviceId), nil
+	}
+	return "circuits/" + url.PathEscape(o.Args[0]), nil
+}
+
+func runPinPath(o *api.Options, entityType string) error {
+	target, err := getPathPinTarget(o, entityType)
+	if err != nil {
+		return err
+	}
+
+	routerIds, err := api.MapNamesToIDs(util.FabricAPI, "routers", o, o.Args[1:]...)
+	if err != nil {
+		return err
+	}
+
+	if len(routerIds) != len(o.Args[1:]) {
+		return fmt.Errorf("unable to find all routers in %v", o.Args[1:])
+	}
+
+	entityData := gabs.New()
+	api.SetJSONValue(entityData, routerIds, "routerIds")
+
+	if _, err = updateEntityOfType(pathPinsPath+"/"+target, entityData.String(), o, resty.MethodPut); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(o.Out, "pinned %v %v to path through routers [%v]\n", entityType, o.Args[0], strings.Join(routerIds, ", "))
+	return err
+}
+
+func runUnpinPath(o *api.Options, entityType string) error {
+	target, err := getPathPinTarget(o, entityType)
+	if err != nil {
+		return err
+	}
+
+	targetType, id, _ := strings.Cut(target, "/")
+	err, _ = util.ControllerDelete(util.FabricAPI, pathPinsPath+"/"+targetType, id, "", o.Out, o.OutputJSONRequest, o.OutputJSONResponse, o.Timeout, o.Verbose)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(o.Out, "removed path pin from %v\n", o.Args[0])
+	return err
+}
+
+func runListPathPins(o *api.Options) error {
+	result, err := util.ControllerList(util.FabricAPI, pathPinsPath, nil, o.OutputJSONResponse, o.Out, o.Timeout, o.Verbose)
+	if err != nil {
+		return err
+	}
+
+	if o.OutputJSONResponse {
+		return nil
+	}
+
+	children, err := result.S("data").Children()
+	if err != nil {
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleRounded)
+	t.AppendHeader(table.Row{"Circuit", "Service", "Routers"})
+
+	for _, pin := range children {
+		circuitId, _ := pin.S("circuitId").Data().(string)
+		serviceId, _ := pin.S("serviceId").Data().(string)
+
+		var routerIds []string
+		routers, _ := pin.S("routerIds").Children()
+		for _, router := range routers {
+			if routerId, ok := router.Data().(string); ok {
+				routerIds = append(routerIds, "r/"+routerId)
+			}
+		}
+
+		t.AppendRow(table.Row{circuitId, serviceId, strings.Join(routerIds, " -> ")})
+	}
+
+	api.RenderTable(o, t, nil)
+	return nil
+}
@@ -36,7 +36,10 @@ func NewFabricCmd(p common.OptionsProvider) *cobra.Command {
 	fabricCmd.AddCommand(newInspectCmd(p))
 	fabricCmd.AddCommand(newDbCmd(p))
 	fabricCmd.AddCommand(newStreamCommand(p))
+	fabricCmd.AddCommand(newEventsCommand(p))
 	fabricCmd.AddCommand(newValidateCommand(p))
+	fabricCmd.AddCommand(newCaptureCommand(p))
+	fabricCmd.AddCommand(newPinCommand(p), newUnpinCommand(p))
 	return fabricCmd
 }
 
@@ -66,6 +69,7 @@ func newUpdateCommand(p common.OptionsProvider) *cobra.Command {
 	}
 
 	updateCmd.AddCommand(newUpdateLinkCmd(p))
+	updateCmd.AddCommand(newUpdateLinkDialBackoffCmd(p))
 	updateCmd.AddCommand(newUpdateRouterCmd(p))
 	updateCmd.AddCommand(newUpdateServiceCmd(p))
 	updateCmd.AddCommand(newUpdateTerminatorCmd(p))
@@ -99,6 +103,19 @@ func newStreamCommand(p common.OptionsProvider) *cobra.Command {
 	return streamCmd
 }
 
+func newEventsCommand(p common.OptionsProvider) *cobra.Command {
+	eventsCmd := &cobra.Command{
+		Use:   "events",
+		Short: "work with fabric events",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdhelper.CheckErr(cmd.Help())
+		},
+	}
+
+	eventsCmd.AddCommand(NewEventsTailCmd(p))
+	return eventsCmd
+}
+
 func newValidateCommand(p common.OptionsProvider) *cobra.Command {
 	validateCmd := &cobra.Command{
 		Use:   "validate",
@@ -115,6 +132,7 @@ func newValidateCommand(p common.OptionsProvider) *cobra.Command {
 	validateCmd.AddCommand(NewValidateRouterErtTerminatorsCmd(p))
 	validateCmd.AddCommand(NewValidateRouterDataModelCmd(p))
 	validateCmd.AddCommand(NewValidateIdentityConnectionStatusesCmd(p))
+	validateCmd.AddCommand(NewValidateNetworkCmd(p))
 	return validateCmd
 }
 
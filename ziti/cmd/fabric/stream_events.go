@@ -218,8 +218,6 @@ func (self *streamEventsAction) streamEvents(cmd *cobra.Command, _ []string) err
 	if self.usageVersion < 2 || self.usageVersion > 3 {
 		return errors.New("invalid usage version")
 	}
-	streamEventsRequest := map[string]interface{}{}
-	streamEventsRequest["format"] = "json"
 
 	subscriptions := self.buildSubscriptions(cmd)
 	if len(subscriptions) == 0 {
@@ -227,12 +225,27 @@ func (self *streamEventsAction) streamEvents(cmd *cobra.Command, _ []string) err
 		subscriptions = self.buildSubscriptions(cmd)
 	}
 
+	closeNotify, err := startEventStream(&self.Options, subscriptions, self)
+	if err != nil {
+		return err
+	}
+
+	<-closeNotify
+	return nil
+}
+
+// startEventStream opens a management channel to the controller and subscribes to the given events. Events are
+// delivered, formatted as JSON, to the given handler. The returned channel is closed when the management channel
+// closes.
+func startEventStream(options *api.Options, subscriptions []*event.Subscription, handler channel.ReceiveHandler) (<-chan struct{}, error) {
+	streamEventsRequest := map[string]interface{}{}
+	streamEventsRequest["format"] = "json"
 	streamEventsRequest["subscriptions"] = subscriptions
 
 	closeNotify := make(chan struct{})
 
 	bindHandler := func(binding channel.Binding) error {
-		binding.AddReceiveHandler(int32(mgmt_pb.ContentType_StreamEventsEventType), self)
+		binding.AddReceiveHandler(int32(mgmt_pb.ContentType_StreamEventsEventType), handler)
 		binding.AddCloseHandler(channel.CloseHandlerF(func(ch channel.Channel) {
 			close(closeNotify)
 		}))
@@ -241,28 +254,28 @@ func (self *streamEventsAction) streamEvents(cmd *cobra.Command, _ []string) err
 
 	ch, err := api.NewWsMgmtChannel(channel.BindHandlerF(bindHandler))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	msgBytes, err := json.Marshal(streamEventsRequest)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if self.Verbose {
+	if options.Verbose {
 		fmt.Printf("Request: %v\n", string(msgBytes))
 	}
 
 	requestMsg := channel.NewMessage(int32(mgmt_pb.ContentType_StreamEventsRequestType), msgBytes)
-	responseMsg, err := requestMsg.WithTimeout(time.Duration(self.Timeout) * time.Second).SendForReply(ch)
+	responseMsg, err := requestMsg.WithTimeout(time.Duration(options.Timeout) * time.Second).SendForReply(ch)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if responseMsg.ContentType == channel.ContentTypeResultType {
 		result := channel.UnmarshalResult(responseMsg)
 		if result.Success {
-			if self.Verbose {
+			if options.Verbose {
 				fmt.Printf("event streaming started: %v\n", result.Message)
 			}
 		} else {
@@ -270,11 +283,10 @@ func (self *streamEventsAction) streamEvents(cmd *cobra.Command, _ []string) err
 			os.Exit(1)
 		}
 	} else {
-		return errors.Errorf("unexpected response type %v", responseMsg.ContentType)
+		return nil, errors.Errorf("unexpected response type %v", responseMsg.ContentType)
 	}
 
-	<-closeNotify
-	return nil
+	return closeNotify, nil
 }
 
 func (self *streamEventsAction) HandleReceive(msg *channel.Message, _ channel.Channel) {
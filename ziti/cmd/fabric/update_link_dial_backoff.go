@@ -0,0 +1,129 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"fmt"
+	"github.com/openziti/channel/v4/protobufs"
+	"github.com/openziti/ziti/common/pb/mgmt_pb"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+type dialBackoffFlags struct {
+	prefix             string
+	minRetryInterval   time.Duration
+	maxRetryInterval   time.Duration
+	retryBackoffFactor float64
+	jitter             float64
+	maxAttempts        uint32
+}
+
+func (self *dialBackoffFlags) addFlags(cmd *cobra.Command) {
+	p := self.prefix
+	cmd.Flags().DurationVar(&self.minRetryInterval, p+"-min-retry-interval", 0, fmt.Sprintf("Minimum interval between dials when the destination router is %s", p))
+	cmd.Flags().DurationVar(&self.maxRetryInterval, p+"-max-retry-interval", 0, fmt.Sprintf("Maximum interval between dials when the destination router is %s", p))
+	cmd.Flags().Float64Var(&self.retryBackoffFactor, p+"-backoff-factor", 0, fmt.Sprintf("Factor the retry interval is multiplied by after each failed dial when the destination router is %s", p))
+	cmd.Flags().Float64Var(&self.jitter, p+"-jitter", 0, fmt.Sprintf("Randomness, between 0 and 1, applied to the backoff factor when the destination router is %s", p))
+	cmd.Flags().Uint32Var(&self.maxAttempts, p+"-max-attempts", 0, fmt.Sprintf("Consecutive failed dials after which the router gives up when the destination router is %s", p))
+}
+
+func (self *dialBackoffFlags) toBackoff(cmd *cobra.Command) *mgmt_pb.LinkDialBackoff {
+	changed := false
+	for _, name := range []string{"-min-retry-interval", "-max-retry-interval", "-backoff-factor", "-jitter", "-max-attempts"} {
+		changed = changed || cmd.Flags().Changed(self.prefix+name)
+	}
+	if !changed {
+		return nil
+	}
+	return &mgmt_pb.LinkDialBackoff{
+		MinRetryIntervalMs: self.minRetryInterval.Milliseconds(),
+		MaxRetryIntervalMs: self.maxRetryInterval.Milliseconds(),
+		RetryBackoffFactor: self.retryBackoffFactor,
+		Jitter:             self.jitter,
+		MaxAttempts:        self.maxAttempts,
+	}
+}
+
+type updateLinkDialBackoffAction struct {
+	api.Options
+	healthy   dialBackoffFlags
+	unhealthy dialBackoffFlags
+	clear     bool
+}
+
+func newUpdateLinkDialBackoffCmd(p common.OptionsProvider) *cobra.Command {
+	action := &updateLinkDialBackoffAction{
+		Options:   api.Options{CommonOptions: p()},
+		healthy:   dialBackoffFlags{prefix: "healthy"},
+		unhealthy: dialBackoffFlags{prefix: "unhealthy"},
+	}
+
+	cmd := &cobra.Command{
+		Use:     "link-dial-backoff <link group>",
+		Short:   "overrides the link dial backoff used by routers for the given link group",
+		Long:    "Overrides the link dial backoff used by routers for the given link group. Settings which aren't specified use the value from the router's dialer configuration. Overrides are held in memory by the controller and sent to routers as they connect.",
+		Example: "ziti fabric update link-dial-backoff default --unhealthy-max-retry-interval 10m --unhealthy-max-attempts 20",
+		Args:    cobra.ExactArgs(1),
+		RunE:    action.run,
+	}
+
+	action.healthy.addFlags(cmd)
+	action.unhealthy.addFlags(cmd)
+	cmd.Flags().BoolVar(&action.clear, "clear", false, "Remove the override for the link group, reverting routers to their configured backoff")
+	action.AddCommonFlags(cmd)
+
+	return cmd
+}
+
+func (self *updateLinkDialBackoffAction) run(cmd *cobra.Command, args []string) error {
+	request := &mgmt_pb.UpdateLinkDialBackoffRequest{
+		Group:     args[0],
+		Healthy:   self.healthy.toBackoff(cmd),
+		Unhealthy: self.unhealthy.toBackoff(cmd),
+		Clear:     self.clear,
+	}
+
+	if !request.Clear && request.Healthy == nil && request.Unhealthy == nil {
+		return errors.New("no change specified. must specify at least one backoff setting or --clear")
+	}
+
+	ch, err := api.NewWsMgmtChannel(nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = ch.Close()
+	}()
+
+	responseMsg, err := protobufs.MarshalTyped(request).WithTimeout(time.Duration(self.Timeout) * time.Second).SendForReply(ch)
+
+	response := &mgmt_pb.UpdateLinkDialBackoffResponse{}
+	if err = protobufs.TypedResponse(response).Unmarshall(responseMsg, err); err != nil {
+		return err
+	}
+
+	if !response.Success {
+		return fmt.Errorf("failed to update link dial backoff: %s", response.Message)
+	}
+
+	fmt.Printf("link dial backoff for group %s updated, sent to %d connected routers\n", request.Group, response.RouterCount)
+	return nil
+}
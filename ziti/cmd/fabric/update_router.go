@@ -18,6 +18,9 @@ package fabric
 
 import (
 	"fmt"
+	"math"
+	"time"
+
 	"github.com/openziti/ziti/ziti/cmd/api"
 	"github.com/openziti/ziti/ziti/cmd/common"
 	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
@@ -30,12 +33,14 @@ import (
 
 type updateRouterOptions struct {
 	api.Options
-	name        string
-	fingerprint string
-	cost        uint16
-	noTraversal bool
-	disabled    bool
-	tags        map[string]string
+	name          string
+	fingerprint   string
+	cost          uint16
+	noTraversal   bool
+	disabled      bool
+	drain         bool
+	drainDeadline time.Duration
+	tags          map[string]string
 }
 
 func newUpdateRouterCmd(p common.OptionsProvider) *cobra.Command {
@@ -63,6 +68,8 @@ func newUpdateRouterCmd(p common.OptionsProvider) *cobra.Command {
 	cmd.Flags().Uint16Var(&options.cost, "cost", 0, "Specifies the router cost. Default 0.")
 	cmd.Flags().BoolVar(&options.noTraversal, "no-traversal", false, "Disallow traversal for this edge router. Default to allowed(false).")
 	cmd.Flags().BoolVar(&options.disabled, "disabled", false, "Disabled routers can't connect to controllers")
+	cmd.Flags().BoolVar(&options.drain, "drain", false, "Draining routers aren't used for new circuits. Use --drain=false to end a drain")
+	cmd.Flags().DurationVar(&options.drainDeadline, "drain-deadline", 0, "How long to wait for existing circuits to finish before moving or closing them. Default is to wait indefinitely")
 	cmd.Flags().StringToStringVar(&options.tags, "tags", nil, "Custom management tags")
 
 	options.AddCommonFlags(cmd)
@@ -104,6 +111,21 @@ func runUpdateRouter(o *updateRouterOptions) error {
 		change = true
 	}
 
+	if o.Cmd.Flags().Changed("drain-deadline") && (!o.Cmd.Flags().Changed("drain") || !o.drain) {
+		return errors.New("--drain-deadline may only be used with --drain")
+	}
+
+	if o.Cmd.Flags().Changed("drain") {
+		api.SetJSONValue(entityData, o.drain, "draining")
+		if o.drain && o.drainDeadline > 0 {
+			// the controller computes the deadline from its own clock, so clock skew on this machine doesn't matter
+			api.SetJSONValue(entityData, int64(math.Ceil(o.drainDeadline.Seconds())), "drainTimeoutSeconds")
+		} else {
+			api.SetJSONValue(entityData, nil, "drainDeadline")
+		}
+		change = true
+	}
+
 	if o.Cmd.Flags().Changed("tags") {
 		api.SetJSONValue(entityData, o.tags, "tags")
 		change = true
@@ -23,6 +23,7 @@ import (
 	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
 	"github.com/openziti/ziti/ziti/util"
 	"github.com/pkg/errors"
+	"time"
 
 	"github.com/Jeffail/gabs"
 	"github.com/spf13/cobra"
@@ -32,6 +33,8 @@ type updateServiceOptions struct {
 	api.Options
 	name               string
 	terminatorStrategy string
+	maxIdleTime        time.Duration
+	maxCircuitLifetime time.Duration
 	tags               map[string]string
 }
 
@@ -57,6 +60,8 @@ func newUpdateServiceCmd(p common.OptionsProvider) *cobra.Command {
 	cmd.Flags().SetInterspersed(true)
 	cmd.Flags().StringVarP(&options.name, "name", "n", "", "Set the name of the service")
 	cmd.Flags().StringVar(&options.terminatorStrategy, "terminator-strategy", "", "Specifies the terminator strategy for the service")
+	cmd.Flags().DurationVar(&options.maxIdleTime, "max-idle-time", 0, "Time after which idle circuit will be terminated. Set to 0 to remove the limit on idle circuits")
+	cmd.Flags().DurationVar(&options.maxCircuitLifetime, "max-circuit-lifetime", 0, "Time after which circuits will be terminated, regardless of activity. Set to 0 to remove the limit on circuit lifetime")
 	cmd.Flags().StringToStringVar(&options.tags, "tags", nil, "Custom management tags")
 	options.AddCommonFlags(cmd)
 
@@ -82,6 +87,16 @@ func runUpdateService(o *updateServiceOptions) error {
 		change = true
 	}
 
+	if o.Cmd.Flags().Changed("max-idle-time") {
+		api.SetJSONValue(entityData, o.maxIdleTime.Milliseconds(), "maxIdleTimeMillis")
+		change = true
+	}
+
+	if o.Cmd.Flags().Changed("max-circuit-lifetime") {
+		api.SetJSONValue(entityData, o.maxCircuitLifetime.Milliseconds(), "maxCircuitLifetimeMillis")
+		change = true
+	}
+
 	if o.Cmd.Flags().Changed("tags") {
 		api.SetJSONValue(entityData, o.tags, "tags")
 		change = true
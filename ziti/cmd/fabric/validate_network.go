@@ -0,0 +1,762 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/openziti/edge-api/rest_management_api_client"
+	"github.com/openziti/edge-api/rest_management_api_client/authenticator"
+	"github.com/openziti/edge-api/rest_management_api_client/certificate_authority"
+	"github.com/openziti/edge-api/rest_management_api_client/controllers"
+	"github.com/openziti/edge-api/rest_management_api_client/edge_router"
+	"github.com/openziti/edge-api/rest_management_api_client/edge_router_policy"
+	"github.com/openziti/edge-api/rest_management_api_client/service_edge_router_policy"
+	"github.com/openziti/edge-api/rest_management_api_client/service_policy"
+	"github.com/openziti/foundation/v2/stringz"
+	fabricRestClient "github.com/openziti/ziti/controller/rest_client"
+	"github.com/openziti/ziti/controller/rest_client/link"
+	"github.com/openziti/ziti/controller/rest_client/router"
+	"github.com/openziti/ziti/controller/rest_client/service"
+	"github.com/openziti/ziti/controller/rest_client/terminator"
+	"github.com/openziti/ziti/controller/rest_model"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	networkCheckRouters     = "routers"
+	networkCheckLinks       = "links"
+	networkCheckTerminators = "terminators"
+	networkCheckPolicies    = "policies"
+	networkCheckCerts       = "certs"
+
+	networkSeverityError   = "error"
+	networkSeverityWarning = "warning"
+	networkSeverityInfo    = "info"
+
+	// each error takes this many points off the score of the check which found it, each warning takes off
+	// networkWarningPenalty points
+	networkErrorPenalty   = 25
+	networkWarningPenalty = 5
+)
+
+var networkChecks = []string{networkCheckRouters, networkCheckLinks, networkCheckTerminators, networkCheckPolicies, networkCheckCerts}
+
+type validateNetworkAction struct {
+	api.Options
+	checks         []string
+	certWarnPeriod time.Duration
+	minScore       int
+}
+
+func NewValidateNetworkCmd(p common.OptionsProvider) *cobra.Command {
+	action := validateNetworkAction{
+		Options: api.Options{
+			CommonOptions: p(),
+		},
+	}
+
+	validateNetworkCmd := &cobra.Command{
+		Use:   "network",
+		Short: "Run a set of health checks against the network and report a score with the findings",
+		Long: "Checks for routers which aren't connected, missing links between connected routers, services without " +
+			"terminators, policies which grant nothing and certificates which are expired or about to expire. " +
+			"Each check is scored from 0 to 100 and the network score is the average of the check scores. " +
+			"Exits with a non-zero status if any errors are found, or the score is below --min-score",
+		Example: "ziti fabric validate network --checks routers,links --cert-warn-period 720h --output json",
+		Args:    cobra.ExactArgs(0),
+		RunE:    action.validateNetwork,
+	}
+
+	action.AddCommonFlags(validateNetworkCmd)
+	validateNetworkCmd.Flags().StringSliceVar(&action.checks, "checks", networkChecks, "Checks to run. Valid values: "+strings.Join(networkChecks, ", "))
+	validateNetworkCmd.Flags().DurationVar(&action.certWarnPeriod, "cert-warn-period", 30*24*time.Hour, "Report certificates expiring within this period")
+	validateNetworkCmd.Flags().IntVar(&action.minScore, "min-score", 0, "Exit with a non-zero status if the network score is below this value")
+	return validateNetworkCmd
+}
+
+// networkFinding is a single problem found by a check. Code is stable, so findings can be processed by scripts.
+type networkFinding struct {
+	Check      string `json:"check"`
+	Code       string `json:"code"`
+	Severity   string `json:"severity"`
+	EntityType string `json:"entityType,omitempty"`
+	EntityId   string `json:"entityId,omitempty"`
+	EntityName string `json:"entityName,omitempty"`
+	Message    string `json:"message"`
+}
+
+type networkCheckResult struct {
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	Checked  int    `json:"checked"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+}
+
+type networkReport struct {
+	Score    int                   `json:"score"`
+	Checks   []*networkCheckResult `json:"checks"`
+	Findings []*networkFinding     `json:"findings"`
+}
+
+// networkSnapshot holds the network state the checks are run against. If loading the data for a check failed, the
+// error is recorded in loadErrors and the check reports it instead of running.
+type networkSnapshot struct {
+	routers     []*rest_model.RouterDetail
+	links       []*rest_model.LinkDetail
+	services    []*rest_model.ServiceDetail
+	terminators []*rest_model.TerminatorDetail
+	policies    []*policyCoverage
+	certs       []*certSource
+	loadErrors  map[string]error
+}
+
+// policyCoverage records which sides of a policy don't match any entities
+type policyCoverage struct {
+	entityType string
+	id         string
+	name       string
+	emptySides []string
+}
+
+type certSource struct {
+	entityType string
+	id         string
+	name       string
+	certPem    string
+}
+
+func (self *validateNetworkAction) validateNetwork(_ *cobra.Command, _ []string) error {
+	for _, check := range self.checks {
+		if !slices.Contains(networkChecks, check) {
+			return errors.Errorf("invalid check '%s', valid checks are: %s", check, strings.Join(networkChecks, ", "))
+		}
+	}
+
+	snapshot, err := self.loadSnapshot()
+	if err != nil {
+		return err
+	}
+
+	report := newNetworkReport(snapshot, self.checks, time.Now(), self.certWarnPeriod)
+
+	if self.OutputResponseJson() {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		if err = util.OutputResponse(self.Out, data); err != nil {
+			return err
+		}
+	} else {
+		report.print()
+	}
+
+	if report.hasErrors() || report.Score < self.minScore {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func (self *validateNetworkAction) loadSnapshot() (*networkSnapshot, error) {
+	fabricClient, err := util.NewFabricManagementClient(self)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &networkSnapshot{
+		loadErrors: map[string]error{},
+	}
+
+	if self.isSelected(networkCheckRouters, networkCheckLinks) {
+		if err = self.loadRoutersAndLinks(fabricClient, result); err != nil {
+			result.loadErrors[networkCheckRouters] = err
+			result.loadErrors[networkCheckLinks] = err
+		}
+	}
+
+	if self.isSelected(networkCheckTerminators) {
+		if err = self.loadServicesAndTerminators(fabricClient, result); err != nil {
+			result.loadErrors[networkCheckTerminators] = err
+		}
+	}
+
+	if self.isSelected(networkCheckPolicies, networkCheckCerts) {
+		edgeClient, err := util.NewEdgeManagementClient(self)
+		if err != nil {
+			return nil, err
+		}
+
+		if self.isSelected(networkCheckPolicies) {
+			if err = self.loadPolicies(edgeClient, result); err != nil {
+				result.loadErrors[networkCheckPolicies] = err
+			}
+		}
+
+		if self.isSelected(networkCheckCerts) {
+			if err = self.loadCerts(edgeClient, result); err != nil {
+				result.loadErrors[networkCheckCerts] = err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (self *validateNetworkAction) isSelected(checks ...string) bool {
+	for _, check := range checks {
+		if slices.Contains(self.checks, check) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *validateNetworkAction) loadRoutersAndLinks(client *fabricRestClient.ZitiFabric, snapshot *networkSnapshot) error {
+	ctx, cancelF := self.TimeoutContext()
+	defer cancelF()
+
+	filter := "true limit none"
+	routers, err := client.Router.ListRouters(&router.ListRoutersParams{
+		Filter:  &filter,
+		Context: ctx,
+	})
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list routers")
+	}
+	snapshot.routers = routers.Payload.Data
+
+	links, err := client.Link.ListLinks(&link.ListLinksParams{
+		Filter:  &filter,
+		Context: ctx,
+	})
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list links")
+	}
+	snapshot.links = links.Payload.Data
+	return nil
+}
+
+func (self *validateNetworkAction) loadServicesAndTerminators(client *fabricRestClient.ZitiFabric, snapshot *networkSnapshot) error {
+	ctx, cancelF := self.TimeoutContext()
+	defer cancelF()
+
+	filter := "true limit none"
+	services, err := client.Service.ListServices(&service.ListServicesParams{
+		Filter:  &filter,
+		Context: ctx,
+	})
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list services")
+	}
+	snapshot.services = services.Payload.Data
+
+	terminators, err := client.Terminator.ListTerminators(&terminator.ListTerminatorsParams{
+		Filter:  &filter,
+		Context: ctx,
+	})
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list terminators")
+	}
+	snapshot.terminators = terminators.Payload.Data
+	return nil
+}
+
+// loadPolicies checks each policy for sides which don't match anything. This takes a request per policy side, so the
+// timeout applies to each request rather than to the whole check.
+func (self *validateNetworkAction) loadPolicies(client *rest_management_api_client.ZitiEdgeManagement, snapshot *networkSnapshot) error {
+	filter := "true limit none"
+	one := int64(1)
+
+	ctx, cancelF := self.TimeoutContext()
+	servicePolicies, err := client.ServicePolicy.ListServicePolicies(&service_policy.ListServicePoliciesParams{
+		Filter:  &filter,
+		Context: ctx,
+	}, nil)
+	cancelF()
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list service policies")
+	}
+
+	for _, policy := range servicePolicies.Payload.Data {
+		coverage := &policyCoverage{
+			entityType: "service-policy",
+			id:         stringz.OrEmpty(policy.ID),
+			name:       stringz.OrEmpty(policy.Name),
+		}
+
+		err = self.checkPolicySide(coverage, "identities", func(ctx context.Context) (int, error) {
+			result, err := client.ServicePolicy.ListServicePolicyIdentities(&service_policy.ListServicePolicyIdentitiesParams{
+				ID:      coverage.id,
+				Limit:   &one,
+				Context: ctx,
+			}, nil)
+			if err != nil {
+				return 0, err
+			}
+			return len(result.Payload.Data), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = self.checkPolicySide(coverage, "services", func(ctx context.Context) (int, error) {
+			result, err := client.ServicePolicy.ListServicePolicyServices(&service_policy.ListServicePolicyServicesParams{
+				ID:      coverage.id,
+				Limit:   &one,
+				Context: ctx,
+			}, nil)
+			if err != nil {
+				return 0, err
+			}
+			return len(result.Payload.Data), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		snapshot.policies = append(snapshot.policies, coverage)
+	}
+
+	ctx, cancelF = self.TimeoutContext()
+	edgeRouterPolicies, err := client.EdgeRouterPolicy.ListEdgeRouterPolicies(&edge_router_policy.ListEdgeRouterPoliciesParams{
+		Filter:  &filter,
+		Context: ctx,
+	}, nil)
+	cancelF()
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list edge router policies")
+	}
+
+	for _, policy := range edgeRouterPolicies.Payload.Data {
+		// system policies are managed by the controller, one per edge router, and can't be changed
+		if policy.IsSystem != nil && *policy.IsSystem {
+			continue
+		}
+
+		coverage := &policyCoverage{
+			entityType: "edge-router-policy",
+			id:         stringz.OrEmpty(policy.ID),
+			name:       stringz.OrEmpty(policy.Name),
+		}
+
+		err = self.checkPolicySide(coverage, "identities", func(ctx context.Context) (int, error) {
+			result, err := client.EdgeRouterPolicy.ListEdgeRouterPolicyIdentities(&edge_router_policy.ListEdgeRouterPolicyIdentitiesParams{
+				ID:      coverage.id,
+				Context: ctx,
+			}, nil)
+			if err != nil {
+				return 0, err
+			}
+			return len(result.Payload.Data), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = self.checkPolicySide(coverage, "edge routers", func(ctx context.Context) (int, error) {
+			result, err := client.EdgeRouterPolicy.ListEdgeRouterPolicyEdgeRouters(&edge_router_policy.ListEdgeRouterPolicyEdgeRoutersParams{
+				ID:      coverage.id,
+				Context: ctx,
+			}, nil)
+			if err != nil {
+				return 0, err
+			}
+			return len(result.Payload.Data), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		snapshot.policies = append(snapshot.policies, coverage)
+	}
+
+	ctx, cancelF = self.TimeoutContext()
+	serpPolicies, err := client.ServiceEdgeRouterPolicy.ListServiceEdgeRouterPolicies(&service_edge_router_policy.ListServiceEdgeRouterPoliciesParams{
+		Filter:  &filter,
+		Context: ctx,
+	}, nil)
+	cancelF()
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list service edge router policies")
+	}
+
+	for _, policy := range serpPolicies.Payload.Data {
+		coverage := &policyCoverage{
+			entityType: "service-edge-router-policy",
+			id:         stringz.OrEmpty(policy.ID),
+			name:       stringz.OrEmpty(policy.Name),
+		}
+
+		err = self.checkPolicySide(coverage, "services", func(ctx context.Context) (int, error) {
+			result, err := client.ServiceEdgeRouterPolicy.ListServiceEdgeRouterPolicyServices(&service_edge_router_policy.ListServiceEdgeRouterPolicyServicesParams{
+				ID:      coverage.id,
+				Context: ctx,
+			}, nil)
+			if err != nil {
+				return 0, err
+			}
+			return len(result.Payload.Data), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = self.checkPolicySide(coverage, "edge routers", func(ctx context.Context) (int, error) {
+			result, err := client.ServiceEdgeRouterPolicy.ListServiceEdgeRouterPolicyEdgeRouters(&service_edge_router_policy.ListServiceEdgeRouterPolicyEdgeRoutersParams{
+				ID:      coverage.id,
+				Context: ctx,
+			}, nil)
+			if err != nil {
+				return 0, err
+			}
+			return len(result.Payload.Data), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		snapshot.policies = append(snapshot.policies, coverage)
+	}
+
+	return nil
+}
+
+func (self *validateNetworkAction) checkPolicySide(coverage *policyCoverage, side string, countF func(ctx context.Context) (int, error)) error {
+	ctx, cancelF := self.TimeoutContext()
+	defer cancelF()
+
+	count, err := countF(ctx)
+	if err != nil {
+		return errors.Wrapf(util.WrapIfApiError(err), "unable to list %s for %s %s", side, coverage.entityType, coverage.name)
+	}
+	if count == 0 {
+		coverage.emptySides = append(coverage.emptySides, side)
+	}
+	return nil
+}
+
+func (self *validateNetworkAction) loadCerts(client *rest_management_api_client.ZitiEdgeManagement, snapshot *networkSnapshot) error {
+	ctx, cancelF := self.TimeoutContext()
+	defer cancelF()
+
+	filter := "true limit none"
+	ctrls, err := client.Controllers.ListControllers(&controllers.ListControllersParams{
+		Filter:  &filter,
+		Context: ctx,
+	}, nil)
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list controllers")
+	}
+	for _, ctrl := range ctrls.Payload.Data {
+		snapshot.addCert("controller", stringz.OrEmpty(ctrl.ID), stringz.OrEmpty(ctrl.Name), stringz.OrEmpty(ctrl.CertPem))
+	}
+
+	cas, err := client.CertificateAuthority.ListCas(&certificate_authority.ListCasParams{
+		Filter:  &filter,
+		Context: ctx,
+	}, nil)
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list certificate authorities")
+	}
+	for _, ca := range cas.Payload.Data {
+		snapshot.addCert("ca", stringz.OrEmpty(ca.ID), stringz.OrEmpty(ca.Name), stringz.OrEmpty(ca.CertPem))
+	}
+
+	edgeRouters, err := client.EdgeRouter.ListEdgeRouters(&edge_router.ListEdgeRoutersParams{
+		Filter:  &filter,
+		Context: ctx,
+	}, nil)
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list edge routers")
+	}
+	for _, edgeRouter := range edgeRouters.Payload.Data {
+		snapshot.addCert("edge-router", stringz.OrEmpty(edgeRouter.ID), stringz.OrEmpty(edgeRouter.Name), stringz.OrEmpty(edgeRouter.CertPem))
+	}
+
+	certFilter := `method="cert" limit none`
+	authenticators, err := client.Authenticator.ListAuthenticators(&authenticator.ListAuthenticatorsParams{
+		Filter:  &certFilter,
+		Context: ctx,
+	}, nil)
+	if err != nil {
+		return errors.Wrap(util.WrapIfApiError(err), "unable to list authenticators")
+	}
+	for _, auth := range authenticators.Payload.Data {
+		name := stringz.OrEmpty(auth.IdentityID)
+		if auth.Identity != nil && auth.Identity.Name != "" {
+			name = auth.Identity.Name
+		}
+		snapshot.addCert("identity", stringz.OrEmpty(auth.IdentityID), name, auth.CertPem)
+	}
+
+	return nil
+}
+
+func (self *networkSnapshot) addCert(entityType, id, name, certPem string) {
+	if certPem != "" {
+		self.certs = append(self.certs, &certSource{
+			entityType: entityType,
+			id:         id,
+			name:       name,
+			certPem:    certPem,
+		})
+	}
+}
+
+// newNetworkReport runs the given checks against the snapshot and scores the results
+func newNetworkReport(snapshot *networkSnapshot, checks []string, now time.Time, certWarnPeriod time.Duration) *networkReport {
+	report := &networkReport{
+		Findings: []*networkFinding{},
+	}
+
+	for _, check := range networkChecks {
+		if !slices.Contains(checks, check) {
+			continue
+		}
+
+		result := &networkCheckResult{
+			Name: check,
+		}
+		report.Checks = append(report.Checks, result)
+
+		findingCount := len(report.Findings)
+		if err := snapshot.loadErrors[check]; err != nil {
+			report.add(check, "CHECK_FAILED", networkSeverityError, "", "", "", "unable to run check: %v", err)
+		} else {
+			switch check {
+			case networkCheckRouters:
+				result.Checked = report.checkRouters(snapshot)
+			case networkCheckLinks:
+				result.Checked = report.checkLinks(snapshot)
+			case networkCheckTerminators:
+				result.Checked = report.checkTerminators(snapshot)
+			case networkCheckPolicies:
+				result.Checked = report.checkPolicies(snapshot)
+			case networkCheckCerts:
+				result.Checked = report.checkCerts(snapshot, now, certWarnPeriod)
+			}
+		}
+
+		for _, finding := range report.Findings[findingCount:] {
+			if finding.Severity == networkSeverityError {
+				result.Errors++
+			} else if finding.Severity == networkSeverityWarning {
+				result.Warnings++
+			}
+		}
+		result.Score = max(0, 100-result.Errors*networkErrorPenalty-result.Warnings*networkWarningPenalty)
+	}
+
+	if len(report.Checks) > 0 {
+		total := 0
+		for _, result := range report.Checks {
+			total += result.Score
+		}
+		report.Score = total / len(report.Checks)
+	}
+
+	return report
+}
+
+func (self *networkReport) add(check, code, severity, entityType, entityId, entityName, msg string, args ...any) {
+	self.Findings = append(self.Findings, &networkFinding{
+		Check:      check,
+		Code:       code,
+		Severity:   severity,
+		EntityType: entityType,
+		EntityId:   entityId,
+		EntityName: entityName,
+		Message:    fmt.Sprintf(msg, args...),
+	})
+}
+
+func (self *networkReport) hasErrors() bool {
+	for _, result := range self.Checks {
+		if result.Errors > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isRouterUsable(r *rest_model.RouterDetail) bool {
+	return r.Connected != nil && *r.Connected && (r.Disabled == nil || !*r.Disabled)
+}
+
+func (self *networkReport) checkRouters(snapshot *networkSnapshot) int {
+	for _, r := range snapshot.routers {
+		id, name := stringz.OrEmpty(r.ID), stringz.OrEmpty(r.Name)
+		if r.Disabled != nil && *r.Disabled {
+			self.add(networkCheckRouters, "ROUTER_DISABLED", networkSeverityInfo, "router", id, name,
+				"router %s is disabled", name)
+		} else if r.Connected == nil || !*r.Connected {
+			self.add(networkCheckRouters, "ROUTER_UNREACHABLE", networkSeverityError, "router", id, name,
+				"router %s is not connected to the controller", name)
+		} else if r.Draining {
+			self.add(networkCheckRouters, "ROUTER_DRAINING", networkSeverityInfo, "router", id, name,
+				"router %s is draining", name)
+		}
+	}
+	return len(snapshot.routers)
+}
+
+// checkLinks expects a connected link between every pair of connected routers where at least one of the routers
+// has a link listener. Link groups and dial-only configuration aren't visible from the management API, so a missing
+// link is a warning rather than an error.
+func (self *networkReport) checkLinks(snapshot *networkSnapshot) int {
+	linked := map[[2]string]bool{}
+	for _, l := range snapshot.links {
+		if l.SourceRouter == nil || l.DestRouter == nil || stringz.OrEmpty(l.State) != "Connected" || (l.Down != nil && *l.Down) {
+			continue
+		}
+		linked[routerPair(l.SourceRouter.ID, l.DestRouter.ID)] = true
+	}
+
+	var routers []*rest_model.RouterDetail
+	for _, r := range snapshot.routers {
+		if isRouterUsable(r) {
+			routers = append(routers, r)
+		}
+	}
+
+	sort.Slice(routers, func(i, j int) bool {
+		return stringz.OrEmpty(routers[i].Name) < stringz.OrEmpty(routers[j].Name)
+	})
+
+	checked := 0
+	for i, src := range routers {
+		for _, dst := range routers[i+1:] {
+			if len(src.ListenerAddresses) == 0 && len(dst.ListenerAddresses) == 0 {
+				continue
+			}
+			checked++
+			if !linked[routerPair(stringz.OrEmpty(src.ID), stringz.OrEmpty(dst.ID))] {
+				srcName, dstName := stringz.OrEmpty(src.Name), stringz.OrEmpty(dst.Name)
+				self.add(networkCheckLinks, "LINK_MISSING", networkSeverityWarning, "router", stringz.OrEmpty(src.ID), srcName,
+					"no connected link between routers %s and %s", srcName, dstName)
+			}
+		}
+	}
+	return checked
+}
+
+func routerPair(a, b string) [2]string {
+	if a > b {
+		return [2]string{b, a}
+	}
+	return [2]string{a, b}
+}
+
+func (self *networkReport) checkTerminators(snapshot *networkSnapshot) int {
+	terminatorCount := map[string]int{}
+	for _, t := range snapshot.terminators {
+		terminatorCount[stringz.OrEmpty(t.ServiceID)]++
+	}
+
+	for _, svc := range snapshot.services {
+		id, name := stringz.OrEmpty(svc.ID), stringz.OrEmpty(svc.Name)
+		if terminatorCount[id] == 0 {
+			self.add(networkCheckTerminators, "SERVICE_NO_TERMINATORS", networkSeverityWarning, "service", id, name,
+				"service %s has no terminators", name)
+		}
+	}
+	return len(snapshot.services)
+}
+
+func (self *networkReport) checkPolicies(snapshot *networkSnapshot) int {
+	for _, policy := range snapshot.policies {
+		if len(policy.emptySides) > 0 {
+			self.add(networkCheckPolicies, "POLICY_GRANTS_NOTHING", networkSeverityWarning, policy.entityType, policy.id, policy.name,
+				"%s %s grants nothing, it matches no %s", policy.entityType, policy.name, strings.Join(policy.emptySides, " and no "))
+		}
+	}
+	return len(snapshot.policies)
+}
+
+func (self *networkReport) checkCerts(snapshot *networkSnapshot, now time.Time, warnPeriod time.Duration) int {
+	checked := 0
+	for _, source := range snapshot.certs {
+		rest := []byte(source.certPem)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+
+			checked++
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				self.add(networkCheckCerts, "CERT_INVALID", networkSeverityWarning, source.entityType, source.id, source.name,
+					"unable to parse certificate for %s %s (%v)", source.entityType, source.name, err)
+				continue
+			}
+
+			if now.After(cert.NotAfter) {
+				self.add(networkCheckCerts, "CERT_EXPIRED", networkSeverityError, source.entityType, source.id, source.name,
+					"certificate %s for %s %s expired on %s", cert.Subject.CommonName, source.entityType, source.name,
+					cert.NotAfter.UTC().Format(time.DateOnly))
+			} else if remaining := cert.NotAfter.Sub(now); remaining < warnPeriod {
+				self.add(networkCheckCerts, "CERT_EXPIRING", networkSeverityWarning, source.entityType, source.id, source.name,
+					"certificate %s for %s %s expires in %d days, on %s", cert.Subject.CommonName, source.entityType, source.name,
+					int(remaining.Hours()/24), cert.NotAfter.UTC().Format(time.DateOnly))
+			}
+		}
+	}
+	return checked
+}
+
+func (self *networkReport) print() {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleRounded)
+	t.AppendHeader(table.Row{"Check", "Score", "Checked", "Errors", "Warnings"})
+	for _, result := range self.Checks {
+		t.AppendRow(table.Row{result.Name, result.Score, result.Checked, result.Errors, result.Warnings})
+	}
+	fmt.Println(t.Render())
+
+	if len(self.Findings) > 0 {
+		t = table.NewWriter()
+		t.SetStyle(table.StyleRounded)
+		t.AppendHeader(table.Row{"Severity", "Check", "Code", "Message"})
+		for _, finding := range self.Findings {
+			t.AppendRow(table.Row{finding.Severity, finding.Check, finding.Code, finding.Message})
+		}
+		fmt.Println(t.Render())
+	}
+
+	fmt.Printf("network score: %d/100\n", self.Score)
+}
@@ -0,0 +1,157 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fabric
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/openziti/ziti/controller/rest_model"
+	"github.com/stretchr/testify/require"
+)
+
+func testRouter(id string, connected bool, listening bool) *rest_model.RouterDetail {
+	result := &rest_model.RouterDetail{
+		BaseEntity: rest_model.BaseEntity{ID: &id},
+		Name:       &id,
+		Connected:  &connected,
+	}
+	if listening {
+		addr := "tls:" + id + ":6000"
+		result.ListenerAddresses = []*rest_model.RouterListener{{Address: &addr}}
+	}
+	return result
+}
+
+func testLink(src, dst, state string) *rest_model.LinkDetail {
+	down := false
+	return &rest_model.LinkDetail{
+		SourceRouter: &rest_model.EntityRef{ID: src},
+		DestRouter:   &rest_model.EntityRef{ID: dst},
+		State:        &state,
+		Down:         &down,
+	}
+}
+
+func testCertPem(t *testing.T, cn string, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func findingCodes(report *networkReport, check string) []string {
+	var result []string
+	for _, finding := range report.Findings {
+		if finding.Check == check {
+			result = append(result, finding.Code+":"+finding.EntityId)
+		}
+	}
+	return result
+}
+
+func TestNetworkReport(t *testing.T) {
+	req := require.New(t)
+	now := time.Now()
+
+	svc1, svc2 := "svc1", "svc2"
+	snapshot := &networkSnapshot{
+		routers: []*rest_model.RouterDetail{
+			testRouter("r1", true, true),
+			testRouter("r2", true, true),
+			testRouter("r3", true, false),
+			testRouter("r4", false, true),
+		},
+		links: []*rest_model.LinkDetail{
+			testLink("r1", "r2", "Connected"),
+			testLink("r3", "r1", "Connected"),
+			testLink("r3", "r2", "Pending"),
+		},
+		services: []*rest_model.ServiceDetail{
+			{BaseEntity: rest_model.BaseEntity{ID: &svc1}, Name: &svc1},
+			{BaseEntity: rest_model.BaseEntity{ID: &svc2}, Name: &svc2},
+		},
+		terminators: []*rest_model.TerminatorDetail{
+			{ServiceID: &svc1},
+		},
+		policies: []*policyCoverage{
+			{entityType: "service-policy", id: "sp1", name: "sp1"},
+			{entityType: "service-policy", id: "sp2", name: "sp2", emptySides: []string{"identities"}},
+		},
+		certs: []*certSource{
+			{entityType: "controller", id: "c1", name: "c1", certPem: testCertPem(t, "c1", now.Add(365*24*time.Hour))},
+			{entityType: "ca", id: "ca1", name: "ca1", certPem: testCertPem(t, "ca1", now.Add(10*24*time.Hour))},
+			{entityType: "identity", id: "i1", name: "i1", certPem: testCertPem(t, "i1", now.Add(-time.Hour))},
+		},
+		loadErrors: map[string]error{},
+	}
+
+	report := newNetworkReport(snapshot, networkChecks, now, 30*24*time.Hour)
+
+	req.Equal([]string{"ROUTER_UNREACHABLE:r4"}, findingCodes(report, networkCheckRouters))
+	// r3 has no listener, but r2 does, so they should be linked. r4 isn't connected, so isn't expected to have links.
+	req.Equal([]string{"LINK_MISSING:r2"}, findingCodes(report, networkCheckLinks))
+	req.Equal([]string{"SERVICE_NO_TERMINATORS:svc2"}, findingCodes(report, networkCheckTerminators))
+	req.Equal([]string{"POLICY_GRANTS_NOTHING:sp2"}, findingCodes(report, networkCheckPolicies))
+	req.Equal([]string{"CERT_EXPIRING:ca1", "CERT_EXPIRED:i1"}, findingCodes(report, networkCheckCerts))
+
+	req.Len(report.Checks, 5)
+	scores := map[string]int{}
+	for _, result := range report.Checks {
+		scores[result.Name] = result.Score
+	}
+	req.Equal(75, scores[networkCheckRouters])
+	req.Equal(3, report.Checks[1].Checked)
+	req.Equal(95, scores[networkCheckLinks])
+	req.Equal(70, scores[networkCheckCerts])
+	req.Equal((75+95+95+95+70)/5, report.Score)
+	req.True(report.hasErrors())
+}
+
+func TestNetworkReportSelectedChecksAndLoadErrors(t *testing.T) {
+	req := require.New(t)
+
+	snapshot := &networkSnapshot{
+		loadErrors: map[string]error{
+			networkCheckPolicies: errors.New("edge api not available"),
+		},
+	}
+
+	report := newNetworkReport(snapshot, []string{networkCheckTerminators, networkCheckPolicies}, time.Now(), time.Hour)
+	req.Len(report.Checks, 2)
+	req.Equal(networkCheckTerminators, report.Checks[0].Name)
+	req.Equal(100, report.Checks[0].Score)
+	req.Equal([]string{"CHECK_FAILED:"}, findingCodes(report, networkCheckPolicies))
+	req.Equal(75, report.Checks[1].Score)
+	req.Equal(87, report.Score)
+}
@@ -0,0 +1,75 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package lets_encrypt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/challenge/http01"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/providers/dns/exec"
+	"github.com/spf13/cobra"
+)
+
+const (
+	challengeHttp01 = "http-01"
+	challengeDns01  = "dns-01"
+)
+
+func (options *leOptions) addChallengeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&options.port, "port", "o", "80", "Port to listen on for HTTP based ACME challenges")
+	cmd.Flags().StringVar(&options.challenge, "challenge", challengeHttp01, "ACME challenge type to use, one of: http-01, dns-01")
+	cmd.Flags().StringVar(&options.dnsExec, "dns-exec", "", "Program to run to present and clean up dns-01 TXT records. "+
+		"It's invoked as '<program> present|cleanup <fqdn> <value>'. If not set, the TXT record must be created manually when prompted")
+	cmd.Flags().DurationVar(&options.dnsTimeout, "dns-timeout", 2*time.Minute, "How long to wait for dns-01 TXT records to propagate")
+	cmd.Flags().StringSliceVar(&options.dnsResolvers, "dns-resolvers", nil, "DNS servers (host:port) used to check dns-01 TXT record propagation")
+}
+
+// setupChallenges configures the challenge provider selected by the --challenge flag. Commands which don't register
+// the challenge flags fall back to http-01, matching the original behavior of the 'pki le' commands.
+func setupChallenges(options *leOptions, client *lego.Client) error {
+	switch options.challenge {
+	case "", challengeHttp01:
+		return client.Challenge.SetHTTP01Provider(http01.NewProviderServer("", options.port))
+	case challengeDns01:
+		var challengeOptions []dns01.ChallengeOption
+		if len(options.dnsResolvers) > 0 {
+			challengeOptions = append(challengeOptions, dns01.AddRecursiveNameservers(dns01.ParseNameservers(options.dnsResolvers)))
+		}
+
+		if options.dnsExec == "" {
+			provider, err := dns01.NewDNSProviderManual()
+			if err != nil {
+				return err
+			}
+			return client.Challenge.SetDNS01Provider(provider, challengeOptions...)
+		}
+
+		config := exec.NewDefaultConfig()
+		config.Program = options.dnsExec
+		config.PropagationTimeout = options.dnsTimeout
+		provider, err := exec.NewDNSProviderConfig(config)
+		if err != nil {
+			return err
+		}
+		return client.Challenge.SetDNS01Provider(provider, challengeOptions...)
+	default:
+		return fmt.Errorf("unsupported challenge type '%v', must be one of: %v, %v", options.challenge, challengeHttp01, challengeDns01)
+	}
+}
@@ -18,7 +18,6 @@ package lets_encrypt
 
 import (
 	"crypto"
-	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/registration"
 	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
@@ -82,8 +81,9 @@ func newCreateCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	if err := cmd.MarkFlagRequired("path"); err != nil {
 		panic(err)
 	}
-	cmd.Flags().StringVarP(&options.port, "port", "o", "80", "Port to listen on for HTTP based ACME challenges")
 	cmd.Flags().StringVarP(&options.csr, "csr", "", "", "Certificate Signing Request filename, if an external CSR is to be used")
+	options.addChallengeFlags(cmd)
+	options.addPkiFlags(cmd)
 
 	return cmd
 }
@@ -110,12 +110,7 @@ func runCreate(options *leOptions) (err error) {
 		}
 	}
 
-	request := certificate.ObtainRequest{
-		Domains: []string{options.domain},
-		Bundle:  true,
-	}
-
-	certificates, err := client.Certificate.Obtain(request)
+	certificates, err := obtain(options, client, []string{options.domain}, nil)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
@@ -126,7 +121,7 @@ func runCreate(options *leOptions) (err error) {
 
 	certsStorage.SaveResource(certificates)
 
-	return nil
+	return installToPki(options, certificates)
 }
 
 func register(options *leOptions, client *lego.Client) (*registration.Resource, error) {
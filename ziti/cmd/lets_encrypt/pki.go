@@ -0,0 +1,184 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package lets_encrypt
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/openziti/ziti/ziti/internal/log"
+	"github.com/openziti/ziti/ziti/pki/store"
+	"github.com/spf13/cobra"
+)
+
+var oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+
+func (options *leOptions) addPkiFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&options.pkiRoot, "pki-root", "", "If set, the issued certificate is also installed into the 'ziti pki' tree in this directory")
+	cmd.Flags().StringVar(&options.caName, "ca-name", "intermediate", "Name of the CA (within --pki-root) to install server certificates under. Not used with --intermediate")
+	cmd.Flags().StringVar(&options.pkiName, "pki-name", "", "Name of the certificate files within --pki-root. Defaults to the domain")
+	cmd.Flags().BoolVar(&options.intermediate, "intermediate", false, "Request an intermediate CA certificate. The ACME server must be configured to issue CA certificates (e.g. a step-ca provisioner template)")
+}
+
+// obtain requests a certificate for the given domains. Server certificates use a regular ACME order. Intermediates
+// are requested with a CSR carrying a CA basic constraint, since ACME itself has no notion of CA certificates.
+func obtain(options *leOptions, client *lego.Client, domains []string, privateKey crypto.PrivateKey) (*certificate.Resource, error) {
+	if !options.intermediate {
+		return client.Certificate.Obtain(certificate.ObtainRequest{
+			Domains:    domains,
+			PrivateKey: privateKey,
+			Bundle:     true,
+		})
+	}
+
+	if privateKey == nil {
+		var err error
+		if privateKey, err = certcrypto.GeneratePrivateKey(options.keyType.Get()); err != nil {
+			return nil, err
+		}
+	}
+
+	csr, err := newIntermediateCsr(domains, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	certRes, err := client.Certificate.ObtainForCSR(certificate.ObtainForCSRRequest{
+		CSR:        csr,
+		PrivateKey: privateKey,
+		Bundle:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := certcrypto.ParsePEMBundle(certRes.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	if !certs[0].IsCA {
+		return nil, fmt.Errorf("ACME server at %v issued a non-CA certificate for %v, check that it's configured to issue intermediates", options.acmeserver, domains[0])
+	}
+
+	if certRes.PrivateKey == nil {
+		certRes.PrivateKey = certcrypto.PEMEncode(privateKey)
+	}
+
+	return certRes, nil
+}
+
+func newIntermediateCsr(domains []string, privateKey crypto.PrivateKey) (*x509.CertificateRequest, error) {
+	basicConstraints, err := asn1.Marshal(struct {
+		IsCA bool `asn1:"optional"`
+	}{IsCA: true})
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidExtensionBasicConstraints, Critical: true, Value: basicConstraints},
+		},
+	}
+
+	der, err := x509.CreateCertificateRequest(nil, template, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificateRequest(der)
+}
+
+// installToPki writes an issued certificate into the 'ziti pki' directory layout, so it can be used in the same way
+// as certificates created with 'ziti pki create'. Intermediates get their own CA directory, so they can be used to
+// sign server and client certificates with 'ziti pki create server --ca-name <name>'.
+func installToPki(options *leOptions, certRes *certificate.Resource) error {
+	if options.pkiRoot == "" {
+		return nil
+	}
+
+	name := options.pkiName
+	if name == "" {
+		name = sanitizedDomain(certRes.Domain)
+	}
+
+	caName := options.caName
+	if options.intermediate {
+		caName = name
+	}
+
+	caDir := filepath.Join(options.pkiRoot, caName)
+	if _, err := os.Stat(caDir); err != nil {
+		if err = store.InitCADir(caDir); err != nil {
+			return fmt.Errorf("unable to create CA directory %v: %w", caDir, err)
+		}
+	}
+
+	certs, err := certcrypto.ParsePEMBundle(certRes.Certificate)
+	if err != nil {
+		return err
+	}
+
+	if certRes.PrivateKey == nil {
+		return fmt.Errorf("no private key available for %v, certificates issued for an external CSR can't be installed into the PKI", certRes.Domain)
+	}
+
+	privateKey, err := certcrypto.ParsePEMPrivateKey(certRes.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+
+	keyPath := filepath.Join(caDir, store.LocalKeysDir, name+".key")
+	if err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), filePerm); err != nil {
+		return err
+	}
+
+	certPath := filepath.Join(caDir, store.LocalCertsDir, name+".cert")
+	if err = os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw}), 0644); err != nil {
+		return err
+	}
+
+	chain := certRes.Certificate
+	if len(certs) == 1 && certRes.IssuerCertificate != nil {
+		chain = append(append([]byte{}, certRes.Certificate...), certRes.IssuerCertificate...)
+	}
+
+	chainPath := filepath.Join(caDir, store.LocalCertsDir, name+".chain.pem")
+	if err = os.WriteFile(chainPath, chain, 0644); err != nil {
+		return err
+	}
+
+	log.Infof("certificate installed into PKI at %v", caDir)
+	return nil
+}
@@ -20,7 +20,6 @@ import (
 	"crypto"
 	"crypto/x509"
 	"github.com/go-acme/lego/v4/certcrypto"
-	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/lego"
 	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
 	"github.com/openziti/ziti/ziti/internal/log"
@@ -72,6 +71,8 @@ func newRenewCmd(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.Flags().VarP(&options.keyType, "keytype", "k", "Key type to use for private keys")
 	cmd.Flags().StringVarP(&options.acmeserver, "acmeserver", "a", acmeProd, "ACME CA hostname")
 	cmd.Flags().BoolVarP(&options.staging, "staging", "s", false, "Enable creation of 'staging' Certs (instead of production Certs)")
+	options.addChallengeFlags(cmd)
+	options.addPkiFlags(cmd)
 
 	return cmd
 }
@@ -110,7 +111,7 @@ func renewForDomains(options *leOptions, client *lego.Client, certsStorage *Cert
 
 	cert := certificates[0]
 
-	if !needRenewal(cert, domain, options.days) {
+	if !needRenewal(cert, domain, options.days, options.intermediate) {
 		return nil
 	}
 
@@ -133,23 +134,19 @@ func renewForDomains(options *leOptions, client *lego.Client, certsStorage *Cert
 		}
 	}
 
-	request := certificate.ObtainRequest{
-		Domains:    merge(certDomains, domain),
-		PrivateKey: privateKey,
-	}
-	certRes, err := client.Certificate.Obtain(request)
+	certRes, err := obtain(options, client, merge(certDomains, domain), privateKey)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
 
 	certsStorage.SaveResource(certRes)
 
-	return nil
+	return installToPki(options, certRes)
 
 }
 
-func needRenewal(x509Cert *x509.Certificate, domain string, days int) bool {
-	if x509Cert.IsCA {
+func needRenewal(x509Cert *x509.Certificate, domain string, days int, intermediate bool) bool {
+	if x509Cert.IsCA && !intermediate {
 		log.Fatalf("[%s] Certificate bundle starts with a CA certificate", domain)
 	}
 
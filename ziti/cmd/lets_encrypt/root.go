@@ -19,6 +19,7 @@ package lets_encrypt
 import (
 	"github.com/openziti/ziti/ziti/util"
 	"io"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -52,6 +53,16 @@ type leOptions struct {
 	port       string
 	csr        string
 	days       int
+
+	challenge    string
+	dnsExec      string
+	dnsTimeout   time.Duration
+	dnsResolvers []string
+
+	pkiRoot      string
+	caName       string
+	pkiName      string
+	intermediate bool
 }
 
 // type leFlags struct {
@@ -21,7 +21,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/go-acme/lego/v4/challenge/http01"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/registration"
 )
@@ -39,8 +38,7 @@ func setup(options *leOptions, accountsStorage *AccountsStorage) (*Account, *leg
 
 	client := newClient(options, account)
 
-	err := client.Challenge.SetHTTP01Provider(http01.NewProviderServer("", options.port))
-	if err != nil {
+	if err := setupChallenges(options, client); err != nil {
 		log.Fatalf("%v", err)
 	}
 
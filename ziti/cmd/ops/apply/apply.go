@@ -0,0 +1,196 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/openziti/ziti/ziti/cmd/ops/snapshot"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var errSystemEntity = errors.New("system entities can't be deleted")
+
+type applyOptions struct {
+	api.Options
+	file   string
+	prune  bool
+	dryRun bool
+}
+
+// NewApplyCmd creates the command which reconciles the controller with a declarative network description
+func NewApplyCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &applyOptions{
+		Options: api.Options{
+			CommonOptions: common.CommonOptions{
+				Out: out,
+				Err: errOut,
+			},
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Reconciles the controller with a declarative description of the network",
+		Long: "Creates and updates configs, services, identities and policies so the controller matches the given file. " +
+			"The file uses the same layout as ziti ops export, in YAML or JSON, with entities referencing each other by name. " +
+			"Only the entity types present in the file are reconciled, and fields which aren't given are left unchanged. " +
+			"Entities which aren't in the file are only deleted if --prune is given. Uses the current ziti edge login.",
+		Example: "ziti ops apply -f network.yaml --dry-run\nziti ops apply -f network.yaml --prune",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			cmdhelper.CheckErr(options.run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.file, "file", "f", "", "File containing the desired network state")
+	cmd.Flags().BoolVar(&options.prune, "prune", false, "Delete entities of the types in the file which aren't declared in it")
+	cmd.Flags().BoolVar(&options.dryRun, "dry-run", false, "Show the changes which would be made, without making them")
+	options.AddCommonFlags(cmd)
+	_ = cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func (self *applyOptions) run() error {
+	raw, err := os.ReadFile(self.file)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read %s", self.file)
+	}
+
+	// YAML is a superset of JSON, so this handles both
+	data := map[string]interface{}{}
+	if err = yaml.Unmarshal(raw, &data); err != nil {
+		return errors.Wrapf(err, "unable to parse %s", self.file)
+	}
+
+	desired, err := ParseDesiredState(data)
+	if err != nil {
+		return err
+	}
+
+	client, err := util.NewEdgeManagementClient(self)
+	if err != nil {
+		return err
+	}
+
+	var exportTypes []string
+	for entityType := range desired.Entities {
+		exportTypes = append(exportTypes, exportNames[entityType])
+	}
+	sort.Strings(exportTypes)
+
+	if len(exportTypes) == 0 {
+		_, err = fmt.Fprintf(self.Out, "no entities declared in %s\n", self.file)
+		return err
+	}
+
+	progressOut := io.Discard
+	if self.Verbose {
+		progressOut = self.Err
+	}
+
+	live, err := snapshot.Capture(client, progressOut, "live", "", exportTypes...)
+	if err != nil {
+		return err
+	}
+
+	plan := NewPlan(live, desired, self.prune)
+	if len(plan) == 0 {
+		_, err = fmt.Fprintln(self.Out, "no changes required")
+		return err
+	}
+
+	if self.dryRun {
+		for _, action := range plan {
+			if err = self.printAction(action); err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(self.Out, "\n%d changes required, none made (dry run)\n", len(plan))
+		return err
+	}
+
+	exec := newExecutor(client)
+	applied := 0
+	for _, action := range plan {
+		if err = exec.execute(action); err != nil {
+			if errors.Is(err, errSystemEntity) {
+				_, err = fmt.Fprintf(self.Out, "  skipping delete of system %s %s\n", action.EntityType, action.Name)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			return errors.Wrapf(err, "unable to %s %s %s, %d of %d changes applied",
+				action.Op, action.EntityType, action.Name, applied, len(plan))
+		}
+		applied++
+		if err = self.printAction(action); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(self.Out, "\n%d changes applied\n", applied)
+	return err
+}
+
+func (self *applyOptions) printAction(action *Action) error {
+	var prefix string
+	switch action.Op {
+	case OpCreate:
+		prefix = "+"
+	case OpDelete:
+		prefix = "-"
+	default:
+		prefix = "~"
+	}
+
+	if _, err := fmt.Fprintf(self.Out, "%s %s %s %s\n", prefix, action.Op, action.EntityType, action.Name); err != nil {
+		return err
+	}
+
+	for _, field := range action.Fields {
+		if _, err := fmt.Fprintf(self.Out, "    %s: %s -> %s\n", field.Path, formatValue(field.Old), formatValue(field.New)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,345 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package apply
+
+import (
+	"github.com/openziti/edge-api/rest_management_api_client"
+	"github.com/openziti/edge-api/rest_management_api_client/config"
+	"github.com/openziti/edge-api/rest_management_api_client/edge_router_policy"
+	"github.com/openziti/edge-api/rest_management_api_client/identity"
+	"github.com/openziti/edge-api/rest_management_api_client/service"
+	"github.com/openziti/edge-api/rest_management_api_client/service_edge_router_policy"
+	"github.com/openziti/edge-api/rest_management_api_client/service_policy"
+	"github.com/openziti/edge-api/rest_model"
+	"github.com/openziti/ziti/internal/rest/mgmt"
+	"github.com/openziti/ziti/ziti/cmd/ascode/importer"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	refTypeConfigTypes   = "configTypes"
+	refTypeEdgeRouters   = "edgeRouters"
+	refTypePostureChecks = "postureChecks"
+	refTypeAuthPolicies  = "authPolicies"
+)
+
+// roleFields maps the role fields of each entity type to the type of entity the roles reference
+var roleFields = map[string]map[string]string{
+	EntityTypeServicePolicies: {
+		"identityRoles":     EntityTypeIdentities,
+		"serviceRoles":      EntityTypeServices,
+		"postureCheckRoles": refTypePostureChecks,
+	},
+	EntityTypeEdgeRouterPolicies: {
+		"identityRoles":   EntityTypeIdentities,
+		"edgeRouterRoles": refTypeEdgeRouters,
+	},
+	EntityTypeServiceEdgeRouterPolicies: {
+		"serviceRoles":    EntityTypeServices,
+		"edgeRouterRoles": refTypeEdgeRouters,
+	},
+}
+
+// executor carries out plan actions against the controller. Entities reference each other by name in the desired
+// state, so the executor translates names to ids, remembering the ids of entities as they're created.
+type executor struct {
+	client *rest_management_api_client.ZitiEdgeManagement
+	ids    map[string]map[string]string
+}
+
+func newExecutor(client *rest_management_api_client.ZitiEdgeManagement) *executor {
+	return &executor{
+		client: client,
+		ids:    map[string]map[string]string{},
+	}
+}
+
+func (self *executor) execute(action *Action) error {
+	if action.Op == OpDelete {
+		return self.delete(action)
+	}
+
+	entity, err := self.resolveReferences(action.EntityType, action.Entity)
+	if err != nil {
+		return err
+	}
+
+	if action.Op == OpCreate {
+		id, err := self.create(action.EntityType, entity)
+		if err != nil {
+			return util.WrapIfApiError(err)
+		}
+		self.setId(action.EntityType, action.Name, id)
+		return nil
+	}
+
+	if action.EntityType == EntityTypeConfigs {
+		for _, field := range action.Fields {
+			if field.Path == "configType" {
+				return errors.New("the config type of a config can't be changed, the config must be deleted and recreated")
+			}
+		}
+	}
+
+	id, err := self.lookupId(action.EntityType, action.Name)
+	if err != nil {
+		return err
+	}
+	return util.WrapIfApiError(self.update(action.EntityType, id, entity))
+}
+
+func (self *executor) create(entityType string, entity map[string]interface{}) (string, error) {
+	switch entityType {
+	case EntityTypeConfigs:
+		resp, err := self.client.Config.CreateConfig(&config.CreateConfigParams{Config: importer.FromMap(entity, rest_model.ConfigCreate{})}, nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Payload.Data.ID, nil
+	case EntityTypeServices:
+		resp, err := self.client.Service.CreateService(&service.CreateServiceParams{Service: importer.FromMap(entity, rest_model.ServiceCreate{})}, nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Payload.Data.ID, nil
+	case EntityTypeIdentities:
+		resp, err := self.client.Identity.CreateIdentity(&identity.CreateIdentityParams{Identity: importer.FromMap(entity, rest_model.IdentityCreate{})}, nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Payload.Data.ID, nil
+	case EntityTypeEdgeRouterPolicies:
+		resp, err := self.client.EdgeRouterPolicy.CreateEdgeRouterPolicy(&edge_router_policy.CreateEdgeRouterPolicyParams{Policy: importer.FromMap(entity, rest_model.EdgeRouterPolicyCreate{})}, nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Payload.Data.ID, nil
+	case EntityTypeServiceEdgeRouterPolicies:
+		resp, err := self.client.ServiceEdgeRouterPolicy.CreateServiceEdgeRouterPolicy(&service_edge_router_policy.CreateServiceEdgeRouterPolicyParams{Policy: importer.FromMap(entity, rest_model.ServiceEdgeRouterPolicyCreate{})}, nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Payload.Data.ID, nil
+	case EntityTypeServicePolicies:
+		resp, err := self.client.ServicePolicy.CreateServicePolicy(&service_policy.CreateServicePolicyParams{Policy: importer.FromMap(entity, rest_model.ServicePolicyCreate{})}, nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Payload.Data.ID, nil
+	}
+	return "", errors.Errorf("unsupported entity type '%s'", entityType)
+}
+
+func (self *executor) update(entityType string, id string, entity map[string]interface{}) error {
+	var err error
+	switch entityType {
+	case EntityTypeConfigs:
+		_, err = self.client.Config.UpdateConfig(&config.UpdateConfigParams{ID: id, Config: importer.FromMap(entity, rest_model.ConfigUpdate{})}, nil)
+	case EntityTypeServices:
+		_, err = self.client.Service.UpdateService(&service.UpdateServiceParams{ID: id, Service: importer.FromMap(entity, rest_model.ServiceUpdate{})}, nil)
+	case EntityTypeIdentities:
+		_, err = self.client.Identity.UpdateIdentity(&identity.UpdateIdentityParams{ID: id, Identity: importer.FromMap(entity, rest_model.IdentityUpdate{})}, nil)
+	case EntityTypeEdgeRouterPolicies:
+		_, err = self.client.EdgeRouterPolicy.UpdateEdgeRouterPolicy(&edge_router_policy.UpdateEdgeRouterPolicyParams{ID: id, Policy: importer.FromMap(entity, rest_model.EdgeRouterPolicyUpdate{})}, nil)
+	case EntityTypeServiceEdgeRouterPolicies:
+		_, err = self.client.ServiceEdgeRouterPolicy.UpdateServiceEdgeRouterPolicy(&service_edge_router_policy.UpdateServiceEdgeRouterPolicyParams{ID: id, Policy: importer.FromMap(entity, rest_model.ServiceEdgeRouterPolicyUpdate{})}, nil)
+	case EntityTypeServicePolicies:
+		_, err = self.client.ServicePolicy.UpdateServicePolicy(&service_policy.UpdateServicePolicyParams{ID: id, Policy: importer.FromMap(entity, rest_model.ServicePolicyUpdate{})}, nil)
+	default:
+		err = errors.Errorf("unsupported entity type '%s'", entityType)
+	}
+	return err
+}
+
+func (self *executor) delete(action *Action) error {
+	if action.EntityType == EntityTypeEdgeRouterPolicies {
+		policy := mgmt.EdgeRouterPolicyFromFilter(self.client, mgmt.NameFilter(action.Name))
+		if policy != nil && policy.IsSystem != nil && *policy.IsSystem {
+			return errSystemEntity
+		}
+	}
+
+	id, err := self.lookupId(action.EntityType, action.Name)
+	if err != nil {
+		return err
+	}
+
+	switch action.EntityType {
+	case EntityTypeConfigs:
+		_, err = self.client.Config.DeleteConfig(&config.DeleteConfigParams{ID: id}, nil)
+	case EntityTypeServices:
+		_, err = self.client.Service.DeleteService(&service.DeleteServiceParams{ID: id}, nil)
+	case EntityTypeIdentities:
+		_, err = self.client.Identity.DeleteIdentity(&identity.DeleteIdentityParams{ID: id}, nil)
+	case EntityTypeEdgeRouterPolicies:
+		_, err = self.client.EdgeRouterPolicy.DeleteEdgeRouterPolicy(&edge_router_policy.DeleteEdgeRouterPolicyParams{ID: id}, nil)
+	case EntityTypeServiceEdgeRouterPolicies:
+		_, err = self.client.ServiceEdgeRouterPolicy.DeleteServiceEdgeRouterPolicy(&service_edge_router_policy.DeleteServiceEdgeRouterPolicyParams{ID: id}, nil)
+	case EntityTypeServicePolicies:
+		_, err = self.client.ServicePolicy.DeleteServicePolicy(&service_policy.DeleteServicePolicyParams{ID: id}, nil)
+	default:
+		err = errors.Errorf("unsupported entity type '%s'", action.EntityType)
+	}
+	return util.WrapIfApiError(err)
+}
+
+// resolveReferences returns a copy of the entity, with references to other entities by name replaced with ids, in
+// the form the management API expects
+func (self *executor) resolveReferences(entityType string, entity map[string]interface{}) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	for k, v := range entity {
+		result[k] = v
+	}
+
+	switch entityType {
+	case EntityTypeConfigs:
+		configTypeId, err := self.lookupRef(refTypeConfigTypes, result["configType"])
+		if err != nil {
+			return nil, err
+		}
+		delete(result, "configType")
+		result["configTypeId"] = configTypeId
+	case EntityTypeServices:
+		var configIds []string
+		configs, _ := result["configs"].([]interface{})
+		for _, configRef := range configs {
+			configId, err := self.lookupRef(EntityTypeConfigs, configRef)
+			if err != nil {
+				return nil, err
+			}
+			configIds = append(configIds, configId)
+		}
+		result["configs"] = configIds
+	case EntityTypeIdentities:
+		if authPolicyRef, found := result["authPolicy"]; found {
+			authPolicyId, err := self.lookupRef(refTypeAuthPolicies, authPolicyRef)
+			if err != nil {
+				return nil, err
+			}
+			delete(result, "authPolicy")
+			result["authPolicyId"] = authPolicyId
+		}
+		if _, found := result["isAdmin"]; !found {
+			result["isAdmin"] = false
+		}
+		if _, found := result["type"]; !found {
+			result["type"] = rest_model.IdentityTypeDefault
+			if typeId, _ := result["typeId"].(string); typeId != "" {
+				result["type"] = typeId
+			}
+		}
+	}
+
+	for field, refType := range roleFields[entityType] {
+		roles, _ := result[field].([]interface{})
+		resolved := []string{}
+		for _, role := range roles {
+			roleStr, _ := role.(string)
+			if len(roleStr) > 1 && roleStr[0] == '@' {
+				id, err := self.lookupId(refType, roleStr[1:])
+				if err != nil {
+					return nil, err
+				}
+				roleStr = "@" + id
+			}
+			resolved = append(resolved, roleStr)
+		}
+		result[field] = resolved
+	}
+
+	return result, nil
+}
+
+// lookupRef resolves a reference of the form @name to an id
+func (self *executor) lookupRef(entityType string, ref interface{}) (string, error) {
+	refStr, _ := ref.(string)
+	if len(refStr) < 2 || refStr[0] != '@' {
+		return "", errors.Errorf("invalid %s reference '%v', references must be of the form @name", entityType, ref)
+	}
+	return self.lookupId(entityType, refStr[1:])
+}
+
+func (self *executor) setId(entityType string, name string, id string) {
+	ids, found := self.ids[entityType]
+	if !found {
+		ids = map[string]string{}
+		self.ids[entityType] = ids
+	}
+	ids[name] = id
+}
+
+func (self *executor) lookupId(entityType string, name string) (string, error) {
+	if id, found := self.ids[entityType][name]; found {
+		return id, nil
+	}
+
+	filter := mgmt.NameFilter(name)
+	var id *string
+
+	switch entityType {
+	case EntityTypeConfigs:
+		if detail := mgmt.ConfigFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	case EntityTypeServices:
+		if detail := mgmt.ServiceFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	case EntityTypeIdentities:
+		if detail := mgmt.IdentityFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	case EntityTypeEdgeRouterPolicies:
+		if detail := mgmt.EdgeRouterPolicyFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	case EntityTypeServiceEdgeRouterPolicies:
+		if detail := mgmt.ServiceEdgeRouterPolicyFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	case EntityTypeServicePolicies:
+		if detail := mgmt.ServicePolicyFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	case refTypeConfigTypes:
+		if detail := mgmt.ConfigTypeFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	case refTypeEdgeRouters:
+		if detail := mgmt.EdgeRouterFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	case refTypePostureChecks:
+		if detail := mgmt.PostureCheckFromFilter(self.client, filter); detail != nil {
+			id = (*detail).ID()
+		}
+	case refTypeAuthPolicies:
+		if detail := mgmt.AuthPolicyFromFilter(self.client, filter); detail != nil {
+			id = detail.ID
+		}
+	default:
+		return "", errors.Errorf("unsupported entity type '%s'", entityType)
+	}
+
+	if id == nil {
+		return "", errors.Errorf("no %s found with name '%s'", entityType, name)
+	}
+
+	self.setId(entityType, name, *id)
+	return *id, nil
+}
@@ -0,0 +1,214 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package apply
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/openziti/ziti/ziti/cmd/ops/snapshot"
+	"github.com/pkg/errors"
+)
+
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+
+	EntityTypeConfigs                   = "configs"
+	EntityTypeServices                  = "services"
+	EntityTypeIdentities                = "identities"
+	EntityTypeEdgeRouterPolicies        = "edgeRouterPolicies"
+	EntityTypeServiceEdgeRouterPolicies = "serviceEdgeRouterPolicies"
+	EntityTypeServicePolicies           = "servicePolicies"
+)
+
+// entityTypes holds the entity types managed by apply, in the order they're created and updated, so that entities
+// exist before they're referenced. Deletes are done in the reverse order.
+var entityTypes = []string{
+	EntityTypeConfigs,
+	EntityTypeServices,
+	EntityTypeIdentities,
+	EntityTypeEdgeRouterPolicies,
+	EntityTypeServiceEdgeRouterPolicies,
+	EntityTypeServicePolicies,
+}
+
+// exportNames maps the managed entity types to the names the exporter uses to select them
+var exportNames = map[string]string{
+	EntityTypeConfigs:                   "config",
+	EntityTypeServices:                  "service",
+	EntityTypeIdentities:                "identity",
+	EntityTypeEdgeRouterPolicies:        "edge-router-policy",
+	EntityTypeServiceEdgeRouterPolicies: "service-edge-router-policy",
+	EntityTypeServicePolicies:           "service-policy",
+}
+
+// Action is a single change needed to bring the controller to the desired state. Entity holds the full entity to
+// create or update, with references to other entities given by name.
+type Action struct {
+	Op         string                  `json:"op"`
+	EntityType string                  `json:"entityType"`
+	Name       string                  `json:"name"`
+	Fields     []*snapshot.FieldChange `json:"fields,omitempty"`
+	Entity     map[string]interface{}  `json:"-"`
+}
+
+// ParseDesiredState builds a snapshot from a declarative network description, which uses the same layout as the
+// output of ziti ops export. Only the entity types managed by apply may be given.
+func ParseDesiredState(data map[string]interface{}) (*snapshot.Snapshot, error) {
+	for entityType := range data {
+		if _, found := exportNames[entityType]; !found {
+			return nil, errors.Errorf("unsupported entity type '%s', supported types are: %s",
+				entityType, strings.Join(entityTypes, ", "))
+		}
+	}
+
+	desired, err := snapshot.NewSnapshot("desired", "", data)
+	if err != nil {
+		return nil, err
+	}
+
+	for entityType, entities := range desired.Entities {
+		names := map[string]struct{}{}
+		for _, entity := range entities {
+			name, _ := entity["name"].(string)
+			if name == "" {
+				return nil, errors.Errorf("%s entries must have a name", entityType)
+			}
+			if _, found := names[name]; found {
+				return nil, errors.Errorf("%s '%s' is declared more than once", entityType, name)
+			}
+			names[name] = struct{}{}
+		}
+	}
+
+	return desired, nil
+}
+
+// NewPlan returns the actions which will reconcile the live state with the desired state. Only the entity types
+// present in the desired state are considered. Fields which aren't given in the desired state are left with their
+// current values. Entities missing from the desired state are only deleted if prune is set.
+func NewPlan(live, desired *snapshot.Snapshot, prune bool) []*Action {
+	var result []*Action
+
+	for _, change := range snapshot.Diff(live, desired) {
+		if _, managed := desired.Entities[change.EntityType]; !managed {
+			continue
+		}
+
+		liveEntity := live.Get(change.EntityType, change.Name)
+		desiredEntity := desired.Get(change.EntityType, change.Name)
+
+		if !isManaged(change.EntityType, liveEntity) || !isManaged(change.EntityType, desiredEntity) {
+			continue
+		}
+
+		switch change.Change {
+		case snapshot.ChangeAdded:
+			result = append(result, &Action{
+				Op:         OpCreate,
+				EntityType: change.EntityType,
+				Name:       change.Name,
+				Entity:     desiredEntity,
+			})
+		case snapshot.ChangeRemoved:
+			if prune {
+				result = append(result, &Action{
+					Op:         OpDelete,
+					EntityType: change.EntityType,
+					Name:       change.Name,
+				})
+			}
+		case snapshot.ChangeModified:
+			var fields []*snapshot.FieldChange
+			for _, field := range change.Fields {
+				if _, declared := desiredEntity[topLevelField(field.Path)]; declared && !(isEmpty(field.Old) && isEmpty(field.New)) {
+					fields = append(fields, field)
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+
+			entity := map[string]interface{}{}
+			for k, v := range liveEntity {
+				entity[k] = v
+			}
+			for k, v := range desiredEntity {
+				entity[k] = v
+			}
+
+			result = append(result, &Action{
+				Op:         OpUpdate,
+				EntityType: change.EntityType,
+				Name:       change.Name,
+				Fields:     fields,
+				Entity:     entity,
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return actionOrder(result[i]) < actionOrder(result[j])
+	})
+
+	return result
+}
+
+// actionOrder puts creates and updates first, ordered so that referenced entities come first, followed by deletes
+// in the reverse order
+func actionOrder(action *Action) int {
+	for idx, entityType := range entityTypes {
+		if entityType == action.EntityType {
+			if action.Op == OpDelete {
+				return 2*len(entityTypes) - idx
+			}
+			return idx
+		}
+	}
+	return 3 * len(entityTypes)
+}
+
+// isManaged returns false for entities which apply must leave alone, such as the default admin and router identities
+func isManaged(entityType string, entity map[string]interface{}) bool {
+	if entity == nil || entityType != EntityTypeIdentities {
+		return true
+	}
+	if isDefaultAdmin, _ := entity["isDefaultAdmin"].(bool); isDefaultAdmin {
+		return false
+	}
+	typeId, _ := entity["typeId"].(string)
+	return !strings.EqualFold(typeId, "router")
+}
+
+func topLevelField(path string) string {
+	if idx := strings.IndexByte(path, '.'); idx >= 0 {
+		return path[:idx]
+	}
+	return path
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	if list, ok := v.([]interface{}); ok {
+		return len(list) == 0
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/openziti/ziti/ziti/cmd/ops/snapshot"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLive(t *testing.T) *snapshot.Snapshot {
+	live, err := snapshot.NewSnapshot("live", "", map[string]interface{}{
+		"services": []map[string]interface{}{
+			{"name": "ssh", "roleAttributes": []string{"a"}, "configs": nil, "encryptionRequired": true},
+			{"name": "old", "roleAttributes": []string{}},
+		},
+		"servicePolicies": []map[string]interface{}{
+			{"name": "ssh-dial", "type": "Dial", "semantic": "AnyOf", "serviceRoles": []string{"@ssh"}, "identityRoles": []string{"#users"}},
+			{"name": "old-dial", "type": "Dial", "semantic": "AnyOf", "serviceRoles": []string{"@old"}, "identityRoles": []string{"#users"}},
+		},
+		"identities": []map[string]interface{}{
+			{"name": "admin", "isDefaultAdmin": true, "typeId": "Default"},
+			{"name": "router1", "typeId": "Router"},
+		},
+		"configs": []map[string]interface{}{
+			{"name": "unmanaged", "configType": "@host.v1"},
+		},
+	})
+	require.NoError(t, err)
+	return live
+}
+
+func TestNewPlan(t *testing.T) {
+	req := require.New(t)
+
+	desired, err := ParseDesiredState(map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{"name": "ssh", "roleAttributes": []interface{}{"b"}, "configs": []interface{}{}},
+			map[string]interface{}{"name": "web", "roleAttributes": []interface{}{"web"}},
+		},
+		"servicePolicies": []interface{}{
+			map[string]interface{}{"name": "ssh-dial", "type": "Dial", "semantic": "AnyOf", "serviceRoles": []interface{}{"@ssh"}, "identityRoles": []interface{}{"#users"}},
+		},
+		"identities": []interface{}{},
+	})
+	req.NoError(err)
+
+	live := newTestLive(t)
+
+	plan := NewPlan(live, desired, false)
+	req.Len(plan, 2)
+
+	req.Equal(OpUpdate, plan[0].Op)
+	req.Equal("ssh", plan[0].Name)
+	req.Len(plan[0].Fields, 1)
+	req.Equal("roleAttributes", plan[0].Fields[0].Path)
+	// fields not given in the desired state keep their current values
+	req.Equal(true, plan[0].Entity["encryptionRequired"])
+
+	req.Equal(OpCreate, plan[1].Op)
+	req.Equal("web", plan[1].Name)
+
+	plan = NewPlan(live, desired, true)
+	req.Len(plan, 4)
+	req.Equal(EntityTypeServices, plan[0].EntityType)
+	req.Equal(EntityTypeServices, plan[1].EntityType)
+
+	// policies are deleted before the services they reference, and the default admin and router identities are
+	// never pruned
+	req.Equal(OpDelete, plan[2].Op)
+	req.Equal(EntityTypeServicePolicies, plan[2].EntityType)
+	req.Equal("old-dial", plan[2].Name)
+	req.Equal(OpDelete, plan[3].Op)
+	req.Equal(EntityTypeServices, plan[3].EntityType)
+	req.Equal("old", plan[3].Name)
+}
+
+func TestParseDesiredState(t *testing.T) {
+	req := require.New(t)
+
+	_, err := ParseDesiredState(map[string]interface{}{
+		"postureChecks": []interface{}{},
+	})
+	req.ErrorContains(err, "unsupported entity type 'postureChecks'")
+
+	_, err = ParseDesiredState(map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{"name": "ssh"},
+			map[string]interface{}{"name": "ssh"},
+		},
+	})
+	req.ErrorContains(err, "declared more than once")
+
+	_, err = ParseDesiredState(map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{"roleAttributes": []interface{}{}},
+		},
+	})
+	req.ErrorContains(err, "must have a name")
+}
@@ -0,0 +1,133 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/openziti/ziti/controller/command"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type integrityCheckAction struct {
+	out       io.Writer
+	fix       bool
+	quick     bool
+	maxErrors int
+}
+
+func NewCheckIntegrityAction(out io.Writer) *cobra.Command {
+	action := &integrityCheckAction{out: out}
+
+	cmd := &cobra.Command{
+		Use:   "check </path/to/ziti-controller.db.file>",
+		Short: "Checks the referential integrity of a controller database file, controller must be shutdown",
+		Long: "Checks the referential integrity of a controller database file, reporting problems such as policies linked to " +
+			"entities which no longer exist, terminators for missing services or routers and index entries which don't " +
+			"match the data. The database isn't modified. Exits with a non-zero status if problems are found",
+		Args: cobra.ExactArgs(1),
+		RunE: action.run,
+	}
+
+	cmd.Flags().BoolVar(&action.quick, "quick", false, "Skip session and api session data, checking only configuration")
+	cmd.Flags().IntVar(&action.maxErrors, "max-errors", 1000, "Maximum number of problems to report")
+	return cmd
+}
+
+func NewRepairIntegrityAction(out io.Writer) *cobra.Command {
+	action := &integrityCheckAction{out: out, fix: true}
+
+	cmd := &cobra.Command{
+		Use:   "repair </path/to/ziti-controller.db.file>",
+		Short: "Repairs referential integrity problems in a controller database file, controller must be shutdown",
+		Long: "Checks the referential integrity of a controller database file and repairs the problems found. A snapshot " +
+			"of the database is made next to the database file before any changes are made",
+		Args: cobra.ExactArgs(1),
+		RunE: action.run,
+	}
+
+	cmd.Flags().IntVar(&action.maxErrors, "max-errors", 1000, "Maximum number of problems to report")
+	return cmd
+}
+
+func (self *integrityCheckAction) run(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	boltDb, err := db.Open(args[0])
+	if err != nil {
+		return errors.Wrapf(err, "unable to open database file '%s'", args[0])
+	}
+
+	defer func() {
+		_ = boltDb.Close()
+	}()
+
+	stores, err := db.InitStores(boltDb, command.NoOpRateLimiter{}, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize database stores")
+	}
+
+	problems := 0
+	fixed := 0
+	errorHandler := func(err error, wasFixed bool) {
+		problems++
+		if wasFixed {
+			fixed++
+		}
+		if problems > self.maxErrors {
+			return
+		}
+		if wasFixed {
+			_, _ = fmt.Fprintf(self.out, "fixed: %s\n", err.Error())
+		} else {
+			_, _ = fmt.Fprintf(self.out, "found: %s\n", err.Error())
+		}
+	}
+
+	if self.quick {
+		err = stores.QuickCheckIntegrity(boltDb, context.Background(), errorHandler)
+	} else {
+		err = stores.CheckIntegrity(boltDb, context.Background(), self.fix, errorHandler)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "integrity check failed")
+	}
+
+	if problems > self.maxErrors {
+		_, _ = fmt.Fprintf(self.out, "... %d more problems not shown\n", problems-self.maxErrors)
+	}
+
+	if problems == 0 {
+		_, _ = fmt.Fprintln(self.out, "no integrity problems found")
+		return nil
+	}
+
+	if self.fix {
+		_, _ = fmt.Fprintf(self.out, "found %d integrity problems, fixed %d\n", problems, fixed)
+		if fixed < problems {
+			return errors.Errorf("%d integrity problems could not be fixed", problems-fixed)
+		}
+		return nil
+	}
+
+	return errors.Errorf("found %d integrity problems, use 'ziti ops db repair' to fix them", problems)
+}
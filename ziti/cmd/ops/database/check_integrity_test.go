@@ -0,0 +1,116 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package database
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/openziti/storage/boltz"
+	"github.com/openziti/ziti/common/eid"
+	"github.com/openziti/ziti/controller/command"
+	"github.com/openziti/ziti/controller/db"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDb creates a migrated controller database with a single config. If corrupt is set, the config references
+// a config type which doesn't exist.
+func newTestDb(t *testing.T, corrupt bool) string {
+	req := require.New(t)
+	path := filepath.Join(t.TempDir(), "ctrl.db")
+
+	boltDb, err := db.Open(path)
+	req.NoError(err)
+	defer func() {
+		req.NoError(boltDb.Close())
+	}()
+
+	stores, err := db.InitStores(boltDb, command.NoOpRateLimiter{}, nil)
+	req.NoError(err)
+	req.NoError(db.RunMigrations(boltDb, stores, nil))
+
+	configType := &db.ConfigType{
+		BaseExtEntity: boltz.BaseExtEntity{Id: eid.New()},
+		Name:          eid.New(),
+	}
+
+	config := &db.Config{
+		BaseExtEntity: boltz.BaseExtEntity{Id: eid.New()},
+		Name:          eid.New(),
+		Type:          configType.Id,
+		Data:          map[string]interface{}{"hello": "world"},
+	}
+
+	req.NoError(boltDb.Update(nil, func(ctx boltz.MutateContext) error {
+		if err := stores.ConfigType.Create(ctx, configType); err != nil {
+			return err
+		}
+		if err := stores.Config.Create(ctx, config); err != nil {
+			return err
+		}
+		if corrupt {
+			stores.Config.GetEntityBucket(ctx.Tx(), []byte(config.Id)).SetString(db.FieldConfigType, "missing", nil)
+		}
+		return nil
+	}))
+
+	return path
+}
+
+func TestCheckIntegrityAction(t *testing.T) {
+	t.Run("clean databases pass", func(t *testing.T) {
+		req := require.New(t)
+		path := newTestDb(t, false)
+
+		for _, args := range [][]string{{path}, {"--quick", path}} {
+			out := &bytes.Buffer{}
+			cmd := NewCheckIntegrityAction(out)
+			cmd.SetArgs(args)
+			req.NoError(cmd.Execute())
+			req.Equal("no integrity problems found\n", out.String())
+		}
+	})
+
+	t.Run("problems are reported and fail the check", func(t *testing.T) {
+		req := require.New(t)
+		path := newTestDb(t, true)
+
+		for _, args := range [][]string{{path}, {"--quick", path}} {
+			out := &bytes.Buffer{}
+			cmd := NewCheckIntegrityAction(out)
+			cmd.SetArgs(args)
+			cmd.SetErr(&bytes.Buffer{})
+			err := cmd.Execute()
+			req.ErrorContains(err, "use 'ziti ops db repair' to fix them")
+			req.Contains(out.String(), "found: configs.type has invalid value")
+		}
+	})
+
+	t.Run("reported problems are limited by max errors", func(t *testing.T) {
+		req := require.New(t)
+		path := newTestDb(t, true)
+
+		out := &bytes.Buffer{}
+		cmd := NewCheckIntegrityAction(out)
+		cmd.SetArgs([]string{"--max-errors", "0", path})
+		cmd.SetErr(&bytes.Buffer{})
+		req.Error(cmd.Execute())
+		req.NotContains(out.String(), "found:")
+		req.Contains(out.String(), "more problems not shown")
+	})
+}
@@ -44,6 +44,8 @@ func NewCmdDb(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.AddCommand(NewAddDebugAdminAction())
 	cmd.AddCommand(NewAnonymizeAction())
 	cmd.AddCommand(NewDeleteSessionsFromDbCmd())
+	cmd.AddCommand(NewCheckIntegrityAction(out))
+	cmd.AddCommand(NewRepairIntegrityAction(out))
 
 	return cmd
 }
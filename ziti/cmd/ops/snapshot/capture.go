@@ -0,0 +1,41 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"io"
+
+	"github.com/openziti/edge-api/rest_management_api_client"
+	"github.com/openziti/ziti/ziti/cmd/ascode/exporter"
+	"github.com/pkg/errors"
+)
+
+// Capture takes a snapshot of the current state of the controller. The entities to capture are given using the
+// exporter entity names, for example service or service-policy. If none are given, all entities are captured.
+func Capture(client *rest_management_api_client.ZitiEdgeManagement, progressOut io.Writer, name string, controller string, entities ...string) (*Snapshot, error) {
+	exp := &exporter.Exporter{
+		Err:    progressOut,
+		Client: client,
+	}
+
+	exported, err := exp.Execute(entities)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read entities from controller")
+	}
+
+	return NewSnapshot(name, controller, exported)
+}
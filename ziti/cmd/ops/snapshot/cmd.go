@@ -0,0 +1,352 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/openziti/ziti/ziti/cmd/api"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	"github.com/openziti/ziti/ziti/cmd/edge"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const liveSnapshotRef = "live"
+
+// NewSnapshotCmd creates the command group for capturing and comparing snapshots of the controller model
+func NewSnapshotCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Capture, list and compare snapshots of the controller model",
+		Long: "Snapshots capture the services, configs, policies, routers, identities and other entities managed by the " +
+			"controller as canonical JSON, with secrets such as enrollment tokens and authenticators removed. " +
+			"Entities are identified by name, so snapshots can be compared across controllers.",
+	}
+
+	cmd.AddCommand(newCreateCmd(out, errOut))
+	cmd.AddCommand(newListCmd(out, errOut))
+	cmd.AddCommand(newDiffCmd(out, errOut))
+
+	return cmd
+}
+
+type snapshotOptions struct {
+	edge.LoginOptions
+	dir string
+}
+
+func newSnapshotOptions(out io.Writer, errOut io.Writer) *snapshotOptions {
+	return &snapshotOptions{
+		LoginOptions: edge.LoginOptions{
+			Options: api.Options{
+				CommonOptions: common.CommonOptions{
+					Out: out,
+					Err: errOut,
+				},
+			},
+		},
+	}
+}
+
+func (self *snapshotOptions) addDirFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&self.dir, "dir", "", "Directory snapshots are stored in. Defaults to a snapshots directory in the ziti config directory")
+}
+
+func (self *snapshotOptions) addLoginFlags(cmd *cobra.Command) {
+	edge.AddLoginFlags(cmd, &self.LoginOptions)
+	cmd.Flags().StringVar(&self.ControllerUrl, "controller-url", "", "The url of the controller")
+}
+
+func (self *snapshotOptions) getDir() (string, error) {
+	if self.dir != "" {
+		return self.dir, nil
+	}
+	return DefaultSnapshotDir()
+}
+
+// captureLive takes a snapshot of the current state of the controller
+func (self *snapshotOptions) captureLive(name string) (*Snapshot, error) {
+	client, err := self.NewMgmtClient()
+	if err != nil {
+		return nil, err
+	}
+
+	progressOut := self.Err
+	if !self.Verbose {
+		progressOut = io.Discard
+	}
+
+	return Capture(client, progressOut, name, self.ControllerUrl)
+}
+
+func (self *snapshotOptions) load(dir string, ref string) (*Snapshot, error) {
+	if ref == liveSnapshotRef {
+		return self.captureLive(liveSnapshotRef)
+	}
+	return LoadSnapshot(ResolveSnapshotPath(dir, ref))
+}
+
+func newCreateCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := newSnapshotOptions(out, errOut)
+
+	cmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Captures a snapshot of the controller model",
+		Long: "Captures the current controller model and writes it to <dir>/<name>.json. " +
+			"If no name is given, one is generated from the current time.",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			cmdhelper.CheckErr(options.runCreate())
+		},
+	}
+
+	options.addDirFlag(cmd)
+	options.addLoginFlags(cmd)
+
+	return cmd
+}
+
+func (self *snapshotOptions) runCreate() error {
+	name := defaultSnapshotName()
+	if len(self.Args) > 0 {
+		name = self.Args[0]
+	}
+
+	if name == liveSnapshotRef || strings.ContainsAny(name, `/\`) {
+		return errors.Errorf("invalid snapshot name '%s'", name)
+	}
+
+	dir, err := self.getDir()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(dir, util.DefaultWritePermissions); err != nil {
+		return errors.Wrapf(err, "unable to create snapshot directory %s", dir)
+	}
+
+	path := ResolveSnapshotPath(dir, name)
+	if _, err = os.Stat(path); err == nil {
+		return errors.Errorf("snapshot '%s' already exists at %s", name, path)
+	}
+
+	snapshot, err := self.captureLive(name)
+	if err != nil {
+		return err
+	}
+
+	if err = snapshot.Save(path); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(self.Out, "created snapshot '%s' at %s\n", name, path)
+	return err
+}
+
+func newListCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := newSnapshotOptions(out, errOut)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists the snapshots in the snapshot directory",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			cmdhelper.CheckErr(options.runList())
+		},
+	}
+
+	options.addDirFlag(cmd)
+	cmd.Flags().BoolVarP(&options.OutputJSONResponse, "output-json", "j", false, "Output the snapshot list as JSON")
+
+	return cmd
+}
+
+type snapshotSummary struct {
+	Name       string         `json:"name"`
+	CreatedAt  string         `json:"createdAt"`
+	Controller string         `json:"controller,omitempty"`
+	Counts     map[string]int `json:"counts"`
+}
+
+func (self *snapshotOptions) runList() error {
+	dir, err := self.getDir()
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := ListSnapshots(dir)
+	if err != nil {
+		return err
+	}
+
+	var summaries []*snapshotSummary
+	for _, snapshot := range snapshots {
+		summaries = append(summaries, &snapshotSummary{
+			Name:       snapshot.Name,
+			CreatedAt:  snapshot.CreatedAt.Format("2006-01-02 15:04:05Z"),
+			Controller: snapshot.Controller,
+			Counts:     snapshot.Counts(),
+		})
+	}
+
+	if self.OutputJSONResponse {
+		return self.outputJson(summaries)
+	}
+
+	if len(summaries) == 0 {
+		_, err = fmt.Fprintf(self.Out, "no snapshots found in %s\n", dir)
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetStyle(table.StyleRounded)
+	t.AppendHeader(table.Row{"Name", "Created", "Controller", "Entities"})
+	for _, summary := range summaries {
+		t.AppendRow(table.Row{summary.Name, summary.CreatedAt, summary.Controller, formatCounts(summary.Counts)})
+	}
+	api.RenderTable(&api.Options{CommonOptions: self.CommonOptions}, t, nil)
+	return nil
+}
+
+func formatCounts(counts map[string]int) string {
+	var entityTypes []string
+	for entityType := range counts {
+		entityTypes = append(entityTypes, entityType)
+	}
+	sort.Strings(entityTypes)
+
+	var parts []string
+	for _, entityType := range entityTypes {
+		parts = append(parts, fmt.Sprintf("%s: %d", entityType, counts[entityType]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func newDiffCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := newSnapshotOptions(out, errOut)
+
+	cmd := &cobra.Command{
+		Use:   "diff <from> [to]",
+		Short: "Shows the differences between two snapshots, or between a snapshot and the live controller model",
+		Long: "Compares two snapshots, given as snapshot names or paths to snapshot files. If only one snapshot is given, " +
+			"or the second snapshot is 'live', the snapshot is compared with the current controller model. " +
+			"Entities are matched by type and name.",
+		Example: "ziti ops snapshot diff before-upgrade\nziti ops snapshot diff before-upgrade after-upgrade",
+		Args:    cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			cmdhelper.CheckErr(options.runDiff())
+		},
+	}
+
+	options.addDirFlag(cmd)
+	options.addLoginFlags(cmd)
+
+	return cmd
+}
+
+func (self *snapshotOptions) runDiff() error {
+	dir, err := self.getDir()
+	if err != nil {
+		return err
+	}
+
+	toRef := liveSnapshotRef
+	if len(self.Args) > 1 {
+		toRef = self.Args[1]
+	}
+
+	from, err := self.load(dir, self.Args[0])
+	if err != nil {
+		return err
+	}
+
+	to, err := self.load(dir, toRef)
+	if err != nil {
+		return err
+	}
+
+	changes := Diff(from, to)
+
+	if self.OutputJSONResponse {
+		if changes == nil {
+			changes = []*EntityChange{}
+		}
+		return self.outputJson(changes)
+	}
+
+	if len(changes) == 0 {
+		_, err = fmt.Fprintf(self.Out, "no differences between '%s' and '%s'\n", from.Name, to.Name)
+		return err
+	}
+
+	for _, change := range changes {
+		switch change.Change {
+		case ChangeAdded:
+			_, err = fmt.Fprintf(self.Out, "+ %s %s\n", change.EntityType, change.Name)
+		case ChangeRemoved:
+			_, err = fmt.Fprintf(self.Out, "- %s %s\n", change.EntityType, change.Name)
+		default:
+			_, err = fmt.Fprintf(self.Out, "~ %s %s\n", change.EntityType, change.Name)
+			for _, field := range change.Fields {
+				if err == nil {
+					_, err = fmt.Fprintf(self.Out, "    %s: %s -> %s\n", field.Path, formatValue(field.Old), formatValue(field.New))
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(self.Out, "\n%d changed entities between '%s' and '%s'\n", len(changes), from.Name, to.Name)
+	return err
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func (self *snapshotOptions) outputJson(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(self.Out, string(data))
+	return err
+}
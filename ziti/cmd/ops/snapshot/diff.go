@@ -0,0 +1,140 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"reflect"
+	"sort"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// EntityChange describes how a single entity differs between two snapshots
+type EntityChange struct {
+	EntityType string         `json:"entityType"`
+	Name       string         `json:"name"`
+	Change     string         `json:"change"`
+	Fields     []*FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange describes a field which differs between two versions of an entity. Nested fields are given as dotted
+// paths, for example config.data.port. Lists are compared as a whole.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Diff returns the semantic differences between two snapshots. Entities are matched by type and name, so entities
+// which were recreated with a new id, but are otherwise the same, aren't reported. Changes are ordered by entity type
+// and name.
+func Diff(from, to *Snapshot) []*EntityChange {
+	var result []*EntityChange
+
+	for _, entityType := range entityTypes(from, to) {
+		fromEntities := indexEntities(from.Entities[entityType])
+		toEntities := indexEntities(to.Entities[entityType])
+
+		for name, fromEntity := range fromEntities {
+			toEntity, found := toEntities[name]
+			if !found {
+				result = append(result, &EntityChange{EntityType: entityType, Name: name, Change: ChangeRemoved})
+				continue
+			}
+			if fields := diffValues("", fromEntity, toEntity, nil); len(fields) > 0 {
+				sort.Slice(fields, func(i, j int) bool {
+					return fields[i].Path < fields[j].Path
+				})
+				result = append(result, &EntityChange{EntityType: entityType, Name: name, Change: ChangeModified, Fields: fields})
+			}
+		}
+
+		for name := range toEntities {
+			if _, found := fromEntities[name]; !found {
+				result = append(result, &EntityChange{EntityType: entityType, Name: name, Change: ChangeAdded})
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].EntityType != result[j].EntityType {
+			return result[i].EntityType < result[j].EntityType
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func entityTypes(snapshots ...*Snapshot) []string {
+	types := map[string]struct{}{}
+	for _, snapshot := range snapshots {
+		for entityType := range snapshot.Entities {
+			types[entityType] = struct{}{}
+		}
+	}
+
+	var result []string
+	for entityType := range types {
+		result = append(result, entityType)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func indexEntities(entities []map[string]interface{}) map[string]map[string]interface{} {
+	result := map[string]map[string]interface{}{}
+	for _, entity := range entities {
+		result[entityKey(entity)] = entity
+	}
+	return result
+}
+
+func diffValues(path string, from, to interface{}, result []*FieldChange) []*FieldChange {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+
+	if !fromIsMap || !toIsMap {
+		if !reflect.DeepEqual(from, to) {
+			result = append(result, &FieldChange{Path: path, Old: from, New: to})
+		}
+		return result
+	}
+
+	for k, fromVal := range fromMap {
+		result = diffValues(joinPath(path, k), fromVal, toMap[k], result)
+	}
+
+	for k, toVal := range toMap {
+		if _, found := fromMap[k]; !found {
+			result = diffValues(joinPath(path, k), nil, toVal, result)
+		}
+	}
+
+	return result
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
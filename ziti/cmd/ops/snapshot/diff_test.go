@@ -0,0 +1,99 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSnapshot(t *testing.T, name string, exported map[string]interface{}) *Snapshot {
+	result, err := NewSnapshot(name, "", exported)
+	require.NoError(t, err)
+	return result
+}
+
+func TestCanonicalize(t *testing.T) {
+	req := require.New(t)
+
+	snapshot := newTestSnapshot(t, "test", map[string]interface{}{
+		"identities": []map[string]interface{}{
+			{"name": "zed", "roleAttributes": []string{"b", "a"}, "enrollment": map[string]interface{}{"ott": map[string]interface{}{"jwt": "secret"}}},
+			{"name": "amy", "roleAttributes": []string{}},
+		},
+	})
+
+	identities := snapshot.Entities["identities"]
+	req.Len(identities, 2)
+	req.Equal("amy", identities[0]["name"])
+	req.Equal("zed", identities[1]["name"])
+	req.Equal([]interface{}{"a", "b"}, identities[1]["roleAttributes"])
+	req.NotContains(identities[1], "enrollment")
+}
+
+func TestDiff(t *testing.T) {
+	req := require.New(t)
+
+	from := newTestSnapshot(t, "from", map[string]interface{}{
+		"services": []map[string]interface{}{
+			{"name": "ssh", "roleAttributes": []string{"a", "b"}, "encryptionRequired": true},
+			{"name": "web", "roleAttributes": []string{}},
+		},
+		"configs": []map[string]interface{}{
+			{"name": "ssh-host", "data": map[string]interface{}{"address": "localhost", "port": 22}},
+		},
+	})
+
+	to := newTestSnapshot(t, "to", map[string]interface{}{
+		"services": []map[string]interface{}{
+			{"name": "ssh", "roleAttributes": []string{"b", "a"}, "encryptionRequired": false},
+			{"name": "db", "roleAttributes": []string{}},
+		},
+		"configs": []map[string]interface{}{
+			{"name": "ssh-host", "data": map[string]interface{}{"address": "localhost", "port": 2222, "protocol": "tcp"}},
+		},
+	})
+
+	req.Empty(Diff(from, from))
+
+	changes := Diff(from, to)
+	req.Len(changes, 4)
+
+	req.Equal("configs", changes[0].EntityType)
+	req.Equal("ssh-host", changes[0].Name)
+	req.Equal(ChangeModified, changes[0].Change)
+	req.Len(changes[0].Fields, 2)
+	req.Equal("data.port", changes[0].Fields[0].Path)
+	req.Equal(float64(22), changes[0].Fields[0].Old)
+	req.Equal(float64(2222), changes[0].Fields[0].New)
+	req.Equal("data.protocol", changes[0].Fields[1].Path)
+	req.Nil(changes[0].Fields[1].Old)
+	req.Equal("tcp", changes[0].Fields[1].New)
+
+	req.Equal("db", changes[1].Name)
+	req.Equal(ChangeAdded, changes[1].Change)
+
+	// reordered role attributes aren't a change
+	req.Equal("ssh", changes[2].Name)
+	req.Equal(ChangeModified, changes[2].Change)
+	req.Len(changes[2].Fields, 1)
+	req.Equal("encryptionRequired", changes[2].Fields[0].Path)
+
+	req.Equal("web", changes[3].Name)
+	req.Equal(ChangeRemoved, changes[3].Change)
+}
@@ -0,0 +1,255 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	SnapshotFormatVersion = 1
+	snapshotFileExtension = ".json"
+)
+
+// unorderedFields holds the list valued fields whose order has no meaning. They are sorted when a snapshot is
+// canonicalized, so that reordering them doesn't show up as a change.
+var unorderedFields = map[string]struct{}{
+	"roleAttributes":    {},
+	"identityRoles":     {},
+	"serviceRoles":      {},
+	"edgeRouterRoles":   {},
+	"postureCheckRoles": {},
+	"configs":           {},
+}
+
+// secretFields holds entity fields which are dropped from snapshots, so that snapshots can be shared and checked in
+// without leaking credentials
+var secretFields = map[string]struct{}{
+	"authenticators": {},
+	"enrollment":     {},
+}
+
+// Snapshot is a point in time capture of the controller model. Entities are grouped by entity type and identified
+// by name, with references to other entities also expressed by name, so that snapshots taken from different
+// controllers can be compared.
+type Snapshot struct {
+	Version    int                                 `json:"version"`
+	Name       string                              `json:"name"`
+	CreatedAt  time.Time                           `json:"createdAt"`
+	Controller string                              `json:"controller,omitempty"`
+	Entities   map[string][]map[string]interface{} `json:"entities"`
+}
+
+// NewSnapshot creates a canonical snapshot from the output of the exporter
+func NewSnapshot(name string, controller string, exported map[string]interface{}) (*Snapshot, error) {
+	// round trip through JSON, so the entities are made only of plain maps, slices and scalars
+	data, err := json.Marshal(exported)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal exported entities")
+	}
+
+	entities := map[string][]map[string]interface{}{}
+	if err = json.Unmarshal(data, &entities); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal exported entities")
+	}
+
+	result := &Snapshot{
+		Version:    SnapshotFormatVersion,
+		Name:       name,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		Controller: controller,
+		Entities:   entities,
+	}
+	result.Canonicalize()
+	return result, nil
+}
+
+// Canonicalize removes secrets and puts the snapshot into a stable order, so that two snapshots of the same model
+// serialize identically
+func (self *Snapshot) Canonicalize() {
+	if self.Entities == nil {
+		self.Entities = map[string][]map[string]interface{}{}
+	}
+
+	for _, entities := range self.Entities {
+		for _, entity := range entities {
+			for field := range secretFields {
+				delete(entity, field)
+			}
+			canonicalizeValue(entity)
+		}
+		sort.SliceStable(entities, func(i, j int) bool {
+			return entityKey(entities[i]) < entityKey(entities[j])
+		})
+	}
+}
+
+func canonicalizeValue(v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if _, unordered := unorderedFields[k]; unordered {
+				sortStrings(child)
+			}
+			canonicalizeValue(child)
+		}
+	case []interface{}:
+		for _, child := range val {
+			canonicalizeValue(child)
+		}
+	}
+}
+
+func sortStrings(v interface{}) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return
+	}
+	for _, item := range list {
+		if _, isString := item.(string); !isString {
+			return
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].(string) < list[j].(string)
+	})
+}
+
+// entityKey returns the name used to match an entity across snapshots
+func entityKey(entity map[string]interface{}) string {
+	if name, ok := entity["name"].(string); ok {
+		return name
+	}
+	data, _ := json.Marshal(entity)
+	return string(data)
+}
+
+// Counts returns the number of entities of each type in the snapshot
+func (self *Snapshot) Counts() map[string]int {
+	result := map[string]int{}
+	for entityType, entities := range self.Entities {
+		result[entityType] = len(entities)
+	}
+	return result
+}
+
+// Get returns the entity of the given type with the given name, or nil if the snapshot doesn't contain one
+func (self *Snapshot) Get(entityType string, name string) map[string]interface{} {
+	for _, entity := range self.Entities[entityType] {
+		if entityKey(entity) == name {
+			return entity
+		}
+	}
+	return nil
+}
+
+// Save writes the snapshot as indented JSON. Map keys are always sorted by the JSON encoder, so the output is
+// suitable for diffing with standard tools and for checking into source control.
+func (self *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(self, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal snapshot %s", self.Name)
+	}
+	data = append(data, '\n')
+	if err = os.WriteFile(path, data, 0640); err != nil {
+		return errors.Wrapf(err, "unable to write snapshot to %s", path)
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot from the given file
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read snapshot %s", path)
+	}
+	result := &Snapshot{}
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse snapshot %s", path)
+	}
+	if result.Version > SnapshotFormatVersion {
+		return nil, errors.Errorf("snapshot %s has format version %d, this version of ziti supports up to %d",
+			path, result.Version, SnapshotFormatVersion)
+	}
+	result.Canonicalize()
+	return result, nil
+}
+
+// DefaultSnapshotDir returns the directory snapshots are stored in when no directory is given
+func DefaultSnapshotDir() (string, error) {
+	configDir, err := util.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "snapshots"), nil
+}
+
+// ResolveSnapshotPath maps a snapshot reference to a file. A reference may be a path to a snapshot file or the name
+// of a snapshot in the snapshot directory.
+func ResolveSnapshotPath(dir string, ref string) string {
+	if _, err := os.Stat(ref); err == nil {
+		return ref
+	}
+	if strings.HasSuffix(ref, snapshotFileExtension) {
+		return filepath.Join(dir, ref)
+	}
+	return filepath.Join(dir, ref+snapshotFileExtension)
+}
+
+// ListSnapshots loads all the snapshots in the given directory, ordered by creation time
+func ListSnapshots(dir string) ([]*Snapshot, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "unable to read snapshot directory %s", dir)
+	}
+
+	var result []*Snapshot
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), snapshotFileExtension) {
+			continue
+		}
+		snapshot, err := LoadSnapshot(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, snapshot)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func defaultSnapshotName() string {
+	return fmt.Sprintf("snapshot-%s", time.Now().UTC().Format("20060102-150405"))
+}
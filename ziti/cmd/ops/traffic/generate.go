@@ -0,0 +1,374 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package traffic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/sdk-golang/ziti"
+	"github.com/openziti/ziti/ziti/cmd/common"
+	cmdhelper "github.com/openziti/ziti/ziti/cmd/helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// NewTrafficCmd creates the command group for traffic utilities
+func NewTrafficCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "traffic",
+		Short: "Utilities for generating traffic over a Ziti network",
+	}
+
+	cmd.AddCommand(newGenerateCmd(out, errOut))
+
+	return cmd
+}
+
+type generateOptions struct {
+	common.CommonOptions
+
+	identityFile    string
+	concurrency     int
+	payloadSizeSpec string
+	payloadSize     *payloadSize
+	duration        time.Duration
+	pattern         string
+	rampTime        time.Duration
+	burstOn         time.Duration
+	burstOff        time.Duration
+	echo            bool
+	messagesPerConn uint64
+	connectTimeout  time.Duration
+	reportInterval  time.Duration
+	outputJson      bool
+	sdkFlowControl  bool
+	dialIdentifier  string
+	trafficPattern  pattern
+	zitiContext     ziti.Context
+	payload         []byte
+}
+
+func newGenerateCmd(out io.Writer, errOut io.Writer) *cobra.Command {
+	options := &generateOptions{
+		CommonOptions: common.CommonOptions{
+			Out: out,
+			Err: errOut,
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "generate <service>",
+		Short: "Generates load against a service and reports throughput and latency",
+		Long: "Dials the given service with a number of concurrent workers, sending payloads for the given duration. " +
+			"If the service echoes data back, use --echo to also measure round trip latency. " +
+			"The service may be given as <identity>@<service> to dial a specific terminator identity.",
+		Example: "ziti ops traffic generate -i client.json --concurrency 20 --payload-size 512-64k --pattern ramp --duration 1m echo",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			cmdhelper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.identityFile, "identity", "i", "", "Ziti identity file to dial the service with")
+	cmd.Flags().IntVarP(&options.concurrency, "concurrency", "c", 10, "Number of concurrent connections")
+	cmd.Flags().StringVarP(&options.payloadSizeSpec, "payload-size", "s", "1k", "Payload size, either fixed (ex: 4k) or a range to pick from at random (ex: 512-64k)")
+	cmd.Flags().DurationVarP(&options.duration, "duration", "d", 30*time.Second, "How long to generate traffic for")
+	cmd.Flags().StringVarP(&options.pattern, "pattern", "p", PatternConstant, fmt.Sprintf("Traffic pattern, one of: %s", strings.Join(patterns, ", ")))
+	cmd.Flags().DurationVar(&options.rampTime, "ramp-time", 0, "Time to ramp up to full concurrency when using the ramp pattern. Defaults to half the duration")
+	cmd.Flags().DurationVar(&options.burstOn, "burst-on", 5*time.Second, "How long each burst lasts when using the burst pattern")
+	cmd.Flags().DurationVar(&options.burstOff, "burst-off", 5*time.Second, "How long to pause between bursts when using the burst pattern")
+	cmd.Flags().BoolVarP(&options.echo, "echo", "e", false, "Expect the service to echo payloads back and measure round trip latency")
+	cmd.Flags().Uint64Var(&options.messagesPerConn, "messages-per-conn", 0, "Number of payloads to send before redialing. 0 reuses connections for the whole run")
+	cmd.Flags().DurationVar(&options.connectTimeout, "connect-timeout", 5*time.Second, "Timeout for dialing the service")
+	cmd.Flags().DurationVar(&options.reportInterval, "report-interval", 5*time.Second, "How often to report progress. 0 disables progress reporting")
+	cmd.Flags().BoolVarP(&options.outputJson, "output-json", "j", false, "Output the final report as JSON")
+	cmd.Flags().BoolVar(&options.sdkFlowControl, "sdk-flow-control", false, "Enable SDK flow control")
+	cmd.Flags().BoolVar(&options.Verbose, "verbose", false, "Enable verbose logging")
+	_ = cmd.MarkFlagRequired("identity")
+
+	return cmd
+}
+
+func (self *generateOptions) validate() error {
+	if self.concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", self.concurrency)
+	}
+
+	if self.duration <= 0 {
+		return fmt.Errorf("duration must be greater than 0, got %s", self.duration)
+	}
+
+	var err error
+	if self.payloadSize, err = parsePayloadSize(self.payloadSizeSpec); err != nil {
+		return err
+	}
+
+	switch self.pattern {
+	case PatternConstant:
+		self.trafficPattern = &constantPattern{concurrency: self.concurrency}
+	case PatternRamp:
+		if self.rampTime <= 0 {
+			self.rampTime = self.duration / 2
+		}
+		self.trafficPattern = &rampPattern{concurrency: self.concurrency, rampTime: self.rampTime}
+	case PatternBurst:
+		if self.burstOn <= 0 || self.burstOff < 0 {
+			return fmt.Errorf("burst-on must be greater than 0 and burst-off may not be negative")
+		}
+		self.trafficPattern = &burstPattern{concurrency: self.concurrency, on: self.burstOn, off: self.burstOff}
+	default:
+		return fmt.Errorf("invalid pattern '%s', must be one of: %s", self.pattern, strings.Join(patterns, ", "))
+	}
+
+	return nil
+}
+
+// Run implements this command
+func (self *generateOptions) Run() error {
+	logLevel := logrus.WarnLevel
+	if self.Verbose {
+		logLevel = logrus.DebugLevel
+	}
+	pfxlog.GlobalInit(logLevel, pfxlog.DefaultOptions().SetTrimPrefix("github.com/openziti/").NoColor())
+
+	if err := self.validate(); err != nil {
+		return err
+	}
+
+	service := self.Args[0]
+	if atIdx := strings.IndexByte(service, '@'); atIdx > 0 {
+		self.dialIdentifier = service[:atIdx]
+		service = service[atIdx+1:]
+	}
+
+	zitiConfig, err := ziti.NewConfigFromFile(self.identityFile)
+	if err != nil {
+		return fmt.Errorf("unable to load ziti identity from [%s] (%w)", self.identityFile, err)
+	}
+
+	if self.sdkFlowControl {
+		zitiConfig.MaxControlConnections = 1
+		zitiConfig.MaxDefaultConnections = 2
+	}
+
+	if self.zitiContext, err = ziti.NewContext(zitiConfig); err != nil {
+		return fmt.Errorf("unable to create sdk context from identity [%s] (%w)", self.identityFile, err)
+	}
+	defer self.zitiContext.Close()
+
+	if _, found := self.zitiContext.GetService(service); !found {
+		return fmt.Errorf("service [%s] not found or not dialable by identity [%s]", service, self.identityFile)
+	}
+
+	// random data doesn't compress, so payload sizes are what ends up on the wire
+	self.payload = make([]byte, self.payloadSize.max)
+	if _, err = rand.Read(self.payload); err != nil {
+		return err
+	}
+
+	s := newStats()
+	ctx, cancel := context.WithTimeout(context.Background(), self.duration)
+	defer cancel()
+
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < self.concurrency; i++ {
+		w := &worker{
+			id:      i,
+			options: self,
+			service: service,
+			stats:   s,
+			start:   start,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.run(ctx)
+		}()
+	}
+
+	if self.reportInterval > 0 && !self.outputJson {
+		go self.reportProgress(ctx, s, start)
+	}
+
+	wg.Wait()
+	report := s.report(self, time.Since(start))
+
+	if self.outputJson {
+		encoder := json.NewEncoder(self.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	report.Print(self.Out)
+	return nil
+}
+
+func (self *generateOptions) reportProgress(ctx context.Context, s *stats, start time.Time) {
+	ticker := time.NewTicker(self.reportInterval)
+	defer ticker.Stop()
+
+	var lastMessages uint64
+	lastTime := start
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			messages := s.messages.Load()
+			rate := float64(messages-lastMessages) / now.Sub(lastTime).Seconds()
+			_, _ = fmt.Fprintf(self.Out, "%s: active: %d, dials: %d, dial errors: %d, messages: %d, errors: %d, rate: %.1f msgs/s\n",
+				now.Sub(start).Round(time.Second), s.activeWorkers.Load(), s.dials.Load(), s.dialErrors.Load(), messages,
+				s.errors.Load(), rate)
+			lastMessages = messages
+			lastTime = now
+		}
+	}
+}
+
+// worker dials the service and sends payloads whenever the traffic pattern says it should be active
+type worker struct {
+	id      int
+	options *generateOptions
+	service string
+	stats   *stats
+	start   time.Time
+	conn    net.Conn
+	sent    uint64
+	readBuf []byte
+}
+
+func (self *worker) run(ctx context.Context) {
+	defer self.closeConn()
+
+	log := pfxlog.Logger().WithField("worker", self.id)
+	if self.options.echo {
+		self.readBuf = make([]byte, self.options.payloadSize.max)
+	}
+
+	for ctx.Err() == nil {
+		if self.id >= self.options.trafficPattern.activeWorkers(time.Since(self.start)) {
+			self.closeConn()
+			self.sleep(ctx, 10*time.Millisecond)
+			continue
+		}
+
+		if self.conn == nil {
+			if err := self.dial(); err != nil {
+				log.WithError(err).Debug("dial failed")
+				self.sleep(ctx, 100*time.Millisecond)
+				continue
+			}
+		}
+
+		if err := self.send(); err != nil {
+			if ctx.Err() == nil {
+				self.stats.errors.Add(1)
+				log.WithError(err).Debug("send failed, closing connection")
+			}
+			self.closeConn()
+			continue
+		}
+
+		if self.options.messagesPerConn > 0 && self.sent >= self.options.messagesPerConn {
+			self.closeConn()
+		}
+	}
+}
+
+func (self *worker) dial() error {
+	dialOptions := &ziti.DialOptions{
+		ConnectTimeout: self.options.connectTimeout,
+		Identity:       self.options.dialIdentifier,
+	}
+	if self.options.sdkFlowControl {
+		dialOptions.SdkFlowControl = &self.options.sdkFlowControl
+	}
+
+	start := time.Now()
+	conn, err := self.options.zitiContext.DialWithOptions(self.service, dialOptions)
+	if err != nil {
+		self.stats.dialErrors.Add(1)
+		return err
+	}
+
+	self.stats.dialLatency.Update(time.Since(start).Nanoseconds())
+	self.stats.dials.Add(1)
+	self.stats.activeWorkers.Add(1)
+	self.conn = conn
+	self.sent = 0
+	return nil
+}
+
+func (self *worker) send() error {
+	size := self.options.payloadSize.next()
+	start := time.Now()
+
+	if self.options.echo {
+		// don't wait forever on services which drop data, but allow for large payloads on slow links
+		if err := self.conn.SetDeadline(start.Add(self.options.connectTimeout + 30*time.Second)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := self.conn.Write(self.options.payload[:size]); err != nil {
+		return err
+	}
+	self.stats.bytesSent.Add(uint64(size))
+
+	if self.options.echo {
+		n, err := io.ReadFull(self.conn, self.readBuf[:size])
+		self.stats.bytesReceived.Add(uint64(n))
+		if err != nil {
+			return err
+		}
+		self.stats.roundTripLatency.Update(time.Since(start).Nanoseconds())
+	}
+
+	self.stats.messages.Add(1)
+	self.sent++
+	return nil
+}
+
+func (self *worker) closeConn() {
+	if self.conn != nil {
+		if err := self.conn.Close(); err != nil {
+			pfxlog.Logger().WithField("worker", self.id).WithError(err).Debug("error closing connection")
+		}
+		self.conn = nil
+		self.stats.activeWorkers.Add(-1)
+	}
+}
+
+func (self *worker) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
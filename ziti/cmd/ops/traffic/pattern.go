@@ -0,0 +1,140 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package traffic
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PatternConstant = "constant"
+	PatternRamp     = "ramp"
+	PatternBurst    = "burst"
+)
+
+var patterns = []string{PatternConstant, PatternRamp, PatternBurst}
+
+// pattern determines how many of the workers should be sending at a given point in the run
+type pattern interface {
+	activeWorkers(elapsed time.Duration) int
+}
+
+// constantPattern keeps all workers active for the whole run
+type constantPattern struct {
+	concurrency int
+}
+
+func (self *constantPattern) activeWorkers(time.Duration) int {
+	return self.concurrency
+}
+
+// rampPattern increases the number of active workers linearly from one to the full concurrency over the ramp time,
+// then keeps all workers active
+type rampPattern struct {
+	concurrency int
+	rampTime    time.Duration
+}
+
+func (self *rampPattern) activeWorkers(elapsed time.Duration) int {
+	if elapsed >= self.rampTime {
+		return self.concurrency
+	}
+	result := 1 + int(float64(self.concurrency-1)*float64(elapsed)/float64(self.rampTime))
+	if result > self.concurrency {
+		return self.concurrency
+	}
+	return result
+}
+
+// burstPattern alternates between all workers being active for the on period and none being active for the
+// off period
+type burstPattern struct {
+	concurrency int
+	on          time.Duration
+	off         time.Duration
+}
+
+func (self *burstPattern) activeWorkers(elapsed time.Duration) int {
+	if elapsed%(self.on+self.off) < self.on {
+		return self.concurrency
+	}
+	return 0
+}
+
+// payloadSize is a fixed size, or a range from which sizes are picked at random
+type payloadSize struct {
+	min int
+	max int
+}
+
+func (self *payloadSize) next() int {
+	if self.max <= self.min {
+		return self.min
+	}
+	return self.min + rand.Intn(self.max-self.min+1)
+}
+
+func (self *payloadSize) String() string {
+	if self.min == self.max {
+		return strconv.Itoa(self.min)
+	}
+	return fmt.Sprintf("%d-%d", self.min, self.max)
+}
+
+// parsePayloadSize parses sizes like 1024, 4k, 1m or ranges like 512-64k
+func parsePayloadSize(val string) (*payloadSize, error) {
+	minStr, maxStr, isRange := strings.Cut(val, "-")
+	minSize, err := parseSize(minStr)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := minSize
+	if isRange {
+		if maxSize, err = parseSize(maxStr); err != nil {
+			return nil, err
+		}
+	}
+
+	if minSize < 1 || maxSize < minSize {
+		return nil, fmt.Errorf("invalid payload size '%s', sizes must be at least 1 and ranges must be in increasing order", val)
+	}
+
+	return &payloadSize{min: minSize, max: maxSize}, nil
+}
+
+func parseSize(val string) (int, error) {
+	val = strings.ToLower(strings.TrimSpace(val))
+	multiplier := 1
+	if strings.HasSuffix(val, "k") {
+		multiplier = 1024
+		val = strings.TrimSuffix(val, "k")
+	} else if strings.HasSuffix(val, "m") {
+		multiplier = 1024 * 1024
+		val = strings.TrimSuffix(val, "m")
+	}
+
+	result, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", val)
+	}
+	return result * multiplier, nil
+}
@@ -0,0 +1,74 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePayloadSize(t *testing.T) {
+	req := require.New(t)
+
+	size, err := parsePayloadSize("4k")
+	req.NoError(err)
+	req.Equal(4096, size.min)
+	req.Equal(4096, size.max)
+	req.Equal(4096, size.next())
+
+	size, err = parsePayloadSize("512-1m")
+	req.NoError(err)
+	req.Equal(512, size.min)
+	req.Equal(1024*1024, size.max)
+	req.Equal("512-1048576", size.String())
+	for i := 0; i < 100; i++ {
+		next := size.next()
+		req.True(next >= size.min && next <= size.max)
+	}
+
+	_, err = parsePayloadSize("0")
+	req.Error(err)
+
+	_, err = parsePayloadSize("64k-1k")
+	req.Error(err)
+
+	_, err = parsePayloadSize("lots")
+	req.Error(err)
+}
+
+func TestPatterns(t *testing.T) {
+	req := require.New(t)
+
+	constant := &constantPattern{concurrency: 10}
+	req.Equal(10, constant.activeWorkers(0))
+	req.Equal(10, constant.activeWorkers(time.Hour))
+
+	ramp := &rampPattern{concurrency: 11, rampTime: 10 * time.Second}
+	req.Equal(1, ramp.activeWorkers(0))
+	req.Equal(6, ramp.activeWorkers(5*time.Second))
+	req.Equal(11, ramp.activeWorkers(10*time.Second))
+	req.Equal(11, ramp.activeWorkers(time.Minute))
+
+	burst := &burstPattern{concurrency: 5, on: 2 * time.Second, off: 3 * time.Second}
+	req.Equal(5, burst.activeWorkers(0))
+	req.Equal(5, burst.activeWorkers(1999*time.Millisecond))
+	req.Equal(0, burst.activeWorkers(2*time.Second))
+	req.Equal(0, burst.activeWorkers(4*time.Second))
+	req.Equal(5, burst.activeWorkers(5*time.Second))
+}
@@ -0,0 +1,155 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package traffic
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+const latencySampleSize = 65536
+
+// stats collects results from all workers. Latencies are sampled, so percentiles are estimates for long runs
+type stats struct {
+	dials         atomic.Uint64
+	dialErrors    atomic.Uint64
+	messages      atomic.Uint64
+	errors        atomic.Uint64
+	bytesSent     atomic.Uint64
+	bytesReceived atomic.Uint64
+	activeWorkers atomic.Int64
+
+	dialLatency      metrics.Histogram
+	roundTripLatency metrics.Histogram
+}
+
+func newStats() *stats {
+	return &stats{
+		dialLatency:      metrics.NewHistogram(metrics.NewUniformSample(latencySampleSize)),
+		roundTripLatency: metrics.NewHistogram(metrics.NewUniformSample(latencySampleSize)),
+	}
+}
+
+type LatencySummary struct {
+	Count  int64   `json:"count"`
+	MinMs  float64 `json:"minMs"`
+	MeanMs float64 `json:"meanMs"`
+	P50Ms  float64 `json:"p50Ms"`
+	P90Ms  float64 `json:"p90Ms"`
+	P99Ms  float64 `json:"p99Ms"`
+	P999Ms float64 `json:"p999Ms"`
+	MaxMs  float64 `json:"maxMs"`
+}
+
+func summarize(h metrics.Histogram) *LatencySummary {
+	snapshot := h.Snapshot()
+	if snapshot.Count() == 0 {
+		return nil
+	}
+	percentiles := snapshot.Percentiles([]float64{0.5, 0.9, 0.99, 0.999})
+	return &LatencySummary{
+		Count:  snapshot.Count(),
+		MinMs:  toMs(float64(snapshot.Min())),
+		MeanMs: toMs(snapshot.Mean()),
+		P50Ms:  toMs(percentiles[0]),
+		P90Ms:  toMs(percentiles[1]),
+		P99Ms:  toMs(percentiles[2]),
+		P999Ms: toMs(percentiles[3]),
+		MaxMs:  toMs(float64(snapshot.Max())),
+	}
+}
+
+func toMs(ns float64) float64 {
+	return ns / float64(time.Millisecond)
+}
+
+type Report struct {
+	Service              string          `json:"service"`
+	Pattern              string          `json:"pattern"`
+	Concurrency          int             `json:"concurrency"`
+	PayloadSize          string          `json:"payloadSize"`
+	Echo                 bool            `json:"echo"`
+	Elapsed              string          `json:"elapsed"`
+	Dials                uint64          `json:"dials"`
+	DialErrors           uint64          `json:"dialErrors"`
+	Messages             uint64          `json:"messages"`
+	Errors               uint64          `json:"errors"`
+	BytesSent            uint64          `json:"bytesSent"`
+	BytesReceived        uint64          `json:"bytesReceived"`
+	MessagesPerSecond    float64         `json:"messagesPerSecond"`
+	SentMBytesPerSec     float64         `json:"sentMBytesPerSec"`
+	ReceivedMBytesPerSec float64         `json:"receivedMBytesPerSec"`
+	DialLatency          *LatencySummary `json:"dialLatency,omitempty"`
+	RoundTripLatency     *LatencySummary `json:"roundTripLatency,omitempty"`
+}
+
+func (self *stats) report(options *generateOptions, elapsed time.Duration) *Report {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+
+	result := &Report{
+		Service:          options.Args[0],
+		Pattern:          options.pattern,
+		Concurrency:      options.concurrency,
+		PayloadSize:      options.payloadSize.String(),
+		Echo:             options.echo,
+		Elapsed:          elapsed.Round(time.Millisecond).String(),
+		Dials:            self.dials.Load(),
+		DialErrors:       self.dialErrors.Load(),
+		Messages:         self.messages.Load(),
+		Errors:           self.errors.Load(),
+		BytesSent:        self.bytesSent.Load(),
+		BytesReceived:    self.bytesReceived.Load(),
+		DialLatency:      summarize(self.dialLatency),
+		RoundTripLatency: summarize(self.roundTripLatency),
+	}
+
+	result.MessagesPerSecond = float64(result.Messages) / seconds
+	result.SentMBytesPerSec = float64(result.BytesSent) / seconds / (1024 * 1024)
+	result.ReceivedMBytesPerSec = float64(result.BytesReceived) / seconds / (1024 * 1024)
+
+	return result
+}
+
+func (self *Report) Print(out io.Writer) {
+	_, _ = fmt.Fprintf(out, "\nservice: %s, pattern: %s, concurrency: %d, payload size: %s, echo: %v, elapsed: %s\n",
+		self.Service, self.Pattern, self.Concurrency, self.PayloadSize, self.Echo, self.Elapsed)
+	_, _ = fmt.Fprintf(out, "dials: %d, dial errors: %d, messages: %d, errors: %d\n",
+		self.Dials, self.DialErrors, self.Messages, self.Errors)
+	_, _ = fmt.Fprintf(out, "throughput: %.1f msgs/s, sent: %.3f MB/s, received: %.3f MB/s\n",
+		self.MessagesPerSecond, self.SentMBytesPerSec, self.ReceivedMBytesPerSec)
+
+	printLatency(out, "dial latency", self.DialLatency)
+	if self.Echo {
+		printLatency(out, "round trip latency", self.RoundTripLatency)
+	}
+}
+
+func printLatency(out io.Writer, name string, summary *LatencySummary) {
+	if summary == nil {
+		_, _ = fmt.Fprintf(out, "%s: no samples\n", name)
+		return
+	}
+	_, _ = fmt.Fprintf(out, "%s (ms): min %.2f, mean %.2f, p50 %.2f, p90 %.2f, p99 %.2f, p99.9 %.2f, max %.2f\n",
+		name, summary.MinMs, summary.MeanMs, summary.P50Ms, summary.P90Ms, summary.P99Ms, summary.P999Ms, summary.MaxMs)
+}
@@ -141,4 +141,12 @@ const (
 	ZitiEdgeRouterCsrOUVarDescription                = "The organization unit to use for router CSRs"
 	ZitiRouterCsrSansDnsVarName                      = "ZITI_ROUTER_CSR_SANS_DNS"
 	ZitiRouterCsrSansDnsVarDescription               = "Additional DNS SAN of the router"
+	ZitiRouterTypeVarName                            = "ZITI_ROUTER_TYPE"
+	ZitiRouterTypeVarDescription                     = "The type of router config to generate, edge or fabric"
+	ZitiRouterModeVarName                            = "ZITI_ROUTER_MODE"
+	ZitiRouterModeVarDescription                     = "The tunneler mode of an edge router: none, host, tproxy or proxy"
+	ZitiRouterLanInterfaceVarName                    = "ZITI_ROUTER_LAN_INTERFACE"
+	ZitiRouterLanInterfaceVarDescription             = "The interface on which to insert iptables ingress filter rules in tproxy mode"
+	ZitiEnrollTokenVarName                           = "ZITI_ENROLL_TOKEN"
+	ZitiEnrollTokenVarDescription                    = "The router's enrollment token, or the path to a file containing it"
 )
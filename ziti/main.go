@@ -29,6 +29,8 @@ import (
 	"github.com/openziti/transport/v2/wss"
 	"github.com/openziti/ziti/common/build"
 	"github.com/openziti/ziti/common/version"
+	"github.com/openziti/ziti/router/xgress_edge/wst"
+	"github.com/openziti/ziti/router/xlink_transport/quic"
 	"github.com/openziti/ziti/ziti/cmd"
 	"github.com/sirupsen/logrus"
 )
@@ -45,6 +47,8 @@ func init() {
 	transport.AddAddressParser(ws.AddressParser{})
 	transport.AddAddressParser(wss.AddressParser{})
 	transport.AddAddressParser(udp.AddressParser{})
+	transport.AddAddressParser(quic.AddressParser{})
+	transport.AddAddressParser(wst.AddressParser{})
 
 	build.InitBuildInfo(version.GetCmdBuildInfo())
 }
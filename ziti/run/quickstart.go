@@ -48,6 +48,7 @@ import (
 	"github.com/openziti/ziti/ziti/cmd/pki"
 	"github.com/openziti/ziti/ziti/constants"
 	"github.com/openziti/ziti/ziti/util"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -72,6 +73,10 @@ type QuickstartOpts struct {
 	verbose            bool
 	nonVoter           bool
 	routerless         bool
+	edgeRouters        int
+	fabricRouters      int
+	topologyFile       string
+	topology           *QuickstartTopology
 }
 
 func addCommonQuickstartFlags(cmd *cobra.Command, options *QuickstartOpts) {
@@ -92,6 +97,9 @@ func addCommonQuickstartFlags(cmd *cobra.Command, options *QuickstartOpts) {
 	cmd.Flags().StringVar(&options.RouterAddress, "router-address", "", "sets the advertised address for the integrated router. current: "+currentRouterAddy)
 	cmd.Flags().Uint16Var(&options.RouterPort, "router-port", uint16(defaultRouterPort), "sets the port to use for the integrated router. current: "+currentRouterPort)
 	cmd.Flags().BoolVar(&options.routerless, "no-router", false, "specifies the quickstart should not start a router")
+	cmd.Flags().IntVar(&options.edgeRouters, "edge-routers", 1, "the number of edge routers to start. ports are assigned sequentially starting at --router-port")
+	cmd.Flags().IntVar(&options.fabricRouters, "fabric-routers", 0, "the number of fabric routers to start, after the edge routers")
+	cmd.Flags().StringVar(&options.topologyFile, "topology", "", "a yaml file describing the routers to start and, optionally, the links between them. overrides --edge-routers and --fabric-routers")
 
 	cmd.Flags().BoolVar(&options.verbose, "verbose", false, "Show additional output.")
 }
@@ -101,13 +109,35 @@ func addQuickstartHaFlags(cmd *cobra.Command, options *QuickstartOpts) {
 	cmd.Flags().StringVar(&options.InstanceID, "instance-id", "", "specifies a unique instance id for use in ha mode.")
 }
 
+const quickstartLong = `runs a Controller and Router in quickstart mode with a temporary directory; suitable for testing and development
+
+Additional edge and fabric routers can be started with --edge-routers and --fabric-routers. Routers started this
+way form a full mesh of links. For more control, use --topology with a yaml file such as:
+
+  routers:
+    - name: edge-1
+    - name: edge-2
+      tunneler: false
+      attributes: [ public, west ]
+    - name: transit-1
+      type: fabric
+      port: 3100
+  links:
+    - from: edge-1
+      to: transit-1
+    - from: edge-2
+      to: transit-1
+
+Routers default to type edge, with tunneling enabled and the 'public' attribute. Routers without a port are
+assigned the next free port starting at --router-port. If links are given, only the listed links will be formed.`
+
 // NewQuickStartCmd creates a command object for the "create" command
 func NewQuickStartCmd(out io.Writer, errOut io.Writer, context context.Context) *cobra.Command {
 	options := &QuickstartOpts{}
 	cmd := &cobra.Command{
 		Use:   "quickstart",
 		Short: "runs a Controller and Router in quickstart mode",
-		Long:  "runs a Controller and Router in quickstart mode with a temporary directory; suitable for testing and development",
+		Long:  quickstartLong,
 		Run: func(cmd *cobra.Command, args []string) {
 			options.out = out
 			options.errOut = errOut
@@ -273,6 +303,10 @@ func (o *QuickstartOpts) run(ctx context.Context) error {
 		routerName = routerNameFromEnv
 	}
 
+	if err := o.resolveTopology(routerName); err != nil {
+		return err
+	}
+
 	dbDir := path.Join(o.instHome(), "db")
 	if _, err := os.Stat(dbDir); !os.IsNotExist(err) {
 		o.AlreadyInitialized = true
@@ -402,27 +436,28 @@ func (o *QuickstartOpts) run(ctx context.Context) error {
 		}
 	}
 
-	erConfigFile := path.Join(o.instHome(), routerName+".yaml")
-	err := o.configureRouter(routerName, erConfigFile, ctrlUrl)
+	err := o.configureRouters(ctrlUrl)
 	if err != nil {
 		return err
 	}
-	o.runRouter(erConfigFile)
+	for _, r := range o.topology.Routers {
+		o.runRouter(r.configFile)
+	}
 
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, os.Interrupt, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGTERM)
 
-	if !o.routerless {
+	for _, qsRouter := range o.topology.Routers {
 		r := make(chan struct{})
 		timeout, _ = time.ParseDuration("30s")
-		logrus.Infof("waiting for router at: %s:%d", o.RouterAddress, o.RouterPort)
-		go waitForRouter(o.RouterAddress, o.RouterPort, r)
+		logrus.Infof("waiting for router %s at: %s:%d", qsRouter.Name, o.RouterAddress, qsRouter.Port)
+		go waitForRouter(o.RouterAddress, qsRouter.Port, r)
 		select {
 		case <-r:
 			//completed normally
 		case <-time.After(timeout):
 			o.cleanupHome()
-			return fmt.Errorf("timed out waiting for router on port: %d", o.RouterPort)
+			return fmt.Errorf("timed out waiting for router %s on port: %d", qsRouter.Name, qsRouter.Port)
 		}
 	}
 
@@ -436,7 +471,7 @@ func (o *QuickstartOpts) run(ctx context.Context) error {
 			fmt.Println("Quickly add another member to this cluster using: ")
 			fmt.Printf("  ziti edge quickstart join \\\n")
 			fmt.Printf("    --ctrl-port %d \\\n", o.ControllerPort+1)
-			fmt.Printf("    --router-port %d \\\n", o.RouterPort+1)
+			fmt.Printf("    --router-port %d \\\n", o.nextFreeRouterPort())
 			fmt.Printf("    --home \"%s\" \\\n", o.Home)
 			fmt.Printf("    --trust-domain=\"%s\" \\\n", o.TrustDomain)
 			fmt.Printf("    --cluster-member tls:%s:%s\\ \n", ctrlAddy, ctrlPort)
@@ -465,89 +500,158 @@ func (o *QuickstartOpts) printDetails() {
 	fmt.Println("=======================================================================================")
 	fmt.Println("controller and router started.")
 	fmt.Println("    controller located at  : " + helpers.GetCtrlAdvertisedAddress() + ":" + strconv.Itoa(int(o.ControllerPort)))
-	fmt.Println("    router located at      : " + helpers.GetRouterAdvertisedAddress() + ":" + strconv.Itoa(int(o.RouterPort)))
+	for _, r := range o.topology.Routers {
+		fmt.Printf("    router located at      : %s:%d (%s, %s)\n", helpers.GetRouterAdvertisedAddress(), r.Port, r.Name, r.Type)
+	}
 	fmt.Println("    config dir located at  : " + o.Home)
 	fmt.Println("    configured trust domain: " + o.TrustDomain)
 	fmt.Printf("    instance pid           : %d\n", os.Getpid())
 }
 
-func (o *QuickstartOpts) configureRouter(routerName string, configFile string, ctrlUrl string) error {
+// resolveTopology works out which routers to start, either from the --topology file or from the router counts
+func (o *QuickstartOpts) resolveTopology(routerName string) error {
 	if o.routerless {
+		o.topology = &QuickstartTopology{}
 		return nil
 	}
 
-	if !o.AlreadyInitialized {
-		loginCmd := edge.NewLoginCmd(o.out, o.errOut)
-		loginCmd.SetArgs([]string{
-			ctrlUrl,
-			fmt.Sprintf("--username=%s", o.Username),
-			fmt.Sprintf("--password=%s", o.Password),
-			"-y",
-		})
-		if o.joinCommand {
-			o.waitForLeader()
+	if o.topologyFile != "" {
+		topology, err := LoadQuickstartTopology(o.topologyFile)
+		if err != nil {
+			return err
 		}
-		loginErr := loginCmd.Execute()
-		if loginErr != nil {
-			logrus.Fatal(loginErr)
+		o.topology = topology
+	} else {
+		if o.edgeRouters < 0 || o.fabricRouters < 0 {
+			return errors.New("--edge-routers and --fabric-routers may not be negative")
 		}
+		o.topology = NewQuickstartTopology(routerName, o.edgeRouters, o.fabricRouters)
+	}
+
+	return o.topology.resolve(o.RouterPort, o.instHome())
+}
 
-		o.configureOverlay()
+func (o *QuickstartOpts) nextFreeRouterPort() uint16 {
+	port := o.RouterPort
+	for _, r := range o.topology.Routers {
+		if r.Port >= port {
+			port = r.Port + 1
+		}
+	}
+	return port
+}
 
-		time.Sleep(1 * time.Second)
+func (o *QuickstartOpts) configureRouters(ctrlUrl string) error {
+	if len(o.topology.Routers) == 0 || o.AlreadyInitialized {
+		return nil
+	}
 
-		var erJwt string
+	loginCmd := edge.NewLoginCmd(o.out, o.errOut)
+	loginCmd.SetArgs([]string{
+		ctrlUrl,
+		fmt.Sprintf("--username=%s", o.Username),
+		fmt.Sprintf("--password=%s", o.Password),
+		"-y",
+	})
+	if o.joinCommand {
+		o.waitForLeader()
+	}
+	loginErr := loginCmd.Execute()
+	if loginErr != nil {
+		logrus.Fatal(loginErr)
+	}
 
-		// ziti edge create edge-router ${ZITI_HOSTNAME}-edge-router -o ${ZITI_HOME}/${ZITI_HOSTNAME}-edge-router.jwt -t -a public
-		createErCmd := edge.NewCreateEdgeRouterCmd(o.out, o.errOut)
-		erJwt = path.Join(o.Home, routerName+".jwt")
-		createErCmd.SetArgs([]string{
-			routerName,
-			fmt.Sprintf("--jwt-output-file=%s", erJwt),
-			"--tunneler-enabled",
-			fmt.Sprintf("--role-attributes=%s", "public"),
-		})
+	o.configureOverlay()
 
-		o.waitForLeader() //wait for a leader before doing anything
-		createErErr := createErCmd.Execute()
+	time.Sleep(1 * time.Second)
 
-		if createErErr != nil {
-			logrus.Fatal(createErErr)
+	for _, r := range o.topology.Routers {
+		if err := o.configureRouter(r); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		// ziti create config router edge --routerName ${ZITI_HOSTNAME}-edge-router >${ZITI_HOME}/${ZITI_HOSTNAME}-edge-router.yaml
-		opts := &create.CreateConfigRouterOptions{}
-
-		data := &create.ConfigTemplateValues{}
-		data.PopulateConfigValues()
-		opts.IsHA = o.isHA
-		create.SetZitiRouterIdentity(&data.Router, routerName)
-		erCfg := create.NewCmdCreateConfigRouterEdge(opts, data)
-		erCfg.SetArgs([]string{
-			fmt.Sprintf("--routerName=%s", routerName),
-			fmt.Sprintf("--output=%s", configFile),
-		})
+func (o *QuickstartOpts) configureRouter(r *QuickstartRouter) error {
+	erJwt := path.Join(o.Home, r.Name+".jwt")
 
-		o.waitForLeader()
-		erCfgErr := erCfg.Execute()
-		if erCfgErr != nil {
-			logrus.Fatal(erCfgErr)
+	var createCmd *cobra.Command
+	createArgs := []string{
+		r.Name,
+		fmt.Sprintf("--jwt-output-file=%s", erJwt),
+	}
+
+	if r.IsFabric() {
+		// ziti edge create transit-router ${ZITI_HOSTNAME}-fabric-router -o ${ZITI_HOME}/${ZITI_HOSTNAME}-fabric-router.jwt
+		createCmd = edge.NewCreateTransitRouterCmd(o.out, o.errOut)
+	} else {
+		// ziti edge create edge-router ${ZITI_HOSTNAME}-edge-router -o ${ZITI_HOME}/${ZITI_HOSTNAME}-edge-router.jwt -t -a public
+		createCmd = edge.NewCreateEdgeRouterCmd(o.out, o.errOut)
+		if r.IsTunneler() {
+			createArgs = append(createArgs, "--tunneler-enabled")
+		}
+		if len(r.Attributes) > 0 {
+			createArgs = append(createArgs, fmt.Sprintf("--role-attributes=%s", strings.Join(r.Attributes, ",")))
 		}
+	}
+	createCmd.SetArgs(createArgs)
 
-		// ziti router enroll ${ZITI_HOME}/${ZITI_HOSTNAME}-edge-router.yaml --jwt ${ZITI_HOME}/${ZITI_HOSTNAME}-edge-router.jwt
-		erEnroll := enroll.NewEnrollEdgeRouterCmd()
-		erEnroll.SetArgs([]string{
-			configFile,
-			fmt.Sprintf("--jwt=%s", erJwt),
-		})
+	o.waitForLeader() //wait for a leader before doing anything
+	createErErr := createCmd.Execute()
 
-		o.waitForLeader() //needed?
-		erEnrollErr := erEnroll.Execute()
-		if erEnrollErr != nil {
-			return erEnrollErr
+	if createErErr != nil {
+		logrus.Fatal(createErErr)
+	}
+
+	// ziti create config router edge --routerName ${ZITI_HOSTNAME}-edge-router >${ZITI_HOME}/${ZITI_HOSTNAME}-edge-router.yaml
+	opts := &create.CreateConfigRouterOptions{}
+
+	data := &create.ConfigTemplateValues{}
+	data.PopulateConfigValues()
+	opts.IsHA = o.isHA
+	create.SetZitiRouterIdentity(&data.Router, r.Name)
+	data.Router.Edge.Port = strconv.Itoa(int(r.Port))
+	data.Router.Edge.ListenerBindPort = strconv.Itoa(int(r.Port))
+	data.Router.Link.ListenerGroups = r.listenerGroups
+	data.Router.Link.DialerGroups = r.dialerGroups
+
+	cfgArgs := []string{
+		fmt.Sprintf("--routerName=%s", r.Name),
+		fmt.Sprintf("--output=%s", r.configFile),
+	}
+
+	var erCfg *cobra.Command
+	if r.IsFabric() {
+		data.Router.IsPrivate = r.private
+		data.Router.IsHA = o.isHA
+		erCfg = create.NewCmdCreateConfigRouterFabric(opts, data)
+	} else {
+		erCfg = create.NewCmdCreateConfigRouterEdge(opts, data)
+		if r.private {
+			cfgArgs = append(cfgArgs, "--private")
+		}
+		if !r.IsTunneler() {
+			cfgArgs = append(cfgArgs, "--tunnelerMode=none")
 		}
 	}
-	return nil
+	erCfg.SetArgs(cfgArgs)
+
+	o.waitForLeader()
+	erCfgErr := erCfg.Execute()
+	if erCfgErr != nil {
+		logrus.Fatal(erCfgErr)
+	}
+
+	// ziti router enroll ${ZITI_HOME}/${ZITI_HOSTNAME}-edge-router.yaml --jwt ${ZITI_HOME}/${ZITI_HOSTNAME}-edge-router.jwt
+	erEnroll := enroll.NewEnrollEdgeRouterCmd()
+	erEnroll.SetArgs([]string{
+		r.configFile,
+		fmt.Sprintf("--jwt=%s", erJwt),
+	})
+
+	o.waitForLeader() //needed?
+	return erEnroll.Execute()
 }
 
 func (o *QuickstartOpts) runRouter(configFile string) {
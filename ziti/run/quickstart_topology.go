@@ -0,0 +1,220 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package run
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	QuickstartRouterTypeEdge   = "edge"
+	QuickstartRouterTypeFabric = "fabric"
+
+	quickstartLinkGroupPrefix = "quickstart-"
+)
+
+// QuickstartTopology describes the routers started by quickstart and, optionally, which routers should link to
+// each other. If no links are given, routers form a full mesh. Example:
+//
+//	routers:
+//	  - name: edge-1
+//	    attributes: [ public ]
+//	  - name: edge-2
+//	    tunneler: false
+//	  - name: transit-1
+//	    type: fabric
+//	    port: 3100
+//	links:
+//	  - from: edge-1
+//	    to: transit-1
+//	  - from: edge-2
+//	    to: transit-1
+type QuickstartTopology struct {
+	Routers []*QuickstartRouter `yaml:"routers"`
+	Links   []*QuickstartLink   `yaml:"links"`
+}
+
+// QuickstartRouter describes a single router. Type is either edge (the default) or fabric. If no port is given,
+// the next free port after --router-port is used. The port is used for both the edge listener and link listener.
+type QuickstartRouter struct {
+	Name       string   `yaml:"name"`
+	Type       string   `yaml:"type"`
+	Port       uint16   `yaml:"port"`
+	Tunneler   *bool    `yaml:"tunneler"`
+	Attributes []string `yaml:"attributes"`
+
+	configFile     string
+	private        bool
+	listenerGroups []string
+	dialerGroups   []string
+}
+
+func (self *QuickstartRouter) IsFabric() bool {
+	return self.Type == QuickstartRouterTypeFabric
+}
+
+func (self *QuickstartRouter) IsTunneler() bool {
+	return !self.IsFabric() && (self.Tunneler == nil || *self.Tunneler)
+}
+
+// QuickstartLink requests a link between two routers. The from router dials the to router.
+type QuickstartLink struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// LoadQuickstartTopology reads a topology from the given yaml file
+func LoadQuickstartTopology(file string) (*QuickstartTopology, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read topology file %s", file)
+	}
+
+	topology := &QuickstartTopology{}
+	if err = yaml.Unmarshal(data, topology); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse topology file %s", file)
+	}
+	return topology, nil
+}
+
+// NewQuickstartTopology creates a fully meshed topology with the given number of edge and fabric routers. The
+// first edge router gets the given name, the others are named after it.
+func NewQuickstartTopology(routerName string, edgeRouters, fabricRouters int) *QuickstartTopology {
+	topology := &QuickstartTopology{}
+	for i := 1; i <= edgeRouters; i++ {
+		name := routerName
+		if i > 1 {
+			name = fmt.Sprintf("%s-edge-%d", routerName, i)
+		}
+		topology.Routers = append(topology.Routers, &QuickstartRouter{
+			Name: name,
+			Type: QuickstartRouterTypeEdge,
+		})
+	}
+	for i := 1; i <= fabricRouters; i++ {
+		topology.Routers = append(topology.Routers, &QuickstartRouter{
+			Name: fmt.Sprintf("%s-fabric-%d", routerName, i),
+			Type: QuickstartRouterTypeFabric,
+		})
+	}
+	return topology
+}
+
+// resolve validates the topology, fills in defaults, assigns ports and config files and works out the link groups
+// needed to restrict links to the requested pairs
+func (self *QuickstartTopology) resolve(firstPort uint16, configDir string) error {
+	routers := map[string]*QuickstartRouter{}
+	usedPorts := map[uint16]string{}
+
+	for idx, r := range self.Routers {
+		if r.Name == "" {
+			return errors.Errorf("router at index %d has no name", idx)
+		}
+		if _, found := routers[r.Name]; found {
+			return errors.Errorf("duplicate router name %s", r.Name)
+		}
+		routers[r.Name] = r
+
+		if r.Type == "" {
+			r.Type = QuickstartRouterTypeEdge
+		}
+		if r.Type != QuickstartRouterTypeEdge && r.Type != QuickstartRouterTypeFabric {
+			return errors.Errorf("router %s has invalid type %s, must be %s or %s", r.Name, r.Type,
+				QuickstartRouterTypeEdge, QuickstartRouterTypeFabric)
+		}
+		if r.IsFabric() && len(r.Attributes) > 0 {
+			return errors.Errorf("router %s is a fabric router and can't have role attributes", r.Name)
+		}
+		if !r.IsFabric() && r.Attributes == nil {
+			r.Attributes = []string{"public"}
+		}
+
+		if r.Port != 0 {
+			if other, found := usedPorts[r.Port]; found {
+				return errors.Errorf("routers %s and %s both use port %d", other, r.Name, r.Port)
+			}
+			usedPorts[r.Port] = r.Name
+		}
+		r.configFile = path.Join(configDir, r.Name+".yaml")
+	}
+
+	nextPort := firstPort
+	for _, r := range self.Routers {
+		if r.Port != 0 {
+			continue
+		}
+		for {
+			if _, found := usedPorts[nextPort]; !found {
+				break
+			}
+			if nextPort == 65535 {
+				return errors.New("ran out of ports to assign to routers")
+			}
+			nextPort++
+		}
+		r.Port = nextPort
+		usedPorts[nextPort] = r.Name
+	}
+
+	if len(self.Links) == 0 {
+		return nil
+	}
+
+	// each router listens on its own link group, and dials the groups of the routers it should link to.
+	// routers which nobody links to don't need a link listener
+	for idx, link := range self.Links {
+		from, found := routers[link.From]
+		if !found {
+			return errors.Errorf("link at index %d references unknown router '%s'", idx, link.From)
+		}
+		to, found := routers[link.To]
+		if !found {
+			return errors.Errorf("link at index %d references unknown router '%s'", idx, link.To)
+		}
+		if from == to {
+			return errors.Errorf("link at index %d links router %s to itself", idx, from.Name)
+		}
+
+		group := quickstartLinkGroupPrefix + to.Name
+		to.listenerGroups = appendIfMissing(to.listenerGroups, group)
+		from.dialerGroups = appendIfMissing(from.dialerGroups, group)
+	}
+
+	for _, r := range self.Routers {
+		r.private = len(r.listenerGroups) == 0
+		if len(r.dialerGroups) == 0 {
+			// the default group would match nothing, but be explicit so it's clear from the config
+			r.dialerGroups = []string{quickstartLinkGroupPrefix + "none"}
+		}
+	}
+
+	return nil
+}
+
+func appendIfMissing(list []string, val string) []string {
+	for _, v := range list {
+		if v == val {
+			return list
+		}
+	}
+	return append(list, val)
+}
@@ -0,0 +1,111 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuickstartTopologyDefaults(t *testing.T) {
+	req := require.New(t)
+
+	topology := NewQuickstartTopology("router-qs", 2, 1)
+	req.NoError(topology.resolve(3022, "/tmp/qs"))
+	req.Len(topology.Routers, 3)
+
+	req.Equal("router-qs", topology.Routers[0].Name)
+	req.Equal(uint16(3022), topology.Routers[0].Port)
+	req.Equal("/tmp/qs/router-qs.yaml", topology.Routers[0].configFile)
+	req.True(topology.Routers[0].IsTunneler())
+	req.Equal([]string{"public"}, topology.Routers[0].Attributes)
+
+	req.Equal("router-qs-edge-2", topology.Routers[1].Name)
+	req.Equal(uint16(3023), topology.Routers[1].Port)
+
+	req.Equal("router-qs-fabric-1", topology.Routers[2].Name)
+	req.Equal(uint16(3024), topology.Routers[2].Port)
+	req.True(topology.Routers[2].IsFabric())
+	req.False(topology.Routers[2].IsTunneler())
+	req.Empty(topology.Routers[2].Attributes)
+
+	for _, r := range topology.Routers {
+		req.False(r.private)
+		req.Empty(r.listenerGroups)
+		req.Empty(r.dialerGroups)
+	}
+}
+
+func TestQuickstartTopologyFile(t *testing.T) {
+	req := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "topology.yml")
+	req.NoError(os.WriteFile(file, []byte(`
+routers:
+  - name: edge-1
+  - name: edge-2
+    tunneler: false
+    attributes: [ west ]
+  - name: transit-1
+    type: fabric
+    port: 3022
+links:
+  - from: edge-1
+    to: transit-1
+  - from: edge-2
+    to: transit-1
+`), 0600))
+
+	topology, err := LoadQuickstartTopology(file)
+	req.NoError(err)
+	req.NoError(topology.resolve(3022, "/tmp/qs"))
+
+	edge1, edge2, transit := topology.Routers[0], topology.Routers[1], topology.Routers[2]
+
+	req.Equal(uint16(3023), edge1.Port)
+	req.Equal(uint16(3024), edge2.Port)
+	req.Equal(uint16(3022), transit.Port)
+
+	req.False(edge2.IsTunneler())
+	req.Equal([]string{"west"}, edge2.Attributes)
+
+	req.True(edge1.private)
+	req.True(edge2.private)
+	req.False(transit.private)
+
+	req.Equal([]string{"quickstart-transit-1"}, transit.listenerGroups)
+	req.Equal([]string{"quickstart-transit-1"}, edge1.dialerGroups)
+	req.Equal([]string{"quickstart-transit-1"}, edge2.dialerGroups)
+	req.Equal([]string{"quickstart-none"}, transit.dialerGroups)
+}
+
+func TestQuickstartTopologyValidation(t *testing.T) {
+	req := require.New(t)
+
+	topology := &QuickstartTopology{
+		Routers: []*QuickstartRouter{{Name: "a"}, {Name: "a"}},
+	}
+	req.ErrorContains(topology.resolve(3022, "/tmp"), "duplicate router name")
+
+	topology = &QuickstartTopology{
+		Routers: []*QuickstartRouter{{Name: "a", Type: "other"}},
+	}
+	req.ErrorContains(topology.resolve(3022, "/tmp"), "invalid type")
+
+	topology = &QuickstartTopology{
+		Routers: []*QuickstartRouter{{Name: "a", Port: 4000}, {Name: "b", Port: 4000}},
+	}
+	req.ErrorContains(topology.resolve(3022, "/tmp"), "both use port")
+
+	topology = &QuickstartTopology{
+		Routers: []*QuickstartRouter{{Name: "a"}},
+		Links:   []*QuickstartLink{{From: "a", To: "b"}},
+	}
+	req.ErrorContains(topology.resolve(3022, "/tmp"), "unknown router 'b'")
+
+	topology = &QuickstartTopology{
+		Routers: []*QuickstartRouter{{Name: "a", Type: QuickstartRouterTypeFabric, Attributes: []string{"public"}}},
+	}
+	req.ErrorContains(topology.resolve(3022, "/tmp"), "can't have role attributes")
+}
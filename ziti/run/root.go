@@ -71,6 +71,7 @@ func NewRunCmd(out, err io.Writer) *cobra.Command {
 
 	cmd.AddCommand(NewRunControllerCmd())
 	cmd.AddCommand(NewRunRouterCmd())
+	cmd.AddCommand(NewRunRouterFromEnvCmd())
 	cmd.AddCommand(tunnel.NewTunnelCmd(false))
 	cmd.AddCommand(NewQuickStartCmd(out, err, context.Background()))
 
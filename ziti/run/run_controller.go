@@ -101,6 +101,7 @@ func (self *ControllerAction) Run(cmd *cobra.Command, args []string) {
 	}
 
 	go self.waitForShutdown()
+	go self.waitForReload()
 
 	self.edgeController.Run()
 	if err := self.fabricController.Run(); err != nil {
@@ -118,3 +119,16 @@ func (self *ControllerAction) waitForShutdown() {
 	self.edgeController.Shutdown()
 	self.fabricController.Shutdown()
 }
+
+func (self *ControllerAction) waitForReload() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+
+	for range ch {
+		log := pfxlog.Logger()
+		log.Info("received SIGHUP, reloading ziti-controller config")
+		if _, err := self.fabricController.ReloadConfig(); err != nil {
+			log.WithError(err).Error("failed to reload ziti-controller config")
+		}
+	}
+}
@@ -70,6 +70,10 @@ func (self *RouterAction) Run(cmd *cobra.Command, args []string) {
 		panic(err)
 	}
 
+	self.run(config, startLogger)
+}
+
+func (self *RouterAction) run(config *env.Config, startLogger *logrus.Entry) {
 	config.Edge.ForceExtendEnrollment = self.ForceCertificateExtension
 
 	startLogger = startLogger.WithField("routerId", config.Id.Token)
@@ -87,7 +91,7 @@ func (self *RouterAction) Run(cmd *cobra.Command, args []string) {
 
 	go r.ListenForShutdownSignal()
 
-	if err = r.Run(); err != nil {
+	if err := r.Run(); err != nil {
 		logrus.WithError(err).Fatal("error starting")
 	}
 }
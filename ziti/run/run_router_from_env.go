@@ -0,0 +1,192 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package run
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/michaelquigley/pfxlog"
+	"github.com/openziti/sdk-golang/ziti"
+	"github.com/openziti/ziti/common/version"
+	"github.com/openziti/ziti/router/enroll"
+	"github.com/openziti/ziti/router/env"
+	"github.com/openziti/ziti/ziti/cmd/create"
+	"github.com/openziti/ziti/ziti/constants"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func NewRunRouterFromEnvCmd() *cobra.Command {
+	action := &RouterFromEnvAction{}
+	return NewCustomRunRouterFromEnvCommand("router-from-env", "Generate a router config from the environment, enroll if needed, and start the router", action)
+}
+
+// NewCustomRunRouterFromEnvCommand creates a command which runs a router without a config file. The config is
+// generated in memory from the same ZITI_* environment variables used by `ziti create config router`. If the
+// router's identity doesn't exist yet, the router is first enrolled using the token in ZITI_ENROLL_TOKEN, which
+// may be the token itself or the path to a mounted file containing it. This makes it possible to deploy routers
+// declaratively, for example as a Kubernetes DaemonSet, with only the identity directory needing to be writable.
+func NewCustomRunRouterFromEnvCommand(name string, desc string, action *RouterFromEnvAction) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:    name,
+		Short:  desc,
+		Args:   cobra.NoArgs,
+		Run:    action.Run,
+		PreRun: action.PreRun,
+	}
+
+	action.BindFlags(cmd)
+
+	cmd.Flags().BoolVar(&action.EnableDebugOps, "debug-ops", false, "Enable/disable debug agent operations (disabled by default)")
+	cmd.Flags().BoolVarP(&action.ForceCertificateExtension, "extend", "e", false, "force extension of enrollment certificates on startup")
+	cmd.Flags().BoolVar(&action.PrintConfig, "print-config", false, "Print the generated config before starting")
+	cmd.Flags().StringVar(&action.Engine, "engine", "", "An engine to use for the private key during enrollment")
+	if err := action.KeyAlg.Set("RSA"); err != nil { // set default
+		panic(err)
+	}
+	cmd.Flags().Var(&action.KeyAlg, "keyAlg", "Crypto algorithm to use when generating the private key during enrollment")
+
+	return cmd
+}
+
+type RouterFromEnvAction struct {
+	RouterAction
+	PrintConfig bool
+	Engine      string
+	KeyAlg      ziti.KeyAlgVar
+}
+
+func (self *RouterFromEnvAction) Run(cmd *cobra.Command, args []string) {
+	startLogger := logrus.WithField("version", version.GetVersion()).
+		WithField("go-version", version.GetGoVersion()).
+		WithField("os", version.GetOS()).
+		WithField("arch", version.GetArchitecture()).
+		WithField("build-date", version.GetBuildDate()).
+		WithField("revision", version.GetRevision()).
+		WithField("configSource", "environment")
+
+	config, err := self.loadConfig()
+	if err != nil {
+		startLogger.WithError(err).Fatal("error bootstrapping ziti router config from environment")
+	}
+
+	self.run(config, startLogger)
+}
+
+func (self *RouterFromEnvAction) loadConfig() (*env.Config, error) {
+	routerType := os.Getenv(constants.ZitiRouterTypeVarName)
+	if routerType == "" {
+		routerType = "edge"
+	}
+
+	options := &create.CreateConfigRouterOptions{
+		RouterName:   os.Getenv(constants.ZitiEdgeRouterNameVarName),
+		TunnelerMode: os.Getenv(constants.ZitiRouterModeVarName),
+		LanInterface: os.Getenv(constants.ZitiRouterLanInterfaceVarName),
+	}
+
+	cfgYaml, err := create.RenderRouterConfig(routerType, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to generate router config")
+	}
+
+	if self.PrintConfig {
+		fmt.Println(string(cfgYaml))
+	}
+
+	cfgmap, err := parseGeneratedRouterConfig(cfgYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := env.LoadConfigFromMap(cfgmap, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = os.Stat(config.IdConfig.Cert); os.IsNotExist(err) {
+		if err = self.enroll(config); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "unable to check for router identity at [%s]", config.IdConfig.Cert)
+	} else {
+		pfxlog.Logger().WithField("cert", config.IdConfig.Cert).Info("router identity found, skipping enrollment")
+	}
+
+	// parse again, since loading the config may modify the map
+	if cfgmap, err = parseGeneratedRouterConfig(cfgYaml); err != nil {
+		return nil, err
+	}
+
+	return env.LoadConfigFromMap(cfgmap, true)
+}
+
+func (self *RouterFromEnvAction) enroll(config *env.Config) error {
+	token := strings.TrimSpace(os.Getenv(constants.ZitiEnrollTokenVarName))
+	if token == "" {
+		return errors.Errorf("router identity not found at [%s] and %s is not set", config.IdConfig.Cert, constants.ZitiEnrollTokenVarName)
+	}
+
+	jwt := []byte(token)
+	if info, err := os.Stat(token); err == nil && !info.IsDir() {
+		if jwt, err = os.ReadFile(token); err != nil {
+			return errors.Wrapf(err, "unable to read enrollment token from [%s]", token)
+		}
+	}
+
+	// enrollment writes the certs as files, so make sure their directories exist
+	for _, path := range []string{config.IdConfig.Cert, config.IdConfig.ServerCert, config.IdConfig.CA} {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return errors.Wrapf(err, "unable to create directory for [%s]", path)
+		}
+	}
+
+	pfxlog.Logger().WithField("cert", config.IdConfig.Cert).Info("router identity not found, enrolling")
+
+	if err := enroll.NewRestEnroller(config).Enroll(jwt, true, self.Engine, self.KeyAlg); err != nil {
+		return errors.Wrap(err, "enrollment failure")
+	}
+
+	return nil
+}
+
+// parseGeneratedRouterConfig parses a generated config. Since there's no config file to put it next to, the
+// controller endpoints file is kept with the router's identity unless configured otherwise.
+func parseGeneratedRouterConfig(cfgYaml []byte) (map[interface{}]interface{}, error) {
+	cfgmap, err := env.ParseConfigMap(cfgYaml)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse generated router config")
+	}
+
+	identityConfig, err := env.LoadIdentityConfigFromMap(cfgmap)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctrlMap, ok := cfgmap["ctrl"].(map[interface{}]interface{}); ok {
+		if _, found := ctrlMap["endpointsFile"]; !found && identityConfig.Cert != "" {
+			ctrlMap["endpointsFile"] = filepath.Join(filepath.Dir(identityConfig.Cert), "endpoints.yml")
+		}
+	}
+
+	return cfgmap, nil
+}
@@ -33,17 +33,20 @@ import (
 	"github.com/openziti/ziti/tunnel"
 	"github.com/openziti/ziti/tunnel/dns"
 	"github.com/openziti/ziti/tunnel/entities"
+	"github.com/openziti/ziti/tunnel/health"
 	"github.com/openziti/ziti/tunnel/intercept"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 const (
-	svcPollRateFlag   = "svcPollRate"
-	resolverCfgFlag   = "resolver"
-	dnsSvcIpRangeFlag = "dnsSvcIpRange"
-	dnsUpstreamFlag   = "dnsUpstream"
-	dnsUnanswerableFlag = "dnsUnanswerable"
+	svcPollRateFlag          = "svcPollRate"
+	resolverCfgFlag          = "resolver"
+	dnsSvcIpRangeFlag        = "dnsSvcIpRange"
+	dnsUpstreamFlag          = "dnsUpstream"
+	dnsUnanswerableFlag      = "dnsUnanswerable"
+	dnsSplitFlag             = "dnsSplit"
+	healthCheckScriptDirFlag = "healthCheckScriptDir"
 )
 
 var hostSpecificCmds []func() *cobra.Command
@@ -61,10 +64,12 @@ func NewTunnelCmd(legacy bool) *cobra.Command {
 	root.PersistentFlags().String("identity-dir", "", "Path to directory file that contains one or more enrolled identities")
 	root.PersistentFlags().Uint(svcPollRateFlag, 15, "Set poll rate for service updates (seconds). Polling in proxy mode is disabled unless this value is explicitly set")
 	root.PersistentFlags().StringP(resolverCfgFlag, "r", "udp://127.0.0.1:53", "Resolver configuration")
-	root.PersistentFlags().String(dnsUpstreamFlag, "", "Upstream DNS server for recursive queries (e.g., udp://10.96.0.10:53 or tcp://8.8.8.8:53)")
+	root.PersistentFlags().String(dnsUpstreamFlag, "", "Comma separated list of upstream DNS servers for recursive queries, tried in order (e.g., udp://10.96.0.10:53, tls://9.9.9.9 or https://dns.quad9.net/dns-query?bootstrap=9.9.9.9)")
 	root.PersistentFlags().String(dnsUnanswerableFlag, "", "Disposition for unanswerable DNS queries (timeout|servfail|refused, default: refused)")
+	root.PersistentFlags().Bool(dnsSplitFlag, false, "Route only intercepted domains to the internal DNS server, using systemd-resolved on Linux or scoped resolvers on macOS, instead of replacing the system resolver")
 	root.PersistentFlags().StringVar(&logFormatter, "log-formatter", "", "Specify log formatter [json|pfxlog|text]")
 	root.PersistentFlags().StringP(dnsSvcIpRangeFlag, "d", "100.64.0.1/10", "cidr to use when assigning IPs to unresolvable intercept hostnames")
+	root.PersistentFlags().String(healthCheckScriptDirFlag, "", "Directory containing scripts which may be run by script health checks. Script health checks are disabled if not set")
 	root.PersistentFlags().BoolVar(&cliAgentEnabled, "cli-agent", true, "Enable/disable CLI Agent (enabled by default)")
 	root.PersistentFlags().StringVar(&cliAgentAddr, "cli-agent-addr", "", "Specify where CLI Agent should listen (ex: unix:/tmp/myfile.sock or tcp:127.0.0.1:10001)")
 	root.PersistentFlags().StringVar(&cliAgentAlias, "cli-agent-alias", "", "Alias which can be used by ziti agent commands to find this instance")
@@ -142,7 +147,8 @@ func rootPostRun(cmd *cobra.Command, _ []string) {
 	resolverConfig := cmd.Flag(resolverCfgFlag).Value.String()
 	upstreamConfig := cmd.Flag(dnsUpstreamFlag).Value.String()
 	unansweredDisposition, _ := cmd.Flags().GetString(dnsUnanswerableFlag)
-	resolver, err := dns.NewResolver(resolverConfig, upstreamConfig, unansweredDisposition)
+	splitDns, _ := cmd.Flags().GetBool(dnsSplitFlag)
+	resolver, err := dns.NewResolver(resolverConfig, upstreamConfig, unansweredDisposition, splitDns)
 	if err != nil {
 		log.WithError(err).Fatal("failed to start DNS resolver")
 	}
@@ -153,6 +159,11 @@ func rootPostRun(cmd *cobra.Command, _ []string) {
 		log.Fatalf("invalid dns service IP range %s: %v", dnsIpRange, err)
 	}
 
+	scriptDir, _ := cmd.Flags().GetString(healthCheckScriptDirFlag)
+	if err := health.SetScriptCheckDir(scriptDir); err != nil {
+		log.Fatalf("invalid health check script directory %s: %v", scriptDir, err)
+	}
+
 	if idDir := cmd.Flag("identity-dir").Value.String(); idDir != "" {
 		files, err := os.ReadDir(idDir)
 		if err != nil {
@@ -175,6 +186,12 @@ func rootPostRun(cmd *cobra.Command, _ []string) {
 
 	serviceListenerGroup.WaitForShutdown()
 
+	if resolver != nil {
+		if err = resolver.Cleanup(); err != nil {
+			log.WithError(err).Error("failed to shut down DNS resolver")
+		}
+	}
+
 	if cliAgentEnabled {
 		agent.Close()
 	}
@@ -0,0 +1,244 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/openziti/ziti/controller/env"
+	"github.com/pkg/errors"
+	"gopkg.in/resty.v1"
+)
+
+const managementApiBinding = "edge-management"
+
+// DiscoverClusterUrls returns the management API URLs of every controller in the cluster the given management API
+// URL belongs to. Controllers which aren't part of a cluster return an empty list.
+func DiscoverClusterUrls(client *resty.Client, managementUrl string, token string) ([]string, error) {
+	resp, err := client.R().
+		SetHeader(env.ZitiSession, token).
+		Get(strings.TrimSuffix(managementUrl, "/") + "/controllers")
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list controllers at %v", managementUrl)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, errors.Errorf("unable to list controllers at %v, status code: %v", managementUrl, resp.Status())
+	}
+
+	return parseClusterUrls(resp.Body())
+}
+
+func parseClusterUrls(body []byte) ([]string, error) {
+	controllers := &struct {
+		Data []struct {
+			ApiAddresses map[string][]struct {
+				Url     string `json:"url"`
+				Version string `json:"version"`
+			} `json:"apiAddresses"`
+		} `json:"data"`
+	}{}
+
+	if err := json.Unmarshal(body, controllers); err != nil {
+		return nil, errors.Wrap(err, "unable to parse controller list")
+	}
+
+	var result []string
+	for _, controller := range controllers.Data {
+		for _, address := range controller.ApiAddresses[managementApiBinding] {
+			if address.Url != "" && (address.Version == "" || address.Version == "v1") {
+				result = appendClusterUrl(result, address.Url)
+			}
+		}
+	}
+	return result, nil
+}
+
+func appendClusterUrl(urls []string, u string) []string {
+	u = strings.TrimSuffix(u, "/")
+	for _, existing := range urls {
+		if strings.EqualFold(existing, u) {
+			return urls
+		}
+	}
+	return append(urls, u)
+}
+
+// GetClusterUrls returns the active management API URL, followed by the other known controller URLs
+func (self *RestClientEdgeIdentity) GetClusterUrls() []string {
+	result := appendClusterUrl(nil, self.Url)
+	for _, u := range self.ClusterUrls {
+		result = appendClusterUrl(result, u)
+	}
+	return result
+}
+
+// SetActiveUrl makes the given controller URL the one requests are sent to, adding it to the cluster URLs if needed
+func (self *RestClientEdgeIdentity) SetActiveUrl(u string) {
+	u = strings.TrimSuffix(u, "/")
+	if len(self.ClusterUrls) > 0 {
+		self.ClusterUrls = appendClusterUrl(self.ClusterUrls, self.Url)
+		self.ClusterUrls = appendClusterUrl(self.ClusterUrls, u)
+	}
+	self.Url = u
+}
+
+func (self *RestClientEdgeIdentity) activeHost() string {
+	parsed, err := url.Parse(self.Url)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+func (self *RestClientEdgeIdentity) isClusterHost(host string) bool {
+	for _, u := range self.GetClusterUrls() {
+		if parsed, err := url.Parse(u); err == nil && strings.EqualFold(parsed.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// failoverHosts returns the hosts of the controllers to try when the controller at failedHost can't be reached
+func (self *RestClientEdgeIdentity) failoverHosts(failedHost string) []string {
+	var result []string
+	for _, u := range self.GetClusterUrls() {
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Host == "" || strings.EqualFold(parsed.Host, failedHost) {
+			continue
+		}
+		result = append(result, parsed.Host)
+	}
+	return result
+}
+
+// clusterFailoverTransport retries requests against the other controllers in the cluster when the target controller
+// can't be connected to. Only connection failures are retried, as the request can't have reached the controller.
+// After a successful failover the identity is updated, so following requests go to the working controller.
+type clusterFailoverTransport struct {
+	http.RoundTripper
+	identity *RestClientEdgeIdentity
+	errOut   io.Writer
+}
+
+func newClusterFailoverTransport(rt http.RoundTripper, identity *RestClientEdgeIdentity, errOut io.Writer) http.RoundTripper {
+	return &clusterFailoverTransport{
+		RoundTripper: rt,
+		identity:     identity,
+		errOut:       errOut,
+	}
+}
+
+func (self *clusterFailoverTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// clients are bound to a host when created, so send requests to the controller we've since failed over to
+	if activeHost := self.identity.activeHost(); activeHost != "" && !strings.EqualFold(activeHost, r.URL.Host) &&
+		self.identity.isClusterHost(r.URL.Host) {
+		r = withHost(r, activeHost)
+	}
+
+	resp, err := self.RoundTripper.RoundTrip(r)
+	if err == nil || !isConnectError(err) || r.Context().Err() != nil {
+		return resp, err
+	}
+
+	failedHost := r.URL.Host
+	for _, host := range self.identity.failoverHosts(failedHost) {
+		retry := withHost(r, host)
+		if r.Body != nil && r.Body != http.NoBody {
+			if r.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := r.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			retry.Body = body
+		}
+
+		resp, err = self.RoundTripper.RoundTrip(retry)
+		if err == nil {
+			self.failedOver(failedHost, host)
+			return resp, nil
+		}
+
+		if !isConnectError(err) || r.Context().Err() != nil {
+			return resp, err
+		}
+	}
+
+	return resp, err
+}
+
+func withHost(r *http.Request, host string) *http.Request {
+	result := r.Clone(r.Context())
+	result.URL.Host = host
+	result.Host = host
+	return result
+}
+
+func (self *clusterFailoverTransport) failedOver(failedHost, host string) {
+	activeUrl, err := url.Parse(self.identity.Url)
+	if err != nil || !strings.EqualFold(activeUrl.Host, failedHost) {
+		return
+	}
+
+	activeUrl.Host = host
+	self.identity.SetActiveUrl(activeUrl.String())
+
+	if self.errOut != nil {
+		_, _ = fmt.Fprintf(self.errOut, "controller %v unreachable, failed over to %v\n", failedHost, host)
+	}
+
+	if err = persistActiveUrl(self.identity); err != nil && self.errOut != nil {
+		_, _ = fmt.Fprintf(self.errOut, "unable to save active controller to cli config: %v\n", err)
+	}
+}
+
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// persistActiveUrl saves the active controller URL of the identity, so later commands start with the working
+// controller
+func persistActiveUrl(identity *RestClientEdgeIdentity) error {
+	if identity.name == "" {
+		return nil
+	}
+
+	config, _, err := LoadRestClientConfig()
+	if err != nil {
+		return err
+	}
+
+	saved, found := config.EdgeIdentities[identity.name]
+	if !found || saved.Token != identity.Token {
+		return nil
+	}
+
+	saved.Url = identity.Url
+	saved.ClusterUrls = identity.ClusterUrls
+	return PersistRestClientConfig(config)
+}
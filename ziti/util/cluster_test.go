@@ -0,0 +1,92 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClusterUrls(t *testing.T) {
+	req := require.New(t)
+
+	body := `{"data": [
+		{"apiAddresses": {"edge-management": [{"url": "https://ctrl1:1280/edge/management/v1", "version": "v1"}],
+		                  "edge-client": [{"url": "https://ctrl1:1280/edge/client/v1", "version": "v1"}]}},
+		{"apiAddresses": {"edge-management": [{"url": "https://ctrl2:1280/edge/management/v1/", "version": "v1"}]}},
+		{"apiAddresses": {"edge-management": [{"url": "https://ctrl1:1280/edge/management/v1", "version": "v1"}]}},
+		{"apiAddresses": {}}
+	]}`
+
+	urls, err := parseClusterUrls([]byte(body))
+	req.NoError(err)
+	req.Equal([]string{"https://ctrl1:1280/edge/management/v1", "https://ctrl2:1280/edge/management/v1"}, urls)
+
+	_, err = parseClusterUrls([]byte("not json"))
+	req.Error(err)
+}
+
+func TestClusterFailoverTransport(t *testing.T) {
+	req := require.New(t)
+
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r.URL.Path+":"+string(body))
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	req.NoError(err)
+	downAddr := listener.Addr().String()
+	req.NoError(listener.Close())
+
+	identity := &RestClientEdgeIdentity{
+		Url: "http://" + downAddr + "/edge/management/v1",
+		ClusterUrls: []string{
+			"http://" + downAddr + "/edge/management/v1",
+			server.URL + "/edge/management/v1",
+		},
+	}
+
+	client := &http.Client{Transport: newClusterFailoverTransport(http.DefaultTransport, identity, nil)}
+
+	resp, err := client.Post("http://"+downAddr+"/edge/management/v1/services", "application/json", bytes.NewBufferString("{}"))
+	req.NoError(err)
+	req.NoError(resp.Body.Close())
+	req.Equal(server.URL+"/edge/management/v1", identity.Url)
+	req.Equal([]string{"/edge/management/v1/services:{}"}, received)
+
+	// requests still addressed to the failed controller go straight to the active one
+	resp, err = client.Get("http://" + downAddr + "/edge/management/v1/identities")
+	req.NoError(err)
+	req.NoError(resp.Body.Close())
+	req.Equal([]string{"/edge/management/v1/services:{}", "/edge/management/v1/identities:"}, received)
+
+	// connection failures are returned once there are no more controllers to try
+	identity.Url = "http://" + downAddr + "/edge/management/v1"
+	identity.ClusterUrls = nil
+	_, err = client.Get("http://" + downAddr + "/edge/management/v1/identities")
+	req.Error(err)
+	req.True(isConnectError(err))
+}
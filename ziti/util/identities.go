@@ -73,6 +73,12 @@ type RestClientEdgeIdentity struct {
 	LoginTime string `json:"loginTime"`
 	CaCert    string `json:"caCert,omitempty"`
 	ReadOnly  bool   `json:"readOnly"`
+
+	// ClusterUrls holds the management API URLs of all controllers, when logged into a controller cluster.
+	// Requests fail over between them when the active controller can't be reached.
+	ClusterUrls []string `json:"clusterUrls,omitempty"`
+
+	name string
 }
 
 func (self *RestClientEdgeIdentity) IsReadOnly() bool {
@@ -104,7 +110,17 @@ func (self *RestClientEdgeIdentity) NewTlsClientConfig() (*tls.Config, error) {
 
 func (self *RestClientEdgeIdentity) NewClient(timeout time.Duration, verbose bool) (*resty.Client, error) {
 	client := NewClient()
-	if self.CaCert != "" {
+	if len(self.ClusterUrls) > 0 {
+		tlsConfig, err := self.NewTlsClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport := &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		}
+		client.SetTransport(newClusterFailoverTransport(transport, self, os.Stderr))
+	} else if self.CaCert != "" {
 		client.SetRootCertificate(self.CaCert)
 	}
 	client.SetTimeout(timeout)
@@ -269,6 +285,7 @@ func LoadSelectedIdentity() (RestClientIdentity, error) {
 				return nil, errors.Errorf("no identity '%v' found in CLI config %v. You can select an existing identity using 'ziti edge use <identity_name>'", id, configFile)
 			}
 		}
+		clientIdentity.name = id
 		selectedIdentity = clientIdentity
 	}
 	return selectedIdentity, nil
@@ -299,6 +316,12 @@ func newRestClientResponseF(clientOpts ClientOpts) func(*http.Response, error) {
 				_, _ = fmt.Fprintf(clientOpts.ErrOutputWriter(), "could not read response body: %v", err)
 				return
 			}
+			if IsOutputTransformed() {
+				if err = OutputResponse(clientOpts.OutputWriter(), bodyContent); err != nil {
+					_, _ = fmt.Fprintf(clientOpts.ErrOutputWriter(), "could not format response body: %v", err)
+				}
+				return
+			}
 			bodyStr := string(bodyContent)
 			_, _ = fmt.Fprint(clientOpts.OutputWriter(), bodyStr, "\n")
 		}
@@ -358,8 +381,13 @@ func newRestClientTransport(clientOpts ClientOpts, clientIdentity RestClientIden
 
 	httpClientTransport.TLSClientConfig = tlsClientConfig
 
+	var transport http.RoundTripper = httpClientTransport
+	if edgeIdentity, ok := clientIdentity.(*RestClientEdgeIdentity); ok && len(edgeIdentity.ClusterUrls) > 0 {
+		transport = newClusterFailoverTransport(transport, edgeIdentity, clientOpts.ErrOutputWriter())
+	}
+
 	httpClient := &http.Client{
-		Transport: httpClientTransport,
+		Transport: transport,
 		Timeout:   10 * time.Second,
 	}
 	return httpClient, nil
@@ -0,0 +1,529 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// JsonPath is a compiled JSONPath expression, used to select values from decoded JSON. It supports the commonly used
+// subset of JSONPath:
+//
+//	$                  the root, which may be omitted
+//	.name, ['name']    child member
+//	.*, [*]            all children
+//	..name, ..*        recursive descent
+//	[0], [-1], [0,2]   array indices, negative indices count from the end
+//	[1:3], [:2]        array slices
+//	[?(@.name)]        children which have the given member
+//	[?(@.name == 'x')] children matching a comparison. Supported operators are ==, !=, <, <=, > and >=
+type JsonPath struct {
+	expr  string
+	steps []*jsonPathStep
+}
+
+type jsonPathStep struct {
+	recursive bool
+	wildcard  bool
+	names     []string
+	indices   []int
+	slice     *jsonPathSlice
+	filter    *jsonPathFilter
+}
+
+type jsonPathSlice struct {
+	start *int
+	end   *int
+}
+
+type jsonPathFilter struct {
+	path  *JsonPath
+	op    string
+	value interface{}
+}
+
+// CompileJsonPath parses the given JSONPath expression
+func CompileJsonPath(expr string) (*JsonPath, error) {
+	p := &jsonPathParser{expr: strings.TrimSpace(expr)}
+	steps, err := p.parse()
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid JSONPath expression '%s'", expr)
+	}
+	return &JsonPath{expr: expr, steps: steps}, nil
+}
+
+func (self *JsonPath) String() string {
+	return self.expr
+}
+
+// Evaluate returns the values selected by the path from the given decoded JSON
+func (self *JsonPath) Evaluate(data interface{}) []interface{} {
+	current := []interface{}{data}
+	for _, step := range self.steps {
+		var next []interface{}
+		for _, val := range current {
+			if step.recursive {
+				for _, descendant := range jsonDescendants(val) {
+					next = append(next, step.apply(descendant)...)
+				}
+			} else {
+				next = append(next, step.apply(val)...)
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+func (self *jsonPathStep) apply(val interface{}) []interface{} {
+	var result []interface{}
+	switch v := val.(type) {
+	case map[string]interface{}:
+		if self.wildcard || self.filter != nil {
+			for _, key := range sortedKeys(v) {
+				if self.filter == nil || self.filter.matches(v[key]) {
+					result = append(result, v[key])
+				}
+			}
+		}
+		for _, name := range self.names {
+			if child, found := v[name]; found {
+				result = append(result, child)
+			}
+		}
+	case []interface{}:
+		if self.wildcard || self.filter != nil {
+			for _, child := range v {
+				if self.filter == nil || self.filter.matches(child) {
+					result = append(result, child)
+				}
+			}
+		}
+		for _, idx := range self.indices {
+			if idx < 0 {
+				idx += len(v)
+			}
+			if idx >= 0 && idx < len(v) {
+				result = append(result, v[idx])
+			}
+		}
+		if self.slice != nil {
+			start, end := 0, len(v)
+			if self.slice.start != nil {
+				start = clampSliceIndex(*self.slice.start, len(v))
+			}
+			if self.slice.end != nil {
+				end = clampSliceIndex(*self.slice.end, len(v))
+			}
+			for i := start; i < end; i++ {
+				result = append(result, v[i])
+			}
+		}
+	}
+	return result
+}
+
+func clampSliceIndex(idx, length int) int {
+	if idx < 0 {
+		idx += length
+	}
+	if idx < 0 {
+		return 0
+	}
+	if idx > length {
+		return length
+	}
+	return idx
+}
+
+// jsonDescendants returns the value and all values nested inside it, in document order
+func jsonDescendants(val interface{}) []interface{} {
+	result := []interface{}{val}
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			result = append(result, jsonDescendants(v[key])...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			result = append(result, jsonDescendants(child)...)
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (self *jsonPathFilter) matches(val interface{}) bool {
+	results := self.path.Evaluate(val)
+	if self.op == "" {
+		return len(results) > 0
+	}
+
+	for _, result := range results {
+		if compareJsonValues(result, self.op, self.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareJsonValues(left interface{}, op string, right interface{}) bool {
+	if l, ok := left.(float64); ok {
+		if r, ok := right.(float64); ok {
+			switch op {
+			case "==":
+				return l == r
+			case "!=":
+				return l != r
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			case ">":
+				return l > r
+			case ">=":
+				return l >= r
+			}
+			return false
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			switch op {
+			case "==":
+				return l == r
+			case "!=":
+				return l != r
+			case "<":
+				return l < r
+			case "<=":
+				return l <= r
+			case ">":
+				return l > r
+			case ">=":
+				return l >= r
+			}
+			return false
+		}
+	}
+
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	}
+	return false
+}
+
+type jsonPathParser struct {
+	expr string
+	pos  int
+}
+
+func (self *jsonPathParser) parse() ([]*jsonPathStep, error) {
+	if self.peek() == '$' || self.peek() == '@' {
+		self.pos++
+	}
+
+	var steps []*jsonPathStep
+	first := true
+	for !self.done() {
+		var step *jsonPathStep
+		var err error
+
+		switch {
+		case strings.HasPrefix(self.expr[self.pos:], ".."):
+			self.pos += 2
+			if self.peek() == '[' {
+				step, err = self.parseBracket()
+			} else {
+				step, err = self.parseDotMember()
+			}
+			if step != nil {
+				step.recursive = true
+			}
+		case self.peek() == '.':
+			self.pos++
+			step, err = self.parseDotMember()
+		case self.peek() == '[':
+			step, err = self.parseBracket()
+		case first:
+			// allow the leading dot to be left off, as in data[0].name
+			step, err = self.parseDotMember()
+		default:
+			return nil, errors.Errorf("unexpected character '%c' at position %d", self.peek(), self.pos)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+		first = false
+	}
+	return steps, nil
+}
+
+func (self *jsonPathParser) done() bool {
+	return self.pos >= len(self.expr)
+}
+
+func (self *jsonPathParser) peek() byte {
+	if self.done() {
+		return 0
+	}
+	return self.expr[self.pos]
+}
+
+func (self *jsonPathParser) parseDotMember() (*jsonPathStep, error) {
+	if self.peek() == '*' {
+		self.pos++
+		return &jsonPathStep{wildcard: true}, nil
+	}
+
+	start := self.pos
+	for !self.done() && self.peek() != '.' && self.peek() != '[' {
+		self.pos++
+	}
+	if start == self.pos {
+		return nil, errors.Errorf("expected member name at position %d", start)
+	}
+	return &jsonPathStep{names: []string{self.expr[start:self.pos]}}, nil
+}
+
+func (self *jsonPathParser) parseBracket() (*jsonPathStep, error) {
+	self.pos++ // [
+	self.skipSpaces()
+
+	step := &jsonPathStep{}
+	switch {
+	case self.peek() == '*':
+		self.pos++
+		step.wildcard = true
+	case self.peek() == '?':
+		filter, err := self.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		step.filter = filter
+	default:
+		for {
+			self.skipSpaces()
+			if self.peek() == '\'' || self.peek() == '"' {
+				name, err := self.parseQuoted()
+				if err != nil {
+					return nil, err
+				}
+				step.names = append(step.names, name)
+			} else {
+				token := self.readUntil(",]")
+				if strings.Contains(token, ":") {
+					slice, err := parseJsonPathSlice(token)
+					if err != nil {
+						return nil, err
+					}
+					step.slice = slice
+				} else {
+					idx, err := strconv.Atoi(strings.TrimSpace(token))
+					if err != nil {
+						return nil, errors.Errorf("invalid array index '%s'", token)
+					}
+					step.indices = append(step.indices, idx)
+				}
+			}
+			self.skipSpaces()
+			if self.peek() != ',' {
+				break
+			}
+			self.pos++
+		}
+	}
+
+	self.skipSpaces()
+	if self.peek() != ']' {
+		return nil, errors.Errorf("expected ']' at position %d", self.pos)
+	}
+	self.pos++
+	return step, nil
+}
+
+func parseJsonPathSlice(token string) (*jsonPathSlice, error) {
+	parts := strings.Split(token, ":")
+	if len(parts) != 2 {
+		return nil, errors.Errorf("invalid array slice '%s', slice steps aren't supported", token)
+	}
+
+	result := &jsonPathSlice{}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		idx, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, errors.Errorf("invalid array slice '%s'", token)
+		}
+		if i == 0 {
+			result.start = &idx
+		} else {
+			result.end = &idx
+		}
+	}
+	return result, nil
+}
+
+func (self *jsonPathParser) parseFilter() (*jsonPathFilter, error) {
+	self.pos++ // ?
+	self.skipSpaces()
+	if self.peek() != '(' {
+		return nil, errors.Errorf("expected '(' at position %d", self.pos)
+	}
+	self.pos++
+
+	depth := 1
+	start := self.pos
+	var quote byte
+	for !self.done() && depth > 0 {
+		c := self.peek()
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		}
+		self.pos++
+	}
+	if depth != 0 {
+		return nil, errors.New("unterminated filter expression")
+	}
+
+	expr := strings.TrimSpace(self.expr[start : self.pos-1])
+	if !strings.HasPrefix(expr, "@") {
+		return nil, errors.Errorf("filter expression '%s' must start with @", expr)
+	}
+
+	filter := &jsonPathFilter{}
+	pathExpr := expr
+	if idx, op := findJsonPathOperator(expr); idx > 0 {
+		filter.op = op
+		pathExpr = strings.TrimSpace(expr[:idx])
+		value, err := parseJsonPathLiteral(strings.TrimSpace(expr[idx+len(op):]))
+		if err != nil {
+			return nil, err
+		}
+		filter.value = value
+	}
+
+	pathParser := &jsonPathParser{expr: pathExpr}
+	steps, err := pathParser.parse()
+	if err != nil {
+		return nil, err
+	}
+	filter.path = &JsonPath{expr: pathExpr, steps: steps}
+	return filter, nil
+}
+
+// findJsonPathOperator returns the position of the first comparison operator outside of quotes, or -1 if there is none
+func findJsonPathOperator(expr string) (int, string) {
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '\'' || c == '"' {
+			quote = c
+			continue
+		}
+		for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+			if strings.HasPrefix(expr[i:], op) {
+				return i, op
+			}
+		}
+	}
+	return -1, ""
+}
+
+func parseJsonPathLiteral(literal string) (interface{}, error) {
+	if len(literal) >= 2 && (literal[0] == '\'' || literal[0] == '"') && literal[len(literal)-1] == literal[0] {
+		return literal[1 : len(literal)-1], nil
+	}
+	switch literal {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if f, err := strconv.ParseFloat(literal, 64); err == nil {
+		return f, nil
+	}
+	return nil, errors.Errorf("invalid literal '%s' in filter expression", literal)
+}
+
+func (self *jsonPathParser) parseQuoted() (string, error) {
+	quote := self.peek()
+	self.pos++
+	start := self.pos
+	for !self.done() && self.peek() != quote {
+		self.pos++
+	}
+	if self.done() {
+		return "", errors.Errorf("unterminated string starting at position %d", start-1)
+	}
+	result := self.expr[start:self.pos]
+	self.pos++
+	return result, nil
+}
+
+func (self *jsonPathParser) readUntil(chars string) string {
+	start := self.pos
+	for !self.done() && !strings.ContainsRune(chars, rune(self.peek())) {
+		self.pos++
+	}
+	return self.expr[start:self.pos]
+}
+
+func (self *jsonPathParser) skipSpaces() {
+	for self.peek() == ' ' {
+		self.pos++
+	}
+}
@@ -0,0 +1,139 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OutputFormatTable = "table"
+	OutputFormatJson  = "json"
+	OutputFormatYaml  = "yaml"
+)
+
+var OutputFormats = []string{OutputFormatTable, OutputFormatJson, OutputFormatYaml}
+
+// OutputFormat and OutputQuery hold the values of the --output and --query flags, which apply to every controller
+// response the CLI prints
+var OutputFormat string
+var OutputQuery *JsonPath
+
+// IsOutputTransformed returns true if controller responses should be printed in a format other than the default
+// pretty-printed JSON
+func IsOutputTransformed() bool {
+	return OutputFormat == OutputFormatYaml || OutputQuery != nil
+}
+
+// OutputResponse prints a controller response, using the format and query given on the command line
+func OutputResponse(out io.Writer, data []byte) error {
+	if !IsOutputTransformed() {
+		OutputJson(out, data)
+		return nil
+	}
+	return FormatOutput(out, data, OutputFormat, OutputQuery)
+}
+
+// FormatOutput prints the given JSON document in the given format, after applying the query, if one is given.
+// Query results are printed one per line in the table format, with strings unquoted, so they can be easily used from
+// scripts. In the json and yaml formats, a single result is printed as is, and multiple results are printed as a list.
+func FormatOutput(out io.Writer, data []byte, format string, query *JsonPath) error {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return errors.Wrap(err, "unable to parse response as JSON")
+	}
+
+	if query == nil {
+		return writeFormatted(out, doc, format)
+	}
+
+	results := query.Evaluate(doc)
+
+	if format == "" || format == OutputFormatTable {
+		for _, result := range results {
+			line, err := formatTextValue(result)
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintln(out, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(results) == 1 {
+		return writeFormatted(out, results[0], format)
+	}
+
+	if results == nil {
+		results = []interface{}{}
+	}
+	return writeFormatted(out, results, format)
+}
+
+func writeFormatted(out io.Writer, val interface{}, format string) error {
+	switch format {
+	case OutputFormatYaml:
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(val); err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		data, err := json.MarshalIndent(val, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+}
+
+func formatTextValue(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
+
+// ValidateOutputFormat returns an error if the given format isn't one of the supported output formats
+func ValidateOutputFormat(format string) error {
+	for _, supported := range OutputFormats {
+		if strings.EqualFold(format, supported) {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid output format '%s', must be one of %s", format, strings.Join(OutputFormats, ", "))
+}
@@ -0,0 +1,125 @@
+/*
+	Copyright NetFoundry Inc.
+
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testListResponse = `{
+	"data": [
+		{"id": "a1", "name": "alpha", "roleAttributes": ["east"], "cost": 10, "disabled": false},
+		{"id": "b2", "name": "beta", "roleAttributes": ["west", "east"], "cost": 20, "disabled": true},
+		{"id": "c3", "name": "gamma", "roleAttributes": null, "cost": 30}
+	],
+	"meta": {"pagination": {"limit": 10, "offset": 0, "totalCount": 3}}
+}`
+
+func evaluateTestPath(t *testing.T, expr string) []interface{} {
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(testListResponse), &doc))
+	path, err := CompileJsonPath(expr)
+	require.NoError(t, err)
+	return path.Evaluate(doc)
+}
+
+func TestJsonPath(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []interface{}
+	}{
+		{"$.data[*].id", []interface{}{"a1", "b2", "c3"}},
+		{".data[*].id", []interface{}{"a1", "b2", "c3"}},
+		{"data[0].name", []interface{}{"alpha"}},
+		{"$.data[-1].name", []interface{}{"gamma"}},
+		{"$.data[0,2].id", []interface{}{"a1", "c3"}},
+		{"$.data[1:].id", []interface{}{"b2", "c3"}},
+		{"$.data[:1].id", []interface{}{"a1"}},
+		{"$['meta']['pagination'].totalCount", []interface{}{float64(3)}},
+		{"$..totalCount", []interface{}{float64(3)}},
+		{"$.data[?(@.name == 'beta')].id", []interface{}{"b2"}},
+		{"$.data[?(@.cost >= 20)].id", []interface{}{"b2", "c3"}},
+		{"$.data[?(@.disabled)].id", []interface{}{"a1", "b2"}},
+		{"$.data[?(@.disabled == true)].id", []interface{}{"b2"}},
+		{"$.data[?(@.roleAttributes[*] == 'east')].name", []interface{}{"alpha", "beta"}},
+		{"$.data[5].id", nil},
+		{"$.missing", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			require.Equal(t, test.expected, evaluateTestPath(t, test.expr))
+		})
+	}
+}
+
+func TestJsonPathInvalid(t *testing.T) {
+	for _, expr := range []string{"$.data[", "$.data[abc]", "$.data[?(@.name == )]", "$.data[?(name)]", "$.data['id"} {
+		_, err := CompileJsonPath(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestFormatOutput(t *testing.T) {
+	t.Run("query with table format prints one value per line", func(t *testing.T) {
+		req := require.New(t)
+		query, err := CompileJsonPath("$.data[*].name")
+		req.NoError(err)
+
+		out := &bytes.Buffer{}
+		req.NoError(FormatOutput(out, []byte(testListResponse), OutputFormatTable, query))
+		req.Equal("alpha\nbeta\ngamma\n", out.String())
+	})
+
+	t.Run("single query result is not wrapped in a list", func(t *testing.T) {
+		req := require.New(t)
+		query, err := CompileJsonPath("$.meta.pagination")
+		req.NoError(err)
+
+		out := &bytes.Buffer{}
+		req.NoError(FormatOutput(out, []byte(testListResponse), OutputFormatYaml, query))
+		req.Equal("limit: 10\noffset: 0\ntotalCount: 3\n", out.String())
+	})
+
+	t.Run("multiple query results are printed as a list", func(t *testing.T) {
+		req := require.New(t)
+		query, err := CompileJsonPath("$.data[*].id")
+		req.NoError(err)
+
+		out := &bytes.Buffer{}
+		req.NoError(FormatOutput(out, []byte(testListResponse), OutputFormatJson, query))
+		var result []string
+		req.NoError(json.Unmarshal(out.Bytes(), &result))
+		req.Equal([]string{"a1", "b2", "c3"}, result)
+	})
+
+	t.Run("yaml without query", func(t *testing.T) {
+		req := require.New(t)
+		out := &bytes.Buffer{}
+		req.NoError(FormatOutput(out, []byte(`{"data": {"id": "a1", "tags": {}}}`), OutputFormatYaml, nil))
+		req.Equal("data:\n  id: a1\n  tags: {}\n", out.String())
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		require.Error(t, ValidateOutputFormat("xml"))
+		require.NoError(t, ValidateOutputFormat("YAML"))
+	})
+}
@@ -98,7 +98,9 @@ func ControllerDetailEntity(api API, entityType, entityId string, logJSON bool,
 	}
 
 	if logJSON {
-		OutputJson(out, resp.Body())
+		if err := OutputResponse(out, resp.Body()); err != nil {
+			return nil, err
+		}
 	}
 
 	jsonParsed, err := gabs.ParseJSON(resp.Body())
@@ -159,7 +161,9 @@ func ControllerList(api API, path string, params url.Values, logJSON bool, out i
 	}
 
 	if logJSON {
-		OutputJson(out, resp.Body())
+		if err := OutputResponse(out, resp.Body()); err != nil {
+			return nil, err
+		}
 	}
 
 	jsonParsed, err := gabs.ParseJSON(resp.Body())
@@ -307,7 +311,9 @@ func ControllerCreate(api API, entityType string, body string, out io.Writer, lo
 	}
 
 	if logResponseJson {
-		OutputJson(out, resp.Body())
+		if err := OutputResponse(out, resp.Body()); err != nil {
+			return nil, err
+		}
 	}
 
 	jsonParsed, err := gabs.ParseJSON(resp.Body())
@@ -362,7 +368,9 @@ func ControllerDelete(api API, entityType string, id string, body string, out io
 	}
 
 	if logResponseJson {
-		OutputJson(out, resp.Body())
+		if err := OutputResponse(out, resp.Body()); err != nil {
+			return err, nil
+		}
 	}
 
 	return nil, nil
@@ -405,7 +413,9 @@ func ControllerUpdate(api API, entityType string, body string, out io.Writer, me
 	}
 
 	if logResponseJSON {
-		OutputJson(out, resp.Body())
+		if err := OutputResponse(out, resp.Body()); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(resp.Body()) == 0 {
@@ -455,7 +465,9 @@ func EdgeControllerVerify(entityType, id, body string, out io.Writer, logJSON bo
 	}
 
 	if logJSON {
-		OutputJson(out, resp.Body())
+		if err := OutputResponse(out, resp.Body()); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -489,7 +501,9 @@ func EdgeControllerRequest(entityType string, out io.Writer, logJSON bool, timeo
 	}
 
 	if logJSON {
-		OutputJson(out, resp.Body())
+		if err := OutputResponse(out, resp.Body()); err != nil {
+			return nil, err
+		}
 	}
 
 	if resp.Body() == nil {